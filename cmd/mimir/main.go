@@ -195,6 +195,16 @@ func main() {
 
 		fmt.Fprintln(os.Stdout)
 		fmt.Fprintln(os.Stdout, "Modules marked with * are included in target All.")
+
+		for _, target := range []string{mimir.Read, mimir.Write, mimir.Backend} {
+			var included []string
+			for _, m := range t.ModuleManager.DependenciesForModule(target) {
+				if t.ModuleManager.IsUserVisibleModule(m) {
+					included = append(included, m)
+				}
+			}
+			fmt.Fprintf(os.Stdout, "Target %s includes: %s.\n", target, strings.Join(included, ", "))
+		}
 		return
 	}
 
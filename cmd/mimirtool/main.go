@@ -21,6 +21,7 @@ var (
 	alertCommand          commands.AlertCommand
 	alertmanagerCommand   commands.AlertmanagerCommand
 	analyzeCommand        commands.AnalyzeCommand
+	benchCommand          commands.BenchCommand
 	bucketValidateCommand commands.BucketValidationCommand
 	configCommand         commands.ConfigCommand
 	loadgenCommand        commands.LoadgenCommand
@@ -28,6 +29,7 @@ var (
 	pushGateway           commands.PushGatewayConfig
 	remoteReadCommand     commands.RemoteReadCommand
 	ruleCommand           commands.RuleCommand
+	tsdbCommand           commands.TSDBCommand
 )
 
 func main() {
@@ -38,6 +40,7 @@ func main() {
 	alertCommand.Register(app, envVars)
 	alertmanagerCommand.Register(app, envVars)
 	analyzeCommand.Register(app, envVars)
+	benchCommand.Register(app, envVars)
 	bucketValidateCommand.Register(app, envVars)
 	configCommand.Register(app, envVars)
 	loadgenCommand.Register(app, envVars)
@@ -45,6 +48,7 @@ func main() {
 	pushGateway.Register(app, envVars)
 	remoteReadCommand.Register(app, envVars)
 	ruleCommand.Register(app, envVars)
+	tsdbCommand.Register(app, envVars)
 
 	app.Command("version", "Get the version of the mimirtool CLI").Action(func(k *kingpin.ParseContext) error {
 		fmt.Fprintln(os.Stdout, mimirversion.Print("Mimirtool"))
@@ -6,6 +6,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"os"
 
@@ -24,6 +25,7 @@ type Config struct {
 	ServerMetricsPort int
 	LogLevel          logging.Level
 	ProxyConfig       querytee.ProxyConfig
+	ReplayConfig      querytee.ReplayConfig
 	PathPrefix        string
 }
 
@@ -34,6 +36,7 @@ func main() {
 	flag.StringVar(&cfg.PathPrefix, "server.path-prefix", "", "Prefix for API paths (query-tee will accept Prometheus API calls at <prefix>/api/v1/...)")
 	cfg.LogLevel.RegisterFlags(flag.CommandLine)
 	cfg.ProxyConfig.RegisterFlags(flag.CommandLine)
+	cfg.ReplayConfig.RegisterFlags(flag.CommandLine)
 	flag.Parse()
 
 	util_log.InitLogger(&server.Config{
@@ -50,6 +53,21 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Replay the captured requests instead of running the proxy, if configured.
+	if cfg.ReplayConfig.RequestLogFile != "" {
+		replayer, err := querytee.NewReplayer(cfg.ReplayConfig, util_log.Logger, registry)
+		if err != nil {
+			level.Error(util_log.Logger).Log("msg", "Unable to initialize the replay", "err", err.Error())
+			os.Exit(1)
+		}
+
+		if err := replayer.Run(context.Background()); err != nil {
+			level.Error(util_log.Logger).Log("msg", "Unable to replay the requests", "err", err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Run the proxy.
 	proxy, err := querytee.NewProxy(cfg.ProxyConfig, util_log.Logger, mimirReadRoutes(cfg), registry)
 	if err != nil {
@@ -0,0 +1,248 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package admin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/concurrency"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/objstore"
+
+	"github.com/grafana/mimir/pkg/distributor"
+	"github.com/grafana/mimir/pkg/ruler/rulespb"
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+	"github.com/grafana/mimir/pkg/util"
+)
+
+const (
+	// readConcurrency is the max number of tenants whose data is read concurrently from the storage.
+	readConcurrency = 16
+)
+
+// SeriesStatsProvider returns the in-memory series stats of all tenants, from the ingesters.
+type SeriesStatsProvider interface {
+	AllUserStats(ctx context.Context) ([]distributor.UserIDStats, error)
+}
+
+// RuleGroupsLister lists the rule groups of the tenants.
+type RuleGroupsLister interface {
+	ListAllUsers(ctx context.Context) ([]string, error)
+	ListRuleGroupsForUserAndNamespace(ctx context.Context, userID string, namespace string) (rulespb.RuleGroupList, error)
+}
+
+// AlertmanagerConfigsLister lists the tenants with an Alertmanager configuration.
+type AlertmanagerConfigsLister interface {
+	ListAllUsers(ctx context.Context) ([]string, error)
+}
+
+// TenantStats holds the summary stats of a tenant, across the components.
+type TenantStats struct {
+	TenantID string `json:"tenant_id"`
+
+	// Number of in-memory series in the ingesters, including replicas.
+	InMemorySeries uint64 `json:"in_memory_series"`
+
+	// Number of blocks and their size in the bucket, according to the tenant's bucket index.
+	Blocks          int   `json:"blocks"`
+	BlocksSizeBytes int64 `json:"blocks_size_bytes"`
+
+	// Whether the tenant has been marked for deletion.
+	MarkedForDeletion bool `json:"marked_for_deletion,omitempty"`
+
+	RuleGroups         int  `json:"rule_groups"`
+	AlertmanagerConfig bool `json:"alertmanager_config"`
+}
+
+// TenantsResponse is the response of the tenants API.
+type TenantsResponse struct {
+	Tenants []*TenantStats `json:"tenants"`
+
+	// Warnings holds the errors occurred while reading the stats from the components. The stats
+	// of the components which failed are missing from the response.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// TenantsAPI lists the tenants with their summary stats across the components: the series in the ingesters,
+// the blocks in the bucket, the rule groups and the Alertmanager configuration.
+type TenantsAPI struct {
+	bucketClient objstore.Bucket
+	cfgProvider  bucket.TenantConfigProvider
+	series       SeriesStatsProvider
+	rules        RuleGroupsLister
+	alertConfigs AlertmanagerConfigsLister
+	logger       log.Logger
+}
+
+// NewTenantsAPI makes a new TenantsAPI. The series, rules and alertConfigs sources are optional, and the
+// respective stats are not reported if nil.
+func NewTenantsAPI(bucketClient objstore.Bucket, cfgProvider bucket.TenantConfigProvider, series SeriesStatsProvider, rules RuleGroupsLister, alertConfigs AlertmanagerConfigsLister, logger log.Logger) *TenantsAPI {
+	return &TenantsAPI{
+		bucketClient: bucketClient,
+		cfgProvider:  cfgProvider,
+		series:       series,
+		rules:        rules,
+		alertConfigs: alertConfigs,
+		logger:       logger,
+	}
+}
+
+// tenantsStats accumulates the stats of the tenants read from the components concurrently.
+type tenantsStats struct {
+	mtx      sync.Mutex
+	tenants  map[string]*TenantStats
+	warnings []string
+}
+
+// update calls fn with the stats of the tenant, creating them if they don't exist yet.
+func (s *tenantsStats) update(userID string, fn func(stats *TenantStats)) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	stats, ok := s.tenants[userID]
+	if !ok {
+		stats = &TenantStats{TenantID: userID}
+		s.tenants[userID] = stats
+	}
+	fn(stats)
+}
+
+func (s *tenantsStats) warn(warning string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.warnings = append(s.warnings, warning)
+}
+
+// TenantsHandler lists the tenants with their summary stats.
+func (api *TenantsAPI) TenantsHandler(w http.ResponseWriter, r *http.Request) {
+	stats := &tenantsStats{tenants: map[string]*TenantStats{}}
+
+	collectors := map[string]func(context.Context, *tenantsStats) error{
+		"ingesters":          api.collectSeries,
+		"bucket":             api.collectBlocks,
+		"ruler storage":      api.collectRuleGroups,
+		"alertmanager store": api.collectAlertmanagerConfigs,
+	}
+
+	wg := sync.WaitGroup{}
+	for name, collect := range collectors {
+		name, collect := name, collect
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if err := collect(r.Context(), stats); err != nil {
+				level.Warn(api.logger).Log("msg", "failed to read tenants stats", "source", name, "err", err)
+				stats.warn(fmt.Sprintf("failed to read tenants stats from the %s: %s", name, err))
+			}
+		}()
+	}
+	wg.Wait()
+
+	resp := TenantsResponse{Tenants: make([]*TenantStats, 0, len(stats.tenants)), Warnings: stats.warnings}
+	for _, s := range stats.tenants {
+		resp.Tenants = append(resp.Tenants, s)
+	}
+	sort.Slice(resp.Tenants, func(i, j int) bool { return resp.Tenants[i].TenantID < resp.Tenants[j].TenantID })
+	sort.Strings(resp.Warnings)
+
+	util.WriteJSONResponse(w, resp)
+}
+
+func (api *TenantsAPI) collectSeries(ctx context.Context, stats *tenantsStats) error {
+	if api.series == nil {
+		return nil
+	}
+
+	series, err := api.series.AllUserStats(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range series {
+		numSeries := s.NumSeries
+		stats.update(s.UserID, func(t *TenantStats) { t.InMemorySeries = numSeries })
+	}
+	return nil
+}
+
+func (api *TenantsAPI) collectBlocks(ctx context.Context, stats *tenantsStats) error {
+	users, deleted, err := mimir_tsdb.NewUsersScanner(api.bucketClient, mimir_tsdb.AllUsers, api.logger).ScanUsers(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to discover users from bucket")
+	}
+
+	for _, userID := range deleted {
+		stats.update(userID, func(t *TenantStats) { t.MarkedForDeletion = true })
+	}
+
+	return concurrency.ForEachUser(ctx, append(users, deleted...), readConcurrency, func(ctx context.Context, userID string) error {
+		idx, err := bucketindex.ReadIndex(ctx, api.bucketClient, userID, api.cfgProvider, api.logger)
+		if errors.Is(err, bucketindex.ErrIndexNotFound) {
+			// The bucket index is created by the compactor, so it may not exist yet for new tenants.
+			stats.update(userID, func(*TenantStats) {})
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to read bucket index of user %s", userID)
+		}
+
+		size := int64(0)
+		for _, b := range idx.Blocks {
+			size += b.SizeBytes
+		}
+
+		stats.update(userID, func(t *TenantStats) {
+			t.Blocks = len(idx.Blocks)
+			t.BlocksSizeBytes = size
+		})
+		return nil
+	})
+}
+
+func (api *TenantsAPI) collectRuleGroups(ctx context.Context, stats *tenantsStats) error {
+	if api.rules == nil {
+		return nil
+	}
+
+	users, err := api.rules.ListAllUsers(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to list users with rule groups")
+	}
+
+	return concurrency.ForEachUser(ctx, users, readConcurrency, func(ctx context.Context, userID string) error {
+		groups, err := api.rules.ListRuleGroupsForUserAndNamespace(ctx, userID, "")
+		if err != nil {
+			return errors.Wrapf(err, "failed to list rule groups of user %s", userID)
+		}
+
+		stats.update(userID, func(t *TenantStats) { t.RuleGroups = len(groups) })
+		return nil
+	})
+}
+
+func (api *TenantsAPI) collectAlertmanagerConfigs(ctx context.Context, stats *tenantsStats) error {
+	if api.alertConfigs == nil {
+		return nil
+	}
+
+	users, err := api.alertConfigs.ListAllUsers(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to list users with Alertmanager configuration")
+	}
+
+	for _, userID := range users {
+		stats.update(userID, func(t *TenantStats) { t.AlertmanagerConfig = true })
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/objstore"
+
+	"github.com/grafana/mimir/pkg/distributor"
+	"github.com/grafana/mimir/pkg/ruler/rulespb"
+	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+)
+
+func TestTenantsAPI(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+
+	require.NoError(t, bucketindex.WriteIndex(ctx, bkt, "user-1", nil, &bucketindex.Index{
+		Version: bucketindex.IndexVersion2,
+		Blocks: bucketindex.Blocks{
+			{ID: ulid.MustNew(1, nil), SizeBytes: 100},
+			{ID: ulid.MustNew(2, nil), SizeBytes: 200},
+		},
+	}))
+	require.NoError(t, mimir_tsdb.WriteTenantDeletionMark(ctx, bkt, "user-3", nil, mimir_tsdb.NewTenantDeletionMark(time.Now())))
+
+	series := &mockSeriesStatsProvider{stats: []distributor.UserIDStats{
+		{UserID: "user-1", UserStats: distributor.UserStats{NumSeries: 1000}},
+		{UserID: "user-2", UserStats: distributor.UserStats{NumSeries: 10}},
+	}}
+	rules := &mockRuleGroupsLister{groups: map[string]rulespb.RuleGroupList{
+		"user-1": {{Name: "group-1"}, {Name: "group-2"}},
+		"user-4": {{Name: "group-1"}},
+	}}
+	alertConfigs := &mockAlertmanagerConfigsLister{users: []string{"user-2"}}
+
+	t.Run("should aggregate the stats of the tenants across the components", func(t *testing.T) {
+		resp := serveTenants(t, NewTenantsAPI(bkt, nil, series, rules, alertConfigs, log.NewNopLogger()))
+
+		assert.Equal(t, TenantsResponse{Tenants: []*TenantStats{
+			{TenantID: "user-1", InMemorySeries: 1000, Blocks: 2, BlocksSizeBytes: 300, RuleGroups: 2},
+			{TenantID: "user-2", InMemorySeries: 10, AlertmanagerConfig: true},
+			{TenantID: "user-3", MarkedForDeletion: true},
+			{TenantID: "user-4", RuleGroups: 1},
+		}}, resp)
+	})
+
+	t.Run("should skip the optional components", func(t *testing.T) {
+		resp := serveTenants(t, NewTenantsAPI(bkt, nil, nil, nil, nil, log.NewNopLogger()))
+
+		assert.Equal(t, TenantsResponse{Tenants: []*TenantStats{
+			{TenantID: "user-1", Blocks: 2, BlocksSizeBytes: 300},
+			{TenantID: "user-3", MarkedForDeletion: true},
+		}}, resp)
+	})
+
+	t.Run("should return partial results if a component fails", func(t *testing.T) {
+		failing := &mockSeriesStatsProvider{err: errors.New("ingesters unavailable")}
+		resp := serveTenants(t, NewTenantsAPI(bkt, nil, failing, nil, alertConfigs, log.NewNopLogger()))
+
+		assert.Equal(t, []*TenantStats{
+			{TenantID: "user-1", Blocks: 2, BlocksSizeBytes: 300},
+			{TenantID: "user-2", AlertmanagerConfig: true},
+			{TenantID: "user-3", MarkedForDeletion: true},
+		}, resp.Tenants)
+		require.Len(t, resp.Warnings, 1)
+		assert.True(t, strings.Contains(resp.Warnings[0], "ingesters unavailable"), resp.Warnings[0])
+	})
+}
+
+func serveTenants(t *testing.T, api *TenantsAPI) TenantsResponse {
+	rec := httptest.NewRecorder()
+	api.TenantsHandler(rec, httptest.NewRequest("GET", "/api/v1/admin/tenants", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	resp := TenantsResponse{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	return resp
+}
+
+type mockSeriesStatsProvider struct {
+	stats []distributor.UserIDStats
+	err   error
+}
+
+func (m *mockSeriesStatsProvider) AllUserStats(context.Context) ([]distributor.UserIDStats, error) {
+	return m.stats, m.err
+}
+
+type mockRuleGroupsLister struct {
+	groups map[string]rulespb.RuleGroupList
+}
+
+func (m *mockRuleGroupsLister) ListAllUsers(context.Context) ([]string, error) {
+	users := make([]string, 0, len(m.groups))
+	for userID := range m.groups {
+		users = append(users, userID)
+	}
+	return users, nil
+}
+
+func (m *mockRuleGroupsLister) ListRuleGroupsForUserAndNamespace(_ context.Context, userID string, _ string) (rulespb.RuleGroupList, error) {
+	return m.groups[userID], nil
+}
+
+type mockAlertmanagerConfigsLister struct {
+	users []string
+}
+
+func (m *mockAlertmanagerConfigsLister) ListAllUsers(context.Context) ([]string, error) {
+	return m.users, nil
+}
@@ -16,6 +16,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	tmpltext "text/template"
 	"time"
 
 	"github.com/go-kit/log"
@@ -83,6 +84,12 @@ type Config struct {
 	Replicator        Replicator
 	Store             alertstore.AlertStore
 	PersisterConfig   PersisterConfig
+
+	// Whether the notification audit log is persisted to the Store.
+	PersistNotificationAuditLog bool
+
+	// Federation of the silences with a peer cluster.
+	SilencesFederation SilencesFederationConfig
 }
 
 // An Alertmanager manages the alerts for one user.
@@ -92,6 +99,8 @@ type Alertmanager struct {
 	logger          log.Logger
 	state           *state
 	persister       *statePersister
+	auditLog        *notificationAuditLog
+	federation      *silencesFederation // Nil if the silences federation is disabled.
 	nflog           *nflog.Log
 	silences        *silence.Silences
 	marker          types.Marker
@@ -184,6 +193,12 @@ func New(cfg *Config, reg *prometheus.Registry) (*Alertmanager, error) {
 	am.state = newReplicatedStates(cfg.UserID, cfg.ReplicationFactor, cfg.Replicator, cfg.Store, am.logger, am.registry)
 	am.persister = newStatePersister(cfg.PersisterConfig, cfg.UserID, am.state, cfg.Store, am.logger, am.registry)
 
+	var auditStore alertstore.AlertStore
+	if cfg.PersistNotificationAuditLog {
+		auditStore = cfg.Store
+	}
+	am.auditLog = newNotificationAuditLog(cfg.UserID, cfg.Limits, auditStore, cfg.PersisterConfig.Interval, am.logger)
+
 	am.wg.Add(1)
 	var err error
 	am.nflog, err = nflog.New(
@@ -225,6 +240,17 @@ func New(cfg *Config, reg *prometheus.Registry) (*Alertmanager, error) {
 		return nil, errors.Wrap(err, "failed to start state persister service")
 	}
 
+	if err := am.auditLog.StartAsync(context.Background()); err != nil {
+		return nil, errors.Wrap(err, "failed to start notification audit log service")
+	}
+
+	if cfg.SilencesFederation.Enabled() {
+		am.federation = newSilencesFederation(cfg.SilencesFederation, cfg.UserID, am.state, am.silences, cfg.Retention, am.logger, am.registry)
+		if err := am.federation.StartAsync(context.Background()); err != nil {
+			return nil, errors.Wrap(err, "failed to start silences federation service")
+		}
+	}
+
 	am.pipelineBuilder = notify.NewPipelineBuilder(am.registry)
 
 	am.wg.Add(1)
@@ -274,6 +300,7 @@ func New(cfg *Config, reg *prometheus.Registry) (*Alertmanager, error) {
 		}
 		am.mux.Handle(a, http.NotFoundHandler())
 	}
+	am.mux.Handle(path.Join(am.cfg.ExternalURL.Path, "/api/v1/notifications"), am.auditLog)
 
 	am.dispatcherMetrics = dispatch.NewDispatcherMetrics(true, am.registry)
 
@@ -296,6 +323,11 @@ func clusterWait(position func() int, timeout time.Duration) func() time.Duratio
 	}
 }
 
+// SetReplicationFactor updates the replication factor of the Alertmanager state.
+func (am *Alertmanager) SetReplicationFactor(rf int) {
+	am.state.setReplicationFactor(rf)
+}
+
 // ApplyConfig applies a new configuration to an Alertmanager.
 func (am *Alertmanager) ApplyConfig(userID string, conf *config.Config, rawCfg string) error {
 	templateFiles := make([]string, len(conf.Templates))
@@ -314,6 +346,15 @@ func (am *Alertmanager) ApplyConfig(userID string, conf *config.Config, rawCfg s
 	}
 	tmpl.ExternalURL = am.cfg.ExternalURL
 
+	// The tenant's templates are parsed by the template sandbox too, to enforce the sandbox limits when
+	// rendering the notifications.
+	var sandboxTmpl *tmpltext.Template
+	if am.cfg.Limits != nil {
+		if sandboxTmpl, err = newTemplateSandbox(am.cfg.Limits, userID).parse(true, templateFiles); err != nil {
+			return err
+		}
+	}
+
 	am.api.Update(conf, func(_ model.LabelSet) {})
 
 	// Ensure inhibitor is set before being called
@@ -340,17 +381,19 @@ func (am *Alertmanager) ApplyConfig(userID string, conf *config.Config, rawCfg s
 	// Create a firewall binded to the per-tenant config.
 	firewallDialer := util_net.NewFirewallDialer(newFirewallDialerConfigProvider(userID, am.cfg.Limits))
 
-	integrationsMap, err := buildIntegrationsMap(conf.Receivers, tmpl, firewallDialer, am.logger, func(integrationName string, notifier notify.Notifier) notify.Notifier {
+	integrationsMap, err := buildIntegrationsMap(conf.Receivers, tmpl, firewallDialer, am.logger, func(integrationName string, integrationCfg interface{}, notifier notify.Notifier) notify.Notifier {
 		if am.cfg.Limits != nil {
+			notifier = newSandboxedNotifier(notifier, userID, am.cfg.Limits, tmpl, sandboxTmpl, integrationCfg, log.With(am.logger, "integration", integrationName))
+
 			rl := &tenantRateLimits{
 				tenant:      userID,
 				limits:      am.cfg.Limits,
 				integration: integrationName,
 			}
 
-			return newRateLimitedNotifier(notifier, rl, 10*time.Second, am.rateLimitedNotifications.WithLabelValues(integrationName))
+			notifier = newRateLimitedNotifier(notifier, rl, 10*time.Second, am.rateLimitedNotifications.WithLabelValues(integrationName))
 		}
-		return notifier
+		return newAuditedNotifier(notifier, integrationName, am.auditLog)
 	})
 	if err != nil {
 		return nil
@@ -400,6 +443,10 @@ func (am *Alertmanager) Stop() {
 	}
 
 	am.persister.StopAsync()
+	am.auditLog.StopAsync()
+	if am.federation != nil {
+		am.federation.StopAsync()
+	}
 	am.state.StopAsync()
 
 	am.alerts.Close()
@@ -413,6 +460,16 @@ func (am *Alertmanager) StopAndWait() {
 		level.Warn(am.logger).Log("msg", "error while stopping state persister service", "err", err)
 	}
 
+	if err := am.auditLog.AwaitTerminated(context.Background()); err != nil {
+		level.Warn(am.logger).Log("msg", "error while stopping notification audit log service", "err", err)
+	}
+
+	if am.federation != nil {
+		if err := am.federation.AwaitTerminated(context.Background()); err != nil {
+			level.Warn(am.logger).Log("msg", "error while stopping silences federation service", "err", err)
+		}
+	}
+
 	if err := am.state.AwaitTerminated(context.Background()); err != nil {
 		level.Warn(am.logger).Log("msg", "error while stopping ring-based replication service", "err", err)
 	}
@@ -430,7 +487,7 @@ func (am *Alertmanager) getFullState() (*clusterpb.FullState, error) {
 
 // buildIntegrationsMap builds a map of name to the list of integration notifiers off of a
 // list of receiver config.
-func buildIntegrationsMap(nc []*config.Receiver, tmpl *template.Template, firewallDialer *util_net.FirewallDialer, logger log.Logger, notifierWrapper func(string, notify.Notifier) notify.Notifier) (map[string][]notify.Integration, error) {
+func buildIntegrationsMap(nc []*config.Receiver, tmpl *template.Template, firewallDialer *util_net.FirewallDialer, logger log.Logger, notifierWrapper func(string, interface{}, notify.Notifier) notify.Notifier) (map[string][]notify.Integration, error) {
 	integrationsMap := make(map[string][]notify.Integration, len(nc))
 	for _, rcv := range nc {
 		integrations, err := buildReceiverIntegrations(rcv, tmpl, firewallDialer, logger, notifierWrapper)
@@ -445,7 +502,7 @@ func buildIntegrationsMap(nc []*config.Receiver, tmpl *template.Template, firewa
 // buildReceiverIntegrations builds a list of integration notifiers off of a
 // receiver config.
 // Taken from https://github.com/prometheus/alertmanager/blob/94d875f1227b29abece661db1a68c001122d1da5/cmd/alertmanager/main.go#L112-L159.
-func buildReceiverIntegrations(nc *config.Receiver, tmpl *template.Template, firewallDialer *util_net.FirewallDialer, logger log.Logger, wrapper func(string, notify.Notifier) notify.Notifier) ([]notify.Integration, error) {
+func buildReceiverIntegrations(nc *config.Receiver, tmpl *template.Template, firewallDialer *util_net.FirewallDialer, logger log.Logger, wrapper func(string, interface{}, notify.Notifier) notify.Notifier) ([]notify.Integration, error) {
 	var (
 		errs         types.MultiError
 		integrations []notify.Integration
@@ -455,7 +512,7 @@ func buildReceiverIntegrations(nc *config.Receiver, tmpl *template.Template, fir
 				errs.Add(err)
 				return
 			}
-			n = wrapper(name, n)
+			n = wrapper(name, rs, n)
 			integrations = append(integrations, notify.NewIntegration(n, rs, name, i))
 		}
 	)
@@ -20,6 +20,7 @@ import (
 	"google.golang.org/grpc/health/grpc_health_v1"
 
 	"github.com/grafana/mimir/pkg/alertmanager/alertmanagerpb"
+	"github.com/grafana/mimir/pkg/util/tlsreload"
 )
 
 // ClientsPool is the interface used to get the client from the pool for a specified address.
@@ -116,7 +117,8 @@ func (f *alertmanagerClientsPool) GetClientFor(addr string) (Client, error) {
 // dialAlertmanagerClient establishes a GRPC connection to an alertmanager that is aware of the the health of the server
 // and collects observations of request durations.
 func dialAlertmanagerClient(cfg grpcclient.Config, addr string, requestDuration *prometheus.HistogramVec) (*alertmanagerClient, error) {
-	opts, err := cfg.DialOption(grpcclient.Instrument(requestDuration))
+	unary, stream := grpcclient.Instrument(requestDuration)
+	opts, err := tlsreload.GRPCDialOptions(cfg, unary, stream)
 	if err != nil {
 		return nil, err
 	}
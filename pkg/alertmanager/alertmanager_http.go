@@ -12,23 +12,11 @@ import (
 	"github.com/go-kit/log/level"
 	"github.com/grafana/dskit/services"
 
+	"github.com/grafana/mimir/pkg/util"
 	util_log "github.com/grafana/mimir/pkg/util/log"
 )
 
 var (
-	ringStatusPageTemplate = template.Must(template.New("ringStatusPage").Parse(`
-	<!DOCTYPE html>
-	<html>
-		<head>
-			<meta charset="UTF-8">
-			<title>Alertmanager Ring</title>
-		</head>
-		<body>
-			<h1>Alertmanager Ring</h1>
-			<p>{{ .Message }}</p>
-		</body>
-	</html>`))
-
 	statusTemplate = template.Must(template.New("statusPage").Parse(`
     <!doctype html>
     <html>
@@ -39,21 +27,11 @@ var (
     </html>`))
 )
 
-func writeRingStatusMessage(w http.ResponseWriter, message string) {
-	w.WriteHeader(http.StatusOK)
-	err := ringStatusPageTemplate.Execute(w, struct {
-		Message string
-	}{Message: message})
-	if err != nil {
-		level.Error(util_log.Logger).Log("msg", "unable to serve alertmanager ring page", "err", err)
-	}
-}
-
 func (am *MultitenantAlertmanager) RingHandler(w http.ResponseWriter, req *http.Request) {
 	if am.State() != services.Running {
 		// we cannot read the ring before the alertmanager is in Running state,
 		// because that would lead to race condition.
-		writeRingStatusMessage(w, "Alertmanager is not running yet.")
+		util.WriteRingStatusMessage(w, req, "Alertmanager Ring", "Alertmanager is not running yet.")
 		return
 	}
 
@@ -19,7 +19,7 @@ func TestMultitenantAlertmanager_GetStatusHandler(t *testing.T) {
 	store := prepareInMemoryAlertStore()
 	reg := prometheus.NewPedanticRegistry()
 	cfg := mockAlertmanagerConfig(t)
-	am := setupSingleMultitenantAlertmanager(t, cfg, store, nil, log.NewNopLogger(), reg)
+	am := setupSingleMultitenantAlertmanager(t, cfg, store, &mockAlertManagerLimits{}, log.NewNopLogger(), reg)
 
 	req := httptest.NewRequest("GET", "http://alertmanager.cortex/status", nil)
 	w := httptest.NewRecorder()
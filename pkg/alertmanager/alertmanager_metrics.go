@@ -64,6 +64,10 @@ type alertmanagerMetrics struct {
 	persistTotal            *prometheus.Desc
 	persistFailed           *prometheus.Desc
 
+	silencesFederationSyncTotal      *prometheus.Desc
+	silencesFederationSyncFailed     *prometheus.Desc
+	silencesFederationMergedSilences *prometheus.Desc
+
 	notificationRateLimited                 *prometheus.Desc
 	dispatcherAggregationGroupsLimitReached *prometheus.Desc
 	insertAlertFailures                     *prometheus.Desc
@@ -214,6 +218,18 @@ func newAlertmanagerMetrics() *alertmanagerMetrics {
 			"cortex_alertmanager_state_persist_failed_total",
 			"Number of times we have failed to persist the running state to storage.",
 			nil, nil),
+		silencesFederationSyncTotal: prometheus.NewDesc(
+			"cortex_alertmanager_silences_federation_sync_total",
+			"Number of times we have tried to fetch the silences from the peer cluster.",
+			nil, nil),
+		silencesFederationSyncFailed: prometheus.NewDesc(
+			"cortex_alertmanager_silences_federation_sync_failed_total",
+			"Number of times we have failed to fetch the silences from the peer cluster.",
+			nil, nil),
+		silencesFederationMergedSilences: prometheus.NewDesc(
+			"cortex_alertmanager_silences_federation_merged_silences_total",
+			"Number of silences fetched from the peer cluster which were new or more recently updated than the local ones.",
+			nil, nil),
 		notificationRateLimited: prometheus.NewDesc(
 			"cortex_alertmanager_notification_rate_limited_total",
 			"Total number of rate-limited notifications per integration.",
@@ -283,6 +299,9 @@ func (m *alertmanagerMetrics) Describe(out chan<- *prometheus.Desc) {
 	out <- m.initialSyncDuration
 	out <- m.persistTotal
 	out <- m.persistFailed
+	out <- m.silencesFederationSyncTotal
+	out <- m.silencesFederationSyncFailed
+	out <- m.silencesFederationMergedSilences
 	out <- m.notificationRateLimited
 	out <- m.dispatcherAggregationGroupsLimitReached
 	out <- m.insertAlertFailures
@@ -333,6 +352,9 @@ func (m *alertmanagerMetrics) Collect(out chan<- prometheus.Metric) {
 	data.SendSumOfHistograms(out, m.initialSyncDuration, "alertmanager_state_initial_sync_duration_seconds")
 	data.SendSumOfCounters(out, m.persistTotal, "alertmanager_state_persist_total")
 	data.SendSumOfCounters(out, m.persistFailed, "alertmanager_state_persist_failed_total")
+	data.SendSumOfCounters(out, m.silencesFederationSyncTotal, "alertmanager_silences_federation_sync_total")
+	data.SendSumOfCounters(out, m.silencesFederationSyncFailed, "alertmanager_silences_federation_sync_failed_total")
+	data.SendSumOfCounters(out, m.silencesFederationMergedSilences, "alertmanager_silences_federation_merged_silences_total")
 
 	data.SendSumOfCountersPerUserWithLabels(out, m.notificationRateLimited, "alertmanager_notification_rate_limited_total", "integration")
 	data.SendSumOfCountersPerUser(out, m.dispatcherAggregationGroupsLimitReached, "alertmanager_dispatcher_aggregation_group_limit_reached_total")
@@ -292,6 +292,15 @@ func TestAlertmanagerMetricsStore(t *testing.T) {
 		# HELP cortex_alertmanager_state_persist_total Number of times we have tried to persist the running state to storage.
 		# TYPE cortex_alertmanager_state_persist_total counter
 		cortex_alertmanager_state_persist_total 0
+		# HELP cortex_alertmanager_silences_federation_merged_silences_total Number of silences fetched from the peer cluster which were new or more recently updated than the local ones.
+		# TYPE cortex_alertmanager_silences_federation_merged_silences_total counter
+		cortex_alertmanager_silences_federation_merged_silences_total 0
+		# HELP cortex_alertmanager_silences_federation_sync_failed_total Number of times we have failed to fetch the silences from the peer cluster.
+		# TYPE cortex_alertmanager_silences_federation_sync_failed_total counter
+		cortex_alertmanager_silences_federation_sync_failed_total 0
+		# HELP cortex_alertmanager_silences_federation_sync_total Number of times we have tried to fetch the silences from the peer cluster.
+		# TYPE cortex_alertmanager_silences_federation_sync_total counter
+		cortex_alertmanager_silences_federation_sync_total 0
 
 		# HELP cortex_alertmanager_alerts_limiter_current_alerts Number of alerts tracked by alerts limiter.
 		# TYPE cortex_alertmanager_alerts_limiter_current_alerts gauge
@@ -603,6 +612,15 @@ func TestAlertmanagerMetricsRemoval(t *testing.T) {
 						# HELP cortex_alertmanager_state_persist_total Number of times we have tried to persist the running state to storage.
 						# TYPE cortex_alertmanager_state_persist_total counter
 						cortex_alertmanager_state_persist_total 0
+						# HELP cortex_alertmanager_silences_federation_merged_silences_total Number of silences fetched from the peer cluster which were new or more recently updated than the local ones.
+						# TYPE cortex_alertmanager_silences_federation_merged_silences_total counter
+						cortex_alertmanager_silences_federation_merged_silences_total 0
+						# HELP cortex_alertmanager_silences_federation_sync_failed_total Number of times we have failed to fetch the silences from the peer cluster.
+						# TYPE cortex_alertmanager_silences_federation_sync_failed_total counter
+						cortex_alertmanager_silences_federation_sync_failed_total 0
+						# HELP cortex_alertmanager_silences_federation_sync_total Number of times we have tried to fetch the silences from the peer cluster.
+						# TYPE cortex_alertmanager_silences_federation_sync_total counter
+						cortex_alertmanager_silences_federation_sync_total 0
 
 						# HELP cortex_alertmanager_alerts_limiter_current_alerts Number of alerts tracked by alerts limiter.
 						# TYPE cortex_alertmanager_alerts_limiter_current_alerts gauge
@@ -859,6 +877,15 @@ func TestAlertmanagerMetricsRemoval(t *testing.T) {
 			# HELP cortex_alertmanager_state_persist_total Number of times we have tried to persist the running state to storage.
 			# TYPE cortex_alertmanager_state_persist_total counter
 			cortex_alertmanager_state_persist_total 0
+			# HELP cortex_alertmanager_silences_federation_merged_silences_total Number of silences fetched from the peer cluster which were new or more recently updated than the local ones.
+			# TYPE cortex_alertmanager_silences_federation_merged_silences_total counter
+			cortex_alertmanager_silences_federation_merged_silences_total 0
+			# HELP cortex_alertmanager_silences_federation_sync_failed_total Number of times we have failed to fetch the silences from the peer cluster.
+			# TYPE cortex_alertmanager_silences_federation_sync_failed_total counter
+			cortex_alertmanager_silences_federation_sync_failed_total 0
+			# HELP cortex_alertmanager_silences_federation_sync_total Number of times we have tried to fetch the silences from the peer cluster.
+			# TYPE cortex_alertmanager_silences_federation_sync_total counter
+			cortex_alertmanager_silences_federation_sync_total 0
 
 			# HELP cortex_alertmanager_alerts_limiter_current_alerts Number of alerts tracked by alerts limiter.
 			# TYPE cortex_alertmanager_alerts_limiter_current_alerts gauge
@@ -7,7 +7,6 @@ package alertmanager
 
 import (
 	"flag"
-	"fmt"
 	"os"
 	"time"
 
@@ -18,6 +17,7 @@ import (
 	"github.com/grafana/dskit/netutil"
 	"github.com/grafana/dskit/ring"
 
+	"github.com/grafana/mimir/pkg/util"
 	util_log "github.com/grafana/mimir/pkg/util/log"
 )
 
@@ -60,6 +60,7 @@ type RingConfig struct {
 	InstanceInterfaceNames []string `yaml:"instance_interface_names" category:"advanced" doc:"default=[<private network interfaces>]"`
 	InstancePort           int      `yaml:"instance_port" category:"advanced"`
 	InstanceAddr           string   `yaml:"instance_addr" category:"advanced"`
+	InstanceAddrFamily     string   `yaml:"instance_addr_family" category:"advanced"`
 	InstanceZone           string   `yaml:"instance_availability_zone" category:"advanced"`
 
 	// Injected internally
@@ -93,6 +94,7 @@ func (cfg *RingConfig) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	cfg.InstanceInterfaceNames = netutil.PrivateNetworkInterfacesWithFallback([]string{"eth0", "en0"}, logger)
 	f.Var((*flagext.StringSlice)(&cfg.InstanceInterfaceNames), rfprefix+"instance-interface-names", "List of network interface names to look up when finding the instance IP address.")
 	f.StringVar(&cfg.InstanceAddr, rfprefix+"instance-addr", "", "IP address to advertise in the ring. Default is auto-detected.")
+	f.StringVar(&cfg.InstanceAddrFamily, rfprefix+"instance-addr-family", util.AddrFamilyIPv4, "IP address family to prefer when auto-detecting the address to advertise in the ring. "+util.AddrFamilyUsage)
 	f.IntVar(&cfg.InstancePort, rfprefix+"instance-port", 0, "Port to advertise in the ring (defaults to -server.grpc-listen-port).")
 	f.StringVar(&cfg.InstanceID, rfprefix+"instance-id", hostname, "Instance ID to register in the ring.")
 	f.StringVar(&cfg.InstanceZone, rfprefix+"instance-availability-zone", "", "The availability zone where this instance is running. Required if zone-awareness is enabled.")
@@ -103,7 +105,7 @@ func (cfg *RingConfig) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 // ToLifecyclerConfig returns a LifecyclerConfig based on the alertmanager
 // ring config.
 func (cfg *RingConfig) ToLifecyclerConfig(logger log.Logger) (ring.BasicLifecyclerConfig, error) {
-	instanceAddr, err := ring.GetInstanceAddr(cfg.InstanceAddr, cfg.InstanceInterfaceNames, logger)
+	instanceAddr, err := util.GetInstanceAddr(cfg.InstanceAddr, cfg.InstanceInterfaceNames, cfg.InstanceAddrFamily)
 	if err != nil {
 		return ring.BasicLifecyclerConfig{}, err
 	}
@@ -112,7 +114,7 @@ func (cfg *RingConfig) ToLifecyclerConfig(logger log.Logger) (ring.BasicLifecycl
 
 	return ring.BasicLifecyclerConfig{
 		ID:                  cfg.InstanceID,
-		Addr:                fmt.Sprintf("%s:%d", instanceAddr, instancePort),
+		Addr:                util.JoinHostPort(instanceAddr, instancePort),
 		HeartbeatPeriod:     cfg.HeartbeatPeriod,
 		TokensObservePeriod: 0,
 		Zone:                cfg.InstanceZone,
@@ -131,3 +133,45 @@ func (cfg *RingConfig) ToRingConfig() ring.Config {
 
 	return rc
 }
+
+// tenantRing is a ring.ReadRing returning the replication sets of a tenant whose
+// replication factor is lower than the ring replication factor.
+type tenantRing struct {
+	ring.ReadRing
+
+	replicationFactor int
+}
+
+// newTenantRing returns the ring to use to shard the given tenant. The tenant's replication
+// factor override is ignored if not set, or if it's not lower than the ring replication factor.
+func newTenantRing(r ring.ReadRing, limits Limits, userID string) ring.ReadRing {
+	rf := limits.AlertmanagerReplicationFactor(userID)
+	if rf <= 0 || rf >= r.ReplicationFactor() {
+		return r
+	}
+
+	return &tenantRing{ReadRing: r, replicationFactor: rf}
+}
+
+// Get returns the first instances of the replication set returned by the ring for the key. Since the
+// instances are sorted by their position in the ring, and unhealthy instances are ignored, this is
+// consistent across Alertmanagers and the instances are still spread across zones if zone-awareness
+// is enabled.
+func (r *tenantRing) Get(key uint32, op ring.Operation, bufDescs []ring.InstanceDesc, bufHosts, bufZones []string) (ring.ReplicationSet, error) {
+	set, err := r.ReadRing.Get(key, op, bufDescs, bufHosts, bufZones)
+	if err != nil {
+		return set, err
+	}
+
+	if len(set.Instances) > r.replicationFactor {
+		set.Instances = set.Instances[:r.replicationFactor]
+	}
+	if set.MaxErrors >= len(set.Instances) {
+		set.MaxErrors = len(set.Instances) - 1
+	}
+	return set, nil
+}
+
+func (r *tenantRing) ReplicationFactor() int {
+	return r.replicationFactor
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/grafana/dskit/ring"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestIsHealthyForAlertmanagerOperations(t *testing.T) {
@@ -57,3 +58,70 @@ func TestIsHealthyForAlertmanagerOperations(t *testing.T) {
 		})
 	}
 }
+
+func TestTenantRing(t *testing.T) {
+	t.Parallel()
+
+	instances := []ring.InstanceDesc{{Addr: "1"}, {Addr: "2"}, {Addr: "3"}}
+
+	tests := map[string]struct {
+		tenantRF          int
+		expectedRF        int
+		expectedInstances []ring.InstanceDesc
+		expectedMaxErrors int
+	}{
+		"should use the ring replication factor if the tenant one is not set": {
+			tenantRF:          0,
+			expectedRF:        3,
+			expectedInstances: instances,
+			expectedMaxErrors: 2,
+		},
+		"should use the tenant replication factor if lower than the ring one": {
+			tenantRF:          2,
+			expectedRF:        2,
+			expectedInstances: instances[:2],
+			expectedMaxErrors: 1,
+		},
+		"should not replicate if the tenant replication factor is 1": {
+			tenantRF:          1,
+			expectedRF:        1,
+			expectedInstances: instances[:1],
+			expectedMaxErrors: 0,
+		},
+		"should cap the tenant replication factor to the ring one": {
+			tenantRF:          5,
+			expectedRF:        3,
+			expectedInstances: instances,
+			expectedMaxErrors: 2,
+		},
+	}
+
+	for testName, testData := range tests {
+		testData := testData
+
+		t.Run(testName, func(t *testing.T) {
+			r := newTenantRing(&mockReadRing{instances: instances}, &mockAlertManagerLimits{replicationFactor: testData.tenantRF}, "user")
+			assert.Equal(t, testData.expectedRF, r.ReplicationFactor())
+
+			set, err := r.Get(shardByUser("user"), RingOp, nil, nil, nil)
+			require.NoError(t, err)
+			assert.Equal(t, testData.expectedInstances, set.Instances)
+			assert.Equal(t, testData.expectedMaxErrors, set.MaxErrors)
+		})
+	}
+}
+
+// mockReadRing is a ring.ReadRing with a replication factor of 3, returning the same instances for every key.
+type mockReadRing struct {
+	ring.ReadRing
+
+	instances []ring.InstanceDesc
+}
+
+func (r *mockReadRing) Get(_ uint32, _ ring.Operation, _ []ring.InstanceDesc, _, _ []string) (ring.ReplicationSet, error) {
+	return ring.ReplicationSet{Instances: r.instances, MaxErrors: len(r.instances) - 1}, nil
+}
+
+func (r *mockReadRing) ReplicationFactor() int {
+	return len(r.instances)
+}
@@ -4,6 +4,7 @@
 package alertspb
 
 import (
+	bytes "bytes"
 	fmt "fmt"
 	_ "github.com/gogo/protobuf/gogoproto"
 	proto "github.com/gogo/protobuf/proto"
@@ -30,6 +31,7 @@ type AlertConfigDesc struct {
 	User      string          `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
 	RawConfig string          `protobuf:"bytes,2,opt,name=raw_config,json=rawConfig,proto3" json:"raw_config,omitempty"`
 	Templates []*TemplateDesc `protobuf:"bytes,3,rep,name=templates,proto3" json:"templates,omitempty"`
+	Assets    []*AssetDesc    `protobuf:"bytes,4,rep,name=assets,proto3" json:"assets,omitempty"`
 }
 
 func (m *AlertConfigDesc) Reset()      { *m = AlertConfigDesc{} }
@@ -85,6 +87,13 @@ func (m *AlertConfigDesc) GetTemplates() []*TemplateDesc {
 	return nil
 }
 
+func (m *AlertConfigDesc) GetAssets() []*AssetDesc {
+	if m != nil {
+		return m.Assets
+	}
+	return nil
+}
+
 type TemplateDesc struct {
 	Filename string `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"`
 	Body     string `protobuf:"bytes,2,opt,name=body,proto3" json:"body,omitempty"`
@@ -136,6 +145,57 @@ func (m *TemplateDesc) GetBody() string {
 	return ""
 }
 
+type AssetDesc struct {
+	Filename string `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"`
+	Body     []byte `protobuf:"bytes,2,opt,name=body,proto3" json:"body,omitempty"`
+}
+
+func (m *AssetDesc) Reset()      { *m = AssetDesc{} }
+func (*AssetDesc) ProtoMessage() {}
+func (*AssetDesc) Descriptor() ([]byte, []int) {
+	return fileDescriptor_20493709c38b81dc, []int{2}
+}
+func (m *AssetDesc) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *AssetDesc) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_AssetDesc.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *AssetDesc) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AssetDesc.Merge(m, src)
+}
+func (m *AssetDesc) XXX_Size() int {
+	return m.Size()
+}
+func (m *AssetDesc) XXX_DiscardUnknown() {
+	xxx_messageInfo_AssetDesc.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AssetDesc proto.InternalMessageInfo
+
+func (m *AssetDesc) GetFilename() string {
+	if m != nil {
+		return m.Filename
+	}
+	return ""
+}
+
+func (m *AssetDesc) GetBody() []byte {
+	if m != nil {
+		return m.Body
+	}
+	return nil
+}
+
 type FullStateDesc struct {
 	State *clusterpb.FullState `protobuf:"bytes,1,opt,name=state,proto3" json:"state,omitempty"`
 }
@@ -143,7 +203,7 @@ type FullStateDesc struct {
 func (m *FullStateDesc) Reset()      { *m = FullStateDesc{} }
 func (*FullStateDesc) ProtoMessage() {}
 func (*FullStateDesc) Descriptor() ([]byte, []int) {
-	return fileDescriptor_20493709c38b81dc, []int{2}
+	return fileDescriptor_20493709c38b81dc, []int{3}
 }
 func (m *FullStateDesc) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -182,33 +242,36 @@ func (m *FullStateDesc) GetState() *clusterpb.FullState {
 func init() {
 	proto.RegisterType((*AlertConfigDesc)(nil), "alerts.AlertConfigDesc")
 	proto.RegisterType((*TemplateDesc)(nil), "alerts.TemplateDesc")
+	proto.RegisterType((*AssetDesc)(nil), "alerts.AssetDesc")
 	proto.RegisterType((*FullStateDesc)(nil), "alerts.FullStateDesc")
 }
 
 func init() { proto.RegisterFile("alerts.proto", fileDescriptor_20493709c38b81dc) }
 
 var fileDescriptor_20493709c38b81dc = []byte{
-	// 320 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x4c, 0x50, 0xbf, 0x4e, 0xc2, 0x40,
-	0x18, 0xbf, 0x13, 0x24, 0x70, 0x62, 0x4c, 0x1a, 0x06, 0x42, 0xe2, 0x27, 0x61, 0x22, 0x0e, 0x6d,
-	0x82, 0x9b, 0x03, 0x09, 0x68, 0x7c, 0x00, 0x74, 0x72, 0x31, 0xd7, 0x7a, 0x14, 0x92, 0x96, 0x6b,
-	0xee, 0xae, 0x41, 0x37, 0x1f, 0xc1, 0x47, 0x70, 0xf4, 0x51, 0x1c, 0x19, 0x19, 0xe5, 0x58, 0x18,
-	0x79, 0x04, 0x73, 0x77, 0xa5, 0x38, 0xf5, 0xf7, 0xf5, 0xf7, 0xe7, 0xfb, 0x7e, 0x47, 0x9a, 0x34,
-	0x61, 0x42, 0x49, 0x3f, 0x13, 0x5c, 0x71, 0xaf, 0xe6, 0xa6, 0x4e, 0x2b, 0xe6, 0x31, 0xb7, 0xbf,
-	0x02, 0x83, 0x1c, 0xdb, 0x19, 0xc7, 0x73, 0x35, 0xcb, 0x43, 0x3f, 0xe2, 0x69, 0x90, 0x09, 0x9e,
-	0x32, 0x35, 0x63, 0xb9, 0x0c, 0xac, 0x27, 0xa5, 0x0b, 0x1a, 0x33, 0x11, 0x44, 0x49, 0x2e, 0xd5,
-	0xf1, 0x9b, 0x85, 0x07, 0xe4, 0x32, 0x7a, 0x6f, 0xe4, 0x62, 0x64, 0xf4, 0x77, 0x7c, 0x31, 0x9d,
-	0xc7, 0xf7, 0x4c, 0x46, 0x9e, 0x47, 0xaa, 0xb9, 0x64, 0xa2, 0x8d, 0xbb, 0xb8, 0xdf, 0x98, 0x58,
-	0xec, 0x5d, 0x12, 0x22, 0xe8, 0xf2, 0x25, 0xb2, 0xaa, 0xf6, 0x89, 0x65, 0x1a, 0x82, 0x2e, 0x9d,
-	0xcd, 0x1b, 0x90, 0x86, 0x62, 0x69, 0x96, 0x50, 0xc5, 0x64, 0xbb, 0xd2, 0xad, 0xf4, 0xcf, 0x06,
-	0x2d, 0xbf, 0x68, 0xf2, 0x54, 0x10, 0x26, 0x7b, 0x72, 0x94, 0xf5, 0x86, 0xa4, 0xf9, 0x9f, 0xf2,
-	0x3a, 0xa4, 0x3e, 0x9d, 0x27, 0x6c, 0x41, 0x53, 0x56, 0xac, 0x2e, 0x67, 0x73, 0x52, 0xc8, 0x5f,
-	0xdf, 0x8b, 0xc5, 0x16, 0xf7, 0x46, 0xe4, 0xfc, 0x21, 0x4f, 0x92, 0x47, 0x75, 0x08, 0xb8, 0x26,
-	0xa7, 0xd2, 0x0c, 0xd6, 0x6d, 0x0e, 0x28, 0x3b, 0xfb, 0xa5, 0x70, 0xe2, 0x24, 0xb7, 0xd5, 0xdd,
-	0xd7, 0x15, 0x1a, 0x0f, 0x57, 0x1b, 0x40, 0xeb, 0x0d, 0xa0, 0xfd, 0x06, 0xf0, 0x87, 0x06, 0xfc,
-	0xad, 0x01, 0xff, 0x68, 0xc0, 0x2b, 0x0d, 0xf8, 0x57, 0x03, 0xde, 0x69, 0x40, 0x7b, 0x0d, 0xf8,
-	0x73, 0x0b, 0x68, 0xb5, 0x05, 0xb4, 0xde, 0x02, 0x7a, 0xae, 0xbb, 0x62, 0x59, 0x18, 0xd6, 0xec,
-	0x1b, 0xde, 0xfc, 0x05, 0x00, 0x00, 0xff, 0xff, 0x3d, 0xbd, 0xc1, 0x50, 0xb5, 0x01, 0x00, 0x00,
+	// 351 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x91, 0xc1, 0x4e, 0xc2, 0x30,
+	0x18, 0xc7, 0x5b, 0x41, 0xc2, 0x2a, 0xc6, 0xd8, 0x70, 0x58, 0x48, 0xac, 0x84, 0x13, 0x7a, 0xd8,
+	0x12, 0xbc, 0x69, 0x42, 0x02, 0x1a, 0x1f, 0x00, 0x3d, 0x79, 0x31, 0xdd, 0x2c, 0x83, 0x64, 0xa3,
+	0x4b, 0xdb, 0x85, 0x78, 0xf3, 0x11, 0x7c, 0x04, 0x13, 0x2f, 0x3e, 0x8a, 0x47, 0x8e, 0x1c, 0xa5,
+	0x5c, 0x38, 0xf2, 0x08, 0xa6, 0xdd, 0x18, 0x1e, 0x3d, 0xed, 0xff, 0xf5, 0xfb, 0xff, 0xfe, 0xfb,
+	0xfa, 0x15, 0x35, 0x68, 0xcc, 0x84, 0x92, 0x5e, 0x2a, 0xb8, 0xe2, 0xb8, 0x96, 0x57, 0xad, 0x66,
+	0xc4, 0x23, 0x6e, 0x8f, 0x7c, 0xa3, 0xf2, 0x6e, 0x6b, 0x18, 0x4d, 0xd5, 0x24, 0x0b, 0xbc, 0x90,
+	0x27, 0x7e, 0x2a, 0x78, 0xc2, 0xd4, 0x84, 0x65, 0xd2, 0xb7, 0x4c, 0x42, 0x67, 0x34, 0x62, 0xc2,
+	0x0f, 0xe3, 0x4c, 0xaa, 0xfd, 0x37, 0x0d, 0x76, 0x2a, 0xcf, 0xe8, 0x7c, 0x42, 0x74, 0x32, 0x30,
+	0xc0, 0x2d, 0x9f, 0x8d, 0xa7, 0xd1, 0x1d, 0x93, 0x21, 0xc6, 0xa8, 0x9a, 0x49, 0x26, 0x5c, 0xd8,
+	0x86, 0x5d, 0x67, 0x64, 0x35, 0x3e, 0x43, 0x48, 0xd0, 0xf9, 0x73, 0x68, 0x5d, 0xee, 0x81, 0xed,
+	0x38, 0x82, 0xce, 0x73, 0x0c, 0xf7, 0x90, 0xa3, 0x58, 0x92, 0xc6, 0x54, 0x31, 0xe9, 0x56, 0xda,
+	0x95, 0xee, 0x51, 0xaf, 0xe9, 0x15, 0x57, 0x79, 0x2c, 0x1a, 0x26, 0x7b, 0xb4, 0xb7, 0xe1, 0x0b,
+	0x54, 0xa3, 0x52, 0x32, 0x25, 0xdd, 0xaa, 0x05, 0x4e, 0x77, 0xc0, 0xc0, 0x9c, 0x5a, 0x77, 0x61,
+	0xe8, 0xf4, 0x51, 0xe3, 0x6f, 0x0a, 0x6e, 0xa1, 0xfa, 0x78, 0x1a, 0xb3, 0x19, 0x4d, 0x58, 0x31,
+	0x65, 0x59, 0x9b, 0xe9, 0x03, 0xfe, 0xf2, 0x5a, 0xcc, 0x68, 0x75, 0xe7, 0x06, 0x39, 0x65, 0xe8,
+	0xbf, 0xe1, 0x46, 0x01, 0x0f, 0xd0, 0xf1, 0x7d, 0x16, 0xc7, 0x0f, 0x6a, 0xf7, 0xf7, 0x4b, 0x74,
+	0x28, 0x4d, 0x61, 0x69, 0x73, 0xd1, 0x72, 0xb9, 0x5e, 0x69, 0x1c, 0xe5, 0x96, 0xeb, 0xea, 0xe6,
+	0xe3, 0x1c, 0x0c, 0xfb, 0x8b, 0x15, 0x01, 0xcb, 0x15, 0x01, 0xdb, 0x15, 0x81, 0x6f, 0x9a, 0xc0,
+	0x2f, 0x4d, 0xe0, 0xb7, 0x26, 0x70, 0xa1, 0x09, 0xfc, 0xd1, 0x04, 0x6e, 0x34, 0x01, 0x5b, 0x4d,
+	0xe0, 0xfb, 0x9a, 0x80, 0xc5, 0x9a, 0x80, 0xe5, 0x9a, 0x80, 0xa7, 0x7a, 0xbe, 0x8f, 0x34, 0x08,
+	0x6a, 0xf6, 0xb1, 0xae, 0x7e, 0x07, 0x00, 0x53, 0xb2, 0x47, 0x9c, 0x1e, 0x02, 0x00, 0x00,
 }
 
 func (this *AlertConfigDesc) Equal(that interface{}) bool {
@@ -244,6 +307,14 @@ func (this *AlertConfigDesc) Equal(that interface{}) bool {
 			return false
 		}
 	}
+	if len(this.Assets) != len(that1.Assets) {
+		return false
+	}
+	for i := range this.Assets {
+		if !this.Assets[i].Equal(that1.Assets[i]) {
+			return false
+		}
+	}
 	return true
 }
 func (this *TemplateDesc) Equal(that interface{}) bool {
@@ -273,17 +344,47 @@ func (this *TemplateDesc) Equal(that interface{}) bool {
 	}
 	return true
 }
+func (this *AssetDesc) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*AssetDesc)
+	if !ok {
+		that2, ok := that.(AssetDesc)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Filename != that1.Filename {
+		return false
+	}
+	if !bytes.Equal(this.Body, that1.Body) {
+		return false
+	}
+	return true
+}
 func (this *AlertConfigDesc) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 7)
+	s := make([]string, 0, 8)
 	s = append(s, "&alertspb.AlertConfigDesc{")
 	s = append(s, "User: "+fmt.Sprintf("%#v", this.User)+",\n")
 	s = append(s, "RawConfig: "+fmt.Sprintf("%#v", this.RawConfig)+",\n")
 	if this.Templates != nil {
 		s = append(s, "Templates: "+fmt.Sprintf("%#v", this.Templates)+",\n")
 	}
+	if this.Assets != nil {
+		s = append(s, "Assets: "+fmt.Sprintf("%#v", this.Assets)+",\n")
+	}
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -298,6 +399,17 @@ func (this *TemplateDesc) GoString() string {
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
+func (this *AssetDesc) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&alertspb.AssetDesc{")
+	s = append(s, "Filename: "+fmt.Sprintf("%#v", this.Filename)+",\n")
+	s = append(s, "Body: "+fmt.Sprintf("%#v", this.Body)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
 func (this *FullStateDesc) GoString() string {
 	if this == nil {
 		return "nil"
@@ -338,6 +450,20 @@ func (m *AlertConfigDesc) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.Assets) > 0 {
+		for iNdEx := len(m.Assets) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Assets[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintAlerts(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x22
+		}
+	}
 	if len(m.Templates) > 0 {
 		for iNdEx := len(m.Templates) - 1; iNdEx >= 0; iNdEx-- {
 			{
@@ -406,6 +532,43 @@ func (m *TemplateDesc) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *AssetDesc) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *AssetDesc) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *AssetDesc) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Body) > 0 {
+		i -= len(m.Body)
+		copy(dAtA[i:], m.Body)
+		i = encodeVarintAlerts(dAtA, i, uint64(len(m.Body)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Filename) > 0 {
+		i -= len(m.Filename)
+		copy(dAtA[i:], m.Filename)
+		i = encodeVarintAlerts(dAtA, i, uint64(len(m.Filename)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
 func (m *FullStateDesc) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -472,6 +635,12 @@ func (m *AlertConfigDesc) Size() (n int) {
 			n += 1 + l + sovAlerts(uint64(l))
 		}
 	}
+	if len(m.Assets) > 0 {
+		for _, e := range m.Assets {
+			l = e.Size()
+			n += 1 + l + sovAlerts(uint64(l))
+		}
+	}
 	return n
 }
 
@@ -492,6 +661,23 @@ func (m *TemplateDesc) Size() (n int) {
 	return n
 }
 
+func (m *AssetDesc) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Filename)
+	if l > 0 {
+		n += 1 + l + sovAlerts(uint64(l))
+	}
+	l = len(m.Body)
+	if l > 0 {
+		n += 1 + l + sovAlerts(uint64(l))
+	}
+	return n
+}
+
 func (m *FullStateDesc) Size() (n int) {
 	if m == nil {
 		return 0
@@ -520,10 +706,16 @@ func (this *AlertConfigDesc) String() string {
 		repeatedStringForTemplates += strings.Replace(f.String(), "TemplateDesc", "TemplateDesc", 1) + ","
 	}
 	repeatedStringForTemplates += "}"
+	repeatedStringForAssets := "[]*AssetDesc{"
+	for _, f := range this.Assets {
+		repeatedStringForAssets += strings.Replace(f.String(), "AssetDesc", "AssetDesc", 1) + ","
+	}
+	repeatedStringForAssets += "}"
 	s := strings.Join([]string{`&AlertConfigDesc{`,
 		`User:` + fmt.Sprintf("%v", this.User) + `,`,
 		`RawConfig:` + fmt.Sprintf("%v", this.RawConfig) + `,`,
 		`Templates:` + repeatedStringForTemplates + `,`,
+		`Assets:` + repeatedStringForAssets + `,`,
 		`}`,
 	}, "")
 	return s
@@ -539,6 +731,17 @@ func (this *TemplateDesc) String() string {
 	}, "")
 	return s
 }
+func (this *AssetDesc) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&AssetDesc{`,
+		`Filename:` + fmt.Sprintf("%v", this.Filename) + `,`,
+		`Body:` + fmt.Sprintf("%v", this.Body) + `,`,
+		`}`,
+	}, "")
+	return s
+}
 func (this *FullStateDesc) String() string {
 	if this == nil {
 		return "nil"
@@ -684,6 +887,40 @@ func (m *AlertConfigDesc) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Assets", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAlerts
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAlerts
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthAlerts
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Assets = append(m.Assets, &AssetDesc{})
+			if err := m.Assets[len(m.Assets)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipAlerts(dAtA[iNdEx:])
@@ -825,6 +1062,125 @@ func (m *TemplateDesc) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *AssetDesc) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAlerts
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: AssetDesc: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: AssetDesc: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Filename", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAlerts
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAlerts
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthAlerts
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Filename = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Body", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAlerts
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthAlerts
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthAlerts
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Body = append(m.Body[:0], dAtA[iNdEx:postIndex]...)
+			if m.Body == nil {
+				m.Body = []byte{}
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAlerts(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthAlerts
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthAlerts
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func (m *FullStateDesc) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
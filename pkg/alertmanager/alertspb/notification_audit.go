@@ -0,0 +1,24 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package alertspb
+
+import "time"
+
+const (
+	NotificationStatusSuccess = "success"
+	NotificationStatusFailure = "failure"
+)
+
+// NotificationAuditEntry records a single attempt to deliver a notification through a receiver's integration.
+type NotificationAuditEntry struct {
+	Timestamp      time.Time `json:"timestamp"`
+	Receiver       string    `json:"receiver"`
+	Integration    string    `json:"integration"`
+	Status         string    `json:"status"`
+	Error          string    `json:"error,omitempty"`
+	LatencySeconds float64   `json:"latency_seconds"`
+	Alerts         int       `json:"alerts"`
+	// PayloadHash is a truncated hash of the notified alerts, which can be used to correlate
+	// attempts delivering the same notification.
+	PayloadHash string `json:"payload_hash"`
+}
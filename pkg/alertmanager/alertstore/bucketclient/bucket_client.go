@@ -8,14 +8,21 @@ package bucketclient
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/json"
 	"io/ioutil"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
 	"github.com/gogo/protobuf/proto"
 	"github.com/grafana/dskit/concurrency"
 	"github.com/grafana/dskit/runutil"
+	"github.com/oklog/ulid"
 	"github.com/pkg/errors"
 	"github.com/thanos-io/thanos/pkg/objstore"
 
@@ -29,11 +36,22 @@ const (
 	//     alerts/<user-id>
 	alertsPrefix = "alerts"
 
+	// The bucket prefix under which all tenants alertmanager config versions are stored.
+	// Note that objects stored under this prefix follow the pattern:
+	//     alerts-versions/<user-id>/<version>
+	alertsVersionsPrefix = "alerts-versions"
+
 	// The bucket prefix under which other alertmanager state is stored.
 	// Note that objects stored under this prefix follow the pattern:
 	//     alertmanager/<user-id>/<object>
 	alertmanagerPrefix = "alertmanager"
 
+	// The bucket prefix under which all tenants notification audit logs are stored.
+	// Note that objects stored under this prefix follow the pattern:
+	//     alertmanager-audit/<user-id>/<ulid>.json
+	// where each object contains a batch of entries, encoded as JSON lines.
+	auditPrefix = "alertmanager-audit"
+
 	// The name of alertmanager full state objects (notification log + silences).
 	fullStateName = "fullstate"
 
@@ -44,18 +62,22 @@ const (
 // BucketAlertStore is used to support the AlertStore interface against an object storage backend. It is implemented
 // using the Thanos objstore.Bucket interface
 type BucketAlertStore struct {
-	alertsBucket objstore.Bucket
-	amBucket     objstore.Bucket
-	cfgProvider  bucket.TenantConfigProvider
-	logger       log.Logger
+	alertsBucket   objstore.Bucket
+	versionsBucket objstore.Bucket
+	amBucket       objstore.Bucket
+	auditBucket    objstore.Bucket
+	cfgProvider    bucket.TenantConfigProvider
+	logger         log.Logger
 }
 
 func NewBucketAlertStore(bkt objstore.Bucket, cfgProvider bucket.TenantConfigProvider, logger log.Logger) *BucketAlertStore {
 	return &BucketAlertStore{
-		alertsBucket: bucket.NewPrefixedBucketClient(bkt, alertsPrefix),
-		amBucket:     bucket.NewPrefixedBucketClient(bkt, alertmanagerPrefix),
-		cfgProvider:  cfgProvider,
-		logger:       logger,
+		alertsBucket:   bucket.NewPrefixedBucketClient(bkt, alertsPrefix),
+		versionsBucket: bucket.NewPrefixedBucketClient(bkt, alertsVersionsPrefix),
+		amBucket:       bucket.NewPrefixedBucketClient(bkt, alertmanagerPrefix),
+		auditBucket:    bucket.NewPrefixedBucketClient(bkt, auditPrefix),
+		cfgProvider:    cfgProvider,
+		logger:         logger,
 	}
 }
 
@@ -120,6 +142,15 @@ func (s *BucketAlertStore) SetAlertConfig(ctx context.Context, cfg alertspb.Aler
 
 // DeleteAlertConfig implements alertstore.AlertStore.
 func (s *BucketAlertStore) DeleteAlertConfig(ctx context.Context, userID string) error {
+	// The versions and the notification audit log are deleted first, so that they're not left behind
+	// if the deletion fails and the config still exists.
+	if _, err := bucket.DeletePrefix(ctx, s.getVersionsUserBucket(userID), "", s.logger); err != nil {
+		return errors.Wrapf(err, "failed to delete alertmanager config versions for user %s", userID)
+	}
+	if err := s.DeleteNotificationAuditLog(ctx, userID, time.Time{}); err != nil {
+		return err
+	}
+
 	userBkt := s.getUserBucket(userID)
 
 	err := userBkt.Delete(ctx, userID)
@@ -129,6 +160,58 @@ func (s *BucketAlertStore) DeleteAlertConfig(ctx context.Context, userID string)
 	return err
 }
 
+// ListAlertConfigVersions implements alertstore.AlertStore.
+func (s *BucketAlertStore) ListAlertConfigVersions(ctx context.Context, userID string) ([]int, error) {
+	var versions []int
+
+	err := s.getVersionsUserBucket(userID).Iter(ctx, "", func(key string) error {
+		version, err := strconv.Atoi(key)
+		if err != nil {
+			level.Warn(s.logger).Log("msg", "skipped invalid alertmanager config version", "user", userID, "key", key)
+			return nil
+		}
+
+		versions = append(versions, version)
+		return nil
+	})
+
+	sort.Ints(versions)
+	return versions, err
+}
+
+// GetAlertConfigVersion implements alertstore.AlertStore.
+func (s *BucketAlertStore) GetAlertConfigVersion(ctx context.Context, userID string, version int) (alertspb.AlertConfigDesc, error) {
+	config := alertspb.AlertConfigDesc{}
+
+	err := s.get(ctx, s.getVersionsUserBucket(userID), strconv.Itoa(version), &config)
+	if s.versionsBucket.IsObjNotFoundErr(err) {
+		return config, alertspb.ErrNotFound
+	}
+
+	return config, err
+}
+
+// SetAlertConfigVersion implements alertstore.AlertStore.
+func (s *BucketAlertStore) SetAlertConfigVersion(ctx context.Context, cfg alertspb.AlertConfigDesc, version int) error {
+	cfgBytes, err := cfg.Marshal()
+	if err != nil {
+		return err
+	}
+
+	return s.getVersionsUserBucket(cfg.User).Upload(ctx, strconv.Itoa(version), bytes.NewBuffer(cfgBytes))
+}
+
+// DeleteAlertConfigVersion implements alertstore.AlertStore.
+func (s *BucketAlertStore) DeleteAlertConfigVersion(ctx context.Context, userID string, version int) error {
+	userBkt := s.getVersionsUserBucket(userID)
+
+	err := userBkt.Delete(ctx, strconv.Itoa(version))
+	if userBkt.IsObjNotFoundErr(err) {
+		return nil
+	}
+	return err
+}
+
 // ListUsersWithFullState implements alertstore.AlertStore.
 func (s *BucketAlertStore) ListUsersWithFullState(ctx context.Context) ([]string, error) {
 	var userIDs []string
@@ -177,6 +260,66 @@ func (s *BucketAlertStore) DeleteFullState(ctx context.Context, userID string) e
 	return err
 }
 
+// AppendNotificationAuditLog implements alertstore.AlertStore.
+func (s *BucketAlertStore) AppendNotificationAuditLog(ctx context.Context, userID string, entries []alertspb.NotificationAuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	buf := bytes.Buffer{}
+	enc := json.NewEncoder(&buf)
+	for _, entry := range entries {
+		if err := enc.Encode(entry); err != nil {
+			return err
+		}
+	}
+
+	name := ulid.MustNew(ulid.Now(), rand.Reader).String() + ".json"
+	return s.getAuditUserBucket(userID).Upload(ctx, name, &buf)
+}
+
+// ListUsersWithNotificationAuditLog implements alertstore.AlertStore.
+func (s *BucketAlertStore) ListUsersWithNotificationAuditLog(ctx context.Context) ([]string, error) {
+	var userIDs []string
+
+	err := s.auditBucket.Iter(ctx, "", func(key string) error {
+		userIDs = append(userIDs, strings.TrimRight(key, "/"))
+		return nil
+	})
+
+	return userIDs, err
+}
+
+// DeleteNotificationAuditLog implements alertstore.AlertStore.
+func (s *BucketAlertStore) DeleteNotificationAuditLog(ctx context.Context, userID string, before time.Time) error {
+	userBkt := s.getAuditUserBucket(userID)
+
+	if before.IsZero() {
+		if _, err := bucket.DeletePrefix(ctx, userBkt, "", s.logger); err != nil {
+			return errors.Wrapf(err, "failed to delete notification audit log for user %s", userID)
+		}
+		return nil
+	}
+
+	err := userBkt.Iter(ctx, "", func(key string) error {
+		// Each batch is named after a ULID, whose timestamp is the time the batch has been stored.
+		id, err := ulid.Parse(strings.TrimSuffix(key, ".json"))
+		if err != nil {
+			level.Warn(s.logger).Log("msg", "skipped invalid notification audit log object", "user", userID, "key", key)
+			return nil
+		}
+		if !ulid.Time(id.Time()).Before(before) {
+			return nil
+		}
+
+		if err := userBkt.Delete(ctx, key); err != nil && !userBkt.IsObjNotFoundErr(err) {
+			return err
+		}
+		return nil
+	})
+	return errors.Wrapf(err, "failed to delete notification audit log for user %s", userID)
+}
+
 func (s *BucketAlertStore) getAlertConfig(ctx context.Context, userID string) (alertspb.AlertConfigDesc, error) {
 	config := alertspb.AlertConfigDesc{}
 	err := s.get(ctx, s.getUserBucket(userID), userID, &config)
@@ -209,6 +352,16 @@ func (s *BucketAlertStore) getUserBucket(userID string) objstore.Bucket {
 	return bucket.NewSSEBucketClient(userID, s.alertsBucket, s.cfgProvider)
 }
 
+func (s *BucketAlertStore) getVersionsUserBucket(userID string) objstore.Bucket {
+	// Versions contain the whole config, including the receivers secrets, so the server-side encryption
+	// based on the tenant config is injected as done for the config itself.
+	return bucket.NewSSEBucketClient(userID, bucket.NewPrefixedBucketClient(s.versionsBucket, userID), s.cfgProvider).WithExpectedErrs(s.versionsBucket.IsObjNotFoundErr)
+}
+
+func (s *BucketAlertStore) getAuditUserBucket(userID string) objstore.Bucket {
+	return bucket.NewUserBucketClient(userID, s.auditBucket, s.cfgProvider)
+}
+
 func (s *BucketAlertStore) getAlertmanagerUserBucket(userID string) objstore.Bucket {
 	return bucket.NewUserBucketClient(userID, s.amBucket, s.cfgProvider).WithExpectedErrs(s.amBucket.IsObjNotFoundErr)
 }
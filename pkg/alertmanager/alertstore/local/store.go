@@ -12,6 +12,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/prometheus/alertmanager/config"
@@ -26,6 +27,7 @@ const (
 var (
 	errReadOnly = errors.New("local alertmanager config storage is read-only")
 	errState    = errors.New("local alertmanager storage does not support state persistency")
+	errVersions = errors.New("local alertmanager config storage does not support config versions")
 )
 
 // StoreConfig configures a static file alertmanager store
@@ -106,6 +108,26 @@ func (f *Store) DeleteAlertConfig(_ context.Context, user string) error {
 	return errReadOnly
 }
 
+// ListAlertConfigVersions implements alertstore.AlertStore.
+func (f *Store) ListAlertConfigVersions(_ context.Context, user string) ([]int, error) {
+	return nil, errVersions
+}
+
+// GetAlertConfigVersion implements alertstore.AlertStore.
+func (f *Store) GetAlertConfigVersion(_ context.Context, user string, version int) (alertspb.AlertConfigDesc, error) {
+	return alertspb.AlertConfigDesc{}, errVersions
+}
+
+// SetAlertConfigVersion implements alertstore.AlertStore.
+func (f *Store) SetAlertConfigVersion(_ context.Context, cfg alertspb.AlertConfigDesc, version int) error {
+	return errReadOnly
+}
+
+// DeleteAlertConfigVersion implements alertstore.AlertStore.
+func (f *Store) DeleteAlertConfigVersion(_ context.Context, user string, version int) error {
+	return errReadOnly
+}
+
 // ListUsersWithFullState implements alertstore.AlertStore.
 func (f *Store) ListUsersWithFullState(ctx context.Context) ([]string, error) {
 	return nil, errState
@@ -126,6 +148,21 @@ func (f *Store) DeleteFullState(ctx context.Context, user string) error {
 	return errState
 }
 
+// AppendNotificationAuditLog implements alertstore.AlertStore.
+func (f *Store) AppendNotificationAuditLog(ctx context.Context, user string, entries []alertspb.NotificationAuditEntry) error {
+	return errState
+}
+
+// ListUsersWithNotificationAuditLog implements alertstore.AlertStore.
+func (f *Store) ListUsersWithNotificationAuditLog(ctx context.Context) ([]string, error) {
+	return nil, errState
+}
+
+// DeleteNotificationAuditLog implements alertstore.AlertStore.
+func (f *Store) DeleteNotificationAuditLog(ctx context.Context, user string, before time.Time) error {
+	return errState
+}
+
 func (f *Store) reloadConfigs() (map[string]alertspb.AlertConfigDesc, error) {
 	configs := map[string]alertspb.AlertConfigDesc{}
 	err := filepath.Walk(f.cfg.Path, func(path string, info os.FileInfo, err error) error {
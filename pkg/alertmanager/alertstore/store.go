@@ -7,6 +7,7 @@ package alertstore
 
 import (
 	"context"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
@@ -34,10 +35,25 @@ type AlertStore interface {
 	// SetAlertConfig stores the alertmanager configuration for an user.
 	SetAlertConfig(ctx context.Context, cfg alertspb.AlertConfigDesc) error
 
-	// DeleteAlertConfig deletes the alertmanager configuration for an user.
+	// DeleteAlertConfig deletes the alertmanager configuration for an user, including its versions
+	// and its notification audit log.
 	// If configuration for the user doesn't exist, no error is reported.
 	DeleteAlertConfig(ctx context.Context, user string) error
 
+	// ListAlertConfigVersions returns the versions of the alertmanager configuration stored for an user,
+	// sorted in ascending order.
+	ListAlertConfigVersions(ctx context.Context, user string) ([]int, error)
+
+	// GetAlertConfigVersion loads and returns a version of the alertmanager configuration for the given user.
+	GetAlertConfigVersion(ctx context.Context, user string, version int) (alertspb.AlertConfigDesc, error)
+
+	// SetAlertConfigVersion stores a version of the alertmanager configuration for an user.
+	SetAlertConfigVersion(ctx context.Context, cfg alertspb.AlertConfigDesc, version int) error
+
+	// DeleteAlertConfigVersion deletes a version of the alertmanager configuration for an user.
+	// If the version doesn't exist, no error is reported.
+	DeleteAlertConfigVersion(ctx context.Context, user string, version int) error
+
 	// ListUsersWithFullState returns the list of users which have had state written.
 	ListUsersWithFullState(ctx context.Context) ([]string, error)
 
@@ -50,6 +66,17 @@ type AlertStore interface {
 	// DeleteFullState deletes the alertmanager state for an user.
 	// If state for the user doesn't exist, no error is reported.
 	DeleteFullState(ctx context.Context, user string) error
+
+	// AppendNotificationAuditLog stores a batch of notification audit log entries for an user.
+	// Each batch is stored as a new object, and previously stored batches are never modified.
+	AppendNotificationAuditLog(ctx context.Context, user string, entries []alertspb.NotificationAuditEntry) error
+
+	// ListUsersWithNotificationAuditLog returns the list of users which have had notification audit log entries stored.
+	ListUsersWithNotificationAuditLog(ctx context.Context) ([]string, error)
+
+	// DeleteNotificationAuditLog deletes the batches of notification audit log entries of an user stored
+	// before the given time. If before is zero, all the batches are deleted.
+	DeleteNotificationAuditLog(ctx context.Context, user string, before time.Time) error
 }
 
 // NewAlertStore returns a alertmanager store backend client based on the provided cfg.
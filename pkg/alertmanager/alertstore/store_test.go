@@ -7,9 +7,13 @@ package alertstore
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
 	"github.com/prometheus/alertmanager/cluster/clusterpb"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -17,6 +21,7 @@ import (
 
 	"github.com/grafana/mimir/pkg/alertmanager/alertspb"
 	"github.com/grafana/mimir/pkg/alertmanager/alertstore/bucketclient"
+	"github.com/grafana/mimir/pkg/storage/bucket/s3"
 )
 
 func TestAlertStore_ListAllUsers(t *testing.T) {
@@ -129,9 +134,11 @@ func TestAlertStore_DeleteAlertConfig(t *testing.T) {
 	user1Cfg := alertspb.AlertConfigDesc{User: "user-1", RawConfig: "content-1"}
 	user2Cfg := alertspb.AlertConfigDesc{User: "user-2", RawConfig: "content-2"}
 
-	// Upload the config for 2 users.
+	// Upload the config and a version of the config for 2 users.
 	require.NoError(t, store.SetAlertConfig(ctx, user1Cfg))
 	require.NoError(t, store.SetAlertConfig(ctx, user2Cfg))
+	require.NoError(t, store.SetAlertConfigVersion(ctx, user1Cfg, 1))
+	require.NoError(t, store.SetAlertConfigVersion(ctx, user2Cfg, 1))
 
 	// Ensure the config has been correctly uploaded.
 	config, err := store.GetAlertConfig(ctx, "user-1")
@@ -145,18 +152,178 @@ func TestAlertStore_DeleteAlertConfig(t *testing.T) {
 	// Delete the config for user-1.
 	require.NoError(t, store.DeleteAlertConfig(ctx, "user-1"))
 
-	// Ensure the correct config has been deleted.
+	// Ensure the correct config has been deleted, together with its versions.
 	_, err = store.GetAlertConfig(ctx, "user-1")
 	assert.Equal(t, alertspb.ErrNotFound, err)
 
+	versions, err := store.ListAlertConfigVersions(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Empty(t, versions)
+
 	config, err = store.GetAlertConfig(ctx, "user-2")
 	require.NoError(t, err)
 	assert.Equal(t, user2Cfg, config)
 
+	versions, err = store.ListAlertConfigVersions(ctx, "user-2")
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, versions)
+
 	// Delete again (should be idempotent).
 	require.NoError(t, store.DeleteAlertConfig(ctx, "user-1"))
 }
 
+func TestBucketAlertStore_GetSetDeleteAlertConfigVersion(t *testing.T) {
+	bucket := objstore.NewInMemBucket()
+	store := bucketclient.NewBucketAlertStore(bucket, nil, log.NewNopLogger())
+
+	ctx := context.Background()
+	version1 := alertspb.AlertConfigDesc{User: "user-1", RawConfig: "content-1"}
+	version2 := alertspb.AlertConfigDesc{User: "user-1", RawConfig: "content-2", Assets: []*alertspb.AssetDesc{{Filename: "logo.png", Body: []byte("logo")}}}
+
+	// The storage is empty.
+	{
+		versions, err := store.ListAlertConfigVersions(ctx, "user-1")
+		require.NoError(t, err)
+		assert.Empty(t, versions)
+
+		_, err = store.GetAlertConfigVersion(ctx, "user-1", 1)
+		assert.Equal(t, alertspb.ErrNotFound, err)
+	}
+
+	// The storage contains versions.
+	{
+		require.NoError(t, store.SetAlertConfigVersion(ctx, version2, 10))
+		require.NoError(t, store.SetAlertConfigVersion(ctx, version1, 2))
+
+		versions, err := store.ListAlertConfigVersions(ctx, "user-1")
+		require.NoError(t, err)
+		assert.Equal(t, []int{2, 10}, versions)
+
+		res, err := store.GetAlertConfigVersion(ctx, "user-1", 10)
+		require.NoError(t, err)
+		assert.Equal(t, version2, res)
+
+		// Ensure the version is stored at the expected location, and doesn't show up as a config.
+		exists, err := bucket.Exists(ctx, "alerts-versions/user-1/10")
+		require.NoError(t, err)
+		assert.True(t, exists)
+
+		users, err := store.ListAllUsers(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, users)
+	}
+
+	// The storage has had a version deleted.
+	{
+		require.NoError(t, store.DeleteAlertConfigVersion(ctx, "user-1", 2))
+
+		versions, err := store.ListAlertConfigVersions(ctx, "user-1")
+		require.NoError(t, err)
+		assert.Equal(t, []int{10}, versions)
+
+		// Delete again (should be idempotent).
+		require.NoError(t, store.DeleteAlertConfigVersion(ctx, "user-1", 2))
+	}
+}
+
+func TestBucketAlertStore_NotificationAuditLog(t *testing.T) {
+	bucket := objstore.NewInMemBucket()
+	store := bucketclient.NewBucketAlertStore(bucket, nil, log.NewNopLogger())
+
+	ctx := context.Background()
+	entries := []alertspb.NotificationAuditEntry{{Receiver: "receiver-1", Integration: "webhook"}}
+
+	// The storage is empty.
+	{
+		users, err := store.ListUsersWithNotificationAuditLog(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, users)
+	}
+
+	// The storage contains audit logs.
+	require.NoError(t, store.AppendNotificationAuditLog(ctx, "user-1", entries))
+	require.NoError(t, store.AppendNotificationAuditLog(ctx, "user-2", entries))
+
+	users, err := store.ListUsersWithNotificationAuditLog(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"user-1", "user-2"}, users)
+
+	// Batches stored before an older time are kept.
+	require.NoError(t, store.DeleteNotificationAuditLog(ctx, "user-1", time.Now().Add(-time.Hour)))
+
+	users, err = store.ListUsersWithNotificationAuditLog(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"user-1", "user-2"}, users)
+
+	// Batches stored before a later time are deleted.
+	require.NoError(t, store.DeleteNotificationAuditLog(ctx, "user-1", time.Now().Add(time.Hour)))
+
+	users, err = store.ListUsersWithNotificationAuditLog(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user-2"}, users)
+
+	// The audit log is deleted together with the config.
+	require.NoError(t, store.SetAlertConfig(ctx, alertspb.AlertConfigDesc{User: "user-2", RawConfig: "content-2"}))
+	require.NoError(t, store.DeleteAlertConfig(ctx, "user-2"))
+
+	users, err = store.ListUsersWithNotificationAuditLog(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, users)
+}
+
+func TestBucketAlertStore_SetAlertConfigVersion_ShouldInjectCustomSSEConfig(t *testing.T) {
+	const kmsKeyID = "ABC"
+
+	var req *http.Request
+
+	// Start a fake HTTP server which simulate S3.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Keep track of the received request.
+		req = r
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	s3Client, err := s3.NewBucketClient(s3.Config{
+		Endpoint:        srv.Listener.Addr().String(),
+		Region:          "test",
+		BucketName:      "test-bucket",
+		SecretAccessKey: flagext.Secret{Value: "test"},
+		AccessKeyID:     "test",
+		Insecure:        true,
+	}, "test", log.NewNopLogger())
+	require.NoError(t, err)
+
+	cfgProvider := &mockTenantConfigProvider{s3SseType: s3.SSEKMS, s3KmsKeyID: kmsKeyID}
+	store := bucketclient.NewBucketAlertStore(s3Client, cfgProvider, log.NewNopLogger())
+
+	require.NoError(t, store.SetAlertConfigVersion(context.Background(), alertspb.AlertConfigDesc{User: "user-1", RawConfig: "content-1"}, 1))
+
+	// Ensure the version has been uploaded with the tenant's KMS config.
+	require.NotNil(t, req)
+	assert.Equal(t, "/test-bucket/alerts-versions/user-1/1", req.URL.Path)
+	assert.Equal(t, "aws:kms", req.Header.Get("x-amz-server-side-encryption"))
+	assert.Equal(t, kmsKeyID, req.Header.Get("x-amz-server-side-encryption-aws-kms-key-id"))
+}
+
+type mockTenantConfigProvider struct {
+	s3SseType  string
+	s3KmsKeyID string
+}
+
+func (m *mockTenantConfigProvider) S3SSEType(_ string) string {
+	return m.s3SseType
+}
+
+func (m *mockTenantConfigProvider) S3SSEKMSKeyID(_ string) string {
+	return m.s3KmsKeyID
+}
+
+func (m *mockTenantConfigProvider) S3SSEKMSEncryptionContext(_ string) string {
+	return ""
+}
+
 func makeTestFullState(content string) alertspb.FullStateDesc {
 	return alertspb.FullStateDesc{
 		State: &clusterpb.FullState{
@@ -140,13 +140,23 @@ func (am *MultitenantAlertmanager) SetUserConfig(w http.ResponseWriter, r *http.
 	}
 
 	cfgDesc := alertspb.ToProto(cfg.AlertmanagerConfig, cfg.TemplateFiles, userID)
+
+	// Static assets are managed through their own API, so they're preserved when the config is replaced.
+	existing, err := am.store.GetAlertConfig(r.Context(), userID)
+	if err != nil && err != alertspb.ErrNotFound {
+		level.Error(logger).Log("msg", errReadingConfiguration, "err", err.Error())
+		http.Error(w, fmt.Sprintf("%s: %s", errReadingConfiguration, err.Error()), http.StatusInternalServerError)
+		return
+	}
+	cfgDesc.Assets = existing.Assets
+
 	if err := validateUserConfig(logger, cfgDesc, am.limits, userID); err != nil {
 		level.Warn(logger).Log("msg", errValidatingConfig, "err", err.Error())
 		http.Error(w, fmt.Sprintf("%s: %s", errValidatingConfig, err.Error()), http.StatusBadRequest)
 		return
 	}
 
-	err = am.store.SetAlertConfig(r.Context(), cfgDesc)
+	err = am.setAlertConfig(r.Context(), logger, cfgDesc)
 	if err != nil {
 		level.Error(logger).Log("msg", errStoringConfiguration, "err", err.Error())
 		http.Error(w, fmt.Sprintf("%s: %s", errStoringConfiguration, err.Error()), http.StatusInternalServerError)
@@ -224,6 +234,13 @@ func validateUserConfig(logger log.Logger, cfg alertspb.AlertConfigDesc, limits
 		}
 	}
 
+	// Validate static assets.
+	for _, asset := range cfg.Assets {
+		if err := validateAssetFilename(asset.Filename); err != nil {
+			return err
+		}
+	}
+
 	// Create templates on disk in a temporary directory.
 	// Note: This means the validation will succeed if we can write to tmp but
 	// not to configured data dir, and on the flipside, it'll fail if we can't write
@@ -258,6 +275,13 @@ func validateUserConfig(logger log.Logger, cfg alertspb.AlertConfigDesc, limits
 		return err
 	}
 
+	// Check the templates against the tenant's sandbox limits.
+	if sandbox := newTemplateSandbox(limits, user); sandbox.enabled() {
+		if err := sandbox.validate(amCfg, templateFiles); err != nil {
+			return err
+		}
+	}
+
 	// Note: Not validating the MultitenantAlertmanager.transformConfig function as that
 	// that function shouldn't break configuration. Only way it can fail is if the base
 	// autoWebhookURL itself is broken. In that case, I would argue, we should accept the config
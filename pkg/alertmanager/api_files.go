@@ -0,0 +1,298 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package alertmanager
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"sort"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/gorilla/mux"
+	"gopkg.in/yaml.v2"
+
+	"github.com/grafana/mimir/pkg/alertmanager/alertspb"
+	"github.com/grafana/mimir/pkg/tenant"
+	util_log "github.com/grafana/mimir/pkg/util/log"
+)
+
+const (
+	errReadingFile        = "unable to read the file"
+	errFileTooBig         = "file is too big, limit: %d bytes"
+	errConfigNotFound     = "the Alertmanager configuration must be set before its templates and assets"
+	errTemplateNotFound   = "template not found"
+	errAssetNotFound      = "asset not found"
+	errInvalidAssetName   = "invalid asset name %q: the asset name cannot contain any path"
+	errMarshallingFileSet = "error marshalling YAML list of files"
+)
+
+// UserTemplates is used to list the names of a user's template files.
+type UserTemplates struct {
+	Templates []string `yaml:"templates"`
+}
+
+// UserAssets is used to list the names of a user's static assets.
+type UserAssets struct {
+	Assets []string `yaml:"assets"`
+}
+
+// ListUserTemplates lists the names of the template files of the tenant's configuration.
+func (am *MultitenantAlertmanager) ListUserTemplates(w http.ResponseWriter, r *http.Request) {
+	cfg, logger, ok := am.getUserConfigForFiles(w, r)
+	if !ok {
+		return
+	}
+
+	names := make([]string, 0, len(cfg.Templates))
+	for _, tmpl := range cfg.Templates {
+		names = append(names, tmpl.Filename)
+	}
+	sort.Strings(names)
+
+	writeFileSet(w, logger, &UserTemplates{Templates: names})
+}
+
+// GetUserTemplate returns the body of a template file of the tenant's configuration.
+func (am *MultitenantAlertmanager) GetUserTemplate(w http.ResponseWriter, r *http.Request) {
+	cfg, _, ok := am.getUserConfigForFiles(w, r)
+	if !ok {
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	for _, tmpl := range cfg.Templates {
+		if tmpl.Filename == name {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			_, _ = w.Write([]byte(tmpl.Body))
+			return
+		}
+	}
+
+	http.Error(w, errTemplateNotFound, http.StatusNotFound)
+}
+
+// SetUserTemplate creates or replaces a template file of the tenant's configuration.
+func (am *MultitenantAlertmanager) SetUserTemplate(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	if err := validateTemplateFilename(name); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	am.updateUserConfigFiles(w, r, func(cfg *alertspb.AlertConfigDesc, body []byte) {
+		for _, tmpl := range cfg.Templates {
+			if tmpl.Filename == name {
+				tmpl.Body = string(body)
+				return
+			}
+		}
+		cfg.Templates = append(cfg.Templates, &alertspb.TemplateDesc{Filename: name, Body: string(body)})
+	})
+}
+
+// DeleteUserTemplate deletes a template file of the tenant's configuration.
+// Note that if the template doesn't exist, StatusOK is returned.
+func (am *MultitenantAlertmanager) DeleteUserTemplate(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	am.updateUserConfigFiles(w, r, func(cfg *alertspb.AlertConfigDesc, _ []byte) {
+		for i, tmpl := range cfg.Templates {
+			if tmpl.Filename == name {
+				cfg.Templates = append(cfg.Templates[:i], cfg.Templates[i+1:]...)
+				return
+			}
+		}
+	})
+}
+
+// ListUserAssets lists the names of the static assets of the tenant's configuration.
+func (am *MultitenantAlertmanager) ListUserAssets(w http.ResponseWriter, r *http.Request) {
+	cfg, logger, ok := am.getUserConfigForFiles(w, r)
+	if !ok {
+		return
+	}
+
+	names := make([]string, 0, len(cfg.Assets))
+	for _, asset := range cfg.Assets {
+		names = append(names, asset.Filename)
+	}
+	sort.Strings(names)
+
+	writeFileSet(w, logger, &UserAssets{Assets: names})
+}
+
+// GetUserAsset returns the content of a static asset of the tenant's configuration.
+func (am *MultitenantAlertmanager) GetUserAsset(w http.ResponseWriter, r *http.Request) {
+	cfg, _, ok := am.getUserConfigForFiles(w, r)
+	if !ok {
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	for _, asset := range cfg.Assets {
+		if asset.Filename == name {
+			contentType := mime.TypeByExtension(filepath.Ext(name))
+			if contentType == "" {
+				contentType = http.DetectContentType(asset.Body)
+			}
+
+			w.Header().Set("Content-Type", contentType)
+			_, _ = w.Write(asset.Body)
+			return
+		}
+	}
+
+	http.Error(w, errAssetNotFound, http.StatusNotFound)
+}
+
+// SetUserAsset creates or replaces a static asset of the tenant's configuration.
+func (am *MultitenantAlertmanager) SetUserAsset(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	if err := validateAssetFilename(name); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	am.updateUserConfigFiles(w, r, func(cfg *alertspb.AlertConfigDesc, body []byte) {
+		for _, asset := range cfg.Assets {
+			if asset.Filename == name {
+				asset.Body = body
+				return
+			}
+		}
+		cfg.Assets = append(cfg.Assets, &alertspb.AssetDesc{Filename: name, Body: body})
+	})
+}
+
+// DeleteUserAsset deletes a static asset of the tenant's configuration.
+// Note that if the asset doesn't exist, StatusOK is returned.
+func (am *MultitenantAlertmanager) DeleteUserAsset(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	am.updateUserConfigFiles(w, r, func(cfg *alertspb.AlertConfigDesc, _ []byte) {
+		for i, asset := range cfg.Assets {
+			if asset.Filename == name {
+				cfg.Assets = append(cfg.Assets[:i], cfg.Assets[i+1:]...)
+				return
+			}
+		}
+	})
+}
+
+// getUserConfigForFiles loads the configuration of the tenant sending the request. If the configuration
+// can't be loaded, it writes the error response and returns false.
+func (am *MultitenantAlertmanager) getUserConfigForFiles(w http.ResponseWriter, r *http.Request) (alertspb.AlertConfigDesc, log.Logger, bool) {
+	logger := util_log.WithContext(r.Context(), am.logger)
+
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		level.Error(logger).Log("msg", errNoOrgID, "err", err.Error())
+		http.Error(w, fmt.Sprintf("%s: %s", errNoOrgID, err.Error()), http.StatusUnauthorized)
+		return alertspb.AlertConfigDesc{}, logger, false
+	}
+
+	cfg, err := am.store.GetAlertConfig(r.Context(), userID)
+	if err != nil {
+		if err == alertspb.ErrNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return alertspb.AlertConfigDesc{}, logger, false
+	}
+
+	return cfg, logger, true
+}
+
+// updateUserConfigFiles reads the file uploaded with the request, applies the update to the
+// tenant's configuration, then validates and stores the updated configuration.
+func (am *MultitenantAlertmanager) updateUserConfigFiles(w http.ResponseWriter, r *http.Request, update func(cfg *alertspb.AlertConfigDesc, body []byte)) {
+	logger := util_log.WithContext(r.Context(), am.logger)
+
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		level.Error(logger).Log("msg", errNoOrgID, "err", err.Error())
+		http.Error(w, fmt.Sprintf("%s: %s", errNoOrgID, err.Error()), http.StatusUnauthorized)
+		return
+	}
+
+	var body []byte
+	if r.Method != http.MethodDelete {
+		var input io.Reader = r.Body
+		maxSize := am.limits.AlertmanagerMaxConfigSize(userID)
+		if maxSize > 0 {
+			// Allow one extra byte to check if we have read too many bytes.
+			input = io.LimitReader(r.Body, int64(maxSize)+1)
+		}
+
+		body, err = ioutil.ReadAll(input)
+		if err != nil {
+			level.Error(logger).Log("msg", errReadingFile, "err", err.Error())
+			http.Error(w, fmt.Sprintf("%s: %s", errReadingFile, err.Error()), http.StatusBadRequest)
+			return
+		}
+
+		if maxSize > 0 && len(body) > maxSize {
+			msg := fmt.Sprintf(errFileTooBig, maxSize)
+			level.Warn(logger).Log("msg", msg)
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+	}
+
+	cfg, err := am.store.GetAlertConfig(r.Context(), userID)
+	if err != nil {
+		if err == alertspb.ErrNotFound {
+			http.Error(w, errConfigNotFound, http.StatusNotFound)
+		} else {
+			level.Error(logger).Log("msg", errReadingConfiguration, "err", err.Error())
+			http.Error(w, fmt.Sprintf("%s: %s", errReadingConfiguration, err.Error()), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	update(&cfg, body)
+
+	if err := validateUserConfig(logger, cfg, am.limits, userID); err != nil {
+		level.Warn(logger).Log("msg", errValidatingConfig, "err", err.Error())
+		http.Error(w, fmt.Sprintf("%s: %s", errValidatingConfig, err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	if err := am.setAlertConfig(r.Context(), logger, cfg); err != nil {
+		level.Error(logger).Log("msg", errStoringConfiguration, "err", err.Error())
+		http.Error(w, fmt.Sprintf("%s: %s", errStoringConfiguration, err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+func writeFileSet(w http.ResponseWriter, logger log.Logger, set interface{}) {
+	d, err := yaml.Marshal(set)
+	if err != nil {
+		level.Error(logger).Log("msg", errMarshallingFileSet, "err", err)
+		http.Error(w, fmt.Sprintf("%s: %s", errMarshallingFileSet, err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/yaml")
+	_, _ = w.Write(d)
+}
+
+func validateAssetFilename(filename string) error {
+	if filename == "" || filename == "." || filename == ".." || filepath.Base(filename) != filename {
+		return fmt.Errorf(errInvalidAssetName, filename)
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package alertmanager
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/alertmanager/alertspb"
+	util_log "github.com/grafana/mimir/pkg/util/log"
+)
+
+const testFilesConfig = `
+route:
+  receiver: 'default-receiver'
+receivers:
+  - name: default-receiver
+templates:
+  - "*.tmpl"
+`
+
+func TestMultitenantAlertmanager_UserTemplatesAPI(t *testing.T) {
+	am := &MultitenantAlertmanager{
+		store:  prepareInMemoryAlertStore(),
+		logger: util_log.Logger,
+		limits: &mockAlertManagerLimits{},
+	}
+
+	// Setting a template without a config fails.
+	res := doFileRequest(am.SetUserTemplate, http.MethodPut, "name", "first.tmpl", `{{ define "first" }}1{{ end }}`)
+	require.Equal(t, http.StatusNotFound, res.Code)
+
+	require.NoError(t, am.store.SetAlertConfig(context.Background(), alertspb.AlertConfigDesc{User: "user-1", RawConfig: testFilesConfig}))
+
+	// Set templates.
+	res = doFileRequest(am.SetUserTemplate, http.MethodPut, "name", "second.tmpl", `{{ define "second" }}2{{ end }}`)
+	require.Equal(t, http.StatusCreated, res.Code)
+	res = doFileRequest(am.SetUserTemplate, http.MethodPut, "name", "first.tmpl", `{{ define "first" }}1{{ end }}`)
+	require.Equal(t, http.StatusCreated, res.Code)
+
+	// Invalid templates are rejected.
+	res = doFileRequest(am.SetUserTemplate, http.MethodPut, "name", "invalid.tmpl", `{{ define "invalid" }}`)
+	require.Equal(t, http.StatusBadRequest, res.Code)
+	res = doFileRequest(am.SetUserTemplate, http.MethodPut, "name", "..", `{{ define "invalid" }}{{ end }}`)
+	require.Equal(t, http.StatusBadRequest, res.Code)
+
+	// Replace a template.
+	res = doFileRequest(am.SetUserTemplate, http.MethodPut, "name", "first.tmpl", `{{ define "first" }}one{{ end }}`)
+	require.Equal(t, http.StatusCreated, res.Code)
+
+	res = doFileRequest(am.ListUserTemplates, http.MethodGet, "", "", "")
+	require.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "templates:\n- first.tmpl\n- second.tmpl\n", res.Body.String())
+
+	res = doFileRequest(am.GetUserTemplate, http.MethodGet, "name", "first.tmpl", "")
+	require.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, `{{ define "first" }}one{{ end }}`, res.Body.String())
+
+	// Delete a template, twice.
+	for i := 0; i < 2; i++ {
+		res = doFileRequest(am.DeleteUserTemplate, http.MethodDelete, "name", "first.tmpl", "")
+		require.Equal(t, http.StatusOK, res.Code)
+	}
+
+	res = doFileRequest(am.GetUserTemplate, http.MethodGet, "name", "first.tmpl", "")
+	require.Equal(t, http.StatusNotFound, res.Code)
+
+	// The config is left untouched.
+	cfg, err := am.store.GetAlertConfig(context.Background(), "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, testFilesConfig, cfg.RawConfig)
+	assert.Equal(t, map[string]string{"second.tmpl": `{{ define "second" }}2{{ end }}`}, alertspb.ParseTemplates(cfg))
+}
+
+func TestMultitenantAlertmanager_UserAssetsAPI(t *testing.T) {
+	limits := &mockAlertManagerLimits{}
+	am := &MultitenantAlertmanager{
+		store:  prepareInMemoryAlertStore(),
+		logger: util_log.Logger,
+		limits: limits,
+	}
+
+	require.NoError(t, am.store.SetAlertConfig(context.Background(), alertspb.AlertConfigDesc{User: "user-1", RawConfig: testFilesConfig}))
+
+	res := doFileRequest(am.SetUserAsset, http.MethodPost, "name", "logo.png", "\x89PNG\r\n\x1a\n")
+	require.Equal(t, http.StatusCreated, res.Code)
+	res = doFileRequest(am.SetUserAsset, http.MethodPost, "name", "style", "body { color: red; }")
+	require.Equal(t, http.StatusCreated, res.Code)
+	res = doFileRequest(am.SetUserAsset, http.MethodPost, "name", "../escape.png", "")
+	require.Equal(t, http.StatusBadRequest, res.Code)
+
+	// Assets bigger than the limit are rejected.
+	limits.maxConfigSize = 5
+	res = doFileRequest(am.SetUserAsset, http.MethodPost, "name", "big.txt", "too big")
+	require.Equal(t, http.StatusBadRequest, res.Code)
+	limits.maxConfigSize = 0
+
+	res = doFileRequest(am.ListUserAssets, http.MethodGet, "", "", "")
+	require.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "assets:\n- logo.png\n- style\n", res.Body.String())
+
+	res = doFileRequest(am.GetUserAsset, http.MethodGet, "name", "logo.png", "")
+	require.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "image/png", res.Header().Get("Content-Type"))
+	assert.Equal(t, "\x89PNG\r\n\x1a\n", res.Body.String())
+
+	res = doFileRequest(am.GetUserAsset, http.MethodGet, "name", "style", "")
+	require.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "text/plain; charset=utf-8", res.Header().Get("Content-Type"))
+
+	// Replacing the config through the config API preserves the assets.
+	res = doFileRequest(am.SetUserConfig, http.MethodPost, "", "", "alertmanager_config: |\n"+indent(testFilesConfig))
+	require.Equal(t, http.StatusCreated, res.Code)
+
+	res = doFileRequest(am.DeleteUserAsset, http.MethodDelete, "name", "style", "")
+	require.Equal(t, http.StatusOK, res.Code)
+
+	cfg, err := am.store.GetAlertConfig(context.Background(), "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, []*alertspb.AssetDesc{{Filename: "logo.png", Body: []byte("\x89PNG\r\n\x1a\n")}}, cfg.Assets)
+}
+
+func doFileRequest(handler http.HandlerFunc, method, varName, varValue, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "http://alertmanager/api/v1/alerts", strings.NewReader(body))
+	req = req.WithContext(user.InjectOrgID(req.Context(), "user-1"))
+	if varName != "" {
+		req = mux.SetURLVars(req, map[string]string{varName: varValue})
+	}
+
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func indent(s string) string {
+	return "  " + strings.ReplaceAll(strings.TrimSpace(s), "\n", "\n  ") + "\n"
+}
@@ -0,0 +1,186 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package alertmanager
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/gorilla/mux"
+	"gopkg.in/yaml.v2"
+
+	"github.com/grafana/mimir/pkg/alertmanager/alertspb"
+	"github.com/grafana/mimir/pkg/tenant"
+	util_log "github.com/grafana/mimir/pkg/util/log"
+)
+
+const (
+	errListingVersions     = "unable to list the Alertmanager config versions"
+	errReadingVersion      = "unable to read the Alertmanager config version"
+	errInvalidVersion      = "invalid Alertmanager config version %q"
+	errVersionNotFound     = "Alertmanager config version not found"
+	errMarshallingVersions = "error marshalling YAML list of Alertmanager config versions"
+)
+
+// UserConfigVersions is used to list the stored versions of a user's alertmanager configs.
+type UserConfigVersions struct {
+	Versions []int `yaml:"versions"`
+}
+
+// ListUserConfigVersions lists the stored versions of the tenant's configuration.
+func (am *MultitenantAlertmanager) ListUserConfigVersions(w http.ResponseWriter, r *http.Request) {
+	logger := util_log.WithContext(r.Context(), am.logger)
+
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		level.Error(logger).Log("msg", errNoOrgID, "err", err.Error())
+		http.Error(w, fmt.Sprintf("%s: %s", errNoOrgID, err.Error()), http.StatusUnauthorized)
+		return
+	}
+
+	versions, err := am.store.ListAlertConfigVersions(r.Context(), userID)
+	if err != nil {
+		level.Error(logger).Log("msg", errListingVersions, "err", err.Error())
+		http.Error(w, fmt.Sprintf("%s: %s", errListingVersions, err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	d, err := yaml.Marshal(&UserConfigVersions{Versions: versions})
+	if err != nil {
+		level.Error(logger).Log("msg", errMarshallingVersions, "err", err)
+		http.Error(w, fmt.Sprintf("%s: %s", errMarshallingVersions, err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/yaml")
+	_, _ = w.Write(d)
+}
+
+// GetUserConfigVersion returns a stored version of the tenant's configuration, in the same format of GetUserConfig.
+func (am *MultitenantAlertmanager) GetUserConfigVersion(w http.ResponseWriter, r *http.Request) {
+	cfg, logger, ok := am.getUserConfigVersion(w, r)
+	if !ok {
+		return
+	}
+
+	d, err := yaml.Marshal(&UserConfig{
+		TemplateFiles:      alertspb.ParseTemplates(cfg),
+		AlertmanagerConfig: cfg.RawConfig,
+	})
+	if err != nil {
+		level.Error(logger).Log("msg", errMarshallingYAML, "err", err, "user", cfg.User)
+		http.Error(w, fmt.Sprintf("%s: %s", errMarshallingYAML, err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/yaml")
+	_, _ = w.Write(d)
+}
+
+// RollbackUserConfig restores a stored version of the tenant's configuration, including its templates and assets.
+// The restored configuration is stored as a new version.
+func (am *MultitenantAlertmanager) RollbackUserConfig(w http.ResponseWriter, r *http.Request) {
+	cfg, logger, ok := am.getUserConfigVersion(w, r)
+	if !ok {
+		return
+	}
+
+	// The configuration is validated again, because the limits may have changed since it was stored.
+	if err := validateUserConfig(logger, cfg, am.limits, cfg.User); err != nil {
+		level.Warn(logger).Log("msg", errValidatingConfig, "err", err.Error())
+		http.Error(w, fmt.Sprintf("%s: %s", errValidatingConfig, err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	if err := am.setAlertConfig(r.Context(), logger, cfg); err != nil {
+		level.Error(logger).Log("msg", errStoringConfiguration, "err", err.Error())
+		http.Error(w, fmt.Sprintf("%s: %s", errStoringConfiguration, err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+// getUserConfigVersion loads the version of the tenant's configuration requested. If the version
+// can't be loaded, it writes the error response and returns false.
+func (am *MultitenantAlertmanager) getUserConfigVersion(w http.ResponseWriter, r *http.Request) (alertspb.AlertConfigDesc, log.Logger, bool) {
+	logger := util_log.WithContext(r.Context(), am.logger)
+
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		level.Error(logger).Log("msg", errNoOrgID, "err", err.Error())
+		http.Error(w, fmt.Sprintf("%s: %s", errNoOrgID, err.Error()), http.StatusUnauthorized)
+		return alertspb.AlertConfigDesc{}, logger, false
+	}
+
+	rawVersion := mux.Vars(r)["version"]
+	version, err := strconv.Atoi(rawVersion)
+	if err != nil || version <= 0 {
+		http.Error(w, fmt.Sprintf(errInvalidVersion, rawVersion), http.StatusBadRequest)
+		return alertspb.AlertConfigDesc{}, logger, false
+	}
+
+	cfg, err := am.store.GetAlertConfigVersion(r.Context(), userID, version)
+	if err != nil {
+		if err == alertspb.ErrNotFound {
+			http.Error(w, errVersionNotFound, http.StatusNotFound)
+		} else {
+			level.Error(logger).Log("msg", errReadingVersion, "err", err.Error())
+			http.Error(w, fmt.Sprintf("%s: %s", errReadingVersion, err.Error()), http.StatusInternalServerError)
+		}
+		return alertspb.AlertConfigDesc{}, logger, false
+	}
+
+	return cfg, logger, true
+}
+
+// setAlertConfig stores the tenant's configuration and, if versioning is enabled for the tenant,
+// a new version of it. Failing to store the version doesn't fail the request, because the
+// configuration itself has already been stored.
+func (am *MultitenantAlertmanager) setAlertConfig(ctx context.Context, logger log.Logger, cfg alertspb.AlertConfigDesc) error {
+	if err := am.store.SetAlertConfig(ctx, cfg); err != nil {
+		return err
+	}
+
+	maxVersions := am.limits.AlertmanagerMaxConfigVersions(cfg.User)
+	if maxVersions <= 0 {
+		return nil
+	}
+
+	if err := am.storeAlertConfigVersion(ctx, cfg, maxVersions); err != nil {
+		level.Warn(logger).Log("msg", "unable to store the Alertmanager config version", "user", cfg.User, "err", err)
+	}
+	return nil
+}
+
+// storeAlertConfigVersion stores the configuration as the next version, and deletes the
+// oldest versions exceeding the maximum number of versions to keep.
+func (am *MultitenantAlertmanager) storeAlertConfigVersion(ctx context.Context, cfg alertspb.AlertConfigDesc, maxVersions int) error {
+	versions, err := am.store.ListAlertConfigVersions(ctx, cfg.User)
+	if err != nil {
+		return err
+	}
+
+	next := 1
+	if len(versions) > 0 {
+		next = versions[len(versions)-1] + 1
+	}
+
+	if err := am.store.SetAlertConfigVersion(ctx, cfg, next); err != nil {
+		return err
+	}
+
+	versions = append(versions, next)
+	for len(versions) > maxVersions {
+		if err := am.store.DeleteAlertConfigVersion(ctx, cfg.User, versions[0]); err != nil {
+			return err
+		}
+		versions = versions[1:]
+	}
+
+	return nil
+}
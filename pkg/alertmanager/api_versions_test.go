@@ -0,0 +1,101 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package alertmanager
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/alertmanager/alertspb"
+	"github.com/grafana/mimir/pkg/alertmanager/alertstore"
+	util_log "github.com/grafana/mimir/pkg/util/log"
+)
+
+func TestMultitenantAlertmanager_UserConfigVersionsAPI(t *testing.T) {
+	limits := &mockAlertManagerLimits{}
+	am := &MultitenantAlertmanager{
+		store:  prepareInMemoryAlertStore(),
+		logger: util_log.Logger,
+		limits: limits,
+	}
+
+	setConfig := func(receiver string) {
+		cfg := "alertmanager_config: |\n  route:\n    receiver: " + receiver + "\n  receivers:\n    - name: " + receiver + "\n"
+		res := doFileRequest(am.SetUserConfig, http.MethodPost, "", "", cfg)
+		require.Equal(t, http.StatusCreated, res.Code)
+	}
+
+	// No version is stored if versioning is disabled.
+	setConfig("disabled")
+
+	res := doFileRequest(am.ListUserConfigVersions, http.MethodGet, "", "", "")
+	require.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "versions: []\n", res.Body.String())
+
+	// Only the configured number of versions is kept.
+	limits.maxConfigVersions = 2
+	setConfig("first")
+	setConfig("second")
+	res = doFileRequest(am.SetUserTemplate, http.MethodPut, "name", "third.tmpl", `{{ define "third" }}3{{ end }}`)
+	require.Equal(t, http.StatusCreated, res.Code)
+
+	res = doFileRequest(am.ListUserConfigVersions, http.MethodGet, "", "", "")
+	require.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "versions:\n- 2\n- 3\n", res.Body.String())
+
+	res = doFileRequest(am.GetUserConfigVersion, http.MethodGet, "version", "1", "")
+	require.Equal(t, http.StatusNotFound, res.Code)
+	res = doFileRequest(am.GetUserConfigVersion, http.MethodGet, "version", "invalid", "")
+	require.Equal(t, http.StatusBadRequest, res.Code)
+
+	res = doFileRequest(am.GetUserConfigVersion, http.MethodGet, "version", "3", "")
+	require.Equal(t, http.StatusOK, res.Code)
+	assert.Contains(t, res.Body.String(), "third.tmpl")
+
+	// Rolling back stores the restored config as a new version.
+	res = doFileRequest(am.RollbackUserConfig, http.MethodPost, "version", "2", "")
+	require.Equal(t, http.StatusCreated, res.Code)
+
+	cfg, err := am.store.GetAlertConfig(context.Background(), "user-1")
+	require.NoError(t, err)
+	assert.Contains(t, cfg.RawConfig, "receiver: second")
+	assert.Empty(t, cfg.Templates)
+
+	versions, err := am.store.ListAlertConfigVersions(context.Background(), "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, []int{3, 4}, versions)
+
+	restored, err := am.store.GetAlertConfigVersion(context.Background(), "user-1", 4)
+	require.NoError(t, err)
+	assert.Equal(t, cfg, restored)
+}
+
+func TestMultitenantAlertmanager_SetAlertConfigWithoutVersionsSupport(t *testing.T) {
+	am := &MultitenantAlertmanager{
+		store:  prepareInMemoryAlertStore(),
+		logger: util_log.Logger,
+		limits: &mockAlertManagerLimits{maxConfigVersions: 1},
+	}
+
+	// The config is stored even if the version can't be stored.
+	am.store = &versionlessAlertStore{AlertStore: am.store}
+	cfg := alertspb.AlertConfigDesc{User: "user-1", RawConfig: "config"}
+	require.NoError(t, am.setAlertConfig(context.Background(), util_log.Logger, cfg))
+
+	stored, err := am.store.GetAlertConfig(context.Background(), "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, cfg, stored)
+}
+
+type versionlessAlertStore struct {
+	alertstore.AlertStore
+}
+
+func (s *versionlessAlertStore) ListAlertConfigVersions(context.Context, string) ([]int, error) {
+	return nil, errors.New("versions not supported")
+}
@@ -42,6 +42,7 @@ func TestDistributor_DistributeRequest(t *testing.T) {
 		name                string
 		numAM, numHappyAM   int
 		replicationFactor   int
+		tenantRF            int
 		isRead              bool
 		isDelete            bool
 		expStatusCode       int
@@ -143,6 +144,16 @@ func TestDistributor_DistributeRequest(t *testing.T) {
 			expectedTotalCalls: 3,
 			route:              "/v2/silences",
 			responseBody:       []byte(`[]`),
+		}, {
+			name:               "Read /v1/notifications is sent to 3 AMs",
+			numAM:              5,
+			numHappyAM:         5,
+			replicationFactor:  3,
+			isRead:             true,
+			expStatusCode:      http.StatusOK,
+			expectedTotalCalls: 3,
+			route:              "/v1/notifications",
+			responseBody:       []byte(`[]`),
 		}, {
 			name:               "Write /silences is sent to only 1 AM",
 			numAM:              5,
@@ -235,13 +246,53 @@ func TestDistributor_DistributeRequest(t *testing.T) {
 			expectedTotalCalls:  0,
 			headersNotPreserved: true,
 			route:               "/receivers",
+		}, {
+			name:               "Write /alerts is sent to 1 AM with a tenant replication factor of 1",
+			numAM:              5,
+			numHappyAM:         5,
+			replicationFactor:  3,
+			tenantRF:           1,
+			expStatusCode:      http.StatusOK,
+			expectedTotalCalls: 1,
+			route:              "/alerts",
+		}, {
+			name:               "Write /alerts, 1 healthy Alertmanager out of 2 with a tenant replication factor of 2",
+			numAM:              2,
+			numHappyAM:         1,
+			replicationFactor:  3,
+			tenantRF:           2,
+			expStatusCode:      http.StatusOK,
+			expectedTotalCalls: 2,
+			route:              "/alerts",
+		}, {
+			name:               "Read /v2/alerts is sent to 2 AMs with a tenant replication factor of 2",
+			numAM:              5,
+			numHappyAM:         5,
+			replicationFactor:  3,
+			tenantRF:           2,
+			isRead:             true,
+			expStatusCode:      http.StatusOK,
+			expectedTotalCalls: 2,
+			route:              "/v2/alerts",
+			responseBody:       []byte(`[]`),
+		}, {
+			name:               "Read /v2/alerts is sent to 3 AMs with a tenant replication factor higher than the ring one",
+			numAM:              5,
+			numHappyAM:         5,
+			replicationFactor:  3,
+			tenantRF:           5,
+			isRead:             true,
+			expStatusCode:      http.StatusOK,
+			expectedTotalCalls: 3,
+			route:              "/v2/alerts",
+			responseBody:       []byte(`[]`),
 		},
 	}
 
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
 			route := "/alertmanager/api/v1" + c.route
-			d, ams, cleanup := prepare(t, c.numAM, c.numHappyAM, c.replicationFactor, c.responseBody)
+			d, ams, cleanup := prepare(t, c.numAM, c.numHappyAM, c.replicationFactor, c.tenantRF, c.responseBody)
 			t.Cleanup(cleanup)
 
 			ctx := user.InjectOrgID(context.Background(), "1")
@@ -292,7 +343,7 @@ func TestDistributor_DistributeRequest(t *testing.T) {
 
 }
 
-func prepare(t *testing.T, numAM, numHappyAM, replicationFactor int, responseBody []byte) (*Distributor, []*mockAlertmanager, func()) {
+func prepare(t *testing.T, numAM, numHappyAM, replicationFactor, tenantRF int, responseBody []byte) (*Distributor, []*mockAlertmanager, func()) {
 	ams := []*mockAlertmanager{}
 	for i := 0; i < numHappyAM; i++ {
 		ams = append(ams, newMockAlertmanager(i, true, responseBody))
@@ -344,7 +395,7 @@ func prepare(t *testing.T, numAM, numHappyAM, replicationFactor int, responseBod
 	cfg := &MultitenantAlertmanagerConfig{}
 	flagext.DefaultValues(cfg)
 
-	d, err := NewDistributor(cfg.AlertmanagerClient, cfg.MaxRecvMsgSize, amRing, newMockAlertmanagerClientFactory(amByAddr), util_log.Logger, prometheus.NewRegistry())
+	d, err := NewDistributor(cfg.AlertmanagerClient, cfg.MaxRecvMsgSize, amRing, newMockAlertmanagerClientFactory(amByAddr), &mockAlertManagerLimits{replicationFactor: tenantRF}, util_log.Logger, prometheus.NewRegistry())
 	require.NoError(t, err)
 	require.NoError(t, services.StartAndAwaitRunning(context.Background(), d))
 
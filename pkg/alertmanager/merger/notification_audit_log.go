@@ -0,0 +1,32 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package merger
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/grafana/mimir/pkg/alertmanager/alertspb"
+)
+
+// NotificationAuditLog implements the Merger interface for GET /api/v1/notifications. It returns the
+// union of the notification attempts recorded by each replica, newest first. Attempts are never
+// deduplicated, because each replica only records the notifications it has attempted.
+type NotificationAuditLog struct{}
+
+func (NotificationAuditLog) MergeResponses(in [][]byte) ([]byte, error) {
+	entries := make([]alertspb.NotificationAuditEntry, 0)
+	for _, body := range in {
+		parsed := make([]alertspb.NotificationAuditEntry, 0)
+		if err := json.Unmarshal(body, &parsed); err != nil {
+			return nil, err
+		}
+		entries = append(entries, parsed...)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp.After(entries[j].Timestamp)
+	})
+
+	return json.Marshal(entries)
+}
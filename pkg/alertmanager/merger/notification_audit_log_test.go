@@ -0,0 +1,32 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package merger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationAuditLog(t *testing.T) {
+	in := [][]byte{
+		[]byte(`[` +
+			`{"timestamp":"2021-04-28T17:32:00Z","receiver":"pager","integration":"pagerduty","status":"success","latency_seconds":0.5,"alerts":2,"payload_hash":"0123456789abcdef"},` +
+			`{"timestamp":"2021-04-28T17:30:00Z","receiver":"pager","integration":"pagerduty","status":"failure","error":"timeout","latency_seconds":10,"alerts":2,"payload_hash":"0123456789abcdef"}` +
+			`]`),
+		[]byte(`[]`),
+		[]byte(`[` +
+			`{"timestamp":"2021-04-28T17:31:00Z","receiver":"team","integration":"email","status":"success","latency_seconds":1,"alerts":1,"payload_hash":"fedcba9876543210"}` +
+			`]`),
+	}
+
+	expected := []byte(`[` +
+		`{"timestamp":"2021-04-28T17:32:00Z","receiver":"pager","integration":"pagerduty","status":"success","latency_seconds":0.5,"alerts":2,"payload_hash":"0123456789abcdef"},` +
+		`{"timestamp":"2021-04-28T17:31:00Z","receiver":"team","integration":"email","status":"success","latency_seconds":1,"alerts":1,"payload_hash":"fedcba9876543210"},` +
+		`{"timestamp":"2021-04-28T17:30:00Z","receiver":"pager","integration":"pagerduty","status":"failure","error":"timeout","latency_seconds":10,"alerts":2,"payload_hash":"0123456789abcdef"}` +
+		`]`)
+
+	out, err := NotificationAuditLog{}.MergeResponses(in)
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(out))
+}
@@ -84,6 +84,11 @@ type MultitenantAlertmanagerConfig struct {
 
 	// For the state persister.
 	Persister PersisterConfig `yaml:",inline"`
+
+	NotificationAuditLogPersistEnabled   bool          `yaml:"notification_audit_log_persist_enabled" category:"experimental"`
+	NotificationAuditLogPersistRetention time.Duration `yaml:"notification_audit_log_persist_retention" category:"experimental"`
+
+	SilencesFederation SilencesFederationConfig `yaml:"silences_federation"`
 }
 
 const (
@@ -106,6 +111,9 @@ func (cfg *MultitenantAlertmanagerConfig) RegisterFlags(f *flag.FlagSet, logger
 
 	cfg.AlertmanagerClient.RegisterFlagsWithPrefix("alertmanager.alertmanager-client", f)
 	cfg.Persister.RegisterFlagsWithPrefix("alertmanager", f)
+	f.BoolVar(&cfg.NotificationAuditLogPersistEnabled, "alertmanager.notification-audit-log-persist-enabled", false, "Persist the notification audit log entries of each tenant to object storage, at the same interval of the alertmanager state. The size of the in-memory audit log is configured via -alertmanager.notification-audit-log-size.")
+	f.DurationVar(&cfg.NotificationAuditLogPersistRetention, "alertmanager.notification-audit-log-persist-retention", 7*24*time.Hour, "How long to keep the notification audit log entries persisted to object storage. The entries of the tenants whose Alertmanager configuration has been deleted are deleted regardless of the retention. 0 to keep them forever.")
+	cfg.SilencesFederation.RegisterFlagsWithPrefix("alertmanager.silences-federation", f)
 	cfg.ShardingRing.RegisterFlags(f, logger)
 
 	f.DurationVar(&cfg.PeerTimeout, "alertmanager.peer-timeout", defaultPeerTimeout, "Time to wait between peers to send notifications.")
@@ -125,6 +133,10 @@ func (cfg *MultitenantAlertmanagerConfig) Validate(storageCfg alertstore.Config)
 		return err
 	}
 
+	if err := cfg.SilencesFederation.Validate(); err != nil {
+		return err
+	}
+
 	if !storageCfg.IsFullStateSupported() {
 		return errShardingUnsupportedStorage
 	}
@@ -208,6 +220,25 @@ type Limits interface {
 	// AlertmanagerMaxAlertsSizeBytes returns total max size of alerts that tenant can have active at the same time. 0 = no limit.
 	// Size of the alert is computed from alert labels, annotations and generator URL.
 	AlertmanagerMaxAlertsSizeBytes(tenant string) int
+
+	// AlertmanagerReplicationFactor returns the number of Alertmanager replicas the tenant is sharded to.
+	// 0 = use the sharding ring replication factor.
+	AlertmanagerReplicationFactor(tenant string) int
+
+	// AlertmanagerMaxConfigVersions returns max number of versions of the configuration to keep for the tenant. 0 = versioning disabled.
+	AlertmanagerMaxConfigVersions(tenant string) int
+
+	// AlertmanagerNotificationAuditLogSize returns max number of notification attempts to keep in the tenant's audit log. 0 = audit log disabled.
+	AlertmanagerNotificationAuditLogSize(tenant string) int
+
+	// AlertmanagerMaxTemplateOutputSizeBytes returns max size of the output of a template rendered against sample or notification data. 0 = no limit.
+	AlertmanagerMaxTemplateOutputSizeBytes(tenant string) int
+
+	// AlertmanagerMaxTemplateExecutionTime returns max time to render a template against sample or notification data. 0 = no limit.
+	AlertmanagerMaxTemplateExecutionTime(tenant string) time.Duration
+
+	// AlertmanagerTemplateAllowedFunctions returns the Alertmanager template functions the tenant is allowed to use. Empty = all functions.
+	AlertmanagerTemplateAllowedFunctions(tenant string) []string
 }
 
 // A MultitenantAlertmanager manages Alertmanager instances for multiple
@@ -232,6 +263,10 @@ type MultitenantAlertmanager struct {
 	// accessed by a single goroutine at a time.
 	ringLastState ring.ReplicationSet
 
+	// Last time the persisted notification audit logs have been cleaned up. This variable is not
+	// protected with a mutex because it's always accessed by a single goroutine at a time.
+	lastNotificationAuditLogCleanup time.Time
+
 	// Subservices manager (ring, lifecycler)
 	subservices        *services.Manager
 	subservicesWatcher *services.FailureWatcher
@@ -361,7 +396,7 @@ func createMultitenantAlertmanager(cfg *MultitenantAlertmanagerConfig, fallbackC
 	am.grpcServer = server.NewServer(&handlerForGRPCServer{am: am})
 
 	am.alertmanagerClientsPool = newAlertmanagerClientsPool(client.NewRingServiceDiscovery(am.ring), cfg.AlertmanagerClient, logger, am.registry)
-	am.distributor, err = NewDistributor(cfg.AlertmanagerClient, cfg.MaxRecvMsgSize, am.ring, am.alertmanagerClientsPool, log.With(logger, "component", "AlertmanagerDistributor"), am.registry)
+	am.distributor, err = NewDistributor(cfg.AlertmanagerClient, cfg.MaxRecvMsgSize, am.ring, am.alertmanagerClientsPool, limits, log.With(logger, "component", "AlertmanagerDistributor"), am.registry)
 	if err != nil {
 		return nil, errors.Wrap(err, "create distributor")
 	}
@@ -615,6 +650,7 @@ func (am *MultitenantAlertmanager) loadAndSyncConfigs(ctx context.Context, syncR
 	// Note when cleaning up remote state, remember that the user may not necessarily be configured
 	// in this instance. Therefore, pass the list of _all_ configured users to filter by.
 	am.deleteUnusedRemoteUserState(ctx, allUsers)
+	am.deleteExpiredNotificationAuditLogs(ctx, allUsers)
 
 	return nil
 }
@@ -683,7 +719,7 @@ func (am *MultitenantAlertmanager) loadAlertmanagerConfigs(ctx context.Context)
 }
 
 func (am *MultitenantAlertmanager) isUserOwned(userID string) bool {
-	alertmanagers, err := am.ring.Get(shardByUser(userID), SyncRingOp, nil, nil, nil)
+	alertmanagers, err := newTenantRing(am.ring, am.limits, userID).Get(shardByUser(userID), SyncRingOp, nil, nil, nil)
 	if err != nil {
 		am.ringCheckErrors.Inc()
 		level.Error(am.logger).Log("msg", "failed to load alertmanager configuration", "user", userID, "err", err)
@@ -815,12 +851,17 @@ func (am *MultitenantAlertmanager) setConfig(cfg alertspb.AlertConfigDesc) error
 			return err
 		}
 		am.alertmanagers[cfg.User] = newAM
-	} else if am.cfgs[cfg.User].RawConfig != cfg.RawConfig || hasTemplateChanges {
-		level.Info(am.logger).Log("msg", "updating new per-tenant alertmanager", "user", cfg.User)
-		// If the config changed, apply the new one.
-		err := existing.ApplyConfig(cfg.User, userAmConfig, rawCfg)
-		if err != nil {
-			return fmt.Errorf("unable to apply Alertmanager config for user %v: %v", cfg.User, err)
+	} else {
+		// The tenant's replication factor can be overridden at runtime.
+		existing.SetReplicationFactor(am.tenantReplicationFactor(cfg.User))
+
+		if am.cfgs[cfg.User].RawConfig != cfg.RawConfig || hasTemplateChanges {
+			level.Info(am.logger).Log("msg", "updating new per-tenant alertmanager", "user", cfg.User)
+			// If the config changed, apply the new one.
+			err := existing.ApplyConfig(cfg.User, userAmConfig, rawCfg)
+			if err != nil {
+				return fmt.Errorf("unable to apply Alertmanager config for user %v: %v", cfg.User, err)
+			}
 		}
 	}
 
@@ -828,6 +869,11 @@ func (am *MultitenantAlertmanager) setConfig(cfg alertspb.AlertConfigDesc) error
 	return nil
 }
 
+// tenantReplicationFactor returns the replication factor of the given tenant's Alertmanager state.
+func (am *MultitenantAlertmanager) tenantReplicationFactor(userID string) int {
+	return newTenantRing(am.ring, am.limits, userID).ReplicationFactor()
+}
+
 func (am *MultitenantAlertmanager) getTenantDirectory(userID string) string {
 	return filepath.Join(am.cfg.DataDir, userID)
 }
@@ -849,10 +895,13 @@ func (am *MultitenantAlertmanager) newAlertmanager(userID string, amConfig *amco
 		Retention:         am.cfg.Retention,
 		ExternalURL:       am.cfg.ExternalURL.URL,
 		Replicator:        am,
-		ReplicationFactor: am.cfg.ShardingRing.ReplicationFactor,
+		ReplicationFactor: am.tenantReplicationFactor(userID),
 		Store:             am.store,
 		PersisterConfig:   am.cfg.Persister,
 		Limits:            am.limits,
+
+		PersistNotificationAuditLog: am.cfg.NotificationAuditLogPersistEnabled,
+		SilencesFederation:          am.cfg.SilencesFederation,
 	}, reg)
 	if err != nil {
 		return nil, fmt.Errorf("unable to start Alertmanager for user %v: %v", userID, err)
@@ -868,12 +917,17 @@ func (am *MultitenantAlertmanager) newAlertmanager(userID string, amConfig *amco
 
 // GetPositionForUser returns the position this Alertmanager instance holds in the ring related to its other replicas for an specific user.
 func (am *MultitenantAlertmanager) GetPositionForUser(userID string) int {
+	if am.ring == nil {
+		return 0
+	}
+
 	// If we have a replication factor of 1 or less we don't need to do any work and can immediately return.
-	if am.ring == nil || am.ring.ReplicationFactor() <= 1 {
+	r := newTenantRing(am.ring, am.limits, userID)
+	if r.ReplicationFactor() <= 1 {
 		return 0
 	}
 
-	set, err := am.ring.Get(shardByUser(userID), RingOp, nil, nil, nil)
+	set, err := r.Get(shardByUser(userID), RingOp, nil, nil, nil)
 	if err != nil {
 		level.Error(am.logger).Log("msg", "unable to read the ring while trying to determine the alertmanager position", "err", err)
 		// If we're  unable to determine the position, we don't want a tenant to miss out on the notification - instead,
@@ -989,7 +1043,7 @@ func (am *MultitenantAlertmanager) ReplicateStateForUser(ctx context.Context, us
 	level.Debug(am.logger).Log("msg", "message received for replication", "user", userID, "key", part.Key)
 
 	selfAddress := am.ringLifecycler.GetInstanceAddr()
-	err := ring.DoBatch(ctx, RingOp, am.ring, []uint32{shardByUser(userID)}, func(desc ring.InstanceDesc, _ []int) error {
+	err := ring.DoBatch(ctx, RingOp, newTenantRing(am.ring, am.limits, userID), []uint32{shardByUser(userID)}, func(desc ring.InstanceDesc, _ []int) error {
 		if desc.GetAddr() == selfAddress {
 			return nil
 		}
@@ -1021,7 +1075,7 @@ func (am *MultitenantAlertmanager) ReplicateStateForUser(ctx context.Context, us
 func (am *MultitenantAlertmanager) ReadFullStateForUser(ctx context.Context, userID string) ([]*clusterpb.FullState, error) {
 	// Only get the set of replicas which contain the specified user.
 	key := shardByUser(userID)
-	replicationSet, err := am.ring.Get(key, RingOp, nil, nil, nil)
+	replicationSet, err := newTenantRing(am.ring, am.limits, userID).Get(key, RingOp, nil, nil, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -1144,6 +1198,44 @@ func (am *MultitenantAlertmanager) deleteUnusedRemoteUserState(ctx context.Conte
 	}
 }
 
+// deleteExpiredNotificationAuditLogs deletes the notification audit logs in remote storage of users that are no
+// longer configured, and the entries older than the retention of the users owned by this instance. The audit logs
+// are persisted at the state persist interval, so they're not cleaned up more frequently.
+func (am *MultitenantAlertmanager) deleteExpiredNotificationAuditLogs(ctx context.Context, allUsers []string) {
+	if time.Since(am.lastNotificationAuditLogCleanup) < am.cfg.Persister.Interval {
+		return
+	}
+	am.lastNotificationAuditLogCleanup = time.Now()
+
+	users := make(map[string]struct{}, len(allUsers))
+	for _, userID := range allUsers {
+		users[userID] = struct{}{}
+	}
+
+	usersWithAuditLog, err := am.store.ListUsersWithNotificationAuditLog(ctx)
+	if err != nil {
+		level.Warn(am.logger).Log("msg", "failed to list users with notification audit log", "err", err)
+		return
+	}
+
+	for _, userID := range usersWithAuditLog {
+		// A zero time deletes the whole audit log.
+		var before time.Time
+		if _, ok := users[userID]; ok {
+			if am.cfg.NotificationAuditLogPersistRetention <= 0 || !am.isUserOwned(userID) {
+				continue
+			}
+			before = time.Now().Add(-am.cfg.NotificationAuditLogPersistRetention)
+		}
+
+		if err := am.store.DeleteNotificationAuditLog(ctx, userID, before); err != nil {
+			level.Warn(am.logger).Log("msg", "failed to delete notification audit log for user", "user", userID, "err", err)
+		} else if before.IsZero() {
+			level.Info(am.logger).Log("msg", "deleted notification audit log for user", "user", userID)
+		}
+	}
+}
+
 // deleteUnusedLocalUserState deletes local files for users that we no longer need.
 func (am *MultitenantAlertmanager) deleteUnusedLocalUserState() {
 	userDirs := am.getPerUserDirectories()
@@ -31,6 +31,7 @@ import (
 	"github.com/grafana/dskit/ring"
 	"github.com/grafana/dskit/services"
 	"github.com/grafana/dskit/test"
+	"github.com/oklog/ulid"
 	"github.com/prometheus/alertmanager/cluster/clusterpb"
 	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/alertmanager/pkg/labels"
@@ -215,7 +216,7 @@ func TestMultitenantAlertmanager_loadAndSyncConfigs(t *testing.T) {
 
 	reg := prometheus.NewPedanticRegistry()
 	cfg := mockAlertmanagerConfig(t)
-	am := setupSingleMultitenantAlertmanager(t, cfg, store, nil, log.NewNopLogger(), reg)
+	am := setupSingleMultitenantAlertmanager(t, cfg, store, &mockAlertManagerLimits{}, log.NewNopLogger(), reg)
 
 	// Ensure the configs are synced correctly
 	err := am.loadAndSyncConfigs(context.Background(), reasonPeriodic)
@@ -584,7 +585,7 @@ func TestMultitenantAlertmanager_migrateStateFilesToPerTenantDirectories(t *test
 
 	reg := prometheus.NewPedanticRegistry()
 	cfg := mockAlertmanagerConfig(t)
-	am, err := createMultitenantAlertmanager(cfg, nil, store, nil, nil, log.NewNopLogger(), reg)
+	am, err := createMultitenantAlertmanager(cfg, nil, store, nil, &mockAlertManagerLimits{}, log.NewNopLogger(), reg)
 	require.NoError(t, err)
 
 	createFile(t, filepath.Join(cfg.DataDir, "nflog:"+user1))
@@ -638,7 +639,7 @@ func TestMultitenantAlertmanager_deleteUnusedLocalUserState(t *testing.T) {
 
 	reg := prometheus.NewPedanticRegistry()
 	cfg := mockAlertmanagerConfig(t)
-	am := setupSingleMultitenantAlertmanager(t, cfg, store, nil, log.NewNopLogger(), reg)
+	am := setupSingleMultitenantAlertmanager(t, cfg, store, &mockAlertManagerLimits{}, log.NewNopLogger(), reg)
 
 	createFile(t, filepath.Join(cfg.DataDir, user1, notificationLogSnapshot))
 	createFile(t, filepath.Join(cfg.DataDir, user1, silencesSnapshot))
@@ -685,7 +686,7 @@ func TestMultitenantAlertmanager_zoneAwareSharding(t *testing.T) {
 		cfg.ShardingRing.ZoneAwarenessEnabled = true
 		cfg.ShardingRing.InstanceZone = zone
 
-		am, err := createMultitenantAlertmanager(cfg, nil, alertStore, ringStore, nil, log.NewLogfmtLogger(os.Stdout), reg)
+		am, err := createMultitenantAlertmanager(cfg, nil, alertStore, ringStore, &mockAlertManagerLimits{}, log.NewLogfmtLogger(os.Stdout), reg)
 		require.NoError(t, err)
 		t.Cleanup(func() {
 			require.NoError(t, services.StopAndAwaitTerminated(ctx, am))
@@ -757,7 +758,7 @@ func TestMultitenantAlertmanager_deleteUnusedRemoteUserState(t *testing.T) {
 		// Increase state write interval so that state gets written sooner, making test faster.
 		cfg.Persister.Interval = 500 * time.Millisecond
 
-		am, err := createMultitenantAlertmanager(cfg, nil, alertStore, ringStore, nil, log.NewLogfmtLogger(os.Stdout), reg)
+		am, err := createMultitenantAlertmanager(cfg, nil, alertStore, ringStore, &mockAlertManagerLimits{}, log.NewLogfmtLogger(os.Stdout), reg)
 		require.NoError(t, err)
 		t.Cleanup(func() {
 			require.NoError(t, services.StopAndAwaitTerminated(ctx, am))
@@ -827,6 +828,67 @@ func TestMultitenantAlertmanager_deleteUnusedRemoteUserState(t *testing.T) {
 	}
 }
 
+func TestMultitenantAlertmanager_deleteExpiredNotificationAuditLogs(t *testing.T) {
+	ctx := context.Background()
+
+	const (
+		user1 = "user1"
+		user2 = "user2"
+	)
+
+	bkt := objstore.NewInMemBucket()
+	alertStore := bucketclient.NewBucketAlertStore(bkt, nil, log.NewNopLogger())
+	ringStore, closer := consul.NewInMemoryClient(ring.GetCodec(), log.NewNopLogger(), nil)
+	t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+
+	cfg := mockAlertmanagerConfig(t)
+	cfg.ShardingRing.ReplicationFactor = 1
+	cfg.Persister.Interval = time.Millisecond
+	cfg.NotificationAuditLogPersistRetention = time.Hour
+
+	am, err := createMultitenantAlertmanager(cfg, nil, alertStore, ringStore, &mockAlertManagerLimits{}, log.NewNopLogger(), prometheus.NewPedanticRegistry())
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(ctx, am))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(ctx, am))
+	})
+
+	for _, user := range []string{user1, user2} {
+		require.NoError(t, alertStore.SetAlertConfig(ctx, alertspb.AlertConfigDesc{User: user, RawConfig: simpleConfigOne}))
+	}
+
+	// Store an audit log batch older than the retention and a recent one for each user.
+	auditLogObject := func(user string, storedAt time.Time) string {
+		return fmt.Sprintf("alertmanager-audit/%s/%s.json", user, ulid.MustNew(ulid.Timestamp(storedAt), nil).String())
+	}
+	expired := map[string]string{user1: auditLogObject(user1, time.Now().Add(-2*time.Hour)), user2: auditLogObject(user2, time.Now().Add(-2*time.Hour))}
+	recent := map[string]string{user1: auditLogObject(user1, time.Now()), user2: auditLogObject(user2, time.Now())}
+	for _, name := range []string{expired[user1], expired[user2], recent[user1], recent[user2]} {
+		require.NoError(t, bkt.Upload(ctx, name, strings.NewReader("{}")))
+	}
+
+	// The batches older than the retention are deleted.
+	require.NoError(t, am.loadAndSyncConfigs(ctx, reasonPeriodic))
+	assert.NotContains(t, bkt.Objects(), expired[user1])
+	assert.NotContains(t, bkt.Objects(), expired[user2])
+	assert.Contains(t, bkt.Objects(), recent[user1])
+	assert.Contains(t, bkt.Objects(), recent[user2])
+
+	// The whole audit log of a user whose config has been deleted is deleted, even if left behind.
+	require.NoError(t, alertStore.DeleteAlertConfig(ctx, user1))
+	assert.NotContains(t, bkt.Objects(), recent[user1])
+	require.NoError(t, bkt.Upload(ctx, recent[user1], strings.NewReader("{}")))
+
+	time.Sleep(cfg.Persister.Interval)
+	require.NoError(t, am.loadAndSyncConfigs(ctx, reasonPeriodic))
+	assert.NotContains(t, bkt.Objects(), recent[user1])
+	assert.Contains(t, bkt.Objects(), recent[user2])
+
+	users, err := alertStore.ListUsersWithNotificationAuditLog(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{user2}, users)
+}
+
 func createFile(t *testing.T, path string) string {
 	dir := filepath.Dir(path)
 	require.NoError(t, os.MkdirAll(dir, 0777))
@@ -850,7 +912,7 @@ func TestMultitenantAlertmanager_ServeHTTP(t *testing.T) {
 
 	// Create the Multitenant Alertmanager.
 	reg := prometheus.NewPedanticRegistry()
-	am := setupSingleMultitenantAlertmanager(t, amConfig, store, nil, log.NewNopLogger(), reg)
+	am := setupSingleMultitenantAlertmanager(t, amConfig, store, &mockAlertManagerLimits{}, log.NewNopLogger(), reg)
 
 	// Request when no user configuration is present.
 	req := httptest.NewRequest("GET", externalURL.String(), nil)
@@ -959,7 +1021,7 @@ receivers:
 	amConfig.ExternalURL = externalURL
 
 	// Create the Multitenant Alertmanager.
-	am := setupSingleMultitenantAlertmanager(t, amConfig, store, nil, log.NewNopLogger(), nil)
+	am := setupSingleMultitenantAlertmanager(t, amConfig, store, &mockAlertManagerLimits{}, log.NewNopLogger(), nil)
 	require.NoError(t, err)
 	am.fallbackConfig = fallbackCfg
 
@@ -1030,7 +1092,7 @@ receivers:
 	amConfig.ExternalURL = externalURL
 
 	// Create the Multitenant Alertmanager.
-	am := setupSingleMultitenantAlertmanager(t, amConfig, store, nil, log.NewNopLogger(), nil)
+	am := setupSingleMultitenantAlertmanager(t, amConfig, store, &mockAlertManagerLimits{}, log.NewNopLogger(), nil)
 	am.fallbackConfig = fallbackCfg
 
 	// Upload config for the user.
@@ -1130,7 +1192,7 @@ func TestMultitenantAlertmanager_InitialSync(t *testing.T) {
 				}))
 			}
 
-			am, err := createMultitenantAlertmanager(amConfig, nil, alertStore, ringStore, nil, log.NewNopLogger(), nil)
+			am, err := createMultitenantAlertmanager(amConfig, nil, alertStore, ringStore, &mockAlertManagerLimits{}, log.NewNopLogger(), nil)
 			require.NoError(t, err)
 			defer services.StopAndAwaitTerminated(ctx, am) //nolint:errcheck
 
@@ -1147,6 +1209,7 @@ func TestMultitenantAlertmanager_InitialSync(t *testing.T) {
 				require.Equal(t, ring.JOINING.String(), am.ringLifecycler.GetState().String())
 			})
 			bkt.MockIter("alertmanager/", nil, nil)
+			bkt.MockIter("alertmanager-audit/", nil, nil)
 
 			// Once successfully started, the instance should be ACTIVE in the ring.
 			require.NoError(t, services.StartAndAwaitRunning(ctx, am))
@@ -1165,6 +1228,7 @@ func TestMultitenantAlertmanager_PerTenantSharding(t *testing.T) {
 		name              string
 		tenantShardSize   int
 		replicationFactor int
+		tenantRF          int
 		instances         int
 		configs           int
 		expectedTenants   int
@@ -1197,6 +1261,30 @@ func TestMultitenantAlertmanager_PerTenantSharding(t *testing.T) {
 			configs:           10,
 			expectedTenants:   30, // configs * replication factor
 		},
+		{
+			name:              "5 instances, RF = 3, tenant RF = 1",
+			instances:         5,
+			replicationFactor: 3,
+			tenantRF:          1,
+			configs:           10,
+			expectedTenants:   10, // configs * tenant replication factor
+		},
+		{
+			name:              "5 instances, RF = 3, tenant RF = 2",
+			instances:         5,
+			replicationFactor: 3,
+			tenantRF:          2,
+			configs:           10,
+			expectedTenants:   20, // configs * tenant replication factor
+		},
+		{
+			name:              "5 instances, RF = 3, tenant RF = 5",
+			instances:         5,
+			replicationFactor: 3,
+			tenantRF:          5,
+			configs:           10,
+			expectedTenants:   30, // configs * replication factor, because the tenant one is capped
+		},
 	}
 
 	for _, tt := range tc {
@@ -1235,7 +1323,7 @@ func TestMultitenantAlertmanager_PerTenantSharding(t *testing.T) {
 				amConfig.ShardingRing.RingCheckPeriod = time.Hour
 
 				reg := prometheus.NewPedanticRegistry()
-				am, err := createMultitenantAlertmanager(amConfig, nil, alertStore, ringStore, nil, log.NewNopLogger(), reg)
+				am, err := createMultitenantAlertmanager(amConfig, nil, alertStore, ringStore, &mockAlertManagerLimits{replicationFactor: tt.tenantRF}, log.NewNopLogger(), reg)
 				require.NoError(t, err)
 				defer services.StopAndAwaitTerminated(ctx, am) //nolint:errcheck
 
@@ -1390,7 +1478,7 @@ func TestMultitenantAlertmanager_SyncOnRingTopologyChanges(t *testing.T) {
 			alertStore := prepareInMemoryAlertStore()
 
 			reg := prometheus.NewPedanticRegistry()
-			am, err := createMultitenantAlertmanager(amConfig, nil, alertStore, ringStore, nil, log.NewNopLogger(), reg)
+			am, err := createMultitenantAlertmanager(amConfig, nil, alertStore, ringStore, &mockAlertManagerLimits{}, log.NewNopLogger(), reg)
 			require.NoError(t, err)
 
 			require.NoError(t, ringStore.CAS(ctx, RingKey, func(in interface{}) (interface{}, bool, error) {
@@ -1441,7 +1529,7 @@ func TestMultitenantAlertmanager_RingLifecyclerShouldAutoForgetUnhealthyInstance
 
 	alertStore := prepareInMemoryAlertStore()
 
-	am, err := createMultitenantAlertmanager(amConfig, nil, alertStore, ringStore, nil, log.NewNopLogger(), nil)
+	am, err := createMultitenantAlertmanager(amConfig, nil, alertStore, ringStore, &mockAlertManagerLimits{}, log.NewNopLogger(), nil)
 	require.NoError(t, err)
 	require.NoError(t, services.StartAndAwaitRunning(ctx, am))
 	defer services.StopAndAwaitTerminated(ctx, am) //nolint:errcheck
@@ -1476,9 +1564,10 @@ func TestMultitenantAlertmanager_InitialSyncFailure(t *testing.T) {
 	bkt := &bucket.ClientMock{}
 	bkt.MockIter("alerts/", nil, errors.New("failed to list alerts"))
 	bkt.MockIter("alertmanager/", nil, nil)
+	bkt.MockIter("alertmanager-audit/", nil, nil)
 	store := bucketclient.NewBucketAlertStore(bkt, nil, log.NewNopLogger())
 
-	am, err := createMultitenantAlertmanager(amConfig, nil, store, ringStore, nil, log.NewNopLogger(), nil)
+	am, err := createMultitenantAlertmanager(amConfig, nil, store, ringStore, &mockAlertManagerLimits{}, log.NewNopLogger(), nil)
 	require.NoError(t, err)
 	defer services.StopAndAwaitTerminated(ctx, am) //nolint:errcheck
 
@@ -1521,7 +1610,7 @@ func TestAlertmanager_ReplicasPosition(t *testing.T) {
 		amConfig.ShardingRing.RingCheckPeriod = time.Hour
 
 		reg := prometheus.NewPedanticRegistry()
-		am, err := createMultitenantAlertmanager(amConfig, nil, mockStore, ringStore, nil, log.NewNopLogger(), reg)
+		am, err := createMultitenantAlertmanager(amConfig, nil, mockStore, ringStore, &mockAlertManagerLimits{}, log.NewNopLogger(), reg)
 		require.NoError(t, err)
 		defer services.StopAndAwaitTerminated(ctx, am) //nolint:errcheck
 
@@ -1627,7 +1716,7 @@ func TestAlertmanager_StateReplication(t *testing.T) {
 				amConfig.ShardingRing.RingCheckPeriod = time.Hour
 
 				reg := prometheus.NewPedanticRegistry()
-				am, err := createMultitenantAlertmanager(amConfig, nil, mockStore, ringStore, nil, log.NewNopLogger(), reg)
+				am, err := createMultitenantAlertmanager(amConfig, nil, mockStore, ringStore, &mockAlertManagerLimits{}, log.NewNopLogger(), reg)
 				require.NoError(t, err)
 				defer services.StopAndAwaitTerminated(ctx, am) //nolint:errcheck
 
@@ -1806,7 +1895,7 @@ func TestAlertmanager_StateReplication_InitialSyncFromPeers(t *testing.T) {
 				amConfig.ShardingRing.RingCheckPeriod = time.Hour
 
 				reg := prometheus.NewPedanticRegistry()
-				am, err := createMultitenantAlertmanager(amConfig, nil, mockStore, ringStore, nil, log.NewNopLogger(), reg)
+				am, err := createMultitenantAlertmanager(amConfig, nil, mockStore, ringStore, &mockAlertManagerLimits{}, log.NewNopLogger(), reg)
 				require.NoError(t, err)
 
 				clientPool.setServer(amConfig.ShardingRing.InstanceAddr+":0", am)
@@ -2106,6 +2195,12 @@ type mockAlertManagerLimits struct {
 	maxDispatcherAggregationGroups int
 	maxAlertsCount                 int
 	maxAlertsSizeBytes             int
+	replicationFactor              int
+	maxConfigVersions              int
+	notificationAuditLogSize       int
+	maxTemplateOutputSize          int
+	maxTemplateExecutionTime       time.Duration
+	templateAllowedFunctions       []string
 }
 
 func (m *mockAlertManagerLimits) AlertmanagerMaxConfigSize(tenant string) int {
@@ -2147,3 +2242,27 @@ func (m *mockAlertManagerLimits) AlertmanagerMaxAlertsCount(_ string) int {
 func (m *mockAlertManagerLimits) AlertmanagerMaxAlertsSizeBytes(_ string) int {
 	return m.maxAlertsSizeBytes
 }
+
+func (m *mockAlertManagerLimits) AlertmanagerReplicationFactor(_ string) int {
+	return m.replicationFactor
+}
+
+func (m *mockAlertManagerLimits) AlertmanagerMaxConfigVersions(_ string) int {
+	return m.maxConfigVersions
+}
+
+func (m *mockAlertManagerLimits) AlertmanagerNotificationAuditLogSize(_ string) int {
+	return m.notificationAuditLogSize
+}
+
+func (m *mockAlertManagerLimits) AlertmanagerMaxTemplateOutputSizeBytes(_ string) int {
+	return m.maxTemplateOutputSize
+}
+
+func (m *mockAlertManagerLimits) AlertmanagerMaxTemplateExecutionTime(_ string) time.Duration {
+	return m.maxTemplateExecutionTime
+}
+
+func (m *mockAlertManagerLimits) AlertmanagerTemplateAllowedFunctions(_ string) []string {
+	return m.templateAllowedFunctions
+}
@@ -0,0 +1,249 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package alertmanager
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/services"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/mimir/pkg/alertmanager/alertspb"
+	"github.com/grafana/mimir/pkg/alertmanager/alertstore"
+)
+
+const (
+	// The length of the hex-encoded payload hash stored in the notification audit log.
+	payloadHashLength = 16
+)
+
+// notificationAuditLog keeps the most recent notification attempts of a tenant in memory, up to the
+// tenant's configured size. If persisting is enabled, the recorded attempts are also periodically
+// written to the object storage.
+type notificationAuditLog struct {
+	services.Service
+
+	userID string
+	limits Limits
+	store  alertstore.AlertStore
+	logger log.Logger
+
+	mtx     sync.Mutex
+	entries []alertspb.NotificationAuditEntry
+	// Entries recorded since the last time the audit log has been persisted. Only used if persisting is enabled.
+	pending []alertspb.NotificationAuditEntry
+
+	timeout time.Duration
+}
+
+// newNotificationAuditLog creates a new notificationAuditLog. Recorded attempts are persisted to the store
+// every persistInterval, unless the store is nil.
+func newNotificationAuditLog(userID string, limits Limits, store alertstore.AlertStore, persistInterval time.Duration, logger log.Logger) *notificationAuditLog {
+	l := &notificationAuditLog{
+		userID:  userID,
+		limits:  limits,
+		store:   store,
+		logger:  logger,
+		timeout: defaultPersistTimeout,
+	}
+
+	if store != nil {
+		l.Service = services.NewTimerService(persistInterval, nil, l.iteration, l.stopping)
+	} else {
+		l.Service = services.NewIdleService(nil, nil)
+	}
+
+	return l
+}
+
+// size returns the maximum number of entries to keep. 0 means the audit log is disabled.
+func (l *notificationAuditLog) size() int {
+	if l.limits == nil {
+		return 0
+	}
+	return l.limits.AlertmanagerNotificationAuditLogSize(l.userID)
+}
+
+func (l *notificationAuditLog) record(entry alertspb.NotificationAuditEntry) {
+	size := l.size()
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if size <= 0 {
+		l.entries = nil
+		l.pending = nil
+		return
+	}
+
+	l.entries = appendBounded(l.entries, entry, size)
+	if l.store != nil {
+		l.pending = appendBounded(l.pending, entry, size)
+	}
+}
+
+// clear removes all the entries, releasing the memory when the audit log has been disabled.
+func (l *notificationAuditLog) clear() {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	l.entries = nil
+	l.pending = nil
+}
+
+// appendBounded appends the entry to the entries, removing the oldest entries exceeding the size.
+func appendBounded(entries []alertspb.NotificationAuditEntry, entry alertspb.NotificationAuditEntry, size int) []alertspb.NotificationAuditEntry {
+	if len(entries) >= size {
+		n := copy(entries, entries[len(entries)-size+1:])
+		entries = entries[:n]
+	}
+	return append(entries, entry)
+}
+
+// query returns the recorded entries matching the filter, newest first.
+func (l *notificationAuditLog) query(filter func(alertspb.NotificationAuditEntry) bool) []alertspb.NotificationAuditEntry {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	result := make([]alertspb.NotificationAuditEntry, 0, len(l.entries))
+	for i := len(l.entries) - 1; i >= 0; i-- {
+		if filter(l.entries[i]) {
+			result = append(result, l.entries[i])
+		}
+	}
+	return result
+}
+
+func (l *notificationAuditLog) iteration(ctx context.Context) error {
+	if err := l.persist(ctx); err != nil {
+		level.Error(l.logger).Log("msg", "failed to persist notification audit log", "user", l.userID, "err", err)
+	}
+	return nil
+}
+
+func (l *notificationAuditLog) stopping(_ error) error {
+	// Persist the entries recorded since the last iteration, to not lose them on shutdown.
+	if err := l.persist(context.Background()); err != nil {
+		level.Error(l.logger).Log("msg", "failed to persist notification audit log", "user", l.userID, "err", err)
+	}
+	return nil
+}
+
+func (l *notificationAuditLog) persist(ctx context.Context) error {
+	l.mtx.Lock()
+	pending := l.pending
+	l.pending = nil
+	l.mtx.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, l.timeout)
+	defer cancel()
+
+	return l.store.AppendNotificationAuditLog(ctx, l.userID, pending)
+}
+
+// ServeHTTP serves the recorded entries, newest first. Entries can be filtered by receiver,
+// integration, status and by the minimum timestamp (RFC3339 formatted "since" parameter).
+func (l *notificationAuditLog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	receiver := r.FormValue("receiver")
+	integration := r.FormValue("integration")
+	status := r.FormValue("status")
+
+	var since time.Time
+	if v := r.FormValue("since"); v != "" {
+		var err error
+		if since, err = time.Parse(time.RFC3339, v); err != nil {
+			http.Error(w, "invalid since parameter: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	entries := l.query(func(e alertspb.NotificationAuditEntry) bool {
+		return (receiver == "" || e.Receiver == receiver) &&
+			(integration == "" || e.Integration == integration) &&
+			(status == "" || e.Status == status) &&
+			!e.Timestamp.Before(since)
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(entries); err != nil {
+		level.Error(l.logger).Log("msg", "failed to write notification audit log response", "user", l.userID, "err", err)
+	}
+}
+
+// auditedNotifier records each notification attempt of the upstream notifier in the notification audit log.
+type auditedNotifier struct {
+	upstream    notify.Notifier
+	integration string
+	auditLog    *notificationAuditLog
+}
+
+func newAuditedNotifier(upstream notify.Notifier, integration string, auditLog *notificationAuditLog) *auditedNotifier {
+	return &auditedNotifier{
+		upstream:    upstream,
+		integration: integration,
+		auditLog:    auditLog,
+	}
+}
+
+func (n *auditedNotifier) Notify(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+	start := time.Now()
+	retry, err := n.upstream.Notify(ctx, alerts...)
+
+	// Avoid computing the entry if the audit log is disabled.
+	if n.auditLog.size() <= 0 {
+		n.auditLog.clear()
+		return retry, err
+	}
+
+	receiver, _ := notify.ReceiverName(ctx)
+	groupKey, _ := notify.GroupKey(ctx)
+
+	entry := alertspb.NotificationAuditEntry{
+		Timestamp:      start,
+		Receiver:       receiver,
+		Integration:    n.integration,
+		Status:         alertspb.NotificationStatusSuccess,
+		LatencySeconds: time.Since(start).Seconds(),
+		Alerts:         len(alerts),
+		PayloadHash:    notificationPayloadHash(groupKey, alerts),
+	}
+	if err != nil {
+		entry.Status = alertspb.NotificationStatusFailure
+		entry.Error = err.Error()
+	}
+
+	n.auditLog.record(entry)
+	return retry, err
+}
+
+// notificationPayloadHash returns a truncated hash of the notified alerts, including their status.
+func notificationPayloadHash(groupKey string, alerts []*types.Alert) string {
+	keys := make([]string, 0, len(alerts))
+	for _, a := range alerts {
+		keys = append(keys, a.Fingerprint().String()+":"+string(a.Status()))
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	_, _ = h.Write([]byte(groupKey))
+	for _, k := range keys {
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(k))
+	}
+
+	return hex.EncodeToString(h.Sum(nil))[:payloadHashLength]
+}
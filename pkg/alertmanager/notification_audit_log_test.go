@@ -0,0 +1,170 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package alertmanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/services"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/objstore"
+
+	"github.com/grafana/mimir/pkg/alertmanager/alertspb"
+	"github.com/grafana/mimir/pkg/alertmanager/alertstore/bucketclient"
+)
+
+type failingNotifier struct{}
+
+func (n *failingNotifier) Notify(context.Context, ...*types.Alert) (bool, error) {
+	return true, errors.New("connection refused")
+}
+
+func TestAuditedNotifier(t *testing.T) {
+	limits := &mockAlertManagerLimits{notificationAuditLogSize: 3}
+	auditLog := newNotificationAuditLog("user-1", limits, nil, time.Minute, log.NewNopLogger())
+
+	ctx := notify.WithReceiverName(context.Background(), "pager")
+	ctx = notify.WithGroupKey(ctx, "group")
+	alert := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "Down"}}}
+
+	webhook := newAuditedNotifier(&mockNotifier{}, "webhook", auditLog)
+	pagerduty := newAuditedNotifier(&failingNotifier{}, "pagerduty", auditLog)
+
+	_, err := webhook.Notify(ctx, alert)
+	require.NoError(t, err)
+	retry, err := pagerduty.Notify(ctx, alert)
+	require.Error(t, err)
+	assert.True(t, retry)
+
+	entries := auditLog.query(func(alertspb.NotificationAuditEntry) bool { return true })
+	require.Len(t, entries, 2)
+
+	// Newest first.
+	assert.Equal(t, "pager", entries[0].Receiver)
+	assert.Equal(t, "pagerduty", entries[0].Integration)
+	assert.Equal(t, alertspb.NotificationStatusFailure, entries[0].Status)
+	assert.Equal(t, "connection refused", entries[0].Error)
+	assert.Equal(t, 1, entries[0].Alerts)
+	assert.Len(t, entries[0].PayloadHash, payloadHashLength)
+
+	assert.Equal(t, "webhook", entries[1].Integration)
+	assert.Equal(t, alertspb.NotificationStatusSuccess, entries[1].Status)
+	assert.Empty(t, entries[1].Error)
+
+	// The same alerts have the same hash, regardless of the integration.
+	assert.Equal(t, entries[0].PayloadHash, entries[1].PayloadHash)
+
+	// Only the most recent entries are kept.
+	for i := 0; i < 3; i++ {
+		_, _ = webhook.Notify(ctx, alert)
+	}
+	entries = auditLog.query(func(alertspb.NotificationAuditEntry) bool { return true })
+	require.Len(t, entries, 3)
+	for _, e := range entries {
+		assert.Equal(t, "webhook", e.Integration)
+	}
+
+	// Nothing is recorded when the audit log is disabled.
+	limits.notificationAuditLogSize = 0
+	_, _ = pagerduty.Notify(ctx, alert)
+	_, _ = pagerduty.Notify(ctx, alert)
+	limits.notificationAuditLogSize = 3
+	_, _ = pagerduty.Notify(ctx, alert)
+
+	entries = auditLog.query(func(alertspb.NotificationAuditEntry) bool { return true })
+	require.Len(t, entries, 1)
+}
+
+func TestNotificationAuditLog_ServeHTTP(t *testing.T) {
+	auditLog := newNotificationAuditLog("user-1", &mockAlertManagerLimits{notificationAuditLogSize: 10}, nil, time.Minute, log.NewNopLogger())
+
+	now := time.Now().UTC().Truncate(time.Second)
+	auditLog.record(alertspb.NotificationAuditEntry{Timestamp: now.Add(-2 * time.Hour), Receiver: "pager", Integration: "pagerduty", Status: alertspb.NotificationStatusSuccess})
+	auditLog.record(alertspb.NotificationAuditEntry{Timestamp: now.Add(-time.Hour), Receiver: "pager", Integration: "pagerduty", Status: alertspb.NotificationStatusFailure})
+	auditLog.record(alertspb.NotificationAuditEntry{Timestamp: now, Receiver: "team", Integration: "email", Status: alertspb.NotificationStatusSuccess})
+
+	tests := map[string]struct {
+		query         string
+		expectedTimes []time.Time
+		expectedCode  int
+	}{
+		"no filter": {
+			expectedTimes: []time.Time{now, now.Add(-time.Hour), now.Add(-2 * time.Hour)},
+		},
+		"filter by receiver and status": {
+			query:         "receiver=pager&status=failure",
+			expectedTimes: []time.Time{now.Add(-time.Hour)},
+		},
+		"filter by integration": {
+			query:         "integration=email",
+			expectedTimes: []time.Time{now},
+		},
+		"filter by time": {
+			query:         "since=" + now.Add(-time.Hour).Format(time.RFC3339),
+			expectedTimes: []time.Time{now, now.Add(-time.Hour)},
+		},
+		"invalid time": {
+			query:        "since=yesterday",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			auditLog.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/notifications?"+tc.query, nil))
+
+			if tc.expectedCode != 0 {
+				require.Equal(t, tc.expectedCode, rec.Code)
+				return
+			}
+			require.Equal(t, http.StatusOK, rec.Code)
+
+			var entries []alertspb.NotificationAuditEntry
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &entries))
+
+			times := make([]time.Time, 0, len(entries))
+			for _, e := range entries {
+				times = append(times, e.Timestamp)
+			}
+			assert.Equal(t, tc.expectedTimes, times)
+		})
+	}
+}
+
+func TestNotificationAuditLog_Persist(t *testing.T) {
+	bucket := objstore.NewInMemBucket()
+	store := bucketclient.NewBucketAlertStore(bucket, nil, log.NewNopLogger())
+
+	auditLog := newNotificationAuditLog("user-1", &mockAlertManagerLimits{notificationAuditLogSize: 10}, store, time.Hour, log.NewNopLogger())
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), auditLog))
+
+	auditLog.record(alertspb.NotificationAuditEntry{Receiver: "pager", Status: alertspb.NotificationStatusSuccess})
+	auditLog.record(alertspb.NotificationAuditEntry{Receiver: "team", Status: alertspb.NotificationStatusFailure})
+
+	// The pending entries are persisted when stopping.
+	require.NoError(t, services.StopAndAwaitTerminated(context.Background(), auditLog))
+
+	objects := bucket.Objects()
+	require.Len(t, objects, 1)
+	for name, content := range objects {
+		assert.True(t, strings.HasPrefix(name, "alertmanager-audit/user-1/"))
+		assert.Equal(t, 2, strings.Count(string(content), "\n"))
+	}
+
+	// Entries are persisted only once.
+	require.NoError(t, auditLog.persist(context.Background()))
+	assert.Len(t, bucket.Objects(), 1)
+}
@@ -0,0 +1,65 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package alertmanager
+
+import (
+	"context"
+	tmpltext "text/template"
+
+	"github.com/go-kit/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// sandboxedNotifier renders the templates of the notifier against the notification data in the
+// tenant's template sandbox before sending the notification, and doesn't send it if the rendering
+// exceeds any of the sandbox limits.
+type sandboxedNotifier struct {
+	upstream notify.Notifier
+	user     string
+	limits   Limits
+	logger   log.Logger
+
+	// The Alertmanager template, used to build the notification data.
+	tmpl *template.Template
+	// The tenant's templates, parsed by the sandbox.
+	sandboxTmpl *tmpltext.Template
+	// The templates inlined in the notifier config.
+	inlined []string
+}
+
+func newSandboxedNotifier(upstream notify.Notifier, user string, limits Limits, tmpl *template.Template, sandboxTmpl *tmpltext.Template, integrationCfg interface{}, logger log.Logger) *sandboxedNotifier {
+	return &sandboxedNotifier{
+		upstream:    upstream,
+		user:        user,
+		limits:      limits,
+		logger:      logger,
+		tmpl:        tmpl,
+		sandboxTmpl: sandboxTmpl,
+		inlined:     collectInlinedTemplates(integrationCfg),
+	}
+}
+
+func (n *sandboxedNotifier) Notify(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+	// The limits are read for each notification, so that changes to the tenant's limits are honored
+	// without having to re-apply the config. The sandbox isn't shared between concurrent notifications.
+	sandbox := newTemplateSandbox(n.limits, n.user)
+	if !sandbox.enabled() || len(n.inlined) == 0 {
+		return n.upstream.Notify(ctx, alerts...)
+	}
+
+	tmpl, err := sandbox.withFuncs(n.sandboxTmpl)
+	if err != nil {
+		return false, err
+	}
+
+	data := notify.GetTemplateData(ctx, n.tmpl, alerts, n.logger)
+	if err := sandbox.renderInlined(tmpl, n.inlined, data); err != nil {
+		// Don't retry this notification later, the rendering would fail again.
+		return false, errors.Wrap(err, "failed to notify due to template sandbox limits")
+	}
+
+	return n.upstream.Notify(ctx, alerts...)
+}
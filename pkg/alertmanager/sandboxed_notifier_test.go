@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package alertmanager
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/alertmanager/alertspb"
+)
+
+func TestSandboxedNotifier(t *testing.T) {
+	const (
+		userID = "user-1"
+
+		// The template renders the description of each alert, which is small in the sample data
+		// used to validate the config, but can be of any size in the actual alerts.
+		customTemplate = `{{ define "custom.title" }}{{ range .Alerts }}{{ .Annotations.description }}{{ end }}{{ end }}`
+
+		userConfig = `
+route:
+  receiver: slack
+receivers:
+  - name: slack
+    slack_configs:
+      - api_url: http://localhost/hook
+        channel: '#alerts'
+        title: '{{ template "custom.title" . }}'
+templates:
+  - "custom.tmpl"
+`
+	)
+
+	limits := &mockAlertManagerLimits{maxTemplateOutputSize: 1024}
+
+	// The config is accepted, because the template output is within the limit with the sample data.
+	require.NoError(t, validateUserConfig(log.NewNopLogger(), alertspb.ToProto(userConfig, map[string]string{"custom.tmpl": customTemplate}, userID), limits, userID))
+
+	templateFile := filepath.Join(t.TempDir(), "custom.tmpl")
+	require.NoError(t, os.WriteFile(templateFile, []byte(customTemplate), 0600))
+
+	tmpl, err := template.FromGlobs(templateFile)
+	require.NoError(t, err)
+	tmpl.ExternalURL = &url.URL{Scheme: "http", Host: "localhost"}
+	sandboxTmpl, err := newTemplateSandbox(limits, userID).parse(true, []string{templateFile})
+	require.NoError(t, err)
+
+	ctx := notify.WithReceiverName(context.Background(), "slack")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{model.AlertNameLabel: "test"})
+
+	newAlert := func(description string) *types.Alert {
+		return &types.Alert{Alert: model.Alert{
+			Labels:      model.LabelSet{model.AlertNameLabel: "test"},
+			Annotations: model.LabelSet{"description": model.LabelValue(description)},
+		}}
+	}
+
+	upstream := &countingNotifier{}
+	notifier := newSandboxedNotifier(upstream, userID, limits, tmpl, sandboxTmpl, &config.SlackConfig{Title: `{{ template "custom.title" . }}`}, log.NewNopLogger())
+
+	retry, err := notifier.Notify(ctx, newAlert("short description"))
+	require.NoError(t, err)
+	assert.False(t, retry)
+	assert.Equal(t, 1, upstream.calls)
+
+	// The rendering of the notification exceeds the output size limit, so it's not sent.
+	retry, err = notifier.Notify(ctx, newAlert(strings.Repeat("x", 600)), newAlert(strings.Repeat("y", 600)))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `exceeded the output size limit of 1024 bytes`)
+	assert.False(t, retry)
+	assert.Equal(t, 1, upstream.calls)
+
+	// The notification is sent when the limit is disabled.
+	limits.maxTemplateOutputSize = 0
+	_, err = notifier.Notify(ctx, newAlert(strings.Repeat("x", 600)), newAlert(strings.Repeat("y", 600)))
+	require.NoError(t, err)
+	assert.Equal(t, 2, upstream.calls)
+}
+
+type countingNotifier struct {
+	calls int
+}
+
+func (n *countingNotifier) Notify(context.Context, ...*types.Alert) (bool, error) {
+	n.calls++
+	return false, nil
+}
@@ -0,0 +1,249 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package alertmanager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/go-openapi/strfmt"
+	"github.com/grafana/dskit/services"
+	"github.com/matttproud/golang_protobuf_extensions/pbutil"
+	"github.com/pkg/errors"
+	"github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/alertmanager/silence"
+	"github.com/prometheus/alertmanager/silence/silencepb"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/weaveworks/common/user"
+)
+
+const (
+	defaultSilencesFederationTimeout = 30 * time.Second
+
+	// silencesFederationPath is the path of the Alertmanager API endpoint listing the silences,
+	// relative to the peer URL.
+	silencesFederationPath = "/api/v2/silences"
+)
+
+var errInvalidSilencesFederationSyncInterval = errors.New("invalid alertmanager silences federation sync interval, must be greater than zero")
+
+// SilencesFederationConfig configures the federation of the tenants' silences with a peer Alertmanager cluster.
+type SilencesFederationConfig struct {
+	PeerURL      string        `yaml:"peer_url" category:"experimental"`
+	SyncInterval time.Duration `yaml:"sync_interval" category:"experimental"`
+}
+
+func (cfg *SilencesFederationConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.StringVar(&cfg.PeerURL, prefix+".peer-url", "", "URL of the Alertmanager of a peer Mimir cluster, including the Alertmanager HTTP prefix, to federate the tenants' silences with. The silences of each tenant are periodically fetched from the peer cluster and merged with the local ones, the most recently updated silence winning in case of conflict. To federate the silences in both directions, configure each cluster with the other one as peer. Basic authentication credentials can be set in the URL. Empty to disable.")
+	f.DurationVar(&cfg.SyncInterval, prefix+".sync-interval", time.Minute, "The interval between fetching the tenants' silences from the peer cluster.")
+}
+
+func (cfg *SilencesFederationConfig) Validate() error {
+	if !cfg.Enabled() {
+		return nil
+	}
+	if _, err := url.Parse(cfg.PeerURL); err != nil {
+		return errors.Wrap(err, "invalid alertmanager silences federation peer URL")
+	}
+	if cfg.SyncInterval <= 0 {
+		return errInvalidSilencesFederationSyncInterval
+	}
+	return nil
+}
+
+// Enabled returns whether the silences federation is enabled.
+func (cfg *SilencesFederationConfig) Enabled() bool {
+	return cfg.PeerURL != ""
+}
+
+// silencesFederation periodically merges the silences of the tenant found in the peer cluster with the local ones.
+// Silences keep their ID across clusters, and the silence state only accepts a silence updated more recently
+// than the one it holds, so silences updated in both clusters converge to the latest update, and the silences
+// fetched back from the peer cluster are ignored.
+type silencesFederation struct {
+	services.Service
+
+	peerURL  string
+	userID   string
+	state    State
+	silences *silence.Silences
+	client   *http.Client
+	logger   log.Logger
+
+	// The merged silences expire at the same time as the local ones.
+	retention time.Duration
+	now       func() time.Time
+
+	syncTotal      prometheus.Counter
+	syncFailed     prometheus.Counter
+	mergedSilences prometheus.Counter
+}
+
+func newSilencesFederation(cfg SilencesFederationConfig, userID string, state State, silences *silence.Silences, retention time.Duration, l log.Logger, r prometheus.Registerer) *silencesFederation {
+	f := &silencesFederation{
+		peerURL:   strings.TrimSuffix(cfg.PeerURL, "/") + silencesFederationPath,
+		userID:    userID,
+		state:     state,
+		silences:  silences,
+		client:    &http.Client{Timeout: defaultSilencesFederationTimeout},
+		logger:    l,
+		retention: retention,
+		now:       time.Now,
+		syncTotal: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Name: "alertmanager_silences_federation_sync_total",
+			Help: "Number of times we have tried to fetch the silences from the peer cluster.",
+		}),
+		syncFailed: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Name: "alertmanager_silences_federation_sync_failed_total",
+			Help: "Number of times we have failed to fetch the silences from the peer cluster.",
+		}),
+		mergedSilences: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Name: "alertmanager_silences_federation_merged_silences_total",
+			Help: "Number of silences fetched from the peer cluster which were new or more recently updated than the local ones.",
+		}),
+	}
+
+	f.Service = services.NewTimerService(cfg.SyncInterval, f.starting, f.iteration, nil)
+
+	return f
+}
+
+func (f *silencesFederation) starting(ctx context.Context) error {
+	// Waits until the state replicator is settled, so that the local silences
+	// are known before merging the ones of the peer cluster.
+	return f.state.WaitReady(ctx)
+}
+
+func (f *silencesFederation) iteration(ctx context.Context) error {
+	if err := f.sync(ctx); err != nil {
+		level.Warn(f.logger).Log("msg", "failed to federate silences with the peer cluster", "user", f.userID, "err", err)
+	}
+	return nil
+}
+
+func (f *silencesFederation) sync(ctx context.Context) (err error) {
+	// Only the replica at position zero should fetch the silences. The merged silences
+	// are replicated to the other replicas like the ones created locally.
+	if f.state.Position() != 0 {
+		return nil
+	}
+
+	f.syncTotal.Inc()
+	defer func() {
+		if err != nil {
+			f.syncFailed.Inc()
+		}
+	}()
+
+	var peerSilences models.GettableSilences
+	peerSilences, err = f.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := f.now()
+	for _, s := range peerSilences {
+		var sil *silencepb.Silence
+		sil, err = silenceFromAPI(s)
+		if err != nil {
+			return err
+		}
+
+		// The silence state doesn't accept silences which should have been garbage collected already.
+		msil := &silencepb.MeshSilence{Silence: sil, ExpiresAt: sil.EndsAt.Add(f.retention)}
+		if msil.ExpiresAt.Before(now) {
+			continue
+		}
+
+		// Each silence is merged on its own, because the merged state is replicated as is.
+		var buf bytes.Buffer
+		if _, err = pbutil.WriteDelimited(&buf, msil); err != nil {
+			return err
+		}
+
+		before := f.silences.Version()
+		if err = f.silences.Merge(buf.Bytes()); err != nil {
+			return errors.Wrapf(err, "failed to merge silence %s", sil.Id)
+		}
+		if f.silences.Version() != before {
+			f.mergedSilences.Inc()
+		}
+	}
+
+	return nil
+}
+
+// fetch returns the silences of the tenant in the peer cluster.
+func (f *silencesFederation) fetch(ctx context.Context) (models.GettableSilences, error) {
+	req, err := http.NewRequest(http.MethodGet, f.peerURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(user.OrgIDHeaderName, f.userID)
+
+	resp, err := f.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch the silences from the peer cluster")
+	}
+	defer func() {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch the silences from the peer cluster: unexpected status code %d", resp.StatusCode)
+	}
+
+	var silences models.GettableSilences
+	if err := json.NewDecoder(resp.Body).Decode(&silences); err != nil {
+		return nil, errors.Wrap(err, "failed to decode the silences of the peer cluster")
+	}
+	return silences, nil
+}
+
+// silenceFromAPI converts a silence returned by the Alertmanager API to the format of the silence state.
+func silenceFromAPI(s *models.GettableSilence) (*silencepb.Silence, error) {
+	if err := s.Validate(strfmt.Default); err != nil {
+		return nil, errors.Wrap(err, "invalid silence")
+	}
+
+	sil := &silencepb.Silence{
+		Id:        *s.ID,
+		StartsAt:  time.Time(*s.StartsAt),
+		EndsAt:    time.Time(*s.EndsAt),
+		UpdatedAt: time.Time(*s.UpdatedAt),
+		Comment:   *s.Comment,
+		CreatedBy: *s.CreatedBy,
+	}
+
+	for _, m := range s.Matchers {
+		matcher := &silencepb.Matcher{Name: *m.Name, Pattern: *m.Value}
+
+		isEqual := m.IsEqual == nil || *m.IsEqual
+		switch {
+		case *m.IsRegex && isEqual:
+			matcher.Type = silencepb.Matcher_REGEXP
+		case *m.IsRegex:
+			matcher.Type = silencepb.Matcher_NOT_REGEXP
+		case isEqual:
+			matcher.Type = silencepb.Matcher_EQUAL
+		default:
+			matcher.Type = silencepb.Matcher_NOT_EQUAL
+		}
+		sil.Matchers = append(sil.Matchers, matcher)
+	}
+
+	return sil, nil
+}
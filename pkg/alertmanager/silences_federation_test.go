@@ -0,0 +1,181 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package alertmanager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-openapi/strfmt"
+	"github.com/matttproud/golang_protobuf_extensions/pbutil"
+	"github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/alertmanager/silence"
+	"github.com/prometheus/alertmanager/silence/silencepb"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+)
+
+func TestSilencesFederationConfig_Validate(t *testing.T) {
+	cfg := SilencesFederationConfig{}
+	cfg.RegisterFlagsWithPrefix("", flag.NewFlagSet("", flag.PanicOnError))
+	require.NoError(t, cfg.Validate())
+	assert.False(t, cfg.Enabled())
+
+	cfg.PeerURL = "http://peer/alertmanager"
+	assert.True(t, cfg.Enabled())
+	require.NoError(t, cfg.Validate())
+
+	cfg.SyncInterval = 0
+	assert.Equal(t, errInvalidSilencesFederationSyncInterval, cfg.Validate())
+
+	cfg.SyncInterval = time.Minute
+	cfg.PeerURL = "://peer"
+	assert.Error(t, cfg.Validate())
+}
+
+func TestSilencesFederation_Sync(t *testing.T) {
+	now := time.Now()
+
+	makeAPISilence := func(id string, updatedAt, endsAt time.Time, comment string) *models.GettableSilence {
+		var (
+			name, value       = "alertname", "test"
+			isRegex, isEqual  = false, false
+			startsAt          = strfmt.DateTime(now.Add(-time.Hour))
+			ends, updated     = strfmt.DateTime(endsAt), strfmt.DateTime(updatedAt)
+			createdBy, status = "peer", models.SilenceStatusStateActive
+		)
+		return &models.GettableSilence{
+			ID:        &id,
+			UpdatedAt: &updated,
+			Status:    &models.SilenceStatus{State: &status},
+			Silence: models.Silence{
+				Matchers:  models.Matchers{{Name: &name, Value: &value, IsRegex: &isRegex, IsEqual: &isEqual}},
+				StartsAt:  &startsAt,
+				EndsAt:    &ends,
+				CreatedBy: &createdBy,
+				Comment:   &comment,
+			},
+		}
+	}
+
+	for name, tc := range map[string]struct {
+		position        int
+		local           []*silencepb.Silence
+		peer            models.GettableSilences
+		peerStatusCode  int
+		expectedFetched bool
+		expectedErr     bool
+		expectedMerged  float64
+		expectedComment map[string]string
+	}{
+		"new silences of the peer cluster are merged": {
+			peer: models.GettableSilences{
+				makeAPISilence("peer-1", now.Add(-time.Minute), now.Add(time.Hour), "from peer"),
+			},
+			expectedFetched: true,
+			expectedMerged:  1,
+			expectedComment: map[string]string{"peer-1": "from peer"},
+		},
+		"the most recently updated silence wins": {
+			local: []*silencepb.Silence{
+				{Id: "older-locally", Comment: "local", UpdatedAt: now.Add(-2 * time.Minute), StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour)},
+				{Id: "newer-locally", Comment: "local", UpdatedAt: now.Add(-time.Minute), StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour)},
+			},
+			peer: models.GettableSilences{
+				makeAPISilence("older-locally", now.Add(-time.Minute), now.Add(time.Hour), "from peer"),
+				makeAPISilence("newer-locally", now.Add(-2*time.Minute), now.Add(time.Hour), "from peer"),
+			},
+			expectedFetched: true,
+			expectedMerged:  1,
+			expectedComment: map[string]string{"older-locally": "from peer", "newer-locally": "local"},
+		},
+		"silences past the retention are skipped": {
+			peer: models.GettableSilences{
+				makeAPISilence("expired", now.Add(-48*time.Hour), now.Add(-48*time.Hour), "from peer"),
+			},
+			expectedFetched: true,
+			expectedComment: map[string]string{},
+		},
+		"the peer cluster fails": {
+			peerStatusCode:  http.StatusInternalServerError,
+			expectedFetched: true,
+			expectedErr:     true,
+			expectedComment: map[string]string{},
+		},
+		"only the replica at position zero syncs": {
+			position: 1,
+			peer: models.GettableSilences{
+				makeAPISilence("peer-1", now.Add(-time.Minute), now.Add(time.Hour), "from peer"),
+			},
+			expectedComment: map[string]string{},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			fetched := false
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fetched = true
+				assert.Equal(t, "/alertmanager"+silencesFederationPath, r.URL.Path)
+				assert.Equal(t, "user", r.Header.Get(user.OrgIDHeaderName))
+
+				if tc.peerStatusCode != 0 {
+					w.WriteHeader(tc.peerStatusCode)
+					return
+				}
+				require.NoError(t, json.NewEncoder(w).Encode(tc.peer))
+			}))
+			t.Cleanup(server.Close)
+
+			silences, err := silence.New(silence.Options{Retention: 24 * time.Hour})
+			require.NoError(t, err)
+			for _, s := range tc.local {
+				require.NoError(t, silences.Merge(mustMarshalMeshSilence(t, s, 24*time.Hour)))
+			}
+
+			cfg := SilencesFederationConfig{}
+			cfg.RegisterFlagsWithPrefix("", flag.NewFlagSet("", flag.PanicOnError))
+			cfg.PeerURL = server.URL + "/alertmanager/"
+
+			state := newFakePersistableState()
+			state.position = tc.position
+
+			reg := prometheus.NewPedanticRegistry()
+			f := newSilencesFederation(cfg, "user", state, silences, 24*time.Hour, log.NewNopLogger(), reg)
+
+			err = f.sync(context.Background())
+			if tc.expectedErr {
+				require.Error(t, err)
+				assert.Equal(t, float64(1), testutil.ToFloat64(f.syncFailed))
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, float64(0), testutil.ToFloat64(f.syncFailed))
+			}
+			assert.Equal(t, tc.expectedFetched, fetched)
+			assert.Equal(t, tc.expectedMerged, testutil.ToFloat64(f.mergedSilences))
+
+			found, _, err := silences.Query()
+			require.NoError(t, err)
+			comments := map[string]string{}
+			for _, s := range found {
+				comments[s.Id] = s.Comment
+			}
+			assert.Equal(t, tc.expectedComment, comments)
+		})
+	}
+}
+
+func mustMarshalMeshSilence(t *testing.T, s *silencepb.Silence, retention time.Duration) []byte {
+	var buf bytes.Buffer
+	_, err := pbutil.WriteDelimited(&buf, &silencepb.MeshSilence{Silence: s, ExpiresAt: s.EndsAt.Add(retention)})
+	require.NoError(t, err)
+	return buf.Bytes()
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/prometheus/alertmanager/cluster"
 	"github.com/prometheus/alertmanager/cluster/clusterpb"
 	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/atomic"
 
 	"github.com/grafana/mimir/pkg/alertmanager/alertspb"
 	"github.com/grafana/mimir/pkg/alertmanager/alertstore"
@@ -50,7 +51,7 @@ type state struct {
 	mtx    sync.Mutex
 	states map[string]cluster.State
 
-	replicationFactor int
+	replicationFactor *atomic.Int64
 	replicator        Replicator
 	store             alertstore.AlertStore
 
@@ -73,7 +74,7 @@ func newReplicatedStates(userID string, rf int, re Replicator, st alertstore.Ale
 	s := &state{
 		logger:            l,
 		userID:            userID,
-		replicationFactor: rf,
+		replicationFactor: atomic.NewInt64(int64(rf)),
 		replicator:        re,
 		store:             st,
 		states:            make(map[string]cluster.State, 2), // we use two, one for the notifications and one for silences.
@@ -202,7 +203,7 @@ func (s *state) starting(ctx context.Context) error {
 	level.Info(s.logger).Log("msg", "Waiting for notification and silences to settle...")
 
 	// If the replication factor is <= 1, there is nowhere to obtain the state from.
-	if s.replicationFactor <= 1 {
+	if s.replicationFactor.Load() <= 1 {
 		level.Info(s.logger).Log("msg", "skipping settling (no replicas)")
 		return nil
 	}
@@ -293,8 +294,8 @@ func (s *state) running(ctx context.Context) error {
 		select {
 		case p := <-s.msgc:
 			// If the replication factor is <= 1, we don't need to replicate any state anywhere else.
-			if s.replicationFactor <= 1 {
-				return nil
+			if s.replicationFactor.Load() <= 1 {
+				continue
 			}
 
 			s.stateReplicationTotal.WithLabelValues(p.Key).Inc()
@@ -308,6 +309,11 @@ func (s *state) running(ctx context.Context) error {
 	}
 }
 
+// setReplicationFactor updates the replication factor of the state, which can be overridden per-tenant at runtime.
+func (s *state) setReplicationFactor(rf int) {
+	s.replicationFactor.Store(int64(rf))
+}
+
 func (s *state) broadcast(key string, b []byte) {
 	// We should ignore the Merges into the initial state during settling.
 	if s.Ready() {
@@ -0,0 +1,451 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package alertmanager
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	tmpltext "text/template"
+	"text/template/parse"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/alertmanager/asset"
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+)
+
+const (
+	errTemplateFunctionNotAllowed = "function %q used in template %q is not allowed, allowed functions: %s"
+	errTemplateRecursive          = "template %q is recursive, which is not allowed"
+	errTemplateOutputTooBig       = "rendering template %q exceeded the output size limit of %d bytes"
+	errTemplateExecutionTooSlow   = "rendering template %q exceeded the execution time limit of %s"
+)
+
+var (
+	errSandboxOutputLimit    = errors.New("template output size limit exceeded")
+	errSandboxExecutionLimit = errors.New("template execution time limit exceeded")
+)
+
+// templateSandbox renders the tenant's templates against sample notification data when the config is
+// uploaded, and the templates of the notifiers against the actual notification data when sending a
+// notification, enforcing the tenant's limits on the template functions which can be used, the
+// execution time and the output size. A templateSandbox must not be used concurrently.
+type templateSandbox struct {
+	allowedFunctions map[string]struct{}
+	maxOutputSize    int
+	maxExecutionTime time.Duration
+
+	// Set for each rendering.
+	deadline time.Time
+}
+
+func newTemplateSandbox(limits Limits, user string) *templateSandbox {
+	s := &templateSandbox{
+		maxOutputSize:    limits.AlertmanagerMaxTemplateOutputSizeBytes(user),
+		maxExecutionTime: limits.AlertmanagerMaxTemplateExecutionTime(user),
+	}
+
+	if allowed := limits.AlertmanagerTemplateAllowedFunctions(user); len(allowed) > 0 {
+		s.allowedFunctions = make(map[string]struct{}, len(allowed))
+		for _, fn := range allowed {
+			s.allowedFunctions[fn] = struct{}{}
+		}
+	}
+
+	return s
+}
+
+// enabled returns whether any sandbox limit is configured.
+func (s *templateSandbox) enabled() bool {
+	return s.allowedFunctions != nil || s.maxOutputSize > 0 || s.maxExecutionTime > 0
+}
+
+// validate checks the templates defined in the given template files, and the templates inlined
+// in the receivers of the given config.
+func (s *templateSandbox) validate(cfg *config.Config, templateFiles []string) error {
+	// The tenant's templates are parsed on their own, to check only the templates defined by the tenant,
+	// and then together with the default templates, which can be referenced by the tenant's templates.
+	tenantTmpl, err := s.parse(false, templateFiles)
+	if err != nil {
+		return err
+	}
+
+	tmpl, err := s.parse(true, templateFiles)
+	if err != nil {
+		return err
+	}
+
+	inlined := collectInlinedTemplates(cfg.Receivers)
+
+	for _, t := range tenantTmpl.Templates() {
+		if t.Tree == nil {
+			continue
+		}
+		if err := s.checkFunctions(t.Name(), t.Tree.Root); err != nil {
+			return err
+		}
+	}
+
+	for _, text := range inlined {
+		t, err := tmpltext.New("").Funcs(s.funcs()).Parse(text)
+		if err != nil {
+			// Invalid templates are reported when rendering a notification, as done by the Alertmanager.
+			continue
+		}
+		if err := s.checkFunctions(text, t.Tree.Root); err != nil {
+			return err
+		}
+	}
+
+	if err := checkRecursion(tmpl); err != nil {
+		return err
+	}
+
+	if s.maxOutputSize <= 0 && s.maxExecutionTime <= 0 {
+		return nil
+	}
+
+	data := sampleTemplateData()
+
+	for _, t := range tenantTmpl.Templates() {
+		if t.Tree == nil || t.Name() == "" {
+			continue
+		}
+		name := t.Name()
+		if err := s.render(name, func(w *sandboxWriter) error {
+			return tmpl.ExecuteTemplate(w, name, data)
+		}); err != nil {
+			return err
+		}
+	}
+
+	return s.renderInlined(tmpl, inlined, data)
+}
+
+// renderInlined renders the inlined templates in the same way the Alertmanager notifiers do, using
+// the given template parsed by the sandbox, enforcing the sandbox limits.
+func (s *templateSandbox) renderInlined(tmpl *tmpltext.Template, inlined []string, data *template.Data) error {
+	for _, text := range inlined {
+		cloned, err := tmpl.Clone()
+		if err != nil {
+			return err
+		}
+		t, err := cloned.New("").Option("missingkey=zero").Parse(text)
+		if err != nil {
+			continue
+		}
+		if err := s.checkFunctions(text, t.Tree.Root); err != nil {
+			return err
+		}
+		if err := s.render(text, func(w *sandboxWriter) error {
+			return t.Execute(w, data)
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// withFuncs returns a copy of the template parsed by another sandbox, using the functions of this sandbox.
+func (s *templateSandbox) withFuncs(tmpl *tmpltext.Template) (*tmpltext.Template, error) {
+	cloned, err := tmpl.Clone()
+	if err != nil {
+		return nil, err
+	}
+	return cloned.Funcs(s.funcs()), nil
+}
+
+// parse parses the template files in the same way the Alertmanager does, optionally including the default templates.
+func (s *templateSandbox) parse(includeDefault bool, templateFiles []string) (*tmpltext.Template, error) {
+	t := tmpltext.New("").Option("missingkey=zero").Funcs(s.funcs())
+
+	if includeDefault {
+		f, err := asset.Assets.Open("/templates/default.tmpl")
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		b, err := ioutil.ReadAll(f)
+		if err != nil {
+			return nil, err
+		}
+		if t, err = t.Parse(string(b)); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, file := range templateFiles {
+		// Templates referenced in the config but not existing are allowed, as done by the Alertmanager.
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			continue
+		}
+
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if t, err = t.New(filepath.Base(file)).Parse(string(b)); err != nil {
+			return nil, err
+		}
+	}
+
+	return t, nil
+}
+
+// funcs returns the Alertmanager template functions, wrapped to abort the rendering once the execution deadline has passed.
+func (s *templateSandbox) funcs() tmpltext.FuncMap {
+	funcs := make(tmpltext.FuncMap, len(template.DefaultFuncs))
+	for name, fn := range template.DefaultFuncs {
+		fnValue := reflect.ValueOf(fn)
+		funcs[name] = reflect.MakeFunc(fnValue.Type(), func(args []reflect.Value) []reflect.Value {
+			if s.deadlineExceeded() {
+				// Panics in template functions are recovered and returned as errors by the template engine.
+				panic(errSandboxExecutionLimit)
+			}
+			if fnValue.Type().IsVariadic() {
+				return fnValue.CallSlice(args)
+			}
+			return fnValue.Call(args)
+		}).Interface()
+	}
+	return funcs
+}
+
+func (s *templateSandbox) deadlineExceeded() bool {
+	return !s.deadline.IsZero() && time.Now().After(s.deadline)
+}
+
+// checkFunctions returns an error if the template uses an Alertmanager template function which is not allowed.
+// The functions built into the template engine are always allowed.
+func (s *templateSandbox) checkFunctions(name string, root parse.Node) error {
+	if s.allowedFunctions == nil {
+		return nil
+	}
+
+	var err error
+	walkTemplateNodes(root, func(n parse.Node) {
+		ident, ok := n.(*parse.IdentifierNode)
+		if !ok || err != nil {
+			return
+		}
+		if _, isDefault := template.DefaultFuncs[ident.Ident]; !isDefault {
+			return
+		}
+		if _, allowed := s.allowedFunctions[ident.Ident]; !allowed {
+			err = fmt.Errorf(errTemplateFunctionNotAllowed, ident.Ident, name, s.allowedFunctionsString())
+		}
+	})
+	return err
+}
+
+func (s *templateSandbox) allowedFunctionsString() string {
+	names := make([]string, 0, len(s.allowedFunctions))
+	for name := range s.allowedFunctions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// render runs the rendering function, enforcing the output size and execution time limits.
+func (s *templateSandbox) render(name string, fn func(w *sandboxWriter) error) error {
+	w := &sandboxWriter{sandbox: s}
+
+	if s.maxExecutionTime > 0 {
+		s.deadline = time.Now().Add(s.maxExecutionTime)
+		defer func() { s.deadline = time.Time{} }()
+	}
+
+	err := fn(w)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, errSandboxOutputLimit) || w.outputLimitExceeded:
+		return fmt.Errorf(errTemplateOutputTooBig, name, s.maxOutputSize)
+	case errors.Is(err, errSandboxExecutionLimit) || s.deadlineExceeded():
+		return fmt.Errorf(errTemplateExecutionTooSlow, name, s.maxExecutionTime)
+	default:
+		// Other errors may be caused by the sample data and are reported by the notifier when rendering
+		// a notification, as done by the Alertmanager.
+		return nil
+	}
+}
+
+// sandboxWriter is an io.Writer aborting the rendering once the output size limit or the execution deadline is exceeded.
+type sandboxWriter struct {
+	sandbox             *templateSandbox
+	size                int
+	outputLimitExceeded bool
+}
+
+func (w *sandboxWriter) Write(p []byte) (int, error) {
+	if w.sandbox.deadlineExceeded() {
+		return 0, errSandboxExecutionLimit
+	}
+
+	w.size += len(p)
+	if w.sandbox.maxOutputSize > 0 && w.size > w.sandbox.maxOutputSize {
+		w.outputLimitExceeded = true
+		return 0, errSandboxOutputLimit
+	}
+	return len(p), nil
+}
+
+// checkRecursion returns an error if any template invokes itself, directly or indirectly. Recursive
+// templates are rejected because the execution time of their rendering can't be bounded.
+func checkRecursion(t *tmpltext.Template) error {
+	calls := map[string][]string{}
+	for _, tmpl := range t.Templates() {
+		if tmpl.Tree == nil {
+			continue
+		}
+		name := tmpl.Name()
+		walkTemplateNodes(tmpl.Tree.Root, func(n parse.Node) {
+			if call, ok := n.(*parse.TemplateNode); ok {
+				calls[name] = append(calls[name], call.Name)
+			}
+		})
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[string]int{}
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf(errTemplateRecursive, name)
+		case visited:
+			return nil
+		}
+
+		state[name] = visiting
+		for _, callee := range calls[name] {
+			if err := visit(callee); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		return nil
+	}
+
+	names := make([]string, 0, len(calls))
+	for name := range calls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkTemplateNodes calls fn for each node of the template parse tree.
+func walkTemplateNodes(node parse.Node, fn func(parse.Node)) {
+	if reflect.ValueOf(node).IsNil() {
+		return
+	}
+
+	fn(node)
+
+	switch n := node.(type) {
+	case *parse.ListNode:
+		for _, child := range n.Nodes {
+			walkTemplateNodes(child, fn)
+		}
+	case *parse.ActionNode:
+		walkTemplateNodes(n.Pipe, fn)
+	case *parse.PipeNode:
+		for _, cmd := range n.Cmds {
+			walkTemplateNodes(cmd, fn)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			walkTemplateNodes(arg, fn)
+		}
+	case *parse.ChainNode:
+		walkTemplateNodes(n.Node, fn)
+	case *parse.IfNode:
+		walkBranchNodes(&n.BranchNode, fn)
+	case *parse.RangeNode:
+		walkBranchNodes(&n.BranchNode, fn)
+	case *parse.WithNode:
+		walkBranchNodes(&n.BranchNode, fn)
+	case *parse.TemplateNode:
+		walkTemplateNodes(n.Pipe, fn)
+	}
+}
+
+func walkBranchNodes(n *parse.BranchNode, fn func(parse.Node)) {
+	walkTemplateNodes(n.Pipe, fn)
+	walkTemplateNodes(n.List, fn)
+	walkTemplateNodes(n.ElseList, fn)
+}
+
+// collectInlinedTemplates returns the strings containing a template found in the given receivers
+// or integrations config.
+func collectInlinedTemplates(cfg interface{}) []string {
+	var out []string
+	var collect func(v reflect.Value)
+	collect = func(v reflect.Value) {
+		switch v.Kind() {
+		case reflect.Ptr, reflect.Interface:
+			if !v.IsNil() {
+				collect(v.Elem())
+			}
+		case reflect.Struct:
+			for i := 0; i < v.NumField(); i++ {
+				if v.Type().Field(i).IsExported() {
+					collect(v.Field(i))
+				}
+			}
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < v.Len(); i++ {
+				collect(v.Index(i))
+			}
+		case reflect.Map:
+			for _, key := range v.MapKeys() {
+				collect(v.MapIndex(key))
+			}
+		case reflect.String:
+			if s := v.String(); strings.Contains(s, "{{") {
+				out = append(out, s)
+			}
+		}
+	}
+
+	collect(reflect.ValueOf(cfg))
+	return out
+}
+
+// sampleTemplateData returns the data used to render the templates in the sandbox.
+func sampleTemplateData() *template.Data {
+	now := time.Now()
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels:       model.LabelSet{model.AlertNameLabel: "SampleAlert", "severity": "critical", "instance": "localhost:9090"},
+			Annotations:  model.LabelSet{"summary": "Sample alert summary", "description": "Sample alert description"},
+			StartsAt:     now.Add(-time.Hour),
+			GeneratorURL: "http://localhost:9090/graph",
+		},
+	}
+
+	t := &template.Template{ExternalURL: &url.URL{Scheme: "http", Host: "localhost"}}
+	return t.Data("sample-receiver", model.LabelSet{model.AlertNameLabel: "SampleAlert"}, alert)
+}
@@ -0,0 +1,112 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package alertmanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/alertmanager/alertspb"
+)
+
+func TestTemplateSandbox(t *testing.T) {
+	const configWithTemplate = `
+route:
+  receiver: slack
+receivers:
+  - name: slack
+    slack_configs:
+      - api_url: http://localhost/hook
+        channel: '#alerts'
+        title: '{{ template "custom.title" . }}'
+templates:
+  - "custom.tmpl"
+`
+
+	tests := map[string]struct {
+		template    string
+		limits      mockAlertManagerLimits
+		expectedErr string
+	}{
+		"no limits": {
+			template: `{{ define "custom.title" }}{{ .CommonLabels.alertname | toUpper }}{{ end }}`,
+		},
+		"allowed functions": {
+			template: `{{ define "custom.title" }}{{ .CommonLabels.alertname | toUpper | printf "%s" }}{{ end }}`,
+			limits:   mockAlertManagerLimits{templateAllowedFunctions: []string{"toUpper"}},
+		},
+		"function not allowed in template file": {
+			template:    `{{ define "custom.title" }}{{ reReplaceAll "a" "b" .CommonLabels.alertname }}{{ end }}`,
+			limits:      mockAlertManagerLimits{templateAllowedFunctions: []string{"toUpper", "toLower"}},
+			expectedErr: `function "reReplaceAll" used in template "custom.title" is not allowed, allowed functions: toLower, toUpper`,
+		},
+		"recursive template": {
+			template:    `{{ define "custom.title" }}{{ template "custom.loop" . }}{{ end }}{{ define "custom.loop" }}{{ template "custom.title" . }}{{ end }}`,
+			limits:      mockAlertManagerLimits{maxTemplateOutputSize: 1024},
+			expectedErr: `template "custom.loop" is recursive, which is not allowed`,
+		},
+		"output within the limit": {
+			template: `{{ define "custom.title" }}{{ .CommonLabels.alertname }}{{ end }}`,
+			limits:   mockAlertManagerLimits{maxTemplateOutputSize: 1024},
+		},
+		"output exceeding the limit": {
+			template:    `{{ define "custom.title" }}{{ range $i, $e := (.CommonLabels.SortedPairs) }}{{ template "custom.big" }}{{ end }}{{ end }}{{ define "custom.big" }}` + string(make([]byte, 600)) + `{{ end }}`,
+			limits:      mockAlertManagerLimits{maxTemplateOutputSize: 1024},
+			expectedErr: `rendering template "custom.title" exceeded the output size limit of 1024 bytes`,
+		},
+		"execution time exceeding the limit": {
+			template:    `{{ define "custom.title" }}{{ range $i, $e := (.CommonLabels.SortedPairs) }}{{ toUpper $e.Value }}{{ end }}{{ end }}`,
+			limits:      mockAlertManagerLimits{maxTemplateExecutionTime: time.Nanosecond},
+			expectedErr: `rendering template "custom.title" exceeded the execution time limit of 1ns`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := alertspb.ToProto(configWithTemplate, map[string]string{"custom.tmpl": tc.template}, "user-1")
+			limits := tc.limits
+
+			err := validateUserConfig(log.NewNopLogger(), cfg, &limits, "user-1")
+			if tc.expectedErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Equal(t, tc.expectedErr, err.Error())
+			}
+		})
+	}
+}
+
+func TestTemplateSandbox_InlinedTemplates(t *testing.T) {
+	const config = `
+route:
+  receiver: slack
+receivers:
+  - name: slack
+    slack_configs:
+      - api_url: http://localhost/hook
+        channel: '#alerts'
+        title: '{{ .CommonLabels.alertname | title }}'
+`
+
+	cfg := alertspb.ToProto(config, nil, "user-1")
+
+	limits := &mockAlertManagerLimits{templateAllowedFunctions: []string{"toUpper"}}
+	err := validateUserConfig(log.NewNopLogger(), cfg, limits, "user-1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `function "title" used in template`)
+
+	limits.templateAllowedFunctions = []string{"title"}
+	require.NoError(t, validateUserConfig(log.NewNopLogger(), cfg, limits, "user-1"))
+
+	// The inlined templates are rendered too.
+	limits.templateAllowedFunctions = nil
+	limits.maxTemplateOutputSize = 5
+	err = validateUserConfig(log.NewNopLogger(), cfg, limits, "user-1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeded the output size limit of 5 bytes")
+}
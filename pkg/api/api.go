@@ -22,11 +22,13 @@ import (
 	"github.com/weaveworks/common/middleware"
 	"github.com/weaveworks/common/server"
 
+	"github.com/grafana/mimir/pkg/admin"
 	"github.com/grafana/mimir/pkg/alertmanager"
 	"github.com/grafana/mimir/pkg/alertmanager/alertmanagerpb"
 	"github.com/grafana/mimir/pkg/compactor"
 	"github.com/grafana/mimir/pkg/distributor"
 	"github.com/grafana/mimir/pkg/distributor/distributorpb"
+	"github.com/grafana/mimir/pkg/flusher"
 	frontendv1 "github.com/grafana/mimir/pkg/frontend/v1"
 	"github.com/grafana/mimir/pkg/frontend/v1/frontendv1pb"
 	frontendv2 "github.com/grafana/mimir/pkg/frontend/v2"
@@ -54,6 +56,13 @@ type Config struct {
 	AlertmanagerHTTPPrefix string `yaml:"alertmanager_http_prefix" category:"advanced"`
 	PrometheusHTTPPrefix   string `yaml:"prometheus_http_prefix" category:"advanced"`
 
+	Listeners ListenersConfig `yaml:"listeners" doc:"nocli|description=Additional HTTP listeners, each serving the HTTP endpoints of some of the modules running in the process in addition to the main HTTP server. The gRPC services are only served by the main gRPC server." category:"experimental"`
+
+	// Limits of the HTTP requests per class of endpoints, so that long queries and fast pushes can be served by the same server.
+	PushRoutes  RouteClassConfig `yaml:"push_endpoints" doc:"description=Limits of the HTTP requests to the push endpoints."`
+	QueryRoutes RouteClassConfig `yaml:"query_endpoints" doc:"description=Limits of the HTTP requests to the query endpoints, like the Prometheus query API and the remote read API."`
+	AdminRoutes RouteClassConfig `yaml:"admin_endpoints" doc:"description=Limits of the HTTP requests to all the other endpoints, like the admin and status pages."`
+
 	// The following configs are injected by the upstream caller.
 	ServerPrefix       string               `yaml:"-"`
 	HTTPAuthMiddleware middleware.Interface `yaml:"-"`
@@ -74,6 +83,9 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	// TODO(56quarters): Mention the specific header "X-Mimir-SkipLabelNameValidation" after Mimir is public
 	f.BoolVar(&cfg.SkipLabelNameValidationHeader, "api.skip-label-name-validation-header-enabled", false, "Allows to skip label name validation via header on the http write path. Use with caution as it breaks PromQL. Allowing this for external clients allows any client to send invalid label names. After enabling it, requests with a specific HTTP header set to true will not have label names validated.")
 	cfg.RegisterFlagsWithPrefix("", f)
+	cfg.PushRoutes.RegisterFlagsWithPrefix("api.push-endpoints.", routeClassPush, f)
+	cfg.QueryRoutes.RegisterFlagsWithPrefix("api.query-endpoints.", routeClassQuery, f)
+	cfg.AdminRoutes.RegisterFlagsWithPrefix("api.admin-endpoints.", routeClassAdmin, f)
 }
 
 // RegisterFlagsWithPrefix adds the flags required to config this to the given FlagSet with the set prefix.
@@ -82,6 +94,20 @@ func (cfg *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
 	f.StringVar(&cfg.PrometheusHTTPPrefix, prefix+"http.prometheus-http-prefix", "/prometheus", "HTTP URL path under which the Prometheus api will be served.")
 }
 
+// Validate the config.
+func (cfg *Config) Validate() error {
+	if err := cfg.PushRoutes.Validate(routeClassPush); err != nil {
+		return err
+	}
+	if err := cfg.QueryRoutes.Validate(routeClassQuery); err != nil {
+		return err
+	}
+	if err := cfg.AdminRoutes.Validate(routeClassAdmin); err != nil {
+		return err
+	}
+	return cfg.Listeners.Validate()
+}
+
 // Push either wraps the distributor push function as configured or returns the distributor push directly.
 func (cfg *Config) wrapDistributorPush(d *distributor.Distributor) push.Func {
 	if cfg.DistributorPushWrapper != nil {
@@ -99,6 +125,17 @@ type API struct {
 	logger    log.Logger
 	sourceIPs *middleware.SourceIPExtractor
 	indexPage *IndexPageContent
+	openAPI   *openAPIRoutes
+
+	// module is the module registering the routes, if any.
+	module string
+
+	// routeClass is the class of the routes registered, whose limits apply to the requests.
+	routeClass         string
+	requestRateLimiter *requestRateLimiter
+
+	listeners       []*listener
+	shutdownTimeout time.Duration
 }
 
 func New(cfg Config, serverCfg server.Config, s *server.Server, logger log.Logger) (*API, error) {
@@ -116,12 +153,21 @@ func New(cfg Config, serverCfg server.Config, s *server.Server, logger log.Logge
 	}
 
 	api := &API{
-		cfg:            cfg,
-		AuthMiddleware: cfg.HTTPAuthMiddleware,
-		server:         s,
-		logger:         logger,
-		sourceIPs:      sourceIPs,
-		indexPage:      newIndexPageContent(),
+		cfg:             cfg,
+		AuthMiddleware:  cfg.HTTPAuthMiddleware,
+		server:          s,
+		logger:          logger,
+		sourceIPs:       sourceIPs,
+		indexPage:       newIndexPageContent(),
+		openAPI:         newOpenAPIRoutes(),
+		shutdownTimeout: serverCfg.ServerGracefulShutdownTimeout,
+
+		routeClass:         routeClassAdmin,
+		requestRateLimiter: newRequestRateLimiter(),
+	}
+
+	for _, l := range cfg.Listeners {
+		api.listeners = append(api.listeners, newListener(l, cfg.ServerPrefix, logger))
 	}
 
 	// If no authentication middleware is present in the config, use the default authentication middleware.
@@ -138,6 +184,7 @@ func (a *API) RegisterDeprecatedRoute(path string, handler http.Handler, auth, g
 	methods = append([]string{method}, methods...)
 	handler = a.deprecatedHandler(handler)
 	level.Debug(a.logger).Log("msg", "api: registering deprecated route", "methods", strings.Join(methods, ","), "path", path, "auth", auth, "gzip", gzipEnabled)
+	a.openAPI.add(a.module, path, auth, true, methods)
 	a.newRoute(path, handler, false, auth, gzipEnabled, methods...)
 }
 
@@ -155,6 +202,7 @@ func (a *API) deprecatedHandler(next http.Handler) http.Handler {
 func (a *API) RegisterRoute(path string, handler http.Handler, auth, gzipEnabled bool, method string, methods ...string) {
 	methods = append([]string{method}, methods...)
 	level.Debug(a.logger).Log("msg", "api: registering route", "methods", strings.Join(methods, ","), "path", path, "auth", auth, "gzip", gzipEnabled)
+	a.openAPI.add(a.module, path, auth, false, methods)
 	a.newRoute(path, handler, false, auth, gzipEnabled, methods...)
 }
 
@@ -165,22 +213,33 @@ func (a *API) RegisterRoutesWithPrefix(prefix string, handler http.Handler, auth
 
 func (a *API) newRoute(path string, handler http.Handler, isPrefix, auth, gzip bool, methods ...string) (route *mux.Route) {
 	if auth {
+		handler = a.requestRateLimiter.wrap(a.routeClass, handler)
 		handler = a.AuthMiddleware.Wrap(handler)
 	}
 	if gzip {
 		handler = gziphandler.GzipHandler(handler)
 	}
+	handler = a.routeClassConfig().wrap(handler)
+
+	for _, l := range a.listeners {
+		if l.serves(a.module, auth) {
+			addRoute(l.routes, path, handler, isPrefix, methods...)
+		}
+	}
+
+	return addRoute(a.server.HTTP, path, handler, isPrefix, methods...)
+}
+
+func addRoute(router *mux.Router, path string, handler http.Handler, isPrefix bool, methods ...string) (route *mux.Route) {
 	if isPrefix {
-		route = a.server.HTTP.PathPrefix(path)
+		route = router.PathPrefix(path)
 	} else {
-		route = a.server.HTTP.Path(path)
+		route = router.Path(path)
 	}
 	if len(methods) > 0 {
 		route = route.Methods(methods...)
 	}
-	route = route.Handler(handler)
-
-	return route
+	return route.Handler(handler)
 }
 
 // RegisterAlertmanager registers endpoints associated with the alertmanager. It will only
@@ -209,6 +268,17 @@ func (a *API) RegisterAlertmanager(am *alertmanager.MultitenantAlertmanager, api
 		a.RegisterRoute("/api/v1/alerts", http.HandlerFunc(am.GetUserConfig), true, true, "GET")
 		a.RegisterRoute("/api/v1/alerts", http.HandlerFunc(am.SetUserConfig), true, true, "POST")
 		a.RegisterRoute("/api/v1/alerts", http.HandlerFunc(am.DeleteUserConfig), true, true, "DELETE")
+		a.RegisterRoute("/api/v1/alerts/templates", http.HandlerFunc(am.ListUserTemplates), true, true, "GET")
+		a.RegisterRoute("/api/v1/alerts/templates/{name}", http.HandlerFunc(am.GetUserTemplate), true, true, "GET")
+		a.RegisterRoute("/api/v1/alerts/templates/{name}", http.HandlerFunc(am.SetUserTemplate), true, true, "PUT", "POST")
+		a.RegisterRoute("/api/v1/alerts/templates/{name}", http.HandlerFunc(am.DeleteUserTemplate), true, true, "DELETE")
+		a.RegisterRoute("/api/v1/alerts/assets", http.HandlerFunc(am.ListUserAssets), true, true, "GET")
+		a.RegisterRoute("/api/v1/alerts/assets/{name}", http.HandlerFunc(am.GetUserAsset), true, true, "GET")
+		a.RegisterRoute("/api/v1/alerts/assets/{name}", http.HandlerFunc(am.SetUserAsset), true, true, "PUT", "POST")
+		a.RegisterRoute("/api/v1/alerts/assets/{name}", http.HandlerFunc(am.DeleteUserAsset), true, true, "DELETE")
+		a.RegisterRoute("/api/v1/alerts/versions", http.HandlerFunc(am.ListUserConfigVersions), true, true, "GET")
+		a.RegisterRoute("/api/v1/alerts/versions/{version}", http.HandlerFunc(am.GetUserConfigVersion), true, true, "GET")
+		a.RegisterRoute("/api/v1/alerts/versions/{version}/rollback", http.HandlerFunc(am.RollbackUserConfig), true, true, "POST")
 	}
 }
 
@@ -224,6 +294,7 @@ func (a *API) RegisterAPI(httpPathPrefix string, actualCfg interface{}, defaultC
 	a.RegisterRoutesWithPrefix("/static/", http.StripPrefix(httpPathPrefix, http.FileServer(http.FS(staticFiles))), false, true, "GET")
 	a.RegisterRoute("/debug/fgprof", fgprof.Handler(), false, true, "GET")
 	a.RegisterRoute("/api/v1/status/buildinfo", buildInfoHandler, false, true, "GET")
+	a.RegisterRoute("/api/openapi.json", openAPIHandler(a.openAPI), false, true, "GET")
 }
 
 // RegisterRuntimeConfig registers the endpoints associates with the runtime configuration
@@ -236,21 +307,44 @@ func (a *API) RegisterRuntimeConfig(runtimeConfigHandler http.HandlerFunc) {
 	a.RegisterRoute("/runtime_config", runtimeConfigHandler, false, true, "GET")
 }
 
+// RegisterFIPSStatus registers the endpoint reporting the FIPS compliance status.
+func (a *API) RegisterFIPSStatus(handler http.Handler) {
+	a.RegisterRoute("/api/v1/status/fips", handler, false, true, "GET")
+}
+
+// RegisterLogging registers the endpoints to change the log levels at runtime, and to manage
+// the per-tenant and per-trace log level overrides.
+func (a *API) RegisterLogging(logLevelHandler, logOverridesHandler http.Handler) {
+	a.indexPage.AddLinks(defaultWeight, "Logging", []IndexPageLink{
+		{Desc: "Log levels", Path: "/log_level"},
+		{Desc: "Log level overrides", Path: "/log/overrides"},
+	})
+	a.RegisterRoute("/log_level", logLevelHandler, false, true, "GET", "POST", "DELETE")
+	a.RegisterRoute("/log/overrides", logOverridesHandler, false, true, "GET", "POST", "DELETE")
+}
+
 // RegisterDistributor registers the endpoints associated with the distributor.
 func (a *API) RegisterDistributor(d *distributor.Distributor, pushConfig distributor.Config) {
 	distributorpb.RegisterDistributorServer(a.server.GRPC, d)
 
-	a.RegisterRoute("/api/v1/push", push.Handler(pushConfig.MaxRecvMsgSize, a.sourceIPs, a.cfg.SkipLabelNameValidationHeader, a.cfg.wrapDistributorPush(d)), true, false, "POST")
+	a.forRouteClass(routeClassPush).RegisterRoute("/api/v1/push", push.Handler(pushConfig.MaxRecvMsgSize, a.sourceIPs, a.cfg.SkipLabelNameValidationHeader, a.cfg.wrapDistributorPush(d)), true, false, "POST")
 
 	a.indexPage.AddLinks(defaultWeight, "Distributor", []IndexPageLink{
 		{Desc: "Ring status", Path: "/distributor/ring"},
 		{Desc: "Usage statistics", Path: "/distributor/all_user_stats"},
 		{Desc: "HA tracker status", Path: "/distributor/ha_tracker"},
+		{Desc: "Unhealthy zones", Path: "/distributor/unhealthy_zones"},
+		{Desc: "Queues status", Path: "/debug/queues/distributor"},
 	})
 
 	a.RegisterRoute("/distributor/ring", d, false, true, "GET", "POST")
 	a.RegisterRoute("/distributor/all_user_stats", http.HandlerFunc(d.AllUserStatsHandler), false, true, "GET")
 	a.RegisterRoute("/distributor/ha_tracker", d.HATracker, false, true, "GET")
+	a.RegisterRoute("/distributor/unhealthy_zones", http.HandlerFunc(d.UnhealthyZonesHandler), false, true, "GET")
+	a.RegisterRoute("/debug/queues/distributor", http.HandlerFunc(d.QueuesDebugHandler), false, true, "GET")
+	a.RegisterRoute("/api/v1/rejected_series", http.HandlerFunc(d.RejectedSeriesHandler), true, true, "GET")
+	a.RegisterRoute("/api/v1/last_write", http.HandlerFunc(d.LastWriteHandler), true, true, "GET")
+	a.RegisterRoute("/api/v1/active_series_custom_trackers_status", http.HandlerFunc(d.ActiveSeriesCustomTrackersStatusHandler), true, true, "GET", "POST")
 }
 
 // Ingester is defined as an interface to allow for alternative implementations
@@ -259,6 +353,11 @@ type Ingester interface {
 	client.IngesterServer
 	FlushHandler(http.ResponseWriter, *http.Request)
 	ShutdownHandler(http.ResponseWriter, *http.Request)
+	PrepareShutdownHandler(http.ResponseWriter, *http.Request)
+	ActiveSeriesCustomTrackersHandler(http.ResponseWriter, *http.Request)
+	ActiveSeriesCustomTrackersStatusHandler(http.ResponseWriter, *http.Request)
+	ActiveSeriesBreakdownHandler(http.ResponseWriter, *http.Request)
+	ActiveSeriesMemoryHandler(http.ResponseWriter, *http.Request)
 	PushWithCleanup(context.Context, *mimirpb.WriteRequest, func()) (*mimirpb.WriteResponse, error)
 }
 
@@ -269,11 +368,22 @@ func (a *API) RegisterIngester(i Ingester, pushConfig distributor.Config) {
 	a.indexPage.AddLinks(dangerousWeight, "Dangerous", []IndexPageLink{
 		{Dangerous: true, Desc: "Trigger a flush of data from ingester to storage", Path: "/ingester/flush"},
 		{Dangerous: true, Desc: "Trigger ingester shutdown", Path: "/ingester/shutdown"},
+		{Dangerous: true, Desc: "Prepare ingester shutdown", Path: "/ingester/prepare-shutdown"},
 	})
 
 	a.RegisterRoute("/ingester/flush", http.HandlerFunc(i.FlushHandler), false, true, "GET", "POST")
 	a.RegisterRoute("/ingester/shutdown", http.HandlerFunc(i.ShutdownHandler), false, true, "GET", "POST")
-	a.RegisterRoute("/ingester/push", push.Handler(pushConfig.MaxRecvMsgSize, a.sourceIPs, a.cfg.SkipLabelNameValidationHeader, i.PushWithCleanup), true, false, "POST") // For testing and debugging.
+	a.RegisterRoute("/ingester/prepare-shutdown", http.HandlerFunc(i.PrepareShutdownHandler), false, true, "GET", "POST", "DELETE")
+	a.RegisterRoute("/api/v1/active_series_custom_trackers", http.HandlerFunc(i.ActiveSeriesCustomTrackersHandler), true, true, "GET", "POST", "DELETE")
+	a.RegisterRoute("/ingester/active_series_custom_trackers_status", http.HandlerFunc(i.ActiveSeriesCustomTrackersStatusHandler), true, true, "GET", "POST")
+	a.RegisterRoute("/ingester/active_series_breakdown", http.HandlerFunc(i.ActiveSeriesBreakdownHandler), true, true, "GET")
+	a.RegisterRoute("/ingester/active_series_memory", http.HandlerFunc(i.ActiveSeriesMemoryHandler), false, true, "GET")
+	a.forRouteClass(routeClassPush).RegisterRoute("/ingester/push", push.Handler(pushConfig.MaxRecvMsgSize, a.sourceIPs, a.cfg.SkipLabelNameValidationHeader, i.PushWithCleanup), true, false, "POST") // For testing and debugging.
+}
+
+// RegisterTenantsAdmin registers the admin endpoint listing the tenants with their summary stats.
+func (a *API) RegisterTenantsAdmin(api *admin.TenantsAPI) {
+	a.RegisterRoute("/api/v1/admin/tenants", http.HandlerFunc(api.TenantsHandler), false, true, "GET")
 }
 
 func (a *API) RegisterTenantDeletion(api *purger.TenantDeletionAPI) {
@@ -286,7 +396,7 @@ func (a *API) RegisterRuler(r *ruler.Ruler) {
 	a.indexPage.AddLinks(defaultWeight, "Ruler", []IndexPageLink{
 		{Desc: "Ring status", Path: "/ruler/ring"},
 	})
-	a.RegisterRoute("/ruler/ring", r, false, true, "GET", "POST")
+	a.RegisterRoute("/ruler/ring", http.HandlerFunc(r.RingHandler), false, true, "GET", "POST")
 
 	// Administrative API, uses authentication to inform which user's configuration to delete.
 	a.RegisterRoute("/ruler/delete_tenant_config", http.HandlerFunc(r.DeleteTenantConfiguration), true, true, "POST")
@@ -349,18 +459,55 @@ func (a *API) RegisterStoreGateway(s *storegateway.StoreGateway) {
 	a.indexPage.AddLinks(defaultWeight, "Store-gateway", []IndexPageLink{
 		{Desc: "Ring status", Path: "/store-gateway/ring"},
 		{Desc: "Tenants & Blocks", Path: "/store-gateway/tenants"},
+		{Desc: "Queues status", Path: "/debug/queues/store-gateway"},
 	})
 	a.RegisterRoute("/store-gateway/ring", http.HandlerFunc(s.RingHandler), false, true, "GET", "POST")
 	a.RegisterRoute("/store-gateway/tenants", http.HandlerFunc(s.TenantsHandler), false, true, "GET")
 	a.RegisterRoute("/store-gateway/tenant/{tenant}/blocks", http.HandlerFunc(s.BlocksHandler), false, true, "GET")
+	a.RegisterRoute("/debug/queues/store-gateway", http.HandlerFunc(s.QueuesDebugHandler), false, true, "GET")
+
+	// Blocks listing API, scoped to the tenant of the request or, in operator mode, to any tenant.
+	a.RegisterRoute("/api/v1/blocks", http.HandlerFunc(s.BlocksListHandler), true, true, "GET")
+	a.RegisterRoute("/api/v1/blocks/{block}/meta.json", http.HandlerFunc(s.BlockMetaHandler), true, true, "GET")
+	a.RegisterRoute("/store-gateway/tenant/{tenant}/api/v1/blocks", http.HandlerFunc(s.BlocksListHandler), false, true, "GET")
+	a.RegisterRoute("/store-gateway/tenant/{tenant}/api/v1/blocks/{block}/meta.json", http.HandlerFunc(s.BlockMetaHandler), false, true, "GET")
 }
 
-// RegisterCompactor registers the ring UI page associated with the compactor.
+// RegisterCompactor registers the ring UI page, the status API, the deleted blocks API, the bucket index repair API, the on-demand compaction API, the compaction planning API and the block marks API associated with the compactor.
 func (a *API) RegisterCompactor(c *compactor.MultitenantCompactor) {
 	a.indexPage.AddLinks(defaultWeight, "Compactor", []IndexPageLink{
 		{Desc: "Ring status", Path: "/compactor/ring"},
+		{Desc: "Compaction status", Path: "/compactor/status"},
+		{Desc: "Queues status", Path: "/debug/queues/compactor"},
 	})
 	a.RegisterRoute("/compactor/ring", http.HandlerFunc(c.RingHandler), false, true, "GET", "POST")
+	a.RegisterRoute("/compactor/status", http.HandlerFunc(c.StatusHandler), false, true, "GET")
+	a.RegisterRoute("/debug/queues/compactor", http.HandlerFunc(c.QueuesDebugHandler), false, true, "GET")
+
+	// Blocks marked for deletion listing and recovery API, for operators.
+	a.RegisterRoute("/compactor/tenant/{tenant}/deleted_blocks", http.HandlerFunc(c.DeletedBlocksHandler), false, true, "GET")
+	a.RegisterRoute("/compactor/tenant/{tenant}/blocks/{block}/undelete", http.HandlerFunc(c.UndeleteBlockHandler), false, true, "POST")
+
+	// Bucket index validation and repair API, for operators.
+	a.RegisterRoute("/compactor/tenant/{tenant}/bucket_index/repair", http.HandlerFunc(c.BucketIndexRepairHandler), false, true, "GET", "POST")
+
+	// On-demand compaction API, for operators.
+	a.RegisterRoute("/compactor/tenant/{tenant}/compact", http.HandlerFunc(c.TriggerCompactionHandler), false, true, "POST")
+
+	// Compaction planning API, for operators.
+	a.RegisterRoute("/compactor/tenant/{tenant}/planned_jobs", http.HandlerFunc(c.PlannedJobsHandler), false, true, "GET")
+
+	// Block marks API, for operators.
+	a.RegisterRoute("/compactor/tenant/{tenant}/blocks/mark_no_compact", http.HandlerFunc(c.MarkBlocksNoCompactHandler), false, true, "POST")
+	a.RegisterRoute("/compactor/tenant/{tenant}/blocks/mark_for_deletion", http.HandlerFunc(c.MarkBlocksForDeletionHandler), false, true, "POST")
+}
+
+// RegisterFlusher registers routes associated with the Flusher service.
+func (a *API) RegisterFlusher(f *flusher.Flusher) {
+	a.indexPage.AddLinks(defaultWeight, "Flusher", []IndexPageLink{
+		{Desc: "Flush progress", Path: "/flusher/progress"},
+	})
+	a.RegisterRoute("/flusher/progress", http.HandlerFunc(f.ProgressHandler), false, true, "GET")
 }
 
 type Distributor interface {
@@ -380,17 +527,19 @@ func (a *API) RegisterQueryable(
 
 // RegisterQueryAPI registers the Prometheus API routes with the provided handler.
 func (a *API) RegisterQueryAPI(handler http.Handler, buildInfoHandler http.Handler) {
-	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/read"), handler, true, true, "POST")
-	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/query"), handler, true, true, "GET", "POST")
-	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/query_range"), handler, true, true, "GET", "POST")
-	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/query_exemplars"), handler, true, true, "GET", "POST")
-	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/labels"), handler, true, true, "GET", "POST")
-	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/label/{name}/values"), handler, true, true, "GET")
-	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/series"), handler, true, true, "GET", "POST", "DELETE")
-	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/status/buildinfo"), buildInfoHandler, false, true, "GET")
-	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/metadata"), handler, true, true, "GET")
-	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/cardinality/label_names"), handler, true, true, "GET", "POST")
-	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/cardinality/label_values"), handler, true, true, "GET", "POST")
+	q := a.forRouteClass(routeClassQuery)
+	q.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/read"), handler, true, true, "POST")
+	q.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/query"), handler, true, true, "GET", "POST")
+	q.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/query_range"), handler, true, true, "GET", "POST")
+	q.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/query_exemplars"), handler, true, true, "GET", "POST")
+	q.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/labels"), handler, true, true, "GET", "POST")
+	q.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/label/{name}/values"), handler, true, true, "GET")
+	q.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/series"), handler, true, true, "GET", "POST", "DELETE")
+	q.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/status/buildinfo"), buildInfoHandler, false, true, "GET")
+	q.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/metadata"), handler, true, true, "GET")
+	q.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/cardinality/label_names"), handler, true, true, "GET", "POST")
+	q.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/cardinality/label_values"), handler, true, true, "GET", "POST")
+	q.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/cardinality/active_series"), handler, true, true, "GET", "POST")
 }
 
 // RegisterQueryFrontend registers the Prometheus routes supported by the
@@ -402,6 +551,11 @@ func (a *API) RegisterQueryFrontendHandler(h http.Handler, buildInfoHandler http
 
 func (a *API) RegisterQueryFrontend1(f *frontendv1.Frontend) {
 	frontendv1pb.RegisterFrontendServer(a.server.GRPC, f)
+
+	a.indexPage.AddLinks(defaultWeight, "Query-frontend", []IndexPageLink{
+		{Desc: "Queues status", Path: "/debug/queues/query-frontend"},
+	})
+	a.RegisterRoute("/debug/queues/query-frontend", http.HandlerFunc(f.QueuesDebugHandler), false, true, "GET")
 }
 
 func (a *API) RegisterQueryFrontend2(f *frontendv2.Frontend) {
@@ -411,6 +565,11 @@ func (a *API) RegisterQueryFrontend2(f *frontendv2.Frontend) {
 func (a *API) RegisterQueryScheduler(f *scheduler.Scheduler) {
 	schedulerpb.RegisterSchedulerForFrontendServer(a.server.GRPC, f)
 	schedulerpb.RegisterSchedulerForQuerierServer(a.server.GRPC, f)
+
+	a.indexPage.AddLinks(defaultWeight, "Query-scheduler", []IndexPageLink{
+		{Desc: "Queues status", Path: "/debug/queues/query-scheduler"},
+	})
+	a.RegisterRoute("/debug/queues/query-scheduler", http.HandlerFunc(f.QueuesDebugHandler), false, true, "GET")
 }
 
 // RegisterServiceMapHandler registers the Mimir structs service handler
@@ -23,13 +23,13 @@ import (
 	dto "github.com/prometheus/client_model/go"
 	"github.com/prometheus/common/route"
 	"github.com/prometheus/prometheus/config"
-	"github.com/prometheus/prometheus/promql"
 	"github.com/prometheus/prometheus/storage"
 	v1 "github.com/prometheus/prometheus/web/api/v1"
 	"github.com/weaveworks/common/instrument"
 	"github.com/weaveworks/common/middleware"
 
 	"github.com/grafana/mimir/pkg/querier"
+	querier_engine "github.com/grafana/mimir/pkg/querier/engine"
 	"github.com/grafana/mimir/pkg/querier/stats"
 	"github.com/grafana/mimir/pkg/util"
 	"github.com/grafana/mimir/pkg/util/validation"
@@ -162,7 +162,7 @@ func NewQuerierHandler(
 	cfg Config,
 	queryable storage.SampleAndChunkQueryable,
 	exemplarQueryable storage.ExemplarQueryable,
-	engine *promql.Engine,
+	engine v1.QueryEngine,
 	distributor Distributor,
 	reg prometheus.Registerer,
 	logger log.Logger,
@@ -235,6 +235,9 @@ func NewQuerierHandler(
 	}
 	router.Use(instrumentMiddleware.Wrap)
 
+	// Allow to select the PromQL engine for a single query.
+	router.Use(querier_engine.QueryEngineMiddleware)
+
 	// Define the prefixes for all routes
 	prefix := path.Join(cfg.ServerPrefix, cfg.PrometheusHTTPPrefix)
 
@@ -255,7 +258,8 @@ func NewQuerierHandler(
 	router.Path(path.Join(prefix, "/api/v1/metadata")).Methods("GET").Handler(promRouter)
 	router.Path(path.Join(prefix, "/api/v1/cardinality/label_names")).Methods("GET", "POST").Handler(querier.LabelNamesCardinalityHandler(distributor, limits))
 	router.Path(path.Join(prefix, "/api/v1/cardinality/label_values")).Methods("GET", "POST").Handler(querier.LabelValuesCardinalityHandler(distributor, limits))
+	router.Path(path.Join(prefix, "/api/v1/cardinality/active_series")).Methods("GET", "POST").Handler(querier.ActiveSeriesCardinalityHandler(distributor, limits))
 
-	// Track execution time.
-	return stats.NewWallTimeMiddleware().Wrap(router)
+	// Track execution time and response encoding memory.
+	return stats.NewWallTimeMiddleware().Wrap(stats.NewEncodingMemoryMiddleware().Wrap(router))
 }
@@ -0,0 +1,233 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/gorilla/mux"
+	"github.com/grafana/dskit/services"
+	"github.com/pkg/errors"
+	node_https "github.com/prometheus/node_exporter/https"
+	"github.com/weaveworks/common/logging"
+	"github.com/weaveworks/common/middleware"
+
+	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/tlsreload"
+)
+
+const (
+	// ListenerEndpointsAll serves all the endpoints of the listener modules.
+	ListenerEndpointsAll = "all"
+
+	// ListenerEndpointsAuthenticated serves only the endpoints of the listener modules which require
+	// the tenant authentication, like the push and query APIs.
+	ListenerEndpointsAuthenticated = "authenticated"
+
+	// ListenerEndpointsUnauthenticated serves only the endpoints of the listener modules which don't
+	// require the tenant authentication, like the admin and status pages.
+	ListenerEndpointsUnauthenticated = "unauthenticated"
+)
+
+var listenerEndpoints = []string{ListenerEndpointsAll, ListenerEndpointsAuthenticated, ListenerEndpointsUnauthenticated}
+
+// ListenersConfig is the list of the additional HTTP listeners.
+type ListenersConfig []ListenerConfig
+
+// ExampleDoc implements the ExamplerConfig interface used by the reference configuration generator.
+func (c *ListenersConfig) ExampleDoc() (comment string, yaml interface{}) {
+	return `The following configuration serves the push API of the distributor on the port 8081, using TLS, ` +
+			`and the admin endpoints of the distributor and ingester on the port 8082.`,
+		[]map[string]interface{}{
+			{
+				"name":             "push",
+				"http_listen_port": 8081,
+				"modules":          []string{"distributor"},
+				"endpoints":        ListenerEndpointsAuthenticated,
+				"tls": map[string]string{
+					"cert_file": "/certs/push.crt",
+					"key_file":  "/certs/push.key",
+				},
+			},
+			{
+				"name":             "admin",
+				"http_listen_port": 8082,
+				"modules":          []string{"distributor", "ingester"},
+				"endpoints":        ListenerEndpointsUnauthenticated,
+			},
+		}
+}
+
+// ListenerConfig configures an additional HTTP listener, serving the endpoints of some modules
+// in addition to the main HTTP server.
+type ListenerConfig struct {
+	Name          string               `yaml:"name" doc:"description=Name of the listener. Must be unique."`
+	ListenAddress string               `yaml:"http_listen_address" doc:"description=HTTP listen address. If empty, the listener listens on all the addresses."`
+	ListenPort    int                  `yaml:"http_listen_port" doc:"description=HTTP listen port."`
+	Modules       []string             `yaml:"modules" doc:"description=Modules whose HTTP endpoints are served by the listener. The modules must be running in the process to be served."`
+	Endpoints     string               `yaml:"endpoints" doc:"description=Which endpoints of the modules are served by the listener. Supported values are: all, authenticated (only the endpoints requiring the tenant authentication), unauthenticated (only the endpoints not requiring the tenant authentication). If empty, all the endpoints are served."`
+	TLS           node_https.TLSStruct `yaml:"tls" doc:"description=TLS configuration of the listener. To require the clients to authenticate with a certificate, set client_auth_type to RequireAndVerifyClientCert and client_ca_file to the CA verifying the client certificates."`
+}
+
+func (cfg *ListenersConfig) Validate() error {
+	names := map[string]struct{}{}
+	for _, l := range *cfg {
+		if l.Name == "" {
+			return errors.New("the listener name is required")
+		}
+		if _, ok := names[l.Name]; ok {
+			return fmt.Errorf("the listener name %q is used by multiple listeners", l.Name)
+		}
+		names[l.Name] = struct{}{}
+
+		if l.ListenPort <= 0 {
+			return fmt.Errorf("the listener %q must have a listen port", l.Name)
+		}
+		if len(l.Modules) == 0 {
+			return fmt.Errorf("the listener %q must serve at least one module", l.Name)
+		}
+		if l.Endpoints != "" && !util.StringsContain(listenerEndpoints, l.Endpoints) {
+			return fmt.Errorf("the listener %q has unsupported endpoints %q", l.Name, l.Endpoints)
+		}
+		if (l.TLS.TLSCertPath == "") != (l.TLS.TLSKeyPath == "") {
+			return fmt.Errorf("the listener %q must have both the TLS certificate and key configured", l.Name)
+		}
+	}
+	return nil
+}
+
+// listener is an additional HTTP listener, serving the routes registered by some modules.
+type listener struct {
+	cfg    ListenerConfig
+	router *mux.Router
+	routes *mux.Router
+	server *http.Server
+	lis    net.Listener
+}
+
+func newListener(cfg ListenerConfig, pathPrefix string, logger log.Logger) *listener {
+	router := mux.NewRouter()
+	// Ensure the encoded path is used, like the main HTTP server does.
+	router.UseEncodedPath()
+
+	routes := router
+	if pathPrefix != "" {
+		routes = router.PathPrefix(pathPrefix).Subrouter()
+	}
+
+	l := &listener{cfg: cfg, router: router, routes: routes}
+	l.server = &http.Server{
+		Handler: middleware.Merge(
+			middleware.Tracer{RouteMatcher: router},
+			middleware.Log{Log: logging.GoKit(log.With(logger, "listener", cfg.Name))},
+		).Wrap(router),
+	}
+	return l
+}
+
+// serves returns whether the listener serves a route registered by the module.
+func (l *listener) serves(module string, auth bool) bool {
+	if !util.StringsContain(l.cfg.Modules, module) {
+		return false
+	}
+
+	switch l.cfg.Endpoints {
+	case ListenerEndpointsAuthenticated:
+		return auth
+	case ListenerEndpointsUnauthenticated:
+		return !auth
+	default:
+		return true
+	}
+}
+
+func (l *listener) listen() error {
+	lis, err := net.Listen("tcp", util.JoinHostPort(l.cfg.ListenAddress, l.cfg.ListenPort))
+	if err != nil {
+		return err
+	}
+
+	if l.cfg.TLS.TLSCertPath != "" {
+		var tlsCfg *tls.Config
+		if tlsCfg, err = tlsreload.ServerConfig(l.cfg.TLS); err != nil {
+			_ = lis.Close()
+			return errors.Wrap(err, "error generating the TLS config")
+		}
+		lis = tls.NewListener(lis, tlsCfg)
+	}
+
+	l.lis = lis
+	return nil
+}
+
+// ForModule returns an API registering the routes of the module also on the additional listeners
+// configured to serve the module.
+func (a *API) ForModule(module string) *API {
+	moduleAPI := *a
+	moduleAPI.module = module
+	return &moduleAPI
+}
+
+// ListenersService returns the service running the additional listeners, or nil if none is configured.
+func (a *API) ListenersService() services.Service {
+	if len(a.listeners) == 0 {
+		return nil
+	}
+
+	return services.NewBasicService(a.startListeners, a.runListeners, a.stopListeners)
+}
+
+func (a *API) startListeners(context.Context) error {
+	for _, l := range a.listeners {
+		if err := l.listen(); err != nil {
+			a.closeListeners()
+			return errors.Wrapf(err, "failed to start the listener %q", l.cfg.Name)
+		}
+		level.Info(a.logger).Log("msg", "api: listener listening", "listener", l.cfg.Name, "addr", l.lis.Addr(), "modules", fmt.Sprint(l.cfg.Modules))
+	}
+	return nil
+}
+
+func (a *API) runListeners(ctx context.Context) error {
+	errs := make(chan error, len(a.listeners))
+	for _, l := range a.listeners {
+		go func(l *listener) {
+			if err := l.server.Serve(l.lis); err != http.ErrServerClosed {
+				errs <- errors.Wrapf(err, "the listener %q failed", l.cfg.Name)
+			}
+		}(l)
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-errs:
+		return err
+	}
+}
+
+func (a *API) stopListeners(error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), a.shutdownTimeout)
+	defer cancel()
+
+	for _, l := range a.listeners {
+		if err := l.server.Shutdown(ctx); err != nil {
+			level.Warn(a.logger).Log("msg", "api: failed to gracefully shutdown the listener", "listener", l.cfg.Name, "err", err)
+		}
+	}
+	return nil
+}
+
+func (a *API) closeListeners() {
+	for _, l := range a.listeners {
+		if l.lis != nil {
+			_ = l.lis.Close()
+		}
+	}
+}
@@ -0,0 +1,187 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/gorilla/mux"
+	"github.com/grafana/dskit/services"
+	node_https "github.com/prometheus/node_exporter/https"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/server"
+)
+
+func TestListenersConfig_Validate(t *testing.T) {
+	tests := map[string]struct {
+		cfg         ListenersConfig
+		expectedErr string
+	}{
+		"no listeners": {},
+		"valid listeners": {
+			cfg: ListenersConfig{
+				{Name: "push", ListenPort: 8081, Modules: []string{"distributor"}, Endpoints: ListenerEndpointsAuthenticated},
+				{Name: "admin", ListenPort: 8082, Modules: []string{"distributor", "ingester"}},
+			},
+		},
+		"missing name": {
+			cfg:         ListenersConfig{{ListenPort: 8081, Modules: []string{"distributor"}}},
+			expectedErr: "the listener name is required",
+		},
+		"duplicated name": {
+			cfg: ListenersConfig{
+				{Name: "push", ListenPort: 8081, Modules: []string{"distributor"}},
+				{Name: "push", ListenPort: 8082, Modules: []string{"ingester"}},
+			},
+			expectedErr: `the listener name "push" is used by multiple listeners`,
+		},
+		"missing port": {
+			cfg:         ListenersConfig{{Name: "push", Modules: []string{"distributor"}}},
+			expectedErr: `the listener "push" must have a listen port`,
+		},
+		"missing modules": {
+			cfg:         ListenersConfig{{Name: "push", ListenPort: 8081}},
+			expectedErr: `the listener "push" must serve at least one module`,
+		},
+		"unsupported endpoints": {
+			cfg:         ListenersConfig{{Name: "push", ListenPort: 8081, Modules: []string{"distributor"}, Endpoints: "some"}},
+			expectedErr: `the listener "push" has unsupported endpoints "some"`,
+		},
+		"TLS certificate without key": {
+			cfg:         ListenersConfig{{Name: "push", ListenPort: 8081, Modules: []string{"distributor"}, TLS: node_https.TLSStruct{TLSCertPath: "/certs/push.crt"}}},
+			expectedErr: `the listener "push" must have both the TLS certificate and key configured`,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			err := testData.cfg.Validate()
+			if testData.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, testData.expectedErr)
+			}
+		})
+	}
+}
+
+func TestAPI_ListenersRoutes(t *testing.T) {
+	cfg := Config{
+		Listeners: ListenersConfig{
+			{Name: "push", ListenPort: 8081, Modules: []string{"distributor"}, Endpoints: ListenerEndpointsAuthenticated},
+			{Name: "admin", ListenPort: 8082, Modules: []string{"distributor", "ingester"}, Endpoints: ListenerEndpointsUnauthenticated},
+			{Name: "ingester", ListenPort: 8083, Modules: []string{"ingester"}},
+		},
+		ServerPrefix: "/prefix",
+	}
+	s := &server.Server{HTTP: mux.NewRouter()}
+
+	a, err := New(cfg, server.Config{}, s, log.NewNopLogger())
+	require.NoError(t, err)
+
+	handler := func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(name))
+		})
+	}
+	a.RegisterRoute("/config", handler("config"), false, true, "GET")
+	a.ForModule("distributor").RegisterRoute("/api/v1/push", handler("push"), true, false, "POST")
+	a.ForModule("distributor").RegisterRoute("/distributor/ring", handler("distributor ring"), false, false, "GET")
+	a.ForModule("ingester").RegisterRoute("/ingester/push", handler("ingester push"), true, false, "POST")
+	a.ForModule("ingester").RegisterRoute("/ingester/flush", handler("ingester flush"), false, false, "POST")
+
+	routes := map[string]struct {
+		method, path string
+		expected     string
+	}{
+		"config":           {method: "GET", path: "/prefix/config", expected: "config"},
+		"push":             {method: "POST", path: "/prefix/api/v1/push", expected: "push"},
+		"distributor ring": {method: "GET", path: "/prefix/distributor/ring", expected: "distributor ring"},
+		"ingester push":    {method: "POST", path: "/prefix/ingester/push", expected: "ingester push"},
+		"ingester flush":   {method: "POST", path: "/prefix/ingester/flush", expected: "ingester flush"},
+	}
+
+	expectedRoutes := map[string][]string{
+		"push":     {"push"},
+		"admin":    {"distributor ring", "ingester flush"},
+		"ingester": {"ingester push", "ingester flush"},
+	}
+
+	for _, l := range a.listeners {
+		for routeName, route := range routes {
+			t.Run(fmt.Sprintf("listener %s route %s", l.cfg.Name, routeName), func(t *testing.T) {
+				req := httptest.NewRequest(route.method, route.path, nil)
+				req.Header.Set("X-Scope-OrgID", "user-1")
+				rec := httptest.NewRecorder()
+				l.router.ServeHTTP(rec, req)
+
+				served := false
+				for _, r := range expectedRoutes[l.cfg.Name] {
+					served = served || r == routeName
+				}
+				if served {
+					assert.Equal(t, http.StatusOK, rec.Code)
+					assert.Equal(t, route.expected, rec.Body.String())
+				} else {
+					assert.Equal(t, http.StatusNotFound, rec.Code)
+				}
+			})
+		}
+	}
+
+	// All the routes are still served by the main HTTP server, which applies the path prefix on its own.
+	for routeName, route := range routes {
+		req := httptest.NewRequest(route.method, strings.TrimPrefix(route.path, "/prefix"), nil)
+		req.Header.Set("X-Scope-OrgID", "user-1")
+		rec := httptest.NewRecorder()
+		s.HTTP.ServeHTTP(rec, req)
+		assert.Equal(t, route.expected, rec.Body.String(), routeName)
+	}
+}
+
+func TestAPI_ListenersService(t *testing.T) {
+	cfg := Config{
+		Listeners: ListenersConfig{
+			// Listen on a random port.
+			{Name: "push", ListenAddress: "localhost", Modules: []string{"distributor"}},
+		},
+	}
+	s := &server.Server{HTTP: mux.NewRouter()}
+
+	a, err := New(cfg, server.Config{}, s, log.NewNopLogger())
+	require.NoError(t, err)
+	a.ForModule("distributor").RegisterRoute("/distributor/ring", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ring"))
+	}), false, false, "GET")
+
+	svc := a.ListenersService()
+	require.NotNil(t, svc)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), svc))
+
+	resp, err := http.Get(fmt.Sprintf("http://%s/distributor/ring", a.listeners[0].lis.Addr()))
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "ring", string(body))
+
+	require.NoError(t, services.StopAndAwaitTerminated(context.Background(), svc))
+
+	_, err = http.Get(fmt.Sprintf("http://%s/distributor/ring", a.listeners[0].lis.Addr()))
+	assert.Error(t, err)
+}
+
+func TestAPI_ListenersServiceWithoutListeners(t *testing.T) {
+	a, err := New(Config{}, server.Config{}, &server.Server{HTTP: mux.NewRouter()}, log.NewNopLogger())
+	require.NoError(t, err)
+	assert.Nil(t, a.ListenersService())
+}
@@ -0,0 +1,247 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package api
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/common/version"
+
+	"github.com/grafana/mimir/pkg/util"
+)
+
+const (
+	openAPIVersion = "3.0.3"
+
+	// openAPITenantSecurityScheme is the name of the security scheme of the routes requiring the tenant ID.
+	openAPITenantSecurityScheme = "tenantID"
+)
+
+// openAPIModules are the modules whose routes are included in the OpenAPI specification, keyed by module name
+// and valued by the tag the routes are grouped by.
+var openAPIModules = map[string]string{
+	"distributor":    "Distributor",
+	"querier":        "Querier",
+	"query-frontend": "Querier",
+	"ruler":          "Ruler",
+	"alertmanager":   "Alertmanager",
+}
+
+// openAPIPathParam matches the path variables of a route, eg. "{name}" or "{name:[a-z]+}".
+var openAPIPathParam = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?}`)
+
+// openAPIRoute is a route registered through the API, which is described in the OpenAPI specification.
+type openAPIRoute struct {
+	module     string
+	path       string
+	methods    []string
+	auth       bool
+	deprecated bool
+}
+
+// openAPIRoutes keeps track of the routes registered through the API. It's shared by all the module APIs.
+type openAPIRoutes struct {
+	mtx    sync.Mutex
+	routes []openAPIRoute
+}
+
+func newOpenAPIRoutes() *openAPIRoutes {
+	return &openAPIRoutes{}
+}
+
+func (r *openAPIRoutes) add(module, path string, auth, deprecated bool, methods []string) {
+	if _, ok := openAPIModules[module]; !ok {
+		return
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.routes = append(r.routes, openAPIRoute{
+		module:     module,
+		path:       path,
+		methods:    append([]string(nil), methods...),
+		auth:       auth,
+		deprecated: deprecated,
+	})
+}
+
+// OpenAPIDocument is an OpenAPI v3 document. Only the subset of the specification required to describe
+// the routes registered through the API is supported.
+type OpenAPIDocument struct {
+	OpenAPI    string                     `json:"openapi"`
+	Info       OpenAPIInfo                `json:"info"`
+	Tags       []OpenAPITag               `json:"tags,omitempty"`
+	Paths      map[string]OpenAPIPathItem `json:"paths"`
+	Components OpenAPIComponents          `json:"components"`
+}
+
+type OpenAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type OpenAPITag struct {
+	Name string `json:"name"`
+}
+
+// OpenAPIPathItem holds the operations available on a single path, keyed by lowercase HTTP method.
+type OpenAPIPathItem map[string]*OpenAPIOperation
+
+type OpenAPIOperation struct {
+	OperationID string                     `json:"operationId"`
+	Tags        []string                   `json:"tags,omitempty"`
+	Deprecated  bool                       `json:"deprecated,omitempty"`
+	Parameters  []OpenAPIParameter         `json:"parameters,omitempty"`
+	Security    []map[string][]string      `json:"security,omitempty"`
+	Responses   map[string]OpenAPIResponse `json:"responses"`
+}
+
+type OpenAPIParameter struct {
+	Name     string        `json:"name"`
+	In       string        `json:"in"`
+	Required bool          `json:"required"`
+	Schema   OpenAPISchema `json:"schema"`
+}
+
+type OpenAPISchema struct {
+	Type string `json:"type"`
+}
+
+type OpenAPIResponse struct {
+	Description string `json:"description"`
+}
+
+type OpenAPIComponents struct {
+	SecuritySchemes map[string]OpenAPISecurityScheme `json:"securitySchemes"`
+}
+
+type OpenAPISecurityScheme struct {
+	Type        string `json:"type"`
+	In          string `json:"in"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// document builds the OpenAPI document describing the routes registered so far.
+func (r *openAPIRoutes) document() OpenAPIDocument {
+	r.mtx.Lock()
+	routes := append([]openAPIRoute(nil), r.routes...)
+	r.mtx.Unlock()
+
+	doc := OpenAPIDocument{
+		OpenAPI: openAPIVersion,
+		Info: OpenAPIInfo{
+			Title:   "Grafana Mimir HTTP API",
+			Version: version.Version,
+		},
+		Paths: map[string]OpenAPIPathItem{},
+		Components: OpenAPIComponents{
+			SecuritySchemes: map[string]OpenAPISecurityScheme{
+				openAPITenantSecurityScheme: {
+					Type:        "apiKey",
+					In:          "header",
+					Name:        "X-Scope-OrgID",
+					Description: "The tenant ID. Multiple tenant IDs can be separated by the pipe character, when tenant federation is enabled.",
+				},
+			},
+		},
+	}
+
+	tags := map[string]struct{}{}
+	for _, route := range routes {
+		path, params := openAPIPath(route.path)
+
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = OpenAPIPathItem{}
+			doc.Paths[path] = item
+		}
+
+		tag := openAPIModules[route.module]
+		tags[tag] = struct{}{}
+
+		for _, method := range route.methods {
+			method = strings.ToLower(method)
+
+			// The same route may be registered by multiple modules (eg. querier and query-frontend).
+			if _, ok := item[method]; ok {
+				continue
+			}
+
+			op := &OpenAPIOperation{
+				OperationID: openAPIOperationID(method, path),
+				Tags:        []string{tag},
+				Deprecated:  route.deprecated,
+				Parameters:  params,
+				Responses: map[string]OpenAPIResponse{
+					"default": {Description: "See the Grafana Mimir HTTP API reference."},
+				},
+			}
+			if route.auth {
+				op.Security = []map[string][]string{{openAPITenantSecurityScheme: {}}}
+			}
+
+			item[method] = op
+		}
+	}
+
+	for tag := range tags {
+		doc.Tags = append(doc.Tags, OpenAPITag{Name: tag})
+	}
+	sort.Slice(doc.Tags, func(i, j int) bool { return doc.Tags[i].Name < doc.Tags[j].Name })
+
+	return doc
+}
+
+// openAPIPath converts a route path to an OpenAPI path, removing the regular expressions of the path
+// variables, and returns the path parameters.
+func openAPIPath(routePath string) (string, []OpenAPIParameter) {
+	var params []OpenAPIParameter
+
+	path := openAPIPathParam.ReplaceAllStringFunc(routePath, func(match string) string {
+		name := openAPIPathParam.FindStringSubmatch(match)[1]
+		params = append(params, OpenAPIParameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   OpenAPISchema{Type: "string"},
+		})
+		return "{" + name + "}"
+	})
+
+	return path, params
+}
+
+// openAPIOperationID returns a unique ID for the operation, eg. "getApiV1AlertsTemplatesName"
+// for "GET /api/v1/alerts/templates/{name}".
+func openAPIOperationID(method, path string) string {
+	id := strings.Builder{}
+	id.WriteString(method)
+
+	upper := true
+	for _, c := range path {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			upper = true
+			continue
+		}
+		if upper && c >= 'a' && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		id.WriteRune(c)
+		upper = false
+	}
+
+	return id.String()
+}
+
+// openAPIHandler serves the OpenAPI document describing the routes registered through the API.
+func openAPIHandler(routes *openAPIRoutes) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		util.WriteJSONResponse(w, routes.document())
+	}
+}
@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/server"
+)
+
+func TestOpenAPIHandler(t *testing.T) {
+	srv := &server.Server{HTTP: mux.NewRouter()}
+	a, err := New(Config{}, server.Config{}, srv, log.NewNopLogger())
+	require.NoError(t, err)
+
+	noop := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+	a.RegisterRoute("/api/openapi.json", openAPIHandler(a.openAPI), false, true, "GET")
+	a.ForModule("alertmanager").RegisterRoute("/api/v1/alerts", noop, true, true, "GET")
+	a.ForModule("alertmanager").RegisterRoute("/api/v1/alerts", noop, true, true, "POST")
+	a.ForModule("alertmanager").RegisterRoute("/api/v1/alerts/templates/{name}", noop, true, true, "PUT", "POST")
+	a.ForModule("ruler").RegisterDeprecatedRoute("/api/v1/rules/{namespace}", noop, true, true, "GET")
+	a.ForModule("querier").RegisterRoute("/prometheus/api/v1/label/{name:[a-z]+}/values", noop, true, true, "GET")
+	a.ForModule("query-frontend").RegisterRoute("/prometheus/api/v1/label/{name:[a-z]+}/values", noop, true, true, "GET")
+	a.ForModule("distributor").RegisterRoute("/distributor/ring", noop, false, true, "GET")
+	a.ForModule("compactor").RegisterRoute("/compactor/ring", noop, false, true, "GET")
+
+	resp := httptest.NewRecorder()
+	srv.HTTP.ServeHTTP(resp, httptest.NewRequest("GET", "/api/openapi.json", nil))
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	doc := OpenAPIDocument{}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &doc))
+
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+	assert.Equal(t, []OpenAPITag{{Name: "Alertmanager"}, {Name: "Distributor"}, {Name: "Querier"}, {Name: "Ruler"}}, doc.Tags)
+	assert.Contains(t, doc.Components.SecuritySchemes, "tenantID")
+
+	// Only the routes of the supported modules are included, and the routes registered by the API itself are not.
+	paths := make([]string, 0, len(doc.Paths))
+	for p := range doc.Paths {
+		paths = append(paths, p)
+	}
+	assert.ElementsMatch(t, []string{
+		"/api/v1/alerts",
+		"/api/v1/alerts/templates/{name}",
+		"/api/v1/rules/{namespace}",
+		"/prometheus/api/v1/label/{name}/values",
+		"/distributor/ring",
+	}, paths)
+
+	// Operations registered with separate calls on the same path are merged.
+	alerts := doc.Paths["/api/v1/alerts"]
+	require.Len(t, alerts, 2)
+	assert.Equal(t, "getApiV1Alerts", alerts["get"].OperationID)
+	assert.Equal(t, "postApiV1Alerts", alerts["post"].OperationID)
+	assert.Equal(t, []map[string][]string{{"tenantID": {}}}, alerts["get"].Security)
+
+	templates := doc.Paths["/api/v1/alerts/templates/{name}"]
+	require.Len(t, templates, 2)
+	assert.Equal(t, []OpenAPIParameter{{Name: "name", In: "path", Required: true, Schema: OpenAPISchema{Type: "string"}}}, templates["put"].Parameters)
+
+	assert.True(t, doc.Paths["/api/v1/rules/{namespace}"]["get"].Deprecated)
+	assert.Equal(t, []string{"Querier"}, doc.Paths["/prometheus/api/v1/label/{name}/values"]["get"].Tags)
+	assert.Empty(t, doc.Paths["/distributor/ring"]["get"].Security)
+}
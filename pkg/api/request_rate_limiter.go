@@ -0,0 +1,244 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/grafana/mimir/pkg/tenant"
+	"github.com/grafana/mimir/pkg/util/globalerror"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+// requestRateLimiter enforces the per-tenant limits of the rate of the HTTP requests to each class of routes.
+// The limits are provided after the routes have been registered, because the overrides depend on the API.
+type requestRateLimiter struct {
+	limits atomic.Value // *validation.Overrides
+
+	// buckets holds the *requestRateBucket of each requestRateBucketKey. The buckets which have been refilled
+	// since they were last used are evicted every requestRateBucketsPruneInterval.
+	buckets        sync.Map
+	nextPruneNanos int64
+}
+
+// requestRateBucketsPruneInterval is how often the refilled buckets are evicted.
+const requestRateBucketsPruneInterval = time.Minute
+
+type requestRateBucketKey struct {
+	tenantID   string
+	routeClass string
+}
+
+// requestRateBucket is the token bucket of the requests of a tenant to a class of routes.
+type requestRateBucket struct {
+	mtx    sync.Mutex
+	tokens float64
+	last   time.Time
+
+	// Limit and burst size of the last request, used to find out whether the bucket has been refilled.
+	rateLimit float64
+	burstSize int
+}
+
+func newRequestRateLimiter() *requestRateLimiter {
+	return &requestRateLimiter{}
+}
+
+func (l *requestRateLimiter) setLimits(limits *validation.Overrides) {
+	l.limits.Store(limits)
+}
+
+// requestRateLimitResult is the outcome of the rate limiting of a request, reported to the client by the
+// RateLimit-* headers.
+type requestRateLimitResult struct {
+	allowed bool
+
+	// Limit and burst size of the tenant's requests to the class of routes.
+	rateLimit float64
+	burstSize int
+
+	// Number of requests allowed before the limit is exceeded, and time until all of them are available again.
+	remaining int
+	reset     time.Duration
+
+	// Time until a request is allowed again, if the request has not been allowed.
+	retryAfter time.Duration
+}
+
+// allow consumes a request of the tenants to the class of routes, from the bucket of each tenant. The request
+// is allowed only if all the tenants' buckets allow it, and it isn't consumed from any bucket otherwise. It returns
+// the most restrictive result, or false if the class of routes isn't rate limited for any of the tenants. The tenant
+// IDs must be sorted and distinct, as returned by tenant.TenantIDs, so that the buckets are always locked in the
+// same order.
+func (l *requestRateLimiter) allow(now time.Time, tenantIDs []string, routeClass string) (requestRateLimitResult, bool) {
+	limits, _ := l.limits.Load().(*validation.Overrides)
+	if limits == nil {
+		return requestRateLimitResult{}, false
+	}
+
+	l.pruneBuckets(now)
+
+	type limitedBucket struct {
+		bucket    *requestRateBucket
+		rateLimit float64
+		burstSize int
+	}
+	buckets := make([]limitedBucket, 0, len(tenantIDs))
+	for _, tenantID := range tenantIDs {
+		rateLimit, burstSize := classLimits(limits, tenantID, routeClass)
+		if rateLimit <= 0 {
+			continue
+		}
+		if burstSize <= 0 {
+			burstSize = int(math.Ceil(rateLimit))
+		}
+
+		key := requestRateBucketKey{tenantID: tenantID, routeClass: routeClass}
+		entry, ok := l.buckets.Load(key)
+		if !ok {
+			entry, _ = l.buckets.LoadOrStore(key, &requestRateBucket{tokens: float64(burstSize), last: now})
+		}
+		buckets = append(buckets, limitedBucket{bucket: entry.(*requestRateBucket), rateLimit: rateLimit, burstSize: burstSize})
+	}
+	if len(buckets) == 0 {
+		return requestRateLimitResult{}, false
+	}
+
+	allowed := true
+	for _, b := range buckets {
+		b.bucket.mtx.Lock()
+		defer b.bucket.mtx.Unlock()
+
+		if !b.bucket.refill(now, b.rateLimit, b.burstSize) {
+			allowed = false
+		}
+	}
+
+	var reported requestRateLimitResult
+	for i, b := range buckets {
+		res := b.bucket.take(allowed, b.rateLimit, b.burstSize)
+		if i == 0 || (reported.allowed && !res.allowed) || (res.allowed == reported.allowed && res.remaining < reported.remaining) {
+			reported = res
+		}
+	}
+	return reported, true
+}
+
+// pruneBuckets evicts the buckets refilled since they were last used, which are equivalent to new buckets,
+// at most once every requestRateBucketsPruneInterval. A request concurrently charged to an evicted bucket
+// isn't charged to the new bucket of the tenant, allowing at most one request above the burst size.
+func (l *requestRateLimiter) pruneBuckets(now time.Time) {
+	next := atomic.LoadInt64(&l.nextPruneNanos)
+	if now.UnixNano() < next || !atomic.CompareAndSwapInt64(&l.nextPruneNanos, next, now.Add(requestRateBucketsPruneInterval).UnixNano()) {
+		return
+	}
+
+	l.buckets.Range(func(key, entry interface{}) bool {
+		if entry.(*requestRateBucket).refilled(now) {
+			l.buckets.Delete(key)
+		}
+		return true
+	})
+}
+
+// refill refills the bucket for the time elapsed since the last request, and returns whether it allows a request.
+// The limits may have changed since the last request. It must be called with the bucket locked.
+func (b *requestRateBucket) refill(now time.Time, rateLimit float64, burstSize int) bool {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * rateLimit
+		b.last = now
+	}
+	b.tokens = math.Min(b.tokens, float64(burstSize))
+	b.rateLimit, b.burstSize = rateLimit, burstSize
+
+	return b.tokens >= 1
+}
+
+// take consumes a request from the refilled bucket if allowed, and returns the result of the bucket.
+// It must be called with the bucket locked.
+func (b *requestRateBucket) take(allowed bool, rateLimit float64, burstSize int) requestRateLimitResult {
+	res := requestRateLimitResult{rateLimit: rateLimit, burstSize: burstSize}
+	if allowed {
+		b.tokens--
+		res.allowed = true
+	} else if b.tokens < 1 {
+		res.retryAfter = secondsToDuration((1 - b.tokens) / rateLimit)
+	}
+	res.remaining = int(b.tokens)
+	res.reset = secondsToDuration((float64(burstSize) - b.tokens) / rateLimit)
+
+	return res
+}
+
+// refilled returns whether the bucket would be full at the given time.
+func (b *requestRateBucket) refilled(now time.Time) bool {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	return b.tokens+now.Sub(b.last).Seconds()*b.rateLimit >= float64(b.burstSize)
+}
+
+func classLimits(limits *validation.Overrides, tenantID, routeClass string) (float64, int) {
+	switch routeClass {
+	case routeClassPush:
+		return limits.PushRequestRateLimit(tenantID), limits.PushRequestBurstSize(tenantID)
+	case routeClassQuery:
+		return limits.QueryRequestRateLimit(tenantID), limits.QueryRequestBurstSize(tenantID)
+	default:
+		return limits.AdminRequestRateLimit(tenantID), limits.AdminRequestBurstSize(tenantID)
+	}
+}
+
+// wrap returns the handler enforcing the limits of the rate of the requests of the tenant to the class of routes.
+// It requires the tenant ID to be in the request context, so it must be wrapped by the authentication middleware.
+func (l *requestRateLimiter) wrap(routeClass string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenantIDs, err := tenant.TenantIDs(r.Context())
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// The requests of multiple tenants, like federated queries, count towards the limit of each tenant.
+		reported, limited := l.allow(time.Now(), tenantIDs, routeClass)
+		if !limited {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(reported.burstSize))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(reported.remaining))
+		w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(reported.reset)))
+
+		if !reported.allowed {
+			msg := globalerror.RequestRateLimited.Message(fmt.Sprintf("the request rate limit of the %s endpoints has been exceeded (limit: %g requests/s, burst: %d)", routeClass, reported.rateLimit, reported.burstSize))
+			w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(reported.retryAfter)))
+			globalerror.SetHeader(w.Header(), msg)
+			http.Error(w, msg, http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// SetRequestRateLimits sets the per-tenant limits of the rate of the authenticated HTTP requests to each class
+// of routes, including the routes already registered. The requests aren't rate limited until it's called.
+func (a *API) SetRequestRateLimits(limits *validation.Overrides) {
+	a.requestRateLimiter.setLimits(limits)
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// ceilSeconds returns the duration in seconds, rounded up, as required by the Retry-After and RateLimit-Reset headers.
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,200 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/gorilla/mux"
+	"github.com/grafana/dskit/flagext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/server"
+
+	"github.com/grafana/mimir/pkg/tenant"
+	"github.com/grafana/mimir/pkg/util/globalerror"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestRequestRateLimiter_Allow(t *testing.T) {
+	limits := validation.Limits{}
+	flagext.DefaultValues(&limits)
+	limits.PushRequestRateLimit = 2
+	limits.PushRequestBurstSize = 3
+	limits.QueryRequestRateLimit = 0.5
+
+	overrides, err := validation.NewOverrides(limits, nil)
+	require.NoError(t, err)
+
+	l := newRequestRateLimiter()
+	now := time.Now()
+
+	// The requests aren't rate limited until the limits are set.
+	_, ok := l.allow(now, []string{"user-1"}, routeClassPush)
+	assert.False(t, ok)
+	l.setLimits(overrides)
+
+	// The classes of routes without a limit aren't rate limited.
+	_, ok = l.allow(now, []string{"user-1"}, routeClassAdmin)
+	assert.False(t, ok)
+
+	// The burst is allowed, then the requests are rejected until the bucket is refilled.
+	for i := 2; i >= 0; i-- {
+		res, ok := l.allow(now, []string{"user-1"}, routeClassPush)
+		require.True(t, ok)
+		assert.True(t, res.allowed)
+		assert.Equal(t, 3, res.burstSize)
+		assert.Equal(t, i, res.remaining)
+	}
+
+	res, ok := l.allow(now, []string{"user-1"}, routeClassPush)
+	require.True(t, ok)
+	assert.False(t, res.allowed)
+	assert.Equal(t, 0, res.remaining)
+	assert.Equal(t, 500*time.Millisecond, res.retryAfter)
+	assert.Equal(t, 1500*time.Millisecond, res.reset)
+
+	res, _ = l.allow(now.Add(500*time.Millisecond), []string{"user-1"}, routeClassPush)
+	assert.True(t, res.allowed)
+
+	// Each tenant and each class of routes has its own bucket.
+	res, _ = l.allow(now, []string{"user-2"}, routeClassPush)
+	assert.True(t, res.allowed)
+	assert.Equal(t, 2, res.remaining)
+
+	// The burst size defaults to the rate limit, rounded up.
+	res, _ = l.allow(now, []string{"user-1"}, routeClassQuery)
+	assert.True(t, res.allowed)
+	assert.Equal(t, 1, res.burstSize)
+
+	res, _ = l.allow(now, []string{"user-1"}, routeClassQuery)
+	assert.False(t, res.allowed)
+	assert.Equal(t, 2*time.Second, res.retryAfter)
+
+	// The request of multiple tenants is charged to no tenant if rejected by the limit of one of them.
+	res, _ = l.allow(now, []string{"user-2", "user-3"}, routeClassPush)
+	assert.True(t, res.allowed)
+	assert.Equal(t, 1, res.remaining)
+
+	res, _ = l.allow(now, []string{"user-1", "user-3"}, routeClassPush)
+	assert.False(t, res.allowed)
+	assert.Equal(t, 0, res.remaining)
+
+	res, _ = l.allow(now, []string{"user-3"}, routeClassPush)
+	assert.True(t, res.allowed)
+	assert.Equal(t, 1, res.remaining)
+}
+
+func TestRequestRateLimiter_PruneBuckets(t *testing.T) {
+	limits := validation.Limits{}
+	flagext.DefaultValues(&limits)
+	limits.PushRequestRateLimit = 1
+	limits.PushRequestBurstSize = 100
+
+	overrides, err := validation.NewOverrides(limits, nil)
+	require.NoError(t, err)
+
+	l := newRequestRateLimiter()
+	l.setLimits(overrides)
+	now := time.Now()
+
+	countBuckets := func() int {
+		count := 0
+		l.buckets.Range(func(_, _ interface{}) bool {
+			count++
+			return true
+		})
+		return count
+	}
+
+	l.allow(now, []string{"user-1"}, routeClassPush)
+	for i := 0; i < 80; i++ {
+		l.allow(now, []string{"user-2"}, routeClassPush)
+	}
+	require.Equal(t, 2, countBuckets())
+
+	// The bucket of user-1 has been refilled, but not the one of user-2.
+	now = now.Add(requestRateBucketsPruneInterval + 5*time.Second)
+	l.allow(now, []string{"user-3"}, routeClassPush)
+	assert.Equal(t, 2, countBuckets())
+	_, ok := l.buckets.Load(requestRateBucketKey{tenantID: "user-1", routeClass: routeClassPush})
+	assert.False(t, ok)
+
+	// The buckets are pruned at most once per interval.
+	now = now.Add(requestRateBucketsPruneInterval / 2)
+	l.allow(now, []string{"user-4"}, routeClassPush)
+	assert.Equal(t, 3, countBuckets())
+}
+
+func TestAPI_RequestRateLimits(t *testing.T) {
+	limits := validation.Limits{}
+	flagext.DefaultValues(&limits)
+	limits.PushRequestRateLimit = 0.001
+	limits.PushRequestBurstSize = 1
+
+	overrides, err := validation.NewOverrides(limits, nil)
+	require.NoError(t, err)
+
+	s := &server.Server{HTTP: mux.NewRouter()}
+	a, err := New(Config{}, server.Config{}, s, log.NewNopLogger())
+	require.NoError(t, err)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	a.forRouteClass(routeClassPush).RegisterRoute("/api/v1/push", handler, true, false, "POST")
+	a.forRouteClass(routeClassPush).RegisterRoute("/unauthenticated", handler, false, false, "POST")
+	a.SetRequestRateLimits(overrides)
+
+	serve := func(path, orgID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		if orgID != "" {
+			req.Header.Set("X-Scope-OrgID", orgID)
+		}
+		resp := httptest.NewRecorder()
+		s.HTTP.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("should allow the requests within the limit", func(t *testing.T) {
+		resp := serve("/api/v1/push", "user-1")
+		assert.Equal(t, http.StatusNoContent, resp.Code)
+		assert.Equal(t, "1", resp.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "0", resp.Header().Get("RateLimit-Remaining"))
+		assert.NotEmpty(t, resp.Header().Get("RateLimit-Reset"))
+		assert.Empty(t, resp.Header().Get("Retry-After"))
+	})
+
+	t.Run("should reject the requests above the limit", func(t *testing.T) {
+		resp := serve("/api/v1/push", "user-1")
+		assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+		assert.Contains(t, resp.Body.String(), "the request rate limit of the push endpoints has been exceeded")
+		assert.Equal(t, "err-mimir-request-rate-limited", resp.Header().Get(globalerror.HeaderName))
+		assert.Equal(t, "0", resp.Header().Get("RateLimit-Remaining"))
+		assert.NotEmpty(t, resp.Header().Get("Retry-After"))
+	})
+
+	t.Run("should count the requests of multiple tenants towards the limit of each tenant", func(t *testing.T) {
+		resolver := tenant.DefaultResolver
+		tenant.WithDefaultResolver(tenant.NewMultiResolver())
+		t.Cleanup(func() {
+			tenant.WithDefaultResolver(resolver)
+		})
+
+		assert.Equal(t, http.StatusNoContent, serve("/api/v1/push", "user-2").Code)
+		assert.Equal(t, http.StatusTooManyRequests, serve("/api/v1/push", "user-2|user-3").Code)
+
+		// The rejected request hasn't been charged to user-3.
+		assert.Equal(t, http.StatusNoContent, serve("/api/v1/push", "user-3").Code)
+	})
+
+	t.Run("should not rate limit the unauthenticated routes", func(t *testing.T) {
+		resp := serve("/unauthenticated", "user-1")
+		assert.Equal(t, http.StatusNoContent, resp.Code)
+		assert.Empty(t, resp.Header().Get("RateLimit-Limit"))
+	})
+}
@@ -0,0 +1,91 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package api
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// routeClassPush is the class of the routes ingesting data, like the push API.
+	routeClassPush = "push"
+
+	// routeClassQuery is the class of the routes reading data, like the Prometheus query API.
+	routeClassQuery = "query"
+
+	// routeClassAdmin is the class of all the other routes, like the admin and status pages. It's the default one.
+	routeClassAdmin = "admin"
+)
+
+// RouteClassConfig configures the limits of the HTTP requests to a class of routes.
+type RouteClassConfig struct {
+	Timeout            time.Duration `yaml:"timeout" category:"experimental"`
+	MaxRequestBodySize int64         `yaml:"max_request_body_size" category:"experimental"`
+}
+
+// RegisterFlagsWithPrefix registers the flags of the limits of the class of routes.
+func (cfg *RouteClassConfig) RegisterFlagsWithPrefix(prefix, class string, f *flag.FlagSet) {
+	f.DurationVar(&cfg.Timeout, prefix+"timeout", 0, fmt.Sprintf("Timeout of the HTTP requests to the %s endpoints, after which the request context is canceled. The HTTP server read and write timeouts still apply to all the endpoints, so they must be greater than the timeout of each class of endpoints. 0 to disable.", class))
+	f.Int64Var(&cfg.MaxRequestBodySize, prefix+"max-request-body-size", 0, fmt.Sprintf("Maximum size in bytes of the body of the HTTP requests to the %s endpoints. The requests declaring a larger content length are rejected with the 413 status code, and reading a larger body fails. 0 to disable.", class))
+}
+
+func (cfg *RouteClassConfig) Validate(class string) error {
+	if cfg.Timeout < 0 {
+		return errors.Errorf("the timeout of the %s endpoints must not be negative", class)
+	}
+	if cfg.MaxRequestBodySize < 0 {
+		return errors.Errorf("the max request body size of the %s endpoints must not be negative", class)
+	}
+	return nil
+}
+
+// wrap returns the handler enforcing the limits of the class of routes.
+func (cfg RouteClassConfig) wrap(next http.Handler) http.Handler {
+	if cfg.Timeout <= 0 && cfg.MaxRequestBodySize <= 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cfg.MaxRequestBodySize > 0 {
+			if r.ContentLength > cfg.MaxRequestBodySize {
+				http.Error(w, fmt.Sprintf("request body too large: %d bytes, limit: %d bytes", r.ContentLength, cfg.MaxRequestBodySize), http.StatusRequestEntityTooLarge)
+				return
+			}
+			// The content length may be unknown, so the body is also limited while read.
+			r.Body = http.MaxBytesReader(w, r.Body, cfg.MaxRequestBodySize)
+		}
+
+		if cfg.Timeout > 0 {
+			ctx, cancel := context.WithTimeout(r.Context(), cfg.Timeout)
+			defer cancel()
+			r = r.WithContext(ctx)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// forRouteClass returns an API registering the routes with the limits of the class of routes.
+func (a *API) forRouteClass(class string) *API {
+	classAPI := *a
+	classAPI.routeClass = class
+	return &classAPI
+}
+
+// routeClassConfig returns the limits of the class of the routes registered by the API.
+func (a *API) routeClassConfig() RouteClassConfig {
+	switch a.routeClass {
+	case routeClassPush:
+		return a.cfg.PushRoutes
+	case routeClassQuery:
+		return a.cfg.QueryRoutes
+	default:
+		return a.cfg.AdminRoutes
+	}
+}
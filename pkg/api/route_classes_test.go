@@ -0,0 +1,115 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package api
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/server"
+)
+
+func TestRouteClassConfig_Validate(t *testing.T) {
+	tests := map[string]struct {
+		cfg         RouteClassConfig
+		expectedErr string
+	}{
+		"no limits": {},
+		"valid limits": {
+			cfg: RouteClassConfig{Timeout: time.Minute, MaxRequestBodySize: 1024},
+		},
+		"negative timeout": {
+			cfg:         RouteClassConfig{Timeout: -time.Second},
+			expectedErr: "the timeout of the push endpoints must not be negative",
+		},
+		"negative max request body size": {
+			cfg:         RouteClassConfig{MaxRequestBodySize: -1},
+			expectedErr: "the max request body size of the push endpoints must not be negative",
+		},
+	}
+
+	for name, testData := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := testData.cfg.Validate(routeClassPush)
+			if testData.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, testData.expectedErr)
+			}
+		})
+	}
+}
+
+func TestAPI_RouteClasses(t *testing.T) {
+	cfg := Config{
+		PushRoutes:  RouteClassConfig{Timeout: time.Minute, MaxRequestBodySize: 10},
+		QueryRoutes: RouteClassConfig{Timeout: time.Hour},
+	}
+	s := &server.Server{HTTP: mux.NewRouter()}
+	a, err := New(cfg, server.Config{}, s, log.NewNopLogger())
+	require.NoError(t, err)
+
+	// The handler writes the remaining time before the request deadline, if any, and fails if the body can't be read.
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := ioutil.ReadAll(r.Body); err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		deadline, ok := r.Context().Deadline()
+		if !ok {
+			_, _ = w.Write([]byte("none"))
+			return
+		}
+		_, _ = w.Write([]byte(time.Until(deadline).Round(time.Minute).String()))
+	})
+
+	a.forRouteClass(routeClassPush).RegisterRoute("/api/v1/push", handler, false, false, "POST")
+	a.forRouteClass(routeClassQuery).RegisterRoute("/prometheus/api/v1/query", handler, false, false, "POST")
+	a.RegisterRoute("/config", handler, false, false, "POST")
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		s.HTTP.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("should apply the timeout of the class of the route", func(t *testing.T) {
+		for path, expected := range map[string]string{
+			"/api/v1/push":             "1m0s",
+			"/prometheus/api/v1/query": "1h0m0s",
+			"/config":                  "none",
+		} {
+			resp := serve(httptest.NewRequest(http.MethodPost, path, nil))
+			require.Equal(t, http.StatusOK, resp.Code, path)
+			assert.Equal(t, expected, resp.Body.String(), path)
+		}
+	})
+
+	t.Run("should reject the requests declaring a body larger than the limit", func(t *testing.T) {
+		resp := serve(httptest.NewRequest(http.MethodPost, "/api/v1/push", strings.NewReader("more than ten bytes")))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, resp.Code)
+		assert.Contains(t, resp.Body.String(), "request body too large")
+	})
+
+	t.Run("should fail reading a body larger than the limit of unknown length", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/push", strings.NewReader("more than ten bytes"))
+		req.ContentLength = -1
+
+		resp := serve(req)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, resp.Code)
+		assert.Contains(t, resp.Body.String(), "request body too large")
+	})
+
+	t.Run("should not limit the body of the routes of the other classes", func(t *testing.T) {
+		resp := serve(httptest.NewRequest(http.MethodPost, "/config", strings.NewReader("more than ten bytes")))
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+}
@@ -12,7 +12,6 @@ import (
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/thanos-io/thanos/pkg/cache"
-	"github.com/thanos-io/thanos/pkg/cacheutil"
 )
 
 // Cache is a generic interface. Re-mapping Thanos one for convenience (same packages name make it annoying to use).
@@ -42,16 +41,19 @@ func (cfg *BackendConfig) Validate() error {
 	return nil
 }
 
-func CreateClient(cacheName string, cfg BackendConfig, logger log.Logger, reg prometheus.Registerer) (cache.Cache, error) {
+// CreateClient creates a new cache client based on the input configuration. The schemaVersion is the
+// version of the format of the entries stored in the cache, which must be bumped whenever it changes
+// in an incompatible way.
+func CreateClient(cacheName string, schemaVersion int, cfg BackendConfig, logger log.Logger, reg prometheus.Registerer) (cache.Cache, error) {
 	switch cfg.Backend {
 	case "":
 		// No caching.
 		return nil, nil
 
 	case BackendMemcached:
-		client, err := cacheutil.NewMemcachedClientWithConfig(logger, cacheName, cfg.Memcached.ToMemcachedClientConfig(), reg)
+		client, err := NewMemcachedClient(cacheName, schemaVersion, cfg.Memcached, logger, reg)
 		if err != nil {
-			return nil, errors.Wrapf(err, "failed to create memcached client")
+			return nil, err
 		}
 		return cache.NewMemcachedCache(cacheName, logger, client, reg), nil
 
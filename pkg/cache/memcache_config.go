@@ -8,6 +8,7 @@ package cache
 import (
 	"errors"
 	"flag"
+	"regexp"
 	"strings"
 	"time"
 
@@ -15,8 +16,15 @@ import (
 	"github.com/thanos-io/thanos/pkg/model"
 )
 
+const (
+	maxKeyNamespaceLength = 64
+)
+
 var (
-	ErrNoMemcachedAddresses = errors.New("no memcached addresses configured")
+	ErrNoMemcachedAddresses   = errors.New("no memcached addresses configured")
+	ErrInvalidKeyNamespace    = errors.New("the memcached key namespace can only contain letters, digits, '_', '-' and '.'")
+	ErrKeyNamespaceTooLong    = errors.New("the memcached key namespace is too long")
+	keyNamespaceAllowedRegexp = regexp.MustCompile(`^[a-zA-Z0-9_.-]*$`)
 )
 
 type MemcachedConfig struct {
@@ -28,6 +36,7 @@ type MemcachedConfig struct {
 	MaxGetMultiConcurrency int           `yaml:"max_get_multi_concurrency" category:"advanced"`
 	MaxGetMultiBatchSize   int           `yaml:"max_get_multi_batch_size" category:"advanced"`
 	MaxItemSize            int           `yaml:"max_item_size" category:"advanced"`
+	KeyNamespace           string        `yaml:"key_namespace" category:"experimental"`
 }
 
 func (cfg *MemcachedConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix string) {
@@ -39,6 +48,7 @@ func (cfg *MemcachedConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix stri
 	f.IntVar(&cfg.MaxGetMultiConcurrency, prefix+"max-get-multi-concurrency", 100, "The maximum number of concurrent connections running get operations. If set to 0, concurrency is unlimited.")
 	f.IntVar(&cfg.MaxGetMultiBatchSize, prefix+"max-get-multi-batch-size", 100, "The maximum number of keys a single underlying get operation should run. If more keys are specified, internally keys are split into multiple batches and fetched concurrently, honoring the max concurrency. If set to 0, the max batch size is unlimited.")
 	f.IntVar(&cfg.MaxItemSize, prefix+"max-item-size", 1024*1024, "The maximum size of an item stored in memcached. Bigger items are not stored. If set to 0, no maximum size is enforced.")
+	f.StringVar(&cfg.KeyNamespace, prefix+"key-namespace", "", "Namespace prepended to the keys stored in memcached. Set a different namespace in each Mimir cluster to safely share the same memcached servers between clusters.")
 }
 
 func (cfg *MemcachedConfig) GetAddresses() []string {
@@ -55,6 +65,14 @@ func (cfg *MemcachedConfig) Validate() error {
 		return ErrNoMemcachedAddresses
 	}
 
+	if len(cfg.KeyNamespace) > maxKeyNamespaceLength {
+		return ErrKeyNamespaceTooLong
+	}
+
+	if !keyNamespaceAllowedRegexp.MatchString(cfg.KeyNamespace) {
+		return ErrInvalidKeyNamespace
+	}
+
 	return nil
 }
 
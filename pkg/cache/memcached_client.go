@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package cache
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/cacheutil"
+)
+
+// NewMemcachedClient creates a new memcached client for the cache. The keys of the cache are prefixed
+// with the configured key namespace and the schema version of the cache entries, so that clusters
+// sharing the same memcached servers don't read each other's entries, and entries written with a
+// previous schema version are not read after an upgrade. The schema version must be bumped
+// whenever the format of the entries stored by the cache changes in an incompatible way.
+func NewMemcachedClient(cacheName string, schemaVersion int, cfg MemcachedConfig, logger log.Logger, reg prometheus.Registerer) (cacheutil.RemoteCacheClient, error) {
+	client, err := cacheutil.NewMemcachedClientWithConfig(logger, cacheName, cfg.ToMemcachedClientConfig(), reg)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create memcached client")
+	}
+
+	return newKeyPrefixClient(client, keyPrefix(cfg.KeyNamespace, schemaVersion)), nil
+}
+
+// keyPrefix returns the prefix of the keys of a cache with the given namespace and schema version.
+func keyPrefix(namespace string, schemaVersion int) string {
+	prefix := "v" + strconv.Itoa(schemaVersion) + ":"
+	if namespace != "" {
+		prefix = namespace + ":" + prefix
+	}
+	return prefix
+}
+
+// keyPrefixClient is a cacheutil.RemoteCacheClient prefixing all the keys with a fixed prefix.
+type keyPrefixClient struct {
+	client cacheutil.RemoteCacheClient
+	prefix string
+}
+
+func newKeyPrefixClient(client cacheutil.RemoteCacheClient, prefix string) *keyPrefixClient {
+	return &keyPrefixClient{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+// GetMulti implements cacheutil.RemoteCacheClient. The keys of the returned map are not prefixed.
+func (c *keyPrefixClient) GetMulti(ctx context.Context, keys []string) map[string][]byte {
+	prefixed := make([]string, 0, len(keys))
+	for _, key := range keys {
+		prefixed = append(prefixed, c.prefix+key)
+	}
+
+	found := c.client.GetMulti(ctx, prefixed)
+
+	result := make(map[string][]byte, len(found))
+	for key, value := range found {
+		result[strings.TrimPrefix(key, c.prefix)] = value
+	}
+	return result
+}
+
+// SetAsync implements cacheutil.RemoteCacheClient.
+func (c *keyPrefixClient) SetAsync(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.client.SetAsync(ctx, c.prefix+key, value, ttl)
+}
+
+// Stop implements cacheutil.RemoteCacheClient.
+func (c *keyPrefixClient) Stop() {
+	c.client.Stop()
+}
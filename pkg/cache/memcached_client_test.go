@@ -0,0 +1,102 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyPrefix(t *testing.T) {
+	assert.Equal(t, "v1:", keyPrefix("", 1))
+	assert.Equal(t, "cell-1:v2:", keyPrefix("cell-1", 2))
+}
+
+func TestKeyPrefixClient(t *testing.T) {
+	ctx := context.Background()
+	backend := newMockRemoteCacheClient()
+
+	cell1 := newKeyPrefixClient(backend, keyPrefix("cell-1", 1))
+	cell2 := newKeyPrefixClient(backend, keyPrefix("cell-2", 1))
+	cell1Upgraded := newKeyPrefixClient(backend, keyPrefix("cell-1", 2))
+
+	assert.NoError(t, cell1.SetAsync(ctx, "key-1", []byte("value-1"), time.Minute))
+	assert.NoError(t, cell2.SetAsync(ctx, "key-1", []byte("value-2"), time.Minute))
+
+	// The keys are stored with the prefix.
+	assert.Equal(t, map[string][]byte{
+		"cell-1:v1:key-1": []byte("value-1"),
+		"cell-2:v1:key-1": []byte("value-2"),
+	}, backend.items)
+
+	// Each client only reads its own keys, which are returned without the prefix.
+	assert.Equal(t, map[string][]byte{"key-1": []byte("value-1")}, cell1.GetMulti(ctx, []string{"key-1", "key-2"}))
+	assert.Equal(t, map[string][]byte{"key-1": []byte("value-2")}, cell2.GetMulti(ctx, []string{"key-1", "key-2"}))
+
+	// Entries stored with a previous schema version are not read.
+	assert.Empty(t, cell1Upgraded.GetMulti(ctx, []string{"key-1"}))
+}
+
+func TestMemcachedConfig_Validate(t *testing.T) {
+	tests := map[string]struct {
+		cfg      MemcachedConfig
+		expected error
+	}{
+		"no addresses": {
+			cfg:      MemcachedConfig{},
+			expected: ErrNoMemcachedAddresses,
+		},
+		"no key namespace": {
+			cfg: MemcachedConfig{Addresses: "localhost:11211"},
+		},
+		"valid key namespace": {
+			cfg: MemcachedConfig{Addresses: "localhost:11211", KeyNamespace: "cell-1.prod_eu"},
+		},
+		"key namespace with invalid characters": {
+			cfg:      MemcachedConfig{Addresses: "localhost:11211", KeyNamespace: "cell 1"},
+			expected: ErrInvalidKeyNamespace,
+		},
+		"key namespace with the separator": {
+			cfg:      MemcachedConfig{Addresses: "localhost:11211", KeyNamespace: "cell:1"},
+			expected: ErrInvalidKeyNamespace,
+		},
+		"key namespace too long": {
+			cfg:      MemcachedConfig{Addresses: "localhost:11211", KeyNamespace: string(make([]byte, maxKeyNamespaceLength+1))},
+			expected: ErrKeyNamespaceTooLong,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			assert.Equal(t, testData.expected, testData.cfg.Validate())
+		})
+	}
+}
+
+type mockRemoteCacheClient struct {
+	items map[string][]byte
+}
+
+func newMockRemoteCacheClient() *mockRemoteCacheClient {
+	return &mockRemoteCacheClient{items: map[string][]byte{}}
+}
+
+func (c *mockRemoteCacheClient) GetMulti(_ context.Context, keys []string) map[string][]byte {
+	found := map[string][]byte{}
+	for _, key := range keys {
+		if value, ok := c.items[key]; ok {
+			found[key] = value
+		}
+	}
+	return found
+}
+
+func (c *mockRemoteCacheClient) SetAsync(_ context.Context, key string, value []byte, _ time.Duration) error {
+	c.items[key] = value
+	return nil
+}
+
+func (c *mockRemoteCacheClient) Stop() {}
@@ -8,6 +8,7 @@ package compactor
 import (
 	"context"
 	"fmt"
+	"path"
 	"sync"
 	"time"
 
@@ -63,8 +64,10 @@ type BlocksCleaner struct {
 	blocksCleanedTotal          prometheus.Counter
 	blocksFailedTotal           prometheus.Counter
 	blocksMarkedForDeletion     prometheus.Counter
+	blocksMarkedForTenantDel    prometheus.Counter
 	tenantBlocks                *prometheus.GaugeVec
 	tenantMarkedBlocks          *prometheus.GaugeVec
+	tenantLockedMarkedBlocks    *prometheus.GaugeVec
 	tenantPartialBlocks         *prometheus.GaugeVec
 	tenantBucketIndexLastUpdate *prometheus.GaugeVec
 }
@@ -106,6 +109,11 @@ func NewBlocksCleaner(cfg BlocksCleanerConfig, bucketClient objstore.Bucket, own
 			Help:        blocksMarkedForDeletionHelp,
 			ConstLabels: prometheus.Labels{"reason": "retention"},
 		}),
+		blocksMarkedForTenantDel: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name:        blocksMarkedForDeletionName,
+			Help:        blocksMarkedForDeletionHelp,
+			ConstLabels: prometheus.Labels{"reason": "tenant-deletion"},
+		}),
 
 		// The following metrics don't have the "cortex_compactor" prefix because not strictly related to
 		// the compactor. They're just tracked by the compactor because it's the most logical place where these
@@ -118,6 +126,10 @@ func NewBlocksCleaner(cfg BlocksCleanerConfig, bucketClient objstore.Bucket, own
 			Name: "cortex_bucket_blocks_marked_for_deletion_count",
 			Help: "Total number of blocks marked for deletion in the bucket.",
 		}, []string{"user"}),
+		tenantLockedMarkedBlocks: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_bucket_blocks_marked_for_deletion_locked_count",
+			Help: "Total number of blocks marked for deletion in the bucket, whose deletion is deferred because their objects are still locked.",
+		}, []string{"user"}),
 		tenantPartialBlocks: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
 			Name: "cortex_bucket_blocks_partials_count",
 			Help: "Total number of partial blocks.",
@@ -181,6 +193,7 @@ func (c *BlocksCleaner) cleanUsers(ctx context.Context) error {
 		if !isActive[userID] && !isDeleted[userID] {
 			c.tenantBlocks.DeleteLabelValues(userID)
 			c.tenantMarkedBlocks.DeleteLabelValues(userID)
+			c.tenantLockedMarkedBlocks.DeleteLabelValues(userID)
 			c.tenantPartialBlocks.DeleteLabelValues(userID)
 			c.tenantBucketIndexLastUpdate.DeleteLabelValues(userID)
 		}
@@ -215,7 +228,9 @@ func (c *BlocksCleaner) deleteUserMarkedForDeletion(ctx context.Context, userID
 	}
 	c.tenantBucketIndexLastUpdate.DeleteLabelValues(userID)
 
-	var deletedBlocks, failed int
+	lockPeriod := c.cfgProvider.CompactorBlocksObjectLockPeriod(userID)
+
+	var deletedBlocks, failed, locked int
 	err := userBucket.Iter(ctx, "", func(name string) error {
 		if err := ctx.Err(); err != nil {
 			return err
@@ -226,6 +241,20 @@ func (c *BlocksCleaner) deleteUserMarkedForDeletion(ctx context.Context, userID
 			return nil
 		}
 
+		if lockPeriod > 0 {
+			isLocked, err := c.markBlockForTenantDeletionIfLocked(ctx, userBucket, id, lockPeriod, userLogger)
+			if err != nil {
+				failed++
+				c.blocksFailedTotal.Inc()
+				level.Warn(userLogger).Log("msg", "failed to check whether the block is locked", "block", id, "err", err)
+				return nil // Continue with other blocks.
+			}
+			if isLocked {
+				locked++
+				return nil
+			}
+		}
+
 		err := block.Delete(ctx, userLogger, userBucket, id)
 		if err != nil {
 			failed++
@@ -244,20 +273,28 @@ func (c *BlocksCleaner) deleteUserMarkedForDeletion(ctx context.Context, userID
 		return err
 	}
 
-	if failed > 0 {
+	if failed > 0 || locked > 0 {
 		// The number of blocks left in the storage is equal to the number of blocks we failed
-		// to delete. We also consider them all marked for deletion given the next run will try
-		// to delete them again.
-		c.tenantBlocks.WithLabelValues(userID).Set(float64(failed))
-		c.tenantMarkedBlocks.WithLabelValues(userID).Set(float64(failed))
+		// to delete or are locked. We also consider them all marked for deletion given the next
+		// run will try to delete them again.
+		c.tenantBlocks.WithLabelValues(userID).Set(float64(failed + locked))
+		c.tenantMarkedBlocks.WithLabelValues(userID).Set(float64(failed + locked))
+		c.tenantLockedMarkedBlocks.WithLabelValues(userID).Set(float64(locked))
 		c.tenantPartialBlocks.WithLabelValues(userID).Set(0)
 
-		return errors.Errorf("failed to delete %d blocks", failed)
+		if failed > 0 {
+			return errors.Errorf("failed to delete %d blocks", failed)
+		}
+
+		// The tenant deletion is completed once the locked blocks are deleted.
+		level.Info(userLogger).Log("msg", "deferred deletion of blocks locked in the storage for tenant marked for deletion", "deletedBlocks", deletedBlocks, "lockedBlocks", locked)
+		return nil
 	}
 
 	// Given all blocks have been deleted, we can also remove the metrics.
 	c.tenantBlocks.DeleteLabelValues(userID)
 	c.tenantMarkedBlocks.DeleteLabelValues(userID)
+	c.tenantLockedMarkedBlocks.DeleteLabelValues(userID)
 	c.tenantPartialBlocks.DeleteLabelValues(userID)
 
 	if deletedBlocks > 0 {
@@ -318,6 +355,9 @@ func (c *BlocksCleaner) cleanUser(ctx context.Context, userID string) (returnErr
 		}
 	}()
 
+	// The objects of the blocks can't be deleted while locked in the storage.
+	lockPeriod := c.cfgProvider.CompactorBlocksObjectLockPeriod(userID)
+
 	// Read the bucket index.
 	idx, err := bucketindex.ReadIndex(ctx, c.bucketClient, userID, c.cfgProvider, c.logger)
 	if errors.Is(err, bucketindex.ErrIndexCorrupted) {
@@ -334,6 +374,9 @@ func (c *BlocksCleaner) cleanUser(ctx context.Context, userID string) (returnErr
 		// We do not want to stop the remaining work in the cleaner if an
 		// error occurs here. Errors are logged in the function.
 		retention := c.cfgProvider.CompactorBlocksRetentionPeriod(userID)
+		if err := validateObjectLockPeriod(retention, lockPeriod); err != nil {
+			level.Warn(userLogger).Log("msg", "blocks exceeding the retention period are deleted only once unlocked", "err", err)
+		}
 		c.applyUserRetentionPeriod(ctx, idx, retention, userBucket, userLogger)
 	}
 
@@ -344,12 +387,12 @@ func (c *BlocksCleaner) cleanUser(ctx context.Context, userID string) (returnErr
 		return err
 	}
 
-	c.deleteBlocksMarkedForDeletion(ctx, idx, userBucket, userLogger)
+	locked := c.deleteBlocksMarkedForDeletion(ctx, idx, lockPeriod, userBucket, userLogger)
 
 	// Partial blocks with a deletion mark can be cleaned up. This is a best effort, so we don't return
 	// error if the cleanup of partial blocks fail.
 	if len(partials) > 0 {
-		c.cleanUserPartialBlocks(ctx, partials, idx, userBucket, userLogger)
+		c.cleanUserPartialBlocks(ctx, partials, idx, lockPeriod, userBucket, userLogger)
 	}
 
 	// Upload the updated index to the storage.
@@ -359,21 +402,40 @@ func (c *BlocksCleaner) cleanUser(ctx context.Context, userID string) (returnErr
 
 	c.tenantBlocks.WithLabelValues(userID).Set(float64(len(idx.Blocks)))
 	c.tenantMarkedBlocks.WithLabelValues(userID).Set(float64(len(idx.BlockDeletionMarks)))
+	c.tenantLockedMarkedBlocks.WithLabelValues(userID).Set(float64(locked))
 	c.tenantPartialBlocks.WithLabelValues(userID).Set(float64(len(partials)))
 	c.tenantBucketIndexLastUpdate.WithLabelValues(userID).SetToCurrentTime()
 
 	return nil
 }
 
-// Concurrently deletes blocks marked for deletion, and removes blocks from index.
-func (c *BlocksCleaner) deleteBlocksMarkedForDeletion(ctx context.Context, idx *bucketindex.Index, userBucket objstore.Bucket, userLogger log.Logger) {
+// Concurrently deletes blocks marked for deletion, and removes blocks from index. Returns the number of blocks
+// whose deletion is deferred because their objects are still locked in the storage.
+func (c *BlocksCleaner) deleteBlocksMarkedForDeletion(ctx context.Context, idx *bucketindex.Index, lockPeriod time.Duration, userBucket objstore.Bucket, userLogger log.Logger) (locked int) {
 	blocksToDelete := make([]ulid.ULID, 0, len(idx.BlockDeletionMarks))
 
+	uploadedAt := make(map[ulid.ULID]time.Time, len(idx.Blocks))
+	for _, b := range idx.Blocks {
+		uploadedAt[b.ID] = time.Unix(b.UploadedAt, 0)
+	}
+
 	// Collect blocks marked for deletion into buffered channel.
 	for _, mark := range idx.BlockDeletionMarks {
 		if time.Since(mark.GetDeletionTime()).Seconds() <= c.cfg.DeletionDelay.Seconds() {
 			continue
 		}
+
+		// The block is kept marked for deletion until its objects, including the deletion mark, are unlocked.
+		blockUploadedAt, ok := uploadedAt[mark.ID]
+		if !ok {
+			blockUploadedAt = ulid.Time(mark.ID.Time())
+		}
+		if lockedUntil := objectsLockedUntil(lockPeriod, blockUploadedAt, mark.GetDeletionTime()); time.Now().Before(lockedUntil) {
+			locked++
+			level.Debug(userLogger).Log("msg", "deferred deletion of block marked for deletion, because its objects are locked", "block", mark.ID, "lockedUntil", lockedUntil)
+			continue
+		}
+
 		blocksToDelete = append(blocksToDelete, mark.ID)
 	}
 
@@ -398,11 +460,13 @@ func (c *BlocksCleaner) deleteBlocksMarkedForDeletion(ctx context.Context, idx *
 		level.Info(userLogger).Log("msg", "deleted block marked for deletion", "block", blockID)
 		return nil
 	})
+
+	return locked
 }
 
 // cleanUserPartialBlocks delete partial blocks which are safe to be deleted. The provided partials map
 // and index are updated accordingly.
-func (c *BlocksCleaner) cleanUserPartialBlocks(ctx context.Context, partials map[ulid.ULID]error, idx *bucketindex.Index, userBucket objstore.InstrumentedBucket, userLogger log.Logger) {
+func (c *BlocksCleaner) cleanUserPartialBlocks(ctx context.Context, partials map[ulid.ULID]error, idx *bucketindex.Index, lockPeriod time.Duration, userBucket objstore.InstrumentedBucket, userLogger log.Logger) {
 	// Collect all blocks with missing meta.json into buffered channel.
 	blocks := make([]ulid.ULID, 0, len(partials))
 
@@ -422,7 +486,8 @@ func (c *BlocksCleaner) cleanUserPartialBlocks(ctx context.Context, partials map
 		blockID := blocks[jobIdx]
 
 		// We can safely delete only partial blocks with a deletion mark.
-		err := metadata.ReadMarker(ctx, userLogger, userBucket, blockID.String(), &metadata.DeletionMark{})
+		mark := metadata.DeletionMark{}
+		err := metadata.ReadMarker(ctx, userLogger, userBucket, blockID.String(), &mark)
 		if errors.Is(err, metadata.ErrorMarkerNotFound) {
 			return nil
 		}
@@ -431,6 +496,13 @@ func (c *BlocksCleaner) cleanUserPartialBlocks(ctx context.Context, partials map
 			return nil
 		}
 
+		// The partial block is deleted once its objects are unlocked. The time the block has been uploaded
+		// is unknown, so the block creation time is used.
+		if lockedUntil := objectsLockedUntil(lockPeriod, ulid.Time(blockID.Time()), time.Unix(mark.DeletionTime, 0)); time.Now().Before(lockedUntil) {
+			level.Debug(userLogger).Log("msg", "deferred deletion of partial block marked for deletion, because its objects are locked", "block", blockID, "lockedUntil", lockedUntil)
+			return nil
+		}
+
 		// Hard-delete partial blocks having a deletion mark, even if the deletion threshold has not
 		// been reached yet.
 		if err := block.Delete(ctx, userLogger, userBucket, blockID); err != nil {
@@ -493,3 +565,67 @@ func listBlocksOutsideRetentionPeriod(idx *bucketindex.Index, threshold time.Tim
 
 	return
 }
+
+// markBlockForTenantDeletionIfLocked returns whether the objects of the block are still locked in the storage,
+// in which case the block is marked for deletion, if not already marked, instead of being deleted.
+func (c *BlocksCleaner) markBlockForTenantDeletionIfLocked(ctx context.Context, userBucket objstore.InstrumentedBucket, blockID ulid.ULID, lockPeriod time.Duration, userLogger log.Logger) (bool, error) {
+	// The meta.json is the last object uploaded, so it's the one locked until later. If it's missing,
+	// the block is partial and the block creation time is used.
+	uploadedAt := ulid.Time(blockID.Time())
+	attrs, err := userBucket.Attributes(ctx, path.Join(blockID.String(), block.MetaFilename))
+	if err == nil {
+		uploadedAt = attrs.LastModified
+	} else if !userBucket.IsObjNotFoundErr(err) {
+		return false, err
+	}
+
+	mark := metadata.DeletionMark{}
+	err = metadata.ReadMarker(ctx, userLogger, userBucket, blockID.String(), &mark)
+	marked := err == nil
+	if err != nil && !errors.Is(err, metadata.ErrorMarkerNotFound) {
+		return false, err
+	}
+
+	markedAt := time.Time{}
+	if marked {
+		markedAt = time.Unix(mark.DeletionTime, 0)
+	}
+	lockedUntil := objectsLockedUntil(lockPeriod, uploadedAt, markedAt)
+	if !time.Now().Before(lockedUntil) {
+		return false, nil
+	}
+
+	if !marked {
+		if err := block.MarkForDeletion(ctx, userLogger, userBucket, blockID, "tenant deleted, block locked in the storage", c.blocksMarkedForTenantDel); err != nil {
+			return false, err
+		}
+	}
+
+	level.Debug(userLogger).Log("msg", "deferred deletion of block, because its objects are locked", "block", blockID, "lockedUntil", lockedUntil)
+	return true, nil
+}
+
+// objectsLockedUntil returns the time until which the objects written at the input times are locked in the
+// storage, given the lock period. Returns the zero time if the objects are not locked.
+func objectsLockedUntil(lockPeriod time.Duration, writtenAt ...time.Time) time.Time {
+	if lockPeriod <= 0 {
+		return time.Time{}
+	}
+
+	latest := time.Time{}
+	for _, t := range writtenAt {
+		if t.After(latest) {
+			latest = t
+		}
+	}
+	return latest.Add(lockPeriod)
+}
+
+// validateObjectLockPeriod returns an error if the blocks can't be deleted once they exceed the retention period,
+// because they're still locked in the storage.
+func validateObjectLockPeriod(retention, lockPeriod time.Duration) error {
+	if retention > 0 && lockPeriod > retention {
+		return errors.Errorf(errInvalidBlocksObjectLockPeriod, lockPeriod, retention)
+	}
+	return nil
+}
@@ -31,6 +31,7 @@ import (
 	mimir_testutil "github.com/grafana/mimir/pkg/storage/tsdb/testutil"
 	"github.com/grafana/mimir/pkg/util"
 	"github.com/grafana/mimir/pkg/util/test"
+	"github.com/grafana/mimir/pkg/util/validation"
 )
 
 type testBlocksCleanerOptions struct {
@@ -565,6 +566,7 @@ func TestBlocksCleaner_ShouldRemoveBlocksOutsideRetentionPeriod(t *testing.T) {
 			# HELP cortex_compactor_blocks_marked_for_deletion_total Total number of blocks marked for deletion in compactor.
 			# TYPE cortex_compactor_blocks_marked_for_deletion_total counter
 			cortex_compactor_blocks_marked_for_deletion_total{reason="retention"} 0
+			cortex_compactor_blocks_marked_for_deletion_total{reason="tenant-deletion"} 0
 			`),
 			"cortex_bucket_blocks_count",
 			"cortex_bucket_blocks_marked_for_deletion_count",
@@ -606,6 +608,7 @@ func TestBlocksCleaner_ShouldRemoveBlocksOutsideRetentionPeriod(t *testing.T) {
 			# HELP cortex_compactor_blocks_marked_for_deletion_total Total number of blocks marked for deletion in compactor.
 			# TYPE cortex_compactor_blocks_marked_for_deletion_total counter
 			cortex_compactor_blocks_marked_for_deletion_total{reason="retention"} 1
+			cortex_compactor_blocks_marked_for_deletion_total{reason="tenant-deletion"} 0
 			`),
 			"cortex_bucket_blocks_count",
 			"cortex_bucket_blocks_marked_for_deletion_count",
@@ -644,6 +647,7 @@ func TestBlocksCleaner_ShouldRemoveBlocksOutsideRetentionPeriod(t *testing.T) {
 			# HELP cortex_compactor_blocks_marked_for_deletion_total Total number of blocks marked for deletion in compactor.
 			# TYPE cortex_compactor_blocks_marked_for_deletion_total counter
 			cortex_compactor_blocks_marked_for_deletion_total{reason="retention"} 1
+			cortex_compactor_blocks_marked_for_deletion_total{reason="tenant-deletion"} 0
 			`),
 			"cortex_bucket_blocks_count",
 			"cortex_bucket_blocks_marked_for_deletion_count",
@@ -673,6 +677,7 @@ func TestBlocksCleaner_ShouldRemoveBlocksOutsideRetentionPeriod(t *testing.T) {
 			# HELP cortex_compactor_blocks_marked_for_deletion_total Total number of blocks marked for deletion in compactor.
 			# TYPE cortex_compactor_blocks_marked_for_deletion_total counter
 			cortex_compactor_blocks_marked_for_deletion_total{reason="retention"} 3
+			cortex_compactor_blocks_marked_for_deletion_total{reason="tenant-deletion"} 0
 			`),
 			"cortex_bucket_blocks_count",
 			"cortex_bucket_blocks_marked_for_deletion_count",
@@ -681,6 +686,90 @@ func TestBlocksCleaner_ShouldRemoveBlocksOutsideRetentionPeriod(t *testing.T) {
 	}
 }
 
+func TestBlocksCleaner_ShouldDeferDeletionOfLockedBlocks(t *testing.T) {
+	bucketClient, _ := mimir_testutil.PrepareFilesystemBucket(t)
+	bucketClient = bucketindex.BucketWithGlobalMarkers(bucketClient)
+
+	// Create blocks, uploaded now.
+	ctx := context.Background()
+	now := time.Now()
+	deletionDelay := 12 * time.Hour
+	block1 := createTSDBBlock(t, bucketClient, "user-1", 10, 20, 2, nil)
+	block2 := createTSDBBlock(t, bucketClient, "user-1", 20, 30, 2, nil)
+	block3 := createTSDBBlock(t, bucketClient, "user-2", 10, 20, 2, nil)
+	createDeletionMark(t, bucketClient, "user-1", block1, now.Add(-deletionDelay).Add(-time.Hour))            // Block reached the deletion threshold.
+	createDeletionMark(t, bucketClient, "user-1", block2, now.Add(-deletionDelay).Add(-time.Hour))            // Partial block reached the deletion threshold.
+	require.NoError(t, bucketClient.Delete(ctx, path.Join("user-1", block2.String(), metadata.MetaFilename))) // Partial block.
+
+	// User-2 marked for deletion.
+	require.NoError(t, tsdb.WriteTenantDeletionMark(ctx, bucketClient, "user-2", nil, tsdb.NewTenantDeletionMark(now)))
+
+	cfg := BlocksCleanerConfig{
+		DeletionDelay:           deletionDelay,
+		CleanupInterval:         time.Minute,
+		CleanupConcurrency:      1,
+		DeleteBlocksConcurrency: 1,
+	}
+
+	logger := log.NewNopLogger()
+	reg := prometheus.NewPedanticRegistry()
+	cfgProvider := newMockConfigProvider()
+	cfgProvider.objectLockPeriods["user-1"] = 24 * time.Hour
+	cfgProvider.objectLockPeriods["user-2"] = 24 * time.Hour
+
+	cleaner := NewBlocksCleaner(cfg, bucketClient, tsdb.AllUsers, cfgProvider, logger, reg)
+
+	assertExists := func(user string, name string, expectExists bool) {
+		exists, err := bucketClient.Exists(ctx, path.Join(user, name))
+		require.NoError(t, err)
+		assert.Equal(t, expectExists, exists, name)
+	}
+
+	// The blocks are locked, so their deletion is deferred and the blocks of the deleted tenant are marked for deletion.
+	{
+		require.NoError(t, cleaner.cleanUsers(ctx))
+		assertExists("user-1", path.Join(block1.String(), metadata.MetaFilename), true)
+		assertExists("user-1", bucketindex.BlockDeletionMarkFilepath(block1), true)
+		assertExists("user-1", path.Join(block2.String(), metadata.DeletionMarkFilename), true)
+		assertExists("user-2", path.Join(block3.String(), metadata.MetaFilename), true)
+		assertExists("user-2", path.Join(block3.String(), metadata.DeletionMarkFilename), true)
+
+		assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP cortex_bucket_blocks_marked_for_deletion_locked_count Total number of blocks marked for deletion in the bucket, whose deletion is deferred because their objects are still locked.
+			# TYPE cortex_bucket_blocks_marked_for_deletion_locked_count gauge
+			cortex_bucket_blocks_marked_for_deletion_locked_count{user="user-1"} 2
+			cortex_bucket_blocks_marked_for_deletion_locked_count{user="user-2"} 1
+			# HELP cortex_compactor_blocks_marked_for_deletion_total Total number of blocks marked for deletion in compactor.
+			# TYPE cortex_compactor_blocks_marked_for_deletion_total counter
+			cortex_compactor_blocks_marked_for_deletion_total{reason="retention"} 0
+			cortex_compactor_blocks_marked_for_deletion_total{reason="tenant-deletion"} 1
+			`),
+			"cortex_bucket_blocks_marked_for_deletion_locked_count",
+			"cortex_compactor_blocks_marked_for_deletion_total",
+		))
+	}
+
+	// Once the blocks are unlocked, they're deleted.
+	{
+		cfgProvider.objectLockPeriods["user-1"] = 0
+		cfgProvider.objectLockPeriods["user-2"] = 0
+
+		require.NoError(t, cleaner.cleanUsers(ctx))
+		assertExists("user-1", path.Join(block1.String(), metadata.MetaFilename), false)
+		assertExists("user-1", bucketindex.BlockDeletionMarkFilepath(block1), false)
+		assertExists("user-1", path.Join(block2.String(), metadata.DeletionMarkFilename), false)
+		assertExists("user-2", path.Join(block3.String(), metadata.MetaFilename), false)
+
+		assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP cortex_bucket_blocks_marked_for_deletion_locked_count Total number of blocks marked for deletion in the bucket, whose deletion is deferred because their objects are still locked.
+			# TYPE cortex_bucket_blocks_marked_for_deletion_locked_count gauge
+			cortex_bucket_blocks_marked_for_deletion_locked_count{user="user-1"} 0
+			`),
+			"cortex_bucket_blocks_marked_for_deletion_locked_count",
+		))
+	}
+}
+
 type mockBucketFailure struct {
 	objstore.Bucket
 
@@ -695,17 +784,25 @@ func (m *mockBucketFailure) Delete(ctx context.Context, name string) error {
 }
 
 type mockConfigProvider struct {
-	userRetentionPeriods map[string]time.Duration
-	splitAndMergeShards  map[string]int
-	instancesShardSize   map[string]int
-	splitGroups          map[string]int
+	userRetentionPeriods    map[string]time.Duration
+	splitAndMergeShards     map[string]int
+	instancesShardSize      map[string]int
+	instancesMaxShardSize   map[string]int
+	jobsPerCompactor        map[string]int
+	splitGroups             map[string]int
+	verticalMergeStrategies map[string]string
+	objectLockPeriods       map[string]time.Duration
 }
 
 func newMockConfigProvider() *mockConfigProvider {
 	return &mockConfigProvider{
-		userRetentionPeriods: make(map[string]time.Duration),
-		splitAndMergeShards:  make(map[string]int),
-		splitGroups:          make(map[string]int),
+		userRetentionPeriods:    make(map[string]time.Duration),
+		splitAndMergeShards:     make(map[string]int),
+		splitGroups:             make(map[string]int),
+		verticalMergeStrategies: make(map[string]string),
+		objectLockPeriods:       make(map[string]time.Duration),
+		instancesMaxShardSize:   make(map[string]int),
+		jobsPerCompactor:        make(map[string]int),
 	}
 }
 
@@ -737,6 +834,34 @@ func (m *mockConfigProvider) CompactorTenantShardSize(user string) int {
 	return 0
 }
 
+func (m *mockConfigProvider) CompactorTenantMaxShardSize(user string) int {
+	if result, ok := m.instancesMaxShardSize[user]; ok {
+		return result
+	}
+	return 0
+}
+
+func (m *mockConfigProvider) CompactorTenantShardSizeJobsPerCompactor(user string) int {
+	if result, ok := m.jobsPerCompactor[user]; ok {
+		return result
+	}
+	return 10
+}
+
+func (m *mockConfigProvider) CompactorVerticalMergeStrategy(user string) string {
+	if result, ok := m.verticalMergeStrategies[user]; ok {
+		return result
+	}
+	return validation.VerticalMergeStrategyChain
+}
+
+func (m *mockConfigProvider) CompactorBlocksObjectLockPeriod(user string) time.Duration {
+	if result, ok := m.objectLockPeriods[user]; ok {
+		return result
+	}
+	return 0
+}
+
 func (m *mockConfigProvider) S3SSEType(user string) string {
 	return ""
 }
@@ -12,6 +12,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
@@ -35,6 +36,7 @@ import (
 
 	"github.com/grafana/mimir/pkg/storage/sharding"
 	mimit_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/bloom"
 	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
 )
 
@@ -273,6 +275,19 @@ type Compactor interface {
 	CompactWithSplitting(dest string, dirs []string, open []*tsdb.Block, shardCount uint64) (result []ulid.ULID, _ error)
 }
 
+// readBlocksAnnotations returns the merged annotations of the blocks stored in the input directories.
+func readBlocksAnnotations(dirs []string) (mimit_tsdb.BlockAnnotations, error) {
+	all := make([]mimit_tsdb.BlockAnnotations, 0, len(dirs))
+	for _, dir := range dirs {
+		annotations, err := mimit_tsdb.ReadBlockAnnotationsFromDir(dir)
+		if err != nil {
+			return nil, errors.Wrapf(err, "read annotations of block %s", dir)
+		}
+		all = append(all, annotations)
+	}
+	return mimit_tsdb.MergeBlockAnnotations(all...), nil
+}
+
 // runCompactionJob plans and runs a single compaction against the provided job. The compacted result
 // is uploaded into the bucket the blocks were retrieved from.
 func (c *BucketCompactor) runCompactionJob(ctx context.Context, job *Job) (shouldRerun bool, compIDs []ulid.ULID, rerr error) {
@@ -359,6 +374,12 @@ func (c *BucketCompactor) runCompactionJob(ctx context.Context, job *Job) (shoul
 		blocksToCompactDirs[ix] = filepath.Join(subDir, meta.ULID.String())
 	}
 
+	// Annotations of the source blocks are propagated to the compacted blocks.
+	annotations, err := readBlocksAnnotations(blocksToCompactDirs)
+	if err != nil {
+		return false, nil, err
+	}
+
 	elapsed := time.Since(downloadBegin)
 	level.Info(jobLogger).Log("msg", "downloaded and verified blocks; compacting blocks", "blocks", len(blocksToCompactDirs), "plan", fmt.Sprintf("%v", blocksToCompactDirs), "duration", elapsed, "duration_ms", elapsed.Milliseconds())
 
@@ -429,11 +450,33 @@ func (c *BucketCompactor) runCompactionJob(ctx context.Context, job *Job) (shoul
 			return errors.Wrapf(err, "invalid result block %s", bdir)
 		}
 
+		if c.writeSeriesBloomFilter {
+			if _, err := bloom.WriteForBlock(bdir, bloom.DefaultFalsePositiveRate); err != nil {
+				return errors.Wrapf(err, "failed to build series bloom filter for block %s", bdir)
+			}
+		}
+
+		// The meta.json is re-encoded by block.Upload(), which doesn't preserve the annotations,
+		// so they're set while it's uploaded.
+		bkt := c.bkt
+		if len(annotations) > 0 {
+			bkt = mimit_tsdb.BucketWithBlockAnnotations(bkt, blockToUpload.ulid, annotations)
+		}
+
 		begin := time.Now()
-		if err := block.Upload(ctx, jobLogger, c.bkt, bdir, job.hashFunc); err != nil {
+		if err := block.Upload(ctx, jobLogger, bkt, bdir, job.hashFunc); err != nil {
 			return errors.Wrapf(err, "upload of %s failed", blockToUpload.ulid)
 		}
 
+		// The bloom filter is optional, so it's uploaded once the block has been uploaded, in order not to leave
+		// an orphan filter in the bucket if the block upload fails. The store-gateways loading the block before the
+		// filter is available, or if its upload fails, just don't use it.
+		if c.writeSeriesBloomFilter {
+			if err := bloom.Upload(ctx, jobLogger, c.bkt, bdir, blockToUpload.ulid); err != nil {
+				level.Warn(jobLogger).Log("msg", "failed to upload the series bloom filter, the block will be queried without it", "result_block", blockToUpload.ulid, "err", err)
+			}
+		}
+
 		elapsed := time.Since(begin)
 		level.Info(jobLogger).Log("msg", "uploaded block", "result_block", blockToUpload.ulid, "duration", elapsed, "duration_ms", elapsed.Milliseconds(), "external_labels", labels.FromMap(newLabels))
 		return nil
@@ -607,6 +650,7 @@ type BucketCompactorMetrics struct {
 	garbageCollectedBlocks       prometheus.Counter
 	blocksMarkedForDeletion      prometheus.Counter
 	blocksMarkedForNoCompact     prometheus.Counter
+	groupCompactionRunsLeased    prometheus.Counter
 }
 
 // NewBucketCompactorMetrics makes a new BucketCompactorMetrics.
@@ -635,6 +679,10 @@ func NewBucketCompactorMetrics(blocksMarkedForDeletion, garbageCollectedBlocks p
 			ConstLabels: prometheus.Labels{"reason": metadata.OutOfOrderChunksNoCompactReason},
 		}),
 		garbageCollectedBlocks: garbageCollectedBlocks,
+		groupCompactionRunsLeased: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_group_compaction_runs_skipped_leased_total",
+			Help: "Total number of group compactions skipped, or canceled, because the job was leased by another compactor.",
+		}),
 	}
 }
 
@@ -656,10 +704,28 @@ type BucketCompactor struct {
 	bkt                            objstore.Bucket
 	concurrency                    int
 	skipBlocksWithOutOfOrderChunks bool
+	writeSeriesBloomFilter         bool
 	ownJob                         ownCompactionJobFunc
+	leases                         *jobLeases
 	sortJobs                       JobsOrderFunc
 	blockSyncConcurrency           int
 	metrics                        *BucketCompactorMetrics
+
+	// Number of jobs planned for the tenant by the first compaction pass, before filtering out
+	// the jobs not owned by this compactor. Used to estimate the tenant's backlog.
+	planned     bool
+	plannedJobs int
+
+	// Jobs waiting for a worker and jobs being compacted, exposed by JobsStatus.
+	jobsMtx     sync.Mutex
+	queuedJobs  []*Job
+	runningJobs map[*Job]time.Time
+
+	// Optional history the completed jobs are recorded to.
+	completedJobs *completedJobsHistory
+
+	// Optional tracker of the tenant's compaction progress, updated with the jobs planned and completed.
+	progress *tenantProgressTracker
 }
 
 // NewBucketCompactor creates a new bucket compactor.
@@ -673,7 +739,9 @@ func NewBucketCompactor(
 	bkt objstore.Bucket,
 	concurrency int,
 	skipBlocksWithOutOfOrderChunks bool,
+	writeSeriesBloomFilter bool,
 	ownJob ownCompactionJobFunc,
+	leases *jobLeases,
 	sortJobs JobsOrderFunc,
 	blockSyncConcurrency int,
 	metrics *BucketCompactorMetrics,
@@ -691,10 +759,13 @@ func NewBucketCompactor(
 		bkt:                            bkt,
 		concurrency:                    concurrency,
 		skipBlocksWithOutOfOrderChunks: skipBlocksWithOutOfOrderChunks,
+		writeSeriesBloomFilter:         writeSeriesBloomFilter,
 		ownJob:                         ownJob,
+		leases:                         leases,
 		sortJobs:                       sortJobs,
 		blockSyncConcurrency:           blockSyncConcurrency,
 		metrics:                        metrics,
+		runningJobs:                    map[*Job]time.Time{},
 	}, nil
 }
 
@@ -748,9 +819,42 @@ func (c *BucketCompactor) Compact(ctx context.Context, maxCompactionTime time.Du
 						continue
 					}
 
+					// Ensure the job isn't run concurrently by another compactor, which would upload overlapping blocks.
+					jobCtx := workCtx
+					var lease *heldJobLease
+					if c.leases != nil {
+						var (
+							acquired bool
+							err      error
+						)
+						lease, acquired, err = c.leases.acquire(workCtx, g)
+						if err != nil {
+							level.Info(c.logger).Log("msg", "skipped compaction because unable to take the lease on the job", "groupKey", g.Key(), "err", err)
+							continue
+						} else if !acquired {
+							level.Warn(c.logger).Log("msg", "skipped compaction because the job is leased by another compactor", "groupKey", g.Key())
+							c.metrics.groupCompactionRunsLeased.Inc()
+							continue
+						}
+						jobCtx = lease.ctx
+					}
+
 					c.metrics.groupCompactionRunsStarted.Inc()
 
-					shouldRerunJob, compactedBlockIDs, err := c.runCompactionJob(workCtx, g)
+					c.startJob(g)
+					shouldRerunJob, compactedBlockIDs, err := c.runCompactionJob(jobCtx, g)
+					c.finishJob(g, err)
+					if lease != nil {
+						lease.release()
+
+						// The job has been canceled because another compactor may have taken it over, which
+						// isn't a failure of the compaction: the job is run again at the next compaction run.
+						if err != nil && lease.lost.Load() {
+							level.Warn(c.logger).Log("msg", "compaction canceled because the lease on the job has been lost", "groupKey", g.Key(), "err", err)
+							c.metrics.groupCompactionRunsLeased.Inc()
+							continue
+						}
+					}
 					if err == nil {
 						c.metrics.groupCompactionRunsCompleted.Inc()
 						if hasNonZeroULIDs(compactedBlockIDs) {
@@ -816,6 +920,14 @@ func (c *BucketCompactor) Compact(ctx context.Context, maxCompactionTime time.Du
 			return errors.Wrap(err, "build compaction jobs")
 		}
 
+		if !c.planned {
+			c.planned = true
+			c.plannedJobs = len(jobs)
+		}
+		if c.progress != nil {
+			c.progress.planned(jobs)
+		}
+
 		// There is another check just before we start processing the job, but we can avoid sending it
 		// to the goroutine in the first place.
 		jobs, err = c.filterOwnJobs(jobs)
@@ -842,19 +954,22 @@ func (c *BucketCompactor) Compact(ctx context.Context, maxCompactionTime time.Du
 		maxCompactionTimeReached := false
 		// Send all jobs found during this pass to the compaction workers.
 		var jobErrs errutil.MultiError
+		c.setQueuedJobs(jobs)
 	jobLoop:
-		for _, g := range jobs {
+		for i, g := range jobs {
 			select {
 			case jobErr := <-errChan:
 				jobErrs.Add(jobErr)
 				break jobLoop
 			case jobChan <- g:
+				c.setQueuedJobs(jobs[i+1:])
 			case <-maxCompactionTimeChan:
 				maxCompactionTimeReached = true
 				level.Info(c.logger).Log("msg", "max compaction time reached, no more compactions will be started")
 				break jobLoop
 			}
 		}
+		c.setQueuedJobs(nil)
 		close(jobChan)
 		wg.Wait()
 
@@ -878,6 +993,74 @@ func (c *BucketCompactor) Compact(ctx context.Context, maxCompactionTime time.Du
 	return nil
 }
 
+func (c *BucketCompactor) setQueuedJobs(jobs []*Job) {
+	c.jobsMtx.Lock()
+	c.queuedJobs = jobs
+	c.jobsMtx.Unlock()
+}
+
+func (c *BucketCompactor) startJob(job *Job) {
+	c.jobsMtx.Lock()
+	c.runningJobs[job] = time.Now()
+	c.jobsMtx.Unlock()
+}
+
+// finishJob removes the job from the running jobs, and records it to the completed jobs history and
+// to the progress tracker, if any.
+func (c *BucketCompactor) finishJob(job *Job, err error) {
+	c.jobsMtx.Lock()
+	startedAt := c.runningJobs[job]
+	delete(c.runningJobs, job)
+	c.jobsMtx.Unlock()
+
+	if c.completedJobs != nil {
+		c.completedJobs.add(job, startedAt, time.Now(), err)
+	}
+	if c.progress != nil && err == nil {
+		c.progress.completed()
+	}
+}
+
+// RunningJobStatus is the state of a compaction job being run.
+type RunningJobStatus struct {
+	Key             string  `json:"key"`
+	DurationSeconds float64 `json:"duration_seconds"`
+}
+
+// JobsStatus is the state of the compaction jobs of a BucketCompactor.
+type JobsStatus struct {
+	// Keys of the jobs waiting for a compaction worker, in the order they will be run.
+	Queued []string `json:"queued"`
+
+	// Jobs being compacted, sorted by decreasing duration.
+	Running []RunningJobStatus `json:"running"`
+}
+
+// JobsStatus returns the jobs waiting for a compaction worker and the jobs being compacted.
+func (c *BucketCompactor) JobsStatus(now time.Time) JobsStatus {
+	c.jobsMtx.Lock()
+	defer c.jobsMtx.Unlock()
+
+	status := JobsStatus{
+		Queued:  make([]string, 0, len(c.queuedJobs)),
+		Running: make([]RunningJobStatus, 0, len(c.runningJobs)),
+	}
+	for _, job := range c.queuedJobs {
+		status.Queued = append(status.Queued, job.Key())
+	}
+	for job, startedAt := range c.runningJobs {
+		status.Running = append(status.Running, RunningJobStatus{Key: job.Key(), DurationSeconds: now.Sub(startedAt).Seconds()})
+	}
+
+	sort.Slice(status.Running, func(i, j int) bool {
+		if status.Running[i].DurationSeconds != status.Running[j].DurationSeconds {
+			return status.Running[i].DurationSeconds > status.Running[j].DurationSeconds
+		}
+		return status.Running[i].Key < status.Running[j].Key
+	})
+	return status
+}
+
 func (c *BucketCompactor) filterOwnJobs(jobs []*Job) ([]*Job, error) {
 	for ix := 0; ix < len(jobs); {
 		// Skip any job which doesn't belong to this compactor instance.
@@ -43,6 +43,7 @@ import (
 	"github.com/thanos-io/thanos/pkg/block/metadata"
 	"github.com/thanos-io/thanos/pkg/objstore"
 
+	"github.com/grafana/mimir/pkg/storage/tsdb/bloom"
 	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
 )
 
@@ -250,7 +251,7 @@ func TestGroupCompactE2E(t *testing.T) {
 		planner := NewSplitAndMergePlanner([]int64{1000, 3000})
 		grouper := NewSplitAndMergeGrouper("user-1", []int64{1000, 3000}, 0, 0, logger)
 		metrics := NewBucketCompactorMetrics(blocksMarkedForDeletion, garbageCollectedBlocks, prometheus.NewPedanticRegistry())
-		bComp, err := NewBucketCompactor(logger, sy, grouper, planner, comp, dir, bkt, 2, true, ownAllJobs, sortJobsByNewestBlocksFirst, 4, metrics)
+		bComp, err := NewBucketCompactor(logger, sy, grouper, planner, comp, dir, bkt, 2, true, true, ownAllJobs, nil, sortJobsByNewestBlocksFirst, 4, metrics)
 		require.NoError(t, err)
 
 		// Compaction on empty should not fail.
@@ -418,6 +419,13 @@ func TestGroupCompactE2E(t *testing.T) {
 			assert.True(t, labels.Equal(extLabels, labels.FromMap(meta.Thanos.Labels)), "ext labels does not match")
 			assert.Equal(t, int64(124), meta.Thanos.Downsample.Resolution)
 			assert.True(t, len(meta.Thanos.SegmentFiles) > 0, "compacted blocks have segment files set")
+
+			// Check the series bloom filter has been uploaded.
+			filter, err := bloom.ReadFromBucket(ctx, bkt, meta.ULID)
+			require.NoError(t, err)
+			require.NotNil(t, filter)
+			assert.True(t, filter.MayContainLabel("a", "6"))
+			assert.True(t, filter.MayContainLabel("b", "2"))
 		}
 		{
 			meta, ok := others[defaultGroupKey(124, extLabels2)]
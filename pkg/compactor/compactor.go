@@ -16,6 +16,7 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-kit/log"
@@ -28,6 +29,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
 	"github.com/thanos-io/thanos/pkg/objstore"
 
 	"github.com/grafana/mimir/pkg/storage/bucket"
@@ -35,6 +37,7 @@ import (
 	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
 	"github.com/grafana/mimir/pkg/util"
 	util_log "github.com/grafana/mimir/pkg/util/log"
+	"github.com/grafana/mimir/pkg/util/validation"
 )
 
 const (
@@ -57,6 +60,7 @@ var (
 	errInvalidMaxOpeningBlocksConcurrency = fmt.Errorf("invalid max-opening-blocks-concurrency value, must be positive")
 	errInvalidMaxClosingBlocksConcurrency = fmt.Errorf("invalid max-closing-blocks-concurrency value, must be positive")
 	errInvalidSymbolFlushersConcurrency   = fmt.Errorf("invalid symbols-flushers-concurrency value, must be positive")
+	errInvalidBlocksObjectLockPeriod      = "the blocks object lock period (%s) must not be greater than the blocks retention period (%s), otherwise the blocks exceeding the retention can't be deleted until they're unlocked"
 	RingOp                                = ring.NewOp([]ring.InstanceState{ring.ACTIVE}, nil)
 )
 
@@ -107,12 +111,22 @@ type Config struct {
 
 	CompactionJobsOrder string `yaml:"compaction_jobs_order" category:"advanced"`
 
+	SeriesBloomFilterEnabled bool `yaml:"series_bloom_filter_enabled" category:"experimental"`
+
+	JobLeaseTTL time.Duration `yaml:"job_lease_ttl" category:"experimental"`
+
+	DryRun bool `yaml:"dry_run" category:"experimental"`
+
 	// No need to add options to customize the retry backoff,
 	// given the defaults should be fine, but allow to override
 	// it in tests.
 	retryMinBackoff time.Duration `yaml:"-"`
 	retryMaxBackoff time.Duration `yaml:"-"`
 
+	// The vertical merge strategy the BlocksCompactorFactory should build the compactor for.
+	// Factories not supporting it can ignore it.
+	verticalMergeStrategy string `yaml:"-"`
+
 	// Allow downstream projects to customise the blocks compactor.
 	BlocksGrouperFactory   BlocksGrouperFactory   `yaml:"-"`
 	BlocksCompactorFactory BlocksCompactorFactory `yaml:"-"`
@@ -147,11 +161,15 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	f.IntVar(&cfg.MaxClosingBlocksConcurrency, "compactor.max-closing-blocks-concurrency", 1, "Max number of blocks that can be closed concurrently during split compaction. Note that closing of newly compacted block uses a lot of memory for writing index.")
 	f.IntVar(&cfg.SymbolsFlushersConcurrency, "compactor.symbols-flushers-concurrency", 1, "Number of symbols flushers used when doing split compaction.")
 
+	f.BoolVar(&cfg.SeriesBloomFilterEnabled, "compactor.series-bloom-filter-enabled", false, "If enabled, the compactor writes a bloom filter over the series label pairs of each compacted block. Store-gateways can use it to skip blocks which don't contain the label pairs requested by a query.")
+	f.DurationVar(&cfg.JobLeaseTTL, "compactor.job-lease-ttl", 0, "If positive, the compactor takes a lease on each compaction job, stored in the tenant's object storage prefix and renewed until the job completes, so that compactors with an overlapping sharding can't run the same job concurrently. The lease is taken over by another compactor if not renewed within this period. 0 to disable.")
+	f.BoolVar(&cfg.DryRun, "compactor.dry-run", false, "If enabled, the compactor only plans the compaction jobs of each tenant and logs the jobs it would run, without compacting any block. The blocks cleaner doesn't run, so the compactor doesn't write to or delete from the object storage.")
+
 	f.Var(&cfg.EnabledTenants, "compactor.enabled-tenants", "Comma separated list of tenants that can be compacted. If specified, only these tenants will be compacted by compactor, otherwise all tenants can be compacted. Subject to sharding.")
 	f.Var(&cfg.DisabledTenants, "compactor.disabled-tenants", "Comma separated list of tenants that cannot be compacted by this compactor. If specified, and compactor would normally pick given tenant for compaction (via -compactor.enabled-tenants or sharding), it will be ignored instead.")
 }
 
-func (cfg *Config) Validate() error {
+func (cfg *Config) Validate(limits validation.Limits) error {
 	// Each block range period should be divisible by the previous one.
 	for i := 1; i < len(cfg.BlockRanges); i++ {
 		if cfg.BlockRanges[i]%cfg.BlockRanges[i-1] != 0 {
@@ -173,6 +191,10 @@ func (cfg *Config) Validate() error {
 		return errInvalidCompactionOrder
 	}
 
+	if err := validateObjectLockPeriod(time.Duration(limits.CompactorBlocksRetentionPeriod), time.Duration(limits.CompactorBlocksObjectLockPeriod)); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -183,6 +205,10 @@ type ConfigProvider interface {
 	// CompactorBlocksRetentionPeriod returns the retention period for a given user.
 	CompactorBlocksRetentionPeriod(user string) time.Duration
 
+	// CompactorBlocksObjectLockPeriod returns the minimum time the objects of the blocks are locked in the
+	// storage after being written for a given user. 0 = objects are not locked.
+	CompactorBlocksObjectLockPeriod(user string) time.Duration
+
 	// CompactorSplitAndMergeShards returns the number of shards to use when splitting blocks.
 	CompactorSplitAndMergeShards(userID string) int
 
@@ -192,6 +218,18 @@ type ConfigProvider interface {
 
 	// CompactorTenantShardSize returns number of compactors that this user can use. 0 = all compactors.
 	CompactorTenantShardSize(userID string) int
+
+	// CompactorTenantMaxShardSize returns the max number of compactors the user's shard can be automatically
+	// grown to, when the user has a backlog of compaction jobs. 0 = disabled.
+	CompactorTenantMaxShardSize(userID string) int
+
+	// CompactorTenantShardSizeJobsPerCompactor returns the number of pending compaction jobs per compactor
+	// above which the user's shard is grown.
+	CompactorTenantShardSizeJobsPerCompactor(userID string) int
+
+	// CompactorVerticalMergeStrategy returns the strategy used to resolve samples with the same timestamp
+	// and different values when compacting overlapping blocks.
+	CompactorVerticalMergeStrategy(userID string) string
 }
 
 // MultitenantCompactor is a multi-tenant TSDB blocks compactor based on Thanos.
@@ -218,6 +256,9 @@ type MultitenantCompactor struct {
 	blocksCompactor Compactor
 	blocksPlanner   Planner
 
+	// Compactors used for tenants configured with a non-default vertical merge strategy, keyed by strategy.
+	blocksCompactorsByStrategy map[string]Compactor
+
 	// Client used to run operations on the bucket storing blocks.
 	bucketClient objstore.Bucket
 
@@ -230,6 +271,31 @@ type MultitenantCompactor struct {
 	shardingStrategy shardingStrategy
 	jobsOrder        JobsOrderFunc
 
+	// Owner of the compaction job leases taken by this compactor, unique for each compactor process.
+	jobLeaseOwner string
+
+	// Compactor shard size of each tenant, automatically grown while the tenant has a backlog of compaction jobs.
+	tenantShardSizes *tenantShardSizes
+
+	// State of the running compaction, exposed by QueuesDebugHandler and StatusHandler.
+	compactionStateMtx        sync.Mutex
+	pendingTenants            []string
+	compactingTenant          string
+	compactingSince           time.Time
+	tenantCompactor           *BucketCompactor
+	lastSuccessfulCompactions map[string]time.Time // Keyed by tenant owned by this compactor.
+
+	// Jobs recently completed by this compactor, exposed by StatusHandler.
+	completedJobs *completedJobsHistory
+
+	// Compaction progress of the tenants owned by this compactor, exposed as metrics and by StatusHandler.
+	compactionProgress *compactionProgress
+
+	// Compactions enqueued by TriggerCompactionHandler, keyed by tenant and protected by compactionStateMtx.
+	// The channel wakes up the compaction loop when a compaction is triggered.
+	triggeredCompactions   map[string]compactionTimeRange
+	triggeredCompactionsCh chan struct{}
+
 	// Metrics.
 	compactionRunsStarted          prometheus.Counter
 	compactionRunsCompleted        prometheus.Counter
@@ -241,6 +307,8 @@ type MultitenantCompactor struct {
 	compactionRunFailedTenants     prometheus.Gauge
 	compactionRunInterval          prometheus.Gauge
 	blocksMarkedForDeletion        prometheus.Counter
+	blocksManuallyMarkedForDel     prometheus.Counter
+	blocksManuallyMarkedNoCompact  prometheus.Counter
 	garbageCollectedBlocks         prometheus.Counter
 
 	// Metrics shared across all BucketCompactor instances.
@@ -296,6 +364,12 @@ func newMultitenantCompactor(
 		blocksGrouperFactory:   blocksGrouperFactory,
 		blocksCompactorFactory: blocksCompactorFactory,
 
+		lastSuccessfulCompactions: map[string]time.Time{},
+		completedJobs:             newCompletedJobsHistory(maxCompletedJobsHistory),
+		compactionProgress:        newCompactionProgress(registerer),
+		triggeredCompactions:      map[string]compactionTimeRange{},
+		triggeredCompactionsCh:    make(chan struct{}, 1),
+
 		compactionRunsStarted: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
 			Name: "cortex_compactor_runs_started_total",
 			Help: "Total number of compaction runs started.",
@@ -337,6 +411,16 @@ func newMultitenantCompactor(
 			Help:        blocksMarkedForDeletionHelp,
 			ConstLabels: prometheus.Labels{"reason": "compaction"},
 		}),
+		blocksManuallyMarkedForDel: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name:        blocksMarkedForDeletionName,
+			Help:        blocksMarkedForDeletionHelp,
+			ConstLabels: prometheus.Labels{"reason": "manual"},
+		}),
+		blocksManuallyMarkedNoCompact: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name:        "cortex_compactor_blocks_marked_for_no_compaction_total",
+			Help:        "Total number of blocks that were marked for no-compaction.",
+			ConstLabels: prometheus.Labels{"reason": string(metadata.ManualNoCompactReason)},
+		}),
 		garbageCollectedBlocks: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
 			Name: "cortex_compactor_garbage_collected_blocks_total",
 			Help: "Total number of blocks marked for deletion by compactor.",
@@ -381,15 +465,36 @@ func (c *MultitenantCompactor) starting(ctx context.Context) error {
 		return errors.Wrap(err, "failed to initialize compactor dependencies")
 	}
 
+	// Create a compactor for each non-default vertical merge strategy. Their metrics are not registered,
+	// because they would clash with the ones of the default compactor.
+	c.blocksCompactorsByStrategy = map[string]Compactor{}
+	for _, strategy := range verticalMergeStrategies {
+		if strategy == validation.VerticalMergeStrategyChain {
+			continue
+		}
+
+		cfg := c.compactorCfg
+		cfg.verticalMergeStrategy = strategy
+		c.blocksCompactorsByStrategy[strategy], _, err = c.blocksCompactorFactory(ctx, cfg, c.logger, nil)
+		if err != nil {
+			return errors.Wrapf(err, "failed to initialize compactor for the %s vertical merge strategy", strategy)
+		}
+	}
+
 	// Wrap the bucket client to write block deletion marks in the global location too.
 	c.bucketClient = bucketindex.BucketWithGlobalMarkers(c.bucketClient)
 
 	// Initialize the compactors ring if sharding is enabled.
-	lifecyclerCfg := c.compactorCfg.ShardingRing.ToLifecyclerConfig()
+	lifecyclerCfg, err := c.compactorCfg.ShardingRing.ToLifecyclerConfig()
+	if err != nil {
+		return errors.Wrap(err, "unable to initialize compactor ring lifecycler")
+	}
+
 	c.ringLifecycler, err = ring.NewLifecycler(lifecyclerCfg, ring.NewNoopFlushTransferer(), "compactor", CompactorRingKey, false, c.logger, prometheus.WrapRegistererWithPrefix("cortex_", c.registerer))
 	if err != nil {
 		return errors.Wrap(err, "unable to initialize compactor ring lifecycler")
 	}
+	c.jobLeaseOwner = newJobLeaseOwner(c.ringLifecycler.ID)
 
 	c.ring, err = ring.New(lifecyclerCfg.RingConfig, "compactor", CompactorRingKey, c.logger, prometheus.WrapRegistererWithPrefix("cortex_", c.registerer))
 	if err != nil {
@@ -440,7 +545,14 @@ func (c *MultitenantCompactor) starting(ctx context.Context) error {
 	}
 
 	allowedTenants := util.NewAllowedTenants(c.compactorCfg.EnabledTenants, c.compactorCfg.DisabledTenants)
-	c.shardingStrategy = newSplitAndMergeShardingStrategy(allowedTenants, c.ring, c.ringLifecycler, c.cfgProvider)
+	c.tenantShardSizes = newTenantShardSizes(c.bucketClient, c.cfgProvider, c.logger)
+	c.shardingStrategy = newSplitAndMergeShardingStrategy(allowedTenants, c.ring, c.ringLifecycler, c.tenantShardSizes.shardSize)
+
+	// In dry-run mode the compactor doesn't modify the bucket, so the blocks cleaner doesn't run.
+	if c.compactorCfg.DryRun {
+		level.Info(c.logger).Log("msg", "compactor running in dry-run mode, the compaction jobs are planned but not run")
+		return nil
+	}
 
 	// Create the blocks cleaner (service).
 	c.blocksCleaner = NewBlocksCleaner(BlocksCleanerConfig{
@@ -463,7 +575,9 @@ func (c *MultitenantCompactor) starting(ctx context.Context) error {
 func (c *MultitenantCompactor) stopping(_ error) error {
 	ctx := context.Background()
 
-	services.StopAndAwaitTerminated(ctx, c.blocksCleaner) //nolint:errcheck
+	if c.blocksCleaner != nil {
+		services.StopAndAwaitTerminated(ctx, c.blocksCleaner) //nolint:errcheck
+	}
 	if c.ringSubservices != nil {
 		return services.StopManagerAndAwaitStopped(ctx, c.ringSubservices)
 	}
@@ -481,6 +595,8 @@ func (c *MultitenantCompactor) running(ctx context.Context) error {
 		select {
 		case <-ticker.C:
 			c.compactUsers(ctx)
+		case <-c.triggeredCompactionsCh:
+			c.compactTriggeredUsers(ctx)
 		case <-ctx.Done():
 			return nil
 		case err := <-c.ringSubservicesWatcher.Chan():
@@ -527,15 +643,26 @@ func (c *MultitenantCompactor) compactUsers(ctx context.Context) {
 		users[i], users[j] = users[j], users[i]
 	})
 
+	defer c.setPendingTenants(nil)
+
 	// Keep track of users owned by this shard, so that we can delete the local files for all other users.
 	ownedUsers := map[string]struct{}{}
-	for _, userID := range users {
+	for i, userID := range users {
+		c.setPendingTenants(users[i+1:])
+
 		// Ensure the context has not been canceled (ie. compactor shutdown has been triggered).
 		if ctx.Err() != nil {
 			level.Info(c.logger).Log("msg", "interrupting compaction of user blocks", "err", err)
 			return
 		}
 
+		// Ensure the user's shard size is up-to-date, in case it has been grown or shrunk by another compactor.
+		if err := c.tenantShardSizes.sync(ctx, userID); err != nil {
+			c.compactionRunSkippedTenants.Inc()
+			level.Warn(c.logger).Log("msg", "unable to read the compactor shard size of user", "user", userID, "err", err)
+			continue
+		}
+
 		// Ensure the user ID belongs to our shard.
 		if owned, err := c.shardingStrategy.compactorOwnUser(userID); err != nil {
 			c.compactionRunSkippedTenants.Inc()
@@ -561,7 +688,7 @@ func (c *MultitenantCompactor) compactUsers(ctx context.Context) {
 
 		level.Info(c.logger).Log("msg", "starting compaction of user blocks", "user", userID)
 
-		if err = c.compactUserWithRetries(ctx, userID); err != nil {
+		if err = c.compactUserWithRetries(ctx, userID, fullCompactionTimeRange); err != nil {
 			c.compactionRunFailedTenants.Inc()
 			compactionErrorCount++
 			level.Error(c.logger).Log("msg", "failed to compact user blocks", "user", userID, "err", err)
@@ -569,9 +696,14 @@ func (c *MultitenantCompactor) compactUsers(ctx context.Context) {
 		}
 
 		c.compactionRunSucceededTenants.Inc()
+		c.setLastSuccessfulCompaction(userID, time.Now())
 		level.Info(c.logger).Log("msg", "successfully compacted user blocks", "user", userID)
 	}
 
+	// Forget the last successful compaction and the progress of the tenants not owned anymore.
+	c.retainLastSuccessfulCompactions(ownedUsers)
+	c.compactionProgress.retain(ownedUsers)
+
 	// Delete local files for unowned tenants, if there are any. This cleans up
 	// leftover local files for tenants that belong to different compactors now,
 	// or have been deleted completely.
@@ -602,7 +734,7 @@ func (c *MultitenantCompactor) compactUsers(ctx context.Context) {
 	succeeded = true
 }
 
-func (c *MultitenantCompactor) compactUserWithRetries(ctx context.Context, userID string) error {
+func (c *MultitenantCompactor) compactUserWithRetries(ctx context.Context, userID string, timeRange compactionTimeRange) error {
 	var lastErr error
 
 	retries := backoff.New(ctx, backoff.Config{
@@ -612,7 +744,7 @@ func (c *MultitenantCompactor) compactUserWithRetries(ctx context.Context, userI
 	})
 
 	for retries.Ongoing() {
-		lastErr = c.compactUser(ctx, userID)
+		lastErr = c.compactUser(ctx, userID, timeRange)
 		if lastErr == nil {
 			return nil
 		}
@@ -623,13 +755,81 @@ func (c *MultitenantCompactor) compactUserWithRetries(ctx context.Context, userI
 	return lastErr
 }
 
-func (c *MultitenantCompactor) compactUser(ctx context.Context, userID string) error {
+// compactUser runs the compaction of the user's jobs overlapping the time range.
+func (c *MultitenantCompactor) compactUser(ctx context.Context, userID string, timeRange compactionTimeRange) error {
+	if c.compactorCfg.DryRun {
+		return c.dryRunUser(ctx, userID, timeRange)
+	}
+
 	bucket := bucket.NewUserBucketClient(userID, c.bucketClient, c.cfgProvider)
 	reg := prometheus.NewRegistry()
 	defer c.syncerMetrics.gatherThanosSyncerMetrics(reg)
 
 	ulogger := util_log.WithUserID(userID, c.logger)
 
+	syncer, err := c.newUserMetaSyncer(userID, bucket, ulogger, reg)
+	if err != nil {
+		return err
+	}
+
+	var leases *jobLeases
+	if c.compactorCfg.JobLeaseTTL > 0 {
+		leases = newJobLeases(bucket, c.jobLeaseOwner, c.compactorCfg.JobLeaseTTL, ulogger)
+	}
+
+	ownJob := c.shardingStrategy.ownJob
+	if timeRange != fullCompactionTimeRange {
+		ownJob = func(job *Job) (bool, error) {
+			if !timeRange.overlaps(job) {
+				return false, nil
+			}
+			return c.shardingStrategy.ownJob(job)
+		}
+	}
+
+	compactor, err := NewBucketCompactor(
+		ulogger,
+		syncer,
+		c.blocksGrouperFactory(ctx, c.compactorCfg, c.cfgProvider, userID, ulogger, reg),
+		c.blocksPlanner,
+		c.blocksCompactorForUser(userID),
+		path.Join(c.compactorCfg.DataDir, "compact"),
+		bucket,
+		c.compactorCfg.CompactionConcurrency,
+		true, // Skip blocks with out of order chunks, and mark them for no-compaction.
+		c.compactorCfg.SeriesBloomFilterEnabled,
+		ownJob,
+		leases,
+		c.jobsOrder,
+		c.compactorCfg.BlockSyncConcurrency,
+		c.bucketCompactorMetrics,
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create bucket compactor")
+	}
+
+	compactor.completedJobs = c.completedJobs
+	compactor.progress = c.compactionProgress.forTenant(userID)
+	c.setCompactingTenant(userID, compactor)
+	defer c.setCompactingTenant("", nil)
+
+	if err := compactor.Compact(ctx, c.compactorCfg.MaxCompactionTime); err != nil {
+		return errors.Wrap(err, "compaction")
+	}
+
+	// Grow or shrink the user's shard based on the jobs planned in the first compaction pass. Only the
+	// compactor running the blocks cleaner for the user updates it, to avoid concurrent updates.
+	if owned, err := c.shardingStrategy.blocksCleanerOwnUser(userID); err == nil && owned {
+		if err := c.tenantShardSizes.update(ctx, userID, compactor.plannedJobs); err != nil {
+			level.Warn(ulogger).Log("msg", "failed to update the compactor shard size of user", "err", err)
+		}
+	}
+
+	return nil
+}
+
+// newUserMetaSyncer returns the syncer of the metas of the user's blocks to compact.
+func (c *MultitenantCompactor) newUserMetaSyncer(userID string, bucket objstore.InstrumentedBucket, ulogger log.Logger, reg prometheus.Registerer) (*Syncer, error) {
 	// While fetching blocks, we filter out blocks that were marked for deletion by using ExcludeMarkedForDeletionFilter.
 	// No delay is used -- all blocks with deletion marker are ignored, and not considered for compaction.
 	excludeMarkedForDeletionFilter := NewExcludeMarkedForDeletionFilter(bucket)
@@ -658,7 +858,7 @@ func (c *MultitenantCompactor) compactUser(ctx context.Context, userID string) e
 		fetcherFilters,
 	)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	syncer, err := NewMetaSyncer(
@@ -672,33 +872,18 @@ func (c *MultitenantCompactor) compactUser(ctx context.Context, userID string) e
 		c.garbageCollectedBlocks,
 	)
 	if err != nil {
-		return errors.Wrap(err, "failed to create syncer")
-	}
-
-	compactor, err := NewBucketCompactor(
-		ulogger,
-		syncer,
-		c.blocksGrouperFactory(ctx, c.compactorCfg, c.cfgProvider, userID, ulogger, reg),
-		c.blocksPlanner,
-		c.blocksCompactor,
-		path.Join(c.compactorCfg.DataDir, "compact"),
-		bucket,
-		c.compactorCfg.CompactionConcurrency,
-		true, // Skip blocks with out of order chunks, and mark them for no-compaction.
-		c.shardingStrategy.ownJob,
-		c.jobsOrder,
-		c.compactorCfg.BlockSyncConcurrency,
-		c.bucketCompactorMetrics,
-	)
-	if err != nil {
-		return errors.Wrap(err, "failed to create bucket compactor")
+		return nil, errors.Wrap(err, "failed to create syncer")
 	}
+	return syncer, nil
+}
 
-	if err := compactor.Compact(ctx, c.compactorCfg.MaxCompactionTime); err != nil {
-		return errors.Wrap(err, "compaction")
+// blocksCompactorForUser returns the compactor to use for the given user, based on the configured vertical merge strategy.
+// The strategy is validated when the limits are loaded.
+func (c *MultitenantCompactor) blocksCompactorForUser(userID string) Compactor {
+	if compactor, ok := c.blocksCompactorsByStrategy[c.cfgProvider.CompactorVerticalMergeStrategy(userID)]; ok {
+		return compactor
 	}
-
-	return nil
+	return c.blocksCompactor
 }
 
 func (c *MultitenantCompactor) discoverUsersWithRetries(ctx context.Context) ([]string, error) {
@@ -750,15 +935,15 @@ type splitAndMergeShardingStrategy struct {
 	allowedTenants *util.AllowedTenants
 	ring           *ring.Ring
 	ringLifecycler *ring.Lifecycler
-	configProvider ConfigProvider
+	shardSize      func(userID string) int
 }
 
-func newSplitAndMergeShardingStrategy(allowedTenants *util.AllowedTenants, ring *ring.Ring, ringLifecycler *ring.Lifecycler, configProvider ConfigProvider) *splitAndMergeShardingStrategy {
+func newSplitAndMergeShardingStrategy(allowedTenants *util.AllowedTenants, ring *ring.Ring, ringLifecycler *ring.Lifecycler, shardSize func(userID string) int) *splitAndMergeShardingStrategy {
 	return &splitAndMergeShardingStrategy{
 		allowedTenants: allowedTenants,
 		ring:           ring,
 		ringLifecycler: ringLifecycler,
-		configProvider: configProvider,
+		shardSize:      shardSize,
 	}
 }
 
@@ -768,7 +953,7 @@ func (s *splitAndMergeShardingStrategy) blocksCleanerOwnUser(userID string) (boo
 		return false, nil
 	}
 
-	r := s.ring.ShuffleShard(userID, s.configProvider.CompactorTenantShardSize(userID))
+	r := s.ring.ShuffleShard(userID, s.shardSize(userID))
 
 	return instanceOwnsTokenInRing(r, s.ringLifecycler.Addr, userID)
 }
@@ -779,7 +964,7 @@ func (s *splitAndMergeShardingStrategy) compactorOwnUser(userID string) (bool, e
 		return false, nil
 	}
 
-	r := s.ring.ShuffleShard(userID, s.configProvider.CompactorTenantShardSize(userID))
+	r := s.ring.ShuffleShard(userID, s.shardSize(userID))
 
 	return r.HasInstance(s.ringLifecycler.ID), nil
 }
@@ -791,7 +976,7 @@ func (s *splitAndMergeShardingStrategy) ownJob(job *Job) (bool, error) {
 		return ok, err
 	}
 
-	r := s.ring.ShuffleShard(job.UserID(), s.configProvider.CompactorTenantShardSize(job.UserID()))
+	r := s.ring.ShuffleShard(job.UserID(), s.shardSize(job.UserID()))
 
 	return instanceOwnsTokenInRing(r, s.ringLifecycler.Addr, job.ShardingKey())
 }
@@ -0,0 +1,139 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/go-kit/log/level"
+	"github.com/gorilla/mux"
+	"github.com/grafana/dskit/services"
+	"github.com/oklog/ulid"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	"github.com/grafana/mimir/pkg/util"
+	util_log "github.com/grafana/mimir/pkg/util/log"
+)
+
+const (
+	blockMarkNoCompact = "no-compact"
+	blockMarkDeletion  = "deletion"
+)
+
+// MarkBlocksResponse is the response of the block marks API.
+type MarkBlocksResponse struct {
+	// Blocks marked by the request.
+	Marked []string `json:"marked"`
+
+	// Blocks which already had the mark, and have been left untouched.
+	AlreadyMarked []string `json:"already_marked"`
+}
+
+// MarkBlocksNoCompactHandler uploads a no-compact mark for the tenant's blocks, so that they're excluded
+// from compaction.
+func (c *MultitenantCompactor) MarkBlocksNoCompactHandler(w http.ResponseWriter, req *http.Request) {
+	c.markBlocks(w, req, blockMarkNoCompact)
+}
+
+// MarkBlocksForDeletionHandler uploads a deletion mark for the tenant's blocks, so that they're deleted
+// from the bucket by the compactor after the deletion delay.
+func (c *MultitenantCompactor) MarkBlocksForDeletionHandler(w http.ResponseWriter, req *http.Request) {
+	c.markBlocks(w, req, blockMarkDeletion)
+}
+
+// markBlocks marks the blocks given by the block parameters. The requested_by parameter, identifying who
+// requested the marks, is required: it's logged with the marked blocks, and stored in the details of the marks
+// together with the optional details parameter, so that the reason of the marks can be audited later on.
+// No block is marked unless all the blocks exist.
+func (c *MultitenantCompactor) markBlocks(w http.ResponseWriter, req *http.Request, mark string) {
+	if c.State() != services.Running {
+		http.Error(w, "Compactor is not running yet.", http.StatusServiceUnavailable)
+		return
+	}
+
+	if err := req.ParseForm(); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	requestedBy := strings.TrimSpace(req.Form.Get("requested_by"))
+	if requestedBy == "" {
+		http.Error(w, "the requested_by parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	if len(req.Form["block"]) == 0 {
+		http.Error(w, "at least one block parameter is required", http.StatusBadRequest)
+		return
+	}
+	blockIDs := make([]ulid.ULID, 0, len(req.Form["block"]))
+	for _, v := range req.Form["block"] {
+		id, err := ulid.Parse(v)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid block ID %q: %s", v, err), http.StatusBadRequest)
+			return
+		}
+		blockIDs = append(blockIDs, id)
+	}
+
+	details := fmt.Sprintf("marked by %s via the compactor API", requestedBy)
+	if d := strings.TrimSpace(req.Form.Get("details")); d != "" {
+		details += ": " + d
+	}
+
+	tenantID := mux.Vars(req)["tenant"]
+	userBucket := bucket.NewUserBucketClient(tenantID, c.bucketClient, c.cfgProvider)
+	userLogger := util_log.WithUserID(tenantID, c.logger)
+
+	markFilename := metadata.NoCompactMarkFilename
+	if mark == blockMarkDeletion {
+		markFilename = metadata.DeletionMarkFilename
+	}
+
+	// Check all the blocks before marking any, so that a typo in a block ID doesn't leave the blocks partially marked.
+	resp := MarkBlocksResponse{Marked: []string{}, AlreadyMarked: []string{}}
+	toMark := make([]ulid.ULID, 0, len(blockIDs))
+	for _, id := range blockIDs {
+		if exists, err := userBucket.Exists(req.Context(), path.Join(id.String(), metadata.MetaFilename)); err != nil {
+			http.Error(w, fmt.Sprintf("failed to read block metadata: %s", err), http.StatusInternalServerError)
+			return
+		} else if !exists {
+			http.Error(w, fmt.Sprintf("block %s not found", id), http.StatusNotFound)
+			return
+		}
+
+		if exists, err := userBucket.Exists(req.Context(), path.Join(id.String(), markFilename)); err != nil {
+			http.Error(w, fmt.Sprintf("failed to read block %s mark: %s", mark, err), http.StatusInternalServerError)
+			return
+		} else if exists {
+			resp.AlreadyMarked = append(resp.AlreadyMarked, id.String())
+			continue
+		}
+
+		toMark = append(toMark, id)
+	}
+
+	for _, id := range toMark {
+		var err error
+		if mark == blockMarkDeletion {
+			err = block.MarkForDeletion(req.Context(), userLogger, userBucket, id, details, c.blocksManuallyMarkedForDel)
+		} else {
+			err = block.MarkForNoCompact(req.Context(), userLogger, userBucket, id, metadata.ManualNoCompactReason, details, c.blocksManuallyMarkedNoCompact)
+		}
+		if err != nil {
+			level.Error(userLogger).Log("msg", "failed to mark block", "mark", mark, "block", id, "requested_by", requestedBy, "err", err)
+			http.Error(w, fmt.Sprintf("failed to upload the %s mark of block %s: %s", mark, id, err), http.StatusInternalServerError)
+			return
+		}
+
+		resp.Marked = append(resp.Marked, id.String())
+		level.Info(userLogger).Log("msg", "audit: block marked via the compactor API", "mark", mark, "block", id, "requested_by", requestedBy, "remote_addr", req.RemoteAddr, "details", details)
+	}
+
+	util.WriteJSONResponse(w, resp)
+}
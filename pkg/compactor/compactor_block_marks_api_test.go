@@ -0,0 +1,137 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/grafana/dskit/services"
+	"github.com/oklog/ulid"
+	prom_testutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+)
+
+func TestMultitenantCompactor_BlockMarksAPI(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+	blockIDs := uploadOverlappingBlocks(t, bkt, "user-1")
+
+	cfg := prepareConfig(t)
+	cfg.DeletionDelay = time.Hour
+	c, _, tsdbPlanner, logs, _ := prepare(t, cfg, bkt)
+	tsdbPlanner.On("Plan", mock.Anything, mock.Anything).Return([]*metadata.Meta{}, nil)
+
+	router := mux.NewRouter()
+	router.Path("/compactor/tenant/{tenant}/blocks/mark_no_compact").HandlerFunc(c.MarkBlocksNoCompactHandler)
+	router.Path("/compactor/tenant/{tenant}/blocks/mark_for_deletion").HandlerFunc(c.MarkBlocksForDeletionHandler)
+
+	serve := func(url string, form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, url, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	mark := func(t *testing.T, url string, form url.Values) MarkBlocksResponse {
+		resp := serve(url, form)
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+		out := MarkBlocksResponse{}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &out))
+		return out
+	}
+
+	readNoCompactMark := func(t *testing.T, blockID string) metadata.NoCompactMark {
+		r, err := bkt.Get(ctx, path.Join("user-1", blockID, metadata.NoCompactMarkFilename))
+		require.NoError(t, err)
+		defer r.Close()
+
+		m := metadata.NoCompactMark{}
+		require.NoError(t, json.NewDecoder(r).Decode(&m))
+		return m
+	}
+
+	t.Run("should fail if the compactor is not running", func(t *testing.T) {
+		resp := serve("/compactor/tenant/user-1/blocks/mark_no_compact", url.Values{"block": {blockIDs[0]}, "requested_by": {"alice"}})
+		assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	})
+
+	require.NoError(t, services.StartAndAwaitRunning(ctx, c))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(ctx, c))
+	})
+
+	t.Run("should fail on invalid parameters", func(t *testing.T) {
+		for name, form := range map[string]url.Values{
+			"missing requested_by": {"block": {blockIDs[0]}},
+			"missing block":        {"requested_by": {"alice"}},
+			"invalid block":        {"block": {blockIDs[0], "invalid"}, "requested_by": {"alice"}},
+		} {
+			assert.Equal(t, http.StatusBadRequest, serve("/compactor/tenant/user-1/blocks/mark_no_compact", form).Code, name)
+		}
+	})
+
+	t.Run("should not mark any block if a block doesn't exist", func(t *testing.T) {
+		resp := serve("/compactor/tenant/user-1/blocks/mark_no_compact", url.Values{"block": {blockIDs[0], ulid.MustNew(3, nil).String()}, "requested_by": {"alice"}})
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+
+		exists, err := bkt.Exists(ctx, path.Join("user-1", blockIDs[0], metadata.NoCompactMarkFilename))
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("should mark the blocks no-compact", func(t *testing.T) {
+		out := mark(t, "/compactor/tenant/user-1/blocks/mark_no_compact", url.Values{"block": {blockIDs[0]}, "requested_by": {"alice"}, "details": {"corrupted chunks"}})
+		assert.Equal(t, []string{blockIDs[0]}, out.Marked)
+		assert.Empty(t, out.AlreadyMarked)
+
+		m := readNoCompactMark(t, blockIDs[0])
+		assert.Equal(t, metadata.ManualNoCompactReason, m.Reason)
+		assert.Equal(t, "marked by alice via the compactor API: corrupted chunks", m.Details)
+
+		// The block which already has the mark is left untouched.
+		out = mark(t, "/compactor/tenant/user-1/blocks/mark_no_compact", url.Values{"block": {blockIDs[0], blockIDs[1]}, "requested_by": {"bob"}})
+		assert.Equal(t, []string{blockIDs[1]}, out.Marked)
+		assert.Equal(t, []string{blockIDs[0]}, out.AlreadyMarked)
+		assert.Equal(t, "marked by alice via the compactor API: corrupted chunks", readNoCompactMark(t, blockIDs[0]).Details)
+		assert.Equal(t, "marked by bob via the compactor API", readNoCompactMark(t, blockIDs[1]).Details)
+
+		assert.Equal(t, 2.0, prom_testutil.ToFloat64(c.blocksManuallyMarkedNoCompact))
+	})
+
+	t.Run("should mark the blocks for deletion", func(t *testing.T) {
+		out := mark(t, "/compactor/tenant/user-1/blocks/mark_for_deletion", url.Values{"block": {blockIDs[1]}, "requested_by": {"alice"}})
+		assert.Equal(t, []string{blockIDs[1]}, out.Marked)
+
+		// Both the block and the global deletion marks have been uploaded.
+		id := ulid.MustParse(blockIDs[1])
+		for _, name := range []string{path.Join("user-1", blockIDs[1], metadata.DeletionMarkFilename), path.Join("user-1", bucketindex.BlockDeletionMarkFilepath(id))} {
+			exists, err := bkt.Exists(ctx, name)
+			require.NoError(t, err)
+			assert.True(t, exists, name)
+		}
+
+		assert.Equal(t, 1.0, prom_testutil.ToFloat64(c.blocksManuallyMarkedForDel))
+	})
+
+	t.Run("should log the marked blocks to the audit log", func(t *testing.T) {
+		assert.Contains(t, logs.String(), `msg="audit: block marked via the compactor API" mark=no-compact block=`+blockIDs[0]+` requested_by=alice`)
+		assert.Contains(t, logs.String(), `msg="audit: block marked via the compactor API" mark=deletion block=`+blockIDs[1]+` requested_by=alice`)
+	})
+}
@@ -0,0 +1,198 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/gorilla/mux"
+	"github.com/grafana/dskit/services"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/block"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+	"github.com/grafana/mimir/pkg/util"
+	util_log "github.com/grafana/mimir/pkg/util/log"
+)
+
+const (
+	bucketIndexStatusOK        = "ok"
+	bucketIndexStatusNotFound  = "not_found"
+	bucketIndexStatusCorrupted = "corrupted"
+)
+
+// PartialBlockInfo holds the details of a block whose upload or deletion didn't complete.
+type PartialBlockInfo struct {
+	ID    string `json:"id"`
+	Error string `json:"error"`
+
+	// Whether the block can be safely deleted: its meta.json is missing and it has been created
+	// more than PartialUploadThresholdAge ago, so its upload is assumed aborted.
+	Deletable bool `json:"deletable"`
+
+	// Whether the block has been marked for deletion by the repair.
+	MarkedForDeletion bool `json:"marked_for_deletion"`
+}
+
+// BucketIndexRepairResponse is the response of the bucket index validation and repair API.
+type BucketIndexRepairResponse struct {
+	// Status of the bucket index stored in the bucket before the repair: "ok", "not_found" or "corrupted".
+	IndexStatus string `json:"index_status"`
+
+	// Blocks found in the bucket but missing from the stored bucket index.
+	MissingBlocks []string `json:"missing_blocks"`
+
+	// Blocks in the stored bucket index which are not in the bucket anymore, or are partial.
+	StaleBlocks []string `json:"stale_blocks"`
+
+	// Block deletion marks found in the bucket but missing from the stored bucket index.
+	MissingDeletionMarks []string `json:"missing_deletion_marks"`
+
+	PartialBlocks []PartialBlockInfo `json:"partial_blocks"`
+
+	// Whether the rebuilt bucket index has been written to the bucket.
+	Repaired bool `json:"repaired"`
+}
+
+// BucketIndexRepairHandler validates the bucket index of a tenant against the blocks in the bucket, and
+// reports the blocks missing from the index, the stale index entries and the partial blocks. GET runs the
+// validation only. POST rebuilds the bucket index from the blocks' meta.json files and writes it to the
+// bucket; with clean=true, the partial blocks which are safe to delete are marked for deletion too, so that
+// they're deleted by the blocks cleaner.
+func (c *MultitenantCompactor) BucketIndexRepairHandler(w http.ResponseWriter, req *http.Request) {
+	if c.State() != services.Running {
+		http.Error(w, "Compactor is not running yet.", http.StatusServiceUnavailable)
+		return
+	}
+
+	repair := req.Method == http.MethodPost
+	clean := false
+	if v := req.FormValue("clean"); v != "" {
+		var err error
+		if clean, err = strconv.ParseBool(v); err != nil {
+			http.Error(w, fmt.Sprintf("invalid clean parameter: %s", err), http.StatusBadRequest)
+			return
+		}
+	}
+	if clean && !repair {
+		http.Error(w, "the partial blocks can only be cleaned by a POST request", http.StatusBadRequest)
+		return
+	}
+
+	tenantID := mux.Vars(req)["tenant"]
+	resp, err := c.repairBucketIndex(req.Context(), tenantID, repair, clean)
+	if err != nil {
+		level.Error(c.logger).Log("msg", "failed to repair bucket index", "user", tenantID, "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	util.WriteJSONResponse(w, resp)
+}
+
+func (c *MultitenantCompactor) repairBucketIndex(ctx context.Context, tenantID string, repair, clean bool) (BucketIndexRepairResponse, error) {
+	userLogger := util_log.WithUserID(tenantID, c.logger)
+
+	resp := BucketIndexRepairResponse{
+		IndexStatus:          bucketIndexStatusOK,
+		MissingBlocks:        []string{},
+		StaleBlocks:          []string{},
+		MissingDeletionMarks: []string{},
+		PartialBlocks:        []PartialBlockInfo{},
+	}
+
+	stored, err := bucketindex.ReadIndex(ctx, c.bucketClient, tenantID, c.cfgProvider, c.logger)
+	switch {
+	case errors.Is(err, bucketindex.ErrIndexNotFound):
+		resp.IndexStatus = bucketIndexStatusNotFound
+	case errors.Is(err, bucketindex.ErrIndexCorrupted):
+		resp.IndexStatus = bucketIndexStatusCorrupted
+	case err != nil:
+		return resp, errors.Wrap(err, "read bucket index")
+	}
+
+	// Rebuild the index from scratch, so that it only relies on the content of the bucket.
+	rebuilt, partials, err := bucketindex.NewUpdater(c.bucketClient, tenantID, c.cfgProvider, c.logger).UpdateIndex(ctx, nil)
+	if err != nil {
+		return resp, errors.Wrap(err, "rebuild bucket index")
+	}
+
+	storedBlocks := map[ulid.ULID]struct{}{}
+	storedMarks := map[ulid.ULID]struct{}{}
+	if stored != nil {
+		for _, id := range stored.Blocks.GetULIDs() {
+			storedBlocks[id] = struct{}{}
+		}
+		for _, id := range stored.BlockDeletionMarks.GetULIDs() {
+			storedMarks[id] = struct{}{}
+		}
+	}
+
+	rebuiltBlocks := map[ulid.ULID]struct{}{}
+	for _, id := range rebuilt.Blocks.GetULIDs() {
+		rebuiltBlocks[id] = struct{}{}
+		if _, ok := storedBlocks[id]; !ok {
+			resp.MissingBlocks = append(resp.MissingBlocks, id.String())
+		}
+	}
+	if stored != nil {
+		for _, id := range stored.Blocks.GetULIDs() {
+			if _, ok := rebuiltBlocks[id]; !ok {
+				resp.StaleBlocks = append(resp.StaleBlocks, id.String())
+			}
+		}
+	}
+	rebuiltMarks := map[ulid.ULID]struct{}{}
+	for _, id := range rebuilt.BlockDeletionMarks.GetULIDs() {
+		rebuiltMarks[id] = struct{}{}
+		if _, ok := storedMarks[id]; !ok {
+			resp.MissingDeletionMarks = append(resp.MissingDeletionMarks, id.String())
+		}
+	}
+
+	partialIDs := make([]ulid.ULID, 0, len(partials))
+	for id := range partials {
+		partialIDs = append(partialIDs, id)
+	}
+	sort.Slice(partialIDs, func(i, j int) bool {
+		return partialIDs[i].Compare(partialIDs[j]) < 0
+	})
+
+	userBucket := bucket.NewUserBucketClient(tenantID, c.bucketClient, c.cfgProvider)
+	for _, id := range partialIDs {
+		info := PartialBlockInfo{
+			ID:        id.String(),
+			Error:     partials[id].Error(),
+			Deletable: errors.Is(partials[id], bucketindex.ErrBlockMetaNotFound) && time.Since(ulid.Time(id.Time())) > PartialUploadThresholdAge,
+		}
+
+		// Partial blocks already marked for deletion are deleted by the blocks cleaner.
+		if _, marked := rebuiltMarks[id]; info.Deletable && clean && !marked {
+			if err := block.MarkForDeletion(ctx, userLogger, userBucket, id, "partial block cleaned by the bucket index repair", c.blocksMarkedForDeletion); err != nil {
+				return resp, errors.Wrapf(err, "mark partial block %s for deletion", id)
+			}
+			info.MarkedForDeletion = true
+			level.Info(userLogger).Log("msg", "marked partial block for deletion", "block", id)
+		}
+
+		resp.PartialBlocks = append(resp.PartialBlocks, info)
+	}
+
+	if repair {
+		if err := bucketindex.WriteIndex(ctx, c.bucketClient, tenantID, c.cfgProvider, rebuilt); err != nil {
+			return resp, errors.Wrap(err, "write bucket index")
+		}
+		resp.Repaired = true
+		level.Info(userLogger).Log("msg", "rebuilt bucket index", "blocks", len(rebuilt.Blocks), "missing_blocks", len(resp.MissingBlocks), "stale_blocks", len(resp.StaleBlocks), "partial_blocks", len(partials))
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,146 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/grafana/dskit/services"
+	"github.com/oklog/ulid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+)
+
+func TestMultitenantCompactor_BucketIndexRepairAPI(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+
+	now := time.Now()
+	block1 := ulid.MustNew(1, nil)
+	block2 := ulid.MustNew(2, nil)
+	staleBlock := ulid.MustNew(3, nil)
+	oldPartialBlock := ulid.MustNew(ulid.Timestamp(now.Add(-PartialUploadThresholdAge-time.Hour)), nil)
+	recentPartialBlock := ulid.MustNew(ulid.Timestamp(now.Add(-time.Minute)), nil)
+
+	uploadMeta := func(blockID ulid.ULID) {
+		meta := metadata.Meta{Thanos: metadata.Thanos{Version: metadata.ThanosVersion1, Source: metadata.CompactorSource}}
+		meta.ULID, meta.MinTime, meta.MaxTime, meta.Version = blockID, 10, 20, metadata.TSDBVersion1
+
+		buf := bytes.Buffer{}
+		require.NoError(t, meta.Write(&buf))
+		require.NoError(t, bkt.Upload(ctx, path.Join("user-1", blockID.String(), metadata.MetaFilename), &buf))
+	}
+
+	uploadMeta(block1)
+	uploadMeta(block2)
+	for _, id := range []ulid.ULID{oldPartialBlock, recentPartialBlock} {
+		require.NoError(t, bkt.Upload(ctx, path.Join("user-1", id.String(), "index"), bytes.NewReader([]byte("index"))))
+	}
+
+	// The stored bucket index is missing block2 and references a block which doesn't exist anymore.
+	require.NoError(t, bucketindex.WriteIndex(ctx, bkt, "user-1", nil, &bucketindex.Index{
+		Version: bucketindex.IndexVersion2,
+		Blocks:  bucketindex.Blocks{{ID: block1}, {ID: staleBlock}},
+	}))
+
+	cfg := prepareConfig(t)
+	c, _, tsdbPlanner, _, _ := prepare(t, cfg, bkt)
+	tsdbPlanner.On("Plan", mock.Anything, mock.Anything).Return([]*metadata.Meta{}, nil)
+
+	router := mux.NewRouter()
+	router.Path("/compactor/tenant/{tenant}/bucket_index/repair").HandlerFunc(c.BucketIndexRepairHandler)
+
+	serve := func(method, url string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, httptest.NewRequest(method, url, nil))
+		return resp
+	}
+
+	repair := func(t *testing.T, method, url string) BucketIndexRepairResponse {
+		resp := serve(method, url)
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+		out := BucketIndexRepairResponse{}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &out))
+		return out
+	}
+
+	t.Run("should fail if the compactor is not running", func(t *testing.T) {
+		assert.Equal(t, http.StatusServiceUnavailable, serve("GET", "/compactor/tenant/user-1/bucket_index/repair").Code)
+	})
+
+	require.NoError(t, services.StartAndAwaitRunning(ctx, c))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(ctx, c))
+	})
+
+	t.Run("should only clean the partial blocks on POST", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, serve("GET", "/compactor/tenant/user-1/bucket_index/repair?clean=true").Code)
+		assert.Equal(t, http.StatusBadRequest, serve("POST", "/compactor/tenant/user-1/bucket_index/repair?clean=invalid").Code)
+	})
+
+	t.Run("should validate the bucket index without changing it on GET", func(t *testing.T) {
+		out := repair(t, "GET", "/compactor/tenant/user-1/bucket_index/repair")
+
+		assert.Equal(t, bucketIndexStatusOK, out.IndexStatus)
+		assert.Equal(t, []string{block2.String()}, out.MissingBlocks)
+		assert.Equal(t, []string{staleBlock.String()}, out.StaleBlocks)
+		assert.False(t, out.Repaired)
+		require.Len(t, out.PartialBlocks, 2)
+		assert.Equal(t, oldPartialBlock.String(), out.PartialBlocks[0].ID)
+		assert.True(t, out.PartialBlocks[0].Deletable)
+		assert.False(t, out.PartialBlocks[0].MarkedForDeletion)
+		assert.Equal(t, recentPartialBlock.String(), out.PartialBlocks[1].ID)
+		assert.False(t, out.PartialBlocks[1].Deletable)
+
+		idx, err := bucketindex.ReadIndex(ctx, bkt, "user-1", nil, nil)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []ulid.ULID{block1, staleBlock}, idx.Blocks.GetULIDs())
+	})
+
+	t.Run("should rebuild the bucket index and mark the deletable partial blocks for deletion on POST", func(t *testing.T) {
+		out := repair(t, "POST", "/compactor/tenant/user-1/bucket_index/repair?clean=true")
+
+		assert.True(t, out.Repaired)
+		require.Len(t, out.PartialBlocks, 2)
+		assert.True(t, out.PartialBlocks[0].MarkedForDeletion)
+		assert.False(t, out.PartialBlocks[1].MarkedForDeletion)
+
+		idx, err := bucketindex.ReadIndex(ctx, bkt, "user-1", nil, nil)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []ulid.ULID{block1, block2}, idx.Blocks.GetULIDs())
+
+		exists, err := bkt.Exists(ctx, path.Join("user-1", oldPartialBlock.String(), metadata.DeletionMarkFilename))
+		require.NoError(t, err)
+		assert.True(t, exists)
+		exists, err = bkt.Exists(ctx, path.Join("user-1", recentPartialBlock.String(), metadata.DeletionMarkFilename))
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("should report a consistent bucket index after the repair", func(t *testing.T) {
+		out := repair(t, "GET", "/compactor/tenant/user-1/bucket_index/repair")
+
+		assert.Empty(t, out.MissingBlocks)
+		assert.Empty(t, out.StaleBlocks)
+		assert.Equal(t, []string{oldPartialBlock.String()}, out.MissingDeletionMarks)
+	})
+
+	t.Run("should report a missing bucket index", func(t *testing.T) {
+		out := repair(t, "GET", "/compactor/tenant/user-2/bucket_index/repair")
+		assert.Equal(t, bucketIndexStatusNotFound, out.IndexStatus)
+	})
+}
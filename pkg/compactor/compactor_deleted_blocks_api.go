@@ -0,0 +1,120 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/gorilla/mux"
+	"github.com/grafana/dskit/services"
+	"github.com/oklog/ulid"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/listblocks"
+)
+
+// DeletedBlockInfo holds the details of a block marked for deletion, but not deleted from the bucket yet.
+type DeletedBlockInfo struct {
+	ID      string `json:"id"`
+	MinTime int64  `json:"min_time"`
+	MaxTime int64  `json:"max_time"`
+
+	// Unix timestamp (seconds) of the deletion mark.
+	DeletionTime int64 `json:"deletion_time"`
+
+	// Seconds left before the block is deleted from the bucket by the compactor, according
+	// to the configured deletion delay. Zero if the block can be deleted at any time.
+	RemainingGracePeriodSeconds int64 `json:"remaining_grace_period_seconds"`
+}
+
+// DeletedBlocksListResponse is the response of the deleted blocks listing API.
+type DeletedBlocksListResponse struct {
+	Blocks []DeletedBlockInfo `json:"blocks"`
+}
+
+// DeletedBlocksHandler lists the blocks of a tenant marked for deletion, which have not been deleted from the bucket yet.
+func (c *MultitenantCompactor) DeletedBlocksHandler(w http.ResponseWriter, req *http.Request) {
+	if c.State() != services.Running {
+		http.Error(w, "Compactor is not running yet.", http.StatusServiceUnavailable)
+		return
+	}
+
+	tenantID := mux.Vars(req)["tenant"]
+	metasMap, deletionTimes, _, err := listblocks.LoadMetaFilesAndDeletionMarkers(req.Context(), c.bucketClient, tenantID, true, time.Time{})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read block metadata: %s", err), http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	resp := DeletedBlocksListResponse{Blocks: make([]DeletedBlockInfo, 0, len(deletionTimes))}
+	for _, m := range listblocks.SortBlocks(metasMap) {
+		deletionTime, ok := deletionTimes[m.ULID]
+		if !ok {
+			continue
+		}
+
+		remaining := deletionTime.Add(c.compactorCfg.DeletionDelay).Sub(now)
+		if remaining < 0 {
+			remaining = 0
+		}
+
+		resp.Blocks = append(resp.Blocks, DeletedBlockInfo{
+			ID:                          m.ULID.String(),
+			MinTime:                     m.MinTime,
+			MaxTime:                     m.MaxTime,
+			DeletionTime:                deletionTime.Unix(),
+			RemainingGracePeriodSeconds: int64(remaining / time.Second),
+		})
+	}
+
+	util.WriteJSONResponse(w, resp)
+}
+
+// UndeleteBlockHandler removes the deletion mark of a tenant's block, so that the block is not deleted
+// from the bucket by the compactor. The block can only be recovered as long as it has not been deleted yet.
+func (c *MultitenantCompactor) UndeleteBlockHandler(w http.ResponseWriter, req *http.Request) {
+	if c.State() != services.Running {
+		http.Error(w, "Compactor is not running yet.", http.StatusServiceUnavailable)
+		return
+	}
+
+	tenantID := mux.Vars(req)["tenant"]
+	blockID, err := ulid.Parse(mux.Vars(req)["block"])
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid block ID: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	userBucket := bucket.NewUserBucketClient(tenantID, c.bucketClient, c.cfgProvider)
+
+	// The meta.json is the first file deleted by the compactor, so the block can't be recovered without it.
+	if exists, err := userBucket.Exists(req.Context(), path.Join(blockID.String(), metadata.MetaFilename)); err != nil {
+		http.Error(w, fmt.Sprintf("failed to read block metadata: %s", err), http.StatusInternalServerError)
+		return
+	} else if !exists {
+		http.Error(w, "block not found", http.StatusNotFound)
+		return
+	}
+
+	// The bucket client removes the deletion mark from the global markers location too.
+	err = userBucket.Delete(req.Context(), path.Join(blockID.String(), metadata.DeletionMarkFilename))
+	if userBucket.IsObjNotFoundErr(err) {
+		http.Error(w, "block is not marked for deletion", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		level.Error(c.logger).Log("msg", "failed to delete block deletion mark", "user", tenantID, "block", blockID, "err", err)
+		http.Error(w, fmt.Sprintf("failed to delete block deletion mark: %s", err), http.StatusInternalServerError)
+		return
+	}
+
+	level.Info(c.logger).Log("msg", "block deletion mark deleted", "user", tenantID, "block", blockID)
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/gorilla/mux"
+	"github.com/grafana/dskit/services"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+)
+
+func TestMultitenantCompactor_DeletedBlocksAPI(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+
+	block1 := ulid.MustNew(1, nil)
+	block2 := ulid.MustNew(2, nil)
+	block3 := ulid.MustNew(3, nil)
+
+	uploadMeta := func(blockID ulid.ULID, minT, maxT int64) {
+		meta := metadata.Meta{Thanos: metadata.Thanos{Version: metadata.ThanosVersion1, Source: metadata.CompactorSource}}
+		meta.ULID, meta.MinTime, meta.MaxTime, meta.Version = blockID, minT, maxT, metadata.TSDBVersion1
+
+		buf := bytes.Buffer{}
+		require.NoError(t, meta.Write(&buf))
+		require.NoError(t, bkt.Upload(ctx, path.Join("user-1", blockID.String(), metadata.MetaFilename), &buf))
+	}
+
+	uploadMeta(block1, 10, 20)
+	uploadMeta(block2, 20, 30)
+	uploadMeta(block3, 30, 40)
+
+	userBkt := bucketindex.BucketWithGlobalMarkers(bucket.NewUserBucketClient("user-1", bkt, nil))
+	require.NoError(t, block.MarkForDeletion(ctx, log.NewNopLogger(), userBkt, block2, "test", promauto.With(nil).NewCounter(prometheus.CounterOpts{})))
+	require.NoError(t, block.MarkForDeletion(ctx, log.NewNopLogger(), userBkt, block3, "test", promauto.With(nil).NewCounter(prometheus.CounterOpts{})))
+
+	cfg := prepareConfig(t)
+	cfg.DeletionDelay = time.Hour
+	c, _, tsdbPlanner, _, _ := prepare(t, cfg, bkt)
+	tsdbPlanner.On("Plan", mock.Anything, mock.Anything).Return([]*metadata.Meta{}, nil)
+
+	router := mux.NewRouter()
+	router.Path("/compactor/tenant/{tenant}/deleted_blocks").HandlerFunc(c.DeletedBlocksHandler)
+	router.Path("/compactor/tenant/{tenant}/blocks/{block}/undelete").HandlerFunc(c.UndeleteBlockHandler)
+
+	serve := func(method, url string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, httptest.NewRequest(method, url, nil))
+		return resp
+	}
+
+	listDeletedBlocks := func(t *testing.T) []string {
+		resp := serve("GET", "/compactor/tenant/user-1/deleted_blocks")
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+		out := DeletedBlocksListResponse{}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &out))
+
+		ids := make([]string, 0, len(out.Blocks))
+		for _, b := range out.Blocks {
+			assert.Greater(t, b.RemainingGracePeriodSeconds, int64(0))
+			assert.LessOrEqual(t, b.RemainingGracePeriodSeconds, int64(time.Hour/time.Second))
+			ids = append(ids, b.ID)
+		}
+		return ids
+	}
+
+	t.Run("should fail if the compactor is not running", func(t *testing.T) {
+		assert.Equal(t, http.StatusServiceUnavailable, serve("GET", "/compactor/tenant/user-1/deleted_blocks").Code)
+		assert.Equal(t, http.StatusServiceUnavailable, serve("POST", "/compactor/tenant/user-1/blocks/"+block2.String()+"/undelete").Code)
+	})
+
+	require.NoError(t, services.StartAndAwaitRunning(ctx, c))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(ctx, c))
+	})
+
+	t.Run("should list the blocks marked for deletion", func(t *testing.T) {
+		assert.Equal(t, []string{block2.String(), block3.String()}, listDeletedBlocks(t))
+	})
+
+	t.Run("should not list the blocks of other tenants", func(t *testing.T) {
+		resp := serve("GET", "/compactor/tenant/user-2/deleted_blocks")
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.JSONEq(t, `{"blocks":[]}`, resp.Body.String())
+	})
+
+	t.Run("should undelete a block marked for deletion", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("POST", "/compactor/tenant/user-1/blocks/"+block2.String()+"/undelete").Code)
+		assert.Equal(t, []string{block3.String()}, listDeletedBlocks(t))
+
+		// Both the block and the global deletion marks have been removed.
+		for _, name := range []string{path.Join("user-1", block2.String(), metadata.DeletionMarkFilename), path.Join("user-1", bucketindex.BlockDeletionMarkFilepath(block2))} {
+			exists, err := bkt.Exists(ctx, name)
+			require.NoError(t, err)
+			assert.False(t, exists, name)
+		}
+	})
+
+	t.Run("should fail to undelete a block not marked for deletion", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, serve("POST", "/compactor/tenant/user-1/blocks/"+block1.String()+"/undelete").Code)
+	})
+
+	t.Run("should fail to undelete a block which doesn't exist", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, serve("POST", "/compactor/tenant/user-2/blocks/"+block3.String()+"/undelete").Code)
+	})
+
+	t.Run("should fail to undelete a block with an invalid ID", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, serve("POST", "/compactor/tenant/user-1/blocks/invalid/undelete").Code)
+	})
+}
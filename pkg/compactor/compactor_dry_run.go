@@ -0,0 +1,202 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-kit/log/level"
+	"github.com/gorilla/mux"
+	"github.com/grafana/dskit/services"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	"github.com/grafana/mimir/pkg/util"
+	util_log "github.com/grafana/mimir/pkg/util/log"
+)
+
+// PlannedJob is a compaction job planned by the dry-run planning of a tenant.
+type PlannedJob struct {
+	Key string `json:"key"`
+
+	// Stage of the split-and-merge compaction: "split" or "merge".
+	Stage string `json:"stage"`
+
+	// Time range of the job blocks, in milliseconds.
+	MinTime int64 `json:"min_time"`
+	MaxTime int64 `json:"max_time"`
+
+	// Blocks grouped in the job, and the blocks the planner would compact. The job would be skipped if the planner
+	// has no blocks to compact.
+	Blocks          []string `json:"blocks"`
+	BlocksToCompact []string `json:"blocks_to_compact"`
+
+	// Whether the job would be run by this compactor, rather than by another compactor of the tenant's shard.
+	Owned bool `json:"owned"`
+}
+
+// PlannedJobsResponse is the response of the planned jobs API.
+type PlannedJobsResponse struct {
+	Tenant string `json:"tenant"`
+
+	// Split-and-merge sharding config the jobs have been planned with.
+	SplitAndMergeShards int `json:"split_and_merge_shards"`
+	SplitGroups         int `json:"split_groups"`
+
+	// Jobs in the order they would be run.
+	Jobs []PlannedJob `json:"jobs"`
+}
+
+// splitAndMergeConfigOverrides overrides the split-and-merge sharding config of the tenants, to plan their
+// compaction with different values than the configured ones.
+type splitAndMergeConfigOverrides struct {
+	ConfigProvider
+
+	splitAndMergeShards int
+	splitGroups         int
+}
+
+func (o splitAndMergeConfigOverrides) CompactorSplitAndMergeShards(string) int {
+	return o.splitAndMergeShards
+}
+
+func (o splitAndMergeConfigOverrides) CompactorSplitGroups(string) int {
+	return o.splitGroups
+}
+
+// PlannedJobsHandler runs the compaction planner for a tenant and writes the jobs the compactors would run as JSON,
+// without compacting any block. The tenant's split-and-merge sharding config can be overridden by the optional
+// split_and_merge_shards and split_groups parameters, to find out the jobs the new values would plan before
+// applying them.
+func (c *MultitenantCompactor) PlannedJobsHandler(w http.ResponseWriter, req *http.Request) {
+	if c.State() != services.Running {
+		http.Error(w, "Compactor is not running yet.", http.StatusServiceUnavailable)
+		return
+	}
+
+	tenantID := mux.Vars(req)["tenant"]
+	overrides := splitAndMergeConfigOverrides{
+		ConfigProvider:      c.cfgProvider,
+		splitAndMergeShards: c.cfgProvider.CompactorSplitAndMergeShards(tenantID),
+		splitGroups:         c.cfgProvider.CompactorSplitGroups(tenantID),
+	}
+
+	for name, value := range map[string]*int{"split_and_merge_shards": &overrides.splitAndMergeShards, "split_groups": &overrides.splitGroups} {
+		v := req.FormValue(name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, fmt.Sprintf("invalid %s parameter: %s", name, v), http.StatusBadRequest)
+			return
+		}
+		*value = n
+	}
+
+	// Ensure the tenant's shard size is up-to-date, so that the ownership of the jobs is accurate.
+	if err := c.tenantShardSizes.sync(req.Context(), tenantID); err != nil {
+		http.Error(w, fmt.Sprintf("unable to read the compactor shard size of the tenant: %s", err), http.StatusInternalServerError)
+		return
+	}
+
+	jobs, err := c.planUser(req.Context(), tenantID, overrides)
+	if err != nil {
+		level.Error(c.logger).Log("msg", "failed to plan the compaction jobs of user", "user", tenantID, "err", err)
+		http.Error(w, fmt.Sprintf("failed to plan the compaction jobs: %s", err), http.StatusInternalServerError)
+		return
+	}
+
+	util.WriteJSONResponse(w, PlannedJobsResponse{
+		Tenant:              tenantID,
+		SplitAndMergeShards: overrides.splitAndMergeShards,
+		SplitGroups:         overrides.splitGroups,
+		Jobs:                jobs,
+	})
+}
+
+// planUser syncs the metas of the user's blocks and runs the grouper and the planner on them, with the given
+// config provider, without downloading or writing any block. The jobs are returned in the order they would be run.
+func (c *MultitenantCompactor) planUser(ctx context.Context, userID string, cfgProvider ConfigProvider) ([]PlannedJob, error) {
+	bucket := bucket.NewUserBucketClient(userID, c.bucketClient, c.cfgProvider)
+	reg := prometheus.NewRegistry()
+	ulogger := util_log.WithUserID(userID, c.logger)
+
+	syncer, err := c.newUserMetaSyncer(userID, bucket, ulogger, reg)
+	if err != nil {
+		return nil, err
+	}
+	if err := syncer.SyncMetas(ctx); err != nil {
+		return nil, errors.Wrap(err, "sync")
+	}
+
+	jobs, err := c.blocksGrouperFactory(ctx, c.compactorCfg, cfgProvider, userID, ulogger, reg).Groups(syncer.Metas())
+	if err != nil {
+		return nil, errors.Wrap(err, "build compaction jobs")
+	}
+
+	res := make([]PlannedJob, 0, len(jobs))
+	for _, job := range c.jobsOrder(jobs) {
+		toCompact, err := c.blocksPlanner.Plan(ctx, job.metasByMinTime)
+		if err != nil {
+			return nil, errors.Wrapf(err, "plan job %s", job.Key())
+		}
+
+		owned, err := c.shardingStrategy.ownJob(job)
+		if err != nil {
+			return nil, errors.Wrapf(err, "check ownership of job %s", job.Key())
+		}
+
+		planned := PlannedJob{
+			Key:             job.Key(),
+			Stage:           "merge",
+			MinTime:         job.MinTime(),
+			MaxTime:         job.MaxTime(),
+			Blocks:          make([]string, 0, len(job.metasByMinTime)),
+			BlocksToCompact: make([]string, 0, len(toCompact)),
+			Owned:           owned,
+		}
+		if job.UseSplitting() {
+			planned.Stage = "split"
+		}
+		for _, id := range job.IDs() {
+			planned.Blocks = append(planned.Blocks, id.String())
+		}
+		for _, meta := range toCompact {
+			planned.BlocksToCompact = append(planned.BlocksToCompact, meta.ULID.String())
+		}
+		res = append(res, planned)
+	}
+
+	return res, nil
+}
+
+// dryRunUser plans the compaction of the user's jobs overlapping the time range, and logs the jobs this compactor
+// would run instead of running them.
+func (c *MultitenantCompactor) dryRunUser(ctx context.Context, userID string, timeRange compactionTimeRange) error {
+	jobs, err := c.planUser(ctx, userID, c.cfgProvider)
+	if err != nil {
+		return err
+	}
+
+	ulogger := util_log.WithUserID(userID, c.logger)
+	ownedJobs := 0
+
+	for _, job := range jobs {
+		if !job.Owned || !timeRange.overlapsMillis(job.MinTime, job.MaxTime) {
+			continue
+		}
+
+		ownedJobs++
+		level.Info(ulogger).Log("msg", "dry-run: compaction job planned", "groupKey", job.Key, "stage", job.Stage,
+			"minTime", util.FormatTimeMillis(job.MinTime), "maxTime", util.FormatTimeMillis(job.MaxTime),
+			"blocks", len(job.Blocks), "blocksToCompact", len(job.BlocksToCompact))
+	}
+
+	level.Info(ulogger).Log("msg", "dry-run: compaction jobs planned", "jobs", len(jobs), "ownedJobs", ownedJobs)
+	return nil
+}
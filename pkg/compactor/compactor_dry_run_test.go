@@ -0,0 +1,145 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/test"
+	"github.com/oklog/ulid"
+	prom_testutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/objstore"
+)
+
+// uploadOverlappingBlocks uploads two overlapping blocks for the user, between 2019-11-26T14:00:00Z
+// and 2019-11-26T16:00:00Z, so that a compaction job is planned, and returns their IDs.
+func uploadOverlappingBlocks(t *testing.T, bkt objstore.Bucket, userID string) []string {
+	ids := []string{ulid.MustNew(1, nil).String(), ulid.MustNew(2, nil).String()}
+	for _, id := range ids {
+		require.NoError(t, bkt.Upload(context.Background(), path.Join(userID, id, metadata.MetaFilename), strings.NewReader(mockBlockMetaJSON(id))))
+	}
+	return ids
+}
+
+func TestMultitenantCompactor_DryRun(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+	blockIDs := uploadOverlappingBlocks(t, bkt, "user-1")
+
+	cfg := prepareConfig(t)
+	cfg.DryRun = true
+
+	// The planner would compact both blocks.
+	c, tsdbCompactor, tsdbPlanner, logs, _ := prepare(t, cfg, bkt)
+	tsdbPlanner.On("Plan", mock.Anything, mock.Anything).Return([]*metadata.Meta{
+		blockMeta(blockIDs[0], 1574776800000, 1574784000000, nil),
+		blockMeta(blockIDs[1], 1574776800000, 1574784000000, nil),
+	}, nil)
+
+	require.NoError(t, services.StartAndAwaitRunning(ctx, c))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(ctx, c))
+	})
+
+	test.Poll(t, 5*time.Second, 1.0, func() interface{} {
+		return prom_testutil.ToFloat64(c.compactionRunsCompleted)
+	})
+
+	// The job has been planned, but not compacted.
+	tsdbPlanner.AssertNumberOfCalls(t, "Plan", 1)
+	tsdbCompactor.AssertNotCalled(t, "Compact", mock.Anything, mock.Anything, mock.Anything)
+	tsdbCompactor.AssertNotCalled(t, "CompactWithSplitting", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	assert.Contains(t, logs.String(), `msg="dry-run: compaction job planned"`)
+	assert.Contains(t, logs.String(), `msg="dry-run: compaction jobs planned" jobs=1 ownedJobs=1`)
+
+	// The blocks cleaner doesn't run, so the bucket index isn't written.
+	assert.Nil(t, c.blocksCleaner)
+	exists, err := bkt.Exists(ctx, path.Join("user-1", "bucket-index.json.gz"))
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestMultitenantCompactor_PlannedJobsHandler(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+	blockIDs := uploadOverlappingBlocks(t, bkt, "user-1")
+
+	c, tsdbCompactor, tsdbPlanner, _, _ := prepare(t, prepareConfig(t), bkt)
+	tsdbPlanner.On("Plan", mock.Anything, mock.Anything).Return([]*metadata.Meta{}, nil)
+
+	router := mux.NewRouter()
+	router.Path("/compactor/tenant/{tenant}/planned_jobs").HandlerFunc(c.PlannedJobsHandler)
+
+	serve := func(url string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, url, nil))
+		return resp
+	}
+
+	// The jobs can't be planned until the compactor is running.
+	assert.Equal(t, http.StatusServiceUnavailable, serve("/compactor/tenant/user-1/planned_jobs").Code)
+
+	require.NoError(t, services.StartAndAwaitRunning(ctx, c))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(ctx, c))
+	})
+
+	// Wait until the initial compaction run has completed, so that the planner calls don't race with it.
+	test.Poll(t, 5*time.Second, 1.0, func() interface{} {
+		return prom_testutil.ToFloat64(c.compactionRunsCompleted)
+	})
+	plannerCalls := len(tsdbPlanner.Calls)
+
+	t.Run("should fail on invalid parameters", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, serve("/compactor/tenant/user-1/planned_jobs?split_and_merge_shards=foo").Code)
+		assert.Equal(t, http.StatusBadRequest, serve("/compactor/tenant/user-1/planned_jobs?split_groups=-1").Code)
+	})
+
+	t.Run("should plan the jobs with the tenant's config", func(t *testing.T) {
+		resp := serve("/compactor/tenant/user-1/planned_jobs")
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+		var out PlannedJobsResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &out))
+		assert.Equal(t, "user-1", out.Tenant)
+		assert.Equal(t, 0, out.SplitAndMergeShards)
+		require.Len(t, out.Jobs, 1)
+		assert.Equal(t, "merge", out.Jobs[0].Stage)
+		assert.Equal(t, int64(1574776800000), out.Jobs[0].MinTime)
+		assert.Equal(t, int64(1574784000000), out.Jobs[0].MaxTime)
+		assert.Equal(t, blockIDs, out.Jobs[0].Blocks)
+		assert.Empty(t, out.Jobs[0].BlocksToCompact)
+		assert.True(t, out.Jobs[0].Owned)
+	})
+
+	t.Run("should plan the jobs with the overridden split-and-merge config", func(t *testing.T) {
+		resp := serve("/compactor/tenant/user-1/planned_jobs?split_and_merge_shards=2&split_groups=1")
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+		var out PlannedJobsResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &out))
+		assert.Equal(t, 2, out.SplitAndMergeShards)
+		assert.Equal(t, 1, out.SplitGroups)
+		require.Len(t, out.Jobs, 1)
+		assert.Equal(t, "split", out.Jobs[0].Stage)
+		assert.Equal(t, blockIDs, out.Jobs[0].Blocks)
+	})
+
+	// The jobs have been planned, but no block has been compacted.
+	tsdbPlanner.AssertNumberOfCalls(t, "Plan", plannerCalls+2)
+	tsdbCompactor.AssertNotCalled(t, "Compact", mock.Anything, mock.Anything, mock.Anything)
+	tsdbCompactor.AssertNotCalled(t, "CompactWithSplitting", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -6,46 +6,18 @@
 package compactor
 
 import (
-	"html/template"
 	"net/http"
 
-	"github.com/go-kit/log/level"
 	"github.com/grafana/dskit/services"
 
-	util_log "github.com/grafana/mimir/pkg/util/log"
+	"github.com/grafana/mimir/pkg/util"
 )
 
-var (
-	compactorStatusPageTemplate = template.Must(template.New("main").Parse(`
-	<!DOCTYPE html>
-	<html>
-		<head>
-			<meta charset="UTF-8">
-			<title>Compactor Ring</title>
-		</head>
-		<body>
-			<h1>Compactor Ring</h1>
-			<p>{{ .Message }}</p>
-		</body>
-	</html>`))
-)
-
-func writeMessage(w http.ResponseWriter, message string) {
-	w.WriteHeader(http.StatusOK)
-	err := compactorStatusPageTemplate.Execute(w, struct {
-		Message string
-	}{Message: message})
-
-	if err != nil {
-		level.Error(util_log.Logger).Log("msg", "unable to serve compactor ring page", "err", err)
-	}
-}
-
 func (c *MultitenantCompactor) RingHandler(w http.ResponseWriter, req *http.Request) {
 	if c.State() != services.Running {
 		// we cannot read the ring before MultitenantCompactor is in Running state,
 		// because that would lead to race condition.
-		writeMessage(w, "Compactor is not running yet.")
+		util.WriteRingStatusMessage(w, req, "Compactor Ring", "Compactor is not running yet.")
 		return
 	}
 
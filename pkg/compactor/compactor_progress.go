@@ -0,0 +1,143 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// tenantCompactionProgress is the compaction progress of a tenant, estimated from the jobs planned by the
+// last compaction pass of the tenant.
+type tenantCompactionProgress struct {
+	// Creation time of the oldest level 1 block, that is uploaded by the ingesters and never compacted,
+	// part of a planned job. Zero if the planned jobs have no level 1 blocks.
+	oldestUncompactedBlockTime time.Time
+
+	// Jobs planned by the last compaction pass, across all the compactors of the tenant, minus the ones
+	// successfully completed by this compactor since.
+	remainingJobs int
+}
+
+// compactionProgress tracks the compaction progress of the tenants owned by the compactor, and exposes it as metrics.
+type compactionProgress struct {
+	mtx     sync.Mutex
+	tenants map[string]*tenantCompactionProgress
+
+	now func() time.Time
+
+	oldestUncompactedBlockAge *prometheus.Desc
+	remainingJobs             *prometheus.Desc
+}
+
+func newCompactionProgress(reg prometheus.Registerer) *compactionProgress {
+	p := &compactionProgress{
+		tenants: map[string]*tenantCompactionProgress{},
+		now:     time.Now,
+		oldestUncompactedBlockAge: prometheus.NewDesc(
+			"cortex_compactor_tenant_oldest_uncompacted_block_age_seconds",
+			"Age of the oldest block uploaded by the ingesters and waiting to be compacted, per tenant, as of the last compaction pass of the tenant. 0 if no block uploaded by the ingesters is waiting to be compacted.",
+			[]string{"user"}, nil),
+		remainingJobs: prometheus.NewDesc(
+			"cortex_compactor_tenant_estimated_remaining_jobs",
+			"Estimated number of compaction jobs left for the tenant: the jobs planned by the last compaction pass of the tenant, minus the ones completed by this compactor since.",
+			[]string{"user"}, nil),
+	}
+
+	if reg != nil {
+		reg.MustRegister(p)
+	}
+	return p
+}
+
+// forTenant returns the tracker of the compaction progress of the tenant.
+func (p *compactionProgress) forTenant(userID string) *tenantProgressTracker {
+	return &tenantProgressTracker{progress: p, userID: userID}
+}
+
+// list returns a copy of the progress of the tenants.
+func (p *compactionProgress) list() map[string]tenantCompactionProgress {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	res := make(map[string]tenantCompactionProgress, len(p.tenants))
+	for userID, t := range p.tenants {
+		res[userID] = *t
+	}
+	return res
+}
+
+// retain removes the progress of the tenants not in the input ones.
+func (p *compactionProgress) retain(userIDs map[string]struct{}) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	for userID := range p.tenants {
+		if _, ok := userIDs[userID]; !ok {
+			delete(p.tenants, userID)
+		}
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (p *compactionProgress) Describe(out chan<- *prometheus.Desc) {
+	out <- p.oldestUncompactedBlockAge
+	out <- p.remainingJobs
+}
+
+// Collect implements prometheus.Collector.
+func (p *compactionProgress) Collect(out chan<- prometheus.Metric) {
+	now := p.now()
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	for userID, t := range p.tenants {
+		age := 0.0
+		if !t.oldestUncompactedBlockTime.IsZero() {
+			age = now.Sub(t.oldestUncompactedBlockTime).Seconds()
+		}
+
+		out <- prometheus.MustNewConstMetric(p.oldestUncompactedBlockAge, prometheus.GaugeValue, age, userID)
+		out <- prometheus.MustNewConstMetric(p.remainingJobs, prometheus.GaugeValue, float64(t.remainingJobs), userID)
+	}
+}
+
+// tenantProgressTracker updates the compaction progress of a tenant with the jobs planned and completed by a BucketCompactor.
+type tenantProgressTracker struct {
+	progress *compactionProgress
+	userID   string
+}
+
+// planned resets the progress of the tenant to the jobs planned by a compaction pass, before filtering out
+// the jobs not owned by this compactor.
+func (t *tenantProgressTracker) planned(jobs []*Job) {
+	progress := &tenantCompactionProgress{remainingJobs: len(jobs)}
+	for _, job := range jobs {
+		for _, meta := range job.metasByMinTime {
+			if meta.Compaction.Level != 1 {
+				continue
+			}
+			if created := ulid.Time(meta.ULID.Time()); progress.oldestUncompactedBlockTime.IsZero() || created.Before(progress.oldestUncompactedBlockTime) {
+				progress.oldestUncompactedBlockTime = created
+			}
+		}
+	}
+
+	t.progress.mtx.Lock()
+	t.progress.tenants[t.userID] = progress
+	t.progress.mtx.Unlock()
+}
+
+// completed accounts for a job successfully compacted by this compactor.
+func (t *tenantProgressTracker) completed() {
+	t.progress.mtx.Lock()
+	defer t.progress.mtx.Unlock()
+
+	if progress, ok := t.progress.tenants[t.userID]; ok && progress.remainingJobs > 0 {
+		progress.remainingJobs--
+	}
+}
@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+)
+
+func TestCompactionProgress(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	p := newCompactionProgress(reg)
+
+	now := time.Unix(1650000000, 0)
+	p.now = func() time.Time { return now }
+
+	newJob := func(userID string, blocks map[ulid.ULID]int) *Job {
+		job := NewJob(userID, "job", nil, 0, metadata.NoneFunc, false, 0, "")
+		for id, level := range blocks {
+			meta := blockMeta(id.String(), 0, 7200000, nil)
+			meta.Compaction.Level = level
+			require.NoError(t, job.AppendMeta(meta))
+		}
+		return job
+	}
+
+	// Blocks created 1 hour, 3 hours and 5 hours ago.
+	block1h := ulid.MustNew(ulid.Timestamp(now.Add(-time.Hour)), nil)
+	block3h := ulid.MustNew(ulid.Timestamp(now.Add(-3*time.Hour)), nil)
+	block5h := ulid.MustNew(ulid.Timestamp(now.Add(-5*time.Hour)), nil)
+
+	// The oldest block of user-1 has already been compacted, so it's not accounted for.
+	user1 := p.forTenant("user-1")
+	user1.planned([]*Job{
+		newJob("user-1", map[ulid.ULID]int{block1h: 1, block5h: 2}),
+		newJob("user-1", map[ulid.ULID]int{block3h: 1}),
+	})
+
+	user2 := p.forTenant("user-2")
+	user2.planned([]*Job{newJob("user-2", map[ulid.ULID]int{block5h: 2})})
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_compactor_tenant_estimated_remaining_jobs Estimated number of compaction jobs left for the tenant: the jobs planned by the last compaction pass of the tenant, minus the ones completed by this compactor since.
+		# TYPE cortex_compactor_tenant_estimated_remaining_jobs gauge
+		cortex_compactor_tenant_estimated_remaining_jobs{user="user-1"} 2
+		cortex_compactor_tenant_estimated_remaining_jobs{user="user-2"} 1
+		# HELP cortex_compactor_tenant_oldest_uncompacted_block_age_seconds Age of the oldest block uploaded by the ingesters and waiting to be compacted, per tenant, as of the last compaction pass of the tenant. 0 if no block uploaded by the ingesters is waiting to be compacted.
+		# TYPE cortex_compactor_tenant_oldest_uncompacted_block_age_seconds gauge
+		cortex_compactor_tenant_oldest_uncompacted_block_age_seconds{user="user-1"} 10800
+		cortex_compactor_tenant_oldest_uncompacted_block_age_seconds{user="user-2"} 0
+	`)))
+
+	// The completed jobs are not remaining anymore, and the age grows until the next compaction pass.
+	user1.completed()
+	user2.completed()
+	user2.completed()
+	now = now.Add(time.Hour)
+	p.retain(map[string]struct{}{"user-1": {}})
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_compactor_tenant_estimated_remaining_jobs Estimated number of compaction jobs left for the tenant: the jobs planned by the last compaction pass of the tenant, minus the ones completed by this compactor since.
+		# TYPE cortex_compactor_tenant_estimated_remaining_jobs gauge
+		cortex_compactor_tenant_estimated_remaining_jobs{user="user-1"} 1
+		# HELP cortex_compactor_tenant_oldest_uncompacted_block_age_seconds Age of the oldest block uploaded by the ingesters and waiting to be compacted, per tenant, as of the last compaction pass of the tenant. 0 if no block uploaded by the ingesters is waiting to be compacted.
+		# TYPE cortex_compactor_tenant_oldest_uncompacted_block_age_seconds gauge
+		cortex_compactor_tenant_oldest_uncompacted_block_age_seconds{user="user-1"} 14400
+	`)))
+
+	// A new compaction pass resets the progress.
+	user1.planned(nil)
+	assert.Equal(t, map[string]tenantCompactionProgress{"user-1": {}}, p.list())
+}
@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/grafana/mimir/pkg/util"
+)
+
+// QueuesDebugStatus is the state of the compaction run returned by QueuesDebugHandler.
+type QueuesDebugStatus struct {
+	// Tenants not processed yet by the running compaction, including the ones not owned by this compactor.
+	PendingTenants []string `json:"pending_tenants"`
+
+	// Tenant being compacted, and the compaction jobs of the tenant. Empty if no tenant is being compacted.
+	CompactingTenant                string     `json:"compacting_tenant"`
+	CompactingTenantDurationSeconds float64    `json:"compacting_tenant_duration_seconds"`
+	Jobs                            JobsStatus `json:"jobs"`
+}
+
+// QueuesDebugHandler writes the state of the running compaction as JSON, to find out which tenant and jobs
+// the compactor is busy with.
+func (c *MultitenantCompactor) QueuesDebugHandler(w http.ResponseWriter, _ *http.Request) {
+	util.WriteJSONResponse(w, c.queuesDebugStatus(time.Now()))
+}
+
+func (c *MultitenantCompactor) queuesDebugStatus(now time.Time) QueuesDebugStatus {
+	c.compactionStateMtx.Lock()
+	status := QueuesDebugStatus{
+		PendingTenants:   append([]string{}, c.pendingTenants...),
+		CompactingTenant: c.compactingTenant,
+		Jobs:             JobsStatus{Queued: []string{}, Running: []RunningJobStatus{}},
+	}
+	tenantCompactor := c.tenantCompactor
+	if c.compactingTenant != "" {
+		status.CompactingTenantDurationSeconds = now.Sub(c.compactingSince).Seconds()
+	}
+	c.compactionStateMtx.Unlock()
+
+	if tenantCompactor != nil {
+		status.Jobs = tenantCompactor.JobsStatus(now)
+	}
+	return status
+}
+
+func (c *MultitenantCompactor) setPendingTenants(tenants []string) {
+	c.compactionStateMtx.Lock()
+	c.pendingTenants = tenants
+	c.compactionStateMtx.Unlock()
+}
+
+func (c *MultitenantCompactor) setCompactingTenant(userID string, tenantCompactor *BucketCompactor) {
+	c.compactionStateMtx.Lock()
+	defer c.compactionStateMtx.Unlock()
+
+	c.compactingTenant = userID
+	c.compactingSince = time.Now()
+	c.tenantCompactor = tenantCompactor
+}
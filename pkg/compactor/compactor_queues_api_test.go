@@ -0,0 +1,78 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/objstore"
+)
+
+func TestMultitenantCompactor_QueuesDebugHandler(t *testing.T) {
+	c, _, _, _, _ := prepare(t, prepareConfig(t), objstore.NewInMemBucket())
+
+	getStatus := func(t *testing.T) QueuesDebugStatus {
+		resp := httptest.NewRecorder()
+		c.QueuesDebugHandler(resp, httptest.NewRequest("GET", "/debug/queues/compactor", nil))
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+		status := QueuesDebugStatus{}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &status))
+		return status
+	}
+
+	t.Run("should return an empty status if no compaction is running", func(t *testing.T) {
+		assert.Equal(t, QueuesDebugStatus{
+			PendingTenants: []string{},
+			Jobs:           JobsStatus{Queued: []string{}, Running: []RunningJobStatus{}},
+		}, getStatus(t))
+	})
+
+	t.Run("should return the tenant and the jobs being compacted", func(t *testing.T) {
+		job1 := NewJob("user-1", "job-1", nil, 0, metadata.NoneFunc, false, 0, "")
+		job2 := NewJob("user-1", "job-2", nil, 0, metadata.NoneFunc, false, 0, "")
+		job3 := NewJob("user-1", "job-3", nil, 0, metadata.NoneFunc, false, 0, "")
+		job4 := NewJob("user-1", "job-4", nil, 0, metadata.NoneFunc, false, 0, "")
+
+		bucketCompactor := &BucketCompactor{runningJobs: map[*Job]time.Time{}}
+		bucketCompactor.setQueuedJobs([]*Job{job3, job4})
+		bucketCompactor.startJob(job1)
+		bucketCompactor.startJob(job2)
+		bucketCompactor.runningJobs[job1] = time.Now().Add(-time.Hour)
+
+		c.setPendingTenants([]string{"user-2", "user-3"})
+		c.setCompactingTenant("user-1", bucketCompactor)
+
+		status := getStatus(t)
+		assert.Equal(t, []string{"user-2", "user-3"}, status.PendingTenants)
+		assert.Equal(t, "user-1", status.CompactingTenant)
+		assert.Equal(t, []string{"job-3", "job-4"}, status.Jobs.Queued)
+		require.Len(t, status.Jobs.Running, 2)
+		assert.Equal(t, "job-1", status.Jobs.Running[0].Key)
+		assert.GreaterOrEqual(t, status.Jobs.Running[0].DurationSeconds, time.Hour.Seconds())
+		assert.Equal(t, "job-2", status.Jobs.Running[1].Key)
+
+		// Once the jobs are done, they're not reported anymore.
+		bucketCompactor.setQueuedJobs(nil)
+		bucketCompactor.finishJob(job1, nil)
+		bucketCompactor.finishJob(job2, nil)
+
+		status = getStatus(t)
+		assert.Empty(t, status.Jobs.Queued)
+		assert.Empty(t, status.Jobs.Running)
+
+		c.setPendingTenants(nil)
+		c.setCompactingTenant("", nil)
+		assert.Equal(t, QueuesDebugStatus{
+			PendingTenants: []string{},
+			Jobs:           JobsStatus{Queued: []string{}, Running: []RunningJobStatus{}},
+		}, getStatus(t))
+	})
+}
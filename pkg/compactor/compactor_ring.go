@@ -17,6 +17,7 @@ import (
 	"github.com/grafana/dskit/netutil"
 	"github.com/grafana/dskit/ring"
 
+	"github.com/grafana/mimir/pkg/util"
 	util_log "github.com/grafana/mimir/pkg/util/log"
 )
 
@@ -38,6 +39,7 @@ type RingConfig struct {
 	InstanceInterfaceNames []string `yaml:"instance_interface_names" doc:"default=[<private network interfaces>]"`
 	InstancePort           int      `yaml:"instance_port" category:"advanced"`
 	InstanceAddr           string   `yaml:"instance_addr" category:"advanced"`
+	InstanceAddrFamily     string   `yaml:"instance_addr_family" category:"advanced"`
 
 	// Injected internally
 	ListenPort int `yaml:"-"`
@@ -69,6 +71,7 @@ func (cfg *RingConfig) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	cfg.InstanceInterfaceNames = netutil.PrivateNetworkInterfacesWithFallback([]string{"eth0", "en0"}, logger)
 	f.Var((*flagext.StringSlice)(&cfg.InstanceInterfaceNames), "compactor.ring.instance-interface-names", "List of network interface names to look up when finding the instance IP address.")
 	f.StringVar(&cfg.InstanceAddr, "compactor.ring.instance-addr", "", "IP address to advertise in the ring. Default is auto-detected.")
+	f.StringVar(&cfg.InstanceAddrFamily, "compactor.ring.instance-addr-family", util.AddrFamilyIPv4, "IP address family to prefer when auto-detecting the address to advertise in the ring. "+util.AddrFamilyUsage)
 	f.IntVar(&cfg.InstancePort, "compactor.ring.instance-port", 0, "Port to advertise in the ring (defaults to -server.grpc-listen-port).")
 	f.StringVar(&cfg.InstanceID, "compactor.ring.instance-id", hostname, "Instance ID to register in the ring.")
 
@@ -78,7 +81,12 @@ func (cfg *RingConfig) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 
 // ToLifecyclerConfig returns a LifecyclerConfig based on the compactor
 // ring config.
-func (cfg *RingConfig) ToLifecyclerConfig() ring.LifecyclerConfig {
+func (cfg *RingConfig) ToLifecyclerConfig() (ring.LifecyclerConfig, error) {
+	instanceAddr, err := util.GetInstanceAddr(cfg.InstanceAddr, cfg.InstanceInterfaceNames, cfg.InstanceAddrFamily)
+	if err != nil {
+		return ring.LifecyclerConfig{}, err
+	}
+
 	// We have to make sure that the ring.LifecyclerConfig and ring.Config
 	// defaults are preserved
 	lc := ring.LifecyclerConfig{}
@@ -96,7 +104,7 @@ func (cfg *RingConfig) ToLifecyclerConfig() ring.LifecyclerConfig {
 	lc.RingConfig = rc
 	lc.RingConfig.SubringCacheDisabled = true
 	lc.ListenPort = cfg.ListenPort
-	lc.Addr = cfg.InstanceAddr
+	lc.Addr = util.LifecyclerAddr(instanceAddr)
 	lc.Port = cfg.InstancePort
 	lc.ID = cfg.InstanceID
 	lc.InfNames = cfg.InstanceInterfaceNames
@@ -111,5 +119,5 @@ func (cfg *RingConfig) ToLifecyclerConfig() ring.LifecyclerConfig {
 	// in order to simplify the config.
 	lc.NumTokens = 512
 
-	return lc
+	return lc, nil
 }
@@ -0,0 +1,178 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grafana/mimir/pkg/util"
+)
+
+// Max number of completed jobs returned by the status API.
+const maxCompletedJobsHistory = 100
+
+// CompletedJobStatus is a compaction job completed by the compactor.
+type CompletedJobStatus struct {
+	Tenant          string    `json:"tenant"`
+	Key             string    `json:"key"`
+	StartedAt       time.Time `json:"started_at"`
+	DurationSeconds float64   `json:"duration_seconds"`
+
+	// Error of the failed compaction. Empty if the job has been successfully compacted.
+	Error string `json:"error,omitempty"`
+}
+
+// TenantCompactionStatus is the compaction status of a tenant owned by the compactor.
+type TenantCompactionStatus struct {
+	Tenant string `json:"tenant"`
+
+	// Time of the last successful compaction of the tenant. Nil if the tenant hasn't been successfully
+	// compacted since the compactor started.
+	LastSuccessfulCompaction *time.Time `json:"last_successful_compaction,omitempty"`
+
+	// Compaction progress estimated from the last compaction pass of the tenant: creation time and age of the
+	// oldest block uploaded by the ingesters waiting to be compacted, if any, and number of jobs left.
+	// Nil if the tenant hasn't been planned since the compactor started.
+	OldestUncompactedBlockTime       *time.Time `json:"oldest_uncompacted_block_time,omitempty"`
+	OldestUncompactedBlockAgeSeconds float64    `json:"oldest_uncompacted_block_age_seconds,omitempty"`
+	EstimatedRemainingJobs           *int       `json:"estimated_remaining_jobs,omitempty"`
+}
+
+// StatusResponse is the response of the compactor status API.
+type StatusResponse struct {
+	// Tenant being compacted, and the number of tenants not processed yet by the running compaction. Empty if
+	// no tenant is being compacted.
+	CompactingTenant string `json:"compacting_tenant"`
+	PendingTenants   int    `json:"pending_tenants"`
+
+	// Jobs of the tenant being compacted waiting for a compaction worker, in the order they will be run, and
+	// jobs being compacted, sorted by decreasing duration.
+	PlannedJobs    []string           `json:"planned_jobs"`
+	InProgressJobs []RunningJobStatus `json:"in_progress_jobs"`
+
+	// Jobs recently completed by this compactor, most recent first.
+	RecentlyCompletedJobs []CompletedJobStatus `json:"recently_completed_jobs"`
+
+	// Tenants successfully compacted or planned since the compactor started, sorted by tenant.
+	Tenants []TenantCompactionStatus `json:"tenants"`
+}
+
+// StatusHandler writes the compaction jobs planned, in progress and recently completed, and the last successful
+// compaction time and the compaction progress of each tenant, as JSON.
+func (c *MultitenantCompactor) StatusHandler(w http.ResponseWriter, _ *http.Request) {
+	util.WriteJSONResponse(w, c.status(time.Now()))
+}
+
+func (c *MultitenantCompactor) status(now time.Time) StatusResponse {
+	queues := c.queuesDebugStatus(now)
+
+	res := StatusResponse{
+		CompactingTenant:      queues.CompactingTenant,
+		PendingTenants:        len(queues.PendingTenants),
+		PlannedJobs:           queues.Jobs.Queued,
+		InProgressJobs:        queues.Jobs.Running,
+		RecentlyCompletedJobs: c.completedJobs.list(),
+	}
+
+	tenants := map[string]*TenantCompactionStatus{}
+	getTenant := func(userID string) *TenantCompactionStatus {
+		if _, ok := tenants[userID]; !ok {
+			tenants[userID] = &TenantCompactionStatus{Tenant: userID}
+		}
+		return tenants[userID]
+	}
+
+	c.compactionStateMtx.Lock()
+	for userID, ts := range c.lastSuccessfulCompactions {
+		ts := ts
+		getTenant(userID).LastSuccessfulCompaction = &ts
+	}
+	c.compactionStateMtx.Unlock()
+
+	for userID, progress := range c.compactionProgress.list() {
+		status := getTenant(userID)
+		remainingJobs := progress.remainingJobs
+		status.EstimatedRemainingJobs = &remainingJobs
+		if !progress.oldestUncompactedBlockTime.IsZero() {
+			oldest := progress.oldestUncompactedBlockTime.UTC()
+			status.OldestUncompactedBlockTime = &oldest
+			status.OldestUncompactedBlockAgeSeconds = now.Sub(oldest).Seconds()
+		}
+	}
+
+	res.Tenants = make([]TenantCompactionStatus, 0, len(tenants))
+	for _, status := range tenants {
+		res.Tenants = append(res.Tenants, *status)
+	}
+
+	sort.Slice(res.Tenants, func(i, j int) bool {
+		return res.Tenants[i].Tenant < res.Tenants[j].Tenant
+	})
+	return res
+}
+
+func (c *MultitenantCompactor) setLastSuccessfulCompaction(userID string, ts time.Time) {
+	c.compactionStateMtx.Lock()
+	c.lastSuccessfulCompactions[userID] = ts
+	c.compactionStateMtx.Unlock()
+}
+
+// retainLastSuccessfulCompactions removes the last successful compaction of the tenants not in the input ones.
+func (c *MultitenantCompactor) retainLastSuccessfulCompactions(userIDs map[string]struct{}) {
+	c.compactionStateMtx.Lock()
+	defer c.compactionStateMtx.Unlock()
+
+	for userID := range c.lastSuccessfulCompactions {
+		if _, ok := userIDs[userID]; !ok {
+			delete(c.lastSuccessfulCompactions, userID)
+		}
+	}
+}
+
+// completedJobsHistory keeps the last completed compaction jobs.
+type completedJobsHistory struct {
+	maxJobs int
+
+	mtx  sync.Mutex
+	jobs []CompletedJobStatus // Oldest first.
+}
+
+func newCompletedJobsHistory(maxJobs int) *completedJobsHistory {
+	return &completedJobsHistory{maxJobs: maxJobs}
+}
+
+func (h *completedJobsHistory) add(job *Job, startedAt, completedAt time.Time, err error) {
+	status := CompletedJobStatus{
+		Tenant:          job.UserID(),
+		Key:             job.Key(),
+		StartedAt:       startedAt,
+		DurationSeconds: completedAt.Sub(startedAt).Seconds(),
+	}
+	if err != nil {
+		status.Error = err.Error()
+	}
+
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	h.jobs = append(h.jobs, status)
+	if len(h.jobs) > h.maxJobs {
+		// Copy the retained jobs, so that the evicted ones don't stay in the underlying array.
+		h.jobs = append(make([]CompletedJobStatus, 0, h.maxJobs), h.jobs[len(h.jobs)-h.maxJobs:]...)
+	}
+}
+
+// list returns the completed jobs, most recent first.
+func (h *completedJobsHistory) list() []CompletedJobStatus {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	res := make([]CompletedJobStatus, 0, len(h.jobs))
+	for i := len(h.jobs) - 1; i >= 0; i-- {
+		res = append(res, h.jobs[i])
+	}
+	return res
+}
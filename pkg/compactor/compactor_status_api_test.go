@@ -0,0 +1,96 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/objstore"
+)
+
+func TestMultitenantCompactor_StatusHandler(t *testing.T) {
+	c, _, _, _, _ := prepare(t, prepareConfig(t), objstore.NewInMemBucket())
+
+	getStatus := func(t *testing.T) StatusResponse {
+		resp := httptest.NewRecorder()
+		c.StatusHandler(resp, httptest.NewRequest("GET", "/compactor/status", nil))
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+		status := StatusResponse{}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &status))
+		return status
+	}
+
+	assert.Equal(t, StatusResponse{
+		PlannedJobs:           []string{},
+		InProgressJobs:        []RunningJobStatus{},
+		RecentlyCompletedJobs: []CompletedJobStatus{},
+		Tenants:               []TenantCompactionStatus{},
+	}, getStatus(t))
+
+	job1 := NewJob("user-1", "job-1", nil, 0, metadata.NoneFunc, false, 0, "")
+	job2 := NewJob("user-1", "job-2", nil, 0, metadata.NoneFunc, false, 0, "")
+	job3 := NewJob("user-1", "job-3", nil, 0, metadata.NoneFunc, false, 0, "")
+
+	bucketCompactor := &BucketCompactor{runningJobs: map[*Job]time.Time{}, completedJobs: c.completedJobs}
+	bucketCompactor.setQueuedJobs([]*Job{job3})
+	bucketCompactor.startJob(job1)
+	bucketCompactor.startJob(job2)
+	bucketCompactor.finishJob(job1, errors.New("compaction failed"))
+
+	lastCompaction := time.Unix(1650000000, 0).UTC()
+	c.setLastSuccessfulCompaction("user-2", lastCompaction)
+	c.setPendingTenants([]string{"user-3"})
+	c.setCompactingTenant("user-1", bucketCompactor)
+
+	status := getStatus(t)
+	assert.Equal(t, "user-1", status.CompactingTenant)
+	assert.Equal(t, 1, status.PendingTenants)
+	assert.Equal(t, []string{"job-3"}, status.PlannedJobs)
+	require.Len(t, status.InProgressJobs, 1)
+	assert.Equal(t, "job-2", status.InProgressJobs[0].Key)
+	require.Len(t, status.RecentlyCompletedJobs, 1)
+	assert.Equal(t, "user-1", status.RecentlyCompletedJobs[0].Tenant)
+	assert.Equal(t, "job-1", status.RecentlyCompletedJobs[0].Key)
+	assert.Equal(t, "compaction failed", status.RecentlyCompletedJobs[0].Error)
+	assert.Equal(t, []TenantCompactionStatus{{Tenant: "user-2", LastSuccessfulCompaction: &lastCompaction}}, status.Tenants)
+
+	// The progress of the planned tenants is reported.
+	c.compactionProgress.forTenant("user-1").planned([]*Job{job1, job2, job3})
+
+	remainingJobs := 3
+	assert.Equal(t, []TenantCompactionStatus{
+		{Tenant: "user-1", EstimatedRemainingJobs: &remainingJobs},
+		{Tenant: "user-2", LastSuccessfulCompaction: &lastCompaction},
+	}, getStatus(t).Tenants)
+
+	// The tenants not owned anymore are not reported.
+	c.retainLastSuccessfulCompactions(map[string]struct{}{"user-1": {}})
+	c.compactionProgress.retain(map[string]struct{}{"user-2": {}})
+	assert.Empty(t, getStatus(t).Tenants)
+}
+
+func TestCompletedJobsHistory(t *testing.T) {
+	h := newCompletedJobsHistory(2)
+	assert.Empty(t, h.list())
+
+	start := time.Unix(1650000000, 0).UTC()
+	for i, key := range []string{"job-1", "job-2", "job-3"} {
+		startedAt := start.Add(time.Duration(i) * time.Minute)
+		h.add(NewJob("user-1", key, nil, 0, metadata.NoneFunc, false, 0, ""), startedAt, startedAt.Add(time.Second), nil)
+	}
+
+	// Only the most recent jobs are kept, most recent first.
+	assert.Equal(t, []CompletedJobStatus{
+		{Tenant: "user-1", Key: "job-3", StartedAt: start.Add(2 * time.Minute), DurationSeconds: 1},
+		{Tenant: "user-1", Key: "job-2", StartedAt: start.Add(time.Minute), DurationSeconds: 1},
+	}, h.list())
+}
@@ -0,0 +1,178 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/runutil"
+
+	util_log "github.com/grafana/mimir/pkg/util/log"
+)
+
+// TenantShardSizePath is the location of the tenant's automatically computed compactor shard size,
+// relative to the tenant's prefix in the bucket.
+const TenantShardSizePath = "markers/compactor-shard-size.json"
+
+// TenantShardSize holds the compactor shard size of a tenant, grown because of a backlog of compaction jobs.
+// It's stored in the bucket, so that all compactors agree on the tenant's shard.
+type TenantShardSize struct {
+	ShardSize int `json:"shard_size"`
+
+	// Unix timestamp when the shard size was last updated.
+	UpdatedAt int64 `json:"updated_at"`
+}
+
+// tenantShardSizes keeps track of the compactor shard size of each tenant. The shard size configured by
+// -compactor.compactor-tenant-shard-size is automatically grown, up to -compactor.compactor-tenant-max-shard-size,
+// while the tenant has a backlog of compaction jobs, and shrunk back once the backlog has been compacted.
+type tenantShardSizes struct {
+	bkt            objstore.Bucket
+	configProvider ConfigProvider
+	logger         log.Logger
+
+	mtx   sync.RWMutex
+	sizes map[string]int
+}
+
+func newTenantShardSizes(bkt objstore.Bucket, configProvider ConfigProvider, logger log.Logger) *tenantShardSizes {
+	return &tenantShardSizes{
+		bkt:            bkt,
+		configProvider: configProvider,
+		logger:         logger,
+		sizes:          map[string]int{},
+	}
+}
+
+// enabled returns whether the automatic growth of the user's shard is enabled. It requires both the
+// shard size and a greater max shard size to be configured.
+func (t *tenantShardSizes) enabled(userID string) bool {
+	size := t.configProvider.CompactorTenantShardSize(userID)
+	return size > 0 && t.configProvider.CompactorTenantMaxShardSize(userID) > size
+}
+
+// shardSize returns the number of compactors in the user's shard. 0 = all compactors.
+func (t *tenantShardSizes) shardSize(userID string) int {
+	size := t.configProvider.CompactorTenantShardSize(userID)
+	if !t.enabled(userID) {
+		return size
+	}
+
+	t.mtx.RLock()
+	grown := t.sizes[userID]
+	t.mtx.RUnlock()
+
+	return clampShardSize(grown, size, t.configProvider.CompactorTenantMaxShardSize(userID))
+}
+
+// sync reads the user's shard size from the bucket.
+func (t *tenantShardSizes) sync(ctx context.Context, userID string) error {
+	if !t.enabled(userID) {
+		t.set(userID, 0)
+		return nil
+	}
+
+	stored, err := readTenantShardSize(ctx, t.bkt, userID)
+	if err != nil {
+		return err
+	}
+
+	if stored == nil {
+		t.set(userID, 0)
+	} else {
+		t.set(userID, stored.ShardSize)
+	}
+	return nil
+}
+
+// update grows or shrinks the user's shard based on the number of pending compaction jobs, and stores the
+// new shard size in the bucket. The shard is grown as soon as there are more pending jobs than the configured
+// jobs per compactor, while it's only shrunk once the number of pending jobs per compactor has halved, to
+// avoid flapping.
+func (t *tenantShardSizes) update(ctx context.Context, userID string, pendingJobs int) error {
+	if !t.enabled(userID) {
+		return nil
+	}
+
+	minSize := t.configProvider.CompactorTenantShardSize(userID)
+	maxSize := t.configProvider.CompactorTenantMaxShardSize(userID)
+	jobsPerCompactor := t.configProvider.CompactorTenantShardSizeJobsPerCompactor(userID)
+	if jobsPerCompactor <= 0 {
+		return nil
+	}
+
+	current := t.shardSize(userID)
+	wanted := clampShardSize((pendingJobs+jobsPerCompactor-1)/jobsPerCompactor, minSize, maxSize)
+
+	switch {
+	case wanted > current:
+	case wanted < current && pendingJobs < jobsPerCompactor*current/2:
+	default:
+		return nil
+	}
+
+	data, err := json.Marshal(TenantShardSize{ShardSize: wanted, UpdatedAt: time.Now().Unix()})
+	if err != nil {
+		return errors.Wrap(err, "serialize compactor shard size")
+	}
+	if err := t.bkt.Upload(ctx, path.Join(userID, TenantShardSizePath), bytes.NewReader(data)); err != nil {
+		return errors.Wrap(err, "upload compactor shard size")
+	}
+
+	t.set(userID, wanted)
+	level.Info(t.logger).Log("msg", "updated compactor shard size of user", "user", userID, "pending_jobs", pendingJobs, "previous_shard_size", current, "shard_size", wanted)
+	return nil
+}
+
+func (t *tenantShardSizes) set(userID string, size int) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if size <= 0 {
+		delete(t.sizes, userID)
+		return
+	}
+	t.sizes[userID] = size
+}
+
+// readTenantShardSize returns the compactor shard size stored in the bucket for the given user.
+// If it doesn't exist, returns nil and no error.
+func readTenantShardSize(ctx context.Context, bkt objstore.BucketReader, userID string) (*TenantShardSize, error) {
+	name := path.Join(userID, TenantShardSizePath)
+
+	r, err := bkt.Get(ctx, name)
+	if err != nil {
+		if bkt.IsObjNotFoundErr(err) {
+			return nil, nil
+		}
+
+		return nil, errors.Wrapf(err, "failed to read compactor shard size object: %s", name)
+	}
+	defer runutil.CloseWithLogOnErr(util_log.Logger, r, "close compactor shard size reader")
+
+	size := &TenantShardSize{}
+	if err := json.NewDecoder(r).Decode(size); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode compactor shard size object: %s", name)
+	}
+
+	return size, nil
+}
+
+func clampShardSize(size, minSize, maxSize int) int {
+	if size < minSize {
+		size = minSize
+	}
+	if maxSize > 0 && size > maxSize {
+		size = maxSize
+	}
+	return size
+}
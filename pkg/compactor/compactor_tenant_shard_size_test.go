@@ -0,0 +1,93 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/objstore"
+)
+
+func TestTenantShardSizes(t *testing.T) {
+	tests := map[string]struct {
+		shardSize        int
+		maxShardSize     int
+		pendingJobs      []int
+		expectedSizes    []int
+		expectedInBucket int
+	}{
+		"should not grow the shard if the max shard size is disabled": {
+			shardSize:     2,
+			maxShardSize:  0,
+			pendingJobs:   []int{100},
+			expectedSizes: []int{2},
+		},
+		"should not grow the shard if the shard size is disabled": {
+			shardSize:     0,
+			maxShardSize:  10,
+			pendingJobs:   []int{100},
+			expectedSizes: []int{0},
+		},
+		"should not grow the shard if the backlog is small": {
+			shardSize:     2,
+			maxShardSize:  10,
+			pendingJobs:   []int{5, 20},
+			expectedSizes: []int{2, 2},
+		},
+		"should grow the shard when the backlog is large, up to the max shard size": {
+			shardSize:        2,
+			maxShardSize:     10,
+			pendingJobs:      []int{35, 500},
+			expectedSizes:    []int{4, 10},
+			expectedInBucket: 10,
+		},
+		"should shrink the shard only once the backlog has halved": {
+			shardSize:        2,
+			maxShardSize:     10,
+			pendingJobs:      []int{80, 50, 35, 0},
+			expectedSizes:    []int{8, 8, 4, 2},
+			expectedInBucket: 2,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			ctx := context.Background()
+			bkt := objstore.NewInMemBucket()
+
+			cfgProvider := newMockConfigProvider()
+			cfgProvider.instancesShardSize = map[string]int{"user-1": testData.shardSize}
+			cfgProvider.instancesMaxShardSize["user-1"] = testData.maxShardSize
+			cfgProvider.jobsPerCompactor["user-1"] = 10
+
+			sizes := newTenantShardSizes(bkt, cfgProvider, log.NewNopLogger())
+			require.NoError(t, sizes.sync(ctx, "user-1"))
+
+			for i, pendingJobs := range testData.pendingJobs {
+				require.NoError(t, sizes.update(ctx, "user-1", pendingJobs))
+				assert.Equal(t, testData.expectedSizes[i], sizes.shardSize("user-1"), "update #%d", i)
+			}
+
+			// Another compactor reads the same shard size from the bucket.
+			other := newTenantShardSizes(bkt, cfgProvider, log.NewNopLogger())
+			require.NoError(t, other.sync(ctx, "user-1"))
+			assert.Equal(t, testData.expectedSizes[len(testData.expectedSizes)-1], other.shardSize("user-1"))
+
+			stored, err := readTenantShardSize(ctx, bkt, "user-1")
+			require.NoError(t, err)
+			if testData.expectedInBucket == 0 {
+				assert.Nil(t, stored)
+			} else {
+				require.NotNil(t, stored)
+				assert.Equal(t, testData.expectedInBucket, stored.ShardSize)
+			}
+
+			// Other tenants are not affected.
+			assert.Equal(t, 0, sizes.shardSize("user-2"))
+		})
+	}
+}
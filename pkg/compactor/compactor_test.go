@@ -33,6 +33,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	prom_testutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/tsdb"
 	"github.com/prometheus/prometheus/tsdb/chunks"
@@ -95,52 +96,73 @@ func TestConfig_ShouldSupportCliFlags(t *testing.T) {
 
 func TestConfig_Validate(t *testing.T) {
 	tests := map[string]struct {
-		setup    func(cfg *Config)
+		setup    func(cfg *Config, limits *validation.Limits)
 		expected string
 	}{
 		"should pass with the default config": {
-			setup:    func(cfg *Config) {},
+			setup:    func(cfg *Config, _ *validation.Limits) {},
 			expected: "",
 		},
 		"should pass with only 1 block range period": {
-			setup: func(cfg *Config) {
+			setup: func(cfg *Config, _ *validation.Limits) {
 				cfg.BlockRanges = mimir_tsdb.DurationList{time.Hour}
 			},
 			expected: "",
 		},
 		"should fail with non divisible block range periods": {
-			setup: func(cfg *Config) {
+			setup: func(cfg *Config, _ *validation.Limits) {
 				cfg.BlockRanges = mimir_tsdb.DurationList{2 * time.Hour, 12 * time.Hour, 24 * time.Hour, 30 * time.Hour}
 			},
 			expected: errors.Errorf(errInvalidBlockRanges, 30*time.Hour, 24*time.Hour).Error(),
 		},
 		"should fail on unknown compaction jobs order": {
-			setup: func(cfg *Config) {
+			setup: func(cfg *Config, _ *validation.Limits) {
 				cfg.CompactionJobsOrder = "everything-is-important"
 			},
 			expected: errInvalidCompactionOrder.Error(),
 		},
 		"should fail on invalid value of max-opening-blocks-concurrency": {
-			setup:    func(cfg *Config) { cfg.MaxOpeningBlocksConcurrency = 0 },
+			setup:    func(cfg *Config, _ *validation.Limits) { cfg.MaxOpeningBlocksConcurrency = 0 },
 			expected: errInvalidMaxOpeningBlocksConcurrency.Error(),
 		},
 		"should fail on invalid value of max-closing-blocks-concurrency": {
-			setup:    func(cfg *Config) { cfg.MaxClosingBlocksConcurrency = 0 },
+			setup:    func(cfg *Config, _ *validation.Limits) { cfg.MaxClosingBlocksConcurrency = 0 },
 			expected: errInvalidMaxClosingBlocksConcurrency.Error(),
 		},
 		"should fail on invalid value of symbols-flushers-concurrency": {
-			setup:    func(cfg *Config) { cfg.SymbolsFlushersConcurrency = 0 },
+			setup:    func(cfg *Config, _ *validation.Limits) { cfg.SymbolsFlushersConcurrency = 0 },
 			expected: errInvalidSymbolFlushersConcurrency.Error(),
 		},
+		"should pass with the object lock period lower than the retention period": {
+			setup: func(_ *Config, limits *validation.Limits) {
+				limits.CompactorBlocksRetentionPeriod = model.Duration(30 * 24 * time.Hour)
+				limits.CompactorBlocksObjectLockPeriod = model.Duration(7 * 24 * time.Hour)
+			},
+			expected: "",
+		},
+		"should pass with the object lock period and no retention period": {
+			setup: func(_ *Config, limits *validation.Limits) {
+				limits.CompactorBlocksObjectLockPeriod = model.Duration(7 * 24 * time.Hour)
+			},
+			expected: "",
+		},
+		"should fail with the object lock period greater than the retention period": {
+			setup: func(_ *Config, limits *validation.Limits) {
+				limits.CompactorBlocksRetentionPeriod = model.Duration(7 * 24 * time.Hour)
+				limits.CompactorBlocksObjectLockPeriod = model.Duration(30 * 24 * time.Hour)
+			},
+			expected: fmt.Sprintf(errInvalidBlocksObjectLockPeriod, 30*24*time.Hour, 7*24*time.Hour),
+		},
 	}
 
 	for testName, testData := range tests {
 		t.Run(testName, func(t *testing.T) {
 			cfg := &Config{}
-			flagext.DefaultValues(cfg)
-			testData.setup(cfg)
+			limits := validation.Limits{}
+			flagext.DefaultValues(cfg, &limits)
+			testData.setup(cfg, &limits)
 
-			if actualErr := cfg.Validate(); testData.expected != "" {
+			if actualErr := cfg.Validate(limits); testData.expected != "" {
 				assert.EqualError(t, actualErr, testData.expected)
 			} else {
 				assert.NoError(t, actualErr)
@@ -254,7 +276,9 @@ func TestMultitenantCompactor_ShouldDoNothingOnNoUserBlocks(t *testing.T) {
 		# HELP cortex_compactor_blocks_marked_for_deletion_total Total number of blocks marked for deletion in compactor.
 		# TYPE cortex_compactor_blocks_marked_for_deletion_total counter
 		cortex_compactor_blocks_marked_for_deletion_total{reason="compaction"} 0
+		cortex_compactor_blocks_marked_for_deletion_total{reason="manual"} 0
 		cortex_compactor_blocks_marked_for_deletion_total{reason="retention"} 0
+		cortex_compactor_blocks_marked_for_deletion_total{reason="tenant-deletion"} 0
 
 		# TYPE cortex_compactor_block_cleanup_started_total counter
 		# HELP cortex_compactor_block_cleanup_started_total Total number of blocks cleanup runs started.
@@ -398,7 +422,9 @@ func TestMultitenantCompactor_ShouldRetryCompactionOnFailureWhileDiscoveringUser
 		# HELP cortex_compactor_blocks_marked_for_deletion_total Total number of blocks marked for deletion in compactor.
 		# TYPE cortex_compactor_blocks_marked_for_deletion_total counter
 		cortex_compactor_blocks_marked_for_deletion_total{reason="compaction"} 0
+		cortex_compactor_blocks_marked_for_deletion_total{reason="manual"} 0
 		cortex_compactor_blocks_marked_for_deletion_total{reason="retention"} 0
+		cortex_compactor_blocks_marked_for_deletion_total{reason="tenant-deletion"} 0
 
 		# TYPE cortex_compactor_block_cleanup_started_total counter
 		# HELP cortex_compactor_block_cleanup_started_total Total number of blocks cleanup runs started.
@@ -609,7 +635,9 @@ func TestMultitenantCompactor_ShouldIterateOverUsersAndRunCompaction(t *testing.
 		# HELP cortex_compactor_blocks_marked_for_deletion_total Total number of blocks marked for deletion in compactor.
 		# TYPE cortex_compactor_blocks_marked_for_deletion_total counter
 		cortex_compactor_blocks_marked_for_deletion_total{reason="compaction"} 0
+		cortex_compactor_blocks_marked_for_deletion_total{reason="manual"} 0
 		cortex_compactor_blocks_marked_for_deletion_total{reason="retention"} 0
+		cortex_compactor_blocks_marked_for_deletion_total{reason="tenant-deletion"} 0
 
 		# TYPE cortex_compactor_block_cleanup_started_total counter
 		# HELP cortex_compactor_block_cleanup_started_total Total number of blocks cleanup runs started.
@@ -623,6 +651,21 @@ func TestMultitenantCompactor_ShouldIterateOverUsersAndRunCompaction(t *testing.
 		# HELP cortex_compactor_block_cleanup_failed_total Total number of blocks cleanup runs failed.
 		cortex_compactor_block_cleanup_failed_total 0
 	`), testedMetrics...))
+
+	// The jobs and the tenants successfully compacted are reported by the status API.
+	status := c.status(time.Now())
+	require.Len(t, status.RecentlyCompletedJobs, 2)
+	assert.ElementsMatch(t, []string{"user-1", "user-2"}, []string{status.RecentlyCompletedJobs[0].Tenant, status.RecentlyCompletedJobs[1].Tenant})
+	for _, job := range status.RecentlyCompletedJobs {
+		assert.Equal(t, "0@17241709254077376921-merge--1574776800000-1574784000000", job.Key)
+		assert.Empty(t, job.Error)
+	}
+	require.Len(t, status.Tenants, 2)
+	assert.Equal(t, "user-1", status.Tenants[0].Tenant)
+	assert.Equal(t, "user-2", status.Tenants[1].Tenant)
+	assert.Empty(t, status.CompactingTenant)
+	assert.Empty(t, status.PlannedJobs)
+	assert.Empty(t, status.InProgressJobs)
 }
 
 func TestMultitenantCompactor_ShouldStopCompactingTenantOnReachingMaxCompactionTime(t *testing.T) {
@@ -793,7 +836,9 @@ func TestMultitenantCompactor_ShouldNotCompactBlocksMarkedForDeletion(t *testing
 		# HELP cortex_compactor_blocks_marked_for_deletion_total Total number of blocks marked for deletion in compactor.
 		# TYPE cortex_compactor_blocks_marked_for_deletion_total counter
 		cortex_compactor_blocks_marked_for_deletion_total{reason="compaction"} 0
+		cortex_compactor_blocks_marked_for_deletion_total{reason="manual"} 0
 		cortex_compactor_blocks_marked_for_deletion_total{reason="retention"} 0
+		cortex_compactor_blocks_marked_for_deletion_total{reason="tenant-deletion"} 0
 
 		# TYPE cortex_compactor_block_cleanup_started_total counter
 		# HELP cortex_compactor_block_cleanup_started_total Total number of blocks cleanup runs started.
@@ -949,7 +994,9 @@ func TestMultitenantCompactor_ShouldNotCompactBlocksForUsersMarkedForDeletion(t
 		# HELP cortex_compactor_blocks_marked_for_deletion_total Total number of blocks marked for deletion in compactor.
 		# TYPE cortex_compactor_blocks_marked_for_deletion_total counter
 		cortex_compactor_blocks_marked_for_deletion_total{reason="compaction"} 0
+		cortex_compactor_blocks_marked_for_deletion_total{reason="manual"} 0
 		cortex_compactor_blocks_marked_for_deletion_total{reason="retention"} 0
+		cortex_compactor_blocks_marked_for_deletion_total{reason="tenant-deletion"} 0
 
 		# TYPE cortex_compactor_block_cleanup_started_total counter
 		# HELP cortex_compactor_block_cleanup_started_total Total number of blocks cleanup runs started.
@@ -1079,7 +1126,9 @@ func TestMultitenantCompactor_ShouldCompactAllUsersOnShardingEnabledButOnlyOneIn
 		# HELP cortex_compactor_blocks_marked_for_deletion_total Total number of blocks marked for deletion in compactor.
 		# TYPE cortex_compactor_blocks_marked_for_deletion_total counter
 		cortex_compactor_blocks_marked_for_deletion_total{reason="compaction"} 0
+		cortex_compactor_blocks_marked_for_deletion_total{reason="manual"} 0
 		cortex_compactor_blocks_marked_for_deletion_total{reason="retention"} 0
+		cortex_compactor_blocks_marked_for_deletion_total{reason="tenant-deletion"} 0
 	`),
 		"cortex_compactor_runs_started_total",
 		"cortex_compactor_runs_completed_total",
@@ -1289,7 +1338,9 @@ func TestMultitenantCompactor_ShouldSkipCompactionForJobsNoMoreOwnedAfterPlannin
 		# HELP cortex_compactor_blocks_marked_for_deletion_total Total number of blocks marked for deletion in compactor.
 		# TYPE cortex_compactor_blocks_marked_for_deletion_total counter
 		cortex_compactor_blocks_marked_for_deletion_total{reason="compaction"} 0
+		cortex_compactor_blocks_marked_for_deletion_total{reason="manual"} 0
 		cortex_compactor_blocks_marked_for_deletion_total{reason="retention"} 0
+		cortex_compactor_blocks_marked_for_deletion_total{reason="tenant-deletion"} 0
 	`),
 		"cortex_compactor_runs_started_total",
 		"cortex_compactor_runs_completed_total",
@@ -1982,6 +2033,7 @@ func TestMultitenantCompactor_OutOfOrderCompaction(t *testing.T) {
 		# HELP cortex_compactor_blocks_marked_for_no_compaction_total Total number of blocks that were marked for no-compaction.
 		# TYPE cortex_compactor_blocks_marked_for_no_compaction_total counter
 		cortex_compactor_blocks_marked_for_no_compaction_total{reason="block-index-out-of-order-chunk"} 1
+		cortex_compactor_blocks_marked_for_no_compaction_total{reason="manual"} 0
 	`),
 		"cortex_compactor_blocks_marked_for_no_compaction_total",
 	))
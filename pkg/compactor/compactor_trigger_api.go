@@ -0,0 +1,192 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/gorilla/mux"
+	"github.com/grafana/dskit/services"
+
+	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/util"
+)
+
+// compactionTimeRange restricts a compaction to the jobs whose blocks overlap [minTime, maxTime), in milliseconds.
+type compactionTimeRange struct {
+	minTime int64
+	maxTime int64
+}
+
+// fullCompactionTimeRange doesn't restrict the compaction jobs.
+var fullCompactionTimeRange = compactionTimeRange{minTime: math.MinInt64, maxTime: math.MaxInt64}
+
+func (r compactionTimeRange) overlaps(job *Job) bool {
+	return r.overlapsMillis(job.MinTime(), job.MaxTime())
+}
+
+// overlapsMillis returns whether the time range overlaps [minTime, maxTime], in milliseconds.
+func (r compactionTimeRange) overlapsMillis(minTime, maxTime int64) bool {
+	return minTime < r.maxTime && maxTime > r.minTime
+}
+
+// union returns the smallest time range containing both r and other.
+func (r compactionTimeRange) union(other compactionTimeRange) compactionTimeRange {
+	res := r
+	if other.minTime < res.minTime {
+		res.minTime = other.minTime
+	}
+	if other.maxTime > res.maxTime {
+		res.maxTime = other.maxTime
+	}
+	return res
+}
+
+// TriggerCompactionResponse is the response of the compaction trigger API.
+type TriggerCompactionResponse struct {
+	Tenant string `json:"tenant"`
+
+	// Time range of the compaction jobs run, in milliseconds. Zero if the compaction isn't restricted.
+	MinTime int64 `json:"min_time,omitempty"`
+	MaxTime int64 `json:"max_time,omitempty"`
+}
+
+// TriggerCompactionHandler enqueues an immediate compaction of a tenant owned by this compactor, without waiting
+// for the next compaction interval. The compaction can be restricted to the jobs overlapping the time range set
+// by the optional start and end parameters. The compaction starts once the running one, if any, has completed.
+func (c *MultitenantCompactor) TriggerCompactionHandler(w http.ResponseWriter, req *http.Request) {
+	if c.State() != services.Running {
+		http.Error(w, "Compactor is not running yet.", http.StatusServiceUnavailable)
+		return
+	}
+
+	tenantID := mux.Vars(req)["tenant"]
+	timeRange := fullCompactionTimeRange
+	resp := TriggerCompactionResponse{Tenant: tenantID}
+
+	if v := req.FormValue("start"); v != "" {
+		start, err := util.ParseTime(v)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid start parameter: %s", v), http.StatusBadRequest)
+			return
+		}
+		timeRange.minTime, resp.MinTime = start, start
+	}
+	if v := req.FormValue("end"); v != "" {
+		end, err := util.ParseTime(v)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid end parameter: %s", v), http.StatusBadRequest)
+			return
+		}
+		timeRange.maxTime, resp.MaxTime = end, end
+	}
+	if timeRange.minTime >= timeRange.maxTime {
+		http.Error(w, "the end parameter must be after the start parameter", http.StatusBadRequest)
+		return
+	}
+
+	// The tenant is compacted by a single compactor, so the compaction must be triggered on that one.
+	if err := c.tenantShardSizes.sync(req.Context(), tenantID); err != nil {
+		http.Error(w, fmt.Sprintf("failed to read the compactor shard size of the tenant: %s", err), http.StatusInternalServerError)
+		return
+	}
+	if owned, err := c.shardingStrategy.compactorOwnUser(tenantID); err != nil {
+		http.Error(w, fmt.Sprintf("failed to check if the tenant is owned by this compactor: %s", err), http.StatusInternalServerError)
+		return
+	} else if !owned {
+		http.Error(w, "the tenant is not owned by this compactor", http.StatusBadRequest)
+		return
+	}
+
+	c.triggerCompaction(tenantID, timeRange)
+	level.Info(c.logger).Log("msg", "compaction of user blocks triggered", "user", tenantID, "min_time", resp.MinTime, "max_time", resp.MaxTime)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	util.WriteJSONResponse(w, resp)
+}
+
+// triggerCompaction enqueues the compaction of the tenant. The time range is merged with the one of the compaction
+// of the tenant already enqueued, if any.
+func (c *MultitenantCompactor) triggerCompaction(userID string, timeRange compactionTimeRange) {
+	c.compactionStateMtx.Lock()
+	if prev, ok := c.triggeredCompactions[userID]; ok {
+		timeRange = timeRange.union(prev)
+	}
+	c.triggeredCompactions[userID] = timeRange
+	c.compactionStateMtx.Unlock()
+
+	// Wake up the compaction loop, unless it has already been.
+	select {
+	case c.triggeredCompactionsCh <- struct{}{}:
+	default:
+	}
+}
+
+// popTriggeredCompactions returns the enqueued compactions, sorted by tenant, and clears them.
+func (c *MultitenantCompactor) popTriggeredCompactions() ([]string, map[string]compactionTimeRange) {
+	c.compactionStateMtx.Lock()
+	triggered := c.triggeredCompactions
+	c.triggeredCompactions = map[string]compactionTimeRange{}
+	c.compactionStateMtx.Unlock()
+
+	userIDs := make([]string, 0, len(triggered))
+	for userID := range triggered {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Strings(userIDs)
+	return userIDs, triggered
+}
+
+// compactTriggeredUsers runs the compactions enqueued by TriggerCompactionHandler.
+func (c *MultitenantCompactor) compactTriggeredUsers(ctx context.Context) {
+	userIDs, timeRanges := c.popTriggeredCompactions()
+
+	for _, userID := range userIDs {
+		if ctx.Err() != nil {
+			level.Info(c.logger).Log("msg", "interrupting triggered compaction of user blocks", "err", ctx.Err())
+			return
+		}
+
+		// The tenant may not be owned by this compactor anymore since the compaction has been triggered.
+		if err := c.tenantShardSizes.sync(ctx, userID); err != nil {
+			level.Warn(c.logger).Log("msg", "unable to read the compactor shard size of user", "user", userID, "err", err)
+			continue
+		}
+		if owned, err := c.shardingStrategy.compactorOwnUser(userID); err != nil {
+			level.Warn(c.logger).Log("msg", "unable to check if user is owned by this shard", "user", userID, "err", err)
+			continue
+		} else if !owned {
+			level.Warn(c.logger).Log("msg", "skipping triggered compaction because the user is not owned by this shard anymore", "user", userID)
+			continue
+		}
+
+		if markedForDeletion, err := mimir_tsdb.TenantDeletionMarkExists(ctx, c.bucketClient, userID); err != nil {
+			level.Warn(c.logger).Log("msg", "unable to check if user is marked for deletion", "user", userID, "err", err)
+			continue
+		} else if markedForDeletion {
+			level.Info(c.logger).Log("msg", "skipping triggered compaction because the user is marked for deletion", "user", userID)
+			continue
+		}
+
+		timeRange := timeRanges[userID]
+		level.Info(c.logger).Log("msg", "starting triggered compaction of user blocks", "user", userID, "min_time", timeRange.minTime, "max_time", timeRange.maxTime)
+
+		if err := c.compactUserWithRetries(ctx, userID, timeRange); err != nil {
+			level.Error(c.logger).Log("msg", "failed to run triggered compaction of user blocks", "user", userID, "err", err)
+			continue
+		}
+
+		// A compaction restricted to a time range may leave jobs to compact outside of it.
+		if timeRange == fullCompactionTimeRange {
+			c.setLastSuccessfulCompaction(userID, time.Now())
+		}
+		level.Info(c.logger).Log("msg", "successfully run triggered compaction of user blocks", "user", userID)
+	}
+}
@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/test"
+	"github.com/oklog/ulid"
+	prom_testutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/objstore"
+)
+
+func TestMultitenantCompactor_TriggerCompactionHandler(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+
+	// Two overlapping blocks, between 2019-11-26T14:00:00Z and 2019-11-26T16:00:00Z, so that a compaction job is planned.
+	for _, id := range []string{ulid.MustNew(1, nil).String(), ulid.MustNew(2, nil).String()} {
+		require.NoError(t, bkt.Upload(ctx, path.Join("user-1", id, metadata.MetaFilename), strings.NewReader(mockBlockMetaJSON(id))))
+	}
+
+	c, _, tsdbPlanner, logs, _ := prepare(t, prepareConfig(t), bkt)
+	tsdbPlanner.On("Plan", mock.Anything, mock.Anything).Return([]*metadata.Meta{}, nil)
+
+	router := mux.NewRouter()
+	router.Path("/compactor/tenant/{tenant}/compact").HandlerFunc(c.TriggerCompactionHandler)
+
+	serve := func(url string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, url, nil))
+		return resp
+	}
+
+	// The compaction can't be triggered until the compactor is running.
+	assert.Equal(t, http.StatusServiceUnavailable, serve("/compactor/tenant/user-1/compact").Code)
+
+	require.NoError(t, services.StartAndAwaitRunning(ctx, c))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(ctx, c))
+	})
+
+	// Wait until the initial compaction run has completed.
+	test.Poll(t, 5*time.Second, 1.0, func() interface{} {
+		return prom_testutil.ToFloat64(c.compactionRunsCompleted)
+	})
+	tsdbPlanner.AssertNumberOfCalls(t, "Plan", 1)
+
+	triggeredCompactions := func() int {
+		return strings.Count(logs.String(), "successfully run triggered compaction of user blocks")
+	}
+
+	t.Run("should fail on an invalid time range", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, serve("/compactor/tenant/user-1/compact?start=foo").Code)
+		assert.Equal(t, http.StatusBadRequest, serve("/compactor/tenant/user-1/compact?end=foo").Code)
+		assert.Equal(t, http.StatusBadRequest, serve("/compactor/tenant/user-1/compact?start=2019-11-26T16:00:00Z&end=2019-11-26T14:00:00Z").Code)
+	})
+
+	t.Run("should not run the jobs outside of the time range", func(t *testing.T) {
+		resp := serve("/compactor/tenant/user-1/compact?start=2019-11-27T00:00:00Z&end=2019-11-28T00:00:00Z")
+		require.Equal(t, http.StatusAccepted, resp.Code, resp.Body.String())
+
+		var out TriggerCompactionResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &out))
+		assert.Equal(t, TriggerCompactionResponse{Tenant: "user-1", MinTime: 1574812800000, MaxTime: 1574899200000}, out)
+
+		test.Poll(t, 5*time.Second, 1, func() interface{} {
+			return triggeredCompactions()
+		})
+		tsdbPlanner.AssertNumberOfCalls(t, "Plan", 1)
+	})
+
+	t.Run("should run the jobs overlapping the time range", func(t *testing.T) {
+		require.Equal(t, http.StatusAccepted, serve("/compactor/tenant/user-1/compact?start=2019-11-26T15:00:00Z&end=2019-11-26T15:30:00Z").Code)
+
+		test.Poll(t, 5*time.Second, 2, func() interface{} {
+			return triggeredCompactions()
+		})
+		tsdbPlanner.AssertNumberOfCalls(t, "Plan", 2)
+	})
+
+	t.Run("should run all the jobs without a time range", func(t *testing.T) {
+		resp := serve("/compactor/tenant/user-1/compact")
+		require.Equal(t, http.StatusAccepted, resp.Code, resp.Body.String())
+		assert.JSONEq(t, `{"tenant":"user-1"}`, resp.Body.String())
+
+		test.Poll(t, 5*time.Second, 3, func() interface{} {
+			return triggeredCompactions()
+		})
+		tsdbPlanner.AssertNumberOfCalls(t, "Plan", 3)
+	})
+}
+
+func TestMultitenantCompactor_triggerCompaction(t *testing.T) {
+	c, _, _, _, _ := prepare(t, prepareConfig(t), objstore.NewInMemBucket())
+
+	c.triggerCompaction("user-2", compactionTimeRange{minTime: 10, maxTime: 20})
+	c.triggerCompaction("user-1", compactionTimeRange{minTime: 30, maxTime: 40})
+	c.triggerCompaction("user-2", compactionTimeRange{minTime: 15, maxTime: 30})
+
+	// The compaction loop is woken up once.
+	assert.Len(t, c.triggeredCompactionsCh, 1)
+
+	userIDs, timeRanges := c.popTriggeredCompactions()
+	assert.Equal(t, []string{"user-1", "user-2"}, userIDs)
+	assert.Equal(t, map[string]compactionTimeRange{
+		"user-1": {minTime: 30, maxTime: 40},
+		"user-2": {minTime: 10, maxTime: 30},
+	}, timeRanges)
+
+	// An unrestricted compaction takes precedence over the time ranges.
+	c.triggerCompaction("user-1", compactionTimeRange{minTime: 30, maxTime: 40})
+	c.triggerCompaction("user-1", fullCompactionTimeRange)
+
+	userIDs, timeRanges = c.popTriggeredCompactions()
+	assert.Equal(t, []string{"user-1"}, userIDs)
+	assert.Equal(t, map[string]compactionTimeRange{"user-1": fullCompactionTimeRange}, timeRanges)
+
+	userIDs, timeRanges = c.popTriggeredCompactions()
+	assert.Empty(t, userIDs)
+	assert.Empty(t, timeRanges)
+}
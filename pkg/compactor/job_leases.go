@@ -0,0 +1,244 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"io/ioutil"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/runutil"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"go.uber.org/atomic"
+)
+
+// JobLeasesDirname is the name of the directory, in the tenant's bucket prefix, holding the compaction job leases.
+const JobLeasesDirname = "compactor-leases"
+
+// jobLease is the content of the lock object taken by a compactor on a compaction job.
+type jobLease struct {
+	Owner string `json:"owner"`
+
+	// ExpiresAt is the time, in Unix milliseconds, after which the lease can be taken by another compactor.
+	ExpiresAt int64 `json:"expires_at"`
+}
+
+func (l jobLease) expired(now time.Time) bool {
+	return now.UnixMilli() >= l.ExpiresAt
+}
+
+// jobLeases takes leases on the compaction jobs of a tenant, so that compactors whose sharding is misconfigured,
+// for example two compactor deployments using different rings, don't run the same job concurrently and upload
+// overlapping blocks. Object storage doesn't support conditional writes, so a lease is taken by writing the lock
+// object and reading it back: a compactor losing the race skips the job.
+type jobLeases struct {
+	bkt    objstore.Bucket
+	owner  string
+	ttl    time.Duration
+	logger log.Logger
+
+	// Time waited before reading back the written lease, to detect concurrent writes. Overridden in tests.
+	readBackDelay time.Duration
+}
+
+func newJobLeases(bkt objstore.Bucket, owner string, ttl time.Duration, logger log.Logger) *jobLeases {
+	return &jobLeases{
+		bkt:           bkt,
+		owner:         owner,
+		ttl:           ttl,
+		logger:        logger,
+		readBackDelay: time.Second,
+	}
+}
+
+// newJobLeaseOwner returns the owner of the compaction job leases taken by the compactor process, made of the
+// compactor instance ID and a random ULID. The instance ID alone isn't unique across overlapping deployments,
+// which can easily have instances with the same name, for example compactor-0 in two namespaces.
+func newJobLeaseOwner(instanceID string) string {
+	return instanceID + "/" + ulid.MustNew(ulid.Now(), rand.Reader).String()
+}
+
+func jobLeasePath(job *Job) string {
+	return path.Join(JobLeasesDirname, job.Key()+".json")
+}
+
+// errJobLeaseLost is returned when renewing a lease which has been taken over by another compactor.
+var errJobLeaseLost = errors.New("the compaction job lease has been taken over by another compactor")
+
+// heldJobLease is a lease held on a compaction job, renewed until released.
+type heldJobLease struct {
+	leases *jobLeases
+	name   string
+
+	// ctx is canceled once the lease is lost, or can't be renewed before it expires, so that the job isn't
+	// run concurrently by another compactor taking over the lease.
+	ctx    context.Context
+	cancel context.CancelFunc
+	lost   atomic.Bool
+
+	stopRenew context.CancelFunc
+	renewWg   sync.WaitGroup
+}
+
+// release stops renewing the lease and deletes it, unless it has been taken over by another compactor.
+func (h *heldJobLease) release() {
+	h.stopRenew()
+	h.renewWg.Wait()
+	h.cancel()
+
+	if h.lost.Load() {
+		return
+	}
+	l := h.leases
+	if current, found, err := l.read(context.Background(), h.name); err != nil || !found || current.Owner != l.owner {
+		return
+	}
+	if err := l.bkt.Delete(context.Background(), h.name); err != nil && !l.bkt.IsObjNotFoundErr(err) {
+		level.Warn(l.logger).Log("msg", "failed to delete compaction job lease", "lease", h.name, "err", err)
+	}
+}
+
+// acquire takes the lease on the job, if it isn't held by another compactor. The lease is renewed until
+// released. The job must be run with the context of the returned lease, derived from the input one.
+func (l *jobLeases) acquire(ctx context.Context, job *Job) (*heldJobLease, bool, error) {
+	name := jobLeasePath(job)
+
+	current, found, err := l.read(ctx, name)
+	if err != nil {
+		return nil, false, err
+	}
+	if found && current.Owner != l.owner && !current.expired(time.Now()) {
+		return nil, false, nil
+	}
+
+	expiresAt, err := l.write(ctx, name)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Another compactor may have written its lease concurrently: the last write wins.
+	select {
+	case <-time.After(l.readBackDelay):
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	}
+	current, found, err = l.read(ctx, name)
+	if err != nil {
+		return nil, false, err
+	}
+	if !found || current.Owner != l.owner {
+		return nil, false, nil
+	}
+
+	h := &heldJobLease{leases: l, name: name}
+	h.ctx, h.cancel = context.WithCancel(ctx)
+
+	var renewCtx context.Context
+	renewCtx, h.stopRenew = context.WithCancel(context.Background())
+	h.renewWg.Add(1)
+	go func() {
+		defer h.renewWg.Done()
+		l.renew(renewCtx, h, expiresAt)
+	}()
+
+	return h, true, nil
+}
+
+// renew extends the lease every third of its TTL, until the context is canceled. The lease is read back
+// before being renewed, and the job is canceled if the lease has been taken over by another compactor,
+// or if it can't be renewed before it expires.
+func (l *jobLeases) renew(ctx context.Context, h *heldJobLease, expiresAt time.Time) {
+	interval := l.ttl / 3
+	if interval <= 0 {
+		interval = l.ttl
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			renewedUntil, err := l.renewOnce(ctx, h.name)
+			if err == nil {
+				expiresAt = renewedUntil
+				continue
+			}
+			if ctx.Err() != nil {
+				return
+			}
+
+			if errors.Is(err, errJobLeaseLost) {
+				level.Warn(l.logger).Log("msg", "canceling compaction job because its lease has been taken over by another compactor", "lease", h.name)
+			} else if time.Now().Add(interval).Before(expiresAt) {
+				level.Warn(l.logger).Log("msg", "failed to renew compaction job lease, will retry", "lease", h.name, "err", err)
+				continue
+			} else {
+				level.Warn(l.logger).Log("msg", "canceling compaction job because its lease can't be renewed before it expires", "lease", h.name, "err", err)
+			}
+
+			h.lost.Store(true)
+			h.cancel()
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// renewOnce extends the lease if it's still owned by the compactor, and returns its new expiration time.
+func (l *jobLeases) renewOnce(ctx context.Context, name string) (time.Time, error) {
+	current, found, err := l.read(ctx, name)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !found || current.Owner != l.owner {
+		return time.Time{}, errJobLeaseLost
+	}
+	return l.write(ctx, name)
+}
+
+func (l *jobLeases) read(ctx context.Context, name string) (jobLease, bool, error) {
+	r, err := l.bkt.Get(ctx, name)
+	if l.bkt.IsObjNotFoundErr(err) {
+		return jobLease{}, false, nil
+	}
+	if err != nil {
+		return jobLease{}, false, errors.Wrapf(err, "read compaction job lease %s", name)
+	}
+	defer runutil.CloseWithLogOnErr(l.logger, r, "close compaction job lease reader")
+
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return jobLease{}, false, errors.Wrapf(err, "read compaction job lease %s", name)
+	}
+
+	lease := jobLease{}
+	if err := json.Unmarshal(content, &lease); err != nil {
+		// A corrupted lease can't be owned by anyone.
+		level.Warn(l.logger).Log("msg", "ignoring corrupted compaction job lease", "lease", name, "err", err)
+		return jobLease{}, false, nil
+	}
+	return lease, true, nil
+}
+
+// write writes the lease owned by the compactor, and returns its expiration time.
+func (l *jobLeases) write(ctx context.Context, name string) (time.Time, error) {
+	expiresAt := time.Now().Add(l.ttl)
+	content, err := json.Marshal(jobLease{Owner: l.owner, ExpiresAt: expiresAt.UnixMilli()})
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := l.bkt.Upload(ctx, name, bytes.NewReader(content)); err != nil {
+		return time.Time{}, errors.Wrapf(err, "write compaction job lease %s", name)
+	}
+	return expiresAt, nil
+}
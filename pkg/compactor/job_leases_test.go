@@ -0,0 +1,189 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"go.uber.org/atomic"
+)
+
+func TestJobLeases(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+	job := NewJob("user-1", "0@12345-merge--0-7200000", nil, 0, "", false, 0, "")
+
+	newLeases := func(owner string) *jobLeases {
+		l := newJobLeases(bkt, owner, time.Minute, log.NewNopLogger())
+		l.readBackDelay = 0
+		return l
+	}
+	compactor1, compactor2 := newLeases("compactor-1"), newLeases("compactor-2")
+
+	lease, acquired, err := compactor1.acquire(ctx, job)
+	require.NoError(t, err)
+	require.True(t, acquired)
+	assert.Contains(t, bkt.Objects(), jobLeasePath(job))
+
+	// The job can't be leased by another compactor while the lease is held.
+	_, acquired, err = compactor2.acquire(ctx, job)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+
+	// Once released, the job can be leased by another compactor.
+	lease.release()
+	assert.NotContains(t, bkt.Objects(), jobLeasePath(job))
+	assert.Error(t, lease.ctx.Err())
+	assert.False(t, lease.lost.Load())
+
+	lease, acquired, err = compactor2.acquire(ctx, job)
+	require.NoError(t, err)
+	require.True(t, acquired)
+	lease.release()
+
+	// An expired lease can be taken over.
+	expired, err := json.Marshal(jobLease{Owner: "compactor-1", ExpiresAt: time.Now().Add(-time.Second).UnixMilli()})
+	require.NoError(t, err)
+	require.NoError(t, bkt.Upload(ctx, jobLeasePath(job), bytes.NewReader(expired)))
+
+	lease, acquired, err = compactor2.acquire(ctx, job)
+	require.NoError(t, err)
+	require.True(t, acquired)
+	lease.release()
+}
+
+func TestJobLeases_SameInstanceID(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+	job := NewJob("user-1", "0@12345-merge--0-7200000", nil, 0, "", false, 0, "")
+
+	// Two compactors of overlapping deployments, whose instances have the same name.
+	newLeases := func() *jobLeases {
+		l := newJobLeases(bkt, newJobLeaseOwner("compactor-0"), time.Minute, log.NewNopLogger())
+		l.readBackDelay = 0
+		return l
+	}
+	compactor1, compactor2 := newLeases(), newLeases()
+	require.NotEqual(t, compactor1.owner, compactor2.owner)
+
+	lease, acquired, err := compactor1.acquire(ctx, job)
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	// The job can't be leased by the other compactor while the lease is held.
+	_, acquired, err = compactor2.acquire(ctx, job)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+
+	// The lease isn't renewed by the other compactor.
+	_, err = compactor2.renewOnce(ctx, jobLeasePath(job))
+	assert.ErrorIs(t, err, errJobLeaseLost)
+
+	lease.release()
+	assert.NotContains(t, bkt.Objects(), jobLeasePath(job))
+}
+
+func TestJobLeases_Renew(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+	job := NewJob("user-1", "0@12345-merge--0-7200000", nil, 0, "", false, 0, "")
+
+	l := newJobLeases(bkt, "compactor-1", 300*time.Millisecond, log.NewNopLogger())
+	l.readBackDelay = 0
+
+	held, acquired, err := l.acquire(ctx, job)
+	require.NoError(t, err)
+	require.True(t, acquired)
+	defer held.release()
+
+	// The lease is renewed while held, so it doesn't expire.
+	time.Sleep(time.Second)
+	lease, found, err := l.read(ctx, jobLeasePath(job))
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, "compactor-1", lease.Owner)
+	assert.False(t, lease.expired(time.Now()))
+	assert.NoError(t, held.ctx.Err())
+}
+
+func TestJobLeases_ShouldCancelTheJobWhenTheLeaseIsTakenOver(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+	job := NewJob("user-1", "0@12345-merge--0-7200000", nil, 0, "", false, 0, "")
+
+	l := newJobLeases(bkt, "compactor-1", 300*time.Millisecond, log.NewNopLogger())
+	l.readBackDelay = 0
+
+	held, acquired, err := l.acquire(ctx, job)
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	// Another compactor takes over the lease, as if it had expired.
+	other, err := json.Marshal(jobLease{Owner: "compactor-2", ExpiresAt: time.Now().Add(time.Minute).UnixMilli()})
+	require.NoError(t, err)
+	require.NoError(t, bkt.Upload(ctx, jobLeasePath(job), bytes.NewReader(other)))
+
+	select {
+	case <-held.ctx.Done():
+	case <-time.After(time.Second):
+		require.FailNow(t, "the job context hasn't been canceled")
+	}
+	assert.True(t, held.lost.Load())
+
+	// The lease of the other compactor is neither overwritten nor deleted.
+	held.release()
+	lease, found, err := l.read(ctx, jobLeasePath(job))
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, "compactor-2", lease.Owner)
+}
+
+func TestJobLeases_ShouldCancelTheJobWhenTheLeaseCantBeRenewedBeforeItExpires(t *testing.T) {
+	ctx := context.Background()
+	bkt := &failingUploadBucket{Bucket: objstore.NewInMemBucket()}
+	job := NewJob("user-1", "0@12345-merge--0-7200000", nil, 0, "", false, 0, "")
+
+	l := newJobLeases(bkt, "compactor-1", 300*time.Millisecond, log.NewNopLogger())
+	l.readBackDelay = 0
+
+	held, acquired, err := l.acquire(ctx, job)
+	require.NoError(t, err)
+	require.True(t, acquired)
+	defer held.release()
+
+	bkt.failing.Store(true)
+	start := time.Now()
+
+	select {
+	case <-held.ctx.Done():
+	case <-time.After(time.Second):
+		require.FailNow(t, "the job context hasn't been canceled")
+	}
+	assert.True(t, held.lost.Load())
+
+	// The job is canceled before the lease expires, after a retry.
+	assert.Less(t, time.Since(start), 300*time.Millisecond)
+}
+
+// failingUploadBucket fails the uploads once failing is set.
+type failingUploadBucket struct {
+	objstore.Bucket
+	failing atomic.Bool
+}
+
+func (b *failingUploadBucket) Upload(ctx context.Context, name string, r io.Reader) error {
+	if b.failing.Load() {
+		return errors.New("upload failed")
+	}
+	return b.Bucket.Upload(ctx, name, r)
+}
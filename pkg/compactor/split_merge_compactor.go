@@ -21,8 +21,9 @@ func splitAndMergeGrouperFactory(ctx context.Context, cfg Config, cfgProvider Co
 }
 
 func splitAndMergeCompactorFactory(ctx context.Context, cfg Config, logger log.Logger, reg prometheus.Registerer) (Compactor, Planner, error) {
-	// We don't need to customise the TSDB compactor so we're just using the Prometheus one.
-	compactor, err := tsdb.NewLeveledCompactor(ctx, reg, logger, cfg.BlockRanges.ToMilliseconds(), downsample.NewPool(), nil)
+	// We don't need to customise the TSDB compactor so we're just using the Prometheus one,
+	// configured with the merge function of the requested vertical merge strategy.
+	compactor, err := tsdb.NewLeveledCompactor(ctx, reg, logger, cfg.BlockRanges.ToMilliseconds(), downsample.NewPool(), verticalChunkSeriesMergeFunc(cfg.verticalMergeStrategy))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -0,0 +1,212 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+var (
+	verticalMergeStrategies = []string{validation.VerticalMergeStrategyChain, validation.VerticalMergeStrategyMaxValue, validation.VerticalMergeStrategyError}
+
+	errConflictingSamples = errors.New("conflicting samples with the same timestamp and different values")
+)
+
+// verticalChunkSeriesMergeFunc returns the function used to merge overlapping series during the vertical
+// compaction of blocks, for the given strategy. Returns nil for the default strategy, which makes the TSDB
+// compactor use its built-in merge function.
+func verticalChunkSeriesMergeFunc(strategy string) storage.VerticalChunkSeriesMergeFunc {
+	switch strategy {
+	case validation.VerticalMergeStrategyMaxValue:
+		return storage.NewCompactingChunkSeriesMerger(newConflictResolvingSeriesMerge(resolveMaxValue))
+	case validation.VerticalMergeStrategyError:
+		return storage.NewCompactingChunkSeriesMerger(newConflictResolvingSeriesMerge(resolveError))
+	default:
+		return nil
+	}
+}
+
+// conflictResolver returns the value to keep among the values of samples with the same timestamp.
+type conflictResolver func(values []float64) (float64, error)
+
+func resolveMaxValue(values []float64) (float64, error) {
+	out := values[0]
+	for _, v := range values[1:] {
+		// A stale marker (or any other NaN) never wins over a real value.
+		if math.IsNaN(out) || v > out {
+			out = v
+		}
+	}
+	return out, nil
+}
+
+func resolveError(values []float64) (float64, error) {
+	for _, v := range values[1:] {
+		// Compare the bits, so that equal NaNs (eg. stale markers) are not considered a conflict.
+		if math.Float64bits(v) != math.Float64bits(values[0]) {
+			return 0, errConflictingSamples
+		}
+	}
+	return values[0], nil
+}
+
+// newConflictResolvingSeriesMerge returns a storage.VerticalSeriesMergeFunc which merges the samples of the
+// input series, using resolve to pick the value to keep when multiple samples have the same timestamp.
+func newConflictResolvingSeriesMerge(resolve conflictResolver) storage.VerticalSeriesMergeFunc {
+	return func(series ...storage.Series) storage.Series {
+		if len(series) == 0 {
+			return nil
+		}
+
+		return &storage.SeriesEntry{
+			Lset: series[0].Labels(),
+			SampleIteratorFn: func() chunkenc.Iterator {
+				iterators := make([]chunkenc.Iterator, 0, len(series))
+				for _, s := range series {
+					iterators = append(iterators, s.Iterator())
+				}
+				return newConflictResolvingIterator(iterators, resolve)
+			},
+		}
+	}
+}
+
+// conflictResolvingIterator merges multiple sorted sample iterators into one, resolving the samples with
+// the same timestamp through a conflictResolver. Iterating is expected to be done on a small number
+// of iterators (the overlapping blocks), so the next sample is found with a linear scan.
+type conflictResolvingIterator struct {
+	iterators []chunkenc.Iterator
+	resolve   conflictResolver
+
+	// Whether each iterator has a current sample which hasn't been consumed yet.
+	valid []bool
+
+	// Values of samples sharing the current timestamp.
+	values []float64
+
+	initialized bool
+	exhausted   bool
+	currT       int64
+	currV       float64
+	err         error
+}
+
+func newConflictResolvingIterator(iterators []chunkenc.Iterator, resolve conflictResolver) *conflictResolvingIterator {
+	return &conflictResolvingIterator{
+		iterators: iterators,
+		resolve:   resolve,
+		valid:     make([]bool, len(iterators)),
+	}
+}
+
+func (it *conflictResolvingIterator) Next() bool {
+	if it.err != nil || it.exhausted {
+		return false
+	}
+
+	if !it.initialized {
+		it.initialized = true
+		for i, iter := range it.iterators {
+			it.valid[i] = it.advance(iter)
+		}
+		if it.err != nil {
+			return false
+		}
+	}
+
+	return it.next()
+}
+
+func (it *conflictResolvingIterator) Seek(t int64) bool {
+	if it.err != nil || it.exhausted {
+		return false
+	}
+	if it.initialized && it.currT >= t {
+		return true
+	}
+
+	it.initialized = true
+	for i, iter := range it.iterators {
+		if it.valid[i] {
+			if ts, _ := iter.At(); ts >= t {
+				continue
+			}
+		}
+		it.valid[i] = iter.Seek(t)
+		if !it.valid[i] && iter.Err() != nil {
+			it.err = iter.Err()
+			return false
+		}
+	}
+
+	return it.next()
+}
+
+// next consumes the samples with the lowest timestamp among all iterators.
+func (it *conflictResolvingIterator) next() bool {
+	minT := int64(math.MaxInt64)
+	found := false
+	for i, iter := range it.iterators {
+		if !it.valid[i] {
+			continue
+		}
+		if ts, _ := iter.At(); !found || ts < minT {
+			minT = ts
+			found = true
+		}
+	}
+	if !found {
+		it.exhausted = true
+		return false
+	}
+
+	it.values = it.values[:0]
+	for i, iter := range it.iterators {
+		if !it.valid[i] {
+			continue
+		}
+		ts, v := iter.At()
+		if ts != minT {
+			continue
+		}
+		it.values = append(it.values, v)
+		it.valid[i] = it.advance(iter)
+		if it.err != nil {
+			return false
+		}
+	}
+
+	v, err := it.resolve(it.values)
+	if err != nil {
+		it.err = errors.Wrapf(err, "timestamp %d", minT)
+		return false
+	}
+
+	it.currT, it.currV = minT, v
+	return true
+}
+
+// advance moves the input iterator to the next sample, recording any error.
+func (it *conflictResolvingIterator) advance(iter chunkenc.Iterator) bool {
+	if iter.Next() {
+		return true
+	}
+	if err := iter.Err(); err != nil {
+		it.err = err
+	}
+	return false
+}
+
+func (it *conflictResolvingIterator) At() (int64, float64) {
+	return it.currT, it.currV
+}
+
+func (it *conflictResolvingIterator) Err() error {
+	return it.err
+}
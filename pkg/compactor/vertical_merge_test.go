@@ -0,0 +1,154 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"math"
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/tsdbutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestVerticalChunkSeriesMergeFunc(t *testing.T) {
+	lbls := labels.FromStrings(labels.MetricName, "up")
+
+	first := storage.NewListChunkSeriesFromSamples(lbls, []tsdbutil.Sample{newSample(10, 1), newSample(20, 5), newSample(30, 3)})
+	second := storage.NewListChunkSeriesFromSamples(lbls, []tsdbutil.Sample{newSample(15, 2), newSample(20, 4), newSample(30, 3), newSample(40, 6)})
+
+	tests := map[string]struct {
+		strategy        string
+		expectedSamples []tsdbutil.Sample
+		expectedErr     error
+	}{
+		"max-value": {
+			strategy:        validation.VerticalMergeStrategyMaxValue,
+			expectedSamples: []tsdbutil.Sample{newSample(10, 1), newSample(15, 2), newSample(20, 5), newSample(30, 3), newSample(40, 6)},
+		},
+		"error": {
+			strategy:    validation.VerticalMergeStrategyError,
+			expectedErr: errConflictingSamples,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			mergeFunc := verticalChunkSeriesMergeFunc(testData.strategy)
+			require.NotNil(t, mergeFunc)
+
+			merged := mergeFunc(first, second)
+			assert.Equal(t, lbls, merged.Labels())
+
+			actual, err := expandChunkSeriesSamples(merged)
+			if testData.expectedErr != nil {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), testData.expectedErr.Error())
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, testData.expectedSamples, actual)
+		})
+	}
+
+	// The default strategy uses the TSDB built-in merge function.
+	assert.Nil(t, verticalChunkSeriesMergeFunc(validation.VerticalMergeStrategyChain))
+}
+
+func TestConflictResolvingIterator_Seek(t *testing.T) {
+	merge := newConflictResolvingSeriesMerge(resolveMaxValue)
+	series := merge(
+		storage.NewListSeries(nil, []tsdbutil.Sample{newSample(10, 1), newSample(20, 2), newSample(30, 3)}),
+		storage.NewListSeries(nil, []tsdbutil.Sample{newSample(20, 4), newSample(25, 5)}),
+	)
+
+	it := series.Iterator()
+	require.True(t, it.Seek(15))
+	ts, v := it.At()
+	assert.Equal(t, int64(20), ts)
+	assert.Equal(t, float64(4), v)
+
+	// Seeking to a timestamp lower than the current one has no effect.
+	require.True(t, it.Seek(5))
+	ts, _ = it.At()
+	assert.Equal(t, int64(20), ts)
+
+	require.True(t, it.Next())
+	ts, v = it.At()
+	assert.Equal(t, int64(25), ts)
+	assert.Equal(t, float64(5), v)
+
+	require.True(t, it.Seek(30))
+	ts, _ = it.At()
+	assert.Equal(t, int64(30), ts)
+
+	assert.False(t, it.Next())
+	assert.False(t, it.Seek(100))
+
+	// Once exhausted, seeking to a timestamp lower than the last one doesn't return the stale sample.
+	assert.False(t, it.Seek(5))
+	assert.False(t, it.Next())
+	assert.NoError(t, it.Err())
+}
+
+func TestResolveMaxValue(t *testing.T) {
+	staleNaN := math.Float64frombits(value.StaleNaN)
+
+	v, err := resolveMaxValue([]float64{staleNaN, 1})
+	require.NoError(t, err)
+	assert.Equal(t, float64(1), v)
+
+	v, err = resolveMaxValue([]float64{2, staleNaN, 1})
+	require.NoError(t, err)
+	assert.Equal(t, float64(2), v)
+}
+
+func TestResolveError(t *testing.T) {
+	staleNaN := math.Float64frombits(value.StaleNaN)
+
+	v, err := resolveError([]float64{staleNaN, staleNaN})
+	require.NoError(t, err)
+	assert.True(t, value.IsStaleNaN(v))
+
+	_, err = resolveError([]float64{staleNaN, 1})
+	assert.ErrorIs(t, err, errConflictingSamples)
+}
+
+func TestMultitenantCompactor_BlocksCompactorForUser(t *testing.T) {
+	cfgProvider := newMockConfigProvider()
+	cfgProvider.verticalMergeStrategies["user-1"] = validation.VerticalMergeStrategyMaxValue
+	cfgProvider.verticalMergeStrategies["user-2"] = "unknown"
+
+	defaultCompactor := &tsdbCompactorMock{}
+	maxValueCompactor := &tsdbCompactorMock{}
+
+	c := &MultitenantCompactor{
+		cfgProvider:                cfgProvider,
+		blocksCompactor:            defaultCompactor,
+		blocksCompactorsByStrategy: map[string]Compactor{validation.VerticalMergeStrategyMaxValue: maxValueCompactor},
+	}
+
+	assert.Same(t, maxValueCompactor, c.blocksCompactorForUser("user-1"))
+	assert.Same(t, defaultCompactor, c.blocksCompactorForUser("user-2"))
+	assert.Same(t, defaultCompactor, c.blocksCompactorForUser("user-3"))
+}
+
+func expandChunkSeriesSamples(series storage.ChunkSeries) ([]tsdbutil.Sample, error) {
+	var out []tsdbutil.Sample
+
+	it := series.Iterator()
+	for it.Next() {
+		samples, err := storage.ExpandSamples(it.At().Chunk.Iterator(nil), newSample)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, samples...)
+	}
+	return out, it.Err()
+}
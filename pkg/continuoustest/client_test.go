@@ -14,6 +14,8 @@ import (
 	"github.com/gogo/protobuf/proto"
 	"github.com/golang/snappy"
 	"github.com/grafana/dskit/flagext"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/prompb"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -48,6 +50,7 @@ func TestClient_WriteSeries(t *testing.T) {
 	flagext.DefaultValues(&cfg)
 	cfg.WriteBatchSize = 10
 	require.NoError(t, cfg.WriteBaseEndpoint.Set(server.URL))
+	require.NoError(t, cfg.ReadBaseEndpoint.Set(server.URL))
 
 	c, err := NewClient(cfg, log.NewNopLogger())
 	require.NoError(t, err)
@@ -104,6 +107,44 @@ func TestClient_WriteSeries(t *testing.T) {
 	})
 }
 
+func TestClient_QueryRange(t *testing.T) {
+	var receivedRequests []*http.Request
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		require.NoError(t, request.ParseForm())
+		receivedRequests = append(receivedRequests, request)
+
+		writer.Header().Set("Content-Type", "application/json")
+		_, err := writer.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[{"metric":{},"values":[[1000,"1.5"],[1020,"2"]]}]}}`))
+		require.NoError(t, err)
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := ClientConfig{}
+	flagext.DefaultValues(&cfg)
+	cfg.TenantID = "user-1"
+	require.NoError(t, cfg.WriteBaseEndpoint.Set(server.URL))
+	require.NoError(t, cfg.ReadBaseEndpoint.Set(server.URL))
+
+	c, err := NewClient(cfg, log.NewNopLogger())
+	require.NoError(t, err)
+
+	matrix, err := c.QueryRange(context.Background(), "sum(test)", time.Unix(1000, 0), time.Unix(1020, 0), 20*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, model.Matrix{{
+		Metric: model.Metric{},
+		Values: []model.SamplePair{{Timestamp: 1000000, Value: 1.5}, {Timestamp: 1020000, Value: 2}},
+	}}, matrix)
+
+	require.Len(t, receivedRequests, 1)
+	assert.Equal(t, "/api/v1/query_range", receivedRequests[0].URL.Path)
+	assert.Equal(t, "user-1", receivedRequests[0].Header.Get("X-Scope-OrgID"))
+	assert.Equal(t, "sum(test)", receivedRequests[0].Form.Get("query"))
+	assert.Equal(t, "1000", receivedRequests[0].Form.Get("start"))
+	assert.Equal(t, "1020", receivedRequests[0].Form.Get("end"))
+	assert.Equal(t, "20", receivedRequests[0].Form.Get("step"))
+}
+
 // ClientMock mocks MimirClient.
 type ClientMock struct {
 	mock.Mock
@@ -113,3 +154,13 @@ func (m *ClientMock) WriteSeries(ctx context.Context, series []prompb.TimeSeries
 	args := m.Called(ctx, series)
 	return args.Int(0), args.Error(1)
 }
+
+func (m *ClientMock) QueryRange(ctx context.Context, query string, start, end time.Time, step time.Duration) (model.Matrix, error) {
+	args := m.Called(ctx, query, start, end, step)
+	return args.Get(0).(model.Matrix), args.Error(1)
+}
+
+func (m *ClientMock) QueryExemplars(ctx context.Context, query string, start, end time.Time) ([]v1.ExemplarQueryResult, error) {
+	args := m.Called(ctx, query, start, end)
+	return args.Get(0).([]v1.ExemplarQueryResult), args.Error(1)
+}
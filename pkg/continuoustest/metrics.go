@@ -10,8 +10,12 @@ import (
 // TestMetrics holds generic metrics tracked by tests. The common metrics are used to enforce the same
 // metric names and labels to track the same information across different tests.
 type TestMetrics struct {
-	writesTotal       prometheus.Counter
-	writesFailedTotal *prometheus.CounterVec
+	writesTotal                  prometheus.Counter
+	writesFailedTotal            *prometheus.CounterVec
+	queriesTotal                 *prometheus.CounterVec
+	queriesFailedTotal           *prometheus.CounterVec
+	queryResultChecksTotal       *prometheus.CounterVec
+	queryResultChecksFailedTotal *prometheus.CounterVec
 }
 
 func NewTestMetrics(testName string, reg prometheus.Registerer) *TestMetrics {
@@ -26,5 +30,25 @@ func NewTestMetrics(testName string, reg prometheus.Registerer) *TestMetrics {
 			Help:        "Total number of failed write requests.",
 			ConstLabels: map[string]string{"test": testName},
 		}, []string{"status_code"}),
+		queriesTotal: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name:        "mimir_continuous_test_queries_total",
+			Help:        "Total number of attempted query requests.",
+			ConstLabels: map[string]string{"test": testName},
+		}, []string{"type"}),
+		queriesFailedTotal: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name:        "mimir_continuous_test_queries_failed_total",
+			Help:        "Total number of failed query requests.",
+			ConstLabels: map[string]string{"test": testName},
+		}, []string{"type"}),
+		queryResultChecksTotal: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name:        "mimir_continuous_test_query_result_checks_total",
+			Help:        "Total number of query results checked for correctness.",
+			ConstLabels: map[string]string{"test": testName},
+		}, []string{"type"}),
+		queryResultChecksFailedTotal: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name:        "mimir_continuous_test_query_result_checks_failed_total",
+			Help:        "Total number of query results failed when checking for correctness.",
+			ConstLabels: map[string]string{"test": testName},
+		}, []string{"type"}),
 	}
 }
@@ -37,6 +37,19 @@ func generateSineWaveSeries(name string, t time.Time, numSeries int) []prompb.Ti
 	return out
 }
 
+// generateSineWaveExemplar returns the exemplar attached to the sine wave series written at the timestamp.
+// The exemplar has the same value of the sample, and its trace ID is the timestamp in milliseconds.
+func generateSineWaveExemplar(t time.Time) prompb.Exemplar {
+	return prompb.Exemplar{
+		Labels: []prompb.Label{{
+			Name:  "trace_id",
+			Value: strconv.FormatInt(t.UnixMilli(), 10),
+		}},
+		Value:     generateSineWaveValue(t),
+		Timestamp: t.UnixMilli(),
+	}
+}
+
 func generateSineWaveValue(t time.Time) float64 {
 	period := 10 * time.Minute
 	radians := 2 * math.Pi * float64(t.UnixNano()) / float64(period.Nanoseconds())
@@ -5,25 +5,44 @@ package continuoustest
 import (
 	"context"
 	"flag"
+	"fmt"
+	"math"
 	"strconv"
 	"time"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
 )
 
 const (
 	writeInterval = 20 * time.Second
 	metricName    = "mimir_continuous_test_sine_wave"
+
+	// maxQueryPoints is the max number of points returned by each range query. The step is
+	// increased, as a multiple of the write interval, to not exceed it.
+	maxQueryPoints = 1000
+
+	// maxComparisonDelta is the max difference allowed between an expected and an actual value,
+	// to account for the floating point error introduced by summing many series.
+	maxComparisonDelta = 0.001
+
+	queryTypeRange     = "range"
+	queryTypeExemplars = "exemplars"
 )
 
 type WriteReadSeriesTestConfig struct {
-	NumSeries int
+	NumSeries        int
+	MaxQueryAge      time.Duration
+	ExemplarsEnabled bool
 }
 
 func (cfg *WriteReadSeriesTestConfig) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&cfg.NumSeries, "tests.write-read-series-test.num-series", 10000, "Number of series used for the test.")
+	f.DurationVar(&cfg.MaxQueryAge, "tests.write-read-series-test.max-query-age", 24*time.Hour, "How back in the past metrics can be queried at most.")
+	f.BoolVar(&cfg.ExemplarsEnabled, "tests.write-read-series-test.exemplars-enabled", false, "Set to true to attach an exemplar to the written series, and check the exemplars returned by the query exemplars API. Requires exemplars storage to be enabled in Mimir.")
 }
 
 type WriteReadSeriesTest struct {
@@ -58,6 +77,7 @@ func (t *WriteReadSeriesTest) Name() string {
 // Init implements Test.
 func (t *WriteReadSeriesTest) Init() error {
 	// TODO Here we should populate lastWrittenTimestamp, queryMinTime, queryMaxTime after querying Mimir to get data previously written.
+	//      Until then, the data written by a previous run of the tool is not queried.
 	return nil
 }
 
@@ -65,7 +85,12 @@ func (t *WriteReadSeriesTest) Init() error {
 func (t *WriteReadSeriesTest) Run(ctx context.Context, now time.Time) {
 	// Write series for each expected timestamp until now.
 	for timestamp := t.nextWriteTimestamp(now); !timestamp.After(now); timestamp = t.nextWriteTimestamp(now) {
-		statusCode, err := t.client.WriteSeries(ctx, generateSineWaveSeries(metricName, timestamp, t.cfg.NumSeries))
+		series := generateSineWaveSeries(metricName, timestamp, t.cfg.NumSeries)
+		if t.cfg.ExemplarsEnabled && len(series) > 0 {
+			series[0].Exemplars = append(series[0].Exemplars, generateSineWaveExemplar(timestamp))
+		}
+
+		statusCode, err := t.client.WriteSeries(ctx, series)
 
 		t.metrics.writesTotal.Inc()
 		if statusCode/100 != 2 {
@@ -81,8 +106,6 @@ func (t *WriteReadSeriesTest) Run(ctx context.Context, now time.Time) {
 		// assert on query results due to possible gaps.
 		if statusCode/100 == 4 {
 			t.lastWrittenTimestamp = timestamp
-
-			// TODO The following reset is related to the read path (not implemented yet), but was added to ensure we don't forget about it.
 			t.queryMinTime = time.Time{}
 			t.queryMaxTime = time.Time{}
 			continue
@@ -96,9 +119,83 @@ func (t *WriteReadSeriesTest) Run(ctx context.Context, now time.Time) {
 
 		// The write request succeeded.
 		t.lastWrittenTimestamp = timestamp
+		t.queryMaxTime = timestamp
+		if t.queryMinTime.IsZero() {
+			t.queryMinTime = timestamp
+		}
+	}
+
+	// Nothing to query if no series has been successfully written since the last reset.
+	if t.queryMinTime.IsZero() || t.queryMaxTime.IsZero() {
+		return
+	}
+
+	start, end := t.queryTimeRange(now)
+	if start.After(end) {
+		return
+	}
+
+	t.runRangeQueryAndVerifyResult(ctx, start, end)
+	if t.cfg.ExemplarsEnabled {
+		t.runExemplarsQueryAndVerifyResult(ctx, start, end)
+	}
+}
+
+// queryTimeRange returns the time range of the written data which is queried, limited to the max query age.
+func (t *WriteReadSeriesTest) queryTimeRange(now time.Time) (start, end time.Time) {
+	start = t.queryMinTime
+	if minAllowed := alignTimestampToInterval(now.Add(-t.cfg.MaxQueryAge), writeInterval).Add(writeInterval); start.Before(minAllowed) {
+		start = minAllowed
+	}
+
+	return start, t.queryMaxTime
+}
+
+func (t *WriteReadSeriesTest) runRangeQueryAndVerifyResult(ctx context.Context, start, end time.Time) {
+	// The step is a multiple of the write interval, so that each point of the result has been written
+	// at exactly the point timestamp.
+	step := writeInterval * time.Duration(math.Ceil(float64(end.Sub(start)/writeInterval+1)/maxQueryPoints))
+	query := fmt.Sprintf("sum(%s)", metricName)
+	logger := log.With(t.logger, "query", query, "start", start.UnixMilli(), "end", end.UnixMilli(), "step", step)
+
+	t.metrics.queriesTotal.WithLabelValues(queryTypeRange).Inc()
+	matrix, err := t.client.QueryRange(ctx, query, start, end, step)
+	if err != nil {
+		t.metrics.queriesFailedTotal.WithLabelValues(queryTypeRange).Inc()
+		level.Warn(logger).Log("msg", "Failed to execute range query", "err", err)
+		return
 	}
 
-	// TODO Here we should query the written data and assert on correctness.
+	t.metrics.queryResultChecksTotal.WithLabelValues(queryTypeRange).Inc()
+	if err := verifySineWaveSumMatrix(matrix, start, end, step, t.cfg.NumSeries); err != nil {
+		t.metrics.queryResultChecksFailedTotal.WithLabelValues(queryTypeRange).Inc()
+		level.Warn(logger).Log("msg", "Range query result check failed", "err", err)
+		return
+	}
+
+	level.Debug(logger).Log("msg", "Range query result check succeeded")
+}
+
+func (t *WriteReadSeriesTest) runExemplarsQueryAndVerifyResult(ctx context.Context, start, end time.Time) {
+	query := fmt.Sprintf(`%s{series_id="0"}`, metricName)
+	logger := log.With(t.logger, "query", query, "start", start.UnixMilli(), "end", end.UnixMilli())
+
+	t.metrics.queriesTotal.WithLabelValues(queryTypeExemplars).Inc()
+	results, err := t.client.QueryExemplars(ctx, query, start, end)
+	if err != nil {
+		t.metrics.queriesFailedTotal.WithLabelValues(queryTypeExemplars).Inc()
+		level.Warn(logger).Log("msg", "Failed to execute exemplars query", "err", err)
+		return
+	}
+
+	t.metrics.queryResultChecksTotal.WithLabelValues(queryTypeExemplars).Inc()
+	if err := verifySineWaveExemplars(results, end); err != nil {
+		t.metrics.queryResultChecksFailedTotal.WithLabelValues(queryTypeExemplars).Inc()
+		level.Warn(logger).Log("msg", "Exemplars query result check failed", "err", err)
+		return
+	}
+
+	level.Debug(logger).Log("msg", "Exemplars query result check succeeded")
 }
 
 func (t *WriteReadSeriesTest) nextWriteTimestamp(now time.Time) time.Time {
@@ -108,3 +205,60 @@ func (t *WriteReadSeriesTest) nextWriteTimestamp(now time.Time) time.Time {
 
 	return t.lastWrittenTimestamp.Add(writeInterval)
 }
+
+// verifySineWaveSumMatrix checks the result of the sum of the sine wave series, queried with the given range.
+func verifySineWaveSumMatrix(matrix model.Matrix, start, end time.Time, step time.Duration, numSeries int) error {
+	if len(matrix) != 1 {
+		return fmt.Errorf("expected 1 series in the result but got %d", len(matrix))
+	}
+
+	samples := matrix[0].Values
+	expectedPoints := int(end.Sub(start)/step) + 1
+	if len(samples) != expectedPoints {
+		return fmt.Errorf("expected %d points in the result but got %d", expectedPoints, len(samples))
+	}
+
+	for i, sample := range samples {
+		ts := start.Add(time.Duration(i) * step)
+		if !sample.Timestamp.Time().Equal(ts) {
+			return fmt.Errorf("expected point at timestamp %d but got %d", ts.UnixMilli(), int64(sample.Timestamp))
+		}
+
+		expected := float64(numSeries) * generateSineWaveValue(ts)
+		if math.Abs(float64(sample.Value)-expected) > maxComparisonDelta {
+			return fmt.Errorf("expected value %f at timestamp %d but got %f", expected, ts.UnixMilli(), float64(sample.Value))
+		}
+	}
+
+	return nil
+}
+
+// verifySineWaveExemplars checks the exemplars of a single sine wave series. Exemplars are stored in a bounded
+// in-memory storage, so older exemplars may be missing, but the latest written one is expected in the result.
+func verifySineWaveExemplars(results []v1.ExemplarQueryResult, lastWritten time.Time) error {
+	if len(results) != 1 {
+		return fmt.Errorf("expected exemplars for 1 series in the result but got %d", len(results))
+	}
+
+	foundLastWritten := false
+	for _, exemplar := range results[0].Exemplars {
+		ts := exemplar.Timestamp.Time()
+		expected := generateSineWaveExemplar(ts)
+
+		if traceID := string(exemplar.Labels["trace_id"]); traceID != expected.Labels[0].Value {
+			return fmt.Errorf("expected exemplar trace ID %s at timestamp %d but got %s", expected.Labels[0].Value, ts.UnixMilli(), traceID)
+		}
+		if math.Abs(float64(exemplar.Value)-expected.Value) > maxComparisonDelta {
+			return fmt.Errorf("expected exemplar value %f at timestamp %d but got %f", expected.Value, ts.UnixMilli(), float64(exemplar.Value))
+		}
+		if ts.Equal(lastWritten) {
+			foundLastWritten = true
+		}
+	}
+
+	if !foundLastWritten {
+		return fmt.Errorf("the last written exemplar at timestamp %d is missing", lastWritten.UnixMilli())
+	}
+
+	return nil
+}
@@ -10,21 +10,26 @@ import (
 	"time"
 
 	"github.com/go-kit/log"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestWriteReadSeriesTest_Run(t *testing.T) {
 	logger := log.NewNopLogger()
 	cfg := WriteReadSeriesTestConfig{
-		NumSeries: 2,
+		NumSeries:   2,
+		MaxQueryAge: 2 * time.Hour,
 	}
 
 	t.Run("should write series with current timestamp if it's already aligned to write interval", func(t *testing.T) {
 		client := &ClientMock{}
 		client.On("WriteSeries", mock.Anything, mock.Anything).Return(200, nil)
+		client.On("QueryRange", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(model.Matrix{}, nil)
 
 		reg := prometheus.NewPedanticRegistry()
 		test := NewWriteReadSeriesTest(cfg, client, logger, reg)
@@ -40,12 +45,13 @@ func TestWriteReadSeriesTest_Run(t *testing.T) {
 			# HELP mimir_continuous_test_writes_total Total number of attempted write requests.
 			# TYPE mimir_continuous_test_writes_total counter
 			mimir_continuous_test_writes_total{test="write-read-series"} 1
-		`)))
+		`), "mimir_continuous_test_writes_total", "mimir_continuous_test_writes_failed_total"))
 	})
 
 	t.Run("should write series with timestamp aligned to write interval", func(t *testing.T) {
 		client := &ClientMock{}
 		client.On("WriteSeries", mock.Anything, mock.Anything).Return(200, nil)
+		client.On("QueryRange", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(model.Matrix{}, nil)
 
 		reg := prometheus.NewPedanticRegistry()
 		test := NewWriteReadSeriesTest(cfg, client, logger, reg)
@@ -61,12 +67,13 @@ func TestWriteReadSeriesTest_Run(t *testing.T) {
 			# HELP mimir_continuous_test_writes_total Total number of attempted write requests.
 			# TYPE mimir_continuous_test_writes_total counter
 			mimir_continuous_test_writes_total{test="write-read-series"} 1
-		`)))
+		`), "mimir_continuous_test_writes_total", "mimir_continuous_test_writes_failed_total"))
 	})
 
 	t.Run("should write series from last written timestamp until now", func(t *testing.T) {
 		client := &ClientMock{}
 		client.On("WriteSeries", mock.Anything, mock.Anything).Return(200, nil)
+		client.On("QueryRange", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(model.Matrix{}, nil)
 
 		reg := prometheus.NewPedanticRegistry()
 		test := NewWriteReadSeriesTest(cfg, client, logger, reg)
@@ -85,7 +92,7 @@ func TestWriteReadSeriesTest_Run(t *testing.T) {
 			# HELP mimir_continuous_test_writes_total Total number of attempted write requests.
 			# TYPE mimir_continuous_test_writes_total counter
 			mimir_continuous_test_writes_total{test="write-read-series"} 3
-		`)))
+		`), "mimir_continuous_test_writes_total", "mimir_continuous_test_writes_failed_total"))
 	})
 
 	t.Run("should stop remote writing on network error", func(t *testing.T) {
@@ -111,7 +118,7 @@ func TestWriteReadSeriesTest_Run(t *testing.T) {
 			# HELP mimir_continuous_test_writes_failed_total Total number of failed write requests.
 			# TYPE mimir_continuous_test_writes_failed_total counter
 			mimir_continuous_test_writes_failed_total{status_code="0",test="write-read-series"} 1
-		`)))
+		`), "mimir_continuous_test_writes_total", "mimir_continuous_test_writes_failed_total"))
 	})
 
 	t.Run("should stop remote writing on 5xx error", func(t *testing.T) {
@@ -137,7 +144,7 @@ func TestWriteReadSeriesTest_Run(t *testing.T) {
 			# HELP mimir_continuous_test_writes_failed_total Total number of failed write requests.
 			# TYPE mimir_continuous_test_writes_failed_total counter
 			mimir_continuous_test_writes_failed_total{status_code="500",test="write-read-series"} 1
-		`)))
+		`), "mimir_continuous_test_writes_total", "mimir_continuous_test_writes_failed_total"))
 	})
 
 	t.Run("should keep remote writing next intervals on 4xx error", func(t *testing.T) {
@@ -165,6 +172,219 @@ func TestWriteReadSeriesTest_Run(t *testing.T) {
 			# HELP mimir_continuous_test_writes_failed_total Total number of failed write requests.
 			# TYPE mimir_continuous_test_writes_failed_total counter
 			mimir_continuous_test_writes_failed_total{status_code="400",test="write-read-series"} 3
-		`)))
+		`), "mimir_continuous_test_writes_total", "mimir_continuous_test_writes_failed_total"))
 	})
 }
+
+func TestWriteReadSeriesTest_RunQueries(t *testing.T) {
+	logger := log.NewNopLogger()
+	cfg := WriteReadSeriesTestConfig{
+		NumSeries:   2,
+		MaxQueryAge: 2 * time.Hour,
+	}
+	query := "sum(mimir_continuous_test_sine_wave)"
+
+	t.Run("should query the written series and check the result", func(t *testing.T) {
+		client := &ClientMock{}
+		client.On("WriteSeries", mock.Anything, mock.Anything).Return(200, nil)
+		client.On("QueryRange", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(generateSineWaveSumMatrix(time.Unix(900, 0), time.Unix(1000, 0), writeInterval, 2), nil)
+
+		reg := prometheus.NewPedanticRegistry()
+		test := NewWriteReadSeriesTest(cfg, client, logger, reg)
+
+		test.lastWrittenTimestamp = time.Unix(940, 0)
+		test.queryMinTime = time.Unix(900, 0)
+		test.queryMaxTime = time.Unix(940, 0)
+		test.Run(context.Background(), time.Unix(1000, 0))
+
+		client.AssertNumberOfCalls(t, "QueryRange", 1)
+		client.AssertCalled(t, "QueryRange", mock.Anything, query, time.Unix(900, 0), time.Unix(1000, 0), writeInterval)
+		assert.Equal(t, int64(900), test.queryMinTime.Unix())
+		assert.Equal(t, int64(1000), test.queryMaxTime.Unix())
+
+		assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP mimir_continuous_test_queries_total Total number of attempted query requests.
+			# TYPE mimir_continuous_test_queries_total counter
+			mimir_continuous_test_queries_total{test="write-read-series",type="range"} 1
+
+			# HELP mimir_continuous_test_query_result_checks_total Total number of query results checked for correctness.
+			# TYPE mimir_continuous_test_query_result_checks_total counter
+			mimir_continuous_test_query_result_checks_total{test="write-read-series",type="range"} 1
+		`), "mimir_continuous_test_queries_total", "mimir_continuous_test_queries_failed_total", "mimir_continuous_test_query_result_checks_total", "mimir_continuous_test_query_result_checks_failed_total"))
+	})
+
+	t.Run("should start querying from the first successfully written timestamp", func(t *testing.T) {
+		client := &ClientMock{}
+		client.On("WriteSeries", mock.Anything, mock.Anything).Return(200, nil)
+		client.On("QueryRange", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(generateSineWaveSumMatrix(time.Unix(1000, 0), time.Unix(1000, 0), writeInterval, 2), nil)
+
+		reg := prometheus.NewPedanticRegistry()
+		test := NewWriteReadSeriesTest(cfg, client, logger, reg)
+		test.Run(context.Background(), time.Unix(1000, 0))
+
+		client.AssertNumberOfCalls(t, "QueryRange", 1)
+		client.AssertCalled(t, "QueryRange", mock.Anything, query, time.Unix(1000, 0), time.Unix(1000, 0), writeInterval)
+	})
+
+	t.Run("should not query data older than the max query age", func(t *testing.T) {
+		client := &ClientMock{}
+		client.On("WriteSeries", mock.Anything, mock.Anything).Return(200, nil)
+		client.On("QueryRange", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(model.Matrix{}, nil)
+
+		longerAgeCfg := cfg
+		longerAgeCfg.MaxQueryAge = 12 * time.Hour
+
+		reg := prometheus.NewPedanticRegistry()
+		test := NewWriteReadSeriesTest(longerAgeCfg, client, logger, reg)
+
+		now := time.Unix(100000, 0)
+		test.lastWrittenTimestamp = now.Add(-writeInterval)
+		test.queryMinTime = now.Add(-24 * time.Hour)
+		test.queryMaxTime = now.Add(-writeInterval)
+		test.Run(context.Background(), now)
+
+		// The range has more points than the max allowed, so the step is increased.
+		client.AssertNumberOfCalls(t, "QueryRange", 1)
+		client.AssertCalled(t, "QueryRange", mock.Anything, query, now.Add(-12*time.Hour).Add(writeInterval), now, 3*writeInterval)
+	})
+
+	t.Run("should not query after a write failed with 4xx error", func(t *testing.T) {
+		client := &ClientMock{}
+		client.On("WriteSeries", mock.Anything, mock.Anything).Return(400, errors.New("400 error"))
+
+		reg := prometheus.NewPedanticRegistry()
+		test := NewWriteReadSeriesTest(cfg, client, logger, reg)
+
+		test.lastWrittenTimestamp = time.Unix(980, 0)
+		test.queryMinTime = time.Unix(900, 0)
+		test.queryMaxTime = time.Unix(980, 0)
+		test.Run(context.Background(), time.Unix(1000, 0))
+
+		client.AssertNumberOfCalls(t, "QueryRange", 0)
+		assert.True(t, test.queryMinTime.IsZero())
+		assert.True(t, test.queryMaxTime.IsZero())
+	})
+
+	t.Run("should track failed queries", func(t *testing.T) {
+		client := &ClientMock{}
+		client.On("WriteSeries", mock.Anything, mock.Anything).Return(200, nil)
+		client.On("QueryRange", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(model.Matrix(nil), errors.New("query failed"))
+
+		reg := prometheus.NewPedanticRegistry()
+		test := NewWriteReadSeriesTest(cfg, client, logger, reg)
+		test.Run(context.Background(), time.Unix(1000, 0))
+
+		assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP mimir_continuous_test_queries_total Total number of attempted query requests.
+			# TYPE mimir_continuous_test_queries_total counter
+			mimir_continuous_test_queries_total{test="write-read-series",type="range"} 1
+
+			# HELP mimir_continuous_test_queries_failed_total Total number of failed query requests.
+			# TYPE mimir_continuous_test_queries_failed_total counter
+			mimir_continuous_test_queries_failed_total{test="write-read-series",type="range"} 1
+		`), "mimir_continuous_test_queries_total", "mimir_continuous_test_queries_failed_total", "mimir_continuous_test_query_result_checks_total", "mimir_continuous_test_query_result_checks_failed_total"))
+	})
+
+	t.Run("should track query results failing the check", func(t *testing.T) {
+		matrix := generateSineWaveSumMatrix(time.Unix(900, 0), time.Unix(1000, 0), writeInterval, 2)
+		matrix[0].Values[2].Value++
+
+		client := &ClientMock{}
+		client.On("WriteSeries", mock.Anything, mock.Anything).Return(200, nil)
+		client.On("QueryRange", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(matrix, nil)
+
+		reg := prometheus.NewPedanticRegistry()
+		test := NewWriteReadSeriesTest(cfg, client, logger, reg)
+
+		test.lastWrittenTimestamp = time.Unix(940, 0)
+		test.queryMinTime = time.Unix(900, 0)
+		test.queryMaxTime = time.Unix(940, 0)
+		test.Run(context.Background(), time.Unix(1000, 0))
+
+		assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP mimir_continuous_test_queries_total Total number of attempted query requests.
+			# TYPE mimir_continuous_test_queries_total counter
+			mimir_continuous_test_queries_total{test="write-read-series",type="range"} 1
+
+			# HELP mimir_continuous_test_query_result_checks_total Total number of query results checked for correctness.
+			# TYPE mimir_continuous_test_query_result_checks_total counter
+			mimir_continuous_test_query_result_checks_total{test="write-read-series",type="range"} 1
+
+			# HELP mimir_continuous_test_query_result_checks_failed_total Total number of query results failed when checking for correctness.
+			# TYPE mimir_continuous_test_query_result_checks_failed_total counter
+			mimir_continuous_test_query_result_checks_failed_total{test="write-read-series",type="range"} 1
+		`), "mimir_continuous_test_queries_total", "mimir_continuous_test_queries_failed_total", "mimir_continuous_test_query_result_checks_total", "mimir_continuous_test_query_result_checks_failed_total"))
+	})
+
+	t.Run("should write and query exemplars if enabled", func(t *testing.T) {
+		exemplarsCfg := cfg
+		exemplarsCfg.ExemplarsEnabled = true
+
+		client := &ClientMock{}
+		client.On("WriteSeries", mock.Anything, mock.Anything).Return(200, nil)
+		client.On("QueryRange", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(generateSineWaveSumMatrix(time.Unix(1000, 0), time.Unix(1000, 0), writeInterval, 2), nil)
+		client.On("QueryExemplars", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]v1.ExemplarQueryResult{{
+			SeriesLabels: model.LabelSet{"__name__": metricName, "series_id": "0"},
+			Exemplars: []v1.Exemplar{{
+				Labels:    model.LabelSet{"trace_id": "1000000"},
+				Value:     model.SampleValue(generateSineWaveValue(time.Unix(1000, 0))),
+				Timestamp: model.TimeFromUnix(1000),
+			}},
+		}}, nil)
+
+		reg := prometheus.NewPedanticRegistry()
+		test := NewWriteReadSeriesTest(exemplarsCfg, client, logger, reg)
+		test.Run(context.Background(), time.Unix(1000, 0))
+
+		expectedSeries := generateSineWaveSeries(metricName, time.Unix(1000, 0), 2)
+		expectedSeries[0].Exemplars = append(expectedSeries[0].Exemplars, generateSineWaveExemplar(time.Unix(1000, 0)))
+		client.AssertCalled(t, "WriteSeries", mock.Anything, expectedSeries)
+		client.AssertCalled(t, "QueryExemplars", mock.Anything, `mimir_continuous_test_sine_wave{series_id="0"}`, time.Unix(1000, 0), time.Unix(1000, 0))
+
+		assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP mimir_continuous_test_queries_total Total number of attempted query requests.
+			# TYPE mimir_continuous_test_queries_total counter
+			mimir_continuous_test_queries_total{test="write-read-series",type="exemplars"} 1
+			mimir_continuous_test_queries_total{test="write-read-series",type="range"} 1
+
+			# HELP mimir_continuous_test_query_result_checks_total Total number of query results checked for correctness.
+			# TYPE mimir_continuous_test_query_result_checks_total counter
+			mimir_continuous_test_query_result_checks_total{test="write-read-series",type="exemplars"} 1
+			mimir_continuous_test_query_result_checks_total{test="write-read-series",type="range"} 1
+		`), "mimir_continuous_test_queries_total", "mimir_continuous_test_queries_failed_total", "mimir_continuous_test_query_result_checks_total", "mimir_continuous_test_query_result_checks_failed_total"))
+	})
+}
+
+func TestVerifySineWaveSumMatrix(t *testing.T) {
+	start, end := time.Unix(900, 0), time.Unix(1000, 0)
+
+	t.Run("should succeed on the expected result", func(t *testing.T) {
+		require.NoError(t, verifySineWaveSumMatrix(generateSineWaveSumMatrix(start, end, writeInterval, 3), start, end, writeInterval, 3))
+	})
+
+	t.Run("should fail on no series", func(t *testing.T) {
+		require.Error(t, verifySineWaveSumMatrix(model.Matrix{}, start, end, writeInterval, 3))
+	})
+
+	t.Run("should fail on missing points", func(t *testing.T) {
+		matrix := generateSineWaveSumMatrix(start, end, writeInterval, 3)
+		matrix[0].Values = matrix[0].Values[1:]
+		require.Error(t, verifySineWaveSumMatrix(matrix, start, end, writeInterval, 3))
+	})
+
+	t.Run("should fail on unexpected value", func(t *testing.T) {
+		require.Error(t, verifySineWaveSumMatrix(generateSineWaveSumMatrix(start, end, writeInterval, 2), start, end, writeInterval, 3))
+	})
+}
+
+// generateSineWaveSumMatrix returns the expected result of summing numSeries sine wave series in the range.
+func generateSineWaveSumMatrix(start, end time.Time, step time.Duration, numSeries int) model.Matrix {
+	stream := &model.SampleStream{Metric: model.Metric{}}
+	for ts := start; !ts.After(end); ts = ts.Add(step) {
+		stream.Values = append(stream.Values, model.SamplePair{
+			Timestamp: model.TimeFromUnixNano(ts.UnixNano()),
+			Value:     model.SampleValue(float64(numSeries) * generateSineWaveValue(ts)),
+		})
+	}
+	return model.Matrix{stream}
+}
@@ -0,0 +1,164 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/grafana/dskit/concurrency"
+	"github.com/grafana/dskit/ring"
+	"github.com/pkg/errors"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/ingester"
+	"github.com/grafana/mimir/pkg/tenant"
+	"github.com/grafana/mimir/pkg/util"
+	util_math "github.com/grafana/mimir/pkg/util/math"
+)
+
+const (
+	// ingesterActiveSeriesCustomTrackersStatusPath is the path of the ingesters' endpoint returning the status
+	// of the tenant's active series custom trackers.
+	ingesterActiveSeriesCustomTrackersStatusPath = "/ingester/active_series_custom_trackers_status"
+
+	// activeSeriesCustomTrackersStatusConcurrency is the max number of ingesters queried concurrently.
+	activeSeriesCustomTrackersStatusConcurrency = 16
+)
+
+// ActiveSeriesCustomTrackersStatusResponse is the response of the active series custom trackers status API.
+type ActiveSeriesCustomTrackersStatusResponse struct {
+	// Consistent is true if all the ingesters holding series of the tenant use the same custom trackers.
+	Consistent bool `json:"consistent"`
+
+	// Valid is true if the active series counts of all the ingesters are accurate with the custom trackers
+	// in use, which is the case from ValidFromMs on.
+	Valid       bool  `json:"valid"`
+	ValidFromMs int64 `json:"valid_from_ms"`
+
+	Ingesters []IngesterActiveSeriesCustomTrackersStatus `json:"ingesters"`
+}
+
+// IngesterActiveSeriesCustomTrackersStatus is the status of the tenant's active series custom trackers in an ingester.
+type IngesterActiveSeriesCustomTrackersStatus struct {
+	Addr  string `json:"addr"`
+	Error string `json:"error,omitempty"`
+
+	ingester.ActiveSeriesCustomTrackersStatus
+}
+
+// ActiveSeriesCustomTrackersStatusHandler returns the status of the tenant's active series custom trackers in
+// all the ingesters. On POST, the ingesters read the tenant's custom trackers from the bucket and reload them
+// first, so that the custom trackers set through the ingesters' API are propagated without waiting for the
+// next poll of the bucket.
+func (d *Distributor) ActiveSeriesCustomTrackersStatusHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	res, err := d.activeSeriesCustomTrackersStatus(r.Context(), userID, r.Method)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	util.WriteJSONResponse(w, res)
+}
+
+// activeSeriesCustomTrackersStatus sends the request with the input method to the status endpoint of all the
+// ingesters in the ring, and merges their responses. The ingesters which fail are reported in the response.
+func (d *Distributor) activeSeriesCustomTrackersStatus(ctx context.Context, userID, method string) (ActiveSeriesCustomTrackersStatusResponse, error) {
+	rs, err := d.ingestersRing.GetAllHealthy(ring.Reporting)
+	if err != nil {
+		return ActiveSeriesCustomTrackersStatusResponse{}, errors.Wrap(err, "failed to get healthy ingesters")
+	}
+
+	ctx = user.InjectOrgID(ctx, userID)
+	req := &httpgrpc.HTTPRequest{
+		Method: method,
+		Url:    ingesterActiveSeriesCustomTrackersStatusPath,
+		Headers: []*httpgrpc.Header{
+			{Key: http.CanonicalHeaderKey(user.OrgIDHeaderName), Values: []string{userID}},
+		},
+	}
+
+	statuses := make([]IngesterActiveSeriesCustomTrackersStatus, len(rs.Instances))
+	_ = concurrency.ForEachJob(ctx, len(rs.Instances), activeSeriesCustomTrackersStatusConcurrency, func(ctx context.Context, idx int) error {
+		addr := rs.Instances[idx].Addr
+		statuses[idx].Addr = addr
+
+		status, err := d.ingesterActiveSeriesCustomTrackersStatus(ctx, addr, req)
+		if err != nil {
+			statuses[idx].Error = err.Error()
+			return nil
+		}
+		statuses[idx].ActiveSeriesCustomTrackersStatus = status
+		return nil
+	})
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Addr < statuses[j].Addr
+	})
+
+	return mergeActiveSeriesCustomTrackersStatuses(statuses), nil
+}
+
+func (d *Distributor) ingesterActiveSeriesCustomTrackersStatus(ctx context.Context, addr string, req *httpgrpc.HTTPRequest) (ingester.ActiveSeriesCustomTrackersStatus, error) {
+	status := ingester.ActiveSeriesCustomTrackersStatus{}
+
+	c, err := d.ingesterPool.GetClientFor(addr)
+	if err != nil {
+		return status, errors.Wrap(err, "failed to get ingester client")
+	}
+	httpClient, ok := c.(httpgrpc.HTTPClient)
+	if !ok {
+		return status, errors.New("the ingester client doesn't support HTTP requests")
+	}
+
+	resp, err := httpClient.Handle(ctx, req)
+	if err != nil {
+		return status, err
+	}
+	if resp.Code != http.StatusOK {
+		return status, fmt.Errorf("unexpected status code %d: %s", resp.Code, string(resp.Body))
+	}
+
+	if err := json.Unmarshal(resp.Body, &status); err != nil {
+		return status, errors.Wrap(err, "failed to decode the response")
+	}
+	return status, nil
+}
+
+// mergeActiveSeriesCustomTrackersStatuses merges the statuses of the ingesters. The ingesters without series
+// of the tenant don't affect the result, while any failed ingester makes it inconsistent and not valid.
+func mergeActiveSeriesCustomTrackersStatuses(statuses []IngesterActiveSeriesCustomTrackersStatus) ActiveSeriesCustomTrackersStatusResponse {
+	res := ActiveSeriesCustomTrackersStatusResponse{Consistent: true, Valid: true, Ingesters: statuses}
+
+	var reference *ingester.ActiveSeriesCustomTrackersStatus
+	for idx := range statuses {
+		s := &statuses[idx]
+		if s.Error != "" {
+			res.Consistent, res.Valid = false, false
+			continue
+		}
+		if !s.TSDBOpen {
+			continue
+		}
+
+		if reference == nil {
+			reference = &s.ActiveSeriesCustomTrackersStatus
+		} else if !reference.Trackers.Equal(s.Trackers) {
+			res.Consistent = false
+		}
+		res.Valid = res.Valid && s.Valid
+		res.ValidFromMs = util_math.Max64(res.ValidFromMs, s.ValidFromMs)
+	}
+
+	return res
+}
@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+	"google.golang.org/grpc"
+
+	"github.com/grafana/mimir/pkg/ingester"
+)
+
+func (i *mockIngester) Handle(ctx context.Context, req *httpgrpc.HTTPRequest, _ ...grpc.CallOption) (*httpgrpc.HTTPResponse, error) {
+	i.Lock()
+	defer i.Unlock()
+
+	i.trackCall(req.Method + " " + req.Url)
+
+	if i.activeSeriesCustomTrackersStatus == nil || req.Url != ingesterActiveSeriesCustomTrackersStatusPath {
+		return &httpgrpc.HTTPResponse{Code: http.StatusNotFound}, nil
+	}
+
+	body, err := json.Marshal(i.activeSeriesCustomTrackersStatus)
+	if err != nil {
+		return nil, err
+	}
+	return &httpgrpc.HTTPResponse{Code: http.StatusOK, Body: body}, nil
+}
+
+func TestDistributor_ActiveSeriesCustomTrackersStatusHandler(t *testing.T) {
+	trackers := ingester.ActiveSeriesCustomTrackersConfig{"test": `{__name__="test"}`}
+
+	ds, ingesters, _ := prepare(t, prepConfig{
+		numIngesters:    3,
+		happyIngesters:  3,
+		numDistributors: 1,
+	})
+	ingesters[0].activeSeriesCustomTrackersStatus = &ingester.ActiveSeriesCustomTrackersStatus{TSDBOpen: true, Trackers: trackers, UpdatedAtMs: 1000, ValidFromMs: 601000, Valid: true}
+	ingesters[1].activeSeriesCustomTrackersStatus = &ingester.ActiveSeriesCustomTrackersStatus{TSDBOpen: true, Trackers: trackers, UpdatedAtMs: 2000, ValidFromMs: 602000, Valid: true}
+	ingesters[2].activeSeriesCustomTrackersStatus = &ingester.ActiveSeriesCustomTrackersStatus{Valid: true}
+
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		t.Run(method, func(t *testing.T) {
+			req := httptest.NewRequest(method, "/api/v1/active_series_custom_trackers_status", nil)
+			req = req.WithContext(user.InjectOrgID(req.Context(), "user"))
+			rec := httptest.NewRecorder()
+			ds[0].ActiveSeriesCustomTrackersStatusHandler(rec, req)
+			require.Equal(t, http.StatusOK, rec.Code)
+
+			res := ActiveSeriesCustomTrackersStatusResponse{}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+			assert.True(t, res.Consistent)
+			assert.True(t, res.Valid)
+			assert.Equal(t, int64(602000), res.ValidFromMs)
+			require.Len(t, res.Ingesters, 3)
+			for idx, s := range res.Ingesters {
+				assert.Empty(t, s.Error)
+				assert.Equal(t, *ingesters[idx].activeSeriesCustomTrackersStatus, s.ActiveSeriesCustomTrackersStatus)
+			}
+
+			// The request has been propagated to all the ingesters with the same method.
+			for idx := range ingesters {
+				assert.Equal(t, 1, ingesters[idx].countCalls(method+" "+ingesterActiveSeriesCustomTrackersStatusPath))
+			}
+		})
+	}
+}
+
+func TestMergeActiveSeriesCustomTrackersStatuses(t *testing.T) {
+	trackers := ingester.ActiveSeriesCustomTrackersConfig{"test": `{__name__="test"}`}
+	otherTrackers := ingester.ActiveSeriesCustomTrackersConfig{"other": `{__name__="other"}`}
+
+	status := func(trackers ingester.ActiveSeriesCustomTrackersConfig, validFromMs int64, valid bool) IngesterActiveSeriesCustomTrackersStatus {
+		return IngesterActiveSeriesCustomTrackersStatus{ActiveSeriesCustomTrackersStatus: ingester.ActiveSeriesCustomTrackersStatus{
+			TSDBOpen:    true,
+			Trackers:    trackers,
+			ValidFromMs: validFromMs,
+			Valid:       valid,
+		}}
+	}
+
+	for name, tc := range map[string]struct {
+		statuses            []IngesterActiveSeriesCustomTrackersStatus
+		expectedConsistent  bool
+		expectedValid       bool
+		expectedValidFromMs int64
+	}{
+		"no ingesters": {
+			expectedConsistent: true,
+			expectedValid:      true,
+		},
+		"all ingesters valid with the same trackers": {
+			statuses:            []IngesterActiveSeriesCustomTrackersStatus{status(trackers, 1000, true), status(trackers, 2000, true)},
+			expectedConsistent:  true,
+			expectedValid:       true,
+			expectedValidFromMs: 2000,
+		},
+		"an ingester not valid yet": {
+			statuses:            []IngesterActiveSeriesCustomTrackersStatus{status(trackers, 1000, true), status(trackers, 3000, false)},
+			expectedConsistent:  true,
+			expectedValidFromMs: 3000,
+		},
+		"an ingester with different trackers": {
+			statuses:            []IngesterActiveSeriesCustomTrackersStatus{status(trackers, 1000, true), status(otherTrackers, 2000, true)},
+			expectedValid:       true,
+			expectedValidFromMs: 2000,
+		},
+		"ingesters without series of the tenant are ignored": {
+			statuses:            []IngesterActiveSeriesCustomTrackersStatus{status(trackers, 1000, true), {ActiveSeriesCustomTrackersStatus: ingester.ActiveSeriesCustomTrackersStatus{Valid: true}}},
+			expectedConsistent:  true,
+			expectedValid:       true,
+			expectedValidFromMs: 1000,
+		},
+		"a failed ingester": {
+			statuses:            []IngesterActiveSeriesCustomTrackersStatus{status(trackers, 1000, true), {Addr: "1", Error: "failed"}},
+			expectedValidFromMs: 1000,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			res := mergeActiveSeriesCustomTrackersStatuses(tc.statuses)
+			assert.Equal(t, tc.expectedConsistent, res.Consistent)
+			assert.Equal(t, tc.expectedValid, res.Valid)
+			assert.Equal(t, tc.expectedValidFromMs, res.ValidFromMs)
+			assert.Equal(t, tc.statuses, res.Ingesters)
+		})
+	}
+}
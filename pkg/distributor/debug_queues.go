@@ -0,0 +1,37 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/grafana/mimir/pkg/util"
+)
+
+// QueuesDebugStatus is the state of the distributor push path returned by QueuesDebugHandler.
+type QueuesDebugStatus struct {
+	InflightPushRequests    int64 `json:"inflight_push_requests"`
+	MaxInflightPushRequests int   `json:"max_inflight_push_requests"`
+
+	// The batched push requests waiting to be sent to the ingesters. Always empty if the ingester push
+	// batching is disabled.
+	IngesterPushBatchingEnabled bool              `json:"ingester_push_batching_enabled"`
+	PendingIngesterPushBatches  []PushBatchStatus `json:"pending_ingester_push_batches"`
+}
+
+// QueuesDebugHandler writes the state of the push requests in progress as JSON, to find out where the
+// requests are queued.
+func (d *Distributor) QueuesDebugHandler(w http.ResponseWriter, _ *http.Request) {
+	status := QueuesDebugStatus{
+		InflightPushRequests:        d.inflightPushRequests.Load(),
+		MaxInflightPushRequests:     d.cfg.InstanceLimits.MaxInflightPushRequests,
+		IngesterPushBatchingEnabled: d.ingesterPushBatcher != nil,
+		PendingIngesterPushBatches:  []PushBatchStatus{},
+	}
+	if d.ingesterPushBatcher != nil {
+		status.PendingIngesterPushBatches = d.ingesterPushBatcher.status(time.Now())
+	}
+
+	util.WriteJSONResponse(w, status)
+}
@@ -18,6 +18,7 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/flagext"
 	"github.com/grafana/dskit/limiter"
 	"github.com/grafana/dskit/ring"
 	ring_client "github.com/grafana/dskit/ring/client"
@@ -42,6 +43,9 @@ import (
 	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/tenant"
 	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/extract"
+	"github.com/grafana/mimir/pkg/util/globalerror"
+	"github.com/grafana/mimir/pkg/util/grpcencoding"
 	"github.com/grafana/mimir/pkg/util/httpgrpcutil"
 	util_math "github.com/grafana/mimir/pkg/util/math"
 	"github.com/grafana/mimir/pkg/util/validation"
@@ -52,8 +56,8 @@ var (
 	errInvalidTenantShardSize = errors.New("invalid tenant shard size, the value must be greater or equal to zero")
 
 	// Distributor instance limits errors.
-	errTooManyInflightPushRequests    = errors.New("too many inflight push requests in distributor")
-	errMaxSamplesPushRateLimitReached = errors.New("distributor's samples push rate limit reached")
+	errTooManyInflightPushRequests    = errors.New(globalerror.DistributorMaxInflightPushes.Message("too many inflight push requests in distributor"))
+	errMaxSamplesPushRateLimitReached = errors.New(globalerror.DistributorMaxIngestionRate.Message("distributor's samples push rate limit reached"))
 )
 
 const (
@@ -85,6 +89,9 @@ type Distributor struct {
 	distributorsLifeCycler *ring.Lifecycler
 	distributorsRing       *ring.Ring
 
+	// Clients to the other distributors in the ring. Nil if the distributor can't join the ring.
+	distributorClients *ring_client.Pool
+
 	// For handling HA replicas.
 	HATracker *haTracker
 
@@ -100,6 +107,21 @@ type Distributor struct {
 	ingestionRate        *util_math.EwmaRate
 	inflightPushRequests atomic.Int64
 
+	// Hedging of queries to ingesters. Nil if disabled.
+	queryHedger *queryHedger
+
+	// Batching of the push requests to ingesters. Nil if disabled.
+	ingesterPushBatcher *ingesterPushBatcher
+
+	// Most recent series rejected by the validation, for each tenant and reason.
+	rejectedSeries *rejectedSeries
+
+	// Timestamp of the most recent sample written to the ingesters, for each tenant.
+	lastWrite *lastWriteTracker
+
+	// Timestamp of the last sample accepted for each series, for each tenant with a minimum sample interval.
+	sampleIntervals *sampleIntervalTracker
+
 	// Metrics
 	queryDuration                    *instrument.HistogramCollector
 	receivedSamples                  *prometheus.CounterVec
@@ -109,6 +131,7 @@ type Distributor struct {
 	incomingExemplars                *prometheus.CounterVec
 	incomingMetadata                 *prometheus.CounterVec
 	nonHASamples                     *prometheus.CounterVec
+	clientCanceledRequests           *prometheus.CounterVec
 	dedupedSamples                   *prometheus.CounterVec
 	labelsHistogram                  prometheus.Histogram
 	sampleDelayHistogram             prometheus.Histogram
@@ -118,6 +141,8 @@ type Distributor struct {
 	ingesterQueryFailures            *prometheus.CounterVec
 	replicationFactor                prometheus.Gauge
 	latestSeenSampleTimestampPerUser *prometheus.GaugeVec
+
+	latestWrittenSampleTimestampPerUser *prometheus.GaugeVec
 }
 
 // Config contains the configuration required to
@@ -132,18 +157,41 @@ type Config struct {
 
 	ExtendWrites bool `yaml:"extend_writes" category:"advanced"`
 
+	UnhealthyZones flagext.StringSliceCSV `yaml:"unhealthy_zones" category:"experimental"`
+
+	// This config is dynamically injected because it can be overridden by the runtime config.
+	UnhealthyZonesFn func() []string `yaml:"-"`
+
+	RejectedSeriesSamplesPerReason int `yaml:"rejected_series_samples_per_reason" category:"experimental"`
+
+	HeartbeatSeriesInterval time.Duration `yaml:"heartbeat_series_interval" category:"experimental"`
+
+	LimitsUtilizationSeriesInterval time.Duration `yaml:"limits_utilization_series_interval" category:"experimental"`
+
+	IngesterPushBatchingWindow    time.Duration `yaml:"ingester_push_batching_window" category:"experimental"`
+	IngesterPushBatchingMaxSeries int           `yaml:"ingester_push_batching_max_series" category:"experimental"`
+
+	CancelPushesOnClientDisconnect bool `yaml:"cancel_pushes_on_client_disconnect" category:"experimental"`
+
 	// Distributors ring
 	DistributorRing RingConfig `yaml:"ring"`
 
 	// for testing and for extending the ingester by adding calls to the client
 	IngesterClientFactory ring_client.PoolFactory `yaml:"-"`
 
+	// for testing the requests to the other distributors
+	DistributorClientFactory ring_client.PoolFactory `yaml:"-"`
+
 	// when true the distributor does not validate the label name, Mimir doesn't directly use
 	// this (and should never use it) but this feature is used by other projects built on top of it
 	SkipLabelNameValidation bool `yaml:"-"`
 
-	// This config is dynamically injected because defined in the querier config.
-	ShuffleShardingLookbackPeriod time.Duration `yaml:"-"`
+	// These configs are dynamically injected because defined in the querier config.
+	ShuffleShardingLookbackPeriod  time.Duration `yaml:"-"`
+	IngesterQueryHedgingEnabled    bool          `yaml:"-"`
+	IngesterQueryHedgingPercentile float64       `yaml:"-"`
+	IngesterQueryHedgingMinDelay   time.Duration `yaml:"-"`
+	IngesterQueryGRPCCompression   string        `yaml:"-"`
 
 	// Limits for distributor
 	InstanceLimits InstanceLimits `yaml:"instance_limits"`
@@ -167,6 +215,13 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	f.IntVar(&cfg.MaxRecvMsgSize, "distributor.max-recv-msg-size", 100<<20, "remote_write API max receive message size (bytes).")
 	f.DurationVar(&cfg.RemoteTimeout, "distributor.remote-timeout", 20*time.Second, "Timeout for downstream ingesters.")
 	f.BoolVar(&cfg.ExtendWrites, "distributor.extend-writes", true, "Try writing to an additional ingester in the presence of an ingester not in the ACTIVE state. It is useful to disable this along with -ingester.ring.unregister-on-shutdown=false in order to not spread samples to extra ingesters during rolling restarts with consistent naming.")
+	f.Var(&cfg.UnhealthyZones, "distributor.unhealthy-zones", "Comma-separated list of ingester availability zones marked as unhealthy. Ingesters in these zones don't receive writes and the write quorum is computed as if the zones were absent, so that writes keep succeeding when a whole zone is unavailable. This value can be overridden through the runtime configuration.")
+	f.IntVar(&cfg.RejectedSeriesSamplesPerReason, "distributor.rejected-series-samples-per-reason", 0, "Number of the most recent series rejected by the validation to keep in memory for each tenant and rejection reason. The series can be listed by the tenant through the /api/v1/rejected_series endpoint. 0 to disable.")
+	f.DurationVar(&cfg.HeartbeatSeriesInterval, "distributor.heartbeat-series-interval", 0, "Interval at which the distributor writes the "+HeartbeatSeriesName+" series to each tenant which recently wrote samples through it, so that the end-to-end freshness of the data can be verified from the read path only. 0 to disable.")
+	f.DurationVar(&cfg.LimitsUtilizationSeriesInterval, "distributor.limits-utilization-series-interval", 0, "Interval at which the distributors write the "+LimitUtilizationSeriesName+" series to each tenant, reporting the ratio between the tenant's current number of series and ingestion rate and their limits, so that tenants can alert on approaching their limits. 0 to disable.")
+	f.DurationVar(&cfg.IngesterPushBatchingWindow, "distributor.ingester-push-batching-window", 0, "Time window during which the series pushed to the same ingester for the same tenant by different requests are merged into a single push request. Batching reduces the per-request overhead at high request rates, at the cost of increasing the write latency by up to the window. 0 to disable.")
+	f.IntVar(&cfg.IngesterPushBatchingMaxSeries, "distributor.ingester-push-batching-max-series", 10000, "Max number of series and metadata in a batched push request to an ingester. The batch is sent as soon as it reaches this size, without waiting for the end of the batching window. 0 to disable.")
+	f.BoolVar(&cfg.CancelPushesOnClientDisconnect, "distributor.cancel-pushes-on-client-disconnect", false, "Cancel the in-flight pushes to the ingesters of a write request when its client disconnects or its deadline expires before the request completes, instead of letting them complete in the background. The client retries the request anyway, so this avoids spending ingester resources on abandoned requests. The pushes batched with other requests are canceled once all the requests in the batch are canceled.")
 	f.Float64Var(&cfg.InstanceLimits.MaxIngestionRate, "distributor.instance-limits.max-ingestion-rate", 0, "Max ingestion rate (samples/sec) that this distributor will accept. This limit is per-distributor, not per-tenant. Additional push requests will be rejected. Current ingestion rate is computed as exponentially weighted moving average, updated every second. 0 = unlimited.")
 	f.IntVar(&cfg.InstanceLimits.MaxInflightPushRequests, "distributor.instance-limits.max-inflight-push-requests", 2000, "Max inflight push requests that this distributor can handle. This limit is per-distributor, not per-tenant. Additional requests will be rejected. 0 = unlimited.")
 }
@@ -210,11 +265,17 @@ func New(cfg Config, clientConfig ingester_client.Config, limits *validation.Ove
 	var ingestionRateStrategy limiter.RateLimiterStrategy
 	var distributorsLifeCycler *ring.Lifecycler
 	var distributorsRing *ring.Ring
+	var distributorClients *ring_client.Pool
 
 	if !canJoinDistributorsRing {
 		ingestionRateStrategy = newInfiniteIngestionRateStrategy()
 	} else {
-		distributorsLifeCycler, err = ring.NewLifecycler(cfg.DistributorRing.ToLifecyclerConfig(), nil, "distributor", DistributorRingKey, true, log, prometheus.WrapRegistererWithPrefix("cortex_", reg))
+		lifecyclerCfg, err := cfg.DistributorRing.ToLifecyclerConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		distributorsLifeCycler, err = ring.NewLifecycler(lifecyclerCfg, nil, "distributor", DistributorRingKey, true, log, prometheus.WrapRegistererWithPrefix("cortex_", reg))
 		if err != nil {
 			return nil, err
 		}
@@ -223,7 +284,8 @@ func New(cfg Config, clientConfig ingester_client.Config, limits *validation.Ove
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to initialize distributors' ring client")
 		}
-		subservices = append(subservices, distributorsLifeCycler, distributorsRing)
+		distributorClients = newDistributorClientPool(clientConfig.GRPCClientConfig, ring_client.NewRingServiceDiscovery(distributorsRing), cfg.DistributorClientFactory, log, reg)
+		subservices = append(subservices, distributorsLifeCycler, distributorsRing, distributorClients)
 
 		ingestionRateStrategy = newGlobalIngestionRateStrategy(limits, distributorsLifeCycler)
 	}
@@ -235,10 +297,14 @@ func New(cfg Config, clientConfig ingester_client.Config, limits *validation.Ove
 		ingesterPool:           NewPool(cfg.PoolConfig, ingestersRing, cfg.IngesterClientFactory, log),
 		distributorsLifeCycler: distributorsLifeCycler,
 		distributorsRing:       distributorsRing,
+		distributorClients:     distributorClients,
 		limits:                 limits,
 		ingestionRateLimiter:   limiter.NewRateLimiter(ingestionRateStrategy, 10*time.Second),
 		HATracker:              haTracker,
 		ingestionRate:          util_math.NewEWMARate(0.2, instanceIngestionRateTickInterval),
+		rejectedSeries:         newRejectedSeries(cfg.RejectedSeriesSamplesPerReason),
+		lastWrite:              newLastWriteTracker(),
+		sampleIntervals:        newSampleIntervalTracker(),
 
 		queryDuration: instrument.NewHistogramCollector(promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
 			Namespace: "cortex",
@@ -281,6 +347,11 @@ func New(cfg Config, clientConfig ingester_client.Config, limits *validation.Ove
 			Name:      "distributor_non_ha_samples_received_total",
 			Help:      "The total number of received samples for a user that has HA tracking turned on, but the sample didn't contain both HA labels.",
 		}, []string{"user"}),
+		clientCanceledRequests: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "cortex",
+			Name:      "distributor_client_canceled_push_requests_total",
+			Help:      "The total number of push requests failed because the client went away or its deadline expired before the request completed.",
+		}, []string{"user"}),
 		dedupedSamples: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
 			Namespace: "cortex",
 			Name:      "distributor_deduped_samples_total",
@@ -340,6 +411,10 @@ func New(cfg Config, clientConfig ingester_client.Config, limits *validation.Ove
 			Name: "cortex_distributor_latest_seen_sample_timestamp_seconds",
 			Help: "Unix timestamp of latest received sample per user.",
 		}, []string{"user"}),
+		latestWrittenSampleTimestampPerUser: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_distributor_latest_written_sample_timestamp_seconds",
+			Help: "Unix timestamp of latest sample successfully written to the ingesters per user.",
+		}, []string{"user"}),
 	}
 
 	promauto.With(reg).NewGauge(prometheus.GaugeOpts{
@@ -366,12 +441,28 @@ func New(cfg Config, clientConfig ingester_client.Config, limits *validation.Ove
 		return d.ingestionRate.Rate()
 	})
 
+	if cfg.IngesterQueryHedgingEnabled {
+		d.queryHedger = newQueryHedger(cfg.IngesterQueryHedgingPercentile, cfg.IngesterQueryHedgingMinDelay, reg)
+	}
+
 	d.forwarder = forwarding.NewForwarder(reg, d.cfg.Forwarding)
 
+	if cfg.IngesterPushBatchingWindow > 0 {
+		d.ingesterPushBatcher = newIngesterPushBatcher(cfg.IngesterPushBatchingWindow, cfg.IngesterPushBatchingMaxSeries, cfg.RemoteTimeout, d.send, reg)
+	}
+
 	d.replicationFactor.Set(float64(ingestersRing.ReplicationFactor()))
 	d.activeUsers = util.NewActiveUsersCleanupWithDefaultValues(d.cleanupInactiveUser)
 
 	subservices = append(subservices, d.ingesterPool, d.activeUsers)
+
+	if cfg.HeartbeatSeriesInterval > 0 {
+		subservices = append(subservices, services.NewTimerService(cfg.HeartbeatSeriesInterval, nil, d.writeHeartbeatSeries, nil).WithName("heartbeat series writer"))
+	}
+	subservices = append(subservices, services.NewTimerService(sampleIntervalPurgePeriod, nil, d.purgeSampleIntervals, nil).WithName("sample intervals purger"))
+	if cfg.LimitsUtilizationSeriesInterval > 0 {
+		subservices = append(subservices, services.NewTimerService(cfg.LimitsUtilizationSeriesInterval, nil, d.writeLimitsUtilizationSeries, nil).WithName("limits utilization series writer"))
+	}
 	d.subservices, err = services.NewManager(subservices...)
 	if err != nil {
 		return nil, err
@@ -418,13 +509,24 @@ func (d *Distributor) cleanupInactiveUser(userID string) {
 	d.incomingExemplars.DeleteLabelValues(userID)
 	d.incomingMetadata.DeleteLabelValues(userID)
 	d.nonHASamples.DeleteLabelValues(userID)
+	d.clientCanceledRequests.DeleteLabelValues(userID)
 	d.latestSeenSampleTimestampPerUser.DeleteLabelValues(userID)
+	d.latestWrittenSampleTimestampPerUser.DeleteLabelValues(userID)
 
 	if err := util.DeleteMatchingLabels(d.dedupedSamples, map[string]string{"user": userID}); err != nil {
 		level.Warn(d.log).Log("msg", "failed to remove cortex_distributor_deduped_samples_total metric for user", "user", userID, "err", err)
 	}
 
 	validation.DeletePerUserValidationMetrics(userID, d.log)
+
+	d.rejectedSeries.deleteUser(userID)
+	d.lastWrite.deleteUser(userID)
+	d.sampleIntervals.deleteUser(userID)
+}
+
+func (d *Distributor) purgeSampleIntervals(_ context.Context) error {
+	d.sampleIntervals.purge(time.Now())
+	return nil
 }
 
 // Called after distributor is asked to stop via StopAsync.
@@ -523,6 +625,13 @@ func (d *Distributor) validateSeries(nowt time.Time, ts mimirpb.PreallocTimeseri
 		}
 	}
 
+	// The created timestamp is only used when the zero sample ingestion is enabled, so it's ignored otherwise.
+	if ts.CreatedTimestamp != 0 && len(ts.Samples) > 0 && d.limits.CreatedTimestampZeroIngestionEnabled(userID) {
+		if err := validation.ValidateCreatedTimestamp(userID, ts.Labels, ts.CreatedTimestamp, ts.Samples[0]); err != nil {
+			return err
+		}
+	}
+
 	for i := 0; i < len(ts.Exemplars); {
 		e := ts.Exemplars[i]
 		if err := validation.ValidateExemplar(userID, ts.Labels, e); err != nil {
@@ -743,6 +852,8 @@ func (d *Distributor) PushWithCleanup(ctx context.Context, req *mimirpb.WriteReq
 		// Errors in validation are considered non-fatal, as one series in a request may contain
 		// invalid data but all the remaining series could be perfectly valid.
 		if validationErr != nil {
+			d.rejectedSeries.add(userID, ts.Labels, validationErr, now)
+
 			if firstPartialErr == nil {
 				// The series labels may be retained by validationErr but that's not a problem for this
 				// use case because we format it calling Error() and then we discard it.
@@ -751,6 +862,25 @@ func (d *Distributor) PushWithCleanup(ctx context.Context, req *mimirpb.WriteReq
 			continue
 		}
 
+		if interval := d.limits.MinSampleInterval(userID); interval > 0 && len(ts.Samples) > 0 {
+			policy := d.limits.MinSampleIntervalPolicy(userID)
+
+			var removed int
+			var firstRemoved int64
+			ts.Samples, removed, firstRemoved = d.sampleIntervals.filter(userID, mimirpb.FromLabelAdaptersToLabels(ts.Labels).Hash(), ts.Samples, interval, policy)
+			if removed > 0 {
+				validation.DiscardedSamples.WithLabelValues(validation.SampleTooFrequent, userID).Add(float64(removed))
+
+				if policy != validation.MinSampleIntervalPolicyDownsample && firstPartialErr == nil {
+					unsafeMetricName, _ := extract.UnsafeMetricNameFromLabelAdapters(ts.Labels)
+					firstPartialErr = httpgrpc.Errorf(http.StatusBadRequest, validation.NewSampleTooFrequentError(unsafeMetricName, firstRemoved).Error())
+				}
+			}
+			if len(ts.Samples) == 0 && len(ts.Exemplars) == 0 {
+				continue
+			}
+		}
+
 		seriesKeys = append(seriesKeys, key)
 		validatedTimeseries = append(validatedTimeseries, ts)
 		validatedSamples += len(ts.Samples)
@@ -800,7 +930,7 @@ func (d *Distributor) PushWithCleanup(ctx context.Context, req *mimirpb.WriteReq
 		// Return a 429 here to tell the client it is going too fast.
 		// Client may discard the data or slow down and re-send.
 		// Prometheus v2.26 added a remote-write option 'retry_on_http_429'.
-		return nil, httpgrpc.Errorf(http.StatusTooManyRequests, "ingestion rate limit (%v) exceeded while adding %d samples and %d metadata", d.ingestionRateLimiter.Limit(now, userID), validatedSamples, len(validatedMetadata))
+		return nil, httpgrpc.Errorf(http.StatusTooManyRequests, globalerror.IngestionRateLimited.Message("ingestion rate limit (%v) exceeded while adding %d samples and %d metadata"), d.ingestionRateLimiter.Limit(now, userID), validatedSamples, len(validatedMetadata))
 	}
 
 	// totalN included samples and metadata. Ingester follows this pattern when computing its ingestion rate.
@@ -809,6 +939,9 @@ func (d *Distributor) PushWithCleanup(ctx context.Context, req *mimirpb.WriteReq
 	// Get a subring if tenant has shuffle shard size configured.
 	subRing := d.ingestersRing.ShuffleShard(userID, d.limits.IngestionTenantShardSize(userID))
 
+	// Exclude the ingesters in the zones marked as unhealthy by the operator.
+	subRing = d.excludeUnhealthyZones(subRing)
+
 	// Use a background context to make sure all ingesters get samples even if we return early
 	localCtx, cancel := context.WithTimeout(context.Background(), d.cfg.RemoteTimeout)
 	localCtx = user.InjectOrgID(localCtx, userID)
@@ -821,6 +954,10 @@ func (d *Distributor) PushWithCleanup(ctx context.Context, req *mimirpb.WriteReq
 	keys := append(seriesKeys, metadataKeys...)
 	initialMetadataIndex := len(seriesKeys)
 
+	// The series buffers are reused once the ingesters have been called, so the latest
+	// sample timestamp is computed upfront.
+	latestWrittenSampleTimestampMs := latestSampleTimestamp(validatedTimeseries)
+
 	op := ring.WriteNoExtend
 	if d.cfg.ExtendWrites {
 		op = ring.Write
@@ -842,9 +979,24 @@ func (d *Distributor) PushWithCleanup(ctx context.Context, req *mimirpb.WriteReq
 			}
 		}
 
+		if d.ingesterPushBatcher != nil {
+			return d.ingesterPushBatcher.push(localCtx, userID, ingester, timeseries, metadata, req.Source)
+		}
 		return d.send(localCtx, ingester, timeseries, metadata, req.Source)
 	}, func() { cleanup(); cancel() })
 
+	// DoBatch returns as soon as the request context is done, so the pushes still in-flight when
+	// the client goes away can be canceled right away. Once the request has succeeded instead,
+	// the pushes to the ingesters beyond the quorum are left to complete.
+	if err != nil && ctx.Err() != nil && d.cfg.CancelPushesOnClientDisconnect {
+		cancel()
+	}
+
+	if err == nil && latestWrittenSampleTimestampMs > 0 {
+		d.lastWrite.update(userID, latestWrittenSampleTimestampMs)
+		d.latestWrittenSampleTimestampPerUser.WithLabelValues(userID).Set(float64(d.lastWrite.get(userID)) / 1000)
+	}
+
 	if forwardingErrCh != nil {
 		// Blocks until the forwarding requests have completed and the final status has been pushed through this chan.
 		forwardingErr := <-forwardingErrCh
@@ -852,6 +1004,11 @@ func (d *Distributor) PushWithCleanup(ctx context.Context, req *mimirpb.WriteReq
 	}
 
 	if err != nil {
+		if ctx.Err() != nil {
+			// The client went away, so the error isn't the distributor's or the ingesters' fault.
+			d.clientCanceledRequests.WithLabelValues(userID).Inc()
+			return nil, httpgrpc.Errorf(httpgrpcutil.StatusClientClosedRequest, "the client went away before the push completed: %s", ctx.Err())
+		}
 		return nil, err
 	}
 	return &mimirpb.WriteResponse{}, firstPartialErr
@@ -913,8 +1070,15 @@ func (d *Distributor) send(ctx context.Context, ingester ring.InstanceDesc, time
 	return err
 }
 
+// withQueryCompression returns a context overriding the compression of the queries to ingesters, if configured.
+func (d *Distributor) withQueryCompression(ctx context.Context) context.Context {
+	return grpcencoding.ContextWithCompression(ctx, d.cfg.IngesterQueryGRPCCompression)
+}
+
 // ForReplicationSet runs f, in parallel, for all ingesters in the input replication set.
 func (d *Distributor) ForReplicationSet(ctx context.Context, replicationSet ring.ReplicationSet, f func(context.Context, ingester_client.IngesterClient) (interface{}, error)) ([]interface{}, error) {
+	ctx = d.withQueryCompression(ctx)
+
 	return replicationSet.Do(ctx, 0, func(ctx context.Context, ing *ring.InstanceDesc) (interface{}, error) {
 		client, err := d.ingesterPool.GetClientFor(ing.Addr)
 		if err != nil {
@@ -1068,6 +1232,96 @@ func (m *labelNamesAndValuesResponseMerger) putItemsToMap(message *ingester_clie
 	return nil
 }
 
+// ActiveSeries queries the ingesters for the labels of the active series matching the matchers,
+// and returns the distinct series.
+func (d *Distributor) ActiveSeries(ctx context.Context, matchers []*labels.Matcher) ([]labels.Labels, error) {
+	replicationSet, err := d.GetIngestersForMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	matchersProto, err := ingester_client.ToLabelMatchers(matchers)
+	if err != nil {
+		return nil, err
+	}
+	req := &ingester_client.ActiveSeriesRequest{Matchers: matchersProto}
+
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	merger := &activeSeriesResponseMerger{result: map[string]labels.Labels{}, sizeLimitBytes: d.limits.ActiveSeriesResultsMaxSizeBytes(userID)}
+	_, err = d.ForReplicationSet(ctx, replicationSet, func(ctx context.Context, client ingester_client.IngesterClient) (interface{}, error) {
+		stream, err := client.ActiveSeries(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		defer stream.CloseSend() //nolint:errcheck
+		return nil, merger.collectResponses(stream)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return merger.toSeries(), nil
+}
+
+type activeSeriesResponseMerger struct {
+	lock             sync.Mutex
+	result           map[string]labels.Labels
+	sizeLimitBytes   int
+	currentSizeBytes int
+}
+
+// collectResponses listens for the stream and puts the received series to the map of distinct series.
+func (m *activeSeriesResponseMerger) collectResponses(stream ingester_client.Ingester_ActiveSeriesClient) error {
+	for {
+		message, err := stream.Recv()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		if err := m.putSeriesToMap(message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *activeSeriesResponseMerger) putSeriesToMap(message *ingester_client.ActiveSeriesResponse) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, metric := range message.Metric {
+		key := mimirpb.FromLabelAdaptersToLabels(metric.Labels).String()
+		if _, exists := m.result[key]; exists {
+			continue
+		}
+		m.currentSizeBytes += len(key)
+		if m.sizeLimitBytes > 0 && m.currentSizeBytes > m.sizeLimitBytes {
+			return fmt.Errorf("size of distinct active series is greater than %v bytes", m.sizeLimitBytes)
+		}
+		// The labels unmarshalled from the message refer to its buffer, so they're copied before being retained.
+		m.result[key] = mimirpb.FromLabelAdaptersToLabelsWithCopy(metric.Labels)
+	}
+	return nil
+}
+
+// toSeries returns the distinct series sorted by labels.
+func (m *activeSeriesResponseMerger) toSeries() []labels.Labels {
+	// The lock is acquired because some ingesters responses may still be processed
+	// when ForReplicationSet() returns after receiving the responses from a quorum of instances.
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	series := make([]labels.Labels, 0, len(m.result))
+	for _, lbls := range m.result {
+		series = append(series, lbls)
+	}
+	sort.Slice(series, func(i, j int) bool {
+		return labels.Compare(series[i], series[j]) < 0
+	})
+	return series
+}
+
 // LabelValuesCardinality performs the following two operations in parallel:
 //  * queries ingesters for label values cardinality of a set of labelNames
 //  * queries ingesters for user stats to get the ingester's series head count
@@ -1305,7 +1559,7 @@ func (d *Distributor) MetricsMetadata(ctx context.Context) ([]scrape.MetricMetad
 
 	req := &ingester_client.MetricsMetadataRequest{}
 	resps, err := d.ForReplicationSet(ctx, replicationSet, func(ctx context.Context, client ingester_client.IngesterClient) (interface{}, error) {
-		return client.MetricsMetadata(ctx, req)
+		return queryIngesterMetricsMetadata(ctx, client, req)
 	})
 	if err != nil {
 		return nil, err
@@ -12,6 +12,7 @@ import (
 	"github.com/grafana/dskit/flagext"
 	"github.com/grafana/dskit/ring"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRingConfig_DefaultConfigToLifecyclerConfig(t *testing.T) {
@@ -32,7 +33,13 @@ func TestRingConfig_DefaultConfigToLifecyclerConfig(t *testing.T) {
 	expected.FinalSleep = 0
 	expected.InfNames = cfg.InstanceInterfaceNames
 
-	assert.Equal(t, expected, cfg.ToLifecyclerConfig())
+	// Skip the auto-detection of the instance address.
+	cfg.InstanceAddr = "1.2.3.4"
+	expected.Addr = cfg.InstanceAddr
+
+	actual, err := cfg.ToLifecyclerConfig()
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
 }
 
 func TestRingConfig_CustomConfigToLifecyclerConfig(t *testing.T) {
@@ -68,5 +75,7 @@ func TestRingConfig_CustomConfigToLifecyclerConfig(t *testing.T) {
 	expected.MinReadyDuration = 0
 	expected.FinalSleep = 0
 
-	assert.Equal(t, expected, cfg.ToLifecyclerConfig())
+	actual, err := cfg.ToLifecyclerConfig()
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
 }
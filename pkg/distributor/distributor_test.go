@@ -40,6 +40,7 @@ import (
 	"go.uber.org/atomic"
 	"golang.org/x/time/rate"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/status"
 
@@ -51,6 +52,7 @@ import (
 	"github.com/grafana/mimir/pkg/tenant"
 	"github.com/grafana/mimir/pkg/util"
 	"github.com/grafana/mimir/pkg/util/chunkcompat"
+	"github.com/grafana/mimir/pkg/util/httpgrpcutil"
 	"github.com/grafana/mimir/pkg/util/limiter"
 	util_math "github.com/grafana/mimir/pkg/util/math"
 	"github.com/grafana/mimir/pkg/util/validation"
@@ -185,7 +187,7 @@ func TestDistributor_Push(t *testing.T) {
 			happyIngesters: 3,
 			samples:        samplesIn{num: 25, startTimestampMs: 123456789000},
 			metadata:       5,
-			expectedError:  httpgrpc.Errorf(http.StatusTooManyRequests, "ingestion rate limit (20) exceeded while adding 25 samples and 5 metadata"),
+			expectedError:  httpgrpc.Errorf(http.StatusTooManyRequests, "ingestion rate limit (20) exceeded while adding 25 samples and 5 metadata (err-mimir-ingestion-rate-limited)"),
 			metricNames:    []string{lastSeenTimestamp},
 			expectedMetrics: `
 				# HELP cortex_distributor_latest_seen_sample_timestamp_seconds Unix timestamp of latest received sample per user.
@@ -459,10 +461,10 @@ func TestDistributor_PushIngestionRateLimiter(t *testing.T) {
 			pushes: []testPush{
 				{samples: 2, expectedError: nil},
 				{samples: 1, expectedError: nil},
-				{samples: 2, metadata: 1, expectedError: httpgrpc.Errorf(http.StatusTooManyRequests, "ingestion rate limit (5) exceeded while adding 2 samples and 1 metadata")},
+				{samples: 2, metadata: 1, expectedError: httpgrpc.Errorf(http.StatusTooManyRequests, "ingestion rate limit (5) exceeded while adding 2 samples and 1 metadata (err-mimir-ingestion-rate-limited)")},
 				{samples: 2, expectedError: nil},
-				{samples: 1, expectedError: httpgrpc.Errorf(http.StatusTooManyRequests, "ingestion rate limit (5) exceeded while adding 1 samples and 0 metadata")},
-				{metadata: 1, expectedError: httpgrpc.Errorf(http.StatusTooManyRequests, "ingestion rate limit (5) exceeded while adding 0 samples and 1 metadata")},
+				{samples: 1, expectedError: httpgrpc.Errorf(http.StatusTooManyRequests, "ingestion rate limit (5) exceeded while adding 1 samples and 0 metadata (err-mimir-ingestion-rate-limited)")},
+				{metadata: 1, expectedError: httpgrpc.Errorf(http.StatusTooManyRequests, "ingestion rate limit (5) exceeded while adding 0 samples and 1 metadata (err-mimir-ingestion-rate-limited)")},
 			},
 		},
 		"burst should set to each distributor": {
@@ -472,10 +474,10 @@ func TestDistributor_PushIngestionRateLimiter(t *testing.T) {
 			pushes: []testPush{
 				{samples: 10, expectedError: nil},
 				{samples: 5, expectedError: nil},
-				{samples: 5, metadata: 1, expectedError: httpgrpc.Errorf(http.StatusTooManyRequests, "ingestion rate limit (5) exceeded while adding 5 samples and 1 metadata")},
+				{samples: 5, metadata: 1, expectedError: httpgrpc.Errorf(http.StatusTooManyRequests, "ingestion rate limit (5) exceeded while adding 5 samples and 1 metadata (err-mimir-ingestion-rate-limited)")},
 				{samples: 5, expectedError: nil},
-				{samples: 1, expectedError: httpgrpc.Errorf(http.StatusTooManyRequests, "ingestion rate limit (5) exceeded while adding 1 samples and 0 metadata")},
-				{metadata: 1, expectedError: httpgrpc.Errorf(http.StatusTooManyRequests, "ingestion rate limit (5) exceeded while adding 0 samples and 1 metadata")},
+				{samples: 1, expectedError: httpgrpc.Errorf(http.StatusTooManyRequests, "ingestion rate limit (5) exceeded while adding 1 samples and 0 metadata (err-mimir-ingestion-rate-limited)")},
+				{metadata: 1, expectedError: httpgrpc.Errorf(http.StatusTooManyRequests, "ingestion rate limit (5) exceeded while adding 0 samples and 1 metadata (err-mimir-ingestion-rate-limited)")},
 			},
 		},
 	}
@@ -667,6 +669,48 @@ func TestDistributor_PushInstanceLimits(t *testing.T) {
 	}
 }
 
+func TestDistributor_Push_ClientDisconnect(t *testing.T) {
+	const pushDelay = 500 * time.Millisecond
+
+	for name, tc := range map[string]struct {
+		cancelPushes   bool
+		expectedSeries int
+	}{
+		"in-flight pushes are canceled":               {cancelPushes: true, expectedSeries: 0},
+		"in-flight pushes complete in the background": {cancelPushes: false, expectedSeries: 1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ds, ingesters, regs := prepare(t, prepConfig{
+				numIngesters:             3,
+				happyIngesters:           3,
+				numDistributors:          1,
+				ingesterPushDelay:        pushDelay,
+				cancelPushesOnDisconnect: tc.cancelPushes,
+			})
+
+			ctx, cancel := context.WithCancel(user.InjectOrgID(context.Background(), "user"))
+			time.AfterFunc(50*time.Millisecond, cancel)
+
+			_, err := ds[0].Push(ctx, makeWriteRequest(0, 1, 0, false))
+			resp, ok := httpgrpc.HTTPResponseFromError(err)
+			require.True(t, ok)
+			assert.Equal(t, int32(httpgrpcutil.StatusClientClosedRequest), resp.Code)
+
+			assert.NoError(t, testutil.GatherAndCompare(regs[0], strings.NewReader(`
+				# HELP cortex_distributor_client_canceled_push_requests_total The total number of push requests failed because the client went away or its deadline expired before the request completed.
+				# TYPE cortex_distributor_client_canceled_push_requests_total counter
+				cortex_distributor_client_canceled_push_requests_total{user="user"} 1
+			`), "cortex_distributor_client_canceled_push_requests_total"))
+
+			// Wait until the pushes not canceled have completed.
+			time.Sleep(2 * pushDelay)
+			for i := range ingesters {
+				assert.Len(t, ingesters[i].series(), tc.expectedSeries)
+			}
+		})
+	}
+}
+
 func TestDistributor_PushHAInstances(t *testing.T) {
 	ctx := user.InjectOrgID(context.Background(), "user")
 
@@ -1069,6 +1113,39 @@ func TestDistributor_QueryStream_ShouldReturnErrorIfMaxChunkBytesPerQueryLimitIs
 	assert.Equal(t, err, validation.LimitError(fmt.Sprintf(limiter.ErrMaxChunkBytesHit, maxBytesLimit)))
 }
 
+func TestDistributor_QueryStream_ShouldReturnErrorIfMaxDataBytesPerQueryLimitIsReached(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "user")
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+
+	ds, _, _ := prepare(t, prepConfig{
+		numIngesters:      3,
+		happyIngesters:    3,
+		numDistributors:   1,
+		limits:            limits,
+		replicationFactor: 1,
+	})
+
+	allSeriesMatchers := []*labels.Matcher{
+		labels.MustNewMatcher(labels.MatchRegexp, model.MetricNameLabel, ".+"),
+	}
+
+	writeRes, err := ds[0].Push(ctx, makeWriteRequest(0, 10, 0, false))
+	assert.Equal(t, &mimirpb.WriteResponse{}, writeRes)
+	require.NoError(t, err)
+
+	// The size of the data received from the ingesters includes the series labels, so it's
+	// greater than the size of the chunks.
+	queryRes, err := ds[0].QueryStream(limiter.AddQueryLimiterToContext(ctx, limiter.NewQueryLimiter(0, 0, 0).WithMaxDataBytes(1e6)), math.MinInt32, math.MaxInt32, allSeriesMatchers...)
+	require.NoError(t, err)
+	assert.Len(t, queryRes.Chunkseries, 10)
+
+	maxBytesLimit := queryRes.ChunksSize()
+	_, err = ds[0].QueryStream(limiter.AddQueryLimiterToContext(ctx, limiter.NewQueryLimiter(0, 0, 0).WithMaxDataBytes(maxBytesLimit)), math.MinInt32, math.MaxInt32, allSeriesMatchers...)
+	require.Error(t, err)
+	assert.Equal(t, err, validation.LimitError(fmt.Sprintf(limiter.ErrMaxDataBytesHit, maxBytesLimit)))
+}
+
 func TestDistributor_Push_LabelRemoval(t *testing.T) {
 	ctx := user.InjectOrgID(context.Background(), "user")
 
@@ -1278,7 +1355,7 @@ func TestDistributor_Push_LabelNameValidation(t *testing.T) {
 		"label name validation is on by default": {
 			inputLabels: inputLabels,
 			errExpected: true,
-			errMessage:  `sample invalid label: "999.illegal" metric "foo{999.illegal=\"baz\"}"`,
+			errMessage:  `sample invalid label: "999.illegal" metric "foo{999.illegal=\"baz\"}" (err-mimir-label-invalid)`,
 		},
 		"label name validation can be skipped via config": {
 			inputLabels:                inputLabels,
@@ -1330,7 +1407,7 @@ func TestDistributor_Push_ExemplarValidation(t *testing.T) {
 		},
 		"rejects exemplar with no labels": {
 			req:    makeWriteRequestExemplar([]string{model.MetricNameLabel, "test"}, 1000, []string{}),
-			errMsg: `exemplar missing labels, timestamp: 1000 series: {__name__="test"} labels: {}`,
+			errMsg: `exemplar missing labels, timestamp: 1000 series: {__name__="test"} labels: {} (err-mimir-exemplar-labels-missing)`,
 		},
 		"rejects exemplar with no timestamp": {
 			req:    makeWriteRequestExemplar([]string{model.MetricNameLabel, "test"}, 0, []string{"foo", "bar"}),
@@ -1930,8 +2007,9 @@ func TestDistributor_MetricsMetadata(t *testing.T) {
 	const numIngesters = 5
 
 	tests := map[string]struct {
-		shuffleShardSize  int
-		expectedIngesters int
+		shuffleShardSize           int
+		ingestersWithoutStreamRPCs bool
+		expectedIngesters          int
 	}{
 		"should query all ingesters if shuffle sharding is enabled but shard size is 0": {
 			shuffleShardSize:  0,
@@ -1941,17 +2019,22 @@ func TestDistributor_MetricsMetadata(t *testing.T) {
 			shuffleShardSize:  3,
 			expectedIngesters: 3,
 		},
+		"should fall back to the unary RPC if ingesters don't support the streaming one": {
+			ingestersWithoutStreamRPCs: true,
+			expectedIngesters:          numIngesters,
+		},
 	}
 
 	for testName, testData := range tests {
 		t.Run(testName, func(t *testing.T) {
 			// Create distributor
 			ds, ingesters, _ := prepare(t, prepConfig{
-				numIngesters:     numIngesters,
-				happyIngesters:   numIngesters,
-				numDistributors:  1,
-				shuffleShardSize: testData.shuffleShardSize,
-				limits:           nil,
+				numIngesters:               numIngesters,
+				happyIngesters:             numIngesters,
+				numDistributors:            1,
+				shuffleShardSize:           testData.shuffleShardSize,
+				limits:                     nil,
+				ingestersWithoutStreamRPCs: testData.ingestersWithoutStreamRPCs,
 			})
 
 			// Push metadata
@@ -1970,7 +2053,14 @@ func TestDistributor_MetricsMetadata(t *testing.T) {
 			// Due to the quorum the distributor could cancel the last request towards ingesters
 			// if all other ones are successful, so we're good either has been queried X or X-1
 			// ingesters.
-			assert.Contains(t, []int{testData.expectedIngesters, testData.expectedIngesters - 1}, countMockIngestersCalls(ingesters, "MetricsMetadata"))
+			assert.Contains(t, []int{testData.expectedIngesters, testData.expectedIngesters - 1}, countMockIngestersCalls(ingesters, "MetricsMetadataStream"))
+
+			// The unary RPC is only called on the ingesters which don't support the streaming one.
+			if testData.ingestersWithoutStreamRPCs {
+				assert.Contains(t, []int{testData.expectedIngesters, testData.expectedIngesters - 1}, countMockIngestersCalls(ingesters, "MetricsMetadata"))
+			} else {
+				assert.Equal(t, 0, countMockIngestersCalls(ingesters, "MetricsMetadata"))
+			}
 		})
 	}
 }
@@ -2134,6 +2224,74 @@ func TestDistributor_LabelNamesAndValues_ExpectedAllPossibleLabelNamesAndValuesT
 	require.Equal(t, 10000, len(response.Items[0].Values))
 }
 
+func TestDistributor_ActiveSeries(t *testing.T) {
+	fixtures := []labels.Labels{
+		labels.FromStrings(labels.MetricName, "metric_0", "status", "200"),
+		labels.FromStrings(labels.MetricName, "metric_1", "status", "500"),
+		labels.FromStrings(labels.MetricName, "metric_1", "status", "200"),
+	}
+
+	tests := map[string]struct {
+		matchers         []*labels.Matcher
+		sizeLimitBytes   int
+		disableSizeLimit bool
+		expectedSeries   []labels.Labels
+		expectedErr      bool
+	}{
+		"should return the distinct series sorted by labels": {
+			expectedSeries: []labels.Labels{fixtures[0], fixtures[2], fixtures[1]},
+		},
+		"should return the distinct series when the size limit is disabled": {
+			disableSizeLimit: true,
+			expectedSeries:   []labels.Labels{fixtures[0], fixtures[2], fixtures[1]},
+		},
+		"should return the series matching the matchers": {
+			matchers:       []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "status", "200")},
+			expectedSeries: []labels.Labels{fixtures[0], fixtures[2]},
+		},
+		"should fail if the size of the distinct series exceeds the limit": {
+			sizeLimitBytes: 50,
+			expectedErr:    true,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			ctx := user.InjectOrgID(context.Background(), "active-series")
+
+			limits := &validation.Limits{}
+			flagext.DefaultValues(limits)
+			if testData.sizeLimitBytes > 0 {
+				limits.ActiveSeriesResultsMaxSizeBytes = testData.sizeLimitBytes
+			}
+			if testData.disableSizeLimit {
+				limits.ActiveSeriesResultsMaxSizeBytes = 0
+			}
+
+			ds, _, _ := prepare(t, prepConfig{
+				numIngesters:      6,
+				happyIngesters:    6,
+				numDistributors:   1,
+				replicationFactor: 3,
+				limits:            limits,
+			})
+
+			for _, series := range fixtures {
+				_, err := ds[0].Push(ctx, mockWriteRequest(series, 1, 100000))
+				require.NoError(t, err)
+			}
+
+			series, err := ds[0].ActiveSeries(ctx, testData.matchers)
+			if testData.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testData.expectedSeries, series)
+		})
+	}
+}
+
 func TestDistributor_IngestionIsControlledByForwarder(t *testing.T) {
 	type testcase struct {
 		name                  string
@@ -2638,6 +2796,12 @@ type prepConfig struct {
 	ingesterZones                []string
 	zonesResponseDelay           map[string]time.Duration
 	forwarding                   bool
+	queryHedging                 bool
+	rejectedSeriesPerReason      int
+	ingesterPushBatchingWindow   time.Duration
+	ingestersWithoutStreamRPCs   bool
+	ingesterPushDelay            time.Duration
+	cancelPushesOnDisconnect     bool
 }
 
 func prepare(t *testing.T, cfg prepConfig) ([]*Distributor, []mockIngester, []*prometheus.Registry) {
@@ -2657,6 +2821,8 @@ func prepare(t *testing.T, cfg prepConfig) ([]*Distributor, []mockIngester, []*p
 			seriesCountTotal: cfg.ingestersSeriesCountTotal,
 			zone:             zone,
 			responseDelay:    responseDelay,
+			noStreamRPCs:     cfg.ingestersWithoutStreamRPCs,
+			pushDelay:        cfg.ingesterPushDelay,
 		})
 	}
 	for i := cfg.happyIngesters; i < cfg.numIngesters; i++ {
@@ -2721,6 +2887,16 @@ func prepare(t *testing.T, cfg prepConfig) ([]*Distributor, []mockIngester, []*p
 
 	distributors := make([]*Distributor, 0, cfg.numDistributors)
 	registries := make([]*prometheus.Registry, 0, cfg.numDistributors)
+
+	distributorFactory := func(addr string) (ring_client.PoolClient, error) {
+		for _, d := range distributors {
+			if d.distributorsLifeCycler.Addr == addr {
+				return newMockDistributorClient(d), nil
+			}
+		}
+		return nil, fmt.Errorf("distributor %s not found", addr)
+	}
+
 	for i := 0; i < cfg.numDistributors; i++ {
 		if cfg.limits == nil {
 			cfg.limits = &validation.Limits{}
@@ -2732,14 +2908,21 @@ func prepare(t *testing.T, cfg prepConfig) ([]*Distributor, []mockIngester, []*p
 		flagext.DefaultValues(&distributorCfg, &clientConfig)
 
 		distributorCfg.IngesterClientFactory = factory
+		distributorCfg.DistributorClientFactory = distributorFactory
 		distributorCfg.DistributorRing.HeartbeatPeriod = 100 * time.Millisecond
 		distributorCfg.DistributorRing.InstanceID = strconv.Itoa(i)
 		distributorCfg.DistributorRing.KVStore.Mock = kvStore
-		distributorCfg.DistributorRing.InstanceAddr = "127.0.0.1"
+		distributorCfg.DistributorRing.InstanceAddr = fmt.Sprintf("127.0.0.%d", i+1)
 		distributorCfg.SkipLabelNameValidation = cfg.skipLabelNameValidation
 		distributorCfg.InstanceLimits.MaxInflightPushRequests = cfg.maxInflightRequests
 		distributorCfg.InstanceLimits.MaxIngestionRate = cfg.maxIngestionRate
 		distributorCfg.ShuffleShardingLookbackPeriod = time.Hour
+		distributorCfg.IngesterQueryHedgingEnabled = cfg.queryHedging
+		distributorCfg.IngesterQueryHedgingPercentile = 0.9
+		distributorCfg.IngesterQueryHedgingMinDelay = 10 * time.Millisecond
+		distributorCfg.RejectedSeriesSamplesPerReason = cfg.rejectedSeriesPerReason
+		distributorCfg.IngesterPushBatchingWindow = cfg.ingesterPushBatchingWindow
+		distributorCfg.CancelPushesOnClientDisconnect = cfg.cancelPushesOnDisconnect
 
 		if cfg.forwarding {
 			distributorCfg.Forwarding.Enabled = true
@@ -2953,6 +3136,13 @@ type mockIngester struct {
 	seriesCountTotal uint64
 	zone             string
 	responseDelay    time.Duration
+	pushDelay        time.Duration
+
+	// noStreamRPCs simulates an ingester which doesn't implement the streaming read RPCs yet.
+	noStreamRPCs bool
+
+	// activeSeriesCustomTrackersStatus is returned by the ingester's HTTP API, which fails if nil.
+	activeSeriesCustomTrackersStatus *ingester.ActiveSeriesCustomTrackersStatus
 }
 
 func (i *mockIngester) series() map[uint32]*mimirpb.PreallocTimeseries {
@@ -2980,6 +3170,14 @@ func (i *mockIngester) Close() error {
 }
 
 func (i *mockIngester) Push(ctx context.Context, req *mimirpb.WriteRequest, opts ...grpc.CallOption) (*mimirpb.WriteResponse, error) {
+	if i.pushDelay > 0 {
+		select {
+		case <-time.After(i.pushDelay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
 	i.Lock()
 	defer i.Unlock()
 
@@ -3179,6 +3377,32 @@ func (i *mockIngester) MetricsMetadata(ctx context.Context, req *client.MetricsM
 	return resp, nil
 }
 
+func (i *mockIngester) MetricsMetadataStream(ctx context.Context, req *client.MetricsMetadataRequest, opts ...grpc.CallOption) (client.Ingester_MetricsMetadataStreamClient, error) {
+	i.Lock()
+	defer i.Unlock()
+
+	i.trackCall("MetricsMetadataStream")
+
+	if i.noStreamRPCs {
+		return nil, status.Error(codes.Unimplemented, "unknown method MetricsMetadataStream")
+	}
+
+	if !i.happy {
+		return nil, errFail
+	}
+
+	// Stream each metadata in a different message.
+	results := []*client.MetricsMetadataResponse{}
+	for _, sets := range i.metadata {
+		for m := range sets {
+			m := m
+			results = append(results, &client.MetricsMetadataResponse{Metadata: []*mimirpb.MetricMetadata{&m}})
+		}
+	}
+
+	return &metricsMetadataStream{results: results}, nil
+}
+
 func (i *mockIngester) LabelNamesAndValues(_ context.Context, _ *client.LabelNamesAndValuesRequest, _ ...grpc.CallOption) (client.Ingester_LabelNamesAndValuesClient, error) {
 	i.Lock()
 	defer i.Unlock()
@@ -3296,6 +3520,70 @@ func (s *labelValuesCardinalityStream) Recv() (*client.LabelValuesCardinalityRes
 	return result, nil
 }
 
+func (i *mockIngester) ActiveSeries(ctx context.Context, req *client.ActiveSeriesRequest, opts ...grpc.CallOption) (client.Ingester_ActiveSeriesClient, error) {
+	i.Lock()
+	defer i.Unlock()
+
+	i.trackCall("ActiveSeries")
+
+	if !i.happy {
+		return nil, errFail
+	}
+
+	matchers, err := client.FromLabelMatchers(req.GetMatchers())
+	if err != nil {
+		return nil, err
+	}
+
+	// Stream each series in a different message.
+	results := []*client.ActiveSeriesResponse{}
+	for _, ts := range i.timeseries {
+		if match(ts.Labels, matchers) {
+			results = append(results, &client.ActiveSeriesResponse{Metric: []*mimirpb.Metric{{Labels: ts.Labels}}})
+		}
+	}
+
+	return &activeSeriesStream{results: results}, nil
+}
+
+type activeSeriesStream struct {
+	grpc.ClientStream
+	i       int
+	results []*client.ActiveSeriesResponse
+}
+
+func (*activeSeriesStream) CloseSend() error {
+	return nil
+}
+
+func (s *activeSeriesStream) Recv() (*client.ActiveSeriesResponse, error) {
+	if s.i >= len(s.results) {
+		return nil, io.EOF
+	}
+	result := s.results[s.i]
+	s.i++
+	return result, nil
+}
+
+type metricsMetadataStream struct {
+	grpc.ClientStream
+	i       int
+	results []*client.MetricsMetadataResponse
+}
+
+func (*metricsMetadataStream) CloseSend() error {
+	return nil
+}
+
+func (s *metricsMetadataStream) Recv() (*client.MetricsMetadataResponse, error) {
+	if s.i >= len(s.results) {
+		return nil, io.EOF
+	}
+	result := s.results[s.i]
+	s.i++
+	return result, nil
+}
+
 func (i *mockIngester) trackCall(name string) {
 	if i.calls == nil {
 		i.calls = map[string]int{}
@@ -3411,11 +3699,12 @@ func TestDistributorValidation(t *testing.T) {
 	future, past := now.Add(5*time.Hour), now.Add(-25*time.Hour)
 
 	for i, tc := range []struct {
-		metadata  []*mimirpb.MetricMetadata
-		labels    []labels.Labels
-		samples   []mimirpb.Sample
-		exemplars []*mimirpb.Exemplar
-		err       error
+		metadata         []*mimirpb.MetricMetadata
+		labels           []labels.Labels
+		samples          []mimirpb.Sample
+		exemplars        []*mimirpb.Exemplar
+		createdTimestamp int64
+		err              error
 	}{
 		// Test validation passes.
 		{
@@ -3439,7 +3728,28 @@ func TestDistributorValidation(t *testing.T) {
 				TimestampMs: int64(future),
 				Value:       4,
 			}},
-			err: httpgrpc.Errorf(http.StatusBadRequest, `timestamp too new: %d metric: "testmetric"`, future),
+			err: httpgrpc.Errorf(http.StatusBadRequest, `timestamp too new: %d metric: "testmetric" (err-mimir-too-far-in-future)`, future),
+		},
+
+		// Test validation passes for a created timestamp older than the samples.
+		{
+			labels: []labels.Labels{{{Name: labels.MetricName, Value: "testmetric"}, {Name: "foo", Value: "bar"}}},
+			samples: []mimirpb.Sample{{
+				TimestampMs: int64(now),
+				Value:       1,
+			}},
+			createdTimestamp: int64(past),
+		},
+
+		// Test validation fails for a created timestamp newer than the samples.
+		{
+			labels: []labels.Labels{{{Name: labels.MetricName, Value: "testmetric"}, {Name: "foo", Value: "bar"}}},
+			samples: []mimirpb.Sample{{
+				TimestampMs: int64(past),
+				Value:       1,
+			}},
+			createdTimestamp: int64(now),
+			err:              httpgrpc.Errorf(http.StatusBadRequest, `created timestamp newer than the first sample of the series: %d metric: "testmetric" (err-mimir-created-timestamp-invalid)`, now),
 		},
 
 		// Test maximum labels names per series.
@@ -3449,7 +3759,7 @@ func TestDistributorValidation(t *testing.T) {
 				TimestampMs: int64(now),
 				Value:       2,
 			}},
-			err: httpgrpc.Errorf(http.StatusBadRequest, `series has too many labels (actual: 3, limit: 2) series: 'testmetric{foo2="bar2", foo="bar"}'`),
+			err: httpgrpc.Errorf(http.StatusBadRequest, `series has too many labels (actual: 3, limit: 2) series: 'testmetric{foo2="bar2", foo="bar"}' (err-mimir-max-label-names-per-series)`),
 		},
 		// Test multiple validation fails return the first one.
 		{
@@ -3461,7 +3771,7 @@ func TestDistributorValidation(t *testing.T) {
 				{TimestampMs: int64(now), Value: 2},
 				{TimestampMs: int64(past), Value: 2},
 			},
-			err: httpgrpc.Errorf(http.StatusBadRequest, `series has too many labels (actual: 3, limit: 2) series: 'testmetric{foo2="bar2", foo="bar"}'`),
+			err: httpgrpc.Errorf(http.StatusBadRequest, `series has too many labels (actual: 3, limit: 2) series: 'testmetric{foo2="bar2", foo="bar"}' (err-mimir-max-label-names-per-series)`),
 		},
 		// Test metadata validation fails
 		{
@@ -3471,7 +3781,7 @@ func TestDistributorValidation(t *testing.T) {
 				TimestampMs: int64(now),
 				Value:       1,
 			}},
-			err: httpgrpc.Errorf(http.StatusBadRequest, `metadata missing metric name`),
+			err: httpgrpc.Errorf(http.StatusBadRequest, `metadata missing metric name (err-mimir-metadata-missing-metric-name)`),
 		},
 		// Test empty exemplar labels fails.
 		{
@@ -3486,7 +3796,7 @@ func TestDistributorValidation(t *testing.T) {
 				TimestampMs: int64(now),
 				Value:       1,
 			}},
-			err: httpgrpc.Errorf(http.StatusBadRequest, "exemplar missing labels, timestamp: %d series: %+v labels: {}", now, labels.Labels{{Name: labels.MetricName, Value: "testmetric"}, {Name: "foo", Value: "bar"}}),
+			err: httpgrpc.Errorf(http.StatusBadRequest, "exemplar missing labels, timestamp: %d series: %+v labels: {} (err-mimir-exemplar-labels-missing)", now, labels.Labels{{Name: labels.MetricName, Value: "testmetric"}, {Name: "foo", Value: "bar"}}),
 		},
 	} {
 		t.Run(strconv.Itoa(i), func(t *testing.T) {
@@ -3495,6 +3805,7 @@ func TestDistributorValidation(t *testing.T) {
 
 			limits.CreationGracePeriod = model.Duration(2 * time.Hour)
 			limits.MaxLabelNamesPerSeries = 2
+			limits.CreatedTimestampZeroIngestionEnabled = true
 
 			ds, _, _ := prepare(t, prepConfig{
 				numIngesters:    3,
@@ -3503,7 +3814,12 @@ func TestDistributorValidation(t *testing.T) {
 				limits:          &limits,
 			})
 
-			_, err := ds[0].Push(ctx, mimirpb.ToWriteRequest(tc.labels, tc.samples, tc.exemplars, tc.metadata, mimirpb.API))
+			req := mimirpb.ToWriteRequest(tc.labels, tc.samples, tc.exemplars, tc.metadata, mimirpb.API)
+			for _, ts := range req.Timeseries {
+				ts.CreatedTimestamp = tc.createdTimestamp
+			}
+
+			_, err := ds[0].Push(ctx, req)
 			require.Equal(t, tc.err, err)
 		})
 	}
@@ -27,6 +27,7 @@ import (
 
 	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/globalerror"
 )
 
 var (
@@ -528,7 +529,7 @@ type tooManyClustersError struct {
 }
 
 func (e tooManyClustersError) Error() string {
-	return fmt.Sprintf("too many HA clusters (limit: %d)", e.limit)
+	return globalerror.TooManyHAClusters.Message(fmt.Sprintf("too many HA clusters (limit: %d)", e.limit))
 }
 
 // Needed for errors.Is to work properly.
@@ -0,0 +1,82 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/concurrency"
+	"github.com/grafana/dskit/ring"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+const (
+	// HeartbeatSeriesName is the name of the series written by the distributors to each tenant, when enabled.
+	HeartbeatSeriesName = "mimir_tenant_heartbeat"
+
+	// heartbeatConcurrency is the max number of tenants to which the heartbeat series is written concurrently.
+	heartbeatConcurrency = 16
+)
+
+// writeHeartbeatSeries writes the heartbeat series to each tenant which recently wrote samples through
+// this distributor. Failures are logged and don't stop the distributor.
+func (d *Distributor) writeHeartbeatSeries(ctx context.Context) error {
+	d.writeHeartbeatSeriesAt(ctx, time.Now())
+	return nil
+}
+
+func (d *Distributor) writeHeartbeatSeriesAt(ctx context.Context, now time.Time) {
+	// The sample timestamp is aligned to the interval, so that all distributors write the very same
+	// sample for a tenant and the ingesters deduplicate it, instead of rejecting it as out of order.
+	timestampMs := now.Truncate(d.cfg.HeartbeatSeriesInterval).UnixMilli()
+
+	_ = concurrency.ForEachUser(ctx, d.lastWrite.users(), heartbeatConcurrency, func(ctx context.Context, userID string) error {
+		if err := d.pushHeartbeatSeries(ctx, userID, timestampMs); err != nil {
+			level.Warn(d.log).Log("msg", "failed to write heartbeat series", "user", userID, "err", err)
+		}
+		return nil
+	})
+}
+
+// pushHeartbeatSeries writes the heartbeat series of the tenant straight to the ingesters.
+func (d *Distributor) pushHeartbeatSeries(ctx context.Context, userID string, timestampMs int64) error {
+	return d.pushInternalSeries(ctx, userID, []mimirpb.PreallocTimeseries{{
+		TimeSeries: &mimirpb.TimeSeries{
+			Labels:  []mimirpb.LabelAdapter{{Name: labels.MetricName, Value: HeartbeatSeriesName}},
+			Samples: []mimirpb.Sample{{TimestampMs: timestampMs, Value: 1}},
+		},
+	}})
+}
+
+// pushInternalSeries writes the series generated by the distributor straight to the ingesters. The push path
+// is skipped on purpose, so that the series aren't subject to the tenant's limits, aren't accounted in
+// the tenant's received samples and don't keep the tenant active.
+func (d *Distributor) pushInternalSeries(ctx context.Context, userID string, series []mimirpb.PreallocTimeseries) error {
+	keys := make([]uint32, 0, len(series))
+	for _, s := range series {
+		key, err := d.tokenForLabels(userID, s.Labels)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, key)
+	}
+
+	subRing := d.ingestersRing.ShuffleShard(userID, d.limits.IngestionTenantShardSize(userID))
+	subRing = d.excludeUnhealthyZones(subRing)
+
+	// The context is canceled once all ingesters have been called, which may be after DoBatch returns.
+	ctx, cancel := context.WithTimeout(user.InjectOrgID(ctx, userID), d.cfg.RemoteTimeout)
+
+	return ring.DoBatch(ctx, ring.WriteNoExtend, subRing, keys, func(ingester ring.InstanceDesc, indexes []int) error {
+		ingesterSeries := make([]mimirpb.PreallocTimeseries, 0, len(indexes))
+		for _, i := range indexes {
+			ingesterSeries = append(ingesterSeries, series[i])
+		}
+		return d.send(ctx, ingester, ingesterSeries, nil, mimirpb.API)
+	}, cancel)
+}
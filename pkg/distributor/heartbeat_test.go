@@ -0,0 +1,53 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/test"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+func TestDistributor_WriteHeartbeatSeries(t *testing.T) {
+	ds, ingesters, _ := prepare(t, prepConfig{
+		numIngesters:    3,
+		happyIngesters:  3,
+		numDistributors: 1,
+	})
+	ds[0].cfg.HeartbeatSeriesInterval = time.Minute
+
+	// Only the tenants which wrote samples through the distributor get the heartbeat series.
+	_, err := ds[0].Push(user.InjectOrgID(context.Background(), "user"), mockWriteRequest(labels.Labels{{Name: labels.MetricName, Value: "foo"}}, 1, 1000))
+	require.NoError(t, err)
+
+	ds[0].writeHeartbeatSeriesAt(context.Background(), time.UnixMilli(90_000))
+
+	heartbeatLabels := []mimirpb.LabelAdapter{{Name: labels.MetricName, Value: HeartbeatSeriesName}}
+	heartbeatSamples := func(userID string) func() interface{} {
+		return func() interface{} {
+			var samples [][]mimirpb.Sample
+			for i := range ingesters {
+				if s, ok := ingesters[i].series()[shardByAllLabels(userID, heartbeatLabels)]; ok {
+					samples = append(samples, s.Samples)
+				}
+			}
+			return samples
+		}
+	}
+
+	// The sample timestamp is aligned to the interval.
+	expected := []mimirpb.Sample{{TimestampMs: 60_000, Value: 1}}
+	test.Poll(t, time.Second, [][]mimirpb.Sample{expected, expected, expected}, heartbeatSamples("user"))
+	assert.Empty(t, heartbeatSamples("another")())
+
+	// The heartbeat series doesn't count as a write of the tenant.
+	assert.Equal(t, int64(1000), ds[0].lastWrite.get("user"))
+}
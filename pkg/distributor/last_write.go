@@ -0,0 +1,252 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/concurrency"
+	"github.com/grafana/dskit/grpcclient"
+	"github.com/grafana/dskit/ring"
+	ring_client "github.com/grafana/dskit/ring/client"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/tenant"
+	"github.com/grafana/mimir/pkg/util"
+	util_math "github.com/grafana/mimir/pkg/util/math"
+	"github.com/grafana/mimir/pkg/util/tlsreload"
+)
+
+const (
+	// lastWriteLocalParam is the query parameter used to only return the last write timestamp
+	// tracked by the distributor receiving the request, without querying the other distributors.
+	lastWriteLocalParam = "local"
+
+	// lastWriteConcurrency is the max number of distributors queried concurrently for the last write timestamp.
+	lastWriteConcurrency = 16
+)
+
+// lastWriteRingOp is the operation used to find the distributors to query for the last write timestamp.
+var lastWriteRingOp = ring.NewOp([]ring.InstanceState{ring.ACTIVE}, nil)
+
+// lastWriteTracker keeps, for each tenant, the timestamp of the most recent sample successfully
+// written to the ingesters through this distributor.
+type lastWriteTracker struct {
+	mtx     sync.Mutex
+	tenants map[string]int64
+}
+
+func newLastWriteTracker() *lastWriteTracker {
+	return &lastWriteTracker{tenants: map[string]int64{}}
+}
+
+// update records the input sample timestamp, if more recent than the one tracked for the tenant.
+func (t *lastWriteTracker) update(userID string, timestampMs int64) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if timestampMs > t.tenants[userID] {
+		t.tenants[userID] = timestampMs
+	}
+}
+
+// get returns the timestamp of the most recent sample written for the tenant, or 0 if none.
+func (t *lastWriteTracker) get(userID string) int64 {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	return t.tenants[userID]
+}
+
+// users returns the tenants which have written samples through this distributor.
+func (t *lastWriteTracker) users() []string {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	users := make([]string, 0, len(t.tenants))
+	for userID := range t.tenants {
+		users = append(users, userID)
+	}
+	return users
+}
+
+func (t *lastWriteTracker) deleteUser(userID string) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	delete(t.tenants, userID)
+}
+
+// latestSampleTimestamp returns the timestamp of the most recent sample in the input series, or 0 if none.
+func latestSampleTimestamp(series []mimirpb.PreallocTimeseries) int64 {
+	latest := int64(0)
+	for _, ts := range series {
+		for _, s := range ts.Samples {
+			latest = util_math.Max64(latest, s.TimestampMs)
+		}
+	}
+	return latest
+}
+
+// LastWriteResponse is the response of the last write API.
+type LastWriteResponse struct {
+	TenantID string `json:"tenant_id"`
+
+	// Timestamp of the most recent sample written by the tenant, or 0 if no sample
+	// has been written since the distributors started.
+	LatestSampleTimestampMs int64 `json:"latest_sample_timestamp_ms"`
+}
+
+// LastWriteHandler returns the timestamp of the most recent sample written by the tenant. The timestamp is
+// merged across all the distributors in the ring, unless the "local" query parameter is set to true.
+func (d *Distributor) LastWriteHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	latest := d.lastWrite.get(userID)
+
+	if d.distributorsRing != nil && r.URL.Query().Get(lastWriteLocalParam) != "true" {
+		remote, err := d.remoteLastWrite(r.Context(), userID, r.URL.Path)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		latest = util_math.Max64(latest, remote)
+	}
+
+	util.WriteJSONResponse(w, LastWriteResponse{TenantID: userID, LatestSampleTimestampMs: latest})
+}
+
+// remoteLastWrite queries the other distributors in the ring for their last write timestamp
+// of the tenant, and returns the most recent one.
+func (d *Distributor) remoteLastWrite(ctx context.Context, userID, path string) (int64, error) {
+	rs, err := d.distributorsRing.GetAllHealthy(lastWriteRingOp)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get healthy distributors")
+	}
+
+	var (
+		mtx    sync.Mutex
+		latest int64
+	)
+
+	ctx = user.InjectOrgID(ctx, userID)
+	req := &httpgrpc.HTTPRequest{
+		Method: http.MethodGet,
+		Url:    path + "?" + lastWriteLocalParam + "=true",
+		Headers: []*httpgrpc.Header{
+			{Key: http.CanonicalHeaderKey(user.OrgIDHeaderName), Values: []string{userID}},
+		},
+	}
+
+	err = concurrency.ForEachJob(ctx, len(rs.Instances), lastWriteConcurrency, func(ctx context.Context, idx int) error {
+		addr := rs.Instances[idx].Addr
+		if addr == d.distributorsLifeCycler.Addr {
+			return nil
+		}
+
+		c, err := d.distributorClients.GetClientFor(addr)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get client for distributor %s", addr)
+		}
+
+		resp, err := c.(httpgrpc.HTTPClient).Handle(ctx, req)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get last write from distributor %s", addr)
+		}
+		if resp.Code != http.StatusOK {
+			return errors.Errorf("failed to get last write from distributor %s: unexpected status code %d", addr, resp.Code)
+		}
+
+		res := LastWriteResponse{}
+		if err := json.Unmarshal(resp.Body, &res); err != nil {
+			return errors.Wrapf(err, "failed to decode last write from distributor %s", addr)
+		}
+
+		mtx.Lock()
+		latest = util_math.Max64(latest, res.LatestSampleTimestampMs)
+		mtx.Unlock()
+		return nil
+	})
+
+	return latest, err
+}
+
+// newDistributorClientPool makes a pool of clients to the other distributors, used to serve
+// the HTTP requests which are merged across distributors.
+func newDistributorClientPool(clientCfg grpcclient.Config, discovery ring_client.PoolServiceDiscovery, factory ring_client.PoolFactory, logger log.Logger, reg prometheus.Registerer) *ring_client.Pool {
+	// We prefer sane defaults instead of exposing further config options.
+	poolCfg := ring_client.PoolConfig{
+		CheckInterval:      time.Minute,
+		HealthCheckEnabled: true,
+		HealthCheckTimeout: 10 * time.Second,
+	}
+
+	if factory == nil {
+		factory = newDistributorClientFactory(clientCfg, reg)
+	}
+
+	clientsCount := promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+		Name: "cortex_distributor_distributor_clients",
+		Help: "The current number of distributor clients in the pool.",
+	})
+
+	return ring_client.NewPool("distributor", poolCfg, discovery, factory, clientsCount, logger)
+}
+
+func newDistributorClientFactory(clientCfg grpcclient.Config, reg prometheus.Registerer) ring_client.PoolFactory {
+	requestDuration := promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "cortex_distributor_client_request_duration_seconds",
+		Help:    "Time spent executing requests to other distributors.",
+		Buckets: prometheus.ExponentialBuckets(0.008, 4, 7),
+	}, []string{"operation", "status_code"})
+
+	return func(addr string) (ring_client.PoolClient, error) {
+		unary, stream := grpcclient.Instrument(requestDuration)
+		opts, err := tlsreload.GRPCDialOptions(clientCfg, unary, stream)
+		if err != nil {
+			return nil, err
+		}
+
+		conn, err := grpc.Dial(addr, opts...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to dial distributor %s", addr)
+		}
+
+		return &distributorClient{
+			HTTPClient:   httpgrpc.NewHTTPClient(conn),
+			HealthClient: grpc_health_v1.NewHealthClient(conn),
+			conn:         conn,
+		}, nil
+	}
+}
+
+// distributorClient is a client to another distributor, which serves HTTP requests over gRPC.
+type distributorClient struct {
+	httpgrpc.HTTPClient
+	grpc_health_v1.HealthClient
+	conn *grpc.ClientConn
+}
+
+func (c *distributorClient) Close() error {
+	return c.conn.Close()
+}
+
+func (c *distributorClient) String() string {
+	return c.conn.Target()
+}
@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+	httpgrpc_server "github.com/weaveworks/common/httpgrpc/server"
+	"github.com/weaveworks/common/middleware"
+	"github.com/weaveworks/common/user"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestLastWriteTracker(t *testing.T) {
+	tracker := newLastWriteTracker()
+	assert.Equal(t, int64(0), tracker.get("user-1"))
+
+	tracker.update("user-1", 2000)
+	tracker.update("user-1", 1000)
+	tracker.update("user-2", 3000)
+	assert.Equal(t, int64(2000), tracker.get("user-1"))
+	assert.Equal(t, int64(3000), tracker.get("user-2"))
+
+	tracker.deleteUser("user-1")
+	assert.Equal(t, int64(0), tracker.get("user-1"))
+	assert.Equal(t, int64(3000), tracker.get("user-2"))
+}
+
+func TestDistributor_LastWriteHandler(t *testing.T) {
+	ds, _, _ := prepare(t, prepConfig{
+		numIngesters:    3,
+		happyIngesters:  3,
+		numDistributors: 3,
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "user")
+	_, err := ds[0].Push(ctx, mockWriteRequest(labels.Labels{{Name: labels.MetricName, Value: "foo"}}, 1, 1000))
+	require.NoError(t, err)
+	_, err = ds[1].Push(ctx, mockWriteRequest(labels.Labels{{Name: labels.MetricName, Value: "foo"}}, 1, 3000))
+	require.NoError(t, err)
+	_, err = ds[2].Push(ctx, mockWriteRequest(labels.Labels{{Name: labels.MetricName, Value: "foo"}}, 1, 2000))
+	require.NoError(t, err)
+
+	getLastWrite := func(d *Distributor, userID, url string) LastWriteResponse {
+		rec := httptest.NewRecorder()
+		d.LastWriteHandler(rec, httptest.NewRequest(http.MethodGet, url, nil).WithContext(user.InjectOrgID(context.Background(), userID)))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		res := LastWriteResponse{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		return res
+	}
+
+	// The timestamp is merged across the distributors.
+	for _, d := range ds {
+		assert.Equal(t, LastWriteResponse{TenantID: "user", LatestSampleTimestampMs: 3000}, getLastWrite(d, "user", "/api/v1/last_write"))
+	}
+
+	// Only the timestamp tracked by the distributor receiving the request is returned if local.
+	assert.Equal(t, LastWriteResponse{TenantID: "user", LatestSampleTimestampMs: 1000}, getLastWrite(ds[0], "user", "/api/v1/last_write?local=true"))
+
+	// Tenants which haven't written any sample have no timestamp.
+	assert.Equal(t, LastWriteResponse{TenantID: "another"}, getLastWrite(ds[0], "another", "/api/v1/last_write"))
+}
+
+// mockDistributorClient serves the HTTP requests over gRPC sent to the distributor, like the server does.
+type mockDistributorClient struct {
+	server *httpgrpc_server.Server
+}
+
+func newMockDistributorClient(d *Distributor) *mockDistributorClient {
+	return &mockDistributorClient{
+		server: httpgrpc_server.NewServer(middleware.AuthenticateUser.Wrap(http.HandlerFunc(d.LastWriteHandler))),
+	}
+}
+
+func (c *mockDistributorClient) Handle(ctx context.Context, req *httpgrpc.HTTPRequest, _ ...grpc.CallOption) (*httpgrpc.HTTPResponse, error) {
+	return c.server.Handle(ctx, req)
+}
+
+func (c *mockDistributorClient) Check(context.Context, *grpc_health_v1.HealthCheckRequest, ...grpc.CallOption) (*grpc_health_v1.HealthCheckResponse, error) {
+	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+}
+
+func (c *mockDistributorClient) Watch(context.Context, *grpc_health_v1.HealthCheckRequest, ...grpc.CallOption) (grpc_health_v1.Health_WatchClient, error) {
+	return nil, nil
+}
+
+func (c *mockDistributorClient) Close() error {
+	return nil
+}
@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/concurrency"
+	"github.com/grafana/dskit/ring"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+const (
+	// LimitUtilizationSeriesName is the name of the series written by the distributors to each tenant, when enabled,
+	// reporting the ratio between the tenant's current usage and its limit.
+	LimitUtilizationSeriesName = "mimir_tenant_limit_utilization"
+
+	// limitUtilizationLabel is the label of the limit whose utilization is reported by the series.
+	limitUtilizationLabel = "limit"
+
+	maxGlobalSeriesPerUserLimit = "max_global_series_per_user"
+	ingestionRateLimit          = "ingestion_rate"
+
+	// limitsUtilizationConcurrency is the max number of tenants to which the limits utilization series are written concurrently.
+	limitsUtilizationConcurrency = 16
+)
+
+// writeLimitsUtilizationSeries writes the limits utilization series to each tenant with series in the ingesters.
+// Failures are logged and don't stop the distributor.
+func (d *Distributor) writeLimitsUtilizationSeries(ctx context.Context) error {
+	d.writeLimitsUtilizationSeriesAt(ctx, time.Now())
+	return nil
+}
+
+func (d *Distributor) writeLimitsUtilizationSeriesAt(ctx context.Context, now time.Time) {
+	stats, err := d.AllUserStats(ctx)
+	if err != nil {
+		level.Warn(d.log).Log("msg", "failed to get the tenants' stats to write the limits utilization series", "err", err)
+		return
+	}
+
+	timestampMs := now.Truncate(d.cfg.LimitsUtilizationSeriesInterval).UnixMilli()
+	replicationFactor := float64(d.ingestersRing.ReplicationFactor())
+
+	statsByUser := make(map[string]UserStats, len(stats))
+	userIDs := make([]string, 0, len(stats))
+	for _, s := range stats {
+		// The series of each tenant are written by a single distributor, so that the samples of
+		// the same series and timestamp written by different distributors don't conflict.
+		if !d.ownsLimitsUtilizationSeries(s.UserID) {
+			continue
+		}
+		statsByUser[s.UserID] = s.UserStats
+		userIDs = append(userIDs, s.UserID)
+	}
+
+	_ = concurrency.ForEachUser(ctx, userIDs, limitsUtilizationConcurrency, func(ctx context.Context, userID string) error {
+		stats := statsByUser[userID]
+
+		// The stats returned by AllUserStats() aren't divided by the replication factor.
+		var series []mimirpb.PreallocTimeseries
+		if limit := d.limits.MaxGlobalSeriesPerUser(userID); limit > 0 {
+			series = append(series, limitUtilizationSeries(maxGlobalSeriesPerUserLimit, timestampMs, float64(stats.NumSeries)/replicationFactor/float64(limit)))
+		}
+		if limit := d.limits.IngestionRate(userID); limit > 0 {
+			series = append(series, limitUtilizationSeries(ingestionRateLimit, timestampMs, stats.IngestionRate/replicationFactor/limit))
+		}
+		if len(series) == 0 {
+			return nil
+		}
+
+		if err := d.pushInternalSeries(ctx, userID, series); err != nil {
+			level.Warn(d.log).Log("msg", "failed to write limits utilization series", "user", userID, "err", err)
+		}
+		return nil
+	})
+}
+
+// ownsLimitsUtilizationSeries returns whether this distributor writes the limits utilization series of the tenant.
+func (d *Distributor) ownsLimitsUtilizationSeries(userID string) bool {
+	if d.distributorsRing == nil {
+		return true
+	}
+
+	rs, err := d.distributorsRing.Get(shardByUser(userID), ring.Read, nil, nil, nil)
+	if err != nil {
+		level.Warn(d.log).Log("msg", "failed to look up the distributor writing the limits utilization series", "user", userID, "err", err)
+		return false
+	}
+
+	for _, instance := range rs.Instances {
+		if instance.Addr == d.distributorsLifeCycler.Addr {
+			return true
+		}
+	}
+	return false
+}
+
+func limitUtilizationSeries(limit string, timestampMs int64, utilization float64) mimirpb.PreallocTimeseries {
+	return mimirpb.PreallocTimeseries{
+		TimeSeries: &mimirpb.TimeSeries{
+			Labels: []mimirpb.LabelAdapter{
+				{Name: labels.MetricName, Value: LimitUtilizationSeriesName},
+				{Name: limitUtilizationLabel, Value: limit},
+			},
+			Samples: []mimirpb.Sample{{TimestampMs: timestampMs, Value: utilization}},
+		},
+	}
+}
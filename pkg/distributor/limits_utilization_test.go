@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/test"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/grafana/mimir/pkg/ingester/client"
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestDistributor_WriteLimitsUtilizationSeries(t *testing.T) {
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.MaxGlobalSeriesPerUser = 100
+	limits.IngestionRate = 50
+
+	ds, ingesters, _ := prepare(t, prepConfig{
+		numIngesters:    3,
+		happyIngesters:  3,
+		numDistributors: 2,
+		limits:          limits,
+	})
+
+	// Each ingester holds all the series of the tenant, given the replication factor is 3.
+	for i := range ingesters {
+		ingesters[i].stats = client.UsersStatsResponse{Stats: []*client.UserIDStatsResponse{
+			{UserId: "user", Data: &client.UserStatsResponse{NumSeries: 30, IngestionRate: 10}},
+		}}
+	}
+
+	for _, d := range ds {
+		d.cfg.LimitsUtilizationSeriesInterval = time.Minute
+		d.writeLimitsUtilizationSeriesAt(context.Background(), time.UnixMilli(90_000))
+	}
+
+	utilizationSamples := func(limit string) func() interface{} {
+		lbls := []mimirpb.LabelAdapter{{Name: labels.MetricName, Value: LimitUtilizationSeriesName}, {Name: "limit", Value: limit}}
+		return func() interface{} {
+			var samples [][]mimirpb.Sample
+			for i := range ingesters {
+				if s, ok := ingesters[i].series()[shardByAllLabels("user", lbls)]; ok {
+					samples = append(samples, s.Samples)
+				}
+			}
+			return samples
+		}
+	}
+
+	// The series are written by a single distributor, with the sample timestamp aligned to the interval.
+	expected := []mimirpb.Sample{{TimestampMs: 60_000, Value: 0.3}}
+	test.Poll(t, time.Second, [][]mimirpb.Sample{expected, expected, expected}, utilizationSamples(maxGlobalSeriesPerUserLimit))
+
+	expected = []mimirpb.Sample{{TimestampMs: 60_000, Value: 0.2}}
+	test.Poll(t, time.Second, [][]mimirpb.Sample{expected, expected, expected}, utilizationSamples(ingestionRateLimit))
+
+	assert.Equal(t, 2, len(ingesters[0].series()))
+}
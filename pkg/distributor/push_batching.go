@@ -0,0 +1,312 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/dskit/ring"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+// pushBatchSendFunc sends a batched push request to an ingester.
+type pushBatchSendFunc func(ctx context.Context, ingester ring.InstanceDesc, timeseries []mimirpb.PreallocTimeseries, metadata []*mimirpb.MetricMetadata, source mimirpb.WriteRequest_SourceEnum) error
+
+// pushBatchKey identifies the requests which can be merged into the same push to an ingester.
+type pushBatchKey struct {
+	ingesterAddr string
+	userID       string
+	source       mimirpb.WriteRequest_SourceEnum
+}
+
+// pushBatch is a push request to an ingester, merging the series and metadata of multiple client requests.
+type pushBatch struct {
+	ingester   ring.InstanceDesc
+	timeseries []mimirpb.PreallocTimeseries
+	metadata   []*mimirpb.MetricMetadata
+	requests   []*pushBatchRequest
+	timer      *time.Timer
+	createdAt  time.Time
+
+	// seriesHashes are the hashes of the labels of the series in the batch.
+	seriesHashes map[uint64]struct{}
+
+	// ctx is the context the batch is sent with. It's canceled once the contexts of all the requests
+	// in the batch are done. Guarded by the mutex of the batcher shard, along with liveRequests and sent.
+	ctx          context.Context
+	cancel       context.CancelFunc
+	liveRequests int
+	sent         bool
+
+	// done is closed once the batch has been sent, and each request has got its outcome.
+	done   chan struct{}
+	sentAt time.Time
+}
+
+// pushBatchRequest is a client request merged into a batch: its series and metadata are the ones
+// in the given ranges of the batch.
+type pushBatchRequest struct {
+	seriesStart, seriesEnd     int
+	metadataStart, metadataEnd int
+	err                        error
+}
+
+// ingesterPushBatcherShards is the number of shards of the batcher. The batches are assigned to a shard
+// by tenant, so that the requests of different tenants don't contend on the same lock.
+const ingesterPushBatcherShards = 16
+
+// ingesterPushBatcher merges the requests pushed to the same ingester, for the same tenant, within a short
+// time window into a single push request, in order to reduce the per-request overhead on both the distributors
+// and the ingesters at high request rates. The callers are blocked until the batch they have been added to has
+// been sent, so the series buffers are retained until then.
+//
+// The requests of a batch never share a series: a request with a series already in the batch is added to a new
+// batch, while the current one is sent. This way, when the ingester rejects some samples of a batch with a client
+// error (4xx), the series the error is about identifies the request which sent them, and the error is only
+// returned to that request. The ingester has accepted the other samples of the batch, so the other requests
+// succeed. Errors which can't be tied to a series, like an ingester being unavailable or over its limits, are
+// returned to all the requests.
+type ingesterPushBatcher struct {
+	window        time.Duration
+	maxSeries     int
+	remoteTimeout time.Duration
+	send          pushBatchSendFunc
+
+	shards [ingesterPushBatcherShards]ingesterPushBatcherShard
+
+	batchRequests prometheus.Histogram
+	batchSeries   prometheus.Histogram
+	waitDuration  prometheus.Histogram
+}
+
+type ingesterPushBatcherShard struct {
+	mtx     sync.Mutex
+	batches map[pushBatchKey]*pushBatch
+}
+
+func newIngesterPushBatcher(window time.Duration, maxSeries int, remoteTimeout time.Duration, send pushBatchSendFunc, reg prometheus.Registerer) *ingesterPushBatcher {
+	b := &ingesterPushBatcher{
+		window:        window,
+		maxSeries:     maxSeries,
+		remoteTimeout: remoteTimeout,
+		send:          send,
+
+		batchRequests: promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
+			Name:    "cortex_distributor_ingester_push_batch_requests",
+			Help:    "Number of client requests merged into each batched push request sent to ingesters.",
+			Buckets: prometheus.ExponentialBuckets(1, 2, 10),
+		}),
+		batchSeries: promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
+			Name:    "cortex_distributor_ingester_push_batch_series",
+			Help:    "Number of series and metadata in each batched push request sent to ingesters.",
+			Buckets: prometheus.ExponentialBuckets(16, 4, 8),
+		}),
+		waitDuration: promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
+			Name:    "cortex_distributor_ingester_push_batch_wait_duration_seconds",
+			Help:    "Time spent by the client requests waiting for their batched push request to be sent to ingesters.",
+			Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1},
+		}),
+	}
+
+	for i := range b.shards {
+		b.shards[i].batches = map[pushBatchKey]*pushBatch{}
+	}
+	return b
+}
+
+func (b *ingesterPushBatcher) shardFor(userID string) *ingesterPushBatcherShard {
+	return &b.shards[shardByUser(userID)%ingesterPushBatcherShards]
+}
+
+// push adds the input series and metadata to the batch of the ingester, and waits until the batch has been sent.
+// It returns the outcome of the push for the input series and metadata. Once the input context is done, the
+// request doesn't keep the batch alive anymore: the batch is sent, or its sending canceled, when the contexts
+// of all its requests are done.
+func (b *ingesterPushBatcher) push(ctx context.Context, userID string, ingester ring.InstanceDesc, timeseries []mimirpb.PreallocTimeseries, metadata []*mimirpb.MetricMetadata, source mimirpb.WriteRequest_SourceEnum) error {
+	key := pushBatchKey{ingesterAddr: ingester.Addr, userID: userID, source: source}
+	shard := b.shardFor(userID)
+	start := time.Now()
+
+	hashes := make([]uint64, 0, len(timeseries))
+	for _, ts := range timeseries {
+		hashes = append(hashes, mimirpb.FromLabelAdaptersToLabels(ts.Labels).Hash())
+	}
+
+	shard.mtx.Lock()
+	batch, ok := shard.batches[key]
+	if ok && batch.hasAnySeries(hashes) {
+		// Send the current batch, so that the samples of the same series from different requests are pushed
+		// in different batches.
+		go b.flush(shard, key, batch)
+		ok = false
+	}
+	if !ok {
+		batch = b.newBatch(shard, key, ingester, start)
+		shard.batches[key] = batch
+	}
+	for _, h := range hashes {
+		batch.seriesHashes[h] = struct{}{}
+	}
+
+	req := &pushBatchRequest{
+		seriesStart:   len(batch.timeseries),
+		seriesEnd:     len(batch.timeseries) + len(timeseries),
+		metadataStart: len(batch.metadata),
+		metadataEnd:   len(batch.metadata) + len(metadata),
+	}
+	batch.timeseries = append(batch.timeseries, timeseries...)
+	batch.metadata = append(batch.metadata, metadata...)
+	batch.requests = append(batch.requests, req)
+	batch.liveRequests++
+
+	full := b.maxSeries > 0 && len(batch.timeseries)+len(batch.metadata) >= b.maxSeries
+	shard.mtx.Unlock()
+
+	if full {
+		// Sent asynchronously, like when the window ends, so that the request keeps watching its context.
+		go b.flush(shard, key, batch)
+	}
+
+	select {
+	case <-batch.done:
+	case <-ctx.Done():
+		shard.mtx.Lock()
+		batch.liveRequests--
+		if batch.liveRequests == 0 && batch.sent {
+			batch.cancel()
+		}
+		shard.mtx.Unlock()
+
+		// The series buffers are referenced by the batch until it has been sent.
+		<-batch.done
+	}
+
+	b.waitDuration.Observe(batch.sentAt.Sub(start).Seconds())
+	return req.err
+}
+
+func (b *ingesterPushBatcher) newBatch(shard *ingesterPushBatcherShard, key pushBatchKey, ingester ring.InstanceDesc, now time.Time) *pushBatch {
+	batch := &pushBatch{ingester: ingester, done: make(chan struct{}), createdAt: now, seriesHashes: map[uint64]struct{}{}}
+	batch.ctx, batch.cancel = context.WithTimeout(user.InjectOrgID(context.Background(), key.userID), b.remoteTimeout)
+	batch.timer = time.AfterFunc(b.window, func() { b.flush(shard, key, batch) })
+	return batch
+}
+
+// hasAnySeries returns whether any of the input series hashes is in the batch.
+func (batch *pushBatch) hasAnySeries(hashes []uint64) bool {
+	for _, h := range hashes {
+		if _, ok := batch.seriesHashes[h]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// PushBatchStatus is the state of a batched push request waiting to be sent to an ingester.
+type PushBatchStatus struct {
+	Ingester   string  `json:"ingester"`
+	Tenant     string  `json:"tenant"`
+	Source     string  `json:"source"`
+	Requests   int     `json:"requests"`
+	Series     int     `json:"series"`
+	Metadata   int     `json:"metadata"`
+	AgeSeconds float64 `json:"age_seconds"`
+}
+
+// status returns the state of the batches waiting to be sent, sorted by decreasing age.
+func (b *ingesterPushBatcher) status(now time.Time) []PushBatchStatus {
+	var res []PushBatchStatus
+	for i := range b.shards {
+		shard := &b.shards[i]
+
+		shard.mtx.Lock()
+		for key, batch := range shard.batches {
+			res = append(res, PushBatchStatus{
+				Ingester:   key.ingesterAddr,
+				Tenant:     key.userID,
+				Source:     key.source.String(),
+				Requests:   len(batch.requests),
+				Series:     len(batch.timeseries),
+				Metadata:   len(batch.metadata),
+				AgeSeconds: now.Sub(batch.createdAt).Seconds(),
+			})
+		}
+		shard.mtx.Unlock()
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].AgeSeconds > res[j].AgeSeconds
+	})
+	return res
+}
+
+// flush sends the batch, unless it has already been sent.
+func (b *ingesterPushBatcher) flush(shard *ingesterPushBatcherShard, key pushBatchKey, batch *pushBatch) {
+	shard.mtx.Lock()
+	if batch.sent {
+		shard.mtx.Unlock()
+		return
+	}
+	if shard.batches[key] == batch {
+		delete(shard.batches, key)
+	}
+	batch.timer.Stop()
+	batch.sent = true
+	if batch.liveRequests == 0 {
+		// All the requests have gone away before the batch was sent.
+		batch.cancel()
+	}
+	shard.mtx.Unlock()
+
+	defer batch.cancel()
+
+	b.batchRequests.Observe(float64(len(batch.requests)))
+	b.batchSeries.Observe(float64(len(batch.timeseries) + len(batch.metadata)))
+
+	batch.sentAt = time.Now()
+	err := b.send(batch.ctx, batch.ingester, batch.timeseries, batch.metadata, key.source)
+	var offending *pushBatchRequest
+	if len(batch.requests) > 1 && isClientError(err) {
+		offending = batch.offendingRequest(err)
+	}
+	for _, req := range batch.requests {
+		if offending == nil || offending == req {
+			req.err = err
+		}
+	}
+	close(batch.done)
+}
+
+// offendingRequest returns the request of the batch with the series the input ingester error is about, if any.
+// The ingester reports the labels of the series in the errors for the samples and exemplars it rejects.
+func (batch *pushBatch) offendingRequest(err error) *pushBatchRequest {
+	msg := err.Error()
+	for _, req := range batch.requests {
+		for _, ts := range batch.timeseries[req.seriesStart:req.seriesEnd] {
+			if strings.Contains(msg, mimirpb.FromLabelAdaptersToLabels(ts.Labels).String()) {
+				return req
+			}
+		}
+	}
+	return nil
+}
+
+// isClientError returns whether the input error is an httpgrpc error with a 4xx status code, other than 429
+// which is returned when the ingester or the tenant is over a rate limit, regardless of the request.
+func isClientError(err error) bool {
+	if err == nil {
+		return false
+	}
+	resp, ok := httpgrpc.HTTPResponseFromError(err)
+	return ok && resp.Code/100 == 4 && resp.Code != http.StatusTooManyRequests
+}
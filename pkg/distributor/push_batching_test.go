@@ -0,0 +1,413 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/ring"
+	"github.com/grafana/dskit/test"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+type pushedBatch struct {
+	ingester   string
+	userID     string
+	source     mimirpb.WriteRequest_SourceEnum
+	timeseries int
+	metadata   int
+}
+
+// mockBatchSender records the batched push requests, and fails the ones sent to the failing ingester.
+type mockBatchSender struct {
+	failingIngester string
+
+	mtx    sync.Mutex
+	pushed []pushedBatch
+}
+
+func (m *mockBatchSender) send(ctx context.Context, ingester ring.InstanceDesc, timeseries []mimirpb.PreallocTimeseries, metadata []*mimirpb.MetricMetadata, source mimirpb.WriteRequest_SourceEnum) error {
+	userID, err := user.ExtractOrgID(ctx)
+	if err != nil {
+		return err
+	}
+
+	m.mtx.Lock()
+	m.pushed = append(m.pushed, pushedBatch{ingester: ingester.Addr, userID: userID, source: source, timeseries: len(timeseries), metadata: len(metadata)})
+	m.mtx.Unlock()
+
+	if ingester.Addr == m.failingIngester {
+		return errors.New("push failed")
+	}
+	return nil
+}
+
+func TestIngesterPushBatcher(t *testing.T) {
+	// series returns series with unique labels, with a sample each.
+	var seriesID atomic.Int64
+	series := func(n int) []mimirpb.PreallocTimeseries {
+		res := make([]mimirpb.PreallocTimeseries, 0, n)
+		for i := 0; i < n; i++ {
+			res = append(res, newPushBatchSeries(fmt.Sprintf("series_%d", seriesID.Inc()), 1))
+		}
+		return res
+	}
+
+	t.Run("should merge the requests to the same ingester for the same tenant and source", func(t *testing.T) {
+		sender := &mockBatchSender{failingIngester: "ingester-2"}
+		reg := prometheus.NewPedanticRegistry()
+		b := newIngesterPushBatcher(100*time.Millisecond, 0, time.Second, sender.send, reg)
+
+		ingester1 := ring.InstanceDesc{Addr: "ingester-1"}
+		ingester2 := ring.InstanceDesc{Addr: "ingester-2"}
+
+		errs := map[string]error{}
+		errsMx := sync.Mutex{}
+		wg := sync.WaitGroup{}
+		push := func(name, userID string, ingester ring.InstanceDesc, numSeries, numMetadata int, source mimirpb.WriteRequest_SourceEnum) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := b.push(context.Background(), userID, ingester, series(numSeries), make([]*mimirpb.MetricMetadata, numMetadata), source)
+
+				errsMx.Lock()
+				errs[name] = err
+				errsMx.Unlock()
+			}()
+		}
+
+		push("a", "user-1", ingester1, 2, 0, mimirpb.API)
+		push("b", "user-1", ingester1, 3, 1, mimirpb.API)
+		push("c", "user-1", ingester1, 1, 0, mimirpb.RULE)
+		push("d", "user-2", ingester1, 1, 0, mimirpb.API)
+		push("e", "user-1", ingester2, 4, 0, mimirpb.API)
+		push("f", "user-1", ingester2, 1, 0, mimirpb.API)
+		wg.Wait()
+
+		assert.ElementsMatch(t, []pushedBatch{
+			{ingester: "ingester-1", userID: "user-1", source: mimirpb.API, timeseries: 5, metadata: 1},
+			{ingester: "ingester-1", userID: "user-1", source: mimirpb.RULE, timeseries: 1},
+			{ingester: "ingester-1", userID: "user-2", source: mimirpb.API, timeseries: 1},
+			{ingester: "ingester-2", userID: "user-1", source: mimirpb.API, timeseries: 5},
+		}, sender.pushed)
+
+		// The errors not caused by the requests are returned to all the requests of the batch.
+		assert.NoError(t, errs["a"])
+		assert.NoError(t, errs["b"])
+		assert.NoError(t, errs["c"])
+		assert.NoError(t, errs["d"])
+		assert.EqualError(t, errs["e"], "push failed")
+		assert.EqualError(t, errs["f"], "push failed")
+
+		assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP cortex_distributor_ingester_push_batch_requests Number of client requests merged into each batched push request sent to ingesters.
+			# TYPE cortex_distributor_ingester_push_batch_requests histogram
+			cortex_distributor_ingester_push_batch_requests_bucket{le="1"} 2
+			cortex_distributor_ingester_push_batch_requests_bucket{le="2"} 4
+			cortex_distributor_ingester_push_batch_requests_bucket{le="4"} 4
+			cortex_distributor_ingester_push_batch_requests_bucket{le="8"} 4
+			cortex_distributor_ingester_push_batch_requests_bucket{le="16"} 4
+			cortex_distributor_ingester_push_batch_requests_bucket{le="32"} 4
+			cortex_distributor_ingester_push_batch_requests_bucket{le="64"} 4
+			cortex_distributor_ingester_push_batch_requests_bucket{le="128"} 4
+			cortex_distributor_ingester_push_batch_requests_bucket{le="256"} 4
+			cortex_distributor_ingester_push_batch_requests_bucket{le="512"} 4
+			cortex_distributor_ingester_push_batch_requests_bucket{le="+Inf"} 4
+			cortex_distributor_ingester_push_batch_requests_sum 6
+			cortex_distributor_ingester_push_batch_requests_count 4
+		`), "cortex_distributor_ingester_push_batch_requests"))
+	})
+
+	t.Run("should send the batch as soon as it reaches the max number of series", func(t *testing.T) {
+		sender := &mockBatchSender{}
+		b := newIngesterPushBatcher(time.Hour, 3, time.Second, sender.send, nil)
+		ingester := ring.InstanceDesc{Addr: "ingester-1"}
+
+		wg := sync.WaitGroup{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, b.push(context.Background(), "user-1", ingester, series(1), nil, mimirpb.API))
+		}()
+
+		// Wait until the first request has been added to the batch.
+		require.Eventually(t, func() bool {
+			return len(pendingPushBatches(b)) == 1
+		}, time.Second, time.Millisecond)
+
+		assert.NoError(t, b.push(context.Background(), "user-1", ingester, series(2), nil, mimirpb.API))
+		wg.Wait()
+
+		assert.Equal(t, []pushedBatch{{ingester: "ingester-1", userID: "user-1", source: mimirpb.API, timeseries: 3}}, sender.pushed)
+		assert.Empty(t, pendingPushBatches(b))
+	})
+
+	t.Run("should return a client error only to the request with the series the error is about", func(t *testing.T) {
+		sender := &mockBatchSender{}
+		tsdb := newMockPushBatchTSDB()
+		send := func(ctx context.Context, ingester ring.InstanceDesc, timeseries []mimirpb.PreallocTimeseries, metadata []*mimirpb.MetricMetadata, source mimirpb.WriteRequest_SourceEnum) error {
+			require.NoError(t, sender.send(ctx, ingester, timeseries, metadata, source))
+			return tsdb.push(timeseries)
+		}
+		b := newIngesterPushBatcher(time.Hour, 3, time.Second, send, nil)
+		ingester := ring.InstanceDesc{Addr: "ingester-1"}
+
+		// The sample of the series "invalid" is out of order.
+		tsdb.lastTimestamps["invalid"] = 10
+
+		var errA error
+		wg := sync.WaitGroup{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errA = b.push(context.Background(), "user-1", ingester, series(1), nil, mimirpb.API)
+		}()
+
+		require.Eventually(t, func() bool {
+			return len(pendingPushBatches(b)) == 1
+		}, time.Second, time.Millisecond)
+
+		errB := b.push(context.Background(), "user-1", ingester, append(series(1), newPushBatchSeries("invalid", 1)), nil, mimirpb.API)
+		wg.Wait()
+
+		assert.NoError(t, errA)
+		assert.EqualError(t, errB, `rpc error: code = Code(400) desc = out of order sample for series {__name__="invalid"}`)
+
+		// The batch isn't re-sent once rejected.
+		assert.Equal(t, []pushedBatch{{ingester: "ingester-1", userID: "user-1", source: mimirpb.API, timeseries: 3}}, sender.pushed)
+		assert.Equal(t, 2, tsdb.samples)
+	})
+
+	t.Run("should push the requests with the same series in different batches", func(t *testing.T) {
+		sender := &mockBatchSender{}
+		tsdb := newMockPushBatchTSDB()
+		send := func(ctx context.Context, ingester ring.InstanceDesc, timeseries []mimirpb.PreallocTimeseries, metadata []*mimirpb.MetricMetadata, source mimirpb.WriteRequest_SourceEnum) error {
+			require.NoError(t, sender.send(ctx, ingester, timeseries, metadata, source))
+			return tsdb.push(timeseries)
+		}
+		b := newIngesterPushBatcher(100*time.Millisecond, 0, time.Second, send, nil)
+		ingester := ring.InstanceDesc{Addr: "ingester-1"}
+
+		var errA error
+		wg := sync.WaitGroup{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errA = b.push(context.Background(), "user-1", ingester, []mimirpb.PreallocTimeseries{newPushBatchSeries("same", 1), newPushBatchSeries("other", 1)}, nil, mimirpb.API)
+		}()
+
+		require.Eventually(t, func() bool {
+			return len(pendingPushBatches(b)) == 1
+		}, time.Second, time.Millisecond)
+
+		// The second request has a newer sample of the same series, so if both were pushed in the same batch
+		// and the batch re-sent, the sample of the first request would be rejected as out of order.
+		errB := b.push(context.Background(), "user-1", ingester, []mimirpb.PreallocTimeseries{newPushBatchSeries("same", 2)}, nil, mimirpb.API)
+		wg.Wait()
+
+		assert.NoError(t, errA)
+		assert.NoError(t, errB)
+		assert.Equal(t, []pushedBatch{
+			{ingester: "ingester-1", userID: "user-1", source: mimirpb.API, timeseries: 2},
+			{ingester: "ingester-1", userID: "user-1", source: mimirpb.API, timeseries: 1},
+		}, sender.pushed)
+		assert.Equal(t, 3, tsdb.samples)
+	})
+
+	t.Run("should cancel the push once the contexts of all the requests in the batch are done", func(t *testing.T) {
+		sent := make(chan struct{})
+		send := func(ctx context.Context, _ ring.InstanceDesc, _ []mimirpb.PreallocTimeseries, _ []*mimirpb.MetricMetadata, _ mimirpb.WriteRequest_SourceEnum) error {
+			close(sent)
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		b := newIngesterPushBatcher(time.Hour, 2, time.Minute, send, nil)
+		ingester := ring.InstanceDesc{Addr: "ingester-1"}
+
+		ctx1, cancel1 := context.WithCancel(context.Background())
+		ctx2, cancel2 := context.WithCancel(context.Background())
+		defer cancel2()
+
+		errs := make(chan error, 2)
+		go func() { errs <- b.push(ctx1, "user-1", ingester, series(1), nil, mimirpb.API) }()
+		require.Eventually(t, func() bool {
+			return len(pendingPushBatches(b)) == 1
+		}, time.Second, time.Millisecond)
+		go func() { errs <- b.push(ctx2, "user-1", ingester, series(1), nil, mimirpb.API) }()
+		<-sent
+
+		// The push is still in progress as long as one of the requests is waiting for it.
+		cancel1()
+		select {
+		case err := <-errs:
+			require.FailNow(t, "unexpected push outcome", "error: %v", err)
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		cancel2()
+		assert.ErrorIs(t, <-errs, context.Canceled)
+		assert.ErrorIs(t, <-errs, context.Canceled)
+	})
+
+	t.Run("should report the status of the batches waiting to be sent", func(t *testing.T) {
+		sender := &mockBatchSender{}
+		b := newIngesterPushBatcher(time.Hour, 0, time.Second, sender.send, nil)
+		assert.Empty(t, b.status(time.Now()))
+
+		wg := sync.WaitGroup{}
+		push := func(userID string, ingester ring.InstanceDesc, numSeries, numMetadata int) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, b.push(context.Background(), userID, ingester, series(numSeries), make([]*mimirpb.MetricMetadata, numMetadata), mimirpb.API))
+			}()
+
+			// Wait until the request has been added to the batch, to get a deterministic age.
+			require.Eventually(t, func() bool {
+				for _, s := range b.status(time.Now()) {
+					if s.Tenant == userID && s.Ingester == ingester.Addr && s.Series >= numSeries {
+						return true
+					}
+				}
+				return false
+			}, time.Second, time.Millisecond)
+		}
+
+		push("user-1", ring.InstanceDesc{Addr: "ingester-1"}, 2, 1)
+		push("user-1", ring.InstanceDesc{Addr: "ingester-1"}, 3, 0)
+		push("user-2", ring.InstanceDesc{Addr: "ingester-2"}, 1, 0)
+
+		status := b.status(time.Now().Add(time.Minute))
+		require.Len(t, status, 2)
+		assert.GreaterOrEqual(t, status[0].AgeSeconds, status[1].AgeSeconds)
+		assert.GreaterOrEqual(t, status[1].AgeSeconds, time.Minute.Seconds())
+
+		for i := range status {
+			status[i].AgeSeconds = 0
+		}
+		assert.Equal(t, []PushBatchStatus{
+			{Ingester: "ingester-1", Tenant: "user-1", Source: "API", Requests: 2, Series: 5, Metadata: 1},
+			{Ingester: "ingester-2", Tenant: "user-2", Source: "API", Requests: 1, Series: 1},
+		}, status)
+
+		// Send the pending batches.
+		for key, batch := range pendingPushBatches(b) {
+			b.flush(b.shardFor(key.userID), key, batch)
+		}
+		wg.Wait()
+
+		assert.Empty(t, b.status(time.Now()))
+	})
+}
+
+func newPushBatchSeries(name string, timestamp int64) mimirpb.PreallocTimeseries {
+	return mimirpb.PreallocTimeseries{TimeSeries: &mimirpb.TimeSeries{
+		Labels:  []mimirpb.LabelAdapter{{Name: labels.MetricName, Value: name}},
+		Samples: []mimirpb.Sample{{TimestampMs: timestamp, Value: 1}},
+	}}
+}
+
+// mockPushBatchTSDB appends the pushed samples like the ingester does: the valid samples are appended,
+// and the error of the first invalid sample is returned.
+type mockPushBatchTSDB struct {
+	mtx            sync.Mutex
+	lastTimestamps map[string]int64
+	samples        int
+}
+
+func newMockPushBatchTSDB() *mockPushBatchTSDB {
+	return &mockPushBatchTSDB{lastTimestamps: map[string]int64{}}
+}
+
+func (m *mockPushBatchTSDB) push(timeseries []mimirpb.PreallocTimeseries) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	var firstErr error
+	for _, ts := range timeseries {
+		lbls := mimirpb.FromLabelAdaptersToLabels(ts.Labels)
+		name := lbls.Get(labels.MetricName)
+		for _, s := range ts.Samples {
+			if last, ok := m.lastTimestamps[name]; ok && s.TimestampMs <= last {
+				if firstErr == nil {
+					firstErr = httpgrpc.Errorf(http.StatusBadRequest, "out of order sample for series %s", lbls.String())
+				}
+				continue
+			}
+			m.lastTimestamps[name] = s.TimestampMs
+			m.samples++
+		}
+	}
+	return firstErr
+}
+
+// pendingPushBatches returns the batches of the batcher waiting to be sent.
+func pendingPushBatches(b *ingesterPushBatcher) map[pushBatchKey]*pushBatch {
+	res := map[pushBatchKey]*pushBatch{}
+	for i := range b.shards {
+		b.shards[i].mtx.Lock()
+		for key, batch := range b.shards[i].batches {
+			res[key] = batch
+		}
+		b.shards[i].mtx.Unlock()
+	}
+	return res
+}
+
+func TestDistributor_Push_IngesterPushBatching(t *testing.T) {
+	const numRequests = 10
+
+	ds, ingesters, _ := prepare(t, prepConfig{
+		numIngesters:               3,
+		happyIngesters:             3,
+		numDistributors:            1,
+		ingesterPushBatchingWindow: 100 * time.Millisecond,
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "user")
+	now := time.Now().UnixMilli()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < numRequests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			req := mimirpb.ToWriteRequest(
+				[]labels.Labels{labels.FromStrings(labels.MetricName, "series", "idx", fmt.Sprint(i))},
+				[]mimirpb.Sample{{Value: float64(i), TimestampMs: now}},
+				nil,
+				nil,
+				mimirpb.API,
+			)
+			_, err := ds[0].Push(ctx, req)
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	// With a replication factor of 3, each ingester receives all the series with fewer push requests.
+	// The push to the slowest ingester may still be in progress once the write quorum has been reached.
+	for i := range ingesters {
+		test.Poll(t, time.Second, numRequests, func() interface{} {
+			return len(ingesters[i].series())
+		})
+		assert.Less(t, ingesters[i].countCalls("Push"), numRequests)
+	}
+}
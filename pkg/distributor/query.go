@@ -17,6 +17,8 @@ import (
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/weaveworks/common/instrument"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	ingester_client "github.com/grafana/mimir/pkg/ingester/client"
 	"github.com/grafana/mimir/pkg/mimirpb"
@@ -61,6 +63,7 @@ func (d *Distributor) QueryStream(ctx context.Context, from, to model.Time, matc
 		if err != nil {
 			return err
 		}
+		req.StreamingProtocolVersion = ingester_client.QueryStreamProtocolVersion
 
 		replicationSet, err := d.GetIngestersForQuery(ctx, matchers...)
 		if err != nil {
@@ -147,6 +150,8 @@ func mergeExemplarSets(a, b []mimirpb.Exemplar) []mimirpb.Exemplar {
 
 // queryIngestersExemplars queries the ingesters for exemplars.
 func (d *Distributor) queryIngestersExemplars(ctx context.Context, replicationSet ring.ReplicationSet, req *ingester_client.ExemplarQueryRequest) (*ingester_client.ExemplarQueryResponse, error) {
+	ctx = d.withQueryCompression(ctx)
+
 	// Fetch exemplars from multiple ingesters in parallel, using the replicationSet
 	// to deal with consistency.
 	results, err := replicationSet.Do(ctx, 0, func(ctx context.Context, ing *ring.InstanceDesc) (interface{}, error) {
@@ -155,7 +160,7 @@ func (d *Distributor) queryIngestersExemplars(ctx context.Context, replicationSe
 			return nil, err
 		}
 
-		resp, err := client.(ingester_client.IngesterClient).QueryExemplars(ctx, req)
+		resp, err := queryIngesterExemplars(ctx, client.(ingester_client.IngesterClient), req)
 		d.ingesterQueries.WithLabelValues(ing.Addr).Inc()
 		if err != nil {
 			d.ingesterQueryFailures.WithLabelValues(ing.Addr).Inc()
@@ -171,6 +176,67 @@ func (d *Distributor) queryIngestersExemplars(ctx context.Context, replicationSe
 	return mergeExemplarQueryResponses(results), nil
 }
 
+// queryIngesterExemplars fetches the exemplars from an ingester through the streaming API, falling back
+// to the unary API if the ingester doesn't support it yet (eg. during a rolling update).
+func queryIngesterExemplars(ctx context.Context, client ingester_client.IngesterClient, req *ingester_client.ExemplarQueryRequest) (*ingester_client.ExemplarQueryResponse, error) {
+	stream, err := client.QueryExemplarsStream(ctx, req)
+	if err != nil {
+		if isUnimplemented(err) {
+			return client.QueryExemplars(ctx, req)
+		}
+		return nil, err
+	}
+	defer stream.CloseSend() //nolint:errcheck
+
+	result := &ingester_client.ExemplarQueryResponse{}
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return result, nil
+		} else if err != nil {
+			if isUnimplemented(err) {
+				return client.QueryExemplars(ctx, req)
+			}
+			return nil, err
+		}
+
+		result.Timeseries = append(result.Timeseries, resp.Timeseries...)
+	}
+}
+
+// queryIngesterMetricsMetadata fetches the metadata from an ingester through the streaming API, falling back
+// to the unary API if the ingester doesn't support it yet (eg. during a rolling update).
+func queryIngesterMetricsMetadata(ctx context.Context, client ingester_client.IngesterClient, req *ingester_client.MetricsMetadataRequest) (*ingester_client.MetricsMetadataResponse, error) {
+	stream, err := client.MetricsMetadataStream(ctx, req)
+	if err != nil {
+		if isUnimplemented(err) {
+			return client.MetricsMetadata(ctx, req)
+		}
+		return nil, err
+	}
+	defer stream.CloseSend() //nolint:errcheck
+
+	result := &ingester_client.MetricsMetadataResponse{}
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return result, nil
+		} else if err != nil {
+			if isUnimplemented(err) {
+				return client.MetricsMetadata(ctx, req)
+			}
+			return nil, err
+		}
+
+		result.Metadata = append(result.Metadata, resp.Metadata...)
+	}
+}
+
+// isUnimplemented returns whether the error has been returned by an ingester which doesn't implement the RPC.
+func isUnimplemented(err error) bool {
+	return status.Code(err) == codes.Unimplemented
+}
+
 func mergeExemplarQueryResponses(results []interface{}) *ingester_client.ExemplarQueryResponse {
 	var keys []string
 	exemplarResults := make(map[string]mimirpb.TimeSeries)
@@ -203,6 +269,8 @@ func mergeExemplarQueryResponses(results []interface{}) *ingester_client.Exempla
 
 // queryIngesterStream queries the ingesters using the new streaming API.
 func (d *Distributor) queryIngesterStream(ctx context.Context, replicationSet ring.ReplicationSet, req *ingester_client.QueryRequest) (*ingester_client.QueryStreamResponse, error) {
+	ctx = d.withQueryCompression(ctx)
+
 	var (
 		queryLimiter = limiter.QueryLimiterFromContextWithFallback(ctx)
 		reqStats     = stats.FromContext(ctx)
@@ -249,14 +317,33 @@ func (d *Distributor) queryIngesterStream(ctx context.Context, replicationSet ri
 		}
 	}()
 
+	// When hedging is enabled, the extra ingesters of the replication set are only
+	// queried if the other ones are slower than the hedging delay, or fail.
+	var (
+		hedgingDelay time.Duration
+		hedged       map[string]struct{}
+	)
+	if d.queryHedger != nil {
+		if hedged = hedgedInstances(replicationSet); len(hedged) > 0 {
+			hedgingDelay = d.queryHedger.getDelay()
+		}
+	}
+
 	// Fetch samples from multiple ingesters, and send them to the results chan
-	_, err := replicationSet.Do(ctx, 0, func(ctx context.Context, ing *ring.InstanceDesc) (interface{}, error) {
+	_, err := replicationSet.Do(ctx, hedgingDelay, func(ctx context.Context, ing *ring.InstanceDesc) (interface{}, error) {
 		client, err := d.ingesterPool.GetClientFor(ing.Addr)
 		if err != nil {
 			return nil, err
 		}
 		d.ingesterQueries.WithLabelValues(ing.Addr).Inc()
 
+		if hedgingDelay > 0 {
+			if _, ok := hedged[ing.Addr]; ok {
+				d.queryHedger.hedgedRequests.Inc()
+			}
+		}
+		start := time.Now()
+
 		stream, err := client.(ingester_client.IngesterClient).QueryStream(ctx, req)
 		if err != nil {
 			d.ingesterQueryFailures.WithLabelValues(ing.Addr).Inc()
@@ -267,6 +354,9 @@ func (d *Distributor) queryIngesterStream(ctx context.Context, replicationSet ri
 		for {
 			resp, err := stream.Recv()
 			if err == io.EOF {
+				if d.queryHedger != nil {
+					d.queryHedger.observe(time.Since(start))
+				}
 				break
 			} else if err != nil {
 				// Do not track a failure if the context was canceled.
@@ -278,22 +368,26 @@ func (d *Distributor) queryIngesterStream(ctx context.Context, replicationSet ri
 			}
 
 			// Enforce the max chunks limits.
-			if chunkLimitErr := queryLimiter.AddChunks(resp.ChunksCount()); chunkLimitErr != nil {
+			if chunkLimitErr := queryLimiter.AddChunks(limiter.SourceIngesters, resp.ChunksCount()); chunkLimitErr != nil {
 				return nil, validation.LimitError(chunkLimitErr.Error())
 			}
 
 			for _, series := range resp.Chunkseries {
-				if limitErr := queryLimiter.AddSeries(series.Labels); limitErr != nil {
+				if limitErr := queryLimiter.AddSeries(limiter.SourceIngesters, series.Labels); limitErr != nil {
 					return nil, validation.LimitError(limitErr.Error())
 				}
 			}
 
-			if chunkBytesLimitErr := queryLimiter.AddChunkBytes(resp.ChunksSize()); chunkBytesLimitErr != nil {
+			if chunkBytesLimitErr := queryLimiter.AddChunkBytes(limiter.SourceIngesters, resp.ChunksSize()); chunkBytesLimitErr != nil {
 				return nil, validation.LimitError(chunkBytesLimitErr.Error())
 			}
 
+			if dataBytesLimitErr := queryLimiter.AddDataBytes(resp.Size()); dataBytesLimitErr != nil {
+				return nil, validation.LimitError(dataBytesLimitErr.Error())
+			}
+
 			for _, series := range resp.Timeseries {
-				if limitErr := queryLimiter.AddSeries(series.Labels); limitErr != nil {
+				if limitErr := queryLimiter.AddSeries(limiter.SourceIngesters, series.Labels); limitErr != nil {
 					return nil, validation.LimitError(limitErr.Error())
 				}
 			}
@@ -330,6 +424,9 @@ func (d *Distributor) queryIngesterStream(ctx context.Context, replicationSet ri
 	reqStats.AddFetchedSeries(uint64(len(resp.Chunkseries) + len(resp.Timeseries)))
 	reqStats.AddFetchedChunkBytes(uint64(resp.ChunksSize()))
 	reqStats.AddFetchedChunks(uint64(resp.ChunksCount()))
+	if reqStats != nil {
+		reqStats.UpdateSelectorPeakMemoryBytes(uint64(resp.Size()))
+	}
 
 	return resp, nil
 }
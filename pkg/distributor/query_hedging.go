@@ -0,0 +1,123 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grafana/dskit/ring"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	// Number of most recent ingester query latencies the hedging delay is computed from.
+	queryHedgingWindowSize = 1000
+
+	// Min number of observed latencies before hedging requests. Until then,
+	// all ingesters are queried at once.
+	queryHedgingMinObservations = 100
+
+	// How frequently the hedging delay is recomputed.
+	queryHedgingUpdateInterval = time.Second
+)
+
+// queryHedger tracks the latency of the queries to ingesters, in order to compute the delay
+// after which hedged requests are sent to the extra ingesters of a replication set.
+type queryHedger struct {
+	percentile float64
+	minDelay   time.Duration
+
+	mtx          sync.Mutex
+	latencies    []time.Duration // Ring buffer of the most recent latencies.
+	next         int
+	delay        time.Duration
+	delayUpdated time.Time
+
+	hedgedRequests prometheus.Counter
+	delayGauge     prometheus.Gauge
+}
+
+func newQueryHedger(percentile float64, minDelay time.Duration, reg prometheus.Registerer) *queryHedger {
+	return &queryHedger{
+		percentile: percentile,
+		minDelay:   minDelay,
+		latencies:  make([]time.Duration, 0, queryHedgingWindowSize),
+
+		hedgedRequests: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_distributor_ingester_query_hedged_requests_total",
+			Help: "The total number of hedged queries sent to ingesters because the other ingesters were slower than the hedging delay or failed.",
+		}),
+		delayGauge: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_distributor_ingester_query_hedging_delay_seconds",
+			Help: "The current delay after which hedged queries are sent to ingesters. 0 if not enough queries have been observed yet.",
+		}),
+	}
+}
+
+// observe records the latency of a successful query to an ingester.
+func (h *queryHedger) observe(latency time.Duration) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	if len(h.latencies) < cap(h.latencies) {
+		h.latencies = append(h.latencies, latency)
+		return
+	}
+
+	h.latencies[h.next] = latency
+	h.next = (h.next + 1) % len(h.latencies)
+}
+
+// getDelay returns the delay after which hedged requests should be sent,
+// or 0 if requests shouldn't be hedged.
+func (h *queryHedger) getDelay() time.Duration {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	if now := time.Now(); now.Sub(h.delayUpdated) >= queryHedgingUpdateInterval {
+		h.delay = h.computeDelay()
+		h.delayUpdated = now
+		h.delayGauge.Set(h.delay.Seconds())
+	}
+
+	return h.delay
+}
+
+func (h *queryHedger) computeDelay() time.Duration {
+	if len(h.latencies) < queryHedgingMinObservations {
+		return 0
+	}
+
+	sorted := make([]time.Duration, len(h.latencies))
+	copy(sorted, h.latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	idx := int(math.Ceil(h.percentile*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+
+	if sorted[idx] < h.minDelay {
+		return h.minDelay
+	}
+	return sorted[idx]
+}
+
+// hedgedInstances returns the addresses of the instances the replication set only
+// queries once the hedging delay has elapsed, or another instance has failed.
+func hedgedInstances(replicationSet ring.ReplicationSet) map[string]struct{} {
+	// Delayed requests are not supported by the replication set when zone-awareness is enabled.
+	if replicationSet.MaxUnavailableZones > 0 || replicationSet.MaxErrors <= 0 || replicationSet.MaxErrors >= len(replicationSet.Instances) {
+		return nil
+	}
+
+	addrs := make(map[string]struct{}, replicationSet.MaxErrors)
+	for _, instance := range replicationSet.Instances[len(replicationSet.Instances)-replicationSet.MaxErrors:] {
+		addrs[instance.Addr] = struct{}{}
+	}
+	return addrs
+}
@@ -0,0 +1,120 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/ring"
+	"github.com/grafana/dskit/test"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+)
+
+func TestQueryHedger_getDelay(t *testing.T) {
+	h := newQueryHedger(0.9, 10*time.Millisecond, nil)
+
+	// Requests are not hedged until enough latencies have been observed.
+	for i := 0; i < queryHedgingMinObservations-1; i++ {
+		h.observe(time.Second)
+	}
+	assert.Equal(t, time.Duration(0), h.getDelay())
+
+	// The delay is the configured percentile of the observed latencies.
+	h = newQueryHedger(0.9, 10*time.Millisecond, nil)
+	for i := 1; i <= queryHedgingMinObservations; i++ {
+		h.observe(time.Duration(i) * time.Second)
+	}
+	assert.Equal(t, 90*time.Second, h.getDelay())
+
+	// The delay is not lower than the min delay.
+	h = newQueryHedger(0.9, 10*time.Millisecond, nil)
+	for i := 0; i < queryHedgingMinObservations; i++ {
+		h.observe(time.Millisecond)
+	}
+	assert.Equal(t, 10*time.Millisecond, h.getDelay())
+
+	// Only the most recent latencies are taken into account.
+	for i := 0; i < queryHedgingWindowSize; i++ {
+		h.observe(time.Minute)
+	}
+	h.delayUpdated = time.Time{}
+	assert.Equal(t, time.Minute, h.getDelay())
+}
+
+func TestHedgedInstances(t *testing.T) {
+	instances := []ring.InstanceDesc{{Addr: "1"}, {Addr: "2"}, {Addr: "3"}}
+
+	assert.Equal(t, map[string]struct{}{"3": {}}, hedgedInstances(ring.ReplicationSet{Instances: instances, MaxErrors: 1}))
+	assert.Nil(t, hedgedInstances(ring.ReplicationSet{Instances: instances, MaxErrors: 0}))
+	assert.Nil(t, hedgedInstances(ring.ReplicationSet{Instances: instances, MaxUnavailableZones: 1}))
+}
+
+func TestDistributor_QueryStream_Hedging(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "user")
+	nameMatcher := mustEqualMatcher(model.MetricNameLabel, "foo")
+
+	tests := map[string]struct {
+		observedLatency         time.Duration
+		expectedQueried         int
+		expectedHedgedRequests  int
+		expectedHedgingDelaySec float64
+	}{
+		"should not query the extra ingester if the other ingesters respond before the hedging delay": {
+			observedLatency:         time.Minute,
+			expectedQueried:         2,
+			expectedHedgedRequests:  0,
+			expectedHedgingDelaySec: 60,
+		},
+		"should query the extra ingester if the other ingesters are slower than the hedging delay": {
+			observedLatency:         time.Millisecond,
+			expectedQueried:         3,
+			expectedHedgedRequests:  1,
+			expectedHedgingDelaySec: 0.01,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			ds, ingesters, regs := prepare(t, prepConfig{
+				numIngesters:    3,
+				happyIngesters:  3,
+				numDistributors: 1,
+				queryDelay:      100 * time.Millisecond,
+				queryHedging:    true,
+			})
+
+			for i := 0; i < queryHedgingMinObservations; i++ {
+				ds[0].queryHedger.observe(testData.observedLatency)
+			}
+
+			_, err := ds[0].QueryStream(ctx, 0, 10, nameMatcher)
+			require.NoError(t, err)
+
+			// The hedged request may still be running once the query returns.
+			test.Poll(t, time.Second, testData.expectedQueried, func() interface{} {
+				queried := 0
+				for i := range ingesters {
+					queried += ingesters[i].countCalls("QueryStream")
+				}
+				return queried
+			})
+
+			assert.NoError(t, testutil.GatherAndCompare(regs[0], strings.NewReader(`
+				# HELP cortex_distributor_ingester_query_hedged_requests_total The total number of hedged queries sent to ingesters because the other ingesters were slower than the hedging delay or failed.
+				# TYPE cortex_distributor_ingester_query_hedged_requests_total counter
+				cortex_distributor_ingester_query_hedged_requests_total `+strconv.Itoa(testData.expectedHedgedRequests)+`
+				# HELP cortex_distributor_ingester_query_hedging_delay_seconds The current delay after which hedged queries are sent to ingesters. 0 if not enough queries have been observed yet.
+				# TYPE cortex_distributor_ingester_query_hedging_delay_seconds gauge
+				cortex_distributor_ingester_query_hedging_delay_seconds `+strconv.FormatFloat(testData.expectedHedgingDelaySec, 'f', -1, 64)+`
+			`), "cortex_distributor_ingester_query_hedged_requests_total", "cortex_distributor_ingester_query_hedging_delay_seconds"))
+		})
+	}
+}
@@ -0,0 +1,147 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/tenant"
+	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/globalerror"
+)
+
+// unknownRejectionReason is the reason used for rejected series whose error has no error code.
+const unknownRejectionReason = "unknown"
+
+// rejectedSeries keeps, for each tenant and rejection reason, the most recent series
+// rejected by the distributor, so that tenants can find out which series are rejected
+// without access to the distributor logs. The rejection reason is the error code.
+type rejectedSeries struct {
+	capacity int
+
+	mtx     sync.Mutex
+	tenants map[string]map[string]*rejectedSeriesBuffer
+}
+
+func newRejectedSeries(capacity int) *rejectedSeries {
+	return &rejectedSeries{
+		capacity: capacity,
+		tenants:  map[string]map[string]*rejectedSeriesBuffer{},
+	}
+}
+
+// add records the series rejected with the input error. It's a no-op if the capture is disabled.
+func (r *rejectedSeries) add(userID string, series []mimirpb.LabelAdapter, err error, now time.Time) {
+	if r.capacity <= 0 {
+		return
+	}
+
+	msg := err.Error()
+	reason, ok := globalerror.CodeFromMessage(msg)
+	if !ok {
+		reason = unknownRejectionReason
+	}
+
+	// Format the series outside of the lock. The resulting string doesn't retain the series labels,
+	// which may be backed by a buffer reused after the request is done.
+	entry := RejectedSeriesEntry{
+		Series:    mimirpb.FromLabelAdaptersToLabels(series).String(),
+		Error:     msg,
+		Timestamp: now,
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	reasons, ok := r.tenants[userID]
+	if !ok {
+		reasons = map[string]*rejectedSeriesBuffer{}
+		r.tenants[userID] = reasons
+	}
+
+	buf, ok := reasons[reason]
+	if !ok {
+		buf = &rejectedSeriesBuffer{entries: make([]RejectedSeriesEntry, 0, r.capacity)}
+		reasons[reason] = buf
+	}
+
+	buf.add(entry)
+}
+
+// get returns the rejected series of the tenant, grouped by reason and sorted by reason.
+func (r *rejectedSeries) get(userID string) []RejectedSeriesReason {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	res := make([]RejectedSeriesReason, 0, len(r.tenants[userID]))
+	for reason, buf := range r.tenants[userID] {
+		res = append(res, RejectedSeriesReason{Reason: reason, Series: buf.list()})
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Reason < res[j].Reason
+	})
+	return res
+}
+
+func (r *rejectedSeries) deleteUser(userID string) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	delete(r.tenants, userID)
+}
+
+// rejectedSeriesBuffer is a ring buffer of rejected series, overwriting the oldest entry once full.
+type rejectedSeriesBuffer struct {
+	entries []RejectedSeriesEntry
+	next    int
+}
+
+func (b *rejectedSeriesBuffer) add(entry RejectedSeriesEntry) {
+	if len(b.entries) < cap(b.entries) {
+		b.entries = append(b.entries, entry)
+		return
+	}
+
+	b.entries[b.next] = entry
+	b.next = (b.next + 1) % len(b.entries)
+}
+
+// list returns a copy of the entries, from the oldest to the most recent one.
+func (b *rejectedSeriesBuffer) list() []RejectedSeriesEntry {
+	res := make([]RejectedSeriesEntry, 0, len(b.entries))
+	res = append(res, b.entries[b.next:]...)
+	return append(res, b.entries[:b.next]...)
+}
+
+// RejectedSeriesEntry is a series rejected by the distributor.
+type RejectedSeriesEntry struct {
+	Series    string    `json:"series"`
+	Error     string    `json:"error"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// RejectedSeriesReason holds the most recent series rejected for a reason.
+type RejectedSeriesReason struct {
+	Reason string                `json:"reason"`
+	Series []RejectedSeriesEntry `json:"series"`
+}
+
+type rejectedSeriesResponse struct {
+	Reasons []RejectedSeriesReason `json:"reasons"`
+}
+
+// RejectedSeriesHandler returns the most recent series of the tenant rejected by this distributor.
+func (d *Distributor) RejectedSeriesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	util.WriteJSONResponse(w, rejectedSeriesResponse{Reasons: d.rejectedSeries.get(userID)})
+}
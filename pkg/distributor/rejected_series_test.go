@@ -0,0 +1,103 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/globalerror"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestRejectedSeries(t *testing.T) {
+	now := time.Now()
+	series := func(i int) []mimirpb.LabelAdapter {
+		return []mimirpb.LabelAdapter{{Name: labels.MetricName, Value: fmt.Sprintf("series_%d", i)}}
+	}
+
+	t.Run("the capture is disabled with a zero capacity", func(t *testing.T) {
+		r := newRejectedSeries(0)
+		r.add("user-1", series(0), errors.New(globalerror.InvalidLabel.Message("invalid")), now)
+		assert.Empty(t, r.get("user-1"))
+	})
+
+	t.Run("the most recent series are kept for each tenant and reason", func(t *testing.T) {
+		r := newRejectedSeries(2)
+		for i := 0; i < 5; i++ {
+			r.add("user-1", series(i), errors.New(globalerror.MaxLabelNamesPerSeries.Message("too many labels")), now)
+		}
+		r.add("user-1", series(5), errors.New(globalerror.InvalidLabel.Message("invalid")), now)
+		r.add("user-1", series(6), errors.New("no error code"), now)
+		r.add("user-2", series(7), errors.New(globalerror.InvalidLabel.Message("invalid")), now)
+
+		res := r.get("user-1")
+		require.Len(t, res, 3)
+
+		assert.Equal(t, "err-mimir-label-invalid", res[0].Reason)
+		assert.Equal(t, []RejectedSeriesEntry{{Series: `{__name__="series_5"}`, Error: "invalid (err-mimir-label-invalid)", Timestamp: now}}, res[0].Series)
+
+		assert.Equal(t, "err-mimir-max-label-names-per-series", res[1].Reason)
+		require.Len(t, res[1].Series, 2)
+		assert.Equal(t, `{__name__="series_3"}`, res[1].Series[0].Series)
+		assert.Equal(t, `{__name__="series_4"}`, res[1].Series[1].Series)
+
+		assert.Equal(t, unknownRejectionReason, res[2].Reason)
+		assert.Len(t, res[2].Series, 1)
+
+		assert.Len(t, r.get("user-2"), 1)
+
+		r.deleteUser("user-1")
+		assert.Empty(t, r.get("user-1"))
+		assert.Len(t, r.get("user-2"), 1)
+	})
+}
+
+func TestDistributor_RejectedSeriesHandler(t *testing.T) {
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.MaxLabelNamesPerSeries = 2
+
+	ds, _, _ := prepare(t, prepConfig{
+		numIngesters:            3,
+		happyIngesters:          3,
+		numDistributors:         1,
+		limits:                  limits,
+		rejectedSeriesPerReason: 10,
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "user")
+	req := mockWriteRequest(labels.Labels{{Name: labels.MetricName, Value: "foo"}, {Name: "a", Value: "1"}, {Name: "b", Value: "2"}}, 1, time.Now().UnixMilli())
+	_, err := ds[0].Push(ctx, req)
+	require.Error(t, err)
+
+	rec := httptest.NewRecorder()
+	ds[0].RejectedSeriesHandler(rec, httptest.NewRequest(http.MethodGet, "/api/v1/rejected_series", nil).WithContext(ctx))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	res := rejectedSeriesResponse{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	require.Len(t, res.Reasons, 1)
+	assert.Equal(t, "err-mimir-max-label-names-per-series", res.Reasons[0].Reason)
+	require.Len(t, res.Reasons[0].Series, 1)
+	assert.Equal(t, `{__name__="foo", a="1", b="2"}`, res.Reasons[0].Series[0].Series)
+
+	// Other tenants can't see the rejected series.
+	rec = httptest.NewRecorder()
+	ds[0].RejectedSeriesHandler(rec, httptest.NewRequest(http.MethodGet, "/api/v1/rejected_series", nil).WithContext(user.InjectOrgID(context.Background(), "another")))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"reasons":[]}`, rec.Body.String())
+}
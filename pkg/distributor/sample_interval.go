@@ -0,0 +1,160 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+const (
+	// sampleIntervalStripes is the number of stripes the series of a tenant are split into, so that
+	// the concurrent pushes of the tenant's series don't contend on a single lock.
+	sampleIntervalStripes = 64
+
+	// sampleIntervalPurgePeriod is how often the idle series are removed from the tracker.
+	sampleIntervalPurgePeriod = time.Minute
+)
+
+// sampleIntervalTracker keeps, for each tenant, the timestamp of the last sample accepted for each
+// series through this distributor, to enforce the tenant's minimum sample interval.
+type sampleIntervalTracker struct {
+	mtx     sync.RWMutex
+	tenants map[string]*tenantSampleIntervals
+}
+
+type tenantSampleIntervals struct {
+	// The most recent interval used to filter the tenant's samples, used to purge the idle series.
+	intervalMs atomic.Int64
+
+	stripes [sampleIntervalStripes]sampleIntervalStripe
+}
+
+// sampleIntervalStripe holds the timestamp of the last accepted sample of a subset of the series of a tenant.
+type sampleIntervalStripe struct {
+	mtx    sync.Mutex
+	series map[uint64]int64
+}
+
+func newSampleIntervalTracker() *sampleIntervalTracker {
+	return &sampleIntervalTracker{tenants: map[string]*tenantSampleIntervals{}}
+}
+
+func (t *sampleIntervalTracker) tenant(userID string) *tenantSampleIntervals {
+	t.mtx.RLock()
+	tenant, ok := t.tenants[userID]
+	t.mtx.RUnlock()
+	if ok {
+		return tenant
+	}
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if tenant, ok = t.tenants[userID]; !ok {
+		tenant = &tenantSampleIntervals{}
+		for i := range tenant.stripes {
+			tenant.stripes[i].series = map[uint64]int64{}
+		}
+		t.tenants[userID] = tenant
+	}
+	return tenant
+}
+
+// filter removes from the input samples of a series the ones arriving closer than the interval to the
+// previous accepted sample, and returns the remaining samples along with the number of removed samples
+// and the timestamp of the first removed one. With the downsample policy, the first sample of each interval
+// aligned to the interval boundaries is kept instead. The samples not newer than the previous accepted one
+// are always kept, so that retried requests and out-of-order samples are handled by the ingesters as usual.
+// The input slice is never modified, because it may be shared with the forwarding request.
+func (t *sampleIntervalTracker) filter(userID string, seriesHash uint64, samples []mimirpb.Sample, interval time.Duration, policy string) ([]mimirpb.Sample, int, int64) {
+	intervalMs := interval.Milliseconds()
+	if intervalMs <= 0 || len(samples) == 0 {
+		return samples, 0, 0
+	}
+	downsample := policy == validation.MinSampleIntervalPolicyDownsample
+
+	tenant := t.tenant(userID)
+	tenant.intervalMs.Store(intervalMs)
+
+	stripe := &tenant.stripes[seriesHash%sampleIntervalStripes]
+	stripe.mtx.Lock()
+	defer stripe.mtx.Unlock()
+
+	var (
+		kept           []mimirpb.Sample
+		firstRemovedMs int64
+	)
+	last, seen := stripe.series[seriesHash]
+	for i, s := range samples {
+		tooFrequent := false
+		if seen && s.TimestampMs > last {
+			if downsample {
+				tooFrequent = s.TimestampMs/intervalMs == last/intervalMs
+			} else {
+				tooFrequent = s.TimestampMs-last < intervalMs
+			}
+		}
+
+		if tooFrequent {
+			if kept == nil {
+				kept = make([]mimirpb.Sample, i, len(samples)-1)
+				copy(kept, samples[:i])
+				firstRemovedMs = s.TimestampMs
+			}
+			continue
+		}
+
+		if kept != nil {
+			kept = append(kept, s)
+		}
+		if !seen || s.TimestampMs > last {
+			last, seen = s.TimestampMs, true
+		}
+	}
+	stripe.series[seriesHash] = last
+
+	if kept == nil {
+		return samples, 0, 0
+	}
+	return kept, len(samples) - len(kept), firstRemovedMs
+}
+
+// purge removes the series whose last accepted sample is older than the tenant's interval, because their
+// next sample can't be too close anymore. It's called periodically, and only locks one stripe at a time.
+func (t *sampleIntervalTracker) purge(now time.Time) {
+	t.mtx.RLock()
+	tenants := make([]*tenantSampleIntervals, 0, len(t.tenants))
+	for _, tenant := range t.tenants {
+		tenants = append(tenants, tenant)
+	}
+	t.mtx.RUnlock()
+
+	for _, tenant := range tenants {
+		minTimestampMs := now.UnixMilli() - tenant.intervalMs.Load()
+
+		for i := range tenant.stripes {
+			stripe := &tenant.stripes[i]
+
+			stripe.mtx.Lock()
+			for hash, last := range stripe.series {
+				if last < minTimestampMs {
+					delete(stripe.series, hash)
+				}
+			}
+			stripe.mtx.Unlock()
+		}
+	}
+}
+
+func (t *sampleIntervalTracker) deleteUser(userID string) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	delete(t.tenants, userID)
+}
@@ -0,0 +1,178 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/test"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/globalerror"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestSampleIntervalTracker(t *testing.T) {
+	samplesAt := func(timestamps ...int64) []mimirpb.Sample {
+		samples := make([]mimirpb.Sample, 0, len(timestamps))
+		for _, ts := range timestamps {
+			samples = append(samples, mimirpb.Sample{TimestampMs: ts})
+		}
+		return samples
+	}
+	timestampsOf := func(samples []mimirpb.Sample) []int64 {
+		timestamps := make([]int64, 0, len(samples))
+		for _, s := range samples {
+			timestamps = append(timestamps, s.TimestampMs)
+		}
+		return timestamps
+	}
+
+	for name, tc := range map[string]struct {
+		policy           string
+		requests         [][]int64
+		expectedKept     [][]int64
+		expectedRemoved  []int
+		expectedFirstRem []int64
+	}{
+		"reject": {
+			policy:           validation.MinSampleIntervalPolicyReject,
+			requests:         [][]int64{{10000, 11000, 15000, 20000}, {22000, 30000}, {30000, 25000}},
+			expectedKept:     [][]int64{{10000, 20000}, {30000}, {30000, 25000}},
+			expectedRemoved:  []int{2, 1, 0},
+			expectedFirstRem: []int64{11000, 22000, 0},
+		},
+		"downsample": {
+			policy:           validation.MinSampleIntervalPolicyDownsample,
+			requests:         [][]int64{{11000, 15000, 19999, 20000, 21000}, {29000, 31000}},
+			expectedKept:     [][]int64{{11000, 20000}, {31000}},
+			expectedRemoved:  []int{3, 1},
+			expectedFirstRem: []int64{15000, 29000},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			tracker := newSampleIntervalTracker()
+
+			for i, timestamps := range tc.requests {
+				input := samplesAt(timestamps...)
+				kept, removed, firstRemoved := tracker.filter("user", 1, input, 10*time.Second, tc.policy)
+				assert.Equal(t, tc.expectedKept[i], timestampsOf(kept))
+				assert.Equal(t, tc.expectedRemoved[i], removed)
+				assert.Equal(t, tc.expectedFirstRem[i], firstRemoved)
+
+				// The input samples are never modified.
+				assert.Equal(t, timestamps, timestampsOf(input))
+			}
+		})
+	}
+
+	t.Run("series and tenants are tracked separately", func(t *testing.T) {
+		tracker := newSampleIntervalTracker()
+
+		for _, series := range []struct {
+			userID string
+			hash   uint64
+		}{{"user-1", 1}, {"user-1", 2}, {"user-2", 1}} {
+			_, removed, _ := tracker.filter(series.userID, series.hash, samplesAt(10000), 10*time.Second, validation.MinSampleIntervalPolicyReject)
+			assert.Equal(t, 0, removed)
+		}
+
+		_, removed, _ := tracker.filter("user-1", 1, samplesAt(15000), 10*time.Second, validation.MinSampleIntervalPolicyReject)
+		assert.Equal(t, 1, removed)
+
+		tracker.deleteUser("user-1")
+		_, removed, _ = tracker.filter("user-1", 1, samplesAt(15000), 10*time.Second, validation.MinSampleIntervalPolicyReject)
+		assert.Equal(t, 0, removed)
+	})
+
+	t.Run("idle series are purged", func(t *testing.T) {
+		tracker := newSampleIntervalTracker()
+
+		tracker.filter("user", 1, samplesAt(10000), 10*time.Second, validation.MinSampleIntervalPolicyReject)
+		tracker.filter("user", 2, samplesAt(70000), 10*time.Second, validation.MinSampleIntervalPolicyReject)
+
+		tracker.purge(time.UnixMilli(75000))
+		_, removed, _ := tracker.filter("user", 1, samplesAt(15000), 10*time.Second, validation.MinSampleIntervalPolicyReject)
+		assert.Equal(t, 0, removed)
+		_, removed, _ = tracker.filter("user", 2, samplesAt(75000), 10*time.Second, validation.MinSampleIntervalPolicyReject)
+		assert.Equal(t, 1, removed)
+	})
+}
+
+func TestDistributor_Push_MinSampleInterval(t *testing.T) {
+	series := labels.Labels{{Name: labels.MetricName, Value: "foo"}}
+
+	// Align the samples to the interval boundaries, so that they're also kept when downsampling.
+	startMs := time.Now().Add(-time.Minute).UnixMilli()
+	startMs -= startMs % 10000
+
+	for name, tc := range map[string]struct {
+		policy    string
+		expectErr bool
+	}{
+		"reject":     {policy: validation.MinSampleIntervalPolicyReject, expectErr: true},
+		"downsample": {policy: validation.MinSampleIntervalPolicyDownsample},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// The discarded samples metric is global, so only its increase is checked.
+			userID := "user-" + name
+			ctx := user.InjectOrgID(context.Background(), userID)
+			discarded := validation.DiscardedSamples.WithLabelValues(validation.SampleTooFrequent, userID)
+			discardedBefore := testutil.ToFloat64(discarded)
+
+			var limits validation.Limits
+			flagext.DefaultValues(&limits)
+			limits.MinSampleInterval = model.Duration(10 * time.Second)
+			limits.MinSampleIntervalPolicy = tc.policy
+
+			ds, ingesters, _ := prepare(t, prepConfig{
+				numIngesters:    3,
+				happyIngesters:  3,
+				numDistributors: 1,
+				limits:          &limits,
+			})
+
+			_, err := ds[0].Push(ctx, mockWriteRequest(series, 1, startMs))
+			require.NoError(t, err)
+
+			_, err = ds[0].Push(ctx, mockWriteRequest(series, 2, startMs+1000))
+			if tc.expectErr {
+				resp, ok := httpgrpc.HTTPResponseFromError(err)
+				require.True(t, ok)
+				assert.Equal(t, int32(http.StatusBadRequest), resp.Code)
+				assert.Contains(t, string(resp.Body), globalerror.SampleTooFrequent.Code())
+			} else {
+				require.NoError(t, err)
+			}
+
+			_, err = ds[0].Push(ctx, mockWriteRequest(series, 3, startMs+10000))
+			require.NoError(t, err)
+
+			assert.Equal(t, float64(1), testutil.ToFloat64(discarded)-discardedBefore)
+
+			// The too frequent sample has been discarded. The pushes to the slowest ingester may complete later.
+			for i := range ingesters {
+				test.Poll(t, time.Second, []int64{startMs, startMs + 10000}, func() interface{} {
+					var timestamps []int64
+					for _, ts := range ingesters[i].series() {
+						for _, s := range ts.Samples {
+							timestamps = append(timestamps, s.TimestampMs)
+						}
+					}
+					return timestamps
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/dskit/ring"
+
+	"github.com/grafana/mimir/pkg/util"
+)
+
+// allStatesNoExtendOp is used to look up the whole replica set of a key, leaving the health
+// check of the instances to unhealthyZonesRing once the unhealthy zones have been excluded.
+var allStatesNoExtendOp = ring.NewOp([]ring.InstanceState{ring.ACTIVE, ring.LEAVING, ring.PENDING, ring.JOINING, ring.LEFT}, nil)
+
+// unhealthyZones returns the ingester zones the operator marked as unhealthy, either
+// through the runtime configuration or, if not set there, through the static configuration.
+func (d *Distributor) unhealthyZones() []string {
+	if d.cfg.UnhealthyZonesFn != nil {
+		if zones := d.cfg.UnhealthyZonesFn(); zones != nil {
+			return zones
+		}
+	}
+	return d.cfg.UnhealthyZones
+}
+
+// excludeUnhealthyZones returns a ring excluding the ingesters in the zones marked as unhealthy
+// from the write path, or the input ring if no zone is marked as unhealthy.
+func (d *Distributor) excludeUnhealthyZones(r ring.ReadRing) ring.ReadRing {
+	zones := d.unhealthyZones()
+	if len(zones) == 0 {
+		return r
+	}
+	return newUnhealthyZonesRing(r, zones)
+}
+
+// unhealthyZonesRing is a ring.ReadRing treating the zones marked as unhealthy as absent:
+// the ingesters in such zones don't receive writes and don't count towards the quorum, which
+// is computed on the replication factor reduced by the number of unhealthy zones in the replica set. For example,
+// with a replication factor of 3 and one unhealthy zone, a write succeeds when both ingesters
+// in the remaining zones succeed.
+type unhealthyZonesRing struct {
+	ring.ReadRing
+
+	zones map[string]struct{}
+}
+
+func newUnhealthyZonesRing(r ring.ReadRing, zones []string) *unhealthyZonesRing {
+	m := make(map[string]struct{}, len(zones))
+	for _, zone := range zones {
+		m[zone] = struct{}{}
+	}
+	return &unhealthyZonesRing{ReadRing: r, zones: m}
+}
+
+// Get implements ring.ReadRing.
+func (r *unhealthyZonesRing) Get(key uint32, op ring.Operation, bufDescs []ring.InstanceDesc, bufHosts, bufZones []string) (ring.ReplicationSet, error) {
+	// Look up the replica set without extending it and without filtering the instances by state:
+	// ingesters in unhealthy zones must neither grow the replica set nor make the quorum fail.
+	set, err := r.ReadRing.Get(key, allStatesNoExtendOp, bufDescs, bufHosts, bufZones)
+	if err != nil {
+		return ring.ReplicationSet{}, err
+	}
+
+	var unhealthy, excludedZones []string
+	instances := set.Instances[:0]
+	for _, instance := range set.Instances {
+		if _, ok := r.zones[instance.Zone]; ok {
+			if !util.StringsContain(excludedZones, instance.Zone) {
+				excludedZones = append(excludedZones, instance.Zone)
+			}
+			continue
+		}
+		if !op.IsInstanceInStateHealthy(instance.State) {
+			unhealthy = append(unhealthy, instance.Addr)
+			continue
+		}
+		instances = append(instances, instance)
+	}
+
+	// Only the zones found in the replica set reduce the replication factor, so that zones unknown
+	// to the ring (eg. a typo in the configuration) can't lower the quorum.
+	replicationFactor := r.ReadRing.ReplicationFactor() - len(excludedZones)
+	if replicationFactor < 1 {
+		replicationFactor = 1
+	}
+	minSuccess := (replicationFactor / 2) + 1
+
+	if len(instances) < minSuccess {
+		var unhealthyStr string
+		if len(unhealthy) > 0 {
+			unhealthyStr = fmt.Sprintf(" - unhealthy instances: %s", strings.Join(unhealthy, ","))
+		}
+		return ring.ReplicationSet{}, fmt.Errorf("at least %d live replicas required across the availability zones not marked as unhealthy, could only find %d%s", minSuccess, len(instances), unhealthyStr)
+	}
+
+	return ring.ReplicationSet{
+		Instances: instances,
+		MaxErrors: len(instances) - minSuccess,
+	}, nil
+}
+
+// ShuffleShard implements ring.ReadRing.
+func (r *unhealthyZonesRing) ShuffleShard(identifier string, size int) ring.ReadRing {
+	return &unhealthyZonesRing{ReadRing: r.ReadRing.ShuffleShard(identifier, size), zones: r.zones}
+}
+
+// ShuffleShardWithLookback implements ring.ReadRing.
+func (r *unhealthyZonesRing) ShuffleShardWithLookback(identifier string, size int, lookbackPeriod time.Duration, now time.Time) ring.ReadRing {
+	return &unhealthyZonesRing{ReadRing: r.ReadRing.ShuffleShardWithLookback(identifier, size, lookbackPeriod, now), zones: r.zones}
+}
+
+type unhealthyZonesResponse struct {
+	UnhealthyZones []string `json:"unhealthy_zones"`
+}
+
+// UnhealthyZonesHandler returns the ingester zones currently excluded from the write path.
+func (d *Distributor) UnhealthyZonesHandler(w http.ResponseWriter, _ *http.Request) {
+	zones := append([]string{}, d.unhealthyZones()...)
+	sort.Strings(zones)
+
+	util.WriteJSONResponse(w, unhealthyZonesResponse{UnhealthyZones: zones})
+}
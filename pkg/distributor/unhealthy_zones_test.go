@@ -0,0 +1,193 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/kv"
+	"github.com/grafana/dskit/kv/consul"
+	"github.com/grafana/dskit/ring"
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/ingester"
+)
+
+func TestUnhealthyZonesRing_Get(t *testing.T) {
+	zones := []string{"zone-a", "zone-b", "zone-c"}
+
+	tests := map[string]struct {
+		states            map[string]ring.InstanceState
+		unhealthyZones    []string
+		expectedInstances int
+		expectedMaxErrors int
+		expectedErr       bool
+	}{
+		"no unhealthy zone": {
+			expectedInstances: 3,
+			expectedMaxErrors: 1,
+		},
+		"one unhealthy zone": {
+			unhealthyZones:    []string{"zone-c"},
+			expectedInstances: 2,
+			expectedMaxErrors: 0,
+		},
+		"one unhealthy zone whose ingesters are leaving": {
+			states:            map[string]ring.InstanceState{"zone-c-0": ring.LEAVING, "zone-c-1": ring.LEAVING},
+			unhealthyZones:    []string{"zone-c"},
+			expectedInstances: 2,
+			expectedMaxErrors: 0,
+		},
+		"one unhealthy zone and an ingester leaving in another zone": {
+			states:         map[string]ring.InstanceState{"zone-a-0": ring.LEAVING, "zone-a-1": ring.LEAVING},
+			unhealthyZones: []string{"zone-c"},
+			expectedErr:    true,
+		},
+		"one unhealthy zone and a zone unknown to the ring": {
+			unhealthyZones:    []string{"zone-c", "zone-x"},
+			expectedInstances: 2,
+			expectedMaxErrors: 0,
+		},
+		"two unhealthy zones": {
+			unhealthyZones:    []string{"zone-b", "zone-c"},
+			expectedInstances: 1,
+			expectedMaxErrors: 0,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			descs := map[string]ring.InstanceDesc{}
+			for i := 0; i < 6; i++ {
+				zone := zones[i%len(zones)]
+				id := fmt.Sprintf("%s-%d", zone, i/len(zones))
+				state, ok := testData.states[id]
+				if !ok {
+					state = ring.ACTIVE
+				}
+				descs[id] = ring.InstanceDesc{
+					Addr:      id,
+					Zone:      zone,
+					State:     state,
+					Timestamp: time.Now().Unix(),
+					Tokens:    []uint32{uint32((math.MaxUint32 / 6) * i)},
+				}
+			}
+
+			r := newZoneAwareTestRing(t, descs)
+
+			var readRing ring.ReadRing = r
+			if len(testData.unhealthyZones) > 0 {
+				readRing = newUnhealthyZonesRing(r, testData.unhealthyZones)
+			}
+
+			for _, key := range []uint32{0, math.MaxUint32 / 3, math.MaxUint32 / 2} {
+				set, err := readRing.Get(key, ring.Write, nil, nil, nil)
+				if testData.expectedErr {
+					require.Error(t, err)
+					continue
+				}
+				require.NoError(t, err)
+				assert.Len(t, set.Instances, testData.expectedInstances)
+				assert.Equal(t, testData.expectedMaxErrors, set.MaxErrors)
+
+				for _, instance := range set.Instances {
+					assert.NotContains(t, testData.unhealthyZones, instance.Zone)
+				}
+			}
+		})
+	}
+}
+
+func TestUnhealthyZonesRing_ShuffleShard(t *testing.T) {
+	descs := map[string]ring.InstanceDesc{}
+	for i, zone := range []string{"zone-a", "zone-b", "zone-c"} {
+		descs[zone] = ring.InstanceDesc{
+			Addr:      zone,
+			Zone:      zone,
+			State:     ring.ACTIVE,
+			Timestamp: time.Now().Unix(),
+			Tokens:    []uint32{uint32((math.MaxUint32 / 3) * i)},
+		}
+	}
+
+	r := newUnhealthyZonesRing(newZoneAwareTestRing(t, descs), []string{"zone-b"})
+
+	subRing := r.ShuffleShard("user-1", 3)
+	require.IsType(t, &unhealthyZonesRing{}, subRing)
+
+	set, err := subRing.Get(0, ring.Write, nil, nil, nil)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"zone-a", "zone-c"}, set.GetAddresses())
+}
+
+func TestDistributor_UnhealthyZones(t *testing.T) {
+	d := &Distributor{cfg: Config{UnhealthyZones: []string{"zone-b", "zone-a"}}}
+
+	getZones := func() []string {
+		rec := httptest.NewRecorder()
+		d.UnhealthyZonesHandler(rec, httptest.NewRequest(http.MethodGet, "/distributor/unhealthy_zones", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		res := unhealthyZonesResponse{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		return res.UnhealthyZones
+	}
+
+	// The zones from the static configuration are used, unless overridden at runtime.
+	assert.Equal(t, []string{"zone-a", "zone-b"}, getZones())
+
+	var runtimeZones []string
+	d.cfg.UnhealthyZonesFn = func() []string { return runtimeZones }
+	assert.Equal(t, []string{"zone-a", "zone-b"}, getZones())
+
+	runtimeZones = []string{"zone-c"}
+	assert.Equal(t, []string{"zone-c"}, getZones())
+
+	// An empty list at runtime marks all zones as healthy again.
+	runtimeZones = []string{}
+	assert.Empty(t, getZones())
+
+	r := &mockReadRing{}
+	assert.Same(t, r, d.excludeUnhealthyZones(r))
+}
+
+type mockReadRing struct {
+	ring.ReadRing
+}
+
+func newZoneAwareTestRing(t *testing.T, descs map[string]ring.InstanceDesc) *ring.Ring {
+	kvStore, closer := consul.NewInMemoryClient(ring.GetCodec(), log.NewNopLogger(), nil)
+	t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+
+	require.NoError(t, kvStore.CAS(context.Background(), ingester.IngesterRingKey, func(_ interface{}) (interface{}, bool, error) {
+		return &ring.Desc{Ingesters: descs}, true, nil
+	}))
+
+	r, err := ring.New(ring.Config{
+		KVStore:              kv.Config{Mock: kvStore},
+		HeartbeatTimeout:     time.Minute,
+		ReplicationFactor:    3,
+		ZoneAwarenessEnabled: true,
+	}, ingester.IngesterRingKey, ingester.IngesterRingKey, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), r))
+	t.Cleanup(func() { r.StopAsync() })
+
+	test.Poll(t, time.Second, len(descs), func() interface{} {
+		return r.InstancesCount()
+	})
+
+	return r
+}
@@ -12,27 +12,39 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/concurrency"
+	"github.com/grafana/dskit/flagext"
 	"github.com/grafana/dskit/modules"
 	"github.com/grafana/dskit/services"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/grafana/mimir/pkg/ingester"
+	"github.com/grafana/mimir/pkg/util"
+	util_math "github.com/grafana/mimir/pkg/util/math"
 	"github.com/grafana/mimir/pkg/util/validation"
 )
 
 // Config for an Ingester.
 type Config struct {
-	ExitAfterFlush bool `yaml:"exit_after_flush" category:"advanced"`
+	ExitAfterFlush bool                   `yaml:"exit_after_flush" category:"advanced"`
+	DataDir        string                 `yaml:"data_dir" category:"experimental"`
+	Tenants        flagext.StringSliceCSV `yaml:"tenants" category:"experimental"`
+	Concurrency    int                    `yaml:"concurrency" category:"experimental"`
 }
 
 // RegisterFlags adds the flags required to config this to the given FlagSet
 func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&cfg.ExitAfterFlush, "flusher.exit-after-flush", true, "Stop after flush has finished. If false, process will keep running, doing nothing.")
+	f.StringVar(&cfg.DataDir, "flusher.data-dir", "", "Directory containing the TSDBs of the tenants to flush, for example the TSDB directory recovered from the disk of a dead ingester. If empty, -blocks-storage.tsdb.dir is used.")
+	f.Var(&cfg.Tenants, "flusher.tenants", "Comma separated list of tenants to flush. If empty, all the tenants found in the data directory are flushed.")
+	f.IntVar(&cfg.Concurrency, "flusher.concurrency", 1, "Number of tenants flushed concurrently.")
 }
 
 // Flusher is designed to be used as a job to flush the data from the WAL on disk.
-// Flusher works with both chunks-based and blocks-based ingesters.
+// Each tenant found on disk is flushed separately: its WAL is replayed, the head
+// is compacted into blocks and the blocks are shipped to the storage. A failure
+// flushing a tenant doesn't stop the flushing of the other tenants.
 type Flusher struct {
 	services.Service
 
@@ -41,6 +53,8 @@ type Flusher struct {
 	limits         *validation.Overrides
 	registerer     prometheus.Registerer
 	logger         log.Logger
+
+	progress *progress
 }
 
 const (
@@ -57,12 +71,17 @@ func New(
 	logger log.Logger,
 ) (*Flusher, error) {
 
+	if cfg.DataDir != "" {
+		ingesterConfig.BlocksStorageConfig.TSDB.Dir = cfg.DataDir
+	}
+
 	f := &Flusher{
 		cfg:            cfg,
 		ingesterConfig: ingesterConfig,
 		limits:         limits,
 		registerer:     registerer,
 		logger:         logger,
+		progress:       newProgress(),
 	}
 	f.Service = services.NewBasicService(nil, f.running, nil)
 	return f, nil
@@ -78,7 +97,7 @@ func (f *Flusher) running(ctx context.Context) error {
 		return errors.Wrap(err, "start and await running ingester")
 	}
 
-	ing.Flush()
+	flushErr := f.flush(ctx, ing)
 
 	// Sleeping to give a chance to Prometheus
 	// to collect the metrics.
@@ -89,6 +108,10 @@ func (f *Flusher) running(ctx context.Context) error {
 		return errors.Wrap(err, "stop and await terminated ingester")
 	}
 
+	if flushErr != nil {
+		return flushErr
+	}
+
 	if f.cfg.ExitAfterFlush {
 		return modules.ErrStopProcess
 	}
@@ -96,3 +119,44 @@ func (f *Flusher) running(ctx context.Context) error {
 	// Return normally -- this keeps Mimir running.
 	return nil
 }
+
+// flush flushes the TSDBs of all the tenants found on disk, and returns an error if the flushing of any tenant failed.
+func (f *Flusher) flush(ctx context.Context, ing *ingester.Ingester) error {
+	users, err := ing.LocalTSDBUsers()
+	if err != nil {
+		return errors.Wrap(err, "list TSDBs")
+	}
+
+	allowed := util.NewAllowedTenants(f.cfg.Tenants, nil)
+	filtered := users[:0]
+	for _, userID := range users {
+		if allowed.IsAllowed(userID) {
+			filtered = append(filtered, userID)
+		}
+	}
+	users = filtered
+
+	level.Info(f.logger).Log("msg", "starting to flush and ship TSDB blocks", "tenants", len(users), "dir", f.ingesterConfig.BlocksStorageConfig.TSDB.Dir)
+	f.progress.setPending(users)
+
+	_ = concurrency.ForEachUser(ctx, users, util_math.Max(f.cfg.Concurrency, 1), func(ctx context.Context, userID string) error {
+		f.progress.setFlushing(userID)
+
+		shipped, err := ing.FlushUser(ctx, userID)
+		if err != nil {
+			level.Error(f.logger).Log("msg", "failed to flush TSDB", "user", userID, "err", err)
+		}
+		f.progress.setFinished(userID, shipped, err)
+
+		// Do not stop flushing the other tenants.
+		return nil
+	})
+
+	summary := f.progress.summary()
+	level.Info(f.logger).Log("msg", "finished flushing and shipping TSDB blocks", "succeeded", summary.Succeeded, "failed", summary.Failed)
+
+	if summary.Failed > 0 {
+		return errors.Errorf("failed to flush the TSDB of %d tenants", summary.Failed)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package flusher
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grafana/mimir/pkg/util"
+)
+
+type tenantState string
+
+const (
+	tenantPending   tenantState = "pending"
+	tenantFlushing  tenantState = "flushing"
+	tenantSucceeded tenantState = "succeeded"
+	tenantFailed    tenantState = "failed"
+)
+
+// tenantProgress is the progress of the flushing of a tenant's TSDB.
+type tenantProgress struct {
+	Tenant        string      `json:"tenant"`
+	State         tenantState `json:"state"`
+	ShippedBlocks int         `json:"shipped_blocks"`
+	Error         string      `json:"error,omitempty"`
+	StartedAt     *time.Time  `json:"started_at,omitempty"`
+	FinishedAt    *time.Time  `json:"finished_at,omitempty"`
+}
+
+type progressSummary struct {
+	Pending   int `json:"pending"`
+	Flushing  int `json:"flushing"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+}
+
+type progressResponse struct {
+	Summary progressSummary  `json:"summary"`
+	Tenants []tenantProgress `json:"tenants"`
+}
+
+// progress tracks the progress of the flushing of all tenants.
+type progress struct {
+	mtx     sync.Mutex
+	tenants map[string]*tenantProgress
+}
+
+func newProgress() *progress {
+	return &progress{tenants: map[string]*tenantProgress{}}
+}
+
+func (p *progress) setPending(users []string) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	for _, userID := range users {
+		p.tenants[userID] = &tenantProgress{Tenant: userID, State: tenantPending}
+	}
+}
+
+func (p *progress) setFlushing(userID string) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	now := time.Now()
+	p.tenants[userID] = &tenantProgress{Tenant: userID, State: tenantFlushing, StartedAt: &now}
+}
+
+func (p *progress) setFinished(userID string, shippedBlocks int, err error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	t, ok := p.tenants[userID]
+	if !ok {
+		t = &tenantProgress{Tenant: userID}
+		p.tenants[userID] = t
+	}
+
+	now := time.Now()
+	t.FinishedAt = &now
+	t.ShippedBlocks = shippedBlocks
+	if err != nil {
+		t.State = tenantFailed
+		t.Error = err.Error()
+	} else {
+		t.State = tenantSucceeded
+	}
+}
+
+func (p *progress) summary() progressSummary {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	return p.summaryLocked()
+}
+
+func (p *progress) summaryLocked() progressSummary {
+	s := progressSummary{}
+	for _, t := range p.tenants {
+		switch t.State {
+		case tenantPending:
+			s.Pending++
+		case tenantFlushing:
+			s.Flushing++
+		case tenantSucceeded:
+			s.Succeeded++
+		case tenantFailed:
+			s.Failed++
+		}
+	}
+	return s
+}
+
+func (p *progress) response() progressResponse {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	res := progressResponse{
+		Summary: p.summaryLocked(),
+		Tenants: make([]tenantProgress, 0, len(p.tenants)),
+	}
+	for _, t := range p.tenants {
+		res.Tenants = append(res.Tenants, *t)
+	}
+	sort.Slice(res.Tenants, func(i, j int) bool {
+		return res.Tenants[i].Tenant < res.Tenants[j].Tenant
+	})
+
+	return res
+}
+
+// ProgressHandler returns the progress of the flushing of each tenant.
+func (f *Flusher) ProgressHandler(w http.ResponseWriter, _ *http.Request) {
+	util.WriteJSONResponse(w, f.progress.response())
+}
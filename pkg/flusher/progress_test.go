@@ -0,0 +1,54 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package flusher
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlusher_ProgressHandler(t *testing.T) {
+	f := &Flusher{progress: newProgress()}
+
+	f.progress.setPending([]string{"user-1", "user-2", "user-3", "user-4"})
+	f.progress.setFlushing("user-1")
+	f.progress.setFinished("user-1", 2, nil)
+	f.progress.setFlushing("user-2")
+	f.progress.setFinished("user-2", 0, errors.New("corrupted WAL"))
+	f.progress.setFlushing("user-3")
+
+	rec := httptest.NewRecorder()
+	f.ProgressHandler(rec, httptest.NewRequest(http.MethodGet, "/flusher/progress", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	res := progressResponse{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+
+	assert.Equal(t, progressSummary{Pending: 1, Flushing: 1, Succeeded: 1, Failed: 1}, res.Summary)
+	require.Len(t, res.Tenants, 4)
+
+	assert.Equal(t, "user-1", res.Tenants[0].Tenant)
+	assert.Equal(t, tenantSucceeded, res.Tenants[0].State)
+	assert.Equal(t, 2, res.Tenants[0].ShippedBlocks)
+	assert.NotNil(t, res.Tenants[0].StartedAt)
+	assert.NotNil(t, res.Tenants[0].FinishedAt)
+
+	assert.Equal(t, "user-2", res.Tenants[1].Tenant)
+	assert.Equal(t, tenantFailed, res.Tenants[1].State)
+	assert.Equal(t, "corrupted WAL", res.Tenants[1].Error)
+
+	assert.Equal(t, "user-3", res.Tenants[2].Tenant)
+	assert.Equal(t, tenantFlushing, res.Tenants[2].State)
+	assert.NotNil(t, res.Tenants[2].StartedAt)
+	assert.Nil(t, res.Tenants[2].FinishedAt)
+
+	assert.Equal(t, "user-4", res.Tenants[3].Tenant)
+	assert.Equal(t, tenantPending, res.Tenants[3].State)
+	assert.Nil(t, res.Tenants[3].StartedAt)
+}
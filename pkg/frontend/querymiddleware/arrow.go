@@ -0,0 +1,469 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/opentracing/opentracing-go"
+	otlog "github.com/opentracing/opentracing-go/log"
+	"github.com/prometheus/common/model"
+
+	apierror "github.com/grafana/mimir/pkg/api/error"
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+const (
+	// arrowStreamContentType is the media type of the Apache Arrow IPC streaming format.
+	arrowStreamContentType = "application/vnd.apache.arrow.stream"
+
+	// arrowMaxRowsPerRecordBatch is the max number of samples encoded in a single Arrow record batch.
+	arrowMaxRowsPerRecordBatch = 64 * 1024
+
+	arrowTimestampColumn = "timestamp"
+	arrowValueColumn     = "value"
+)
+
+// Values of the Arrow flatbuffers schema (see https://github.com/apache/arrow/tree/master/format).
+const (
+	arrowMetadataVersionV5 = int16(4)
+
+	arrowMessageHeaderSchema      = uint8(1)
+	arrowMessageHeaderRecordBatch = uint8(3)
+
+	arrowTypeFloatingPoint = uint8(3)
+	arrowTypeUtf8          = uint8(5)
+	arrowTypeTimestamp     = uint8(10)
+
+	arrowPrecisionDouble   = int16(2)
+	arrowTimeUnitMillisecs = int16(1)
+	arrowEndiannessLittle  = int16(0)
+)
+
+// acceptsArrow returns whether the client asked for the query result in the Apache Arrow IPC streaming format.
+func acceptsArrow(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaRange := range strings.Split(accept, ",") {
+			if mediaType, _, err := mime.ParseMediaType(mediaRange); err == nil && mediaType == arrowStreamContentType {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// encodeArrowResponse encodes a query result in the Apache Arrow IPC streaming format. The result is encoded in
+// long format: each sample is a row with the timestamp, the value and one nullable column per label name found
+// in the result. Rows are streamed to the client in record batches of up to arrowMaxRowsPerRecordBatch samples,
+// so that the whole encoded result is never buffered in memory.
+func encodeArrowResponse(ctx context.Context, res Response) (*http.Response, error) {
+	sp, _ := opentracing.StartSpanFromContext(ctx, "APIResponse.ToArrowHTTPResponse")
+	defer sp.Finish()
+
+	a, ok := res.(*PrometheusResponse)
+	if !ok {
+		return nil, apierror.Newf(apierror.TypeInternal, "invalid response format")
+	}
+
+	var series []SampleStream
+	if a.Data != nil {
+		if a.Data.ResultType == model.ValString.String() {
+			return nil, apierror.Newf(apierror.TypeBadData, "string results can't be encoded as %s", arrowStreamContentType)
+		}
+		series = a.Data.Result
+		sp.LogFields(otlog.Int("series", len(series)))
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		_ = pw.CloseWithError(writeArrowStream(pw, series))
+	}()
+
+	return &http.Response{
+		Header: http.Header{
+			"Content-Type": []string{arrowStreamContentType},
+		},
+		Body:          pr,
+		StatusCode:    http.StatusOK,
+		ContentLength: -1,
+	}, nil
+}
+
+// writeArrowStream writes the schema, the record batches and the end-of-stream marker of the series to w.
+func writeArrowStream(w io.Writer, series []SampleStream) error {
+	labelNames := arrowLabelNames(series)
+
+	if err := writeArrowMessage(w, arrowSchemaMessage(labelNames), nil); err != nil {
+		return err
+	}
+
+	batch := arrowRecordBatchBuilder{labelNames: labelNames}
+	for _, s := range series {
+		batch.startSeries(s.Labels)
+
+		for _, sample := range s.Samples {
+			batch.append(sample)
+
+			if batch.rows == arrowMaxRowsPerRecordBatch {
+				if err := batch.flush(w); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if batch.rows > 0 {
+		if err := batch.flush(w); err != nil {
+			return err
+		}
+	}
+
+	// End-of-stream marker: continuation token followed by a zero metadata length.
+	_, err := w.Write([]byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0})
+	return err
+}
+
+// arrowLabelNames returns the sorted union of the label names of the input series.
+func arrowLabelNames(series []SampleStream) []string {
+	unique := map[string]struct{}{}
+	for _, s := range series {
+		for _, l := range s.Labels {
+			unique[l.Name] = struct{}{}
+		}
+	}
+
+	names := make([]string, 0, len(unique))
+	for name := range unique {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func arrowSchemaMessage(labelNames []string) fbTable {
+	fields := make([]fbTable, 0, 2+len(labelNames))
+	fields = append(fields,
+		arrowField(arrowTimestampColumn, false, arrowTypeTimestamp, fbTable{arrowTimeUnitMillisecs, "UTC"}),
+		arrowField(arrowValueColumn, false, arrowTypeFloatingPoint, fbTable{arrowPrecisionDouble}),
+	)
+	for _, name := range labelNames {
+		fields = append(fields, arrowField(name, true, arrowTypeUtf8, fbTable{}))
+	}
+
+	schema := fbTable{arrowEndiannessLittle, fields}
+	return fbTable{arrowMetadataVersionV5, arrowMessageHeaderSchema, schema, int64(0)}
+}
+
+func arrowField(name string, nullable bool, typeType uint8, typ fbTable) fbTable {
+	// Fields: name, nullable, type_type, type, dictionary, children.
+	return fbTable{name, nullable, typeType, typ, nil, []fbTable{}}
+}
+
+// writeArrowMessage writes an encapsulated Arrow IPC message: the continuation token, the length of the
+// flatbuffers metadata, the metadata itself padded to 8 bytes and the message body.
+func writeArrowMessage(w io.Writer, message fbTable, body []byte) error {
+	metadata := encodeFlatbuffer(message)
+	for len(metadata)%8 != 0 {
+		metadata = append(metadata, 0)
+	}
+
+	prefix := make([]byte, 8)
+	binary.LittleEndian.PutUint32(prefix[0:], 0xffffffff)
+	binary.LittleEndian.PutUint32(prefix[4:], uint32(len(metadata)))
+
+	for _, b := range [][]byte{prefix, metadata, body} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// arrowRecordBatchBuilder accumulates samples into the columns of an Arrow record batch.
+type arrowRecordBatchBuilder struct {
+	labelNames []string
+	columns    map[string]int
+
+	// Value of each label column for the current series, and whether the current series has the label.
+	seriesValues  []string
+	seriesPresent []bool
+
+	rows       int
+	timestamps []byte
+	values     []byte
+	labels     []arrowUtf8Column
+}
+
+type arrowUtf8Column struct {
+	validity []byte
+	nulls    int
+	offsets  []byte
+	data     []byte
+}
+
+func (b *arrowRecordBatchBuilder) startSeries(lbls []mimirpb.LabelAdapter) {
+	if b.columns == nil {
+		b.columns = make(map[string]int, len(b.labelNames))
+		for col, name := range b.labelNames {
+			b.columns[name] = col
+		}
+		b.seriesValues = make([]string, len(b.labelNames))
+		b.seriesPresent = make([]bool, len(b.labelNames))
+	}
+
+	for col := range b.labelNames {
+		b.seriesValues[col], b.seriesPresent[col] = "", false
+	}
+	for _, l := range lbls {
+		col := b.columns[l.Name]
+		b.seriesValues[col], b.seriesPresent[col] = l.Value, true
+	}
+}
+
+func (b *arrowRecordBatchBuilder) append(sample mimirpb.Sample) {
+	if b.rows == 0 {
+		b.reset()
+	}
+
+	b.timestamps = appendUint64(b.timestamps, uint64(sample.TimestampMs))
+	b.values = appendUint64(b.values, math.Float64bits(sample.Value))
+
+	for col := range b.labels {
+		c := &b.labels[col]
+		if b.rows%8 == 0 {
+			c.validity = append(c.validity, 0)
+		}
+		if b.seriesPresent[col] {
+			c.validity[b.rows/8] |= 1 << (b.rows % 8)
+			c.data = append(c.data, b.seriesValues[col]...)
+		} else {
+			c.nulls++
+		}
+		c.offsets = appendUint32(c.offsets, uint32(len(c.data)))
+	}
+
+	b.rows++
+}
+
+func (b *arrowRecordBatchBuilder) reset() {
+	b.timestamps = b.timestamps[:0]
+	b.values = b.values[:0]
+
+	if b.labels == nil {
+		b.labels = make([]arrowUtf8Column, len(b.labelNames))
+	}
+	for col := range b.labels {
+		c := &b.labels[col]
+		c.validity = c.validity[:0]
+		c.nulls = 0
+		c.data = c.data[:0]
+		// The offsets buffer has one more entry than the number of rows.
+		c.offsets = appendUint32(c.offsets[:0], 0)
+	}
+}
+
+// flush writes the accumulated samples to w as a record batch message and resets the builder.
+func (b *arrowRecordBatchBuilder) flush(w io.Writer) error {
+	var (
+		body    []byte
+		nodes   []byte
+		buffers []byte
+	)
+
+	addBuffer := func(buf []byte) {
+		buffers = appendUint64(buffers, uint64(len(body)))
+		buffers = appendUint64(buffers, uint64(len(buf)))
+		body = append(body, buf...)
+		for len(body)%8 != 0 {
+			body = append(body, 0)
+		}
+	}
+	addNode := func(nulls int) {
+		nodes = appendUint64(nodes, uint64(b.rows))
+		nodes = appendUint64(nodes, uint64(nulls))
+	}
+
+	// The timestamp and value columns are not nullable, so they have an empty validity buffer.
+	for _, data := range [][]byte{b.timestamps, b.values} {
+		addNode(0)
+		addBuffer(nil)
+		addBuffer(data)
+	}
+	for _, c := range b.labels {
+		addNode(c.nulls)
+		if c.nulls == 0 {
+			addBuffer(nil)
+		} else {
+			addBuffer(c.validity)
+		}
+		addBuffer(c.offsets)
+		addBuffer(c.data)
+	}
+
+	// RecordBatch fields: length, nodes, buffers.
+	batch := fbTable{int64(b.rows), fbStructVector(nodes), fbStructVector(buffers)}
+	message := fbTable{arrowMetadataVersionV5, arrowMessageHeaderRecordBatch, batch, int64(len(body))}
+
+	b.rows = 0
+	return writeArrowMessage(w, message, body)
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	return appendUint32(appendUint32(b, uint32(v)), uint32(v>>32))
+}
+
+// fbTable is a flatbuffers table, holding the value of each field by field ID. A nil value means the field
+// is not set. Supported values are uint8, int16, int64, bool, string, fbTable, []fbTable and fbStructVector.
+type fbTable []interface{}
+
+// fbStructVector is a flatbuffers vector of structs made of 8 bytes aligned fields, holding
+// the encoded structs. Each struct is 16 bytes long, as all the structs used by Arrow messages.
+type fbStructVector []byte
+
+// encodeFlatbuffer encodes root as a flatbuffer. The buffer is written front to back: each table is preceded
+// by its vtable and followed by its children, so that offsets to children are always positive.
+func encodeFlatbuffer(root fbTable) []byte {
+	b := &fbBuilder{buf: make([]byte, 4, 256)}
+	binary.LittleEndian.PutUint32(b.buf, uint32(b.writeTable(root)))
+	return b.buf
+}
+
+type fbBuilder struct {
+	buf []byte
+}
+
+// alignTo pads the buffer until its length is congruent to offset modulo alignment.
+func (b *fbBuilder) alignTo(alignment, offset int) {
+	for len(b.buf)%alignment != offset {
+		b.buf = append(b.buf, 0)
+	}
+}
+
+// patchOffset sets the uoffset at position pos to point to target.
+func (b *fbBuilder) patchOffset(pos, target int) {
+	binary.LittleEndian.PutUint32(b.buf[pos:], uint32(target-pos))
+}
+
+func fbInlineSize(v interface{}) int {
+	switch v.(type) {
+	case int64:
+		return 8
+	case int16:
+		return 2
+	case uint8, bool:
+		return 1
+	case nil:
+		return 0
+	default:
+		// Offset to a string, a table or a vector.
+		return 4
+	}
+}
+
+func (b *fbBuilder) writeTable(t fbTable) int {
+	// Lay out the inline fields after the soffset to the vtable, largest first so that they're all aligned.
+	fieldOffsets := make([]int, len(t))
+	tableSize := 4
+	for _, size := range []int{8, 4, 2, 1} {
+		for id, v := range t {
+			if fbInlineSize(v) == size {
+				fieldOffsets[id] = tableSize
+				tableSize += size
+			}
+		}
+	}
+
+	b.alignTo(2, 0)
+	vtablePos := len(b.buf)
+	b.buf = appendUint16(b.buf, uint16(4+2*len(t)))
+	b.buf = appendUint16(b.buf, uint16(tableSize))
+	for _, off := range fieldOffsets {
+		b.buf = appendUint16(b.buf, uint16(off))
+	}
+
+	// The table starts 4 bytes before an 8 bytes boundary, so that 8 bytes fields are aligned.
+	b.alignTo(8, 4)
+	tablePos := len(b.buf)
+	b.buf = append(b.buf, make([]byte, tableSize)...)
+	binary.LittleEndian.PutUint32(b.buf[tablePos:], uint32(tablePos-vtablePos))
+
+	for id, v := range t {
+		pos := tablePos + fieldOffsets[id]
+
+		switch v := v.(type) {
+		case int64:
+			binary.LittleEndian.PutUint64(b.buf[pos:], uint64(v))
+		case int16:
+			binary.LittleEndian.PutUint16(b.buf[pos:], uint16(v))
+		case uint8:
+			b.buf[pos] = v
+		case bool:
+			if v {
+				b.buf[pos] = 1
+			}
+		}
+	}
+
+	// Write the children after the table.
+	for id, v := range t {
+		pos := tablePos + fieldOffsets[id]
+
+		switch v := v.(type) {
+		case string:
+			b.patchOffset(pos, b.writeString(v))
+		case fbTable:
+			b.patchOffset(pos, b.writeTable(v))
+		case []fbTable:
+			b.patchOffset(pos, b.writeTableVector(v))
+		case fbStructVector:
+			b.patchOffset(pos, b.writeStructVector(v))
+		}
+	}
+
+	return tablePos
+}
+
+func (b *fbBuilder) writeString(s string) int {
+	b.alignTo(4, 0)
+	pos := len(b.buf)
+	b.buf = appendUint32(b.buf, uint32(len(s)))
+	b.buf = append(b.buf, s...)
+	b.buf = append(b.buf, 0)
+	return pos
+}
+
+func (b *fbBuilder) writeTableVector(tables []fbTable) int {
+	b.alignTo(4, 0)
+	pos := len(b.buf)
+	b.buf = appendUint32(b.buf, uint32(len(tables)))
+	b.buf = append(b.buf, make([]byte, 4*len(tables))...)
+
+	for i, t := range tables {
+		elemPos := pos + 4 + 4*i
+		b.patchOffset(elemPos, b.writeTable(t))
+	}
+	return pos
+}
+
+func (b *fbBuilder) writeStructVector(structs fbStructVector) int {
+	// The vector length is followed by the structs, which must be 8 bytes aligned.
+	b.alignTo(8, 4)
+	pos := len(b.buf)
+	b.buf = appendUint32(b.buf, uint32(len(structs)/16))
+	b.buf = append(b.buf, structs...)
+	return pos
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v), byte(v>>8))
+}
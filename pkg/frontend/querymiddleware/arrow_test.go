@@ -0,0 +1,376 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	apierror "github.com/grafana/mimir/pkg/api/error"
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+func TestAcceptsArrow(t *testing.T) {
+	for accept, expected := range map[string]bool{
+		"":                                    false,
+		"application/json":                    false,
+		"application/vnd.apache.arrow.stream": true,
+		"application/json;q=0.5, application/vnd.apache.arrow.stream": true,
+		"application/vnd.apache.arrow.file":                           false,
+	} {
+		r, err := http.NewRequest("GET", "/api/v1/query_range", nil)
+		require.NoError(t, err)
+		if accept != "" {
+			r.Header.Set("Accept", accept)
+		}
+
+		assert.Equal(t, expected, acceptsArrow(r), accept)
+	}
+}
+
+func TestEncodeArrowResponse(t *testing.T) {
+	series := []SampleStream{
+		{
+			Labels:  []mimirpb.LabelAdapter{{Name: "__name__", Value: "up"}, {Name: "job", Value: "api"}},
+			Samples: []mimirpb.Sample{{TimestampMs: 1000, Value: 1}, {TimestampMs: 2000, Value: 0}},
+		},
+		{
+			Labels:  []mimirpb.LabelAdapter{{Name: "instance", Value: "host-1"}, {Name: "__name__", Value: "up"}},
+			Samples: []mimirpb.Sample{{TimestampMs: 1000, Value: math.Inf(1)}},
+		},
+	}
+
+	rows := decodeArrowStreamFromResponse(t, &PrometheusResponse{
+		Status: statusSuccess,
+		Data:   &PrometheusData{ResultType: "matrix", Result: series},
+	})
+
+	assert.Equal(t, []arrowTestRow{
+		{timestamp: 1000, value: 1, labels: map[string]string{"__name__": "up", "job": "api"}},
+		{timestamp: 2000, value: 0, labels: map[string]string{"__name__": "up", "job": "api"}},
+		{timestamp: 1000, value: math.Inf(1), labels: map[string]string{"__name__": "up", "instance": "host-1"}},
+	}, rows)
+}
+
+func TestEncodeArrowResponse_ShouldSplitLargeResultsInMultipleRecordBatches(t *testing.T) {
+	samples := make([]mimirpb.Sample, arrowMaxRowsPerRecordBatch+10)
+	for i := range samples {
+		samples[i] = mimirpb.Sample{TimestampMs: int64(i), Value: float64(i)}
+	}
+
+	resp, err := encodeArrowResponse(context.Background(), &PrometheusResponse{
+		Status: statusSuccess,
+		Data: &PrometheusData{ResultType: "matrix", Result: []SampleStream{
+			{Labels: []mimirpb.LabelAdapter{{Name: "series", Value: "1"}}, Samples: samples[:10]},
+			{Labels: []mimirpb.LabelAdapter{{Name: "series", Value: "2"}}, Samples: samples[10:]},
+		}},
+	})
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	schema, batches := decodeArrowStream(t, body)
+	require.Len(t, batches, 2)
+	assert.Equal(t, arrowMaxRowsPerRecordBatch, int(batches[0].int64(0)))
+	assert.Equal(t, 10, int(batches[1].int64(0)))
+
+	rows := decodeArrowRows(t, body, schema, batches)
+	require.Len(t, rows, len(samples))
+	for i, row := range rows {
+		expectedSeries := "1"
+		if i >= 10 {
+			expectedSeries = "2"
+		}
+		require.Equal(t, arrowTestRow{timestamp: int64(i), value: float64(i), labels: map[string]string{"series": expectedSeries}}, row)
+	}
+}
+
+func TestEncodeArrowResponse_EmptyResult(t *testing.T) {
+	rows := decodeArrowStreamFromResponse(t, &PrometheusResponse{
+		Status: statusSuccess,
+		Data:   &PrometheusData{ResultType: "vector", Result: []SampleStream{}},
+	})
+	assert.Empty(t, rows)
+}
+
+func TestEncodeArrowResponse_ShouldRejectStringResults(t *testing.T) {
+	_, err := encodeArrowResponse(context.Background(), &PrometheusResponse{
+		Status: statusSuccess,
+		Data:   &PrometheusData{ResultType: "string", Result: []SampleStream{{Labels: []mimirpb.LabelAdapter{{Name: "value", Value: "foo"}}}}},
+	})
+	require.Error(t, err)
+
+	resp, ok := apierror.HTTPResponseFromError(err)
+	require.True(t, ok)
+	assert.Equal(t, int32(http.StatusBadRequest), resp.Code)
+}
+
+func TestLimitedRoundTripper_ShouldEncodeTheResponseAsArrowIfRequested(t *testing.T) {
+	downstream := RoundTripFunc(func(_ *http.Request) (*http.Response, error) {
+		return &http.Response{Body: http.NoBody}, nil
+	})
+	middleware := MiddlewareFunc(func(next Handler) Handler {
+		return HandlerFunc(func(context.Context, Request) (Response, error) {
+			return &PrometheusResponse{
+				Status: statusSuccess,
+				Data: &PrometheusData{ResultType: "matrix", Result: []SampleStream{
+					{Labels: []mimirpb.LabelAdapter{{Name: "__name__", Value: "up"}}, Samples: []mimirpb.Sample{{TimestampMs: 1000, Value: 1}}},
+				}},
+			}, nil
+		})
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+	r, err := PrometheusCodec.EncodeRequest(ctx, &PrometheusRangeQueryRequest{
+		Path:  "/api/v1/query_range",
+		Start: 0,
+		End:   time.Minute.Milliseconds(),
+		Step:  time.Minute.Milliseconds(),
+		Query: "up",
+	})
+	require.NoError(t, err)
+	r = r.WithContext(ctx)
+
+	rt := newLimitedParallelismRoundTripper(downstream, PrometheusCodec, mockLimits{maxQueryParallelism: 1}, middleware)
+
+	resp, err := rt.RoundTrip(r)
+	require.NoError(t, err)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	r.Header.Set("Accept", arrowStreamContentType)
+	resp, err = rt.RoundTrip(r)
+	require.NoError(t, err)
+	assert.Equal(t, arrowStreamContentType, resp.Header.Get("Content-Type"))
+
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	schema, batches := decodeArrowStream(t, body)
+	assert.Equal(t, []arrowTestRow{{timestamp: 1000, value: 1, labels: map[string]string{"__name__": "up"}}}, decodeArrowRows(t, body, schema, batches))
+}
+
+type arrowTestRow struct {
+	timestamp int64
+	value     float64
+	labels    map[string]string
+}
+
+func decodeArrowStreamFromResponse(t *testing.T, res Response) []arrowTestRow {
+	resp, err := encodeArrowResponse(context.Background(), res)
+	require.NoError(t, err)
+	assert.Equal(t, arrowStreamContentType, resp.Header.Get("Content-Type"))
+
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	schema, batches := decodeArrowStream(t, body)
+	return decodeArrowRows(t, body, schema, batches)
+}
+
+// arrowTestRecordBatch is a decoded record batch, along with the position of its body in the stream.
+type arrowTestRecordBatch struct {
+	fbTestTable
+	bodyPos int
+}
+
+// decodeArrowStream decodes the messages of an Arrow IPC stream, checking their framing, and returns
+// the schema and the record batches.
+func decodeArrowStream(t *testing.T, stream []byte) (fbTestTable, []arrowTestRecordBatch) {
+	var (
+		schema  fbTestTable
+		batches []arrowTestRecordBatch
+		pos     = 0
+	)
+
+	for {
+		require.Equal(t, 0, pos%8, "messages must be 8 bytes aligned")
+		require.Equal(t, uint32(0xffffffff), binary.LittleEndian.Uint32(stream[pos:]))
+		metadataLen := int(binary.LittleEndian.Uint32(stream[pos+4:]))
+		pos += 8
+
+		if metadataLen == 0 {
+			require.Equal(t, len(stream), pos, "end-of-stream marker must be the last message")
+			break
+		}
+		require.Equal(t, 0, metadataLen%8)
+
+		message := fbTestRoot(t, stream[pos:pos+metadataLen])
+		pos += metadataLen
+		assert.Equal(t, arrowMetadataVersionV5, message.int16(0))
+
+		bodyLen := int(message.int64(3))
+		switch message.uint8(1) {
+		case arrowMessageHeaderSchema:
+			require.Nil(t, schema.buf, "the schema must be sent once")
+			require.Zero(t, bodyLen)
+			schema = message.table(2)
+		case arrowMessageHeaderRecordBatch:
+			require.NotNil(t, schema.buf, "the schema must be sent before the record batches")
+			batches = append(batches, arrowTestRecordBatch{fbTestTable: message.table(2), bodyPos: pos})
+		default:
+			require.Fail(t, "unexpected message header type")
+		}
+		pos += bodyLen
+	}
+
+	require.NotNil(t, schema.buf)
+	return schema, batches
+}
+
+func decodeArrowRows(t *testing.T, stream []byte, schema fbTestTable, batches []arrowTestRecordBatch) []arrowTestRow {
+	fields := schema.tableVector(1)
+	require.GreaterOrEqual(t, len(fields), 2)
+	assert.Equal(t, arrowEndiannessLittle, schema.int16(0))
+
+	assert.Equal(t, "timestamp", fields[0].string(0))
+	assert.Equal(t, arrowTypeTimestamp, fields[0].uint8(2))
+	assert.Equal(t, arrowTimeUnitMillisecs, fields[0].table(3).int16(0))
+	assert.Equal(t, "UTC", fields[0].table(3).string(1))
+	assert.Equal(t, "value", fields[1].string(0))
+	assert.Equal(t, arrowTypeFloatingPoint, fields[1].uint8(2))
+	assert.Equal(t, arrowPrecisionDouble, fields[1].table(3).int16(0))
+	for _, f := range fields[2:] {
+		assert.Equal(t, arrowTypeUtf8, f.uint8(2))
+		assert.True(t, f.bool(1))
+	}
+	for _, f := range fields {
+		assert.Empty(t, f.tableVector(5))
+	}
+
+	rows := []arrowTestRow{}
+	for _, batch := range batches {
+		numRows := int(batch.int64(0))
+		nodes := batch.structVector(1)
+		buffers := batch.structVector(2)
+		require.Len(t, nodes, len(fields))
+		require.Len(t, buffers, 2*2+3*(len(fields)-2))
+
+		buffer := func(i int) []byte {
+			offset, length := int(buffers[i][0]), int(buffers[i][1])
+			require.Equal(t, 0, offset%8, "buffers must be 8 bytes aligned")
+			return stream[batch.bodyPos+offset : batch.bodyPos+offset+length]
+		}
+
+		timestamps, values := buffer(1), buffer(3)
+		require.Len(t, timestamps, 8*numRows)
+		require.Len(t, values, 8*numRows)
+
+		for i := 0; i < numRows; i++ {
+			row := arrowTestRow{
+				timestamp: int64(binary.LittleEndian.Uint64(timestamps[8*i:])),
+				value:     math.Float64frombits(binary.LittleEndian.Uint64(values[8*i:])),
+				labels:    map[string]string{},
+			}
+
+			for col, f := range fields[2:] {
+				validity, offsets, data := buffer(4+3*col), buffer(5+3*col), buffer(6+3*col)
+				if nodes[2+col][1] > 0 && validity[i/8]&(1<<(i%8)) == 0 {
+					continue
+				}
+				start, end := binary.LittleEndian.Uint32(offsets[4*i:]), binary.LittleEndian.Uint32(offsets[4*i+4:])
+				row.labels[f.string(0)] = string(data[start:end])
+			}
+
+			rows = append(rows, row)
+		}
+
+		for col := range fields {
+			require.Equal(t, int64(numRows), nodes[col][0])
+		}
+	}
+
+	return rows
+}
+
+// fbTestTable is a minimal flatbuffers table reader, used to decode the Arrow messages in tests.
+type fbTestTable struct {
+	t   *testing.T
+	buf []byte
+	pos int
+}
+
+func fbTestRoot(t *testing.T, buf []byte) fbTestTable {
+	return fbTestTable{t: t, buf: buf, pos: int(binary.LittleEndian.Uint32(buf))}
+}
+
+// field returns the position of the field with the given ID, or 0 if the field is not set.
+func (f fbTestTable) field(id int) int {
+	vtable := f.pos - int(int32(binary.LittleEndian.Uint32(f.buf[f.pos:])))
+	if 4+2*id >= int(binary.LittleEndian.Uint16(f.buf[vtable:])) {
+		return 0
+	}
+	if off := int(binary.LittleEndian.Uint16(f.buf[vtable+4+2*id:])); off != 0 {
+		return f.pos + off
+	}
+	return 0
+}
+
+func (f fbTestTable) int64(id int) int64 {
+	pos := f.field(id)
+	require.Equal(f.t, 0, pos%8, "int64 fields must be 8 bytes aligned")
+	return int64(binary.LittleEndian.Uint64(f.buf[pos:]))
+}
+
+func (f fbTestTable) int16(id int) int16 {
+	pos := f.field(id)
+	require.NotZero(f.t, pos)
+	return int16(binary.LittleEndian.Uint16(f.buf[pos:]))
+}
+
+func (f fbTestTable) uint8(id int) uint8 {
+	pos := f.field(id)
+	require.NotZero(f.t, pos)
+	return f.buf[pos]
+}
+
+func (f fbTestTable) bool(id int) bool {
+	return f.uint8(id) != 0
+}
+
+func (f fbTestTable) deref(id int) int {
+	pos := f.field(id)
+	require.NotZero(f.t, pos)
+	return pos + int(binary.LittleEndian.Uint32(f.buf[pos:]))
+}
+
+func (f fbTestTable) table(id int) fbTestTable {
+	return fbTestTable{t: f.t, buf: f.buf, pos: f.deref(id)}
+}
+
+func (f fbTestTable) string(id int) string {
+	pos := f.deref(id)
+	length := int(binary.LittleEndian.Uint32(f.buf[pos:]))
+	require.Equal(f.t, byte(0), f.buf[pos+4+length], "strings must be null terminated")
+	return string(f.buf[pos+4 : pos+4+length])
+}
+
+func (f fbTestTable) tableVector(id int) []fbTestTable {
+	pos := f.deref(id)
+	tables := make([]fbTestTable, binary.LittleEndian.Uint32(f.buf[pos:]))
+	for i := range tables {
+		elemPos := pos + 4 + 4*i
+		tables[i] = fbTestTable{t: f.t, buf: f.buf, pos: elemPos + int(binary.LittleEndian.Uint32(f.buf[elemPos:]))}
+	}
+	return tables
+}
+
+// structVector decodes a vector of structs made of two int64 fields.
+func (f fbTestTable) structVector(id int) [][2]int64 {
+	pos := f.deref(id)
+	structs := make([][2]int64, binary.LittleEndian.Uint32(f.buf[pos:]))
+	require.Equal(f.t, 0, (pos+4)%8, "structs must be 8 bytes aligned")
+	for i := range structs {
+		r := bytes.NewReader(f.buf[pos+4+16*i:])
+		require.NoError(f.t, binary.Read(r, binary.LittleEndian, &structs[i]))
+	}
+	return structs
+}
@@ -0,0 +1,164 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	apierror "github.com/grafana/mimir/pkg/api/error"
+	"github.com/grafana/mimir/pkg/cache"
+	"github.com/grafana/mimir/pkg/querier/stats"
+	"github.com/grafana/mimir/pkg/tenant"
+	"github.com/grafana/mimir/pkg/util/spanlogger"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+const (
+	// cardinalityEstimateTTL is how long the estimated cardinality of a query is cached for.
+	cardinalityEstimateTTL = 7 * 24 * time.Hour
+
+	// cardinalityEstimateBucketSize is the size of the time buckets the queries are grouped by,
+	// when estimating their cardinality. Queries on the same time buckets share the same estimate.
+	cardinalityEstimateBucketSize = 2 * time.Hour
+
+	// cardinalityEstimateMaxDelta is the max relative difference between the estimated and the
+	// actual cardinality of a query, before the estimate is updated.
+	cardinalityEstimateMaxDelta = 0.1
+)
+
+type estimatedSeriesCountContextKey int
+
+const estimatedSeriesCountKey = estimatedSeriesCountContextKey(0)
+
+// contextWithEstimatedSeriesCount returns a context holding the estimated number of series fetched by the query.
+func contextWithEstimatedSeriesCount(ctx context.Context, count uint64) context.Context {
+	return context.WithValue(ctx, estimatedSeriesCountKey, count)
+}
+
+// estimatedSeriesCountFromContext returns the estimated number of series fetched by the query, if available.
+func estimatedSeriesCountFromContext(ctx context.Context) (uint64, bool) {
+	count, ok := ctx.Value(estimatedSeriesCountKey).(uint64)
+	return count, ok
+}
+
+// cardinalityEstimation is a middleware estimating the number of series fetched by a query, based on the
+// number of series fetched by previous executions of the same query on the same time range, as tracked by
+// the query stats. The estimate is used by the query sharding to choose the number of shards of the query.
+type cardinalityEstimation struct {
+	limits Limits
+	cache  cache.Cache
+	next   Handler
+	logger log.Logger
+
+	estimationLookups prometheus.Counter
+	estimationHits    prometheus.Counter
+}
+
+func newCardinalityEstimationMiddleware(limits Limits, cache cache.Cache, logger log.Logger, registerer prometheus.Registerer) Middleware {
+	lookups := promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+		Namespace: "cortex",
+		Name:      "frontend_query_sharding_cardinality_estimation_lookups_total",
+		Help:      "Total number of lookups of the estimated cardinality of a query, used to choose the number of query shards.",
+	})
+	hits := promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+		Namespace: "cortex",
+		Name:      "frontend_query_sharding_cardinality_estimation_hits_total",
+		Help:      "Total number of lookups of the estimated cardinality of a query, which found an estimate.",
+	})
+
+	return MiddlewareFunc(func(next Handler) Handler {
+		return &cardinalityEstimation{
+			limits:            limits,
+			cache:             cache,
+			next:              next,
+			logger:            logger,
+			estimationLookups: lookups,
+			estimationHits:    hits,
+		}
+	})
+}
+
+func (c *cardinalityEstimation) Do(ctx context.Context, r Request) (Response, error) {
+	spanLog, ctx := spanlogger.NewWithLogger(ctx, c.logger, "cardinalityEstimation.Do")
+	defer spanLog.Span.Finish()
+
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return nil, apierror.New(apierror.TypeBadData, err.Error())
+	}
+
+	// The estimate is only used when the target series per shard is configured.
+	if validation.SmallestPositiveIntPerTenant(tenantIDs, c.limits.QueryShardingTargetSeriesPerShard) <= 0 {
+		return c.next.Do(ctx, r)
+	}
+
+	key := generateCardinalityEstimationCacheKey(tenant.JoinTenantIDs(tenantIDs), r)
+	estimate, estimateAvailable := c.lookupEstimate(ctx, key)
+	if estimateAvailable {
+		level.Debug(spanLog).Log("msg", "found estimated cardinality of the query", "estimated series", estimate)
+		ctx = contextWithEstimatedSeriesCount(ctx, estimate)
+	}
+
+	// Track the series fetched by this request separately, because the stats in the context may
+	// be shared by other requests (eg. the other requests split by interval of the same query).
+	parentStats := stats.FromContext(ctx)
+	reqStats, ctx := stats.ContextWithEmptyStats(ctx)
+	defer parentStats.Merge(reqStats)
+
+	res, err := c.next.Do(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	actual := reqStats.LoadFetchedSeries()
+	if actual > 0 && (!estimateAvailable || !isCardinalityEstimateAccurate(estimate, actual)) {
+		level.Debug(spanLog).Log("msg", "updating estimated cardinality of the query", "estimated series", estimate, "actual series", actual)
+		c.storeEstimate(ctx, key, actual)
+	}
+
+	return res, nil
+}
+
+func (c *cardinalityEstimation) lookupEstimate(ctx context.Context, key string) (uint64, bool) {
+	c.estimationLookups.Inc()
+
+	hashed := cacheHashKey(key)
+	res := c.cache.Fetch(ctx, []string{hashed})
+	buf, ok := res[hashed]
+	if !ok || len(buf) != 8 {
+		return 0, false
+	}
+
+	c.estimationHits.Inc()
+	return binary.BigEndian.Uint64(buf), true
+}
+
+func (c *cardinalityEstimation) storeEstimate(ctx context.Context, key string, count uint64) {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, count)
+	c.cache.Store(ctx, map[string][]byte{cacheHashKey(key): buf}, cardinalityEstimateTTL)
+}
+
+// generateCardinalityEstimationCacheKey generates the key the estimated cardinality of the query is cached at.
+// Queries with the same start time bucket and the same number of time buckets share the same estimate.
+func generateCardinalityEstimationCacheKey(userID string, r Request) string {
+	bucketSize := cardinalityEstimateBucketSize.Milliseconds()
+	startBucket := r.GetStart() / bucketSize
+	rangeBuckets := (r.GetEnd() - r.GetStart()) / bucketSize
+
+	return fmt.Sprintf("QS:%s:%s:%d:%d", userID, r.GetQuery(), startBucket, rangeBuckets)
+}
+
+// isCardinalityEstimateAccurate returns whether the estimated cardinality is close enough to the actual one.
+func isCardinalityEstimateAccurate(estimate, actual uint64) bool {
+	return math.Abs(float64(actual)-float64(estimate)) <= cardinalityEstimateMaxDelta*float64(actual)
+}
@@ -0,0 +1,154 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/cache"
+	"github.com/grafana/mimir/pkg/querier/stats"
+	"github.com/grafana/mimir/pkg/util"
+)
+
+func TestCardinalityEstimation(t *testing.T) {
+	req := &PrometheusRangeQueryRequest{
+		Path:  "/query_range",
+		Start: util.TimeToMillis(time.Unix(3600, 0)),
+		End:   util.TimeToMillis(time.Unix(7200, 0)),
+		Step:  60000,
+		Query: "sum(metric)",
+	}
+
+	tests := map[string]struct {
+		targetSeriesPerShard int
+		fetchedSeries        []uint64
+		expectedEstimates    []*uint64
+		expectedLookups      int
+		expectedHits         int
+	}{
+		"should not estimate the cardinality if the target series per shard is disabled": {
+			targetSeriesPerShard: 0,
+			fetchedSeries:        []uint64{100, 100},
+			expectedEstimates:    []*uint64{nil, nil},
+		},
+		"should estimate the cardinality from the previous execution of the query": {
+			targetSeriesPerShard: 1000,
+			fetchedSeries:        []uint64{100, 100, 100},
+			expectedEstimates:    []*uint64{nil, uint64Ptr(100), uint64Ptr(100)},
+			expectedLookups:      3,
+			expectedHits:         2,
+		},
+		"should not update the estimate if close to the actual cardinality": {
+			targetSeriesPerShard: 1000,
+			fetchedSeries:        []uint64{100, 105, 100},
+			expectedEstimates:    []*uint64{nil, uint64Ptr(100), uint64Ptr(100)},
+			expectedLookups:      3,
+			expectedHits:         2,
+		},
+		"should update the estimate if far from the actual cardinality": {
+			targetSeriesPerShard: 1000,
+			fetchedSeries:        []uint64{100, 200, 100},
+			expectedEstimates:    []*uint64{nil, uint64Ptr(100), uint64Ptr(200)},
+			expectedLookups:      3,
+			expectedHits:         2,
+		},
+		"should not store the estimate if no series have been fetched": {
+			targetSeriesPerShard: 1000,
+			fetchedSeries:        []uint64{0, 100},
+			expectedEstimates:    []*uint64{nil, nil},
+			expectedLookups:      2,
+			expectedHits:         0,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			reg := prometheus.NewPedanticRegistry()
+			mw := newCardinalityEstimationMiddleware(mockLimits{targetSeriesPerShard: testData.targetSeriesPerShard}, cache.NewMockCache(), log.NewNopLogger(), reg)
+
+			for i, fetchedSeries := range testData.fetchedSeries {
+				var (
+					estimate          uint64
+					estimateAvailable bool
+				)
+				handler := mw.Wrap(HandlerFunc(func(ctx context.Context, _ Request) (Response, error) {
+					estimate, estimateAvailable = estimatedSeriesCountFromContext(ctx)
+					stats.FromContext(ctx).AddFetchedSeries(fetchedSeries)
+					return &PrometheusResponse{Status: statusSuccess}, nil
+				}))
+
+				queryStats, ctx := stats.ContextWithEmptyStats(user.InjectOrgID(context.Background(), "test"))
+				_, err := handler.Do(ctx, req)
+				require.NoError(t, err)
+
+				// The series fetched by the request are tracked in the parent stats too.
+				assert.Equal(t, fetchedSeries, queryStats.LoadFetchedSeries())
+
+				if expected := testData.expectedEstimates[i]; expected == nil {
+					assert.False(t, estimateAvailable, "request #%d", i)
+				} else {
+					assert.True(t, estimateAvailable, "request #%d", i)
+					assert.Equal(t, *expected, estimate, "request #%d", i)
+				}
+			}
+
+			assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(fmt.Sprintf(`
+				# HELP cortex_frontend_query_sharding_cardinality_estimation_lookups_total Total number of lookups of the estimated cardinality of a query, used to choose the number of query shards.
+				# TYPE cortex_frontend_query_sharding_cardinality_estimation_lookups_total counter
+				cortex_frontend_query_sharding_cardinality_estimation_lookups_total %d
+				# HELP cortex_frontend_query_sharding_cardinality_estimation_hits_total Total number of lookups of the estimated cardinality of a query, which found an estimate.
+				# TYPE cortex_frontend_query_sharding_cardinality_estimation_hits_total counter
+				cortex_frontend_query_sharding_cardinality_estimation_hits_total %d
+			`, testData.expectedLookups, testData.expectedHits))))
+		})
+	}
+}
+
+func TestGenerateCardinalityEstimationCacheKey(t *testing.T) {
+	hour := time.Hour.Milliseconds()
+
+	tests := map[string]struct {
+		first, second Request
+		expectedEqual bool
+	}{
+		"same query on the same time buckets": {
+			first:         &PrometheusRangeQueryRequest{Query: "up", Start: 0, End: 2 * hour},
+			second:        &PrometheusRangeQueryRequest{Query: "up", Start: hour, End: 3 * hour},
+			expectedEqual: true,
+		},
+		"same query with a different start time bucket": {
+			first:         &PrometheusRangeQueryRequest{Query: "up", Start: 0, End: 2 * hour},
+			second:        &PrometheusRangeQueryRequest{Query: "up", Start: 2 * hour, End: 4 * hour},
+			expectedEqual: false,
+		},
+		"same query with a different range": {
+			first:         &PrometheusRangeQueryRequest{Query: "up", Start: 0, End: 2 * hour},
+			second:        &PrometheusRangeQueryRequest{Query: "up", Start: 0, End: 24 * hour},
+			expectedEqual: false,
+		},
+		"different query": {
+			first:         &PrometheusRangeQueryRequest{Query: "up", Start: 0, End: 2 * hour},
+			second:        &PrometheusRangeQueryRequest{Query: "down", Start: 0, End: 2 * hour},
+			expectedEqual: false,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			first := generateCardinalityEstimationCacheKey("test", testData.first)
+			second := generateCardinalityEstimationCacheKey("test", testData.second)
+			assert.Equal(t, testData.expectedEqual, first == second)
+		})
+	}
+}
@@ -28,6 +28,7 @@ import (
 
 	apierror "github.com/grafana/mimir/pkg/api/error"
 	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/querier/engine"
 	"github.com/grafana/mimir/pkg/util"
 	"github.com/grafana/mimir/pkg/util/spanlogger"
 )
@@ -224,6 +225,8 @@ func decodeOptions(r *http.Request, opts *Options) {
 			opts.ShardingDisabled = true
 		}
 	}
+
+	opts.QueryEngine = r.Header.Get(engine.QueryEngineHeader)
 }
 
 func (prometheusCodec) EncodeRequest(ctx context.Context, r Request) (*http.Request, error) {
@@ -259,6 +262,11 @@ func (prometheusCodec) EncodeRequest(ctx context.Context, r Request) (*http.Requ
 		Header:     http.Header{},
 	}
 
+	// Propagate the PromQL engine requested for the query to the querier.
+	if queryEngine := r.GetOptions().QueryEngine; queryEngine != "" {
+		req.Header.Set(engine.QueryEngineHeader, queryEngine)
+	}
+
 	return req.WithContext(ctx), nil
 }
 
@@ -25,6 +25,7 @@ import (
 
 	apierror "github.com/grafana/mimir/pkg/api/error"
 	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/querier/engine"
 )
 
 var (
@@ -701,6 +702,17 @@ func Test_DecodeOptions(t *testing.T) {
 				ShardingDisabled: true,
 			},
 		},
+		{
+			name: "query engine",
+			input: &http.Request{
+				Header: http.Header{
+					engine.QueryEngineHeader: []string{engine.StreamingEngine},
+				},
+			},
+			expected: &Options{
+				QueryEngine: engine.StreamingEngine,
+			},
+		},
 	} {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
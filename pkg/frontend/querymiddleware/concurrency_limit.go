@@ -0,0 +1,194 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/grafana/mimir/pkg/tenant"
+	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/globalerror"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+// Seconds the clients are asked to wait before retrying the queries rejected because of the concurrency limit.
+const concurrentQueriesRetryAfterSeconds = 1
+
+// errTooManyConcurrentQueries is returned when both the concurrent queries and the queue of the tenant are full.
+var errTooManyConcurrentQueries = errors.New("too many concurrent queries for the tenant")
+
+// tenantQueries tracks the queries of a tenant run and queued by the concurrencyLimiter.
+type tenantQueries struct {
+	running int
+	waiting []chan struct{} // Oldest first. Closed when the query can run.
+}
+
+// concurrencyLimiter limits the queries run concurrently per tenant, with a queue of the queries waiting to run.
+type concurrencyLimiter struct {
+	mtx     sync.Mutex
+	tenants map[string]*tenantQueries
+}
+
+func newConcurrencyLimiter() *concurrencyLimiter {
+	return &concurrencyLimiter{tenants: map[string]*tenantQueries{}}
+}
+
+// acquire waits until the query of the tenant can run, and returns the function to call when the query has
+// completed. It returns errTooManyConcurrentQueries if the tenant has maxConcurrent queries running and
+// maxQueued queries waiting, or the context error if the context is done while the query is waiting.
+func (l *concurrencyLimiter) acquire(ctx context.Context, tenantID string, maxConcurrent, maxQueued int) (func(), error) {
+	l.mtx.Lock()
+	t, ok := l.tenants[tenantID]
+	if !ok {
+		t = &tenantQueries{}
+		l.tenants[tenantID] = t
+	}
+
+	release := func() { l.release(tenantID, maxConcurrent) }
+
+	if t.running < maxConcurrent && len(t.waiting) == 0 {
+		t.running++
+		l.mtx.Unlock()
+		return release, nil
+	}
+	if len(t.waiting) >= maxQueued {
+		l.mtx.Unlock()
+		return nil, errTooManyConcurrentQueries
+	}
+
+	ready := make(chan struct{})
+	t.waiting = append(t.waiting, ready)
+	l.mtx.Unlock()
+
+	select {
+	case <-ready:
+		return release, nil
+	case <-ctx.Done():
+	}
+
+	l.mtx.Lock()
+	for i, ch := range t.waiting {
+		if ch == ready {
+			t.waiting = append(t.waiting[:i], t.waiting[i+1:]...)
+			l.mtx.Unlock()
+			return nil, ctx.Err()
+		}
+	}
+	l.mtx.Unlock()
+
+	// The query has been allowed to run concurrently with the cancellation, so its slot is freed.
+	release()
+	return nil, ctx.Err()
+}
+
+// waiting returns the number of queries waiting to run, across all tenants.
+func (l *concurrencyLimiter) waiting() int {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	res := 0
+	for _, t := range l.tenants {
+		res += len(t.waiting)
+	}
+	return res
+}
+
+// release frees the slot of a completed query, and lets the oldest waiting queries of the tenant run as long
+// as the tenant runs less than maxConcurrent queries.
+func (l *concurrencyLimiter) release(tenantID string, maxConcurrent int) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	t := l.tenants[tenantID]
+	t.running--
+
+	for len(t.waiting) > 0 && t.running < maxConcurrent {
+		close(t.waiting[0])
+		t.waiting = t.waiting[1:]
+		t.running++
+	}
+
+	if t.running == 0 && len(t.waiting) == 0 {
+		delete(l.tenants, tenantID)
+	}
+}
+
+// newConcurrencyLimitTripperware returns a Tripperware limiting the range and instant queries run concurrently
+// per tenant, according to the tenant's MaxConcurrentQueriesPerTenant and MaxQueuedQueriesPerTenant limits.
+// The queries above the limit wait in a per-tenant queue, and are rejected with the 429 status code once the
+// queue is full. The limit is enforced before the queries are split and sharded, so it applies to the queries
+// received from the clients, whether they're then enqueued to the query-scheduler or not.
+func newConcurrencyLimitTripperware(limits Limits, logger log.Logger, registerer prometheus.Registerer) Tripperware {
+	limiter := newConcurrencyLimiter()
+
+	promauto.With(registerer).NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "cortex_query_frontend_queued_concurrent_queries",
+		Help: "Number of queries waiting to run because their tenant has too many concurrent queries.",
+	}, func() float64 {
+		return float64(limiter.waiting())
+	})
+	rejectedQueries := promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+		Name: "cortex_query_frontend_rejected_concurrent_queries_total",
+		Help: "Total number of queries rejected because the tenant has too many concurrent queries.",
+	}, []string{"user"})
+
+	activeUsers := util.NewActiveUsersCleanupWithDefaultValues(func(user string) {
+		rejectedQueries.DeleteLabelValues(user)
+	})
+
+	// Start cleanup. If cleaner stops or fail, we will simply not clean the metrics for inactive users.
+	_ = activeUsers.StartAsync(context.Background())
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+			if !isRangeQuery(r.URL.Path) && !isInstantQuery(r.URL.Path) {
+				return next.RoundTrip(r)
+			}
+
+			tenantIDs, err := tenant.TenantIDs(r.Context())
+			if err != nil {
+				return nil, err
+			}
+
+			maxConcurrent := validation.SmallestPositiveNonZeroIntPerTenant(tenantIDs, limits.MaxConcurrentQueriesPerTenant)
+			if maxConcurrent <= 0 {
+				return next.RoundTrip(r)
+			}
+			maxQueued := validation.SmallestPositiveIntPerTenant(tenantIDs, limits.MaxQueuedQueriesPerTenant)
+
+			userStr := tenant.JoinTenantIDs(tenantIDs)
+			release, err := limiter.acquire(r.Context(), userStr, maxConcurrent, maxQueued)
+			if err == errTooManyConcurrentQueries {
+				activeUsers.UpdateUserTimestamp(userStr, time.Now())
+				rejectedQueries.WithLabelValues(userStr).Inc()
+				level.Debug(logger).Log("msg", "query rejected because the tenant has too many concurrent queries", "user", userStr, "max_concurrent", maxConcurrent, "max_queued", maxQueued)
+
+				return nil, httpgrpc.ErrorFromHTTPResponse(&httpgrpc.HTTPResponse{
+					Code: http.StatusTooManyRequests,
+					Headers: []*httpgrpc.Header{
+						{Key: "Retry-After", Values: []string{strconv.Itoa(concurrentQueriesRetryAfterSeconds)}},
+					},
+					Body: []byte(globalerror.MaxConcurrentQueriesPerTenant.Message(fmt.Sprintf("%s (limit: %d concurrent and %d queued queries)", errTooManyConcurrentQueries, maxConcurrent, maxQueued))),
+				})
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			defer release()
+			return next.RoundTrip(r)
+		})
+	}
+}
@@ -0,0 +1,208 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/test"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/util/globalerror"
+)
+
+func TestConcurrencyLimiter(t *testing.T) {
+	ctx := context.Background()
+	l := newConcurrencyLimiter()
+
+	release1, err := l.acquire(ctx, "user-1", 2, 2)
+	require.NoError(t, err)
+	release2, err := l.acquire(ctx, "user-1", 2, 2)
+	require.NoError(t, err)
+
+	// The limit is per tenant.
+	releaseOther, err := l.acquire(ctx, "user-2", 2, 2)
+	require.NoError(t, err)
+	releaseOther()
+
+	// The queries above the limit wait in the queue, in order.
+	acquired := make(chan string, 2)
+	for i, id := range []string{"first", "second"} {
+		id := id
+		go func() {
+			release, err := l.acquire(ctx, "user-1", 2, 2)
+			if err == nil {
+				acquired <- id
+				release()
+			}
+		}()
+		test.Poll(t, time.Second, i+1, func() interface{} {
+			return l.waiting()
+		})
+	}
+
+	// The queries above the limit are rejected once the queue is full.
+	_, err = l.acquire(ctx, "user-1", 2, 2)
+	assert.Equal(t, errTooManyConcurrentQueries, err)
+
+	// A completed query lets the oldest waiting query run.
+	release1()
+	assert.Equal(t, "first", <-acquired)
+	assert.Equal(t, "second", <-acquired)
+	assert.Equal(t, 0, l.waiting())
+
+	// A waiting query is removed from the queue when its context is canceled.
+	release3, err := l.acquire(ctx, "user-1", 2, 2)
+	require.NoError(t, err)
+
+	cancelCtx, cancel := context.WithCancel(ctx)
+	done := make(chan error)
+	go func() {
+		_, err := l.acquire(cancelCtx, "user-1", 2, 2)
+		done <- err
+	}()
+	test.Poll(t, time.Second, 1, func() interface{} {
+		return l.waiting()
+	})
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+	assert.Equal(t, 0, l.waiting())
+
+	// The tenant isn't tracked anymore once all its queries have completed.
+	release2()
+	release3()
+
+	l.mtx.Lock()
+	assert.Empty(t, l.tenants)
+	l.mtx.Unlock()
+}
+
+func TestConcurrencyLimiter_ShouldRejectImmediatelyWithoutQueue(t *testing.T) {
+	l := newConcurrencyLimiter()
+
+	release, err := l.acquire(context.Background(), "user-1", 1, 0)
+	require.NoError(t, err)
+
+	_, err = l.acquire(context.Background(), "user-1", 1, 0)
+	assert.Equal(t, errTooManyConcurrentQueries, err)
+
+	release()
+	release, err = l.acquire(context.Background(), "user-1", 1, 0)
+	require.NoError(t, err)
+	release()
+}
+
+func TestConcurrencyLimitTripperware(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+
+	unblock := make(chan struct{})
+	downstream := RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if isRangeQuery(r.URL.Path) || isInstantQuery(r.URL.Path) {
+			<-unblock
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	})
+	rt := newConcurrencyLimitTripperware(mockLimits{maxConcurrent: 1, maxQueued: 1}, log.NewNopLogger(), reg)(downstream)
+
+	roundTrip := func(path string) (*http.Response, error) {
+		req, err := http.NewRequest("GET", path, http.NoBody)
+		require.NoError(t, err)
+		ctx := user.InjectOrgID(context.Background(), "user-1")
+		require.NoError(t, user.InjectOrgIDIntoHTTPRequest(ctx, req))
+		return rt.RoundTrip(req.WithContext(ctx))
+	}
+
+	// The first query runs, the second one waits in the queue.
+	results := make(chan error, 2)
+	for _, path := range []string{"/api/v1/query_range", "/api/v1/query"} {
+		path := path
+		go func() {
+			_, err := roundTrip(path)
+			results <- err
+		}()
+	}
+	test.Poll(t, time.Second, 1.0, func() interface{} {
+		return gatherGaugeValue(t, reg, "cortex_query_frontend_queued_concurrent_queries")
+	})
+
+	// The queries above the limit are rejected, asking the client to retry later.
+	_, err := roundTrip("/api/v1/query")
+	require.Error(t, err)
+	resp, ok := httpgrpc.HTTPResponseFromError(err)
+	require.True(t, ok)
+	assert.Equal(t, int32(http.StatusTooManyRequests), resp.Code)
+	require.Len(t, resp.Headers, 1)
+	assert.Equal(t, "Retry-After", resp.Headers[0].Key)
+	assert.Equal(t, []string{"1"}, resp.Headers[0].Values)
+	assert.Contains(t, string(resp.Body), globalerror.MaxConcurrentQueriesPerTenant.Code())
+
+	// The other requests are not limited.
+	_, err = roundTrip("/api/v1/labels")
+	require.NoError(t, err)
+
+	close(unblock)
+	require.NoError(t, <-results)
+	require.NoError(t, <-results)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_query_frontend_queued_concurrent_queries Number of queries waiting to run because their tenant has too many concurrent queries.
+		# TYPE cortex_query_frontend_queued_concurrent_queries gauge
+		cortex_query_frontend_queued_concurrent_queries 0
+		# HELP cortex_query_frontend_rejected_concurrent_queries_total Total number of queries rejected because the tenant has too many concurrent queries.
+		# TYPE cortex_query_frontend_rejected_concurrent_queries_total counter
+		cortex_query_frontend_rejected_concurrent_queries_total{user="user-1"} 1
+	`)))
+}
+
+func TestConcurrencyLimitTripperware_ShouldNotLimitWhenDisabled(t *testing.T) {
+	running := make(chan struct{}, 10)
+	unblock := make(chan struct{})
+	downstream := RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		running <- struct{}{}
+		<-unblock
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	})
+	rt := newConcurrencyLimitTripperware(mockLimits{maxQueued: 1}, log.NewNopLogger(), nil)(downstream)
+
+	results := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		go func() {
+			req, err := http.NewRequest("GET", "/api/v1/query", http.NoBody)
+			if err == nil {
+				_, err = rt.RoundTrip(req.WithContext(user.InjectOrgID(context.Background(), "user-1")))
+			}
+			results <- err
+		}()
+	}
+
+	// All the queries run concurrently.
+	for i := 0; i < 5; i++ {
+		<-running
+	}
+	close(unblock)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, <-results)
+	}
+}
+
+func gatherGaugeValue(t *testing.T, reg prometheus.Gatherer, name string) float64 {
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	for _, f := range families {
+		if f.GetName() == name {
+			return f.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+	t.Fatalf("metric %s not found", name)
+	return 0
+}
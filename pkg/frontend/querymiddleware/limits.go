@@ -41,6 +41,16 @@ type Limits interface {
 	// to prevent caching of very recent results.
 	MaxCacheFreshness(userID string) time.Duration
 
+	// ResultsCacheTTL returns the time to live of the query results stored in the results cache.
+	ResultsCacheTTL(userID string) time.Duration
+
+	// ResultsCacheMaxQueryLength returns the max length (in time) of a query whose
+	// results can be cached. 0 to disable limit.
+	ResultsCacheMaxQueryLength(userID string) time.Duration
+
+	// ResultsCacheUnalignedRequests returns whether requests that are not step-aligned can be cached.
+	ResultsCacheUnalignedRequests(userID string) bool
+
 	// QueryShardingTotalShards returns the number of shards to use for a given tenant.
 	QueryShardingTotalShards(userID string) int
 
@@ -48,6 +58,18 @@ type Limits interface {
 	// be run for a given received query. 0 to disable limit.
 	QueryShardingMaxShardedQueries(userID string) int
 
+	// QueryShardingTargetSeriesPerShard returns the target number of series fetched by each query shard,
+	// used to choose the number of shards based on the estimated cardinality of the query. 0 to disable.
+	QueryShardingTargetSeriesPerShard(userID string) int
+
+	// MaxConcurrentQueriesPerTenant returns the max number of range and instant queries of the tenant
+	// run concurrently by the query-frontend. 0 to disable limit.
+	MaxConcurrentQueriesPerTenant(userID string) int
+
+	// MaxQueuedQueriesPerTenant returns the max number of queries of the tenant waiting to run
+	// when MaxConcurrentQueriesPerTenant is reached.
+	MaxQueuedQueriesPerTenant(userID string) int
+
 	// CompactorSplitAndMergeShards returns the number of shards to use when splitting blocks
 	// This method is copied from compactor.ConfigProvider.
 	CompactorSplitAndMergeShards(userID string) int
@@ -226,6 +248,9 @@ func (rt limitedParallelismRoundTripper) RoundTrip(r *http.Request) (*http.Respo
 		return nil, err
 	}
 
+	if acceptsArrow(r) {
+		return encodeArrowResponse(ctx, response)
+	}
 	return rt.codec.EncodeResponse(ctx, response)
 }
 
@@ -196,13 +196,19 @@ func TestLimitsMiddleware_MaxQueryLength(t *testing.T) {
 }
 
 type mockLimits struct {
-	maxQueryLookback    time.Duration
-	maxQueryLength      time.Duration
-	maxCacheFreshness   time.Duration
-	maxQueryParallelism int
-	maxShardedQueries   int
-	totalShards         int
-	compactorShards     int
+	maxQueryLookback     time.Duration
+	maxQueryLength       time.Duration
+	maxCacheFreshness    time.Duration
+	resultsCacheTTL      time.Duration
+	resultsCacheMaxLen   time.Duration
+	cacheUnaligned       bool
+	maxQueryParallelism  int
+	maxShardedQueries    int
+	totalShards          int
+	targetSeriesPerShard int
+	compactorShards      int
+	maxConcurrent        int
+	maxQueued            int
 }
 
 func (m mockLimits) MaxQueryLookback(string) time.Duration {
@@ -224,6 +230,18 @@ func (m mockLimits) MaxCacheFreshness(string) time.Duration {
 	return m.maxCacheFreshness
 }
 
+func (m mockLimits) ResultsCacheTTL(string) time.Duration {
+	return m.resultsCacheTTL
+}
+
+func (m mockLimits) ResultsCacheMaxQueryLength(string) time.Duration {
+	return m.resultsCacheMaxLen
+}
+
+func (m mockLimits) ResultsCacheUnalignedRequests(string) bool {
+	return m.cacheUnaligned
+}
+
 func (m mockLimits) QueryShardingTotalShards(string) int {
 	return m.totalShards
 }
@@ -232,6 +250,18 @@ func (m mockLimits) QueryShardingMaxShardedQueries(string) int {
 	return m.maxShardedQueries
 }
 
+func (m mockLimits) QueryShardingTargetSeriesPerShard(string) int {
+	return m.targetSeriesPerShard
+}
+
+func (m mockLimits) MaxConcurrentQueriesPerTenant(string) int {
+	return m.maxConcurrent
+}
+
+func (m mockLimits) MaxQueuedQueriesPerTenant(string) int {
+	return m.maxQueued
+}
+
 func (m mockLimits) CompactorSplitAndMergeShards(userID string) int {
 	return m.compactorShards
 }
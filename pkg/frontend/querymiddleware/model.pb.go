@@ -571,9 +571,10 @@ func (m *Extent) GetResponse() *types.Any {
 }
 
 type Options struct {
-	CacheDisabled    bool  `protobuf:"varint,1,opt,name=CacheDisabled,proto3" json:"CacheDisabled,omitempty"`
-	ShardingDisabled bool  `protobuf:"varint,2,opt,name=ShardingDisabled,proto3" json:"ShardingDisabled,omitempty"`
-	TotalShards      int32 `protobuf:"varint,3,opt,name=TotalShards,proto3" json:"TotalShards,omitempty"`
+	CacheDisabled    bool   `protobuf:"varint,1,opt,name=CacheDisabled,proto3" json:"CacheDisabled,omitempty"`
+	ShardingDisabled bool   `protobuf:"varint,2,opt,name=ShardingDisabled,proto3" json:"ShardingDisabled,omitempty"`
+	TotalShards      int32  `protobuf:"varint,3,opt,name=TotalShards,proto3" json:"TotalShards,omitempty"`
+	QueryEngine      string `protobuf:"bytes,4,opt,name=QueryEngine,proto3" json:"QueryEngine,omitempty"`
 }
 
 func (m *Options) Reset()      { *m = Options{} }
@@ -629,6 +630,13 @@ func (m *Options) GetTotalShards() int32 {
 	return 0
 }
 
+func (m *Options) GetQueryEngine() string {
+	if m != nil {
+		return m.QueryEngine
+	}
+	return ""
+}
+
 type Hints struct {
 	// Total number of queries that are expected to to be executed to serve the original request.
 	TotalQueries int32 `protobuf:"varint,1,opt,name=TotalQueries,proto3" json:"TotalQueries,omitempty"`
@@ -689,68 +697,68 @@ func init() {
 func init() { proto.RegisterFile("model.proto", fileDescriptor_4c16552f9fdb66d8) }
 
 var fileDescriptor_4c16552f9fdb66d8 = []byte{
-	// 966 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x54, 0xcd, 0x6e, 0x23, 0x45,
-	0x17, 0x75, 0xbb, 0xdd, 0xb6, 0x73, 0x9d, 0xcf, 0xc9, 0x57, 0x19, 0x89, 0x4e, 0xd0, 0x74, 0x5b,
-	0xad, 0x59, 0x84, 0x9f, 0x38, 0x90, 0x11, 0x1b, 0x24, 0x10, 0xd3, 0x93, 0x48, 0x33, 0x08, 0xc1,
-	0x50, 0x89, 0x58, 0xb0, 0x41, 0xe5, 0x74, 0x8d, 0xdd, 0x4c, 0xff, 0x4d, 0x75, 0x35, 0x8c, 0x17,
-	0x48, 0x88, 0x27, 0x60, 0xc9, 0x0b, 0x20, 0xb1, 0x60, 0xcd, 0x8a, 0x07, 0x98, 0x65, 0xd8, 0x8d,
-	0x58, 0x34, 0xc4, 0xd9, 0x20, 0xaf, 0xe6, 0x11, 0x50, 0xdd, 0xea, 0x8e, 0x3b, 0x04, 0xc4, 0xb0,
-	0xb1, 0xab, 0xce, 0x3d, 0xf7, 0xd6, 0xb9, 0xa7, 0xab, 0x2e, 0x0c, 0xe2, 0x34, 0xe0, 0xd1, 0x38,
-	0x13, 0xa9, 0x4c, 0x09, 0x3c, 0x2e, 0xb8, 0x98, 0x0b, 0x96, 0x4c, 0xf9, 0xce, 0xde, 0x34, 0x94,
-	0xb3, 0x62, 0x32, 0x3e, 0x4d, 0xe3, 0xfd, 0x69, 0x3a, 0x4d, 0xf7, 0x91, 0x32, 0x29, 0x1e, 0xe2,
-	0x0e, 0x37, 0xb8, 0xd2, 0xa9, 0x3b, 0xce, 0x34, 0x4d, 0xa7, 0x11, 0x5f, 0xb1, 0x82, 0x42, 0x30,
-	0x19, 0xa6, 0x49, 0x15, 0x7f, 0xa3, 0x59, 0x4e, 0xb0, 0x87, 0x2c, 0x61, 0xfb, 0x71, 0x18, 0x87,
-	0x62, 0x3f, 0x7b, 0x34, 0xd5, 0xab, 0x6c, 0xa2, 0xff, 0xab, 0x8c, 0xed, 0xbf, 0x56, 0x64, 0xc9,
-	0x5c, 0x87, 0xbc, 0x9f, 0xda, 0xf0, 0xf2, 0x03, 0x91, 0xc6, 0x5c, 0xce, 0x78, 0x91, 0x53, 0xa5,
-	0xf7, 0x63, 0xa5, 0x9c, 0xf2, 0xc7, 0x05, 0xcf, 0x25, 0x21, 0xd0, 0xc9, 0x98, 0x9c, 0xd9, 0xc6,
-	0xc8, 0xd8, 0x5d, 0xa3, 0xb8, 0x26, 0x37, 0xc0, 0xca, 0x25, 0x13, 0xd2, 0x6e, 0x8f, 0x8c, 0x5d,
-	0x93, 0xea, 0x0d, 0xd9, 0x04, 0x93, 0x27, 0x81, 0x6d, 0x22, 0xa6, 0x96, 0x2a, 0x37, 0x97, 0x3c,
-	0xb3, 0x3b, 0x08, 0xe1, 0x9a, 0xbc, 0x03, 0x3d, 0x19, 0xc6, 0x3c, 0x2d, 0xa4, 0x6d, 0x8d, 0x8c,
-	0xdd, 0xc1, 0xc1, 0xf6, 0x58, 0x8b, 0x1b, 0xd7, 0xe2, 0xc6, 0x87, 0x55, 0xbb, 0x7e, 0xff, 0x69,
-	0xe9, 0xb6, 0xbe, 0xfb, 0xcd, 0x35, 0x68, 0x9d, 0xa3, 0x8e, 0x46, 0x63, 0xed, 0x2e, 0xea, 0xd1,
-	0x1b, 0x72, 0x1b, 0x7a, 0x69, 0xa6, 0x52, 0x72, 0xbb, 0x87, 0x45, 0xb7, 0xc6, 0x2b, 0xfb, 0xc7,
-	0x1f, 0xe9, 0x90, 0xdf, 0x51, 0xe5, 0x68, 0xcd, 0x24, 0x43, 0x68, 0x87, 0x81, 0xdd, 0x47, 0x6d,
-	0xed, 0x30, 0x20, 0x7b, 0x60, 0xcd, 0xc2, 0x44, 0xe6, 0xf6, 0x1a, 0x96, 0xf8, 0x7f, 0xb3, 0xc4,
-	0x3d, 0x15, 0xc0, 0x02, 0x06, 0xd5, 0x2c, 0xef, 0x17, 0x03, 0x6e, 0xae, 0x8c, 0xbb, 0x9f, 0xe4,
-	0x92, 0x25, 0xf2, 0x5f, 0xad, 0x23, 0xd0, 0x51, 0xad, 0x54, 0xce, 0xe1, 0x7a, 0xd5, 0x93, 0xf9,
-	0x0f, 0x3d, 0x75, 0xfe, 0x63, 0x4f, 0xd6, 0xf5, 0x9e, 0xba, 0x2f, 0xd4, 0xd3, 0x09, 0xd8, 0x8d,
-	0xbb, 0xc0, 0xf3, 0x2c, 0x4d, 0x72, 0x7e, 0x8f, 0xb3, 0x80, 0x0b, 0xb2, 0x0d, 0x9d, 0x0f, 0x59,
-	0xcc, 0x75, 0x37, 0xbe, 0xb5, 0x2c, 0x5d, 0x63, 0x8f, 0x22, 0x44, 0x6e, 0x42, 0xf7, 0x13, 0x16,
-	0x15, 0x3c, 0xb7, 0xdb, 0x23, 0x73, 0x15, 0xac, 0x40, 0xef, 0xfb, 0x36, 0x90, 0xeb, 0x65, 0x89,
-	0x07, 0xdd, 0x63, 0xc9, 0x64, 0x91, 0x57, 0x25, 0x61, 0x59, 0xba, 0xdd, 0x1c, 0x11, 0x5a, 0x45,
-	0x88, 0x0f, 0x9d, 0x43, 0x26, 0x19, 0xda, 0x35, 0x38, 0xd8, 0x69, 0xca, 0x5f, 0x55, 0x54, 0x0c,
-	0x9f, 0x2c, 0x4b, 0x77, 0x18, 0x30, 0xc9, 0x5e, 0x4f, 0xe3, 0x50, 0xf2, 0x38, 0x93, 0x73, 0x8a,
-	0xb9, 0xe4, 0x2d, 0x58, 0x3b, 0x12, 0x22, 0x15, 0x27, 0xf3, 0x8c, 0x6b, 0x8b, 0xfd, 0x97, 0x96,
-	0xa5, 0xbb, 0xc5, 0x6b, 0xb0, 0x91, 0xb1, 0x62, 0x92, 0x57, 0xc0, 0xc2, 0x0d, 0xba, 0xbf, 0xe6,
-	0x6f, 0x2d, 0x4b, 0x77, 0x03, 0x53, 0x1a, 0x74, 0xcd, 0x20, 0x47, 0xd0, 0xd3, 0x26, 0xe5, 0xb6,
-	0x35, 0x32, 0x77, 0x07, 0x07, 0xb7, 0xfe, 0x5e, 0xe8, 0x55, 0x47, 0x6b, 0x9b, 0xea, 0x5c, 0xef,
-	0x1b, 0x03, 0x86, 0x57, 0xbb, 0x22, 0x63, 0x00, 0xca, 0xf3, 0x22, 0x92, 0x28, 0x5e, 0xfb, 0x34,
-	0x5c, 0x96, 0x2e, 0x88, 0x4b, 0x94, 0x36, 0x18, 0xe4, 0x3d, 0xe8, 0xea, 0x1d, 0x7e, 0x89, 0xc1,
-	0x81, 0xdd, 0x14, 0x72, 0xcc, 0xe2, 0x2c, 0xe2, 0xc7, 0x52, 0x70, 0x16, 0xfb, 0x43, 0x75, 0x71,
-	0x94, 0xe3, 0xba, 0x12, 0xad, 0xf2, 0xbc, 0x9f, 0x0d, 0x58, 0x6f, 0x12, 0x49, 0x06, 0xdd, 0x88,
-	0x4d, 0x78, 0xa4, 0x3e, 0x93, 0x89, 0xd7, 0xf0, 0x34, 0x15, 0x92, 0x3f, 0xc9, 0x26, 0xe3, 0x0f,
-	0x14, 0xfe, 0x80, 0x85, 0xc2, 0xbf, 0xab, 0xaa, 0xfd, 0x5a, 0xba, 0x6f, 0xbe, 0xc8, 0x68, 0xd2,
-	0x79, 0x77, 0x02, 0x96, 0x49, 0x2e, 0x94, 0x84, 0x98, 0x4b, 0x11, 0x9e, 0xd2, 0xea, 0x1c, 0xf2,
-	0x36, 0xf4, 0x72, 0x54, 0x90, 0x57, 0x5d, 0x6c, 0xae, 0x8e, 0xd4, 0xd2, 0x56, 0xea, 0xbf, 0xc0,
-	0x2b, 0x46, 0xeb, 0x04, 0xef, 0x73, 0x18, 0xde, 0x65, 0xa7, 0x33, 0x1e, 0x5c, 0x5e, 0xb3, 0x6d,
-	0x30, 0x1f, 0xf1, 0x79, 0xe5, 0x5d, 0x6f, 0x59, 0xba, 0x6a, 0x4b, 0xd5, 0x8f, 0x9a, 0x45, 0xfc,
-	0x89, 0xe4, 0xea, 0x7d, 0xe8, 0x83, 0x48, 0xd3, 0xae, 0x23, 0x0c, 0xf9, 0x1b, 0xd5, 0x51, 0x35,
-	0x95, 0xd6, 0x0b, 0xef, 0x47, 0x03, 0xba, 0x9a, 0x44, 0xdc, 0x7a, 0x22, 0xaa, 0x63, 0x4c, 0x7f,
-	0x6d, 0x59, 0xba, 0x1a, 0xa8, 0x87, 0xe3, 0xb6, 0x1e, 0x8e, 0xf8, 0xec, 0xb5, 0x0a, 0x9e, 0x04,
-	0x7a, 0x4a, 0x8e, 0xa0, 0x2f, 0x05, 0x3b, 0xe5, 0x9f, 0x85, 0x41, 0x75, 0xd7, 0xea, 0x8b, 0x81,
-	0xf0, 0xfd, 0x80, 0xbc, 0x0b, 0x7d, 0x51, 0xb5, 0x53, 0x0d, 0xcd, 0x1b, 0xd7, 0x86, 0xe6, 0x9d,
-	0x64, 0xee, 0xaf, 0x2f, 0x4b, 0xf7, 0x92, 0x49, 0x2f, 0x57, 0xef, 0x77, 0xfa, 0xe6, 0x66, 0xc7,
-	0xfb, 0x0a, 0x7a, 0xd5, 0xd4, 0x20, 0xb7, 0xe0, 0x7f, 0xe8, 0xd2, 0x61, 0x98, 0xb3, 0x49, 0xc4,
-	0x03, 0x94, 0xdd, 0xa7, 0x57, 0x41, 0xf2, 0x2a, 0x6c, 0x1e, 0xcf, 0x98, 0x08, 0xc2, 0x64, 0x7a,
-	0x49, 0x6c, 0x23, 0xf1, 0x1a, 0x4e, 0x46, 0x30, 0x38, 0x49, 0x25, 0x8b, 0x30, 0x90, 0xe3, 0x33,
-	0xb3, 0x68, 0x13, 0xf2, 0x5e, 0x03, 0x0b, 0x27, 0x0e, 0xf1, 0x60, 0x1d, 0x71, 0x35, 0x2b, 0x43,
-	0xae, 0x5f, 0xbf, 0x45, 0xaf, 0x60, 0xfe, 0xd1, 0xd9, 0xb9, 0xd3, 0x7a, 0x76, 0xee, 0xb4, 0x9e,
-	0x9f, 0x3b, 0xc6, 0xd7, 0x0b, 0xc7, 0xf8, 0x61, 0xe1, 0x18, 0x4f, 0x17, 0x8e, 0x71, 0xb6, 0x70,
-	0x8c, 0xdf, 0x17, 0x8e, 0xf1, 0xc7, 0xc2, 0x69, 0x3d, 0x5f, 0x38, 0xc6, 0xb7, 0x17, 0x4e, 0xeb,
-	0xec, 0xc2, 0x69, 0x3d, 0xbb, 0x70, 0x5a, 0x9f, 0x6e, 0xe0, 0xd7, 0x8b, 0xc3, 0x20, 0x88, 0xf8,
-	0x97, 0x4c, 0xf0, 0x49, 0x17, 0xed, 0xb9, 0xfd, 0x67, 0x00, 0x00, 0x00, 0xff, 0xff, 0x78, 0x72,
-	0x07, 0xd5, 0x9a, 0x07, 0x00, 0x00,
+	// 974 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x54, 0x4f, 0x6f, 0x23, 0xc5,
+	0x13, 0xf5, 0x78, 0x3c, 0xb6, 0x53, 0xce, 0xcf, 0xc9, 0xaf, 0xb3, 0x12, 0x93, 0xa0, 0x9d, 0xb1,
+	0x46, 0x7b, 0x08, 0x7f, 0xe2, 0x40, 0x56, 0x5c, 0x90, 0x40, 0xec, 0x6c, 0x22, 0xed, 0x22, 0x04,
+	0x4b, 0x27, 0xe2, 0xc0, 0x05, 0xb5, 0x33, 0xbd, 0xf6, 0xb0, 0xf3, 0x6f, 0x7b, 0x7a, 0x60, 0x7d,
+	0x43, 0x7c, 0x02, 0x8e, 0xdc, 0x38, 0x21, 0x71, 0xe0, 0xcc, 0x89, 0x0f, 0xb0, 0xc7, 0x70, 0x5b,
+	0x71, 0x18, 0x88, 0x73, 0x41, 0x3e, 0xed, 0x47, 0x40, 0x5d, 0x3d, 0x13, 0x4f, 0x08, 0x88, 0xe5,
+	0x92, 0x54, 0xbf, 0x7a, 0x55, 0xfd, 0xea, 0x4d, 0xbb, 0x60, 0x10, 0xa7, 0x01, 0x8f, 0xc6, 0x99,
+	0x48, 0x65, 0x4a, 0xe0, 0x71, 0xc1, 0xc5, 0x5c, 0xb0, 0x64, 0xca, 0x77, 0xf6, 0xa6, 0xa1, 0x9c,
+	0x15, 0x93, 0xf1, 0x69, 0x1a, 0xef, 0x4f, 0xd3, 0x69, 0xba, 0x8f, 0x94, 0x49, 0xf1, 0x10, 0x4f,
+	0x78, 0xc0, 0x48, 0x97, 0xee, 0x38, 0xd3, 0x34, 0x9d, 0x46, 0x7c, 0xc5, 0x0a, 0x0a, 0xc1, 0x64,
+	0x98, 0x26, 0x55, 0xfe, 0x8d, 0x66, 0x3b, 0xc1, 0x1e, 0xb2, 0x84, 0xed, 0xc7, 0x61, 0x1c, 0x8a,
+	0xfd, 0xec, 0xd1, 0x54, 0x47, 0xd9, 0x44, 0xff, 0xaf, 0x2a, 0xb6, 0xff, 0xda, 0x91, 0x25, 0x73,
+	0x9d, 0xf2, 0x7e, 0x6a, 0xc3, 0xcb, 0x0f, 0x44, 0x1a, 0x73, 0x39, 0xe3, 0x45, 0x4e, 0x95, 0xde,
+	0x8f, 0x95, 0x72, 0xca, 0x1f, 0x17, 0x3c, 0x97, 0x84, 0x40, 0x27, 0x63, 0x72, 0x66, 0x1b, 0x23,
+	0x63, 0x77, 0x8d, 0x62, 0x4c, 0x6e, 0x80, 0x95, 0x4b, 0x26, 0xa4, 0xdd, 0x1e, 0x19, 0xbb, 0x26,
+	0xd5, 0x07, 0xb2, 0x09, 0x26, 0x4f, 0x02, 0xdb, 0x44, 0x4c, 0x85, 0xaa, 0x36, 0x97, 0x3c, 0xb3,
+	0x3b, 0x08, 0x61, 0x4c, 0xde, 0x81, 0x9e, 0x0c, 0x63, 0x9e, 0x16, 0xd2, 0xb6, 0x46, 0xc6, 0xee,
+	0xe0, 0x60, 0x7b, 0xac, 0xc5, 0x8d, 0x6b, 0x71, 0xe3, 0xc3, 0x6a, 0x5c, 0xbf, 0xff, 0xb4, 0x74,
+	0x5b, 0xdf, 0xfe, 0xe6, 0x1a, 0xb4, 0xae, 0x51, 0x57, 0xa3, 0xb1, 0x76, 0x17, 0xf5, 0xe8, 0x03,
+	0xb9, 0x0d, 0xbd, 0x34, 0x53, 0x25, 0xb9, 0xdd, 0xc3, 0xa6, 0x5b, 0xe3, 0x95, 0xfd, 0xe3, 0x8f,
+	0x74, 0xca, 0xef, 0xa8, 0x76, 0xb4, 0x66, 0x92, 0x21, 0xb4, 0xc3, 0xc0, 0xee, 0xa3, 0xb6, 0x76,
+	0x18, 0x90, 0x3d, 0xb0, 0x66, 0x61, 0x22, 0x73, 0x7b, 0x0d, 0x5b, 0xfc, 0xbf, 0xd9, 0xe2, 0x9e,
+	0x4a, 0x60, 0x03, 0x83, 0x6a, 0x96, 0xf7, 0x8b, 0x01, 0x37, 0x57, 0xc6, 0xdd, 0x4f, 0x72, 0xc9,
+	0x12, 0xf9, 0xaf, 0xd6, 0x11, 0xe8, 0xa8, 0x51, 0x2a, 0xe7, 0x30, 0x5e, 0xcd, 0x64, 0xfe, 0xc3,
+	0x4c, 0x9d, 0xff, 0x38, 0x93, 0x75, 0x7d, 0xa6, 0xee, 0x0b, 0xcd, 0x74, 0x02, 0x76, 0xe3, 0x2d,
+	0xf0, 0x3c, 0x4b, 0x93, 0x9c, 0xdf, 0xe3, 0x2c, 0xe0, 0x82, 0x6c, 0x43, 0xe7, 0x43, 0x16, 0x73,
+	0x3d, 0x8d, 0x6f, 0x2d, 0x4b, 0xd7, 0xd8, 0xa3, 0x08, 0x91, 0x9b, 0xd0, 0xfd, 0x84, 0x45, 0x05,
+	0xcf, 0xed, 0xf6, 0xc8, 0x5c, 0x25, 0x2b, 0xd0, 0xfb, 0xbe, 0x0d, 0xe4, 0x7a, 0x5b, 0xe2, 0x41,
+	0xf7, 0x58, 0x32, 0x59, 0xe4, 0x55, 0x4b, 0x58, 0x96, 0x6e, 0x37, 0x47, 0x84, 0x56, 0x19, 0xe2,
+	0x43, 0xe7, 0x90, 0x49, 0x86, 0x76, 0x0d, 0x0e, 0x76, 0x9a, 0xf2, 0x57, 0x1d, 0x15, 0xc3, 0x27,
+	0xcb, 0xd2, 0x1d, 0x06, 0x4c, 0xb2, 0xd7, 0xd3, 0x38, 0x94, 0x3c, 0xce, 0xe4, 0x9c, 0x62, 0x2d,
+	0x79, 0x0b, 0xd6, 0x8e, 0x84, 0x48, 0xc5, 0xc9, 0x3c, 0xe3, 0xda, 0x62, 0xff, 0xa5, 0x65, 0xe9,
+	0x6e, 0xf1, 0x1a, 0x6c, 0x54, 0xac, 0x98, 0xe4, 0x15, 0xb0, 0xf0, 0x80, 0xee, 0xaf, 0xf9, 0x5b,
+	0xcb, 0xd2, 0xdd, 0xc0, 0x92, 0x06, 0x5d, 0x33, 0xc8, 0x11, 0xf4, 0xb4, 0x49, 0xb9, 0x6d, 0x8d,
+	0xcc, 0xdd, 0xc1, 0xc1, 0xad, 0xbf, 0x17, 0x7a, 0xd5, 0xd1, 0xda, 0xa6, 0xba, 0xd6, 0xfb, 0xda,
+	0x80, 0xe1, 0xd5, 0xa9, 0xc8, 0x18, 0x80, 0xf2, 0xbc, 0x88, 0x24, 0x8a, 0xd7, 0x3e, 0x0d, 0x97,
+	0xa5, 0x0b, 0xe2, 0x12, 0xa5, 0x0d, 0x06, 0x79, 0x0f, 0xba, 0xfa, 0x84, 0x5f, 0x62, 0x70, 0x60,
+	0x37, 0x85, 0x1c, 0xb3, 0x38, 0x8b, 0xf8, 0xb1, 0x14, 0x9c, 0xc5, 0xfe, 0x50, 0x3d, 0x1c, 0xe5,
+	0xb8, 0xee, 0x44, 0xab, 0x3a, 0xef, 0x67, 0x03, 0xd6, 0x9b, 0x44, 0x92, 0x41, 0x37, 0x62, 0x13,
+	0x1e, 0xa9, 0xcf, 0x64, 0xe2, 0x33, 0x3c, 0x4d, 0x85, 0xe4, 0x4f, 0xb2, 0xc9, 0xf8, 0x03, 0x85,
+	0x3f, 0x60, 0xa1, 0xf0, 0xef, 0xaa, 0x6e, 0xbf, 0x96, 0xee, 0x9b, 0x2f, 0xb2, 0x9a, 0x74, 0xdd,
+	0x9d, 0x80, 0x65, 0x92, 0x0b, 0x25, 0x21, 0xe6, 0x52, 0x84, 0xa7, 0xb4, 0xba, 0x87, 0xbc, 0x0d,
+	0xbd, 0x1c, 0x15, 0xe4, 0xd5, 0x14, 0x9b, 0xab, 0x2b, 0xb5, 0xb4, 0x95, 0xfa, 0x2f, 0xf0, 0x89,
+	0xd1, 0xba, 0xc0, 0xfb, 0x1c, 0x86, 0x77, 0xd9, 0xe9, 0x8c, 0x07, 0x97, 0xcf, 0x6c, 0x1b, 0xcc,
+	0x47, 0x7c, 0x5e, 0x79, 0xd7, 0x5b, 0x96, 0xae, 0x3a, 0x52, 0xf5, 0x47, 0xed, 0x22, 0xfe, 0x44,
+	0xf2, 0x44, 0xd6, 0x17, 0x91, 0xa6, 0x5d, 0x47, 0x98, 0xf2, 0x37, 0xaa, 0xab, 0x6a, 0x2a, 0xad,
+	0x03, 0xef, 0x47, 0x03, 0xba, 0x9a, 0x44, 0xdc, 0x7a, 0x23, 0xaa, 0x6b, 0x4c, 0x7f, 0x6d, 0x59,
+	0xba, 0x1a, 0xa8, 0x97, 0xe3, 0xb6, 0x5e, 0x8e, 0xf8, 0xb3, 0xd7, 0x2a, 0x78, 0x12, 0xe8, 0x2d,
+	0x39, 0x82, 0xbe, 0x14, 0xec, 0x94, 0x7f, 0x16, 0x06, 0xd5, 0x5b, 0xab, 0x1f, 0x06, 0xc2, 0xf7,
+	0x03, 0xf2, 0x2e, 0xf4, 0x45, 0x35, 0x4e, 0xb5, 0x34, 0x6f, 0x5c, 0x5b, 0x9a, 0x77, 0x92, 0xb9,
+	0xbf, 0xbe, 0x2c, 0xdd, 0x4b, 0x26, 0xbd, 0x8c, 0xde, 0xef, 0xf4, 0xcd, 0xcd, 0x8e, 0xf7, 0x9d,
+	0x01, 0xbd, 0x6a, 0x6d, 0x90, 0x5b, 0xf0, 0x3f, 0xb4, 0xe9, 0x30, 0xcc, 0xd9, 0x24, 0xe2, 0x01,
+	0xea, 0xee, 0xd3, 0xab, 0x20, 0x79, 0x15, 0x36, 0x8f, 0x67, 0x4c, 0x04, 0x61, 0x32, 0xbd, 0x24,
+	0xb6, 0x91, 0x78, 0x0d, 0x27, 0x23, 0x18, 0x9c, 0xa4, 0x92, 0x45, 0x98, 0xc8, 0xf1, 0x77, 0x66,
+	0xd1, 0x26, 0xa4, 0x18, 0xb8, 0x1e, 0x8f, 0x92, 0x69, 0x98, 0x70, 0x3d, 0x2a, 0x6d, 0x42, 0xde,
+	0x6b, 0x60, 0xe1, 0x52, 0x22, 0x1e, 0xac, 0x63, 0xa5, 0x4a, 0x86, 0x5c, 0x2f, 0x08, 0x8b, 0x5e,
+	0xc1, 0xfc, 0xa3, 0xb3, 0x73, 0xa7, 0xf5, 0xec, 0xdc, 0x69, 0x3d, 0x3f, 0x77, 0x8c, 0xaf, 0x16,
+	0x8e, 0xf1, 0xc3, 0xc2, 0x31, 0x9e, 0x2e, 0x1c, 0xe3, 0x6c, 0xe1, 0x18, 0xbf, 0x2f, 0x1c, 0xe3,
+	0x8f, 0x85, 0xd3, 0x7a, 0xbe, 0x70, 0x8c, 0x6f, 0x2e, 0x9c, 0xd6, 0xd9, 0x85, 0xd3, 0x7a, 0x76,
+	0xe1, 0xb4, 0x3e, 0xdd, 0xc0, 0x0f, 0x1c, 0x87, 0x41, 0x10, 0xf1, 0x2f, 0x99, 0xe0, 0x93, 0x2e,
+	0x3a, 0x78, 0xfb, 0xcf, 0x01, 0x00, 0x01, 0x9c, 0xd5, 0xf1, 0xbd, 0x07, 0x00, 0x00,
 }
 
 func (this *PrometheusRangeQueryRequest) Equal(that interface{}) bool {
@@ -1075,6 +1083,9 @@ func (this *Options) Equal(that interface{}) bool {
 	if this.TotalShards != that1.TotalShards {
 		return false
 	}
+	if this.QueryEngine != that1.QueryEngine {
+		return false
+	}
 	return true
 }
 func (this *Hints) Equal(that interface{}) bool {
@@ -1237,11 +1248,12 @@ func (this *Options) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 7)
+	s := make([]string, 0, 8)
 	s = append(s, "&querymiddleware.Options{")
 	s = append(s, "CacheDisabled: "+fmt.Sprintf("%#v", this.CacheDisabled)+",\n")
 	s = append(s, "ShardingDisabled: "+fmt.Sprintf("%#v", this.ShardingDisabled)+",\n")
 	s = append(s, "TotalShards: "+fmt.Sprintf("%#v", this.TotalShards)+",\n")
+	s = append(s, "QueryEngine: "+fmt.Sprintf("%#v", this.QueryEngine)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -1739,6 +1751,13 @@ func (m *Options) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.QueryEngine) > 0 {
+		i -= len(m.QueryEngine)
+		copy(dAtA[i:], m.QueryEngine)
+		i = encodeVarintModel(dAtA, i, uint64(len(m.QueryEngine)))
+		i--
+		dAtA[i] = 0x22
+	}
 	if m.TotalShards != 0 {
 		i = encodeVarintModel(dAtA, i, uint64(m.TotalShards))
 		i--
@@ -2019,6 +2038,10 @@ func (m *Options) Size() (n int) {
 	if m.TotalShards != 0 {
 		n += 1 + sovModel(uint64(m.TotalShards))
 	}
+	l = len(m.QueryEngine)
+	if l > 0 {
+		n += 1 + l + sovModel(uint64(l))
+	}
 	return n
 }
 
@@ -2172,6 +2195,7 @@ func (this *Options) String() string {
 		`CacheDisabled:` + fmt.Sprintf("%v", this.CacheDisabled) + `,`,
 		`ShardingDisabled:` + fmt.Sprintf("%v", this.ShardingDisabled) + `,`,
 		`TotalShards:` + fmt.Sprintf("%v", this.TotalShards) + `,`,
+		`QueryEngine:` + fmt.Sprintf("%v", this.QueryEngine) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -3655,6 +3679,38 @@ func (m *Options) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field QueryEngine", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowModel
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthModel
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthModel
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.QueryEngine = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipModel(dAtA[iNdEx:])
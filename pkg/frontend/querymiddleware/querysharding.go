@@ -8,6 +8,7 @@ package querymiddleware
 import (
 	"context"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/go-kit/log"
@@ -104,7 +105,7 @@ func (s *querySharding) Do(ctx context.Context, r Request) (Response, error) {
 		return nil, apierror.New(apierror.TypeBadData, err.Error())
 	}
 
-	totalShards := s.getShardsForQuery(tenantIDs, r, log)
+	totalShards := s.getShardsForQuery(ctx, tenantIDs, r, log)
 	if totalShards <= 1 {
 		level.Debug(log).Log("msg", "query sharding is disabled for this query or tenant")
 		return s.next.Do(ctx, r)
@@ -242,7 +243,7 @@ func (s *querySharding) shardQuery(query string, totalShards int) (string, *astm
 }
 
 // getShardsForQuery calculates and return the number of shards that should be used to run the query.
-func (s *querySharding) getShardsForQuery(tenantIDs []string, r Request, spanLog log.Logger) int {
+func (s *querySharding) getShardsForQuery(ctx context.Context, tenantIDs []string, r Request, spanLog log.Logger) int {
 	// Check if sharding is disabled for the given request.
 	if r.GetOptions().ShardingDisabled {
 		return 1
@@ -254,9 +255,29 @@ func (s *querySharding) getShardsForQuery(tenantIDs []string, r Request, spanLog
 		return 1
 	}
 
-	// Honor the number of shards specified in the request (if any).
+	// Honor the number of shards specified in the request (if any). Otherwise, if the cardinality
+	// of the query has been estimated, we reduce the number of shards so that each shard fetches
+	// about the target number of series.
 	if r.GetOptions().TotalShards > 0 {
 		totalShards = int(r.GetOptions().TotalShards)
+	} else if targetSeriesPerShard := validation.SmallestPositiveIntPerTenant(tenantIDs, s.limit.QueryShardingTargetSeriesPerShard); targetSeriesPerShard > 0 {
+		if estimatedSeries, ok := estimatedSeriesCountFromContext(ctx); ok {
+			prevTotalShards := totalShards
+			totalShards = util_math.Max(1, util_math.Min(totalShards, int(math.Ceil(float64(estimatedSeries)/float64(targetSeriesPerShard)))))
+
+			if prevTotalShards != totalShards {
+				level.Debug(spanLog).Log(
+					"msg", "number of shards has been adjusted to honor the target series per shard",
+					"updated total shards", totalShards,
+					"previous total shards", prevTotalShards,
+					"estimated series", estimatedSeries,
+					"target series per shard", targetSeriesPerShard)
+			}
+
+			if totalShards <= 1 {
+				return 1
+			}
+		}
 	}
 
 	maxShardedQueries := validation.SmallestPositiveIntPerTenant(tenantIDs, s.limit.QueryShardingMaxShardedQueries)
@@ -1089,6 +1089,93 @@ func TestQuerySharding_ShouldSupportMaxShardedQueries(t *testing.T) {
 	}
 }
 
+func TestQuerySharding_ShouldSupportTargetSeriesPerShard(t *testing.T) {
+	tests := map[string]struct {
+		estimatedSeries      *uint64
+		totalShards          int
+		targetSeriesPerShard int
+		optionTotalShards    int32
+		expectedShards       int
+	}{
+		"no estimated cardinality": {
+			totalShards:          16,
+			targetSeriesPerShard: 1000,
+			expectedShards:       16,
+		},
+		"target series per shard is disabled": {
+			estimatedSeries:      uint64Ptr(1000),
+			totalShards:          16,
+			targetSeriesPerShard: 0,
+			expectedShards:       16,
+		},
+		"estimated cardinality lower than target series per shard": {
+			estimatedSeries:      uint64Ptr(500),
+			totalShards:          16,
+			targetSeriesPerShard: 1000,
+			expectedShards:       1,
+		},
+		"estimated cardinality requires less shards than total shards": {
+			estimatedSeries:      uint64Ptr(4500),
+			totalShards:          16,
+			targetSeriesPerShard: 1000,
+			expectedShards:       5,
+		},
+		"estimated cardinality requires more shards than total shards": {
+			estimatedSeries:      uint64Ptr(100000),
+			totalShards:          16,
+			targetSeriesPerShard: 1000,
+			expectedShards:       16,
+		},
+		"total shards specified in the request options": {
+			estimatedSeries:      uint64Ptr(500),
+			totalShards:          16,
+			targetSeriesPerShard: 1000,
+			optionTotalShards:    8,
+			expectedShards:       8,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			req := &PrometheusRangeQueryRequest{
+				Path:    "/query_range",
+				Start:   util.TimeToMillis(start),
+				End:     util.TimeToMillis(end),
+				Step:    step.Milliseconds(),
+				Query:   "sum(metric)",
+				Options: Options{TotalShards: testData.optionTotalShards},
+			}
+
+			limits := mockLimits{
+				totalShards:          testData.totalShards,
+				targetSeriesPerShard: testData.targetSeriesPerShard,
+			}
+			shardingware := newQueryShardingMiddleware(log.NewNopLogger(), newEngine(), limits, nil)
+
+			downstream := &mockHandler{}
+			downstream.On("Do", mock.Anything, mock.Anything).Return(&PrometheusResponse{
+				Status: statusSuccess, Data: &PrometheusData{
+					ResultType: string(parser.ValueTypeVector),
+				},
+			}, nil)
+
+			ctx := user.InjectOrgID(context.Background(), "test")
+			if testData.estimatedSeries != nil {
+				ctx = contextWithEstimatedSeriesCount(ctx, *testData.estimatedSeries)
+			}
+
+			res, err := shardingware.Wrap(downstream).Do(ctx, req)
+			require.NoError(t, err)
+			assert.Equal(t, statusSuccess, res.(*PrometheusResponse).GetStatus())
+			downstream.AssertNumberOfCalls(t, "Do", testData.expectedShards)
+		})
+	}
+}
+
+func uint64Ptr(v uint64) *uint64 {
+	return &v
+}
+
 func TestQuerySharding_ShouldReturnErrorOnDownstreamHandlerFailure(t *testing.T) {
 	req := &PrometheusRangeQueryRequest{
 		Path:  "/query_range",
@@ -36,6 +36,10 @@ const (
 
 	// noStoreValue is the value that cacheControlHeader has if the response indicates that the results should not be cached.
 	noStoreValue = "no-store"
+
+	// resultsCacheSchemaVersion is the version of the format of the entries stored in the results cache.
+	// Bump it whenever the format of CachedResponse or of the cache keys changes in an incompatible way.
+	resultsCacheSchemaVersion = 1
 )
 
 var (
@@ -79,7 +83,7 @@ func errUnsupportedResultsCacheBackend(unsupportedBackend string) error {
 
 // newResultsCache creates a new results cache based on the input configuration.
 func newResultsCache(cfg ResultsCacheConfig, logger log.Logger, reg prometheus.Registerer) (cache.Cache, error) {
-	client, err := cache.CreateClient("frontend-cache", cfg.BackendConfig, logger, reg)
+	client, err := cache.CreateClient("frontend-cache", resultsCacheSchemaVersion, cfg.BackendConfig, logger, reg)
 	if err != nil {
 		return nil, err
 	} else if client == nil {
@@ -141,6 +141,7 @@ func NewTripperware(
 	}
 	return MergeTripperwares(
 		newActiveUsersTripperware(log, registerer),
+		newConcurrencyLimitTripperware(limits, log, registerer),
 		queryRangeTripperware,
 	), err
 }
@@ -166,21 +167,20 @@ func newQueryTripperware(
 		queryRangeMiddleware = append(queryRangeMiddleware, newInstrumentMiddleware("step_align", metrics, log), newStepAlignMiddleware())
 	}
 
-	// Inject the middleware to split requests by interval + results cache (if at least one of the two is enabled).
-	if cfg.SplitQueriesByInterval > 0 || cfg.CacheResults {
-		var c cache.Cache
-
-		// Init the cache client.
-		if cfg.CacheResults {
-			var err error
+	// Init the cache client.
+	var c cache.Cache
+	if cfg.CacheResults {
+		var err error
 
-			c, err = newResultsCache(cfg.ResultsCacheConfig, log, registerer)
-			if err != nil {
-				return nil, err
-			}
-			c = cache.NewCompression(cfg.ResultsCacheConfig.Compression, c, log)
+		c, err = newResultsCache(cfg.ResultsCacheConfig, log, registerer)
+		if err != nil {
+			return nil, err
 		}
+		c = cache.NewCompression(cfg.ResultsCacheConfig.Compression, c, log)
+	}
 
+	// Inject the middleware to split requests by interval + results cache (if at least one of the two is enabled).
+	if cfg.SplitQueriesByInterval > 0 || cfg.CacheResults {
 		shouldCache := func(r Request) bool {
 			return !r.GetOptions().CacheDisabled
 		}
@@ -211,6 +211,22 @@ func newQueryTripperware(
 			limits,
 			registerer,
 		)
+
+		// The cardinality estimation is stored in the results cache, so it's only available if the cache is enabled.
+		if c != nil {
+			cardinalityEstimationMiddleware := newCardinalityEstimationMiddleware(limits, c, log, registerer)
+			queryRangeMiddleware = append(
+				queryRangeMiddleware,
+				newInstrumentMiddleware("cardinality_estimation", metrics, log),
+				cardinalityEstimationMiddleware,
+			)
+			queryInstantMiddleware = append(
+				queryInstantMiddleware,
+				newInstrumentMiddleware("cardinality_estimation", metrics, log),
+				cardinalityEstimationMiddleware,
+			)
+		}
+
 		queryRangeMiddleware = append(
 			queryRangeMiddleware,
 			newInstrumentMiddleware("querysharding", metrics, log),
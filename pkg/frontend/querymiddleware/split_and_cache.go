@@ -32,7 +32,8 @@ import (
 )
 
 const (
-	// Cache entries for 7 days. We're not disabling TTL because the backend client currently doesn't support it.
+	// Cache entries for 7 days by default, unless overridden for the tenant. We're not disabling
+	// TTL because the backend client currently doesn't support it.
 	resultsCacheTTL = 7 * 24 * time.Hour
 )
 
@@ -125,7 +126,9 @@ func (s *splitAndCacheMiddleware) Do(ctx context.Context, req Request) (Response
 		return nil, err
 	}
 
-	isCacheEnabled := s.cacheEnabled && (s.shouldCacheReq == nil || s.shouldCacheReq(req))
+	isCacheEnabled := s.cacheEnabled && (s.shouldCacheReq == nil || s.shouldCacheReq(req)) && s.isQueryLengthCachable(tenantIDs, req)
+	cacheUnalignedRequests := s.cacheUnalignedRequests || s.cacheUnalignedRequestsForTenants(tenantIDs)
+	cacheTTL := s.cacheTTL(tenantIDs)
 	maxCacheFreshness := validation.MaxDurationPerTenant(tenantIDs, s.limits.MaxCacheFreshness)
 	maxCacheTime := int64(model.Now().Add(-maxCacheFreshness))
 
@@ -137,7 +140,7 @@ func (s *splitAndCacheMiddleware) Do(ctx context.Context, req Request) (Response
 
 		for _, splitReq := range splitReqs {
 			// Do not try to pick response from cache at all if the request is not cachable.
-			if !isRequestCachable(splitReq.orig, maxCacheTime, s.cacheUnalignedRequests, s.logger) {
+			if !isRequestCachable(splitReq.orig, maxCacheTime, cacheUnalignedRequests, s.logger) {
 				splitReq.downstreamRequests = []Request{splitReq.orig}
 				continue
 			}
@@ -211,7 +214,7 @@ func (s *splitAndCacheMiddleware) Do(ctx context.Context, req Request) (Response
 			}
 
 			// Skip caching if the request is not cachable.
-			if !isRequestCachable(splitReq.orig, maxCacheTime, s.cacheUnalignedRequests, s.logger) {
+			if !isRequestCachable(splitReq.orig, maxCacheTime, cacheUnalignedRequests, s.logger) {
 				continue
 			}
 
@@ -249,7 +252,7 @@ func (s *splitAndCacheMiddleware) Do(ctx context.Context, req Request) (Response
 			}
 
 			// Put back into the cache the filtered ones.
-			s.storeCacheExtents(ctx, splitReq.cacheKey, filteredExtents)
+			s.storeCacheExtents(ctx, splitReq.cacheKey, filteredExtents, cacheTTL)
 		}
 	}
 
@@ -264,6 +267,32 @@ func (s *splitAndCacheMiddleware) Do(ctx context.Context, req Request) (Response
 	return s.merger.MergeResponse(responses...)
 }
 
+// isQueryLengthCachable returns whether the length (in time) of the query is within the max
+// cacheable query length of the tenants.
+func (s *splitAndCacheMiddleware) isQueryLengthCachable(tenantIDs []string, req Request) bool {
+	maxQueryLength := validation.SmallestPositiveNonZeroDurationPerTenant(tenantIDs, s.limits.ResultsCacheMaxQueryLength)
+	return maxQueryLength == 0 || req.GetEnd()-req.GetStart() <= maxQueryLength.Milliseconds()
+}
+
+// cacheUnalignedRequestsForTenants returns whether requests that are not step-aligned
+// can be cached for all the tenants.
+func (s *splitAndCacheMiddleware) cacheUnalignedRequestsForTenants(tenantIDs []string) bool {
+	for _, tenantID := range tenantIDs {
+		if !s.limits.ResultsCacheUnalignedRequests(tenantID) {
+			return false
+		}
+	}
+	return len(tenantIDs) > 0
+}
+
+// cacheTTL returns the time to live of the results cache entries, which is the smallest one of the tenants.
+func (s *splitAndCacheMiddleware) cacheTTL(tenantIDs []string) time.Duration {
+	if ttl := validation.SmallestPositiveNonZeroDurationPerTenant(tenantIDs, s.limits.ResultsCacheTTL); ttl > 0 {
+		return ttl
+	}
+	return resultsCacheTTL
+}
+
 // splitRequestByInterval splits the given Request by configured interval. Returns the input request if splitting is disabled.
 func (s *splitAndCacheMiddleware) splitRequestByInterval(req Request) (splitRequests, error) {
 	if !s.splitEnabled {
@@ -349,7 +378,7 @@ func (s *splitAndCacheMiddleware) fetchCacheExtents(ctx context.Context, keys []
 }
 
 // storeCacheExtents stores the extents for given key in the cache.
-func (s *splitAndCacheMiddleware) storeCacheExtents(ctx context.Context, key string, extents []Extent) {
+func (s *splitAndCacheMiddleware) storeCacheExtents(ctx context.Context, key string, extents []Extent, ttl time.Duration) {
 	buf, err := proto.Marshal(&CachedResponse{
 		Key:     key,
 		Extents: extents,
@@ -359,7 +388,7 @@ func (s *splitAndCacheMiddleware) storeCacheExtents(ctx context.Context, key str
 		return
 	}
 
-	s.cache.Store(ctx, map[string][]byte{cacheHashKey(key): buf}, resultsCacheTTL)
+	s.cache.Store(ctx, map[string][]byte{cacheHashKey(key): buf}, ttl)
 }
 
 // splitRequest holds information about a split request.
@@ -343,6 +343,99 @@ func TestSplitAndCacheMiddleware_ResultsCache_EnabledCachingOfStepUnalignedReque
 	assert.Equal(t, 2, cacheBackend.CountStoreCalls())
 }
 
+func TestSplitAndCacheMiddleware_ResultsCache_TenantLimits(t *testing.T) {
+	tests := map[string]struct {
+		limits mockLimits
+		step   int64
+
+		// The expected number of calls after running the same request twice.
+		expectedDownstreamReqs int
+		expectedFetchCalls     int
+		expectedStoreCalls     int
+	}{
+		"step-unaligned request should not be cached by default": {
+			limits:                 mockLimits{},
+			step:                   13 * 1000,
+			expectedDownstreamReqs: 2,
+			expectedFetchCalls:     0,
+			expectedStoreCalls:     0,
+		},
+		"step-unaligned request should be cached if enabled for the tenant": {
+			limits:                 mockLimits{cacheUnaligned: true},
+			step:                   13 * 1000,
+			expectedDownstreamReqs: 1,
+			expectedFetchCalls:     2,
+			expectedStoreCalls:     1,
+		},
+		"request should not be cached if longer than the max cacheable query length": {
+			limits:                 mockLimits{resultsCacheMaxLen: time.Hour},
+			step:                   60 * 1000,
+			expectedDownstreamReqs: 2,
+			expectedFetchCalls:     0,
+			expectedStoreCalls:     0,
+		},
+		"request should be cached if within the max cacheable query length": {
+			limits:                 mockLimits{resultsCacheMaxLen: 2 * time.Hour},
+			step:                   60 * 1000,
+			expectedDownstreamReqs: 1,
+			expectedFetchCalls:     2,
+			expectedStoreCalls:     1,
+		},
+		"request should not be picked up from the cache once the tenant's TTL has expired": {
+			limits:                 mockLimits{resultsCacheTTL: time.Nanosecond},
+			step:                   60 * 1000,
+			expectedDownstreamReqs: 2,
+			expectedFetchCalls:     2,
+			expectedStoreCalls:     2,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			cacheBackend := cache.NewInstrumentedMockCache()
+
+			mw := newSplitAndCacheMiddleware(
+				true,
+				true,
+				24*time.Hour,
+				false,
+				testData.limits,
+				PrometheusCodec,
+				cacheBackend,
+				constSplitter(day),
+				PrometheusResponseExtractor{},
+				resultsCacheAlwaysEnabled,
+				log.NewNopLogger(),
+				prometheus.NewPedanticRegistry(),
+			)
+
+			downstreamReqs := 0
+			rc := mw.Wrap(HandlerFunc(func(_ context.Context, req Request) (Response, error) {
+				downstreamReqs++
+				return mkAPIResponse(req.GetStart(), req.GetEnd(), req.GetStep()), nil
+			}))
+
+			req := Request(&PrometheusRangeQueryRequest{
+				Path:  "/api/v1/query_range",
+				Start: parseTimeRFC3339(t, "2021-10-15T10:00:00Z").Unix() * 1000,
+				End:   parseTimeRFC3339(t, "2021-10-15T12:00:00Z").Unix() * 1000,
+				Step:  testData.step,
+				Query: `{__name__=~".+"}`,
+			})
+
+			ctx := user.InjectOrgID(context.Background(), "1")
+			for i := 0; i < 2; i++ {
+				_, err := rc.Do(ctx, req)
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, testData.expectedDownstreamReqs, downstreamReqs)
+			assert.Equal(t, testData.expectedFetchCalls, cacheBackend.CountFetchCalls())
+			assert.Equal(t, testData.expectedStoreCalls, cacheBackend.CountStoreCalls())
+		})
+	}
+}
+
 func TestSplitAndCacheMiddleware_ResultsCache_ShouldNotCacheRequestEarlierThanMaxCacheFreshness(t *testing.T) {
 	const (
 		maxCacheFreshness = 10 * time.Minute
@@ -860,7 +953,7 @@ func TestSplitAndCacheMiddleware_ResultsCache_ExtentsEdgeCases(t *testing.T) {
 
 			// Store all extents fixtures in the cache.
 			cacheKey := cacheSplitter.GenerateCacheKey(userID, testData.req)
-			mw.storeCacheExtents(ctx, cacheKey, testData.cachedExtents)
+			mw.storeCacheExtents(ctx, cacheKey, testData.cachedExtents, resultsCacheTTL)
 
 			// Run the request.
 			actualRes, err := mw.Do(ctx, testData.req)
@@ -910,8 +1003,8 @@ func TestSplitAndCacheMiddleware_StoreAndFetchCacheExtents(t *testing.T) {
 	})
 
 	t.Run("fetchCacheExtents() should return a slice with the same number of input keys and some extends filled up on partial cache hit", func(t *testing.T) {
-		mw.storeCacheExtents(ctx, "key-1", []Extent{mkExtent(10, 20)})
-		mw.storeCacheExtents(ctx, "key-3", []Extent{mkExtent(20, 30), mkExtent(40, 50)})
+		mw.storeCacheExtents(ctx, "key-1", []Extent{mkExtent(10, 20)}, resultsCacheTTL)
+		mw.storeCacheExtents(ctx, "key-3", []Extent{mkExtent(20, 30), mkExtent(40, 50)}, resultsCacheTTL)
 
 		actual := mw.fetchCacheExtents(ctx, []string{"key-1", "key-2", "key-3"})
 		expected := [][]Extent{{mkExtent(10, 20)}, nil, {mkExtent(20, 30), mkExtent(40, 50)}}
@@ -924,7 +1017,7 @@ func TestSplitAndCacheMiddleware_StoreAndFetchCacheExtents(t *testing.T) {
 		require.NoError(t, err)
 		cacheBackend.Store(ctx, map[string][]byte{cacheHashKey("key-1"): buf}, 0)
 
-		mw.storeCacheExtents(ctx, "key-3", []Extent{mkExtent(20, 30), mkExtent(40, 50)})
+		mw.storeCacheExtents(ctx, "key-3", []Extent{mkExtent(20, 30), mkExtent(40, 50)}, resultsCacheTTL)
 
 		actual := mw.fetchCacheExtents(ctx, []string{"key-1", "key-2", "key-3"})
 		expected := [][]Extent{nil, nil, {mkExtent(20, 30), mkExtent(40, 50)}}
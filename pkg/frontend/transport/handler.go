@@ -29,13 +29,20 @@ import (
 	querier_stats "github.com/grafana/mimir/pkg/querier/stats"
 	"github.com/grafana/mimir/pkg/tenant"
 	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/globalerror"
+	"github.com/grafana/mimir/pkg/util/httpgrpcutil"
 	util_log "github.com/grafana/mimir/pkg/util/log"
+	"github.com/grafana/mimir/pkg/util/validation"
 )
 
 const (
 	// StatusClientClosedRequest is the status code for when a client request cancellation of an http request
 	StatusClientClosedRequest = 499
 	ServiceTimingHeaderName   = "Server-Timing"
+	QueryMemoryHeaderName     = "Query-Memory-Bytes"
+
+	// maxErrorBodyPeekBytes is the max size of the error response body searched for the error code.
+	maxErrorBodyPeekBytes = 64 * 1024
 )
 
 var (
@@ -49,12 +56,25 @@ type HandlerConfig struct {
 	LogQueriesLongerThan time.Duration `yaml:"log_queries_longer_than"`
 	MaxBodySize          int64         `yaml:"max_body_size" category:"advanced"`
 	QueryStatsEnabled    bool          `yaml:"query_stats_enabled" category:"advanced"`
+	SlowQueryLogFile     string        `yaml:"slow_query_log_file" category:"experimental"`
 }
 
 func (cfg *HandlerConfig) RegisterFlags(f *flag.FlagSet) {
 	f.DurationVar(&cfg.LogQueriesLongerThan, "query-frontend.log-queries-longer-than", 0, "Log queries that are slower than the specified duration. Set to 0 to disable. Set to < 0 to enable on all queries.")
 	f.Int64Var(&cfg.MaxBodySize, "query-frontend.max-body-size", 10*1024*1024, "Max body size for downstream prometheus.")
 	f.BoolVar(&cfg.QueryStatsEnabled, "query-frontend.query-stats-enabled", true, "False to disable query statistics tracking. When enabled, a message with some statistics is logged for every query.")
+	f.StringVar(&cfg.SlowQueryLogFile, "query-frontend.slow-query-log-file", "", "File where the queries slower than the tenant's -query-frontend.slow-query-log-threshold are logged in JSON format, with their full parameters, headers and statistics. The values of the headers and parameters that may contain secrets are redacted. When empty, the slow query log is disabled.")
+}
+
+// Limits are the per-tenant limits used by the Handler.
+type Limits interface {
+	// QuerySLOLatencyThreshold returns the max response time of the queries meeting the tenant's latency
+	// objective, or 0 if the query SLO tracking is disabled for the tenant.
+	QuerySLOLatencyThreshold(userID string) time.Duration
+
+	// SlowQueryLogThreshold returns the response time above which the tenant's queries are logged
+	// to the slow query log, or 0 if disabled for the tenant.
+	SlowQueryLogThreshold(userID string) time.Duration
 }
 
 // Handler accepts queries and forwards them to RoundTripper. It can log slow queries,
@@ -63,6 +83,8 @@ type Handler struct {
 	cfg          HandlerConfig
 	log          log.Logger
 	roundTripper http.RoundTripper
+	limits       Limits
+	slowQueryLog log.Logger
 
 	// Metrics.
 	querySeconds *prometheus.CounterVec
@@ -70,14 +92,23 @@ type Handler struct {
 	queryBytes   *prometheus.CounterVec
 	queryChunks  *prometheus.CounterVec
 	activeUsers  *util.ActiveUsersCleanupService
+
+	// Query SLO metrics.
+	sloQueries              *prometheus.CounterVec
+	sloSuccessfulQueries    *prometheus.CounterVec
+	sloQueriesWithinLatency *prometheus.CounterVec
+	sloRejectedQueries      *prometheus.CounterVec
 }
 
-// NewHandler creates a new frontend handler.
-func NewHandler(cfg HandlerConfig, roundTripper http.RoundTripper, log log.Logger, reg prometheus.Registerer) http.Handler {
+// NewHandler creates a new frontend handler. The query SLO metrics are tracked only if limits is not nil,
+// and the slow queries are logged only if both limits and slowQueryLog are not nil.
+func NewHandler(cfg HandlerConfig, roundTripper http.RoundTripper, limits Limits, log log.Logger, slowQueryLog log.Logger, reg prometheus.Registerer) http.Handler {
 	h := &Handler{
 		cfg:          cfg,
 		log:          log,
 		roundTripper: roundTripper,
+		limits:       limits,
+		slowQueryLog: slowQueryLog,
 	}
 
 	if cfg.QueryStatsEnabled {
@@ -100,14 +131,32 @@ func NewHandler(cfg HandlerConfig, roundTripper http.RoundTripper, log log.Logge
 			Name: "cortex_query_fetched_chunks_total",
 			Help: "Number of chunks fetched to execute a query.",
 		}, []string{"user"})
+	}
+
+	if limits != nil {
+		h.sloQueries = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_query_frontend_slo_queries_total",
+			Help: "Total number of queries tracked by the query SLO of the tenant. Queries canceled by the client or rejected because of rate or concurrency limits are not tracked.",
+		}, []string{"user"})
+
+		h.sloSuccessfulQueries = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_query_frontend_slo_successful_queries_total",
+			Help: "Total number of queries tracked by the query SLO of the tenant which didn't fail with a server error.",
+		}, []string{"user"})
 
-		h.activeUsers = util.NewActiveUsersCleanupWithDefaultValues(func(user string) {
-			h.querySeconds.DeleteLabelValues(user, "true")
-			h.querySeconds.DeleteLabelValues(user, "false")
-			h.querySeries.DeleteLabelValues(user)
-			h.queryBytes.DeleteLabelValues(user)
-			h.queryChunks.DeleteLabelValues(user)
-		})
+		h.sloQueriesWithinLatency = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_query_frontend_slo_queries_within_latency_objective_total",
+			Help: "Total number of successful queries tracked by the query SLO of the tenant whose response time is within the latency objective.",
+		}, []string{"user"})
+
+		h.sloRejectedQueries = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_query_frontend_slo_rejected_queries_total",
+			Help: "Total number of queries of the tenants with a query SLO rejected with a 429 status code because of rate or concurrency limits. These queries are not tracked by the query SLO.",
+		}, []string{"user"})
+	}
+
+	if cfg.QueryStatsEnabled || limits != nil {
+		h.activeUsers = util.NewActiveUsersCleanupWithDefaultValues(h.cleanupMetrics)
 		// If cleaner stops or fail, we will simply not clean the metrics for inactive users.
 		_ = h.activeUsers.StartAsync(context.Background())
 	}
@@ -115,6 +164,22 @@ func NewHandler(cfg HandlerConfig, roundTripper http.RoundTripper, log log.Logge
 	return h
 }
 
+func (f *Handler) cleanupMetrics(user string) {
+	if f.cfg.QueryStatsEnabled {
+		f.querySeconds.DeleteLabelValues(user, "true")
+		f.querySeconds.DeleteLabelValues(user, "false")
+		f.querySeries.DeleteLabelValues(user)
+		f.queryBytes.DeleteLabelValues(user)
+		f.queryChunks.DeleteLabelValues(user)
+	}
+	if f.limits != nil {
+		f.sloQueries.DeleteLabelValues(user)
+		f.sloSuccessfulQueries.DeleteLabelValues(user)
+		f.sloQueriesWithinLatency.DeleteLabelValues(user)
+		f.sloRejectedQueries.DeleteLabelValues(user)
+	}
+}
+
 func (f *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	var (
 		stats       *querier_stats.Stats
@@ -133,6 +198,20 @@ func (f *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		_ = r.Body.Close()
 	}()
 
+	// Stop processing the request once the deadline budget set by the client is exhausted.
+	// The remaining budget is propagated to the downstream components.
+	if value := r.Header.Get(httpgrpcutil.DeadlineBudgetHeader); value != "" {
+		budget, err := httpgrpcutil.ParseDeadlineBudget(value)
+		if err != nil {
+			writeError(w, apierror.New(apierror.TypeBadData, err.Error()))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), budget)
+		defer cancel()
+		r = r.WithContext(ctx)
+	}
+
 	// Buffer the body for later use to track slow queries.
 	var buf bytes.Buffer
 	r.Body = http.MaxBytesReader(w, r.Body, f.cfg.MaxBodySize)
@@ -142,11 +221,24 @@ func (f *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	resp, err := f.roundTripper.RoundTrip(r)
 	queryResponseTime := time.Since(startTime)
 
+	slowQueryLogThreshold := f.slowQueryLogThreshold(r)
+	shouldLogSlowQuery := slowQueryLogThreshold > 0 && queryResponseTime > slowQueryLogThreshold
+
 	if err != nil {
-		writeError(w, err)
+		sw := &statusCodeWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		writeError(sw, err)
+		f.reportQuerySLO(r, sw.statusCode, queryResponseTime)
+		if shouldLogSlowQuery {
+			f.reportSlowQueryLog(r, f.parseRequestQueryString(r, buf), sw.statusCode, queryResponseTime, slowQueryLogThreshold, stats)
+		}
 		return
 	}
 
+	// The response body may be streamed, so make sure it's released if the client goes away.
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
 	hs := w.Header()
 	for h, vs := range resp.Header {
 		hs[h] = vs
@@ -154,21 +246,38 @@ func (f *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	if f.cfg.QueryStatsEnabled {
 		writeServiceTimingHeader(queryResponseTime, hs, stats)
+		writeQueryMemoryHeader(hs, stats)
+	}
+
+	// Expose the code of the error returned by the querier, if any. Only the beginning of the error
+	// response is buffered and searched for the code, and the rest is streamed.
+	var body io.Reader = resp.Body
+	if resp.StatusCode/100 != 2 {
+		peek, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyPeekBytes))
+		if err == nil {
+			globalerror.SetHeader(hs, string(peek))
+		}
+		body = io.MultiReader(bytes.NewReader(peek), resp.Body)
 	}
 
 	w.WriteHeader(resp.StatusCode)
 	// we don't check for copy error as there is no much we can do at this point
-	_, _ = io.Copy(w, resp.Body)
+	_, _ = io.Copy(w, body)
+
+	f.reportQuerySLO(r, resp.StatusCode, queryResponseTime)
 
 	// Check whether we should parse the query string.
 	shouldReportSlowQuery := f.cfg.LogQueriesLongerThan > 0 && queryResponseTime > f.cfg.LogQueriesLongerThan
-	if shouldReportSlowQuery || f.cfg.QueryStatsEnabled {
+	if shouldReportSlowQuery || shouldLogSlowQuery || f.cfg.QueryStatsEnabled {
 		queryString = f.parseRequestQueryString(r, buf)
 	}
 
 	if shouldReportSlowQuery {
 		f.reportSlowQuery(r, queryString, queryResponseTime)
 	}
+	if shouldLogSlowQuery {
+		f.reportSlowQueryLog(r, queryString, resp.StatusCode, queryResponseTime, slowQueryLogThreshold, stats)
+	}
 	if f.cfg.QueryStatsEnabled {
 		f.reportQueryStats(r, queryString, queryResponseTime, stats)
 	}
@@ -213,16 +322,63 @@ func (f *Handler) reportQueryStats(r *http.Request, queryString url.Values, quer
 		"method", r.Method,
 		"path", r.URL.Path,
 		"response_time", queryResponseTime,
-		"query_wall_time_seconds", wallTime.Seconds(),
-		"fetched_series_count", numSeries,
-		"fetched_chunk_bytes", numBytes,
-		"fetched_chunks_count", numChunks,
-		"sharded_queries", stats.LoadShardedQueries(),
-	}, formatQueryString(queryString)...)
+	}, formatQueryStats(stats)...)
+	logMessage = append(logMessage, formatQueryString(queryString)...)
 
 	level.Info(util_log.WithContext(r.Context(), f.log)).Log(logMessage...)
 }
 
+func formatQueryStats(stats *querier_stats.Stats) []interface{} {
+	return []interface{}{
+		"query_wall_time_seconds", stats.LoadWallTime().Seconds(),
+		"fetched_series_count", stats.LoadFetchedSeries(),
+		"fetched_chunk_bytes", stats.LoadFetchedChunkBytes(),
+		"fetched_chunks_count", stats.LoadFetchedChunks(),
+		"sharded_queries", stats.LoadShardedQueries(),
+		"fetched_blocks_from_bucket", stats.LoadFetchedBlocksFromBucket(),
+		"selector_peak_memory_bytes", stats.LoadSelectorPeakMemoryBytes(),
+		"eval_peak_memory_bytes", stats.LoadEvalPeakMemoryBytes(),
+		"encoding_peak_memory_bytes", stats.LoadEncodingPeakMemoryBytes(),
+	}
+}
+
+// reportQuerySLO tracks the query in the query SLO of the tenant. Queries failed with a server error count
+// against the success objective, while successful queries count against the latency objective if their
+// response time is above the tenant's threshold. Queries canceled by the client are not tracked, and queries
+// rejected because of rate or concurrency limits are counted separately, since they're neither failures nor
+// successes of the service.
+func (f *Handler) reportQuerySLO(r *http.Request, statusCode int, queryResponseTime time.Duration) {
+	if f.limits == nil || statusCode == StatusClientClosedRequest {
+		return
+	}
+
+	tenantIDs, err := tenant.TenantIDs(r.Context())
+	if err != nil {
+		return
+	}
+
+	// The strictest objective applies to queries spanning multiple tenants.
+	threshold := validation.SmallestPositiveNonZeroDurationPerTenant(tenantIDs, f.limits.QuerySLOLatencyThreshold)
+	if threshold <= 0 {
+		return
+	}
+
+	userID := tenant.JoinTenantIDs(tenantIDs)
+	f.activeUsers.UpdateUserTimestamp(userID, time.Now())
+	if statusCode == http.StatusTooManyRequests {
+		f.sloRejectedQueries.WithLabelValues(userID).Inc()
+		return
+	}
+
+	f.sloQueries.WithLabelValues(userID).Inc()
+	if statusCode/100 != 5 {
+		f.sloSuccessfulQueries.WithLabelValues(userID).Inc()
+		if queryResponseTime <= threshold {
+			f.sloQueriesWithinLatency.WithLabelValues(userID).Inc()
+		}
+	}
+}
+
 func (f *Handler) parseRequestQueryString(r *http.Request, bodyBuf bytes.Buffer) url.Values {
 	// Use previously buffered body.
 	r.Body = ioutil.NopCloser(&bodyBuf)
@@ -256,6 +412,8 @@ func writeError(w http.ResponseWriter, err error) {
 		}
 	}
 
+	globalerror.SetHeader(w.Header(), err.Error())
+
 	// if the error error is an APIError, ensure it gets written as a JSON response
 	if resp, ok := apierror.HTTPResponseFromError(err); ok {
 		_ = server.WriteResponse(w, resp)
@@ -265,6 +423,17 @@ func writeError(w http.ResponseWriter, err error) {
 	server.WriteError(w, err)
 }
 
+// statusCodeWriter is a http.ResponseWriter keeping track of the status code of the response.
+type statusCodeWriter struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (w *statusCodeWriter) WriteHeader(statusCode int) {
+	w.statusCode = statusCode
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
 func writeServiceTimingHeader(queryResponseTime time.Duration, headers http.Header, stats *querier_stats.Stats) {
 	if stats != nil {
 		parts := make([]string, 0)
@@ -274,6 +443,21 @@ func writeServiceTimingHeader(queryResponseTime time.Duration, headers http.Head
 	}
 }
 
+func writeQueryMemoryHeader(headers http.Header, stats *querier_stats.Stats) {
+	if stats != nil {
+		parts := []string{
+			memoryValue("selector", stats.LoadSelectorPeakMemoryBytes()),
+			memoryValue("eval", stats.LoadEvalPeakMemoryBytes()),
+			memoryValue("encoding", stats.LoadEncodingPeakMemoryBytes()),
+		}
+		headers.Set(QueryMemoryHeaderName, strings.Join(parts, ", "))
+	}
+}
+
+func memoryValue(stage string, bytes uint64) string {
+	return stage + "=" + strconv.FormatUint(bytes, 10)
+}
+
 func statsValue(name string, d time.Duration) string {
 	durationInMs := strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', -1, 64)
 	return name + ";dur=" + durationInMs
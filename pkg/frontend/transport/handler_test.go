@@ -7,11 +7,16 @@ package transport
 
 import (
 	"context"
+	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/pkg/errors"
@@ -21,6 +26,11 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/weaveworks/common/httpgrpc"
 	"github.com/weaveworks/common/user"
+
+	querier_stats "github.com/grafana/mimir/pkg/querier/stats"
+	"github.com/grafana/mimir/pkg/tenant"
+	"github.com/grafana/mimir/pkg/util/globalerror"
+	"github.com/grafana/mimir/pkg/util/httpgrpcutil"
 )
 
 type roundTripperFunc func(*http.Request) (*http.Response, error)
@@ -49,14 +59,16 @@ func TestWriteError(t *testing.T) {
 
 func TestHandler_ServeHTTP(t *testing.T) {
 	for _, tt := range []struct {
-		name            string
-		cfg             HandlerConfig
-		expectedMetrics int
+		name                 string
+		cfg                  HandlerConfig
+		expectedMetrics      int
+		expectedMemoryHeader string
 	}{
 		{
-			name:            "test handler with stats enabled",
-			cfg:             HandlerConfig{QueryStatsEnabled: true},
-			expectedMetrics: 4,
+			name:                 "test handler with stats enabled",
+			cfg:                  HandlerConfig{QueryStatsEnabled: true},
+			expectedMetrics:      4,
+			expectedMemoryHeader: "selector=300, eval=200, encoding=100",
 		},
 		{
 			name:            "test handler with stats disabled",
@@ -66,6 +78,11 @@ func TestHandler_ServeHTTP(t *testing.T) {
 	} {
 		t.Run(tt.name, func(t *testing.T) {
 			roundTripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				reqStats := querier_stats.FromContext(req.Context())
+				reqStats.UpdateSelectorPeakMemoryBytes(300)
+				reqStats.UpdateEvalPeakMemoryBytes(200)
+				reqStats.UpdateEncodingPeakMemoryBytes(100)
+
 				return &http.Response{
 					StatusCode: http.StatusOK,
 					Body:       io.NopCloser(strings.NewReader("{}")),
@@ -73,7 +90,7 @@ func TestHandler_ServeHTTP(t *testing.T) {
 			})
 
 			reg := prometheus.NewPedanticRegistry()
-			handler := NewHandler(tt.cfg, roundTripper, log.NewNopLogger(), reg)
+			handler := NewHandler(tt.cfg, roundTripper, nil, log.NewNopLogger(), nil, reg)
 
 			ctx := user.InjectOrgID(context.Background(), "12345")
 			req := httptest.NewRequest("GET", "/", nil)
@@ -83,6 +100,7 @@ func TestHandler_ServeHTTP(t *testing.T) {
 			handler.ServeHTTP(resp, req)
 			_, _ = io.ReadAll(resp.Body)
 			require.Equal(t, resp.Code, http.StatusOK)
+			assert.Equal(t, tt.expectedMemoryHeader, resp.Header().Get(QueryMemoryHeaderName))
 
 			count, err := promtest.GatherAndCount(
 				reg,
@@ -97,3 +115,244 @@ func TestHandler_ServeHTTP(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_ServeHTTPWithErrorCode(t *testing.T) {
+	roundTripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusUnprocessableEntity,
+			Body:       io.NopCloser(strings.NewReader(`{"status":"error","errorType":"execution","error":"` + globalerror.MaxSeriesPerQuery.Message("the query hit the max number of series limit") + `"}`)),
+		}, nil
+	})
+
+	handler := NewHandler(HandlerConfig{}, roundTripper, nil, log.NewNopLogger(), nil, nil)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req = req.WithContext(user.InjectOrgID(context.Background(), "12345"))
+	resp := httptest.NewRecorder()
+
+	handler.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+	assert.Equal(t, "err-mimir-max-series-per-query", resp.Header().Get(globalerror.HeaderName))
+	assert.Contains(t, resp.Body.String(), "(err-mimir-max-series-per-query)")
+}
+
+func TestHandler_ServeHTTPWithLargeErrorBody(t *testing.T) {
+	largeBody := strings.Repeat("x", 2*maxErrorBodyPeekBytes) + globalerror.MaxSeriesPerQuery.Message("")
+	roundTripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusInternalServerError,
+			Body:       io.NopCloser(strings.NewReader(largeBody)),
+		}, nil
+	})
+
+	handler := NewHandler(HandlerConfig{}, roundTripper, nil, log.NewNopLogger(), nil, nil)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req = req.WithContext(user.InjectOrgID(context.Background(), "12345"))
+	resp := httptest.NewRecorder()
+
+	// The code past the searched prefix isn't exposed, but the whole body is returned.
+	handler.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	assert.Empty(t, resp.Header().Get(globalerror.HeaderName))
+	assert.Equal(t, largeBody, resp.Body.String())
+}
+
+func TestHandler_ServeHTTPWithDeadlineBudget(t *testing.T) {
+	var propagatedBudget time.Duration
+	roundTripper := AdaptGrpcRoundTripperToHTTPRoundTripper(grpcRoundTripperFunc(func(ctx context.Context, req *httpgrpc.HTTPRequest) (*httpgrpc.HTTPResponse, error) {
+		var err error
+		propagatedBudget, _, err = httpgrpcutil.GetDeadlineBudget(req)
+		if err != nil {
+			return nil, err
+		}
+
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}))
+
+	handler := NewHandler(HandlerConfig{}, roundTripper, nil, log.NewNopLogger(), nil, nil)
+
+	t.Run("the request is stopped once the deadline budget is exhausted", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req = req.WithContext(user.InjectOrgID(context.Background(), "12345"))
+		req.Header.Set(httpgrpcutil.DeadlineBudgetHeader, "100")
+		resp := httptest.NewRecorder()
+
+		handler.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusGatewayTimeout, resp.Code)
+		assert.LessOrEqual(t, propagatedBudget, 100*time.Millisecond)
+		assert.Greater(t, propagatedBudget, time.Duration(0))
+	})
+
+	t.Run("an invalid deadline budget is rejected", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req = req.WithContext(user.InjectOrgID(context.Background(), "12345"))
+		req.Header.Set(httpgrpcutil.DeadlineBudgetHeader, "invalid")
+		resp := httptest.NewRecorder()
+
+		handler.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
+
+func TestHandler_QuerySLO(t *testing.T) {
+	resolver := tenant.DefaultResolver
+	tenant.WithDefaultResolver(tenant.NewMultiResolver())
+	t.Cleanup(func() {
+		tenant.WithDefaultResolver(resolver)
+	})
+
+	roundTripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if d, err := time.ParseDuration(req.URL.Query().Get("sleep")); err == nil {
+			time.Sleep(d)
+		}
+
+		switch req.URL.Query().Get("outcome") {
+		case "canceled":
+			return nil, context.Canceled
+		case "error":
+			return nil, errors.New("unknown error")
+		case "bad_data":
+			return &http.Response{StatusCode: http.StatusBadRequest, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+		case "server_error":
+			return &http.Response{StatusCode: http.StatusInternalServerError, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+		case "rate_limited":
+			return &http.Response{StatusCode: http.StatusTooManyRequests, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+	})
+
+	reg := prometheus.NewPedanticRegistry()
+	limits := mockLimits{sloLatencyThreshold: map[string]time.Duration{"user-1": 50 * time.Millisecond, "user-2": time.Hour}}
+	handler := NewHandler(HandlerConfig{}, roundTripper, limits, log.NewNopLogger(), nil, reg)
+
+	for _, req := range []struct {
+		orgID string
+		query string
+	}{
+		{orgID: "user-1", query: ""},
+		{orgID: "user-1", query: "sleep=100ms"},
+		{orgID: "user-1", query: "outcome=bad_data"},
+		{orgID: "user-1", query: "outcome=server_error"},
+		{orgID: "user-1", query: "outcome=error"},
+		{orgID: "user-1", query: "outcome=canceled"},
+		{orgID: "user-1", query: "outcome=rate_limited"},
+		{orgID: "user-1", query: "outcome=rate_limited"},
+		{orgID: "user-1|user-2", query: "sleep=100ms"},
+		{orgID: "user-2", query: "sleep=100ms"},
+		{orgID: "user-3", query: ""},
+	} {
+		r := httptest.NewRequest("GET", "/api/v1/query?"+req.query, nil)
+		r = r.WithContext(user.InjectOrgID(context.Background(), req.orgID))
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	// The tenant without threshold is not tracked, and the strictest threshold applies to queries spanning multiple tenants.
+	// The rate limited queries are counted separately.
+	assert.NoError(t, promtest.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_query_frontend_slo_queries_total Total number of queries tracked by the query SLO of the tenant. Queries canceled by the client or rejected because of rate or concurrency limits are not tracked.
+		# TYPE cortex_query_frontend_slo_queries_total counter
+		cortex_query_frontend_slo_queries_total{user="user-1"} 5
+		cortex_query_frontend_slo_queries_total{user="user-1|user-2"} 1
+		cortex_query_frontend_slo_queries_total{user="user-2"} 1
+		# HELP cortex_query_frontend_slo_successful_queries_total Total number of queries tracked by the query SLO of the tenant which didn't fail with a server error.
+		# TYPE cortex_query_frontend_slo_successful_queries_total counter
+		cortex_query_frontend_slo_successful_queries_total{user="user-1"} 3
+		cortex_query_frontend_slo_successful_queries_total{user="user-1|user-2"} 1
+		cortex_query_frontend_slo_successful_queries_total{user="user-2"} 1
+		# HELP cortex_query_frontend_slo_queries_within_latency_objective_total Total number of successful queries tracked by the query SLO of the tenant whose response time is within the latency objective.
+		# TYPE cortex_query_frontend_slo_queries_within_latency_objective_total counter
+		cortex_query_frontend_slo_queries_within_latency_objective_total{user="user-1"} 2
+		cortex_query_frontend_slo_queries_within_latency_objective_total{user="user-2"} 1
+		# HELP cortex_query_frontend_slo_rejected_queries_total Total number of queries of the tenants with a query SLO rejected with a 429 status code because of rate or concurrency limits. These queries are not tracked by the query SLO.
+		# TYPE cortex_query_frontend_slo_rejected_queries_total counter
+		cortex_query_frontend_slo_rejected_queries_total{user="user-1"} 2
+	`), "cortex_query_frontend_slo_queries_total", "cortex_query_frontend_slo_successful_queries_total", "cortex_query_frontend_slo_queries_within_latency_objective_total", "cortex_query_frontend_slo_rejected_queries_total"))
+}
+
+func TestHandler_SlowQueryLog(t *testing.T) {
+	roundTripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		// Consume the body like the downstream round trippers do.
+		_, _ = io.ReadAll(req.Body)
+		time.Sleep(20 * time.Millisecond)
+
+		if req.URL.Query().Get("outcome") == "error" {
+			return nil, errors.New("unknown error")
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+	})
+
+	slowQueryLogFile := filepath.Join(t.TempDir(), "slow-queries.log")
+	slowQueryLog, err := NewSlowQueryLogger(slowQueryLogFile)
+	require.NoError(t, err)
+
+	limits := mockLimits{slowQueryLogThreshold: map[string]time.Duration{"user-1": 10 * time.Millisecond, "user-2": time.Hour}}
+	handler := NewHandler(HandlerConfig{QueryStatsEnabled: true, MaxBodySize: 1024 * 1024}, roundTripper, limits, log.NewNopLogger(), slowQueryLog, prometheus.NewPedanticRegistry())
+
+	longQuery := `sum by (namespace) (rate(http_requests_total{job="api", namespace=~"` + strings.Repeat("x", 10000) + `"}[5m]))`
+	for _, req := range []struct {
+		orgID string
+		query string
+		body  url.Values
+	}{
+		{orgID: "user-1", query: "outcome=error"},
+		{orgID: "user-1", body: url.Values{"query": {longQuery}, "access_token": {"secret-value"}}},
+		{orgID: "user-2", query: "query=up"},
+		{orgID: "user-3", query: "query=up"},
+	} {
+		r := httptest.NewRequest("POST", "/api/v1/query?"+req.query, strings.NewReader(req.body.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.Header.Set("Authorization", "Bearer secret-value")
+		r.Header.Set("User-Agent", "test")
+		r = r.WithContext(user.InjectOrgID(context.Background(), req.orgID))
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	content, err := os.ReadFile(slowQueryLogFile)
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "secret-value")
+
+	// Only the queries of user-1 are slower than the tenant's threshold.
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 2)
+
+	var entries []map[string]interface{}
+	for _, line := range lines {
+		entry := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+
+	assert.Equal(t, "user-1", entries[0]["org_id"])
+	assert.Equal(t, "slow query", entries[0]["msg"])
+	assert.Equal(t, float64(http.StatusInternalServerError), entries[0]["status_code"])
+	assert.Equal(t, "error", entries[0]["param_outcome"])
+
+	assert.Equal(t, float64(http.StatusOK), entries[1]["status_code"])
+	assert.Equal(t, longQuery, entries[1]["param_query"])
+	assert.Equal(t, redactedValue, entries[1]["param_access_token"])
+	assert.Equal(t, redactedValue, entries[1]["header_Authorization"])
+	assert.Equal(t, "test", entries[1]["header_User-Agent"])
+	assert.Equal(t, "10ms", entries[1]["threshold"])
+	assert.Contains(t, entries[1], "fetched_series_count")
+}
+
+type mockLimits struct {
+	sloLatencyThreshold   map[string]time.Duration
+	slowQueryLogThreshold map[string]time.Duration
+}
+
+func (m mockLimits) QuerySLOLatencyThreshold(userID string) time.Duration {
+	return m.sloLatencyThreshold[userID]
+}
+
+func (m mockLimits) SlowQueryLogThreshold(userID string) time.Duration {
+	return m.slowQueryLogThreshold[userID]
+}
+
+type grpcRoundTripperFunc func(context.Context, *httpgrpc.HTTPRequest) (*httpgrpc.HTTPResponse, error)
+
+func (f grpcRoundTripperFunc) RoundTripGRPC(ctx context.Context, req *httpgrpc.HTTPRequest) (*httpgrpc.HTTPResponse, error) {
+	return f(ctx, req)
+}
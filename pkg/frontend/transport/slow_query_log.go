@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package transport
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+
+	querier_stats "github.com/grafana/mimir/pkg/querier/stats"
+	"github.com/grafana/mimir/pkg/tenant"
+	util_log "github.com/grafana/mimir/pkg/util/log"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+const redactedValue = "[REDACTED]"
+
+// sensitiveNameParts are the parts of the names of the headers and parameters whose values are
+// redacted from the slow query log.
+var sensitiveNameParts = []string{"authorization", "cookie", "token", "secret", "password", "passwd", "api-key", "api_key", "apikey"}
+
+// NewSlowQueryLogger returns a logger writing the slow query log in JSON format to the file at path,
+// which is created if it doesn't exist and appended to otherwise.
+func NewSlowQueryLogger(path string) (log.Logger, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open the slow query log file")
+	}
+	return log.With(log.NewJSONLogger(log.NewSyncWriter(f)), "ts", log.DefaultTimestampUTC), nil
+}
+
+// isSensitiveName returns whether the values of the header or parameter with the given name must be redacted.
+func isSensitiveName(name string) bool {
+	name = strings.ToLower(name)
+	for _, part := range sensitiveNameParts {
+		if strings.Contains(name, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// slowQueryLogThreshold returns the threshold above which the queries of the request's tenants are
+// logged to the slow query log, or 0 if disabled. The strictest threshold applies to queries spanning
+// multiple tenants.
+func (f *Handler) slowQueryLogThreshold(r *http.Request) time.Duration {
+	if f.slowQueryLog == nil || f.limits == nil {
+		return 0
+	}
+
+	tenantIDs, err := tenant.TenantIDs(r.Context())
+	if err != nil {
+		return 0
+	}
+	return validation.SmallestPositiveNonZeroDurationPerTenant(tenantIDs, f.limits.SlowQueryLogThreshold)
+}
+
+// reportSlowQueryLog logs the query to the slow query log, with its full parameters and statistics,
+// redacting the values of the sensitive headers and parameters.
+func (f *Handler) reportSlowQueryLog(r *http.Request, queryString url.Values, statusCode int, queryResponseTime, threshold time.Duration, stats *querier_stats.Stats) {
+	logMessage := []interface{}{
+		"msg", "slow query",
+		"method", r.Method,
+		"host", r.Host,
+		"path", r.URL.Path,
+		"status_code", statusCode,
+		"response_time", queryResponseTime.String(),
+		"threshold", threshold.String(),
+	}
+	if stats != nil {
+		logMessage = append(logMessage, formatQueryStats(stats)...)
+	}
+	for k, v := range queryString {
+		value := strings.Join(v, ",")
+		if isSensitiveName(k) {
+			value = redactedValue
+		}
+		logMessage = append(logMessage, "param_"+k, value)
+	}
+	for k, v := range r.Header {
+		value := strings.Join(v, ",")
+		if isSensitiveName(k) {
+			value = redactedValue
+		}
+		logMessage = append(logMessage, "header_"+k, value)
+	}
+
+	level.Info(util_log.WithContext(r.Context(), f.slowQueryLog)).Log(logMessage...)
+}
@@ -233,6 +233,9 @@ func (f *Frontend) Process(server frontendv1pb.Frontend_ProcessServer) error {
 			continue
 		}
 
+		// Update the deadline budget to account for the time spent in the queue.
+		httpgrpcutil.SetDeadlineBudgetFromContext(req.originalCtx, req.request)
+
 		// Handle the stream sending & receiving on a goroutine so we can
 		// monitoring the contexts in a select and cancel things appropriately.
 		resps := make(chan *frontendv1pb.ClientToFrontend, 1)
@@ -348,3 +351,9 @@ func (f *Frontend) CheckReady(_ context.Context) error {
 	level.Info(f.log).Log("msg", msg)
 	return errors.New(msg)
 }
+
+// QueuesDebugHandler writes the state of the tenant queues as JSON, to find out where the requests are queued.
+// The queues are the same as the query-scheduler ones, so the status has the same format.
+func (f *Frontend) QueuesDebugHandler(w http.ResponseWriter, _ *http.Request) {
+	util.WriteJSONResponse(w, f.requestQueue.Status(time.Now()))
+}
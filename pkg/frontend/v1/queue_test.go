@@ -7,7 +7,10 @@ package v1
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strconv"
 	"strings"
 	"testing"
@@ -23,6 +26,7 @@ import (
 	"google.golang.org/grpc/metadata"
 
 	"github.com/grafana/mimir/pkg/frontend/v1/frontendv1pb"
+	"github.com/grafana/mimir/pkg/scheduler/queue"
 )
 
 func setupFrontend(t *testing.T, config Config) (*Frontend, error) {
@@ -132,6 +136,33 @@ func TestRoundRobinQueues(t *testing.T) {
 	}
 }
 
+func TestQueuesDebugHandler(t *testing.T) {
+	var config Config
+	flagext.DefaultValues(&config)
+
+	f, err := setupFrontend(t, config)
+	require.NoError(t, err)
+
+	for i, userID := range []string{"user-1", "user-2", "user-2"} {
+		ctx := user.InjectOrgID(context.Background(), userID)
+		require.NoError(t, f.queueRequest(ctx, testReq(ctx, fmt.Sprintf("%d", i), userID)))
+	}
+
+	rec := httptest.NewRecorder()
+	f.QueuesDebugHandler(rec, httptest.NewRequest("GET", "/debug/queues/query-frontend", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	status := queue.QueuesStatus{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	require.Equal(t, 0, status.ConnectedQuerierWorkers)
+	require.Len(t, status.Tenants, 2)
+	require.Equal(t, "user-2", status.Tenants[0].Tenant)
+	require.Equal(t, 2, status.Tenants[0].Length)
+	require.Equal(t, 3, status.Tenants[0].MaxQueriers)
+	require.Equal(t, "user-1", status.Tenants[1].Tenant)
+	require.Equal(t, 1, status.Tenants[1].Length)
+}
+
 // This mock behaves as connected querier worker to frontend. It will remember each request
 // that frontend sends, and reply with 200 HTTP status code.
 type processServerMock struct {
@@ -30,6 +30,8 @@ import (
 	"github.com/grafana/mimir/pkg/frontend/v2/frontendv2pb"
 	"github.com/grafana/mimir/pkg/querier/stats"
 	"github.com/grafana/mimir/pkg/tenant"
+	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/grpcencoding"
 	"github.com/grafana/mimir/pkg/util/httpgrpcutil"
 )
 
@@ -43,6 +45,9 @@ type Config struct {
 	// Used to find local IP address, that is sent to scheduler and querier-worker.
 	InfNames []string `yaml:"instance_interface_names" category:"advanced" doc:"default=[<private network interfaces>]"`
 
+	// Used to choose the local IP address when the interfaces have addresses of both families.
+	AddrFamily string `yaml:"address_family" category:"advanced"`
+
 	// If set, address is not computed from interfaces.
 	Addr string `yaml:"address" category:"advanced"`
 	Port int    `category:"advanced"`
@@ -56,11 +61,19 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	cfg.InfNames = netutil.PrivateNetworkInterfacesWithFallback([]string{"eth0", "en0"}, logger)
 	f.Var((*flagext.StringSlice)(&cfg.InfNames), "query-frontend.instance-interface-names", "List of network interface names to look up when finding the instance IP address. This address is sent to query-scheduler and querier, which uses it to send the query response back to query-frontend.")
 	f.StringVar(&cfg.Addr, "query-frontend.instance-addr", "", "IP address to advertise to the querier (via scheduler) (default is auto-detected from network interfaces).")
+	f.StringVar(&cfg.AddrFamily, "query-frontend.instance-addr-family", util.AddrFamilyIPv4, "IP address family to prefer when auto-detecting the address to advertise to the querier. "+util.AddrFamilyUsage)
 	f.IntVar(&cfg.Port, "query-frontend.instance-port", 0, "Port to advertise to querier (via scheduler) (defaults to server.grpc-listen-port).")
 
 	cfg.GRPCClientConfig.RegisterFlagsWithPrefix("query-frontend.grpc-client-config", f)
 }
 
+func (cfg *Config) Validate(log log.Logger) error {
+	if err := util.ValidateAddrFamily(cfg.AddrFamily); err != nil {
+		return err
+	}
+	return grpcencoding.ValidateClientConfig(cfg.GRPCClientConfig, log)
+}
+
 // Frontend implements GrpcRoundTripper. It queues HTTP requests,
 // dispatches them to backends via gRPC, and handles retries for requests which failed.
 type Frontend struct {
@@ -110,7 +123,7 @@ type enqueueResult struct {
 func NewFrontend(cfg Config, log log.Logger, reg prometheus.Registerer) (*Frontend, error) {
 	requestsCh := make(chan *frontendRequest)
 
-	schedulerWorkers, err := newFrontendSchedulerWorkers(cfg, fmt.Sprintf("%s:%d", cfg.Addr, cfg.Port), requestsCh, log, reg)
+	schedulerWorkers, err := newFrontendSchedulerWorkers(cfg, util.JoinHostPort(cfg.Addr, cfg.Port), requestsCh, log, reg)
 	if err != nil {
 		return nil, err
 	}
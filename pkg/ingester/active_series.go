@@ -11,17 +11,38 @@ import (
 	"time"
 
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
 	"go.uber.org/atomic"
 )
 
 const (
-	numActiveSeriesStripes = 512
+	// DefaultActiveSeriesStripes is the default number of stripes the active series of a tenant are split into.
+	DefaultActiveSeriesStripes = 512
+
+	// minAdaptiveActiveSeriesStripes is the number of stripes a tenant starts with when the stripes are adaptive.
+	minAdaptiveActiveSeriesStripes = 16
 )
 
 // ActiveSeries is keeping track of recently active series for a single tenant.
+//
+// The series are identified by their reference in the TSDB head, so that their labels don't need to be retained:
+// the labels are only used to find the custom trackers matching a series when it's first seen. A series removed
+// from the head and created again gets a new reference, but that only happens once the series has been idle for
+// longer than the head compaction interval, so its previous entry has usually been purged already.
 type ActiveSeries struct {
-	asm     *ActiveSeriesMatchers
-	stripes [numActiveSeriesStripes]activeSeriesStripe
+	// Protects asm and lastAsmUpdate, and guarantees that all stripes use the same matchers while reading the totals.
+	// Also held for writing while the stripes are resized.
+	mtx           sync.RWMutex
+	asm           *ActiveSeriesMatchers
+	lastAsmUpdate time.Time
+
+	// The number of stripes is fixed to maxStripes if targetSeriesPerStripe is 0, otherwise it's adjusted
+	// between minAdaptiveActiveSeriesStripes and maxStripes based on the number of active series.
+	maxStripes            int
+	targetSeriesPerStripe int
+
+	// Holds the current []activeSeriesStripe. It's read without holding mtx when updating the series.
+	stripes atomic.Value
 }
 
 // activeSeriesStripe holds a subset of the series timestamps for a single tenant.
@@ -34,65 +55,296 @@ type activeSeriesStripe struct {
 	oldestEntryTs atomic.Int64
 
 	mu             sync.RWMutex
-	refs           map[uint64][]activeSeriesEntry
+	retired        bool // Set once the stripes have been resized and the series of this stripe have been moved.
+	refs           map[storage.SeriesRef]activeSeriesEntry
 	active         int   // Number of active entries in this stripe. Only decreased during purge or clear.
 	activeMatching []int // Number of active entries in this stripe matching each matcher of the configured ActiveSeriesMatchers.
+
+	created         int   // Number of entries created in this stripe since the last purge.
+	createdMatching []int // Number of entries created in this stripe since the last purge matching each matcher.
+}
+
+// ActiveSeriesChurn holds the number of series created and expired since the previous purge, in total and for
+// each custom tracker (in the same order as custom trackers are defined).
+type ActiveSeriesChurn struct {
+	Created         int
+	Expired         int
+	CreatedMatching []int
+	ExpiredMatching []int
+}
+
+func (c *ActiveSeriesChurn) add(created, expired int, createdMatching, expiredMatching []int) {
+	c.Created += created
+	c.Expired += expired
+	for i := range createdMatching {
+		c.CreatedMatching[i] += createdMatching[i]
+	}
+	for i := range expiredMatching {
+		c.ExpiredMatching[i] += expiredMatching[i]
+	}
 }
 
 // activeSeriesEntry holds a timestamp for single series.
 type activeSeriesEntry struct {
-	lbs     labels.Labels
 	nanos   *atomic.Int64 // Unix timestamp in nanoseconds. Needs to be a pointer because we don't store pointers to entries in the stripe.
 	matches []bool        // Which matchers of ActiveSeriesMatchers does this series match
 }
 
+// NewActiveSeries makes a new ActiveSeries with DefaultActiveSeriesStripes stripes.
 func NewActiveSeries(asm *ActiveSeriesMatchers) *ActiveSeries {
-	c := &ActiveSeries{asm: asm}
+	return NewActiveSeriesWithStripes(asm, DefaultActiveSeriesStripes, 0)
+}
+
+// NewActiveSeriesWithStripes makes a new ActiveSeries split into maxStripes stripes. If targetSeriesPerStripe is
+// greater than 0, the number of stripes is instead adjusted on Purge to have about targetSeriesPerStripe active
+// series per stripe, up to maxStripes: a few stripes are enough for a small tenant, while more stripes reduce the
+// lock contention for a big tenant.
+func NewActiveSeriesWithStripes(asm *ActiveSeriesMatchers, maxStripes, targetSeriesPerStripe int) *ActiveSeries {
+	c := &ActiveSeries{
+		asm:                   asm,
+		maxStripes:            maxStripes,
+		targetSeriesPerStripe: targetSeriesPerStripe,
+	}
+
+	numStripes := maxStripes
+	if targetSeriesPerStripe > 0 && numStripes > minAdaptiveActiveSeriesStripes {
+		numStripes = minAdaptiveActiveSeriesStripes
+	}
 
 	// Stripes are pre-allocated so that we only read on them and no lock is required.
-	for i := 0; i < numActiveSeriesStripes; i++ {
-		c.stripes[i] = activeSeriesStripe{
-			asm:            asm,
-			refs:           map[uint64][]activeSeriesEntry{},
-			activeMatching: makeIntSliceIfNotEmpty(len(asm.MatcherNames())),
+	c.stripes.Store(newActiveSeriesStripes(asm, numStripes))
+
+	return c
+}
+
+func newActiveSeriesStripes(asm *ActiveSeriesMatchers, numStripes int) []activeSeriesStripe {
+	stripes := make([]activeSeriesStripe, numStripes)
+	for i := range stripes {
+		stripes[i] = activeSeriesStripe{
+			asm:             asm,
+			refs:            map[storage.SeriesRef]activeSeriesEntry{},
+			activeMatching:  makeIntSliceIfNotEmpty(len(asm.MatcherNames())),
+			createdMatching: makeIntSliceIfNotEmpty(len(asm.MatcherNames())),
 		}
 	}
+	return stripes
+}
 
-	return c
+func (c *ActiveSeries) loadStripes() []activeSeriesStripe {
+	return c.stripes.Load().([]activeSeriesStripe)
 }
 
-// Updates series timestamp to 'now'. Function is called to make a copy of labels if entry doesn't exist yet.
-func (c *ActiveSeries) UpdateSeries(series labels.Labels, now time.Time, labelsCopy func(labels.Labels) labels.Labels) {
-	fp := series.Hash()
-	stripeID := fp % numActiveSeriesStripes
+// NumStripes returns the current number of stripes.
+func (c *ActiveSeries) NumStripes() int {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	return len(c.loadStripes())
+}
+
+// ReloadMatchers replaces the custom trackers matchers. The tracked series are cleared, because their matches
+// can't be recomputed, so the counts are only valid again once all the active series have been pushed after now.
+func (c *ActiveSeries) ReloadMatchers(asm *ActiveSeriesMatchers, now time.Time) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	stripes := c.loadStripes()
+	for i := range stripes {
+		stripes[i].reinitialize(asm)
+	}
+	c.asm = asm
+	c.lastAsmUpdate = now
+}
+
+// CurrentMatchers returns the custom trackers matchers currently in use.
+func (c *ActiveSeries) CurrentMatchers() *ActiveSeriesMatchers {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	return c.asm
+}
 
-	c.stripes[stripeID].updateSeriesTimestamp(now, series, fp, labelsCopy)
+// MatchersUpdatedAt returns the last time the custom trackers matchers have been reloaded,
+// or the zero time if they have never been reloaded.
+func (c *ActiveSeries) MatchersUpdatedAt() time.Time {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	return c.lastAsmUpdate
+}
+
+// UpdateSeries updates the timestamp of the series with the input reference in the TSDB head to 'now'. The labels
+// are only used to match the custom trackers if the series isn't tracked yet, and aren't retained.
+func (c *ActiveSeries) UpdateSeries(series labels.Labels, ref storage.SeriesRef, now time.Time) {
+	for {
+		stripes := c.loadStripes()
+		stripeID := uint64(ref) % uint64(len(stripes))
+
+		if stripes[stripeID].updateSeriesTimestamp(now, series, ref) {
+			return
+		}
+
+		// The stripes have been resized in the meanwhile, so we retry on the new ones.
+	}
 }
 
 // Purge removes expired entries from the cache. This function should be called
-// periodically to avoid memory leaks.
-func (c *ActiveSeries) Purge(keepUntil time.Time) {
-	for s := 0; s < numActiveSeriesStripes; s++ {
-		c.stripes[s].purge(keepUntil)
+// periodically to avoid memory leaks. If the stripes are adaptive, they're resized
+// after the purge if the number of active series requires it.
+//
+// It returns the number of series created since the previous purge and the number
+// of series expired by this purge. The series created before the custom trackers
+// have been reloaded are not counted, while the series already active at that time
+// are counted again as created once updated.
+func (c *ActiveSeries) Purge(keepUntil time.Time) ActiveSeriesChurn {
+	c.mtx.RLock()
+	numMatchers := len(c.asm.MatcherNames())
+	churn := ActiveSeriesChurn{
+		CreatedMatching: makeIntSliceIfNotEmpty(numMatchers),
+		ExpiredMatching: makeIntSliceIfNotEmpty(numMatchers),
+	}
+	stripes := c.loadStripes()
+	for s := range stripes {
+		stripes[s].purge(keepUntil, &churn)
+	}
+	c.mtx.RUnlock()
+
+	if c.targetSeriesPerStripe > 0 {
+		c.resizeStripesIfNeeded()
+	}
+	return churn
+}
+
+// resizeStripesIfNeeded grows the stripes when there are more than targetSeriesPerStripe active series per stripe,
+// and shrinks them when there are less than a quarter of it, to not resize them back and forth.
+func (c *ActiveSeries) resizeStripesIfNeeded() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	stripes := c.loadStripes()
+	total := 0
+	for s := range stripes {
+		total += stripes[s].getTotal()
+	}
+
+	numStripes := targetActiveSeriesStripes(total, c.targetSeriesPerStripe, c.maxStripes)
+	if numStripes > len(stripes) || numStripes*4 <= len(stripes) {
+		c.resizeStripes(stripes, numStripes)
+	}
+}
+
+// targetActiveSeriesStripes returns the power of two number of stripes needed to have at most targetSeriesPerStripe
+// series per stripe, between minAdaptiveActiveSeriesStripes and maxStripes.
+func targetActiveSeriesStripes(numSeries, targetSeriesPerStripe, maxStripes int) int {
+	numStripes := minAdaptiveActiveSeriesStripes
+	for numStripes*targetSeriesPerStripe < numSeries && numStripes < maxStripes {
+		numStripes *= 2
+	}
+	if numStripes > maxStripes {
+		numStripes = maxStripes
+	}
+	return numStripes
+}
+
+// resizeStripes moves the series of the current stripes to numStripes new stripes. Must be called while holding mtx
+// for writing. All the current stripes are locked while moving the series, and are then retired, so that the updates
+// in progress on them are retried on the new stripes.
+func (c *ActiveSeries) resizeStripes(stripes []activeSeriesStripe, numStripes int) {
+	resized := newActiveSeriesStripes(c.asm, numStripes)
+
+	for s := range stripes {
+		stripes[s].mu.Lock()
+	}
+
+	for s := range stripes {
+		for ref, entry := range stripes[s].refs {
+			// The entries are moved as they are, so that the timestamps updated through a pointer to the entry
+			// found before the resize are not lost.
+			resized[uint64(ref)%uint64(numStripes)].addEntry(ref, entry)
+		}
+
+		// The series created since the last purge are still reported by the next one.
+		resized[0].created += stripes[s].created
+		for i, n := range stripes[s].createdMatching {
+			resized[0].createdMatching[i] += n
+		}
+		stripes[s].retired = true
+	}
+	c.stripes.Store(resized)
+
+	for s := range stripes {
+		stripes[s].mu.Unlock()
 	}
 }
 
 //nolint // Linter reports that this method is unused, but it is.
 func (c *ActiveSeries) clear() {
-	for s := 0; s < numActiveSeriesStripes; s++ {
-		c.stripes[s].clear()
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	stripes := c.loadStripes()
+	for s := range stripes {
+		stripes[s].clear()
 	}
 }
 
 // Active returns the total number of active series, as well as a slice of active series matching each one of the
 // custom trackers provided (in the same order as custom trackers are defined)
 func (c *ActiveSeries) Active() (int, []int) {
+	total, totalMatching, _ := c.ActiveWithMatchers()
+	return total, totalMatching
+}
+
+// ActiveWithMatchers is like Active, but also returns the names of the custom trackers the totals refer to.
+func (c *ActiveSeries) ActiveWithMatchers() (int, []int, []string) {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
 	total := 0
 	totalMatching := makeIntSliceIfNotEmpty(len(c.asm.MatcherNames()))
-	for s := 0; s < numActiveSeriesStripes; s++ {
-		total += c.stripes[s].getTotalAndUpdateMatching(totalMatching)
+	stripes := c.loadStripes()
+	for s := range stripes {
+		total += stripes[s].getTotalAndUpdateMatching(totalMatching)
 	}
-	return total, totalMatching
+	return total, totalMatching, c.asm.MatcherNames()
+}
+
+// ActiveSeriesRefs returns the references in the TSDB head of the active series matching the custom tracker with
+// the input name, or of all the active series if the name is empty. It returns false if the tracker doesn't exist.
+func (c *ActiveSeries) ActiveSeriesRefs(tracker string) ([]storage.SeriesRef, bool) {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	matcherIdx := -1
+	if tracker != "" {
+		for i, name := range c.asm.MatcherNames() {
+			if name == tracker {
+				matcherIdx = i
+				break
+			}
+		}
+		if matcherIdx < 0 {
+			return nil, false
+		}
+	}
+
+	var refs []storage.SeriesRef
+	stripes := c.loadStripes()
+	for s := range stripes {
+		refs = stripes[s].appendActiveRefs(refs, matcherIdx)
+	}
+	return refs, true
+}
+
+// appendActiveRefs appends to the input slice the references of the series in the stripe matching the matcher
+// at the input index, or of all the series if the index is negative.
+func (s *activeSeriesStripe) appendActiveRefs(refs []storage.SeriesRef, matcherIdx int) []storage.SeriesRef {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for ref, entry := range s.refs {
+		if matcherIdx < 0 || entry.matches[matcherIdx] {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
 }
 
 // getTotalAndUpdateMatching will return the total active series in the stripe and also update the slice provided
@@ -109,13 +361,41 @@ func (s *activeSeriesStripe) getTotalAndUpdateMatching(matching []int) int {
 	return s.active
 }
 
-func (s *activeSeriesStripe) updateSeriesTimestamp(now time.Time, series labels.Labels, fingerprint uint64, labelsCopy func(labels.Labels) labels.Labels) {
+// getTotal returns the total active series in the stripe.
+func (s *activeSeriesStripe) getTotal() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.active
+}
+
+// addEntry adds an entry to the stripe. Must be called while holding the stripe lock for writing, unless the stripe
+// is not in use yet.
+func (s *activeSeriesStripe) addEntry(ref storage.SeriesRef, e activeSeriesEntry) {
+	s.refs[ref] = e
+	s.active++
+	for i, ok := range e.matches {
+		if ok {
+			s.activeMatching[i]++
+		}
+	}
+}
+
+// updateSeriesTimestamp returns false if the stripe has been retired, in which case the series must be updated
+// in the new stripes.
+func (s *activeSeriesStripe) updateSeriesTimestamp(now time.Time, series labels.Labels, ref storage.SeriesRef) bool {
 	nowNanos := now.UnixNano()
 
-	e := s.findEntryForSeries(fingerprint, series)
+	e, ok := s.findEntryForSeries(ref)
+	if !ok {
+		return false
+	}
 	entryTimeSet := false
 	if e == nil {
-		e, entryTimeSet = s.findOrCreateEntryForSeries(fingerprint, series, nowNanos, labelsCopy)
+		e, entryTimeSet, ok = s.findOrCreateEntryForSeries(ref, series, nowNanos)
+		if !ok {
+			return false
+		}
 	}
 
 	if !entryTimeSet {
@@ -133,52 +413,50 @@ func (s *activeSeriesStripe) updateSeriesTimestamp(now time.Time, series labels.
 			}
 		}
 	}
+
+	return true
 }
 
-func (s *activeSeriesStripe) findEntryForSeries(fingerprint uint64, series labels.Labels) *atomic.Int64 {
+// findEntryForSeries returns false if the stripe has been retired.
+func (s *activeSeriesStripe) findEntryForSeries(ref storage.SeriesRef) (*atomic.Int64, bool) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	// Check if already exists within the entries.
-	for _, entry := range s.refs[fingerprint] {
-		if labels.Equal(entry.lbs, series) {
-			return entry.nanos
-		}
+	if s.retired {
+		return nil, false
 	}
-
-	return nil
+	return s.refs[ref].nanos, true
 }
 
-func (s *activeSeriesStripe) findOrCreateEntryForSeries(fingerprint uint64, series labels.Labels, nowNanos int64, labelsCopy func(labels.Labels) labels.Labels) (*atomic.Int64, bool) {
+// findOrCreateEntryForSeries returns the entry, whether it has been created, and false if the stripe has been retired.
+func (s *activeSeriesStripe) findOrCreateEntryForSeries(ref storage.SeriesRef, series labels.Labels, nowNanos int64) (*atomic.Int64, bool, bool) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// Check if already exists within the entries.
-	// This repeats findEntryForSeries(), but under write lock.
-	for _, entry := range s.refs[fingerprint] {
-		if labels.Equal(entry.lbs, series) {
-			return entry.nanos, false
-		}
+	if s.retired {
+		return nil, false, false
 	}
 
-	matches := s.asm.Matches(series)
-
-	s.active++
-	for i, ok := range matches {
-		if ok {
-			s.activeMatching[i]++
-		}
+	// Check if already exists within the entries.
+	// This repeats findEntryForSeries(), but under write lock.
+	if entry, ok := s.refs[ref]; ok {
+		return entry.nanos, false, true
 	}
 
 	e := activeSeriesEntry{
-		lbs:     labelsCopy(series),
 		nanos:   atomic.NewInt64(nowNanos),
-		matches: matches,
+		matches: s.asm.Matches(series),
 	}
+	s.addEntry(ref, e)
 
-	s.refs[fingerprint] = append(s.refs[fingerprint], e)
+	s.created++
+	for i, ok := range e.matches {
+		if ok {
+			s.createdMatching[i]++
+		}
+	}
 
-	return e.nanos, true
+	return e.nanos, true, true
 }
 
 //nolint // Linter reports that this method is unused, but it is.
@@ -187,78 +465,76 @@ func (s *activeSeriesStripe) clear() {
 	defer s.mu.Unlock()
 
 	s.oldestEntryTs.Store(0)
-	s.refs = map[uint64][]activeSeriesEntry{}
+	s.refs = map[storage.SeriesRef]activeSeriesEntry{}
 	s.active = 0
 	for i := range s.activeMatching {
 		s.activeMatching[i] = 0
 	}
+	s.created = 0
+	for i := range s.createdMatching {
+		s.createdMatching[i] = 0
+	}
 }
 
-func (s *activeSeriesStripe) purge(keepUntil time.Time) {
+// reinitialize clears the stripe and replaces its matchers.
+func (s *activeSeriesStripe) reinitialize(asm *ActiveSeriesMatchers) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.oldestEntryTs.Store(0)
+	s.refs = map[storage.SeriesRef]activeSeriesEntry{}
+	s.active = 0
+	s.asm = asm
+	s.activeMatching = makeIntSliceIfNotEmpty(len(asm.MatcherNames()))
+	s.created = 0
+	s.createdMatching = makeIntSliceIfNotEmpty(len(asm.MatcherNames()))
+}
+
+// purge removes the entries older than keepUntil, and adds to the input churn the entries created since the
+// previous purge and the ones removed by this purge.
+func (s *activeSeriesStripe) purge(keepUntil time.Time, churn *ActiveSeriesChurn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	created, createdMatching := s.created, s.createdMatching
+	s.created = 0
+	s.createdMatching = makeIntSliceIfNotEmpty(len(createdMatching))
+
 	keepUntilNanos := keepUntil.UnixNano()
 	if oldest := s.oldestEntryTs.Load(); oldest > 0 && keepUntilNanos <= oldest {
-		// Nothing to do.
+		// Nothing to purge.
+		churn.add(created, 0, createdMatching, nil)
 		return
 	}
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	active := 0
 	activeMatching := makeIntSliceIfNotEmpty(len(s.activeMatching))
+	expired := 0
+	expiredMatching := makeIntSliceIfNotEmpty(len(s.activeMatching))
 
 	oldest := int64(math.MaxInt64)
-	for fp, entries := range s.refs {
-		// Since we do expect very few fingerprint collisions, we
-		// have an optimized implementation for the common case.
-		if len(entries) == 1 {
-			ts := entries[0].nanos.Load()
-			if ts < keepUntilNanos {
-				delete(s.refs, fp)
-				continue
-			}
+	for ref, entry := range s.refs {
+		ts := entry.nanos.Load()
+		if ts < keepUntilNanos {
+			delete(s.refs, ref)
 
-			active++
-			for i, ok := range entries[0].matches {
+			expired++
+			for i, ok := range entry.matches {
 				if ok {
-					activeMatching[i]++
+					expiredMatching[i]++
 				}
 			}
-			if ts < oldest {
-				oldest = ts
-			}
 			continue
 		}
 
-		// We have more entries, which means there's a collision,
-		// so we have to iterate over the entries.
-		for i := 0; i < len(entries); {
-			ts := entries[i].nanos.Load()
-			if ts < keepUntilNanos {
-				entries = append(entries[:i], entries[i+1:]...)
-			} else {
-				if ts < oldest {
-					oldest = ts
-				}
-
-				i++
+		active++
+		for i, ok := range entry.matches {
+			if ok {
+				activeMatching[i]++
 			}
 		}
-
-		// Either update or delete the entries in the map
-		if cnt := len(entries); cnt == 0 {
-			delete(s.refs, fp)
-		} else {
-			active += cnt
-			for i := range entries {
-				for i, ok := range entries[i].matches {
-					if ok {
-						activeMatching[i]++
-					}
-				}
-			}
-
-			s.refs[fp] = entries
+		if ts < oldest {
+			oldest = ts
 		}
 	}
 
@@ -269,6 +545,7 @@ func (s *activeSeriesStripe) purge(keepUntil time.Time) {
 	}
 	s.active = active
 	s.activeMatching = activeMatching
+	churn.add(created, expired, createdMatching, expiredMatching)
 }
 
 func makeIntSliceIfNotEmpty(l int) []int {
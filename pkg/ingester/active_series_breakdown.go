@@ -0,0 +1,154 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb"
+
+	"github.com/grafana/mimir/pkg/tenant"
+	"github.com/grafana/mimir/pkg/util"
+	util_log "github.com/grafana/mimir/pkg/util/log"
+)
+
+const (
+	activeSeriesBreakdownDefaultLimit = 20
+	activeSeriesBreakdownMaxLimit     = 500
+)
+
+// ActiveSeriesBreakdownResponse is the response of the active series breakdown API.
+type ActiveSeriesBreakdownResponse struct {
+	// Tracker is the name of the custom tracker the active series have been selected by,
+	// or empty if all the active series have been counted.
+	Tracker   string `json:"tracker,omitempty"`
+	LabelName string `json:"label_name"`
+
+	// Total number of active series, and number of distinct values of the label among them.
+	Total            int `json:"total"`
+	LabelValuesCount int `json:"label_values_count"`
+
+	// Values are the label values with the most active series, sorted by descending count.
+	// The active series without the label are counted under the empty label value.
+	Values []ActiveSeriesBreakdownItem `json:"values"`
+}
+
+// ActiveSeriesBreakdownItem is the number of active series with a label value.
+type ActiveSeriesBreakdownItem struct {
+	LabelValue string `json:"label_value"`
+	Count      int    `json:"count"`
+}
+
+// ActiveSeriesBreakdownHandler returns the number of active series of the tenant in this ingester by value
+// of the "label_name" parameter, optionally only counting the series matching the custom tracker selected
+// by the "tracker" parameter. The "limit" parameter sets the max number of label values returned.
+func (i *Ingester) ActiveSeriesBreakdownHandler(w http.ResponseWriter, r *http.Request) {
+	if err := i.checkRunning(); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	tracker, labelName := r.FormValue("tracker"), r.FormValue("label_name")
+	if labelName == "" {
+		http.Error(w, "the label_name parameter is required", http.StatusBadRequest)
+		return
+	}
+	limit, err := parseActiveSeriesBreakdownLimit(r.FormValue("limit"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	res := ActiveSeriesBreakdownResponse{Tracker: tracker, LabelName: labelName, Values: []ActiveSeriesBreakdownItem{}}
+
+	db := i.getTSDB(userID)
+	if db == nil {
+		util.WriteJSONResponse(w, res)
+		return
+	}
+
+	refs, ok := db.activeSeries.ActiveSeriesRefs(tracker)
+	if !ok {
+		http.Error(w, fmt.Sprintf("the custom tracker %q doesn't exist", tracker), http.StatusNotFound)
+		return
+	}
+
+	idx, err := db.Head().Index()
+	if err != nil {
+		level.Error(util_log.WithContext(r.Context(), i.logger)).Log("msg", "failed to open the TSDB head index", "user", userID, "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer idx.Close()
+
+	res.Total, res.Values, err = activeSeriesBreakdown(idx, refs, labelName)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	res.LabelValuesCount = len(res.Values)
+	if len(res.Values) > limit {
+		res.Values = res.Values[:limit]
+	}
+
+	util.WriteJSONResponse(w, res)
+}
+
+// activeSeriesBreakdown counts the input series by value of the label, and returns the total number of series
+// found in the index along with the counts sorted by descending count. The series removed from the head since
+// they have been tracked as active are skipped.
+func activeSeriesBreakdown(idx tsdb.IndexReader, refs []storage.SeriesRef, labelName string) (int, []ActiveSeriesBreakdownItem, error) {
+	total := 0
+	counts := map[string]int{}
+
+	var lbls labels.Labels
+	for _, ref := range refs {
+		if err := idx.Series(ref, &lbls, nil); err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				continue
+			}
+			return 0, nil, err
+		}
+		total++
+		counts[lbls.Get(labelName)]++
+	}
+
+	items := make([]ActiveSeriesBreakdownItem, 0, len(counts))
+	for value, count := range counts {
+		items = append(items, ActiveSeriesBreakdownItem{LabelValue: value, Count: count})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Count != items[j].Count {
+			return items[i].Count > items[j].Count
+		}
+		return items[i].LabelValue < items[j].LabelValue
+	})
+	return total, items, nil
+}
+
+func parseActiveSeriesBreakdownLimit(value string) (int, error) {
+	if value == "" {
+		return activeSeriesBreakdownDefaultLimit, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 0 {
+		return 0, fmt.Errorf("invalid limit %q", value)
+	}
+	if limit > activeSeriesBreakdownMaxLimit {
+		return 0, fmt.Errorf("the limit can't be greater than %d", activeSeriesBreakdownMaxLimit)
+	}
+	return limit, nil
+}
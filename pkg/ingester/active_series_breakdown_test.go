@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/test"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+)
+
+func TestIngester_ActiveSeriesBreakdownHandler(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+	cfg.IngesterRing.JoinAfter = 0
+	cfg.ActiveSeriesCustomTrackers = ActiveSeriesCustomTrackersConfig{"dev": `{namespace=~"dev-.*"}`}
+
+	i, err := prepareIngesterWithBlocksStorage(t, cfg, nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+	test.Poll(t, 1*time.Second, 1, func() interface{} {
+		return i.lifecycler.HealthyInstancesCount()
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+	for _, series := range []labels.Labels{
+		labels.FromStrings(labels.MetricName, "up", "namespace", "dev-1", "job", "a"),
+		labels.FromStrings(labels.MetricName, "up", "namespace", "dev-1", "job", "b"),
+		labels.FromStrings(labels.MetricName, "up", "namespace", "dev-2", "job", "a"),
+		labels.FromStrings(labels.MetricName, "up", "namespace", "prod", "job", "a"),
+		labels.FromStrings(labels.MetricName, "up", "job", "c"),
+	} {
+		req, _, _, _ := mockWriteRequest(t, series, 1, 100000)
+		_, err = i.Push(ctx, req)
+		require.NoError(t, err)
+	}
+
+	request := func(userID string, params url.Values) (int, ActiveSeriesBreakdownResponse) {
+		req := httptest.NewRequest(http.MethodGet, "/ingester/active_series_breakdown?"+params.Encode(), nil)
+		req = req.WithContext(user.InjectOrgID(req.Context(), userID))
+		rec := httptest.NewRecorder()
+		i.ActiveSeriesBreakdownHandler(rec, req)
+
+		var res ActiveSeriesBreakdownResponse
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		}
+		return rec.Code, res
+	}
+
+	t.Run("all active series", func(t *testing.T) {
+		code, res := request("user-1", url.Values{"label_name": {"namespace"}})
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, ActiveSeriesBreakdownResponse{
+			LabelName:        "namespace",
+			Total:            5,
+			LabelValuesCount: 4,
+			Values: []ActiveSeriesBreakdownItem{
+				{LabelValue: "dev-1", Count: 2},
+				{LabelValue: "", Count: 1},
+				{LabelValue: "dev-2", Count: 1},
+				{LabelValue: "prod", Count: 1},
+			},
+		}, res)
+	})
+
+	t.Run("active series of a custom tracker", func(t *testing.T) {
+		code, res := request("user-1", url.Values{"tracker": {"dev"}, "label_name": {"job"}, "limit": {"1"}})
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, ActiveSeriesBreakdownResponse{
+			Tracker:          "dev",
+			LabelName:        "job",
+			Total:            3,
+			LabelValuesCount: 2,
+			Values:           []ActiveSeriesBreakdownItem{{LabelValue: "a", Count: 2}},
+		}, res)
+	})
+
+	t.Run("tenant without series", func(t *testing.T) {
+		code, res := request("user-2", url.Values{"label_name": {"namespace"}})
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, ActiveSeriesBreakdownResponse{LabelName: "namespace", Values: []ActiveSeriesBreakdownItem{}}, res)
+	})
+
+	t.Run("unknown custom tracker", func(t *testing.T) {
+		code, _ := request("user-1", url.Values{"tracker": {"prod"}, "label_name": {"job"}})
+		assert.Equal(t, http.StatusNotFound, code)
+	})
+
+	for name, params := range map[string]url.Values{
+		"missing label name": {"tracker": {"dev"}},
+		"invalid limit":      {"label_name": {"job"}, "limit": {"-1"}},
+		"too high limit":     {"label_name": {"job"}, "limit": {"1000"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			code, _ := request("user-1", params)
+			assert.Equal(t, http.StatusBadRequest, code)
+		})
+	}
+}
@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	amlabels "github.com/prometheus/alertmanager/pkg/labels"
 	"github.com/prometheus/prometheus/model/labels"
+	"go.uber.org/atomic"
 )
 
 // ActiveSeriesCustomTrackersConfig configures the additional custom trackers for active series in the ingester.
@@ -52,6 +54,19 @@ func (c *ActiveSeriesCustomTrackersConfig) Set(s string) error {
 	return nil
 }
 
+// Equal returns whether the two configs have the same trackers with the same matchers.
+func (c ActiveSeriesCustomTrackersConfig) Equal(other ActiveSeriesCustomTrackersConfig) bool {
+	if len(c) != len(other) {
+		return false
+	}
+	for name, matcher := range c {
+		if otherMatcher, ok := other[name]; !ok || otherMatcher != matcher {
+			return false
+		}
+	}
+	return true
+}
+
 func (c *ActiveSeriesCustomTrackersConfig) ExampleDoc() (comment string, yaml interface{}) {
 	return `The following configuration will count the active series coming from dev and prod namespaces for each tenant` +
 			` and label them as {name="dev"} and {name="prod"} in the cortex_ingester_active_series_custom_tracker metric.`,
@@ -62,9 +77,9 @@ func (c *ActiveSeriesCustomTrackersConfig) ExampleDoc() (comment string, yaml in
 }
 
 func NewActiveSeriesMatchers(matchers ActiveSeriesCustomTrackersConfig) (*ActiveSeriesMatchers, error) {
-	asm := &ActiveSeriesMatchers{}
+	asm := &ActiveSeriesMatchers{config: matchers}
 	for name, matcher := range matchers {
-		sm, err := amlabels.ParseMatchers(matcher)
+		sm, err := parseMatchers(matcher)
 		if err != nil {
 			return nil, fmt.Errorf("can't build active series matcher %s: %w", name, err)
 		}
@@ -79,12 +94,46 @@ func NewActiveSeriesMatchers(matchers ActiveSeriesCustomTrackersConfig) (*Active
 	// Sort the result to make it deterministic for tests.
 	// Order doesn't matter for the functionality as long as the order remains consistent during the execution of the program.
 	sort.Sort(asm)
+	asm.stats = make([]activeSeriesMatcherStats, len(asm.names))
 	return asm, nil
 }
 
+// parseMatchers is like amlabels.ParseMatchers, but returns an error instead of panicking when a matcher has
+// no value (e.g. `{foo=}`), because the matchers can be provided by tenants through the API.
+func parseMatchers(s string) (_ []*amlabels.Matcher, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("bad matcher format: %s", s)
+		}
+	}()
+
+	return amlabels.ParseMatchers(s)
+}
+
 type ActiveSeriesMatchers struct {
+	config   ActiveSeriesCustomTrackersConfig
 	names    []string
 	matchers []labelsMatchers
+	stats    []activeSeriesMatcherStats // Evaluation stats of each matcher, in the same order as the matchers.
+}
+
+// activeSeriesMatcherStats holds the evaluation stats of a matcher since they have been last read.
+type activeSeriesMatcherStats struct {
+	evaluations     atomic.Int64
+	matches         atomic.Int64
+	evaluationNanos atomic.Int64
+}
+
+// ActiveSeriesMatcherStats is a snapshot of the evaluation stats of a matcher.
+type ActiveSeriesMatcherStats struct {
+	Evaluations    int64
+	Matches        int64
+	EvaluationTime time.Duration
+}
+
+// Config returns the custom trackers config the matchers have been built from.
+func (asm *ActiveSeriesMatchers) Config() ActiveSeriesCustomTrackersConfig {
+	return asm.config
 }
 
 func (asm *ActiveSeriesMatchers) MatcherNames() []string {
@@ -97,11 +146,36 @@ func (asm *ActiveSeriesMatchers) Matches(series labels.Labels) []bool {
 	}
 	matches := make([]bool, len(asm.matchers))
 	for i, sm := range asm.matchers {
+		start := time.Now()
 		matches[i] = sm.Matches(series)
+
+		stats := &asm.stats[i]
+		stats.evaluationNanos.Add(int64(time.Since(start)))
+		stats.evaluations.Inc()
+		if matches[i] {
+			stats.matches.Inc()
+		}
 	}
 	return matches
 }
 
+// ReadAndResetStats returns the evaluation stats of each matcher since they have been last read, in the same order as
+// the matcher names.
+func (asm *ActiveSeriesMatchers) ReadAndResetStats() []ActiveSeriesMatcherStats {
+	if len(asm.stats) == 0 {
+		return nil
+	}
+	res := make([]ActiveSeriesMatcherStats, len(asm.stats))
+	for i := range asm.stats {
+		res[i] = ActiveSeriesMatcherStats{
+			Evaluations:    asm.stats[i].evaluations.Swap(0),
+			Matches:        asm.stats[i].matches.Swap(0),
+			EvaluationTime: time.Duration(asm.stats[i].evaluationNanos.Swap(0)),
+		}
+	}
+	return res
+}
+
 // labelsMatchers is like alertmanager's labels.Matchers but for Prometheus' labels.Matcher slice
 type labelsMatchers []*labels.Matcher
 
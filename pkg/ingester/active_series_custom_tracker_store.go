@@ -0,0 +1,308 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/concurrency"
+	"github.com/grafana/dskit/runutil"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"gopkg.in/yaml.v2"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	"github.com/grafana/mimir/pkg/tenant"
+	"github.com/grafana/mimir/pkg/util"
+	util_log "github.com/grafana/mimir/pkg/util/log"
+)
+
+const (
+	// ActiveSeriesCustomTrackersFilename is the name of the object, stored in the tenant's blocks storage
+	// prefix, holding the tenant's active series custom trackers set through the API.
+	ActiveSeriesCustomTrackersFilename = "active-series-custom-trackers.yaml"
+
+	// maxActiveSeriesCustomTrackersSize is the max size of the custom trackers config accepted by the API.
+	maxActiveSeriesCustomTrackersSize = 1024 * 1024
+
+	// activeSeriesCustomTrackersSyncConcurrency is the max number of tenants whose custom trackers are read concurrently.
+	activeSeriesCustomTrackersSyncConcurrency = 16
+)
+
+var (
+	errActiveSeriesCustomTrackersNotFound = errors.New("active series custom trackers not found")
+	errActiveSeriesCustomTrackersDisabled = errors.New("per-tenant active series custom trackers are disabled")
+)
+
+// ReadActiveSeriesCustomTrackers reads the tenant's active series custom trackers from the bucket.
+func ReadActiveSeriesCustomTrackers(ctx context.Context, bkt objstore.Bucket, userID string, cfgProvider bucket.TenantConfigProvider, logger log.Logger) (ActiveSeriesCustomTrackersConfig, error) {
+	userBkt := bucket.NewUserBucketClient(userID, bkt, cfgProvider)
+
+	reader, err := userBkt.WithExpectedErrs(userBkt.IsObjNotFoundErr).Get(ctx, ActiveSeriesCustomTrackersFilename)
+	if err != nil {
+		if userBkt.IsObjNotFoundErr(err) {
+			return nil, errActiveSeriesCustomTrackersNotFound
+		}
+		return nil, errors.Wrap(err, "read active series custom trackers")
+	}
+	defer runutil.CloseWithLogOnErr(logger, reader, "close active series custom trackers reader")
+
+	content, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "read active series custom trackers")
+	}
+
+	trackers := ActiveSeriesCustomTrackersConfig{}
+	if err := yaml.Unmarshal(content, &trackers); err != nil {
+		return nil, errors.Wrap(err, "unmarshal active series custom trackers")
+	}
+
+	return trackers, nil
+}
+
+// WriteActiveSeriesCustomTrackers uploads the tenant's active series custom trackers to the bucket.
+func WriteActiveSeriesCustomTrackers(ctx context.Context, bkt objstore.Bucket, userID string, cfgProvider bucket.TenantConfigProvider, trackers ActiveSeriesCustomTrackersConfig) error {
+	userBkt := bucket.NewUserBucketClient(userID, bkt, cfgProvider)
+
+	content, err := yaml.Marshal(trackers)
+	if err != nil {
+		return errors.Wrap(err, "marshal active series custom trackers")
+	}
+
+	if err := userBkt.Upload(ctx, ActiveSeriesCustomTrackersFilename, bytes.NewReader(content)); err != nil {
+		return errors.Wrap(err, "upload active series custom trackers")
+	}
+
+	return nil
+}
+
+// DeleteActiveSeriesCustomTrackers deletes the tenant's active series custom trackers from the bucket.
+// No error is returned if they don't exist.
+func DeleteActiveSeriesCustomTrackers(ctx context.Context, bkt objstore.Bucket, userID string, cfgProvider bucket.TenantConfigProvider) error {
+	userBkt := bucket.NewUserBucketClient(userID, bkt, cfgProvider)
+
+	err := userBkt.Delete(ctx, ActiveSeriesCustomTrackersFilename)
+	if err != nil && !userBkt.IsObjNotFoundErr(err) {
+		return errors.Wrap(err, "delete active series custom trackers")
+	}
+	return nil
+}
+
+// syncActiveSeriesCustomTrackers reads the custom trackers of each tenant with an open TSDB from the bucket, and
+// reloads the tenant's active series matchers if they changed. Tenants without custom trackers in the bucket
+// use the ones configured through -ingester.active-series-custom-trackers.
+func (i *Ingester) syncActiveSeriesCustomTrackers(ctx context.Context) {
+	_ = concurrency.ForEachUser(ctx, i.getTSDBUsers(), activeSeriesCustomTrackersSyncConcurrency, func(ctx context.Context, userID string) error {
+		if err := i.syncUserActiveSeriesCustomTrackers(ctx, userID); err != nil {
+			level.Warn(i.logger).Log("msg", "failed to sync active series custom trackers", "user", userID, "err", err)
+		}
+		return nil
+	})
+}
+
+// syncUserActiveSeriesCustomTrackers reads the custom trackers of the tenant from the bucket, and reloads the
+// tenant's active series matchers if they changed.
+func (i *Ingester) syncUserActiveSeriesCustomTrackers(ctx context.Context, userID string) error {
+	trackers, err := ReadActiveSeriesCustomTrackers(ctx, i.bucket, userID, i.limits, i.logger)
+	if errors.Is(err, errActiveSeriesCustomTrackersNotFound) {
+		trackers = i.cfg.ActiveSeriesCustomTrackers
+	} else if err != nil {
+		return err
+	}
+
+	return i.reloadActiveSeriesCustomTrackers(userID, trackers, time.Now())
+}
+
+// reloadActiveSeriesCustomTrackers replaces the active series matchers of the tenant, if the input trackers
+// differ from the ones in use.
+func (i *Ingester) reloadActiveSeriesCustomTrackers(userID string, trackers ActiveSeriesCustomTrackersConfig, now time.Time) error {
+	userDB := i.getTSDB(userID)
+	if userDB == nil {
+		return nil
+	}
+
+	current := userDB.activeSeries.CurrentMatchers()
+	if current.Config().Equal(trackers) {
+		return nil
+	}
+
+	asm, err := NewActiveSeriesMatchers(trackers)
+	if err != nil {
+		return err
+	}
+
+	userDB.activeSeries.ReloadMatchers(asm, now)
+
+	// The metrics of the removed trackers would never be updated again.
+	i.metrics.deleteActiveSeriesCustomTrackerMetrics(userID, current.MatcherNames())
+
+	level.Info(i.logger).Log("msg", "reloaded active series custom trackers", "user", userID, "trackers", len(asm.MatcherNames()))
+	return nil
+}
+
+// ActiveSeriesCustomTrackersHandler gets, sets or deletes the active series custom trackers of the tenant, which
+// override the ones configured through -ingester.active-series-custom-trackers. The changes are applied right away
+// by the ingester receiving the request, and picked up by the other ingesters at the next poll of the bucket.
+func (i *Ingester) ActiveSeriesCustomTrackersHandler(w http.ResponseWriter, r *http.Request) {
+	logger := util_log.WithContext(r.Context(), i.logger)
+
+	if i.cfg.ActiveSeriesCustomTrackersPollInterval <= 0 {
+		http.Error(w, errActiveSeriesCustomTrackersDisabled.Error(), http.StatusNotFound)
+		return
+	}
+
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		trackers, err := ReadActiveSeriesCustomTrackers(r.Context(), i.bucket, userID, i.limits, logger)
+		if errors.Is(err, errActiveSeriesCustomTrackersNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		} else if err != nil {
+			level.Error(logger).Log("msg", "failed to read active series custom trackers", "user", userID, "err", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		content, err := yaml.Marshal(trackers)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/yaml")
+		if _, err := w.Write(content); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+	case http.MethodPost:
+		// LimitReader will return EOF after reading specified number of bytes. To check if
+		// we have read too many bytes, allow one extra byte.
+		payload, err := ioutil.ReadAll(io.LimitReader(r.Body, maxActiveSeriesCustomTrackersSize+1))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(payload) > maxActiveSeriesCustomTrackersSize {
+			http.Error(w, fmt.Sprintf("active series custom trackers config is too big, limit: %d bytes", maxActiveSeriesCustomTrackersSize), http.StatusBadRequest)
+			return
+		}
+
+		trackers := ActiveSeriesCustomTrackersConfig{}
+		if err := yaml.UnmarshalStrict(payload, &trackers); err != nil {
+			http.Error(w, fmt.Sprintf("unable to parse the active series custom trackers config: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+		if _, err := NewActiveSeriesMatchers(trackers); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := WriteActiveSeriesCustomTrackers(r.Context(), i.bucket, userID, i.limits, trackers); err != nil {
+			level.Error(logger).Log("msg", "failed to store active series custom trackers", "user", userID, "err", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := i.reloadActiveSeriesCustomTrackers(userID, trackers, time.Now()); err != nil {
+			level.Warn(logger).Log("msg", "failed to reload active series custom trackers", "user", userID, "err", err)
+		}
+
+		w.WriteHeader(http.StatusCreated)
+
+	case http.MethodDelete:
+		if err := DeleteActiveSeriesCustomTrackers(r.Context(), i.bucket, userID, i.limits); err != nil {
+			level.Error(logger).Log("msg", "failed to delete active series custom trackers", "user", userID, "err", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := i.reloadActiveSeriesCustomTrackers(userID, i.cfg.ActiveSeriesCustomTrackers, time.Now()); err != nil {
+			level.Warn(logger).Log("msg", "failed to reload active series custom trackers", "user", userID, "err", err)
+		}
+
+		w.WriteHeader(http.StatusOK)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// ActiveSeriesCustomTrackersStatus is the status of the tenant's active series custom trackers in an ingester.
+type ActiveSeriesCustomTrackersStatus struct {
+	// TSDBOpen is false if the ingester doesn't hold any series of the tenant, in which case
+	// no custom trackers are in use.
+	TSDBOpen bool                             `json:"tsdb_open"`
+	Trackers ActiveSeriesCustomTrackersConfig `json:"trackers,omitempty"`
+
+	// UpdatedAtMs is the time the custom trackers have been reloaded, or 0 if they haven't
+	// been reloaded since the tenant's TSDB has been opened.
+	UpdatedAtMs int64 `json:"updated_at_ms"`
+
+	// The active series counts are only accurate again once the active series idle timeout has
+	// elapsed after the custom trackers have been reloaded.
+	ValidFromMs int64 `json:"valid_from_ms"`
+	Valid       bool  `json:"valid"`
+}
+
+// ActiveSeriesCustomTrackersStatusHandler returns the status of the active series custom trackers of the tenant
+// in this ingester. On POST, the tenant's custom trackers are read from the bucket and reloaded first, without
+// waiting for the next poll.
+func (i *Ingester) ActiveSeriesCustomTrackersStatusHandler(w http.ResponseWriter, r *http.Request) {
+	if err := i.checkRunning(); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		if i.cfg.ActiveSeriesCustomTrackersPollInterval <= 0 {
+			http.Error(w, errActiveSeriesCustomTrackersDisabled.Error(), http.StatusNotFound)
+			return
+		}
+		if err := i.syncUserActiveSeriesCustomTrackers(r.Context(), userID); err != nil {
+			level.Error(util_log.WithContext(r.Context(), i.logger)).Log("msg", "failed to sync active series custom trackers", "user", userID, "err", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	util.WriteJSONResponse(w, i.activeSeriesCustomTrackersStatus(userID, time.Now()))
+}
+
+func (i *Ingester) activeSeriesCustomTrackersStatus(userID string, now time.Time) ActiveSeriesCustomTrackersStatus {
+	userDB := i.getTSDB(userID)
+	if userDB == nil {
+		return ActiveSeriesCustomTrackersStatus{Valid: true}
+	}
+
+	status := ActiveSeriesCustomTrackersStatus{
+		TSDBOpen: true,
+		Trackers: userDB.activeSeries.CurrentMatchers().Config(),
+		Valid:    true,
+	}
+	if updatedAt := userDB.activeSeries.MatchersUpdatedAt(); !updatedAt.IsZero() {
+		validFrom := updatedAt.Add(i.cfg.ActiveSeriesMetricsIdleTimeout)
+		status.UpdatedAtMs = updatedAt.UnixMilli()
+		status.ValidFromMs = validFrom.UnixMilli()
+		status.Valid = !now.Before(validFrom)
+	}
+	return status
+}
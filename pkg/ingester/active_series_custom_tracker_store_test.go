@@ -0,0 +1,200 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/test"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+func TestActiveSeriesCustomTrackersStorage(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+
+	_, err := ReadActiveSeriesCustomTrackers(ctx, bkt, "user-1", nil, log.NewNopLogger())
+	assert.Equal(t, errActiveSeriesCustomTrackersNotFound, err)
+
+	trackers := ActiveSeriesCustomTrackersConfig{"dev": `{namespace=~"dev-.*"}`}
+	require.NoError(t, WriteActiveSeriesCustomTrackers(ctx, bkt, "user-1", nil, trackers))
+	assert.Contains(t, bkt.Objects(), "user-1/"+ActiveSeriesCustomTrackersFilename)
+
+	actual, err := ReadActiveSeriesCustomTrackers(ctx, bkt, "user-1", nil, log.NewNopLogger())
+	require.NoError(t, err)
+	assert.Equal(t, trackers, actual)
+
+	_, err = ReadActiveSeriesCustomTrackers(ctx, bkt, "user-2", nil, log.NewNopLogger())
+	assert.Equal(t, errActiveSeriesCustomTrackersNotFound, err)
+
+	require.NoError(t, DeleteActiveSeriesCustomTrackers(ctx, bkt, "user-1", nil))
+	_, err = ReadActiveSeriesCustomTrackers(ctx, bkt, "user-1", nil, log.NewNopLogger())
+	assert.Equal(t, errActiveSeriesCustomTrackersNotFound, err)
+
+	// Deleting non-existing trackers doesn't fail.
+	require.NoError(t, DeleteActiveSeriesCustomTrackers(ctx, bkt, "user-1", nil))
+}
+
+func TestIngester_ActiveSeriesCustomTrackersHandler(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+	cfg.ActiveSeriesCustomTrackersPollInterval = time.Minute
+
+	i, err := prepareIngesterWithBlocksStorage(t, cfg, nil)
+	require.NoError(t, err)
+	i.bucket = objstore.NewInMemBucket()
+
+	request := func(method, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v1/active_series_custom_trackers", strings.NewReader(body))
+		req = req.WithContext(user.InjectOrgID(req.Context(), "user-1"))
+		rec := httptest.NewRecorder()
+		i.ActiveSeriesCustomTrackersHandler(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusNotFound, request(http.MethodGet, "").Code)
+
+	// Invalid configs are rejected.
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, "dev: 'namespace=~'").Code)
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, "- dev").Code)
+
+	assert.Equal(t, http.StatusCreated, request(http.MethodPost, `dev: '{namespace=~"dev-.*"}'`).Code)
+
+	rec := request(http.MethodGet, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	body, err := ioutil.ReadAll(rec.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "dev: '{namespace=~\"dev-.*\"}'\n", string(body))
+
+	assert.Equal(t, http.StatusOK, request(http.MethodDelete, "").Code)
+	assert.Equal(t, http.StatusNotFound, request(http.MethodGet, "").Code)
+
+	// The API is disabled when the custom trackers aren't polled.
+	i.cfg.ActiveSeriesCustomTrackersPollInterval = 0
+	assert.Equal(t, http.StatusNotFound, request(http.MethodPost, `dev: '{namespace=~"dev-.*"}'`).Code)
+}
+
+func TestIngester_SyncActiveSeriesCustomTrackers(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+	cfg.IngesterRing.JoinAfter = 0
+	cfg.ActiveSeriesCustomTrackers = ActiveSeriesCustomTrackersConfig{"all": `{__name__=~".+"}`}
+	cfg.ActiveSeriesCustomTrackersPollInterval = time.Minute
+
+	i, err := prepareIngesterWithBlocksStorage(t, cfg, nil)
+	require.NoError(t, err)
+	bkt := objstore.NewInMemBucket()
+	i.bucket = bkt
+
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+	test.Poll(t, 1*time.Second, 1, func() interface{} {
+		return i.lifecycler.HealthyInstancesCount()
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+	req, _, _, _ := mockWriteRequest(t, labels.Labels{{Name: labels.MetricName, Value: "test"}}, 1, 100000)
+	_, err = i.Push(ctx, req)
+	require.NoError(t, err)
+
+	userDB := i.getTSDB("user-1")
+	require.NotNil(t, userDB)
+
+	// Without custom trackers in the bucket, the ones from the config are kept.
+	i.syncActiveSeriesCustomTrackers(context.Background())
+	assert.Equal(t, []string{"all"}, userDB.activeSeries.CurrentMatchers().MatcherNames())
+	assert.True(t, userDB.activeSeries.MatchersUpdatedAt().IsZero())
+
+	// The custom trackers stored in the bucket override the ones from the config.
+	require.NoError(t, WriteActiveSeriesCustomTrackers(context.Background(), bkt, "user-1", nil, ActiveSeriesCustomTrackersConfig{"test": `{__name__="test"}`}))
+	i.syncActiveSeriesCustomTrackers(context.Background())
+	assert.Equal(t, []string{"test"}, userDB.activeSeries.CurrentMatchers().MatcherNames())
+	assert.False(t, userDB.activeSeries.MatchersUpdatedAt().IsZero())
+
+	// The tracked series have been cleared.
+	allActive, activeMatching := userDB.activeSeries.Active()
+	assert.Equal(t, 0, allActive)
+	assert.Equal(t, []int{0}, activeMatching)
+
+	// Once the custom trackers are deleted from the bucket, the ones from the config are restored.
+	require.NoError(t, DeleteActiveSeriesCustomTrackers(context.Background(), bkt, "user-1", nil))
+	i.syncActiveSeriesCustomTrackers(context.Background())
+	assert.Equal(t, []string{"all"}, userDB.activeSeries.CurrentMatchers().MatcherNames())
+
+	_, err = i.Push(ctx, mimirpb.ToWriteRequest([]labels.Labels{{{Name: labels.MetricName, Value: "test"}}}, []mimirpb.Sample{{Value: 2, TimestampMs: 100001}}, nil, nil, mimirpb.API))
+	require.NoError(t, err)
+	allActive, activeMatching = userDB.activeSeries.Active()
+	assert.Equal(t, 1, allActive)
+	assert.Equal(t, []int{1}, activeMatching)
+}
+
+func TestIngester_ActiveSeriesCustomTrackersStatusHandler(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+	cfg.IngesterRing.JoinAfter = 0
+	cfg.ActiveSeriesCustomTrackers = ActiveSeriesCustomTrackersConfig{"all": `{__name__=~".+"}`}
+	cfg.ActiveSeriesCustomTrackersPollInterval = time.Hour
+
+	i, err := prepareIngesterWithBlocksStorage(t, cfg, nil)
+	require.NoError(t, err)
+	bkt := objstore.NewInMemBucket()
+	i.bucket = bkt
+
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+	test.Poll(t, 1*time.Second, 1, func() interface{} {
+		return i.lifecycler.HealthyInstancesCount()
+	})
+
+	request := func(method, userID string) ActiveSeriesCustomTrackersStatus {
+		req := httptest.NewRequest(method, "/ingester/active_series_custom_trackers_status", nil)
+		req = req.WithContext(user.InjectOrgID(req.Context(), userID))
+		rec := httptest.NewRecorder()
+		i.ActiveSeriesCustomTrackersStatusHandler(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		status := ActiveSeriesCustomTrackersStatus{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+		return status
+	}
+
+	// The tenant has no series in the ingester.
+	assert.Equal(t, ActiveSeriesCustomTrackersStatus{Valid: true}, request(http.MethodGet, "user-1"))
+
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+	req, _, _, _ := mockWriteRequest(t, labels.Labels{{Name: labels.MetricName, Value: "test"}}, 1, 100000)
+	_, err = i.Push(ctx, req)
+	require.NoError(t, err)
+
+	// The custom trackers from the config have never been reloaded.
+	assert.Equal(t, ActiveSeriesCustomTrackersStatus{TSDBOpen: true, Trackers: cfg.ActiveSeriesCustomTrackers, Valid: true}, request(http.MethodGet, "user-1"))
+
+	// The custom trackers stored in the bucket are reloaded right away on POST.
+	trackers := ActiveSeriesCustomTrackersConfig{"test": `{__name__="test"}`}
+	require.NoError(t, WriteActiveSeriesCustomTrackers(context.Background(), bkt, "user-1", nil, trackers))
+	assert.Equal(t, cfg.ActiveSeriesCustomTrackers, request(http.MethodGet, "user-1").Trackers)
+
+	status := request(http.MethodPost, "user-1")
+	assert.True(t, status.TSDBOpen)
+	assert.Equal(t, trackers, status.Trackers)
+	assert.NotZero(t, status.UpdatedAtMs)
+	assert.Equal(t, status.UpdatedAtMs+cfg.ActiveSeriesMetricsIdleTimeout.Milliseconds(), status.ValidFromMs)
+	assert.False(t, status.Valid)
+
+	// The counts are valid once the idle timeout has elapsed.
+	assert.True(t, i.activeSeriesCustomTrackersStatus("user-1", time.UnixMilli(status.ValidFromMs).Add(time.Millisecond)).Valid)
+}
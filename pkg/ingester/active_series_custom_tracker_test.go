@@ -5,6 +5,7 @@ package ingester
 import (
 	"flag"
 	"testing"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
@@ -175,10 +176,40 @@ func TestActiveSeriesMatcher_MatchesSeries(t *testing.T) {
 	}
 }
 
+func TestActiveSeriesMatcher_ReadAndResetStats(t *testing.T) {
+	asm, err := NewActiveSeriesMatchers(ActiveSeriesCustomTrackersConfig{
+		"foo": `{foo="true"}`,
+		"bar": `{bar=~"t.*"}`,
+	})
+	require.NoError(t, err)
+
+	asm.Matches(labels.FromStrings("foo", "true", "bar", "true"))
+	asm.Matches(labels.FromStrings("foo", "true"))
+	asm.Matches(labels.FromStrings("foo", "false"))
+
+	stats := asm.ReadAndResetStats()
+	require.Len(t, stats, 2)
+	assert.Equal(t, []string{"bar", "foo"}, asm.MatcherNames())
+	assert.Equal(t, int64(3), stats[0].Evaluations)
+	assert.Equal(t, int64(1), stats[0].Matches)
+	assert.Equal(t, int64(3), stats[1].Evaluations)
+	assert.Equal(t, int64(2), stats[1].Matches)
+	for _, s := range stats {
+		assert.Greater(t, s.EvaluationTime, time.Duration(0))
+	}
+
+	// The stats are reset once read.
+	assert.Equal(t, []ActiveSeriesMatcherStats{{}, {}}, asm.ReadAndResetStats())
+
+	// Matchers without custom trackers have no stats.
+	assert.Nil(t, (&ActiveSeriesMatchers{}).ReadAndResetStats())
+}
+
 func TestActiveSeriesMatcher_MalformedMatcher(t *testing.T) {
 	for _, matcher := range []string{
 		`{foo}`,
 		`{foo=~"}`,
+		`{foo=}`,
 	} {
 		t.Run(matcher, func(t *testing.T) {
 			config := ActiveSeriesCustomTrackersConfig{
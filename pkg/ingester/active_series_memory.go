@@ -0,0 +1,137 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"net/http"
+	"sort"
+	"unsafe"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+
+	"github.com/grafana/mimir/pkg/util"
+)
+
+const (
+	// Estimated size of an entry of the refs map of a stripe: the map buckets hold 8 keys, 8 values, their
+	// hashes and an overflow pointer, and are up to about 80% full.
+	activeSeriesRefsMapEntryBytes = int64((8*(1+unsafe.Sizeof(storage.SeriesRef(0))+unsafe.Sizeof(activeSeriesEntry{})) + unsafe.Sizeof(uintptr(0))) * 10 / (8 * 8))
+
+	// Size of the timestamp allocated for each entry.
+	activeSeriesTimestampBytes = int64(unsafe.Sizeof(int64(0)))
+)
+
+// ActiveSeriesMemoryEstimate is the estimated memory used to track the active series of a tenant.
+// The series labels are not retained, so they're not accounted for.
+type ActiveSeriesMemoryEstimate struct {
+	Tenant string `json:"tenant"`
+
+	// Number of series tracked, including the ones which are not active anymore but haven't been purged yet.
+	Series         int `json:"series"`
+	CustomTrackers int `json:"custom_trackers"`
+	Stripes        int `json:"stripes"`
+
+	// Memory used by the entries of the refs maps and the series timestamps.
+	RefsBytes int64 `json:"refs_bytes"`
+
+	// Memory used by the custom trackers matched by each series.
+	MatchesBytes int64 `json:"matches_bytes"`
+
+	// Memory used by the stripes and their counters of active series per custom tracker.
+	StripesBytes int64 `json:"stripes_bytes"`
+
+	// Memory used by the label matchers of the custom trackers, excluding the compiled regular expressions.
+	MatchersBytes int64 `json:"matchers_bytes"`
+
+	TotalBytes int64 `json:"total_bytes"`
+}
+
+// ActiveSeriesMemoryResponse is the response of the active series memory API.
+type ActiveSeriesMemoryResponse struct {
+	TotalBytes int64 `json:"total_bytes"`
+
+	// Tenants sorted by descending estimated memory.
+	Tenants []ActiveSeriesMemoryEstimate `json:"tenants"`
+}
+
+// ActiveSeriesMemoryHandler returns the estimated memory used to track the active series of each tenant in this
+// ingester, to quantify the overhead of the custom trackers.
+func (i *Ingester) ActiveSeriesMemoryHandler(w http.ResponseWriter, _ *http.Request) {
+	if err := i.checkRunning(); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	res := ActiveSeriesMemoryResponse{Tenants: []ActiveSeriesMemoryEstimate{}}
+	for _, userID := range i.getTSDBUsers() {
+		db := i.getTSDB(userID)
+		if db == nil {
+			continue
+		}
+
+		estimate := db.activeSeries.EstimateMemory()
+		estimate.Tenant = userID
+		res.Tenants = append(res.Tenants, estimate)
+		res.TotalBytes += estimate.TotalBytes
+	}
+
+	sort.Slice(res.Tenants, func(i, j int) bool {
+		if res.Tenants[i].TotalBytes != res.Tenants[j].TotalBytes {
+			return res.Tenants[i].TotalBytes > res.Tenants[j].TotalBytes
+		}
+		return res.Tenants[i].Tenant < res.Tenants[j].Tenant
+	})
+
+	util.WriteJSONResponse(w, res)
+}
+
+// EstimateMemory returns the estimated memory used to track the active series. The estimate is based on the
+// number of series currently tracked, while the maps don't shrink once the series have been purged.
+func (c *ActiveSeries) EstimateMemory() ActiveSeriesMemoryEstimate {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	stripes := c.loadStripes()
+	numTrackers := len(c.asm.MatcherNames())
+
+	res := ActiveSeriesMemoryEstimate{
+		CustomTrackers: numTrackers,
+		Stripes:        len(stripes),
+		StripesBytes:   int64(len(stripes)) * (int64(unsafe.Sizeof(activeSeriesStripe{})) + 2*allocationBytes(numTrackers*int(unsafe.Sizeof(int(0))))),
+		MatchersBytes:  c.asm.estimateMemory(),
+	}
+	for i := range stripes {
+		res.Series += stripes[i].numEntries()
+	}
+
+	res.RefsBytes = int64(res.Series) * (activeSeriesRefsMapEntryBytes + activeSeriesTimestampBytes)
+	res.MatchesBytes = int64(res.Series) * allocationBytes(numTrackers)
+	res.TotalBytes = res.RefsBytes + res.MatchesBytes + res.StripesBytes + res.MatchersBytes
+	return res
+}
+
+func (s *activeSeriesStripe) numEntries() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.refs)
+}
+
+// estimateMemory returns the estimated memory used by the names and label matchers of the custom trackers.
+func (asm *ActiveSeriesMatchers) estimateMemory() int64 {
+	size := int64(0)
+	for i, name := range asm.names {
+		size += int64(len(name)) + int64(unsafe.Sizeof(activeSeriesMatcherStats{}))
+		for _, m := range asm.matchers[i] {
+			size += int64(unsafe.Sizeof(labels.Matcher{})) + int64(len(m.Name)+len(m.Value))
+		}
+	}
+	return size
+}
+
+// allocationBytes rounds up the size of a heap allocation to the word size.
+func allocationBytes(size int) int64 {
+	const word = int(unsafe.Sizeof(uintptr(0)))
+	return int64((size + word - 1) / word * word)
+}
@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/test"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+)
+
+func TestActiveSeries_EstimateMemory(t *testing.T) {
+	withoutTrackers := NewActiveSeriesWithStripes(&ActiveSeriesMatchers{}, 4, 0)
+
+	asm, err := NewActiveSeriesMatchers(ActiveSeriesCustomTrackersConfig{"foo": `{a=~"2|3"}`, "bar": `{a="1", b="2"}`})
+	require.NoError(t, err)
+	withTrackers := NewActiveSeriesWithStripes(asm, 4, 0)
+
+	empty := withoutTrackers.EstimateMemory()
+	assert.Equal(t, 0, empty.Series)
+	assert.Equal(t, 4, empty.Stripes)
+	assert.Zero(t, empty.RefsBytes)
+	assert.Zero(t, empty.MatchesBytes)
+	assert.Zero(t, empty.MatchersBytes)
+	assert.Equal(t, empty.StripesBytes, empty.TotalBytes)
+
+	now := time.Now()
+	for i := 0; i < 100; i++ {
+		series := labels.FromStrings("a", "2", "b", "2")
+		withoutTrackers.UpdateSeries(series, storage.SeriesRef(i), now)
+		withTrackers.UpdateSeries(series, storage.SeriesRef(i), now)
+	}
+
+	estimate := withoutTrackers.EstimateMemory()
+	assert.Equal(t, ActiveSeriesMemoryEstimate{
+		Series:       100,
+		Stripes:      4,
+		RefsBytes:    100 * (activeSeriesRefsMapEntryBytes + activeSeriesTimestampBytes),
+		StripesBytes: empty.StripesBytes,
+		TotalBytes:   100*(activeSeriesRefsMapEntryBytes+activeSeriesTimestampBytes) + empty.StripesBytes,
+	}, estimate)
+
+	// The custom trackers take memory for each series, in each stripe and for their matchers.
+	trackersEstimate := withTrackers.EstimateMemory()
+	assert.Equal(t, 100, trackersEstimate.Series)
+	assert.Equal(t, 2, trackersEstimate.CustomTrackers)
+	assert.Equal(t, estimate.RefsBytes, trackersEstimate.RefsBytes)
+	assert.Equal(t, int64(100*8), trackersEstimate.MatchesBytes)
+	assert.Greater(t, trackersEstimate.StripesBytes, estimate.StripesBytes)
+	assert.Greater(t, trackersEstimate.MatchersBytes, int64(0))
+	assert.Equal(t, trackersEstimate.RefsBytes+trackersEstimate.MatchesBytes+trackersEstimate.StripesBytes+trackersEstimate.MatchersBytes, trackersEstimate.TotalBytes)
+
+	// The purged series are not accounted for anymore.
+	withTrackers.Purge(now.Add(time.Minute))
+	assert.Equal(t, 0, withTrackers.EstimateMemory().Series)
+}
+
+func TestIngester_ActiveSeriesMemoryHandler(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+	cfg.IngesterRing.JoinAfter = 0
+	cfg.ActiveSeriesCustomTrackers = ActiveSeriesCustomTrackersConfig{"dev": `{namespace=~"dev-.*"}`}
+
+	i, err := prepareIngesterWithBlocksStorage(t, cfg, nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+	test.Poll(t, 1*time.Second, 1, func() interface{} {
+		return i.lifecycler.HealthyInstancesCount()
+	})
+
+	push := func(userID string, series ...labels.Labels) {
+		for _, s := range series {
+			req, _, _, _ := mockWriteRequest(t, s, 1, 100000)
+			_, err := i.Push(user.InjectOrgID(context.Background(), userID), req)
+			require.NoError(t, err)
+		}
+	}
+	push("user-1", labels.FromStrings(labels.MetricName, "up", "namespace", "dev-1"))
+	push("user-2",
+		labels.FromStrings(labels.MetricName, "up", "namespace", "dev-1"),
+		labels.FromStrings(labels.MetricName, "up", "namespace", "dev-2"),
+		labels.FromStrings(labels.MetricName, "up", "namespace", "prod"),
+	)
+
+	rec := httptest.NewRecorder()
+	i.ActiveSeriesMemoryHandler(rec, httptest.NewRequest(http.MethodGet, "/ingester/active_series_memory", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var res ActiveSeriesMemoryResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+
+	require.Len(t, res.Tenants, 2)
+	assert.Equal(t, "user-2", res.Tenants[0].Tenant)
+	assert.Equal(t, 3, res.Tenants[0].Series)
+	assert.Equal(t, 1, res.Tenants[0].CustomTrackers)
+	assert.Equal(t, "user-1", res.Tenants[1].Tenant)
+	assert.Equal(t, 1, res.Tenants[1].Series)
+	assert.Greater(t, res.Tenants[0].TotalBytes, res.Tenants[1].TotalBytes)
+	assert.Equal(t, res.Tenants[0].TotalBytes+res.Tenants[1].TotalBytes, res.TotalBytes)
+}
@@ -14,32 +14,30 @@ import (
 	"time"
 
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-
-	"github.com/grafana/mimir/pkg/ingester/client"
 )
 
-func copyFn(l labels.Labels) labels.Labels { return l }
-
 func TestActiveSeries_UpdateSeries_NoMatchers(t *testing.T) {
 	ls1 := []labels.Label{{Name: "a", Value: "1"}}
 	ls2 := []labels.Label{{Name: "a", Value: "2"}}
+	ref1, ref2 := storage.SeriesRef(1), storage.SeriesRef(2)
 
 	c := NewActiveSeries(&ActiveSeriesMatchers{})
 	allActive, activeMatching := c.Active()
 	assert.Equal(t, 0, allActive)
 	assert.Nil(t, activeMatching)
 
-	c.UpdateSeries(ls1, time.Now(), copyFn)
+	c.UpdateSeries(ls1, ref1, time.Now())
 	allActive, _ = c.Active()
 	assert.Equal(t, 1, allActive)
 
-	c.UpdateSeries(ls1, time.Now(), copyFn)
+	c.UpdateSeries(ls1, ref1, time.Now())
 	allActive, _ = c.Active()
 	assert.Equal(t, 1, allActive)
 
-	c.UpdateSeries(ls2, time.Now(), copyFn)
+	c.UpdateSeries(ls2, ref2, time.Now())
 	allActive, _ = c.Active()
 	assert.Equal(t, 2, allActive)
 }
@@ -48,6 +46,7 @@ func TestActiveSeries_UpdateSeries_WithMatchers(t *testing.T) {
 	ls1 := []labels.Label{{Name: "a", Value: "1"}}
 	ls2 := []labels.Label{{Name: "a", Value: "2"}}
 	ls3 := []labels.Label{{Name: "a", Value: "3"}}
+	ref1, ref2, ref3 := storage.SeriesRef(1), storage.SeriesRef(2), storage.SeriesRef(3)
 
 	asm, err := NewActiveSeriesMatchers(ActiveSeriesCustomTrackersConfig{"foo": `{a=~"2|3"}`})
 	require.NoError(t, err)
@@ -57,47 +56,69 @@ func TestActiveSeries_UpdateSeries_WithMatchers(t *testing.T) {
 	assert.Equal(t, 0, allActive)
 	assert.Equal(t, []int{0}, activeMatching)
 
-	c.UpdateSeries(ls1, time.Now(), copyFn)
+	c.UpdateSeries(ls1, ref1, time.Now())
 	allActive, activeMatching = c.Active()
 	assert.Equal(t, 1, allActive)
 	assert.Equal(t, []int{0}, activeMatching)
 
-	c.UpdateSeries(ls2, time.Now(), copyFn)
+	c.UpdateSeries(ls2, ref2, time.Now())
 	allActive, activeMatching = c.Active()
 	assert.Equal(t, 2, allActive)
 	assert.Equal(t, []int{1}, activeMatching)
 
-	c.UpdateSeries(ls3, time.Now(), copyFn)
+	c.UpdateSeries(ls3, ref3, time.Now())
 	allActive, activeMatching = c.Active()
 	assert.Equal(t, 3, allActive)
 	assert.Equal(t, []int{2}, activeMatching)
 
-	c.UpdateSeries(ls3, time.Now(), copyFn)
+	c.UpdateSeries(ls3, ref3, time.Now())
 	allActive, activeMatching = c.Active()
 	assert.Equal(t, 3, allActive)
 	assert.Equal(t, []int{2}, activeMatching)
 }
 
-func TestActiveSeries_ShouldCorrectlyHandleFingerprintCollisions(t *testing.T) {
-	metric := labels.NewBuilder(labels.FromStrings("__name__", "logs"))
-	ls1 := metric.Set("_", "ypfajYg2lsv").Labels()
-	ls2 := metric.Set("_", "KiqbryhzUpn").Labels()
+func TestActiveSeries_ReloadMatchers(t *testing.T) {
+	ls1 := []labels.Label{{Name: "a", Value: "1"}}
+	ls2 := []labels.Label{{Name: "a", Value: "2"}}
+	ref1, ref2 := storage.SeriesRef(1), storage.SeriesRef(2)
 
-	require.True(t, client.Fingerprint(ls1) == client.Fingerprint(ls2))
+	asm, err := NewActiveSeriesMatchers(ActiveSeriesCustomTrackersConfig{"foo": `{a=~"2|3"}`})
+	require.NoError(t, err)
 
-	c := NewActiveSeries(&ActiveSeriesMatchers{})
-	c.UpdateSeries(ls1, time.Now(), copyFn)
-	c.UpdateSeries(ls2, time.Now(), copyFn)
+	c := NewActiveSeries(asm)
+	c.UpdateSeries(ls1, ref1, time.Now())
+	c.UpdateSeries(ls2, ref2, time.Now())
+	allActive, activeMatching, names := c.ActiveWithMatchers()
+	assert.Equal(t, 2, allActive)
+	assert.Equal(t, []int{1}, activeMatching)
+	assert.Equal(t, []string{"foo"}, names)
+	assert.True(t, c.MatchersUpdatedAt().IsZero())
 
-	allActive, _ := c.Active()
+	// Reloading the matchers clears the tracked series.
+	newAsm, err := NewActiveSeriesMatchers(ActiveSeriesCustomTrackersConfig{"bar": `{a="1"}`, "baz": `{a=~".+"}`})
+	require.NoError(t, err)
+
+	reloadTime := time.Now()
+	c.ReloadMatchers(newAsm, reloadTime)
+	assert.Equal(t, reloadTime, c.MatchersUpdatedAt())
+	assert.Equal(t, newAsm, c.CurrentMatchers())
+
+	allActive, activeMatching, names = c.ActiveWithMatchers()
+	assert.Equal(t, 0, allActive)
+	assert.Equal(t, []int{0, 0}, activeMatching)
+	assert.Equal(t, []string{"bar", "baz"}, names)
+
+	c.UpdateSeries(ls1, ref1, time.Now())
+	c.UpdateSeries(ls2, ref2, time.Now())
+	allActive, activeMatching, _ = c.ActiveWithMatchers()
 	assert.Equal(t, 2, allActive)
+	assert.Equal(t, []int{1, 2}, activeMatching)
 }
 
 func TestActiveSeries_Purge_NoMatchers(t *testing.T) {
 	series := [][]labels.Label{
 		{{Name: "a", Value: "1"}},
 		{{Name: "a", Value: "2"}},
-		// The two following series have the same Fingerprint
 		{{Name: "_", Value: "ypfajYg2lsv"}, {Name: "__name__", Value: "logs"}},
 		{{Name: "_", Value: "KiqbryhzUpn"}, {Name: "__name__", Value: "logs"}},
 	}
@@ -108,7 +129,7 @@ func TestActiveSeries_Purge_NoMatchers(t *testing.T) {
 			c := NewActiveSeries(&ActiveSeriesMatchers{})
 
 			for i := 0; i < len(series); i++ {
-				c.UpdateSeries(series[i], time.Unix(int64(i), 0), copyFn)
+				c.UpdateSeries(series[i], storage.SeriesRef(i), time.Unix(int64(i), 0))
 			}
 
 			c.Purge(time.Unix(int64(ttl), 0))
@@ -127,7 +148,6 @@ func TestActiveSeries_Purge_WithMatchers(t *testing.T) {
 	series := [][]labels.Label{
 		{{Name: "a", Value: "1"}},
 		{{Name: "a", Value: "2"}},
-		// The two following series have the same Fingerprint
 		{{Name: "_", Value: "ypfajYg2lsv"}, {Name: "__name__", Value: "logs"}},
 		{{Name: "_", Value: "KiqbryhzUpn"}, {Name: "__name__", Value: "logs"}},
 	}
@@ -144,7 +164,7 @@ func TestActiveSeries_Purge_WithMatchers(t *testing.T) {
 			expMatchingSeries := 0
 
 			for i, s := range series {
-				c.UpdateSeries(series[i], time.Unix(int64(i), 0), copyFn)
+				c.UpdateSeries(series[i], storage.SeriesRef(i), time.Unix(int64(i), 0))
 
 				// if this series is matching, and they're within the ttl
 				if asm.matchers[0].Matches(s) && i >= ttl {
@@ -167,34 +187,157 @@ func TestActiveSeries_PurgeOpt(t *testing.T) {
 	metric := labels.NewBuilder(labels.FromStrings("__name__", "logs"))
 	ls1 := metric.Set("_", "ypfajYg2lsv").Labels()
 	ls2 := metric.Set("_", "KiqbryhzUpn").Labels()
+	ref1, ref2 := storage.SeriesRef(1), storage.SeriesRef(2)
 
 	c := NewActiveSeries(&ActiveSeriesMatchers{})
 
 	now := time.Now()
-	c.UpdateSeries(ls1, now.Add(-2*time.Minute), copyFn)
-	c.UpdateSeries(ls2, now, copyFn)
+	c.UpdateSeries(ls1, ref1, now.Add(-2*time.Minute))
+	c.UpdateSeries(ls2, ref2, now)
 	c.Purge(now)
 
 	allActive, _ := c.Active()
 	assert.Equal(t, 1, allActive)
 
-	c.UpdateSeries(ls1, now.Add(-1*time.Minute), copyFn)
-	c.UpdateSeries(ls2, now, copyFn)
+	c.UpdateSeries(ls1, ref1, now.Add(-1*time.Minute))
+	c.UpdateSeries(ls2, ref2, now)
 	c.Purge(now)
 
 	allActive, _ = c.Active()
 	assert.Equal(t, 1, allActive)
 
 	// This will *not* update the series, since there is already newer timestamp.
-	c.UpdateSeries(ls2, now.Add(-1*time.Minute), copyFn)
+	c.UpdateSeries(ls2, ref2, now.Add(-1*time.Minute))
 	c.Purge(now)
 
 	allActive, _ = c.Active()
 	assert.Equal(t, 1, allActive)
 }
 
+func TestActiveSeries_Purge_Churn(t *testing.T) {
+	asm, err := NewActiveSeriesMatchers(ActiveSeriesCustomTrackersConfig{"even": `{a=~".*[02468]"}`})
+	require.NoError(t, err)
+
+	for _, targetSeriesPerStripe := range []int{0, 10} {
+		t.Run(fmt.Sprintf("target series per stripe: %d", targetSeriesPerStripe), func(t *testing.T) {
+			now := time.Now()
+			c := NewActiveSeriesWithStripes(asm, 128, targetSeriesPerStripe)
+
+			for i := 0; i < 100; i++ {
+				c.UpdateSeries(labels.FromStrings("a", strconv.Itoa(i)), storage.SeriesRef(i), now)
+			}
+			churn := c.Purge(now.Add(-time.Minute))
+			assert.Equal(t, ActiveSeriesChurn{Created: 100, CreatedMatching: []int{50}, ExpiredMatching: []int{0}}, churn)
+
+			// Updating the existing series doesn't count them as created again.
+			for i := 50; i < 150; i++ {
+				c.UpdateSeries(labels.FromStrings("a", strconv.Itoa(i)), storage.SeriesRef(i), now.Add(time.Minute))
+			}
+			churn = c.Purge(now.Add(time.Second))
+			assert.Equal(t, ActiveSeriesChurn{Created: 50, Expired: 50, CreatedMatching: []int{25}, ExpiredMatching: []int{25}}, churn)
+
+			// Nothing has changed since the previous purge.
+			churn = c.Purge(now.Add(time.Second))
+			assert.Equal(t, ActiveSeriesChurn{CreatedMatching: []int{0}, ExpiredMatching: []int{0}}, churn)
+
+			allActive, activeMatching := c.Active()
+			assert.Equal(t, 100, allActive)
+			assert.Equal(t, []int{50}, activeMatching)
+		})
+	}
+}
+
 var activeSeriesTestGoroutines = []int{50, 100, 500}
 
+func TestActiveSeries_AdaptiveStripes(t *testing.T) {
+	asm, err := NewActiveSeriesMatchers(ActiveSeriesCustomTrackersConfig{"even": `{a=~".*[02468]"}`})
+	require.NoError(t, err)
+
+	now := time.Now()
+	c := NewActiveSeriesWithStripes(asm, 128, 10)
+	assert.Equal(t, minAdaptiveActiveSeriesStripes, c.NumStripes())
+
+	// 500 series need 50 stripes with 10 series per stripe, so the stripes grow to 64.
+	for i := 0; i < 500; i++ {
+		c.UpdateSeries(labels.FromStrings("a", strconv.Itoa(i)), storage.SeriesRef(i), now)
+	}
+	c.Purge(now.Add(-time.Minute))
+	assert.Equal(t, 64, c.NumStripes())
+
+	allActive, activeMatching := c.Active()
+	assert.Equal(t, 500, allActive)
+	assert.Equal(t, []int{250}, activeMatching)
+
+	// The series moved to the new stripes are still updated and purged.
+	for i := 0; i < 100; i++ {
+		c.UpdateSeries(labels.FromStrings("a", strconv.Itoa(i)), storage.SeriesRef(i), now.Add(time.Minute))
+	}
+	c.Purge(now.Add(time.Second))
+	assert.Equal(t, 16, c.NumStripes())
+
+	allActive, activeMatching = c.Active()
+	assert.Equal(t, 100, allActive)
+	assert.Equal(t, []int{50}, activeMatching)
+	refs, _ := c.ActiveSeriesRefs("")
+	assert.Len(t, refs, 100)
+
+	// The stripes don't grow over the max.
+	for i := 0; i < 5000; i++ {
+		c.UpdateSeries(labels.FromStrings("a", strconv.Itoa(i)), storage.SeriesRef(i), now.Add(time.Minute))
+	}
+	c.Purge(now.Add(time.Second))
+	assert.Equal(t, 128, c.NumStripes())
+
+	allActive, _ = c.Active()
+	assert.Equal(t, 5000, allActive)
+}
+
+func TestActiveSeries_AdaptiveStripes_ConcurrentUpdates(t *testing.T) {
+	const numSeries = 1000
+
+	now := time.Now()
+	c := NewActiveSeriesWithStripes(&ActiveSeriesMatchers{}, 512, 1)
+
+	wg := sync.WaitGroup{}
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := g; i < numSeries; i += 4 {
+				c.UpdateSeries(labels.FromStrings("a", strconv.Itoa(i)), storage.SeriesRef(i), now)
+			}
+		}(g)
+	}
+
+	// Resize the stripes while the series are updated.
+	for i := 0; i < 10; i++ {
+		c.Purge(now.Add(-time.Minute))
+	}
+	wg.Wait()
+
+	c.Purge(now.Add(-time.Minute))
+	allActive, _ := c.Active()
+	assert.Equal(t, numSeries, allActive)
+	assert.Equal(t, 512, c.NumStripes())
+}
+
+func TestTargetActiveSeriesStripes(t *testing.T) {
+	for _, tc := range []struct {
+		numSeries, targetSeriesPerStripe, maxStripes, expected int
+	}{
+		{numSeries: 0, targetSeriesPerStripe: 100, maxStripes: 512, expected: 16},
+		{numSeries: 1600, targetSeriesPerStripe: 100, maxStripes: 512, expected: 16},
+		{numSeries: 1601, targetSeriesPerStripe: 100, maxStripes: 512, expected: 32},
+		{numSeries: 1000000, targetSeriesPerStripe: 100, maxStripes: 512, expected: 512},
+		{numSeries: 1000000, targetSeriesPerStripe: 100, maxStripes: 300, expected: 300},
+		{numSeries: 1000000, targetSeriesPerStripe: 100, maxStripes: 8, expected: 8},
+	} {
+		t.Run(fmt.Sprintf("series=%d target=%d max=%d", tc.numSeries, tc.targetSeriesPerStripe, tc.maxStripes), func(t *testing.T) {
+			assert.Equal(t, tc.expected, targetActiveSeriesStripes(tc.numSeries, tc.targetSeriesPerStripe, tc.maxStripes))
+		})
+	}
+}
+
 func BenchmarkActiveSeriesTest_single_series(b *testing.B) {
 	for _, num := range activeSeriesTestGoroutines {
 		b.Run(fmt.Sprintf("%d", num), func(b *testing.B) {
@@ -207,6 +350,7 @@ func benchmarkActiveSeriesConcurrencySingleSeries(b *testing.B, goroutines int)
 	series := labels.Labels{
 		{Name: "a", Value: "a"},
 	}
+	ref := storage.SeriesRef(1)
 
 	c := NewActiveSeries(&ActiveSeriesMatchers{})
 
@@ -224,7 +368,7 @@ func benchmarkActiveSeriesConcurrencySingleSeries(b *testing.B, goroutines int)
 
 			for ix := 0; ix < max; ix++ {
 				now = now.Add(time.Duration(ix) * time.Millisecond)
-				c.UpdateSeries(series, now, copyFn)
+				c.UpdateSeries(series, ref, now)
 			}
 		}()
 	}
@@ -275,7 +419,7 @@ func BenchmarkActiveSeries_UpdateSeries(b *testing.B) {
 				c := NewActiveSeries(&ActiveSeriesMatchers{})
 				for round := 0; round <= tt.nRounds; round++ {
 					for ix := 0; ix < tt.nSeries; ix++ {
-						c.UpdateSeries(series[ix], time.Unix(0, now), copyFn)
+						c.UpdateSeries(series[ix], storage.SeriesRef(ix), time.Unix(0, now))
 						now++
 					}
 				}
@@ -284,6 +428,60 @@ func BenchmarkActiveSeries_UpdateSeries(b *testing.B) {
 	}
 }
 
+// BenchmarkActiveSeries_UpdateSeries_Stripes shows the lock contention when updating the series of a tenant
+// concurrently with different numbers of stripes.
+func BenchmarkActiveSeries_UpdateSeries_Stripes(b *testing.B) {
+	const numSeries = 10000
+
+	series := make([]labels.Labels, numSeries)
+	for s := 0; s < numSeries; s++ {
+		series[s] = labels.FromStrings("a", strconv.Itoa(s))
+	}
+
+	for _, numStripes := range []int{1, 16, 128, 512} {
+		for _, goroutines := range []int{1, 8, 32} {
+			b.Run(fmt.Sprintf("stripes=%d goroutines=%d", numStripes, goroutines), func(b *testing.B) {
+				c := NewActiveSeriesWithStripes(&ActiveSeriesMatchers{}, numStripes, 0)
+				now := time.Now()
+
+				wg := sync.WaitGroup{}
+				start := make(chan struct{})
+				perGoroutine := int(math.Ceil(float64(b.N) / float64(goroutines)))
+
+				for g := 0; g < goroutines; g++ {
+					wg.Add(1)
+					go func(g int) {
+						defer wg.Done()
+						<-start
+
+						for i := 0; i < perGoroutine; i++ {
+							ix := (g*perGoroutine + i) % numSeries
+							c.UpdateSeries(series[ix], storage.SeriesRef(ix), now)
+						}
+					}(g)
+				}
+
+				b.ResetTimer()
+				close(start)
+				wg.Wait()
+			})
+		}
+	}
+}
+
+// BenchmarkNewActiveSeries shows the memory allocated for the active series of each tenant with different numbers
+// of stripes.
+func BenchmarkNewActiveSeries(b *testing.B) {
+	for _, numStripes := range []int{minAdaptiveActiveSeriesStripes, 128, DefaultActiveSeriesStripes} {
+		b.Run(fmt.Sprintf("stripes=%d", numStripes), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				NewActiveSeriesWithStripes(&ActiveSeriesMatchers{}, numStripes, 0)
+			}
+		})
+	}
+}
+
 func BenchmarkActiveSeries_Purge_once(b *testing.B) {
 	benchmarkPurge(b, false)
 }
@@ -310,9 +508,9 @@ func benchmarkPurge(b *testing.B, twice bool) {
 		// Prepare series
 		for ix, s := range series {
 			if ix < numExpiresSeries {
-				c.UpdateSeries(s, now.Add(-time.Minute), copyFn)
+				c.UpdateSeries(s, storage.SeriesRef(ix), now.Add(-time.Minute))
 			} else {
-				c.UpdateSeries(s, now, copyFn)
+				c.UpdateSeries(s, storage.SeriesRef(ix), now)
 			}
 		}
 
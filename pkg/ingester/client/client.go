@@ -12,8 +12,12 @@ import (
 	"github.com/grafana/dskit/grpcclient"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/weaveworks/common/httpgrpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/grafana/mimir/pkg/util/grpcencoding"
+	"github.com/grafana/mimir/pkg/util/tlsreload"
 )
 
 var ingesterClientRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
@@ -33,12 +37,18 @@ type HealthAndIngesterClient interface {
 type closableHealthAndIngesterClient struct {
 	IngesterClient
 	grpc_health_v1.HealthClient
+	httpgrpc.HTTPClient
 	conn *grpc.ClientConn
 }
 
-// MakeIngesterClient makes a new IngesterClient
+// MakeIngesterClient makes a new IngesterClient. The client also implements httpgrpc.HTTPClient,
+// to call the HTTP API of the ingester through gRPC.
 func MakeIngesterClient(addr string, cfg Config) (HealthAndIngesterClient, error) {
-	dialOpts, err := cfg.GRPCClientConfig.DialOption(grpcclient.Instrument(ingesterClientRequestDuration))
+	unary, stream := grpcclient.Instrument(ingesterClientRequestDuration)
+	unary = append(unary, grpcencoding.UnaryClientInterceptor)
+	stream = append(stream, grpcencoding.StreamClientInterceptor)
+
+	dialOpts, err := tlsreload.GRPCDialOptions(cfg.GRPCClientConfig, unary, stream)
 	if err != nil {
 		return nil, err
 	}
@@ -49,6 +59,7 @@ func MakeIngesterClient(addr string, cfg Config) (HealthAndIngesterClient, error
 	return &closableHealthAndIngesterClient{
 		IngesterClient: NewIngesterClient(conn),
 		HealthClient:   grpc_health_v1.NewHealthClient(conn),
+		HTTPClient:     httpgrpc.NewHTTPClient(conn),
 		conn:           conn,
 	}, nil
 }
@@ -60,13 +71,15 @@ func (c *closableHealthAndIngesterClient) Close() error {
 // Config is the configuration struct for the ingester client
 type Config struct {
 	GRPCClientConfig grpcclient.Config `yaml:"grpc_client_config"`
+	InProcessEnabled bool              `yaml:"in_process_enabled" category:"experimental"`
 }
 
 // RegisterFlags registers configuration settings used by the ingester client config.
 func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.GRPCClientConfig.RegisterFlagsWithPrefix("ingester.client", f)
+	f.BoolVar(&cfg.InProcessEnabled, "ingester.client.in-process-enabled", false, "When enabled, distributors and queriers call the ingester running in the same process, like in monolithic mode, in-process instead of through gRPC. The requests to the ingester running in the same process are not tracked by the gRPC server metrics.")
 }
 
 func (cfg *Config) Validate(log log.Logger) error {
-	return cfg.GRPCClientConfig.Validate(log)
+	return grpcencoding.ValidateClientConfig(cfg.GRPCClientConfig, log)
 }
@@ -0,0 +1,299 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package client
+
+import (
+	"context"
+	"io"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+// InProcessServer is an ingester which can be called in-process.
+type InProcessServer interface {
+	IngesterServer
+
+	// PushWithCleanup is like Push, but calls cleanup once done instead of reusing the request.
+	PushWithCleanup(ctx context.Context, req *mimirpb.WriteRequest, cleanup func()) (*mimirpb.WriteResponse, error)
+}
+
+// NewInProcessClient returns a client calling the ingester in-process, instead of sending the requests
+// through gRPC. Requests and responses of unary calls are passed as they are. The messages of streaming
+// calls are marshalled when sent, because the ingester reuses them, and the memory they reference,
+// once sent.
+func NewInProcessClient(server InProcessServer) HealthAndIngesterClient {
+	return &inProcessClient{server: server}
+}
+
+type inProcessClient struct {
+	server InProcessServer
+}
+
+func (c *inProcessClient) Push(ctx context.Context, in *mimirpb.WriteRequest, _ ...grpc.CallOption) (*mimirpb.WriteResponse, error) {
+	// The request is owned by the caller, so the ingester must not reuse it.
+	resp, err := c.server.PushWithCleanup(ctx, in, func() {})
+	return resp, toGRPCError(err)
+}
+
+func (c *inProcessClient) QueryExemplars(ctx context.Context, in *ExemplarQueryRequest, _ ...grpc.CallOption) (*ExemplarQueryResponse, error) {
+	resp, err := c.server.QueryExemplars(ctx, in)
+	return resp, toGRPCError(err)
+}
+
+func (c *inProcessClient) LabelValues(ctx context.Context, in *LabelValuesRequest, _ ...grpc.CallOption) (*LabelValuesResponse, error) {
+	resp, err := c.server.LabelValues(ctx, in)
+	return resp, toGRPCError(err)
+}
+
+func (c *inProcessClient) LabelNames(ctx context.Context, in *LabelNamesRequest, _ ...grpc.CallOption) (*LabelNamesResponse, error) {
+	resp, err := c.server.LabelNames(ctx, in)
+	return resp, toGRPCError(err)
+}
+
+func (c *inProcessClient) UserStats(ctx context.Context, in *UserStatsRequest, _ ...grpc.CallOption) (*UserStatsResponse, error) {
+	resp, err := c.server.UserStats(ctx, in)
+	return resp, toGRPCError(err)
+}
+
+func (c *inProcessClient) AllUserStats(ctx context.Context, in *UserStatsRequest, _ ...grpc.CallOption) (*UsersStatsResponse, error) {
+	resp, err := c.server.AllUserStats(ctx, in)
+	return resp, toGRPCError(err)
+}
+
+func (c *inProcessClient) MetricsForLabelMatchers(ctx context.Context, in *MetricsForLabelMatchersRequest, _ ...grpc.CallOption) (*MetricsForLabelMatchersResponse, error) {
+	resp, err := c.server.MetricsForLabelMatchers(ctx, in)
+	return resp, toGRPCError(err)
+}
+
+func (c *inProcessClient) MetricsMetadata(ctx context.Context, in *MetricsMetadataRequest, _ ...grpc.CallOption) (*MetricsMetadataResponse, error) {
+	resp, err := c.server.MetricsMetadata(ctx, in)
+	return resp, toGRPCError(err)
+}
+
+func (c *inProcessClient) QueryStream(ctx context.Context, in *QueryRequest, _ ...grpc.CallOption) (Ingester_QueryStreamClient, error) {
+	s := newInProcessStream(ctx)
+	go s.run(func() error { return c.server.QueryStream(in, &queryStreamServer{s}) })
+	return &queryStreamClient{s}, nil
+}
+
+func (c *inProcessClient) LabelNamesAndValues(ctx context.Context, in *LabelNamesAndValuesRequest, _ ...grpc.CallOption) (Ingester_LabelNamesAndValuesClient, error) {
+	s := newInProcessStream(ctx)
+	go s.run(func() error { return c.server.LabelNamesAndValues(in, &labelNamesAndValuesServer{s}) })
+	return &labelNamesAndValuesClient{s}, nil
+}
+
+func (c *inProcessClient) LabelValuesCardinality(ctx context.Context, in *LabelValuesCardinalityRequest, _ ...grpc.CallOption) (Ingester_LabelValuesCardinalityClient, error) {
+	s := newInProcessStream(ctx)
+	go s.run(func() error { return c.server.LabelValuesCardinality(in, &labelValuesCardinalityServer{s}) })
+	return &labelValuesCardinalityClient{s}, nil
+}
+
+func (c *inProcessClient) QueryExemplarsStream(ctx context.Context, in *ExemplarQueryRequest, _ ...grpc.CallOption) (Ingester_QueryExemplarsStreamClient, error) {
+	s := newInProcessStream(ctx)
+	go s.run(func() error { return c.server.QueryExemplarsStream(in, &queryExemplarsStreamServer{s}) })
+	return &queryExemplarsStreamClient{s}, nil
+}
+
+func (c *inProcessClient) MetricsMetadataStream(ctx context.Context, in *MetricsMetadataRequest, _ ...grpc.CallOption) (Ingester_MetricsMetadataStreamClient, error) {
+	s := newInProcessStream(ctx)
+	go s.run(func() error { return c.server.MetricsMetadataStream(in, &metricsMetadataStreamServer{s}) })
+	return &metricsMetadataStreamClient{s}, nil
+}
+
+func (c *inProcessClient) ActiveSeries(ctx context.Context, in *ActiveSeriesRequest, _ ...grpc.CallOption) (Ingester_ActiveSeriesClient, error) {
+	s := newInProcessStream(ctx)
+	go s.run(func() error { return c.server.ActiveSeries(in, &activeSeriesServer{s}) })
+	return &activeSeriesClient{s}, nil
+}
+
+// Check always reports the ingester as serving, because it runs in this same process.
+func (c *inProcessClient) Check(context.Context, *grpc_health_v1.HealthCheckRequest, ...grpc.CallOption) (*grpc_health_v1.HealthCheckResponse, error) {
+	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+}
+
+func (c *inProcessClient) Watch(context.Context, *grpc_health_v1.HealthCheckRequest, ...grpc.CallOption) (grpc_health_v1.Health_WatchClient, error) {
+	return nil, status.Error(codes.Unimplemented, "watch is not supported by the in-process ingester client")
+}
+
+func (c *inProcessClient) Close() error {
+	return nil
+}
+
+// toGRPCError converts the error to a gRPC status error, as the gRPC server would do.
+func toGRPCError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if s := status.FromContextError(err); s.Code() != codes.Unknown {
+		return s.Err()
+	}
+	return status.Error(codes.Unknown, err.Error())
+}
+
+type marshaler interface {
+	Marshal() ([]byte, error)
+}
+
+type unmarshaler interface {
+	Unmarshal([]byte) error
+}
+
+// inProcessStream is a server-side streaming call between the in-process client and the ingester.
+// It implements both grpc.ServerStream and grpc.ClientStream.
+type inProcessStream struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	msgs   chan []byte
+
+	// err is the error returned by the ingester, set before msgs is closed.
+	err error
+}
+
+func newInProcessStream(ctx context.Context) *inProcessStream {
+	ctx, cancel := context.WithCancel(ctx)
+	return &inProcessStream{
+		ctx:    ctx,
+		cancel: cancel,
+		msgs:   make(chan []byte),
+	}
+}
+
+func (s *inProcessStream) run(handler func() error) {
+	s.err = toGRPCError(handler())
+	close(s.msgs)
+}
+
+func (s *inProcessStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *inProcessStream) SendMsg(m interface{}) error {
+	data, err := m.(marshaler).Marshal()
+	if err != nil {
+		return err
+	}
+
+	select {
+	case s.msgs <- data:
+		return nil
+	case <-s.ctx.Done():
+		return toGRPCError(s.ctx.Err())
+	}
+}
+
+func (s *inProcessStream) RecvMsg(m interface{}) error {
+	data, ok := <-s.msgs
+	if !ok {
+		// Release the context once the ingester is done.
+		s.cancel()
+
+		if s.err != nil {
+			return s.err
+		}
+		return io.EOF
+	}
+	return m.(unmarshaler).Unmarshal(data)
+}
+
+func (s *inProcessStream) SetHeader(metadata.MD) error  { return nil }
+func (s *inProcessStream) SendHeader(metadata.MD) error { return nil }
+func (s *inProcessStream) SetTrailer(metadata.MD)       {}
+
+func (s *inProcessStream) Header() (metadata.MD, error) { return nil, nil }
+func (s *inProcessStream) Trailer() metadata.MD         { return nil }
+func (s *inProcessStream) CloseSend() error             { return nil }
+
+type queryStreamServer struct{ *inProcessStream }
+
+func (s *queryStreamServer) Send(m *QueryStreamResponse) error { return s.SendMsg(m) }
+
+type queryStreamClient struct{ *inProcessStream }
+
+func (s *queryStreamClient) Recv() (*QueryStreamResponse, error) {
+	m := &QueryStreamResponse{}
+	if err := s.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+type labelNamesAndValuesServer struct{ *inProcessStream }
+
+func (s *labelNamesAndValuesServer) Send(m *LabelNamesAndValuesResponse) error { return s.SendMsg(m) }
+
+type labelNamesAndValuesClient struct{ *inProcessStream }
+
+func (s *labelNamesAndValuesClient) Recv() (*LabelNamesAndValuesResponse, error) {
+	m := &LabelNamesAndValuesResponse{}
+	if err := s.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+type labelValuesCardinalityServer struct{ *inProcessStream }
+
+func (s *labelValuesCardinalityServer) Send(m *LabelValuesCardinalityResponse) error {
+	return s.SendMsg(m)
+}
+
+type labelValuesCardinalityClient struct{ *inProcessStream }
+
+func (s *labelValuesCardinalityClient) Recv() (*LabelValuesCardinalityResponse, error) {
+	m := &LabelValuesCardinalityResponse{}
+	if err := s.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+type queryExemplarsStreamServer struct{ *inProcessStream }
+
+func (s *queryExemplarsStreamServer) Send(m *ExemplarQueryResponse) error { return s.SendMsg(m) }
+
+type queryExemplarsStreamClient struct{ *inProcessStream }
+
+func (s *queryExemplarsStreamClient) Recv() (*ExemplarQueryResponse, error) {
+	m := &ExemplarQueryResponse{}
+	if err := s.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+type metricsMetadataStreamServer struct{ *inProcessStream }
+
+func (s *metricsMetadataStreamServer) Send(m *MetricsMetadataResponse) error { return s.SendMsg(m) }
+
+type metricsMetadataStreamClient struct{ *inProcessStream }
+
+func (s *metricsMetadataStreamClient) Recv() (*MetricsMetadataResponse, error) {
+	m := &MetricsMetadataResponse{}
+	if err := s.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+type activeSeriesServer struct{ *inProcessStream }
+
+func (s *activeSeriesServer) Send(m *ActiveSeriesResponse) error { return s.SendMsg(m) }
+
+type activeSeriesClient struct{ *inProcessStream }
+
+func (s *activeSeriesClient) Recv() (*ActiveSeriesResponse, error) {
+	m := &ActiveSeriesResponse{}
+	if err := s.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
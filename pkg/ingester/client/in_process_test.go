@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/tenant"
+)
+
+type inProcessServerMock struct {
+	*IngesterServerMock
+}
+
+func (m *inProcessServerMock) PushWithCleanup(ctx context.Context, req *mimirpb.WriteRequest, cleanup func()) (*mimirpb.WriteResponse, error) {
+	defer cleanup()
+	return m.Push(ctx, req)
+}
+
+func TestInProcessClient_Push(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+	req := &mimirpb.WriteRequest{Source: mimirpb.RULE}
+
+	server := &inProcessServerMock{&IngesterServerMock{}}
+	server.On("Push", mock.Anything, mock.Anything).Return(&mimirpb.WriteResponse{}, nil).Run(func(args mock.Arguments) {
+		// The request and the tenant are passed as they are.
+		userID, err := tenant.TenantID(args.Get(0).(context.Context))
+		require.NoError(t, err)
+		assert.Equal(t, "user-1", userID)
+		assert.Same(t, req, args.Get(1))
+	}).Once()
+	server.On("Push", mock.Anything, mock.Anything).Return((*mimirpb.WriteResponse)(nil), errors.New("push failed")).Once()
+
+	c := NewInProcessClient(server)
+
+	_, err := c.Push(ctx, req)
+	require.NoError(t, err)
+
+	// Errors are converted to gRPC errors, like the gRPC server does.
+	_, err = c.Push(ctx, req)
+	s, ok := status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, codes.Unknown, s.Code())
+	assert.Equal(t, "push failed", s.Message())
+}
+
+func TestInProcessClient_QueryStream(t *testing.T) {
+	t.Run("should receive the messages sent by the ingester", func(t *testing.T) {
+		server := &inProcessServerMock{&IngesterServerMock{}}
+		server.On("QueryStream", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+			stream := args.Get(1).(Ingester_QueryStreamServer)
+
+			// The ingester reuses the message once sent.
+			msg := &QueryStreamResponse{Timeseries: []mimirpb.TimeSeries{{Labels: []mimirpb.LabelAdapter{{Name: "series", Value: "1"}}}}}
+			require.NoError(t, stream.Send(msg))
+
+			msg.Timeseries[0].Labels[0].Value = "2"
+			require.NoError(t, stream.Send(msg))
+		})
+
+		stream, err := NewInProcessClient(server).QueryStream(context.Background(), &QueryRequest{})
+		require.NoError(t, err)
+
+		for _, expected := range []string{"1", "2"} {
+			resp, err := stream.Recv()
+			require.NoError(t, err)
+			require.Len(t, resp.Timeseries, 1)
+			assert.Equal(t, []mimirpb.LabelAdapter{{Name: "series", Value: expected}}, resp.Timeseries[0].Labels)
+		}
+
+		_, err = stream.Recv()
+		assert.Equal(t, io.EOF, err)
+	})
+
+	t.Run("should return the error returned by the ingester", func(t *testing.T) {
+		server := &inProcessServerMock{&IngesterServerMock{}}
+		server.On("QueryStream", mock.Anything, mock.Anything).Return(status.Error(codes.Unavailable, "not running"))
+
+		stream, err := NewInProcessClient(server).QueryStream(context.Background(), &QueryRequest{})
+		require.NoError(t, err)
+
+		_, err = stream.Recv()
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	})
+
+	t.Run("should stop the ingester from sending once the client context is canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		sendErr := make(chan error, 1)
+
+		server := &inProcessServerMock{&IngesterServerMock{}}
+		server.On("QueryStream", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+			stream := args.Get(1).(Ingester_QueryStreamServer)
+
+			// Nobody receives the message, so the send is only unblocked by the cancellation.
+			cancel()
+			sendErr <- stream.Send(&QueryStreamResponse{})
+		})
+
+		_, err := NewInProcessClient(server).QueryStream(ctx, &QueryRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, codes.Canceled, status.Code(<-sendErr))
+	})
+}
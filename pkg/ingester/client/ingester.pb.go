@@ -433,9 +433,10 @@ func (m *ReadResponse) GetResults() []*QueryResponse {
 }
 
 type QueryRequest struct {
-	StartTimestampMs int64           `protobuf:"varint,1,opt,name=start_timestamp_ms,json=startTimestampMs,proto3" json:"start_timestamp_ms,omitempty"`
-	EndTimestampMs   int64           `protobuf:"varint,2,opt,name=end_timestamp_ms,json=endTimestampMs,proto3" json:"end_timestamp_ms,omitempty"`
-	Matchers         []*LabelMatcher `protobuf:"bytes,3,rep,name=matchers,proto3" json:"matchers,omitempty"`
+	StartTimestampMs         int64           `protobuf:"varint,1,opt,name=start_timestamp_ms,json=startTimestampMs,proto3" json:"start_timestamp_ms,omitempty"`
+	EndTimestampMs           int64           `protobuf:"varint,2,opt,name=end_timestamp_ms,json=endTimestampMs,proto3" json:"end_timestamp_ms,omitempty"`
+	Matchers                 []*LabelMatcher `protobuf:"bytes,3,rep,name=matchers,proto3" json:"matchers,omitempty"`
+	StreamingProtocolVersion uint32          `protobuf:"varint,4,opt,name=streaming_protocol_version,json=streamingProtocolVersion,proto3" json:"streaming_protocol_version,omitempty"`
 }
 
 func (m *QueryRequest) Reset()      { *m = QueryRequest{} }
@@ -491,6 +492,13 @@ func (m *QueryRequest) GetMatchers() []*LabelMatcher {
 	return nil
 }
 
+func (m *QueryRequest) GetStreamingProtocolVersion() uint32 {
+	if m != nil {
+		return m.StreamingProtocolVersion
+	}
+	return 0
+}
+
 type ExemplarQueryRequest struct {
 	StartTimestampMs int64            `protobuf:"varint,1,opt,name=start_timestamp_ms,json=startTimestampMs,proto3" json:"start_timestamp_ms,omitempty"`
 	EndTimestampMs   int64            `protobuf:"varint,2,opt,name=end_timestamp_ms,json=endTimestampMs,proto3" json:"end_timestamp_ms,omitempty"`
@@ -1198,13 +1206,99 @@ func (m *MetricsForLabelMatchersResponse) GetMetric() []*mimirpb.Metric {
 	return nil
 }
 
+type ActiveSeriesRequest struct {
+	Matchers []*LabelMatcher `protobuf:"bytes,1,rep,name=matchers,proto3" json:"matchers,omitempty"`
+}
+
+func (m *ActiveSeriesRequest) Reset()      { *m = ActiveSeriesRequest{} }
+func (*ActiveSeriesRequest) ProtoMessage() {}
+func (*ActiveSeriesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_60f6df4f3586b478, []int{23}
+}
+func (m *ActiveSeriesRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ActiveSeriesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ActiveSeriesRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ActiveSeriesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ActiveSeriesRequest.Merge(m, src)
+}
+func (m *ActiveSeriesRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *ActiveSeriesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ActiveSeriesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ActiveSeriesRequest proto.InternalMessageInfo
+
+func (m *ActiveSeriesRequest) GetMatchers() []*LabelMatcher {
+	if m != nil {
+		return m.Matchers
+	}
+	return nil
+}
+
+type ActiveSeriesResponse struct {
+	Metric []*mimirpb.Metric `protobuf:"bytes,1,rep,name=metric,proto3" json:"metric,omitempty"`
+}
+
+func (m *ActiveSeriesResponse) Reset()      { *m = ActiveSeriesResponse{} }
+func (*ActiveSeriesResponse) ProtoMessage() {}
+func (*ActiveSeriesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_60f6df4f3586b478, []int{24}
+}
+func (m *ActiveSeriesResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ActiveSeriesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ActiveSeriesResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ActiveSeriesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ActiveSeriesResponse.Merge(m, src)
+}
+func (m *ActiveSeriesResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *ActiveSeriesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ActiveSeriesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ActiveSeriesResponse proto.InternalMessageInfo
+
+func (m *ActiveSeriesResponse) GetMetric() []*mimirpb.Metric {
+	if m != nil {
+		return m.Metric
+	}
+	return nil
+}
+
 type MetricsMetadataRequest struct {
 }
 
 func (m *MetricsMetadataRequest) Reset()      { *m = MetricsMetadataRequest{} }
 func (*MetricsMetadataRequest) ProtoMessage() {}
 func (*MetricsMetadataRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{23}
+	return fileDescriptor_60f6df4f3586b478, []int{25}
 }
 func (m *MetricsMetadataRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1240,7 +1334,7 @@ type MetricsMetadataResponse struct {
 func (m *MetricsMetadataResponse) Reset()      { *m = MetricsMetadataResponse{} }
 func (*MetricsMetadataResponse) ProtoMessage() {}
 func (*MetricsMetadataResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{24}
+	return fileDescriptor_60f6df4f3586b478, []int{26}
 }
 func (m *MetricsMetadataResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1286,7 +1380,7 @@ type TimeSeriesChunk struct {
 func (m *TimeSeriesChunk) Reset()      { *m = TimeSeriesChunk{} }
 func (*TimeSeriesChunk) ProtoMessage() {}
 func (*TimeSeriesChunk) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{25}
+	return fileDescriptor_60f6df4f3586b478, []int{27}
 }
 func (m *TimeSeriesChunk) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1346,7 +1440,7 @@ type Chunk struct {
 func (m *Chunk) Reset()      { *m = Chunk{} }
 func (*Chunk) ProtoMessage() {}
 func (*Chunk) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{26}
+	return fileDescriptor_60f6df4f3586b478, []int{28}
 }
 func (m *Chunk) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1410,7 +1504,7 @@ type LabelMatchers struct {
 func (m *LabelMatchers) Reset()      { *m = LabelMatchers{} }
 func (*LabelMatchers) ProtoMessage() {}
 func (*LabelMatchers) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{27}
+	return fileDescriptor_60f6df4f3586b478, []int{29}
 }
 func (m *LabelMatchers) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1455,7 +1549,7 @@ type LabelMatcher struct {
 func (m *LabelMatcher) Reset()      { *m = LabelMatcher{} }
 func (*LabelMatcher) ProtoMessage() {}
 func (*LabelMatcher) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{28}
+	return fileDescriptor_60f6df4f3586b478, []int{30}
 }
 func (m *LabelMatcher) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1515,7 +1609,7 @@ type TimeSeriesFile struct {
 func (m *TimeSeriesFile) Reset()      { *m = TimeSeriesFile{} }
 func (*TimeSeriesFile) ProtoMessage() {}
 func (*TimeSeriesFile) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{29}
+	return fileDescriptor_60f6df4f3586b478, []int{31}
 }
 func (m *TimeSeriesFile) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1598,6 +1692,8 @@ func init() {
 	proto.RegisterType((*UsersStatsResponse)(nil), "cortex.UsersStatsResponse")
 	proto.RegisterType((*MetricsForLabelMatchersRequest)(nil), "cortex.MetricsForLabelMatchersRequest")
 	proto.RegisterType((*MetricsForLabelMatchersResponse)(nil), "cortex.MetricsForLabelMatchersResponse")
+	proto.RegisterType((*ActiveSeriesRequest)(nil), "cortex.ActiveSeriesRequest")
+	proto.RegisterType((*ActiveSeriesResponse)(nil), "cortex.ActiveSeriesResponse")
 	proto.RegisterType((*MetricsMetadataRequest)(nil), "cortex.MetricsMetadataRequest")
 	proto.RegisterType((*MetricsMetadataResponse)(nil), "cortex.MetricsMetadataResponse")
 	proto.RegisterType((*TimeSeriesChunk)(nil), "cortex.TimeSeriesChunk")
@@ -1610,96 +1706,102 @@ func init() {
 func init() { proto.RegisterFile("ingester.proto", fileDescriptor_60f6df4f3586b478) }
 
 var fileDescriptor_60f6df4f3586b478 = []byte{
-	// 1416 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x58, 0xcd, 0x6f, 0xdb, 0xc6,
-	0x12, 0xd7, 0x4a, 0xb2, 0x6c, 0x8d, 0x64, 0x45, 0x5e, 0xc7, 0xb6, 0xc2, 0x3c, 0xd3, 0x7e, 0x7c,
-	0x48, 0x9e, 0xfa, 0x11, 0x39, 0x71, 0x5a, 0x20, 0x09, 0x5a, 0x04, 0xb6, 0xe3, 0x24, 0x6e, 0x62,
-	0x3b, 0xa1, 0x9d, 0xb6, 0x28, 0x50, 0x08, 0x2b, 0x69, 0x6d, 0x13, 0x26, 0x29, 0x85, 0x5c, 0x16,
-	0xf1, 0xad, 0x40, 0xef, 0x6d, 0xd1, 0x53, 0x4f, 0x05, 0x7a, 0xeb, 0xb9, 0x97, 0xde, 0x7a, 0xce,
-	0x31, 0xc7, 0xa0, 0x87, 0xa0, 0x51, 0x2e, 0xed, 0x2d, 0x7f, 0x42, 0xc1, 0xdd, 0x25, 0x45, 0x52,
-	0xf4, 0x47, 0x8a, 0x24, 0x27, 0x6b, 0x67, 0x7e, 0x33, 0xfb, 0xdb, 0x99, 0xd9, 0x99, 0xa5, 0xa1,
-	0x62, 0xd8, 0xbb, 0xd4, 0x65, 0xd4, 0x69, 0xf4, 0x9c, 0x2e, 0xeb, 0xe2, 0x42, 0xbb, 0xeb, 0x30,
-	0xfa, 0x48, 0xb9, 0xb0, 0x6b, 0xb0, 0x3d, 0xaf, 0xd5, 0x68, 0x77, 0xad, 0x85, 0xdd, 0xee, 0x6e,
-	0x77, 0x81, 0xab, 0x5b, 0xde, 0x0e, 0x5f, 0xf1, 0x05, 0xff, 0x25, 0xcc, 0x94, 0x8b, 0x51, 0xb8,
-	0x43, 0x76, 0x88, 0x4d, 0x16, 0x2c, 0xc3, 0x32, 0x9c, 0x85, 0xde, 0xfe, 0xae, 0xf8, 0xd5, 0x6b,
-	0x89, 0xbf, 0xc2, 0x42, 0xdb, 0x00, 0xe5, 0x2e, 0x69, 0x51, 0x73, 0x83, 0x58, 0xd4, 0x5d, 0xb2,
-	0x3b, 0x9f, 0x12, 0xd3, 0xa3, 0xae, 0x4e, 0x1f, 0x7a, 0xd4, 0x65, 0xf8, 0x22, 0x8c, 0x59, 0x84,
-	0xb5, 0xf7, 0xa8, 0xe3, 0xd6, 0xd0, 0x7c, 0xae, 0x5e, 0x5a, 0x3c, 0xdd, 0x10, 0xcc, 0x1a, 0xdc,
-	0x6a, 0x5d, 0x28, 0xf5, 0x10, 0xa5, 0xdd, 0x86, 0xb3, 0xa9, 0xfe, 0xdc, 0x5e, 0xd7, 0x76, 0x29,
-	0x7e, 0x07, 0x46, 0x0c, 0x46, 0xad, 0xc0, 0xdb, 0x64, 0xcc, 0x9b, 0xc4, 0x0a, 0x84, 0x76, 0x03,
-	0x4a, 0x11, 0x29, 0x9e, 0x05, 0x30, 0xfd, 0x65, 0xd3, 0x26, 0x16, 0xad, 0xa1, 0x79, 0x54, 0x2f,
-	0xea, 0x45, 0x33, 0xd8, 0x0a, 0x4f, 0x43, 0xe1, 0x2b, 0x0e, 0xac, 0x65, 0xe7, 0x73, 0xf5, 0xa2,
-	0x2e, 0x57, 0x9a, 0x03, 0xb3, 0x11, 0x2f, 0x2b, 0xc4, 0xe9, 0x18, 0x36, 0x31, 0x0d, 0x76, 0x10,
-	0x1c, 0x71, 0x0e, 0x4a, 0x03, 0xbf, 0x82, 0x57, 0x51, 0x87, 0xd0, 0xb1, 0x1b, 0x8b, 0x41, 0xf6,
-	0x44, 0x31, 0x78, 0x00, 0xea, 0x61, 0x7b, 0xca, 0x30, 0x5c, 0x8e, 0x87, 0x61, 0x76, 0x38, 0x0c,
-	0x5b, 0xd4, 0x31, 0xa8, 0xbb, 0xd2, 0xf5, 0x6c, 0x16, 0x04, 0xe4, 0x19, 0x82, 0xa9, 0x54, 0xc0,
-	0x71, 0xb1, 0x21, 0x80, 0x85, 0x9a, 0xc7, 0xa4, 0xe9, 0x72, 0x4b, 0x79, 0x96, 0xcb, 0x47, 0x6e,
-	0x3d, 0x24, 0x5d, 0xb5, 0x99, 0x73, 0xa0, 0x57, 0xcd, 0x84, 0x58, 0x59, 0x19, 0xa6, 0xc6, 0xa1,
-	0xb8, 0x0a, 0xb9, 0x7d, 0x7a, 0x20, 0x39, 0xf9, 0x3f, 0xf1, 0x69, 0x18, 0xe1, 0x3c, 0x6a, 0xd9,
-	0x79, 0x54, 0xcf, 0xeb, 0x62, 0x71, 0x2d, 0x7b, 0x05, 0x69, 0x1f, 0x43, 0x49, 0xa7, 0xa4, 0x13,
-	0x64, 0xa6, 0x01, 0xa3, 0x0f, 0x3d, 0xc1, 0x35, 0x51, 0x7b, 0xf7, 0x3d, 0xea, 0x04, 0x09, 0xd4,
-	0x03, 0x90, 0x76, 0x1d, 0xca, 0xc2, 0x5c, 0x06, 0x79, 0x01, 0x46, 0x1d, 0xea, 0x7a, 0x26, 0x0b,
-	0xec, 0xa7, 0x12, 0xf6, 0x02, 0xa7, 0x07, 0x28, 0xed, 0x47, 0x04, 0xe5, 0xa8, 0x6b, 0xfc, 0x3e,
-	0x60, 0x97, 0x11, 0x87, 0x35, 0x99, 0x61, 0x51, 0x97, 0x11, 0xab, 0xd7, 0xe4, 0x39, 0x43, 0xf5,
-	0x9c, 0x5e, 0xe5, 0x9a, 0xed, 0x40, 0xb1, 0xee, 0xe2, 0x3a, 0x54, 0xa9, 0xdd, 0x89, 0x63, 0xb3,
-	0x1c, 0x5b, 0xa1, 0x76, 0x27, 0x8a, 0x8c, 0x96, 0x54, 0xee, 0x44, 0x25, 0xf5, 0x33, 0x82, 0xd3,
-	0xab, 0x8f, 0xa8, 0xd5, 0x33, 0x89, 0xf3, 0x56, 0x28, 0x5e, 0x1a, 0xa2, 0x38, 0x95, 0x46, 0xd1,
-	0x8d, 0x70, 0xbc, 0x03, 0xe3, 0xb1, 0xc0, 0xe2, 0x6b, 0x00, 0x7c, 0xa7, 0xb4, 0x1c, 0xf6, 0x5a,
-	0x0d, 0x7f, 0x3b, 0x51, 0x2a, 0xcb, 0xf9, 0xc7, 0xcf, 0xe6, 0x32, 0x7a, 0x04, 0xad, 0xfd, 0x80,
-	0x60, 0x92, 0x7b, 0xdb, 0x62, 0x0e, 0x25, 0x56, 0xe8, 0xf3, 0x3a, 0x94, 0xda, 0x7b, 0x9e, 0xbd,
-	0x1f, 0x73, 0x3a, 0x13, 0x50, 0x1b, 0xb8, 0x5c, 0xf1, 0x41, 0xd2, 0x6f, 0xd4, 0x22, 0x41, 0x2a,
-	0xfb, 0x4a, 0xa4, 0xb6, 0x60, 0x2a, 0x91, 0x84, 0xd7, 0x70, 0xd2, 0xdf, 0x11, 0xe0, 0x68, 0xfb,
-	0x93, 0x89, 0x3d, 0xe6, 0x4e, 0xa7, 0xe7, 0x3d, 0xfb, 0x0a, 0x79, 0xcf, 0x1d, 0x9b, 0xf7, 0xfc,
-	0x3c, 0x3a, 0x49, 0xde, 0xaf, 0xc0, 0x64, 0x8c, 0xbf, 0x8c, 0xc9, 0x7f, 0xa1, 0x1c, 0xe9, 0x3a,
-	0x41, 0x67, 0x2d, 0x0d, 0x5a, 0x87, 0xab, 0xfd, 0x84, 0x60, 0x62, 0x30, 0x2d, 0xde, 0x6e, 0x49,
-	0x9f, 0xe8, 0x68, 0x1f, 0xca, 0xd4, 0x48, 0x7e, 0xf2, 0x64, 0xc7, 0x8d, 0x0c, 0x0d, 0x43, 0xf5,
-	0x81, 0x4b, 0x9d, 0x2d, 0x46, 0x58, 0x70, 0x2a, 0xed, 0x37, 0x04, 0x13, 0x11, 0xa1, 0x74, 0x75,
-	0x2e, 0x98, 0xfc, 0x46, 0xd7, 0x6e, 0x3a, 0x84, 0x89, 0x4c, 0x23, 0x7d, 0x3c, 0x94, 0xea, 0x84,
-	0x51, 0xbf, 0x18, 0x6c, 0xcf, 0x1a, 0x74, 0x6e, 0xbf, 0x71, 0x16, 0x6d, 0xcf, 0x12, 0x45, 0xe5,
-	0x47, 0x8c, 0xf4, 0x8c, 0x66, 0xc2, 0x53, 0x8e, 0x7b, 0xaa, 0x92, 0x9e, 0xb1, 0x16, 0x73, 0xd6,
-	0x80, 0x49, 0xc7, 0x33, 0x69, 0x12, 0x9e, 0xe7, 0xf0, 0x09, 0x5f, 0x15, 0xc3, 0x6b, 0x5f, 0xc2,
-	0xa4, 0x4f, 0x7c, 0xed, 0x46, 0x9c, 0xfa, 0x0c, 0x8c, 0x7a, 0x2e, 0x75, 0x9a, 0x46, 0x47, 0x56,
-	0x67, 0xc1, 0x5f, 0xae, 0x75, 0xf0, 0x05, 0xc8, 0x77, 0x08, 0x23, 0x9c, 0x66, 0x69, 0xf1, 0x4c,
-	0x10, 0xe3, 0xa1, 0xc3, 0xeb, 0x1c, 0xa6, 0xdd, 0x02, 0xec, 0xab, 0xdc, 0xb8, 0xf7, 0x4b, 0x30,
-	0xe2, 0xfa, 0x02, 0x79, 0x99, 0xce, 0x46, 0xbd, 0x24, 0x98, 0xe8, 0x02, 0xa9, 0xfd, 0x8a, 0x40,
-	0x5d, 0xa7, 0xcc, 0x31, 0xda, 0xee, 0xcd, 0xae, 0x13, 0x4f, 0xe9, 0x1b, 0x2e, 0xad, 0x2b, 0x50,
-	0x0e, 0x6a, 0xa6, 0xe9, 0x52, 0x76, 0x74, 0xc7, 0x2c, 0x05, 0xd0, 0x2d, 0xca, 0xb4, 0x3b, 0x30,
-	0x77, 0x28, 0x67, 0x19, 0x8a, 0x3a, 0x14, 0x2c, 0x0e, 0x91, 0xb1, 0xa8, 0x0e, 0x1a, 0x8b, 0x30,
-	0xd5, 0xa5, 0x5e, 0xab, 0xc1, 0xb4, 0x74, 0xb6, 0x4e, 0x19, 0xf1, 0xa3, 0x1b, 0x54, 0xdf, 0x26,
-	0xcc, 0x0c, 0x69, 0xa4, 0xfb, 0x0f, 0x60, 0xcc, 0x92, 0x32, 0xb9, 0x41, 0x2d, 0xb9, 0x41, 0x68,
-	0x13, 0x22, 0xb5, 0xbf, 0x11, 0x9c, 0x4a, 0x74, 0x5b, 0x3f, 0x5e, 0x3b, 0x4e, 0xd7, 0x6a, 0x06,
-	0x6f, 0xd9, 0x41, 0x69, 0x54, 0x7c, 0xf9, 0x9a, 0x14, 0xaf, 0x75, 0xa2, 0xb5, 0x93, 0x8d, 0xd5,
-	0xce, 0x0e, 0x14, 0xf8, 0x3d, 0x0a, 0x86, 0xce, 0xe4, 0x80, 0x0a, 0x0f, 0xce, 0x3d, 0x62, 0x38,
-	0xcb, 0x57, 0xfd, 0x1e, 0xfa, 0xc7, 0xb3, 0xb9, 0x4b, 0x27, 0x79, 0xed, 0x0a, 0xbb, 0xa5, 0x0e,
-	0xe9, 0x31, 0xea, 0xe8, 0xd2, 0x3b, 0x7e, 0x0f, 0x0a, 0x62, 0x28, 0xd4, 0xf2, 0x7c, 0x9f, 0xf1,
-	0x20, 0x55, 0xd1, 0xb9, 0x21, 0x21, 0xda, 0x77, 0x08, 0x46, 0xc4, 0x09, 0xdf, 0x54, 0xfd, 0x28,
-	0x30, 0x46, 0xed, 0x76, 0xb7, 0x63, 0xd8, 0xbb, 0xfc, 0xda, 0x8e, 0xe8, 0xe1, 0x1a, 0x63, 0x79,
-	0x9d, 0xfc, 0xfb, 0x59, 0x96, 0x77, 0x66, 0x09, 0xc6, 0x63, 0xb5, 0xf2, 0x2f, 0x1e, 0xea, 0x4d,
-	0x28, 0x47, 0x35, 0xf8, 0x1c, 0xe4, 0xd9, 0x41, 0x4f, 0xf4, 0x9f, 0xca, 0xe2, 0x44, 0x60, 0xcd,
-	0xd5, 0xdb, 0x07, 0x3d, 0xaa, 0x73, 0xb5, 0xcf, 0x86, 0x0f, 0x24, 0x91, 0x36, 0xfe, 0x7b, 0xf0,
-	0xa2, 0xcb, 0x71, 0xa1, 0x58, 0x68, 0xdf, 0x20, 0xa8, 0x0c, 0x2a, 0xe4, 0xa6, 0x61, 0xd2, 0xd7,
-	0x51, 0x20, 0x0a, 0x8c, 0xed, 0x18, 0x26, 0xe5, 0x1c, 0xc4, 0x76, 0xe1, 0x3a, 0x2d, 0x52, 0xef,
-	0x7e, 0x02, 0xc5, 0xf0, 0x08, 0xb8, 0x08, 0x23, 0xab, 0xf7, 0x1f, 0x2c, 0xdd, 0xad, 0x66, 0xf0,
-	0x38, 0x14, 0x37, 0x36, 0xb7, 0x9b, 0x62, 0x89, 0xf0, 0x29, 0x28, 0xe9, 0xab, 0xb7, 0x56, 0x3f,
-	0x6f, 0xae, 0x2f, 0x6d, 0xaf, 0xdc, 0xae, 0x66, 0x31, 0x86, 0x8a, 0x10, 0x6c, 0x6c, 0x4a, 0x59,
-	0x6e, 0xf1, 0xdb, 0x51, 0x18, 0x0b, 0x38, 0xe2, 0xab, 0x90, 0xbf, 0xe7, 0xb9, 0x7b, 0x78, 0x7a,
-	0x50, 0xa1, 0x9f, 0x39, 0x06, 0xa3, 0xf2, 0xc6, 0x29, 0x33, 0x43, 0x72, 0x71, 0xdf, 0xb4, 0x0c,
-	0xbe, 0x01, 0xa5, 0xc8, 0xd3, 0x06, 0xa7, 0x3e, 0x6b, 0x95, 0xb3, 0x31, 0x69, 0xfc, 0x15, 0xa4,
-	0x65, 0x2e, 0x22, 0xbc, 0x09, 0x15, 0xae, 0x0a, 0x5e, 0x24, 0x2e, 0xfe, 0x4f, 0x60, 0x92, 0xf6,
-	0x52, 0x54, 0x66, 0x0f, 0xd1, 0x86, 0xb4, 0x6e, 0xc7, 0x3f, 0xb8, 0x94, 0xb4, 0x6f, 0xb3, 0x24,
-	0xb9, 0x94, 0xc1, 0xaf, 0x65, 0xf0, 0x2a, 0xc0, 0x60, 0x6c, 0xe2, 0x33, 0x31, 0x70, 0x74, 0xd4,
-	0x2b, 0x4a, 0x9a, 0x2a, 0x74, 0xb3, 0x0c, 0xc5, 0x70, 0x68, 0xe0, 0x5a, 0xca, 0x1c, 0x11, 0x4e,
-	0x0e, 0x9f, 0x30, 0x5a, 0x06, 0xdf, 0x84, 0xf2, 0x92, 0x69, 0x9e, 0xc4, 0x8d, 0x12, 0xd5, 0xb8,
-	0x49, 0x3f, 0x66, 0xd8, 0x40, 0x93, 0x7d, 0x1a, 0x9f, 0x0f, 0xef, 0xca, 0x91, 0xc3, 0x47, 0xf9,
-	0xff, 0xb1, 0xb8, 0x70, 0xb7, 0x6d, 0x38, 0x95, 0x68, 0xd7, 0x58, 0x4d, 0x58, 0x27, 0x3a, 0xbc,
-	0x32, 0x77, 0xa8, 0x3e, 0xf4, 0xda, 0x92, 0x0f, 0xb5, 0xf8, 0xb7, 0x39, 0xd6, 0x86, 0x93, 0x90,
-	0xfc, 0x47, 0x80, 0xf2, 0xbf, 0x23, 0x31, 0x91, 0xaa, 0xdc, 0x87, 0xe9, 0xf4, 0x6f, 0x5f, 0x7c,
-	0x2e, 0xa5, 0x66, 0x86, 0xbf, 0xc7, 0x95, 0xf3, 0xc7, 0xc1, 0x06, 0x9b, 0x2d, 0x7f, 0xf4, 0xe4,
-	0xb9, 0x9a, 0x79, 0xfa, 0x5c, 0xcd, 0xbc, 0x7c, 0xae, 0xa2, 0xaf, 0xfb, 0x2a, 0xfa, 0xa5, 0xaf,
-	0xa2, 0xc7, 0x7d, 0x15, 0x3d, 0xe9, 0xab, 0xe8, 0xcf, 0xbe, 0x8a, 0xfe, 0xea, 0xab, 0x99, 0x97,
-	0x7d, 0x15, 0x7d, 0xff, 0x42, 0xcd, 0x3c, 0x79, 0xa1, 0x66, 0x9e, 0xbe, 0x50, 0x33, 0x5f, 0x14,
-	0xda, 0xa6, 0x41, 0x6d, 0xd6, 0x2a, 0xf0, 0xff, 0x80, 0x5c, 0xfe, 0x27, 0x00, 0x00, 0xff, 0xff,
-	0x5c, 0xeb, 0x47, 0xa9, 0x7c, 0x11, 0x00, 0x00,
+	// 1508 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x58, 0x4b, 0x6f, 0x14, 0xc7,
+	0x16, 0x9e, 0x9a, 0x19, 0x0f, 0x9e, 0x33, 0x63, 0x33, 0xae, 0xf1, 0x63, 0x68, 0x70, 0xdb, 0xb7,
+	0xaf, 0xe0, 0xce, 0x4d, 0x82, 0x6d, 0x4c, 0x22, 0x01, 0x22, 0x22, 0xb6, 0x31, 0xe0, 0x80, 0x6d,
+	0x68, 0x1b, 0x88, 0xa2, 0x44, 0xa3, 0xf6, 0x4c, 0xd9, 0x6e, 0xb9, 0x1f, 0x43, 0x77, 0x35, 0xc2,
+	0xbb, 0x48, 0xf9, 0x01, 0x89, 0xa2, 0xac, 0x23, 0x65, 0x97, 0x75, 0x36, 0xd9, 0x65, 0xcd, 0x92,
+	0x55, 0x84, 0xb2, 0x40, 0xc1, 0x6c, 0x92, 0x1d, 0x3f, 0x21, 0xea, 0xaa, 0xea, 0xe7, 0xb4, 0x1f,
+	0x3c, 0x57, 0x9e, 0x3a, 0xe7, 0x3b, 0x5f, 0x9d, 0x3a, 0xaf, 0xaa, 0x36, 0x0c, 0xea, 0xd6, 0x16,
+	0x71, 0x29, 0x71, 0xa6, 0xba, 0x8e, 0x4d, 0x6d, 0x5c, 0x6a, 0xdb, 0x0e, 0x25, 0x8f, 0xa4, 0xb3,
+	0x5b, 0x3a, 0xdd, 0xf6, 0x36, 0xa6, 0xda, 0xb6, 0x39, 0xbd, 0x65, 0x6f, 0xd9, 0xd3, 0x4c, 0xbd,
+	0xe1, 0x6d, 0xb2, 0x15, 0x5b, 0xb0, 0x5f, 0xdc, 0x4c, 0x9a, 0x89, 0xc3, 0x1d, 0x6d, 0x53, 0xb3,
+	0xb4, 0x69, 0x53, 0x37, 0x75, 0x67, 0xba, 0xbb, 0xb3, 0xc5, 0x7f, 0x75, 0x37, 0xf8, 0x5f, 0x6e,
+	0xa1, 0xac, 0x80, 0x74, 0x4b, 0xdb, 0x20, 0xc6, 0x8a, 0x66, 0x12, 0x77, 0xce, 0xea, 0xdc, 0xd3,
+	0x0c, 0x8f, 0xb8, 0x2a, 0x79, 0xe0, 0x11, 0x97, 0xe2, 0x19, 0xe8, 0x37, 0x35, 0xda, 0xde, 0x26,
+	0x8e, 0xdb, 0x40, 0x93, 0x85, 0x66, 0x65, 0x76, 0x78, 0x8a, 0x7b, 0x36, 0xc5, 0xac, 0x96, 0xb9,
+	0x52, 0x0d, 0x51, 0xca, 0x0d, 0x38, 0x99, 0xc9, 0xe7, 0x76, 0x6d, 0xcb, 0x25, 0xf8, 0xff, 0xd0,
+	0xa7, 0x53, 0x62, 0x06, 0x6c, 0xf5, 0x04, 0x9b, 0xc0, 0x72, 0x84, 0x72, 0x15, 0x2a, 0x31, 0x29,
+	0x1e, 0x07, 0x30, 0xfc, 0x65, 0xcb, 0xd2, 0x4c, 0xd2, 0x40, 0x93, 0xa8, 0x59, 0x56, 0xcb, 0x46,
+	0xb0, 0x15, 0x1e, 0x85, 0xd2, 0x43, 0x06, 0x6c, 0xe4, 0x27, 0x0b, 0xcd, 0xb2, 0x2a, 0x56, 0x8a,
+	0x03, 0xe3, 0x31, 0x96, 0x05, 0xcd, 0xe9, 0xe8, 0x96, 0x66, 0xe8, 0x74, 0x37, 0x38, 0xe2, 0x04,
+	0x54, 0x22, 0x5e, 0xee, 0x57, 0x59, 0x85, 0x90, 0xd8, 0x4d, 0xc4, 0x20, 0x7f, 0xa4, 0x18, 0xdc,
+	0x05, 0x79, 0xbf, 0x3d, 0x45, 0x18, 0xce, 0x27, 0xc3, 0x30, 0xde, 0x1b, 0x86, 0x35, 0xe2, 0xe8,
+	0xc4, 0x5d, 0xb0, 0x3d, 0x8b, 0x06, 0x01, 0x79, 0x86, 0x60, 0x24, 0x13, 0x70, 0x58, 0x6c, 0x34,
+	0xc0, 0x5c, 0xcd, 0x62, 0xd2, 0x72, 0x99, 0xa5, 0x38, 0xcb, 0xf9, 0x03, 0xb7, 0xee, 0x91, 0x2e,
+	0x5a, 0xd4, 0xd9, 0x55, 0x6b, 0x46, 0x4a, 0x2c, 0x2d, 0xc0, 0x48, 0x26, 0x14, 0xd7, 0xa0, 0xb0,
+	0x43, 0x76, 0x85, 0x4f, 0xfe, 0x4f, 0x3c, 0x0c, 0x7d, 0xcc, 0x8f, 0x46, 0x7e, 0x12, 0x35, 0x8b,
+	0x2a, 0x5f, 0x5c, 0xca, 0x5f, 0x40, 0xca, 0xa7, 0x50, 0x51, 0x89, 0xd6, 0x09, 0x32, 0x33, 0x05,
+	0xc7, 0x1e, 0x78, 0xdc, 0xd7, 0x54, 0xed, 0xdd, 0xf1, 0x88, 0x13, 0x24, 0x50, 0x0d, 0x40, 0xca,
+	0x15, 0xa8, 0x72, 0x73, 0x11, 0xe4, 0x69, 0x38, 0xe6, 0x10, 0xd7, 0x33, 0x68, 0x60, 0x3f, 0x92,
+	0xb2, 0xe7, 0x38, 0x35, 0x40, 0x29, 0x7f, 0x20, 0xa8, 0xc6, 0xa9, 0xf1, 0x47, 0x80, 0x5d, 0xaa,
+	0x39, 0xb4, 0x45, 0x75, 0x93, 0xb8, 0x54, 0x33, 0xbb, 0x2d, 0x96, 0x33, 0xd4, 0x2c, 0xa8, 0x35,
+	0xa6, 0x59, 0x0f, 0x14, 0xcb, 0x2e, 0x6e, 0x42, 0x8d, 0x58, 0x9d, 0x24, 0x36, 0xcf, 0xb0, 0x83,
+	0xc4, 0xea, 0xc4, 0x91, 0xf1, 0x92, 0x2a, 0x1c, 0xa5, 0xa4, 0xf0, 0x65, 0x90, 0x5c, 0xea, 0x10,
+	0xcd, 0xd4, 0xad, 0xad, 0x16, 0xeb, 0xdc, 0xb6, 0x6d, 0xb4, 0x1e, 0x12, 0xc7, 0xd5, 0x6d, 0xab,
+	0x51, 0x9c, 0x44, 0xcd, 0x01, 0xb5, 0x11, 0x22, 0x6e, 0x0b, 0xc0, 0x3d, 0xae, 0x57, 0x7e, 0x46,
+	0x30, 0xbc, 0xf8, 0x88, 0x98, 0x5d, 0x43, 0x73, 0xde, 0xcb, 0x01, 0xcf, 0xf5, 0x1c, 0x70, 0x24,
+	0xeb, 0x80, 0x6e, 0xac, 0x69, 0x6e, 0xc2, 0x40, 0x22, 0x2d, 0xf8, 0x12, 0x00, 0xdb, 0x29, 0xab,
+	0x02, 0xba, 0x1b, 0x53, 0xfe, 0x76, 0xbc, 0xd0, 0xe6, 0x8b, 0x8f, 0x9f, 0x4d, 0xe4, 0xd4, 0x18,
+	0x5a, 0xf9, 0x01, 0x41, 0x9d, 0xb1, 0xad, 0xb1, 0x90, 0x84, 0x9c, 0x57, 0xa0, 0xd2, 0xde, 0xf6,
+	0xac, 0x9d, 0x04, 0xe9, 0x58, 0xe0, 0x5a, 0x44, 0xb9, 0xe0, 0x83, 0x04, 0x6f, 0xdc, 0x22, 0xe5,
+	0x54, 0xfe, 0x95, 0x9c, 0x5a, 0x83, 0x91, 0x54, 0x12, 0xde, 0xc2, 0x49, 0x7f, 0x47, 0x80, 0xe3,
+	0xc3, 0x53, 0x24, 0xf6, 0x90, 0x89, 0x90, 0x9d, 0xf7, 0xfc, 0x2b, 0xe4, 0xbd, 0x70, 0x68, 0xde,
+	0xfd, 0xa2, 0x3c, 0x42, 0xde, 0x2f, 0x40, 0x3d, 0xe1, 0xbf, 0x88, 0xc9, 0x7f, 0xa0, 0x1a, 0x9b,
+	0x59, 0xc1, 0x5c, 0xae, 0x44, 0x83, 0xc7, 0x55, 0x7e, 0x42, 0x30, 0x14, 0xdd, 0x35, 0xef, 0xb7,
+	0xa4, 0x8f, 0x74, 0xb4, 0x4f, 0x00, 0xc7, 0xfd, 0x13, 0x27, 0x3b, 0xec, 0xc2, 0x51, 0x30, 0xd4,
+	0xee, 0xba, 0xc4, 0x59, 0xa3, 0x1a, 0x0d, 0x4e, 0xa5, 0xfc, 0x86, 0x60, 0x28, 0x26, 0x14, 0x54,
+	0xa7, 0x83, 0x77, 0x83, 0x6e, 0x5b, 0x2d, 0x47, 0xa3, 0x3c, 0xd3, 0x48, 0x1d, 0x08, 0xa5, 0xaa,
+	0x46, 0x89, 0x5f, 0x0c, 0x96, 0x67, 0x46, 0x73, 0xdf, 0x1f, 0xbb, 0x65, 0xcb, 0x33, 0x79, 0x51,
+	0xf9, 0x11, 0xd3, 0xba, 0x7a, 0x2b, 0xc5, 0x54, 0x60, 0x4c, 0x35, 0xad, 0xab, 0x2f, 0x25, 0xc8,
+	0xa6, 0xa0, 0xee, 0x78, 0x06, 0x49, 0xc3, 0x8b, 0x0c, 0x3e, 0xe4, 0xab, 0x12, 0x78, 0xe5, 0x6b,
+	0xa8, 0xfb, 0x8e, 0x2f, 0x5d, 0x4d, 0xba, 0x3e, 0x06, 0xc7, 0x3c, 0x97, 0x38, 0x2d, 0xbd, 0x23,
+	0xaa, 0xb3, 0xe4, 0x2f, 0x97, 0x3a, 0xf8, 0x2c, 0x14, 0x3b, 0x1a, 0xd5, 0x98, 0x9b, 0x95, 0xd9,
+	0x13, 0x41, 0x8c, 0x7b, 0x0e, 0xaf, 0x32, 0x98, 0x72, 0x1d, 0xb0, 0xaf, 0x72, 0x93, 0xec, 0xe7,
+	0xa0, 0xcf, 0xf5, 0x05, 0xa2, 0x99, 0x4e, 0xc6, 0x59, 0x52, 0x9e, 0xa8, 0x1c, 0xa9, 0xfc, 0x8a,
+	0x40, 0x5e, 0x26, 0xd4, 0xd1, 0xdb, 0xee, 0x35, 0xdb, 0x49, 0xa6, 0xf4, 0x1d, 0x97, 0xd6, 0x05,
+	0xa8, 0x06, 0x35, 0xd3, 0x72, 0x09, 0x3d, 0x78, 0x62, 0x56, 0x02, 0xe8, 0x1a, 0xa1, 0xca, 0x4d,
+	0x98, 0xd8, 0xd7, 0x67, 0x11, 0x8a, 0x26, 0x94, 0x4c, 0x06, 0x11, 0xb1, 0xa8, 0x45, 0x83, 0x85,
+	0x9b, 0xaa, 0x42, 0xaf, 0x5c, 0x87, 0xfa, 0x5c, 0x9b, 0xea, 0x0f, 0xc5, 0xb0, 0x79, 0xfd, 0x37,
+	0xe0, 0x67, 0x30, 0x9c, 0x24, 0x7a, 0x65, 0x57, 0x1a, 0x30, 0x2a, 0xce, 0xb5, 0x4c, 0xa8, 0xe6,
+	0x27, 0x3a, 0x68, 0x84, 0x55, 0x18, 0xeb, 0xd1, 0x08, 0xfa, 0x8f, 0xa1, 0xdf, 0x14, 0x32, 0xb1,
+	0x41, 0x23, 0xbd, 0x41, 0x68, 0x13, 0x22, 0x95, 0x7f, 0x10, 0x1c, 0x4f, 0x0d, 0x7e, 0x3f, 0x75,
+	0x9b, 0x8e, 0x6d, 0xb6, 0x82, 0x47, 0x79, 0x54, 0xa5, 0x83, 0xbe, 0x7c, 0x49, 0x88, 0x97, 0x3a,
+	0xf1, 0x32, 0xce, 0x27, 0xca, 0x78, 0x13, 0x4a, 0xac, 0xa5, 0x83, 0xfb, 0xaf, 0x1e, 0xb9, 0xc2,
+	0xa2, 0x76, 0x5b, 0xd3, 0x9d, 0xf9, 0x8b, 0xfe, 0x38, 0xff, 0xf3, 0xd9, 0xc4, 0xb9, 0xa3, 0x3c,
+	0xdb, 0xb9, 0xdd, 0x5c, 0x47, 0xeb, 0x52, 0xe2, 0xa8, 0x82, 0x1d, 0x7f, 0x08, 0x25, 0x7e, 0x3f,
+	0x35, 0x8a, 0x6c, 0x9f, 0x81, 0x20, 0x37, 0xf1, 0x2b, 0x4c, 0x40, 0x94, 0xef, 0x10, 0xf4, 0xf1,
+	0x13, 0xbe, 0xab, 0x52, 0x96, 0xa0, 0x9f, 0x58, 0x6d, 0xbb, 0xa3, 0x5b, 0x5b, 0x6c, 0x82, 0xf4,
+	0xa9, 0xe1, 0x1a, 0x63, 0xd1, 0xd9, 0xfe, 0xa8, 0xa8, 0x8a, 0xf6, 0x9d, 0x83, 0x81, 0x44, 0xd9,
+	0xbe, 0x46, 0xb5, 0xb5, 0xa0, 0x1a, 0xd7, 0xe0, 0xd3, 0x50, 0xa4, 0xbb, 0x5d, 0x3e, 0x0a, 0x07,
+	0x67, 0x87, 0x02, 0x6b, 0xa6, 0x5e, 0xdf, 0xed, 0x12, 0x95, 0xa9, 0x7d, 0x6f, 0xd8, 0xdd, 0xc8,
+	0xd3, 0xc6, 0x7e, 0x47, 0x4f, 0xd3, 0x02, 0x13, 0xf2, 0x85, 0xf2, 0x2d, 0x82, 0xc1, 0xa8, 0x42,
+	0xae, 0xe9, 0x06, 0x79, 0x1b, 0x05, 0x22, 0x41, 0xff, 0xa6, 0x6e, 0x10, 0xe6, 0x03, 0xdf, 0x2e,
+	0x5c, 0x67, 0x45, 0xea, 0x83, 0xcf, 0xa1, 0x1c, 0x1e, 0x01, 0x97, 0xa1, 0x6f, 0xf1, 0xce, 0xdd,
+	0xb9, 0x5b, 0xb5, 0x1c, 0x1e, 0x80, 0xf2, 0xca, 0xea, 0x7a, 0x8b, 0x2f, 0x11, 0x3e, 0x0e, 0x15,
+	0x75, 0xf1, 0xfa, 0xe2, 0x17, 0xad, 0xe5, 0xb9, 0xf5, 0x85, 0x1b, 0xb5, 0x3c, 0xc6, 0x30, 0xc8,
+	0x05, 0x2b, 0xab, 0x42, 0x56, 0x98, 0xfd, 0xb1, 0x0c, 0xfd, 0x81, 0x8f, 0xf8, 0x22, 0x14, 0x6f,
+	0x7b, 0xee, 0x36, 0x1e, 0x8d, 0x2a, 0xf4, 0xbe, 0xa3, 0x53, 0x22, 0x3a, 0x4e, 0x1a, 0xeb, 0x91,
+	0xf3, 0x7e, 0x53, 0x72, 0xf8, 0x2a, 0x54, 0x62, 0xaf, 0x2c, 0x9c, 0xf9, 0x3e, 0x97, 0x4e, 0x26,
+	0xa4, 0xc9, 0x07, 0x99, 0x92, 0x9b, 0x41, 0x78, 0x15, 0x06, 0x99, 0x2a, 0x78, 0x1c, 0xb9, 0xf8,
+	0x54, 0x60, 0x92, 0xf5, 0x68, 0x95, 0xc6, 0xf7, 0xd1, 0x86, 0x6e, 0xdd, 0x48, 0x7e, 0x39, 0x4a,
+	0x59, 0x1f, 0x99, 0x69, 0xe7, 0x32, 0xde, 0x20, 0x4a, 0x0e, 0x2f, 0x02, 0x44, 0x37, 0x38, 0x3e,
+	0x91, 0x00, 0xc7, 0x5f, 0x1d, 0x92, 0x94, 0xa5, 0x0a, 0x69, 0xe6, 0xa1, 0x1c, 0xde, 0x5f, 0xb8,
+	0x91, 0x71, 0xa5, 0x71, 0x92, 0xfd, 0x2f, 0x3b, 0x25, 0x87, 0xaf, 0x41, 0x75, 0xce, 0x30, 0x8e,
+	0x42, 0x23, 0xc5, 0x35, 0x6e, 0x9a, 0xc7, 0x80, 0xb1, 0x7d, 0xae, 0x0c, 0x7c, 0x26, 0xec, 0x95,
+	0x03, 0xef, 0x41, 0xe9, 0x7f, 0x87, 0xe2, 0xc2, 0xdd, 0xd6, 0xe1, 0x78, 0x6a, 0x5c, 0x63, 0x39,
+	0x65, 0x9d, 0x9a, 0xf0, 0xd2, 0xc4, 0xbe, 0xfa, 0x90, 0x75, 0x03, 0xea, 0x51, 0x9c, 0xc3, 0x7f,
+	0x32, 0x60, 0xa5, 0x37, 0x09, 0xe9, 0xff, 0x68, 0x48, 0xff, 0x3d, 0x10, 0x13, 0xab, 0xca, 0x1d,
+	0x18, 0xcd, 0xfe, 0x88, 0xc7, 0xa7, 0x33, 0x6a, 0xa6, 0xf7, 0x1f, 0x0b, 0xd2, 0x99, 0xc3, 0x60,
+	0xb1, 0xcd, 0xee, 0xc3, 0x70, 0xb2, 0x05, 0x44, 0x47, 0xbd, 0x59, 0x23, 0xcc, 0x20, 0xfc, 0x15,
+	0x8c, 0xa4, 0xc2, 0x28, 0x98, 0xdf, 0x3c, 0x0b, 0x33, 0x08, 0x2f, 0x43, 0x35, 0x7e, 0xd1, 0xe3,
+	0xb0, 0x9b, 0x32, 0xde, 0x11, 0xd2, 0xa9, 0x6c, 0x65, 0x44, 0x37, 0x7f, 0xf9, 0xc9, 0x73, 0x39,
+	0xf7, 0xf4, 0xb9, 0x9c, 0x7b, 0xf9, 0x5c, 0x46, 0xdf, 0xec, 0xc9, 0xe8, 0x97, 0x3d, 0x19, 0x3d,
+	0xde, 0x93, 0xd1, 0x93, 0x3d, 0x19, 0xfd, 0xb5, 0x27, 0xa3, 0xbf, 0xf7, 0xe4, 0xdc, 0xcb, 0x3d,
+	0x19, 0x7d, 0xff, 0x42, 0xce, 0x3d, 0x79, 0x21, 0xe7, 0x9e, 0xbe, 0x90, 0x73, 0x5f, 0x96, 0xda,
+	0x86, 0x4e, 0x2c, 0xba, 0x51, 0x62, 0x9f, 0xc5, 0xe7, 0xff, 0x1d, 0x00, 0x65, 0xfc, 0xc7, 0x28,
+	0x4b, 0x13, 0x00, 0x00,
 }
 
 func (x MatchType) String() string {
@@ -1988,6 +2090,9 @@ func (this *QueryRequest) Equal(that interface{}) bool {
 			return false
 		}
 	}
+	if this.StreamingProtocolVersion != that1.StreamingProtocolVersion {
+		return false
+	}
 	return true
 }
 func (this *ExemplarQueryRequest) Equal(that interface{}) bool {
@@ -2415,6 +2520,64 @@ func (this *MetricsForLabelMatchersResponse) Equal(that interface{}) bool {
 	}
 	return true
 }
+func (this *ActiveSeriesRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*ActiveSeriesRequest)
+	if !ok {
+		that2, ok := that.(ActiveSeriesRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.Matchers) != len(that1.Matchers) {
+		return false
+	}
+	for i := range this.Matchers {
+		if !this.Matchers[i].Equal(that1.Matchers[i]) {
+			return false
+		}
+	}
+	return true
+}
+func (this *ActiveSeriesResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*ActiveSeriesResponse)
+	if !ok {
+		that2, ok := that.(ActiveSeriesResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.Metric) != len(that1.Metric) {
+		return false
+	}
+	for i := range this.Metric {
+		if !this.Metric[i].Equal(that1.Metric[i]) {
+			return false
+		}
+	}
+	return true
+}
 func (this *MetricsMetadataRequest) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
@@ -2744,13 +2907,14 @@ func (this *QueryRequest) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 7)
+	s := make([]string, 0, 8)
 	s = append(s, "&client.QueryRequest{")
 	s = append(s, "StartTimestampMs: "+fmt.Sprintf("%#v", this.StartTimestampMs)+",\n")
 	s = append(s, "EndTimestampMs: "+fmt.Sprintf("%#v", this.EndTimestampMs)+",\n")
 	if this.Matchers != nil {
 		s = append(s, "Matchers: "+fmt.Sprintf("%#v", this.Matchers)+",\n")
 	}
+	s = append(s, "StreamingProtocolVersion: "+fmt.Sprintf("%#v", this.StreamingProtocolVersion)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -2945,6 +3109,30 @@ func (this *MetricsForLabelMatchersResponse) GoString() string {
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
+func (this *ActiveSeriesRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&client.ActiveSeriesRequest{")
+	if this.Matchers != nil {
+		s = append(s, "Matchers: "+fmt.Sprintf("%#v", this.Matchers)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *ActiveSeriesResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&client.ActiveSeriesResponse{")
+	if this.Metric != nil {
+		s = append(s, "Metric: "+fmt.Sprintf("%#v", this.Metric)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
 func (this *MetricsMetadataRequest) GoString() string {
 	if this == nil {
 		return "nil"
@@ -3072,6 +3260,9 @@ type IngesterClient interface {
 	// that match the matchers.
 	// The listing order of the labels is not guaranteed.
 	LabelValuesCardinality(ctx context.Context, in *LabelValuesCardinalityRequest, opts ...grpc.CallOption) (Ingester_LabelValuesCardinalityClient, error)
+	QueryExemplarsStream(ctx context.Context, in *ExemplarQueryRequest, opts ...grpc.CallOption) (Ingester_QueryExemplarsStreamClient, error)
+	MetricsMetadataStream(ctx context.Context, in *MetricsMetadataRequest, opts ...grpc.CallOption) (Ingester_MetricsMetadataStreamClient, error)
+	ActiveSeries(ctx context.Context, in *ActiveSeriesRequest, opts ...grpc.CallOption) (Ingester_ActiveSeriesClient, error)
 }
 
 type ingesterClient struct {
@@ -3250,6 +3441,102 @@ func (x *ingesterLabelValuesCardinalityClient) Recv() (*LabelValuesCardinalityRe
 	return m, nil
 }
 
+func (c *ingesterClient) QueryExemplarsStream(ctx context.Context, in *ExemplarQueryRequest, opts ...grpc.CallOption) (Ingester_QueryExemplarsStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Ingester_serviceDesc.Streams[3], "/cortex.Ingester/QueryExemplarsStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &ingesterQueryExemplarsStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Ingester_QueryExemplarsStreamClient interface {
+	Recv() (*ExemplarQueryResponse, error)
+	grpc.ClientStream
+}
+
+type ingesterQueryExemplarsStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *ingesterQueryExemplarsStreamClient) Recv() (*ExemplarQueryResponse, error) {
+	m := new(ExemplarQueryResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *ingesterClient) MetricsMetadataStream(ctx context.Context, in *MetricsMetadataRequest, opts ...grpc.CallOption) (Ingester_MetricsMetadataStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Ingester_serviceDesc.Streams[4], "/cortex.Ingester/MetricsMetadataStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &ingesterMetricsMetadataStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Ingester_MetricsMetadataStreamClient interface {
+	Recv() (*MetricsMetadataResponse, error)
+	grpc.ClientStream
+}
+
+type ingesterMetricsMetadataStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *ingesterMetricsMetadataStreamClient) Recv() (*MetricsMetadataResponse, error) {
+	m := new(MetricsMetadataResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *ingesterClient) ActiveSeries(ctx context.Context, in *ActiveSeriesRequest, opts ...grpc.CallOption) (Ingester_ActiveSeriesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Ingester_serviceDesc.Streams[5], "/cortex.Ingester/ActiveSeries", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &ingesterActiveSeriesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Ingester_ActiveSeriesClient interface {
+	Recv() (*ActiveSeriesResponse, error)
+	grpc.ClientStream
+}
+
+type ingesterActiveSeriesClient struct {
+	grpc.ClientStream
+}
+
+func (x *ingesterActiveSeriesClient) Recv() (*ActiveSeriesResponse, error) {
+	m := new(ActiveSeriesResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // IngesterServer is the server API for Ingester service.
 type IngesterServer interface {
 	Push(context.Context, *mimirpb.WriteRequest) (*mimirpb.WriteResponse, error)
@@ -3268,6 +3555,9 @@ type IngesterServer interface {
 	// that match the matchers.
 	// The listing order of the labels is not guaranteed.
 	LabelValuesCardinality(*LabelValuesCardinalityRequest, Ingester_LabelValuesCardinalityServer) error
+	QueryExemplarsStream(*ExemplarQueryRequest, Ingester_QueryExemplarsStreamServer) error
+	MetricsMetadataStream(*MetricsMetadataRequest, Ingester_MetricsMetadataStreamServer) error
+	ActiveSeries(*ActiveSeriesRequest, Ingester_ActiveSeriesServer) error
 }
 
 // UnimplementedIngesterServer can be embedded to have forward compatible implementations.
@@ -3307,6 +3597,15 @@ func (*UnimplementedIngesterServer) LabelNamesAndValues(req *LabelNamesAndValues
 func (*UnimplementedIngesterServer) LabelValuesCardinality(req *LabelValuesCardinalityRequest, srv Ingester_LabelValuesCardinalityServer) error {
 	return status.Errorf(codes.Unimplemented, "method LabelValuesCardinality not implemented")
 }
+func (*UnimplementedIngesterServer) QueryExemplarsStream(req *ExemplarQueryRequest, srv Ingester_QueryExemplarsStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method QueryExemplarsStream not implemented")
+}
+func (*UnimplementedIngesterServer) MetricsMetadataStream(req *MetricsMetadataRequest, srv Ingester_MetricsMetadataStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method MetricsMetadataStream not implemented")
+}
+func (*UnimplementedIngesterServer) ActiveSeries(req *ActiveSeriesRequest, srv Ingester_ActiveSeriesServer) error {
+	return status.Errorf(codes.Unimplemented, "method ActiveSeries not implemented")
+}
 
 func RegisterIngesterServer(s *grpc.Server, srv IngesterServer) {
 	s.RegisterService(&_Ingester_serviceDesc, srv)
@@ -3519,6 +3818,69 @@ func (x *ingesterLabelValuesCardinalityServer) Send(m *LabelValuesCardinalityRes
 	return x.ServerStream.SendMsg(m)
 }
 
+func _Ingester_QueryExemplarsStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExemplarQueryRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(IngesterServer).QueryExemplarsStream(m, &ingesterQueryExemplarsStreamServer{stream})
+}
+
+type Ingester_QueryExemplarsStreamServer interface {
+	Send(*ExemplarQueryResponse) error
+	grpc.ServerStream
+}
+
+type ingesterQueryExemplarsStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *ingesterQueryExemplarsStreamServer) Send(m *ExemplarQueryResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Ingester_MetricsMetadataStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(MetricsMetadataRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(IngesterServer).MetricsMetadataStream(m, &ingesterMetricsMetadataStreamServer{stream})
+}
+
+type Ingester_MetricsMetadataStreamServer interface {
+	Send(*MetricsMetadataResponse) error
+	grpc.ServerStream
+}
+
+type ingesterMetricsMetadataStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *ingesterMetricsMetadataStreamServer) Send(m *MetricsMetadataResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Ingester_ActiveSeries_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ActiveSeriesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(IngesterServer).ActiveSeries(m, &ingesterActiveSeriesServer{stream})
+}
+
+type Ingester_ActiveSeriesServer interface {
+	Send(*ActiveSeriesResponse) error
+	grpc.ServerStream
+}
+
+type ingesterActiveSeriesServer struct {
+	grpc.ServerStream
+}
+
+func (x *ingesterActiveSeriesServer) Send(m *ActiveSeriesResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 var _Ingester_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "cortex.Ingester",
 	HandlerType: (*IngesterServer)(nil),
@@ -3572,6 +3934,21 @@ var _Ingester_serviceDesc = grpc.ServiceDesc{
 			Handler:       _Ingester_LabelValuesCardinality_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "QueryExemplarsStream",
+			Handler:       _Ingester_QueryExemplarsStream_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "MetricsMetadataStream",
+			Handler:       _Ingester_MetricsMetadataStream_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ActiveSeries",
+			Handler:       _Ingester_ActiveSeries_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "ingester.proto",
 }
@@ -3913,6 +4290,11 @@ func (m *QueryRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.StreamingProtocolVersion != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.StreamingProtocolVersion))
+		i--
+		dAtA[i] = 0x20
+	}
 	if len(m.Matchers) > 0 {
 		for iNdEx := len(m.Matchers) - 1; iNdEx >= 0; iNdEx-- {
 			{
@@ -4444,7 +4826,91 @@ func (m *MetricsForLabelMatchersRequest) MarshalToSizedBuffer(dAtA []byte) (int,
 	if len(m.MatchersSet) > 0 {
 		for iNdEx := len(m.MatchersSet) - 1; iNdEx >= 0; iNdEx-- {
 			{
-				size, err := m.MatchersSet[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				size, err := m.MatchersSet[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x1a
+		}
+	}
+	if m.EndTimestampMs != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.EndTimestampMs))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.StartTimestampMs != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.StartTimestampMs))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *MetricsForLabelMatchersResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *MetricsForLabelMatchersResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *MetricsForLabelMatchersResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Metric) > 0 {
+		for iNdEx := len(m.Metric) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Metric[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ActiveSeriesRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ActiveSeriesRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ActiveSeriesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Matchers) > 0 {
+		for iNdEx := len(m.Matchers) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Matchers[iNdEx].MarshalToSizedBuffer(dAtA[:i])
 				if err != nil {
 					return 0, err
 				}
@@ -4452,23 +4918,13 @@ func (m *MetricsForLabelMatchersRequest) MarshalToSizedBuffer(dAtA []byte) (int,
 				i = encodeVarintIngester(dAtA, i, uint64(size))
 			}
 			i--
-			dAtA[i] = 0x1a
+			dAtA[i] = 0xa
 		}
 	}
-	if m.EndTimestampMs != 0 {
-		i = encodeVarintIngester(dAtA, i, uint64(m.EndTimestampMs))
-		i--
-		dAtA[i] = 0x10
-	}
-	if m.StartTimestampMs != 0 {
-		i = encodeVarintIngester(dAtA, i, uint64(m.StartTimestampMs))
-		i--
-		dAtA[i] = 0x8
-	}
 	return len(dAtA) - i, nil
 }
 
-func (m *MetricsForLabelMatchersResponse) Marshal() (dAtA []byte, err error) {
+func (m *ActiveSeriesResponse) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalToSizedBuffer(dAtA[:size])
@@ -4478,12 +4934,12 @@ func (m *MetricsForLabelMatchersResponse) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *MetricsForLabelMatchersResponse) MarshalTo(dAtA []byte) (int, error) {
+func (m *ActiveSeriesResponse) MarshalTo(dAtA []byte) (int, error) {
 	size := m.Size()
 	return m.MarshalToSizedBuffer(dAtA[:size])
 }
 
-func (m *MetricsForLabelMatchersResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+func (m *ActiveSeriesResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	i := len(dAtA)
 	_ = i
 	var l int
@@ -4970,6 +5426,9 @@ func (m *QueryRequest) Size() (n int) {
 			n += 1 + l + sovIngester(uint64(l))
 		}
 	}
+	if m.StreamingProtocolVersion != 0 {
+		n += 1 + sovIngester(uint64(m.StreamingProtocolVersion))
+	}
 	return n
 }
 
@@ -5215,6 +5674,36 @@ func (m *MetricsForLabelMatchersResponse) Size() (n int) {
 	return n
 }
 
+func (m *ActiveSeriesRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Matchers) > 0 {
+		for _, e := range m.Matchers {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *ActiveSeriesResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Metric) > 0 {
+		for _, e := range m.Metric {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	return n
+}
+
 func (m *MetricsMetadataRequest) Size() (n int) {
 	if m == nil {
 		return 0
@@ -5492,6 +5981,7 @@ func (this *QueryRequest) String() string {
 		`StartTimestampMs:` + fmt.Sprintf("%v", this.StartTimestampMs) + `,`,
 		`EndTimestampMs:` + fmt.Sprintf("%v", this.EndTimestampMs) + `,`,
 		`Matchers:` + repeatedStringForMatchers + `,`,
+		`StreamingProtocolVersion:` + fmt.Sprintf("%v", this.StreamingProtocolVersion) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -5689,6 +6179,36 @@ func (this *MetricsForLabelMatchersResponse) String() string {
 	}, "")
 	return s
 }
+func (this *ActiveSeriesRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForMatchers := "[]*LabelMatcher{"
+	for _, f := range this.Matchers {
+		repeatedStringForMatchers += strings.Replace(f.String(), "LabelMatcher", "LabelMatcher", 1) + ","
+	}
+	repeatedStringForMatchers += "}"
+	s := strings.Join([]string{`&ActiveSeriesRequest{`,
+		`Matchers:` + repeatedStringForMatchers + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *ActiveSeriesResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForMetric := "[]*Metric{"
+	for _, f := range this.Metric {
+		repeatedStringForMetric += strings.Replace(fmt.Sprintf("%v", f), "Metric", "mimirpb.Metric", 1) + ","
+	}
+	repeatedStringForMetric += "}"
+	s := strings.Join([]string{`&ActiveSeriesResponse{`,
+		`Metric:` + repeatedStringForMetric + `,`,
+		`}`,
+	}, "")
+	return s
+}
 func (this *MetricsMetadataRequest) String() string {
 	if this == nil {
 		return "nil"
@@ -6762,6 +7282,25 @@ func (m *QueryRequest) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StreamingProtocolVersion", wireType)
+			}
+			m.StreamingProtocolVersion = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.StreamingProtocolVersion |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipIngester(dAtA[iNdEx:])
@@ -8240,6 +8779,180 @@ func (m *MetricsForLabelMatchersResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *ActiveSeriesRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ActiveSeriesRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ActiveSeriesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Matchers", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Matchers = append(m.Matchers, &LabelMatcher{})
+			if err := m.Matchers[len(m.Matchers)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ActiveSeriesResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ActiveSeriesResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ActiveSeriesResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Metric", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Metric = append(m.Metric, &mimirpb.Metric{})
+			if err := m.Metric[len(m.Metric)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func (m *MetricsMetadataRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
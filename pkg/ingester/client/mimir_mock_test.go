@@ -76,3 +76,18 @@ func (m *IngesterServerMock) LabelValuesCardinality(req *LabelValuesCardinalityR
 	args := m.Called(req, srv)
 	return args.Error(0)
 }
+
+func (m *IngesterServerMock) QueryExemplarsStream(req *ExemplarQueryRequest, srv Ingester_QueryExemplarsStreamServer) error {
+	args := m.Called(req, srv)
+	return args.Error(0)
+}
+
+func (m *IngesterServerMock) MetricsMetadataStream(req *MetricsMetadataRequest, srv Ingester_MetricsMetadataStreamServer) error {
+	args := m.Called(req, srv)
+	return args.Error(0)
+}
+
+func (m *IngesterServerMock) ActiveSeries(req *ActiveSeriesRequest, srv Ingester_ActiveSeriesServer) error {
+	args := m.Called(req, srv)
+	return args.Error(0)
+}
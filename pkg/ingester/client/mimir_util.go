@@ -9,6 +9,19 @@ import (
 	context "context"
 )
 
+const (
+	// QueryStreamProtocolVersionUnversioned is the version of the clients which predate the versioning of the
+	// QueryStream protocol: the ingester streams either chunks or samples, depending on its configuration.
+	QueryStreamProtocolVersionUnversioned uint32 = 0
+
+	// QueryStreamProtocolVersionChunks is the version of the clients which can only receive chunks: the ingester
+	// always streams chunks, in batches of bounded size.
+	QueryStreamProtocolVersionChunks uint32 = 1
+
+	// QueryStreamProtocolVersion is the highest version of the QueryStream protocol supported by this build.
+	QueryStreamProtocolVersion = QueryStreamProtocolVersionChunks
+)
+
 // SendQueryStream wraps the stream's Send() checking if the context is done
 // before calling Send().
 func SendQueryStream(s Ingester_QueryStreamServer, m *QueryStreamResponse) error {
@@ -33,6 +46,30 @@ func SendLabelValuesCardinalityResponse(s Ingester_LabelValuesCardinalityServer,
 	})
 }
 
+// SendQueryExemplarsStreamResponse wraps the stream's Send() checking if the context is done
+// before calling Send().
+func SendQueryExemplarsStreamResponse(s Ingester_QueryExemplarsStreamServer, response *ExemplarQueryResponse) error {
+	return sendWithContextErrChecking(s.Context(), func() error {
+		return s.Send(response)
+	})
+}
+
+// SendMetricsMetadataStreamResponse wraps the stream's Send() checking if the context is done
+// before calling Send().
+func SendMetricsMetadataStreamResponse(s Ingester_MetricsMetadataStreamServer, response *MetricsMetadataResponse) error {
+	return sendWithContextErrChecking(s.Context(), func() error {
+		return s.Send(response)
+	})
+}
+
+// SendActiveSeriesResponse wraps the stream's Send() checking if the context is done
+// before calling Send().
+func SendActiveSeriesResponse(s Ingester_ActiveSeriesServer, response *ActiveSeriesResponse) error {
+	return sendWithContextErrChecking(s.Context(), func() error {
+		return s.Send(response)
+	})
+}
+
 func sendWithContextErrChecking(ctx context.Context, send func() error) error {
 	// If the context has been canceled or its deadline exceeded, we should return it
 	// instead of the cryptic error the Send() will return.
@@ -0,0 +1,242 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"context"
+	"flag"
+	"syscall"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/ring"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/mimir/pkg/util/globalerror"
+)
+
+var (
+	errInvalidDiskSpaceWatchdogThresholds = errors.New("the disk space watchdog thresholds must be between 0 and 1, and the compaction threshold must be greater than or equal to the reject new series threshold, which must be greater than or equal to the read-only threshold")
+
+	// We don't include values in the message to avoid leaking Mimir cluster configuration to users.
+	errDiskSpaceLowNewSeriesRejected = errors.New(globalerror.IngesterDiskSpaceLow.Message("cannot add series: ingester's free disk space is low"))
+	errDiskSpaceLowReadOnly          = errors.New(globalerror.IngesterDiskSpaceLowNoWrite.Message("cannot push: ingester is read-only because its free disk space is low"))
+)
+
+// diskSpaceStage is the protective stage the disk space watchdog is in. Each stage
+// includes the actions of the previous ones.
+type diskSpaceStage int
+
+const (
+	diskSpaceOK diskSpaceStage = iota
+	diskSpaceCompacting
+	diskSpaceRejectingNewSeries
+	diskSpaceReadOnly
+)
+
+func (s diskSpaceStage) String() string {
+	switch s {
+	case diskSpaceCompacting:
+		return "compacting"
+	case diskSpaceRejectingNewSeries:
+		return "rejecting-new-series"
+	case diskSpaceReadOnly:
+		return "read-only"
+	default:
+		return "ok"
+	}
+}
+
+// DiskSpaceWatchdogConfig configures the watchdog monitoring the free disk space of the ingester TSDB directory.
+type DiskSpaceWatchdogConfig struct {
+	CheckInterval            time.Duration `yaml:"check_interval" category:"experimental"`
+	CompactionThreshold      float64       `yaml:"compaction_threshold" category:"experimental"`
+	RejectNewSeriesThreshold float64       `yaml:"reject_new_series_threshold" category:"experimental"`
+	ReadOnlyThreshold        float64       `yaml:"read_only_threshold" category:"experimental"`
+}
+
+// RegisterFlags registers the flags.
+func (cfg *DiskSpaceWatchdogConfig) RegisterFlags(f *flag.FlagSet) {
+	f.DurationVar(&cfg.CheckInterval, "ingester.disk-space-watchdog.check-interval", 0, "How frequently the free disk space of the TSDB directory is checked. 0 to disable the disk space watchdog.")
+	f.Float64Var(&cfg.CompactionThreshold, "ingester.disk-space-watchdog.compaction-threshold", 0.15, "Ratio of free disk space below which the ingester compacts the TSDB heads and ships the blocks to the storage, without waiting for the next scheduled compaction.")
+	f.Float64Var(&cfg.RejectNewSeriesThreshold, "ingester.disk-space-watchdog.reject-new-series-threshold", 0.1, "Ratio of free disk space below which the ingester rejects the creation of new series. Samples for existing series are still accepted.")
+	f.Float64Var(&cfg.ReadOnlyThreshold, "ingester.disk-space-watchdog.read-only-threshold", 0.05, "Ratio of free disk space below which the ingester rejects all writes and leaves the ring to stop receiving them, while keeping serving queries. The ingester must be restarted to receive writes again.")
+}
+
+// Validate the config.
+func (cfg *DiskSpaceWatchdogConfig) Validate() error {
+	if cfg.CheckInterval <= 0 {
+		return nil
+	}
+
+	for _, threshold := range []float64{cfg.CompactionThreshold, cfg.RejectNewSeriesThreshold, cfg.ReadOnlyThreshold} {
+		if threshold < 0 || threshold > 1 {
+			return errInvalidDiskSpaceWatchdogThresholds
+		}
+	}
+	if cfg.CompactionThreshold < cfg.RejectNewSeriesThreshold || cfg.RejectNewSeriesThreshold < cfg.ReadOnlyThreshold {
+		return errInvalidDiskSpaceWatchdogThresholds
+	}
+
+	return nil
+}
+
+// stageFor returns the stage for the input ratio of free disk space.
+func (cfg *DiskSpaceWatchdogConfig) stageFor(freeRatio float64) diskSpaceStage {
+	switch {
+	case freeRatio < cfg.ReadOnlyThreshold:
+		return diskSpaceReadOnly
+	case freeRatio < cfg.RejectNewSeriesThreshold:
+		return diskSpaceRejectingNewSeries
+	case freeRatio < cfg.CompactionThreshold:
+		return diskSpaceCompacting
+	default:
+		return diskSpaceOK
+	}
+}
+
+// diskSpaceWatchdog tracks the protective stage of the ingester based on its free disk space.
+// The read-only stage is never left, because the ingester can't join the ring again without
+// being restarted.
+type diskSpaceWatchdog struct {
+	cfg    DiskSpaceWatchdogConfig
+	dir    string
+	logger log.Logger
+
+	// Returns the ratio of free disk space of the input directory. Configurable for testing.
+	freeRatioFn func(dir string) (float64, error)
+
+	stage atomic.Int64
+
+	freeRatio prometheus.Gauge
+	stageInfo *prometheus.GaugeVec
+}
+
+func newDiskSpaceWatchdog(cfg DiskSpaceWatchdogConfig, dir string, logger log.Logger, reg prometheus.Registerer) *diskSpaceWatchdog {
+	w := &diskSpaceWatchdog{
+		cfg:         cfg,
+		dir:         dir,
+		logger:      logger,
+		freeRatioFn: diskFreeRatio,
+
+		freeRatio: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_ingester_disk_space_free_ratio",
+			Help: "Ratio of free disk space of the ingester TSDB directory, as observed by the disk space watchdog.",
+		}),
+		stageInfo: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_ingester_disk_space_watchdog_stage",
+			Help: "The protective stage the ingester disk space watchdog is in. The value is 1 for the current stage and 0 for the others.",
+		}, []string{"stage"}),
+	}
+
+	w.setStage(diskSpaceOK)
+	return w
+}
+
+func (w *diskSpaceWatchdog) currentStage() diskSpaceStage {
+	if w == nil {
+		return diskSpaceOK
+	}
+	return diskSpaceStage(w.stage.Load())
+}
+
+func (w *diskSpaceWatchdog) setStage(stage diskSpaceStage) {
+	w.stage.Store(int64(stage))
+	for s := diskSpaceOK; s <= diskSpaceReadOnly; s++ {
+		if s == stage {
+			w.stageInfo.WithLabelValues(s.String()).Set(1)
+		} else {
+			w.stageInfo.WithLabelValues(s.String()).Set(0)
+		}
+	}
+}
+
+// check the free disk space and returns the previous and the new stage.
+func (w *diskSpaceWatchdog) check() (prev, curr diskSpaceStage, _ error) {
+	prev = w.currentStage()
+
+	ratio, err := w.freeRatioFn(w.dir)
+	if err != nil {
+		return prev, prev, err
+	}
+	w.freeRatio.Set(ratio)
+
+	curr = w.cfg.stageFor(ratio)
+	if prev == diskSpaceReadOnly {
+		curr = diskSpaceReadOnly
+	}
+
+	if curr != prev {
+		level.Warn(w.logger).Log("msg", "ingester disk space watchdog stage changed", "dir", w.dir, "free_ratio", ratio, "previous_stage", prev, "stage", curr)
+		w.setStage(curr)
+	}
+	return prev, curr, nil
+}
+
+// diskFreeRatio returns the ratio of disk space available to unprivileged users in the filesystem of dir.
+func diskFreeRatio(dir string) (float64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, errors.Wrapf(err, "failed to get the filesystem stats of %s", dir)
+	}
+	if stat.Blocks == 0 {
+		return 1, nil
+	}
+	return float64(stat.Bavail) / float64(stat.Blocks), nil
+}
+
+// checkDiskSpace runs the disk space watchdog and applies the actions of the stage it's in.
+func (i *Ingester) checkDiskSpace(ctx context.Context) error {
+	prev, curr, err := i.diskSpaceWatchdog.check()
+	if err != nil {
+		level.Warn(i.logger).Log("msg", "ingester disk space watchdog failed to check the free disk space", "err", err)
+		return nil
+	}
+
+	// Free up disk space as soon as possible once the free disk space gets low, by compacting the TSDB heads
+	// (which truncates the WAL) and shipping the blocks, instead of waiting for the next scheduled run.
+	if curr >= diskSpaceCompacting && prev < diskSpaceCompacting {
+		i.compactAndShipBlocksNow(ctx)
+	}
+
+	// Stop receiving writes. Writes are already rejected by Push, while leaving the ring
+	// makes the distributors send them to other ingesters.
+	if curr == diskSpaceReadOnly && prev < diskSpaceReadOnly && i.lifecycler != nil {
+		if err := i.lifecycler.ChangeState(ctx, ring.LEAVING); err != nil {
+			level.Error(i.logger).Log("msg", "ingester disk space watchdog failed to mark the ingester as read-only in the ring", "err", err)
+		}
+	}
+
+	return nil
+}
+
+// compactAndShipBlocksNow forces the compaction of the TSDB heads of all tenants and, if enabled, the shipping
+// of the blocks, and waits until done.
+func (i *Ingester) compactAndShipBlocksNow(ctx context.Context) {
+	level.Info(i.logger).Log("msg", "ingester disk space watchdog is compacting TSDB heads and shipping blocks")
+
+	triggers := []chan requestWithUsersAndCallback{i.forceCompactTrigger}
+	if i.cfg.BlocksStorageConfig.TSDB.IsBlocksShippingEnabled() {
+		triggers = append(triggers, i.shipTrigger)
+	}
+
+	for _, trigger := range triggers {
+		callback := make(chan struct{})
+
+		select {
+		case trigger <- requestWithUsersAndCallback{callback: callback}:
+		case <-ctx.Done():
+			return
+		}
+
+		select {
+		case <-callback:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,163 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/ring"
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/test"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+	"go.uber.org/atomic"
+)
+
+func TestDiskSpaceWatchdogConfig_Validate(t *testing.T) {
+	tests := map[string]struct {
+		cfg      DiskSpaceWatchdogConfig
+		expected error
+	}{
+		"disabled": {
+			cfg: DiskSpaceWatchdogConfig{CheckInterval: 0, CompactionThreshold: 2},
+		},
+		"valid thresholds": {
+			cfg: DiskSpaceWatchdogConfig{CheckInterval: time.Minute, CompactionThreshold: 0.15, RejectNewSeriesThreshold: 0.1, ReadOnlyThreshold: 0.05},
+		},
+		"equal thresholds": {
+			cfg: DiskSpaceWatchdogConfig{CheckInterval: time.Minute, CompactionThreshold: 0.1, RejectNewSeriesThreshold: 0.1, ReadOnlyThreshold: 0.1},
+		},
+		"threshold greater than 1": {
+			cfg:      DiskSpaceWatchdogConfig{CheckInterval: time.Minute, CompactionThreshold: 1.5, RejectNewSeriesThreshold: 0.1, ReadOnlyThreshold: 0.05},
+			expected: errInvalidDiskSpaceWatchdogThresholds,
+		},
+		"negative threshold": {
+			cfg:      DiskSpaceWatchdogConfig{CheckInterval: time.Minute, CompactionThreshold: 0.15, RejectNewSeriesThreshold: 0.1, ReadOnlyThreshold: -0.05},
+			expected: errInvalidDiskSpaceWatchdogThresholds,
+		},
+		"thresholds not in order": {
+			cfg:      DiskSpaceWatchdogConfig{CheckInterval: time.Minute, CompactionThreshold: 0.05, RejectNewSeriesThreshold: 0.1, ReadOnlyThreshold: 0.01},
+			expected: errInvalidDiskSpaceWatchdogThresholds,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			assert.Equal(t, testData.expected, testData.cfg.Validate())
+		})
+	}
+}
+
+func TestDiskSpaceWatchdog_Check(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	cfg := DiskSpaceWatchdogConfig{CheckInterval: time.Minute, CompactionThreshold: 0.15, RejectNewSeriesThreshold: 0.1, ReadOnlyThreshold: 0.05}
+	w := newDiskSpaceWatchdog(cfg, t.TempDir(), log.NewNopLogger(), reg)
+
+	freeRatio := atomic.NewFloat64(0.5)
+	w.freeRatioFn = func(string) (float64, error) { return freeRatio.Load(), nil }
+
+	for _, step := range []struct {
+		freeRatio            float64
+		expectedPrev, expect diskSpaceStage
+	}{
+		{freeRatio: 0.5, expectedPrev: diskSpaceOK, expect: diskSpaceOK},
+		{freeRatio: 0.12, expectedPrev: diskSpaceOK, expect: diskSpaceCompacting},
+		{freeRatio: 0.08, expectedPrev: diskSpaceCompacting, expect: diskSpaceRejectingNewSeries},
+		{freeRatio: 0.2, expectedPrev: diskSpaceRejectingNewSeries, expect: diskSpaceOK},
+		{freeRatio: 0.01, expectedPrev: diskSpaceOK, expect: diskSpaceReadOnly},
+		// The read-only stage is never left.
+		{freeRatio: 0.5, expectedPrev: diskSpaceReadOnly, expect: diskSpaceReadOnly},
+	} {
+		freeRatio.Store(step.freeRatio)
+
+		prev, curr, err := w.check()
+		require.NoError(t, err)
+		assert.Equal(t, step.expectedPrev, prev)
+		assert.Equal(t, step.expect, curr)
+		assert.Equal(t, step.expect, w.currentStage())
+	}
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_ingester_disk_space_free_ratio Ratio of free disk space of the ingester TSDB directory, as observed by the disk space watchdog.
+		# TYPE cortex_ingester_disk_space_free_ratio gauge
+		cortex_ingester_disk_space_free_ratio 0.5
+		# HELP cortex_ingester_disk_space_watchdog_stage The protective stage the ingester disk space watchdog is in. The value is 1 for the current stage and 0 for the others.
+		# TYPE cortex_ingester_disk_space_watchdog_stage gauge
+		cortex_ingester_disk_space_watchdog_stage{stage="compacting"} 0
+		cortex_ingester_disk_space_watchdog_stage{stage="ok"} 0
+		cortex_ingester_disk_space_watchdog_stage{stage="read-only"} 1
+		cortex_ingester_disk_space_watchdog_stage{stage="rejecting-new-series"} 0
+	`)))
+}
+
+func TestDiskFreeRatio(t *testing.T) {
+	ratio, err := diskFreeRatio(t.TempDir())
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, ratio, 0.0)
+	assert.LessOrEqual(t, ratio, 1.0)
+}
+
+func TestIngester_DiskSpaceWatchdog(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+	cfg.IngesterRing.JoinAfter = 0
+	cfg.DiskSpaceWatchdog = DiskSpaceWatchdogConfig{
+		// The watchdog is run manually by the test.
+		CheckInterval:            time.Hour,
+		CompactionThreshold:      0.15,
+		RejectNewSeriesThreshold: 0.1,
+		ReadOnlyThreshold:        0.05,
+	}
+
+	i, err := prepareIngesterWithBlocksStorage(t, cfg, nil)
+	require.NoError(t, err)
+
+	freeRatio := atomic.NewFloat64(0.5)
+	i.diskSpaceWatchdog.freeRatioFn = func(string) (float64, error) { return freeRatio.Load(), nil }
+
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(context.Background(), i))
+	})
+
+	// Wait until the ingester is healthy.
+	test.Poll(t, time.Second, 1, func() interface{} {
+		return i.lifecycler.HealthyInstancesCount()
+	})
+
+	ctx := user.InjectOrgID(context.Background(), userID)
+	now := time.Now()
+	push := func(metricName string) error {
+		req, _, _, _ := mockWriteRequest(t, labels.Labels{{Name: labels.MetricName, Value: metricName}}, 1, now.UnixMilli())
+		_, err := i.Push(ctx, req)
+		now = now.Add(time.Second)
+		return err
+	}
+
+	require.NoError(t, push("series_1"))
+
+	// When the free disk space gets low, the TSDB head is compacted.
+	freeRatio.Store(0.12)
+	require.NoError(t, i.checkDiskSpace(ctx))
+	assert.Equal(t, 1.0, testutil.ToFloat64(i.metrics.compactionsTriggered))
+	require.NoError(t, push("series_2"))
+
+	// When the free disk space gets lower, new series are rejected, while existing ones are accepted.
+	freeRatio.Store(0.08)
+	require.NoError(t, i.checkDiskSpace(ctx))
+	assert.Equal(t, wrapWithUser(errDiskSpaceLowNewSeriesRejected, userID), push("series_3"))
+	require.NoError(t, push("series_2"))
+
+	// When the free disk space gets very low, all writes are rejected and the ingester leaves the ring.
+	freeRatio.Store(0.01)
+	require.NoError(t, i.checkDiskSpace(ctx))
+	assert.Equal(t, errDiskSpaceLowReadOnly, push("series_2"))
+	assert.Equal(t, ring.LEAVING, i.lifecycler.GetState())
+}
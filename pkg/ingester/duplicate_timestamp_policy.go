@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"math"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+// coercesDuplicateTimestamps returns whether the samples with the same timestamp as an existing sample
+// and a different value are dropped instead of rejected, according to the input policy.
+func coercesDuplicateTimestamps(policy string) bool {
+	return policy == validation.DuplicateTimestampPolicyKeepFirst || policy == validation.DuplicateTimestampPolicyKeepLast
+}
+
+// dedupeSamplesKeepLast removes the samples followed by a sample with the same timestamp, and returns
+// the remaining samples along with the number of removed samples with a different value. The samples
+// of a series in a write request are sorted by timestamp, so the duplicates are adjacent. The input
+// slice is never modified, because it may be shared with the requests to other ingesters.
+func dedupeSamplesKeepLast(samples []mimirpb.Sample) ([]mimirpb.Sample, int) {
+	first := -1
+	for i := 1; i < len(samples); i++ {
+		if samples[i].TimestampMs == samples[i-1].TimestampMs {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		return samples, 0
+	}
+
+	out := make([]mimirpb.Sample, first, len(samples)-1)
+	copy(out, samples[:first])
+	coerced := 0
+
+	for _, s := range samples[first:] {
+		last := &out[len(out)-1]
+		if s.TimestampMs != last.TimestampMs {
+			out = append(out, s)
+			continue
+		}
+
+		if math.Float64bits(s.Value) != math.Float64bits(last.Value) {
+			coerced++
+		}
+		*last = s
+	}
+
+	return out, coerced
+}
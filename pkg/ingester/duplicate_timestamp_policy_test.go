@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+func TestDedupeSamplesKeepLast(t *testing.T) {
+	tests := map[string]struct {
+		input           []mimirpb.Sample
+		expected        []mimirpb.Sample
+		expectedCoerced int
+	}{
+		"no samples": {
+			input:    nil,
+			expected: nil,
+		},
+		"no duplicates": {
+			input:    []mimirpb.Sample{{TimestampMs: 1, Value: 1}, {TimestampMs: 2, Value: 2}},
+			expected: []mimirpb.Sample{{TimestampMs: 1, Value: 1}, {TimestampMs: 2, Value: 2}},
+		},
+		"duplicates with different values": {
+			input:           []mimirpb.Sample{{TimestampMs: 1, Value: 1}, {TimestampMs: 2, Value: 2}, {TimestampMs: 2, Value: 3}, {TimestampMs: 2, Value: 4}, {TimestampMs: 3, Value: 5}},
+			expected:        []mimirpb.Sample{{TimestampMs: 1, Value: 1}, {TimestampMs: 2, Value: 4}, {TimestampMs: 3, Value: 5}},
+			expectedCoerced: 2,
+		},
+		"duplicates with the same value": {
+			input:    []mimirpb.Sample{{TimestampMs: 1, Value: 1}, {TimestampMs: 1, Value: 1}},
+			expected: []mimirpb.Sample{{TimestampMs: 1, Value: 1}},
+		},
+		"duplicates with NaN values": {
+			input:    []mimirpb.Sample{{TimestampMs: 1, Value: math.NaN()}, {TimestampMs: 1, Value: math.NaN()}},
+			expected: []mimirpb.Sample{{TimestampMs: 1, Value: math.NaN()}},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			input := append([]mimirpb.Sample(nil), testData.input...)
+
+			actual, coerced := dedupeSamplesKeepLast(input)
+			assert.Equal(t, testData.expectedCoerced, coerced)
+			assert.Len(t, actual, len(testData.expected))
+			for i := range testData.expected {
+				assert.Equal(t, testData.expected[i].TimestampMs, actual[i].TimestampMs)
+				assert.Equal(t, math.Float64bits(testData.expected[i].Value), math.Float64bits(actual[i].Value))
+			}
+
+			// The input samples are not modified.
+			assert.Len(t, input, len(testData.input))
+			for i := range testData.input {
+				assert.Equal(t, math.Float64bits(testData.input[i].Value), math.Float64bits(input[i].Value))
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+)
+
+// LocalTSDBUsers returns the users having a non-empty TSDB directory on disk, sorted by user ID.
+func (i *Ingester) LocalTSDBUsers() ([]string, error) {
+	dir := i.cfg.BlocksStorageConfig.TSDB.Dir
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		// If the root directory doesn't exist, there are no TSDBs.
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "unable to read directory %s containing existing TSDBs", dir)
+	}
+
+	var users []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		empty, err := isEmptyDir(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read TSDB dir for user %s", entry.Name())
+		}
+		if !empty {
+			users = append(users, entry.Name())
+		}
+	}
+
+	sort.Strings(users)
+	return users, nil
+}
+
+func isEmptyDir(dir string) (bool, error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	if _, err := f.Readdirnames(1); err != nil {
+		if err == io.EOF {
+			return true, nil
+		}
+		return false, err
+	}
+	return false, nil
+}
+
+// FlushUser opens the TSDB of the user from disk, replaying its WAL, compacts the whole head into blocks
+// and ships all the blocks not shipped yet to the storage, if shipping is enabled. The TSDB is closed once done,
+// so that the users can be flushed one by one without keeping all their TSDBs in memory.
+// FlushUser is meant to be used by the flusher, and returns the number of shipped blocks.
+func (i *Ingester) FlushUser(ctx context.Context, userID string) (int, error) {
+	startTime := time.Now()
+
+	if i.getTSDB(userID) != nil {
+		return 0, errors.Errorf("TSDB for user %s is already open", userID)
+	}
+
+	db, err := i.createTSDB(userID)
+	if err != nil {
+		return 0, errors.Wrapf(err, "unable to open TSDB for user %s", userID)
+	}
+
+	i.tsdbsMtx.Lock()
+	i.tsdbs[userID] = db
+	i.tsdbsMtx.Unlock()
+
+	i.metrics.memUsers.Inc()
+	i.metrics.walReplayTime.Observe(time.Since(startTime).Seconds())
+
+	defer func() {
+		if err := db.Close(); err != nil {
+			level.Warn(i.logger).Log("msg", "unable to close TSDB", "err", err, "user", userID)
+		}
+
+		i.tsdbsMtx.Lock()
+		delete(i.tsdbs, userID)
+		i.tsdbsMtx.Unlock()
+
+		i.metrics.memUsers.Dec()
+		i.tsdbMetrics.removeRegistryForUser(userID)
+	}()
+
+	if db.Head().NumSeries() > 0 {
+		i.metrics.compactionsTriggered.Inc()
+
+		if err := db.compactHead(i.cfg.BlocksStorageConfig.TSDB.BlockRanges[0].Milliseconds()); err != nil {
+			i.metrics.compactionsFailed.Inc()
+			return 0, errors.Wrapf(err, "unable to compact TSDB head for user %s", userID)
+		}
+	}
+
+	if db.shipper == nil {
+		return 0, nil
+	}
+
+	uploaded, err := db.shipper.Sync(ctx)
+	if err != nil {
+		return uploaded, errors.Wrapf(err, "unable to ship TSDB blocks for user %s", userID)
+	}
+
+	level.Info(i.logger).Log("msg", "flushed TSDB for user", "user", userID, "shipped_blocks", uploaded, "duration", time.Since(startTime))
+	return uploaded, nil
+}
@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestIngester_FlushUser(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "user-2", "wal"), os.ModePerm))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "user-1", "wal"), os.ModePerm))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "empty"), os.ModePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file"), []byte{}, os.ModePerm))
+
+	cfg := defaultIngesterTestConfig(t)
+	cfg.BlocksStorageConfig.TSDB.Dir = dir
+	cfg.BlocksStorageConfig.Bucket.Backend = "filesystem"
+	cfg.BlocksStorageConfig.Bucket.Filesystem.Directory = t.TempDir()
+
+	overrides, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
+	require.NoError(t, err)
+
+	i, err := NewForFlusher(cfg, overrides, prometheus.NewPedanticRegistry(), log.NewNopLogger())
+	require.NoError(t, err)
+
+	users, err := i.LocalTSDBUsers()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user-1", "user-2"}, users)
+
+	// A non existing directory has no users.
+	i.cfg.BlocksStorageConfig.TSDB.Dir = filepath.Join(dir, "not-existing")
+	users, err = i.LocalTSDBUsers()
+	require.NoError(t, err)
+	assert.Empty(t, users)
+
+	// A user whose TSDB can't be opened fails to flush.
+	i.cfg.BlocksStorageConfig.TSDB.Dir = dir
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "user-3"), os.ModePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "user-3", "wal"), []byte("not a directory"), os.ModePerm))
+	_, err = i.FlushUser(context.Background(), "user-3")
+	assert.Error(t, err)
+	assert.Nil(t, i.getTSDB("user-3"))
+
+	// A user with an empty TSDB is flushed without shipping any block.
+	shipped, err := i.FlushUser(context.Background(), "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, 0, shipped)
+	assert.Nil(t, i.getTSDB("user-1"))
+}
@@ -55,6 +55,7 @@ import (
 	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
 	"github.com/grafana/mimir/pkg/tenant"
 	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/globalerror"
 	util_log "github.com/grafana/mimir/pkg/util/log"
 	util_math "github.com/grafana/mimir/pkg/util/math"
 	"github.com/grafana/mimir/pkg/util/spanlogger"
@@ -62,7 +63,7 @@ import (
 )
 
 const (
-	// Number of timeseries to return in each batch of a QueryStream.
+	// Number of timeseries (or metadata) to return in each batch of a streamed read.
 	queryStreamBatchSize = 128
 
 	// Discarded Metadata metric labels.
@@ -91,6 +92,9 @@ const (
 
 var (
 	errExemplarRef = errors.New("exemplars not ingested because series not already present")
+
+	errInvalidActiveSeriesStripes               = errors.New("the number of active series stripes must be greater than 0")
+	errInvalidActiveSeriesTargetSeriesPerStripe = errors.New("the target number of active series per stripe must be greater than or equal to 0")
 )
 
 // Shipper interface is used to have an easy way to mock it in tests.
@@ -126,6 +130,11 @@ type Config struct {
 	ActiveSeriesMetricsIdleTimeout  time.Duration                    `yaml:"active_series_metrics_idle_timeout" category:"advanced"`
 	ActiveSeriesCustomTrackers      ActiveSeriesCustomTrackersConfig `yaml:"active_series_custom_trackers" doc:"description=Additional custom trackers for active metrics. If there are active series matching a provided matcher (map value), the count will be exposed in the custom trackers metric labeled using the tracker name (map key). Zero valued counts are not exposed (and removed when they go back to zero)." category:"advanced"`
 
+	ActiveSeriesCustomTrackersPollInterval time.Duration `yaml:"active_series_custom_trackers_poll_interval" category:"experimental"`
+
+	ActiveSeriesStripes               int `yaml:"active_series_stripes" category:"experimental"`
+	ActiveSeriesTargetSeriesPerStripe int `yaml:"active_series_target_series_per_stripe" category:"experimental"`
+
 	ExemplarsUpdatePeriod time.Duration `yaml:"exemplars_update_period" category:"experimental"`
 
 	BlocksStorageConfig         mimir_tsdb.BlocksStorageConfig `yaml:"-"`
@@ -138,6 +147,8 @@ type Config struct {
 
 	IgnoreSeriesLimitForMetricNames string `yaml:"ignore_series_limit_for_metric_names" category:"advanced"`
 
+	DiskSpaceWatchdog DiskSpaceWatchdogConfig `yaml:"disk_space_watchdog"`
+
 	// For testing, you can override the address and ID of this ingester.
 	ingesterClientFactory func(addr string, cfg client.Config) (client.HealthAndIngesterClient, error)
 }
@@ -154,7 +165,12 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	f.DurationVar(&cfg.ActiveSeriesMetricsIdleTimeout, "ingester.active-series-metrics-idle-timeout", 10*time.Minute, "After what time a series is considered to be inactive.")
 	f.Var(&cfg.ActiveSeriesCustomTrackers, "ingester.active-series-custom-trackers", "Additional active series metrics, matching the provided matchers. Matchers should be in form <name>:<matcher>, like 'foobar:{foo=\"bar\"}'. Multiple matchers can be provided either providing the flag multiple times or providing multiple semicolon-separated values to a single flag.")
 
-	f.BoolVar(&cfg.StreamChunksWhenUsingBlocks, "ingester.stream-chunks-when-using-blocks", true, "Stream chunks from ingesters to queriers.")
+	f.DurationVar(&cfg.ActiveSeriesCustomTrackersPollInterval, "ingester.active-series-custom-trackers-poll-interval", 0, "How often to poll the object storage for the per-tenant active series custom trackers set through the API, which override the ones configured through -ingester.active-series-custom-trackers. When the custom trackers of a tenant change, the tenant's active series counts are reset and are accurate again after -ingester.active-series-metrics-idle-timeout. 0 to disable the API and the per-tenant custom trackers.")
+
+	f.IntVar(&cfg.ActiveSeriesStripes, "ingester.active-series-stripes", DefaultActiveSeriesStripes, "Number of stripes the active series of each tenant are split into. More stripes reduce the lock contention when tracking the active series of big tenants, at the cost of more memory per tenant. If -ingester.active-series-target-series-per-stripe is set, this is the maximum number of stripes.")
+	f.IntVar(&cfg.ActiveSeriesTargetSeriesPerStripe, "ingester.active-series-target-series-per-stripe", 0, "If greater than 0, the number of stripes of the active series of each tenant is adjusted to have about this number of active series per stripe, between 16 and -ingester.active-series-stripes. Reduces the memory used by the tenants with few series. 0 to use a fixed number of stripes.")
+
+	f.BoolVar(&cfg.StreamChunksWhenUsingBlocks, "ingester.stream-chunks-when-using-blocks", true, "Stream chunks from ingesters to queriers. Only applies to the queriers which don't negotiate the streaming protocol version, which otherwise always get chunks.")
 	f.DurationVar(&cfg.ExemplarsUpdatePeriod, "ingester.exemplars-update-period", 15*time.Second, "Period with which to update per-tenant max exemplar limit.")
 
 	f.Float64Var(&cfg.DefaultLimits.MaxIngestionRate, "ingester.instance-limits.max-ingestion-rate", 0, "Max ingestion rate (samples/sec) that ingester will accept. This limit is per-ingester, not per-tenant. Additional push requests will be rejected. Current ingestion rate is computed as exponentially weighted moving average, updated every second. 0 = unlimited.")
@@ -163,6 +179,20 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	f.Int64Var(&cfg.DefaultLimits.MaxInflightPushRequests, "ingester.instance-limits.max-inflight-push-requests", 30000, "Max inflight push requests that this ingester can handle (across all tenants). Additional requests will be rejected. 0 = unlimited.")
 
 	f.StringVar(&cfg.IgnoreSeriesLimitForMetricNames, "ingester.ignore-series-limit-for-metric-names", "", "Comma-separated list of metric names, for which the -ingester.max-global-series-per-metric limit will be ignored. Does not affect the -ingester.max-global-series-per-user limit.")
+
+	cfg.DiskSpaceWatchdog.RegisterFlags(f)
+}
+
+// Validate the config.
+func (cfg *Config) Validate() error {
+	if cfg.ActiveSeriesStripes <= 0 {
+		return errInvalidActiveSeriesStripes
+	}
+	if cfg.ActiveSeriesTargetSeriesPerStripe < 0 {
+		return errInvalidActiveSeriesTargetSeriesPerStripe
+	}
+
+	return cfg.DiskSpaceWatchdog.Validate()
 }
 
 func (cfg *Config) getIgnoreSeriesLimitForMetricNamesMap() map[string]struct{} {
@@ -213,6 +243,12 @@ type Ingester struct {
 	// Value used by shipper as external label.
 	shipperIngesterID string
 
+	// Limits the bandwidth used by the shipper to upload blocks.
+	shipperBandwidthLimiter *shipperBandwidthLimiter
+
+	// Monitors the free disk space. Nil if disabled.
+	diskSpaceWatchdog *diskSpaceWatchdog
+
 	subservices *services.Manager
 
 	tsdbMetrics *tsdbMetrics
@@ -236,6 +272,10 @@ type Ingester struct {
 	// Rate of pushed samples. Used to limit global samples push rate.
 	ingestionRate        *util_math.EwmaRate
 	inflightPushRequests atomic.Int64
+
+	// Shutdown preparation requested through the PrepareShutdownHandler.
+	prepareShutdownMtx sync.Mutex
+	prepareShutdown    prepareShutdownState
 }
 
 func newIngester(cfg Config, limits *validation.Overrides, registerer prometheus.Registerer, logger log.Logger) (*Ingester, error) {
@@ -261,6 +301,7 @@ func newIngester(cfg Config, limits *validation.Overrides, registerer prometheus
 		forceCompactTrigger: make(chan requestWithUsersAndCallback),
 		shipTrigger:         make(chan requestWithUsersAndCallback),
 		seriesHashCache:     hashcache.NewSeriesHashCache(cfg.BlocksStorageConfig.TSDB.SeriesHashCacheMaxBytes),
+		prepareShutdown:     prepareShutdownState{status: prepareShutdownUnset},
 	}, nil
 }
 
@@ -279,6 +320,10 @@ func New(cfg Config, clientConfig client.Config, limits *validation.Overrides, r
 	i.clientConfig = clientConfig
 	i.ingestionRate = util_math.NewEWMARate(0.2, instanceIngestionRateTickInterval)
 	i.metrics = newIngesterMetrics(registerer, cfg.ActiveSeriesMetricsEnabled, i.activeSeriesMatcher.MatcherNames(), i.getInstanceLimits, i.ingestionRate, &i.inflightPushRequests)
+	i.shipperBandwidthLimiter = i.newShipperBandwidthLimiter()
+	if cfg.DiskSpaceWatchdog.CheckInterval > 0 {
+		i.diskSpaceWatchdog = newDiskSpaceWatchdog(cfg.DiskSpaceWatchdog, cfg.BlocksStorageConfig.TSDB.Dir, logger, registerer)
+	}
 
 	asm, err := NewActiveSeriesMatchers(cfg.ActiveSeriesCustomTrackers)
 	if err != nil {
@@ -300,7 +345,12 @@ func New(cfg Config, clientConfig client.Config, limits *validation.Overrides, r
 		}, i.getOldestUnshippedBlockMetric)
 	}
 
-	i.lifecycler, err = ring.NewLifecycler(cfg.IngesterRing.ToLifecyclerConfig(), i, "ingester", IngesterRingKey, cfg.BlocksStorageConfig.TSDB.FlushBlocksOnShutdown, logger, prometheus.WrapRegistererWithPrefix("cortex_", registerer))
+	lifecyclerCfg, err := cfg.IngesterRing.ToLifecyclerConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	i.lifecycler, err = ring.NewLifecycler(lifecyclerCfg, i, "ingester", IngesterRingKey, cfg.BlocksStorageConfig.TSDB.FlushBlocksOnShutdown, logger, prometheus.WrapRegistererWithPrefix("cortex_", registerer))
 	if err != nil {
 		return nil, err
 	}
@@ -325,35 +375,24 @@ func New(cfg Config, clientConfig client.Config, limits *validation.Overrides, r
 }
 
 // NewForFlusher is a special version of ingester used by Flusher. This
-// ingester is not ingesting anything, its only purpose is to react on Flush
-// method and flush all openened TSDBs when called.
+// ingester is not ingesting anything, its only purpose is to react on FlushUser
+// method and flush the TSDB of a user found on disk when called.
 func NewForFlusher(cfg Config, limits *validation.Overrides, registerer prometheus.Registerer, logger log.Logger) (*Ingester, error) {
 	i, err := newIngester(cfg, limits, registerer, logger)
 	if err != nil {
 		return nil, err
 	}
 	i.metrics = newIngesterMetrics(registerer, false, nil, i.getInstanceLimits, nil, &i.inflightPushRequests)
+	i.shipperBandwidthLimiter = i.newShipperBandwidthLimiter()
 
 	i.shipperIngesterID = "flusher"
 
 	// This ingester will not start any subservices (lifecycler, compaction, shipping),
-	// and will only open TSDBs, wait for Flush to be called, and then close TSDBs again.
-	i.BasicService = services.NewIdleService(i.startingForFlusher, i.stoppingForFlusher)
+	// and will only open TSDBs when FlushUser is called, and then close them again.
+	i.BasicService = services.NewIdleService(nil, i.stoppingForFlusher)
 	return i, nil
 }
 
-func (i *Ingester) startingForFlusher(ctx context.Context) error {
-	if err := i.openExistingTSDB(ctx); err != nil {
-		// Try to rollback and close opened TSDBs before halting the ingester.
-		i.closeAllTSDB()
-
-		return errors.Wrap(err, "opening existing TSDBs")
-	}
-
-	// Don't start any sub-services (lifecycler, compaction, shipper) at all.
-	return nil
-}
-
 func (i *Ingester) starting(ctx context.Context) error {
 	if err := i.openExistingTSDB(ctx); err != nil {
 		// Try to rollback and close opened TSDBs before halting the ingester.
@@ -390,6 +429,11 @@ func (i *Ingester) starting(ctx context.Context) error {
 		servs = append(servs, closeIdleService)
 	}
 
+	if i.diskSpaceWatchdog != nil {
+		diskSpaceWatchdogService := services.NewTimerService(i.cfg.DiskSpaceWatchdog.CheckInterval, nil, i.checkDiskSpace, nil)
+		servs = append(servs, diskSpaceWatchdogService)
+	}
+
 	var err error
 	i.subservices, err = services.NewManager(servs...)
 	if err == nil {
@@ -442,6 +486,13 @@ func (i *Ingester) updateLoop(ctx context.Context) error {
 		defer t.Stop()
 	}
 
+	var activeSeriesCustomTrackersTickerChan <-chan time.Time
+	if i.cfg.ActiveSeriesMetricsEnabled && i.cfg.ActiveSeriesCustomTrackersPollInterval > 0 {
+		t := time.NewTicker(i.cfg.ActiveSeriesCustomTrackersPollInterval)
+		activeSeriesCustomTrackersTickerChan = t.C
+		defer t.Stop()
+	}
+
 	// Similarly to the above, this is a hardcoded value.
 	metadataPurgeTicker := time.NewTicker(metadataPurgePeriod)
 	defer metadataPurgeTicker.Stop()
@@ -454,9 +505,11 @@ func (i *Ingester) updateLoop(ctx context.Context) error {
 			i.ingestionRate.Tick()
 		case <-rateUpdateTicker.C:
 			i.tsdbsMtx.RLock()
+			now := time.Now()
 			for _, db := range i.tsdbs {
 				db.ingestedAPISamples.Tick()
 				db.ingestedRuleSamples.Tick()
+				i.metrics.seriesCreatedLastHour.WithLabelValues(db.userID).Set(float64(db.seriesChurn.count(now)))
 			}
 			i.tsdbsMtx.RUnlock()
 
@@ -466,6 +519,9 @@ func (i *Ingester) updateLoop(ctx context.Context) error {
 		case <-activeSeriesTickerChan:
 			i.updateActiveSeries(time.Now())
 
+		case <-activeSeriesCustomTrackersTickerChan:
+			i.syncActiveSeriesCustomTrackers(ctx)
+
 		case <-ctx.Done():
 			return nil
 		case err := <-i.subservicesWatcher.Chan():
@@ -482,14 +538,39 @@ func (i *Ingester) updateActiveSeries(now time.Time) {
 			continue
 		}
 
-		userDB.activeSeries.Purge(purgeTime)
-		allActive, activeMatching := userDB.activeSeries.Active()
+		churn := userDB.activeSeries.Purge(purgeTime)
+
+		asm := userDB.activeSeries.CurrentMatchers()
+		for idx, stats := range asm.ReadAndResetStats() {
+			name := asm.MatcherNames()[idx]
+			i.metrics.activeSeriesCustomTrackerEvaluations.WithLabelValues(userID, name).Add(float64(stats.Evaluations))
+			i.metrics.activeSeriesCustomTrackerMatches.WithLabelValues(userID, name).Add(float64(stats.Matches))
+			i.metrics.activeSeriesCustomTrackerEvaluationSeconds.WithLabelValues(userID, name).Add(stats.EvaluationTime.Seconds())
+		}
+
+		// After the custom trackers have been reloaded, the counts aren't accurate until the idle timeout
+		// has elapsed, so the previous values are kept in the meanwhile. The churn isn't accurate either,
+		// because all the active series are tracked again as created, so it's not reported.
+		if userDB.activeSeries.MatchersUpdatedAt().After(purgeTime) {
+			continue
+		}
+
+		allActive, activeMatching, matcherNames := userDB.activeSeries.ActiveWithMatchers()
+		i.metrics.activeSeriesCreatedPerUser.WithLabelValues(userID).Add(float64(churn.Created))
+		i.metrics.activeSeriesExpiredPerUser.WithLabelValues(userID).Add(float64(churn.Expired))
+		if len(churn.CreatedMatching) == len(matcherNames) {
+			for idx, name := range matcherNames {
+				i.metrics.activeSeriesCustomTrackerCreatedPerUser.WithLabelValues(userID, name).Add(float64(churn.CreatedMatching[idx]))
+				i.metrics.activeSeriesCustomTrackerExpiredPerUser.WithLabelValues(userID, name).Add(float64(churn.ExpiredMatching[idx]))
+			}
+		}
+
 		if allActive > 0 {
 			i.metrics.activeSeriesPerUser.WithLabelValues(userID).Set(float64(allActive))
 		} else {
 			i.metrics.activeSeriesPerUser.DeleteLabelValues(userID)
 		}
-		for idx, name := range i.activeSeriesMatcher.MatcherNames() {
+		for idx, name := range matcherNames {
 			// We only set the metrics for matchers that actually exist, to avoid increasing cardinality with zero valued metrics.
 			if activeMatching[idx] > 0 {
 				i.metrics.activeSeriesCustomTrackersPerUser.WithLabelValues(userID, name).Set(float64(activeMatching[idx]))
@@ -556,6 +637,10 @@ func (i *Ingester) PushWithCleanup(ctx context.Context, req *mimirpb.WriteReques
 		}
 	}
 
+	if i.diskSpaceWatchdog.currentStage() == diskSpaceReadOnly {
+		return nil, errDiskSpaceLowReadOnly
+	}
+
 	userID, err := tenant.TenantID(ctx)
 	if err != nil {
 		return nil, err
@@ -608,6 +693,14 @@ func (i *Ingester) PushWithCleanup(ctx context.Context, req *mimirpb.WriteReques
 		perUserSeriesLimitCount   = 0
 		perMetricSeriesLimitCount = 0
 
+		perUserSeriesCreatedPerHourLimitCount = 0
+
+		coercedDuplicateSamplesCount = 0
+		duplicateTimestampPolicy     = i.limits.DuplicateTimestampPolicy(userID)
+
+		createdTimestampZeroSamplesCount     = 0
+		createdTimestampZeroIngestionEnabled = i.limits.CreatedTimestampZeroIngestionEnabled(userID)
+
 		minAppendTime, minAppendTimeAvailable = db.Head().AppendableMinValidTime()
 
 		updateFirstPartial = func(errFn func() error) {
@@ -646,7 +739,41 @@ func (i *Ingester) PushWithCleanup(ctx context.Context, req *mimirpb.WriteReques
 		// To find out if any sample was added to this series, we keep old value.
 		oldSucceededSamplesCount := succeededSamplesCount
 
-		for _, s := range ts.Samples {
+		samples := ts.Samples
+		if duplicateTimestampPolicy == validation.DuplicateTimestampPolicyKeepLast {
+			var coerced int
+			samples, coerced = dedupeSamplesKeepLast(samples)
+			coercedDuplicateSamplesCount += coerced
+		}
+
+		// Ingest a zero sample at the created timestamp, so that the increase of the counter since its creation
+		// or reset isn't lost. The zero sample is expected to fail if it has already been ingested with a previous
+		// request, or if the series already has newer samples, so TSDB soft errors are ignored.
+		if createdTimestampZeroIngestionEnabled && ts.CreatedTimestamp > 0 && len(samples) > 0 && ts.CreatedTimestamp < samples[0].TimestampMs {
+			if ref == 0 {
+				// Copy the label set because TSDB may retain it.
+				copiedLabels = mimirpb.FromLabelAdaptersToLabelsWithCopy(ts.Labels)
+			}
+
+			newRef, err := app.Append(ref, copiedLabels, ts.CreatedTimestamp, 0)
+			switch errors.Cause(err) {
+			case nil:
+				ref = newRef
+				createdTimestampZeroSamplesCount++
+			case storage.ErrOutOfBounds, storage.ErrOutOfOrderSample, storage.ErrDuplicateSampleForTimestamp,
+				errMaxSeriesPerUserLimitExceeded, errMaxSeriesCreatedPerHourExceeded, errMaxSeriesPerMetricLimitExceeded:
+				// The limits are enforced on the samples of the series, which fail with the same error.
+			default:
+				// The error looks an issue on our side, so we should rollback
+				if rollbackErr := app.Rollback(); rollbackErr != nil {
+					level.Warn(i.logger).Log("msg", "failed to rollback on error", "user", userID, "err", rollbackErr)
+				}
+
+				return nil, wrapWithUser(err, userID)
+			}
+		}
+
+		for _, s := range samples {
 			var err error
 
 			// If the cached reference exists, we try to use it.
@@ -656,7 +783,7 @@ func (i *Ingester) PushWithCleanup(ctx context.Context, req *mimirpb.WriteReques
 					continue
 				}
 			} else {
-				// Copy the label set because both TSDB and the active series tracker may retain it.
+				// Copy the label set because TSDB may retain it.
 				copiedLabels = mimirpb.FromLabelAdaptersToLabelsWithCopy(ts.Labels)
 
 				// Retain the reference in case there are multiple samples for the series.
@@ -666,6 +793,12 @@ func (i *Ingester) PushWithCleanup(ctx context.Context, req *mimirpb.WriteReques
 				}
 			}
 
+			if errors.Cause(err) == storage.ErrDuplicateSampleForTimestamp && coercesDuplicateTimestamps(duplicateTimestampPolicy) {
+				// The sample is dropped without error, according to the tenant's duplicate timestamp policy.
+				coercedDuplicateSamplesCount++
+				continue
+			}
+
 			failedSamplesCount++
 
 			// Check if the error is a soft error we can proceed on. If so, we keep track
@@ -693,6 +826,13 @@ func (i *Ingester) PushWithCleanup(ctx context.Context, req *mimirpb.WriteReques
 				updateFirstPartial(func() error { return makeLimitError(perUserSeriesLimit, i.limiter.FormatError(userID, cause)) })
 				continue
 
+			case errMaxSeriesCreatedPerHourExceeded:
+				perUserSeriesCreatedPerHourLimitCount++
+				updateFirstPartial(func() error {
+					return makeLimitError(perUserSeriesCreatedPerHourLimit, i.limiter.FormatError(userID, cause))
+				})
+				continue
+
 			case errMaxSeriesPerMetricLimitExceeded:
 				perMetricSeriesLimitCount++
 				updateFirstPartial(func() error {
@@ -710,10 +850,8 @@ func (i *Ingester) PushWithCleanup(ctx context.Context, req *mimirpb.WriteReques
 		}
 
 		if i.cfg.ActiveSeriesMetricsEnabled && succeededSamplesCount > oldSucceededSamplesCount {
-			db.activeSeries.UpdateSeries(mimirpb.FromLabelAdaptersToLabels(ts.Labels), startAppend, func(l labels.Labels) labels.Labels {
-				// we must already have copied the labels if succeededSamplesCount has been incremented.
-				return copiedLabels
-			})
+			// The series reference is set if succeededSamplesCount has been incremented.
+			db.activeSeries.UpdateSeries(mimirpb.FromLabelAdaptersToLabels(ts.Labels), ref, startAppend)
 		}
 
 		if len(ts.Exemplars) > 0 && i.limits.MaxGlobalExemplarsPerUser(userID) > 0 {
@@ -794,6 +932,15 @@ func (i *Ingester) PushWithCleanup(ctx context.Context, req *mimirpb.WriteReques
 	if perMetricSeriesLimitCount > 0 {
 		validation.DiscardedSamples.WithLabelValues(perMetricSeriesLimit, userID).Add(float64(perMetricSeriesLimitCount))
 	}
+	if perUserSeriesCreatedPerHourLimitCount > 0 {
+		validation.DiscardedSamples.WithLabelValues(perUserSeriesCreatedPerHourLimit, userID).Add(float64(perUserSeriesCreatedPerHourLimitCount))
+	}
+	if coercedDuplicateSamplesCount > 0 {
+		i.metrics.coercedDuplicateSamples.WithLabelValues(userID).Add(float64(coercedDuplicateSamplesCount))
+	}
+	if createdTimestampZeroSamplesCount > 0 {
+		i.metrics.createdTimestampZeroSamples.WithLabelValues(userID).Add(float64(createdTimestampZeroSamplesCount))
+	}
 	if succeededSamplesCount > 0 {
 		i.ingestionRate.Add(int64(succeededSamplesCount))
 
@@ -827,50 +974,105 @@ func (i *Ingester) QueryExemplars(ctx context.Context, req *client.ExemplarQuery
 	spanlog, ctx := spanlogger.NewWithLogger(ctx, i.logger, "Ingester.QueryExemplars")
 	defer spanlog.Finish()
 
-	userID, err := tenant.TenantID(ctx)
+	res, err := i.selectExemplars(ctx, req)
 	if err != nil {
 		return nil, err
 	}
 
-	from, through, matchers, err := client.FromExemplarQueryRequest(req)
-	if err != nil {
-		return nil, err
-	}
+	numExemplars := 0
 
-	i.metrics.queries.Inc()
+	result := &client.ExemplarQueryResponse{}
+	for _, es := range res {
+		ts := mimirpb.TimeSeries{
+			Labels:    mimirpb.FromLabelsToLabelAdapters(es.SeriesLabels),
+			Exemplars: mimirpb.FromExemplarsToExemplarProtos(es.Exemplars),
+		}
 
-	db := i.getTSDB(userID)
-	if db == nil {
-		return &client.ExemplarQueryResponse{}, nil
+		numExemplars += len(ts.Exemplars)
+		result.Timeseries = append(result.Timeseries, ts)
 	}
 
-	q, err := db.ExemplarQuerier(ctx)
-	if err != nil {
-		return nil, err
+	i.metrics.queriedExemplars.Observe(float64(numExemplars))
+
+	return result, nil
+}
+
+// QueryExemplarsStream is like QueryExemplars, but streams the series in batches of bounded size,
+// so that the response of a large query is never buffered in a single message.
+func (i *Ingester) QueryExemplarsStream(req *client.ExemplarQueryRequest, stream client.Ingester_QueryExemplarsStreamServer) error {
+	if err := i.checkRunning(); err != nil {
+		return err
 	}
 
-	// It's not required to sort series from a single ingester because series are sorted by the Exemplar Storage before returning from Select.
-	res, err := q.Select(from, through, matchers...)
+	spanlog, ctx := spanlogger.NewWithLogger(stream.Context(), i.logger, "Ingester.QueryExemplarsStream")
+	defer spanlog.Finish()
+
+	res, err := i.selectExemplars(ctx, req)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	numExemplars := 0
-
-	result := &client.ExemplarQueryResponse{}
+	batch := make([]mimirpb.TimeSeries, 0, queryStreamBatchSize)
+	batchSizeBytes := 0
 	for _, es := range res {
 		ts := mimirpb.TimeSeries{
 			Labels:    mimirpb.FromLabelsToLabelAdapters(es.SeriesLabels),
 			Exemplars: mimirpb.FromExemplarsToExemplarProtos(es.Exemplars),
 		}
-
 		numExemplars += len(ts.Exemplars)
-		result.Timeseries = append(result.Timeseries, ts)
+		tsSize := ts.Size()
+
+		if (batchSizeBytes > 0 && batchSizeBytes+tsSize > queryStreamBatchMessageSize) || len(batch) >= queryStreamBatchSize {
+			if err := client.SendQueryExemplarsStreamResponse(stream, &client.ExemplarQueryResponse{Timeseries: batch}); err != nil {
+				return err
+			}
+
+			batchSizeBytes = 0
+			batch = batch[:0]
+		}
+
+		batch = append(batch, ts)
+		batchSizeBytes += tsSize
+	}
+
+	// Final flush any existing series.
+	if len(batch) > 0 {
+		if err := client.SendQueryExemplarsStreamResponse(stream, &client.ExemplarQueryResponse{Timeseries: batch}); err != nil {
+			return err
+		}
 	}
 
 	i.metrics.queriedExemplars.Observe(float64(numExemplars))
+	return nil
+}
 
-	return result, nil
+// selectExemplars returns the exemplars of the tenant matching the request, sorted by series.
+func (i *Ingester) selectExemplars(ctx context.Context, req *client.ExemplarQueryRequest) ([]exemplar.QueryResult, error) {
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	from, through, matchers, err := client.FromExemplarQueryRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	i.metrics.queries.Inc()
+
+	db := i.getTSDB(userID)
+	if db == nil {
+		return nil, nil
+	}
+
+	q, err := db.ExemplarQuerier(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// It's not required to sort series from a single ingester because series are sorted by the Exemplar Storage before returning from Select.
+	return q.Select(from, through, matchers...)
 }
 
 func (i *Ingester) LabelValues(ctx context.Context, req *client.LabelValuesRequest) (*client.LabelValuesResponse, error) {
@@ -1117,6 +1319,87 @@ func (i *Ingester) LabelValuesCardinality(req *client.LabelValuesCardinalityRequ
 	)
 }
 
+// ActiveSeries streams the labels of the tenant's active series matching the request matchers, in batches of
+// bounded size. The series whose custom trackers have just been reloaded are only listed once they're pushed again.
+func (i *Ingester) ActiveSeries(request *client.ActiveSeriesRequest, stream client.Ingester_ActiveSeriesServer) error {
+	if err := i.checkRunning(); err != nil {
+		return err
+	}
+	if !i.cfg.ActiveSeriesMetricsEnabled {
+		return status.Error(codes.FailedPrecondition, "active series tracking is disabled")
+	}
+
+	userID, err := tenant.TenantID(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	db := i.getTSDB(userID)
+	if db == nil {
+		return nil
+	}
+
+	matchers, err := client.FromLabelMatchers(request.GetMatchers())
+	if err != nil {
+		return err
+	}
+
+	idx, err := db.Head().Index()
+	if err != nil {
+		return err
+	}
+	defer idx.Close()
+
+	refs, _ := db.activeSeries.ActiveSeriesRefs("")
+
+	batch := make([]*mimirpb.Metric, 0, queryStreamBatchSize)
+	batchSizeBytes := 0
+	var lbls labels.Labels
+	for _, ref := range refs {
+		// The series may have been removed from the head since it has been tracked as active.
+		if err := idx.Series(ref, &lbls, nil); err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				continue
+			}
+			return err
+		}
+		if !matchesAll(matchers, lbls) {
+			continue
+		}
+
+		m := &mimirpb.Metric{Labels: mimirpb.FromLabelsToLabelAdapters(lbls.Copy())}
+		mSize := m.Size()
+
+		if (batchSizeBytes > 0 && batchSizeBytes+mSize > queryStreamBatchMessageSize) || len(batch) >= queryStreamBatchSize {
+			if err := client.SendActiveSeriesResponse(stream, &client.ActiveSeriesResponse{Metric: batch}); err != nil {
+				return err
+			}
+
+			batchSizeBytes = 0
+			batch = batch[:0]
+		}
+
+		batch = append(batch, m)
+		batchSizeBytes += mSize
+	}
+
+	// Final flush any existing series.
+	if len(batch) > 0 {
+		return client.SendActiveSeriesResponse(stream, &client.ActiveSeriesResponse{Metric: batch})
+	}
+	return nil
+}
+
+// matchesAll returns whether the labels match all the input matchers.
+func matchesAll(matchers []*labels.Matcher, lbls labels.Labels) bool {
+	for _, m := range matchers {
+		if !m.Matches(lbls.Get(m.Name)) {
+			return false
+		}
+	}
+	return true
+}
+
 func createUserStats(db *userTSDB) *client.UserStatsResponse {
 	apiRate := db.ingestedAPISamples.Rate()
 	ruleRate := db.ingestedRuleSamples.Rate()
@@ -1166,6 +1449,32 @@ func (i *Ingester) QueryStream(req *client.QueryRequest, stream client.Ingester_
 	numSamples := 0
 	numSeries := 0
 
+	streamType := i.queryStreamType(req)
+
+	if streamType == QueryStreamChunks {
+		level.Debug(spanlog).Log("msg", "using queryStreamChunks")
+		numSeries, numSamples, err = i.queryStreamChunks(ctx, db, int64(from), int64(through), matchers, shard, stream)
+	} else {
+		level.Debug(spanlog).Log("msg", "using queryStreamSamples")
+		numSeries, numSamples, err = i.queryStreamSamples(ctx, db, int64(from), int64(through), matchers, shard, stream)
+	}
+	if err != nil {
+		return err
+	}
+
+	i.metrics.queriedSeries.Observe(float64(numSeries))
+	i.metrics.queriedSamples.Observe(float64(numSamples))
+	level.Debug(spanlog).Log("series", numSeries, "samples", numSamples)
+	return nil
+}
+
+// queryStreamType returns how the series are streamed to the client. The clients which negotiate a version of the
+// protocol always get chunks, while the stream type of the unversioned clients depends on the configuration.
+func (i *Ingester) queryStreamType(req *client.QueryRequest) QueryStreamType {
+	if req.StreamingProtocolVersion >= client.QueryStreamProtocolVersionChunks {
+		return QueryStreamChunks
+	}
+
 	streamType := QueryStreamSamples
 	if i.cfg.StreamChunksWhenUsingBlocks {
 		streamType = QueryStreamChunks
@@ -1183,21 +1492,7 @@ func (i *Ingester) QueryStream(req *client.QueryRequest, stream client.Ingester_
 		}
 	}
 
-	if streamType == QueryStreamChunks {
-		level.Debug(spanlog).Log("msg", "using queryStreamChunks")
-		numSeries, numSamples, err = i.queryStreamChunks(ctx, db, int64(from), int64(through), matchers, shard, stream)
-	} else {
-		level.Debug(spanlog).Log("msg", "using queryStreamSamples")
-		numSeries, numSamples, err = i.queryStreamSamples(ctx, db, int64(from), int64(through), matchers, shard, stream)
-	}
-	if err != nil {
-		return err
-	}
-
-	i.metrics.queriedSeries.Observe(float64(numSeries))
-	i.metrics.queriedSamples.Observe(float64(numSamples))
-	level.Debug(spanlog).Log("series", numSeries, "samples", numSamples)
-	return nil
+	return streamType
 }
 
 func (i *Ingester) queryStreamSamples(ctx context.Context, db *userTSDB, from, through int64, matchers []*labels.Matcher, shard *sharding.ShardSelector, stream client.Ingester_QueryStreamServer) (numSeries, numSamples int, _ error) {
@@ -1446,13 +1741,14 @@ func (i *Ingester) createTSDB(userID string) (*userTSDB, error) {
 
 	userDB := &userTSDB{
 		userID:              userID,
-		activeSeries:        NewActiveSeries(i.activeSeriesMatcher),
+		activeSeries:        NewActiveSeriesWithStripes(i.activeSeriesMatcher, i.cfg.ActiveSeriesStripes, i.cfg.ActiveSeriesTargetSeriesPerStripe),
 		seriesInMetric:      newMetricCounter(i.limiter, i.cfg.getIgnoreSeriesLimitForMetricNamesMap()),
 		ingestedAPISamples:  util_math.NewEWMARate(0.2, i.cfg.RateUpdatePeriod),
 		ingestedRuleSamples: util_math.NewEWMARate(0.2, i.cfg.RateUpdatePeriod),
 
 		instanceLimitsFn:    i.getInstanceLimits,
 		instanceSeriesCount: &i.seriesCount,
+		diskSpaceWatchdog:   i.diskSpaceWatchdog,
 	}
 
 	maxExemplars := i.limiter.convertGlobalToLocalLimit(userID, i.limits.MaxGlobalExemplarsPerUser(userID))
@@ -1523,7 +1819,7 @@ func (i *Ingester) createTSDB(userID string) (*userTSDB, error) {
 			userLogger,
 			tsdbPromReg,
 			udir,
-			bucket.NewUserBucketClient(userID, i.bucket, i.limits),
+			i.shipperBandwidthLimiter.wrapBucket(userID, bucket.NewUserBucketClient(userID, i.bucket, i.limits)),
 			func() labels.Labels { return l },
 			metadata.ReceiveSource,
 			false, // No need to upload compacted blocks. Mimir compactor takes care of that.
@@ -1569,9 +1865,7 @@ func (i *Ingester) closeAllTSDB() {
 
 			i.metrics.memUsers.Dec()
 			i.metrics.activeSeriesPerUser.DeleteLabelValues(userID)
-			for _, name := range i.metrics.activeSeriesCustomTrackerNames {
-				i.metrics.activeSeriesCustomTrackersPerUser.DeleteLabelValues(userID, name)
-			}
+			i.metrics.deleteActiveSeriesCustomTrackerMetrics(userID, db.activeSeries.CurrentMatchers().MatcherNames())
 		}(userDB)
 	}
 
@@ -1968,6 +2262,8 @@ func (i *Ingester) closeAndDeleteUserTSDBIfIdle(userID string) tsdbCloseCheckRes
 
 	i.deleteUserMetadata(userID)
 	i.metrics.deletePerUserMetrics(userID)
+	i.metrics.deleteActiveSeriesCustomTrackerMetrics(userID, userDB.activeSeries.CurrentMatchers().MatcherNames())
+	i.shipperBandwidthLimiter.removeUser(userID)
 
 	validation.DeletePerUserValidationMetrics(userID, i.logger)
 
@@ -2028,66 +2324,73 @@ func (i *Ingester) FlushHandler(w http.ResponseWriter, r *http.Request) {
 
 	allowedUsers := util.NewAllowedTenants(tenants, nil)
 	run := func() {
-		ingCtx := i.BasicService.ServiceContext()
-		if ingCtx == nil || ingCtx.Err() != nil {
-			level.Info(i.logger).Log("msg", "flushing TSDB blocks: ingester not running, ignoring flush request")
-			return
-		}
+		i.compactAndShipBlocks(allowedUsers)
+	}
 
-		compactionCallbackCh := make(chan struct{})
+	if len(r.Form[waitParam]) > 0 && r.Form[waitParam][0] == "true" {
+		// Run synchronously. This simplifies and speeds up tests.
+		run()
+	} else {
+		go run()
+	}
 
-		level.Info(i.logger).Log("msg", "flushing TSDB blocks: triggering compaction")
-		select {
-		case i.forceCompactTrigger <- requestWithUsersAndCallback{users: allowedUsers, callback: compactionCallbackCh}:
-			// Compacting now.
-		case <-ingCtx.Done():
-			level.Warn(i.logger).Log("msg", "failed to compact TSDB blocks, ingester not running anymore")
-			return
-		}
+	w.WriteHeader(http.StatusNoContent)
+}
 
-		// Wait until notified about compaction being finished.
-		select {
-		case <-compactionCallbackCh:
-			level.Info(i.logger).Log("msg", "finished compacting TSDB blocks")
-		case <-ingCtx.Done():
-			level.Warn(i.logger).Log("msg", "failed to compact TSDB blocks, ingester not running anymore")
-			return
-		}
+// compactAndShipBlocks force-compacts the TSDB head of the allowed tenants and ships the blocks,
+// through the compaction and shipping loops. It returns whether both completed.
+func (i *Ingester) compactAndShipBlocks(allowedUsers *util.AllowedTenants) bool {
+	ingCtx := i.BasicService.ServiceContext()
+	if ingCtx == nil || ingCtx.Err() != nil {
+		level.Info(i.logger).Log("msg", "flushing TSDB blocks: ingester not running, ignoring flush request")
+		return false
+	}
 
-		if i.cfg.BlocksStorageConfig.TSDB.IsBlocksShippingEnabled() {
-			shippingCallbackCh := make(chan struct{}) // must be new channel, as compactionCallbackCh is closed now.
+	compactionCallbackCh := make(chan struct{})
 
-			level.Info(i.logger).Log("msg", "flushing TSDB blocks: triggering shipping")
+	level.Info(i.logger).Log("msg", "flushing TSDB blocks: triggering compaction")
+	select {
+	case i.forceCompactTrigger <- requestWithUsersAndCallback{users: allowedUsers, callback: compactionCallbackCh}:
+		// Compacting now.
+	case <-ingCtx.Done():
+		level.Warn(i.logger).Log("msg", "failed to compact TSDB blocks, ingester not running anymore")
+		return false
+	}
 
-			select {
-			case i.shipTrigger <- requestWithUsersAndCallback{users: allowedUsers, callback: shippingCallbackCh}:
-				// shipping now
-			case <-ingCtx.Done():
-				level.Warn(i.logger).Log("msg", "failed to ship TSDB blocks, ingester not running anymore")
-				return
-			}
+	// Wait until notified about compaction being finished.
+	select {
+	case <-compactionCallbackCh:
+		level.Info(i.logger).Log("msg", "finished compacting TSDB blocks")
+	case <-ingCtx.Done():
+		level.Warn(i.logger).Log("msg", "failed to compact TSDB blocks, ingester not running anymore")
+		return false
+	}
 
-			// Wait until shipping finished.
-			select {
-			case <-shippingCallbackCh:
-				level.Info(i.logger).Log("msg", "shipping of TSDB blocks finished")
-			case <-ingCtx.Done():
-				level.Warn(i.logger).Log("msg", "failed to ship TSDB blocks, ingester not running anymore")
-				return
-			}
-		}
+	if i.cfg.BlocksStorageConfig.TSDB.IsBlocksShippingEnabled() {
+		shippingCallbackCh := make(chan struct{}) // must be new channel, as compactionCallbackCh is closed now.
 
-		level.Info(i.logger).Log("msg", "flushing TSDB blocks: finished")
-	}
+		level.Info(i.logger).Log("msg", "flushing TSDB blocks: triggering shipping")
 
-	if len(r.Form[waitParam]) > 0 && r.Form[waitParam][0] == "true" {
-		// Run synchronously. This simplifies and speeds up tests.
-		run()
-	} else {
-		go run()
+		select {
+		case i.shipTrigger <- requestWithUsersAndCallback{users: allowedUsers, callback: shippingCallbackCh}:
+			// shipping now
+		case <-ingCtx.Done():
+			level.Warn(i.logger).Log("msg", "failed to ship TSDB blocks, ingester not running anymore")
+			return false
+		}
+
+		// Wait until shipping finished.
+		select {
+		case <-shippingCallbackCh:
+			level.Info(i.logger).Log("msg", "shipping of TSDB blocks finished")
+		case <-ingCtx.Done():
+			level.Warn(i.logger).Log("msg", "failed to ship TSDB blocks, ingester not running anymore")
+			return false
+		}
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	level.Info(i.logger).Log("msg", "flushing TSDB blocks: finished")
+	return true
 }
 
 func wrappedTSDBIngestErr(ingestErr error, timestamp model.Time, labels []mimirpb.LabelAdapter) error {
@@ -2095,7 +2398,18 @@ func wrappedTSDBIngestErr(ingestErr error, timestamp model.Time, labels []mimirp
 		return nil
 	}
 
-	return fmt.Errorf(errTSDBIngest, ingestErr, timestamp.Time().UTC().Format(time.RFC3339Nano), mimirpb.FromLabelAdaptersToLabels(labels).String())
+	msg := fmt.Sprintf(errTSDBIngest, ingestErr, timestamp.Time().UTC().Format(time.RFC3339Nano), mimirpb.FromLabelAdaptersToLabels(labels).String())
+	if id, ok := tsdbIngestErrIDs[ingestErr]; ok {
+		msg = id.Message(msg)
+	}
+	return errors.New(msg)
+}
+
+// tsdbIngestErrIDs maps the TSDB ingestion errors returned to clients to their error IDs.
+var tsdbIngestErrIDs = map[error]globalerror.ID{
+	storage.ErrOutOfBounds:                 globalerror.SampleOutOfBounds,
+	storage.ErrOutOfOrderSample:            globalerror.SampleOutOfOrder,
+	storage.ErrDuplicateSampleForTimestamp: globalerror.SampleDuplicateTimestamp,
 }
 
 func wrappedTSDBIngestExemplarErr(ingestErr error, timestamp model.Time, seriesLabels, exemplarLabels []mimirpb.LabelAdapter) error {
@@ -2281,6 +2595,52 @@ func (i *Ingester) MetricsMetadata(ctx context.Context, req *client.MetricsMetad
 	return &client.MetricsMetadataResponse{Metadata: userMetadata.toClientMetadata()}, nil
 }
 
+// MetricsMetadataStream is like MetricsMetadata, but streams the metadata in batches of bounded size.
+func (i *Ingester) MetricsMetadataStream(req *client.MetricsMetadataRequest, stream client.Ingester_MetricsMetadataStreamServer) error {
+	if err := i.checkRunning(); err != nil {
+		return err
+	}
+
+	userID, err := tenant.TenantID(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	userMetadata := i.getUserMetadata(userID)
+	if userMetadata == nil {
+		return nil
+	}
+
+	batch := make([]*mimirpb.MetricMetadata, 0, queryStreamBatchSize)
+	batchSizeBytes := 0
+	for _, m := range userMetadata.toClientMetadata() {
+		mSize := m.Size()
+
+		if (batchSizeBytes > 0 && batchSizeBytes+mSize > queryStreamBatchMessageSize) || len(batch) >= queryStreamBatchSize {
+			if err := client.SendMetricsMetadataStreamResponse(stream, &client.MetricsMetadataResponse{Metadata: batch}); err != nil {
+				return err
+			}
+
+			batchSizeBytes = 0
+			batch = batch[:0]
+		}
+
+		batch = append(batch, m)
+		batchSizeBytes += mSize
+	}
+
+	// Final flush any existing metadata.
+	if len(batch) > 0 {
+		return client.SendMetricsMetadataStreamResponse(stream, &client.MetricsMetadataResponse{Metadata: batch})
+	}
+	return nil
+}
+
+// RingAddr returns the address the ingester is registered with in the ring.
+func (i *Ingester) RingAddr() string {
+	return i.lifecycler.Addr
+}
+
 // CheckReady is the readiness handler used to indicate to k8s when the ingesters
 // are ready for the addition or removal of another ingester.
 func (i *Ingester) CheckReady(ctx context.Context) error {
@@ -128,6 +128,33 @@ func (i *ActivityTrackerWrapper) LabelValuesCardinality(request *client.LabelVal
 	return i.ing.LabelValuesCardinality(request, server)
 }
 
+func (i *ActivityTrackerWrapper) QueryExemplarsStream(request *client.ExemplarQueryRequest, server client.Ingester_QueryExemplarsStreamServer) error {
+	ix := i.tracker.Insert(func() string {
+		return requestActivity(server.Context(), "Ingester/QueryExemplarsStream", request)
+	})
+	defer i.tracker.Delete(ix)
+
+	return i.ing.QueryExemplarsStream(request, server)
+}
+
+func (i *ActivityTrackerWrapper) MetricsMetadataStream(request *client.MetricsMetadataRequest, server client.Ingester_MetricsMetadataStreamServer) error {
+	ix := i.tracker.Insert(func() string {
+		return requestActivity(server.Context(), "Ingester/MetricsMetadataStream", request)
+	})
+	defer i.tracker.Delete(ix)
+
+	return i.ing.MetricsMetadataStream(request, server)
+}
+
+func (i *ActivityTrackerWrapper) ActiveSeries(request *client.ActiveSeriesRequest, server client.Ingester_ActiveSeriesServer) error {
+	ix := i.tracker.Insert(func() string {
+		return requestActivity(server.Context(), "Ingester/ActiveSeries", request)
+	})
+	defer i.tracker.Delete(ix)
+
+	return i.ing.ActiveSeries(request, server)
+}
+
 func (i *ActivityTrackerWrapper) FlushHandler(w http.ResponseWriter, r *http.Request) {
 	ix := i.tracker.Insert(func() string {
 		return requestActivity(r.Context(), "Ingester/FlushHandler", nil)
@@ -146,6 +173,51 @@ func (i *ActivityTrackerWrapper) ShutdownHandler(w http.ResponseWriter, r *http.
 	i.ing.ShutdownHandler(w, r)
 }
 
+func (i *ActivityTrackerWrapper) PrepareShutdownHandler(w http.ResponseWriter, r *http.Request) {
+	ix := i.tracker.Insert(func() string {
+		return requestActivity(r.Context(), "Ingester/PrepareShutdownHandler", nil)
+	})
+	defer i.tracker.Delete(ix)
+
+	i.ing.PrepareShutdownHandler(w, r)
+}
+
+func (i *ActivityTrackerWrapper) ActiveSeriesCustomTrackersHandler(w http.ResponseWriter, r *http.Request) {
+	ix := i.tracker.Insert(func() string {
+		return requestActivity(r.Context(), "Ingester/ActiveSeriesCustomTrackersHandler", nil)
+	})
+	defer i.tracker.Delete(ix)
+
+	i.ing.ActiveSeriesCustomTrackersHandler(w, r)
+}
+
+func (i *ActivityTrackerWrapper) ActiveSeriesCustomTrackersStatusHandler(w http.ResponseWriter, r *http.Request) {
+	ix := i.tracker.Insert(func() string {
+		return requestActivity(r.Context(), "Ingester/ActiveSeriesCustomTrackersStatusHandler", nil)
+	})
+	defer i.tracker.Delete(ix)
+
+	i.ing.ActiveSeriesCustomTrackersStatusHandler(w, r)
+}
+
+func (i *ActivityTrackerWrapper) ActiveSeriesBreakdownHandler(w http.ResponseWriter, r *http.Request) {
+	ix := i.tracker.Insert(func() string {
+		return requestActivity(r.Context(), "Ingester/ActiveSeriesBreakdownHandler", nil)
+	})
+	defer i.tracker.Delete(ix)
+
+	i.ing.ActiveSeriesBreakdownHandler(w, r)
+}
+
+func (i *ActivityTrackerWrapper) ActiveSeriesMemoryHandler(w http.ResponseWriter, r *http.Request) {
+	ix := i.tracker.Insert(func() string {
+		return requestActivity(r.Context(), "Ingester/ActiveSeriesMemoryHandler", nil)
+	})
+	defer i.tracker.Delete(ix)
+
+	i.ing.ActiveSeriesMemoryHandler(w, r)
+}
+
 func requestActivity(ctx context.Context, name string, req interface{}) string {
 	userID, _ := tenant.TenantID(ctx)
 	traceID, _ := tracing.ExtractSampledTraceID(ctx)
@@ -13,6 +13,8 @@ import (
 	"github.com/grafana/dskit/kv"
 	"github.com/grafana/dskit/netutil"
 	"github.com/grafana/dskit/ring"
+
+	"github.com/grafana/mimir/pkg/util"
 )
 
 const (
@@ -38,6 +40,7 @@ type RingConfig struct {
 	InstanceInterfaceNames []string `yaml:"instance_interface_names" category:"advanced" doc:"default=[<private network interfaces>]"`
 	InstancePort           int      `yaml:"instance_port" category:"advanced"`
 	InstanceAddr           string   `yaml:"instance_addr" category:"advanced"`
+	InstanceAddrFamily     string   `yaml:"instance_addr_family" category:"advanced"`
 	InstanceZone           string   `yaml:"instance_availability_zone" category:"advanced"`
 
 	UnregisterOnShutdown bool `yaml:"unregister_on_shutdown" category:"advanced"`
@@ -82,6 +85,7 @@ func (cfg *RingConfig) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	f.Var((*flagext.StringSlice)(&cfg.InstanceInterfaceNames), prefix+"instance-interface-names", "List of network interface names to look up when finding the instance IP address.")
 	f.IntVar(&cfg.InstancePort, prefix+"instance-port", 0, "Port to advertise in the ring (defaults to -server.grpc-listen-port).")
 	f.StringVar(&cfg.InstanceAddr, prefix+"instance-addr", "", "IP address to advertise in the ring. Default is auto-detected.")
+	f.StringVar(&cfg.InstanceAddrFamily, prefix+"instance-addr-family", util.AddrFamilyIPv4, "IP address family to prefer when auto-detecting the address to advertise in the ring. "+util.AddrFamilyUsage)
 	f.StringVar(&cfg.InstanceZone, prefix+"instance-availability-zone", "", "The availability zone where this instance is running.")
 
 	f.BoolVar(&cfg.UnregisterOnShutdown, prefix+"unregister-on-shutdown", true, "Unregister from the ring upon clean shutdown. It can be useful to disable for rolling restarts with consistent naming in conjunction with -distributor.extend-writes=false.")
@@ -113,7 +117,12 @@ func (cfg *RingConfig) ToRingConfig() ring.Config {
 
 // ToLifecyclerConfig returns a ring.LifecyclerConfig based on the ingester
 // ring config.
-func (cfg *RingConfig) ToLifecyclerConfig() ring.LifecyclerConfig {
+func (cfg *RingConfig) ToLifecyclerConfig() (ring.LifecyclerConfig, error) {
+	instanceAddr, err := util.GetInstanceAddr(cfg.InstanceAddr, cfg.InstanceInterfaceNames, cfg.InstanceAddrFamily)
+	if err != nil {
+		return ring.LifecyclerConfig{}, err
+	}
+
 	// Configure lifecycler
 	lc := ring.LifecyclerConfig{}
 	flagext.DefaultValues(&lc)
@@ -130,10 +139,10 @@ func (cfg *RingConfig) ToLifecyclerConfig() ring.LifecyclerConfig {
 	lc.Zone = cfg.InstanceZone
 	lc.UnregisterOnShutdown = cfg.UnregisterOnShutdown
 	lc.ReadinessCheckRingHealth = cfg.ReadinessCheckRingHealth
-	lc.Addr = cfg.InstanceAddr
+	lc.Addr = util.LifecyclerAddr(instanceAddr)
 	lc.Port = cfg.InstancePort
 	lc.ID = cfg.InstanceID
 	lc.ListenPort = cfg.ListenPort
 
-	return lc
+	return lc, nil
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/grafana/dskit/flagext"
 	"github.com/grafana/dskit/ring"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRingConfig_DefaultConfigToLifecyclerConfig(t *testing.T) {
@@ -27,7 +28,13 @@ func TestRingConfig_DefaultConfigToLifecyclerConfig(t *testing.T) {
 	expected.MinReadyDuration = cfg.MinReadyDuration
 	expected.FinalSleep = cfg.FinalSleep
 
-	assert.Equal(t, expected, cfg.ToLifecyclerConfig())
+	// Skip the auto-detection of the instance address.
+	cfg.InstanceAddr = "1.2.3.4"
+	expected.Addr = cfg.InstanceAddr
+
+	actual, err := cfg.ToLifecyclerConfig()
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
 }
 
 func TestRingConfig_CustomConfigToLifecyclerConfig(t *testing.T) {
@@ -84,5 +91,19 @@ func TestRingConfig_CustomConfigToLifecyclerConfig(t *testing.T) {
 	expected.Addr = cfg.InstanceAddr
 	expected.ListenPort = cfg.ListenPort
 
-	assert.Equal(t, expected, cfg.ToLifecyclerConfig())
+	actual, err := cfg.ToLifecyclerConfig()
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+}
+
+func TestRingConfig_ToLifecyclerConfigWithIPv6Address(t *testing.T) {
+	cfg := RingConfig{}
+	flagext.DefaultValues(&cfg)
+	cfg.InstanceAddr = "2001:db8::1"
+	cfg.InstancePort = 9095
+
+	// The lifecycler joins the address and the port, so the IPv6 address must be enclosed in square brackets.
+	actual, err := cfg.ToLifecyclerConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "[2001:db8::1]", actual.Addr)
 }
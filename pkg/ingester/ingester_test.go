@@ -51,6 +51,8 @@ import (
 	"github.com/weaveworks/common/user"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/grafana/mimir/pkg/ingester/client"
 	"github.com/grafana/mimir/pkg/mimirpb"
@@ -79,16 +81,25 @@ func TestIngester_Push(t *testing.T) {
 	}
 	userID := "test"
 
+	withCreatedTimestamp := func(req *mimirpb.WriteRequest, createdTimestamp int64) *mimirpb.WriteRequest {
+		for _, ts := range req.Timeseries {
+			ts.CreatedTimestamp = createdTimestamp
+		}
+		return req
+	}
+
 	tests := map[string]struct {
-		reqs                      []*mimirpb.WriteRequest
-		expectedErr               error
-		expectedIngested          model.Matrix
-		expectedMetadataIngested  []*mimirpb.MetricMetadata
-		expectedExemplarsIngested []mimirpb.TimeSeries
-		expectedMetrics           string
-		additionalMetrics         []string
-		disableActiveSeries       bool
-		maxExemplars              int
+		reqs                                 []*mimirpb.WriteRequest
+		expectedErr                          error
+		expectedIngested                     model.Matrix
+		expectedMetadataIngested             []*mimirpb.MetricMetadata
+		expectedExemplarsIngested            []mimirpb.TimeSeries
+		expectedMetrics                      string
+		additionalMetrics                    []string
+		disableActiveSeries                  bool
+		maxExemplars                         int
+		duplicateTimestampPolicy             string
+		createdTimestampZeroIngestionEnabled bool
 	}{
 		"should succeed on valid series and metadata": {
 			reqs: []*mimirpb.WriteRequest{
@@ -497,6 +508,210 @@ func TestIngester_Push(t *testing.T) {
 				cortex_ingester_active_series{user="test"} 1
 			`,
 		},
+		"should drop without error a sample with same timestamp and different value if the policy is keep-first": {
+			reqs: []*mimirpb.WriteRequest{
+				mimirpb.ToWriteRequest(
+					[]labels.Labels{metricLabels},
+					[]mimirpb.Sample{{Value: 2, TimestampMs: 1575043969}},
+					nil,
+					nil,
+					mimirpb.API,
+				),
+				mimirpb.ToWriteRequest(
+					[]labels.Labels{metricLabels},
+					[]mimirpb.Sample{{Value: 1, TimestampMs: 1575043969}},
+					nil,
+					nil,
+					mimirpb.API,
+				),
+			},
+			duplicateTimestampPolicy: validation.DuplicateTimestampPolicyKeepFirst,
+			expectedErr:              nil,
+			expectedIngested: model.Matrix{
+				&model.SampleStream{Metric: metricLabelSet, Values: []model.SamplePair{{Value: 2, Timestamp: 1575043969}}},
+			},
+			additionalMetrics: []string{"cortex_ingester_coerced_duplicate_samples_total"},
+			expectedMetrics: `
+				# HELP cortex_ingester_ingested_samples_total The total number of samples ingested.
+				# TYPE cortex_ingester_ingested_samples_total counter
+				cortex_ingester_ingested_samples_total 1
+				# HELP cortex_ingester_ingested_samples_failures_total The total number of samples that errored on ingestion.
+				# TYPE cortex_ingester_ingested_samples_failures_total counter
+				cortex_ingester_ingested_samples_failures_total 0
+				# HELP cortex_ingester_memory_users The current number of users in memory.
+				# TYPE cortex_ingester_memory_users gauge
+				cortex_ingester_memory_users 1
+				# HELP cortex_ingester_memory_series The current number of series in memory.
+				# TYPE cortex_ingester_memory_series gauge
+				cortex_ingester_memory_series 1
+				# HELP cortex_ingester_memory_series_created_total The total number of series that were created per user.
+				# TYPE cortex_ingester_memory_series_created_total counter
+				cortex_ingester_memory_series_created_total{user="test"} 1
+				# HELP cortex_ingester_memory_series_removed_total The total number of series that were removed per user.
+				# TYPE cortex_ingester_memory_series_removed_total counter
+				cortex_ingester_memory_series_removed_total{user="test"} 0
+				# HELP cortex_ingester_active_series Number of currently active series per user.
+				# TYPE cortex_ingester_active_series gauge
+				cortex_ingester_active_series{user="test"} 1
+				# HELP cortex_ingester_coerced_duplicate_samples_total The total number of samples with the same timestamp as another sample of the series and a different value, which were dropped without error according to the duplicate timestamp policy, per user.
+				# TYPE cortex_ingester_coerced_duplicate_samples_total counter
+				cortex_ingester_coerced_duplicate_samples_total{user="test"} 1
+			`,
+		},
+		"should keep the last sample with same timestamp in the request if the policy is keep-last": {
+			reqs: []*mimirpb.WriteRequest{
+				{
+					Timeseries: []mimirpb.PreallocTimeseries{{TimeSeries: &mimirpb.TimeSeries{
+						Labels:  metricLabelAdapters,
+						Samples: []mimirpb.Sample{{Value: 1, TimestampMs: 1575043969}, {Value: 2, TimestampMs: 1575043969}, {Value: 3, TimestampMs: 1575043970}},
+					}}},
+					Source: mimirpb.API,
+				},
+				// The sample already stored in the head can't be rewritten.
+				mimirpb.ToWriteRequest(
+					[]labels.Labels{metricLabels},
+					[]mimirpb.Sample{{Value: 4, TimestampMs: 1575043970}},
+					nil,
+					nil,
+					mimirpb.API,
+				),
+			},
+			duplicateTimestampPolicy: validation.DuplicateTimestampPolicyKeepLast,
+			expectedErr:              nil,
+			expectedIngested: model.Matrix{
+				&model.SampleStream{Metric: metricLabelSet, Values: []model.SamplePair{{Value: 2, Timestamp: 1575043969}, {Value: 3, Timestamp: 1575043970}}},
+			},
+			additionalMetrics: []string{"cortex_ingester_coerced_duplicate_samples_total"},
+			expectedMetrics: `
+				# HELP cortex_ingester_ingested_samples_total The total number of samples ingested.
+				# TYPE cortex_ingester_ingested_samples_total counter
+				cortex_ingester_ingested_samples_total 2
+				# HELP cortex_ingester_ingested_samples_failures_total The total number of samples that errored on ingestion.
+				# TYPE cortex_ingester_ingested_samples_failures_total counter
+				cortex_ingester_ingested_samples_failures_total 0
+				# HELP cortex_ingester_memory_users The current number of users in memory.
+				# TYPE cortex_ingester_memory_users gauge
+				cortex_ingester_memory_users 1
+				# HELP cortex_ingester_memory_series The current number of series in memory.
+				# TYPE cortex_ingester_memory_series gauge
+				cortex_ingester_memory_series 1
+				# HELP cortex_ingester_memory_series_created_total The total number of series that were created per user.
+				# TYPE cortex_ingester_memory_series_created_total counter
+				cortex_ingester_memory_series_created_total{user="test"} 1
+				# HELP cortex_ingester_memory_series_removed_total The total number of series that were removed per user.
+				# TYPE cortex_ingester_memory_series_removed_total counter
+				cortex_ingester_memory_series_removed_total{user="test"} 0
+				# HELP cortex_ingester_active_series Number of currently active series per user.
+				# TYPE cortex_ingester_active_series gauge
+				cortex_ingester_active_series{user="test"} 1
+				# HELP cortex_ingester_coerced_duplicate_samples_total The total number of samples with the same timestamp as another sample of the series and a different value, which were dropped without error according to the duplicate timestamp policy, per user.
+				# TYPE cortex_ingester_coerced_duplicate_samples_total counter
+				cortex_ingester_coerced_duplicate_samples_total{user="test"} 2
+			`,
+		},
+		"should ingest a zero sample at the created timestamp if enabled": {
+			reqs: []*mimirpb.WriteRequest{
+				withCreatedTimestamp(mimirpb.ToWriteRequest(
+					[]labels.Labels{metricLabels},
+					[]mimirpb.Sample{{Value: 1, TimestampMs: 1575043969}},
+					nil,
+					nil,
+					mimirpb.API,
+				), 1575043960),
+				// The zero sample has already been ingested, and it's skipped without error.
+				withCreatedTimestamp(mimirpb.ToWriteRequest(
+					[]labels.Labels{metricLabels},
+					[]mimirpb.Sample{{Value: 2, TimestampMs: 1575043970}},
+					nil,
+					nil,
+					mimirpb.API,
+				), 1575043960),
+				// The counter has been reset.
+				withCreatedTimestamp(mimirpb.ToWriteRequest(
+					[]labels.Labels{metricLabels},
+					[]mimirpb.Sample{{Value: 1, TimestampMs: 1575043980}},
+					nil,
+					nil,
+					mimirpb.API,
+				), 1575043975),
+			},
+			createdTimestampZeroIngestionEnabled: true,
+			expectedErr:                          nil,
+			expectedIngested: model.Matrix{
+				&model.SampleStream{Metric: metricLabelSet, Values: []model.SamplePair{
+					{Value: 0, Timestamp: 1575043960},
+					{Value: 1, Timestamp: 1575043969},
+					{Value: 2, Timestamp: 1575043970},
+					{Value: 0, Timestamp: 1575043975},
+					{Value: 1, Timestamp: 1575043980},
+				}},
+			},
+			additionalMetrics: []string{"cortex_ingester_created_timestamp_zero_samples_total"},
+			expectedMetrics: `
+				# HELP cortex_ingester_ingested_samples_total The total number of samples ingested.
+				# TYPE cortex_ingester_ingested_samples_total counter
+				cortex_ingester_ingested_samples_total 3
+				# HELP cortex_ingester_ingested_samples_failures_total The total number of samples that errored on ingestion.
+				# TYPE cortex_ingester_ingested_samples_failures_total counter
+				cortex_ingester_ingested_samples_failures_total 0
+				# HELP cortex_ingester_memory_users The current number of users in memory.
+				# TYPE cortex_ingester_memory_users gauge
+				cortex_ingester_memory_users 1
+				# HELP cortex_ingester_memory_series The current number of series in memory.
+				# TYPE cortex_ingester_memory_series gauge
+				cortex_ingester_memory_series 1
+				# HELP cortex_ingester_memory_series_created_total The total number of series that were created per user.
+				# TYPE cortex_ingester_memory_series_created_total counter
+				cortex_ingester_memory_series_created_total{user="test"} 1
+				# HELP cortex_ingester_memory_series_removed_total The total number of series that were removed per user.
+				# TYPE cortex_ingester_memory_series_removed_total counter
+				cortex_ingester_memory_series_removed_total{user="test"} 0
+				# HELP cortex_ingester_active_series Number of currently active series per user.
+				# TYPE cortex_ingester_active_series gauge
+				cortex_ingester_active_series{user="test"} 1
+				# HELP cortex_ingester_created_timestamp_zero_samples_total The total number of zero samples ingested at the created timestamp of the series, per user.
+				# TYPE cortex_ingester_created_timestamp_zero_samples_total counter
+				cortex_ingester_created_timestamp_zero_samples_total{user="test"} 2
+			`,
+		},
+		"should ignore the created timestamp if the zero sample ingestion is disabled": {
+			reqs: []*mimirpb.WriteRequest{
+				withCreatedTimestamp(mimirpb.ToWriteRequest(
+					[]labels.Labels{metricLabels},
+					[]mimirpb.Sample{{Value: 1, TimestampMs: 1575043969}},
+					nil,
+					nil,
+					mimirpb.API,
+				), 1575043960),
+			},
+			expectedErr: nil,
+			expectedIngested: model.Matrix{
+				&model.SampleStream{Metric: metricLabelSet, Values: []model.SamplePair{{Value: 1, Timestamp: 1575043969}}},
+			},
+			expectedMetrics: `
+				# HELP cortex_ingester_ingested_samples_total The total number of samples ingested.
+				# TYPE cortex_ingester_ingested_samples_total counter
+				cortex_ingester_ingested_samples_total 1
+				# HELP cortex_ingester_ingested_samples_failures_total The total number of samples that errored on ingestion.
+				# TYPE cortex_ingester_ingested_samples_failures_total counter
+				cortex_ingester_ingested_samples_failures_total 0
+				# HELP cortex_ingester_memory_users The current number of users in memory.
+				# TYPE cortex_ingester_memory_users gauge
+				cortex_ingester_memory_users 1
+				# HELP cortex_ingester_memory_series The current number of series in memory.
+				# TYPE cortex_ingester_memory_series gauge
+				cortex_ingester_memory_series 1
+				# HELP cortex_ingester_memory_series_created_total The total number of series that were created per user.
+				# TYPE cortex_ingester_memory_series_created_total counter
+				cortex_ingester_memory_series_created_total{user="test"} 1
+				# HELP cortex_ingester_memory_series_removed_total The total number of series that were removed per user.
+				# TYPE cortex_ingester_memory_series_removed_total counter
+				cortex_ingester_memory_series_removed_total{user="test"} 0
+				# HELP cortex_ingester_active_series Number of currently active series per user.
+				# TYPE cortex_ingester_active_series gauge
+				cortex_ingester_active_series{user="test"} 1
+			`,
+		},
 		"should soft fail on exemplar with unknown series": {
 			maxExemplars: 1,
 			reqs: []*mimirpb.WriteRequest{
@@ -622,6 +837,10 @@ func TestIngester_Push(t *testing.T) {
 			cfg.ActiveSeriesMetricsEnabled = !testData.disableActiveSeries
 			limits := defaultLimitsTestConfig()
 			limits.MaxGlobalExemplarsPerUser = testData.maxExemplars
+			if testData.duplicateTimestampPolicy != "" {
+				limits.DuplicateTimestampPolicy = testData.duplicateTimestampPolicy
+			}
+			limits.CreatedTimestampZeroIngestionEnabled = testData.createdTimestampZeroIngestionEnabled
 
 			i, err := prepareIngesterWithBlocksStorageAndLimits(t, cfg, limits, "", registry)
 			require.NoError(t, err)
@@ -678,6 +897,27 @@ func TestIngester_Push(t *testing.T) {
 			require.NotNil(t, exemplarRes)
 			assert.Equal(t, testData.expectedExemplarsIngested, exemplarRes.Timeseries)
 
+			// The streaming API returns the same exemplars.
+			exemplarStream, err := client.NewInProcessClient(i).QueryExemplarsStream(ctx, &client.ExemplarQueryRequest{
+				StartTimestampMs: math.MinInt64,
+				EndTimestampMs:   math.MaxInt64,
+				Matchers: []*client.LabelMatchers{
+					{Matchers: []*client.LabelMatcher{{Type: client.REGEX_MATCH, Name: labels.MetricName, Value: ".*"}}},
+				},
+			})
+			require.NoError(t, err)
+
+			var streamedExemplars []mimirpb.TimeSeries
+			for {
+				resp, err := exemplarStream.Recv()
+				if err == io.EOF {
+					break
+				}
+				require.NoError(t, err)
+				streamedExemplars = append(streamedExemplars, resp.Timeseries...)
+			}
+			assert.Equal(t, testData.expectedExemplarsIngested, streamedExemplars)
+
 			// Read back metadata to see what has been really ingested.
 			mres, err := i.MetricsMetadata(ctx, &client.MetricsMetadataRequest{})
 
@@ -1656,6 +1896,75 @@ func BenchmarkIngester_LabelValuesCardinality(b *testing.B) {
 	})
 }
 
+func TestIngester_ActiveSeries(t *testing.T) {
+	series := []series{
+		{labels.FromStrings(labels.MetricName, "metric_0", "status", "500"), 1, 100000},
+		{labels.FromStrings(labels.MetricName, "metric_0", "status", "200"), 1, 110000},
+		{labels.FromStrings(labels.MetricName, "metric_1", "env", "prod"), 2, 100000},
+	}
+
+	tests := map[string]struct {
+		matchers       []*client.LabelMatcher
+		expectedSeries []labels.Labels
+	}{
+		"no matchers": {
+			expectedSeries: []labels.Labels{series[0].lbls, series[1].lbls, series[2].lbls},
+		},
+		"matchers": {
+			matchers:       []*client.LabelMatcher{{Type: client.EQUAL, Name: labels.MetricName, Value: "metric_0"}},
+			expectedSeries: []labels.Labels{series[0].lbls, series[1].lbls},
+		},
+		"no matching series": {
+			matchers: []*client.LabelMatcher{{Type: client.EQUAL, Name: labels.MetricName, Value: "metric_2"}},
+		},
+	}
+
+	i := requireActiveIngesterWithBlocksStorage(t, defaultIngesterTestConfig(t), nil)
+	ctx := pushSeriesToIngester(t, series, i)
+
+	for tName, tc := range tests {
+		t.Run(tName, func(t *testing.T) {
+			s := &mockActiveSeriesServer{context: ctx}
+			require.NoError(t, i.ActiveSeries(&client.ActiveSeriesRequest{Matchers: tc.matchers}, s))
+
+			var actual []labels.Labels
+			for _, res := range s.SentResponses {
+				for _, m := range res.Metric {
+					actual = append(actual, mimirpb.FromLabelAdaptersToLabels(m.Labels))
+				}
+			}
+			assert.ElementsMatch(t, tc.expectedSeries, actual)
+		})
+	}
+
+	t.Run("active series tracking disabled", func(t *testing.T) {
+		cfg := defaultIngesterTestConfig(t)
+		cfg.ActiveSeriesMetricsEnabled = false
+		i := requireActiveIngesterWithBlocksStorage(t, cfg, nil)
+
+		err := i.ActiveSeries(&client.ActiveSeriesRequest{}, &mockActiveSeriesServer{context: ctx})
+		require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+}
+
+type mockActiveSeriesServer struct {
+	client.Ingester_ActiveSeriesServer
+	SentResponses []client.ActiveSeriesResponse
+	context       context.Context
+}
+
+func (m *mockActiveSeriesServer) Send(response *client.ActiveSeriesResponse) error {
+	// The ingester reuses the slice of the response once sent.
+	metric := make([]*mimirpb.Metric, len(response.Metric))
+	copy(metric, response.Metric)
+	m.SentResponses = append(m.SentResponses, client.ActiveSeriesResponse{Metric: metric})
+	return nil
+}
+
+func (m *mockActiveSeriesServer) Context() context.Context {
+	return m.context
+}
+
 type series struct {
 	lbls      labels.Labels
 	value     float64
@@ -2237,6 +2546,7 @@ func TestIngester_QueryStream(t *testing.T) {
 
 	tests := map[string]struct {
 		streamType         QueryStreamType
+		protocolVersion    uint32
 		numShards          int
 		expectedStreamType QueryStreamType
 	}{
@@ -2262,6 +2572,17 @@ func TestIngester_QueryStream(t *testing.T) {
 			numShards:          16,
 			expectedStreamType: QueryStreamChunks,
 		},
+		"should query chunks when the client supports the chunks protocol, regardless of the configured stream type": {
+			streamType:         QueryStreamSamples,
+			protocolVersion:    client.QueryStreamProtocolVersionChunks,
+			expectedStreamType: QueryStreamChunks,
+		},
+		"should support sharding when the client supports the chunks protocol": {
+			streamType:         QueryStreamSamples,
+			protocolVersion:    client.QueryStreamProtocolVersionChunks,
+			numShards:          16,
+			expectedStreamType: QueryStreamChunks,
+		},
 	}
 
 	for testName, testData := range tests {
@@ -2310,6 +2631,7 @@ func TestIngester_QueryStream(t *testing.T) {
 								ShardCount: uint64(testData.numShards),
 							}.LabelValue()},
 						},
+						StreamingProtocolVersion: testData.protocolVersion,
 					})
 
 					require.NoError(t, err)
@@ -2317,9 +2639,10 @@ func TestIngester_QueryStream(t *testing.T) {
 				}
 			} else {
 				receivedSeries, err := runQueryAndSaveResponse(&client.QueryRequest{
-					StartTimestampMs: math.MinInt64,
-					EndTimestampMs:   math.MaxInt64,
-					Matchers:         []*client.LabelMatcher{{Type: client.EQUAL, Name: model.MetricNameLabel, Value: "foo"}},
+					StartTimestampMs:         math.MinInt64,
+					EndTimestampMs:           math.MaxInt64,
+					Matchers:                 []*client.LabelMatcher{{Type: client.EQUAL, Name: model.MetricNameLabel, Value: "foo"}},
+					StreamingProtocolVersion: testData.protocolVersion,
 				})
 
 				require.NoError(t, err)
@@ -3613,12 +3936,19 @@ func TestIngester_ForFlush(t *testing.T) {
 	require.NoError(t, err)
 	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
 
-	// Our single sample should be reloaded from WAL
-	verifyCompactedHead(t, i, false)
-	i.Flush()
+	// The TSDB is found on disk, but not opened on startup.
+	users, err := i.LocalTSDBUsers()
+	require.NoError(t, err)
+	require.Equal(t, []string{userID}, users)
+	require.Nil(t, i.getTSDB(userID))
 
-	// Head should be empty after flushing.
-	verifyCompactedHead(t, i, true)
+	// Our single sample should be reloaded from WAL, compacted and shipped.
+	shipped, err := i.FlushUser(context.Background(), userID)
+	require.NoError(t, err)
+	require.Equal(t, 1, shipped)
+
+	// The TSDB should be closed after flushing.
+	require.Nil(t, i.getTSDB(userID))
 
 	// Verify that block has been shipped.
 	require.NoError(t, testutil.GatherAndCompare(reg, bytes.NewBufferString(`
@@ -4724,6 +5054,53 @@ func TestIngesterPurgeMetadata(t *testing.T) {
 	}
 }
 
+func TestIngester_MetricsMetadataStream(t *testing.T) {
+	ing, err := prepareIngesterWithBlocksStorage(t, defaultIngesterTestConfig(t), nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), ing))
+	defer services.StopAndAwaitTerminated(context.Background(), ing) //nolint:errcheck
+
+	// Wait until the ingester is healthy
+	test.Poll(t, 100*time.Millisecond, 1, func() interface{} {
+		return ing.lifecycler.HealthyInstancesCount()
+	})
+
+	// Push more metadata than fit in a single batch.
+	userIDs, testData := pushTestMetadata(t, ing, 100, 3)
+
+	for _, userID := range userIDs {
+		ctx := user.InjectOrgID(context.Background(), userID)
+
+		s, err := client.NewInProcessClient(ing).MetricsMetadataStream(ctx, &client.MetricsMetadataRequest{})
+		require.NoError(t, err)
+
+		var (
+			numResponses int
+			metadata     []*mimirpb.MetricMetadata
+		)
+		for {
+			resp, err := s.Recv()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(resp.Metadata), queryStreamBatchSize)
+
+			numResponses++
+			metadata = append(metadata, resp.Metadata...)
+		}
+
+		assert.Equal(t, 3, numResponses)
+		assert.ElementsMatch(t, testData[userID], metadata)
+	}
+
+	// Nothing is streamed for a tenant without metadata.
+	s, err := client.NewInProcessClient(ing).MetricsMetadataStream(user.InjectOrgID(context.Background(), "unknown"), &client.MetricsMetadataRequest{})
+	require.NoError(t, err)
+	_, err = s.Recv()
+	assert.Equal(t, io.EOF, err)
+}
+
 func TestIngesterMetadataMetrics(t *testing.T) {
 	reg := prometheus.NewPedanticRegistry()
 	cfg := defaultIngesterTestConfig(t)
@@ -5238,6 +5615,75 @@ func TestIngesterActiveSeries(t *testing.T) {
 				require.NoError(t, testutil.GatherAndCompare(gatherer, strings.NewReader(""), metricNames...))
 			},
 		},
+		"should track the active series churn": {
+			test: func(t *testing.T, ingester *Ingester, gatherer prometheus.Gatherer) {
+				churnMetricNames := []string{
+					"cortex_ingester_active_series_created_total",
+					"cortex_ingester_active_series_expired_total",
+					"cortex_ingester_active_series_custom_tracker_created_total",
+					"cortex_ingester_active_series_custom_tracker_expired_total",
+				}
+
+				firstPushTime := time.Now()
+				for _, req := range []*mimirpb.WriteRequest{
+					req(metricLabelsBoolTrue, firstPushTime.Add(-time.Minute)),
+					req(metricLabelsBoolFalse, firstPushTime.Add(-time.Minute)),
+				} {
+					ctx := user.InjectOrgID(context.Background(), userID)
+					_, err := ingester.Push(ctx, req)
+					require.NoError(t, err)
+				}
+
+				ingester.updateActiveSeries(firstPushTime)
+
+				expectedMetrics := `
+					# HELP cortex_ingester_active_series_created_total The total number of series which became active per user.
+					# TYPE cortex_ingester_active_series_created_total counter
+					cortex_ingester_active_series_created_total{user="test"} 2
+					# HELP cortex_ingester_active_series_expired_total The total number of active series which expired after the idle timeout per user.
+					# TYPE cortex_ingester_active_series_expired_total counter
+					cortex_ingester_active_series_expired_total{user="test"} 0
+					# HELP cortex_ingester_active_series_custom_tracker_created_total The total number of series matching the label matchers of the custom tracker which became active per user.
+					# TYPE cortex_ingester_active_series_custom_tracker_created_total counter
+					cortex_ingester_active_series_custom_tracker_created_total{name="bool_is_false",user="test"} 1
+					cortex_ingester_active_series_custom_tracker_created_total{name="bool_is_true",user="test"} 1
+					# HELP cortex_ingester_active_series_custom_tracker_expired_total The total number of active series matching the label matchers of the custom tracker which expired after the idle timeout per user.
+					# TYPE cortex_ingester_active_series_custom_tracker_expired_total counter
+					cortex_ingester_active_series_custom_tracker_expired_total{name="bool_is_false",user="test"} 0
+					cortex_ingester_active_series_custom_tracker_expired_total{name="bool_is_true",user="test"} 0
+				`
+				require.NoError(t, testutil.GatherAndCompare(gatherer, strings.NewReader(expectedMetrics), churnMetricNames...))
+
+				// Only the series with bool="true" is pushed again, so the other one expires.
+				time.Sleep(time.Millisecond)
+				secondPushTime := time.Now()
+				time.Sleep(time.Millisecond)
+
+				ctx := user.InjectOrgID(context.Background(), userID)
+				_, err := ingester.Push(ctx, req(metricLabelsBoolTrue, secondPushTime))
+				require.NoError(t, err)
+
+				ingester.updateActiveSeries(secondPushTime.Add(ingester.cfg.ActiveSeriesMetricsIdleTimeout))
+
+				expectedMetrics = `
+					# HELP cortex_ingester_active_series_created_total The total number of series which became active per user.
+					# TYPE cortex_ingester_active_series_created_total counter
+					cortex_ingester_active_series_created_total{user="test"} 2
+					# HELP cortex_ingester_active_series_expired_total The total number of active series which expired after the idle timeout per user.
+					# TYPE cortex_ingester_active_series_expired_total counter
+					cortex_ingester_active_series_expired_total{user="test"} 1
+					# HELP cortex_ingester_active_series_custom_tracker_created_total The total number of series matching the label matchers of the custom tracker which became active per user.
+					# TYPE cortex_ingester_active_series_custom_tracker_created_total counter
+					cortex_ingester_active_series_custom_tracker_created_total{name="bool_is_false",user="test"} 1
+					cortex_ingester_active_series_custom_tracker_created_total{name="bool_is_true",user="test"} 1
+					# HELP cortex_ingester_active_series_custom_tracker_expired_total The total number of active series matching the label matchers of the custom tracker which expired after the idle timeout per user.
+					# TYPE cortex_ingester_active_series_custom_tracker_expired_total counter
+					cortex_ingester_active_series_custom_tracker_expired_total{name="bool_is_false",user="test"} 1
+					cortex_ingester_active_series_custom_tracker_expired_total{name="bool_is_true",user="test"} 0
+				`
+				require.NoError(t, testutil.GatherAndCompare(gatherer, strings.NewReader(expectedMetrics), churnMetricNames...))
+			},
+		},
 		"successful push, active series disabled": {
 			disableActiveSeries: true,
 			test: func(t *testing.T, ingester *Ingester, gatherer prometheus.Gatherer) {
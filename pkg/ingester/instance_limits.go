@@ -5,14 +5,18 @@
 
 package ingester
 
-import "github.com/pkg/errors"
+import (
+	"github.com/pkg/errors"
+
+	"github.com/grafana/mimir/pkg/util/globalerror"
+)
 
 var (
 	// We don't include values in the message to avoid leaking Mimir cluster configuration to users.
-	errMaxSamplesPushRateLimitReached = errors.New("cannot push more samples: ingester's samples push rate limit reached")
-	errMaxUsersLimitReached           = errors.New("cannot create TSDB: ingesters's max tenants limit reached")
-	errMaxSeriesLimitReached          = errors.New("cannot add series: ingesters's max series limit reached")
-	errTooManyInflightPushRequests    = errors.New("cannot push: too many inflight push requests in ingester")
+	errMaxSamplesPushRateLimitReached = errors.New(globalerror.IngesterMaxIngestionRate.Message("cannot push more samples: ingester's samples push rate limit reached"))
+	errMaxUsersLimitReached           = errors.New(globalerror.IngesterMaxTenants.Message("cannot create TSDB: ingesters's max tenants limit reached"))
+	errMaxSeriesLimitReached          = errors.New(globalerror.IngesterMaxInMemorySeries.Message("cannot add series: ingesters's max series limit reached"))
+	errTooManyInflightPushRequests    = errors.New(globalerror.IngesterMaxInflightPushes.Message("cannot push: too many inflight push requests in ingester"))
 )
 
 // InstanceLimits describes limits used by ingester. Reaching any of these will result in Push method to return
@@ -12,6 +12,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/globalerror"
 	util_math "github.com/grafana/mimir/pkg/util/math"
 	"github.com/grafana/mimir/pkg/util/validation"
 )
@@ -20,6 +21,7 @@ var (
 	errMaxSeriesPerMetricLimitExceeded   = errors.New("per-metric series limit exceeded")
 	errMaxMetadataPerMetricLimitExceeded = errors.New("per-metric metadata limit exceeded")
 	errMaxSeriesPerUserLimitExceeded     = errors.New("per-user series limit exceeded")
+	errMaxSeriesCreatedPerHourExceeded   = errors.New("per-user series created per hour limit exceeded")
 	errMaxMetadataPerUserLimitExceeded   = errors.New("per-user metric metadata limit exceeded")
 )
 
@@ -84,6 +86,16 @@ func (l *Limiter) AssertMaxSeriesPerUser(userID string, series int) error {
 	return errMaxSeriesPerUserLimitExceeded
 }
 
+// AssertMaxSeriesCreatedPerHour limit has not been reached compared to the number
+// of series created in the last hour and returns an error if so.
+func (l *Limiter) AssertMaxSeriesCreatedPerHour(userID string, created int) error {
+	if actualLimit := l.maxSeriesCreatedPerHour(userID); created < actualLimit {
+		return nil
+	}
+
+	return errMaxSeriesCreatedPerHourExceeded
+}
+
 // AssertMaxMetricsWithMetadataPerUser limit has not been reached compared to the current
 // number of metrics with metadata in input and returns an error if so.
 func (l *Limiter) AssertMaxMetricsWithMetadataPerUser(userID string, metrics int) error {
@@ -102,6 +114,8 @@ func (l *Limiter) FormatError(userID string, err error) error {
 		return l.formatMaxSeriesPerUserError(userID)
 	case errMaxSeriesPerMetricLimitExceeded:
 		return l.formatMaxSeriesPerMetricError(userID)
+	case errMaxSeriesCreatedPerHourExceeded:
+		return l.formatMaxSeriesCreatedPerHourError(userID)
 	case errMaxMetadataPerUserLimitExceeded:
 		return l.formatMaxMetadataPerUserError(userID)
 	case errMaxMetadataPerMetricLimitExceeded:
@@ -115,32 +129,40 @@ func (l *Limiter) formatMaxSeriesPerUserError(userID string) error {
 	actualLimit := l.maxSeriesPerUser(userID)
 	globalLimit := l.limits.MaxGlobalSeriesPerUser(userID)
 
-	return fmt.Errorf("per-user series limit of %d exceeded, please contact administrator to raise it (per-ingester local limit: %d)",
-		globalLimit, actualLimit)
+	return errors.New(globalerror.MaxSeriesPerUser.Message(fmt.Sprintf("per-user series limit of %d exceeded, please contact administrator to raise it (per-ingester local limit: %d)",
+		globalLimit, actualLimit)))
 }
 
 func (l *Limiter) formatMaxSeriesPerMetricError(userID string) error {
 	actualLimit := l.maxSeriesPerMetric(userID)
 	globalLimit := l.limits.MaxGlobalSeriesPerMetric(userID)
 
-	return fmt.Errorf("per-metric series limit of %d exceeded, please contact administrator to raise it (per-ingester local limit: %d)",
-		globalLimit, actualLimit)
+	return errors.New(globalerror.MaxSeriesPerMetric.Message(fmt.Sprintf("per-metric series limit of %d exceeded, please contact administrator to raise it (per-ingester local limit: %d)",
+		globalLimit, actualLimit)))
+}
+
+func (l *Limiter) formatMaxSeriesCreatedPerHourError(userID string) error {
+	actualLimit := l.maxSeriesCreatedPerHour(userID)
+	globalLimit := l.limits.MaxSeriesCreatedPerHour(userID)
+
+	return errors.New(globalerror.MaxSeriesCreatedPerHour.Message(fmt.Sprintf("per-user limit of %d series created per hour exceeded, please contact administrator to raise it (per-ingester local limit: %d)",
+		globalLimit, actualLimit)))
 }
 
 func (l *Limiter) formatMaxMetadataPerUserError(userID string) error {
 	actualLimit := l.maxMetadataPerUser(userID)
 	globalLimit := l.limits.MaxGlobalMetricsWithMetadataPerUser(userID)
 
-	return fmt.Errorf("per-user metric metadata limit of %d exceeded, please contact administrator to raise it (per-ingester local limit: %d)",
-		globalLimit, actualLimit)
+	return errors.New(globalerror.MaxMetadataPerUser.Message(fmt.Sprintf("per-user metric metadata limit of %d exceeded, please contact administrator to raise it (per-ingester local limit: %d)",
+		globalLimit, actualLimit)))
 }
 
 func (l *Limiter) formatMaxMetadataPerMetricError(userID string) error {
 	actualLimit := l.maxMetadataPerMetric(userID)
 	globalLimit := l.limits.MaxGlobalMetadataPerMetric(userID)
 
-	return fmt.Errorf("per-metric metadata limit of %d exceeded, please contact administrator to raise it (per-ingester local limit: %d)",
-		globalLimit, actualLimit)
+	return errors.New(globalerror.MaxMetadataPerMetric.Message(fmt.Sprintf("per-metric metadata limit of %d exceeded, please contact administrator to raise it (per-ingester local limit: %d)",
+		globalLimit, actualLimit)))
 }
 
 func (l *Limiter) maxSeriesPerMetric(userID string) int {
@@ -155,6 +177,10 @@ func (l *Limiter) maxSeriesPerUser(userID string) int {
 	return l.convertGlobalToLocalLimitOrUnlimited(userID, l.limits.MaxGlobalSeriesPerUser)
 }
 
+func (l *Limiter) maxSeriesCreatedPerHour(userID string) int {
+	return l.convertGlobalToLocalLimitOrUnlimited(userID, l.limits.MaxSeriesCreatedPerHour)
+}
+
 func (l *Limiter) maxMetadataPerUser(userID string) int {
 	return l.convertGlobalToLocalLimitOrUnlimited(userID, l.limits.MaxGlobalMetricsWithMetadataPerUser)
 }
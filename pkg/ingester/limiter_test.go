@@ -340,6 +340,60 @@ func TestLimiter_AssertMaxSeriesPerUser(t *testing.T) {
 	}
 }
 
+func TestLimiter_AssertMaxSeriesCreatedPerHour(t *testing.T) {
+	tests := map[string]struct {
+		maxSeriesCreatedPerHour int
+		ringReplicationFactor   int
+		ringIngesterCount       int
+		created                 int
+		expected                error
+	}{
+		"limit is disabled": {
+			maxSeriesCreatedPerHour: 0,
+			ringReplicationFactor:   1,
+			ringIngesterCount:       1,
+			created:                 100,
+			expected:                nil,
+		},
+		"number of series created in the last hour is below the limit": {
+			maxSeriesCreatedPerHour: 1000,
+			ringReplicationFactor:   3,
+			ringIngesterCount:       10,
+			created:                 299,
+			expected:                nil,
+		},
+		"number of series created in the last hour is above the limit": {
+			maxSeriesCreatedPerHour: 1000,
+			ringReplicationFactor:   3,
+			ringIngesterCount:       10,
+			created:                 300,
+			expected:                errMaxSeriesCreatedPerHourExceeded,
+		},
+	}
+
+	for testName, testData := range tests {
+		testData := testData
+
+		t.Run(testName, func(t *testing.T) {
+			// Mock the ring
+			ring := &ringCountMock{}
+			ring.On("HealthyInstancesCount").Return(testData.ringIngesterCount)
+			ring.On("ZonesCount").Return(1)
+
+			// Mock limits
+			limits, err := validation.NewOverrides(validation.Limits{
+				MaxSeriesCreatedPerHour: testData.maxSeriesCreatedPerHour,
+			}, nil)
+			require.NoError(t, err)
+
+			limiter := NewLimiter(limits, ring, testData.ringReplicationFactor, false)
+			actual := limiter.AssertMaxSeriesCreatedPerHour("test", testData.created)
+
+			assert.Equal(t, testData.expected, actual)
+		})
+	}
+}
+
 func TestLimiter_AssertMaxMetricsWithMetadataPerUser(t *testing.T) {
 	tests := map[string]struct {
 		maxGlobalMetadataPerUser int
@@ -406,22 +460,26 @@ func TestLimiter_FormatError(t *testing.T) {
 		MaxGlobalSeriesPerMetric:            20,
 		MaxGlobalMetricsWithMetadataPerUser: 10,
 		MaxGlobalMetadataPerMetric:          3,
+		MaxSeriesCreatedPerHour:             60,
 	}, nil)
 	require.NoError(t, err)
 
 	limiter := NewLimiter(limits, ring, 3, false)
 
 	actual := limiter.FormatError("user-1", errMaxSeriesPerUserLimitExceeded)
-	assert.EqualError(t, actual, "per-user series limit of 100 exceeded, please contact administrator to raise it (per-ingester local limit: 100)")
+	assert.EqualError(t, actual, "per-user series limit of 100 exceeded, please contact administrator to raise it (per-ingester local limit: 100) (err-mimir-max-series-per-user)")
 
 	actual = limiter.FormatError("user-1", errMaxSeriesPerMetricLimitExceeded)
-	assert.EqualError(t, actual, "per-metric series limit of 20 exceeded, please contact administrator to raise it (per-ingester local limit: 20)")
+	assert.EqualError(t, actual, "per-metric series limit of 20 exceeded, please contact administrator to raise it (per-ingester local limit: 20) (err-mimir-max-series-per-metric)")
 
 	actual = limiter.FormatError("user-1", errMaxMetadataPerUserLimitExceeded)
-	assert.EqualError(t, actual, "per-user metric metadata limit of 10 exceeded, please contact administrator to raise it (per-ingester local limit: 10)")
+	assert.EqualError(t, actual, "per-user metric metadata limit of 10 exceeded, please contact administrator to raise it (per-ingester local limit: 10) (err-mimir-max-metadata-per-user)")
 
 	actual = limiter.FormatError("user-1", errMaxMetadataPerMetricLimitExceeded)
-	assert.EqualError(t, actual, "per-metric metadata limit of 3 exceeded, please contact administrator to raise it (per-ingester local limit: 3)")
+	assert.EqualError(t, actual, "per-metric metadata limit of 3 exceeded, please contact administrator to raise it (per-ingester local limit: 3) (err-mimir-max-metadata-per-metric)")
+
+	actual = limiter.FormatError("user-1", errMaxSeriesCreatedPerHourExceeded)
+	assert.EqualError(t, actual, "per-user limit of 60 series created per hour exceeded, please contact administrator to raise it (per-ingester local limit: 60) (err-mimir-max-series-created-per-hour)")
 
 	input := errors.New("unknown error")
 	actual = limiter.FormatError("user-1", input)
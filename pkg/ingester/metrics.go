@@ -32,7 +32,16 @@ type ingesterMetrics struct {
 
 	activeSeriesPerUser               *prometheus.GaugeVec
 	activeSeriesCustomTrackersPerUser *prometheus.GaugeVec
-	activeSeriesCustomTrackerNames    []string
+
+	activeSeriesCustomTrackerEvaluations       *prometheus.CounterVec
+	activeSeriesCustomTrackerMatches           *prometheus.CounterVec
+	activeSeriesCustomTrackerEvaluationSeconds *prometheus.CounterVec
+	activeSeriesCustomTrackerNames             []string
+
+	activeSeriesCreatedPerUser              *prometheus.CounterVec
+	activeSeriesExpiredPerUser              *prometheus.CounterVec
+	activeSeriesCustomTrackerCreatedPerUser *prometheus.CounterVec
+	activeSeriesCustomTrackerExpiredPerUser *prometheus.CounterVec
 
 	// Global limit metrics
 	maxUsersGauge           prometheus.GaugeFunc
@@ -49,6 +58,19 @@ type ingesterMetrics struct {
 	appenderAddDuration    prometheus.Histogram
 	appenderCommitDuration prometheus.Histogram
 	idleTsdbChecks         *prometheus.CounterVec
+
+	// Blocks shipping metrics.
+	shipperUploadedBytes          *prometheus.CounterVec
+	shipperUploadThrottledSeconds *prometheus.CounterVec
+
+	// Samples dropped according to the tenant's duplicate timestamp policy.
+	coercedDuplicateSamples *prometheus.CounterVec
+
+	// Zero samples ingested at the created timestamp of the series.
+	createdTimestampZeroSamples *prometheus.CounterVec
+
+	// Series churn.
+	seriesCreatedLastHour *prometheus.GaugeVec
 }
 
 func newIngesterMetrics(
@@ -220,6 +242,44 @@ func newIngesterMetrics(
 			Name: "cortex_ingester_active_series_custom_tracker",
 			Help: "Number of currently active series matching a pre-configured label matchers per user.",
 		}, []string{"user", "name"}),
+
+		// Not registered automatically, but only if activeSeriesEnabled is true.
+		activeSeriesCustomTrackerEvaluations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ingester_active_series_custom_tracker_evaluations_total",
+			Help: "The total number of new active series evaluated against the label matchers of the custom tracker per user.",
+		}, []string{"user", "name"}),
+		// Not registered automatically, but only if activeSeriesEnabled is true.
+		activeSeriesCustomTrackerMatches: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ingester_active_series_custom_tracker_matches_total",
+			Help: "The total number of new active series matching the label matchers of the custom tracker per user.",
+		}, []string{"user", "name"}),
+		// Not registered automatically, but only if activeSeriesEnabled is true.
+		activeSeriesCustomTrackerEvaluationSeconds: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ingester_active_series_custom_tracker_evaluation_seconds_total",
+			Help: "The total time spent evaluating new active series against the label matchers of the custom tracker per user.",
+		}, []string{"user", "name"}),
+
+		// Not registered automatically, but only if activeSeriesEnabled is true.
+		activeSeriesCreatedPerUser: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ingester_active_series_created_total",
+			Help: "The total number of series which became active per user.",
+		}, []string{"user"}),
+		// Not registered automatically, but only if activeSeriesEnabled is true.
+		activeSeriesExpiredPerUser: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ingester_active_series_expired_total",
+			Help: "The total number of active series which expired after the idle timeout per user.",
+		}, []string{"user"}),
+		// Not registered automatically, but only if activeSeriesEnabled is true.
+		activeSeriesCustomTrackerCreatedPerUser: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ingester_active_series_custom_tracker_created_total",
+			Help: "The total number of series matching the label matchers of the custom tracker which became active per user.",
+		}, []string{"user", "name"}),
+		// Not registered automatically, but only if activeSeriesEnabled is true.
+		activeSeriesCustomTrackerExpiredPerUser: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ingester_active_series_custom_tracker_expired_total",
+			Help: "The total number of active series matching the label matchers of the custom tracker which expired after the idle timeout per user.",
+		}, []string{"user", "name"}),
+
 		// activeSeriesCustomTrackerNames contains all the values for the `name` label of activeSeriesCustomTrackersPerUser,
 		// so we can delete all the labels for each user when needed.
 		activeSeriesCustomTrackerNames: activeSeriesCustomTrackerNames,
@@ -250,11 +310,41 @@ func newIngesterMetrics(
 		}),
 
 		idleTsdbChecks: idleTsdbChecks,
+
+		shipperUploadedBytes: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ingester_shipper_uploaded_bytes_total",
+			Help: "The total number of bytes of blocks uploaded to the storage per user.",
+		}, []string{"user"}),
+		shipperUploadThrottledSeconds: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ingester_shipper_upload_throttled_seconds_total",
+			Help: "The total time spent waiting on the shipping bandwidth limits while uploading blocks to the storage per user.",
+		}, []string{"user"}),
+
+		coercedDuplicateSamples: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ingester_coerced_duplicate_samples_total",
+			Help: "The total number of samples with the same timestamp as another sample of the series and a different value, which were dropped without error according to the duplicate timestamp policy, per user.",
+		}, []string{"user"}),
+		createdTimestampZeroSamples: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ingester_created_timestamp_zero_samples_total",
+			Help: "The total number of zero samples ingested at the created timestamp of the series, per user.",
+		}, []string{"user"}),
+
+		seriesCreatedLastHour: promauto.With(r).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_ingester_series_created_last_hour",
+			Help: "The number of series created in the last hour per user.",
+		}, []string{"user"}),
 	}
 
 	if activeSeriesEnabled && r != nil {
 		r.MustRegister(m.activeSeriesPerUser)
 		r.MustRegister(m.activeSeriesCustomTrackersPerUser)
+		r.MustRegister(m.activeSeriesCustomTrackerEvaluations)
+		r.MustRegister(m.activeSeriesCustomTrackerMatches)
+		r.MustRegister(m.activeSeriesCustomTrackerEvaluationSeconds)
+		r.MustRegister(m.activeSeriesCreatedPerUser)
+		r.MustRegister(m.activeSeriesExpiredPerUser)
+		r.MustRegister(m.activeSeriesCustomTrackerCreatedPerUser)
+		r.MustRegister(m.activeSeriesCustomTrackerExpiredPerUser)
 	}
 
 	return m
@@ -264,8 +354,25 @@ func (m *ingesterMetrics) deletePerUserMetrics(userID string) {
 	m.memMetadataCreatedTotal.DeleteLabelValues(userID)
 	m.memMetadataRemovedTotal.DeleteLabelValues(userID)
 	m.activeSeriesPerUser.DeleteLabelValues(userID)
-	for _, name := range m.activeSeriesCustomTrackerNames {
+	m.activeSeriesCreatedPerUser.DeleteLabelValues(userID)
+	m.activeSeriesExpiredPerUser.DeleteLabelValues(userID)
+	m.shipperUploadedBytes.DeleteLabelValues(userID)
+	m.shipperUploadThrottledSeconds.DeleteLabelValues(userID)
+	m.coercedDuplicateSamples.DeleteLabelValues(userID)
+	m.createdTimestampZeroSamples.DeleteLabelValues(userID)
+	m.seriesCreatedLastHour.DeleteLabelValues(userID)
+	m.deleteActiveSeriesCustomTrackerMetrics(userID, m.activeSeriesCustomTrackerNames)
+}
+
+// deleteActiveSeriesCustomTrackerMetrics deletes the metrics of the input custom trackers of the user.
+func (m *ingesterMetrics) deleteActiveSeriesCustomTrackerMetrics(userID string, names []string) {
+	for _, name := range names {
 		m.activeSeriesCustomTrackersPerUser.DeleteLabelValues(userID, name)
+		m.activeSeriesCustomTrackerEvaluations.DeleteLabelValues(userID, name)
+		m.activeSeriesCustomTrackerMatches.DeleteLabelValues(userID, name)
+		m.activeSeriesCustomTrackerEvaluationSeconds.DeleteLabelValues(userID, name)
+		m.activeSeriesCustomTrackerCreatedPerUser.DeleteLabelValues(userID, name)
+		m.activeSeriesCustomTrackerExpiredPerUser.DeleteLabelValues(userID, name)
 	}
 }
 
@@ -0,0 +1,120 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"net/http"
+
+	"github.com/go-kit/log/level"
+
+	"github.com/grafana/mimir/pkg/util"
+)
+
+const (
+	// prepareShutdownUnset is the status of the shutdown preparation when it hasn't been requested.
+	prepareShutdownUnset = "unset"
+
+	// prepareShutdownInProgress is the status of the shutdown preparation while the blocks are being flushed.
+	prepareShutdownInProgress = "in_progress"
+
+	// prepareShutdownReady is the status of the shutdown preparation once the blocks have been flushed,
+	// and the ingester can be terminated.
+	prepareShutdownReady = "ready"
+
+	// prepareShutdownFailed is the status of the shutdown preparation if the blocks couldn't be flushed.
+	prepareShutdownFailed = "failed"
+)
+
+// prepareShutdownState tracks the shutdown preparation requested through the PrepareShutdownHandler.
+type prepareShutdownState struct {
+	status string
+
+	// generation is incremented at each request, so that the completion of a canceled
+	// preparation doesn't change the status of the following ones.
+	generation int
+
+	// The lifecycler settings before the preparation, restored when it's canceled.
+	originalFlush      bool
+	originalUnregister bool
+}
+
+type prepareShutdownResponse struct {
+	Status string `json:"status"`
+}
+
+// PrepareShutdownHandler prepares the ingester to be terminated, for example by a rollout operator.
+// POST configures the ingester to flush the blocks and leave the ring on shutdown, and flushes and ships
+// the blocks in the background, so that the shutdown only has to flush the most recent samples. GET returns
+// the status of the preparation: the ingester is safe to be terminated once it's "ready". DELETE cancels
+// the preparation, restoring the original shutdown settings.
+func (i *Ingester) PrepareShutdownHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		i.prepareShutdownMtx.Lock()
+		status := i.prepareShutdown.status
+		i.prepareShutdownMtx.Unlock()
+
+		util.WriteJSONResponse(w, prepareShutdownResponse{Status: status})
+
+	case http.MethodPost:
+		if err := i.checkRunning(); err != nil {
+			http.Error(w, "the ingester is not running", http.StatusServiceUnavailable)
+			return
+		}
+
+		i.prepareShutdownMtx.Lock()
+		if i.prepareShutdown.status == prepareShutdownInProgress {
+			i.prepareShutdownMtx.Unlock()
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		if i.prepareShutdown.status == prepareShutdownUnset {
+			i.prepareShutdown.originalFlush = i.lifecycler.FlushOnShutdown()
+			i.prepareShutdown.originalUnregister = i.lifecycler.ShouldUnregisterOnShutdown()
+		}
+		i.lifecycler.SetFlushOnShutdown(true)
+		i.lifecycler.SetUnregisterOnShutdown(true)
+
+		i.prepareShutdown.status = prepareShutdownInProgress
+		i.prepareShutdown.generation++
+		generation := i.prepareShutdown.generation
+		i.prepareShutdownMtx.Unlock()
+
+		level.Info(i.logger).Log("msg", "preparing the ingester for shutdown")
+
+		go func() {
+			status := prepareShutdownFailed
+			if i.compactAndShipBlocks(nil) {
+				status = prepareShutdownReady
+			}
+
+			i.prepareShutdownMtx.Lock()
+			defer i.prepareShutdownMtx.Unlock()
+
+			if i.prepareShutdown.generation == generation && i.prepareShutdown.status == prepareShutdownInProgress {
+				i.prepareShutdown.status = status
+				level.Info(i.logger).Log("msg", "finished preparing the ingester for shutdown", "status", status)
+			}
+		}()
+
+		w.WriteHeader(http.StatusAccepted)
+
+	case http.MethodDelete:
+		i.prepareShutdownMtx.Lock()
+		defer i.prepareShutdownMtx.Unlock()
+
+		if i.prepareShutdown.status != prepareShutdownUnset {
+			i.lifecycler.SetFlushOnShutdown(i.prepareShutdown.originalFlush)
+			i.lifecycler.SetUnregisterOnShutdown(i.prepareShutdown.originalUnregister)
+			i.prepareShutdown.status = prepareShutdownUnset
+			i.prepareShutdown.generation++
+
+			level.Info(i.logger).Log("msg", "canceled the preparation of the ingester for shutdown")
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIngester_PrepareShutdownHandler(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+	cfg.IngesterRing.UnregisterOnShutdown = false
+
+	i, err := prepareIngesterWithBlocksStorage(t, cfg, nil)
+	require.NoError(t, err)
+
+	request := func(method string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		i.PrepareShutdownHandler(rec, httptest.NewRequest(method, "/ingester/prepare-shutdown", nil))
+		return rec
+	}
+	status := func() interface{} {
+		rec := request(http.MethodGet)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		res := prepareShutdownResponse{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		return res.Status
+	}
+
+	// The shutdown can't be prepared until the ingester is running.
+	assert.Equal(t, http.StatusServiceUnavailable, request(http.MethodPost).Code)
+
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	t.Cleanup(func() {
+		_ = services.StopAndAwaitTerminated(context.Background(), i)
+	})
+
+	test.Poll(t, 1*time.Second, 1, func() interface{} {
+		return i.lifecycler.HealthyInstancesCount()
+	})
+
+	pushSingleSampleWithMetadata(t, i)
+	assert.Equal(t, prepareShutdownUnset, status())
+
+	// The blocks are flushed, and the ingester is configured to flush and leave the ring on shutdown.
+	assert.Equal(t, http.StatusAccepted, request(http.MethodPost).Code)
+	test.Poll(t, 5*time.Second, prepareShutdownReady, status)
+
+	assert.True(t, i.lifecycler.FlushOnShutdown())
+	assert.True(t, i.lifecycler.ShouldUnregisterOnShutdown())
+	assert.Equal(t, uint64(0), i.getTSDB(userID).Head().NumSeries())
+	assert.Len(t, i.getTSDB(userID).Blocks(), 1)
+
+	// Canceling the preparation restores the original shutdown settings.
+	assert.Equal(t, http.StatusNoContent, request(http.MethodDelete).Code)
+	assert.Equal(t, prepareShutdownUnset, status())
+	assert.False(t, i.lifecycler.ShouldUnregisterOnShutdown())
+
+	assert.Equal(t, http.StatusMethodNotAllowed, request(http.MethodPut).Code)
+}
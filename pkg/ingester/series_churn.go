@@ -0,0 +1,58 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	seriesChurnWindow     = time.Hour
+	seriesChurnBucketSize = time.Minute
+	seriesChurnBuckets    = int64(seriesChurnWindow / seriesChurnBucketSize)
+)
+
+// seriesChurnBucket holds the number of series created in a bucket of time.
+type seriesChurnBucket struct {
+	// The index of the bucket since the Unix epoch.
+	index   int64
+	created int
+}
+
+// seriesChurnTracker counts the series created in the last hour, with a granularity of one minute.
+type seriesChurnTracker struct {
+	mtx     sync.Mutex
+	buckets [seriesChurnBuckets]seriesChurnBucket
+}
+
+// add records the creation of a series.
+func (t *seriesChurnTracker) add(now time.Time) {
+	index := now.UnixNano() / int64(seriesChurnBucketSize)
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	b := &t.buckets[index%seriesChurnBuckets]
+	if b.index != index {
+		b.index = index
+		b.created = 0
+	}
+	b.created++
+}
+
+// count returns the number of series created in the last hour.
+func (t *seriesChurnTracker) count(now time.Time) int {
+	index := now.UnixNano() / int64(seriesChurnBucketSize)
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	created := 0
+	for _, b := range t.buckets {
+		if b.index <= index && index-b.index < seriesChurnBuckets {
+			created += b.created
+		}
+	}
+	return created
+}
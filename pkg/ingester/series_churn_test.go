@@ -0,0 +1,34 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeriesChurnTracker(t *testing.T) {
+	var tracker seriesChurnTracker
+	start := time.Unix(1000*60, 0)
+
+	assert.Equal(t, 0, tracker.count(start))
+
+	tracker.add(start)
+	tracker.add(start.Add(30 * time.Second))
+	tracker.add(start.Add(10 * time.Minute))
+	assert.Equal(t, 3, tracker.count(start.Add(10*time.Minute)))
+
+	// The series created in the first minute fall out of the window after one hour.
+	assert.Equal(t, 3, tracker.count(start.Add(59*time.Minute)))
+	assert.Equal(t, 1, tracker.count(start.Add(time.Hour)))
+
+	// A bucket reused after one hour is reset.
+	tracker.add(start.Add(time.Hour))
+	assert.Equal(t, 2, tracker.count(start.Add(time.Hour)))
+	assert.Equal(t, 1, tracker.count(start.Add(time.Hour+10*time.Minute)))
+
+	// Nothing is left after a long inactivity.
+	assert.Equal(t, 0, tracker.count(start.Add(24*time.Hour)))
+}
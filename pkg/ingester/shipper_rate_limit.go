@@ -0,0 +1,163 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"golang.org/x/time/rate"
+)
+
+// shipperBandwidthLimiter limits the bandwidth used by the shipper to upload blocks to the storage,
+// both across all tenants and for each tenant.
+type shipperBandwidthLimiter struct {
+	// Limiter shared by all tenants. Nil if the global limit is disabled.
+	global *rate.Limiter
+
+	// Returns the per-tenant limit in bytes per second, 0 if disabled.
+	tenantLimit func(userID string) int
+
+	tenantsMtx sync.Mutex
+	tenants    map[string]*rate.Limiter
+
+	uploadedBytes    *prometheus.CounterVec
+	throttledSeconds *prometheus.CounterVec
+}
+
+func newShipperBandwidthLimiter(globalLimit int, tenantLimit func(userID string) int, uploadedBytes, throttledSeconds *prometheus.CounterVec) *shipperBandwidthLimiter {
+	l := &shipperBandwidthLimiter{
+		tenantLimit:      tenantLimit,
+		tenants:          map[string]*rate.Limiter{},
+		uploadedBytes:    uploadedBytes,
+		throttledSeconds: throttledSeconds,
+	}
+
+	if globalLimit > 0 {
+		l.global = rate.NewLimiter(rate.Limit(globalLimit), globalLimit)
+	}
+
+	return l
+}
+
+func (i *Ingester) newShipperBandwidthLimiter() *shipperBandwidthLimiter {
+	return newShipperBandwidthLimiter(
+		i.cfg.BlocksStorageConfig.TSDB.ShipMaxBytesPerSecond,
+		i.limits.IngesterShipMaxBytesPerSecond,
+		i.metrics.shipperUploadedBytes,
+		i.metrics.shipperUploadThrottledSeconds,
+	)
+}
+
+// limitersForUser returns the limiters to apply to the uploads of the input user.
+func (l *shipperBandwidthLimiter) limitersForUser(userID string) []*rate.Limiter {
+	var limiters []*rate.Limiter
+	if l.global != nil {
+		limiters = append(limiters, l.global)
+	}
+
+	limit := l.tenantLimit(userID)
+
+	l.tenantsMtx.Lock()
+	defer l.tenantsMtx.Unlock()
+
+	if limit <= 0 {
+		delete(l.tenants, userID)
+		return limiters
+	}
+
+	tenant, ok := l.tenants[userID]
+	if !ok {
+		tenant = rate.NewLimiter(rate.Limit(limit), limit)
+		l.tenants[userID] = tenant
+	} else if tenant.Burst() != limit {
+		// The limit has been changed in the runtime config.
+		tenant.SetLimit(rate.Limit(limit))
+		tenant.SetBurst(limit)
+	}
+
+	return append(limiters, tenant)
+}
+
+// removeUser removes the state of the input user.
+func (l *shipperBandwidthLimiter) removeUser(userID string) {
+	l.tenantsMtx.Lock()
+	delete(l.tenants, userID)
+	l.tenantsMtx.Unlock()
+}
+
+// wrapBucket returns a bucket whose uploads are subject to the bandwidth limits of the input user.
+func (l *shipperBandwidthLimiter) wrapBucket(userID string, bkt objstore.Bucket) objstore.Bucket {
+	return &rateLimitedUploadBucket{
+		Bucket:           bkt,
+		userID:           userID,
+		limiter:          l,
+		uploadedBytes:    l.uploadedBytes.WithLabelValues(userID),
+		throttledSeconds: l.throttledSeconds.WithLabelValues(userID),
+	}
+}
+
+// rateLimitedUploadBucket is an objstore.Bucket whose uploads are rate limited. All other operations
+// are passed through unchanged.
+type rateLimitedUploadBucket struct {
+	objstore.Bucket
+
+	userID           string
+	limiter          *shipperBandwidthLimiter
+	uploadedBytes    prometheus.Counter
+	throttledSeconds prometheus.Counter
+}
+
+func (b *rateLimitedUploadBucket) Upload(ctx context.Context, name string, r io.Reader) error {
+	return b.Bucket.Upload(ctx, name, &rateLimitedReader{
+		ctx:              ctx,
+		r:                r,
+		limiters:         b.limiter.limitersForUser(b.userID),
+		uploadedBytes:    b.uploadedBytes,
+		throttledSeconds: b.throttledSeconds,
+	})
+}
+
+// rateLimitedReader is an io.Reader waiting on the limiters before returning the read bytes.
+type rateLimitedReader struct {
+	ctx              context.Context
+	r                io.Reader
+	limiters         []*rate.Limiter
+	uploadedBytes    prometheus.Counter
+	throttledSeconds prometheus.Counter
+}
+
+func (r *rateLimitedReader) Read(p []byte) (int, error) {
+	// Each read can't be bigger than the burst of the limiters, otherwise waiting on them would fail.
+	for _, l := range r.limiters {
+		if burst := l.Burst(); len(p) > burst {
+			p = p[:burst]
+		}
+	}
+
+	n, err := r.r.Read(p)
+	if n <= 0 {
+		return n, err
+	}
+
+	start := time.Now()
+	for _, l := range r.limiters {
+		if waitErr := l.WaitN(r.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+
+	r.throttledSeconds.Add(time.Since(start).Seconds())
+	r.uploadedBytes.Add(float64(n))
+	return n, err
+}
+
+// ObjectSize implements objstore.ObjectSizer, so that the bucket clients can still get the size
+// of the uploaded object upfront.
+func (r *rateLimitedReader) ObjectSize() (int64, error) {
+	return objstore.TryToGetSize(r.r)
+}
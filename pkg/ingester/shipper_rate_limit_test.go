@@ -0,0 +1,115 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/objstore"
+)
+
+func TestShipperBandwidthLimiter_Upload(t *testing.T) {
+	const payloadSize = 4096
+
+	tests := map[string]struct {
+		globalLimit     int
+		tenantLimit     int
+		expectThrottled bool
+	}{
+		"no limits": {},
+		"global limit": {
+			globalLimit:     payloadSize,
+			expectThrottled: true,
+		},
+		"tenant limit": {
+			tenantLimit:     payloadSize,
+			expectThrottled: true,
+		},
+		"global and tenant limits": {
+			globalLimit:     payloadSize * 10,
+			tenantLimit:     payloadSize,
+			expectThrottled: true,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			ctx := context.Background()
+			uploadedBytes, throttledSeconds := newShipperTestMetrics()
+			l := newShipperBandwidthLimiter(testData.globalLimit, func(string) int { return testData.tenantLimit }, uploadedBytes, throttledSeconds)
+
+			bkt := objstore.NewInMemBucket()
+			userBkt := l.wrapBucket("user-1", bkt)
+
+			// The first upload consumes the burst, so the second one has to wait.
+			payload := bytes.Repeat([]byte("a"), payloadSize)
+			start := time.Now()
+			require.NoError(t, userBkt.Upload(ctx, "object-1", bytes.NewReader(payload)))
+			require.NoError(t, userBkt.Upload(ctx, "object-2", bytes.NewReader(payload)))
+			elapsed := time.Since(start)
+
+			// Ensure the objects have been fully uploaded.
+			for _, name := range []string{"object-1", "object-2"} {
+				r, err := bkt.Get(ctx, name)
+				require.NoError(t, err)
+				actual, err := io.ReadAll(r)
+				require.NoError(t, err)
+				assert.Equal(t, payload, actual)
+			}
+
+			assert.Equal(t, float64(2*payloadSize), testutil.ToFloat64(uploadedBytes.WithLabelValues("user-1")))
+			if testData.expectThrottled {
+				assert.Greater(t, elapsed, 500*time.Millisecond)
+				assert.Greater(t, testutil.ToFloat64(throttledSeconds.WithLabelValues("user-1")), 0.5)
+			} else {
+				assert.Less(t, elapsed, 500*time.Millisecond)
+			}
+		})
+	}
+}
+
+func TestShipperBandwidthLimiter_ShouldApplyTenantLimitChanges(t *testing.T) {
+	uploadedBytes, throttledSeconds := newShipperTestMetrics()
+	tenantLimit := 1000
+	l := newShipperBandwidthLimiter(0, func(string) int { return tenantLimit }, uploadedBytes, throttledSeconds)
+
+	limiters := l.limitersForUser("user-1")
+	require.Len(t, limiters, 1)
+	assert.Equal(t, 1000, limiters[0].Burst())
+
+	tenantLimit = 2000
+	limiters = l.limitersForUser("user-1")
+	require.Len(t, limiters, 1)
+	assert.Equal(t, 2000, limiters[0].Burst())
+
+	tenantLimit = 0
+	assert.Empty(t, l.limitersForUser("user-1"))
+	assert.Empty(t, l.tenants)
+}
+
+func TestRateLimitedReader_ShouldPreserveObjectSize(t *testing.T) {
+	uploadedBytes, throttledSeconds := newShipperTestMetrics()
+	r := &rateLimitedReader{
+		r:                strings.NewReader("hello"),
+		uploadedBytes:    uploadedBytes.WithLabelValues("user-1"),
+		throttledSeconds: throttledSeconds.WithLabelValues("user-1"),
+	}
+
+	size, err := objstore.TryToGetSize(r)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), size)
+}
+
+func newShipperTestMetrics() (uploadedBytes, throttledSeconds *prometheus.CounterVec) {
+	m := newIngesterMetrics(prometheus.NewPedanticRegistry(), false, nil, nil, nil, nil)
+	return m.shipperUploadedBytes, m.shipperUploadThrottledSeconds
+}
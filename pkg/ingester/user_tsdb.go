@@ -59,10 +59,12 @@ type userTSDB struct {
 	userID         string
 	activeSeries   *ActiveSeries
 	seriesInMetric *metricCounter
+	seriesChurn    seriesChurnTracker
 	limiter        *Limiter
 
 	instanceSeriesCount *atomic.Int64 // Shared across all userTSDB instances created by ingester.
 	instanceLimitsFn    func() *InstanceLimits
+	diskSpaceWatchdog   *diskSpaceWatchdog // Shared across all userTSDB instances created by ingester. Nil if disabled.
 
 	stateMtx       sync.RWMutex
 	state          tsdbState
@@ -185,11 +187,21 @@ func (u *userTSDB) PreCreation(metric labels.Labels) error {
 		}
 	}
 
+	// Verify ingester's free disk space.
+	if u.diskSpaceWatchdog.currentStage() >= diskSpaceRejectingNewSeries {
+		return errDiskSpaceLowNewSeriesRejected
+	}
+
 	// Total series limit.
 	if err := u.limiter.AssertMaxSeriesPerUser(u.userID, int(u.Head().NumSeries())); err != nil {
 		return err
 	}
 
+	// Series created per hour limit.
+	if err := u.limiter.AssertMaxSeriesCreatedPerHour(u.userID, u.seriesChurn.count(time.Now())); err != nil {
+		return err
+	}
+
 	// Series per metric name limit.
 	metricName, err := extract.MetricNameFromLabels(metric)
 	if err != nil {
@@ -206,6 +218,11 @@ func (u *userTSDB) PreCreation(metric labels.Labels) error {
 func (u *userTSDB) PostCreation(metric labels.Labels) {
 	u.instanceSeriesCount.Inc()
 
+	// The limiter is set once the TSDB has been opened, so that the series replayed from the WAL aren't counted as created.
+	if u.limiter != nil {
+		u.seriesChurn.add(time.Now())
+	}
+
 	metricName, err := extract.MetricNameFromLabels(metric)
 	if err != nil {
 		// This should never happen because it has already been checked in PreCreation().
@@ -6,13 +6,14 @@
 package mimir
 
 import (
-	"bytes"
 	"context"
 	"flag"
 	"fmt"
 	"net/http"
 	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
@@ -26,8 +27,8 @@ import (
 	"github.com/grafana/dskit/services"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/prometheus/promql"
 	prom_storage "github.com/prometheus/prometheus/storage"
+	v1 "github.com/prometheus/prometheus/web/api/v1"
 	"github.com/weaveworks/common/server"
 	"github.com/weaveworks/common/signals"
 	"google.golang.org/grpc/health/grpc_health_v1"
@@ -43,6 +44,7 @@ import (
 	"github.com/grafana/mimir/pkg/frontend"
 	"github.com/grafana/mimir/pkg/frontend/querymiddleware"
 	frontendv1 "github.com/grafana/mimir/pkg/frontend/v1"
+	frontendv2 "github.com/grafana/mimir/pkg/frontend/v2"
 	"github.com/grafana/mimir/pkg/ingester"
 	"github.com/grafana/mimir/pkg/ingester/client"
 	"github.com/grafana/mimir/pkg/querier"
@@ -52,19 +54,27 @@ import (
 	"github.com/grafana/mimir/pkg/ruler/rulestore"
 	rulestorelocal "github.com/grafana/mimir/pkg/ruler/rulestore/local"
 	"github.com/grafana/mimir/pkg/scheduler"
+	"github.com/grafana/mimir/pkg/sqlgateway"
 	"github.com/grafana/mimir/pkg/storage/bucket"
 	"github.com/grafana/mimir/pkg/storage/tsdb"
 	"github.com/grafana/mimir/pkg/storegateway"
 	"github.com/grafana/mimir/pkg/tenant"
 	"github.com/grafana/mimir/pkg/util"
 	"github.com/grafana/mimir/pkg/util/activitytracker"
+	"github.com/grafana/mimir/pkg/util/fips"
+	"github.com/grafana/mimir/pkg/util/grpcencoding"
 	util_log "github.com/grafana/mimir/pkg/util/log"
 	"github.com/grafana/mimir/pkg/util/noauth"
+	"github.com/grafana/mimir/pkg/util/otlpexport"
 	"github.com/grafana/mimir/pkg/util/process"
 	"github.com/grafana/mimir/pkg/util/validation"
 )
 
-var errInvalidBucketConfig = errors.New("invalid bucket config")
+var (
+	errInvalidBucketConfig           = errors.New("invalid bucket config")
+	errBucketDirectReadDirInSyncDir  = errors.New("the querier bucket direct read directory must not be within the store-gateway sync directory")
+	errIngesterQueryHedgingWithZones = errors.New("the hedging of queries to ingesters is not supported when ingesters zone-awareness is enabled")
+)
 
 // The design pattern for Mimir is a series of config objects, which are
 // registered for command line flags, and then a series of components that
@@ -88,6 +98,9 @@ type Config struct {
 	Target              flagext.StringSliceCSV `yaml:"target"`
 	MultitenancyEnabled bool                   `yaml:"multitenancy_enabled"`
 	NoAuthTenant        string                 `yaml:"no_auth_tenant" category:"advanced"`
+	TLSFIPSModeEnabled  bool                   `yaml:"tls_fips_mode_enabled" category:"experimental"`
+	LogTenantRateLimit  float64                `yaml:"log_tenant_rate_limit" category:"experimental"`
+	LogTenantRateBurst  int                    `yaml:"log_tenant_rate_limit_burst" category:"experimental"`
 	PrintConfig         bool                   `yaml:"-"`
 	ApplicationName     string                 `yaml:"-"`
 
@@ -114,6 +127,8 @@ type Config struct {
 	RuntimeConfig       runtimeconfig.Config                       `yaml:"runtime_config"`
 	MemberlistKV        memberlist.KVConfig                        `yaml:"memberlist"`
 	QueryScheduler      scheduler.Config                           `yaml:"query_scheduler"`
+	SQLGateway          sqlgateway.Config                          `yaml:"sql_gateway"`
+	OTLPMetricsExport   otlpexport.Config                          `yaml:"otlp_metrics_export"`
 }
 
 // RegisterFlags registers flag.
@@ -127,10 +142,14 @@ func (c *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 
 	f.Var(&c.Target, "target", "Comma-separated list of components to include in the instantiated process. "+
 		"The default value 'all' includes all components that are required to form a functional Grafana Mimir instance in single-binary mode. "+
-		"Use the '-modules' command line flag to get a list of available components, and to see which components are included with 'all'.")
+		"The values 'read', 'write' and 'backend' include the components of the read path, the write path and the backend in the read-write deployment mode. "+
+		"Use the '-modules' command line flag to get a list of available components, and to see which components are included with 'all', 'read', 'write' and 'backend'.")
 
 	f.BoolVar(&c.MultitenancyEnabled, "auth.multitenancy-enabled", true, "When set to true, incoming HTTP requests must specify tenant ID in HTTP X-Scope-OrgId header. When set to false, tenant ID from -auth.no-auth-tenant is used instead.")
 	f.StringVar(&c.NoAuthTenant, "auth.no-auth-tenant", "anonymous", "Tenant ID to use when multitenancy is disabled.")
+	f.BoolVar(&c.TLSFIPSModeEnabled, "tls.fips-mode-enabled", false, "Restrict the TLS versions, cipher suites and elliptic curves used by the HTTP and gRPC servers and by the gRPC clients to the FIPS-approved ones. The FIPS mode is always enabled in the binaries built with the fips build tag.")
+	f.Float64Var(&c.LogTenantRateLimit, "log.tenant-rate-limit", 0, "Maximum number of log lines per second logged on behalf of each tenant. Log lines exceeding the limit are discarded. 0 to disable.")
+	f.IntVar(&c.LogTenantRateBurst, "log.tenant-rate-limit-burst", 100, "Maximum number of log lines logged in a burst on behalf of each tenant, when -log.tenant-rate-limit is enabled.")
 	f.BoolVar(&c.PrintConfig, "print.config", false, "Print the config and exit.")
 
 	c.API.RegisterFlags(f)
@@ -156,6 +175,8 @@ func (c *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	c.MemberlistKV.RegisterFlags(f)
 	c.ActivityTracker.RegisterFlags(f)
 	c.QueryScheduler.RegisterFlags(f)
+	c.SQLGateway.RegisterFlags(f)
+	c.OTLPMetricsExport.RegisterFlags(f)
 }
 
 // Validate the mimir config and return an error if the validation
@@ -171,6 +192,9 @@ func (c *Config) Validate(log log.Logger) error {
 	if err := c.RulerStorage.Validate(); err != nil {
 		return errors.Wrap(err, "invalid rulestore config")
 	}
+	if err := c.LimitsConfig.Validate(); err != nil {
+		return errors.Wrap(err, "invalid limits config")
+	}
 	if err := c.Ruler.Validate(c.LimitsConfig, log); err != nil {
 		return errors.Wrap(err, "invalid ruler config")
 	}
@@ -183,6 +207,16 @@ func (c *Config) Validate(log log.Logger) error {
 	if err := c.Querier.Validate(); err != nil {
 		return errors.Wrap(err, "invalid querier config")
 	}
+	if err := c.validateBucketDirectReadDir(); err != nil {
+		return errors.Wrap(err, "invalid querier config")
+	}
+	if c.Querier.IngesterQueryHedgingEnabled && c.Ingester.IngesterRing.ZoneAwarenessEnabled {
+		// The replication set doesn't support delayed requests to the ingesters of the extra zones.
+		return errors.Wrap(errIngesterQueryHedgingWithZones, "invalid querier config")
+	}
+	if err := c.Ingester.Validate(); err != nil {
+		return errors.Wrap(err, "invalid ingester config")
+	}
 	if err := c.IngesterClient.Validate(log); err != nil {
 		return errors.Wrap(err, "invalid ingester_client config")
 	}
@@ -192,10 +226,16 @@ func (c *Config) Validate(log log.Logger) error {
 	if err := c.Frontend.QueryMiddleware.Validate(); err != nil {
 		return errors.Wrap(err, "invalid query-frontend middleware config")
 	}
+	if err := c.Frontend.FrontendV2.Validate(log); err != nil {
+		return errors.Wrap(err, "invalid query-frontend config")
+	}
+	if err := c.QueryScheduler.Validate(log); err != nil {
+		return errors.Wrap(err, "invalid query-scheduler config")
+	}
 	if err := c.StoreGateway.Validate(c.LimitsConfig); err != nil {
 		return errors.Wrap(err, "invalid store-gateway config")
 	}
-	if err := c.Compactor.Validate(); err != nil {
+	if err := c.Compactor.Validate(c.LimitsConfig); err != nil {
 		return errors.Wrap(err, "invalid compactor config")
 	}
 	if err := c.AlertmanagerStorage.Validate(); err != nil {
@@ -204,6 +244,54 @@ func (c *Config) Validate(log log.Logger) error {
 	if err := c.Alertmanager.Validate(c.AlertmanagerStorage); err != nil {
 		return errors.Wrap(err, "invalid alertmanager config")
 	}
+	if err := c.validateAPIListeners(); err != nil {
+		return errors.Wrap(err, "invalid api config")
+	}
+	if err := c.OTLPMetricsExport.Validate(); err != nil {
+		return errors.Wrap(err, "invalid otlp_metrics_export config")
+	}
+	if c.isModuleEnabled(SQLGateway) {
+		if err := c.SQLGateway.Validate(c.MultitenancyEnabled); err != nil {
+			return errors.Wrap(err, "invalid sql_gateway config")
+		}
+	}
+	return nil
+}
+
+func (c *Config) validateAPIListeners() error {
+	if err := c.API.Validate(); err != nil {
+		return err
+	}
+
+	for _, l := range c.API.Listeners {
+		for _, m := range l.Modules {
+			if !util.StringsContain(listenerModules, m) {
+				return fmt.Errorf("the listener %q can't serve the module %q, supported modules are: %s", l.Name, m, strings.Join(listenerModules, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// validateBucketDirectReadDir checks that the directory of the blocks read directly from the bucket by the querier
+// is not within the store-gateway sync directory, whose content is deleted by the store-gateway if it doesn't
+// belong to a tenant it owns.
+func (c *Config) validateBucketDirectReadDir() error {
+	if !c.Querier.StoreGatewayBucketFallbackEnabled && !c.Querier.BypassStoreGateways {
+		return nil
+	}
+
+	dir, err := filepath.Abs(c.Querier.BucketDirectReadDir)
+	if err != nil {
+		return err
+	}
+	syncDir, err := filepath.Abs(c.BlocksStorage.BucketStore.SyncDir)
+	if err != nil {
+		return err
+	}
+	if rel, err := filepath.Rel(syncDir, dir); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return errBucketDirectReadDirInSyncDir
+	}
 	return nil
 }
 
@@ -257,7 +345,7 @@ func (c *Config) validateBucketConfigs() error {
 	}
 
 	// Validate ruler bucket config.
-	if c.isAnyModuleEnabled(All, Ruler) && c.RulerStorage.Backend != rulestorelocal.Name {
+	if c.isAnyModuleEnabled(All, Backend, Ruler) && c.RulerStorage.Backend != rulestorelocal.Name {
 		errs.Add(errors.Wrap(validateBucketConfig(c.RulerStorage.Config, c.BlocksStorage.Bucket), "ruler storage"))
 	}
 
@@ -269,6 +357,11 @@ func validateBucketConfig(cfg bucket.Config, blockStorageBucketCfg bucket.Config
 		return nil
 	}
 
+	// Different storage prefixes allow to safely share the same bucket.
+	if cfg.StoragePrefix != blockStorageBucketCfg.StoragePrefix {
+		return nil
+	}
+
 	switch cfg.Backend {
 	case bucket.S3:
 		if cfg.S3.BucketName == blockStorageBucketCfg.S3.BucketName {
@@ -338,10 +431,11 @@ type Mimir struct {
 	Ingester                 *ingester.Ingester
 	Flusher                  *flusher.Flusher
 	Frontend                 *frontendv1.Frontend
+	FrontendV2               *frontendv2.Frontend
 	RuntimeConfig            *runtimeconfig.Manager
 	QuerierQueryable         prom_storage.SampleAndChunkQueryable
 	ExemplarQueryable        prom_storage.ExemplarQueryable
-	QuerierEngine            *promql.Engine
+	QuerierEngine            v1.QueryEngine
 	QueryFrontendTripperware querymiddleware.Tripperware
 	Ruler                    *ruler.Ruler
 	RulerStorage             rulestore.RuleStore
@@ -350,6 +444,8 @@ type Mimir struct {
 	StoreGateway             *storegateway.StoreGateway
 	MemberlistKV             *memberlist.KVInitService
 	ActivityTracker          *activitytracker.ActivityTracker
+	SQLGateway               *sqlgateway.Gateway
+	OTLPMetricsExporter      *otlpexport.Exporter
 	BuildInfoHandler         http.Handler
 
 	// Queryables that the querier should use to query the long term storage.
@@ -365,6 +461,16 @@ func New(cfg Config) (*Mimir, error) {
 		os.Exit(0)
 	}
 
+	if cfg.TLSFIPSModeEnabled {
+		fips.SetEnabled(true)
+		util_log.WarnExperimentalUse("tls.fips-mode-enabled")
+	}
+
+	if cfg.LogTenantRateLimit > 0 {
+		util_log.TenantLogs.SetRateLimit(cfg.LogTenantRateLimit, cfg.LogTenantRateBurst)
+		util_log.WarnExperimentalUse("log.tenant-rate-limit")
+	}
+
 	// Swap out the default resolver to support multiple tenant IDs separated by a '|'
 	if cfg.TenantFederation.Enabled {
 		tenant.WithDefaultResolver(tenant.NewMultiResolver())
@@ -374,6 +480,10 @@ func New(cfg Config) (*Mimir, error) {
 		}
 	}
 
+	// The gRPC compressors are shared by all the clients and servers, so they can't decompress larger messages
+	// than the largest message any of them can receive.
+	grpcencoding.SetMaxDecompressedSize(cfg.maxGRPCRecvMsgSize())
+
 	cfg.API.HTTPAuthMiddleware = noauth.SetupAuthMiddleware(&cfg.Server, cfg.MultitenancyEnabled,
 		// Also don't check auth for these gRPC methods, since single call is used for multiple users (or no user like health check).
 		[]string{
@@ -398,6 +508,24 @@ func New(cfg Config) (*Mimir, error) {
 	return mimir, nil
 }
 
+// maxGRPCRecvMsgSize returns the largest max receive message size of the gRPC server and clients.
+func (c *Config) maxGRPCRecvMsgSize() int {
+	max := c.Server.GPRCServerMaxRecvMsgSize
+	for _, size := range []int{
+		c.IngesterClient.GRPCClientConfig.MaxRecvMsgSize,
+		c.Worker.GRPCClientConfig.MaxRecvMsgSize,
+		c.Frontend.FrontendV2.GRPCClientConfig.MaxRecvMsgSize,
+		c.QueryScheduler.GRPCClientConfig.MaxRecvMsgSize,
+		c.Ruler.ClientTLSConfig.MaxRecvMsgSize,
+		querier.StoreGatewayClientMaxRecvMsgSize,
+	} {
+		if size > max {
+			max = size
+		}
+	}
+	return max
+}
+
 // setupThanosTracing appends a gRPC middleware used to inject our tracer into the custom
 // context used by Thanos, in order to get Thanos spans correctly attached to our traces.
 func (t *Mimir) setupThanosTracing() {
@@ -450,7 +578,7 @@ func (t *Mimir) Run() error {
 
 	// before starting servers, register /ready handler and gRPC health check service.
 	// It should reflect entire Mimir.
-	t.Server.HTTP.Path("/ready").Handler(t.readyHandler(sm))
+	t.Server.HTTP.Path("/ready").Handler(t.readyHandler(prometheus.DefaultRegisterer))
 	grpc_health_v1.RegisterHealthServer(t.Server.GRPC, grpcutil.NewHealthCheck(sm))
 
 	// Let's listen for events from this manager, and log them.
@@ -509,40 +637,3 @@ func (t *Mimir) Run() error {
 	}
 	return err
 }
-
-func (t *Mimir) readyHandler(sm *services.Manager) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		if !sm.IsHealthy() {
-			msg := bytes.Buffer{}
-			msg.WriteString("Some services are not Running:\n")
-
-			byState := sm.ServicesByState()
-			for st, ls := range byState {
-				msg.WriteString(fmt.Sprintf("%v: %d\n", st, len(ls)))
-			}
-
-			http.Error(w, msg.String(), http.StatusServiceUnavailable)
-			return
-		}
-
-		// Ingester has a special check that makes sure that it was able to register into the ring,
-		// and that all other ring entries are OK too.
-		if t.Ingester != nil {
-			if err := t.Ingester.CheckReady(r.Context()); err != nil {
-				http.Error(w, "Ingester not ready: "+err.Error(), http.StatusServiceUnavailable)
-				return
-			}
-		}
-
-		// Query Frontend has a special check that makes sure that a querier is attached before it signals
-		// itself as ready
-		if t.Frontend != nil {
-			if err := t.Frontend.CheckReady(r.Context()); err != nil {
-				http.Error(w, "Query Frontend not ready: "+err.Error(), http.StatusServiceUnavailable)
-				return
-			}
-		}
-
-		util.WriteTextResponse(w, "ready")
-	}
-}
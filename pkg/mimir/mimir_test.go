@@ -35,6 +35,7 @@ import (
 
 	"github.com/grafana/mimir/pkg/alertmanager"
 	"github.com/grafana/mimir/pkg/alertmanager/alertstore"
+	"github.com/grafana/mimir/pkg/api"
 	"github.com/grafana/mimir/pkg/cache"
 	"github.com/grafana/mimir/pkg/compactor"
 	"github.com/grafana/mimir/pkg/distributor"
@@ -242,6 +243,22 @@ func TestConfigValidation(t *testing.T) {
 			},
 			expectedError: errInvalidBucketConfig,
 		},
+		{
+			name: "S3: should pass if bucket name is shared between alertmanager and blocks storage, with different storage prefixes",
+			getTestConfig: func() *Config {
+				cfg := newDefaultConfig()
+				_ = cfg.Target.Set("all,alertmanager")
+
+				for i, bucketCfg := range []*bucket.Config{&cfg.BlocksStorage.Bucket, &cfg.AlertmanagerStorage.Config} {
+					bucketCfg.Backend = bucket.S3
+					bucketCfg.S3.BucketName = "b1"
+					bucketCfg.S3.Region = "r1"
+					bucketCfg.StoragePrefix = fmt.Sprintf("prefix%d", i)
+				}
+				return cfg
+			},
+			expectedError: nil,
+		},
 		{
 			name: "GCS: should fail if bucket name is shared between alertmanager and blocks storage",
 			getTestConfig: func() *Config {
@@ -316,6 +333,38 @@ func TestConfigValidation(t *testing.T) {
 			},
 			expectedError: nil,
 		},
+		{
+			name: "should fail if the querier bucket direct read dir is within the store-gateway sync dir",
+			getTestConfig: func() *Config {
+				cfg := newDefaultConfig()
+				cfg.Querier.StoreGatewayBucketFallbackEnabled = true
+				cfg.BlocksStorage.BucketStore.SyncDir = "./data/tsdb-sync/"
+				cfg.Querier.BucketDirectReadDir = "./data/tsdb-sync/querier"
+				return cfg
+			},
+			expectedError: errBucketDirectReadDirInSyncDir,
+		},
+		{
+			name: "should fail if the hedging of queries to ingesters is enabled with ingesters zone-awareness",
+			getTestConfig: func() *Config {
+				cfg := newDefaultConfig()
+				cfg.Querier.IngesterQueryHedgingEnabled = true
+				cfg.Ingester.IngesterRing.ZoneAwarenessEnabled = true
+				return cfg
+			},
+			expectedError: errIngesterQueryHedgingWithZones,
+		},
+		{
+			name: "should pass if the querier bucket direct read dir is next to the store-gateway sync dir",
+			getTestConfig: func() *Config {
+				cfg := newDefaultConfig()
+				cfg.Querier.BypassStoreGateways = true
+				cfg.BlocksStorage.BucketStore.SyncDir = "./data/tsdb-sync/"
+				cfg.Querier.BucketDirectReadDir = "./data/tsdb-sync-querier/"
+				return cfg
+			},
+			expectedError: nil,
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			err := tc.getTestConfig().Validate(nil)
@@ -328,6 +377,20 @@ func TestConfigValidation(t *testing.T) {
 	}
 }
 
+func TestConfigValidation_APIListeners(t *testing.T) {
+	cfg := newDefaultConfig()
+	cfg.API.Listeners = api.ListenersConfig{
+		{Name: "push", ListenPort: 8081, Modules: []string{Distributor}, Endpoints: api.ListenerEndpointsAuthenticated},
+	}
+	require.NoError(t, cfg.Validate(nil))
+
+	cfg.API.Listeners[0].Modules = []string{Distributor, MemberlistKV}
+	require.EqualError(t, cfg.Validate(nil), `invalid api config: the listener "push" can't serve the module "memberlist-kv", supported modules are: alertmanager, compactor, distributor, flusher, ingester, purger, querier, query-frontend, ruler, store-gateway`)
+
+	cfg.API.Listeners[0].Modules = nil
+	require.EqualError(t, cfg.Validate(nil), `invalid api config: the listener "push" must serve at least one module`)
+}
+
 func TestGrpcAuthMiddleware(t *testing.T) {
 	prepareGlobalMetricsRegistry(t)
 
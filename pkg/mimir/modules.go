@@ -13,13 +13,17 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/grafana/dskit/kv/codec"
 	"github.com/grafana/dskit/kv/memberlist"
 	"github.com/grafana/dskit/modules"
+	"github.com/grafana/dskit/netutil"
 	"github.com/grafana/dskit/ring"
+	ring_client "github.com/grafana/dskit/ring/client"
 	"github.com/grafana/dskit/runtimeconfig"
 	"github.com/grafana/dskit/services"
+	"github.com/hashicorp/go-sockaddr"
 	"github.com/opentracing-contrib/go-stdlib/nethttp"
 	"github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
@@ -28,8 +32,8 @@ import (
 	prom_storage "github.com/prometheus/prometheus/storage"
 	"github.com/thanos-io/thanos/pkg/discovery/dns"
 	httpgrpc_server "github.com/weaveworks/common/httpgrpc/server"
-	"github.com/weaveworks/common/server"
 
+	"github.com/grafana/mimir/pkg/admin"
 	"github.com/grafana/mimir/pkg/alertmanager"
 	"github.com/grafana/mimir/pkg/alertmanager/alertstore"
 	"github.com/grafana/mimir/pkg/api"
@@ -40,6 +44,7 @@ import (
 	"github.com/grafana/mimir/pkg/frontend/querymiddleware"
 	"github.com/grafana/mimir/pkg/frontend/transport"
 	"github.com/grafana/mimir/pkg/ingester"
+	ingester_client "github.com/grafana/mimir/pkg/ingester/client"
 	"github.com/grafana/mimir/pkg/purger"
 	"github.com/grafana/mimir/pkg/querier"
 	"github.com/grafana/mimir/pkg/querier/engine"
@@ -47,10 +52,14 @@ import (
 	querier_worker "github.com/grafana/mimir/pkg/querier/worker"
 	"github.com/grafana/mimir/pkg/ruler"
 	"github.com/grafana/mimir/pkg/scheduler"
+	"github.com/grafana/mimir/pkg/sqlgateway"
+	"github.com/grafana/mimir/pkg/storage/bucket"
 	"github.com/grafana/mimir/pkg/storegateway"
 	"github.com/grafana/mimir/pkg/util"
 	"github.com/grafana/mimir/pkg/util/activitytracker"
+	"github.com/grafana/mimir/pkg/util/fips"
 	util_log "github.com/grafana/mimir/pkg/util/log"
+	"github.com/grafana/mimir/pkg/util/otlpexport"
 	"github.com/grafana/mimir/pkg/util/validation"
 	"github.com/grafana/mimir/pkg/util/version"
 )
@@ -82,12 +91,23 @@ const (
 	StoreGateway             string = "store-gateway"
 	MemberlistKV             string = "memberlist-kv"
 	TenantDeletion           string = "tenant-deletion"
+	TenantsAdmin             string = "tenants-admin"
 	Purger                   string = "purger"
 	QueryScheduler           string = "query-scheduler"
 	TenantFederation         string = "tenant-federation"
+	SQLGateway               string = "sql-gateway"
+	OTLPMetricsExport        string = "otlp-metrics-export"
 	All                      string = "all"
+
+	// Composite targets of the read-write deployment mode.
+	Read    string = "read"
+	Write   string = "write"
+	Backend string = "backend"
 )
 
+// listenerModules are the modules whose HTTP endpoints can be served by the additional API listeners.
+var listenerModules = []string{AlertManager, Compactor, Distributor, Flusher, Ingester, Purger, Querier, QueryFrontend, Ruler, StoreGateway}
+
 func newDefaultConfig() *Config {
 	defaultConfig := &Config{}
 	defaultFS := flag.NewFlagSet("", flag.PanicOnError)
@@ -114,8 +134,13 @@ func (t *Mimir) initAPI() (services.Service, error) {
 
 	t.API = a
 	t.API.RegisterAPI(t.Cfg.Server.PathPrefix, t.Cfg, newDefaultConfig(), t.BuildInfoHandler)
+	t.API.RegisterFIPSStatus(fips.StatusHandler(
+		t.Cfg.Server.HTTPTLSConfig.TLSCertPath != "" && t.Cfg.Server.HTTPTLSConfig.TLSKeyPath != "",
+		t.Cfg.Server.GRPCTLSConfig.TLSCertPath != "" && t.Cfg.Server.GRPCTLSConfig.TLSKeyPath != "",
+	))
+	t.API.RegisterLogging(util_log.LogLevels, util_log.TenantLogs)
 
-	return nil, nil
+	return t.API.ListenersService(), nil
 }
 
 func (t *Mimir) initActivityTracker() (services.Service, error) {
@@ -158,6 +183,15 @@ func (t *Mimir) initActivityTracker() (services.Service, error) {
 	}), nil
 }
 
+func (t *Mimir) initOTLPMetricsExport() (services.Service, error) {
+	if t.Cfg.OTLPMetricsExport.Endpoint == "" {
+		return nil, nil
+	}
+
+	t.OTLPMetricsExporter = otlpexport.NewExporter(t.Cfg.OTLPMetricsExport, t.Cfg.Target, prometheus.DefaultGatherer, util_log.Logger, prometheus.DefaultRegisterer)
+	return t.OTLPMetricsExporter, nil
+}
+
 func (t *Mimir) initSanityCheck() (services.Service, error) {
 	return services.NewIdleService(func(ctx context.Context) error {
 		return runSanityCheck(ctx, t.Cfg, util_log.Logger)
@@ -167,7 +201,7 @@ func (t *Mimir) initSanityCheck() (services.Service, error) {
 func (t *Mimir) initServer() (services.Service, error) {
 	// Mimir handles signals on its own.
 	DisableSignalHandling(&t.Cfg.Server)
-	serv, err := server.New(t.Cfg.Server)
+	serv, err := newServerWithTLSReload(t.Cfg.Server)
 	if err != nil {
 		return nil, err
 	}
@@ -233,9 +267,16 @@ func (t *Mimir) initRuntimeConfig() (services.Service, error) {
 
 func (t *Mimir) initOverrides() (serv services.Service, err error) {
 	t.Overrides, err = validation.NewOverrides(t.Cfg.LimitsConfig, t.TenantLimits)
+	if err != nil {
+		return nil, err
+	}
+
+	// The API is initialized before the overrides, which depend on the runtime config served by the API.
+	t.API.SetRequestRateLimits(t.Overrides)
+
 	// overrides don't have operational state, nor do they need to do anything more in starting/stopping phase,
 	// so there is no need to return any service.
-	return nil, err
+	return nil, nil
 }
 
 func (t *Mimir) initOverridesExporter() (services.Service, error) {
@@ -251,11 +292,19 @@ func (t *Mimir) initDistributorService() (serv services.Service, err error) {
 	t.Cfg.Distributor.DistributorRing.KVStore.Multi.ConfigProvider = multiClientRuntimeConfigChannel(t.RuntimeConfig)
 	t.Cfg.Distributor.DistributorRing.ListenPort = t.Cfg.Server.GRPCListenPort
 	t.Cfg.Distributor.ShuffleShardingLookbackPeriod = t.Cfg.Querier.ShuffleShardingIngestersLookbackPeriod
+	t.Cfg.Distributor.UnhealthyZonesFn = distributorUnhealthyZones(t.RuntimeConfig)
+	t.Cfg.Distributor.IngesterQueryHedgingEnabled = t.Cfg.Querier.IngesterQueryHedgingEnabled
+	t.Cfg.Distributor.IngesterQueryHedgingPercentile = t.Cfg.Querier.IngesterQueryHedgingPercentile
+	t.Cfg.Distributor.IngesterQueryHedgingMinDelay = t.Cfg.Querier.IngesterQueryHedgingMinDelay
+	t.Cfg.Distributor.IngesterQueryGRPCCompression = t.Cfg.Querier.IngesterClientGRPCCompression
+	if t.Cfg.IngesterClient.InProcessEnabled && t.Cfg.Distributor.IngesterClientFactory == nil {
+		t.Cfg.Distributor.IngesterClientFactory = t.inProcessIngesterClientFactory()
+	}
 
 	// Check whether the distributor can join the distributors ring, which is
 	// whenever it's not running as an internal dependency (ie. querier or
 	// ruler's dependency)
-	canJoinDistributorsRing := t.Cfg.isModuleEnabled(Distributor) || t.Cfg.isModuleEnabled(All)
+	canJoinDistributorsRing := t.Cfg.isAnyModuleEnabled(Distributor, All, Write)
 
 	t.Distributor, err = distributor.New(t.Cfg.Distributor, t.Cfg.IngesterClient, t.Overrides, t.Ring, canJoinDistributorsRing, prometheus.DefaultRegisterer, util_log.Logger)
 	if err != nil {
@@ -265,8 +314,20 @@ func (t *Mimir) initDistributorService() (serv services.Service, err error) {
 	return t.Distributor, nil
 }
 
+// inProcessIngesterClientFactory returns a factory of ingester clients, which calls the ingester running
+// in this same process in-process. The ingester is looked up when the clients are created, because the
+// ingester module may be initialized after the distributor's one.
+func (t *Mimir) inProcessIngesterClientFactory() ring_client.PoolFactory {
+	return func(addr string) (ring_client.PoolClient, error) {
+		if t.Ingester != nil && addr == t.Ingester.RingAddr() {
+			return ingester_client.NewInProcessClient(t.Ingester), nil
+		}
+		return ingester_client.MakeIngesterClient(addr, t.Cfg.IngesterClient)
+	}
+}
+
 func (t *Mimir) initDistributor() (serv services.Service, err error) {
-	t.API.RegisterDistributor(t.Distributor, t.Cfg.Distributor)
+	t.API.ForModule(Distributor).RegisterDistributor(t.Distributor, t.Cfg.Distributor)
 
 	return nil, nil
 }
@@ -280,7 +341,7 @@ func (t *Mimir) initQueryable() (serv services.Service, err error) {
 	t.QuerierQueryable, t.ExemplarQueryable, t.QuerierEngine = querier.New(t.Cfg.Querier, t.Overrides, t.Distributor, t.StoreQueryables, querierRegisterer, util_log.Logger, t.ActivityTracker)
 
 	// Register the default endpoints that are always enabled for the querier module
-	t.API.RegisterQueryable(t.QuerierQueryable, t.Distributor)
+	t.API.ForModule(Querier).RegisterQueryable(t.QuerierQueryable, t.Distributor)
 
 	return nil, nil
 }
@@ -365,9 +426,9 @@ func (t *Mimir) initQuerier() (serv services.Service, err error) {
 	// If the querier is running standalone without the query-frontend or query-scheduler, we must register it's internal
 	// HTTP handler externally and provide the external Mimir Server HTTP handler to the frontend worker
 	// to ensure requests it processes use the default middleware instrumentation.
-	if !t.Cfg.isModuleEnabled(QueryFrontend) && !t.Cfg.isModuleEnabled(QueryScheduler) && !t.Cfg.isModuleEnabled(All) {
+	if !t.Cfg.isAnyModuleEnabled(QueryFrontend, QueryScheduler, All, Read) {
 		// First, register the internal querier handler with the external HTTP server
-		t.API.RegisterQueryAPI(internalQuerierRouter, t.BuildInfoHandler)
+		t.API.ForModule(Querier).RegisterQueryAPI(internalQuerierRouter, t.BuildInfoHandler)
 
 		// Second, set the http.Handler that the frontend worker will use to process requests to point to
 		// the external HTTP server. This will allow the querier to consolidate query metrics both external
@@ -453,7 +514,7 @@ func (t *Mimir) initIngester() (serv services.Service, err error) {
 	if t.ActivityTracker != nil {
 		ing = ingester.NewIngesterActivityTracker(t.Ingester, t.ActivityTracker)
 	}
-	t.API.RegisterIngester(ing, t.Cfg.Distributor)
+	t.API.ForModule(Ingester).RegisterIngester(ing, t.Cfg.Distributor)
 	return nil, nil
 }
 
@@ -471,6 +532,7 @@ func (t *Mimir) initFlusher() (serv services.Service, err error) {
 		return
 	}
 
+	t.API.ForModule(Flusher).RegisterFlusher(t.Flusher)
 	return t.Flusher, nil
 }
 
@@ -503,8 +565,16 @@ func (t *Mimir) initQueryFrontend() (serv services.Service, err error) {
 	// Wrap roundtripper into Tripperware.
 	roundTripper = t.QueryFrontendTripperware(roundTripper)
 
-	handler := transport.NewHandler(t.Cfg.Frontend.Handler, roundTripper, util_log.Logger, prometheus.DefaultRegisterer)
-	t.API.RegisterQueryFrontendHandler(handler, t.BuildInfoHandler)
+	var slowQueryLog log.Logger
+	if t.Cfg.Frontend.Handler.SlowQueryLogFile != "" {
+		if slowQueryLog, err = transport.NewSlowQueryLogger(t.Cfg.Frontend.Handler.SlowQueryLogFile); err != nil {
+			return nil, err
+		}
+		util_log.WarnExperimentalUse("query-frontend.slow-query-log-file")
+	}
+
+	handler := transport.NewHandler(t.Cfg.Frontend.Handler, roundTripper, t.Overrides, util_log.Logger, slowQueryLog, prometheus.DefaultRegisterer)
+	t.API.ForModule(QueryFrontend).RegisterQueryFrontendHandler(handler, t.BuildInfoHandler)
 
 	if frontendV1 != nil {
 		t.API.RegisterQueryFrontend1(frontendV1)
@@ -513,6 +583,7 @@ func (t *Mimir) initQueryFrontend() (serv services.Service, err error) {
 		return frontendV1, nil
 	} else if frontendV2 != nil {
 		t.API.RegisterQueryFrontend2(frontendV2)
+		t.FrontendV2 = frontendV2
 
 		return frontendV2, nil
 	}
@@ -525,8 +596,8 @@ func (t *Mimir) initRulerStorage() (serv services.Service, err error) {
 	// unfortunately there is no way to generate a "default" config and compare default against actual
 	// to determine if it's unconfigured.  the following check, however, correctly tests this.
 	// Single binary integration tests will break if this ever drifts
-	if t.Cfg.isModuleEnabled(All) && t.Cfg.RulerStorage.IsDefaults() {
-		level.Info(util_log.Logger).Log("msg", "Ruler storage is not configured in single binary mode and will not be started.")
+	if t.Cfg.isAnyModuleEnabled(All, Backend) && t.Cfg.RulerStorage.IsDefaults() {
+		level.Info(util_log.Logger).Log("msg", "Ruler storage is not configured in single binary or backend mode and will not be started.")
 		return
 	}
 
@@ -571,7 +642,7 @@ func (t *Mimir) initRuler() (serv services.Service, err error) {
 	)
 
 	dnsResolver := dns.NewProvider(util_log.Logger, dnsProviderReg, dns.GolangResolverType)
-	manager, err := ruler.NewDefaultMultiTenantManager(t.Cfg.Ruler, managerFactory, prometheus.DefaultRegisterer, util_log.Logger, dnsResolver)
+	manager, err := ruler.NewDefaultMultiTenantManager(t.Cfg.Ruler, managerFactory, prometheus.DefaultRegisterer, util_log.Logger, dnsResolver, t.Overrides)
 	if err != nil {
 		return nil, err
 	}
@@ -589,10 +660,10 @@ func (t *Mimir) initRuler() (serv services.Service, err error) {
 	}
 
 	// Expose HTTP/GRPC admin endpoints for the Ruler service
-	t.API.RegisterRuler(t.Ruler)
+	t.API.ForModule(Ruler).RegisterRuler(t.Ruler)
 
 	// Expose HTTP configuration and prometheus-compatible Ruler APIs
-	t.API.RegisterRulerAPI(ruler.NewAPI(t.Ruler, t.RulerStorage, util_log.Logger), t.Cfg.Ruler.EnableAPI)
+	t.API.ForModule(Ruler).RegisterRulerAPI(ruler.NewAPI(t.Ruler, t.RulerStorage, util_log.Logger), t.Cfg.Ruler.EnableAPI)
 
 	return t.Ruler, nil
 }
@@ -601,6 +672,9 @@ func (t *Mimir) initAlertManager() (serv services.Service, err error) {
 	t.Cfg.Alertmanager.ShardingRing.KVStore.Multi.ConfigProvider = multiClientRuntimeConfigChannel(t.RuntimeConfig)
 	t.Cfg.Alertmanager.ShardingRing.ListenPort = t.Cfg.Server.GRPCListenPort
 	t.Cfg.Alertmanager.CheckExternalURL(t.Cfg.API.AlertmanagerHTTPPrefix, util_log.Logger)
+	if t.Cfg.Alertmanager.SilencesFederation.Enabled() {
+		util_log.WarnExperimentalUse("alertmanager.silences-federation")
+	}
 
 	store, err := alertstore.NewAlertStore(context.Background(), t.Cfg.AlertmanagerStorage, t.Overrides, util_log.Logger, prometheus.DefaultRegisterer)
 	if err != nil {
@@ -612,7 +686,7 @@ func (t *Mimir) initAlertManager() (serv services.Service, err error) {
 		return
 	}
 
-	t.API.RegisterAlertmanager(t.Alertmanager, t.Cfg.Alertmanager.EnableAPI, t.BuildInfoHandler)
+	t.API.ForModule(AlertManager).RegisterAlertmanager(t.Alertmanager, t.Cfg.Alertmanager.EnableAPI, t.BuildInfoHandler)
 	return t.Alertmanager, nil
 }
 
@@ -626,7 +700,7 @@ func (t *Mimir) initCompactor() (serv services.Service, err error) {
 	}
 
 	// Expose HTTP endpoints.
-	t.API.RegisterCompactor(t.Compactor)
+	t.API.ForModule(Compactor).RegisterCompactor(t.Compactor)
 	return t.Compactor, nil
 }
 
@@ -640,7 +714,7 @@ func (t *Mimir) initStoreGateway() (serv services.Service, err error) {
 	}
 
 	// Expose HTTP endpoints.
-	t.API.RegisterStoreGateway(t.StoreGateway)
+	t.API.ForModule(StoreGateway).RegisterStoreGateway(t.StoreGateway)
 
 	return t.StoreGateway, nil
 }
@@ -651,6 +725,19 @@ func (t *Mimir) initMemberlistKV() (services.Service, error) {
 	t.Cfg.MemberlistKV.Codecs = []codec.Codec{
 		ring.GetCodec(),
 	}
+
+	// Memberlist only auto-detects a private IPv4 address to advertise, which doesn't exist
+	// on IPv6-only networks. In that case we advertise the first IPv6 address found instead.
+	if t.Cfg.MemberlistKV.AdvertiseAddr == "" && memberlistBindsAllAddrs(t.Cfg.MemberlistKV.TCPTransport.BindAddrs) {
+		if ip, err := sockaddr.GetPrivateIP(); err == nil && ip == "" {
+			infNames := netutil.PrivateNetworkInterfacesWithFallback([]string{"eth0", "en0"}, util_log.Logger)
+			if addr, err := util.GetFirstAddressOf(infNames, util.AddrFamilyIPv6); err == nil {
+				level.Info(util_log.Logger).Log("msg", "no private IPv4 address found, advertising the IPv6 address to memberlist", "addr", addr)
+				t.Cfg.MemberlistKV.AdvertiseAddr = addr
+			}
+		}
+	}
+
 	dnsProviderReg := prometheus.WrapRegistererWithPrefix(
 		"cortex_",
 		prometheus.WrapRegistererWith(
@@ -673,6 +760,12 @@ func (t *Mimir) initMemberlistKV() (services.Service, error) {
 	return t.MemberlistKV, nil
 }
 
+// memberlistBindsAllAddrs returns whether memberlist listens on all the addresses, which is when
+// it has to auto-detect the address to advertise.
+func memberlistBindsAllAddrs(bindAddrs []string) bool {
+	return len(bindAddrs) == 0 || bindAddrs[0] == "0.0.0.0"
+}
+
 func (t *Mimir) initTenantDeletionAPI() (services.Service, error) {
 	// t.RulerStorage can be nil when running in single-binary mode, and rule storage is not configured.
 	tenantDeletionAPI, err := purger.NewTenantDeletionAPI(t.Cfg.BlocksStorage, t.Overrides, util_log.Logger, prometheus.DefaultRegisterer)
@@ -680,7 +773,29 @@ func (t *Mimir) initTenantDeletionAPI() (services.Service, error) {
 		return nil, err
 	}
 
-	t.API.RegisterTenantDeletion(tenantDeletionAPI)
+	t.API.ForModule(Purger).RegisterTenantDeletion(tenantDeletionAPI)
+	return nil, nil
+}
+
+func (t *Mimir) initTenantsAdminAPI() (services.Service, error) {
+	bucketClient, err := bucket.NewClient(context.Background(), t.Cfg.BlocksStorage.Bucket, "tenants-admin", util_log.Logger, prometheus.DefaultRegisterer)
+	if err != nil {
+		return nil, errors.Wrap(err, "create bucket client")
+	}
+
+	// The store metrics are not registered, because they would clash with the Alertmanager ones.
+	alertStore, err := alertstore.NewAlertStore(context.Background(), t.Cfg.AlertmanagerStorage, t.Overrides, util_log.Logger, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "create alertmanager store")
+	}
+
+	// t.RulerStorage can be nil when running in single-binary mode, and rule storage is not configured.
+	var rules admin.RuleGroupsLister
+	if t.RulerStorage != nil {
+		rules = t.RulerStorage
+	}
+
+	t.API.ForModule(Purger).RegisterTenantsAdmin(admin.NewTenantsAPI(bucketClient, t.Overrides, t.Distributor, rules, alertStore, util_log.Logger))
 	return nil, nil
 }
 
@@ -694,6 +809,17 @@ func (t *Mimir) initQueryScheduler() (services.Service, error) {
 	return s, nil
 }
 
+func (t *Mimir) initSQLGateway() (services.Service, error) {
+	// Without multitenancy, all the connections query the no-auth tenant.
+	noAuthTenant := ""
+	if !t.Cfg.MultitenancyEnabled {
+		noAuthTenant = t.Cfg.NoAuthTenant
+	}
+
+	t.SQLGateway = sqlgateway.NewGateway(t.Cfg.SQLGateway, t.QuerierQueryable, t.QuerierEngine, noAuthTenant, util_log.Logger, prometheus.DefaultRegisterer)
+	return t.SQLGateway, nil
+}
+
 func (t *Mimir) setupModuleManager() error {
 	mm := modules.NewManager(util_log.Logger)
 
@@ -702,6 +828,7 @@ func (t *Mimir) setupModuleManager() error {
 	mm.RegisterModule(Server, t.initServer, modules.UserInvisibleModule)
 	mm.RegisterModule(ActivityTracker, t.initActivityTracker, modules.UserInvisibleModule)
 	mm.RegisterModule(SanityCheck, t.initSanityCheck, modules.UserInvisibleModule)
+	mm.RegisterModule(OTLPMetricsExport, t.initOTLPMetricsExport, modules.UserInvisibleModule)
 	mm.RegisterModule(API, t.initAPI, modules.UserInvisibleModule)
 	mm.RegisterModule(RuntimeConfig, t.initRuntimeConfig, modules.UserInvisibleModule)
 	mm.RegisterModule(MemberlistKV, t.initMemberlistKV, modules.UserInvisibleModule)
@@ -724,14 +851,19 @@ func (t *Mimir) setupModuleManager() error {
 	mm.RegisterModule(Compactor, t.initCompactor)
 	mm.RegisterModule(StoreGateway, t.initStoreGateway)
 	mm.RegisterModule(TenantDeletion, t.initTenantDeletionAPI, modules.UserInvisibleModule)
+	mm.RegisterModule(TenantsAdmin, t.initTenantsAdminAPI, modules.UserInvisibleModule)
 	mm.RegisterModule(Purger, nil)
 	mm.RegisterModule(QueryScheduler, t.initQueryScheduler)
 	mm.RegisterModule(TenantFederation, t.initTenantFederation, modules.UserInvisibleModule)
+	mm.RegisterModule(SQLGateway, t.initSQLGateway)
 	mm.RegisterModule(All, nil)
+	mm.RegisterModule(Read, nil)
+	mm.RegisterModule(Write, nil)
+	mm.RegisterModule(Backend, nil)
 
 	// Add dependencies
 	deps := map[string][]string{
-		Server:                   {ActivityTracker, SanityCheck},
+		Server:                   {ActivityTracker, SanityCheck, OTLPMetricsExport},
 		API:                      {Server},
 		MemberlistKV:             {API},
 		RuntimeConfig:            {API},
@@ -755,9 +887,14 @@ func (t *Mimir) setupModuleManager() error {
 		Compactor:                {API, MemberlistKV, Overrides},
 		StoreGateway:             {API, Overrides, MemberlistKV},
 		TenantDeletion:           {API, Overrides},
-		Purger:                   {TenantDeletion},
+		TenantsAdmin:             {API, Overrides, DistributorService, RulerStorage},
+		Purger:                   {TenantDeletion, TenantsAdmin},
 		TenantFederation:         {Queryable},
+		SQLGateway:               {TenantFederation},
 		All:                      {QueryFrontend, Querier, Ingester, Distributor, Purger, StoreGateway, Ruler, Compactor},
+		Read:                     {QueryFrontend, Querier},
+		Write:                    {Distributor, Ingester},
+		Backend:                  {QueryScheduler, StoreGateway, Compactor, Ruler, Purger, OverridesExporter},
 	}
 	for mod, targets := range deps {
 		if err := mm.AddDependency(mod, targets...); err != nil {
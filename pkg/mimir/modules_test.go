@@ -130,6 +130,14 @@ func TestMimir_InitRulerStorage(t *testing.T) {
 			}(),
 			expectedInit: false,
 		},
+		"should not init the ruler storage on default config with target=backend": {
+			config: func() *Config {
+				cfg := newDefaultConfig()
+				cfg.Target = []string{"backend"}
+				return cfg
+			}(),
+			expectedInit: false,
+		},
 		"should init the ruler storage on ruler storage config with target=all": {
 			config: func() *Config {
 				cfg := newDefaultConfig()
@@ -161,6 +169,39 @@ func TestMimir_InitRulerStorage(t *testing.T) {
 	}
 }
 
+func TestMimir_ReadWriteModeTargets(t *testing.T) {
+	mimir := &Mimir{Cfg: *newDefaultConfig()}
+	require.NoError(t, mimir.setupModuleManager())
+
+	tests := map[string]struct {
+		included []string
+		excluded []string
+	}{
+		Read: {
+			included: []string{QueryFrontend, Querier},
+			excluded: []string{Distributor, Ingester, QueryScheduler, StoreGateway, Compactor, Ruler},
+		},
+		Write: {
+			included: []string{Distributor, Ingester},
+			excluded: []string{QueryFrontend, Querier, QueryScheduler, StoreGateway, Compactor, Ruler},
+		},
+		Backend: {
+			included: []string{QueryScheduler, StoreGateway, Compactor, Ruler, Purger, OverridesExporter},
+			excluded: []string{Distributor, Ingester, QueryFrontend, Querier},
+		},
+	}
+
+	for target, testData := range tests {
+		t.Run(target, func(t *testing.T) {
+			deps := mimir.ModuleManager.DependenciesForModule(target)
+			assert.Subset(t, deps, testData.included)
+			for _, m := range testData.excluded {
+				assert.NotContains(t, deps, m)
+			}
+		})
+	}
+}
+
 func TestMultiKVSetup(t *testing.T) {
 	dir := t.TempDir()
 
@@ -200,6 +241,7 @@ func TestMultiKVSetup(t *testing.T) {
 
 			// Must be set, otherwise MultiKV config provider will not be set.
 			cfg.RuntimeConfig.LoadPath = filepath.Join(dir, "config.yaml")
+			cfg.ActivityTracker.Filepath = filepath.Join(dir, "metrics-activity.log")
 
 			c, err := New(cfg)
 			require.NoError(t, err)
@@ -0,0 +1,226 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package mimir
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/grafana/dskit/services"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/mimir/pkg/util"
+)
+
+// Reasons why the /ready endpoint reports Mimir as not ready.
+const (
+	// The module is starting.
+	readyReasonStarting = "starting"
+
+	// The ingester is starting, opening the TSDBs and replaying their WAL.
+	readyReasonWALReplay = "wal_replay_in_progress"
+
+	// The store-gateway is starting, running the initial sync of the blocks.
+	readyReasonStoreSync = "store_sync_in_progress"
+
+	// The module is stopping or has stopped.
+	readyReasonStopping = "stopping"
+
+	// The module has failed.
+	readyReasonFailed = "failed"
+
+	// The ingester isn't registered in the ring yet, or some instances in the ring are not healthy.
+	readyReasonRingNotHealthy = "ring_not_healthy"
+
+	// No querier is connected to the query-frontend.
+	readyReasonNoQueriersConnected = "no_queriers_connected"
+
+	// The query-frontend is not connected to any query-scheduler.
+	readyReasonSchedulerUnreachable = "scheduler_unreachable"
+)
+
+const (
+	readyStatusReady    = "ready"
+	readyStatusStarting = "starting"
+	readyStatusNotReady = "not_ready"
+)
+
+// startingReasons are the reasons reported while the modules are starting. They're expected to resolve
+// without intervention, unlike the other reasons.
+var startingReasons = map[string]struct{}{
+	readyReasonStarting:  {},
+	readyReasonWALReplay: {},
+	readyReasonStoreSync: {},
+}
+
+// moduleStartingReasons are the reasons reported while specific modules are starting, describing what
+// they're doing while starting.
+var moduleStartingReasons = map[string]string{
+	IngesterService: readyReasonWALReplay,
+	StoreGateway:    readyReasonStoreSync,
+}
+
+// readinessReason is a reason why Mimir is not ready.
+type readinessReason struct {
+	Module  string `json:"module"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
+
+// readinessStatus is the response of the /ready endpoint, when JSON is requested.
+type readinessStatus struct {
+	// Status is ready, starting (all the reasons are expected to resolve once the modules
+	// completed the startup) or not_ready.
+	Status  string            `json:"status"`
+	Reasons []readinessReason `json:"reasons,omitempty"`
+}
+
+// readinessChecker is implemented by the components having a readiness check in addition to their
+// service running.
+type readinessChecker interface {
+	CheckReady(ctx context.Context) error
+}
+
+type readinessCheck struct {
+	module  string
+	service string // The module running the service of the component.
+	reason  string
+	checker readinessChecker
+}
+
+// readinessChecks returns the readiness checks of the components running in the process.
+func (t *Mimir) readinessChecks() []readinessCheck {
+	var checks []readinessCheck
+
+	// Ingester has a special check that makes sure that it was able to register into the ring,
+	// and that all other ring entries are OK too.
+	if t.Ingester != nil {
+		checks = append(checks, readinessCheck{module: Ingester, service: IngesterService, reason: readyReasonRingNotHealthy, checker: t.Ingester})
+	}
+
+	// Query Frontend has a special check that makes sure that a querier (or a query-scheduler, when
+	// used) is attached before it signals itself as ready.
+	if t.Frontend != nil {
+		checks = append(checks, readinessCheck{module: QueryFrontend, service: QueryFrontend, reason: readyReasonNoQueriersConnected, checker: t.Frontend})
+	}
+	if t.FrontendV2 != nil {
+		checks = append(checks, readinessCheck{module: QueryFrontend, service: QueryFrontend, reason: readyReasonSchedulerUnreachable, checker: t.FrontendV2})
+	}
+
+	return checks
+}
+
+// checkReadiness returns the reasons why Mimir is not ready, or none if it's ready.
+func checkReadiness(ctx context.Context, serviceMap map[string]services.Service, checks []readinessCheck) []readinessReason {
+	var reasons []readinessReason
+
+	for module, s := range serviceMap {
+		switch state := s.State(); state {
+		case services.Running:
+		case services.New, services.Starting:
+			reason, ok := moduleStartingReasons[module]
+			if !ok {
+				reason = readyReasonStarting
+			}
+			reasons = append(reasons, readinessReason{Module: module, Reason: reason, Message: state.String()})
+		case services.Failed:
+			msg := state.String()
+			if err := s.FailureCase(); err != nil {
+				msg = fmt.Sprintf("%s: %v", msg, err)
+			}
+			reasons = append(reasons, readinessReason{Module: module, Reason: readyReasonFailed, Message: msg})
+		default:
+			reasons = append(reasons, readinessReason{Module: module, Reason: readyReasonStopping, Message: state.String()})
+		}
+	}
+
+	for _, c := range checks {
+		// The check is only meaningful once the service is running, otherwise its state is already reported.
+		if s, ok := serviceMap[c.service]; !ok || s.State() != services.Running {
+			continue
+		}
+		if err := c.checker.CheckReady(ctx); err != nil {
+			reasons = append(reasons, readinessReason{Module: c.module, Reason: c.reason, Message: err.Error()})
+		}
+	}
+
+	sort.Slice(reasons, func(i, j int) bool {
+		if reasons[i].Module != reasons[j].Module {
+			return reasons[i].Module < reasons[j].Module
+		}
+		return reasons[i].Reason < reasons[j].Reason
+	})
+	return reasons
+}
+
+// readinessStatusOf returns the readiness status given the reasons why Mimir is not ready.
+func readinessStatusOf(reasons []readinessReason) string {
+	if len(reasons) == 0 {
+		return readyStatusReady
+	}
+	for _, r := range reasons {
+		if _, ok := startingReasons[r.Reason]; !ok {
+			return readyStatusNotReady
+		}
+	}
+	return readyStatusStarting
+}
+
+// readyHandler returns the handler of the /ready endpoint, which reports whether all the modules are running
+// and the components are ready. If not ready, the response lists the reasons, as JSON if requested through
+// the Accept header, or as text otherwise.
+func (t *Mimir) readyHandler(reg prometheus.Registerer) http.HandlerFunc {
+	failing := promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+		Name: "cortex_ready_check_failing",
+		Help: "Whether the readiness check failed for the reason, as of the last check. The series are removed once the reason resolves.",
+	}, []string{"module", "reason"})
+	failingMtx := sync.Mutex{}
+
+	checks := t.readinessChecks()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		reasons := checkReadiness(r.Context(), t.ServiceMap, checks)
+
+		failingMtx.Lock()
+		failing.Reset()
+		for _, reason := range reasons {
+			failing.WithLabelValues(reason.Module, reason.Reason).Set(1)
+		}
+		failingMtx.Unlock()
+
+		code := http.StatusOK
+		if len(reasons) > 0 {
+			code = http.StatusServiceUnavailable
+		}
+
+		if strings.Contains(r.Header.Get("Accept"), "application/json") {
+			data, err := json.Marshal(readinessStatus{Status: readinessStatusOf(reasons), Reasons: reasons})
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(code)
+			_, _ = w.Write(data)
+			return
+		}
+
+		if len(reasons) == 0 {
+			util.WriteTextResponse(w, "ready")
+			return
+		}
+
+		msg := strings.Builder{}
+		msg.WriteString("Not ready:\n")
+		for _, reason := range reasons {
+			msg.WriteString(fmt.Sprintf("%s: %s: %s\n", reason.Module, reason.Reason, reason.Message))
+		}
+		http.Error(w, msg.String(), code)
+	}
+}
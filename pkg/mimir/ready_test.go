@@ -0,0 +1,174 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package mimir
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/grafana/dskit/services"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type readinessCheckerFunc func(ctx context.Context) error
+
+func (f readinessCheckerFunc) CheckReady(ctx context.Context) error {
+	return f(ctx)
+}
+
+func TestCheckReadiness(t *testing.T) {
+	runningService := func(t *testing.T) services.Service {
+		s := services.NewIdleService(nil, nil)
+		require.NoError(t, services.StartAndAwaitRunning(context.Background(), s))
+		t.Cleanup(func() { _ = services.StopAndAwaitTerminated(context.Background(), s) })
+		return s
+	}
+	startingService := func(t *testing.T) services.Service {
+		done := make(chan struct{})
+		s := services.NewIdleService(func(context.Context) error {
+			<-done
+			return nil
+		}, nil)
+		require.NoError(t, s.StartAsync(context.Background()))
+		t.Cleanup(func() { close(done) })
+		return s
+	}
+	failedService := func(t *testing.T) services.Service {
+		s := services.NewIdleService(func(context.Context) error { return errors.New("failed to start") }, nil)
+		require.Error(t, services.StartAndAwaitRunning(context.Background(), s))
+		return s
+	}
+
+	ready := readinessCheckerFunc(func(context.Context) error { return nil })
+	notReady := readinessCheckerFunc(func(context.Context) error { return errors.New("not ready") })
+
+	tests := map[string]struct {
+		serviceMap      func(t *testing.T) map[string]services.Service
+		checks          []readinessCheck
+		expectedReasons []readinessReason
+		expectedStatus  string
+	}{
+		"all services running and checks succeeding": {
+			serviceMap: func(t *testing.T) map[string]services.Service {
+				return map[string]services.Service{IngesterService: runningService(t), QueryFrontend: runningService(t)}
+			},
+			checks: []readinessCheck{
+				{module: Ingester, service: IngesterService, reason: readyReasonRingNotHealthy, checker: ready},
+				{module: QueryFrontend, service: QueryFrontend, reason: readyReasonSchedulerUnreachable, checker: ready},
+			},
+			expectedStatus: readyStatusReady,
+		},
+		"services starting": {
+			serviceMap: func(t *testing.T) map[string]services.Service {
+				return map[string]services.Service{IngesterService: startingService(t), StoreGateway: startingService(t), Distributor: startingService(t)}
+			},
+			checks: []readinessCheck{
+				{module: Ingester, service: IngesterService, reason: readyReasonRingNotHealthy, checker: notReady},
+			},
+			expectedReasons: []readinessReason{
+				{Module: Distributor, Reason: readyReasonStarting, Message: "Starting"},
+				{Module: IngesterService, Reason: readyReasonWALReplay, Message: "Starting"},
+				{Module: StoreGateway, Reason: readyReasonStoreSync, Message: "Starting"},
+			},
+			expectedStatus: readyStatusStarting,
+		},
+		"service failed": {
+			serviceMap: func(t *testing.T) map[string]services.Service {
+				return map[string]services.Service{Distributor: failedService(t), StoreGateway: startingService(t)}
+			},
+			expectedReasons: []readinessReason{
+				{Module: Distributor, Reason: readyReasonFailed, Message: "Failed: failed to start"},
+				{Module: StoreGateway, Reason: readyReasonStoreSync, Message: "Starting"},
+			},
+			expectedStatus: readyStatusNotReady,
+		},
+		"checks failing": {
+			serviceMap: func(t *testing.T) map[string]services.Service {
+				return map[string]services.Service{IngesterService: runningService(t), QueryFrontend: runningService(t)}
+			},
+			checks: []readinessCheck{
+				{module: Ingester, service: IngesterService, reason: readyReasonRingNotHealthy, checker: notReady},
+				{module: QueryFrontend, service: QueryFrontend, reason: readyReasonSchedulerUnreachable, checker: notReady},
+			},
+			expectedReasons: []readinessReason{
+				{Module: Ingester, Reason: readyReasonRingNotHealthy, Message: "not ready"},
+				{Module: QueryFrontend, Reason: readyReasonSchedulerUnreachable, Message: "not ready"},
+			},
+			expectedStatus: readyStatusNotReady,
+		},
+	}
+
+	for name, testData := range tests {
+		t.Run(name, func(t *testing.T) {
+			reasons := checkReadiness(context.Background(), testData.serviceMap(t), testData.checks)
+			assert.Equal(t, testData.expectedReasons, reasons)
+			assert.Equal(t, testData.expectedStatus, readinessStatusOf(reasons))
+		})
+	}
+}
+
+func TestReadyHandler(t *testing.T) {
+	starting := make(chan struct{})
+	t.Cleanup(func() { close(starting) })
+
+	storeGateway := services.NewIdleService(func(context.Context) error {
+		<-starting
+		return nil
+	}, nil)
+	require.NoError(t, storeGateway.StartAsync(context.Background()))
+
+	reg := prometheus.NewPedanticRegistry()
+	m := &Mimir{ServiceMap: map[string]services.Service{StoreGateway: storeGateway}}
+	handler := m.readyHandler(reg)
+
+	t.Run("text response", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Equal(t, "Not ready:\nstore-gateway: store_sync_in_progress: Starting\n\n", rec.Body.String())
+	})
+
+	t.Run("JSON response", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/ready", nil)
+		req.Header.Set("Accept", "application/json")
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+		var status readinessStatus
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+		assert.Equal(t, readinessStatus{
+			Status:  readyStatusStarting,
+			Reasons: []readinessReason{{Module: StoreGateway, Reason: readyReasonStoreSync, Message: "Starting"}},
+		}, status)
+	})
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_ready_check_failing Whether the readiness check failed for the reason, as of the last check. The series are removed once the reason resolves.
+		# TYPE cortex_ready_check_failing gauge
+		cortex_ready_check_failing{module="store-gateway",reason="store_sync_in_progress"} 1
+	`), "cortex_ready_check_failing"))
+
+	// Once the store-gateway is running, Mimir is ready.
+	starting <- struct{}{}
+	require.NoError(t, storeGateway.AwaitRunning(context.Background()))
+	t.Cleanup(func() { _ = services.StopAndAwaitTerminated(context.Background(), storeGateway) })
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "ready", rec.Body.String())
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(""), "cortex_ready_check_failing"))
+}
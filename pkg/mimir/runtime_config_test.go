@@ -9,6 +9,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/grafana/dskit/flagext"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -19,7 +20,9 @@ import (
 // a configmap is limited to 1MB, we need to minimise the limits file.
 // One way to do it is via YAML anchors.
 func TestLoadRuntimeConfig_ShouldLoadAnchoredYAML(t *testing.T) {
-	validation.SetDefaultLimitsForYAMLUnmarshalling(validation.Limits{})
+	defaults := validation.Limits{}
+	flagext.DefaultValues(&defaults)
+	validation.SetDefaultLimitsForYAMLUnmarshalling(defaults)
 
 	yamlFile := strings.NewReader(`
 overrides:
@@ -36,15 +39,13 @@ overrides:
 	runtimeCfg, err := loadRuntimeConfig(yamlFile)
 	require.NoError(t, err)
 
-	limits := validation.Limits{
-		IngestionRate:                       1500,
-		IngestionBurstSize:                  15000,
-		MaxGlobalSeriesPerUser:              15000,
-		MaxGlobalSeriesPerMetric:            7000,
-		RulerMaxRulesPerRuleGroup:           20,
-		RulerMaxRuleGroupsPerTenant:         20,
-		NotificationRateLimitPerIntegration: validation.NotificationRateLimitMap{},
-	}
+	limits := defaults
+	limits.IngestionRate = 1500
+	limits.IngestionBurstSize = 15000
+	limits.MaxGlobalSeriesPerUser = 15000
+	limits.MaxGlobalSeriesPerMetric = 7000
+	limits.RulerMaxRulesPerRuleGroup = 20
+	limits.RulerMaxRuleGroupsPerTenant = 20
 
 	loadedLimits := runtimeCfg.(*runtimeConfigValues).TenantLimits
 	require.Equal(t, 3, len(loadedLimits))
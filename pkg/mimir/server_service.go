@@ -7,13 +7,18 @@ package mimir
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 
 	"github.com/go-kit/log/level"
 	"github.com/grafana/dskit/services"
+	node_https "github.com/prometheus/node_exporter/https"
 	"github.com/weaveworks/common/server"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
 	util_log "github.com/grafana/mimir/pkg/util/log"
+	"github.com/grafana/mimir/pkg/util/tlsreload"
 )
 
 // NewServerService constructs service from Server component.
@@ -59,6 +64,45 @@ func NewServerService(serv *server.Server, servicesToWaitFor func() []services.S
 	return services.NewBasicService(nil, runFn, stoppingFn)
 }
 
+// newServerWithTLSReload creates the server, configuring the HTTP and gRPC TLS so that the
+// certificates and the client CAs are reloaded once their files changed.
+func newServerWithTLSReload(cfg server.Config) (*server.Server, error) {
+	httpTLS, grpcTLS := cfg.HTTPTLSConfig, cfg.GRPCTLSConfig
+
+	// The server only enables TLS if both the certificate and the key are configured.
+	tlsEnabled := func(c node_https.TLSStruct) bool {
+		return c.TLSCertPath != "" && c.TLSKeyPath != ""
+	}
+
+	if tlsEnabled(grpcTLS) {
+		tlsCfg, err := tlsreload.ServerConfig(grpcTLS)
+		if err != nil {
+			return nil, fmt.Errorf("error generating grpc tls config: %v", err)
+		}
+		cfg.GRPCTLSConfig = node_https.TLSStruct{}
+		cfg.GRPCOptions = append(cfg.GRPCOptions, grpc.Creds(credentials.NewTLS(tlsCfg)))
+	}
+
+	var httpTLSCfg *tls.Config
+	if tlsEnabled(httpTLS) {
+		var err error
+		if httpTLSCfg, err = tlsreload.ServerConfig(httpTLS); err != nil {
+			return nil, fmt.Errorf("error generating http tls config: %v", err)
+		}
+		cfg.HTTPTLSConfig = node_https.TLSStruct{}
+	}
+
+	serv, err := server.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// The server serves HTTPS if the TLS config is set. Since the certificate and key paths are
+	// not set, the certificate is only loaded by the TLS config.
+	serv.HTTPServer.TLSConfig = httpTLSCfg
+	return serv, nil
+}
+
 // DisableSignalHandling puts a dummy signal handler
 func DisableSignalHandling(config *server.Config) {
 	config.SignalHandler = make(ignoreSignalHandler)
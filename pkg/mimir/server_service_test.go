@@ -7,13 +7,30 @@ package mimir
 
 import (
 	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/grafana/dskit/crypto/tls"
 	"github.com/grafana/dskit/services"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/weaveworks/common/server"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	"github.com/grafana/mimir/integration/ca"
+	"github.com/grafana/mimir/pkg/util/tlsreload"
 )
 
 func TestServerStopViaContext(t *testing.T) {
@@ -78,3 +95,71 @@ func TestServerStopViaStop(t *testing.T) {
 	require.Error(t, s.AwaitTerminated(context.Background()))
 	require.Equal(t, services.Failed, s.State())
 }
+
+func TestNewServerWithTLSReload(t *testing.T) {
+	// server registers some metrics to default registry
+	savedRegistry := prometheus.DefaultRegisterer
+	prometheus.DefaultRegisterer = prometheus.NewRegistry()
+	defer func() {
+		prometheus.DefaultRegisterer = savedRegistry
+	}()
+
+	dir := t.TempDir()
+	caCert, serverCert, serverKey := filepath.Join(dir, "ca.crt"), filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")
+	testCA := ca.New("test")
+	require.NoError(t, testCA.WriteCACertificate(caCert))
+	require.NoError(t, testCA.WriteCertificate(&x509.Certificate{
+		Subject:     pkix.Name{CommonName: "server"},
+		DNSNames:    []string{"localhost"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, serverCert, serverKey))
+
+	freePort := func() int {
+		lis, err := net.Listen("tcp", "localhost:0")
+		require.NoError(t, err)
+		defer lis.Close()
+		return lis.Addr().(*net.TCPAddr).Port
+	}
+
+	cfg := server.Config{
+		HTTPListenAddress: "localhost",
+		HTTPListenPort:    freePort(),
+		GRPCListenAddress: "localhost",
+		GRPCListenPort:    freePort(),
+	}
+	cfg.HTTPTLSConfig.TLSCertPath, cfg.HTTPTLSConfig.TLSKeyPath = serverCert, serverKey
+	cfg.GRPCTLSConfig.TLSCertPath, cfg.GRPCTLSConfig.TLSKeyPath = serverCert, serverKey
+
+	serv, err := newServerWithTLSReload(cfg)
+	require.NoError(t, err)
+	serv.HTTP.Path("/test").HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+
+	s := NewServerService(serv, func() []services.Service { return nil })
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), s))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(context.Background(), s))
+	})
+
+	clientTLS, err := tlsreload.ClientConfig(tls.ClientConfig{CAPath: caCert, ServerName: "localhost"})
+	require.NoError(t, err)
+
+	// The HTTP server serves HTTPS.
+	httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: clientTLS}}
+	resp, err := httpClient.Get(fmt.Sprintf("https://localhost:%d/test", cfg.HTTPListenPort))
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, "ok", string(body))
+
+	// The gRPC server uses TLS. The health service is not registered, so the call is only expected
+	// to reach the server.
+	conn, err := grpc.Dial(fmt.Sprintf("localhost:%d", cfg.GRPCListenPort), grpc.WithTransportCredentials(credentials.NewTLS(clientTLS)))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = grpc_health_v1.NewHealthClient(conn).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+}
@@ -188,6 +188,8 @@ type TimeSeries struct {
 	// Sorted by time, oldest sample first.
 	Samples   []Sample   `protobuf:"bytes,2,rep,name=samples,proto3" json:"samples"`
 	Exemplars []Exemplar `protobuf:"bytes,3,rep,name=exemplars,proto3" json:"exemplars"`
+	// Timestamp (in milliseconds) when the counter, histogram or summary was created or reset. 0 if unknown.
+	CreatedTimestamp int64 `protobuf:"varint,6,opt,name=created_timestamp,json=createdTimestamp,proto3" json:"created_timestamp,omitempty"`
 }
 
 func (m *TimeSeries) Reset()      { *m = TimeSeries{} }
@@ -236,6 +238,13 @@ func (m *TimeSeries) GetExemplars() []Exemplar {
 	return nil
 }
 
+func (m *TimeSeries) GetCreatedTimestamp() int64 {
+	if m != nil {
+		return m.CreatedTimestamp
+	}
+	return 0
+}
+
 type LabelPair struct {
 	Name  []byte `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
 	Value []byte `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
@@ -511,51 +520,53 @@ func init() {
 func init() { proto.RegisterFile("mimir.proto", fileDescriptor_86d4d7485f544059) }
 
 var fileDescriptor_86d4d7485f544059 = []byte{
-	// 701 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x54, 0xbd, 0x6e, 0xd3, 0x5e,
-	0x14, 0xf7, 0xcd, 0x77, 0x4e, 0xd2, 0xfc, 0xad, 0xfb, 0xaf, 0x84, 0xd5, 0xc1, 0x49, 0xcd, 0x92,
-	0x01, 0x52, 0x54, 0x04, 0x08, 0x04, 0x83, 0x83, 0xd2, 0x52, 0xb5, 0xf9, 0xd0, 0x8d, 0x43, 0x05,
-	0x4b, 0x74, 0x93, 0xde, 0xb6, 0x16, 0xbe, 0xb1, 0xb1, 0x9d, 0xaa, 0xd9, 0x98, 0x98, 0x99, 0x79,
-	0x02, 0x9e, 0x00, 0x89, 0x37, 0xe8, 0xd8, 0xb1, 0x62, 0xa8, 0xa8, 0xbb, 0x74, 0xec, 0x23, 0x20,
-	0x5f, 0x3b, 0x71, 0xab, 0x8a, 0xad, 0xdb, 0x3d, 0xe7, 0xf7, 0x71, 0x8e, 0xcf, 0x39, 0x32, 0x94,
-	0xb8, 0xc9, 0x4d, 0xb7, 0xe1, 0xb8, 0xb6, 0x6f, 0xe3, 0xc2, 0xd8, 0x76, 0x7d, 0x76, 0xec, 0x8c,
-	0x56, 0x1e, 0x1f, 0x98, 0xfe, 0xe1, 0x74, 0xd4, 0x18, 0xdb, 0x7c, 0xed, 0xc0, 0x3e, 0xb0, 0xd7,
-	0x04, 0x61, 0x34, 0xdd, 0x17, 0x91, 0x08, 0xc4, 0x2b, 0x12, 0x6a, 0x3f, 0x53, 0x50, 0xde, 0x75,
-	0x4d, 0x9f, 0x11, 0xf6, 0x79, 0xca, 0x3c, 0x1f, 0xf7, 0x00, 0x7c, 0x93, 0x33, 0x8f, 0xb9, 0x26,
-	0xf3, 0x14, 0x54, 0x4b, 0xd7, 0x4b, 0xeb, 0xcb, 0x8d, 0xb9, 0x7d, 0xc3, 0x30, 0x39, 0xeb, 0x0b,
-	0xac, 0xb9, 0x72, 0x72, 0x5e, 0x95, 0x7e, 0x9f, 0x57, 0x71, 0xcf, 0x65, 0xd4, 0xb2, 0xec, 0xb1,
-	0xb1, 0xd0, 0x91, 0x1b, 0x1e, 0xf8, 0x25, 0xe4, 0xfa, 0xf6, 0xd4, 0x1d, 0x33, 0x25, 0x55, 0x43,
-	0xf5, 0xca, 0xfa, 0x6a, 0xe2, 0x76, 0xb3, 0x72, 0x23, 0x22, 0xb5, 0x26, 0x53, 0x4e, 0x62, 0x01,
-	0x7e, 0x05, 0x05, 0xce, 0x7c, 0xba, 0x47, 0x7d, 0xaa, 0xa4, 0x45, 0x2b, 0x4a, 0x22, 0x6e, 0x33,
-	0xdf, 0x35, 0xc7, 0xed, 0x18, 0x6f, 0x66, 0x4e, 0xce, 0xab, 0x88, 0x2c, 0xf8, 0xf8, 0x35, 0xac,
-	0x78, 0x9f, 0x4c, 0x67, 0x68, 0xd1, 0x11, 0xb3, 0x86, 0x13, 0xca, 0xd9, 0xf0, 0x88, 0x5a, 0xe6,
-	0x1e, 0xf5, 0x4d, 0x7b, 0xa2, 0x5c, 0xe5, 0x6b, 0xa8, 0x5e, 0x20, 0x0f, 0x42, 0xca, 0x4e, 0xc8,
-	0xe8, 0x50, 0xce, 0xde, 0x2f, 0x70, 0xad, 0x0a, 0x90, 0xf4, 0x83, 0xf3, 0x90, 0xd6, 0x7b, 0x5b,
-	0xb2, 0x84, 0x0b, 0x90, 0x21, 0x83, 0x9d, 0x96, 0x8c, 0xb4, 0xff, 0x60, 0x29, 0xee, 0xde, 0x73,
-	0xec, 0x89, 0xc7, 0xb4, 0x5f, 0x08, 0x20, 0x99, 0x0e, 0xd6, 0x21, 0x27, 0x2a, 0xcf, 0x67, 0xf8,
-	0x7f, 0xd2, 0xb8, 0xa8, 0xd7, 0xa3, 0xa6, 0xdb, 0x5c, 0x8e, 0x47, 0x58, 0x16, 0x29, 0x7d, 0x8f,
-	0x3a, 0x3e, 0x73, 0x49, 0x2c, 0xc4, 0x4f, 0x20, 0xef, 0x51, 0xee, 0x58, 0xcc, 0x53, 0x52, 0xc2,
-	0x43, 0x4e, 0x3c, 0xfa, 0x02, 0x10, 0x1f, 0x2d, 0x91, 0x39, 0x0d, 0x3f, 0x87, 0x22, 0x3b, 0x66,
-	0xdc, 0xb1, 0xa8, 0xeb, 0xc5, 0x03, 0xc3, 0x89, 0xa6, 0x15, 0x43, 0xb1, 0x2a, 0xa1, 0x6a, 0xcf,
-	0xa0, 0xb8, 0x68, 0x0a, 0x63, 0xc8, 0x84, 0xd3, 0x52, 0x50, 0x0d, 0xd5, 0xcb, 0x44, 0xbc, 0xf1,
-	0x32, 0x64, 0x8f, 0xa8, 0x35, 0x8d, 0x56, 0x58, 0x26, 0x51, 0xa0, 0xe9, 0x90, 0x8b, 0xfa, 0xc0,
-	0xab, 0x50, 0x16, 0x1b, 0xf7, 0x29, 0x77, 0x86, 0xdc, 0x13, 0xb4, 0x34, 0x29, 0x2d, 0x72, 0x6d,
-	0x2f, 0xb1, 0x08, 0x7d, 0xd1, 0xdc, 0xe2, 0x7b, 0x0a, 0x2a, 0xb7, 0x17, 0x89, 0x5f, 0x40, 0xc6,
-	0x9f, 0x39, 0x11, 0xaf, 0xb2, 0xfe, 0xf0, 0x5f, 0x0b, 0x8f, 0x43, 0x63, 0xe6, 0x30, 0x22, 0x04,
-	0xf8, 0x11, 0x60, 0x2e, 0x72, 0xc3, 0x7d, 0xca, 0x4d, 0x6b, 0x26, 0x96, 0x2e, 0x5a, 0x29, 0x12,
-	0x39, 0x42, 0x36, 0x04, 0x10, 0xee, 0x3a, 0xfc, 0xcc, 0x43, 0x66, 0x39, 0x4a, 0x46, 0xe0, 0xe2,
-	0x1d, 0xe6, 0xa6, 0x13, 0xd3, 0x57, 0xb2, 0x51, 0x2e, 0x7c, 0x6b, 0x33, 0x80, 0xa4, 0x12, 0x2e,
-	0x41, 0x7e, 0xd0, 0xd9, 0xee, 0x74, 0x77, 0x3b, 0xb2, 0x14, 0x06, 0x6f, 0xbb, 0x83, 0x8e, 0xd1,
-	0x22, 0x32, 0xc2, 0x45, 0xc8, 0x6e, 0xea, 0x83, 0xcd, 0x96, 0x9c, 0xc2, 0x4b, 0x50, 0x7c, 0xb7,
-	0xd5, 0x37, 0xba, 0x9b, 0x44, 0x6f, 0xcb, 0x69, 0x8c, 0xa1, 0x22, 0x90, 0x24, 0x97, 0x09, 0xa5,
-	0xfd, 0x41, 0xbb, 0xad, 0x93, 0x0f, 0x72, 0x36, 0xbc, 0xaa, 0xad, 0xce, 0x46, 0x57, 0xce, 0xe1,
-	0x32, 0x14, 0xfa, 0x86, 0x6e, 0xb4, 0xfa, 0x2d, 0x43, 0xce, 0x6b, 0xdb, 0x90, 0x8b, 0x4a, 0xdf,
-	0xc3, 0x35, 0x69, 0x5f, 0x11, 0x14, 0xe6, 0x17, 0x70, 0x1f, 0xd7, 0x79, 0xeb, 0x24, 0xe6, 0xfb,
-	0xbc, 0x73, 0x08, 0xe9, 0x3b, 0x87, 0xd0, 0x7c, 0x73, 0x7a, 0xa1, 0x4a, 0x67, 0x17, 0xaa, 0x74,
-	0x7d, 0xa1, 0xa2, 0x2f, 0x81, 0x8a, 0x7e, 0x04, 0x2a, 0x3a, 0x09, 0x54, 0x74, 0x1a, 0xa8, 0xe8,
-	0x4f, 0xa0, 0xa2, 0xab, 0x40, 0x95, 0xae, 0x03, 0x15, 0x7d, 0xbb, 0x54, 0xa5, 0xd3, 0x4b, 0x55,
-	0x3a, 0xbb, 0x54, 0xa5, 0x8f, 0x79, 0xf1, 0xbb, 0x73, 0x46, 0xa3, 0x9c, 0xf8, 0x71, 0x3d, 0xfd,
-	0x1b, 0x00, 0x00, 0xff, 0xff, 0x40, 0x35, 0x74, 0x55, 0x00, 0x05, 0x00, 0x00,
+	// 722 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x54, 0xcd, 0x6e, 0xd3, 0x4c,
+	0x14, 0xf5, 0xe4, 0x3f, 0x37, 0x69, 0x3e, 0x7f, 0x43, 0x25, 0xac, 0x2e, 0x9c, 0xd4, 0x6c, 0x22,
+	0x01, 0x29, 0x2a, 0x02, 0x04, 0x82, 0x85, 0x83, 0xd2, 0x52, 0xb5, 0xf9, 0xd1, 0xc4, 0xa1, 0x82,
+	0x4d, 0x34, 0x49, 0xa6, 0xad, 0x85, 0x1d, 0x1b, 0x7b, 0x52, 0x35, 0x3b, 0x56, 0xac, 0x59, 0xf3,
+	0x04, 0x3c, 0x01, 0xcf, 0xd0, 0x65, 0x97, 0x15, 0x8b, 0x8a, 0xa6, 0x12, 0xea, 0xb2, 0x8f, 0x80,
+	0x3c, 0x76, 0xe2, 0x56, 0x15, 0xbb, 0xee, 0xe6, 0xde, 0x73, 0xce, 0xbd, 0x77, 0xee, 0x1c, 0x0d,
+	0x14, 0x6c, 0xd3, 0x36, 0xbd, 0x9a, 0xeb, 0x39, 0xdc, 0xc1, 0xb9, 0xa1, 0xe3, 0x71, 0x76, 0xe4,
+	0x0e, 0x56, 0x1e, 0xef, 0x9b, 0xfc, 0x60, 0x32, 0xa8, 0x0d, 0x1d, 0x7b, 0x6d, 0xdf, 0xd9, 0x77,
+	0xd6, 0x04, 0x61, 0x30, 0xd9, 0x13, 0x91, 0x08, 0xc4, 0x29, 0x14, 0x6a, 0x3f, 0x13, 0x50, 0xdc,
+	0xf5, 0x4c, 0xce, 0x08, 0xfb, 0x3c, 0x61, 0x3e, 0xc7, 0x1d, 0x00, 0x6e, 0xda, 0xcc, 0x67, 0x9e,
+	0xc9, 0x7c, 0x05, 0x55, 0x92, 0xd5, 0xc2, 0xfa, 0x72, 0x6d, 0x5e, 0xbe, 0x66, 0x98, 0x36, 0xeb,
+	0x0a, 0xac, 0xbe, 0x72, 0x7c, 0x56, 0x96, 0x7e, 0x9d, 0x95, 0x71, 0xc7, 0x63, 0xd4, 0xb2, 0x9c,
+	0xa1, 0xb1, 0xd0, 0x91, 0x6b, 0x35, 0xf0, 0x4b, 0xc8, 0x74, 0x9d, 0x89, 0x37, 0x64, 0x4a, 0xa2,
+	0x82, 0xaa, 0xa5, 0xf5, 0xd5, 0xb8, 0xda, 0xf5, 0xce, 0xb5, 0x90, 0xd4, 0x18, 0x4f, 0x6c, 0x12,
+	0x09, 0xf0, 0x2b, 0xc8, 0xd9, 0x8c, 0xd3, 0x11, 0xe5, 0x54, 0x49, 0x8a, 0x51, 0x94, 0x58, 0xdc,
+	0x64, 0xdc, 0x33, 0x87, 0xcd, 0x08, 0xaf, 0xa7, 0x8e, 0xcf, 0xca, 0x88, 0x2c, 0xf8, 0xf8, 0x35,
+	0xac, 0xf8, 0x9f, 0x4c, 0xb7, 0x6f, 0xd1, 0x01, 0xb3, 0xfa, 0x63, 0x6a, 0xb3, 0xfe, 0x21, 0xb5,
+	0xcc, 0x11, 0xe5, 0xa6, 0x33, 0x56, 0x2e, 0xb3, 0x15, 0x54, 0xcd, 0x91, 0xfb, 0x01, 0x65, 0x27,
+	0x60, 0xb4, 0xa8, 0xcd, 0xde, 0x2f, 0x70, 0xad, 0x0c, 0x10, 0xcf, 0x83, 0xb3, 0x90, 0xd4, 0x3b,
+	0x5b, 0xb2, 0x84, 0x73, 0x90, 0x22, 0xbd, 0x9d, 0x86, 0x8c, 0xb4, 0xff, 0x60, 0x29, 0x9a, 0xde,
+	0x77, 0x9d, 0xb1, 0xcf, 0xb4, 0x3f, 0x08, 0x20, 0xde, 0x0e, 0xd6, 0x21, 0x23, 0x3a, 0xcf, 0x77,
+	0x78, 0x2f, 0x1e, 0x5c, 0xf4, 0xeb, 0x50, 0xd3, 0xab, 0x2f, 0x47, 0x2b, 0x2c, 0x8a, 0x94, 0x3e,
+	0xa2, 0x2e, 0x67, 0x1e, 0x89, 0x84, 0xf8, 0x09, 0x64, 0x7d, 0x6a, 0xbb, 0x16, 0xf3, 0x95, 0x84,
+	0xa8, 0x21, 0xc7, 0x35, 0xba, 0x02, 0x10, 0x97, 0x96, 0xc8, 0x9c, 0x86, 0x9f, 0x43, 0x9e, 0x1d,
+	0x31, 0xdb, 0xb5, 0xa8, 0xe7, 0x47, 0x0b, 0xc3, 0xb1, 0xa6, 0x11, 0x41, 0x91, 0x2a, 0xa6, 0xe2,
+	0x87, 0xf0, 0xff, 0xd0, 0x63, 0x94, 0xb3, 0x51, 0x5f, 0x3c, 0x1c, 0xa7, 0xb6, 0xab, 0x64, 0x2a,
+	0xa8, 0x9a, 0x24, 0x72, 0x04, 0x18, 0xf3, 0xbc, 0xf6, 0x0c, 0xf2, 0x8b, 0x1b, 0x60, 0x0c, 0xa9,
+	0x60, 0xb5, 0x0a, 0xaa, 0xa0, 0x6a, 0x91, 0x88, 0x33, 0x5e, 0x86, 0xf4, 0x21, 0xb5, 0x26, 0xe1,
+	0x7b, 0x17, 0x49, 0x18, 0x68, 0x3a, 0x64, 0xc2, 0xa1, 0xf1, 0x2a, 0x14, 0x17, 0x5d, 0xfa, 0xb6,
+	0x2f, 0x68, 0x49, 0x52, 0x58, 0xe4, 0x9a, 0x7e, 0x5c, 0x22, 0xa8, 0x8b, 0xe6, 0x25, 0xbe, 0x27,
+	0xa0, 0x74, 0xf3, 0xd5, 0xf1, 0x0b, 0x48, 0xf1, 0xa9, 0x1b, 0xf2, 0x4a, 0xeb, 0x0f, 0xfe, 0xe5,
+	0x8e, 0x28, 0x34, 0xa6, 0x2e, 0x23, 0x42, 0x80, 0x1f, 0x01, 0xb6, 0x45, 0xae, 0xbf, 0x47, 0x6d,
+	0xd3, 0x9a, 0x0a, 0x87, 0x88, 0x51, 0xf2, 0x44, 0x0e, 0x91, 0x0d, 0x01, 0x04, 0xc6, 0x08, 0xae,
+	0x79, 0xc0, 0x2c, 0x57, 0x49, 0x09, 0x5c, 0x9c, 0x83, 0xdc, 0x64, 0x6c, 0x72, 0x25, 0x1d, 0xe6,
+	0x82, 0xb3, 0x36, 0x05, 0x88, 0x3b, 0xe1, 0x02, 0x64, 0x7b, 0xad, 0xed, 0x56, 0x7b, 0xb7, 0x25,
+	0x4b, 0x41, 0xf0, 0xb6, 0xdd, 0x6b, 0x19, 0x0d, 0x22, 0x23, 0x9c, 0x87, 0xf4, 0xa6, 0xde, 0xdb,
+	0x6c, 0xc8, 0x09, 0xbc, 0x04, 0xf9, 0x77, 0x5b, 0x5d, 0xa3, 0xbd, 0x49, 0xf4, 0xa6, 0x9c, 0xc4,
+	0x18, 0x4a, 0x02, 0x89, 0x73, 0xa9, 0x40, 0xda, 0xed, 0x35, 0x9b, 0x3a, 0xf9, 0x20, 0xa7, 0x03,
+	0x0b, 0x6e, 0xb5, 0x36, 0xda, 0x72, 0x06, 0x17, 0x21, 0xd7, 0x35, 0x74, 0xa3, 0xd1, 0x6d, 0x18,
+	0x72, 0x56, 0xdb, 0x86, 0x4c, 0xd8, 0xfa, 0x0e, 0xac, 0xa7, 0x7d, 0x45, 0x90, 0x9b, 0xdb, 0xe5,
+	0x2e, 0xac, 0x7c, 0xc3, 0x12, 0xf3, 0xf7, 0xbc, 0x65, 0x84, 0xe4, 0x2d, 0x23, 0xd4, 0xdf, 0x9c,
+	0x9c, 0xab, 0xd2, 0xe9, 0xb9, 0x2a, 0x5d, 0x9d, 0xab, 0xe8, 0xcb, 0x4c, 0x45, 0x3f, 0x66, 0x2a,
+	0x3a, 0x9e, 0xa9, 0xe8, 0x64, 0xa6, 0xa2, 0xdf, 0x33, 0x15, 0x5d, 0xce, 0x54, 0xe9, 0x6a, 0xa6,
+	0xa2, 0x6f, 0x17, 0xaa, 0x74, 0x72, 0xa1, 0x4a, 0xa7, 0x17, 0xaa, 0xf4, 0x31, 0x2b, 0xfe, 0x46,
+	0x77, 0x30, 0xc8, 0x88, 0x5f, 0xee, 0xe9, 0xdf, 0x01, 0x00, 0xfb, 0x88, 0xe8, 0xfc, 0x2d, 0x05,
+	0x00, 0x00,
 }
 
 func (x WriteRequest_SourceEnum) String() string {
@@ -679,6 +690,9 @@ func (this *TimeSeries) Equal(that interface{}) bool {
 			return false
 		}
 	}
+	if this.CreatedTimestamp != that1.CreatedTimestamp {
+		return false
+	}
 	return true
 }
 func (this *LabelPair) Equal(that interface{}) bool {
@@ -860,7 +874,7 @@ func (this *TimeSeries) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 7)
+	s := make([]string, 0, 8)
 	s = append(s, "&mimirpb.TimeSeries{")
 	s = append(s, "Labels: "+fmt.Sprintf("%#v", this.Labels)+",\n")
 	if this.Samples != nil {
@@ -877,6 +891,7 @@ func (this *TimeSeries) GoString() string {
 		}
 		s = append(s, "Exemplars: "+fmt.Sprintf("%#v", vs)+",\n")
 	}
+	s = append(s, "CreatedTimestamp: "+fmt.Sprintf("%#v", this.CreatedTimestamp)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -1056,6 +1071,11 @@ func (m *TimeSeries) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.CreatedTimestamp != 0 {
+		i = encodeVarintMimir(dAtA, i, uint64(m.CreatedTimestamp))
+		i--
+		dAtA[i] = 0x30
+	}
 	if len(m.Exemplars) > 0 {
 		for iNdEx := len(m.Exemplars) - 1; iNdEx >= 0; iNdEx-- {
 			{
@@ -1377,6 +1397,9 @@ func (m *TimeSeries) Size() (n int) {
 			n += 1 + l + sovMimir(uint64(l))
 		}
 	}
+	if m.CreatedTimestamp != 0 {
+		n += 1 + sovMimir(uint64(m.CreatedTimestamp))
+	}
 	return n
 }
 
@@ -1523,6 +1546,7 @@ func (this *TimeSeries) String() string {
 		`Labels:` + fmt.Sprintf("%v", this.Labels) + `,`,
 		`Samples:` + repeatedStringForSamples + `,`,
 		`Exemplars:` + repeatedStringForExemplars + `,`,
+		`CreatedTimestamp:` + fmt.Sprintf("%v", this.CreatedTimestamp) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -1936,6 +1960,25 @@ func (m *TimeSeries) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CreatedTimestamp", wireType)
+			}
+			m.CreatedTimestamp = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMimir
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.CreatedTimestamp |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMimir(dAtA[iNdEx:])
@@ -301,5 +301,6 @@ func ReuseTimeseries(ts *TimeSeries) {
 		}
 	}
 	ts.Exemplars = ts.Exemplars[:0]
+	ts.CreatedTimestamp = 0
 	timeSeriesPool.Put(ts)
 }
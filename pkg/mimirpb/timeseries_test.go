@@ -62,10 +62,12 @@ func TestTimeseriesFromPool(t *testing.T) {
 		ts := TimeseriesFromPool()
 		ts.Labels = []LabelAdapter{{Name: "foo", Value: "bar"}}
 		ts.Samples = []Sample{{Value: 1, TimestampMs: 2}}
+		ts.CreatedTimestamp = 1
 		ReuseTimeseries(ts)
 
 		reused := TimeseriesFromPool()
 		assert.Len(t, reused.Labels, 0)
 		assert.Len(t, reused.Samples, 0)
+		assert.Zero(t, reused.CreatedTimestamp)
 	})
 }
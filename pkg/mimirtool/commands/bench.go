@@ -0,0 +1,458 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/api"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/storage/remote"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+var defaultBenchQueryTemplates = []string{
+	`sum(rate({{.Metric}}[5m]))`,
+	`sum by (instance) (rate({{.Metric}}[5m]))`,
+	`rate({{.Metric}}{instance="{{.Instance}}"}[5m])`,
+}
+
+// BenchCommand generates a reproducible write or query load against Grafana Mimir. The written series
+// are fully determined by the flags, so that the query load can target them without any coordination.
+type BenchCommand struct {
+	tenants        int
+	tenantIDPrefix string
+	series         int
+	metricNames    int
+	churnPeriod    time.Duration
+	duration       time.Duration
+	seed           int64
+
+	writeURL       string
+	scrapeInterval time.Duration
+	parallelism    int
+	batchSize      int
+	writeTimeout   time.Duration
+
+	queryURL         string
+	queryTemplates   []string
+	queryParallelism int
+	queryInterval    time.Duration
+	queryTimeout     time.Duration
+	queryRange       time.Duration
+	queryStep        time.Duration
+
+	metricsListenAddress string
+
+	// Runtime stuff.
+	writeRequestDuration *prometheus.HistogramVec
+	queryRequestDuration *prometheus.HistogramVec
+	seriesWritten        *prometheus.CounterVec
+}
+
+func (c *BenchCommand) Register(app *kingpin.Application, _ EnvVarNames) {
+	benchCmd := app.Command("bench", "Generate a reproducible load against Grafana Mimir, to size clusters.")
+	writeCmd := benchCmd.Command("write", "Generate a remote write load, with realistic series churn.").Action(c.write)
+	queryCmd := benchCmd.Command("query", "Generate a query load, running range queries from templates over the series written by the write command.").Action(c.query)
+
+	for _, cmd := range []*kingpin.CmdClause{writeCmd, queryCmd} {
+		cmd.Flag("tenants", "Number of tenants to generate the load for.").
+			Default("1").IntVar(&c.tenants)
+		cmd.Flag("tenant-id-prefix", "Prefix of the tenant IDs. The tenant IDs are the prefix followed by the tenant number, starting from 0.").
+			Default("bench-").StringVar(&c.tenantIDPrefix)
+		cmd.Flag("series", "Number of active series written per tenant.").
+			Default("1000").IntVar(&c.series)
+		cmd.Flag("metric-names", "Number of distinct metric names the series are spread across. Each instance exposes one series per metric name.").
+			Default("10").IntVar(&c.metricNames)
+		cmd.Flag("churn-period", "Period after which the series of each instance are replaced by new series, with a new pod label. The replacements are evenly spread over the period, so that series-count/churn-period series are churned per second. 0 to disable churn.").
+			Default("0s").DurationVar(&c.churnPeriod)
+		cmd.Flag("duration", "How long to generate the load for. 0 to run until interrupted.").
+			Default("0s").DurationVar(&c.duration)
+		cmd.Flag("seed", "Seed of the random generation of the queries, to reproduce the same query load.").
+			Default("1").Int64Var(&c.seed)
+		cmd.Flag("metrics-listen-address", "Address to serve metrics on.").
+			Default(":8080").StringVar(&c.metricsListenAddress)
+	}
+
+	writeCmd.Flag("write-url", "URL of the remote write endpoint, for example http://localhost:8080/api/v1/push.").
+		Required().StringVar(&c.writeURL)
+	writeCmd.Flag("scrape-interval", "Interval at which a sample is written for each series.").
+		Default("15s").DurationVar(&c.scrapeInterval)
+	writeCmd.Flag("parallelism", "Number of concurrent writers per tenant. The series of the tenant are sharded across them.").
+		Default("10").IntVar(&c.parallelism)
+	writeCmd.Flag("batch-size", "Number of series per write request.").
+		Default("1000").IntVar(&c.batchSize)
+	writeCmd.Flag("write-timeout", "Timeout for write requests.").
+		Default("5s").DurationVar(&c.writeTimeout)
+
+	queryCmd.Flag("query-url", "URL of the Prometheus compatible query API, for example http://localhost:8080/prometheus.").
+		Required().StringVar(&c.queryURL)
+	queryCmd.Flag("query-template", "Template of the queries to run, which can be repeated. The query to run is randomly chosen among the templates. The templates can reference {{.Metric}}, {{.Instance}} and {{.Pod}} of a random written series.").
+		Default(defaultBenchQueryTemplates...).StringsVar(&c.queryTemplates)
+	queryCmd.Flag("query-parallelism", "Number of concurrent queriers. Each querier runs queries for random tenants.").
+		Default("10").IntVar(&c.queryParallelism)
+	queryCmd.Flag("query-interval", "Interval between two queries of the same querier. 0 to run queries back to back.").
+		Default("1s").DurationVar(&c.queryInterval)
+	queryCmd.Flag("query-timeout", "Timeout for query requests.").
+		Default("20s").DurationVar(&c.queryTimeout)
+	queryCmd.Flag("query-range", "Time range of the range queries, ending now.").
+		Default("1h").DurationVar(&c.queryRange)
+	queryCmd.Flag("query-step", "Step of the range queries.").
+		Default("1m").DurationVar(&c.queryStep)
+}
+
+func (c *BenchCommand) validate() error {
+	if c.tenants <= 0 {
+		return errors.New("the number of tenants must be greater than 0")
+	}
+	if c.series <= 0 {
+		return errors.New("the number of series must be greater than 0")
+	}
+	if c.metricNames <= 0 || c.metricNames > c.series {
+		return errors.New("the number of metric names must be greater than 0 and not greater than the number of series")
+	}
+	if c.churnPeriod < 0 {
+		return errors.New("the churn period must not be negative")
+	}
+	return nil
+}
+
+func (c *BenchCommand) write(_ *kingpin.ParseContext) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+	if c.parallelism <= 0 || c.batchSize <= 0 || c.scrapeInterval <= 0 {
+		return errors.New("the parallelism, batch size and scrape interval must be greater than 0")
+	}
+
+	writeURL, err := url.Parse(c.writeURL)
+	if err != nil {
+		return errors.Wrap(err, "invalid write URL")
+	}
+
+	ctx, cancel := c.runContext()
+	defer cancel()
+	c.serveMetrics()
+
+	gen := c.seriesGenerator()
+	log.Infof("generating write load: tenants=%d series per tenant=%d churned series per second=%.2f", c.tenants, c.series, gen.churnRate())
+
+	var wg sync.WaitGroup
+	for t := 0; t < c.tenants; t++ {
+		tenantID := c.tenantID(t)
+		writeClient, err := remote.NewWriteClient("bench-"+tenantID, &remote.ClientConfig{
+			URL:     &config.URL{URL: writeURL},
+			Timeout: model.Duration(c.writeTimeout),
+			Headers: map[string]string{"X-Scope-OrgID": tenantID},
+		})
+		if err != nil {
+			return err
+		}
+
+		// Shard the series of the tenant across the writers.
+		seriesPerShard := (c.series + c.parallelism - 1) / c.parallelism
+		for from := 0; from < c.series; from += seriesPerShard {
+			to := from + seriesPerShard
+			if to > c.series {
+				to = c.series
+			}
+
+			wg.Add(1)
+			go func(tenantID string, client remote.WriteClient, from, to int) {
+				defer wg.Done()
+				c.runWriteShard(ctx, gen, tenantID, client, from, to)
+			}(tenantID, writeClient, from, to)
+		}
+	}
+
+	wg.Wait()
+	return nil
+}
+
+func (c *BenchCommand) runWriteShard(ctx context.Context, gen *benchSeriesGenerator, tenantID string, client remote.WriteClient, from, to int) {
+	ticker := time.NewTicker(c.scrapeInterval)
+	defer ticker.Stop()
+
+	for {
+		now := time.Now()
+		for i := from; i < to; i += c.batchSize {
+			end := i + c.batchSize
+			if end > to {
+				end = to
+			}
+
+			if err := c.writeBatch(ctx, client, gen.series(i, end, now)); err != nil {
+				log.WithError(err).WithField("tenant", tenantID).Warnln("failed to write series")
+				continue
+			}
+			c.seriesWritten.WithLabelValues(tenantID).Add(float64(end - i))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *BenchCommand) writeBatch(ctx context.Context, client remote.WriteClient, series []prompb.TimeSeries) error {
+	data, err := proto.Marshal(&prompb.WriteRequest{Timeseries: series})
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	err = client.Store(ctx, snappy.Encode(nil, data))
+	c.writeRequestDuration.WithLabelValues(successLabelValue(err)).Observe(time.Since(start).Seconds())
+	return err
+}
+
+func (c *BenchCommand) query(_ *kingpin.ParseContext) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+	if c.queryParallelism <= 0 || c.queryStep <= 0 {
+		return errors.New("the query parallelism and step must be greater than 0")
+	}
+
+	templates, err := parseBenchQueryTemplates(c.queryTemplates)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := c.runContext()
+	defer cancel()
+	c.serveMetrics()
+
+	queryClients := make([]v1.API, 0, c.tenants)
+	for t := 0; t < c.tenants; t++ {
+		client, err := api.NewClient(api.Config{
+			Address:      c.queryURL,
+			RoundTripper: &setTenantIDTransport{RoundTripper: api.DefaultRoundTripper, tenantID: c.tenantID(t)},
+		})
+		if err != nil {
+			return err
+		}
+		queryClients = append(queryClients, v1.NewAPI(client))
+	}
+
+	gen := c.seriesGenerator()
+	log.Infof("generating query load: tenants=%d queriers=%d templates=%d", c.tenants, c.queryParallelism, len(templates))
+
+	var wg sync.WaitGroup
+	for q := 0; q < c.queryParallelism; q++ {
+		// Each querier has its own random generator, so that the queries are reproducible.
+		rnd := rand.New(rand.NewSource(c.seed + int64(q)))
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.runQuerier(ctx, gen, templates, queryClients, rnd)
+		}()
+	}
+
+	wg.Wait()
+	return nil
+}
+
+func (c *BenchCommand) runQuerier(ctx context.Context, gen *benchSeriesGenerator, templates []*template.Template, clients []v1.API, rnd *rand.Rand) {
+	for ctx.Err() == nil {
+		now := time.Now()
+		query, err := gen.query(templates[rnd.Intn(len(templates))], rnd.Intn(c.series), now)
+		if err != nil {
+			log.WithError(err).Errorln("failed to generate query")
+			return
+		}
+
+		queryCtx, cancel := context.WithTimeout(ctx, c.queryTimeout)
+		start := time.Now()
+		_, _, err = clients[rnd.Intn(len(clients))].QueryRange(queryCtx, query, v1.Range{
+			Start: now.Add(-c.queryRange),
+			End:   now,
+			Step:  c.queryStep,
+		})
+		cancel()
+		c.queryRequestDuration.WithLabelValues(successLabelValue(err)).Observe(time.Since(start).Seconds())
+		if err != nil && ctx.Err() == nil {
+			log.WithError(err).WithField("query", query).Warnln("failed to run query")
+		}
+
+		if c.queryInterval > 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(c.queryInterval):
+			}
+		}
+	}
+}
+
+// runContext returns the context the load is generated until, which is canceled once the duration elapses.
+func (c *BenchCommand) runContext() (context.Context, context.CancelFunc) {
+	if c.duration > 0 {
+		return context.WithTimeout(context.Background(), c.duration)
+	}
+	return context.WithCancel(context.Background())
+}
+
+func (c *BenchCommand) serveMetrics() {
+	reg := prometheus.NewRegistry()
+	c.writeRequestDuration = promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "bench",
+		Name:      "write_request_duration_seconds",
+		Help:      "Time (in seconds) spent writing series.",
+		Buckets:   defBuckets,
+	}, []string{"success"})
+	c.queryRequestDuration = promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "bench",
+		Name:      "query_request_duration_seconds",
+		Help:      "Time (in seconds) spent running queries.",
+		Buckets:   defBuckets,
+	}, []string{"success"})
+	c.seriesWritten = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+		Namespace: "bench",
+		Name:      "series_written_total",
+		Help:      "Total number of series successfully written, per tenant.",
+	}, []string{"tenant"})
+
+	if c.metricsListenAddress == "" {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	go func() {
+		if err := http.ListenAndServe(c.metricsListenAddress, mux); err != nil {
+			log.WithError(err).Errorln("metrics listener failed")
+		}
+	}()
+}
+
+func (c *BenchCommand) tenantID(t int) string {
+	return fmt.Sprintf("%s%d", c.tenantIDPrefix, t)
+}
+
+func (c *BenchCommand) seriesGenerator() *benchSeriesGenerator {
+	return &benchSeriesGenerator{
+		numSeries:   c.series,
+		metricNames: c.metricNames,
+		churnPeriod: c.churnPeriod,
+	}
+}
+
+func successLabelValue(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "success"
+}
+
+// benchSeriesGenerator deterministically generates the series written by the bench command. The series
+// are grouped by instance, with one series per metric name for each instance. When churn is enabled,
+// the pod label of each instance changes every churn period, at an offset specific to the instance.
+type benchSeriesGenerator struct {
+	numSeries   int
+	metricNames int
+	churnPeriod time.Duration
+}
+
+func (g *benchSeriesGenerator) numInstances() int {
+	return (g.numSeries + g.metricNames - 1) / g.metricNames
+}
+
+// churnRate returns the number of series replaced by new series per second.
+func (g *benchSeriesGenerator) churnRate() float64 {
+	if g.churnPeriod <= 0 {
+		return 0
+	}
+	return float64(g.numSeries) / g.churnPeriod.Seconds()
+}
+
+// labels returns the metric name, instance and pod of the series at the given time.
+func (g *benchSeriesGenerator) labels(i int, t time.Time) (metric, instance, pod string) {
+	metricIdx, instanceIdx := i%g.metricNames, i/g.metricNames
+
+	generation := int64(0)
+	if g.churnPeriod > 0 {
+		// Spread the generation changes of the instances over the churn period.
+		offset := int64(g.churnPeriod) * int64(instanceIdx) / int64(g.numInstances())
+		generation = (t.UnixNano() + offset) / int64(g.churnPeriod)
+	}
+
+	return fmt.Sprintf("bench_metric_%d_total", metricIdx), fmt.Sprintf("instance-%d", instanceIdx), fmt.Sprintf("pod-%d-%d", instanceIdx, generation)
+}
+
+// series returns the series in the range [from, to), with a sample at the given time.
+func (g *benchSeriesGenerator) series(from, to int, t time.Time) []prompb.TimeSeries {
+	out := make([]prompb.TimeSeries, 0, to-from)
+	for i := from; i < to; i++ {
+		metric, instance, pod := g.labels(i, t)
+		out = append(out, prompb.TimeSeries{
+			Labels: []prompb.Label{
+				{Name: "__name__", Value: metric},
+				{Name: "instance", Value: instance},
+				{Name: "job", Value: "bench"},
+				{Name: "pod", Value: pod},
+			},
+			Samples: []prompb.Sample{{
+				// Each series is a counter increasing at a constant rate.
+				Value:     float64(t.Unix()) * float64(i%10+1),
+				Timestamp: t.UnixMilli(),
+			}},
+		})
+	}
+	return out
+}
+
+// benchQueryData is the data the query templates are executed with.
+type benchQueryData struct {
+	Metric   string
+	Instance string
+	Pod      string
+}
+
+// query returns the query generated by executing the template for the series at the given time.
+func (g *benchSeriesGenerator) query(tmpl *template.Template, i int, t time.Time) (string, error) {
+	metric, instance, pod := g.labels(i, t)
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, benchQueryData{Metric: metric, Instance: instance, Pod: pod}); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func parseBenchQueryTemplates(texts []string) ([]*template.Template, error) {
+	if len(texts) == 0 {
+		return nil, errors.New("at least one query template is required")
+	}
+
+	templates := make([]*template.Template, 0, len(texts))
+	for i, text := range texts {
+		tmpl, err := template.New(fmt.Sprintf("query-%d", i)).Parse(strings.TrimSpace(text))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid query template %q", text)
+		}
+		templates = append(templates, tmpl)
+	}
+	return templates, nil
+}
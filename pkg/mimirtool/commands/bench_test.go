@@ -0,0 +1,118 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package commands
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBenchSeriesGenerator_Churn(t *testing.T) {
+	gen := &benchSeriesGenerator{numSeries: 100, metricNames: 10, churnPeriod: 10 * time.Minute}
+	assert.Equal(t, 10, gen.numInstances())
+	assert.InDelta(t, 100.0/600, gen.churnRate(), 0.0001)
+
+	start := time.Unix(0, 0)
+	countChurned := func(from, to time.Time) int {
+		churned := 0
+		for i := 0; i < gen.numSeries; i++ {
+			_, _, podFrom := gen.labels(i, from)
+			_, _, podTo := gen.labels(i, to)
+			if podFrom != podTo {
+				churned++
+			}
+		}
+		return churned
+	}
+
+	// The series are churned evenly over the churn period, one instance at a time.
+	assert.Equal(t, 10, countChurned(start, start.Add(time.Minute)))
+	assert.Equal(t, 50, countChurned(start, start.Add(5*time.Minute)))
+	assert.Equal(t, 100, countChurned(start, start.Add(10*time.Minute)))
+
+	// The series of the same instance are churned together.
+	metric, instance, pod := gen.labels(12, start)
+	assert.Equal(t, "bench_metric_2_total", metric)
+	assert.Equal(t, "instance-1", instance)
+	_, _, otherPod := gen.labels(19, start)
+	assert.Equal(t, pod, otherPod)
+}
+
+func TestBenchSeriesGenerator_NoChurn(t *testing.T) {
+	gen := &benchSeriesGenerator{numSeries: 100, metricNames: 10}
+	assert.Equal(t, 0.0, gen.churnRate())
+
+	first := gen.series(0, 100, time.Unix(0, 0))
+	later := gen.series(0, 100, time.Unix(86400, 0))
+	require.Len(t, first, 100)
+	for i := range first {
+		assert.Equal(t, first[i].Labels, later[i].Labels)
+	}
+}
+
+func TestBenchSeriesGenerator_Query(t *testing.T) {
+	gen := &benchSeriesGenerator{numSeries: 100, metricNames: 10}
+	templates, err := parseBenchQueryTemplates(defaultBenchQueryTemplates)
+	require.NoError(t, err)
+
+	query, err := gen.query(templates[2], 23, time.Unix(0, 0))
+	require.NoError(t, err)
+	assert.Equal(t, `rate(bench_metric_3_total{instance="instance-2"}[5m])`, query)
+
+	_, err = parseBenchQueryTemplates([]string{"sum({{.Metric}"})
+	require.Error(t, err)
+
+	invalid, err := parseBenchQueryTemplates([]string{"sum({{.Unknown}})"})
+	require.NoError(t, err)
+	_, err = gen.query(invalid[0], 0, time.Unix(0, 0))
+	require.Error(t, err)
+}
+
+func TestBenchCommand_Write(t *testing.T) {
+	var (
+		mtx            sync.Mutex
+		seriesByTenant = map[string]int{}
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		compressed, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		data, err := snappy.Decode(nil, compressed)
+		require.NoError(t, err)
+
+		var req prompb.WriteRequest
+		require.NoError(t, proto.Unmarshal(data, &req))
+
+		mtx.Lock()
+		seriesByTenant[r.Header.Get("X-Scope-OrgID")] += len(req.Timeseries)
+		mtx.Unlock()
+	}))
+	t.Cleanup(server.Close)
+
+	c := &BenchCommand{
+		tenants:        2,
+		tenantIDPrefix: "tenant-",
+		series:         25,
+		metricNames:    5,
+		duration:       100 * time.Millisecond,
+		writeURL:       server.URL,
+		scrapeInterval: time.Hour,
+		parallelism:    3,
+		batchSize:      4,
+		writeTimeout:   time.Second,
+	}
+	require.NoError(t, c.write(nil))
+
+	// Each series has been written once, before the load duration elapsed.
+	assert.Equal(t, map[string]int{"tenant-0": 25, "tenant-1": 25}, seriesByTenant)
+}
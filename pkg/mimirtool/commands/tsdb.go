@@ -0,0 +1,491 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package commands
+
+import (
+	"context"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unsafe"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/timestamp"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/fileutil"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+)
+
+// TSDBCommand inspects a TSDB block, either from a local directory or from the blocks of a tenant in the bucket.
+type TSDBCommand struct {
+	block        string
+	blocks       []string
+	tenantID     string
+	bucketConfig string
+	downloadDir  string
+
+	selector    string
+	showChunks  bool
+	chunkRefs   []string
+	topN        int
+	samples     bool
+	shardCount  int
+	checkChunks bool
+
+	logger log.Logger
+	out    io.Writer
+}
+
+func (c *TSDBCommand) Register(app *kingpin.Application, envVars EnvVarNames) {
+	c.out = os.Stdout
+
+	tsdbCmd := app.Command("tsdb", "Inspect TSDB blocks, stored in a local directory or in the bucket.")
+	seriesCmd := tsdbCmd.Command("series", "List the series of the block, and optionally their chunks.").Action(c.series)
+	chunksCmd := tsdbCmd.Command("chunks", "Print the samples of the chunks of the block.").Action(c.chunks)
+	seriesCountCmd := tsdbCmd.Command("series-count", "Count the series of the block, in total and per metric name.").Action(c.seriesCount)
+	indexTOCCmd := tsdbCmd.Command("index-toc", "Print the sizes of the sections of the block index.").Action(c.indexTOC)
+	segmentsCmd := tsdbCmd.Command("segments", "Print the chunks stored in the chunks segment files of the block, and optionally their samples.").Action(c.segments)
+	indexHealthCmd := tsdbCmd.Command("index-health", "Print a JSON report of the health of the block index, as checked by the compactor.").Action(c.indexHealth)
+	symbolsCmd := tsdbCmd.Command("symbols", "Analyse the symbols of the blocks index, and optionally of the split-and-merge compactor shards of their series.").Action(c.symbols)
+
+	for _, cmd := range []*kingpin.CmdClause{seriesCmd, chunksCmd, seriesCountCmd, indexTOCCmd, segmentsCmd, indexHealthCmd} {
+		cmd.Arg("block", "Path to the local block directory, or block ID when --bucket-config is set.").
+			Required().StringVar(&c.block)
+	}
+	symbolsCmd.Arg("blocks", "Paths to the local block directories, or block IDs when --bucket-config is set.").
+		Required().StringsVar(&c.blocks)
+
+	for _, cmd := range []*kingpin.CmdClause{seriesCmd, chunksCmd, seriesCountCmd, indexTOCCmd, segmentsCmd, indexHealthCmd, symbolsCmd} {
+		cmd.Flag("bucket-config", "The CLI args to configure the storage bucket to download the block from, like the bucket-validation command. The block is read from a local directory if not set.").
+			StringVar(&c.bucketConfig)
+		cmd.Flag("id", "Grafana Mimir tenant ID owning the block in the bucket; alternatively, set "+envVars.TenantID+".").
+			Envar(envVars.TenantID).
+			Default("").
+			StringVar(&c.tenantID)
+		cmd.Flag("download-dir", "Directory to download the block from the bucket into. If not set, a temporary directory is used and deleted once done.").
+			Default("").
+			StringVar(&c.downloadDir)
+	}
+
+	for _, cmd := range []*kingpin.CmdClause{seriesCmd, seriesCountCmd} {
+		cmd.Flag("select", `PromQL metric selector to filter the series on, for example 'up{job="node"}'.`).
+			Default("").
+			StringVar(&c.selector)
+	}
+	seriesCmd.Flag("show-chunks", "Print the chunks metadata of each series.").
+		BoolVar(&c.showChunks)
+	chunksCmd.Arg("chunk-ref", "References of the chunks to print, as listed by the series command.").
+		Required().StringsVar(&c.chunkRefs)
+	seriesCountCmd.Flag("top", "Number of metric names with the most series to print.").
+		Default("20").IntVar(&c.topN)
+	segmentsCmd.Flag("samples", "Print the samples of each chunk.").
+		BoolVar(&c.samples)
+	indexHealthCmd.Flag("check-chunks", "Also verify the samples of the chunks of each series against the index, which requires the chunks.").
+		BoolVar(&c.checkChunks)
+	symbolsCmd.Flag("shard-count", "Number of split-and-merge compactor shards to compute the symbols of. Disabled if lower than 2.").
+		Default("0").IntVar(&c.shardCount)
+}
+
+func (c *TSDBCommand) series(_ *kingpin.ParseContext) error {
+	matchers, err := c.parseSelector()
+	if err != nil {
+		return err
+	}
+
+	return c.withBlock(c.block, false, func(b *tsdb.Block) error {
+		return forEachSeries(b, matchers, func(lbls labels.Labels, chks []chunks.Meta) {
+			fmt.Fprintln(c.out, "series", lbls.String())
+			if c.showChunks {
+				for _, chk := range chks {
+					fmt.Fprintln(c.out, "chunk", chk.Ref,
+						"min time:", chk.MinTime, timestamp.Time(chk.MinTime).UTC().Format(time.RFC3339Nano),
+						"max time:", chk.MaxTime, timestamp.Time(chk.MaxTime).UTC().Format(time.RFC3339Nano))
+				}
+			}
+		})
+	})
+}
+
+func (c *TSDBCommand) chunks(_ *kingpin.ParseContext) error {
+	refs := make([]chunks.ChunkRef, 0, len(c.chunkRefs))
+	for _, ref := range c.chunkRefs {
+		val, err := strconv.ParseUint(ref, 10, 64)
+		if err != nil {
+			return errors.Wrapf(err, "invalid chunk reference %s", ref)
+		}
+		refs = append(refs, chunks.ChunkRef(val))
+	}
+
+	return c.withBlock(c.block, true, func(b *tsdb.Block) error {
+		cr, err := b.Chunks()
+		if err != nil {
+			return errors.Wrap(err, "open block chunks")
+		}
+		defer cr.Close()
+
+		for _, ref := range refs {
+			chk, err := cr.Chunk(ref)
+			if err != nil {
+				return errors.Wrapf(err, "read chunk %d", ref)
+			}
+
+			fmt.Fprintln(c.out, "Chunk ref:", ref, "samples:", chk.NumSamples(), "bytes:", len(chk.Bytes()))
+
+			it := chk.Iterator(nil)
+			for it.Next() {
+				ts, val := it.At()
+				fmt.Fprintf(c.out, "%g\t%d (%s)\n", val, ts, timestamp.Time(ts).UTC().Format(time.RFC3339Nano))
+			}
+			if err := it.Err(); err != nil {
+				return errors.Wrapf(err, "iterate chunk %d", ref)
+			}
+		}
+		return nil
+	})
+}
+
+func (c *TSDBCommand) seriesCount(_ *kingpin.ParseContext) error {
+	matchers, err := c.parseSelector()
+	if err != nil {
+		return err
+	}
+
+	return c.withBlock(c.block, false, func(b *tsdb.Block) error {
+		total := 0
+		perMetric := map[string]int{}
+		err := forEachSeries(b, matchers, func(lbls labels.Labels, _ []chunks.Meta) {
+			total++
+			perMetric[lbls.Get(labels.MetricName)]++
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(c.out, "Total series:", total)
+		for _, m := range topSeriesCounts(perMetric, c.topN) {
+			fmt.Fprintf(c.out, "%d\t%s\n", m.count, m.name)
+		}
+		return nil
+	})
+}
+
+func (c *TSDBCommand) indexTOC(_ *kingpin.ParseContext) error {
+	return c.withBlockDir(c.block, false, func(dir string) error {
+		f, err := fileutil.OpenMmapFile(filepath.Join(dir, block.IndexFilename))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		toc, err := index.NewTOCFromByteSlice(realByteSlice(f.Bytes()))
+		if err != nil {
+			return errors.Wrap(err, "read index TOC")
+		}
+
+		// See https://github.com/prometheus/prometheus/blob/main/tsdb/docs/format/index.md on the index format.
+		fmt.Fprintln(c.out, "Symbols table size:   ", toc.Series-toc.Symbols)
+		fmt.Fprintln(c.out, "Series size:          ", toc.LabelIndices-toc.Series)
+		fmt.Fprintln(c.out, "Label indices:        ", toc.Postings-toc.LabelIndices)
+		fmt.Fprintln(c.out, "Postings:             ", toc.LabelIndicesTable-toc.Postings)
+		fmt.Fprintln(c.out, "Label offset table:   ", toc.PostingsTable-toc.LabelIndicesTable)
+
+		// Requires the full index to be correct.
+		if uint64(len(f.Bytes())) > toc.PostingsTable {
+			// TOC is a simple struct so unsafe.Sizeof() works correctly.
+			tocLength := uint64(unsafe.Sizeof(index.TOC{})) + crc32.Size
+
+			fmt.Fprintln(c.out, "Postings offset table:", uint64(len(f.Bytes()))-toc.PostingsTable-tocLength)
+		} else {
+			fmt.Fprintln(c.out, "Postings offset table: N/A")
+		}
+		return nil
+	})
+}
+
+func (c *TSDBCommand) segments(_ *kingpin.ParseContext) error {
+	return c.withBlockDir(c.block, true, func(dir string) error {
+		files, err := ioutil.ReadDir(filepath.Join(dir, block.ChunksDirname))
+		if err != nil {
+			return errors.Wrap(err, "list chunks segment files")
+		}
+
+		for _, f := range files {
+			if f.IsDir() {
+				continue
+			}
+			if err := printChunksSegment(c.out, filepath.Join(dir, block.ChunksDirname, f.Name()), c.samples); err != nil {
+				return errors.Wrapf(err, "read chunks segment file %s", f.Name())
+			}
+		}
+		return nil
+	})
+}
+
+// printChunksSegment prints the position, length, encoding, checksum and number of samples of each chunk of the
+// chunks segment file, and optionally their samples.
+func printChunksSegment(w io.Writer, filename string, printSamples bool) error {
+	f, err := fileutil.OpenMmapFile(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fmt.Fprintln(w, filename)
+
+	// See https://github.com/prometheus/prometheus/blob/main/tsdb/docs/format/chunks.md on the chunks format.
+	b := f.Bytes()
+	if len(b) < chunks.SegmentHeaderSize {
+		return errors.New("file too small to contain the segment header")
+	}
+	if m := binary.BigEndian.Uint32(b); m != chunks.MagicChunks {
+		return errors.Errorf("invalid magic number %x", m)
+	}
+	if v := b[chunks.MagicChunksSize]; v != 1 {
+		return errors.Errorf("invalid chunks format version %d", v)
+	}
+
+	for pos, ix := chunks.SegmentHeaderSize, 0; pos < len(b); ix++ {
+		// ┌───────────────┬───────────────────┬──────────────┬────────────────┐
+		// │ len <uvarint> │ encoding <1 byte> │ data <bytes> │ CRC32 <4 byte> │
+		// └───────────────┴───────────────────┴──────────────┴────────────────┘
+		length, n := binary.Uvarint(b[pos:])
+		if n <= 0 {
+			return errors.Errorf("invalid length of chunk #%d at position %d", ix, pos)
+		}
+		start := pos + n + chunks.ChunkEncodingSize
+		end := start + int(length)
+		if end+crc32.Size > len(b) {
+			return errors.Errorf("chunk #%d at position %d exceeds the file size", ix, pos)
+		}
+
+		enc := chunkenc.Encoding(b[pos+n])
+		chk, err := chunkenc.FromData(enc, b[start:end])
+		if err != nil {
+			return errors.Wrapf(err, "decode chunk #%d at position %d", ix, pos)
+		}
+		fmt.Fprintf(w, "Chunk #%d: position: %d length: %d encoding: %v, crc32: %x, samples: %d\n", ix, pos, length, enc, b[end:end+crc32.Size], chk.NumSamples())
+
+		if printSamples {
+			minTS, maxTS := int64(math.MaxInt64), int64(math.MinInt64)
+			it := chk.Iterator(nil)
+			for six := 0; it.Next(); six++ {
+				ts, val := it.At()
+				if ts < minTS {
+					minTS = ts
+				}
+				if ts > maxTS {
+					maxTS = ts
+				}
+				fmt.Fprintf(w, "Chunk #%d, sample #%d: ts: %s, val: %g\n", ix, six, formatTimestamp(ts), val)
+			}
+			if err := it.Err(); err != nil {
+				fmt.Fprintf(w, "Chunk #%d: error: %v\n", ix, err)
+			}
+			fmt.Fprintf(w, "Chunk #%d: minTS=%s, maxTS=%s\n", ix, formatTimestamp(minTS), formatTimestamp(maxTS))
+		}
+
+		pos = end + crc32.Size
+	}
+	return nil
+}
+
+func (c *TSDBCommand) parseSelector() ([]*labels.Matcher, error) {
+	if c.selector == "" {
+		return nil, nil
+	}
+
+	matchers, err := parser.ParseMetricSelector(c.selector)
+	return matchers, errors.Wrap(err, "invalid selector")
+}
+
+// withBlock opens the block and calls f with it.
+func (c *TSDBCommand) withBlock(blockArg string, withChunks bool, f func(b *tsdb.Block) error) error {
+	return c.withBlockDir(blockArg, withChunks, func(dir string) error {
+		b, err := tsdb.OpenBlock(c.getLogger(), dir, nil)
+		if err != nil {
+			return errors.Wrapf(err, "open block %s", dir)
+		}
+		defer b.Close()
+
+		return f(b)
+	})
+}
+
+// withBlockDir calls f with the local directory of the block, downloading it from the bucket if configured.
+// The block argument is the path to the block directory, or the block ID if read from the bucket. The chunks
+// of the block are only downloaded if required.
+func (c *TSDBCommand) withBlockDir(blockArg string, withChunks bool, f func(dir string) error) error {
+	if c.bucketConfig == "" {
+		return f(blockArg)
+	}
+
+	if c.tenantID == "" {
+		return errors.New("the tenant ID is required to read the block from the bucket")
+	}
+	blockID, err := ulid.Parse(blockArg)
+	if err != nil {
+		return errors.Wrapf(err, "invalid block ID %s", blockArg)
+	}
+
+	var cfg bucket.Config
+	fs := flag.NewFlagSet("bucket-config", flag.ContinueOnError)
+	cfg.RegisterFlags(fs)
+	if err := fs.Parse(strings.Split(c.bucketConfig, " ")); err != nil {
+		return errors.Wrap(err, "error when parsing bucket config")
+	}
+	if err := cfg.Validate(); err != nil {
+		return errors.Wrap(err, "invalid bucket config")
+	}
+
+	ctx := context.Background()
+	bkt, err := bucket.NewClient(ctx, cfg, "tsdb", c.getLogger(), nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to create the bucket client")
+	}
+	defer bkt.Close()
+
+	downloadDir := c.downloadDir
+	if downloadDir == "" {
+		if downloadDir, err = ioutil.TempDir("", "mimirtool-tsdb"); err != nil {
+			return err
+		}
+		defer os.RemoveAll(downloadDir)
+	}
+
+	dir := filepath.Join(downloadDir, blockID.String())
+	if err := downloadBlock(ctx, c.getLogger(), bucket.NewUserBucketClient(c.tenantID, bkt, nil), blockID, dir, withChunks); err != nil {
+		return errors.Wrapf(err, "download block %s", blockID)
+	}
+
+	return f(dir)
+}
+
+func (c *TSDBCommand) getLogger() log.Logger {
+	if c.logger == nil {
+		c.logger = log.NewLogfmtLogger(log.NewSyncWriter(os.Stderr))
+	}
+	return c.logger
+}
+
+// downloadBlock downloads the meta and index of the block to dst, and its chunks only if required.
+func downloadBlock(ctx context.Context, logger log.Logger, bkt objstore.Bucket, id ulid.ULID, dst string, withChunks bool) error {
+	if err := os.MkdirAll(dst, os.ModePerm); err != nil {
+		return err
+	}
+
+	for _, name := range []string{block.MetaFilename, block.IndexFilename} {
+		if err := objstore.DownloadFile(ctx, logger, bkt, path.Join(id.String(), name), filepath.Join(dst, name)); err != nil {
+			return err
+		}
+	}
+
+	chunksDir := filepath.Join(dst, block.ChunksDirname)
+	if withChunks {
+		if err := objstore.DownloadDir(ctx, logger, bkt, id.String(), path.Join(id.String(), block.ChunksDirname), chunksDir); err != nil {
+			return err
+		}
+	}
+
+	// The chunks directory is required to open the block, even if empty.
+	return os.MkdirAll(chunksDir, os.ModePerm)
+}
+
+// forEachSeries calls f for each series of the block matching all the matchers.
+func forEachSeries(b *tsdb.Block, matchers []*labels.Matcher, f func(lbls labels.Labels, chks []chunks.Meta)) error {
+	idx, err := b.Index()
+	if err != nil {
+		return errors.Wrap(err, "open block index")
+	}
+	defer idx.Close()
+
+	k, v := index.AllPostingsKey()
+	p, err := idx.Postings(k, v)
+	if err != nil {
+		return errors.Wrap(err, "get postings")
+	}
+
+	for p.Next() {
+		var (
+			lbls labels.Labels
+			chks []chunks.Meta
+		)
+		if err := idx.Series(p.At(), &lbls, &chks); err != nil {
+			return errors.Wrapf(err, "get series %d", p.At())
+		}
+
+		matches := true
+		for _, m := range matchers {
+			if !m.Matches(lbls.Get(m.Name)) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			f(lbls, chks)
+		}
+	}
+
+	return errors.Wrap(p.Err(), "iterate postings")
+}
+
+type metricSeriesCount struct {
+	name  string
+	count int
+}
+
+// topSeriesCounts returns the n metric names with the most series, sorted by series count and then name.
+func topSeriesCounts(counts map[string]int, n int) []metricSeriesCount {
+	out := make([]metricSeriesCount, 0, len(counts))
+	for name, count := range counts {
+		out = append(out, metricSeriesCount{name: name, count: count})
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].count != out[j].count {
+			return out[i].count > out[j].count
+		}
+		return out[i].name < out[j].name
+	})
+
+	if n >= 0 && len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
+
+// formatTimestamp returns the timestamp in milliseconds followed by its UTC time.
+func formatTimestamp(ts int64) string {
+	return fmt.Sprintf("%d (%s)", ts, timestamp.Time(ts).UTC().Format(time.RFC3339Nano))
+}
+
+type realByteSlice []byte
+
+func (b realByteSlice) Len() int {
+	return len(b)
+}
+
+func (b realByteSlice) Range(start, end int) []byte {
+	return b[start:end]
+}
@@ -1,13 +1,11 @@
 // SPDX-License-Identifier: AGPL-3.0-only
 
-package main
+package commands
 
 import (
 	"encoding/json"
-	"flag"
 	"fmt"
 	"math"
-	"os"
 	"path/filepath"
 	"time"
 
@@ -22,45 +20,29 @@ import (
 	"github.com/thanos-io/thanos/pkg/block"
 	"github.com/thanos-io/thanos/pkg/block/metadata"
 	"github.com/thanos-io/thanos/pkg/runutil"
+	"gopkg.in/alecthomas/kingpin.v2"
 )
 
-var logger = log.NewLogfmtLogger(os.Stderr)
-
-func main() {
-	verifyChunks := flag.Bool("check-chunks", false, "Verify chunks in segment files.")
-	flag.Usage = func() {
-		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [options...] <block-dir> [<block-dir> ...]:\n", os.Args[0])
-		fmt.Fprintln(flag.CommandLine.Output())
-		flag.PrintDefaults()
-	}
-
-	flag.Parse()
-
-	if flag.NArg() == 0 {
-		flag.Usage()
-		return
-	}
-
-	for _, b := range flag.Args() {
-		meta, err := metadata.ReadFromDir(b)
+func (c *TSDBCommand) indexHealth(_ *kingpin.ParseContext) error {
+	return c.withBlockDir(c.block, c.checkChunks, func(dir string) error {
+		meta, err := metadata.ReadFromDir(dir)
 		if err != nil {
-			fmt.Fprintln(os.Stderr, "Failed to read meta from block dir", b, "error:", err)
-			continue
+			return errors.Wrap(err, "read block meta")
 		}
 
-		stats, err := GatherIndexHealthStats(logger, b, meta.MinTime, meta.MaxTime, *verifyChunks)
+		stats, err := gatherIndexHealthStats(c.getLogger(), dir, meta.MinTime, meta.MaxTime, c.checkChunks)
 		if err != nil {
-			fmt.Fprintln(os.Stderr, "Failed to gather health stats from block dir", b, "error:", err)
-			continue
+			return errors.Wrap(err, "gather index health stats")
 		}
 
-		enc := json.NewEncoder(os.Stdout)
-		enc.SetIndent("  ", "  ")
-		_ = enc.Encode(stats)
-	}
+		enc := json.NewEncoder(c.out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(stats)
+	})
 }
 
-type HealthStats struct {
+// indexHealthStats is the report of the health of a block index.
+type indexHealthStats struct {
 	// TotalSeries represents total number of series in block.
 	TotalSeries int64
 	// OutOfOrderSeries represents number of series that have out of order chunks.
@@ -147,7 +129,9 @@ func (n *minMaxSumInt64) Avg() int64 {
 	return n.sum / n.cnt
 }
 
-func GatherIndexHealthStats(logger log.Logger, blockDir string, minTime, maxTime int64, checkChunks bool) (stats HealthStats, err error) {
+// gatherIndexHealthStats returns the health report of the index of the block, and of its chunks if checkChunks is set.
+// It runs the same checks as the compactor does before compacting a block.
+func gatherIndexHealthStats(logger log.Logger, blockDir string, minTime, maxTime int64, checkChunks bool) (stats indexHealthStats, err error) {
 	var cr *chunks.Reader
 	if checkChunks {
 		cr, err = chunks.NewDirReader(filepath.Join(blockDir, block.ChunksDirname), nil)
@@ -295,11 +279,11 @@ func GatherIndexHealthStats(logger log.Logger, blockDir string, minTime, maxTime
 		}
 
 		if checkChunks {
-			verifyChunks(logger, cr, lset, chks)
+			verifyChunks(logger, cr, chks)
 		}
 	}
 	if p.Err() != nil {
-		return stats, errors.Wrap(err, "walk postings")
+		return stats, errors.Wrap(p.Err(), "walk postings")
 	}
 
 	stats.SeriesMaxLifeDuration = model.Duration(time.Duration(seriesLifeDuration.max) * time.Millisecond)
@@ -324,7 +308,8 @@ func GatherIndexHealthStats(logger log.Logger, blockDir string, minTime, maxTime
 	return stats, nil
 }
 
-func verifyChunks(l log.Logger, cr *chunks.Reader, lset labels.Labels, chks []chunks.Meta) {
+// verifyChunks logs the chunks whose samples are out of order or don't match the time range of the chunk in the index.
+func verifyChunks(l log.Logger, cr *chunks.Reader, chks []chunks.Meta) {
 	for _, cm := range chks {
 		ch, err := cr.Chunk(cm.Ref)
 		if err != nil {
@@ -353,7 +338,7 @@ func verifyChunks(l log.Logger, cr *chunks.Reader, lset labels.Labels, chks []ch
 			prevTs = ts
 		}
 
-		if e := it.Err(); e != nil {
+		if err := it.Err(); err != nil {
 			level.Warn(l).Log("ref", cm.Ref, "msg", "failed to iterate over chunk samples", "err", err)
 		} else if samples == 0 {
 			level.Warn(l).Log("ref", cm.Ref, "msg", "no samples found in the chunk")
@@ -362,7 +347,3 @@ func verifyChunks(l log.Logger, cr *chunks.Reader, lset labels.Labels, chks []ch
 		}
 	}
 }
-
-func formatTimestamp(ts int64) string {
-	return fmt.Sprintf("%d (%s)", ts, timestamp.Time(ts).UTC().Format(time.RFC3339Nano))
-}
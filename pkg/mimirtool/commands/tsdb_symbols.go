@@ -0,0 +1,169 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package commands
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+func (c *TSDBCommand) symbols(_ *kingpin.ParseContext) error {
+	startTime := time.Now()
+
+	uniqueSymbols := map[string]struct{}{}
+	var uniqueSymbolsPerShard []map[string]struct{}
+	if c.shardCount > 1 {
+		uniqueSymbolsPerShard = make([]map[string]struct{}, c.shardCount)
+		for ix := range uniqueSymbolsPerShard {
+			uniqueSymbolsPerShard[ix] = map[string]struct{}{}
+		}
+	}
+
+	for _, blockArg := range c.blocks {
+		err := c.withBlock(blockArg, false, func(b *tsdb.Block) error {
+			return analyseSymbols(c.out, b, uniqueSymbols, uniqueSymbolsPerShard)
+		})
+		if err != nil {
+			return errors.Wrapf(err, "analyse symbols of block %s", blockArg)
+		}
+		fmt.Fprintln(c.out)
+	}
+
+	uniqueSymbolsLength := int64(0)
+	for s := range uniqueSymbols {
+		uniqueSymbolsLength += int64(len(s))
+	}
+	fmt.Fprintln(c.out, "Found", len(uniqueSymbols), "unique symbols from series across ALL blocks, with total length", uniqueSymbolsLength, "bytes")
+
+	for ix, shardSymbols := range uniqueSymbolsPerShard {
+		shardSymbolsLength := int64(0)
+		for s := range shardSymbols {
+			shardSymbolsLength += int64(len(s))
+		}
+
+		fmt.Fprintf(c.out, "Shard %d: Found %d unique symbols from series in the shard (%0.4g %%), length of symbols in the shard: %d bytes (%0.4g %%)\n",
+			ix,
+			len(shardSymbols),
+			float64(len(shardSymbols))/float64(len(uniqueSymbols))*100,
+			shardSymbolsLength,
+			float64(shardSymbolsLength)/float64(uniqueSymbolsLength)*100)
+	}
+
+	fmt.Fprintln(c.out)
+	fmt.Fprintln(c.out, "Analysis complete in", time.Since(startTime))
+	return nil
+}
+
+// analyseSymbols prints the size of the symbols table of the block index, and the number of symbols used by its
+// series. The symbols of the series are added to uniqueSymbols, and to the set of their split-and-merge compactor
+// shard, if any.
+func analyseSymbols(w io.Writer, b *tsdb.Block, uniqueSymbols map[string]struct{}, uniqueSymbolsPerShard []map[string]struct{}) error {
+	id := b.Meta().ULID.String()
+
+	fmt.Fprintf(w, "%s: mint=%s, maxt=%s, duration: %v\n", id, formatTimestamp(b.MinTime()), formatTimestamp(b.MaxTime()),
+		time.Duration(b.MaxTime()-b.MinTime())*time.Millisecond)
+
+	if meta, err := metadata.ReadFromDir(b.Dir()); err == nil {
+		fmt.Fprintf(w, "%s: %v\n", id, labels.FromMap(meta.Thanos.Labels))
+	}
+
+	symbolsTableSize, symbolsCount, err := readSymbolsTableSizeAndCount(filepath.Join(b.Dir(), block.IndexFilename))
+	if err != nil {
+		fmt.Fprintf(w, "%s: failed to read symbols table size and symbols count from index: %v\n", id, err)
+	} else {
+		fmt.Fprintf(w, "%s: index: symbol table size: %d bytes, symbols: %d\n", id, symbolsTableSize, symbolsCount)
+	}
+
+	idx, err := b.Index()
+	if err != nil {
+		return errors.Wrap(err, "open block index")
+	}
+	defer idx.Close()
+
+	count, length := 0, 0
+	it := idx.Symbols()
+	for it.Next() {
+		count++
+		length += len(it.At())
+	}
+	if it.Err() != nil {
+		return errors.Wrap(it.Err(), "iterate symbols")
+	}
+
+	fmt.Fprintf(w, "%s: symbols iteration: total length of symbols: %d bytes, symbols: %d\n", id, length, count)
+	if symbolsTableSize > 0 {
+		fmt.Fprintf(w, "%s: index structure overhead: %d bytes\n", id, int64(symbolsTableSize)-int64(length))
+	}
+
+	k, v := index.AllPostingsKey()
+	p, err := idx.Postings(k, v)
+	if err != nil {
+		return errors.Wrap(err, "get postings")
+	}
+
+	shards := uint64(len(uniqueSymbolsPerShard))
+	uniqueSymbolsPerBlock := map[string]struct{}{}
+	for p.Next() {
+		var lbls labels.Labels
+		if err := idx.Series(p.At(), &lbls, nil); err != nil {
+			return errors.Wrapf(err, "get series %d", p.At())
+		}
+
+		for _, l := range lbls {
+			uniqueSymbols[l.Name] = struct{}{}
+			uniqueSymbols[l.Value] = struct{}{}
+
+			uniqueSymbolsPerBlock[l.Name] = struct{}{}
+			uniqueSymbolsPerBlock[l.Value] = struct{}{}
+
+			if shards > 0 {
+				shardSymbols := uniqueSymbolsPerShard[lbls.Hash()%shards]
+				shardSymbols[l.Name] = struct{}{}
+				shardSymbols[l.Value] = struct{}{}
+			}
+		}
+	}
+	if p.Err() != nil {
+		return errors.Wrap(p.Err(), "iterate postings")
+	}
+
+	fmt.Fprintf(w, "%s: found %d unique symbols from series in the block\n", id, len(uniqueSymbolsPerBlock))
+	return nil
+}
+
+// readSymbolsTableSizeAndCount returns the size and the number of symbols of the symbols table of the index,
+// which starts right after the magic number and the version of the index, with the same format in the index
+// versions 1 and 2. See https://github.com/prometheus/prometheus/blob/main/tsdb/docs/format/index.md.
+func readSymbolsTableSizeAndCount(indexFile string) (size, count uint32, _ error) {
+	f, err := os.Open(indexFile)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	header := make([]byte, index.HeaderLen+4+4)
+	if _, err := io.ReadFull(f, header); err != nil {
+		return 0, 0, errors.Wrap(err, "read header")
+	}
+
+	if m := binary.BigEndian.Uint32(header); m != index.MagicIndex {
+		return 0, 0, errors.Errorf("invalid magic number %x", m)
+	}
+	if v := header[4]; v != index.FormatV1 && v != index.FormatV2 {
+		return 0, 0, errors.Errorf("invalid index version %d", v)
+	}
+
+	return binary.BigEndian.Uint32(header[index.HeaderLen:]), binary.BigEndian.Uint32(header[index.HeaderLen+4:]), nil
+}
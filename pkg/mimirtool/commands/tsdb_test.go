@@ -0,0 +1,157 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/tsdbutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	"github.com/grafana/mimir/pkg/storage/bucket/filesystem"
+)
+
+func TestTSDBCommand(t *testing.T) {
+	const userID = "user-1"
+
+	tmpDir := t.TempDir()
+	bucketDir := filepath.Join(tmpDir, "bucket")
+
+	blockDir, err := tsdb.CreateBlock([]storage.Series{
+		storage.NewListSeries(labels.FromStrings(labels.MetricName, "series_1", "job", "a"), tsdbutil.GenerateSamples(0, 10)),
+		storage.NewListSeries(labels.FromStrings(labels.MetricName, "series_1", "job", "b"), tsdbutil.GenerateSamples(0, 10)),
+		storage.NewListSeries(labels.FromStrings(labels.MetricName, "series_2", "job", "a"), tsdbutil.GenerateSamples(0, 10)),
+	}, filepath.Join(tmpDir, "blocks"), 0, log.NewNopLogger())
+	require.NoError(t, err)
+	blockID := filepath.Base(blockDir)
+
+	bkt, err := filesystem.NewBucketClient(filesystem.Config{Directory: bucketDir})
+	require.NoError(t, err)
+	require.NoError(t, block.UploadPromBlock(context.Background(), log.NewNopLogger(), bucket.NewUserBucketClient(userID, bkt, nil), blockDir, metadata.NoneFunc))
+
+	sources := map[string]TSDBCommand{
+		"local directory": {
+			block: blockDir,
+		},
+		"bucket": {
+			block:        blockID,
+			tenantID:     userID,
+			bucketConfig: fmt.Sprintf("-backend=filesystem -filesystem.dir=%s", bucketDir),
+		},
+	}
+
+	for name, source := range sources {
+		t.Run(name, func(t *testing.T) {
+			t.Run("series", func(t *testing.T) {
+				c, out := source, &bytes.Buffer{}
+				c.out, c.logger = out, log.NewNopLogger()
+				c.selector = `{job="a"}`
+
+				require.NoError(t, c.series(nil))
+				assert.Equal(t, []string{
+					`series {__name__="series_1", job="a"}`,
+					`series {__name__="series_2", job="a"}`,
+				}, strings.Split(strings.TrimSpace(out.String()), "\n"))
+			})
+
+			t.Run("series-count", func(t *testing.T) {
+				c, out := source, &bytes.Buffer{}
+				c.out, c.logger = out, log.NewNopLogger()
+				c.topN = 1
+
+				require.NoError(t, c.seriesCount(nil))
+				assert.Equal(t, []string{
+					"Total series: 3",
+					"2\tseries_1",
+				}, strings.Split(strings.TrimSpace(out.String()), "\n"))
+			})
+
+			t.Run("chunks", func(t *testing.T) {
+				c, out := source, &bytes.Buffer{}
+				c.out, c.logger = out, log.NewNopLogger()
+				c.selector, c.showChunks = `series_2`, true
+
+				require.NoError(t, c.series(nil))
+				lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+				require.Len(t, lines, 2)
+				c.chunkRefs = []string{strings.Fields(lines[1])[1]}
+
+				out.Reset()
+				require.NoError(t, c.chunks(nil))
+				lines = strings.Split(strings.TrimSpace(out.String()), "\n")
+				require.Len(t, lines, 11)
+				assert.True(t, strings.HasPrefix(lines[0], "Chunk ref: "+c.chunkRefs[0]+" samples: 10 "))
+			})
+
+			t.Run("index-toc", func(t *testing.T) {
+				c, out := source, &bytes.Buffer{}
+				c.out, c.logger = out, log.NewNopLogger()
+
+				require.NoError(t, c.indexTOC(nil))
+				assert.Contains(t, out.String(), "Symbols table size:")
+			})
+
+			t.Run("segments", func(t *testing.T) {
+				c, out := source, &bytes.Buffer{}
+				c.out, c.logger = out, log.NewNopLogger()
+				c.samples = true
+
+				require.NoError(t, c.segments(nil))
+				lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+				require.Len(t, lines, 1+3*12)
+				assert.True(t, strings.HasSuffix(lines[0], filepath.Join(block.ChunksDirname, "000001")))
+				assert.True(t, strings.HasPrefix(lines[1], "Chunk #0: position: 8 "))
+				assert.True(t, strings.HasSuffix(lines[1], "samples: 10"))
+				assert.Equal(t, "Chunk #0: minTS=0 (1970-01-01T00:00:00Z), maxTS=9 (1970-01-01T00:00:00.009Z)", lines[12])
+			})
+
+			t.Run("index-health", func(t *testing.T) {
+				c, out := source, &bytes.Buffer{}
+				c.out, c.logger = out, log.NewNopLogger()
+				c.checkChunks = true
+
+				require.NoError(t, c.indexHealth(nil))
+				var stats indexHealthStats
+				require.NoError(t, json.Unmarshal(out.Bytes(), &stats))
+				assert.Equal(t, int64(3), stats.TotalSeries)
+				assert.Equal(t, int64(3), stats.TotalChunks)
+				assert.Equal(t, 0, stats.OutOfOrderChunks)
+				assert.Equal(t, int64(2), stats.LabelNamesCount)
+				assert.Equal(t, int64(2), stats.MetricLabelValuesCount)
+			})
+
+			t.Run("symbols", func(t *testing.T) {
+				c, out := source, &bytes.Buffer{}
+				c.out, c.logger = out, log.NewNopLogger()
+				c.blocks, c.shardCount = []string{source.block}, 2
+
+				require.NoError(t, c.symbols(nil))
+				// The symbols are __name__, job, series_1, series_2, a and b.
+				assert.Contains(t, out.String(), "found 6 unique symbols from series in the block")
+				assert.Contains(t, out.String(), "Found 6 unique symbols from series across ALL blocks")
+				assert.Contains(t, out.String(), "Shard 1: Found ")
+			})
+		})
+	}
+}
+
+func TestTopSeriesCounts(t *testing.T) {
+	counts := map[string]int{"a": 1, "b": 3, "c": 3, "d": 2}
+
+	assert.Equal(t, []metricSeriesCount{{"b", 3}, {"c", 3}, {"d", 2}, {"a", 1}}, topSeriesCounts(counts, 10))
+	assert.Equal(t, []metricSeriesCount{{"b", 3}, {"c", 3}}, topSeriesCounts(counts, 2))
+}
@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"io"
+
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/grafana/mimir/pkg/storegateway"
+	"github.com/grafana/mimir/pkg/storegateway/storegatewaypb"
+)
+
+// bucketFallbackAddress is the address used to identify the bucket fallback in place of a store-gateway address.
+const bucketFallbackAddress = "bucket"
+
+// bucketFallbackClient is a BlocksStoreClient reading a given set of blocks directly from the bucket,
+// without going through the store-gateways. Each request loads the blocks from scratch and the
+// response is fully buffered in memory, so it should only be used as a slow path.
+type bucketFallbackClient struct {
+	reader   *storegateway.DirectBucketReader
+	userID   string
+	blockIDs []ulid.ULID
+}
+
+func newBucketFallbackClient(reader *storegateway.DirectBucketReader, userID string, blockIDs []ulid.ULID) *bucketFallbackClient {
+	return &bucketFallbackClient{
+		reader:   reader,
+		userID:   userID,
+		blockIDs: blockIDs,
+	}
+}
+
+func (c *bucketFallbackClient) Series(ctx context.Context, req *storepb.SeriesRequest, _ ...grpc.CallOption) (storegatewaypb.StoreGateway_SeriesClient, error) {
+	store, err := c.reader.Open(ctx, c.userID, c.blockIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+
+	srv := &bufferedSeriesServer{ctx: ctx}
+	if err := store.Series(req, srv); err != nil {
+		return nil, err
+	}
+
+	return &bufferedSeriesClient{ctx: ctx, responses: srv.responses}, nil
+}
+
+func (c *bucketFallbackClient) LabelNames(ctx context.Context, req *storepb.LabelNamesRequest, _ ...grpc.CallOption) (*storepb.LabelNamesResponse, error) {
+	store, err := c.reader.Open(ctx, c.userID, c.blockIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+
+	return store.LabelNames(ctx, req)
+}
+
+func (c *bucketFallbackClient) LabelValues(ctx context.Context, req *storepb.LabelValuesRequest, _ ...grpc.CallOption) (*storepb.LabelValuesResponse, error) {
+	store, err := c.reader.Open(ctx, c.userID, c.blockIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+
+	return store.LabelValues(ctx, req)
+}
+
+func (c *bucketFallbackClient) RemoteAddress() string {
+	return bucketFallbackAddress
+}
+
+// bufferedSeriesServer is a storepb.Store_SeriesServer keeping all responses in memory.
+type bufferedSeriesServer struct {
+	grpc.ServerStream
+
+	ctx       context.Context
+	responses []*storepb.SeriesResponse
+}
+
+func (s *bufferedSeriesServer) Send(r *storepb.SeriesResponse) error {
+	// The store uses pools for the chunks, so we need to copy the response
+	// to retain it after the store has been closed.
+	data, err := r.Marshal()
+	if err != nil {
+		return errors.Wrap(err, "marshal series response")
+	}
+
+	copied := &storepb.SeriesResponse{}
+	if err := copied.Unmarshal(data); err != nil {
+		return errors.Wrap(err, "unmarshal series response")
+	}
+
+	s.responses = append(s.responses, copied)
+	return nil
+}
+
+func (s *bufferedSeriesServer) Context() context.Context {
+	return s.ctx
+}
+
+// bufferedSeriesClient is a storegatewaypb.StoreGateway_SeriesClient returning the responses
+// buffered by bufferedSeriesServer.
+type bufferedSeriesClient struct {
+	grpc.ClientStream
+
+	ctx       context.Context
+	responses []*storepb.SeriesResponse
+}
+
+func (c *bufferedSeriesClient) Recv() (*storepb.SeriesResponse, error) {
+	if len(c.responses) == 0 {
+		return nil, io.EOF
+	}
+
+	res := c.responses[0]
+	c.responses = c.responses[1:]
+	return res, nil
+}
+
+func (c *bufferedSeriesClient) Context() context.Context {
+	return c.ctx
+}
+
+func (c *bufferedSeriesClient) Trailer() metadata.MD {
+	return nil
+}
+
+func (c *bufferedSeriesClient) CloseSend() error {
+	return nil
+}
@@ -0,0 +1,246 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/services"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/tsdbutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/extprom"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"github.com/weaveworks/common/logging"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/querier/stats"
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+	"github.com/grafana/mimir/pkg/storegateway"
+	"github.com/grafana/mimir/pkg/util/limiter"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestBlocksStoreQuerier_BucketFallback(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := limiter.AddQueryLimiterToContext(context.Background(), limiter.NewQueryLimiter(0, 0, 0))
+	tmpDir := t.TempDir()
+	bkt := objstore.NewInMemBucket()
+
+	// Create a block with a single series and upload it to the bucket.
+	series := labels.FromStrings(labels.MetricName, "series_1")
+	blockDir, err := tsdb.CreateBlock([]storage.Series{storage.NewListSeries(series, tsdbutil.GenerateSamples(0, 10))}, filepath.Join(tmpDir, "blocks"), 0, log.NewNopLogger())
+	require.NoError(t, err)
+	blockID := ulid.MustParse(filepath.Base(blockDir))
+	require.NoError(t, block.UploadPromBlock(ctx, log.NewNopLogger(), bucket.NewUserBucketClient(userID, bkt, nil), blockDir, metadata.NoneFunc))
+
+	storageCfg := mimir_tsdb.BlocksStorageConfig{}
+	flagext.DefaultValues(&storageCfg)
+
+	// The store-gateway doesn't have the block and there are no other replicas to retry on.
+	storeSetResponses := []interface{}{
+		map[BlocksStoreClient][]ulid.ULID{
+			&storeGatewayClientMock{remoteAddr: "1.1.1.1", mockedSeriesResponses: []*storepb.SeriesResponse{mockHintsResponse()}}: {blockID},
+		},
+		errors.New("no store-gateway remaining after exclude"),
+	}
+
+	tests := map[string]struct {
+		bucketFallback      bool
+		bypassStoreGateways bool
+		storeSetResponses   []interface{}
+		expectedErr         string
+		expectedMetrics     string
+	}{
+		"should fail the consistency check if the bucket fallback is disabled": {
+			bucketFallback:    false,
+			storeSetResponses: storeSetResponses,
+			expectedErr:       "consistency check failed because some blocks were not queried: " + blockID.String(),
+		},
+		"should query the missing blocks from the bucket if the bucket fallback is enabled": {
+			bucketFallback:    true,
+			storeSetResponses: storeSetResponses,
+			expectedMetrics: `
+				# HELP cortex_querier_blocks_queried_from_bucket_fallback_total Number of blocks queried directly from the bucket instead of store-gateways.
+				# TYPE cortex_querier_blocks_queried_from_bucket_fallback_total counter
+				cortex_querier_blocks_queried_from_bucket_fallback_total 1
+			`,
+		},
+		"should query all blocks from the bucket without querying store-gateways if bypassing store-gateways": {
+			bucketFallback:      true,
+			bypassStoreGateways: true,
+			storeSetResponses:   nil, // The mock panics if called.
+			expectedMetrics: `
+				# HELP cortex_querier_blocks_queried_from_bucket_fallback_total Number of blocks queried directly from the bucket instead of store-gateways.
+				# TYPE cortex_querier_blocks_queried_from_bucket_fallback_total counter
+				cortex_querier_blocks_queried_from_bucket_fallback_total 1
+			`,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			reg := prometheus.NewPedanticRegistry()
+
+			stores := &blocksStoreSetMock{mockedResponses: testData.storeSetResponses}
+
+			finder := &blocksFinderMock{}
+			finder.On("GetBlocks", mock.Anything, userID, int64(0), int64(10)).Return(bucketindex.Blocks{{ID: blockID}}, map[ulid.ULID]*bucketindex.BlockDeletionMark(nil), error(nil))
+
+			queryStats, ctx := stats.ContextWithEmptyStats(ctx)
+
+			q := &blocksStoreQuerier{
+				ctx:         ctx,
+				minT:        0,
+				maxT:        10,
+				userID:      userID,
+				finder:      finder,
+				stores:      stores,
+				consistency: NewBlocksConsistencyChecker(0, 0, log.NewNopLogger(), nil),
+				logger:      log.NewNopLogger(),
+				metrics:     newBlocksStoreQueryableMetrics(reg),
+				limits:      &blocksStoreLimitsMock{},
+			}
+			if testData.bucketFallback {
+				q.bucketFallback = storegateway.NewDirectBucketReader(storegateway.DirectBucketReaderConfig{}, storageCfg, bkt, filepath.Join(tmpDir, "fallback"), log.NewNopLogger())
+				q.bypassStoreGateways = testData.bypassStoreGateways
+			}
+
+			set := q.Select(true, &storage.SelectHints{Start: 0, End: 10}, labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "series_1"))
+			if testData.expectedErr != "" {
+				require.Error(t, set.Err())
+				assert.Equal(t, testData.expectedErr, set.Err().Error())
+				return
+			}
+
+			require.True(t, set.Next())
+			assert.Equal(t, series, set.At().Labels())
+
+			samples := 0
+			for it := set.At().Iterator(); it.Next(); {
+				samples++
+			}
+			assert.Equal(t, 10, samples)
+			assert.False(t, set.Next())
+			require.NoError(t, set.Err())
+
+			assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(testData.expectedMetrics), "cortex_querier_blocks_queried_from_bucket_fallback_total"))
+			assert.Equal(t, uint64(1), queryStats.LoadFetchedBlocksFromBucket())
+
+			// The blocks loaded from the bucket should have been removed from disk.
+			entries, err := os.ReadDir(filepath.Join(tmpDir, "fallback", "stores"))
+			require.NoError(t, err)
+			assert.Empty(t, entries)
+		})
+	}
+}
+
+func TestBlocksStoreQueryable_BucketFallbackWithStoreGatewayInSameProcess(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := user.InjectOrgID(context.Background(), userID)
+	ctx = limiter.AddQueryLimiterToContext(ctx, limiter.NewQueryLimiter(0, 0, 0))
+	tmpDir := t.TempDir()
+
+	storageCfg := mimir_tsdb.BlocksStorageConfig{}
+	flagext.DefaultValues(&storageCfg)
+	storageCfg.Bucket.Backend = bucket.Filesystem
+	storageCfg.Bucket.Filesystem.Directory = filepath.Join(tmpDir, "bucket")
+	storageCfg.BucketStore.SyncDir = filepath.Join(tmpDir, "tsdb-sync")
+	storageCfg.BucketStore.BucketIndex.Enabled = false
+
+	querierCfg := Config{}
+	flagext.DefaultValues(&querierCfg)
+	querierCfg.BypassStoreGateways = true
+	querierCfg.BucketDirectReadDir = filepath.Join(tmpDir, "querier-bucket-direct-read")
+
+	gatewayCfg := storegateway.Config{}
+	flagext.DefaultValues(&gatewayCfg)
+	gatewayCfg.ShardingRing.KVStore.Store = "inmemory"
+
+	overrides, err := validation.NewOverrides(defaultLimitsConfig(), nil)
+	require.NoError(t, err)
+
+	// Create a block with a single series and upload it to the bucket.
+	bkt, err := bucket.NewClient(ctx, storageCfg.Bucket, "test", log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	series := labels.FromStrings(labels.MetricName, "series_1")
+	blockDir, err := tsdb.CreateBlock([]storage.Series{storage.NewListSeries(series, tsdbutil.GenerateSamples(0, 10))}, filepath.Join(tmpDir, "blocks"), 0, log.NewNopLogger())
+	require.NoError(t, err)
+	require.NoError(t, block.UploadPromBlock(ctx, log.NewNopLogger(), bucket.NewUserBucketClient(userID, bkt, nil), blockDir, metadata.NoneFunc))
+
+	// Run the store-gateway bucket stores and the querier in the same process, with the same storage config.
+	// The store-gateway doesn't own any tenant, so it removes the local files of all of them on sync.
+	logLevel := logging.Level{}
+	require.NoError(t, logLevel.Set("info"))
+	stores, err := storegateway.NewBucketStores(storageCfg, &noTenantsShardingStrategy{}, bkt, overrides, logLevel, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+
+	queryable, err := NewBlocksStoreQueryableFromConfig(querierCfg, gatewayCfg, storageCfg, overrides, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(ctx, queryable))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(context.Background(), queryable))
+	})
+
+	query := func() {
+		q, err := queryable.Querier(ctx, 0, 10)
+		require.NoError(t, err)
+
+		set := q.Select(true, &storage.SelectHints{Start: 0, End: 10}, labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "series_1"))
+		require.True(t, set.Next())
+		assert.Equal(t, series, set.At().Labels())
+		assert.False(t, set.Next())
+		require.NoError(t, set.Err())
+	}
+
+	query()
+
+	// The index-header of the queried block is cached in the querier local directory.
+	entries, err := os.ReadDir(filepath.Join(querierCfg.BucketDirectReadDir, "index-headers"))
+	require.NoError(t, err)
+	require.NotEmpty(t, entries)
+
+	require.NoError(t, stores.SyncBlocks(ctx))
+
+	// The store-gateway sync should have left the querier local directory untouched.
+	entries, err = os.ReadDir(filepath.Join(querierCfg.BucketDirectReadDir, "index-headers"))
+	require.NoError(t, err)
+	assert.NotEmpty(t, entries)
+
+	query()
+}
+
+// noTenantsShardingStrategy is a storegateway.ShardingStrategy which doesn't own any tenant.
+type noTenantsShardingStrategy struct{}
+
+func (s *noTenantsShardingStrategy) FilterUsers(context.Context, []string) []string {
+	return nil
+}
+
+func (s *noTenantsShardingStrategy) FilterBlocks(_ context.Context, _ string, metas map[ulid.ULID]*metadata.Meta, _ map[ulid.ULID]struct{}, _ *extprom.TxGaugeVec) error {
+	for id := range metas {
+		delete(metas, id)
+	}
+	return nil
+}
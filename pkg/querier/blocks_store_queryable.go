@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"io"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -47,6 +48,7 @@ import (
 	"github.com/grafana/mimir/pkg/storegateway/storegatewaypb"
 	"github.com/grafana/mimir/pkg/tenant"
 	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/globalerror"
 	"github.com/grafana/mimir/pkg/util/limiter"
 	util_log "github.com/grafana/mimir/pkg/util/log"
 	"github.com/grafana/mimir/pkg/util/math"
@@ -62,7 +64,7 @@ const (
 )
 
 var (
-	errMaxChunksPerQueryLimit = "the query hit the max number of chunks limit while fetching chunks from store-gateways for %s (limit: %d)"
+	errMaxChunksPerQueryLimit = globalerror.MaxChunksPerQuery.Message("the query hit the max number of chunks limit while fetching chunks from store-gateways for %s (limit: %d)")
 )
 
 // BlocksStoreSet is the interface used to get the clients to query series on a set of blocks.
@@ -110,6 +112,7 @@ type blocksStoreQueryableMetrics struct {
 	blocksFound                                       prometheus.Counter
 	blocksQueried                                     prometheus.Counter
 	blocksWithCompactorShardButIncompatibleQueryShard prometheus.Counter
+	blocksQueriedFromBucketFallback                   prometheus.Counter
 }
 
 func newBlocksStoreQueryableMetrics(reg prometheus.Registerer) *blocksStoreQueryableMetrics {
@@ -139,6 +142,10 @@ func newBlocksStoreQueryableMetrics(reg prometheus.Registerer) *blocksStoreQuery
 			Name: "cortex_querier_blocks_with_compactor_shard_but_incompatible_query_shard_total",
 			Help: "Blocks that couldn't be checked for query and compactor sharding optimization due to incompatible shard counts.",
 		}),
+		blocksQueriedFromBucketFallback: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_querier_blocks_queried_from_bucket_fallback_total",
+			Help: "Number of blocks queried directly from the bucket instead of store-gateways.",
+		}),
 	}
 }
 
@@ -155,6 +162,12 @@ type BlocksStoreQueryable struct {
 	metrics         *blocksStoreQueryableMetrics
 	limits          BlocksStoreLimits
 
+	// If set, blocks which couldn't be queried from any store-gateway are read from the bucket.
+	bucketFallback *storegateway.DirectBucketReader
+
+	// If true, store-gateways are not queried at all and all blocks are read through bucketFallback.
+	bypassStoreGateways bool
+
 	// Subservices manager.
 	subservices        *services.Manager
 	subservicesWatcher *services.FailureWatcher
@@ -262,7 +275,22 @@ func NewBlocksStoreQueryableFromConfig(querierCfg Config, gatewayCfg storegatewa
 		reg,
 	)
 
-	return NewBlocksStoreQueryable(stores, finder, consistency, limits, querierCfg.QueryStoreAfter, logger, reg)
+	q, err := NewBlocksStoreQueryable(stores, finder, consistency, limits, querierCfg.QueryStoreAfter, logger, reg)
+	if err != nil {
+		return nil, err
+	}
+
+	if querierCfg.StoreGatewayBucketFallbackEnabled || querierCfg.BypassStoreGateways {
+		readerCfg := storegateway.DirectBucketReaderConfig{
+			RateLimit:            querierCfg.BucketDirectReadRateLimit,
+			IndexHeaderCacheSize: querierCfg.BucketDirectReadIndexHeaderCacheSize,
+		}
+
+		q.bucketFallback = storegateway.NewDirectBucketReader(readerCfg, storageCfg, bucketClient, querierCfg.BucketDirectReadDir, logger)
+		q.bypassStoreGateways = querierCfg.BypassStoreGateways
+	}
+
+	return q, nil
 }
 
 func (q *BlocksStoreQueryable) starting(ctx context.Context) error {
@@ -302,17 +330,19 @@ func (q *BlocksStoreQueryable) Querier(ctx context.Context, mint, maxt int64) (s
 	}
 
 	return &blocksStoreQuerier{
-		ctx:             ctx,
-		minT:            mint,
-		maxT:            maxt,
-		userID:          userID,
-		finder:          q.finder,
-		stores:          q.stores,
-		metrics:         q.metrics,
-		limits:          q.limits,
-		consistency:     q.consistency,
-		logger:          q.logger,
-		queryStoreAfter: q.queryStoreAfter,
+		ctx:                 ctx,
+		minT:                mint,
+		maxT:                maxt,
+		userID:              userID,
+		finder:              q.finder,
+		stores:              q.stores,
+		metrics:             q.metrics,
+		limits:              q.limits,
+		consistency:         q.consistency,
+		logger:              q.logger,
+		queryStoreAfter:     q.queryStoreAfter,
+		bucketFallback:      q.bucketFallback,
+		bypassStoreGateways: q.bypassStoreGateways,
 	}, nil
 }
 
@@ -330,6 +360,12 @@ type blocksStoreQuerier struct {
 	// If set, the querier manipulates the max time to not be greater than
 	// "now - queryStoreAfter" so that most recent blocks are not queried.
 	queryStoreAfter time.Duration
+
+	// If set, blocks which couldn't be queried from any store-gateway are read from the bucket.
+	bucketFallback *storegateway.DirectBucketReader
+
+	// If true, store-gateways are not queried at all and all blocks are read through bucketFallback.
+	bypassStoreGateways bool
 }
 
 // Select implements storage.Querier interface.
@@ -547,9 +583,17 @@ func (q *blocksStoreQuerier) queryWithConsistencyCheck(ctx context.Context, logg
 		touchedStores   = map[string]struct{}{}
 
 		resQueriedBlocks = []ulid.ULID(nil)
+
+		attempt     int
+		maxAttempts = maxFetchSeriesAttempts
 	)
 
-	for attempt := 1; attempt <= maxFetchSeriesAttempts; attempt++ {
+	// When bypassing the store-gateways, all blocks are directly queried from the bucket.
+	if q.bypassStoreGateways {
+		maxAttempts = 0
+	}
+
+	for attempt = 1; attempt <= maxAttempts; attempt++ {
 		// Find the set of store-gateway instances having the blocks. The exclude parameter is the
 		// map of blocks queried so far, with the list of store-gateway addresses for each block.
 		clients, err := q.stores.GetClientsFor(q.userID, remainingBlocks, attemptedBlocks)
@@ -599,6 +643,37 @@ func (q *blocksStoreQuerier) queryWithConsistencyCheck(ctx context.Context, logg
 		remainingBlocks = missingBlocks
 	}
 
+	// As a last resort, query the missing blocks directly from the bucket.
+	if q.bucketFallback != nil {
+		if !q.bypassStoreGateways {
+			level.Warn(util_log.WithContext(ctx, logger)).Log("msg", "querying blocks missing from store-gateways directly from the bucket", "blocks", strings.Join(convertULIDsToString(remainingBlocks), " "))
+		}
+
+		clients := map[BlocksStoreClient][]ulid.ULID{
+			newBucketFallbackClient(q.bucketFallback, q.userID, remainingBlocks): remainingBlocks,
+		}
+
+		queriedBlocks, err := queryFunc(clients, minT, maxT)
+		if err != nil {
+			return errors.Wrap(err, "query blocks from the bucket")
+		}
+
+		q.metrics.blocksQueriedFromBucketFallback.Add(float64(len(queriedBlocks)))
+		stats.FromContext(ctx).AddFetchedBlocksFromBucket(uint64(len(queriedBlocks)))
+		resQueriedBlocks = append(resQueriedBlocks, queriedBlocks...)
+
+		missingBlocks := q.consistency.Check(knownBlocks, knownDeletionMarks, resQueriedBlocks)
+		if len(missingBlocks) == 0 {
+			q.metrics.storesHit.Observe(float64(len(touchedStores)))
+			// The bucket fallback counts as a re-fetch too.
+			q.metrics.refetches.Observe(float64(attempt - 1))
+
+			return nil
+		}
+
+		remainingBlocks = missingBlocks
+	}
+
 	// We've not been able to query all expected blocks after all retries.
 	level.Warn(util_log.WithContext(ctx, logger)).Log("msg", "failed consistency check", "err", err)
 	return fmt.Errorf("consistency check failed because some blocks were not queried: %s", strings.Join(convertULIDsToString(remainingBlocks), " "))
@@ -696,6 +771,7 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(
 		warnings      = storage.Warnings(nil)
 		queriedBlocks = []ulid.ULID(nil)
 		numChunks     = atomic.NewInt32(0)
+		seriesBytes   = atomic.NewUint64(0)
 		spanLog       = spanlogger.FromContext(ctx, q.logger)
 		queryLimiter  = limiter.QueryLimiterFromContextWithFallback(ctx)
 		reqStats      = stats.FromContext(ctx)
@@ -738,6 +814,10 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(
 
 				resp, err := stream.Recv()
 				if err == io.EOF {
+					// The size of the postings read by the store-gateway is only known once all series have been received.
+					if dataBytesLimitErr := queryLimiter.AddDataBytes(postingsBytesFromTrailer(stream.Trailer())); dataBytesLimitErr != nil {
+						return validation.LimitError(dataBytesLimitErr.Error())
+					}
 					break
 				}
 				if err != nil {
@@ -750,7 +830,7 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(
 					mySeries = append(mySeries, s)
 
 					// Add series fingerprint to query limiter; will return error if we are over the limit
-					limitErr := queryLimiter.AddSeries(mimirpb.FromLabelsToLabelAdapters(s.PromLabels()))
+					limitErr := queryLimiter.AddSeries(limiter.SourceStoreGateways, mimirpb.FromLabelsToLabelAdapters(s.PromLabels()))
 					if limitErr != nil {
 						return validation.LimitError(limitErr.Error())
 					}
@@ -764,12 +844,15 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(
 							return validation.LimitError(fmt.Sprintf(errMaxChunksPerQueryLimit, util.LabelMatchersToString(matchers), maxChunksLimit))
 						}
 					}
-					if chunkBytesLimitErr := queryLimiter.AddChunkBytes(chunksSize); chunkBytesLimitErr != nil {
+					if chunkBytesLimitErr := queryLimiter.AddChunkBytes(limiter.SourceStoreGateways, chunksSize); chunkBytesLimitErr != nil {
 						return validation.LimitError(chunkBytesLimitErr.Error())
 					}
-					if chunkLimitErr := queryLimiter.AddChunks(len(s.Chunks)); chunkLimitErr != nil {
+					if chunkLimitErr := queryLimiter.AddChunks(limiter.SourceStoreGateways, len(s.Chunks)); chunkLimitErr != nil {
 						return validation.LimitError(chunkLimitErr.Error())
 					}
+					if dataBytesLimitErr := queryLimiter.AddDataBytes(s.Size()); dataBytesLimitErr != nil {
+						return validation.LimitError(dataBytesLimitErr.Error())
+					}
 				}
 
 				if w := resp.GetWarning(); w != "" {
@@ -797,6 +880,9 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(
 			reqStats.AddFetchedSeries(uint64(numSeries))
 			reqStats.AddFetchedChunkBytes(uint64(chunkBytes))
 			reqStats.AddFetchedChunks(uint64(chunksFetched))
+			if reqStats != nil {
+				seriesBytes.Add(uint64(seriesSize(mySeries...)))
+			}
 
 			level.Debug(spanLog).Log("msg", "received series from store-gateway",
 				"instance", c.RemoteAddress(),
@@ -822,6 +908,9 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(
 		return nil, nil, nil, 0, err
 	}
 
+	// The series received from all the store-gateways are held in memory together.
+	reqStats.UpdateSelectorPeakMemoryBytes(seriesBytes.Load())
+
 	return seriesSets, queriedBlocks, warnings, int(numChunks.Load()), nil
 }
 
@@ -1091,6 +1180,28 @@ func convertBlockHintsToULIDs(hints []hintspb.Block) ([]ulid.ULID, error) {
 }
 
 // countChunksAndBytes returns the number of chunks and size of the chunks making up the provided series in bytes
+func seriesSize(series ...*storepb.Series) (size int) {
+	for _, s := range series {
+		size += s.Size()
+	}
+
+	return size
+}
+
+// postingsBytesFromTrailer returns the size of the postings read by the store-gateway to look up the series,
+// or 0 if the store-gateway didn't report it.
+func postingsBytesFromTrailer(trailer grpc_metadata.MD) int {
+	values := trailer.Get(storegateway.SeriesPostingsBytesTrailer)
+	if len(values) == 0 {
+		return 0
+	}
+	size, err := strconv.Atoi(values[0])
+	if err != nil {
+		return 0
+	}
+	return size
+}
+
 func countChunksAndBytes(series ...*storepb.Series) (chunks, bytes int) {
 	for _, s := range series {
 		chunks += len(s.Chunks)
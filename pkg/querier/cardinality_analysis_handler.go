@@ -86,6 +86,41 @@ func LabelValuesCardinalityHandler(distributor Distributor, limits *validation.O
 	})
 }
 
+// ActiveSeriesCardinalityHandler creates handler for active series endpoint.
+func ActiveSeriesCardinalityHandler(distributor Distributor, limits *validation.Overrides) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		// Guarantee request's context is for a single tenant id
+		tenantID, err := tenant.TenantID(ctx)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !limits.CardinalityAnalysisEnabled(tenantID) {
+			http.Error(w, fmt.Sprintf("cardinality analysis is disabled for the tenant: %v", tenantID), http.StatusBadRequest)
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		matchers, err := extractSelector(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		series, err := distributor.ActiveSeries(ctx, matchers)
+		if err != nil {
+			respondFromError(err, w)
+			return
+		}
+
+		util.WriteJSONResponse(w, toActiveSeriesResponse(series))
+	})
+}
+
 func extractLabelNamesRequestParams(r *http.Request) ([]*labels.Matcher, int, error) {
 	err := r.ParseForm()
 	if err != nil {
@@ -306,3 +341,16 @@ type labelValuesCardinalityResponse struct {
 	SeriesCountTotal uint64                  `json:"series_count_total"`
 	Labels           []labelNamesCardinality `json:"labels"`
 }
+
+// toActiveSeriesResponse converts the series returned by the distributor, which are already sorted, to activeSeriesResponse.
+func toActiveSeriesResponse(series []labels.Labels) *activeSeriesResponse {
+	data := make([]map[string]string, 0, len(series))
+	for _, lbls := range series {
+		data = append(data, lbls.Map())
+	}
+	return &activeSeriesResponse{Data: data}
+}
+
+type activeSeriesResponse struct {
+	Data []map[string]string `json:"data"`
+}
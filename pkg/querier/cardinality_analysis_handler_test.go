@@ -772,6 +772,88 @@ func TestLabelValuesCardinalityHandler_DistributorError(t *testing.T) {
 	}
 }
 
+func TestActiveSeriesCardinalityHandler(t *testing.T) {
+	series := []labels.Labels{
+		labels.FromStrings("__name__", "metric", "job", "a"),
+		labels.FromStrings("__name__", "metric", "job", "b"),
+	}
+
+	tests := map[string]struct {
+		url                        string
+		cardinalityAnalysisEnabled bool
+		distributorError           error
+		expectedDistributorCall    bool
+		expectedMatchers           []*labels.Matcher
+		expectedStatusCode         int
+		expectedBody               string
+	}{
+		"should return the active series": {
+			url:                        "/active_series",
+			cardinalityAnalysisEnabled: true,
+			expectedDistributorCall:    true,
+			expectedMatchers:           []*labels.Matcher(nil),
+			expectedStatusCode:         http.StatusOK,
+			expectedBody:               `{"data":[{"__name__":"metric","job":"a"},{"__name__":"metric","job":"b"}]}`,
+		},
+		"should pass the selector to the distributor": {
+			url:                        "/active_series?selector=" + url.QueryEscape(`{__name__="metric"}`),
+			cardinalityAnalysisEnabled: true,
+			expectedDistributorCall:    true,
+			expectedMatchers:           []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "__name__", "metric")},
+			expectedStatusCode:         http.StatusOK,
+			expectedBody:               `{"data":[{"__name__":"metric","job":"a"},{"__name__":"metric","job":"b"}]}`,
+		},
+		"should return an error if the cardinality analysis feature is disabled": {
+			url:                "/active_series",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       "cardinality analysis is disabled for the tenant: team-a\n",
+		},
+		"should return bad request if multiple selector params are provided": {
+			url:                        "/active_series?selector=foo&selector=bar",
+			cardinalityAnalysisEnabled: true,
+			expectedStatusCode:         http.StatusBadRequest,
+			expectedBody:               "multiple 'selector' params are not allowed\n",
+		},
+		"should return internal server error if the distributor fails": {
+			url:                        "/active_series",
+			cardinalityAnalysisEnabled: true,
+			distributorError:           fmt.Errorf("size of distinct active series is greater than 10 bytes"),
+			expectedDistributorCall:    true,
+			expectedMatchers:           []*labels.Matcher(nil),
+			expectedStatusCode:         http.StatusInternalServerError,
+			expectedBody:               "size of distinct active series is greater than 10 bytes\n",
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			distributor := &mockDistributor{}
+			distributor.On("ActiveSeries", mock.Anything, mock.Anything).Return(series, testData.distributorError)
+
+			limits := validation.Limits{CardinalityAnalysisEnabled: testData.cardinalityAnalysisEnabled}
+			overrides, err := validation.NewOverrides(limits, nil)
+			require.NoError(t, err)
+			handler := ActiveSeriesCardinalityHandler(distributor, overrides)
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, createRequest(testData.url, "team-a"))
+
+			require.Equal(t, testData.expectedStatusCode, recorder.Result().StatusCode)
+			body := recorder.Result().Body
+			defer func() { _ = body.Close() }()
+			bodyContent, err := ioutil.ReadAll(body)
+			require.NoError(t, err)
+			require.Equal(t, testData.expectedBody, string(bodyContent))
+
+			if testData.expectedDistributorCall {
+				distributor.AssertCalled(t, "ActiveSeries", mock.Anything, testData.expectedMatchers)
+			} else {
+				distributor.AssertNotCalled(t, "ActiveSeries", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
 // createEnabledHandler creates a cardinalityHandler that can be either a LabelNamesCardinalityHandler or a LabelValuesCardinalityHandler
 func createEnabledHandler(t *testing.T, cardinalityHandler func(Distributor, *validation.Overrides) http.Handler, distributor *mockDistributor) http.Handler {
 	limits := validation.Limits{CardinalityAnalysisEnabled: true}
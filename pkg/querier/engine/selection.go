@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package engine
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+const (
+	// StandardEngine is the Prometheus PromQL engine.
+	StandardEngine = validation.QueryEngineStandard
+
+	// StreamingEngine is the PromQL engine evaluating a subset of PromQL expressions one series at a time.
+	StreamingEngine = validation.QueryEngineStreaming
+
+	// QueryEngineHeader is the HTTP header used to select the PromQL engine evaluating a single query.
+	QueryEngineHeader = "Query-Engine"
+)
+
+type contextKey int
+
+const queryEngineContextKey contextKey = 0
+
+// IsValidQueryEngine returns whether the given name is the name of a supported PromQL engine.
+func IsValidQueryEngine(name string) bool {
+	return name == StandardEngine || name == StreamingEngine
+}
+
+// ContextWithQueryEngine returns a new context requesting the given PromQL engine to evaluate queries.
+func ContextWithQueryEngine(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, queryEngineContextKey, name)
+}
+
+// QueryEngineFromContext returns the PromQL engine requested in the context, or an empty string if none has been requested.
+func QueryEngineFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(queryEngineContextKey).(string)
+	return name
+}
+
+// QueryEngineMiddleware requests the PromQL engine set in the QueryEngineHeader of HTTP requests, if any.
+func QueryEngineMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.Header.Get(QueryEngineHeader)
+		if name == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !IsValidQueryEngine(name) {
+			http.Error(w, fmt.Sprintf("invalid %s header value %q, supported values are: %s, %s", QueryEngineHeader, name, StandardEngine, StreamingEngine), http.StatusBadRequest)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(ContextWithQueryEngine(r.Context(), name)))
+	})
+}
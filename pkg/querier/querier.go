@@ -20,6 +20,7 @@ import (
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/promql"
 	"github.com/prometheus/prometheus/storage"
+	v1 "github.com/prometheus/prometheus/web/api/v1"
 	"github.com/thanos-io/thanos/pkg/strutil"
 	"golang.org/x/sync/errgroup"
 
@@ -28,9 +29,11 @@ import (
 	"github.com/grafana/mimir/pkg/querier/iterators"
 	"github.com/grafana/mimir/pkg/storage/chunk"
 	"github.com/grafana/mimir/pkg/storage/lazyquery"
+	"github.com/grafana/mimir/pkg/streamingpromql"
 	"github.com/grafana/mimir/pkg/tenant"
 	"github.com/grafana/mimir/pkg/util"
 	"github.com/grafana/mimir/pkg/util/activitytracker"
+	"github.com/grafana/mimir/pkg/util/grpcencoding"
 	"github.com/grafana/mimir/pkg/util/limiter"
 	"github.com/grafana/mimir/pkg/util/spanlogger"
 	"github.com/grafana/mimir/pkg/util/validation"
@@ -48,8 +51,20 @@ type Config struct {
 
 	StoreGatewayClient ClientConfig `yaml:"store_gateway_client"`
 
+	StoreGatewayBucketFallbackEnabled    bool    `yaml:"store_gateway_bucket_fallback_enabled" category:"experimental"`
+	BypassStoreGateways                  bool    `yaml:"bypass_store_gateways" category:"experimental"`
+	BucketDirectReadRateLimit            float64 `yaml:"bucket_direct_read_rate_limit" category:"experimental"`
+	BucketDirectReadIndexHeaderCacheSize int     `yaml:"bucket_direct_read_index_header_cache_size" category:"experimental"`
+	BucketDirectReadDir                  string  `yaml:"bucket_direct_read_dir" category:"experimental"`
+
 	ShuffleShardingIngestersLookbackPeriod time.Duration `yaml:"shuffle_sharding_ingesters_lookback_period" category:"advanced"`
 
+	IngesterQueryHedgingEnabled    bool          `yaml:"ingester_query_hedging_enabled" category:"experimental"`
+	IngesterQueryHedgingPercentile float64       `yaml:"ingester_query_hedging_percentile" category:"experimental"`
+	IngesterQueryHedgingMinDelay   time.Duration `yaml:"ingester_query_hedging_min_delay" category:"experimental"`
+
+	IngesterClientGRPCCompression string `yaml:"ingester_client_grpc_compression" category:"experimental"`
+
 	// PromQL engine config.
 	EngineConfig engine.Config `yaml:",inline"`
 }
@@ -58,11 +73,17 @@ var (
 	errBadLookbackConfigs                             = errors.New("bad settings, query_store_after >= query_ingesters_within which can result in queries not being sent")
 	errShuffleShardingLookbackLessThanQueryStoreAfter = errors.New("the shuffle-sharding lookback period should be greater or equal than the configured 'query store after'")
 	errEmptyTimeRange                                 = errors.New("empty time range")
+	errInvalidIngesterQueryHedgingPercentile          = errors.New("the ingester query hedging percentile must be greater than 0 and less or equal than 1")
 )
 
 // RegisterFlags adds the flags required to config this to the given FlagSet.
 func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.StoreGatewayClient.RegisterFlagsWithPrefix("querier.store-gateway-client", f)
+	f.BoolVar(&cfg.StoreGatewayBucketFallbackEnabled, "querier.store-gateway-bucket-fallback-enabled", false, "When enabled, blocks that couldn't be queried from any store-gateway replica are read directly from the bucket by the querier, before failing the query. This is a slow path, because the querier has to load each missing block for every request.")
+	f.BoolVar(&cfg.BypassStoreGateways, "querier.bypass-store-gateways", false, "When enabled, queriers read blocks directly from the bucket instead of querying the store-gateways. This is an emergency mode to keep serving queries while store-gateways are unavailable, trading query latency and querier resources for availability.")
+	f.Float64Var(&cfg.BucketDirectReadRateLimit, "querier.bucket-direct-read-rate-limit", 10, "Per-querier maximum number of requests per second reading blocks directly from the bucket, when -querier.bypass-store-gateways or -querier.store-gateway-bucket-fallback-enabled are enabled. Requests over the limit wait until they're allowed, or fail if the query times out first. 0 to disable the limit.")
+	f.IntVar(&cfg.BucketDirectReadIndexHeaderCacheSize, "querier.bucket-direct-read-index-header-cache-size", 100, "Maximum number of index-headers of blocks read directly from the bucket to keep on the querier local disk, so that they don't have to be built again when the same blocks are queried. 0 to disable the cache.")
+	f.StringVar(&cfg.BucketDirectReadDir, "querier.bucket-direct-read-dir", "./querier-bucket-direct-read/", "Directory to store the index-headers of the blocks read directly from the bucket. It's cleaned up when the querier starts, and must not be within -blocks-storage.bucket-store.sync-dir, which is managed by the store-gateway.")
 	f.BoolVar(&cfg.Iterators, "querier.iterators", false, "Use iterators to execute query, as opposed to fully materialising the series in memory.")
 	f.BoolVar(&cfg.BatchIterators, "querier.batch-iterators", true, "Use batch iterators to execute query, as opposed to fully materialising the series in memory.  Takes precedent over the -querier.iterators flag.")
 	f.DurationVar(&cfg.QueryIngestersWithin, "querier.query-ingesters-within", 13*time.Hour, "Maximum lookback beyond which queries are not sent to ingester. 0 means all queries are sent to ingester.")
@@ -70,6 +91,11 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.DurationVar(&cfg.QueryStoreAfter, "querier.query-store-after", 0, "The time after which a metric should be queried from storage and not just ingesters. 0 means all queries are sent to store. If this option is enabled, the time range of the query sent to the store-gateway will be manipulated to ensure the query end is not more recent than 'now - query-store-after'.")
 	f.DurationVar(&cfg.ShuffleShardingIngestersLookbackPeriod, "querier.shuffle-sharding-ingesters-lookback-period", 0, "When distributor's sharding strategy is shuffle-sharding and this setting is > 0, queriers fetch in-memory series from the minimum set of required ingesters, selecting only ingesters which may have received series since 'now - lookback period'. The lookback period should be greater or equal than the configured -querier.query-store-after and -querier.query-ingesters-within. If this setting is 0, queriers always query all ingesters (ingesters shuffle sharding on read path is disabled).")
 
+	f.BoolVar(&cfg.IngesterQueryHedgingEnabled, "querier.ingester-query-hedging-enabled", false, "When enabled, the ingesters which are not required to reach the quorum are only queried if the other ingesters take longer than the hedging delay to respond, or fail. The hedging delay is a percentile of the recently observed ingester query latencies. Hedging can't be enabled when ingesters zone-awareness is enabled.")
+	f.Float64Var(&cfg.IngesterQueryHedgingPercentile, "querier.ingester-query-hedging-percentile", 0.9, "Percentile of the recently observed ingester query latencies used as hedging delay, between 0 and 1.")
+	f.DurationVar(&cfg.IngesterQueryHedgingMinDelay, "querier.ingester-query-hedging-min-delay", 10*time.Millisecond, "Minimum hedging delay.")
+	f.StringVar(&cfg.IngesterClientGRPCCompression, "querier.ingester-client.grpc-compression", "", "Use compression when querying ingesters, overriding -ingester.client.grpc-compression for the queries. "+grpcencoding.SupportedUsage+" If empty, -ingester.client.grpc-compression is used.")
+
 	cfg.EngineConfig.RegisterFlags(f)
 }
 
@@ -82,6 +108,18 @@ func (cfg *Config) Validate() error {
 		}
 	}
 
+	if cfg.IngesterQueryHedgingEnabled && (cfg.IngesterQueryHedgingPercentile <= 0 || cfg.IngesterQueryHedgingPercentile > 1) {
+		return errInvalidIngesterQueryHedgingPercentile
+	}
+
+	if err := grpcencoding.Validate(cfg.IngesterClientGRPCCompression); err != nil {
+		return fmt.Errorf("invalid ingester client gRPC compression: %w", err)
+	}
+
+	if err := cfg.StoreGatewayClient.Validate(); err != nil {
+		return fmt.Errorf("invalid store-gateway client config: %w", err)
+	}
+
 	if cfg.ShuffleShardingIngestersLookbackPeriod > 0 {
 		if cfg.ShuffleShardingIngestersLookbackPeriod < cfg.QueryStoreAfter {
 			return errShuffleShardingLookbackLessThanQueryStoreAfter
@@ -101,7 +139,7 @@ func getChunksIteratorFunction(cfg Config) chunkIteratorFunc {
 }
 
 // New builds a queryable and promql engine.
-func New(cfg Config, limits *validation.Overrides, distributor Distributor, stores []QueryableWithFilter, reg prometheus.Registerer, logger log.Logger, tracker *activitytracker.ActivityTracker) (storage.SampleAndChunkQueryable, storage.ExemplarQueryable, *promql.Engine) {
+func New(cfg Config, limits *validation.Overrides, distributor Distributor, stores []QueryableWithFilter, reg prometheus.Registerer, logger log.Logger, tracker *activitytracker.ActivityTracker) (storage.SampleAndChunkQueryable, storage.ExemplarQueryable, v1.QueryEngine) {
 	iteratorFunc := getChunksIteratorFunction(cfg)
 
 	distributorQueryable := newDistributorQueryable(distributor, iteratorFunc, cfg.QueryIngestersWithin, logger)
@@ -124,8 +162,10 @@ func New(cfg Config, limits *validation.Overrides, distributor Distributor, stor
 		return lazyquery.NewLazyQuerier(querier), nil
 	})
 
-	engine := promql.NewEngine(engine.NewPromQLEngineOptions(cfg.EngineConfig, tracker, logger, reg))
-	return NewSampleAndChunkQueryable(lazyQueryable), exemplarQueryable, engine
+	// Both engines share the same options, and so the same limit of concurrent queries.
+	opts := engine.NewPromQLEngineOptions(cfg.EngineConfig, tracker, logger, reg)
+	queryEngine := NewQueryEngine(promql.NewEngine(opts), streamingpromql.NewEngine(opts), limits, reg, logger)
+	return NewSampleAndChunkQueryable(lazyQueryable), exemplarQueryable, queryEngine
 }
 
 // NewSampleAndChunkQueryable creates a SampleAndChunkQueryable from a
@@ -161,7 +201,7 @@ func NewQueryable(distributor QueryableWithFilter, stores []QueryableWithFilter,
 			return nil, err
 		}
 
-		ctx = limiter.AddQueryLimiterToContext(ctx, limiter.NewQueryLimiter(limits.MaxFetchedSeriesPerQuery(userID), limits.MaxFetchedChunkBytesPerQuery(userID), limits.MaxChunksPerQuery(userID)))
+		ctx = limiter.AddQueryLimiterToContext(ctx, newQueryLimiter(limits, userID))
 
 		mint, maxt, err = validateQueryTimeRange(ctx, userID, mint, maxt, limits, cfg.MaxQueryIntoFuture, logger)
 		if err == errEmptyTimeRange {
@@ -189,7 +229,7 @@ func NewQueryable(distributor QueryableWithFilter, stores []QueryableWithFilter,
 		}
 
 		for _, s := range stores {
-			if !s.UseQueryable(now, mint, maxt) {
+			if IngestersOnlyFromContext(ctx) || !s.UseQueryable(now, mint, maxt) {
 				continue
 			}
 
@@ -205,6 +245,22 @@ func NewQueryable(distributor QueryableWithFilter, stores []QueryableWithFilter,
 	})
 }
 
+type contextKey int
+
+const ingestersOnlyKey contextKey = 0
+
+// ContextWithIngestersOnly returns a context whose queries are run only against the ingesters,
+// skipping the long-term storage.
+func ContextWithIngestersOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, ingestersOnlyKey, true)
+}
+
+// IngestersOnlyFromContext returns whether the queries run with the context must hit only the ingesters.
+func IngestersOnlyFromContext(ctx context.Context) bool {
+	ingestersOnly, _ := ctx.Value(ingestersOnlyKey).(bool)
+	return ingestersOnly
+}
+
 // querier implements storage.Querier, running requests across a set of queriers.
 type querier struct {
 	queriers []storage.Querier
@@ -531,3 +587,20 @@ func clampTime(ctx context.Context, t model.Time, limit time.Duration, clamp mod
 	}
 	return t
 }
+
+// newQueryLimiter returns a limiter enforcing the limits to the data fetched by a query of the user,
+// both from all sources and from each source.
+func newQueryLimiter(limits *validation.Overrides, userID string) *limiter.QueryLimiter {
+	return limiter.NewQueryLimiter(limits.MaxFetchedSeriesPerQuery(userID), limits.MaxFetchedChunkBytesPerQuery(userID), limits.MaxChunksPerQuery(userID)).
+		WithSourceLimits(limiter.SourceIngesters, limiter.SourceLimits{
+			MaxSeries:     limits.MaxFetchedSeriesPerQueryFromIngesters(userID),
+			MaxChunkBytes: limits.MaxFetchedChunkBytesPerQueryFromIngesters(userID),
+			MaxChunks:     limits.MaxChunksPerQueryFromIngesters(userID),
+		}).
+		WithSourceLimits(limiter.SourceStoreGateways, limiter.SourceLimits{
+			MaxSeries:     limits.MaxFetchedSeriesPerQueryFromStoreGateways(userID),
+			MaxChunkBytes: limits.MaxFetchedChunkBytesPerQueryFromStoreGateways(userID),
+			MaxChunks:     limits.MaxChunksPerQueryFromStoreGateways(userID),
+		}).
+		WithMaxDataBytes(limits.MaxFetchedDataBytesPerQuery(userID))
+}
@@ -480,13 +480,13 @@ func TestQuerier_ValidateQueryTimeRange_MaxQueryLength(t *testing.T) {
 			query:          "rate(foo[31d])",
 			queryStartTime: time.Now().Add(-time.Hour),
 			queryEndTime:   time.Now(),
-			expected:       errors.New("expanding series: the query time range exceeds the limit (query length: 745h0m0s, limit: 720h0m0s)"),
+			expected:       errors.New("expanding series: the query time range exceeds the limit (query length: 745h0m0s, limit: 720h0m0s) (err-mimir-max-query-length)"),
 		},
 		"should forbid query on large time range over the limit and short rate time window": {
 			query:          "rate(foo[1m])",
 			queryStartTime: time.Now().Add(-maxQueryLength).Add(-time.Hour),
 			queryEndTime:   time.Now(),
-			expected:       errors.New("expanding series: the query time range exceeds the limit (query length: 721h1m0s, limit: 720h0m0s)"),
+			expected:       errors.New("expanding series: the query time range exceeds the limit (query length: 721h1m0s, limit: 720h0m0s) (err-mimir-max-query-length)"),
 		},
 	}
 
@@ -879,6 +879,10 @@ func (m *errDistributor) LabelValuesCardinality(ctx context.Context, labelNames
 	return 0, nil, errDistributorError
 }
 
+func (m *errDistributor) ActiveSeries(ctx context.Context, matchers []*labels.Matcher) ([]labels.Labels, error) {
+	return nil, errDistributorError
+}
+
 type emptyDistributor struct{}
 
 func (d *emptyDistributor) LabelNamesAndValues(_ context.Context, _ []*labels.Matcher) (*client.LabelNamesAndValuesResponse, error) {
@@ -917,12 +921,17 @@ func (d *emptyDistributor) LabelValuesCardinality(ctx context.Context, labelName
 	return 0, nil, nil
 }
 
+func (d *emptyDistributor) ActiveSeries(ctx context.Context, matchers []*labels.Matcher) ([]labels.Labels, error) {
+	return nil, nil
+}
+
 func TestQuerier_QueryStoreAfterConfig(t *testing.T) {
 	testCases := []struct {
 		name                 string
 		mint, maxt           time.Time
 		queryIngestersWithin time.Duration
 		queryStoreAfter      time.Duration
+		ingestersOnly        bool
 		expectedHitIngester  bool
 		expectedHitStorage   bool
 	}{
@@ -953,6 +962,16 @@ func TestQuerier_QueryStoreAfterConfig(t *testing.T) {
 			queryIngestersWithin: 1 * time.Hour,
 			queryStoreAfter:      0,
 		},
+		{
+			name:                 "hit only ingester when querying only the ingesters",
+			mint:                 time.Now().Add(-5 * time.Hour),
+			maxt:                 time.Now(),
+			expectedHitIngester:  true,
+			expectedHitStorage:   false,
+			queryIngestersWithin: 1 * time.Hour,
+			queryStoreAfter:      time.Hour,
+			ingestersOnly:        true,
+		},
 	}
 
 	dir, err := ioutil.TempDir("", t.Name())
@@ -990,6 +1009,9 @@ func TestQuerier_QueryStoreAfterConfig(t *testing.T) {
 			require.NoError(t, err)
 
 			ctx := user.InjectOrgID(context.Background(), "0")
+			if c.ingestersOnly {
+				ctx = ContextWithIngestersOnly(ctx)
+			}
 			r := query.Exec(ctx)
 			_, err = r.Matrix()
 
@@ -1079,6 +1101,12 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			expected: errShuffleShardingLookbackLessThanQueryStoreAfter,
 		},
+		"should pass if the gRPC compression to ingesters and store-gateways is enabled": {
+			setup: func(cfg *Config) {
+				cfg.IngesterClientGRPCCompression = "zstd"
+				cfg.StoreGatewayClient.GRPCCompression = "snappy-block"
+			},
+		},
 	}
 
 	for testName, testData := range tests {
@@ -0,0 +1,180 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/storage"
+	promql_stats "github.com/prometheus/prometheus/util/stats"
+	v1 "github.com/prometheus/prometheus/web/api/v1"
+
+	"github.com/grafana/mimir/pkg/querier/engine"
+	"github.com/grafana/mimir/pkg/querier/stats"
+	"github.com/grafana/mimir/pkg/streamingpromql"
+	"github.com/grafana/mimir/pkg/tenant"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+// pointSize is the size in memory of a promql.Point: a timestamp and a value.
+const pointSize = 16
+
+// queryEngine selects, when each query is executed, whether the query is evaluated by
+// the standard Prometheus engine or by the streaming engine. Queries are evaluated by
+// the streaming engine if requested for the query, or configured for the tenant, and
+// fall back to the standard engine if the expression is not supported by the streaming engine.
+type queryEngine struct {
+	standard  *promql.Engine
+	streaming *streamingpromql.Engine
+	limits    *validation.Overrides
+	logger    log.Logger
+
+	streamingQueries prometheus.Counter
+	fallbacks        prometheus.Counter
+}
+
+// NewQueryEngine returns a PromQL engine selecting, for each query, between the standard and the streaming engines.
+func NewQueryEngine(standard *promql.Engine, streaming *streamingpromql.Engine, limits *validation.Overrides, reg prometheus.Registerer, logger log.Logger) v1.QueryEngine {
+	return &queryEngine{
+		standard:  standard,
+		streaming: streaming,
+		limits:    limits,
+		logger:    logger,
+
+		streamingQueries: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_querier_streaming_engine_queries_total",
+			Help: "Total number of queries evaluated by the streaming PromQL engine.",
+		}),
+		fallbacks: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_querier_streaming_engine_fallbacks_total",
+			Help: "Total number of queries requested to be evaluated by the streaming PromQL engine, which have been evaluated by the standard engine because the expression is not supported.",
+		}),
+	}
+}
+
+func (e *queryEngine) SetQueryLogger(l promql.QueryLogger) {
+	// The streaming engine doesn't support logging queries.
+	e.standard.SetQueryLogger(l)
+}
+
+func (e *queryEngine) NewInstantQuery(q storage.Queryable, qs string, ts time.Time) (promql.Query, error) {
+	// The standard query is always created, in order to validate the query
+	// and return its statement before knowing which engine will execute it.
+	query, err := e.standard.NewInstantQuery(q, qs, ts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &selectingQuery{
+		Query:  query,
+		engine: e,
+		newStreamingQuery: func() (promql.Query, error) {
+			return e.streaming.NewInstantQuery(q, qs, ts)
+		},
+	}, nil
+}
+
+func (e *queryEngine) NewRangeQuery(q storage.Queryable, qs string, start, end time.Time, interval time.Duration) (promql.Query, error) {
+	query, err := e.standard.NewRangeQuery(q, qs, start, end, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	return &selectingQuery{
+		Query:  query,
+		engine: e,
+		newStreamingQuery: func() (promql.Query, error) {
+			return e.streaming.NewRangeQuery(q, qs, start, end, interval)
+		},
+	}, nil
+}
+
+// useStreamingEngine returns whether the query with the given context should be evaluated by the streaming engine.
+func (e *queryEngine) useStreamingEngine(ctx context.Context) bool {
+	if name := engine.QueryEngineFromContext(ctx); name != "" {
+		return name == engine.StreamingEngine
+	}
+
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return false
+	}
+
+	// Federated queries are evaluated by the streaming engine only if all tenants are configured to use it.
+	for _, tenantID := range tenantIDs {
+		if e.limits.QueryEngine(tenantID) != engine.StreamingEngine {
+			return false
+		}
+	}
+	return true
+}
+
+// selectingQuery is a promql.Query which is executed by the streaming engine if selected
+// when the query is executed, or by the wrapped standard query otherwise.
+type selectingQuery struct {
+	promql.Query
+
+	engine            *queryEngine
+	newStreamingQuery func() (promql.Query, error)
+	streamingQuery    promql.Query
+}
+
+func (q *selectingQuery) Exec(ctx context.Context) *promql.Result {
+	res := q.exec(ctx)
+
+	// Only the streaming engine tracks the samples it holds while evaluating the query, so the peak
+	// memory of the evaluation isn't reported for the queries evaluated by the standard engine.
+	if sq, ok := q.streamingQuery.(interface{ PeakSamples() int }); ok && res.Err == nil {
+		stats.FromContext(ctx).UpdateEvalPeakMemoryBytes(uint64(sq.PeakSamples()) * pointSize)
+	}
+	return res
+}
+
+func (q *selectingQuery) exec(ctx context.Context) *promql.Result {
+	if !q.engine.useStreamingEngine(ctx) {
+		return q.Query.Exec(ctx)
+	}
+
+	streamingQuery, err := q.newStreamingQuery()
+	if err != nil {
+		if !errors.Is(err, streamingpromql.ErrNotSupported) {
+			return &promql.Result{Err: err}
+		}
+
+		level.Debug(q.engine.logger).Log("msg", "falling back to the standard PromQL engine", "query", q.String(), "reason", err)
+		q.engine.fallbacks.Inc()
+		return q.Query.Exec(ctx)
+	}
+
+	q.engine.streamingQueries.Inc()
+	q.streamingQuery = streamingQuery
+	return streamingQuery.Exec(ctx)
+}
+
+func (q *selectingQuery) Stats() *promql_stats.QueryTimers {
+	if q.streamingQuery != nil {
+		return q.streamingQuery.Stats()
+	}
+	return q.Query.Stats()
+}
+
+func (q *selectingQuery) Cancel() {
+	if q.streamingQuery != nil {
+		q.streamingQuery.Cancel()
+	}
+	q.Query.Cancel()
+}
+
+func (q *selectingQuery) Close() {
+	if q.streamingQuery != nil {
+		q.streamingQuery.Close()
+	}
+	q.Query.Close()
+}
@@ -0,0 +1,160 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/querier/engine"
+	"github.com/grafana/mimir/pkg/querier/stats"
+	"github.com/grafana/mimir/pkg/streamingpromql"
+	"github.com/grafana/mimir/pkg/tenant"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestQueryEngine(t *testing.T) {
+	resolver := tenant.DefaultResolver
+	tenant.WithDefaultResolver(tenant.NewMultiResolver())
+
+	t.Cleanup(func() {
+		tenant.WithDefaultResolver(resolver)
+	})
+
+	test, err := promql.NewTest(t, `
+		load 1m
+			metric{instance="1"} 0+1x60
+			metric{instance="2"} 0+2x60
+	`)
+	require.NoError(t, err)
+	defer test.Close()
+	require.NoError(t, test.Run())
+
+	defaults := validation.Limits{}
+	defaults.QueryEngine = engine.StandardEngine
+	streamingLimits := defaults
+	streamingLimits.QueryEngine = engine.StreamingEngine
+
+	overrides, err := validation.NewOverrides(defaults, queryEngineTenantLimits{
+		"streaming-user":   &streamingLimits,
+		"streaming-user-2": &streamingLimits,
+	})
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		tenantID          string
+		requestedEngine   string
+		query             string
+		expectedStreaming float64
+		expectedFallbacks float64
+	}{
+		"should use the standard engine by default": {
+			tenantID: "user",
+			query:    `sum(metric)`,
+		},
+		"should use the streaming engine if configured for the tenant": {
+			tenantID:          "streaming-user",
+			query:             `sum(metric)`,
+			expectedStreaming: 1,
+		},
+		"should use the streaming engine if requested for the query": {
+			tenantID:          "user",
+			requestedEngine:   engine.StreamingEngine,
+			query:             `sum(metric)`,
+			expectedStreaming: 1,
+		},
+		"should use the standard engine if requested for the query": {
+			tenantID:        "streaming-user",
+			requestedEngine: engine.StandardEngine,
+			query:           `sum(metric)`,
+		},
+		"should use the streaming engine if all federated tenants are configured to use it": {
+			tenantID:          "streaming-user|streaming-user-2",
+			query:             `sum(metric)`,
+			expectedStreaming: 1,
+		},
+		"should use the standard engine if not all federated tenants are configured to use the streaming engine": {
+			tenantID: "streaming-user|user",
+			query:    `sum(metric)`,
+		},
+		"should fall back to the standard engine if the expression is not supported": {
+			tenantID:          "streaming-user",
+			query:             `sum(metric) * 2`,
+			expectedFallbacks: 1,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			opts := promql.EngineOpts{
+				MaxSamples: 1e6,
+				Timeout:    time.Minute,
+			}
+			standard := promql.NewEngine(opts)
+			e := NewQueryEngine(standard, streamingpromql.NewEngine(opts), overrides, prometheus.NewPedanticRegistry(), log.NewNopLogger())
+
+			queryStats, ctx := stats.ContextWithEmptyStats(context.Background())
+			ctx = user.InjectOrgID(ctx, testData.tenantID)
+			if testData.requestedEngine != "" {
+				ctx = engine.ContextWithQueryEngine(ctx, testData.requestedEngine)
+			}
+
+			start, end, step := time.Unix(0, 0), time.Unix(3600, 0), time.Minute
+
+			expectedQuery, err := standard.NewRangeQuery(test.Queryable(), testData.query, start, end, step)
+			require.NoError(t, err)
+			expected := expectedQuery.Exec(context.Background())
+			require.NoError(t, expected.Err)
+
+			q, err := e.NewRangeQuery(test.Queryable(), testData.query, start, end, step)
+			require.NoError(t, err)
+			res := q.Exec(ctx)
+			require.NoError(t, res.Err)
+			assert.Equal(t, expected.Value, res.Value)
+
+			// The streaming engine holds a single point per step, while the peak memory isn't reported
+			// for the standard engine.
+			if testData.expectedStreaming > 0 {
+				assert.Equal(t, uint64(61*pointSize), queryStats.LoadEvalPeakMemoryBytes())
+			} else {
+				assert.Zero(t, queryStats.LoadEvalPeakMemoryBytes())
+			}
+
+			assert.Equal(t, testData.expectedStreaming, testutil.ToFloat64(e.(*queryEngine).streamingQueries))
+			assert.Equal(t, testData.expectedFallbacks, testutil.ToFloat64(e.(*queryEngine).fallbacks))
+		})
+	}
+}
+
+func TestQueryEngine_ShouldReturnValidationErrorsOnQueryCreation(t *testing.T) {
+	overrides, err := validation.NewOverrides(validation.Limits{}, nil)
+	require.NoError(t, err)
+
+	opts := promql.EngineOpts{MaxSamples: 1e6, Timeout: time.Minute}
+	queryEngine := NewQueryEngine(promql.NewEngine(opts), streamingpromql.NewEngine(opts), overrides, nil, log.NewNopLogger())
+
+	_, err = queryEngine.NewInstantQuery(nil, `sum(`, time.Now())
+	assert.Error(t, err)
+
+	_, err = queryEngine.NewRangeQuery(nil, `metric[5m]`, time.Now(), time.Now(), time.Minute)
+	assert.Error(t, err)
+}
+
+type queryEngineTenantLimits map[string]*validation.Limits
+
+func (l queryEngineTenantLimits) ByUserID(userID string) *validation.Limits {
+	return l[userID]
+}
+
+func (l queryEngineTenantLimits) AllByUserID() map[string]*validation.Limits {
+	return l
+}
@@ -0,0 +1,45 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package stats
+
+import (
+	"net/http"
+)
+
+// EncodingMemoryMiddleware tracks the memory of the encoded response.
+type EncodingMemoryMiddleware struct{}
+
+// NewEncodingMemoryMiddleware makes a new EncodingMemoryMiddleware.
+func NewEncodingMemoryMiddleware() EncodingMemoryMiddleware {
+	return EncodingMemoryMiddleware{}
+}
+
+// Wrap implements middleware.Interface.
+func (m EncodingMemoryMiddleware) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !IsEnabled(r.Context()) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Query responses are fully encoded in memory before being written,
+		// so the size of the written response is the memory used to encode it.
+		sw := &sizeResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r)
+
+		stats := FromContext(r.Context())
+		stats.UpdateEncodingPeakMemoryBytes(sw.size)
+	})
+}
+
+// sizeResponseWriter is a http.ResponseWriter counting the bytes written to the response body.
+type sizeResponseWriter struct {
+	http.ResponseWriter
+	size uint64
+}
+
+func (w *sizeResponseWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.size += uint64(n)
+	return n, err
+}
@@ -0,0 +1,38 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package stats
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodingMemoryMiddleware(t *testing.T) {
+	handler := NewEncodingMemoryMiddleware().Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte("hello "))
+		require.NoError(t, err)
+		_, err = w.Write([]byte("world"))
+		require.NoError(t, err)
+	}))
+
+	t.Run("should track the size of the response if stats are enabled", func(t *testing.T) {
+		stats, ctx := ContextWithEmptyStats(context.Background())
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+
+		assert.Equal(t, "hello world", rec.Body.String())
+		assert.Equal(t, uint64(11), stats.LoadEncodingPeakMemoryBytes())
+	})
+
+	t.Run("should not fail if stats are disabled", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, "hello world", rec.Body.String())
+	})
+}
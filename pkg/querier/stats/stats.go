@@ -123,6 +123,76 @@ func (s *Stats) LoadShardedQueries() uint32 {
 	return atomic.LoadUint32(&s.ShardedQueries)
 }
 
+func (s *Stats) AddFetchedBlocksFromBucket(blocks uint64) {
+	if s == nil {
+		return
+	}
+
+	atomic.AddUint64(&s.FetchedBlocksFromBucket, blocks)
+}
+
+func (s *Stats) LoadFetchedBlocksFromBucket() uint64 {
+	if s == nil {
+		return 0
+	}
+
+	return atomic.LoadUint64(&s.FetchedBlocksFromBucket)
+}
+
+// UpdateSelectorPeakMemoryBytes updates the estimated peak memory of the series and chunks materialized
+// by a single query selector, if the provided value is higher than the current one.
+func (s *Stats) UpdateSelectorPeakMemoryBytes(bytes uint64) {
+	if s == nil {
+		return
+	}
+
+	updatePeak(&s.SelectorPeakMemoryBytes, bytes)
+}
+
+func (s *Stats) LoadSelectorPeakMemoryBytes() uint64 {
+	if s == nil {
+		return 0
+	}
+
+	return atomic.LoadUint64(&s.SelectorPeakMemoryBytes)
+}
+
+// UpdateEvalPeakMemoryBytes updates the estimated peak memory of the samples held by
+// the PromQL engine, if the provided value is higher than the current one.
+func (s *Stats) UpdateEvalPeakMemoryBytes(bytes uint64) {
+	if s == nil {
+		return
+	}
+
+	updatePeak(&s.EvalPeakMemoryBytes, bytes)
+}
+
+func (s *Stats) LoadEvalPeakMemoryBytes() uint64 {
+	if s == nil {
+		return 0
+	}
+
+	return atomic.LoadUint64(&s.EvalPeakMemoryBytes)
+}
+
+// UpdateEncodingPeakMemoryBytes updates the estimated peak memory of the encoded
+// query response, if the provided value is higher than the current one.
+func (s *Stats) UpdateEncodingPeakMemoryBytes(bytes uint64) {
+	if s == nil {
+		return
+	}
+
+	updatePeak(&s.EncodingPeakMemoryBytes, bytes)
+}
+
+func (s *Stats) LoadEncodingPeakMemoryBytes() uint64 {
+	if s == nil {
+		return 0
+	}
+
+	return atomic.LoadUint64(&s.EncodingPeakMemoryBytes)
+}
+
 // Merge the provided Stats into this one.
 func (s *Stats) Merge(other *Stats) {
 	if s == nil || other == nil {
@@ -134,6 +204,22 @@ func (s *Stats) Merge(other *Stats) {
 	s.AddFetchedChunkBytes(other.LoadFetchedChunkBytes())
 	s.AddFetchedChunks(other.LoadFetchedChunks())
 	s.AddShardedQueries(other.LoadShardedQueries())
+	s.AddFetchedBlocksFromBucket(other.LoadFetchedBlocksFromBucket())
+
+	// The peak memory of different queriers doesn't add up, so we keep the highest one.
+	updatePeak(&s.SelectorPeakMemoryBytes, other.LoadSelectorPeakMemoryBytes())
+	updatePeak(&s.EvalPeakMemoryBytes, other.LoadEvalPeakMemoryBytes())
+	updatePeak(&s.EncodingPeakMemoryBytes, other.LoadEncodingPeakMemoryBytes())
+}
+
+// updatePeak atomically sets the value pointed by addr to the provided value, if higher.
+func updatePeak(addr *uint64, value uint64) {
+	for {
+		current := atomic.LoadUint64(addr)
+		if value <= current || atomic.CompareAndSwapUint64(addr, current, value) {
+			return
+		}
+	}
 }
 
 func ShouldTrackHTTPGRPCResponse(r *httpgrpc.HTTPResponse) bool {
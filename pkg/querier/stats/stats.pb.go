@@ -40,6 +40,15 @@ type Stats struct {
 	FetchedChunksCount uint64 `protobuf:"varint,4,opt,name=fetched_chunks_count,json=fetchedChunksCount,proto3" json:"fetched_chunks_count,omitempty"`
 	// The number of sharded queries executed. 0 if sharding is disabled or the query can't be sharded.
 	ShardedQueries uint32 `protobuf:"varint,5,opt,name=sharded_queries,json=shardedQueries,proto3" json:"sharded_queries,omitempty"`
+	// The number of blocks read directly from the bucket, bypassing the store-gateways.
+	FetchedBlocksFromBucket uint64 `protobuf:"varint,6,opt,name=fetched_blocks_from_bucket,json=fetchedBlocksFromBucket,proto3" json:"fetched_blocks_from_bucket,omitempty"`
+	// The estimated peak memory, in bytes, of the series and chunks materialized by a single query selector.
+	SelectorPeakMemoryBytes uint64 `protobuf:"varint,7,opt,name=selector_peak_memory_bytes,json=selectorPeakMemoryBytes,proto3" json:"selector_peak_memory_bytes,omitempty"`
+	// The estimated peak memory, in bytes, of the samples held by the PromQL engine while evaluating the query.
+	// Only reported by the streaming engine.
+	EvalPeakMemoryBytes uint64 `protobuf:"varint,8,opt,name=eval_peak_memory_bytes,json=evalPeakMemoryBytes,proto3" json:"eval_peak_memory_bytes,omitempty"`
+	// The estimated peak memory, in bytes, of the encoded query response.
+	EncodingPeakMemoryBytes uint64 `protobuf:"varint,9,opt,name=encoding_peak_memory_bytes,json=encodingPeakMemoryBytes,proto3" json:"encoding_peak_memory_bytes,omitempty"`
 }
 
 func (m *Stats) Reset()      { *m = Stats{} }
@@ -109,6 +118,34 @@ func (m *Stats) GetShardedQueries() uint32 {
 	return 0
 }
 
+func (m *Stats) GetFetchedBlocksFromBucket() uint64 {
+	if m != nil {
+		return m.FetchedBlocksFromBucket
+	}
+	return 0
+}
+
+func (m *Stats) GetSelectorPeakMemoryBytes() uint64 {
+	if m != nil {
+		return m.SelectorPeakMemoryBytes
+	}
+	return 0
+}
+
+func (m *Stats) GetEvalPeakMemoryBytes() uint64 {
+	if m != nil {
+		return m.EvalPeakMemoryBytes
+	}
+	return 0
+}
+
+func (m *Stats) GetEncodingPeakMemoryBytes() uint64 {
+	if m != nil {
+		return m.EncodingPeakMemoryBytes
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*Stats)(nil), "stats.Stats")
 }
@@ -116,27 +153,34 @@ func init() {
 func init() { proto.RegisterFile("stats.proto", fileDescriptor_b4756a0aec8b9d44) }
 
 var fileDescriptor_b4756a0aec8b9d44 = []byte{
-	// 320 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x54, 0x91, 0xbd, 0x4e, 0xc3, 0x30,
-	0x14, 0x85, 0x7d, 0x4b, 0x8b, 0x4a, 0x2a, 0x40, 0x04, 0x86, 0xd0, 0xe1, 0xb6, 0x62, 0xa1, 0x0b,
-	0x2e, 0x82, 0x91, 0x05, 0xb5, 0xbc, 0x00, 0x2d, 0x13, 0x4b, 0x94, 0x1f, 0x37, 0x89, 0x68, 0x6b,
-	0x88, 0x6d, 0x21, 0x36, 0x26, 0x66, 0x46, 0x1e, 0x81, 0x47, 0xe9, 0xd8, 0xb1, 0x13, 0x50, 0x77,
-	0x61, 0xec, 0x23, 0xa0, 0x38, 0x89, 0x80, 0xcd, 0xe7, 0x7e, 0xf7, 0xf3, 0x91, 0x6c, 0xab, 0x21,
-	0xa4, 0x27, 0x05, 0xbd, 0x4f, 0xb9, 0xe4, 0x76, 0xcd, 0x84, 0xe6, 0x49, 0x94, 0xc8, 0x58, 0xf9,
-	0x34, 0xe0, 0x93, 0x6e, 0xc4, 0x23, 0xde, 0x35, 0xd4, 0x57, 0x23, 0x93, 0x4c, 0x30, 0xa7, 0xdc,
-	0x6a, 0x62, 0xc4, 0x79, 0x34, 0x66, 0xbf, 0x5b, 0xa1, 0x4a, 0x3d, 0x99, 0xf0, 0x69, 0xce, 0x8f,
-	0x5e, 0x2a, 0x56, 0x6d, 0x98, 0x5d, 0x6c, 0x5f, 0x5a, 0x5b, 0x8f, 0xde, 0x78, 0xec, 0xca, 0x64,
-	0xc2, 0x1c, 0x68, 0x43, 0xa7, 0x71, 0x76, 0x48, 0x73, 0x9b, 0x96, 0x36, 0xbd, 0x2a, 0xec, 0x5e,
-	0x7d, 0xf6, 0xd1, 0x22, 0x6f, 0x9f, 0x2d, 0x18, 0xd4, 0x33, 0xeb, 0x26, 0x99, 0x30, 0xfb, 0xd4,
-	0x3a, 0x18, 0x31, 0x19, 0xc4, 0x2c, 0x74, 0x05, 0x4b, 0x13, 0x26, 0xdc, 0x80, 0xab, 0xa9, 0x74,
-	0x2a, 0x6d, 0xe8, 0x54, 0x07, 0x76, 0xc1, 0x86, 0x06, 0xf5, 0x33, 0x62, 0x53, 0x6b, 0xbf, 0x34,
-	0x82, 0x58, 0x4d, 0xef, 0x5c, 0xff, 0x49, 0x32, 0xe1, 0x6c, 0x18, 0x61, 0xaf, 0x40, 0xfd, 0x8c,
-	0xf4, 0x32, 0xf0, 0xb7, 0xc1, 0xec, 0x97, 0x0d, 0xd5, 0x7f, 0x0d, 0x46, 0x28, 0x1a, 0x8e, 0xad,
-	0x5d, 0x11, 0x7b, 0x69, 0xc8, 0x42, 0xf7, 0x41, 0x99, 0x66, 0xa7, 0xd6, 0x86, 0xce, 0xf6, 0x60,
-	0xa7, 0x18, 0x5f, 0xe7, 0xd3, 0xde, 0xc5, 0x7c, 0x89, 0x64, 0xb1, 0x44, 0xb2, 0x5e, 0x22, 0x3c,
-	0x6b, 0x84, 0x77, 0x8d, 0x30, 0xd3, 0x08, 0x73, 0x8d, 0xf0, 0xa5, 0x11, 0xbe, 0x35, 0x92, 0xb5,
-	0x46, 0x78, 0x5d, 0x21, 0x99, 0xaf, 0x90, 0x2c, 0x56, 0x48, 0x6e, 0xf3, 0x4f, 0xf1, 0x37, 0xcd,
-	0x03, 0x9d, 0xff, 0x04, 0x00, 0x00, 0xff, 0xff, 0x28, 0xab, 0xfa, 0xea, 0xb1, 0x01, 0x00, 0x00,
+	// 418 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x92, 0x3f, 0x6f, 0xd4, 0x30,
+	0x18, 0xc6, 0x63, 0xda, 0x2b, 0x57, 0x57, 0x80, 0x48, 0x11, 0x84, 0x0c, 0xee, 0x89, 0x85, 0x5b,
+	0x48, 0x11, 0x1d, 0x6f, 0x41, 0x29, 0x62, 0x43, 0x82, 0x2b, 0x13, 0x8b, 0x95, 0x38, 0xef, 0x25,
+	0x51, 0xfe, 0xb8, 0xd8, 0x0e, 0xa8, 0x1b, 0x1f, 0x81, 0x91, 0x8f, 0xc0, 0x47, 0xe9, 0x78, 0x63,
+	0x27, 0xe0, 0x72, 0x0b, 0x63, 0xc5, 0x27, 0x40, 0x79, 0x9d, 0x08, 0x7a, 0xba, 0xcd, 0xaf, 0x7f,
+	0xcf, 0xcf, 0x8f, 0x2c, 0xbd, 0xf4, 0x40, 0x9b, 0xc8, 0xe8, 0xe0, 0x5c, 0x49, 0x23, 0xdd, 0x11,
+	0x0e, 0xfe, 0xb3, 0x34, 0x37, 0x59, 0x13, 0x07, 0x42, 0x56, 0xc7, 0xa9, 0x4c, 0xe5, 0x31, 0xd2,
+	0xb8, 0x59, 0xe0, 0x84, 0x03, 0x9e, 0xac, 0xe5, 0xb3, 0x54, 0xca, 0xb4, 0x84, 0x7f, 0xa9, 0xa4,
+	0x51, 0x91, 0xc9, 0x65, 0x6d, 0xf9, 0x93, 0x3f, 0x3b, 0x74, 0x74, 0xd6, 0x3d, 0xec, 0xbe, 0xa4,
+	0xfb, 0x9f, 0xa3, 0xb2, 0xe4, 0x26, 0xaf, 0xc0, 0x23, 0x13, 0x32, 0x3d, 0x78, 0xf1, 0x38, 0xb0,
+	0x76, 0x30, 0xd8, 0xc1, 0xab, 0xde, 0x0e, 0xc7, 0x97, 0x3f, 0x8e, 0x9c, 0x6f, 0x3f, 0x8f, 0xc8,
+	0x7c, 0xdc, 0x59, 0xef, 0xf3, 0x0a, 0xdc, 0xe7, 0xf4, 0xc1, 0x02, 0x8c, 0xc8, 0x20, 0xe1, 0x1a,
+	0x54, 0x0e, 0x9a, 0x0b, 0xd9, 0xd4, 0xc6, 0xbb, 0x35, 0x21, 0xd3, 0xdd, 0xb9, 0xdb, 0xb3, 0x33,
+	0x44, 0xa7, 0x1d, 0x71, 0x03, 0x7a, 0x38, 0x18, 0x22, 0x6b, 0xea, 0x82, 0xc7, 0x17, 0x06, 0xb4,
+	0xb7, 0x83, 0xc2, 0xfd, 0x1e, 0x9d, 0x76, 0x24, 0xec, 0xc0, 0xff, 0x0d, 0x98, 0x1f, 0x1a, 0x76,
+	0x6f, 0x34, 0xa0, 0xd0, 0x37, 0x3c, 0xa5, 0xf7, 0x74, 0x16, 0xa9, 0x04, 0x12, 0xfe, 0xb1, 0xc1,
+	0x66, 0x6f, 0x34, 0x21, 0xd3, 0x3b, 0xf3, 0xbb, 0xfd, 0xf5, 0x3b, 0x7b, 0xeb, 0xce, 0xa8, 0x3f,
+	0x3c, 0x1d, 0x97, 0x52, 0x14, 0x9a, 0x2f, 0x94, 0xac, 0x78, 0xdc, 0x88, 0x02, 0x8c, 0xb7, 0x87,
+	0x05, 0x8f, 0xfa, 0x44, 0x88, 0x81, 0xd7, 0x4a, 0x56, 0x21, 0xe2, 0x4e, 0xd6, 0x50, 0x82, 0x30,
+	0x52, 0xf1, 0x73, 0x88, 0x0a, 0x5e, 0x41, 0x25, 0xd5, 0x45, 0xff, 0x9d, 0xdb, 0x56, 0x1e, 0x12,
+	0x6f, 0x21, 0x2a, 0xde, 0x20, 0xb7, 0x9f, 0x3a, 0xa1, 0x0f, 0xe1, 0x53, 0x54, 0x6e, 0x11, 0xc7,
+	0x28, 0x1e, 0x76, 0x74, 0x53, 0x9a, 0x51, 0x1f, 0x6a, 0x21, 0x93, 0xbc, 0x4e, 0xb7, 0x88, 0xfb,
+	0xb6, 0x71, 0x48, 0x6c, 0xc8, 0xe1, 0x6c, 0xb9, 0x62, 0xce, 0xd5, 0x8a, 0x39, 0xd7, 0x2b, 0x46,
+	0xbe, 0xb4, 0x8c, 0x7c, 0x6f, 0x19, 0xb9, 0x6c, 0x19, 0x59, 0xb6, 0x8c, 0xfc, 0x6a, 0x19, 0xf9,
+	0xdd, 0x32, 0xe7, 0xba, 0x65, 0xe4, 0xeb, 0x9a, 0x39, 0xcb, 0x35, 0x73, 0xae, 0xd6, 0xcc, 0xf9,
+	0x60, 0x17, 0x30, 0xde, 0xc3, 0x65, 0x38, 0xf9, 0x3b, 0x00, 0x44, 0x01, 0xe1, 0xad, 0x9d, 0x02,
+	0x00, 0x00,
 }
 
 func (this *Stats) Equal(that interface{}) bool {
@@ -173,19 +217,35 @@ func (this *Stats) Equal(that interface{}) bool {
 	if this.ShardedQueries != that1.ShardedQueries {
 		return false
 	}
+	if this.FetchedBlocksFromBucket != that1.FetchedBlocksFromBucket {
+		return false
+	}
+	if this.SelectorPeakMemoryBytes != that1.SelectorPeakMemoryBytes {
+		return false
+	}
+	if this.EvalPeakMemoryBytes != that1.EvalPeakMemoryBytes {
+		return false
+	}
+	if this.EncodingPeakMemoryBytes != that1.EncodingPeakMemoryBytes {
+		return false
+	}
 	return true
 }
 func (this *Stats) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 9)
+	s := make([]string, 0, 13)
 	s = append(s, "&stats.Stats{")
 	s = append(s, "WallTime: "+fmt.Sprintf("%#v", this.WallTime)+",\n")
 	s = append(s, "FetchedSeriesCount: "+fmt.Sprintf("%#v", this.FetchedSeriesCount)+",\n")
 	s = append(s, "FetchedChunkBytes: "+fmt.Sprintf("%#v", this.FetchedChunkBytes)+",\n")
 	s = append(s, "FetchedChunksCount: "+fmt.Sprintf("%#v", this.FetchedChunksCount)+",\n")
 	s = append(s, "ShardedQueries: "+fmt.Sprintf("%#v", this.ShardedQueries)+",\n")
+	s = append(s, "FetchedBlocksFromBucket: "+fmt.Sprintf("%#v", this.FetchedBlocksFromBucket)+",\n")
+	s = append(s, "SelectorPeakMemoryBytes: "+fmt.Sprintf("%#v", this.SelectorPeakMemoryBytes)+",\n")
+	s = append(s, "EvalPeakMemoryBytes: "+fmt.Sprintf("%#v", this.EvalPeakMemoryBytes)+",\n")
+	s = append(s, "EncodingPeakMemoryBytes: "+fmt.Sprintf("%#v", this.EncodingPeakMemoryBytes)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -217,6 +277,26 @@ func (m *Stats) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.EncodingPeakMemoryBytes != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.EncodingPeakMemoryBytes))
+		i--
+		dAtA[i] = 0x48
+	}
+	if m.EvalPeakMemoryBytes != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.EvalPeakMemoryBytes))
+		i--
+		dAtA[i] = 0x40
+	}
+	if m.SelectorPeakMemoryBytes != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.SelectorPeakMemoryBytes))
+		i--
+		dAtA[i] = 0x38
+	}
+	if m.FetchedBlocksFromBucket != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.FetchedBlocksFromBucket))
+		i--
+		dAtA[i] = 0x30
+	}
 	if m.ShardedQueries != 0 {
 		i = encodeVarintStats(dAtA, i, uint64(m.ShardedQueries))
 		i--
@@ -279,6 +359,18 @@ func (m *Stats) Size() (n int) {
 	if m.ShardedQueries != 0 {
 		n += 1 + sovStats(uint64(m.ShardedQueries))
 	}
+	if m.FetchedBlocksFromBucket != 0 {
+		n += 1 + sovStats(uint64(m.FetchedBlocksFromBucket))
+	}
+	if m.SelectorPeakMemoryBytes != 0 {
+		n += 1 + sovStats(uint64(m.SelectorPeakMemoryBytes))
+	}
+	if m.EvalPeakMemoryBytes != 0 {
+		n += 1 + sovStats(uint64(m.EvalPeakMemoryBytes))
+	}
+	if m.EncodingPeakMemoryBytes != 0 {
+		n += 1 + sovStats(uint64(m.EncodingPeakMemoryBytes))
+	}
 	return n
 }
 
@@ -298,6 +390,10 @@ func (this *Stats) String() string {
 		`FetchedChunkBytes:` + fmt.Sprintf("%v", this.FetchedChunkBytes) + `,`,
 		`FetchedChunksCount:` + fmt.Sprintf("%v", this.FetchedChunksCount) + `,`,
 		`ShardedQueries:` + fmt.Sprintf("%v", this.ShardedQueries) + `,`,
+		`FetchedBlocksFromBucket:` + fmt.Sprintf("%v", this.FetchedBlocksFromBucket) + `,`,
+		`SelectorPeakMemoryBytes:` + fmt.Sprintf("%v", this.SelectorPeakMemoryBytes) + `,`,
+		`EvalPeakMemoryBytes:` + fmt.Sprintf("%v", this.EvalPeakMemoryBytes) + `,`,
+		`EncodingPeakMemoryBytes:` + fmt.Sprintf("%v", this.EncodingPeakMemoryBytes) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -448,6 +544,82 @@ func (m *Stats) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FetchedBlocksFromBucket", wireType)
+			}
+			m.FetchedBlocksFromBucket = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.FetchedBlocksFromBucket |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SelectorPeakMemoryBytes", wireType)
+			}
+			m.SelectorPeakMemoryBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.SelectorPeakMemoryBytes |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EvalPeakMemoryBytes", wireType)
+			}
+			m.EvalPeakMemoryBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.EvalPeakMemoryBytes |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EncodingPeakMemoryBytes", wireType)
+			}
+			m.EncodingPeakMemoryBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.EncodingPeakMemoryBytes |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipStats(dAtA[iNdEx:])
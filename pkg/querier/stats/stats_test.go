@@ -98,6 +98,57 @@ func TestStats_AddShardedQueries(t *testing.T) {
 	})
 }
 
+func TestStats_AddFetchedBlocksFromBucket(t *testing.T) {
+	t.Run("add and load blocks fetched from the bucket", func(t *testing.T) {
+		stats, _ := ContextWithEmptyStats(context.Background())
+		stats.AddFetchedBlocksFromBucket(20)
+		stats.AddFetchedBlocksFromBucket(22)
+
+		assert.Equal(t, uint64(42), stats.LoadFetchedBlocksFromBucket())
+	})
+
+	t.Run("add and load blocks fetched from the bucket nil receiver", func(t *testing.T) {
+		var stats *Stats
+		stats.AddFetchedBlocksFromBucket(3)
+
+		assert.Equal(t, uint64(0), stats.LoadFetchedBlocksFromBucket())
+	})
+}
+
+func TestStats_PeakMemoryBytes(t *testing.T) {
+	t.Run("update and load selector peak memory", func(t *testing.T) {
+		stats, _ := ContextWithEmptyStats(context.Background())
+		stats.UpdateSelectorPeakMemoryBytes(20)
+		stats.UpdateSelectorPeakMemoryBytes(42)
+		stats.UpdateSelectorPeakMemoryBytes(22)
+
+		assert.Equal(t, uint64(42), stats.LoadSelectorPeakMemoryBytes())
+	})
+
+	t.Run("update and load eval and encoding peak memory", func(t *testing.T) {
+		stats, _ := ContextWithEmptyStats(context.Background())
+		stats.UpdateEvalPeakMemoryBytes(20)
+		stats.UpdateEvalPeakMemoryBytes(42)
+		stats.UpdateEvalPeakMemoryBytes(22)
+		stats.UpdateEncodingPeakMemoryBytes(10)
+		stats.UpdateEncodingPeakMemoryBytes(5)
+
+		assert.Equal(t, uint64(42), stats.LoadEvalPeakMemoryBytes())
+		assert.Equal(t, uint64(10), stats.LoadEncodingPeakMemoryBytes())
+	})
+
+	t.Run("add, update and load peak memory nil receiver", func(t *testing.T) {
+		var stats *Stats
+		stats.UpdateSelectorPeakMemoryBytes(3)
+		stats.UpdateEvalPeakMemoryBytes(3)
+		stats.UpdateEncodingPeakMemoryBytes(3)
+
+		assert.Equal(t, uint64(0), stats.LoadSelectorPeakMemoryBytes())
+		assert.Equal(t, uint64(0), stats.LoadEvalPeakMemoryBytes())
+		assert.Equal(t, uint64(0), stats.LoadEncodingPeakMemoryBytes())
+	})
+}
+
 func TestStats_Merge(t *testing.T) {
 	t.Run("merge two stats objects", func(t *testing.T) {
 		stats1 := &Stats{}
@@ -106,6 +157,10 @@ func TestStats_Merge(t *testing.T) {
 		stats1.AddFetchedChunkBytes(42)
 		stats1.AddFetchedChunks(10)
 		stats1.AddShardedQueries(20)
+		stats1.AddFetchedBlocksFromBucket(1)
+		stats1.UpdateSelectorPeakMemoryBytes(100)
+		stats1.UpdateEvalPeakMemoryBytes(20)
+		stats1.UpdateEncodingPeakMemoryBytes(30)
 
 		stats2 := &Stats{}
 		stats2.AddWallTime(time.Second)
@@ -113,6 +168,10 @@ func TestStats_Merge(t *testing.T) {
 		stats2.AddFetchedChunkBytes(100)
 		stats2.AddFetchedChunks(11)
 		stats2.AddShardedQueries(21)
+		stats2.AddFetchedBlocksFromBucket(2)
+		stats2.UpdateSelectorPeakMemoryBytes(50)
+		stats2.UpdateEvalPeakMemoryBytes(40)
+		stats2.UpdateEncodingPeakMemoryBytes(30)
 
 		stats1.Merge(stats2)
 
@@ -121,6 +180,10 @@ func TestStats_Merge(t *testing.T) {
 		assert.Equal(t, uint64(142), stats1.LoadFetchedChunkBytes())
 		assert.Equal(t, uint64(21), stats1.LoadFetchedChunks())
 		assert.Equal(t, uint32(41), stats1.LoadShardedQueries())
+		assert.Equal(t, uint64(3), stats1.LoadFetchedBlocksFromBucket())
+		assert.Equal(t, uint64(100), stats1.LoadSelectorPeakMemoryBytes())
+		assert.Equal(t, uint64(40), stats1.LoadEvalPeakMemoryBytes())
+		assert.Equal(t, uint64(30), stats1.LoadEncodingPeakMemoryBytes())
 	})
 
 	t.Run("merge two nil stats objects", func(t *testing.T) {
@@ -134,5 +197,9 @@ func TestStats_Merge(t *testing.T) {
 		assert.Equal(t, uint64(0), stats1.LoadFetchedChunkBytes())
 		assert.Equal(t, uint64(0), stats1.LoadFetchedChunks())
 		assert.Equal(t, uint32(0), stats1.LoadShardedQueries())
+		assert.Equal(t, uint64(0), stats1.LoadFetchedBlocksFromBucket())
+		assert.Equal(t, uint64(0), stats1.LoadSelectorPeakMemoryBytes())
+		assert.Equal(t, uint64(0), stats1.LoadEvalPeakMemoryBytes())
+		assert.Equal(t, uint64(0), stats1.LoadEncodingPeakMemoryBytes())
 	})
 }
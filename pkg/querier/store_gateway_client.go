@@ -20,6 +20,8 @@ import (
 	"google.golang.org/grpc/health/grpc_health_v1"
 
 	"github.com/grafana/mimir/pkg/storegateway/storegatewaypb"
+	"github.com/grafana/mimir/pkg/util/grpcencoding"
+	"github.com/grafana/mimir/pkg/util/tlsreload"
 )
 
 func newStoreGatewayClientFactory(clientCfg grpcclient.Config, reg prometheus.Registerer) client.PoolFactory {
@@ -37,7 +39,8 @@ func newStoreGatewayClientFactory(clientCfg grpcclient.Config, reg prometheus.Re
 }
 
 func dialStoreGatewayClient(clientCfg grpcclient.Config, addr string, requestDuration *prometheus.HistogramVec) (*storeGatewayClient, error) {
-	opts, err := clientCfg.DialOption(grpcclient.Instrument(requestDuration))
+	unary, stream := grpcclient.Instrument(requestDuration)
+	opts, err := tlsreload.GRPCDialOptions(clientCfg, unary, stream)
 	if err != nil {
 		return nil, err
 	}
@@ -72,12 +75,15 @@ func (c *storeGatewayClient) RemoteAddress() string {
 	return c.conn.Target()
 }
 
+// StoreGatewayClientMaxRecvMsgSize is the max size of the messages received from the store-gateways.
+const StoreGatewayClientMaxRecvMsgSize = 100 << 20
+
 func newStoreGatewayClientPool(discovery client.PoolServiceDiscovery, clientConfig ClientConfig, logger log.Logger, reg prometheus.Registerer) *client.Pool {
 	// We prefer sane defaults instead of exposing further config options.
 	clientCfg := grpcclient.Config{
-		MaxRecvMsgSize:      100 << 20,
+		MaxRecvMsgSize:      StoreGatewayClientMaxRecvMsgSize,
 		MaxSendMsgSize:      16 << 20,
-		GRPCCompression:     "",
+		GRPCCompression:     clientConfig.GRPCCompression,
 		RateLimit:           0,
 		RateLimitBurst:      0,
 		BackoffOnRatelimits: false,
@@ -103,9 +109,16 @@ func newStoreGatewayClientPool(discovery client.PoolServiceDiscovery, clientConf
 type ClientConfig struct {
 	TLSEnabled bool             `yaml:"tls_enabled" category:"advanced"`
 	TLS        tls.ClientConfig `yaml:",inline"`
+
+	GRPCCompression string `yaml:"grpc_compression" category:"experimental"`
 }
 
 func (cfg *ClientConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
 	f.BoolVar(&cfg.TLSEnabled, prefix+".tls-enabled", cfg.TLSEnabled, "Enable TLS for gRPC client connecting to store-gateway.")
 	cfg.TLS.RegisterFlagsWithPrefix(prefix, f)
+	f.StringVar(&cfg.GRPCCompression, prefix+".grpc-compression", "", "Use compression when sending messages to the store-gateway. "+grpcencoding.SupportedUsage+" Empty to disable compression.")
+}
+
+func (cfg *ClientConfig) Validate() error {
+	return grpcencoding.Validate(cfg.GRPCCompression)
 }
@@ -125,7 +125,7 @@ func (fp *frontendProcessor) runRequest(ctx context.Context, request *httpgrpc.H
 		stats, ctx = querier_stats.ContextWithEmptyStats(ctx)
 	}
 
-	response, err := fp.handler.Handle(ctx, request)
+	response, err := handleWithDeadlineBudget(ctx, fp.handler, request)
 	if err != nil {
 		var ok bool
 		response, ok = httpgrpc.HTTPResponseFromError(err)
@@ -8,6 +8,7 @@ package worker
 import (
 	"context"
 	"flag"
+	"net/http"
 	"os"
 	"sync"
 	"time"
@@ -22,8 +23,14 @@ import (
 	"google.golang.org/grpc"
 
 	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/globalerror"
+	"github.com/grafana/mimir/pkg/util/grpcencoding"
+	"github.com/grafana/mimir/pkg/util/httpgrpcutil"
+	"github.com/grafana/mimir/pkg/util/tlsreload"
 )
 
+var errDeadlineBudgetExhausted = httpgrpc.Errorf(http.StatusGatewayTimeout, globalerror.DeadlineBudgetExhausted.Message("the deadline budget of the request is exhausted"))
+
 type Config struct {
 	FrontendAddress       string        `yaml:"frontend_address"`
 	SchedulerAddress      string        `yaml:"scheduler_address"`
@@ -47,7 +54,7 @@ func (cfg *Config) Validate(log log.Logger) error {
 	if cfg.FrontendAddress != "" && cfg.SchedulerAddress != "" {
 		return errors.New("frontend address and scheduler address are mutually exclusive, please use only one")
 	}
-	return cfg.GRPCClientConfig.Validate(log)
+	return grpcencoding.ValidateClientConfig(cfg.GRPCClientConfig, log)
 }
 
 // Handler for HTTP requests wrapped in protobuf messages.
@@ -55,6 +62,28 @@ type RequestHandler interface {
 	Handle(context.Context, *httpgrpc.HTTPRequest) (*httpgrpc.HTTPResponse, error)
 }
 
+// handleWithDeadlineBudget runs the request with the handler, stopping it once the deadline budget
+// of the request is exhausted. The deadline is propagated to ingesters and store-gateways by gRPC.
+func handleWithDeadlineBudget(ctx context.Context, handler RequestHandler, request *httpgrpc.HTTPRequest) (*httpgrpc.HTTPResponse, error) {
+	budget, ok, err := httpgrpcutil.GetDeadlineBudget(request)
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+
+	if ok {
+		// Don't even start processing a request which can't complete in time.
+		if budget <= 0 {
+			return nil, errDeadlineBudgetExhausted
+		}
+
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, budget)
+		defer cancel()
+	}
+
+	return handler.Handle(ctx, request)
+}
+
 // Single processor handles all streaming operations to query-frontend or query-scheduler to fetch queries
 // and process them.
 type processor interface {
@@ -248,7 +277,7 @@ func (w *querierWorker) resetConcurrency() {
 
 func (w *querierWorker) connect(ctx context.Context, address string) (*grpc.ClientConn, error) {
 	// Because we only use single long-running method, it doesn't make sense to inject user ID, send over tracing or add metrics.
-	opts, err := w.cfg.GRPCClientConfig.DialOption(nil, nil)
+	opts, err := tlsreload.GRPCDialOptions(w.cfg.GRPCClientConfig, nil, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -8,6 +8,7 @@ package worker
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"testing"
 	"time"
 
@@ -16,7 +17,10 @@ import (
 	"github.com/grafana/dskit/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
 	"google.golang.org/grpc"
+
+	"github.com/grafana/mimir/pkg/util/httpgrpcutil"
 )
 
 func TestResetConcurrency(t *testing.T) {
@@ -91,3 +95,61 @@ func (m mockProcessor) processQueriesOnSingleStream(ctx context.Context, _ *grpc
 }
 
 func (m mockProcessor) notifyShutdown(_ context.Context, _ *grpc.ClientConn, _ string) {}
+
+type requestHandlerFunc func(context.Context, *httpgrpc.HTTPRequest) (*httpgrpc.HTTPResponse, error)
+
+func (f requestHandlerFunc) Handle(ctx context.Context, req *httpgrpc.HTTPRequest) (*httpgrpc.HTTPResponse, error) {
+	return f(ctx, req)
+}
+
+func TestHandleWithDeadlineBudget(t *testing.T) {
+	var handled bool
+	var deadline time.Time
+	var hasDeadline bool
+	handler := requestHandlerFunc(func(ctx context.Context, _ *httpgrpc.HTTPRequest) (*httpgrpc.HTTPResponse, error) {
+		handled = true
+		deadline, hasDeadline = ctx.Deadline()
+		return &httpgrpc.HTTPResponse{Code: http.StatusOK}, nil
+	})
+
+	t.Run("no deadline budget", func(t *testing.T) {
+		handled = false
+		_, err := handleWithDeadlineBudget(context.Background(), handler, &httpgrpc.HTTPRequest{})
+		require.NoError(t, err)
+		assert.True(t, handled)
+		assert.False(t, hasDeadline)
+	})
+
+	t.Run("deadline budget left", func(t *testing.T) {
+		handled = false
+		req := &httpgrpc.HTTPRequest{}
+		httpgrpcutil.SetDeadlineBudget(req, time.Minute)
+
+		_, err := handleWithDeadlineBudget(context.Background(), handler, req)
+		require.NoError(t, err)
+		assert.True(t, handled)
+		assert.True(t, hasDeadline)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, 5*time.Second)
+	})
+
+	t.Run("deadline budget exhausted", func(t *testing.T) {
+		handled = false
+		req := &httpgrpc.HTTPRequest{}
+		httpgrpcutil.SetDeadlineBudget(req, -time.Second)
+
+		_, err := handleWithDeadlineBudget(context.Background(), handler, req)
+		assert.Equal(t, errDeadlineBudgetExhausted, err)
+		assert.False(t, handled)
+	})
+
+	t.Run("invalid deadline budget", func(t *testing.T) {
+		handled = false
+		req := &httpgrpc.HTTPRequest{Headers: []*httpgrpc.Header{{Key: httpgrpcutil.DeadlineBudgetHeader, Values: []string{"invalid"}}}}
+
+		_, err := handleWithDeadlineBudget(context.Background(), handler, req)
+		resp, ok := httpgrpc.HTTPResponseFromError(err)
+		require.True(t, ok)
+		assert.Equal(t, int32(http.StatusBadRequest), resp.Code)
+		assert.False(t, handled)
+	})
+}
@@ -21,7 +21,6 @@ import (
 	"github.com/pkg/errors"
 	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
 	"github.com/prometheus/prometheus/model/labels"
-	"github.com/prometheus/prometheus/model/rulefmt"
 	"github.com/weaveworks/common/user"
 	"gopkg.in/yaml.v3"
 
@@ -73,7 +72,9 @@ type RuleGroup struct {
 	Interval       float64   `json:"interval"`
 	LastEvaluation time.Time `json:"lastEvaluation"`
 	EvaluationTime float64   `json:"evaluationTime"`
-	SourceTenants  []string  `json:"sourceTenants"`
+	// The offset within the interval at which the group is evaluated, in seconds.
+	EvaluationOffset float64  `json:"evaluationOffset"`
+	SourceTenants    []string `json:"sourceTenants"`
 }
 
 type rule interface{}
@@ -163,13 +164,14 @@ func (a *API) PrometheusRules(w http.ResponseWriter, req *http.Request) {
 
 	for _, g := range rgs {
 		grp := RuleGroup{
-			Name:           g.Group.Name,
-			File:           g.Group.Namespace,
-			Rules:          make([]rule, len(g.ActiveRules)),
-			Interval:       g.Group.Interval.Seconds(),
-			LastEvaluation: g.GetEvaluationTimestamp(),
-			EvaluationTime: g.GetEvaluationDuration().Seconds(),
-			SourceTenants:  g.Group.GetSourceTenants(),
+			Name:             g.Group.Name,
+			File:             g.Group.Namespace,
+			Rules:            make([]rule, len(g.ActiveRules)),
+			Interval:         g.Group.Interval.Seconds(),
+			LastEvaluation:   g.GetEvaluationTimestamp(),
+			EvaluationTime:   g.GetEvaluationDuration().Seconds(),
+			EvaluationOffset: g.GetEvaluationOffset().Seconds(),
+			SourceTenants:    g.Group.GetSourceTenants(),
 		}
 
 		for i, rl := range g.ActiveRules {
@@ -421,7 +423,7 @@ func (a *API) ListRules(w http.ResponseWriter, req *http.Request) {
 
 	level.Debug(logger).Log("msg", "retrieved rule groups from rule store", "userID", userID, "num_namespaces", len(rgs))
 
-	formatted := rgs.Formatted()
+	formatted := rgs.APIFormatted()
 	marshalAndSend(formatted, w, logger)
 }
 
@@ -443,7 +445,7 @@ func (a *API) GetRuleGroup(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	formatted := rulespb.FromProto(rg)
+	formatted := rulespb.FromProtoToAPI(rg)
 	marshalAndSend(formatted, w, logger)
 }
 
@@ -464,7 +466,7 @@ func (a *API) CreateRuleGroup(w http.ResponseWriter, req *http.Request) {
 
 	level.Debug(logger).Log("msg", "attempting to unmarshal rulegroup", "userID", userID, "group", string(payload))
 
-	rg := rulefmt.RuleGroup{}
+	rg := rulespb.RuleGroup{}
 	err = yaml.Unmarshal(payload, &rg)
 	if err != nil {
 		level.Error(logger).Log("msg", "unable to unmarshal rule group payload", "err", err.Error())
@@ -472,7 +474,13 @@ func (a *API) CreateRuleGroup(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	errs := a.ruler.manager.ValidateRuleGroup(rg)
+	errs := a.ruler.manager.ValidateRuleGroup(rg.RuleGroup)
+	if err := validateWriteInterval(rg, a.ruler.cfg.EvaluationInterval); err != nil {
+		errs = append(errs, err)
+	}
+	if err := validateQuerySource(rg); err != nil {
+		errs = append(errs, err)
+	}
 	if len(errs) > 0 {
 		e := []string{}
 		for _, err := range errs {
@@ -503,7 +511,9 @@ func (a *API) CreateRuleGroup(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	rgProto := rulespb.ToProto(userID, namespace, rg)
+	rgProto := rulespb.ToProto(userID, namespace, rg.RuleGroup)
+	rgProto.WriteInterval = time.Duration(rg.WriteInterval)
+	rgProto.QuerySource = rg.QuerySource
 
 	level.Debug(logger).Log("msg", "attempting to store rulegroup", "userID", userID, "group", rgProto.String())
 	err = a.store.SetRuleGroup(req.Context(), userID, namespace, rgProto)
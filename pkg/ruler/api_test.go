@@ -184,6 +184,15 @@ func TestRuler(t *testing.T) {
 			require.Equal(t, http.StatusOK, resp.StatusCode)
 			require.Equal(t, responseJSON.Status, "success")
 
+			// The evaluation offset depends on the groups' file path, so it's taken from the loaded groups.
+			for _, g := range r.manager.GetRules(tc.userID) {
+				for _, expected := range tc.expectedResponse.Data.(*RuleDiscovery).RuleGroups {
+					if expected.Name == g.Name() {
+						expected.EvaluationOffset = evaluationOffset(g).Seconds()
+					}
+				}
+			}
+
 			// Testing the running rules
 			expectedResponse, err := json.Marshal(tc.expectedResponse)
 			require.NoError(t, err)
@@ -296,6 +305,59 @@ rules:
 `,
 			output: "name: test\ninterval: 15s\nrules:\n    - record: up_rule\n      expr: up{}\n    - alert: up_alert\n      expr: sum(up{}) > 1\n      for: 30s\n      labels:\n        test: test\n      annotations:\n        test: test\n",
 		},
+		{
+			name: "with a write interval not multiple of the evaluation interval",
+			input: `
+name: test
+interval: 15s
+write_interval: 20s
+rules:
+- record: up_rule
+  expr: up{}
+`,
+			status: 400,
+			err:    errors.New("invalid rules config: rule group 'test' write interval 20s must be a multiple of the evaluation interval 15s"),
+		},
+		{
+			name:   "with a write interval",
+			status: 202,
+			input: `
+name: test
+interval: 15s
+write_interval: 1m
+rules:
+- record: up_rule
+  expr: up{}
+`,
+			output: "name: test\ninterval: 15s\nrules:\n    - record: up_rule\n      expr: up{}\nwrite_interval: 1m\n",
+		},
+		{
+			name: "with an invalid query source",
+			input: `
+name: test
+interval: 15s
+query_source: store-gateways
+rules:
+- record: up_rule
+  expr: up{}
+`,
+			status: 400,
+			err:    errors.New("invalid rules config: rule group 'test' has invalid query source 'store-gateways', supported values are 'all' and 'ingesters'"),
+		},
+		{
+			name:   "with a query source and an evaluation delay",
+			status: 202,
+			input: `
+name: test
+interval: 15s
+evaluation_delay: 1m
+query_source: ingesters
+rules:
+- record: up_rule
+  expr: up{}
+`,
+			output: "name: test\ninterval: 15s\nevaluation_delay: 1m\nrules:\n    - record: up_rule\n      expr: up{}\nquery_source: ingesters\n",
+		},
 	}
 
 	for _, tt := range tc {
@@ -17,6 +17,8 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/grafana/mimir/pkg/util/tlsreload"
 )
 
 // ClientsPool is the interface used to get the client from the pool for a specified address.
@@ -69,7 +71,8 @@ func newRulerClientFactory(clientCfg grpcclient.Config, reg prometheus.Registere
 }
 
 func dialRulerClient(clientCfg grpcclient.Config, addr string, requestDuration *prometheus.HistogramVec) (*rulerExtendedClient, error) {
-	opts, err := clientCfg.DialOption(grpcclient.Instrument(requestDuration))
+	unary, stream := grpcclient.Instrument(requestDuration)
+	opts, err := tlsreload.GRPCDialOptions(clientCfg, unary, stream)
 	if err != nil {
 		return nil, err
 	}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/services"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/prometheus/model/exemplar"
@@ -20,6 +21,7 @@ import (
 	"github.com/prometheus/prometheus/promql"
 	"github.com/prometheus/prometheus/rules"
 	"github.com/prometheus/prometheus/storage"
+	v1 "github.com/prometheus/prometheus/web/api/v1"
 	"github.com/weaveworks/common/httpgrpc"
 	"github.com/weaveworks/common/user"
 
@@ -43,9 +45,19 @@ type PusherAppender struct {
 	labels  []labels.Labels
 	samples []mimirpb.Sample
 	userID  string
+
+	// The write interval and the evaluation interval of the rule group whose results are appended.
+	writeInterval time.Duration
+	evalInterval  time.Duration
 }
 
 func (a *PusherAppender) Append(_ storage.SeriesRef, l labels.Labels, t int64, v float64) (storage.SeriesRef, error) {
+	// Samples not written because of the write interval are not reported as errors,
+	// so that the rule group doesn't consider the series stale.
+	if !shouldWriteSample(l, t, v, a.writeInterval, a.evalInterval) {
+		return 0, nil
+	}
+
 	a.labels = append(a.labels, l)
 	a.samples = append(a.samples, mimirpb.Sample{
 		TimestampMs: t,
@@ -103,22 +115,58 @@ func NewPusherAppendable(pusher Pusher, userID string, limits RulesLimits, total
 
 // Appender returns a storage.Appender
 func (t *PusherAppendable) Appender(ctx context.Context) storage.Appender {
+	writeInterval, evalInterval := groupWriteIntervalFromContext(ctx)
+
 	return &PusherAppender{
 		failedWrites: t.failedWrites,
 		totalWrites:  t.totalWrites,
 
-		ctx:    ctx,
-		pusher: t.pusher,
-		userID: t.userID,
+		ctx:           ctx,
+		pusher:        t.pusher,
+		userID:        t.userID,
+		writeInterval: writeInterval,
+		evalInterval:  evalInterval,
 	}
 }
 
+// groupEvaluationContextFunc prepares the context for the evaluation of the rule group.
+func groupEvaluationContextFunc(ctx context.Context, g *rules.Group) context.Context {
+	return WriteIntervalGroupContextFunc(FederatedGroupContextFunc(ctx, g), g)
+}
+
 // RulesLimits defines limits used by Ruler.
 type RulesLimits interface {
 	EvaluationDelay(userID string) time.Duration
 	RulerTenantShardSize(userID string) int
 	RulerMaxRuleGroupsPerTenant(userID string) int
 	RulerMaxRulesPerRuleGroup(userID string) int
+	RulerAlertmanagerURL(userID string) string
+}
+
+// EngineQueryFunc returns a new query function that executes instant queries against
+// the given engine. It works like rules.EngineQueryFunc, but with any v1.QueryEngine.
+func EngineQueryFunc(engine v1.QueryEngine, q storage.Queryable) rules.QueryFunc {
+	return func(ctx context.Context, qs string, t time.Time) (promql.Vector, error) {
+		q, err := engine.NewInstantQuery(q, qs, t)
+		if err != nil {
+			return nil, err
+		}
+		res := q.Exec(ctx)
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		switch v := res.Value.(type) {
+		case promql.Vector:
+			return v, nil
+		case promql.Scalar:
+			return promql.Vector{promql.Sample{
+				Point:  promql.Point(v),
+				Metric: labels.Labels{},
+			}}, nil
+		default:
+			return nil, errors.New("rule result is not a vector or scalar")
+		}
+	}
 }
 
 func MetricsQueryFunc(qf rules.QueryFunc, queries, failedQueries prometheus.Counter) rules.QueryFunc {
@@ -214,7 +262,7 @@ type RulesManager interface {
 // ManagerFactory is a function that creates new RulesManager for given user and notifier.Manager.
 type ManagerFactory func(ctx context.Context, userID string, notifier *notifier.Manager, logger log.Logger, reg prometheus.Registerer) RulesManager
 
-func DefaultTenantManagerFactory(cfg Config, p Pusher, queryable, federatedQueryable storage.Queryable, engine *promql.Engine, overrides RulesLimits, reg prometheus.Registerer) ManagerFactory {
+func DefaultTenantManagerFactory(cfg Config, p Pusher, queryable, federatedQueryable storage.Queryable, engine v1.QueryEngine, overrides RulesLimits, reg prometheus.Registerer) ManagerFactory {
 	totalWrites := promauto.With(reg).NewCounter(prometheus.CounterOpts{
 		Name: "cortex_ruler_write_requests_total",
 		Help: "Number of write requests to ingesters.",
@@ -232,6 +280,11 @@ func DefaultTenantManagerFactory(cfg Config, p Pusher, queryable, federatedQuery
 		Name: "cortex_ruler_queries_failed_total",
 		Help: "Number of failed queries by ruler.",
 	})
+	var walMetrics *walPusherMetrics
+	if cfg.WAL.Enabled {
+		walMetrics = newWALPusherMetrics(reg, totalWrites, failedWrites)
+	}
+
 	var rulerQuerySeconds *prometheus.CounterVec
 	if cfg.EnableQueryStats {
 		rulerQuerySeconds = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
@@ -252,7 +305,7 @@ func DefaultTenantManagerFactory(cfg Config, p Pusher, queryable, federatedQuery
 			// Errors from PromQL are always "user" errors.
 			q = querier.NewErrorTranslateQueryableWithFn(q, WrapQueryableErrors)
 
-			queryFunc = rules.EngineQueryFunc(engine, q)
+			queryFunc = EngineQueryFunc(engine, q)
 			queryFunc = MetricsQueryFunc(queryFunc, totalQueries, failedQueries)
 			queryFunc = RecordAndReportRuleQueryMetrics(queryFunc, queryTime, logger)
 			return queryFunc
@@ -261,12 +314,25 @@ func DefaultTenantManagerFactory(cfg Config, p Pusher, queryable, federatedQuery
 		regularQueryFunc := wrapQueryable(queryable)
 		federatedQueryFunc := wrapQueryable(federatedQueryable)
 
-		return rules.NewManager(&rules.ManagerOptions{
-			Appendable:                 NewPusherAppendable(p, userID, overrides, totalWrites, failedWrites),
+		// When the WAL is enabled, the appender writes to the tenant's WAL, which pushes asynchronously.
+		var walP *walPusher
+		appendable := NewPusherAppendable(p, userID, overrides, totalWrites, failedWrites)
+		if walMetrics != nil {
+			walP = newWALPusher(cfg.WAL, userID, p, walMetrics, logger)
+			if err := services.StartAndAwaitRunning(ctx, walP); err != nil {
+				level.Error(logger).Log("msg", "failed to start the ruler WAL, writing the results of the rules evaluation synchronously", "user", userID, "err", err)
+				walP = nil
+			} else {
+				appendable = NewPusherAppendable(walP, userID, overrides, walMetrics.totalLogged, walMetrics.failedLogged)
+			}
+		}
+
+		manager := rules.NewManager(&rules.ManagerOptions{
+			Appendable:                 appendable,
 			Queryable:                  queryable,
-			QueryFunc:                  TenantFederationQueryFunc(regularQueryFunc, federatedQueryFunc),
+			QueryFunc:                  QuerySourceQueryFunc(TenantFederationQueryFunc(regularQueryFunc, federatedQueryFunc)),
 			Context:                    user.InjectOrgID(ctx, userID),
-			GroupEvaluationContextFunc: FederatedGroupContextFunc,
+			GroupEvaluationContextFunc: groupEvaluationContextFunc,
 			ExternalURL:                cfg.ExternalURL.URL,
 			NotifyFunc:                 SendAlerts(notifier, cfg.ExternalURL.URL.String()),
 			Logger:                     log.With(logger, "user", userID),
@@ -280,6 +346,11 @@ func DefaultTenantManagerFactory(cfg Config, p Pusher, queryable, federatedQuery
 				return overrides.EvaluationDelay(userID)
 			},
 		})
+
+		if walP != nil {
+			return &walRulesManager{RulesManager: manager, pusher: walP}
+		}
+		return manager
 	}
 }
 
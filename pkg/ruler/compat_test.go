@@ -10,23 +10,29 @@ import (
 	"errors"
 	"math"
 	"net/http"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/go-kit/log"
+	"github.com/grafana/dskit/test"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/model/rulefmt"
 	"github.com/prometheus/prometheus/model/value"
 	"github.com/prometheus/prometheus/notifier"
 	"github.com/prometheus/prometheus/promql"
 	"github.com/prometheus/prometheus/promql/parser"
 	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/weaveworks/common/httpgrpc"
 
 	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/querier"
 	"github.com/grafana/mimir/pkg/ruler/rulespb"
 )
 
@@ -95,6 +101,116 @@ func TestPusherAppendable(t *testing.T) {
 	}
 }
 
+func TestQuerySourceQueryFunc(t *testing.T) {
+	sources := newGroupQuerySources()
+	sources.sources["group-1"] = rulespb.QuerySourceIngesters
+
+	var ingestersOnly bool
+	qf := QuerySourceQueryFunc(func(ctx context.Context, _ string, _ time.Time) (promql.Vector, error) {
+		ingestersOnly = querier.IngestersOnlyFromContext(ctx)
+		return nil, nil
+	})
+
+	ctx := contextWithGroupQuerySources(context.Background(), sources)
+
+	for group, expected := range map[string]bool{"group-1": true, "group-2": false} {
+		t.Run(group, func(t *testing.T) {
+			_, err := qf(context.WithValue(ctx, evaluatedGroupKey, evaluatedGroup{key: group}), "up", time.Now())
+			require.NoError(t, err)
+			require.Equal(t, expected, ingestersOnly)
+		})
+	}
+}
+
+func TestPusherAppendable_WriteInterval(t *testing.T) {
+	pusher := &fakePusher{response: &mimirpb.WriteResponse{}}
+	pa := NewPusherAppendable(pusher, "user-1", nil, prometheus.NewCounter(prometheus.CounterOpts{}), prometheus.NewCounter(prometheus.CounterOpts{}))
+
+	intervals := newGroupWriteIntervals()
+	intervals.intervals["group-1"] = time.Minute
+
+	ctx := contextWithGroupWriteIntervals(context.Background(), intervals)
+	ctx = context.WithValue(ctx, evaluatedGroupKey, evaluatedGroup{key: "group-1", interval: 15 * time.Second})
+
+	for _, tc := range []struct {
+		name          string
+		series        string
+		ts            int64
+		value         float64
+		expectWritten bool
+	}{
+		{
+			name:          "first evaluation within the write interval",
+			series:        "foo_bar",
+			ts:            120_000,
+			value:         1.234,
+			expectWritten: true,
+		},
+		{
+			name:          "first evaluation within the write interval, with offset",
+			series:        "foo_bar",
+			ts:            125_000,
+			value:         1.234,
+			expectWritten: true,
+		},
+		{
+			name:          "other evaluation within the write interval",
+			series:        "foo_bar",
+			ts:            135_000,
+			value:         1.234,
+			expectWritten: false,
+		},
+		{
+			name:          "other evaluation within the write interval, stale nan value",
+			series:        "foo_bar",
+			ts:            135_000,
+			value:         math.Float64frombits(value.StaleNaN),
+			expectWritten: true,
+		},
+		{
+			name:          "other evaluation within the write interval, ALERTS",
+			series:        `ALERTS{alertname="boop"}`,
+			ts:            135_000,
+			value:         1.234,
+			expectWritten: true,
+		},
+		{
+			name:          "other evaluation within the write interval, ALERTS_FOR_STATE",
+			series:        `ALERTS_FOR_STATE{alertname="boop"}`,
+			ts:            135_000,
+			value:         1.234,
+			expectWritten: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			lbls, err := parser.ParseMetric(tc.series)
+			require.NoError(t, err)
+
+			a := pa.Appender(ctx)
+			_, err = a.Append(0, lbls, tc.ts, tc.value)
+			require.NoError(t, err)
+			require.NoError(t, a.Commit())
+
+			if tc.expectWritten {
+				require.Len(t, pusher.request.Timeseries, 1)
+				require.Equal(t, tc.ts, pusher.request.Timeseries[0].Samples[0].TimestampMs)
+			} else {
+				require.Empty(t, pusher.request.Timeseries)
+			}
+		})
+	}
+
+	t.Run("group without write interval", func(t *testing.T) {
+		ctx := context.WithValue(ctx, evaluatedGroupKey, evaluatedGroup{key: "group-2", interval: 15 * time.Second})
+
+		a := pa.Appender(ctx)
+		_, err := a.Append(0, labels.FromStrings(labels.MetricName, "foo_bar"), 135_000, 1.234)
+		require.NoError(t, err)
+		require.NoError(t, a.Commit())
+		require.Len(t, pusher.request.Timeseries, 1)
+	})
+}
+
 func TestPusherErrors(t *testing.T) {
 	for name, tc := range map[string]struct {
 		returnedError    error
@@ -314,6 +430,38 @@ func TestManagerFactory_CorrectQueryableUsed(t *testing.T) {
 	}
 }
 
+func TestManagerFactory_WAL(t *testing.T) {
+	const userID = "tenant-1"
+
+	cfg := defaultRulerConfig(t)
+	cfg.WAL.Enabled = true
+	cfg.WAL.Dir = t.TempDir()
+	engine, _, _, logger, overrides := testSetup(t)
+	notifierManager := notifier.NewManager(&notifier.Options{Do: func(_ context.Context, _ *http.Client, _ *http.Request) (*http.Response, error) { return nil, nil }}, logger)
+	ruleFiles := writeRuleGroupToFiles(t, cfg.RulePath, logger, userID, rulespb.RuleGroupDesc{
+		Name:  "group",
+		Rules: []*rulespb.RuleDesc{{Expr: "vector(1)", Record: "one"}},
+	})
+
+	pusher := &recordingPusher{}
+	managerFactory := DefaultTenantManagerFactory(cfg, pusher, newMockQueryable(), newMockQueryable(), engine, overrides, prometheus.NewPedanticRegistry())
+	manager := managerFactory(context.Background(), userID, notifierManager, logger, nil)
+	require.IsType(t, &walRulesManager{}, manager)
+
+	require.NoError(t, manager.Update(time.Millisecond, ruleFiles, nil, ""))
+	go manager.Run()
+
+	// The results of the evaluation are pushed asynchronously through the tenant's WAL.
+	test.Poll(t, time.Second, true, func() interface{} {
+		return len(pusher.pushedValues()) > 0
+	})
+	manager.Stop()
+
+	// The tenant's WAL is removed once all the write requests have been pushed.
+	_, err := os.Stat(filepath.Join(cfg.WAL.Dir, userID))
+	assert.True(t, os.IsNotExist(err))
+}
+
 func writeRuleGroupToFiles(t *testing.T, path string, logger log.Logger, userID string, ruleGroup rulespb.RuleGroupDesc) []string {
 	_, files, err := newMapper(path, logger).MapRules(userID, map[string][]rulefmt.RuleGroup{
 		"namespace": {rulespb.FromProto(&ruleGroup)},
@@ -32,6 +32,8 @@ type DefaultMultiTenantManager struct {
 	cfg            Config
 	notifierCfg    *config.Config
 	managerFactory ManagerFactory
+	limits         RulesLimits
+	dnsResolver    cacheutil.AddressProvider
 
 	mapper *mapper
 
@@ -41,6 +43,10 @@ type DefaultMultiTenantManager struct {
 	userManagers       map[string]RulesManager
 	userManagerMetrics *ManagerMetrics
 
+	// Per-user write interval of the rule groups. Protected by userManagerMtx.
+	writeIntervals map[string]*groupWriteIntervals
+	querySources   map[string]*groupQuerySources
+
 	// Per-user notifiers with separate queues.
 	notifiersMtx sync.Mutex
 	notifiers    map[string]*rulerNotifier
@@ -53,7 +59,7 @@ type DefaultMultiTenantManager struct {
 	logger                        log.Logger
 }
 
-func NewDefaultMultiTenantManager(cfg Config, managerFactory ManagerFactory, reg prometheus.Registerer, logger log.Logger, dnsResolver cacheutil.AddressProvider) (*DefaultMultiTenantManager, error) {
+func NewDefaultMultiTenantManager(cfg Config, managerFactory ManagerFactory, reg prometheus.Registerer, logger log.Logger, dnsResolver cacheutil.AddressProvider, limits RulesLimits) (*DefaultMultiTenantManager, error) {
 	ncfg, err := buildNotifierConfig(&cfg, dnsResolver)
 	if err != nil {
 		return nil, err
@@ -64,14 +70,18 @@ func NewDefaultMultiTenantManager(cfg Config, managerFactory ManagerFactory, reg
 		reg.MustRegister(userManagerMetrics)
 	}
 
-	return &DefaultMultiTenantManager{
+	m := &DefaultMultiTenantManager{
 		cfg:                cfg,
 		notifierCfg:        ncfg,
 		managerFactory:     managerFactory,
+		limits:             limits,
+		dnsResolver:        dnsResolver,
 		notifiers:          map[string]*rulerNotifier{},
 		mapper:             newMapper(cfg.RulePath, logger),
 		userManagers:       map[string]RulesManager{},
 		userManagerMetrics: userManagerMetrics,
+		writeIntervals:     map[string]*groupWriteIntervals{},
+		querySources:       map[string]*groupQuerySources{},
 		managersTotal: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
 			Namespace: "cortex",
 			Name:      "ruler_managers_total",
@@ -94,7 +104,13 @@ func NewDefaultMultiTenantManager(cfg Config, managerFactory ManagerFactory, reg
 		}, []string{"user"}),
 		registry: reg,
 		logger:   logger,
-	}, nil
+	}
+
+	if reg != nil {
+		reg.MustRegister(newNotifierTargetsCollector(m.getNotifiers))
+	}
+
+	return m, nil
 }
 
 func (r *DefaultMultiTenantManager) SyncRuleGroups(ctx context.Context, ruleGroups map[string]rulespb.RuleGroupList) {
@@ -116,6 +132,8 @@ func (r *DefaultMultiTenantManager) SyncRuleGroups(ctx context.Context, ruleGrou
 		if _, exists := ruleGroups[userID]; !exists {
 			go mngr.Stop()
 			delete(r.userManagers, userID)
+			delete(r.writeIntervals, userID)
+			delete(r.querySources, userID)
 
 			r.mapper.cleanupUser(userID)
 			r.lastReloadSuccessful.DeleteLabelValues(userID)
@@ -141,13 +159,36 @@ func (r *DefaultMultiTenantManager) syncRulesToManager(ctx context.Context, user
 		return
 	}
 
+	// The write intervals and query sources are updated before the manager, so that
+	// they're already in place when the updated rule groups are evaluated.
+	writeIntervals, ok := r.writeIntervals[user]
+	if !ok {
+		writeIntervals = newGroupWriteIntervals()
+		r.writeIntervals[user] = writeIntervals
+	}
+	writeIntervals.set(r.mapper, user, groups)
+
+	querySources, ok := r.querySources[user]
+	if !ok {
+		querySources = newGroupQuerySources()
+		r.querySources[user] = querySources
+	}
+	querySources.set(r.mapper, user, groups)
+
 	manager, exists := r.userManagers[user]
+	if exists {
+		// The tenant's Alertmanager URL may have been overridden since the notifier has been created.
+		if err := r.syncNotifierConfig(user); err != nil {
+			level.Error(r.logger).Log("msg", "unable to update notifier config", "user", user, "err", err)
+		}
+	}
+
 	if !exists || update {
 		level.Debug(r.logger).Log("msg", "updating rules", "user", user)
 		r.configUpdatesTotal.WithLabelValues(user).Inc()
 		if !exists {
 			level.Debug(r.logger).Log("msg", "creating rule manager for user", "user", user)
-			manager, err = r.newManager(ctx, user)
+			manager, err = r.newManager(contextWithGroupQuerySources(contextWithGroupWriteIntervals(ctx, writeIntervals), querySources), user)
 			if err != nil {
 				r.lastReloadSuccessful.WithLabelValues(user).Set(0)
 				level.Error(r.logger).Log("msg", "unable to create rule manager", "user", user, "err", err)
@@ -219,15 +260,82 @@ func (r *DefaultMultiTenantManager) getOrCreateNotifier(userID string) (*notifie
 
 	n.run()
 
-	// This should never fail, unless there's a programming mistake.
-	if err := n.applyConfig(r.notifierCfg); err != nil {
+	amURL, ncfg, err := r.notifierConfig(userID)
+	if err == nil {
+		err = n.applyConfig(ncfg)
+	}
+	if err != nil {
+		n.stop()
 		return nil, err
 	}
+	n.alertmanagerURL = amURL
 
 	r.notifiers[userID] = n
 	return n.notifier, nil
 }
 
+// syncNotifierConfig applies the notifier config again to the tenant's notifier,
+// if the tenant's Alertmanager URL has changed since it was last applied.
+func (r *DefaultMultiTenantManager) syncNotifierConfig(userID string) error {
+	r.notifiersMtx.Lock()
+	defer r.notifiersMtx.Unlock()
+
+	n, ok := r.notifiers[userID]
+	if !ok || n.alertmanagerURL == r.alertmanagerURL(userID) {
+		return nil
+	}
+
+	amURL, ncfg, err := r.notifierConfig(userID)
+	if err != nil {
+		return err
+	}
+	if err := n.applyConfig(ncfg); err != nil {
+		return err
+	}
+
+	level.Info(r.logger).Log("msg", "updated notifier config", "user", userID)
+	n.alertmanagerURL = amURL
+	return nil
+}
+
+// alertmanagerURL returns the Alertmanager URL of the tenant, which is the per-tenant
+// override if set, or the globally configured one otherwise.
+func (r *DefaultMultiTenantManager) alertmanagerURL(userID string) string {
+	if r.limits != nil {
+		if amURL := r.limits.RulerAlertmanagerURL(userID); amURL != "" {
+			return amURL
+		}
+	}
+	return r.cfg.AlertmanagerURL
+}
+
+// notifierConfig returns the notifier config of the tenant, along with the Alertmanager URL it has been built from.
+func (r *DefaultMultiTenantManager) notifierConfig(userID string) (string, *config.Config, error) {
+	amURL := r.alertmanagerURL(userID)
+	if amURL == r.cfg.AlertmanagerURL {
+		return amURL, r.notifierCfg, nil
+	}
+
+	cfg := r.cfg
+	cfg.AlertmanagerURL = amURL
+	ncfg, err := buildNotifierConfig(&cfg, r.dnsResolver)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "invalid Alertmanager URL override")
+	}
+	return amURL, ncfg, nil
+}
+
+func (r *DefaultMultiTenantManager) getNotifiers() map[string]*rulerNotifier {
+	r.notifiersMtx.Lock()
+	defer r.notifiersMtx.Unlock()
+
+	notifiers := make(map[string]*rulerNotifier, len(r.notifiers))
+	for userID, n := range r.notifiers {
+		notifiers[userID] = n
+	}
+	return notifiers
+}
+
 func (r *DefaultMultiTenantManager) GetRules(userID string) []*promRules.Group {
 	var groups []*promRules.Group
 	r.userManagerMtx.Lock()
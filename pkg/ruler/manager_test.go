@@ -8,13 +8,18 @@ package ruler
 import (
 	"context"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/go-kit/log"
 	"github.com/grafana/dskit/test"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/notifier"
 	promRules "github.com/prometheus/prometheus/rules"
@@ -31,7 +36,7 @@ func TestSyncRuleGroups(t *testing.T) {
 		_ = os.RemoveAll(dir)
 	})
 
-	m, err := NewDefaultMultiTenantManager(Config{RulePath: dir}, factory, nil, log.NewNopLogger(), nil)
+	m, err := NewDefaultMultiTenantManager(Config{RulePath: dir}, factory, nil, log.NewNopLogger(), nil, nil)
 	require.NoError(t, err)
 
 	const user = "testUser"
@@ -103,6 +108,163 @@ func TestSyncRuleGroups(t *testing.T) {
 	})
 }
 
+func TestSyncRuleGroups_AlertmanagerURLOverride(t *testing.T) {
+	dir := t.TempDir()
+
+	var healthy atomic.Bool
+	healthy.Store(true)
+	am := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(am.Close)
+
+	cfg := Config{
+		RulePath:                  dir,
+		AlertmanagerURL:           "http://alertmanager.default.svc.cluster.local/alertmanager",
+		NotificationQueueCapacity: 10,
+		NotificationTimeout:       time.Second,
+	}
+	reg := prometheus.NewPedanticRegistry()
+	m, err := NewDefaultMultiTenantManager(cfg, factory, reg, log.NewNopLogger(), nil, ruleLimits{})
+	require.NoError(t, err)
+	t.Cleanup(m.Stop)
+
+	const user = "testUser"
+	userRules := map[string]rulespb.RuleGroupList{
+		user: {
+			&rulespb.RuleGroupDesc{Name: "group1", Namespace: "ns", Interval: time.Minute, User: user},
+		},
+	}
+
+	alertmanagers := func() interface{} {
+		n, err := m.getOrCreateNotifier(user)
+		require.NoError(t, err)
+
+		var urls []string
+		for _, u := range n.Alertmanagers() {
+			urls = append(urls, u.String())
+		}
+		return urls
+	}
+
+	m.SyncRuleGroups(context.Background(), userRules)
+	test.Poll(t, 5*time.Second, []string{"http://alertmanager.default.svc.cluster.local/alertmanager/api/v2/alerts"}, alertmanagers)
+
+	// Override the tenant's Alertmanager URL and resync the same rules.
+	m.limits = ruleLimits{alertmanagerURL: am.URL + "/tenant"}
+	m.SyncRuleGroups(context.Background(), userRules)
+	test.Poll(t, 5*time.Second, []string{am.URL + "/tenant/api/v2/alerts"}, alertmanagers)
+
+	n, err := m.getOrCreateNotifier(user)
+	require.NoError(t, err)
+
+	// Send a notification to the tenant's Alertmanager, then check the target metrics.
+	n.Send(&notifier.Alert{Labels: labels.FromStrings("alertname", "test")})
+	test.Poll(t, 5*time.Second, nil, func() interface{} {
+		return testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP cortex_ruler_alertmanager_target_inflight_requests Number of notification requests currently being sent to the Alertmanager.
+			# TYPE cortex_ruler_alertmanager_target_inflight_requests gauge
+			cortex_ruler_alertmanager_target_inflight_requests{alertmanager="`+am.URL+`/tenant/api/v2/alerts",user="testUser"} 0
+			# HELP cortex_ruler_alertmanager_target_up Whether the last notification sent to the Alertmanager succeeded (1) or failed (0).
+			# TYPE cortex_ruler_alertmanager_target_up gauge
+			cortex_ruler_alertmanager_target_up{alertmanager="`+am.URL+`/tenant/api/v2/alerts",user="testUser"} 1
+		`), "cortex_ruler_alertmanager_target_inflight_requests", "cortex_ruler_alertmanager_target_up")
+	})
+
+	// The target is reported as down once a notification fails.
+	healthy.Store(false)
+	n.Send(&notifier.Alert{Labels: labels.FromStrings("alertname", "test")})
+	test.Poll(t, 5*time.Second, nil, func() interface{} {
+		return testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP cortex_ruler_alertmanager_target_up Whether the last notification sent to the Alertmanager succeeded (1) or failed (0).
+			# TYPE cortex_ruler_alertmanager_target_up gauge
+			cortex_ruler_alertmanager_target_up{alertmanager="`+am.URL+`/tenant/api/v2/alerts",user="testUser"} 0
+		`), "cortex_ruler_alertmanager_target_up")
+	})
+
+	// An invalid override doesn't change the notifier config.
+	m.limits = ruleLimits{alertmanagerURL: "dnsserv+http://alertmanager"}
+	m.SyncRuleGroups(context.Background(), userRules)
+	require.Equal(t, []string{am.URL + "/tenant/api/v2/alerts"}, alertmanagers())
+}
+
+func TestSyncRuleGroups_WriteInterval(t *testing.T) {
+	dir := t.TempDir()
+
+	m, err := NewDefaultMultiTenantManager(Config{RulePath: dir}, factory, nil, log.NewNopLogger(), nil, nil)
+	require.NoError(t, err)
+	t.Cleanup(m.Stop)
+
+	const user = "testUser"
+	rules := []*rulespb.RuleDesc{{Record: "up_rule", Expr: "up"}}
+	userRules := map[string]rulespb.RuleGroupList{
+		user: {
+			&rulespb.RuleGroupDesc{Name: "group1", Namespace: "ns/1", Interval: time.Minute, WriteInterval: 5 * time.Minute, Rules: rules, User: user},
+			&rulespb.RuleGroupDesc{Name: "group2", Namespace: "ns/1", Interval: time.Minute, Rules: rules, User: user},
+		},
+	}
+	m.SyncRuleGroups(context.Background(), userRules)
+
+	// The write intervals must be looked up by the key of the groups loaded from the mapped rule files.
+	files, err := filepath.Glob(filepath.Join(dir, user, "*"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	groups, errs := promRules.NewManager(&promRules.ManagerOptions{}).LoadGroups(time.Minute, nil, "", files...)
+	require.Empty(t, errs)
+	require.Len(t, groups, 2)
+
+	expected := map[string]time.Duration{"group1": 5 * time.Minute, "group2": 0}
+	for _, g := range groups {
+		require.Equal(t, expected[g.Name()], m.writeIntervals[user].get(promRules.GroupKey(g.File(), g.Name())))
+	}
+
+	// The write intervals are removed with the user.
+	m.SyncRuleGroups(context.Background(), nil)
+	require.NotContains(t, m.writeIntervals, user)
+}
+
+func TestSyncRuleGroups_QuerySourceAndEvaluationDelay(t *testing.T) {
+	dir := t.TempDir()
+
+	m, err := NewDefaultMultiTenantManager(Config{RulePath: dir}, factory, nil, log.NewNopLogger(), nil, nil)
+	require.NoError(t, err)
+	t.Cleanup(m.Stop)
+
+	const user = "testUser"
+	evaluationDelay := 2 * time.Minute
+	rules := []*rulespb.RuleDesc{{Record: "up_rule", Expr: "up"}}
+	userRules := map[string]rulespb.RuleGroupList{
+		user: {
+			&rulespb.RuleGroupDesc{Name: "group1", Namespace: "ns/1", Interval: time.Minute, EvaluationDelay: &evaluationDelay, QuerySource: rulespb.QuerySourceIngesters, Rules: rules, User: user},
+			&rulespb.RuleGroupDesc{Name: "group2", Namespace: "ns/1", Interval: time.Minute, QuerySource: rulespb.QuerySourceAll, Rules: rules, User: user},
+			&rulespb.RuleGroupDesc{Name: "group3", Namespace: "ns/1", Interval: time.Minute, Rules: rules, User: user},
+		},
+	}
+	m.SyncRuleGroups(context.Background(), userRules)
+
+	files, err := filepath.Glob(filepath.Join(dir, user, "*"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	groups, errs := promRules.NewManager(&promRules.ManagerOptions{}).LoadGroups(time.Minute, nil, "", files...)
+	require.Empty(t, errs)
+	require.Len(t, groups, 3)
+
+	expectedSources := map[string]string{"group1": rulespb.QuerySourceIngesters, "group2": "", "group3": ""}
+	expectedDelays := map[string]time.Duration{"group1": evaluationDelay, "group2": 0, "group3": 0}
+	for _, g := range groups {
+		require.Equal(t, expectedSources[g.Name()], m.querySources[user].get(promRules.GroupKey(g.File(), g.Name())))
+		require.Equal(t, expectedDelays[g.Name()], g.EvaluationDelay())
+	}
+
+	// The query sources are removed with the user.
+	m.SyncRuleGroups(context.Background(), nil)
+	require.NotContains(t, m.querySources, user)
+}
+
 func getManager(m *DefaultMultiTenantManager, user string) RulesManager {
 	m.userManagerMtx.Lock()
 	defer m.userManagerMtx.Unlock()
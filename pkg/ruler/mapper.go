@@ -94,9 +94,7 @@ func (m *mapper) MapRules(user string, ruleConfigs map[string][]rulefmt.RuleGrou
 
 	// write all rule configs to disk
 	for filename, groups := range ruleConfigs {
-		// Store the encoded file name to better handle `/` characters
-		encodedFileName := url.PathEscape(filename)
-		fullFileName := filepath.Join(path, encodedFileName)
+		fullFileName := m.filename(user, filename)
 
 		fileUpdated, err := m.writeRuleGroupsIfNewer(groups, fullFileName)
 		if err != nil {
@@ -136,6 +134,12 @@ func (m *mapper) MapRules(user string, ruleConfigs map[string][]rulefmt.RuleGrou
 	return anyUpdated, filenames, nil
 }
 
+// filename returns the path of the file the namespace's rule groups are mapped to.
+func (m *mapper) filename(user, namespace string) string {
+	// Store the encoded file name to better handle `/` characters
+	return filepath.Join(m.Path, user, url.PathEscape(namespace))
+}
+
 func (m *mapper) writeRuleGroupsIfNewer(groups []rulefmt.RuleGroup, filename string) (bool, error) {
 	sort.Slice(groups, func(i, j int) bool {
 		return groups[i].Name > groups[j].Name
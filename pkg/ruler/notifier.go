@@ -8,19 +8,23 @@ package ruler
 import (
 	"context"
 	"flag"
+	"net/http"
 	"net/url"
 	"strings"
 	"sync"
+	"time"
 
 	gklog "github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/grafana/dskit/crypto/tls"
+	"github.com/prometheus/client_golang/prometheus"
 	config_util "github.com/prometheus/common/config"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/config"
 	"github.com/prometheus/prometheus/discovery"
 	"github.com/prometheus/prometheus/notifier"
 	"github.com/thanos-io/thanos/pkg/cacheutil"
+	"golang.org/x/net/context/ctxhttp"
 
 	"github.com/grafana/mimir/pkg/util"
 )
@@ -42,16 +46,37 @@ type rulerNotifier struct {
 	notifier  *notifier.Manager
 	sdCancel  context.CancelFunc
 	sdManager *discovery.Manager
+	targets   *notifierTargets
 	wg        sync.WaitGroup
 	logger    gklog.Logger
+
+	// The Alertmanager URL of the last applied config.
+	alertmanagerURL string
 }
 
 func newRulerNotifier(o *notifier.Options, l gklog.Logger) *rulerNotifier {
 	sdCtx, sdCancel := context.WithCancel(context.Background())
+	targets := newNotifierTargets()
+
+	// Track the notifications sent to each Alertmanager.
+	opts := *o
+	do := opts.Do
+	if do == nil {
+		do = ctxhttp.Do
+	}
+	opts.Do = func(ctx context.Context, client *http.Client, req *http.Request) (*http.Response, error) {
+		target := req.URL.String()
+		targets.started(target)
+		resp, err := do(ctx, client, req)
+		targets.finished(target, err == nil && resp.StatusCode/100 == 2)
+		return resp, err
+	}
+
 	return &rulerNotifier{
-		notifier:  notifier.NewManager(o, l),
+		notifier:  notifier.NewManager(&opts, l),
 		sdCancel:  sdCancel,
 		sdManager: discovery.NewManager(sdCtx, l),
+		targets:   targets,
 		logger:    l,
 	}
 }
@@ -107,9 +132,18 @@ func buildNotifierConfig(rulerConfig *Config, resolver cacheutil.AddressProvider
 		}
 
 		var sdConfig discovery.Config
-		if isSD {
+		switch {
+		case qType == httpSDQType:
+			sdConfig = httpSD(rulerConfig, url)
+
+			// The discovered Alertmanagers are reached with the scheme of the HTTP service
+			// discovery endpoint, while its path is not used as the Alertmanager path prefix.
+			amURL := *url
+			amURL.Path = ""
+			url = &amURL
+		case isSD:
 			sdConfig = dnsSD(rulerConfig, resolver, qType, url)
-		} else {
+		default:
 			sdConfig = staticTarget(url)
 		}
 
@@ -164,3 +198,127 @@ func amConfigWithSD(rulerConfig *Config, url *url.URL, sdConfig discovery.Config
 
 	return amConfig
 }
+
+// notifierTargets tracks the state of the notifications sent to each Alertmanager, keyed by the alerts URL.
+type notifierTargets struct {
+	mtx     sync.Mutex
+	targets map[string]*notifierTarget
+}
+
+type notifierTarget struct {
+	inflight    int
+	lastFailed  bool
+	lastSuccess time.Time
+}
+
+func newNotifierTargets() *notifierTargets {
+	return &notifierTargets{targets: map[string]*notifierTarget{}}
+}
+
+func (t *notifierTargets) started(target string) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	s, ok := t.targets[target]
+	if !ok {
+		s = &notifierTarget{}
+		t.targets[target] = s
+	}
+	s.inflight++
+}
+
+func (t *notifierTargets) finished(target string, success bool) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	s, ok := t.targets[target]
+	if !ok {
+		return
+	}
+	s.inflight--
+	s.lastFailed = !success
+	if success {
+		s.lastSuccess = time.Now()
+	}
+}
+
+// get returns a copy of the state of the target.
+func (t *notifierTargets) get(target string) notifierTarget {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if s, ok := t.targets[target]; ok {
+		return *s
+	}
+	return notifierTarget{}
+}
+
+// retain removes the state of the targets not included in the input ones.
+func (t *notifierTargets) retain(targets map[string]struct{}) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	for target, s := range t.targets {
+		if _, ok := targets[target]; !ok && s.inflight == 0 {
+			delete(t.targets, target)
+		}
+	}
+}
+
+// notifierTargetsCollector exports the state of the Alertmanagers currently discovered by each tenant's notifier.
+type notifierTargetsCollector struct {
+	notifiers func() map[string]*rulerNotifier
+
+	up          *prometheus.Desc
+	inflight    *prometheus.Desc
+	lastSuccess *prometheus.Desc
+}
+
+func newNotifierTargetsCollector(notifiers func() map[string]*rulerNotifier) *notifierTargetsCollector {
+	return &notifierTargetsCollector{
+		notifiers: notifiers,
+		up: prometheus.NewDesc(
+			"cortex_ruler_alertmanager_target_up",
+			"Whether the last notification sent to the Alertmanager succeeded (1) or failed (0).",
+			[]string{"user", "alertmanager"}, nil),
+		inflight: prometheus.NewDesc(
+			"cortex_ruler_alertmanager_target_inflight_requests",
+			"Number of notification requests currently being sent to the Alertmanager.",
+			[]string{"user", "alertmanager"}, nil),
+		lastSuccess: prometheus.NewDesc(
+			"cortex_ruler_alertmanager_target_last_success_timestamp_seconds",
+			"Timestamp of the last notification successfully sent to the Alertmanager.",
+			[]string{"user", "alertmanager"}, nil),
+	}
+}
+
+func (c *notifierTargetsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.up
+	ch <- c.inflight
+	ch <- c.lastSuccess
+}
+
+func (c *notifierTargetsCollector) Collect(ch chan<- prometheus.Metric) {
+	for userID, n := range c.notifiers() {
+		discovered := map[string]struct{}{}
+
+		for _, u := range n.notifier.Alertmanagers() {
+			target := u.String()
+			discovered[target] = struct{}{}
+			s := n.targets.get(target)
+
+			up := 1.0
+			if s.lastFailed {
+				up = 0
+			}
+			ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, up, userID, target)
+			ch <- prometheus.MustNewConstMetric(c.inflight, prometheus.GaugeValue, float64(s.inflight), userID, target)
+			if !s.lastSuccess.IsZero() {
+				ch <- prometheus.MustNewConstMetric(c.lastSuccess, prometheus.GaugeValue, float64(s.lastSuccess.UnixNano())/1e9, userID, target)
+			}
+		}
+
+		// Forget the Alertmanagers which are not discovered anymore.
+		n.targets.retain(discovered)
+	}
+}
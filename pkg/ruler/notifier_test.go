@@ -7,6 +7,7 @@ package ruler
 
 import (
 	"errors"
+	"net/http"
 	"testing"
 	"time"
 
@@ -264,6 +265,30 @@ func TestBuildNotifierConfig(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "with HTTP service discovery",
+			cfg: &Config{
+				AlertmanagerURL:             "http_sd+https://sd.default.svc.cluster.local/alertmanagers",
+				AlertmanagerRefreshInterval: time.Minute,
+			},
+			ncfg: &config.Config{
+				AlertingConfig: config.AlertingConfig{
+					AlertmanagerConfigs: []*config.AlertmanagerConfig{
+						{
+							APIVersion: "v2",
+							Scheme:     "https",
+							ServiceDiscoveryConfigs: discovery.Configs{
+								httpServiceDiscovery{
+									Client:          &http.Client{},
+									RefreshInterval: time.Minute,
+									URL:             "https://sd.default.svc.cluster.local/alertmanagers",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			name: "with DNS service discovery and missing scheme",
 			cfg: &Config{
@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ruler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/rules"
+
+	"github.com/grafana/mimir/pkg/querier"
+	"github.com/grafana/mimir/pkg/ruler/rulespb"
+)
+
+const groupQuerySourcesKey contextKey = 4
+
+// groupQuerySources holds the query source of a tenant's rule groups, keyed by rules.GroupKey().
+// Only the groups not querying all the sources are tracked.
+type groupQuerySources struct {
+	mtx     sync.RWMutex
+	sources map[string]string
+}
+
+func newGroupQuerySources() *groupQuerySources {
+	return &groupQuerySources{sources: map[string]string{}}
+}
+
+// set replaces the query sources with the ones of the input groups, mapped to disk by the mapper.
+func (s *groupQuerySources) set(m *mapper, user string, groups rulespb.RuleGroupList) {
+	sources := map[string]string{}
+	for _, g := range groups {
+		if src := g.GetQuerySource(); src != "" && src != rulespb.QuerySourceAll {
+			sources[rules.GroupKey(m.filename(user, g.GetNamespace()), g.GetName())] = src
+		}
+	}
+
+	s.mtx.Lock()
+	s.sources = sources
+	s.mtx.Unlock()
+}
+
+func (s *groupQuerySources) get(key string) string {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	return s.sources[key]
+}
+
+func contextWithGroupQuerySources(ctx context.Context, s *groupQuerySources) context.Context {
+	return context.WithValue(ctx, groupQuerySourcesKey, s)
+}
+
+// groupQuerySourceFromContext returns the query source of the rule group being evaluated.
+func groupQuerySourceFromContext(ctx context.Context) string {
+	s, ok := ctx.Value(groupQuerySourcesKey).(*groupQuerySources)
+	if !ok {
+		return ""
+	}
+	g, ok := ctx.Value(evaluatedGroupKey).(evaluatedGroup)
+	if !ok {
+		return ""
+	}
+	return s.get(g.key)
+}
+
+// QuerySourceQueryFunc restricts the queries of the rule groups configured to query only the
+// ingesters, so that they don't hit the long-term storage.
+func QuerySourceQueryFunc(qf rules.QueryFunc) rules.QueryFunc {
+	return func(ctx context.Context, qs string, t time.Time) (promql.Vector, error) {
+		if groupQuerySourceFromContext(ctx) == rulespb.QuerySourceIngesters {
+			ctx = querier.ContextWithIngestersOnly(ctx)
+		}
+		return qf(ctx, qs, t)
+	}
+}
+
+// validateQuerySource checks that the query source of the rule group is supported.
+func validateQuerySource(rg rulespb.RuleGroup) error {
+	switch rg.QuerySource {
+	case "", rulespb.QuerySourceAll, rulespb.QuerySourceIngesters:
+		return nil
+	default:
+		return fmt.Errorf("invalid rules config: rule group '%s' has invalid query source '%s', supported values are '%s' and '%s'", rg.Name, rg.QuerySource, rulespb.QuerySourceAll, rulespb.QuerySourceIngesters)
+	}
+}
@@ -40,6 +40,7 @@ import (
 	"github.com/grafana/mimir/pkg/ruler/rulestore"
 	"github.com/grafana/mimir/pkg/tenant"
 	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/grpcencoding"
 	util_log "github.com/grafana/mimir/pkg/util/log"
 	"github.com/grafana/mimir/pkg/util/validation"
 )
@@ -116,6 +117,8 @@ type Config struct {
 	EnableQueryStats bool `yaml:"query_stats_enabled" category:"advanced"`
 
 	TenantFederation TenantFederationConfig `yaml:"tenant_federation"`
+
+	WAL WALConfig `yaml:"wal"`
 }
 
 // Validate config and returns error on failure
@@ -124,10 +127,10 @@ func (cfg *Config) Validate(limits validation.Limits, log log.Logger) error {
 		return errInvalidTenantShardSize
 	}
 
-	if err := cfg.ClientTLSConfig.Validate(log); err != nil {
+	if err := grpcencoding.ValidateClientConfig(cfg.ClientTLSConfig, log); err != nil {
 		return errors.Wrap(err, "invalid ruler gRPC client config")
 	}
-	return nil
+	return cfg.WAL.Validate()
 }
 
 // RegisterFlags adds the flags required to config this to the given FlagSet
@@ -136,14 +139,15 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	cfg.Ring.RegisterFlags(f, logger)
 	cfg.Notifier.RegisterFlags(f)
 	cfg.TenantFederation.RegisterFlags(f)
+	cfg.WAL.RegisterFlags(f)
 
 	cfg.ExternalURL.URL, _ = url.Parse("") // Must be non-nil
 	f.Var(&cfg.ExternalURL, "ruler.external.url", "URL of alerts return path.")
 	f.DurationVar(&cfg.EvaluationInterval, "ruler.evaluation-interval", 1*time.Minute, "How frequently to evaluate rules")
 	f.DurationVar(&cfg.PollInterval, "ruler.poll-interval", 1*time.Minute, "How frequently to poll for rule changes")
 
-	f.StringVar(&cfg.AlertmanagerURL, "ruler.alertmanager-url", "", "Comma-separated list of URL(s) of the Alertmanager(s) to send notifications to. Each URL is treated as a separate group. Multiple Alertmanagers in HA per group can be supported by using DNS service discovery format. Basic auth is supported as part of the URL.")
-	f.DurationVar(&cfg.AlertmanagerRefreshInterval, "ruler.alertmanager-refresh-interval", 1*time.Minute, "How long to wait between refreshing DNS resolutions of Alertmanager hosts.")
+	f.StringVar(&cfg.AlertmanagerURL, "ruler.alertmanager-url", "", "Comma-separated list of URL(s) of the Alertmanager(s) to send notifications to. Each URL is treated as a separate group. Multiple Alertmanagers in HA per group can be supported by using DNS service discovery format, or HTTP service discovery by prefixing the URL of the discovery endpoint with http_sd+. Basic auth is supported as part of the URL.")
+	f.DurationVar(&cfg.AlertmanagerRefreshInterval, "ruler.alertmanager-refresh-interval", 1*time.Minute, "How long to wait between refreshing DNS resolutions or HTTP service discovery of Alertmanager hosts.")
 	f.IntVar(&cfg.NotificationQueueCapacity, "ruler.notification-queue-capacity", 10000, "Capacity of the queue for notifications to be sent to the Alertmanager.")
 	f.DurationVar(&cfg.NotificationTimeout, "ruler.notification-timeout", 10*time.Second, "HTTP timeout duration when sending notifications to the Alertmanager.")
 
@@ -422,10 +426,6 @@ func instanceOwnsRuleGroup(r ring.ReadRing, g *rulespb.RuleGroupDesc, instanceAd
 	return rlrs.Instances[0].Addr == instanceAddr, nil
 }
 
-func (r *Ruler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	r.ring.ServeHTTP(w, req)
-}
-
 func (r *Ruler) run(ctx context.Context) error {
 	level.Info(r.logger).Log("msg", "ruler up and running")
 
@@ -669,6 +669,16 @@ func (r *Ruler) Rules(ctx context.Context, in *RulesRequest) (*RulesResponse, er
 	return &RulesResponse{Groups: groupDescs}, nil
 }
 
+// evaluationOffset returns the offset, within the evaluation interval, at which the group is evaluated.
+// The offset is computed from the hash of the group's name and file, so that the evaluations of the
+// groups with the same interval are spread over the interval instead of being run at the same time.
+func evaluationOffset(group *promRules.Group) time.Duration {
+	if group.Interval() <= 0 {
+		return 0
+	}
+	return time.Duration(group.EvalTimestamp(0).UnixNano())
+}
+
 func (r *Ruler) getLocalRules(userID string) ([]*GroupStateDesc, error) {
 	groups := r.manager.GetRules(userID)
 
@@ -695,6 +705,7 @@ func (r *Ruler) getLocalRules(userID string) ([]*GroupStateDesc, error) {
 
 			EvaluationTimestamp: group.GetLastEvaluation(),
 			EvaluationDuration:  group.GetEvaluationTime(),
+			EvaluationOffset:    evaluationOffset(group),
 		}
 		for _, r := range group.Rules() {
 			lastError := ""
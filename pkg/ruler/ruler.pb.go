@@ -122,6 +122,7 @@ type GroupStateDesc struct {
 	ActiveRules         []*RuleStateDesc       `protobuf:"bytes,2,rep,name=active_rules,json=activeRules,proto3" json:"active_rules,omitempty"`
 	EvaluationTimestamp time.Time              `protobuf:"bytes,3,opt,name=evaluationTimestamp,proto3,stdtime" json:"evaluationTimestamp"`
 	EvaluationDuration  time.Duration          `protobuf:"bytes,4,opt,name=evaluationDuration,proto3,stdduration" json:"evaluationDuration"`
+	EvaluationOffset    time.Duration          `protobuf:"bytes,5,opt,name=evaluationOffset,proto3,stdduration" json:"evaluationOffset"`
 }
 
 func (m *GroupStateDesc) Reset()      { *m = GroupStateDesc{} }
@@ -184,6 +185,13 @@ func (m *GroupStateDesc) GetEvaluationDuration() time.Duration {
 	return 0
 }
 
+func (m *GroupStateDesc) GetEvaluationOffset() time.Duration {
+	if m != nil {
+		return m.EvaluationOffset
+	}
+	return 0
+}
+
 // RuleStateDesc is a proto representation of a Prometheus Rule
 type RuleStateDesc struct {
 	Rule                *rulespb.RuleDesc `protobuf:"bytes,1,opt,name=rule,proto3" json:"rule,omitempty"`
@@ -380,50 +388,51 @@ func init() {
 func init() { proto.RegisterFile("ruler.proto", fileDescriptor_9ecbec0a4cfddea6) }
 
 var fileDescriptor_9ecbec0a4cfddea6 = []byte{
-	// 682 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x54, 0xcd, 0x6e, 0x13, 0x3b,
-	0x14, 0x1e, 0xa7, 0x4d, 0x9a, 0x38, 0xbd, 0xbd, 0x92, 0x9b, 0x7b, 0x35, 0x44, 0xc8, 0xa9, 0xc2,
-	0xa6, 0x42, 0xea, 0x04, 0x4a, 0x05, 0x62, 0x01, 0x28, 0x55, 0x0b, 0x1b, 0x16, 0x68, 0x0a, 0x6c,
-	0x2b, 0x4f, 0xe2, 0x4c, 0x47, 0x4c, 0xc6, 0x83, 0xed, 0x89, 0x58, 0xf2, 0x08, 0x5d, 0xb2, 0x66,
-	0xc5, 0xa3, 0x74, 0xd9, 0x65, 0x85, 0x50, 0xa1, 0xd3, 0x0d, 0xcb, 0x3e, 0x02, 0xf2, 0xf1, 0x84,
-	0x24, 0x50, 0x10, 0x11, 0xea, 0x26, 0xf1, 0xf9, 0xf9, 0xbe, 0x73, 0xce, 0x77, 0xc6, 0xc6, 0x75,
-	0x99, 0xc5, 0x5c, 0x7a, 0xa9, 0x14, 0x5a, 0x90, 0x32, 0x18, 0xcd, 0x8d, 0x30, 0xd2, 0x07, 0x59,
-	0xe0, 0xf5, 0xc4, 0xb0, 0x13, 0x8a, 0x50, 0x74, 0x20, 0x1a, 0x64, 0x03, 0xb0, 0xc0, 0x80, 0x93,
-	0x45, 0x35, 0x69, 0x28, 0x44, 0x18, 0xf3, 0x49, 0x56, 0x3f, 0x93, 0x4c, 0x47, 0x22, 0x29, 0xe2,
-	0xad, 0x1f, 0xe3, 0x3a, 0x1a, 0x72, 0xa5, 0xd9, 0x30, 0x2d, 0x12, 0x6e, 0x4d, 0xd7, 0x93, 0x6c,
-	0xc0, 0x12, 0xd6, 0x19, 0x46, 0xc3, 0x48, 0x76, 0xd2, 0x57, 0xa1, 0x3d, 0xa5, 0x81, 0xfd, 0x2f,
-	0x10, 0x77, 0x7f, 0x8b, 0x80, 0x29, 0xe0, 0x57, 0xa5, 0x81, 0xfd, 0xb7, 0xb8, 0xf6, 0x0a, 0x5e,
-	0xf6, 0x8d, 0xe9, 0xf3, 0xd7, 0x19, 0x57, 0xba, 0xfd, 0x10, 0xff, 0x53, 0xd8, 0x2a, 0x15, 0x89,
-	0xe2, 0x64, 0x03, 0x57, 0x42, 0x29, 0xb2, 0x54, 0xb9, 0x68, 0x6d, 0x61, 0xbd, 0xbe, 0xf9, 0x9f,
-	0x67, 0xf5, 0x79, 0x62, 0x9c, 0x7b, 0x9a, 0x69, 0xbe, 0xc3, 0x55, 0xcf, 0x2f, 0x92, 0xda, 0xef,
-	0x4b, 0x78, 0x65, 0x36, 0x44, 0x6e, 0xe2, 0x32, 0x04, 0x5d, 0xb4, 0x86, 0xd6, 0xeb, 0x9b, 0x0d,
-	0xcf, 0xd6, 0x37, 0x65, 0x20, 0x13, 0xf0, 0x36, 0x85, 0xdc, 0xc3, 0xcb, 0xac, 0xa7, 0xa3, 0x11,
-	0xdf, 0x87, 0x24, 0xb7, 0x04, 0x35, 0x1b, 0x45, 0x4d, 0x03, 0x99, 0x94, 0xac, 0xdb, 0x4c, 0x68,
-	0x97, 0xbc, 0xc4, 0xab, 0x7c, 0xc4, 0xe2, 0x0c, 0x64, 0x7e, 0x3e, 0x96, 0xd3, 0x5d, 0x80, 0x92,
-	0x4d, 0xcf, 0x0a, 0xee, 0x8d, 0x05, 0xf7, 0xbe, 0x67, 0x6c, 0x57, 0x8f, 0x4e, 0x5b, 0xce, 0xe1,
-	0xe7, 0x16, 0xf2, 0x2f, 0x23, 0x20, 0x7b, 0x98, 0x4c, 0xdc, 0x3b, 0xc5, 0x1a, 0xdd, 0x45, 0xa0,
-	0xbd, 0xf6, 0x13, 0xed, 0x38, 0xc1, 0xb2, 0xbe, 0x33, 0xac, 0x97, 0xc0, 0xdb, 0x9f, 0x4a, 0x56,
-	0xe5, 0x89, 0x46, 0x37, 0xf0, 0xa2, 0x19, 0xb1, 0x90, 0xe8, 0xdf, 0x29, 0x89, 0x60, 0x54, 0x08,
-	0x92, 0x06, 0x2e, 0x2b, 0x83, 0x70, 0x4b, 0x6b, 0x68, 0xbd, 0xe6, 0x5b, 0x83, 0xfc, 0x8f, 0x2b,
-	0x07, 0x9c, 0xc5, 0xfa, 0x00, 0x86, 0xad, 0xf9, 0x85, 0x45, 0xae, 0xe3, 0x5a, 0xcc, 0x94, 0xde,
-	0x95, 0x52, 0x48, 0x68, 0xb8, 0xe6, 0x4f, 0x1c, 0x66, 0xad, 0x2c, 0xe6, 0x52, 0x2b, 0xb7, 0x3c,
-	0xb3, 0xd6, 0xae, 0x71, 0x4e, 0xad, 0xd5, 0x26, 0xfd, 0x4a, 0xde, 0xca, 0xd5, 0xc8, 0xbb, 0xf4,
-	0x77, 0xf2, 0x5e, 0x2c, 0xe2, 0x95, 0xd9, 0x39, 0x26, 0xd2, 0xa1, 0x69, 0xe9, 0x06, 0xb8, 0x12,
-	0xb3, 0x80, 0xc7, 0xe3, 0xef, 0x6c, 0xd5, 0xeb, 0x09, 0xa9, 0xf9, 0x9b, 0x34, 0xf0, 0x9e, 0x1a,
-	0xff, 0x33, 0x16, 0xc9, 0xed, 0xfb, 0xa6, 0xd6, 0xc7, 0xd3, 0xd6, 0xed, 0x3f, 0xb9, 0x93, 0x16,
-	0xd7, 0xed, 0xb3, 0x54, 0x73, 0xe9, 0x17, 0xec, 0x24, 0xc5, 0x75, 0x96, 0x24, 0x42, 0x43, 0x7b,
-	0xca, 0x5d, 0xb8, 0x92, 0x62, 0xd3, 0x25, 0xcc, 0xbc, 0x46, 0x17, 0x0e, 0x8b, 0x47, 0xbe, 0x35,
-	0x48, 0x17, 0xd7, 0x8a, 0xdb, 0xc5, 0xb4, 0x5b, 0x9e, 0x63, 0x77, 0x55, 0x0b, 0xeb, 0x6a, 0xf2,
-	0x08, 0x57, 0x07, 0x91, 0xe4, 0x7d, 0xc3, 0x30, 0xcf, 0xf6, 0x97, 0x00, 0xd5, 0xd5, 0x64, 0x17,
-	0xd7, 0x25, 0x57, 0x22, 0x1e, 0x59, 0x8e, 0xa5, 0x39, 0x38, 0xf0, 0x18, 0xd8, 0xd5, 0xe4, 0x31,
-	0x5e, 0x36, 0x1f, 0xf3, 0xbe, 0xe2, 0x89, 0x36, 0x3c, 0xd5, 0x79, 0x78, 0x0c, 0x72, 0x8f, 0x27,
-	0xda, 0xb6, 0x33, 0x62, 0x71, 0xd4, 0xdf, 0xcf, 0x12, 0x1d, 0xc5, 0x6e, 0x6d, 0x1e, 0x1a, 0x00,
-	0xbe, 0x30, 0xb8, 0xcd, 0x07, 0xb8, 0x6c, 0x2e, 0xab, 0x24, 0x5b, 0xf6, 0xa0, 0xc8, 0xea, 0xd4,
-	0x9b, 0x35, 0x7e, 0x5d, 0x9b, 0x8d, 0x59, 0xa7, 0x7d, 0x62, 0xdb, 0xce, 0xf6, 0xd6, 0xf1, 0x19,
-	0x75, 0x4e, 0xce, 0xa8, 0x73, 0x71, 0x46, 0xd1, 0xdb, 0x9c, 0xa2, 0x0f, 0x39, 0x45, 0x47, 0x39,
-	0x45, 0xc7, 0x39, 0x45, 0x5f, 0x72, 0x8a, 0xbe, 0xe6, 0xd4, 0xb9, 0xc8, 0x29, 0x3a, 0x3c, 0xa7,
-	0xce, 0xf1, 0x39, 0x75, 0x4e, 0xce, 0xa9, 0x13, 0x54, 0xa0, 0xbd, 0x3b, 0xdf, 0x02, 0x00, 0x00,
-	0xff, 0xff, 0x0c, 0x0b, 0x21, 0x72, 0xb2, 0x06, 0x00, 0x00,
+	// 697 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x54, 0x4f, 0x6f, 0xd3, 0x3e,
+	0x18, 0x8e, 0xdb, 0xb5, 0x6b, 0xdd, 0x6d, 0xbf, 0x9f, 0xbc, 0x81, 0x42, 0x85, 0xdc, 0xaa, 0x5c,
+	0x26, 0xa4, 0xa5, 0x30, 0x26, 0x10, 0x07, 0x40, 0x9d, 0x36, 0xb8, 0x20, 0x0d, 0x65, 0xc0, 0x75,
+	0x72, 0x5a, 0x37, 0x8b, 0x48, 0xe3, 0x60, 0x3b, 0x15, 0x47, 0x3e, 0xc2, 0x8e, 0x7c, 0x04, 0x3e,
+	0xca, 0x8e, 0x3b, 0x4e, 0x08, 0x0d, 0x96, 0x5d, 0x38, 0x4e, 0xe2, 0x0b, 0x20, 0xdb, 0xc9, 0xda,
+	0xb2, 0x81, 0x5a, 0xa1, 0x5d, 0x12, 0xbf, 0x7f, 0x9e, 0xe7, 0xb5, 0x9f, 0xf7, 0xb5, 0x61, 0x8d,
+	0x27, 0x21, 0xe5, 0x4e, 0xcc, 0x99, 0x64, 0xa8, 0xa4, 0x8d, 0xfa, 0x9a, 0x1f, 0xc8, 0xfd, 0xc4,
+	0x73, 0xba, 0x6c, 0xd0, 0xf6, 0x99, 0xcf, 0xda, 0x3a, 0xea, 0x25, 0x7d, 0x6d, 0x69, 0x43, 0xaf,
+	0x0c, 0xaa, 0x8e, 0x7d, 0xc6, 0xfc, 0x90, 0x8e, 0xb2, 0x7a, 0x09, 0x27, 0x32, 0x60, 0x51, 0x16,
+	0x6f, 0xfc, 0x1e, 0x97, 0xc1, 0x80, 0x0a, 0x49, 0x06, 0x71, 0x96, 0x70, 0x6f, 0xbc, 0x1e, 0x27,
+	0x7d, 0x12, 0x91, 0xf6, 0x20, 0x18, 0x04, 0xbc, 0x1d, 0xbf, 0xf3, 0xcd, 0x2a, 0xf6, 0xcc, 0x3f,
+	0x43, 0x3c, 0xfc, 0x2b, 0x42, 0x9f, 0x42, 0x7f, 0x45, 0xec, 0x99, 0xbf, 0xc1, 0xb5, 0x96, 0xe0,
+	0x82, 0xab, 0x4c, 0x97, 0xbe, 0x4f, 0xa8, 0x90, 0xad, 0xa7, 0x70, 0x31, 0xb3, 0x45, 0xcc, 0x22,
+	0x41, 0xd1, 0x1a, 0x2c, 0xfb, 0x9c, 0x25, 0xb1, 0xb0, 0x41, 0xb3, 0xb8, 0x5a, 0x5b, 0xbf, 0xe1,
+	0x18, 0x7d, 0x5e, 0x28, 0xe7, 0xae, 0x24, 0x92, 0x6e, 0x51, 0xd1, 0x75, 0xb3, 0xa4, 0xd6, 0xcf,
+	0x02, 0x5c, 0x9a, 0x0c, 0xa1, 0xbb, 0xb0, 0xa4, 0x83, 0x36, 0x68, 0x82, 0xd5, 0xda, 0xfa, 0x8a,
+	0x63, 0xea, 0xab, 0x32, 0x3a, 0x53, 0xe3, 0x4d, 0x0a, 0x7a, 0x04, 0x17, 0x48, 0x57, 0x06, 0x43,
+	0xba, 0xa7, 0x93, 0xec, 0x42, 0xb3, 0x78, 0x01, 0xe1, 0x1a, 0x32, 0x2a, 0x59, 0x33, 0x99, 0x7a,
+	0xbb, 0xe8, 0x2d, 0x5c, 0xa6, 0x43, 0x12, 0x26, 0x5a, 0xe6, 0xd7, 0xb9, 0x9c, 0x76, 0x51, 0x97,
+	0xac, 0x3b, 0x46, 0x70, 0x27, 0x17, 0xdc, 0xb9, 0xc8, 0xd8, 0xac, 0x1c, 0x9e, 0x34, 0xac, 0x83,
+	0x6f, 0x0d, 0xe0, 0x5e, 0x45, 0x80, 0x76, 0x21, 0x1a, 0xb9, 0xb7, 0xb2, 0x36, 0xda, 0x73, 0x9a,
+	0xf6, 0xd6, 0x25, 0xda, 0x3c, 0xc1, 0xb0, 0x7e, 0x52, 0xac, 0x57, 0xc0, 0xd1, 0x0e, 0xfc, 0x7f,
+	0xe4, 0xdd, 0xe9, 0xf7, 0x05, 0x95, 0x76, 0x69, 0x7a, 0xca, 0x4b, 0xe0, 0xd6, 0xd7, 0x02, 0x5c,
+	0x9c, 0x10, 0x07, 0xdd, 0x81, 0x73, 0x4a, 0xb3, 0x4c, 0xf3, 0xff, 0xc6, 0x34, 0xd7, 0xda, 0xe9,
+	0x20, 0x5a, 0x81, 0x25, 0xa1, 0x10, 0x76, 0xa1, 0x09, 0x56, 0xab, 0xae, 0x31, 0xd0, 0x4d, 0x58,
+	0xde, 0xa7, 0x24, 0x94, 0xfb, 0x5a, 0xbd, 0xaa, 0x9b, 0x59, 0xe8, 0x36, 0xac, 0x86, 0x44, 0xc8,
+	0x6d, 0xce, 0x19, 0xd7, 0x0a, 0x54, 0xdd, 0x91, 0x43, 0xcd, 0x09, 0x09, 0x29, 0x97, 0xc2, 0x2e,
+	0x4d, 0xcc, 0x49, 0x47, 0x39, 0xc7, 0xe6, 0xc4, 0x24, 0xfd, 0xa9, 0x5f, 0xe5, 0xeb, 0xe9, 0xd7,
+	0xfc, 0x3f, 0xf5, 0xab, 0x75, 0x3e, 0x07, 0x97, 0x26, 0xcf, 0x31, 0x92, 0x0e, 0x8c, 0x4b, 0xd7,
+	0x87, 0xe5, 0x90, 0x78, 0x34, 0xcc, 0x07, 0x77, 0xd9, 0xe9, 0x32, 0x2e, 0xe9, 0x87, 0xd8, 0x73,
+	0x5e, 0x2a, 0xff, 0x2b, 0x12, 0xf0, 0xcd, 0xc7, 0xaa, 0xd6, 0x97, 0x93, 0xc6, 0xfd, 0x69, 0x2e,
+	0xb9, 0xc1, 0x75, 0x7a, 0x24, 0x96, 0x94, 0xbb, 0x19, 0x3b, 0x8a, 0x61, 0x8d, 0x44, 0x11, 0x93,
+	0x7a, 0x7b, 0xc2, 0x2e, 0x5e, 0x4b, 0xb1, 0xf1, 0x12, 0xea, 0xbc, 0x4a, 0x17, 0xaa, 0x1b, 0x0f,
+	0x5c, 0x63, 0xa0, 0x0e, 0xac, 0x66, 0xd7, 0x95, 0xe4, 0x13, 0x3c, 0x5d, 0xef, 0x2a, 0x06, 0xd6,
+	0x91, 0xe8, 0x19, 0xac, 0xf4, 0x03, 0x4e, 0x7b, 0x8a, 0x61, 0x96, 0xee, 0xcf, 0x6b, 0x54, 0x47,
+	0xa2, 0x6d, 0x58, 0xe3, 0x54, 0xb0, 0x70, 0x68, 0x38, 0xe6, 0x67, 0xe0, 0x80, 0x39, 0xb0, 0x23,
+	0xd1, 0x73, 0xb8, 0xa0, 0x86, 0x79, 0x4f, 0xd0, 0x48, 0x2a, 0x9e, 0xca, 0x2c, 0x3c, 0x0a, 0xb9,
+	0x4b, 0x23, 0x69, 0xb6, 0x33, 0x24, 0x61, 0xd0, 0xdb, 0x4b, 0x22, 0x19, 0x84, 0x76, 0x75, 0x16,
+	0x1a, 0x0d, 0x7c, 0xa3, 0x70, 0xeb, 0x4f, 0x60, 0x49, 0x5d, 0x56, 0x8e, 0x36, 0xcc, 0x42, 0xa0,
+	0xe5, 0xb1, 0x47, 0x30, 0x7f, 0xae, 0xeb, 0x2b, 0x93, 0x4e, 0xf3, 0x66, 0xb7, 0xac, 0xcd, 0x8d,
+	0xa3, 0x53, 0x6c, 0x1d, 0x9f, 0x62, 0xeb, 0xfc, 0x14, 0x83, 0x8f, 0x29, 0x06, 0x9f, 0x53, 0x0c,
+	0x0e, 0x53, 0x0c, 0x8e, 0x52, 0x0c, 0xbe, 0xa7, 0x18, 0xfc, 0x48, 0xb1, 0x75, 0x9e, 0x62, 0x70,
+	0x70, 0x86, 0xad, 0xa3, 0x33, 0x6c, 0x1d, 0x9f, 0x61, 0xcb, 0x2b, 0xeb, 0xed, 0x3d, 0xf8, 0x35,
+	0x00, 0x98, 0x71, 0xc5, 0xf2, 0x03, 0x07, 0x00, 0x00,
 }
 
 func (this *RulesRequest) Equal(that interface{}) bool {
@@ -512,6 +521,9 @@ func (this *GroupStateDesc) Equal(that interface{}) bool {
 	if this.EvaluationDuration != that1.EvaluationDuration {
 		return false
 	}
+	if this.EvaluationOffset != that1.EvaluationOffset {
+		return false
+	}
 	return true
 }
 func (this *RuleStateDesc) Equal(that interface{}) bool {
@@ -644,7 +656,7 @@ func (this *GroupStateDesc) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 8)
+	s := make([]string, 0, 9)
 	s = append(s, "&ruler.GroupStateDesc{")
 	if this.Group != nil {
 		s = append(s, "Group: "+fmt.Sprintf("%#v", this.Group)+",\n")
@@ -654,6 +666,7 @@ func (this *GroupStateDesc) GoString() string {
 	}
 	s = append(s, "EvaluationTimestamp: "+fmt.Sprintf("%#v", this.EvaluationTimestamp)+",\n")
 	s = append(s, "EvaluationDuration: "+fmt.Sprintf("%#v", this.EvaluationDuration)+",\n")
+	s = append(s, "EvaluationOffset: "+fmt.Sprintf("%#v", this.EvaluationOffset)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -864,21 +877,29 @@ func (m *GroupStateDesc) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
-	n1, err1 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.EvaluationDuration, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.EvaluationDuration):])
+	n1, err1 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.EvaluationOffset, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.EvaluationOffset):])
 	if err1 != nil {
 		return 0, err1
 	}
 	i -= n1
 	i = encodeVarintRuler(dAtA, i, uint64(n1))
 	i--
-	dAtA[i] = 0x22
-	n2, err2 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.EvaluationTimestamp, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.EvaluationTimestamp):])
+	dAtA[i] = 0x2a
+	n2, err2 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.EvaluationDuration, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.EvaluationDuration):])
 	if err2 != nil {
 		return 0, err2
 	}
 	i -= n2
 	i = encodeVarintRuler(dAtA, i, uint64(n2))
 	i--
+	dAtA[i] = 0x22
+	n3, err3 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.EvaluationTimestamp, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.EvaluationTimestamp):])
+	if err3 != nil {
+		return 0, err3
+	}
+	i -= n3
+	i = encodeVarintRuler(dAtA, i, uint64(n3))
+	i--
 	dAtA[i] = 0x1a
 	if len(m.ActiveRules) > 0 {
 		for iNdEx := len(m.ActiveRules) - 1; iNdEx >= 0; iNdEx-- {
@@ -929,21 +950,21 @@ func (m *RuleStateDesc) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
-	n4, err4 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.EvaluationDuration, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.EvaluationDuration):])
-	if err4 != nil {
-		return 0, err4
-	}
-	i -= n4
-	i = encodeVarintRuler(dAtA, i, uint64(n4))
-	i--
-	dAtA[i] = 0x3a
-	n5, err5 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.EvaluationTimestamp, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.EvaluationTimestamp):])
+	n5, err5 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.EvaluationDuration, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.EvaluationDuration):])
 	if err5 != nil {
 		return 0, err5
 	}
 	i -= n5
 	i = encodeVarintRuler(dAtA, i, uint64(n5))
 	i--
+	dAtA[i] = 0x3a
+	n6, err6 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.EvaluationTimestamp, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.EvaluationTimestamp):])
+	if err6 != nil {
+		return 0, err6
+	}
+	i -= n6
+	i = encodeVarintRuler(dAtA, i, uint64(n6))
+	i--
 	dAtA[i] = 0x32
 	if len(m.Alerts) > 0 {
 		for iNdEx := len(m.Alerts) - 1; iNdEx >= 0; iNdEx-- {
@@ -1015,45 +1036,45 @@ func (m *AlertStateDesc) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
-	n7, err7 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.ValidUntil, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.ValidUntil):])
-	if err7 != nil {
-		return 0, err7
-	}
-	i -= n7
-	i = encodeVarintRuler(dAtA, i, uint64(n7))
-	i--
-	dAtA[i] = 0x4a
-	n8, err8 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.LastSentAt, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.LastSentAt):])
+	n8, err8 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.ValidUntil, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.ValidUntil):])
 	if err8 != nil {
 		return 0, err8
 	}
 	i -= n8
 	i = encodeVarintRuler(dAtA, i, uint64(n8))
 	i--
-	dAtA[i] = 0x42
-	n9, err9 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.ResolvedAt, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.ResolvedAt):])
+	dAtA[i] = 0x4a
+	n9, err9 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.LastSentAt, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.LastSentAt):])
 	if err9 != nil {
 		return 0, err9
 	}
 	i -= n9
 	i = encodeVarintRuler(dAtA, i, uint64(n9))
 	i--
-	dAtA[i] = 0x3a
-	n10, err10 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.FiredAt, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.FiredAt):])
+	dAtA[i] = 0x42
+	n10, err10 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.ResolvedAt, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.ResolvedAt):])
 	if err10 != nil {
 		return 0, err10
 	}
 	i -= n10
 	i = encodeVarintRuler(dAtA, i, uint64(n10))
 	i--
-	dAtA[i] = 0x32
-	n11, err11 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.ActiveAt, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.ActiveAt):])
+	dAtA[i] = 0x3a
+	n11, err11 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.FiredAt, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.FiredAt):])
 	if err11 != nil {
 		return 0, err11
 	}
 	i -= n11
 	i = encodeVarintRuler(dAtA, i, uint64(n11))
 	i--
+	dAtA[i] = 0x32
+	n12, err12 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.ActiveAt, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.ActiveAt):])
+	if err12 != nil {
+		return 0, err12
+	}
+	i -= n12
+	i = encodeVarintRuler(dAtA, i, uint64(n12))
+	i--
 	dAtA[i] = 0x2a
 	if m.Value != 0 {
 		i -= 8
@@ -1154,6 +1175,8 @@ func (m *GroupStateDesc) Size() (n int) {
 	n += 1 + l + sovRuler(uint64(l))
 	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.EvaluationDuration)
 	n += 1 + l + sovRuler(uint64(l))
+	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.EvaluationOffset)
+	n += 1 + l + sovRuler(uint64(l))
 	return n
 }
 
@@ -1274,6 +1297,7 @@ func (this *GroupStateDesc) String() string {
 		`ActiveRules:` + repeatedStringForActiveRules + `,`,
 		`EvaluationTimestamp:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.EvaluationTimestamp), "Timestamp", "timestamp.Timestamp", 1), `&`, ``, 1) + `,`,
 		`EvaluationDuration:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.EvaluationDuration), "Duration", "duration.Duration", 1), `&`, ``, 1) + `,`,
+		`EvaluationOffset:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.EvaluationOffset), "Duration", "duration.Duration", 1), `&`, ``, 1) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -1630,6 +1654,39 @@ func (m *GroupStateDesc) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EvaluationOffset", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRuler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRuler
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRuler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdDurationUnmarshal(&m.EvaluationOffset, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRuler(dAtA[iNdEx:])
@@ -0,0 +1,22 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ruler
+
+import (
+	"net/http"
+
+	"github.com/grafana/dskit/services"
+
+	"github.com/grafana/mimir/pkg/util"
+)
+
+func (r *Ruler) RingHandler(w http.ResponseWriter, req *http.Request) {
+	if r.State() != services.Running {
+		// we cannot read the ring before the ruler is in Running state,
+		// because that would lead to race condition.
+		util.WriteRingStatusMessage(w, req, "Ruler Ring", "Ruler is not running yet.")
+		return
+	}
+
+	r.ring.ServeHTTP(w, req)
+}
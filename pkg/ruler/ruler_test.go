@@ -79,6 +79,7 @@ type ruleLimits struct {
 	tenantShard          int
 	maxRulesPerRuleGroup int
 	maxRuleGroups        int
+	alertmanagerURL      string
 }
 
 func (r ruleLimits) EvaluationDelay(_ string) time.Duration {
@@ -97,6 +98,10 @@ func (r ruleLimits) RulerMaxRulesPerRuleGroup(_ string) int {
 	return r.maxRulesPerRuleGroup
 }
 
+func (r ruleLimits) RulerAlertmanagerURL(_ string) string {
+	return r.alertmanagerURL
+}
+
 func testSetup(t *testing.T) (*promql.Engine, storage.QueryableFunc, Pusher, log.Logger, RulesLimits) {
 	dir := t.TempDir()
 	tracker := promql.NewActiveQueryTracker(dir, 20, log.NewNopLogger())
@@ -123,7 +128,7 @@ func testSetup(t *testing.T) (*promql.Engine, storage.QueryableFunc, Pusher, log
 
 func newManager(t *testing.T, cfg Config) *DefaultMultiTenantManager {
 	engine, noopQueryable, pusher, logger, overrides := testSetup(t)
-	manager, err := NewDefaultMultiTenantManager(cfg, DefaultTenantManagerFactory(cfg, pusher, noopQueryable, noopQueryable, engine, overrides, nil), prometheus.NewRegistry(), logger, nil)
+	manager, err := NewDefaultMultiTenantManager(cfg, DefaultTenantManagerFactory(cfg, pusher, noopQueryable, noopQueryable, engine, overrides, nil), prometheus.NewRegistry(), logger, nil, overrides)
 	require.NoError(t, err)
 
 	return manager
@@ -172,7 +177,7 @@ func buildRuler(t *testing.T, cfg Config, storage rulestore.RuleStore, rulerAddr
 
 	reg := prometheus.NewRegistry()
 	managerFactory := DefaultTenantManagerFactory(cfg, pusher, noopQueryable, noopQueryable, engine, overrides, reg)
-	manager, err := NewDefaultMultiTenantManager(cfg, managerFactory, reg, log.NewNopLogger(), nil)
+	manager, err := NewDefaultMultiTenantManager(cfg, managerFactory, reg, log.NewNopLogger(), nil, overrides)
 	require.NoError(t, err)
 
 	ruler, err := newRuler(cfg, manager, reg, logger, storage, overrides, newMockClientsPool(cfg, logger, reg, rulerAddrMap))
@@ -1113,3 +1118,29 @@ func TestSendAlerts(t *testing.T) {
 		})
 	}
 }
+
+func TestEvaluationOffset(t *testing.T) {
+	const interval = time.Minute
+	now := time.Now()
+
+	offsets := map[time.Duration]struct{}{}
+	for i := 0; i < 10; i++ {
+		g := promRules.NewGroup(promRules.GroupOptions{
+			Name:     fmt.Sprintf("group-%d", i),
+			File:     "/rules/user-1/namespace",
+			Interval: interval,
+			Opts:     &promRules.ManagerOptions{},
+		})
+
+		offset := evaluationOffset(g)
+		require.GreaterOrEqual(t, offset, time.Duration(0))
+		require.Less(t, offset, interval)
+
+		// The group is evaluated at the offset within each interval.
+		require.Equal(t, offset, g.EvalTimestamp(now.UnixNano()).Sub(now.Truncate(interval).Add(-interval))%interval)
+		offsets[offset] = struct{}{}
+	}
+
+	// The groups are not all evaluated at the same time.
+	require.Greater(t, len(offsets), 1)
+}
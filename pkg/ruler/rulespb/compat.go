@@ -16,15 +16,36 @@ import (
 	"github.com/grafana/mimir/pkg/mimirpb" //lint:ignore faillint allowed to import other protobuf
 )
 
+// RuleGroup is the format of a rule group in the ruler configuration API. It extends the
+// Prometheus rule group format with the fields only supported by Mimir.
+type RuleGroup struct {
+	rulefmt.RuleGroup `yaml:",inline"`
+
+	// WriteInterval is the interval at which the results of the recording rules are written.
+	WriteInterval model.Duration `yaml:"write_interval,omitempty"`
+
+	// QuerySource is the source of the data queried by the rules. See the QuerySource* constants.
+	QuerySource string `yaml:"query_source,omitempty"`
+}
+
+const (
+	// QuerySourceAll queries all the sources of the read path: ingesters and long-term storage.
+	QuerySourceAll = "all"
+
+	// QuerySourceIngesters queries only the ingesters, which hold the most recent data.
+	QuerySourceIngesters = "ingesters"
+)
+
 // ToProto transforms a formatted prometheus rulegroup to a rule group protobuf
 func ToProto(user string, namespace string, rl rulefmt.RuleGroup) *RuleGroupDesc {
 	rg := RuleGroupDesc{
-		Name:          rl.Name,
-		Namespace:     namespace,
-		Interval:      time.Duration(rl.Interval),
-		Rules:         formattedRuleToProto(rl.Rules),
-		User:          user,
-		SourceTenants: rl.SourceTenants,
+		Name:            rl.Name,
+		Namespace:       namespace,
+		Interval:        time.Duration(rl.Interval),
+		Rules:           formattedRuleToProto(rl.Rules),
+		User:            user,
+		SourceTenants:   rl.SourceTenants,
+		EvaluationDelay: (*time.Duration)(rl.EvaluationDelay),
 	}
 	return &rg
 }
@@ -48,10 +69,11 @@ func formattedRuleToProto(rls []rulefmt.RuleNode) []*RuleDesc {
 // FromProto generates a rulefmt RuleGroup
 func FromProto(rg *RuleGroupDesc) rulefmt.RuleGroup {
 	formattedRuleGroup := rulefmt.RuleGroup{
-		Name:          rg.GetName(),
-		Interval:      model.Duration(rg.Interval),
-		Rules:         make([]rulefmt.RuleNode, len(rg.GetRules())),
-		SourceTenants: rg.GetSourceTenants(),
+		Name:            rg.GetName(),
+		Interval:        model.Duration(rg.Interval),
+		Rules:           make([]rulefmt.RuleNode, len(rg.GetRules())),
+		SourceTenants:   rg.GetSourceTenants(),
+		EvaluationDelay: (*model.Duration)(rg.GetEvaluationDelay()),
 	}
 
 	for i, rl := range rg.GetRules() {
@@ -80,3 +102,12 @@ func FromProto(rg *RuleGroupDesc) rulefmt.RuleGroup {
 
 	return formattedRuleGroup
 }
+
+// FromProtoToAPI generates a RuleGroup in the format of the ruler configuration API.
+func FromProtoToAPI(rg *RuleGroupDesc) RuleGroup {
+	return RuleGroup{
+		RuleGroup:     FromProto(rg),
+		WriteInterval: model.Duration(rg.GetWriteInterval()),
+		QuerySource:   rg.GetQuerySource(),
+	}
+}
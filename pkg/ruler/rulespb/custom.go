@@ -24,3 +24,13 @@ func (l RuleGroupList) Formatted() map[string][]rulefmt.RuleGroup {
 	}
 	return ruleMap
 }
+
+// APIFormatted returns the rule group list as a set of rule groups in the format of the
+// ruler configuration API, mapped by namespace
+func (l RuleGroupList) APIFormatted() map[string][]RuleGroup {
+	ruleMap := map[string][]RuleGroup{}
+	for _, g := range l {
+		ruleMap[g.Namespace] = append(ruleMap[g.Namespace], FromProtoToAPI(g))
+	}
+	return ruleMap
+}
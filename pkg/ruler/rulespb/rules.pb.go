@@ -43,8 +43,11 @@ type RuleGroupDesc struct {
 	// having to repeatedly redefine the proto description. It can also be leveraged
 	// to create custom `ManagerOpts` based on rule configs which can then be passed
 	// to the Prometheus Manager.
-	Options       []*types.Any `protobuf:"bytes,9,rep,name=options,proto3" json:"options,omitempty"`
-	SourceTenants []string     `protobuf:"bytes,10,rep,name=sourceTenants,proto3" json:"sourceTenants,omitempty"`
+	Options         []*types.Any   `protobuf:"bytes,9,rep,name=options,proto3" json:"options,omitempty"`
+	SourceTenants   []string       `protobuf:"bytes,10,rep,name=sourceTenants,proto3" json:"sourceTenants,omitempty"`
+	WriteInterval   time.Duration  `protobuf:"bytes,11,opt,name=writeInterval,proto3,stdduration" json:"writeInterval"`
+	EvaluationDelay *time.Duration `protobuf:"bytes,12,opt,name=evaluationDelay,proto3,stdduration" json:"evaluationDelay,omitempty"`
+	QuerySource     string         `protobuf:"bytes,13,opt,name=querySource,proto3" json:"querySource,omitempty"`
 }
 
 func (m *RuleGroupDesc) Reset()      { *m = RuleGroupDesc{} }
@@ -128,6 +131,27 @@ func (m *RuleGroupDesc) GetSourceTenants() []string {
 	return nil
 }
 
+func (m *RuleGroupDesc) GetWriteInterval() time.Duration {
+	if m != nil {
+		return m.WriteInterval
+	}
+	return 0
+}
+
+func (m *RuleGroupDesc) GetEvaluationDelay() *time.Duration {
+	if m != nil {
+		return m.EvaluationDelay
+	}
+	return nil
+}
+
+func (m *RuleGroupDesc) GetQuerySource() string {
+	if m != nil {
+		return m.QuerySource
+	}
+	return ""
+}
+
 // RuleDesc is a proto representation of a Prometheus Rule
 type RuleDesc struct {
 	Expr        string                                              `protobuf:"bytes,1,opt,name=expr,proto3" json:"expr,omitempty"`
@@ -206,39 +230,42 @@ func init() {
 func init() { proto.RegisterFile("rules.proto", fileDescriptor_8e722d3e922f0937) }
 
 var fileDescriptor_8e722d3e922f0937 = []byte{
-	// 501 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x52, 0x31, 0x8f, 0xd3, 0x30,
-	0x18, 0x8d, 0xdb, 0x34, 0x4d, 0x5c, 0x55, 0x54, 0xe6, 0x84, 0x72, 0x27, 0xe4, 0x56, 0x27, 0x90,
-	0xba, 0x90, 0xc2, 0x21, 0x06, 0x06, 0x84, 0xae, 0x3a, 0x09, 0xa9, 0x62, 0x40, 0x11, 0x13, 0x9b,
-	0x93, 0xba, 0x21, 0x22, 0xb5, 0x2d, 0xc7, 0x41, 0x77, 0x1b, 0x3f, 0x81, 0x91, 0x3f, 0x80, 0xc4,
-	0x4f, 0xb9, 0xb1, 0xe3, 0x89, 0xe1, 0xa0, 0xe9, 0xc2, 0x78, 0x3f, 0x01, 0xd9, 0x4e, 0xb9, 0x03,
-	0x96, 0x2e, 0x37, 0xf9, 0x7b, 0xdf, 0xfb, 0x9e, 0xbf, 0xe7, 0x27, 0xc3, 0x9e, 0xac, 0x0a, 0x5a,
-	0x46, 0x42, 0x72, 0xc5, 0x51, 0xc7, 0x80, 0x83, 0x47, 0x59, 0xae, 0xde, 0x57, 0x49, 0x94, 0xf2,
-	0xe5, 0x24, 0xe3, 0x19, 0x9f, 0x18, 0x36, 0xa9, 0x16, 0x06, 0x19, 0x60, 0x2a, 0xab, 0x3a, 0xc0,
-	0x19, 0xe7, 0x59, 0x41, 0xaf, 0xa7, 0xe6, 0x95, 0x24, 0x2a, 0xe7, 0xac, 0xe1, 0xf7, 0xff, 0xe5,
-	0x09, 0x3b, 0x6b, 0xa8, 0xc7, 0x37, 0x37, 0x49, 0xb2, 0x20, 0x8c, 0x4c, 0x96, 0xf9, 0x32, 0x97,
-	0x13, 0xf1, 0x21, 0xb3, 0x95, 0x48, 0xec, 0x69, 0x15, 0x87, 0x5f, 0x5b, 0xb0, 0x1f, 0x57, 0x05,
-	0x7d, 0x25, 0x79, 0x25, 0x4e, 0x68, 0x99, 0x22, 0x04, 0x5d, 0x46, 0x96, 0x34, 0x04, 0x23, 0x30,
-	0x0e, 0x62, 0x53, 0xa3, 0xfb, 0x30, 0xd0, 0x67, 0x29, 0x48, 0x4a, 0xc3, 0x96, 0x21, 0xae, 0x1b,
-	0xe8, 0x25, 0xf4, 0x73, 0xa6, 0xa8, 0xfc, 0x48, 0x8a, 0xb0, 0x3d, 0x02, 0xe3, 0xde, 0xd1, 0x7e,
-	0x64, 0x3d, 0x46, 0x5b, 0x8f, 0xd1, 0x49, 0xf3, 0x86, 0xa9, 0x7f, 0x7e, 0x39, 0x74, 0xbe, 0xfc,
-	0x18, 0x82, 0xf8, 0x8f, 0x08, 0x3d, 0x84, 0x36, 0xa9, 0xd0, 0x1d, 0xb5, 0xc7, 0xbd, 0xa3, 0x3b,
-	0x91, 0x0d, 0x51, 0xfb, 0xd2, 0x96, 0x62, 0xcb, 0x6a, 0x67, 0x55, 0x49, 0x65, 0xe8, 0x59, 0x67,
-	0xba, 0x46, 0x11, 0xec, 0x72, 0xa1, 0x2f, 0x2e, 0xc3, 0xc0, 0x88, 0xf7, 0xfe, 0x5b, 0x7d, 0xcc,
-	0xce, 0xe2, 0xed, 0x10, 0x7a, 0x00, 0xfb, 0x25, 0xaf, 0x64, 0x4a, 0xdf, 0x52, 0x46, 0x98, 0x2a,
-	0x43, 0x38, 0x6a, 0x8f, 0x83, 0xf8, 0xef, 0xe6, 0xcc, 0xf5, 0x3b, 0x03, 0x6f, 0xe6, 0xfa, 0xdd,
-	0x81, 0x3f, 0x73, 0x7d, 0x7f, 0x10, 0x1c, 0x6e, 0x5a, 0xd0, 0xdf, 0xfa, 0xd1, 0x46, 0xe8, 0xa9,
-	0x90, 0xdb, 0x88, 0x74, 0x8d, 0xee, 0x41, 0x4f, 0xd2, 0x94, 0xcb, 0x79, 0x93, 0x4f, 0x83, 0xd0,
-	0x1e, 0xec, 0x90, 0x82, 0x4a, 0x65, 0x92, 0x09, 0x62, 0x0b, 0xd0, 0x33, 0xd8, 0x5e, 0x70, 0x19,
-	0xba, 0xbb, 0xa7, 0xa5, 0xe7, 0xd1, 0x02, 0x7a, 0x05, 0x49, 0x68, 0x51, 0x86, 0x1d, 0xf3, 0xd8,
-	0xbb, 0x51, 0xca, 0xa5, 0xa2, 0xa7, 0x22, 0x89, 0x5e, 0xeb, 0xfe, 0x1b, 0x92, 0xcb, 0xe9, 0x73,
-	0xad, 0xf9, 0x7e, 0x39, 0x7c, 0xb2, 0xcb, 0x67, 0xb0, 0xba, 0xe3, 0x39, 0x11, 0x8a, 0xca, 0xb8,
-	0xb9, 0x1d, 0x09, 0xd8, 0x23, 0x8c, 0x71, 0x45, 0x6c, 0xb2, 0xde, 0xad, 0x2c, 0xbb, 0xb9, 0xc2,
-	0x64, 0xdd, 0x9f, 0xbe, 0x58, 0xad, 0xb1, 0x73, 0xb1, 0xc6, 0xce, 0xd5, 0x1a, 0x83, 0x4f, 0x35,
-	0x06, 0xdf, 0x6a, 0x0c, 0xce, 0x6b, 0x0c, 0x56, 0x35, 0x06, 0x3f, 0x6b, 0x0c, 0x7e, 0xd5, 0xd8,
-	0xb9, 0xaa, 0x31, 0xf8, 0xbc, 0xc1, 0xce, 0x6a, 0x83, 0x9d, 0x8b, 0x0d, 0x76, 0xde, 0x75, 0xcd,
-	0xf7, 0x10, 0x49, 0xe2, 0x99, 0x00, 0x9f, 0xfe, 0x0e, 0x00, 0x00, 0xff, 0xff, 0x99, 0x26, 0xa3,
-	0xae, 0x85, 0x03, 0x00, 0x00,
+	// 552 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x52, 0x3f, 0x6f, 0xd3, 0x40,
+	0x14, 0xb7, 0x1b, 0xc7, 0xb5, 0xcf, 0x44, 0xad, 0x8e, 0x0a, 0x5d, 0x2b, 0x74, 0x89, 0x2a, 0x90,
+	0xb2, 0xe0, 0x40, 0x11, 0x03, 0x03, 0x42, 0x8d, 0x22, 0xa1, 0x44, 0x0c, 0xc8, 0x30, 0xb1, 0x9d,
+	0x9d, 0x8b, 0xb1, 0x70, 0x7c, 0xc7, 0xd9, 0x2e, 0xcd, 0xc6, 0x47, 0x60, 0xe4, 0x23, 0xf0, 0x51,
+	0x3a, 0x66, 0xac, 0x90, 0x28, 0xc4, 0x59, 0x18, 0xfb, 0x11, 0xd0, 0xdd, 0x39, 0x34, 0x2d, 0x03,
+	0x59, 0x98, 0xee, 0xfd, 0xfb, 0xbd, 0xf7, 0xbb, 0xdf, 0x7b, 0xc0, 0x13, 0x65, 0x4a, 0x73, 0x9f,
+	0x0b, 0x56, 0x30, 0xd8, 0x54, 0xce, 0xc1, 0x83, 0x38, 0x29, 0xde, 0x95, 0xa1, 0x1f, 0xb1, 0x69,
+	0x2f, 0x66, 0x31, 0xeb, 0xa9, 0x6c, 0x58, 0x4e, 0x94, 0xa7, 0x1c, 0x65, 0x69, 0xd4, 0x01, 0x8e,
+	0x19, 0x8b, 0x53, 0x7a, 0x55, 0x35, 0x2e, 0x05, 0x29, 0x12, 0x96, 0xd5, 0xf9, 0xfd, 0x9b, 0x79,
+	0x92, 0xcd, 0xea, 0xd4, 0xc3, 0xf5, 0x49, 0x82, 0x4c, 0x48, 0x46, 0x7a, 0xd3, 0x64, 0x9a, 0x88,
+	0x1e, 0x7f, 0x1f, 0x6b, 0x8b, 0x87, 0xfa, 0xd5, 0x88, 0xc3, 0xef, 0x0d, 0xd0, 0x0a, 0xca, 0x94,
+	0xbe, 0x10, 0xac, 0xe4, 0x03, 0x9a, 0x47, 0x10, 0x02, 0x2b, 0x23, 0x53, 0x8a, 0xcc, 0x8e, 0xd9,
+	0x75, 0x03, 0x65, 0xc3, 0xbb, 0xc0, 0x95, 0x6f, 0xce, 0x49, 0x44, 0xd1, 0x96, 0x4a, 0x5c, 0x05,
+	0xe0, 0x73, 0xe0, 0x24, 0x59, 0x41, 0xc5, 0x09, 0x49, 0x51, 0xa3, 0x63, 0x76, 0xbd, 0xa3, 0x7d,
+	0x5f, 0x73, 0xf4, 0x57, 0x1c, 0xfd, 0x41, 0xfd, 0x87, 0xbe, 0x73, 0x76, 0xd1, 0x36, 0xbe, 0xfc,
+	0x68, 0x9b, 0xc1, 0x1f, 0x10, 0xbc, 0x0f, 0xb4, 0x52, 0xc8, 0xea, 0x34, 0xba, 0xde, 0xd1, 0x8e,
+	0xaf, 0x3c, 0x5f, 0xf2, 0x92, 0x94, 0x02, 0x9d, 0x95, 0xcc, 0xca, 0x9c, 0x0a, 0x64, 0x6b, 0x66,
+	0xd2, 0x86, 0x3e, 0xd8, 0x66, 0x5c, 0x36, 0xce, 0x91, 0xab, 0xc0, 0x7b, 0x7f, 0x8d, 0x3e, 0xce,
+	0x66, 0xc1, 0xaa, 0x08, 0xde, 0x03, 0xad, 0x9c, 0x95, 0x22, 0xa2, 0x6f, 0x68, 0x46, 0xb2, 0x22,
+	0x47, 0xa0, 0xd3, 0xe8, 0xba, 0xc1, 0xf5, 0x20, 0x1c, 0x82, 0xd6, 0x47, 0x91, 0x14, 0x74, 0xb8,
+	0xfa, 0x96, 0xb7, 0xf9, 0xb7, 0xae, 0x23, 0xe1, 0x10, 0xec, 0xd0, 0x13, 0x92, 0x96, 0xaa, 0x6c,
+	0x40, 0x53, 0x32, 0x43, 0xb7, 0xfe, 0xd5, 0xcc, 0x52, 0x8d, 0x6e, 0xe2, 0x60, 0x07, 0x78, 0x1f,
+	0x4a, 0x2a, 0x66, 0xaf, 0x15, 0x57, 0xd4, 0x52, 0x32, 0xac, 0x87, 0x46, 0x96, 0xd3, 0xdc, 0xb5,
+	0x47, 0x96, 0xb3, 0xbd, 0xeb, 0x8c, 0x2c, 0xc7, 0xd9, 0x75, 0x0f, 0x97, 0x5b, 0xc0, 0x59, 0xe9,
+	0x28, 0x05, 0xa4, 0xa7, 0x5c, 0xac, 0x56, 0x2b, 0x6d, 0x78, 0x07, 0xd8, 0x82, 0x46, 0x4c, 0x8c,
+	0xeb, 0xbd, 0xd6, 0x1e, 0xdc, 0x03, 0x4d, 0x92, 0x52, 0x51, 0xa8, 0x8d, 0xba, 0x81, 0x76, 0xe0,
+	0x13, 0xd0, 0x98, 0x30, 0x81, 0xac, 0xcd, 0xe5, 0x90, 0xf5, 0x70, 0x02, 0xec, 0x94, 0x84, 0x34,
+	0xcd, 0x51, 0x53, 0x2d, 0xe9, 0xb6, 0x1f, 0x31, 0x51, 0xd0, 0x53, 0x1e, 0xfa, 0x2f, 0x65, 0xfc,
+	0x15, 0x49, 0x44, 0xff, 0xa9, 0xc4, 0x7c, 0xbb, 0x68, 0x3f, 0xda, 0xe4, 0x88, 0x35, 0xee, 0x78,
+	0x4c, 0x78, 0x41, 0x45, 0x50, 0x77, 0x87, 0x1c, 0x78, 0x24, 0xcb, 0x58, 0x41, 0xf4, 0x45, 0xd8,
+	0xff, 0x65, 0xd8, 0xfa, 0x08, 0xa5, 0x75, 0xab, 0xff, 0x6c, 0xbe, 0xc0, 0xc6, 0xf9, 0x02, 0x1b,
+	0x97, 0x0b, 0x6c, 0x7e, 0xaa, 0xb0, 0xf9, 0xb5, 0xc2, 0xe6, 0x59, 0x85, 0xcd, 0x79, 0x85, 0xcd,
+	0x9f, 0x15, 0x36, 0x7f, 0x55, 0xd8, 0xb8, 0xac, 0xb0, 0xf9, 0x79, 0x89, 0x8d, 0xf9, 0x12, 0x1b,
+	0xe7, 0x4b, 0x6c, 0xbc, 0xdd, 0x56, 0x67, 0xcd, 0xc3, 0xd0, 0x56, 0x02, 0x3e, 0xfe, 0x3d, 0x00,
+	0x23, 0xd8, 0x1f, 0x5f, 0x3d, 0x04, 0x00, 0x00,
 }
 
 func (this *RuleGroupDesc) Equal(that interface{}) bool {
@@ -296,6 +323,21 @@ func (this *RuleGroupDesc) Equal(that interface{}) bool {
 			return false
 		}
 	}
+	if this.WriteInterval != that1.WriteInterval {
+		return false
+	}
+	if this.EvaluationDelay != nil && that1.EvaluationDelay != nil {
+		if *this.EvaluationDelay != *that1.EvaluationDelay {
+			return false
+		}
+	} else if this.EvaluationDelay != nil {
+		return false
+	} else if that1.EvaluationDelay != nil {
+		return false
+	}
+	if this.QuerySource != that1.QuerySource {
+		return false
+	}
 	return true
 }
 func (this *RuleDesc) Equal(that interface{}) bool {
@@ -351,7 +393,7 @@ func (this *RuleGroupDesc) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 11)
+	s := make([]string, 0, 14)
 	s = append(s, "&rulespb.RuleGroupDesc{")
 	s = append(s, "Name: "+fmt.Sprintf("%#v", this.Name)+",\n")
 	s = append(s, "Namespace: "+fmt.Sprintf("%#v", this.Namespace)+",\n")
@@ -364,6 +406,9 @@ func (this *RuleGroupDesc) GoString() string {
 		s = append(s, "Options: "+fmt.Sprintf("%#v", this.Options)+",\n")
 	}
 	s = append(s, "SourceTenants: "+fmt.Sprintf("%#v", this.SourceTenants)+",\n")
+	s = append(s, "WriteInterval: "+fmt.Sprintf("%#v", this.WriteInterval)+",\n")
+	s = append(s, "EvaluationDelay: "+fmt.Sprintf("%#v", this.EvaluationDelay)+",\n")
+	s = append(s, "QuerySource: "+fmt.Sprintf("%#v", this.QuerySource)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -410,6 +455,31 @@ func (m *RuleGroupDesc) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.QuerySource) > 0 {
+		i -= len(m.QuerySource)
+		copy(dAtA[i:], m.QuerySource)
+		i = encodeVarintRules(dAtA, i, uint64(len(m.QuerySource)))
+		i--
+		dAtA[i] = 0x6a
+	}
+	if m.EvaluationDelay != nil {
+		n1, err1 := github_com_gogo_protobuf_types.StdDurationMarshalTo(*m.EvaluationDelay, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(*m.EvaluationDelay):])
+		if err1 != nil {
+			return 0, err1
+		}
+		i -= n1
+		i = encodeVarintRules(dAtA, i, uint64(n1))
+		i--
+		dAtA[i] = 0x62
+	}
+	n2, err2 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.WriteInterval, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.WriteInterval):])
+	if err2 != nil {
+		return 0, err2
+	}
+	i -= n2
+	i = encodeVarintRules(dAtA, i, uint64(n2))
+	i--
+	dAtA[i] = 0x5a
 	if len(m.SourceTenants) > 0 {
 		for iNdEx := len(m.SourceTenants) - 1; iNdEx >= 0; iNdEx-- {
 			i -= len(m.SourceTenants[iNdEx])
@@ -454,12 +524,12 @@ func (m *RuleGroupDesc) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 			dAtA[i] = 0x22
 		}
 	}
-	n1, err1 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.Interval, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.Interval):])
-	if err1 != nil {
-		return 0, err1
+	n3, err3 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.Interval, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.Interval):])
+	if err3 != nil {
+		return 0, err3
 	}
-	i -= n1
-	i = encodeVarintRules(dAtA, i, uint64(n1))
+	i -= n3
+	i = encodeVarintRules(dAtA, i, uint64(n3))
 	i--
 	dAtA[i] = 0x1a
 	if len(m.Namespace) > 0 {
@@ -527,12 +597,12 @@ func (m *RuleDesc) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 			dAtA[i] = 0x2a
 		}
 	}
-	n2, err2 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.For, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.For):])
-	if err2 != nil {
-		return 0, err2
+	n4, err4 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.For, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.For):])
+	if err4 != nil {
+		return 0, err4
 	}
-	i -= n2
-	i = encodeVarintRules(dAtA, i, uint64(n2))
+	i -= n4
+	i = encodeVarintRules(dAtA, i, uint64(n4))
 	i--
 	dAtA[i] = 0x22
 	if len(m.Alert) > 0 {
@@ -608,6 +678,16 @@ func (m *RuleGroupDesc) Size() (n int) {
 			n += 1 + l + sovRules(uint64(l))
 		}
 	}
+	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.WriteInterval)
+	n += 1 + l + sovRules(uint64(l))
+	if m.EvaluationDelay != nil {
+		l = github_com_gogo_protobuf_types.SizeOfStdDuration(*m.EvaluationDelay)
+		n += 1 + l + sovRules(uint64(l))
+	}
+	l = len(m.QuerySource)
+	if l > 0 {
+		n += 1 + l + sovRules(uint64(l))
+	}
 	return n
 }
 
@@ -674,6 +754,9 @@ func (this *RuleGroupDesc) String() string {
 		`User:` + fmt.Sprintf("%v", this.User) + `,`,
 		`Options:` + repeatedStringForOptions + `,`,
 		`SourceTenants:` + fmt.Sprintf("%v", this.SourceTenants) + `,`,
+		`WriteInterval:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.WriteInterval), "Duration", "duration.Duration", 1), `&`, ``, 1) + `,`,
+		`EvaluationDelay:` + strings.Replace(fmt.Sprintf("%v", this.EvaluationDelay), "Duration", "duration.Duration", 1) + `,`,
+		`QuerySource:` + fmt.Sprintf("%v", this.QuerySource) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -959,6 +1042,107 @@ func (m *RuleGroupDesc) Unmarshal(dAtA []byte) error {
 			}
 			m.SourceTenants = append(m.SourceTenants, string(dAtA[iNdEx:postIndex]))
 			iNdEx = postIndex
+		case 11:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field WriteInterval", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRules
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRules
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRules
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdDurationUnmarshal(&m.WriteInterval, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 12:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EvaluationDelay", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRules
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRules
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRules
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.EvaluationDelay == nil {
+				m.EvaluationDelay = new(time.Duration)
+			}
+			if err := github_com_gogo_protobuf_types.StdDurationUnmarshal(m.EvaluationDelay, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 13:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field QuerySource", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRules
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRules
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRules
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.QuerySource = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRules(dAtA[iNdEx:])
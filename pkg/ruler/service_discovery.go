@@ -4,8 +4,12 @@ package ruler
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
 	"net/url"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -18,9 +22,20 @@ import (
 )
 
 const (
-	mechanismName = "dns_sd"
+	mechanismName     = "dns_sd"
+	httpMechanismName = "http_sd"
+
+	// httpSDQType is the prefix of the Alertmanager URLs discovered via HTTP service discovery.
+	httpSDQType = dns.QType("http_sd")
+
+	// The maximum size of the HTTP service discovery response.
+	maxHTTPSDResponseSize = 10 << 20
 )
 
+// The resolver only keeps the addresses of the last resolution, so resolutions
+// of different hosts must not interleave.
+var dnsResolveMtx sync.Mutex
+
 type dnsServiceDiscovery struct {
 	Resolver cacheutil.AddressProvider
 
@@ -38,11 +53,15 @@ func (c dnsServiceDiscovery) NewDiscoverer(opts discovery.DiscovererOptions) (di
 }
 
 func (c dnsServiceDiscovery) resolve(ctx context.Context) ([]*targetgroup.Group, error) {
+	dnsResolveMtx.Lock()
 	if err := c.Resolver.Resolve(ctx, []string{string(c.QType) + "+" + c.Host}); err != nil {
+		dnsResolveMtx.Unlock()
 		return nil, err
 	}
 
 	resolved := c.Resolver.Addresses()
+	dnsResolveMtx.Unlock()
+
 	targets := make([]model.LabelSet, len(resolved))
 	for i, r := range resolved {
 		targets[i] = model.LabelSet{
@@ -58,6 +77,58 @@ func (c dnsServiceDiscovery) resolve(ctx context.Context) ([]*targetgroup.Group,
 	return []*targetgroup.Group{tg}, nil
 }
 
+// httpServiceDiscovery discovers the Alertmanagers from an HTTP endpoint returning
+// the target groups in the Prometheus HTTP service discovery format.
+type httpServiceDiscovery struct {
+	Client *http.Client
+
+	RefreshInterval time.Duration
+	URL             string
+}
+
+func (httpServiceDiscovery) Name() string {
+	return httpMechanismName
+}
+
+func (c httpServiceDiscovery) NewDiscoverer(opts discovery.DiscovererOptions) (discovery.Discoverer, error) {
+	return refresh.NewDiscovery(opts.Logger, httpMechanismName, c.RefreshInterval, c.resolve), nil
+}
+
+func (c httpServiceDiscovery) resolve(ctx context.Context) ([]*targetgroup.Group, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("server returned HTTP status %s", resp.Status)
+	}
+
+	var groups []*targetgroup.Group
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxHTTPSDResponseSize)).Decode(&groups); err != nil {
+		return nil, errors.Wrap(err, "unable to decode the HTTP service discovery response")
+	}
+
+	for i, tg := range groups {
+		if tg == nil {
+			return nil, errors.New("nil target group in the HTTP service discovery response")
+		}
+		tg.Source = fmt.Sprintf("%s:%d", c.URL, i)
+	}
+
+	return groups, nil
+}
+
 func dnsSD(rulerConfig *Config, resolver cacheutil.AddressProvider, qType dns.QType, url *url.URL) discovery.Config {
 	return dnsServiceDiscovery{
 		Resolver:        resolver,
@@ -67,6 +138,14 @@ func dnsSD(rulerConfig *Config, resolver cacheutil.AddressProvider, qType dns.QT
 	}
 }
 
+func httpSD(rulerConfig *Config, url *url.URL) discovery.Config {
+	return httpServiceDiscovery{
+		Client:          &http.Client{Timeout: rulerConfig.NotificationTimeout},
+		RefreshInterval: rulerConfig.AlertmanagerRefreshInterval,
+		URL:             url.String(),
+	}
+}
+
 func staticTarget(url *url.URL) discovery.Config {
 	return discovery.StaticConfig{
 		{
@@ -80,7 +159,7 @@ func sanitizedAlertmanagerURL(amURL string) (isServiceDiscovery bool, qType dns.
 	qType = dns.QType(rawQType)
 
 	switch qType {
-	case "", dns.A, dns.SRV, dns.SRVNoA:
+	case "", dns.A, dns.SRV, dns.SRVNoA, httpSDQType:
 	default:
 		err = errors.Errorf("invalid DNS service discovery prefix %q", qType)
 		return
@@ -4,6 +4,8 @@ package ruler
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -142,6 +144,84 @@ func TestConfig_ConstructsLookupNamesCorrectly(t *testing.T) {
 	}
 }
 
+func TestHTTPServiceDiscovery(t *testing.T) {
+	testCases := map[string]struct {
+		status   int
+		response string
+
+		expectedTargetGroups []*targetgroup.Group
+		expectedErr          bool
+	}{
+		"happy flow": {
+			status:   http.StatusOK,
+			response: `[{"targets": ["am-1:9093", "am-2:9093"], "labels": {"zone": "a"}}, {"targets": ["am-3:9093"]}]`,
+			expectedTargetGroups: []*targetgroup.Group{
+				{
+					Targets: []model.LabelSet{
+						{model.AddressLabel: "am-1:9093"},
+						{model.AddressLabel: "am-2:9093"},
+					},
+					Labels: model.LabelSet{"zone": "a"},
+					Source: "/sd:0",
+				},
+				{
+					Targets: []model.LabelSet{
+						{model.AddressLabel: "am-3:9093"},
+					},
+					Source: "/sd:1",
+				},
+			},
+		},
+		"no target groups": {
+			status:               http.StatusOK,
+			response:             `[]`,
+			expectedTargetGroups: []*targetgroup.Group{},
+		},
+		"unexpected status code": {
+			status:      http.StatusInternalServerError,
+			expectedErr: true,
+		},
+		"invalid response": {
+			status:      http.StatusOK,
+			response:    `{"targets": ["am-1:9093"]}`,
+			expectedErr: true,
+		},
+		"nil target group": {
+			status:      http.StatusOK,
+			response:    `[null]`,
+			expectedErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.response))
+			}))
+			t.Cleanup(srv.Close)
+
+			cfg := httpServiceDiscovery{
+				Client:          srv.Client(),
+				RefreshInterval: time.Minute,
+				URL:             srv.URL + "/sd",
+			}
+
+			groups, err := cfg.resolve(context.Background())
+			if tc.expectedErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			for _, g := range tc.expectedTargetGroups {
+				g.Source = srv.URL + g.Source
+			}
+			assert.Equal(t, tc.expectedTargetGroups, groups)
+		})
+	}
+}
+
 type mockResolver struct {
 	mock.Mock
 }
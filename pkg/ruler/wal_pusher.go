@@ -0,0 +1,412 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ruler
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/backoff"
+	"github.com/grafana/dskit/services"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/tsdb/wal"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+const (
+	// walTruncateInterval is how frequently the WAL segments holding pushed write requests are removed.
+	walTruncateInterval = time.Minute
+
+	// walStopPushTimeout is how long the pending write requests are pushed for when stopping.
+	walStopPushTimeout = 10 * time.Second
+
+	walDroppedReasonRejected = "rejected"
+	walDroppedReasonTooOld   = "too_old"
+)
+
+var (
+	errInvalidWALDir     = errors.New("invalid ruler WAL directory, must not be empty")
+	errInvalidWALBackoff = errors.New("invalid ruler WAL backoff, the min backoff must be greater than zero and not greater than the max backoff")
+	errInvalidWALMaxAge  = errors.New("invalid ruler WAL max age, must be greater than zero")
+)
+
+// WALConfig configures the local WAL buffering the results of the rules evaluation before they're written.
+type WALConfig struct {
+	Enabled    bool          `yaml:"enabled" category:"experimental"`
+	Dir        string        `yaml:"dir" category:"experimental"`
+	MinBackoff time.Duration `yaml:"min_backoff" category:"experimental"`
+	MaxBackoff time.Duration `yaml:"max_backoff" category:"experimental"`
+	MaxAge     time.Duration `yaml:"max_age" category:"experimental"`
+}
+
+func (cfg *WALConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "ruler.wal.enabled", false, "Log the results of the rules evaluation to a local WAL, and write them asynchronously to the ingesters, retrying with backoff on failures. When disabled, the results are written synchronously at the end of each evaluation, and lost if the write fails.")
+	f.StringVar(&cfg.Dir, "ruler.wal.dir", "./data-ruler-wal/", "Directory to store the WAL of each tenant. The write requests not written yet when the ruler restarts are written once the tenant's rules are evaluated by this ruler again, so this directory should be persisted between restarts.")
+	f.DurationVar(&cfg.MinBackoff, "ruler.wal.min-backoff", 100*time.Millisecond, "Minimum backoff between retries of a failed write.")
+	f.DurationVar(&cfg.MaxBackoff, "ruler.wal.max-backoff", 10*time.Second, "Maximum backoff between retries of a failed write.")
+	f.DurationVar(&cfg.MaxAge, "ruler.wal.max-age", time.Hour, "Maximum age of the samples of a write request. The write requests with older samples, which the ingesters would reject, are dropped instead of retried.")
+}
+
+func (cfg *WALConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.Dir == "" {
+		return errInvalidWALDir
+	}
+	if cfg.MinBackoff <= 0 || cfg.MinBackoff > cfg.MaxBackoff {
+		return errInvalidWALBackoff
+	}
+	if cfg.MaxAge <= 0 {
+		return errInvalidWALMaxAge
+	}
+	return nil
+}
+
+type walPusherMetrics struct {
+	// The write requests to the ingesters.
+	totalWrites  prometheus.Counter
+	failedWrites prometheus.Counter
+
+	// The write requests logged to the WAL, tracked by the PusherAppender.
+	totalLogged  prometheus.Counter
+	failedLogged prometheus.Counter
+
+	pendingRequests prometheus.Gauge
+	droppedRequests *prometheus.CounterVec
+}
+
+func newWALPusherMetrics(reg prometheus.Registerer, totalWrites, failedWrites prometheus.Counter) *walPusherMetrics {
+	return &walPusherMetrics{
+		totalWrites:  totalWrites,
+		failedWrites: failedWrites,
+		totalLogged: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_ruler_wal_write_requests_total",
+			Help: "Number of write requests logged to the ruler WAL.",
+		}),
+		failedLogged: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_ruler_wal_write_requests_failed_total",
+			Help: "Number of write requests which failed to be logged to the ruler WAL.",
+		}),
+		pendingRequests: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_ruler_wal_pending_write_requests",
+			Help: "Number of write requests logged to the ruler WAL and not written to the ingesters yet.",
+		}),
+		droppedRequests: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ruler_wal_dropped_write_requests_total",
+			Help: "Number of write requests logged to the ruler WAL which have been dropped without being written to the ingesters.",
+		}, []string{"reason"}),
+	}
+}
+
+// walEntry is a write request logged to the WAL and not pushed yet.
+type walEntry struct {
+	record  []byte
+	segment int
+
+	// The timestamp of the oldest sample of the write request, in milliseconds.
+	minTimestamp int64
+}
+
+// walPusher is a Pusher which logs the write requests of a tenant to a local WAL, and pushes them
+// asynchronously to the wrapped Pusher in the same order, retrying with backoff on failures.
+// The write requests rejected with a 4xx status code are not retried.
+type walPusher struct {
+	services.Service
+
+	cfg     WALConfig
+	userID  string
+	dir     string
+	pusher  Pusher
+	metrics *walPusherMetrics
+	logger  log.Logger
+
+	stopPushTimeout time.Duration
+
+	// The lock protects the entries and keeps them in the same order as the WAL records.
+	mtx     sync.Mutex
+	wal     *wal.WAL
+	entries []walEntry
+
+	notify chan struct{}
+}
+
+func newWALPusher(cfg WALConfig, userID string, pusher Pusher, metrics *walPusherMetrics, logger log.Logger) *walPusher {
+	w := &walPusher{
+		cfg:             cfg,
+		userID:          userID,
+		dir:             filepath.Join(cfg.Dir, userID),
+		pusher:          pusher,
+		metrics:         metrics,
+		logger:          log.With(logger, "user", userID),
+		stopPushTimeout: walStopPushTimeout,
+		notify:          make(chan struct{}, 1),
+	}
+
+	w.Service = services.NewBasicService(w.starting, w.running, w.stopping)
+	return w
+}
+
+func (w *walPusher) starting(_ context.Context) error {
+	// The write requests left in the WAL by a previous run are pushed first.
+	if err := w.replay(); err != nil {
+		level.Warn(w.logger).Log("msg", "failed to replay the ruler WAL, some write requests may be lost", "err", err)
+	}
+
+	var err error
+	w.wal, err = wal.NewSize(w.logger, nil, w.dir, wal.DefaultSegmentSize, false)
+	if err != nil {
+		return errors.Wrap(err, "failed to open the ruler WAL")
+	}
+
+	if len(w.entries) > 0 {
+		level.Info(w.logger).Log("msg", "replayed the ruler WAL", "write_requests", len(w.entries))
+		w.metrics.pendingRequests.Add(float64(len(w.entries)))
+		w.notifyPending()
+	}
+	return nil
+}
+
+func (w *walPusher) replay() error {
+	first, _, err := wal.Segments(w.dir)
+	if os.IsNotExist(err) || (err == nil && first < 0) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	segments, err := wal.NewSegmentsReader(w.dir)
+	if err != nil {
+		return err
+	}
+	defer segments.Close() //nolint:errcheck
+
+	r := wal.NewReader(segments)
+	for r.Next() {
+		// The reader reuses its buffer for the next record.
+		record := append([]byte(nil), r.Record()...)
+
+		req := mimirpb.WriteRequest{}
+		if err := req.Unmarshal(record); err != nil {
+			return err
+		}
+		w.entries = append(w.entries, walEntry{record: record, segment: r.Segment(), minTimestamp: minSampleTimestamp(&req)})
+	}
+	return r.Err()
+}
+
+func (w *walPusher) running(ctx context.Context) error {
+	truncateTicker := time.NewTicker(walTruncateInterval)
+	defer truncateTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-w.notify:
+			w.pushPending(ctx)
+		case <-truncateTicker.C:
+			if err := w.truncate(); err != nil {
+				level.Warn(w.logger).Log("msg", "failed to truncate the ruler WAL", "err", err)
+			}
+		}
+	}
+}
+
+func (w *walPusher) stopping(_ error) error {
+	// The pending write requests are pushed before stopping, so that they're not left
+	// in the WAL if this ruler doesn't evaluate the tenant's rules anymore.
+	ctx, cancel := context.WithTimeout(context.Background(), w.stopPushTimeout)
+	w.pushPending(ctx)
+	cancel()
+
+	w.mtx.Lock()
+	pending := len(w.entries)
+	w.metrics.pendingRequests.Sub(float64(pending))
+	w.entries = nil
+	w.mtx.Unlock()
+
+	if err := w.wal.Close(); err != nil {
+		return errors.Wrap(err, "failed to close the ruler WAL")
+	}
+
+	if pending > 0 {
+		level.Warn(w.logger).Log("msg", "write requests left in the ruler WAL, they will be written once the tenant's rules are evaluated by this ruler again", "write_requests", pending)
+		return nil
+	}
+	return os.RemoveAll(w.dir)
+}
+
+// Push logs the write request to the WAL. The write request is pushed asynchronously.
+func (w *walPusher) Push(_ context.Context, req *mimirpb.WriteRequest) (*mimirpb.WriteResponse, error) {
+	// The wrapped Pusher returns the slice to the pool once pushed, while it's not used after being logged.
+	defer mimirpb.ReuseSlice(req.Timeseries)
+
+	if len(req.Timeseries) == 0 {
+		return &mimirpb.WriteResponse{}, nil
+	}
+
+	if err := w.log(req); err != nil {
+		return nil, errors.Wrap(err, "failed to log the write request to the ruler WAL")
+	}
+
+	w.notifyPending()
+	return &mimirpb.WriteResponse{}, nil
+}
+
+func (w *walPusher) log(req *mimirpb.WriteRequest) error {
+	record, err := req.Marshal()
+	if err != nil {
+		return err
+	}
+
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	if err := w.wal.Log(record); err != nil {
+		return err
+	}
+	segment, _, err := w.wal.LastSegmentAndOffset()
+	if err != nil {
+		return err
+	}
+
+	w.entries = append(w.entries, walEntry{record: record, segment: segment, minTimestamp: minSampleTimestamp(req)})
+	w.metrics.pendingRequests.Inc()
+	return nil
+}
+
+func (w *walPusher) notifyPending() {
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+// pushPending pushes the pending write requests in order, until there are none left or the context is done.
+func (w *walPusher) pushPending(ctx context.Context) {
+	for ctx.Err() == nil {
+		w.mtx.Lock()
+		if len(w.entries) == 0 {
+			w.mtx.Unlock()
+			return
+		}
+		entry := w.entries[0]
+		w.mtx.Unlock()
+
+		if !w.push(ctx, entry) {
+			return
+		}
+
+		w.mtx.Lock()
+		w.entries = w.entries[1:]
+		w.metrics.pendingRequests.Dec()
+		w.mtx.Unlock()
+	}
+}
+
+// push pushes the write request of the entry, retrying with backoff on failures. It returns false if
+// the context is done before the write request has been either pushed or dropped.
+func (w *walPusher) push(ctx context.Context, entry walEntry) bool {
+	b := backoff.New(ctx, backoff.Config{
+		MinBackoff: w.cfg.MinBackoff,
+		MaxBackoff: w.cfg.MaxBackoff,
+	})
+
+	for b.Ongoing() {
+		if time.Since(time.UnixMilli(entry.minTimestamp)) > w.cfg.MaxAge {
+			w.metrics.droppedRequests.WithLabelValues(walDroppedReasonTooOld).Inc()
+			return true
+		}
+
+		// The request is unmarshalled on each attempt, because the wrapped Pusher returns its slice to the pool.
+		req := &mimirpb.WriteRequest{}
+		if err := req.Unmarshal(entry.record); err != nil {
+			level.Error(w.logger).Log("msg", "failed to decode a write request of the ruler WAL", "err", err)
+			return true
+		}
+
+		w.metrics.totalWrites.Inc()
+		_, err := w.pusher.Push(user.InjectOrgID(ctx, w.userID), req)
+		if err == nil {
+			return true
+		}
+
+		// Don't retry errors that ended with 4xx HTTP status code (series limits, duplicate samples, out of order, etc.)
+		if resp, ok := httpgrpc.HTTPResponseFromError(err); ok && resp.Code/100 == 4 {
+			w.metrics.droppedRequests.WithLabelValues(walDroppedReasonRejected).Inc()
+			return true
+		}
+
+		w.metrics.failedWrites.Inc()
+		level.Warn(w.logger).Log("msg", "failed to write the results of the rules evaluation, retrying", "retries", b.NumRetries(), "err", err)
+		b.Wait()
+	}
+
+	return false
+}
+
+// truncate removes the WAL segments whose write requests have all been pushed.
+func (w *walPusher) truncate() error {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	if len(w.entries) > 0 {
+		return w.wal.Truncate(w.entries[0].segment)
+	}
+
+	// All the write requests have been pushed, so a new segment is cut to remove the current one too.
+	segment, offset, err := w.wal.LastSegmentAndOffset()
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		if err := w.wal.NextSegment(); err != nil {
+			return err
+		}
+		segment++
+	}
+	return w.wal.Truncate(segment)
+}
+
+// minSampleTimestamp returns the timestamp of the oldest sample of the write request.
+func minSampleTimestamp(req *mimirpb.WriteRequest) int64 {
+	var (
+		minTimestamp int64
+		found        bool
+	)
+	for _, ts := range req.Timeseries {
+		for _, s := range ts.Samples {
+			if !found || s.TimestampMs < minTimestamp {
+				minTimestamp, found = s.TimestampMs, true
+			}
+		}
+	}
+	return minTimestamp
+}
+
+// walRulesManager is a RulesManager which stops the tenant's walPusher once the rules manager is stopped.
+type walRulesManager struct {
+	RulesManager
+	pusher *walPusher
+}
+
+func (m *walRulesManager) Stop() {
+	m.RulesManager.Stop()
+
+	if err := services.StopAndAwaitTerminated(context.Background(), m.pusher); err != nil {
+		level.Warn(m.pusher.logger).Log("msg", "failed to stop the ruler WAL", "err", err)
+	}
+}
@@ -0,0 +1,194 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ruler
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/test"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb/wal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+func TestWALConfig_Validate(t *testing.T) {
+	cfg := WALConfig{}
+	cfg.RegisterFlags(flag.NewFlagSet("", flag.PanicOnError))
+	require.NoError(t, cfg.Validate())
+
+	cfg.Enabled = true
+	require.NoError(t, cfg.Validate())
+
+	cfg.MinBackoff = 2 * cfg.MaxBackoff
+	assert.Equal(t, errInvalidWALBackoff, cfg.Validate())
+
+	cfg.MinBackoff = 0
+	assert.Equal(t, errInvalidWALBackoff, cfg.Validate())
+
+	cfg.MinBackoff = time.Millisecond
+	cfg.MaxAge = 0
+	assert.Equal(t, errInvalidWALMaxAge, cfg.Validate())
+
+	cfg.MaxAge = time.Hour
+	cfg.Dir = ""
+	assert.Equal(t, errInvalidWALDir, cfg.Validate())
+}
+
+func TestWALPusher(t *testing.T) {
+	now := time.Now()
+
+	t.Run("should push the write requests in order, retrying on failures", func(t *testing.T) {
+		p := &recordingPusher{failures: 2, err: errors.New("unavailable")}
+		w, metrics := startWALPusher(t, t.TempDir(), p)
+
+		pushToWALPusher(t, w, now, 1)
+		pushToWALPusher(t, w, now, 2)
+
+		test.Poll(t, time.Second, []float64{1, 2}, func() interface{} {
+			return p.pushedValues()
+		})
+		test.Poll(t, time.Second, float64(0), func() interface{} {
+			return testutil.ToFloat64(metrics.pendingRequests)
+		})
+		assert.Equal(t, float64(4), testutil.ToFloat64(metrics.totalWrites))
+		assert.Equal(t, float64(2), testutil.ToFloat64(metrics.failedWrites))
+
+		// All the write requests have been pushed, so only the new segment is left once truncated.
+		require.NoError(t, w.truncate())
+		first, last, err := wal.Segments(w.dir)
+		require.NoError(t, err)
+		assert.Equal(t, first, last)
+	})
+
+	t.Run("should drop the write requests rejected with a 4xx status code", func(t *testing.T) {
+		p := &recordingPusher{failures: 1, err: httpgrpc.Errorf(http.StatusBadRequest, "out of order sample")}
+		w, metrics := startWALPusher(t, t.TempDir(), p)
+
+		pushToWALPusher(t, w, now, 1)
+		pushToWALPusher(t, w, now, 2)
+
+		test.Poll(t, time.Second, []float64{2}, func() interface{} {
+			return p.pushedValues()
+		})
+		assert.Equal(t, float64(1), testutil.ToFloat64(metrics.droppedRequests.WithLabelValues(walDroppedReasonRejected)))
+		assert.Equal(t, float64(0), testutil.ToFloat64(metrics.failedWrites))
+	})
+
+	t.Run("should drop the write requests older than the max age", func(t *testing.T) {
+		p := &recordingPusher{}
+		w, metrics := startWALPusher(t, t.TempDir(), p)
+
+		pushToWALPusher(t, w, now.Add(-2*time.Hour), 1)
+		pushToWALPusher(t, w, now, 2)
+
+		test.Poll(t, time.Second, []float64{2}, func() interface{} {
+			return p.pushedValues()
+		})
+		assert.Equal(t, float64(1), testutil.ToFloat64(metrics.droppedRequests.WithLabelValues(walDroppedReasonTooOld)))
+	})
+
+	t.Run("should push the write requests left in the WAL on restart", func(t *testing.T) {
+		dir := t.TempDir()
+
+		failing := &recordingPusher{failures: -1, err: errors.New("unavailable")}
+		w, _ := startWALPusher(t, dir, failing)
+		pushToWALPusher(t, w, now, 1)
+		require.NoError(t, services.StopAndAwaitTerminated(context.Background(), w))
+		assert.Empty(t, failing.pushedValues())
+
+		p := &recordingPusher{}
+		w, _ = startWALPusher(t, dir, p)
+		test.Poll(t, time.Second, []float64{1}, func() interface{} {
+			return p.pushedValues()
+		})
+
+		// The tenant's WAL is removed once stopped without pending write requests.
+		require.NoError(t, services.StopAndAwaitTerminated(context.Background(), w))
+		_, err := os.Stat(w.dir)
+		assert.True(t, os.IsNotExist(err))
+	})
+}
+
+func startWALPusher(t *testing.T, dir string, p Pusher) (*walPusher, *walPusherMetrics) {
+	cfg := WALConfig{}
+	cfg.RegisterFlags(flag.NewFlagSet("", flag.PanicOnError))
+	cfg.Enabled = true
+	cfg.Dir = dir
+	cfg.MinBackoff = time.Millisecond
+	cfg.MaxBackoff = 10 * time.Millisecond
+
+	reg := prometheus.NewPedanticRegistry()
+	metrics := newWALPusherMetrics(reg, prometheus.NewCounter(prometheus.CounterOpts{Name: "total"}), prometheus.NewCounter(prometheus.CounterOpts{Name: "failed"}))
+
+	w := newWALPusher(cfg, "user", p, metrics, log.NewNopLogger())
+	w.stopPushTimeout = 50 * time.Millisecond
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), w))
+	t.Cleanup(func() {
+		_ = services.StopAndAwaitTerminated(context.Background(), w)
+	})
+	return w, metrics
+}
+
+func pushToWALPusher(t *testing.T, w *walPusher, ts time.Time, value float64) {
+	req := mimirpb.ToWriteRequest(
+		[]labels.Labels{labels.FromStrings(labels.MetricName, "metric")},
+		[]mimirpb.Sample{{TimestampMs: ts.UnixMilli(), Value: value}},
+		nil, nil, mimirpb.RULE)
+	_, err := w.Push(context.Background(), req)
+	require.NoError(t, err)
+}
+
+// recordingPusher fails the first pushes, or all of them if failures is negative, and records the
+// values of the samples pushed successfully.
+type recordingPusher struct {
+	mtx      sync.Mutex
+	failures int
+	err      error
+	values   []float64
+}
+
+func (p *recordingPusher) Push(ctx context.Context, req *mimirpb.WriteRequest) (*mimirpb.WriteResponse, error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if _, err := user.ExtractOrgID(ctx); err != nil {
+		return nil, errors.New("missing tenant")
+	}
+
+	if p.failures != 0 {
+		if p.failures > 0 {
+			p.failures--
+		}
+		return nil, p.err
+	}
+
+	for _, ts := range req.Timeseries {
+		for _, s := range ts.Samples {
+			p.values = append(p.values, s.Value)
+		}
+	}
+	return &mimirpb.WriteResponse{}, nil
+}
+
+func (p *recordingPusher) pushedValues() []float64 {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	return append([]float64{}, p.values...)
+}
@@ -0,0 +1,124 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ruler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/rules"
+
+	"github.com/grafana/mimir/pkg/ruler/rulespb"
+)
+
+const (
+	groupWriteIntervalsKey contextKey = 2
+	evaluatedGroupKey      contextKey = 3
+
+	// The names of the series written by the alerting rules, which are not affected by the write interval.
+	alertMetricName         = "ALERTS"
+	alertForStateMetricName = "ALERTS_FOR_STATE"
+)
+
+// groupWriteIntervals holds the write interval of a tenant's rule groups, keyed by rules.GroupKey().
+type groupWriteIntervals struct {
+	mtx       sync.RWMutex
+	intervals map[string]time.Duration
+}
+
+func newGroupWriteIntervals() *groupWriteIntervals {
+	return &groupWriteIntervals{intervals: map[string]time.Duration{}}
+}
+
+// set replaces the write intervals with the ones of the input groups, mapped to disk by the mapper.
+func (w *groupWriteIntervals) set(m *mapper, user string, groups rulespb.RuleGroupList) {
+	intervals := map[string]time.Duration{}
+	for _, g := range groups {
+		if g.GetWriteInterval() > 0 {
+			intervals[rules.GroupKey(m.filename(user, g.GetNamespace()), g.GetName())] = g.GetWriteInterval()
+		}
+	}
+
+	w.mtx.Lock()
+	w.intervals = intervals
+	w.mtx.Unlock()
+}
+
+func (w *groupWriteIntervals) get(key string) time.Duration {
+	w.mtx.RLock()
+	defer w.mtx.RUnlock()
+
+	return w.intervals[key]
+}
+
+// evaluatedGroup identifies the rule group being evaluated.
+type evaluatedGroup struct {
+	key      string
+	interval time.Duration
+}
+
+func contextWithGroupWriteIntervals(ctx context.Context, w *groupWriteIntervals) context.Context {
+	return context.WithValue(ctx, groupWriteIntervalsKey, w)
+}
+
+// WriteIntervalGroupContextFunc injects the rule group being evaluated in to the context,
+// to be used by the PusherAppender to look up the group's write interval, and by the
+// QuerySourceQueryFunc to look up the group's query source.
+func WriteIntervalGroupContextFunc(ctx context.Context, g *rules.Group) context.Context {
+	return context.WithValue(ctx, evaluatedGroupKey, evaluatedGroup{
+		key:      rules.GroupKey(g.File(), g.Name()),
+		interval: g.Interval(),
+	})
+}
+
+// groupWriteIntervalFromContext returns the write interval and the evaluation interval of the rule group
+// being evaluated. The returned write interval is 0 if the results of every evaluation are written.
+func groupWriteIntervalFromContext(ctx context.Context) (writeInterval, evalInterval time.Duration) {
+	w, ok := ctx.Value(groupWriteIntervalsKey).(*groupWriteIntervals)
+	if !ok {
+		return 0, 0
+	}
+	g, ok := ctx.Value(evaluatedGroupKey).(evaluatedGroup)
+	if !ok {
+		return 0, 0
+	}
+	return w.get(g.key), g.interval
+}
+
+// shouldWriteSample returns whether a sample with timestamp t (in milliseconds) must be written, given
+// the write interval and the evaluation interval of the rule group. Only the first evaluation within
+// each write interval is written. Staleness markers and the series of the alerting rules are always written.
+func shouldWriteSample(l labels.Labels, t int64, v float64, writeInterval, evalInterval time.Duration) bool {
+	if writeInterval <= evalInterval || value.IsStaleNaN(v) {
+		return true
+	}
+
+	if name := l.Get(labels.MetricName); name == alertMetricName || name == alertForStateMetricName {
+		return true
+	}
+
+	return t%writeInterval.Milliseconds() < evalInterval.Milliseconds()
+}
+
+// validateWriteInterval checks that the write interval of the rule group is a multiple of its evaluation interval.
+func validateWriteInterval(rg rulespb.RuleGroup, defaultEvalInterval time.Duration) error {
+	writeInterval := time.Duration(rg.WriteInterval)
+	if writeInterval == 0 {
+		return nil
+	}
+
+	evalInterval := time.Duration(rg.Interval)
+	if evalInterval == 0 {
+		evalInterval = defaultEvalInterval
+	}
+
+	if writeInterval < 0 || evalInterval <= 0 || writeInterval%evalInterval != 0 {
+		return fmt.Errorf("invalid rules config: rule group '%s' write interval %s must be a multiple of the evaluation interval %s", rg.Name, writeInterval, evalInterval)
+	}
+
+	return nil
+}
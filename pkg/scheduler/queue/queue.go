@@ -100,6 +100,7 @@ func (q *RequestQueue) EnqueueRequest(userID string, req Request, maxQueriers in
 
 	select {
 	case queue <- req:
+		q.queues.markEnqueued(userID, time.Now())
 		q.queueLength.WithLabelValues(userID).Inc()
 		q.cond.Broadcast()
 		// Call this function while holding a lock. This guarantees that no querier can fetch the request before function returns.
@@ -147,6 +148,7 @@ FindQueue:
 		// Pick next request from the queue.
 		for {
 			request := <-queue
+			q.queues.markDequeued(userID)
 			if len(queue) == 0 {
 				q.queues.deleteQueue(userID)
 			}
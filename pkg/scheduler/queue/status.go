@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package queue
+
+import (
+	"sort"
+	"time"
+)
+
+// QueuesStatus is a snapshot of the state of the request queue, used to debug where the requests are queued.
+type QueuesStatus struct {
+	ConnectedQuerierWorkers int                 `json:"connected_querier_workers"`
+	Queriers                []QuerierStatus     `json:"queriers"`
+	Tenants                 []TenantQueueStatus `json:"tenants"`
+}
+
+// QuerierStatus is the state of a querier connected to the request queue.
+type QuerierStatus struct {
+	ID           string `json:"id"`
+	Connections  int    `json:"connections"`
+	ShuttingDown bool   `json:"shutting_down"`
+}
+
+// TenantQueueStatus is the state of the queue of a tenant.
+type TenantQueueStatus struct {
+	Tenant                  string  `json:"tenant"`
+	Length                  int     `json:"length"`
+	OldestRequestAgeSeconds float64 `json:"oldest_request_age_seconds"`
+
+	// The max number of queriers the tenant's requests are sharded to, and the number of queriers actually
+	// assigned to the tenant. Zero queriers means that all the queriers handle the tenant's requests.
+	MaxQueriers int `json:"max_queriers"`
+	Queriers    int `json:"queriers"`
+}
+
+// Status returns a snapshot of the state of the queue. The tenants are sorted by decreasing queue length.
+func (q *RequestQueue) Status(now time.Time) QueuesStatus {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	status := QueuesStatus{
+		ConnectedQuerierWorkers: int(q.connectedQuerierWorkers.Load()),
+		Queriers:                make([]QuerierStatus, 0, len(q.queues.sortedQueriers)),
+		Tenants:                 make([]TenantQueueStatus, 0, len(q.queues.userQueues)),
+	}
+
+	for _, id := range q.queues.sortedQueriers {
+		info := q.queues.queriers[id]
+		status.Queriers = append(status.Queriers, QuerierStatus{
+			ID:           id,
+			Connections:  info.connections,
+			ShuttingDown: info.shuttingDown,
+		})
+	}
+
+	for userID, uq := range q.queues.userQueues {
+		tenant := TenantQueueStatus{
+			Tenant:      userID,
+			Length:      len(uq.ch),
+			MaxQueriers: uq.maxQueriers,
+			Queriers:    len(uq.queriers),
+		}
+		if len(uq.enqueuedAt) > 0 {
+			tenant.OldestRequestAgeSeconds = now.Sub(uq.enqueuedAt[0]).Seconds()
+		}
+		status.Tenants = append(status.Tenants, tenant)
+	}
+
+	sort.Slice(status.Tenants, func(i, j int) bool {
+		if status.Tenants[i].Length != status.Tenants[j].Length {
+			return status.Tenants[i].Length > status.Tenants[j].Length
+		}
+		return status.Tenants[i].Tenant < status.Tenants[j].Tenant
+	})
+
+	return status
+}
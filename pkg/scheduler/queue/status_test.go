@@ -0,0 +1,76 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestQueue_Status(t *testing.T) {
+	queue := NewRequestQueue(10, 0,
+		prometheus.NewGaugeVec(prometheus.GaugeOpts{}, []string{"user"}),
+		prometheus.NewCounterVec(prometheus.CounterOpts{}, []string{"user"}),
+	)
+
+	queue.RegisterQuerierConnection("querier-1")
+	queue.RegisterQuerierConnection("querier-1")
+	queue.RegisterQuerierConnection("querier-2")
+	queue.NotifyQuerierShutdown("querier-2")
+
+	start := time.Now()
+	require.NoError(t, queue.EnqueueRequest("user-1", "request-1", 0, nil))
+	require.NoError(t, queue.EnqueueRequest("user-2", "request-2", 1, nil))
+	require.NoError(t, queue.EnqueueRequest("user-2", "request-3", 1, nil))
+	require.NoError(t, queue.EnqueueRequest("user-2", "request-4", 1, nil))
+
+	now := start.Add(time.Minute)
+	status := queue.Status(now)
+	assert.Equal(t, 3, status.ConnectedQuerierWorkers)
+	assert.Equal(t, []QuerierStatus{
+		{ID: "querier-1", Connections: 2},
+		{ID: "querier-2", Connections: 1, ShuttingDown: true},
+	}, status.Queriers)
+
+	require.Len(t, status.Tenants, 2)
+	assert.Equal(t, "user-2", status.Tenants[0].Tenant)
+	assert.Equal(t, 3, status.Tenants[0].Length)
+	assert.Equal(t, 1, status.Tenants[0].MaxQueriers)
+	assert.Equal(t, 1, status.Tenants[0].Queriers)
+	assert.Equal(t, "user-1", status.Tenants[1].Tenant)
+	assert.Equal(t, 1, status.Tenants[1].Length)
+	assert.Equal(t, 0, status.Tenants[1].Queriers)
+	for _, tenant := range status.Tenants {
+		assert.InDelta(t, time.Minute.Seconds(), tenant.OldestRequestAgeSeconds, time.Second.Seconds())
+	}
+
+	// Dequeue the requests of user-2 until its queue is empty, from the querier it's sharded to.
+	var querierID string
+	for id := range queue.queues.userQueues["user-2"].queriers {
+		querierID = id
+	}
+
+	last := FirstUser()
+	for i := 0; i < 3; {
+		req, idx, err := queue.GetNextRequestForQuerier(context.Background(), last, querierID)
+		require.NoError(t, err)
+		last = idx
+		if req == "request-1" {
+			// Requeue the request of user-1, to keep it in the queue.
+			require.NoError(t, queue.EnqueueRequest("user-1", req, 0, nil))
+			continue
+		}
+		i++
+	}
+
+	status = queue.Status(now)
+	require.Len(t, status.Tenants, 1)
+	assert.Equal(t, "user-1", status.Tenants[0].Tenant)
+	assert.Equal(t, 1, status.Tenants[0].Length)
+	assert.Less(t, status.Tenants[0].OldestRequestAgeSeconds, time.Minute.Seconds())
+}
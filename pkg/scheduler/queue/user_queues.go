@@ -62,6 +62,9 @@ type userQueue struct {
 
 	// Points back to 'users' field in queues. Enables quick cleanup.
 	index int
+
+	// When the requests in the queue have been enqueued, in the same order as the requests.
+	enqueuedAt []time.Time
 }
 
 func newUserQueues(maxUserQueueSize int, forgetDelay time.Duration) *queues {
@@ -142,6 +145,20 @@ func (q *queues) getOrAddQueue(userID string, maxQueriers int) chan Request {
 	return uq.ch
 }
 
+// markEnqueued records the time a request has been added to the user's queue.
+func (q *queues) markEnqueued(userID string, now time.Time) {
+	if uq := q.userQueues[userID]; uq != nil {
+		uq.enqueuedAt = append(uq.enqueuedAt, now)
+	}
+}
+
+// markDequeued forgets the time the oldest request of the user's queue has been enqueued.
+func (q *queues) markDequeued(userID string) {
+	if uq := q.userQueues[userID]; uq != nil && len(uq.enqueuedAt) > 0 {
+		uq.enqueuedAt = uq.enqueuedAt[1:]
+	}
+}
+
 // Finds next queue for the querier. To support fair scheduling between users, client is expected
 // to pass last user index returned by this function as argument. Is there was no previous
 // last user index, use -1.
@@ -32,7 +32,9 @@ import (
 	"github.com/grafana/mimir/pkg/scheduler/schedulerpb"
 	"github.com/grafana/mimir/pkg/tenant"
 	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/grpcencoding"
 	"github.com/grafana/mimir/pkg/util/httpgrpcutil"
+	"github.com/grafana/mimir/pkg/util/tlsreload"
 	"github.com/grafana/mimir/pkg/util/validation"
 )
 
@@ -97,6 +99,10 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.GRPCClientConfig.RegisterFlagsWithPrefix("query-scheduler.grpc-client-config", f)
 }
 
+func (cfg *Config) Validate(log log.Logger) error {
+	return grpcencoding.ValidateClientConfig(cfg.GRPCClientConfig, log)
+}
+
 // NewScheduler creates a new Scheduler.
 func NewScheduler(cfg Config, limits Limits, log log.Logger, registerer prometheus.Registerer) (*Scheduler, error) {
 	s := &Scheduler{
@@ -279,8 +285,22 @@ func (s *Scheduler) frontendDisconnected(frontendAddress string) {
 }
 
 func (s *Scheduler) enqueueRequest(frontendContext context.Context, frontendAddr string, msg *schedulerpb.FrontendToScheduler) error {
-	// Create new context for this request, to support cancellation.
-	ctx, cancel := context.WithCancel(frontendContext)
+	// Create new context for this request, to support cancellation. If the request has a deadline
+	// budget, the request expires once the budget is exhausted and is not forwarded to queriers anymore.
+	budget, hasBudget, err := httpgrpcutil.GetDeadlineBudget(msg.HttpRequest)
+	if err != nil {
+		return err
+	}
+
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+	)
+	if hasBudget {
+		ctx, cancel = context.WithTimeout(frontendContext, budget)
+	} else {
+		ctx, cancel = context.WithCancel(frontendContext)
+	}
 	shouldCancel := true
 	defer func() {
 		if shouldCancel {
@@ -410,6 +430,9 @@ func (s *Scheduler) forwardRequestToQuerier(querier schedulerpb.SchedulerForQuer
 	// Make sure to cancel request at the end to cleanup resources.
 	defer s.cancelRequestAndRemoveFromPending(req.frontendAddress, req.queryID)
 
+	// Update the deadline budget to account for the time spent in the queue.
+	httpgrpcutil.SetDeadlineBudgetFromContext(req.ctx, req.request)
+
 	// Handle the stream sending & receiving on a goroutine so we can
 	// monitoring the contexts in a select and cancel things appropriately.
 	errCh := make(chan error, 1)
@@ -449,7 +472,7 @@ func (s *Scheduler) forwardRequestToQuerier(querier schedulerpb.SchedulerForQuer
 }
 
 func (s *Scheduler) forwardErrorToFrontend(ctx context.Context, req *schedulerRequest, requestErr error) {
-	opts, err := s.cfg.GRPCClientConfig.DialOption([]grpc.UnaryClientInterceptor{
+	opts, err := tlsreload.GRPCDialOptions(s.cfg.GRPCClientConfig, []grpc.UnaryClientInterceptor{
 		otgrpc.OpenTracingClientInterceptor(opentracing.GlobalTracer()),
 		middleware.ClientUserHeaderInterceptor},
 		nil)
@@ -546,3 +569,27 @@ func (s *Scheduler) getConnectedFrontendClientsMetric() float64 {
 
 	return float64(count)
 }
+
+// QueuesDebugStatus is the state of the scheduler queues returned by QueuesDebugHandler.
+type QueuesDebugStatus struct {
+	queue.QueuesStatus
+
+	// Number of connections from the query-frontends, and number of requests received from them which have not
+	// completed yet, either queued or dispatched to the queriers.
+	ConnectedFrontendClients int `json:"connected_frontend_clients"`
+	PendingRequests          int `json:"pending_requests"`
+}
+
+// QueuesDebugHandler writes the state of the tenant queues as JSON, to find out where the requests are queued.
+func (s *Scheduler) QueuesDebugHandler(w http.ResponseWriter, _ *http.Request) {
+	status := QueuesDebugStatus{
+		QueuesStatus:             s.requestQueue.Status(time.Now()),
+		ConnectedFrontendClients: int(s.getConnectedFrontendClientsMetric()),
+	}
+
+	s.pendingRequestsMu.Lock()
+	status.PendingRequests = len(s.pendingRequests)
+	s.pendingRequestsMu.Unlock()
+
+	util.WriteJSONResponse(w, status)
+}
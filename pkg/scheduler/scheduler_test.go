@@ -7,9 +7,11 @@ package scheduler
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"sync"
 	"testing"
@@ -100,6 +102,33 @@ func TestSchedulerBasicEnqueue(t *testing.T) {
 	verifyNoPendingRequestsLeft(t, scheduler)
 }
 
+func TestScheduler_QueuesDebugHandler(t *testing.T) {
+	scheduler, frontendClient, _ := setupScheduler(t, nil)
+
+	frontendLoop := initFrontendLoop(t, frontendClient, "frontend-12345")
+	for i := 1; i <= 2; i++ {
+		frontendToScheduler(t, frontendLoop, &schedulerpb.FrontendToScheduler{
+			Type:        schedulerpb.ENQUEUE,
+			QueryID:     uint64(i),
+			UserID:      "test",
+			HttpRequest: &httpgrpc.HTTPRequest{Method: "GET", Url: "/hello"},
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	scheduler.QueuesDebugHandler(rec, httptest.NewRequest("GET", "/debug/queues/query-scheduler", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	status := QueuesDebugStatus{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	require.Equal(t, 1, status.ConnectedFrontendClients)
+	require.Equal(t, 2, status.PendingRequests)
+	require.Len(t, status.Tenants, 1)
+	require.Equal(t, "test", status.Tenants[0].Tenant)
+	require.Equal(t, 2, status.Tenants[0].Length)
+	require.Equal(t, 2, status.Tenants[0].MaxQueriers)
+}
+
 func TestSchedulerEnqueueWithCancel(t *testing.T) {
 	scheduler, frontendClient, querierClient := setupScheduler(t, nil)
 
@@ -122,6 +151,61 @@ func TestSchedulerEnqueueWithCancel(t *testing.T) {
 	verifyNoPendingRequestsLeft(t, scheduler)
 }
 
+func TestSchedulerEnqueueWithExhaustedDeadlineBudget(t *testing.T) {
+	scheduler, frontendClient, querierClient := setupScheduler(t, nil)
+
+	req := &httpgrpc.HTTPRequest{Method: "GET", Url: "/hello"}
+	httpgrpcutil.SetDeadlineBudget(req, 100*time.Millisecond)
+
+	frontendLoop := initFrontendLoop(t, frontendClient, "frontend-12345")
+	frontendToScheduler(t, frontendLoop, &schedulerpb.FrontendToScheduler{
+		Type:        schedulerpb.ENQUEUE,
+		QueryID:     1,
+		UserID:      "test",
+		HttpRequest: req,
+	})
+
+	// Wait until the deadline budget is exhausted before connecting a querier.
+	time.Sleep(200 * time.Millisecond)
+
+	querierLoop := initQuerierLoop(t, querierClient, "querier-1")
+
+	verifyQuerierDoesntReceiveRequest(t, querierLoop, 500*time.Millisecond)
+	verifyNoPendingRequestsLeft(t, scheduler)
+}
+
+func TestSchedulerDecrementsDeadlineBudget(t *testing.T) {
+	scheduler, frontendClient, querierClient := setupScheduler(t, nil)
+
+	req := &httpgrpc.HTTPRequest{Method: "GET", Url: "/hello"}
+	httpgrpcutil.SetDeadlineBudget(req, time.Minute)
+
+	frontendLoop := initFrontendLoop(t, frontendClient, "frontend-12345")
+	frontendToScheduler(t, frontendLoop, &schedulerpb.FrontendToScheduler{
+		Type:        schedulerpb.ENQUEUE,
+		QueryID:     1,
+		UserID:      "test",
+		HttpRequest: req,
+	})
+
+	// Keep the request in the queue for a while.
+	time.Sleep(100 * time.Millisecond)
+
+	querierLoop := initQuerierLoop(t, querierClient, "querier-1")
+
+	msg, err := querierLoop.Recv()
+	require.NoError(t, err)
+
+	budget, ok, err := httpgrpcutil.GetDeadlineBudget(msg.HttpRequest)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.LessOrEqual(t, budget, time.Minute-100*time.Millisecond)
+	require.Greater(t, budget, time.Duration(0))
+
+	require.NoError(t, querierLoop.Send(&schedulerpb.QuerierToScheduler{}))
+	verifyNoPendingRequestsLeft(t, scheduler)
+}
+
 func initQuerierLoop(t *testing.T, querierClient schedulerpb.SchedulerForQuerierClient, querier string) schedulerpb.SchedulerForQuerier_QuerierLoopClient {
 	querierLoop, err := querierClient.QuerierLoop(context.Background())
 	require.NoError(t, err)
@@ -0,0 +1,560 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package sqlgateway
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/services"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/storage"
+	v1 "github.com/prometheus/prometheus/web/api/v1"
+	"github.com/weaveworks/common/user"
+	"golang.org/x/crypto/bcrypt"
+
+	util_log "github.com/grafana/mimir/pkg/util/log"
+)
+
+const (
+	sqlStateInvalidAuthorization = "28000"
+	sqlStateInvalidPassword      = "28P01"
+	sqlStateTooManyConnections   = "53300"
+	sqlStateProgramLimitExceeded = "54000"
+	sqlStateQueryCanceled        = "57014"
+)
+
+var errAuthRequired = errors.New("the SQL gateway requires a passwords file when multi-tenancy is enabled, to authenticate the tenants")
+
+// Config holds the SQL gateway configuration.
+type Config struct {
+	ListenAddress   string        `yaml:"listen_address" category:"experimental"`
+	ListenPort      int           `yaml:"listen_port" category:"experimental"`
+	PasswordsFile   string        `yaml:"passwords_file" category:"experimental"`
+	MaxConnections  int           `yaml:"max_connections" category:"experimental"`
+	MaxQueryRange   time.Duration `yaml:"max_query_range" category:"experimental"`
+	MaxRowsPerQuery int           `yaml:"max_rows_per_query" category:"experimental"`
+}
+
+// RegisterFlags registers the SQL gateway flags.
+func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
+	f.StringVar(&cfg.ListenAddress, "sql-gateway.listen-address", "localhost", "Address the SQL gateway listens on for connections using the Postgres wire protocol. An empty value listens on all the addresses.")
+	f.IntVar(&cfg.ListenPort, "sql-gateway.listen-port", 5432, "Port the SQL gateway listens on for connections using the Postgres wire protocol.")
+	f.StringVar(&cfg.PasswordsFile, "sql-gateway.passwords-file", "", "Path to the file with the passwords of the tenants allowed to connect, with one <tenant ID>:<bcrypt hash> line per tenant. When set, the clients authenticate with the tenant ID as user and the tenant's password. Required when multi-tenancy is enabled.")
+	f.IntVar(&cfg.MaxConnections, "sql-gateway.max-connections", 100, "Maximum number of open connections to the SQL gateway. 0 to disable.")
+	f.DurationVar(&cfg.MaxQueryRange, "sql-gateway.max-query-range", 24*time.Hour, "Maximum time range between the lower and upper bounds on the timestamp column of a query. 0 to disable.")
+	f.IntVar(&cfg.MaxRowsPerQuery, "sql-gateway.max-rows-per-query", 1000000, "Maximum number of rows returned by a query. Queries returning more rows fail, unless their LIMIT is lower. 0 to disable.")
+}
+
+// Validate validates the config.
+func (cfg *Config) Validate(multitenancyEnabled bool) error {
+	if multitenancyEnabled && cfg.PasswordsFile == "" {
+		return errAuthRequired
+	}
+	return nil
+}
+
+// Gateway exposes a read-only SQL interface over the Postgres wire protocol, so that BI tools can pull
+// data from Mimir using their Postgres connectors. The SELECT statements over the virtual series table
+// are translated to PromQL range vector selectors, and executed with the querier's PromQL engine.
+//
+// Only the simple query protocol is supported. When a passwords file is configured, the connections are
+// authenticated with the tenant ID as user, and a clear text password.
+type Gateway struct {
+	services.Service
+
+	cfg          Config
+	queryable    storage.Queryable
+	engine       v1.QueryEngine
+	noAuthTenant string
+	logger       log.Logger
+
+	// Bcrypt hashes of the tenants' passwords by tenant ID, or nil if the connections are not authenticated.
+	passwords map[string][]byte
+	// Bcrypt hash compared with the passwords of the unknown users, so that they can't be told apart
+	// from the wrong passwords by the authentication time.
+	unknownUserPassword []byte
+
+	listener net.Listener
+
+	connsMx sync.Mutex
+	conns   map[net.Conn]struct{}
+	connsWg sync.WaitGroup
+
+	queries         *prometheus.CounterVec
+	openConnections prometheus.Gauge
+}
+
+// NewGateway makes a new Gateway. If noAuthTenant is not empty, it's used as the tenant ID of all the connections.
+func NewGateway(cfg Config, queryable storage.Queryable, engine v1.QueryEngine, noAuthTenant string, logger log.Logger, reg prometheus.Registerer) *Gateway {
+	g := &Gateway{
+		cfg:          cfg,
+		queryable:    queryable,
+		engine:       engine,
+		noAuthTenant: noAuthTenant,
+		logger:       logger,
+		conns:        map[net.Conn]struct{}{},
+
+		queries: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_sql_gateway_queries_total",
+			Help: "Total number of SQL queries received by the SQL gateway.",
+		}, []string{"status"}),
+		openConnections: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_sql_gateway_open_connections",
+			Help: "Number of open connections to the SQL gateway.",
+		}),
+	}
+
+	g.Service = services.NewBasicService(g.starting, g.running, g.stopping)
+	return g
+}
+
+func (g *Gateway) starting(_ context.Context) error {
+	var err error
+	if g.cfg.PasswordsFile != "" {
+		if g.passwords, err = loadPasswordsFile(g.cfg.PasswordsFile); err != nil {
+			return errors.Wrap(err, "SQL gateway load passwords file")
+		}
+		if g.unknownUserPassword, err = bcrypt.GenerateFromPassword([]byte("unknown user"), bcrypt.DefaultCost); err != nil {
+			return err
+		}
+	}
+
+	g.listener, err = net.Listen("tcp", net.JoinHostPort(g.cfg.ListenAddress, strconv.Itoa(g.cfg.ListenPort)))
+	if err != nil {
+		return errors.Wrap(err, "SQL gateway listen")
+	}
+
+	level.Info(g.logger).Log("msg", "SQL gateway listening", "address", g.listener.Addr())
+	return nil
+}
+
+func (g *Gateway) running(ctx context.Context) error {
+	go func() {
+		<-ctx.Done()
+		_ = g.listener.Close()
+	}()
+
+	for {
+		conn, err := g.listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return errors.Wrap(err, "SQL gateway accept connection")
+		}
+
+		g.connsMx.Lock()
+		tooMany := g.cfg.MaxConnections > 0 && len(g.conns) >= g.cfg.MaxConnections
+		if !tooMany {
+			g.conns[conn] = struct{}{}
+		}
+		g.connsMx.Unlock()
+
+		if tooMany {
+			g.connsWg.Add(1)
+			go g.rejectConnection(conn, sqlStateTooManyConnections, "too many open connections to the SQL gateway")
+			continue
+		}
+
+		g.connsWg.Add(1)
+		go g.handleConnection(ctx, conn)
+	}
+}
+
+func (g *Gateway) stopping(_ error) error {
+	g.connsMx.Lock()
+	for conn := range g.conns {
+		_ = conn.Close()
+	}
+	g.connsMx.Unlock()
+
+	g.connsWg.Wait()
+	return nil
+}
+
+// Addr returns the address the gateway listens on. It must be called once the gateway is running.
+func (g *Gateway) Addr() net.Addr {
+	return g.listener.Addr()
+}
+
+// rejectConnection writes an error to the client once it has sent its startup message, and closes the connection.
+func (g *Gateway) rejectConnection(conn net.Conn, code, message string) {
+	defer g.connsWg.Done()
+	defer conn.Close()
+
+	// The client doesn't read the error before its startup message has been processed.
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+	c := newPGConn(conn)
+	if _, err := c.readStartup(); err != nil {
+		return
+	}
+	if err := c.writeError(code, message); err == nil {
+		_ = c.flush()
+	}
+}
+
+func (g *Gateway) handleConnection(ctx context.Context, conn net.Conn) {
+	g.openConnections.Inc()
+	defer func() {
+		_ = conn.Close()
+
+		g.connsMx.Lock()
+		delete(g.conns, conn)
+		g.connsMx.Unlock()
+
+		g.openConnections.Dec()
+		g.connsWg.Done()
+	}()
+
+	if err := g.serveConnection(ctx, newPGConn(conn)); err != nil {
+		level.Debug(g.logger).Log("msg", "SQL gateway connection closed", "remote", conn.RemoteAddr(), "err", err)
+	}
+}
+
+func (g *Gateway) serveConnection(ctx context.Context, c *pgConn) error {
+	params, err := c.readStartup()
+	if err != nil {
+		return err
+	}
+
+	tenantID := g.noAuthTenant
+	if tenantID == "" {
+		tenantID = params["user"]
+	}
+	if tenantID == "" {
+		if err := c.writeError(sqlStateInvalidAuthorization, "no tenant ID: set the user connection parameter to the tenant ID"); err != nil {
+			return err
+		}
+		return c.flush()
+	}
+	if g.passwords != nil {
+		if ok, err := g.authenticate(c, params["user"]); err != nil || !ok {
+			return err
+		}
+	}
+	ctx = user.InjectOrgID(ctx, tenantID)
+	logger := util_log.WithUserID(tenantID, g.logger)
+
+	if err := c.writeAuthenticationOK(); err != nil {
+		return err
+	}
+	for _, p := range [][2]string{
+		{"server_version", "14.0"},
+		{"server_encoding", "UTF8"},
+		{"client_encoding", "UTF8"},
+		{"DateStyle", "ISO, MDY"},
+		{"TimeZone", "UTC"},
+		{"integer_datetimes", "on"},
+		{"standard_conforming_strings", "on"},
+	} {
+		if err := c.writeParameterStatus(p[0], p[1]); err != nil {
+			return err
+		}
+	}
+	if err := c.writeReadyForQuery(); err != nil {
+		return err
+	}
+
+	// After an error, the messages of the extended query protocol are discarded until the next Sync message.
+	discardUntilSync := false
+
+	for {
+		if err := c.flush(); err != nil {
+			return err
+		}
+
+		typ, payload, err := c.readMessage()
+		if err != nil {
+			return err
+		}
+
+		switch typ {
+		case pgMsgQuery:
+			err = g.handleQuery(ctx, logger, c, strings.TrimSuffix(string(payload), "\x00"))
+		case pgMsgTerminate:
+			return nil
+		case pgMsgSync:
+			discardUntilSync = false
+			err = c.writeReadyForQuery()
+		case pgMsgParse, pgMsgBind, pgMsgDescribe, pgMsgExecute, pgMsgClose, pgMsgFlush:
+			if !discardUntilSync {
+				discardUntilSync = true
+				err = c.writeError(sqlStateFeatureNotSupported, "the extended query protocol is not supported, use the simple query protocol")
+			}
+		default:
+			if err := c.writeError(sqlStateProtocolViolation, "unsupported message type "+strconv.QuoteRune(rune(typ))); err != nil {
+				return err
+			}
+			return c.flush()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// authenticate requests the password of the user to the client, and checks it. It returns whether the user is
+// authenticated. Authentication failures are written to the client.
+func (g *Gateway) authenticate(c *pgConn, username string) (bool, error) {
+	if err := c.writeAuthenticationCleartextPassword(); err != nil {
+		return false, err
+	}
+	if err := c.flush(); err != nil {
+		return false, err
+	}
+
+	typ, payload, err := c.readMessage()
+	if err != nil {
+		return false, err
+	}
+	if typ != pgMsgPassword {
+		if err := c.writeError(sqlStateProtocolViolation, "expected a password message"); err != nil {
+			return false, err
+		}
+		return false, c.flush()
+	}
+
+	hash, ok := g.passwords[username]
+	if !ok {
+		hash = g.unknownUserPassword
+	}
+	if bcrypt.CompareHashAndPassword(hash, bytes.TrimSuffix(payload, []byte{0})) != nil || !ok {
+		level.Warn(g.logger).Log("msg", "SQL gateway authentication failed", "user", username)
+		if err := c.writeError(sqlStateInvalidPassword, fmt.Sprintf("password authentication failed for user %q", username)); err != nil {
+			return false, err
+		}
+		return false, c.flush()
+	}
+	return true, nil
+}
+
+// loadPasswordsFile returns the bcrypt password hashes by tenant ID, read from the input file.
+// Each line has the <tenant ID>:<bcrypt hash> format, like the files generated by "htpasswd -B".
+func loadPasswordsFile(path string) (map[string][]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	passwords := map[string][]byte{}
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("line %d: expected <tenant ID>:<bcrypt hash>", i+1)
+		}
+		if _, err := bcrypt.Cost([]byte(parts[1])); err != nil {
+			return nil, fmt.Errorf("line %d: invalid bcrypt hash: %w", i+1, err)
+		}
+		passwords[parts[0]] = []byte(parts[1])
+	}
+	return passwords, nil
+}
+
+// handleQuery executes a query sent with the simple query protocol, and writes its result to the client.
+func (g *Gateway) handleQuery(ctx context.Context, logger log.Logger, c *pgConn, query string) error {
+	trimmed := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(query), ";"))
+
+	switch {
+	case trimmed == "":
+		if err := c.writeEmptyQueryResponse(); err != nil {
+			return err
+		}
+
+	case len(trimmed) > 4 && strings.EqualFold(trimmed[:4], "set "):
+		// Clients commonly set session parameters when connecting, which are ignored.
+		if err := c.writeCommandComplete("SET"); err != nil {
+			return err
+		}
+
+	default:
+		rows, err := g.executeSelect(ctx, c, query)
+		if err != nil {
+			g.queries.WithLabelValues("error").Inc()
+			level.Warn(logger).Log("msg", "SQL gateway query failed", "query", query, "err", err)
+
+			code := sqlStateInternalError
+			var sqlErr *sqlError
+			if errors.As(err, &sqlErr) {
+				code = sqlErr.code
+			}
+			if err := c.writeError(code, err.Error()); err != nil {
+				return err
+			}
+			break
+		}
+
+		g.queries.WithLabelValues("success").Inc()
+		if err := c.writeCommandComplete("SELECT " + strconv.Itoa(rows)); err != nil {
+			return err
+		}
+	}
+
+	return c.writeReadyForQuery()
+}
+
+// executeSelect executes a SELECT statement and writes the resulting rows to the client. It returns the
+// number of written rows.
+func (g *Gateway) executeSelect(ctx context.Context, c *pgConn, query string) (int, error) {
+	now := time.Now()
+	stmt, err := parseSelect(query, now)
+	if err != nil {
+		return 0, err
+	}
+
+	end := stmt.end
+	if end.IsZero() {
+		end = now
+	}
+	if g.cfg.MaxQueryRange > 0 && end.Sub(stmt.start) > g.cfg.MaxQueryRange {
+		return 0, newSQLError(sqlStateProgramLimitExceeded, "the time range of the query (%s) exceeds the limit (%s)", end.Sub(stmt.start), g.cfg.MaxQueryRange)
+	}
+	qs, err := stmt.promQL(end)
+	if err != nil {
+		return 0, err
+	}
+
+	q, err := g.engine.NewInstantQuery(g.queryable, qs, end)
+	if err != nil {
+		return 0, err
+	}
+	defer q.Close()
+
+	res := q.Exec(ctx)
+	if res.Err != nil {
+		var timeoutErr promql.ErrQueryTimeout
+		var canceledErr promql.ErrQueryCanceled
+		if errors.As(res.Err, &timeoutErr) || errors.As(res.Err, &canceledErr) {
+			return 0, newSQLError(sqlStateQueryCanceled, "%s", res.Err)
+		}
+		return 0, res.Err
+	}
+	matrix, err := res.Matrix()
+	if err != nil {
+		return 0, err
+	}
+	sort.Sort(matrix)
+
+	if g.cfg.MaxRowsPerQuery > 0 {
+		total := 0
+		for _, series := range matrix {
+			total += len(series.Points)
+		}
+		if stmt.limit > 0 && stmt.limit < total {
+			total = stmt.limit
+		}
+		if total > g.cfg.MaxRowsPerQuery {
+			return 0, newSQLError(sqlStateProgramLimitExceeded, "the query returns more rows than the limit (%d), add a lower LIMIT or narrow the conditions", g.cfg.MaxRowsPerQuery)
+		}
+	}
+
+	columns := stmt.columns
+	if columns == nil {
+		columns = allColumns(matrix)
+	}
+
+	desc := make([]pgColumn, 0, len(columns))
+	for _, name := range columns {
+		typeOID := uint32(pgTypeText)
+		switch name {
+		case timestampColumn:
+			typeOID = pgTypeTimestamptz
+		case valueColumn:
+			typeOID = pgTypeFloat8
+		}
+		desc = append(desc, pgColumn{name: name, typeOID: typeOID})
+	}
+	if err := c.writeRowDescription(desc); err != nil {
+		return 0, err
+	}
+
+	rows := 0
+	values := make([]*string, len(columns))
+	for _, series := range matrix {
+		for _, p := range series.Points {
+			if stmt.limit > 0 && rows >= stmt.limit {
+				return rows, nil
+			}
+
+			for i, name := range columns {
+				var v string
+				switch name {
+				case timestampColumn:
+					v = formatTimestamp(p.T)
+				case valueColumn:
+					v = formatFloat(p.V)
+				default:
+					v = series.Metric.Get(name)
+					if v == "" {
+						values[i] = nil
+						continue
+					}
+				}
+				values[i] = &v
+			}
+
+			if err := c.writeDataRow(values); err != nil {
+				return rows, err
+			}
+			rows++
+		}
+	}
+
+	return rows, nil
+}
+
+// allColumns returns the timestamp and value columns, followed by the sorted label names of the series.
+func allColumns(matrix promql.Matrix) []string {
+	unique := map[string]struct{}{}
+	for _, series := range matrix {
+		for _, l := range series.Metric {
+			unique[l.Name] = struct{}{}
+		}
+	}
+
+	names := make([]string, 0, len(unique))
+	for name := range unique {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return append([]string{timestampColumn, valueColumn}, names...)
+}
+
+// formatTimestamp formats a timestamp in milliseconds as a Postgres timestamptz.
+func formatTimestamp(ms int64) string {
+	return time.UnixMilli(ms).UTC().Format("2006-01-02 15:04:05.000") + "+00"
+}
+
+// formatFloat formats a float as a Postgres float8.
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "Infinity"
+	case math.IsInf(v, -1):
+		return "-Infinity"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,409 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package sqlgateway
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"math"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/services"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/util/teststorage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestGateway(t *testing.T) {
+	db := teststorage.New(t)
+	t.Cleanup(func() { _ = db.Close() })
+
+	app := db.Appender(context.Background())
+	for _, s := range []struct {
+		lbls labels.Labels
+		ts   int64
+		v    float64
+	}{
+		{lbls: labels.FromStrings("__name__", "up", "job", "api", "instance", "host-1"), ts: 10_000, v: 1},
+		{lbls: labels.FromStrings("__name__", "up", "job", "api", "instance", "host-1"), ts: 20_000, v: 0},
+		{lbls: labels.FromStrings("__name__", "up", "job", "db"), ts: 15_000, v: math.Inf(1)},
+		{lbls: labels.FromStrings("__name__", "other", "job", "api"), ts: 15_000, v: 2},
+	} {
+		_, err := app.Append(0, s.lbls, s.ts, s.v)
+		require.NoError(t, err)
+	}
+	require.NoError(t, app.Commit())
+
+	// Track the tenant IDs the queryable is called with.
+	var tenantIDs []string
+	queryable := storage.QueryableFunc(func(ctx context.Context, mint, maxt int64) (storage.Querier, error) {
+		tenantID, err := user.ExtractOrgID(ctx)
+		if err != nil {
+			return nil, err
+		}
+		tenantIDs = append(tenantIDs, tenantID)
+		return db.Querier(ctx, mint, maxt)
+	})
+
+	engine := promql.NewEngine(promql.EngineOpts{MaxSamples: 1e6, Timeout: time.Minute})
+	reg := prometheus.NewPedanticRegistry()
+	g := NewGateway(Config{ListenAddress: "localhost", ListenPort: 0}, queryable, engine, "", log.NewNopLogger(), reg)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), g))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(context.Background(), g))
+	})
+
+	c := dialTestClient(t, g.Addr().String(), "user-1")
+
+	t.Run("should return the samples of the matching series", func(t *testing.T) {
+		res := c.query(t, `SELECT * FROM series WHERE __name__ = 'up' AND timestamp BETWEEN 10 AND 20`)
+
+		require.Empty(t, res.errorCode, res.errorMessage)
+		assert.Equal(t, []string{"timestamp", "value", "__name__", "instance", "job"}, res.columns)
+		assert.Equal(t, []uint32{pgTypeTimestamptz, pgTypeFloat8, pgTypeText, pgTypeText, pgTypeText}, res.columnTypes)
+		assert.Equal(t, [][]string{
+			{"1970-01-01 00:00:10.000+00", "1", "up", "host-1", "api"},
+			{"1970-01-01 00:00:20.000+00", "0", "up", "host-1", "api"},
+			{"1970-01-01 00:00:15.000+00", "Infinity", "up", "NULL", "db"},
+		}, res.rows)
+		assert.Equal(t, "SELECT 3", res.commandTag)
+		assert.Equal(t, []string{"user-1"}, tenantIDs)
+	})
+
+	t.Run("should apply the label conditions, the time range and the limit", func(t *testing.T) {
+		res := c.query(t, `SELECT value, job, missing FROM series WHERE job LIKE 'a%' AND timestamp > 10 AND timestamp <= 20 LIMIT 1`)
+
+		require.Empty(t, res.errorCode, res.errorMessage)
+		assert.Equal(t, []string{"value", "job", "missing"}, res.columns)
+		assert.Equal(t, [][]string{{"2", "api", "NULL"}}, res.rows)
+		assert.Equal(t, "SELECT 1", res.commandTag)
+	})
+
+	t.Run("should return an error on invalid queries and keep the connection usable", func(t *testing.T) {
+		res := c.query(t, `SELECT * FROM series WHERE __name__ = 'up'`)
+		assert.Equal(t, sqlStateFeatureNotSupported, res.errorCode)
+
+		res = c.query(t, `SELECT * FROM series WHERE __name__ = 'up' AND timestamp >= 10 AND timestamp <= 10`)
+		assert.Equal(t, sqlStateDataException, res.errorCode)
+
+		res = c.query(t, `SELECT * FROM series WHERE __name__ = 'up' AND timestamp >= 15 AND timestamp <= 15.5`)
+		require.Empty(t, res.errorCode, res.errorMessage)
+		assert.Len(t, res.rows, 1)
+	})
+
+	t.Run("should accept SET statements and empty queries", func(t *testing.T) {
+		res := c.query(t, `SET extra_float_digits = 3`)
+		require.Empty(t, res.errorCode, res.errorMessage)
+		assert.Equal(t, "SET", res.commandTag)
+
+		res = c.query(t, ` ; `)
+		require.Empty(t, res.errorCode, res.errorMessage)
+		assert.Empty(t, res.commandTag)
+	})
+
+	t.Run("should reject the extended query protocol", func(t *testing.T) {
+		c.send(t, pgMsgParse, []byte("\x00SELECT 1\x00\x00\x00"))
+		c.send(t, pgMsgBind, []byte("\x00\x00\x00\x00\x00\x00\x00\x00"))
+		c.send(t, pgMsgSync, nil)
+
+		res := c.readResult(t)
+		assert.Equal(t, sqlStateFeatureNotSupported, res.errorCode)
+	})
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_sql_gateway_queries_total Total number of SQL queries received by the SQL gateway.
+		# TYPE cortex_sql_gateway_queries_total counter
+		cortex_sql_gateway_queries_total{status="error"} 2
+		cortex_sql_gateway_queries_total{status="success"} 3
+		# HELP cortex_sql_gateway_open_connections Number of open connections to the SQL gateway.
+		# TYPE cortex_sql_gateway_open_connections gauge
+		cortex_sql_gateway_open_connections 1
+	`), "cortex_sql_gateway_queries_total", "cortex_sql_gateway_open_connections"))
+
+	t.Run("should reject connections without tenant ID", func(t *testing.T) {
+		conn, err := net.Dial("tcp", g.Addr().String())
+		require.NoError(t, err)
+		defer conn.Close()
+
+		noTenant := &testClient{conn: conn, r: bufio.NewReader(conn)}
+		noTenant.sendStartup(t, "")
+
+		res := noTenant.readResult(t)
+		assert.Equal(t, sqlStateInvalidAuthorization, res.errorCode)
+	})
+}
+
+func TestGateway_NoAuthTenant(t *testing.T) {
+	var tenantID string
+	queryable := storage.QueryableFunc(func(ctx context.Context, mint, maxt int64) (storage.Querier, error) {
+		tenantID, _ = user.ExtractOrgID(ctx)
+		return storage.NoopQuerier(), nil
+	})
+
+	engine := promql.NewEngine(promql.EngineOpts{MaxSamples: 1e6, Timeout: time.Minute})
+	g := NewGateway(Config{ListenAddress: "localhost", ListenPort: 0}, queryable, engine, "anonymous", log.NewNopLogger(), nil)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), g))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(context.Background(), g))
+	})
+
+	c := dialTestClient(t, g.Addr().String(), "user-1")
+	res := c.query(t, `SELECT * FROM series WHERE __name__ = 'up' AND timestamp >= now() - interval '1h'`)
+	require.Empty(t, res.errorCode, res.errorMessage)
+	assert.Empty(t, res.rows)
+	assert.Equal(t, "SELECT 0", res.commandTag)
+	assert.Equal(t, "anonymous", tenantID)
+}
+
+func TestGateway_Authentication(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+	passwordsFile := filepath.Join(t.TempDir(), "passwords")
+	require.NoError(t, ioutil.WriteFile(passwordsFile, []byte("# Tenants.\nuser-1:"+string(hash)+"\n"), 0600))
+
+	var tenantID string
+	queryable := storage.QueryableFunc(func(ctx context.Context, mint, maxt int64) (storage.Querier, error) {
+		tenantID, _ = user.ExtractOrgID(ctx)
+		return storage.NoopQuerier(), nil
+	})
+
+	engine := promql.NewEngine(promql.EngineOpts{MaxSamples: 1e6, Timeout: time.Minute})
+	g := NewGateway(Config{ListenAddress: "localhost", ListenPort: 0, PasswordsFile: passwordsFile}, queryable, engine, "", log.NewNopLogger(), nil)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), g))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(context.Background(), g))
+	})
+
+	t.Run("should accept the tenant with the right password", func(t *testing.T) {
+		c := dialTestClientWithPassword(t, g.Addr().String(), "user-1", "secret")
+		res := c.query(t, `SELECT * FROM series WHERE __name__ = 'up' AND timestamp >= now() - interval '1h'`)
+		require.Empty(t, res.errorCode, res.errorMessage)
+		assert.Equal(t, "user-1", tenantID)
+	})
+
+	for name, tc := range map[string]struct{ username, password string }{
+		"should reject the tenant with a wrong password": {username: "user-1", password: "wrong"},
+		"should reject an unknown tenant":                {username: "user-2", password: "secret"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c := dialRawTestClient(t, g.Addr().String())
+			c.sendStartup(t, tc.username)
+			require.True(t, c.readResult(t).passwordRequested)
+
+			c.send(t, pgMsgPassword, appendCString(nil, tc.password))
+			assert.Equal(t, sqlStateInvalidPassword, c.readResult(t).errorCode)
+		})
+	}
+}
+
+func TestGateway_Limits(t *testing.T) {
+	db := teststorage.New(t)
+	t.Cleanup(func() { _ = db.Close() })
+
+	app := db.Appender(context.Background())
+	for ts := int64(0); ts < 10; ts++ {
+		_, err := app.Append(0, labels.FromStrings("__name__", "up"), ts*1000, 1)
+		require.NoError(t, err)
+	}
+	require.NoError(t, app.Commit())
+
+	queryable := storage.QueryableFunc(func(ctx context.Context, mint, maxt int64) (storage.Querier, error) {
+		return db.Querier(ctx, mint, maxt)
+	})
+
+	engine := promql.NewEngine(promql.EngineOpts{MaxSamples: 1e6, Timeout: time.Minute})
+	cfg := Config{ListenAddress: "localhost", ListenPort: 0, MaxConnections: 1, MaxQueryRange: time.Hour, MaxRowsPerQuery: 5}
+	g := NewGateway(cfg, queryable, engine, "", log.NewNopLogger(), nil)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), g))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(context.Background(), g))
+	})
+
+	c := dialTestClient(t, g.Addr().String(), "user-1")
+
+	t.Run("should reject the queries with a time range larger than the limit", func(t *testing.T) {
+		res := c.query(t, `SELECT * FROM series WHERE __name__ = 'up' AND timestamp >= 0 AND timestamp <= 7200`)
+		assert.Equal(t, sqlStateProgramLimitExceeded, res.errorCode)
+	})
+
+	t.Run("should reject the queries returning more rows than the limit", func(t *testing.T) {
+		res := c.query(t, `SELECT * FROM series WHERE __name__ = 'up' AND timestamp >= 0 AND timestamp <= 10`)
+		assert.Equal(t, sqlStateProgramLimitExceeded, res.errorCode)
+
+		res = c.query(t, `SELECT * FROM series WHERE __name__ = 'up' AND timestamp >= 0 AND timestamp <= 10 LIMIT 5`)
+		require.Empty(t, res.errorCode, res.errorMessage)
+		assert.Len(t, res.rows, 5)
+	})
+
+	t.Run("should reject the connections above the limit", func(t *testing.T) {
+		other := dialRawTestClient(t, g.Addr().String())
+		other.sendStartup(t, "user-1")
+		assert.Equal(t, sqlStateTooManyConnections, other.readResult(t).errorCode)
+	})
+}
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, (&Config{}).Validate(false))
+	assert.ErrorIs(t, (&Config{}).Validate(true), errAuthRequired)
+	assert.NoError(t, (&Config{PasswordsFile: "passwords"}).Validate(true))
+}
+
+// testClient is a minimal Postgres client using the simple query protocol.
+type testClient struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+type testResult struct {
+	passwordRequested bool
+	columns      []string
+	columnTypes  []uint32
+	rows         [][]string
+	commandTag   string
+	errorCode    string
+	errorMessage string
+}
+
+func dialTestClient(t *testing.T, addr, username string) *testClient {
+	c := dialRawTestClient(t, addr)
+
+	// Request SSL, which must be declined.
+	req := appendInt32(appendInt32(nil, 8), pgSSLRequestCode)
+	_, err := c.conn.Write(req)
+	require.NoError(t, err)
+	b, err := c.r.ReadByte()
+	require.NoError(t, err)
+	require.Equal(t, byte('N'), b)
+
+	c.sendStartup(t, username)
+	res := c.readResult(t)
+	require.Empty(t, res.errorCode, res.errorMessage)
+	require.False(t, res.passwordRequested)
+	return c
+}
+
+func dialTestClientWithPassword(t *testing.T, addr, username, password string) *testClient {
+	c := dialRawTestClient(t, addr)
+
+	c.sendStartup(t, username)
+	require.True(t, c.readResult(t).passwordRequested)
+
+	c.send(t, pgMsgPassword, appendCString(nil, password))
+	res := c.readResult(t)
+	require.Empty(t, res.errorCode, res.errorMessage)
+	return c
+}
+
+// dialRawTestClient returns a client connected to the input address, which hasn't sent its startup message yet.
+func dialRawTestClient(t *testing.T, addr string) *testClient {
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return &testClient{conn: conn, r: bufio.NewReader(conn)}
+}
+
+func (c *testClient) sendStartup(t *testing.T, username string) {
+	payload := appendInt32(nil, pgProtocolVersion)
+	if username != "" {
+		payload = appendCString(appendCString(payload, "user"), username)
+	}
+	payload = appendCString(appendCString(payload, "database"), "mimir")
+	payload = append(payload, 0)
+
+	_, err := c.conn.Write(append(appendInt32(nil, int32(len(payload)+4)), payload...))
+	require.NoError(t, err)
+}
+
+func (c *testClient) send(t *testing.T, typ byte, payload []byte) {
+	msg := append([]byte{typ}, appendInt32(nil, int32(len(payload)+4))...)
+	_, err := c.conn.Write(append(msg, payload...))
+	require.NoError(t, err)
+}
+
+func (c *testClient) query(t *testing.T, query string) testResult {
+	c.send(t, pgMsgQuery, appendCString(nil, query))
+	return c.readResult(t)
+}
+
+// readResult reads the messages sent by the server until it's ready for a new query.
+func (c *testClient) readResult(t *testing.T) testResult {
+	res := testResult{}
+
+	for {
+		typ, err := c.r.ReadByte()
+		require.NoError(t, err)
+		var length uint32
+		require.NoError(t, binary.Read(c.r, binary.BigEndian, &length))
+		payload := make([]byte, length-4)
+		_, err = io.ReadFull(c.r, payload)
+		require.NoError(t, err)
+
+		switch typ {
+		case 'Z':
+			return res
+		case 'R':
+			// The server waits for the password once requested.
+			if binary.BigEndian.Uint32(payload) == 3 {
+				res.passwordRequested = true
+				return res
+			}
+		case 'E':
+			for _, field := range strings.Split(string(payload), "\x00") {
+				if strings.HasPrefix(field, "C") {
+					res.errorCode = field[1:]
+				} else if strings.HasPrefix(field, "M") {
+					res.errorMessage = field[1:]
+				}
+			}
+			require.NotEmpty(t, res.errorCode)
+
+			// The server closes the connection after a failed authorization.
+			if res.errorCode == sqlStateInvalidAuthorization || res.errorCode == sqlStateInvalidPassword || res.errorCode == sqlStateTooManyConnections {
+				return res
+			}
+		case 'T':
+			n := int(binary.BigEndian.Uint16(payload))
+			pos := 2
+			for i := 0; i < n; i++ {
+				end := pos + strings.IndexByte(string(payload[pos:]), 0)
+				res.columns = append(res.columns, string(payload[pos:end]))
+				pos = end + 1 + 6
+				res.columnTypes = append(res.columnTypes, binary.BigEndian.Uint32(payload[pos:]))
+				pos += 12
+			}
+		case 'D':
+			n := int(binary.BigEndian.Uint16(payload))
+			pos := 2
+			row := make([]string, 0, n)
+			for i := 0; i < n; i++ {
+				l := int32(binary.BigEndian.Uint32(payload[pos:]))
+				pos += 4
+				if l < 0 {
+					row = append(row, "NULL")
+					continue
+				}
+				row = append(row, string(payload[pos:pos+int(l)]))
+				pos += int(l)
+			}
+			res.rows = append(res.rows, row)
+		case 'C':
+			res.commandTag = strings.TrimSuffix(string(payload), "\x00")
+		}
+	}
+}
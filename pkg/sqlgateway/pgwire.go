@@ -0,0 +1,219 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package sqlgateway
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// The subset of the Postgres frontend/backend protocol (version 3.0) implemented by the gateway.
+// See https://www.postgresql.org/docs/current/protocol.html.
+const (
+	pgProtocolVersion   = 196608
+	pgSSLRequestCode    = 80877103
+	pgGSSENCRequestCode = 80877104
+	pgCancelRequestCode = 80877102
+
+	// pgMaxMessageSize is the max size of the messages sent by the clients.
+	pgMaxMessageSize = 1 << 20
+
+	// Type OIDs of the columns returned by the gateway.
+	pgTypeText        = 25
+	pgTypeFloat8      = 701
+	pgTypeTimestamptz = 1184
+)
+
+// Message types sent by the clients.
+const (
+	pgMsgQuery     = 'Q'
+	pgMsgTerminate = 'X'
+	pgMsgSync      = 'S'
+	pgMsgParse     = 'P'
+	pgMsgBind      = 'B'
+	pgMsgDescribe  = 'D'
+	pgMsgExecute   = 'E'
+	pgMsgClose     = 'C'
+	pgMsgFlush     = 'H'
+	pgMsgPassword  = 'p'
+)
+
+// pgConn reads and writes the messages of a Postgres connection. Written messages are buffered
+// until flush is called.
+type pgConn struct {
+	r *bufio.Reader
+	w *bufio.Writer
+}
+
+func newPGConn(rw io.ReadWriter) *pgConn {
+	return &pgConn{r: bufio.NewReader(rw), w: bufio.NewWriter(rw)}
+}
+
+// readStartup reads the startup message and returns its parameters. SSL and GSSAPI encryption requests are
+// declined, and the client is expected to send the startup message in clear text afterwards.
+func (c *pgConn) readStartup() (map[string]string, error) {
+	for {
+		payload, err := c.readPayload()
+		if err != nil {
+			return nil, err
+		}
+		if len(payload) < 4 {
+			return nil, fmt.Errorf("invalid startup message")
+		}
+
+		switch code := binary.BigEndian.Uint32(payload); code {
+		case pgSSLRequestCode, pgGSSENCRequestCode:
+			if err := c.w.WriteByte('N'); err != nil {
+				return nil, err
+			}
+			if err := c.w.Flush(); err != nil {
+				return nil, err
+			}
+
+		case pgProtocolVersion:
+			params := map[string]string{}
+			fields := strings.Split(string(payload[4:]), "\x00")
+			for i := 0; i+1 < len(fields); i += 2 {
+				if fields[i] == "" {
+					break
+				}
+				params[fields[i]] = fields[i+1]
+			}
+			return params, nil
+
+		case pgCancelRequestCode:
+			return nil, fmt.Errorf("cancel requests are not supported")
+
+		default:
+			return nil, fmt.Errorf("unsupported protocol version %d.%d", code>>16, code&0xffff)
+		}
+	}
+}
+
+// readMessage reads a message and returns its type and payload.
+func (c *pgConn) readMessage() (byte, []byte, error) {
+	typ, err := c.r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	payload, err := c.readPayload()
+	return typ, payload, err
+}
+
+// readPayload reads the length-prefixed payload of a message.
+func (c *pgConn) readPayload() ([]byte, error) {
+	var length uint32
+	if err := binary.Read(c.r, binary.BigEndian, &length); err != nil {
+		return nil, err
+	}
+	if length < 4 || length > pgMaxMessageSize {
+		return nil, fmt.Errorf("invalid message length %d", length)
+	}
+
+	payload := make([]byte, length-4)
+	_, err := io.ReadFull(c.r, payload)
+	return payload, err
+}
+
+// writeMessage writes a message with the given type and payload.
+func (c *pgConn) writeMessage(typ byte, payload []byte) error {
+	header := [5]byte{typ}
+	binary.BigEndian.PutUint32(header[1:], uint32(len(payload)+4))
+	if _, err := c.w.Write(header[:]); err != nil {
+		return err
+	}
+	_, err := c.w.Write(payload)
+	return err
+}
+
+func (c *pgConn) flush() error {
+	return c.w.Flush()
+}
+
+func (c *pgConn) writeAuthenticationOK() error {
+	return c.writeMessage('R', appendInt32(nil, 0))
+}
+
+// writeAuthenticationCleartextPassword requests the client to send its password in clear text.
+func (c *pgConn) writeAuthenticationCleartextPassword() error {
+	return c.writeMessage('R', appendInt32(nil, 3))
+}
+
+func (c *pgConn) writeParameterStatus(name, value string) error {
+	return c.writeMessage('S', appendCString(appendCString(nil, name), value))
+}
+
+// writeReadyForQuery tells the client that the gateway is ready for a new query, outside of any transaction.
+func (c *pgConn) writeReadyForQuery() error {
+	return c.writeMessage('Z', []byte{'I'})
+}
+
+func (c *pgConn) writeCommandComplete(tag string) error {
+	return c.writeMessage('C', appendCString(nil, tag))
+}
+
+func (c *pgConn) writeEmptyQueryResponse() error {
+	return c.writeMessage('I', nil)
+}
+
+func (c *pgConn) writeError(code, message string) error {
+	var payload []byte
+	payload = appendCString(append(payload, 'S'), "ERROR")
+	payload = appendCString(append(payload, 'V'), "ERROR")
+	payload = appendCString(append(payload, 'C'), code)
+	payload = appendCString(append(payload, 'M'), message)
+	return c.writeMessage('E', append(payload, 0))
+}
+
+type pgColumn struct {
+	name    string
+	typeOID uint32
+}
+
+func (c *pgConn) writeRowDescription(columns []pgColumn) error {
+	payload := appendInt16(nil, int16(len(columns)))
+	for _, col := range columns {
+		typeSize := int16(8)
+		if col.typeOID == pgTypeText {
+			typeSize = -1
+		}
+
+		payload = appendCString(payload, col.name)
+		payload = appendInt32(payload, 0) // Table OID.
+		payload = appendInt16(payload, 0) // Column attribute number.
+		payload = appendInt32(payload, int32(col.typeOID))
+		payload = appendInt16(payload, typeSize)
+		payload = appendInt32(payload, -1) // Type modifier.
+		payload = appendInt16(payload, 0)  // Text format.
+	}
+	return c.writeMessage('T', payload)
+}
+
+// writeDataRow writes a row of values in text format. Nil values are NULLs.
+func (c *pgConn) writeDataRow(values []*string) error {
+	payload := appendInt16(nil, int16(len(values)))
+	for _, v := range values {
+		if v == nil {
+			payload = appendInt32(payload, -1)
+			continue
+		}
+		payload = appendInt32(payload, int32(len(*v)))
+		payload = append(payload, *v...)
+	}
+	return c.writeMessage('D', payload)
+}
+
+func appendInt16(b []byte, v int16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
+
+func appendInt32(b []byte, v int32) []byte {
+	return append(b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+func appendCString(b []byte, s string) []byte {
+	return append(append(b, s...), 0)
+}
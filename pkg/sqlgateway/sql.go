@@ -0,0 +1,561 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package sqlgateway
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+const (
+	// seriesTable is the name of the virtual table holding the samples of all the series.
+	seriesTable = "series"
+
+	timestampColumn = "timestamp"
+	valueColumn     = "value"
+)
+
+// selectStatement is a SELECT over the virtual series table.
+type selectStatement struct {
+	// columns are the selected columns, or nil if all the columns are selected.
+	columns []string
+
+	matchers []*labels.Matcher
+
+	// start and end are the inclusive bounds on the sample timestamps. start is required,
+	// while a zero end means the time the query is executed at.
+	start time.Time
+	end   time.Time
+
+	// limit is the max number of rows returned, or 0 if there's no limit.
+	limit int
+}
+
+// promQL returns the PromQL expression selecting the raw samples of the statement, to be evaluated at end.
+func (s *selectStatement) promQL(end time.Time) (string, error) {
+	if !end.After(s.start) {
+		return "", newSQLError(sqlStateDataException, "the upper bound on the %s column must be after the lower bound", timestampColumn)
+	}
+
+	// The range selector selects the samples in [end - range, end].
+	expr := &parser.MatrixSelector{
+		VectorSelector: &parser.VectorSelector{LabelMatchers: s.matchers},
+		Range:          end.Sub(s.start),
+	}
+	return expr.String(), nil
+}
+
+// parseSelect parses a SELECT statement over the virtual series table. The supported grammar is:
+//
+//	SELECT * | column [, column ...] FROM series
+//	  WHERE condition [AND condition ...]
+//	  [LIMIT count] [;]
+//
+// Conditions on the timestamp column use the <, <=, >, >= and BETWEEN operators, and their operand is either
+// an RFC 3339 or "YYYY-MM-DD hh:mm:ss" string, a Unix timestamp in seconds, or now() optionally followed by
+// "- interval '<duration>'". Conditions on the other columns match labels with the =, !=, <>, ~ and !~
+// operators (the latter two for regular expressions), LIKE, NOT LIKE and IN.
+func parseSelect(query string, now time.Time) (*selectStatement, error) {
+	tokens, err := tokenize(query)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &sqlParser{tokens: tokens, now: now}
+	stmt, err := p.parseSelect()
+	if err != nil {
+		return nil, err
+	}
+
+	if stmt.start.IsZero() {
+		return nil, newSQLError(sqlStateFeatureNotSupported, "a lower bound on the %s column is required", timestampColumn)
+	}
+	if len(stmt.matchers) == 0 {
+		return nil, newSQLError(sqlStateFeatureNotSupported, "at least one condition on a label column is required")
+	}
+	if _, err := parser.ParseExpr((&parser.VectorSelector{LabelMatchers: stmt.matchers}).String()); err != nil {
+		return nil, newSQLError(sqlStateFeatureNotSupported, "invalid label conditions: %s", err)
+	}
+
+	return stmt, nil
+}
+
+type sqlParser struct {
+	tokens []sqlToken
+	pos    int
+	now    time.Time
+}
+
+func (p *sqlParser) peek() sqlToken {
+	return p.tokens[p.pos]
+}
+
+func (p *sqlParser) next() sqlToken {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// keyword consumes the next token if it's the given keyword.
+func (p *sqlParser) keyword(kw string) bool {
+	if t := p.peek(); t.kind == tokenIdentifier && strings.EqualFold(t.text, kw) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// symbol consumes the next token if it's the given symbol.
+func (p *sqlParser) symbol(sym string) bool {
+	if t := p.peek(); t.kind == tokenSymbol && t.text == sym {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *sqlParser) expectKeyword(kw string) error {
+	if !p.keyword(kw) {
+		return p.syntaxError()
+	}
+	return nil
+}
+
+func (p *sqlParser) syntaxError() error {
+	if t := p.peek(); t.kind != tokenEOF {
+		return newSQLError(sqlStateSyntaxError, "syntax error at or near %q", t.text)
+	}
+	return newSQLError(sqlStateSyntaxError, "syntax error at end of input")
+}
+
+func (p *sqlParser) parseSelect() (*selectStatement, error) {
+	stmt := &selectStatement{}
+
+	if err := p.expectKeyword("select"); err != nil {
+		return nil, err
+	}
+	if !p.symbol("*") {
+		for {
+			name, ok := p.identifier()
+			if !ok {
+				return nil, p.syntaxError()
+			}
+			stmt.columns = append(stmt.columns, name)
+
+			if !p.symbol(",") {
+				break
+			}
+		}
+	}
+
+	if err := p.expectKeyword("from"); err != nil {
+		return nil, err
+	}
+	if table, ok := p.identifier(); !ok {
+		return nil, p.syntaxError()
+	} else if table != seriesTable {
+		return nil, newSQLError(sqlStateUndefinedTable, "relation %q does not exist", table)
+	}
+
+	if p.keyword("where") {
+		for {
+			if err := p.parseCondition(stmt); err != nil {
+				return nil, err
+			}
+			if !p.keyword("and") {
+				break
+			}
+		}
+	}
+
+	if p.keyword("limit") {
+		t := p.next()
+		limit, err := strconv.Atoi(t.text)
+		if t.kind != tokenNumber || err != nil || limit < 0 {
+			return nil, newSQLError(sqlStateSyntaxError, "invalid LIMIT %q", t.text)
+		}
+		stmt.limit = limit
+	}
+
+	p.symbol(";")
+	if p.peek().kind != tokenEOF {
+		return nil, p.syntaxError()
+	}
+	return stmt, nil
+}
+
+// identifier consumes the next token if it's a bare or quoted identifier, and returns its name.
+// Keywords can only be used as column names if quoted.
+func (p *sqlParser) identifier() (string, bool) {
+	switch t := p.peek(); {
+	case t.kind == tokenQuotedIdentifier:
+		p.pos++
+		return t.text, true
+	case t.kind == tokenIdentifier && !isReservedKeyword(t.text):
+		p.pos++
+		return t.text, true
+	}
+	return "", false
+}
+
+func (p *sqlParser) parseCondition(stmt *selectStatement) error {
+	column, ok := p.identifier()
+	if !ok {
+		return p.syntaxError()
+	}
+
+	switch column {
+	case timestampColumn:
+		return p.parseTimestampCondition(stmt)
+	case valueColumn:
+		return newSQLError(sqlStateFeatureNotSupported, "conditions on the %s column are not supported", valueColumn)
+	}
+
+	matcher, err := p.parseLabelCondition(column)
+	if err != nil {
+		return err
+	}
+	stmt.matchers = append(stmt.matchers, matcher)
+	return nil
+}
+
+func (p *sqlParser) parseTimestampCondition(stmt *selectStatement) error {
+	setStart := func(t time.Time) {
+		if t.After(stmt.start) {
+			stmt.start = t
+		}
+	}
+	setEnd := func(t time.Time) {
+		if stmt.end.IsZero() || t.Before(stmt.end) {
+			stmt.end = t
+		}
+	}
+
+	if p.keyword("between") {
+		start, err := p.parseTimestamp()
+		if err != nil {
+			return err
+		}
+		if err := p.expectKeyword("and"); err != nil {
+			return err
+		}
+		end, err := p.parseTimestamp()
+		if err != nil {
+			return err
+		}
+		setStart(start)
+		setEnd(end)
+		return nil
+	}
+
+	op := p.next()
+	if op.kind != tokenSymbol {
+		return newSQLError(sqlStateSyntaxError, "syntax error at or near %q", op.text)
+	}
+	ts, err := p.parseTimestamp()
+	if err != nil {
+		return err
+	}
+
+	switch op.text {
+	case ">=":
+		setStart(ts)
+	case ">":
+		setStart(ts.Add(time.Millisecond))
+	case "<=":
+		setEnd(ts)
+	case "<":
+		setEnd(ts.Add(-time.Millisecond))
+	default:
+		return newSQLError(sqlStateFeatureNotSupported, "operator %s is not supported on the %s column", op.text, timestampColumn)
+	}
+	return nil
+}
+
+func (p *sqlParser) parseTimestamp() (time.Time, error) {
+	t := p.next()
+
+	switch t.kind {
+	case tokenNumber:
+		secs, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return time.Time{}, newSQLError(sqlStateInvalidDatetimeFormat, "invalid timestamp %q", t.text)
+		}
+		return time.UnixMilli(int64(secs * 1000)).UTC(), nil
+
+	case tokenString:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999Z07:00", "2006-01-02 15:04:05.999999999", "2006-01-02"} {
+			if ts, err := time.Parse(layout, t.text); err == nil {
+				return ts.UTC(), nil
+			}
+		}
+		return time.Time{}, newSQLError(sqlStateInvalidDatetimeFormat, "invalid timestamp %q", t.text)
+
+	case tokenIdentifier:
+		if !strings.EqualFold(t.text, "now") || !p.symbol("(") || !p.symbol(")") {
+			break
+		}
+		ts := p.now
+		if p.symbol("-") {
+			if err := p.expectKeyword("interval"); err != nil {
+				return time.Time{}, err
+			}
+			d := p.next()
+			dur, err := model.ParseDuration(d.text)
+			if d.kind != tokenString || err != nil {
+				return time.Time{}, newSQLError(sqlStateInvalidDatetimeFormat, "invalid interval %q", d.text)
+			}
+			ts = ts.Add(-time.Duration(dur))
+		}
+		return ts.UTC(), nil
+	}
+
+	return time.Time{}, newSQLError(sqlStateSyntaxError, "syntax error at or near %q", t.text)
+}
+
+func (p *sqlParser) parseLabelCondition(name string) (*labels.Matcher, error) {
+	var (
+		matchType labels.MatchType
+		value     string
+	)
+
+	switch {
+	case p.keyword("in"):
+		values, err := p.parseStringList()
+		if err != nil {
+			return nil, err
+		}
+		for i := range values {
+			values[i] = regexp.QuoteMeta(values[i])
+		}
+		matchType, value = labels.MatchRegexp, strings.Join(values, "|")
+
+	case p.keyword("like"):
+		matchType = labels.MatchRegexp
+		pattern, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		value = likeToRegexp(pattern)
+
+	case p.keyword("not"):
+		if err := p.expectKeyword("like"); err != nil {
+			return nil, err
+		}
+		matchType = labels.MatchNotRegexp
+		pattern, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		value = likeToRegexp(pattern)
+
+	default:
+		op := p.next()
+		switch {
+		case op.kind == tokenSymbol && op.text == "=":
+			matchType = labels.MatchEqual
+		case op.kind == tokenSymbol && (op.text == "!=" || op.text == "<>"):
+			matchType = labels.MatchNotEqual
+		case op.kind == tokenSymbol && op.text == "~":
+			matchType = labels.MatchRegexp
+		case op.kind == tokenSymbol && op.text == "!~":
+			matchType = labels.MatchNotRegexp
+		default:
+			return nil, newSQLError(sqlStateSyntaxError, "syntax error at or near %q", op.text)
+		}
+
+		var err error
+		if value, err = p.parseString(); err != nil {
+			return nil, err
+		}
+	}
+
+	m, err := labels.NewMatcher(matchType, name, value)
+	if err != nil {
+		return nil, newSQLError(sqlStateInvalidRegularExpression, "invalid regular expression %q: %s", value, err)
+	}
+	return m, nil
+}
+
+func (p *sqlParser) parseString() (string, error) {
+	t := p.next()
+	if t.kind != tokenString {
+		return "", newSQLError(sqlStateSyntaxError, "syntax error at or near %q: label values must be string literals", t.text)
+	}
+	return t.text, nil
+}
+
+func (p *sqlParser) parseStringList() ([]string, error) {
+	if !p.symbol("(") {
+		return nil, p.syntaxError()
+	}
+
+	var values []string
+	for {
+		v, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+
+		if p.symbol(")") {
+			return values, nil
+		}
+		if !p.symbol(",") {
+			return nil, p.syntaxError()
+		}
+	}
+}
+
+// likeToRegexp converts a LIKE pattern to an equivalent regular expression. The regular expression is
+// anchored by the label matcher.
+func likeToRegexp(pattern string) string {
+	var sb strings.Builder
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			sb.WriteString(".*")
+		case '_':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	return sb.String()
+}
+
+var reservedKeywords = map[string]struct{}{
+	"select": {}, "from": {}, "where": {}, "and": {}, "limit": {}, "between": {}, "in": {}, "like": {}, "not": {},
+}
+
+func isReservedKeyword(s string) bool {
+	_, ok := reservedKeywords[strings.ToLower(s)]
+	return ok
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdentifier
+	tokenQuotedIdentifier
+	tokenString
+	tokenNumber
+	tokenSymbol
+)
+
+type sqlToken struct {
+	kind tokenKind
+	text string
+}
+
+// tokenize splits a query into tokens. String literals and quoted identifiers are unquoted.
+func tokenize(query string) ([]sqlToken, error) {
+	var (
+		tokens []sqlToken
+		runes  = []rune(query)
+	)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '\'' || r == '"':
+			// Quotes are escaped by doubling them.
+			var sb strings.Builder
+			j := i + 1
+			for ; j < len(runes); j++ {
+				if runes[j] == r {
+					if j+1 < len(runes) && runes[j+1] == r {
+						sb.WriteRune(r)
+						j++
+						continue
+					}
+					break
+				}
+				sb.WriteRune(runes[j])
+			}
+			if j >= len(runes) {
+				return nil, newSQLError(sqlStateSyntaxError, "unterminated quoted string at or near %q", string(runes[i:]))
+			}
+
+			kind := tokenString
+			if r == '"' {
+				kind = tokenQuotedIdentifier
+			}
+			tokens = append(tokens, sqlToken{kind: kind, text: sb.String()})
+			i = j + 1
+
+		case r == '_' || unicode.IsLetter(r):
+			j := i + 1
+			for j < len(runes) && (runes[j] == '_' || unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j])) {
+				j++
+			}
+			tokens = append(tokens, sqlToken{kind: tokenIdentifier, text: string(runes[i:j])})
+			i = j
+
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			j := i + 1
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, sqlToken{kind: tokenNumber, text: string(runes[i:j])})
+			i = j
+
+		default:
+			sym := string(r)
+			if i+1 < len(runes) {
+				switch two := string(runes[i : i+2]); two {
+				case "!=", "<>", "<=", ">=", "!~":
+					sym = two
+				}
+			}
+			if !strings.Contains("=<>!~,*();-", sym[:1]) || sym == "!" {
+				return nil, newSQLError(sqlStateSyntaxError, "syntax error at or near %q", sym)
+			}
+			tokens = append(tokens, sqlToken{kind: tokenSymbol, text: sym})
+			i += len([]rune(sym))
+		}
+	}
+
+	return append(tokens, sqlToken{kind: tokenEOF}), nil
+}
+
+// Postgres error codes (SQLSTATE) returned to the clients.
+const (
+	sqlStateSyntaxError              = "42601"
+	sqlStateUndefinedTable           = "42P01"
+	sqlStateFeatureNotSupported      = "0A000"
+	sqlStateDataException            = "22000"
+	sqlStateInvalidDatetimeFormat    = "22007"
+	sqlStateInvalidRegularExpression = "2201B"
+	sqlStateInternalError            = "XX000"
+	sqlStateProtocolViolation        = "08P01"
+)
+
+// sqlError is an error returned to the client with its SQLSTATE code.
+type sqlError struct {
+	code    string
+	message string
+}
+
+func newSQLError(code, format string, args ...interface{}) error {
+	return &sqlError{code: code, message: fmt.Sprintf(format, args...)}
+}
+
+func (e *sqlError) Error() string {
+	return e.message
+}
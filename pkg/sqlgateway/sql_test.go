@@ -0,0 +1,153 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package sqlgateway
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSelect(t *testing.T) {
+	now := time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		query            string
+		expected         *selectStatement
+		expectedErrState string
+	}{
+		"all columns with a time range": {
+			query: `SELECT * FROM series WHERE __name__ = 'up' AND timestamp >= '2022-05-01T10:00:00Z' AND timestamp < '2022-05-01 11:00:00';`,
+			expected: &selectStatement{
+				matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "__name__", "up")},
+				start:    time.Date(2022, 5, 1, 10, 0, 0, 0, time.UTC),
+				end:      time.Date(2022, 5, 1, 10, 59, 59, 999e6, time.UTC),
+			},
+		},
+		"selected columns, keywords in lower case and quoted identifiers": {
+			query: `select timestamp, "value", job from series where "__name__" = 'up' and timestamp between 1651392000 and 1651395600 limit 10`,
+			expected: &selectStatement{
+				columns:  []string{"timestamp", "value", "job"},
+				matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "__name__", "up")},
+				start:    time.Unix(1651392000, 0).UTC(),
+				end:      time.Unix(1651395600, 0).UTC(),
+				limit:    10,
+			},
+		},
+		"label matchers": {
+			query: `SELECT * FROM series WHERE job != 'a' AND env <> 'b' AND instance ~ 'host-.*' AND pod !~ 'p.+' AND "namespace" IN ('x', 'y.z') AND cluster LIKE 'eu-%_' AND zone NOT LIKE '%a' AND timestamp > now() - interval '1h'`,
+			expected: &selectStatement{
+				matchers: []*labels.Matcher{
+					labels.MustNewMatcher(labels.MatchNotEqual, "job", "a"),
+					labels.MustNewMatcher(labels.MatchNotEqual, "env", "b"),
+					labels.MustNewMatcher(labels.MatchRegexp, "instance", "host-.*"),
+					labels.MustNewMatcher(labels.MatchNotRegexp, "pod", "p.+"),
+					labels.MustNewMatcher(labels.MatchRegexp, "namespace", `x|y\.z`),
+					labels.MustNewMatcher(labels.MatchRegexp, "cluster", "eu-.*."),
+					labels.MustNewMatcher(labels.MatchNotRegexp, "zone", ".*a"),
+				},
+				start: now.Add(-time.Hour + time.Millisecond),
+			},
+		},
+		"string literals with escaped quotes": {
+			query: `SELECT * FROM series WHERE job = 'it''s' AND timestamp >= now() - interval '5m'`,
+			expected: &selectStatement{
+				matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "job", "it's")},
+				start:    now.Add(-5 * time.Minute),
+			},
+		},
+		"the most restrictive time bounds are used": {
+			query: `SELECT * FROM series WHERE job = 'a' AND timestamp >= 100 AND timestamp >= 200 AND timestamp <= 500 AND timestamp <= 400`,
+			expected: &selectStatement{
+				matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "job", "a")},
+				start:    time.Unix(200, 0).UTC(),
+				end:      time.Unix(400, 0).UTC(),
+			},
+		},
+		"unknown table": {
+			query:            `SELECT * FROM pg_catalog WHERE job = 'a' AND timestamp >= 100`,
+			expectedErrState: sqlStateUndefinedTable,
+		},
+		"missing lower bound on the timestamp": {
+			query:            `SELECT * FROM series WHERE job = 'a'`,
+			expectedErrState: sqlStateFeatureNotSupported,
+		},
+		"missing label conditions": {
+			query:            `SELECT * FROM series WHERE timestamp >= 100`,
+			expectedErrState: sqlStateFeatureNotSupported,
+		},
+		"only matchers matching the empty string": {
+			query:            `SELECT * FROM series WHERE job = '' AND timestamp >= 100`,
+			expectedErrState: sqlStateFeatureNotSupported,
+		},
+		"condition on the value column": {
+			query:            `SELECT * FROM series WHERE job = 'a' AND value > 1 AND timestamp >= 100`,
+			expectedErrState: sqlStateFeatureNotSupported,
+		},
+		"equality on the timestamp column": {
+			query:            `SELECT * FROM series WHERE job = 'a' AND timestamp = 100`,
+			expectedErrState: sqlStateFeatureNotSupported,
+		},
+		"invalid timestamp": {
+			query:            `SELECT * FROM series WHERE job = 'a' AND timestamp >= 'yesterday'`,
+			expectedErrState: sqlStateInvalidDatetimeFormat,
+		},
+		"invalid regular expression": {
+			query:            `SELECT * FROM series WHERE job ~ '(' AND timestamp >= 100`,
+			expectedErrState: sqlStateInvalidRegularExpression,
+		},
+		"unterminated string": {
+			query:            `SELECT * FROM series WHERE job = 'a`,
+			expectedErrState: sqlStateSyntaxError,
+		},
+		"unsupported clause": {
+			query:            `SELECT * FROM series WHERE job = 'a' AND timestamp >= 100 ORDER BY timestamp`,
+			expectedErrState: sqlStateSyntaxError,
+		},
+		"OR conditions": {
+			query:            `SELECT * FROM series WHERE job = 'a' OR job = 'b' AND timestamp >= 100`,
+			expectedErrState: sqlStateSyntaxError,
+		},
+		"not a SELECT": {
+			query:            `DELETE FROM series`,
+			expectedErrState: sqlStateSyntaxError,
+		},
+	}
+
+	for name, testData := range tests {
+		t.Run(name, func(t *testing.T) {
+			stmt, err := parseSelect(testData.query, now)
+
+			if testData.expectedErrState != "" {
+				require.Error(t, err)
+				sqlErr, ok := err.(*sqlError)
+				require.True(t, ok)
+				assert.Equal(t, testData.expectedErrState, sqlErr.code, sqlErr.message)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, testData.expected, stmt)
+		})
+	}
+}
+
+func TestSelectStatement_PromQL(t *testing.T) {
+	stmt := &selectStatement{
+		matchers: []*labels.Matcher{
+			labels.MustNewMatcher(labels.MatchEqual, "__name__", "up"),
+			labels.MustNewMatcher(labels.MatchRegexp, "job", `a"b|c`),
+		},
+		start: time.Unix(100, 0),
+	}
+
+	qs, err := stmt.promQL(time.Unix(3700, 500e6))
+	require.NoError(t, err)
+	assert.Equal(t, `{__name__="up",job=~"a\"b|c"}[1h500ms]`, qs)
+
+	_, err = stmt.promQL(time.Unix(100, 0))
+	require.Error(t, err)
+}
@@ -10,6 +10,7 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/go-kit/log"
@@ -44,7 +45,10 @@ const (
 var (
 	SupportedBackends = []string{S3, GCS, Azure, Swift, Filesystem}
 
-	ErrUnsupportedStorageBackend = errors.New("unsupported storage backend")
+	validStoragePrefix = regexp.MustCompile(`^[\da-zA-Z]*$`)
+
+	ErrUnsupportedStorageBackend        = errors.New("unsupported storage backend")
+	ErrInvalidCharactersInStoragePrefix = errors.New("storage prefix contains invalid characters, it may only contain digits and English alphabet letters")
 )
 
 // Config holds configuration for accessing long-term storage.
@@ -57,6 +61,8 @@ type Config struct {
 	Swift      swift.Config      `yaml:"swift"`
 	Filesystem filesystem.Config `yaml:"filesystem"`
 
+	StoragePrefix string `yaml:"storage_prefix" category:"experimental"`
+
 	// Not used internally, meant to allow callers to wrap Buckets
 	// created using this config
 	Middlewares []func(objstore.Bucket) (objstore.Bucket, error) `yaml:"-"`
@@ -83,6 +89,7 @@ func (cfg *Config) RegisterFlagsWithPrefixAndDefaultDirectory(prefix, dir string
 	cfg.Swift.RegisterFlagsWithPrefix(prefix, f)
 	cfg.Filesystem.RegisterFlagsWithPrefixAndDefaultDirectory(prefix, dir, f)
 
+	f.StringVar(&cfg.StoragePrefix, prefix+"storage-prefix", "", "Prefix for all objects stored in the backend storage. It allows multiple Mimir clusters, or multiple storages of the same cluster, to share a single bucket. For simplicity, it may only contain digits and English alphabet letters.")
 	f.StringVar(&cfg.Backend, prefix+"backend", Filesystem, fmt.Sprintf("Backend storage to use. Supported backends are: %s.", strings.Join(cfg.supportedBackends(), ", ")))
 }
 
@@ -95,12 +102,28 @@ func (cfg *Config) Validate() error {
 		return ErrUnsupportedStorageBackend
 	}
 
+	if !validStoragePrefix.MatchString(cfg.StoragePrefix) {
+		return ErrInvalidCharactersInStoragePrefix
+	}
+
 	if cfg.Backend == S3 {
 		if err := cfg.S3.Validate(); err != nil {
 			return err
 		}
 	}
 
+	if cfg.Backend == GCS {
+		if err := cfg.GCS.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Backend == Swift {
+		if err := cfg.Swift.Validate(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -125,6 +148,10 @@ func NewClient(ctx context.Context, cfg Config, name string, logger log.Logger,
 		return nil, err
 	}
 
+	if cfg.StoragePrefix != "" {
+		client = NewPrefixedBucketClient(client, cfg.StoragePrefix)
+	}
+
 	client = objstore.NewTracingBucket(bucketWithMetrics(client, name, reg))
 
 	// Wrap the client with any provided middleware
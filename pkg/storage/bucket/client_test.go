@@ -8,12 +8,17 @@ package bucket
 import (
 	"context"
 	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 
 	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/objstore"
 	yaml "gopkg.in/yaml.v2"
 
 	util_log "github.com/grafana/mimir/pkg/util/log"
@@ -51,6 +56,11 @@ gcs:
 
 	configWithUnknownBackend = `
 backend: unknown
+`
+
+	configWithFilesystemBackendAndStoragePrefix = `
+backend: filesystem
+storage_prefix: cell1
 `
 )
 
@@ -100,6 +110,73 @@ func TestNewClient(t *testing.T) {
 	}
 }
 
+func TestNewClient_StoragePrefix(t *testing.T) {
+	cfg := Config{}
+	flagext.DefaultValues(&cfg)
+	require.NoError(t, yaml.Unmarshal([]byte(configWithFilesystemBackendAndStoragePrefix), &cfg))
+	require.NoError(t, cfg.Validate())
+	cfg.Filesystem.Directory = t.TempDir()
+
+	bucketClient, err := NewClient(context.Background(), cfg, "test", util_log.Logger, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, bucketClient.Close()) })
+
+	require.NoError(t, bucketClient.Upload(context.Background(), "user-1/object", strings.NewReader("content")))
+
+	// The object is stored under the prefix.
+	content, err := os.ReadFile(filepath.Join(cfg.Filesystem.Directory, "cell1", "user-1", "object"))
+	require.NoError(t, err)
+	assert.Equal(t, "content", string(content))
+
+	// The prefix is transparent to the bucket client users.
+	var names []string
+	require.NoError(t, bucketClient.Iter(context.Background(), "", func(name string) error {
+		names = append(names, name)
+		return nil
+	}))
+	assert.Equal(t, []string{"user-1/"}, names)
+}
+
+func TestNewClient_AcceptanceTest(t *testing.T) {
+	cfg := Config{}
+	flagext.DefaultValues(&cfg)
+	cfg.Backend = Filesystem
+	cfg.Filesystem.Directory = t.TempDir()
+	require.NoError(t, cfg.Validate())
+
+	bucketClient, err := NewClient(context.Background(), cfg, "test", util_log.Logger, prometheus.NewPedanticRegistry())
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, bucketClient.Close()) })
+
+	objstore.AcceptanceTest(t, bucketClient)
+}
+
+func TestConfig_Validate_StoragePrefix(t *testing.T) {
+	for prefix, expectedErr := range map[string]error{
+		"":          nil,
+		"cell1":     nil,
+		"Cell1":     nil,
+		"cell-1":    ErrInvalidCharactersInStoragePrefix,
+		"cell/1":    ErrInvalidCharactersInStoragePrefix,
+		"cell1/":    ErrInvalidCharactersInStoragePrefix,
+		"../cell1":  ErrInvalidCharactersInStoragePrefix,
+		"cell 1":    ErrInvalidCharactersInStoragePrefix,
+		"cell1\n":   ErrInvalidCharactersInStoragePrefix,
+		"ćell1":     ErrInvalidCharactersInStoragePrefix,
+		"cell_1":    ErrInvalidCharactersInStoragePrefix,
+		"cell.1":    ErrInvalidCharactersInStoragePrefix,
+		"cell1\x00": ErrInvalidCharactersInStoragePrefix,
+	} {
+		t.Run(prefix, func(t *testing.T) {
+			cfg := Config{}
+			flagext.DefaultValues(&cfg)
+			cfg.StoragePrefix = prefix
+
+			assert.Equal(t, expectedErr, cfg.Validate())
+		})
+	}
+}
+
 func TestClientMock_MockGet(t *testing.T) {
 	expected := "body"
 
@@ -0,0 +1,265 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+// Provenance-includes-location: https://github.com/thanos-io/thanos/blob/main/pkg/objstore/gcs/gcs.go
+// Provenance-includes-license: Apache-2.0
+// Provenance-includes-copyright: The Thanos Authors.
+
+package gcs
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/backoff"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/iterator"
+)
+
+// Bucket implements the objstore.Bucket interface against GCS. Unlike the Thanos GCS client, each operation
+// can be bounded by a timeout and retried.
+type Bucket struct {
+	logger log.Logger
+	bkt    *storage.BucketHandle
+	name   string
+	closer io.Closer
+
+	operationTimeout time.Duration
+	retries          backoff.Config
+}
+
+// Name returns the bucket name for gcs.
+func (b *Bucket) Name() string {
+	return b.name
+}
+
+// Iter calls f for each entry in the given directory. The argument to f is the full
+// object name including the prefix of the inspected directory.
+func (b *Bucket) Iter(ctx context.Context, dir string, f func(string) error, options ...objstore.IterOption) error {
+	// Ensure the object name actually ends with a dir suffix. Otherwise we'll just iterate the
+	// object itself as one prefix item.
+	if dir != "" {
+		dir = strings.TrimSuffix(dir, objstore.DirDelim) + objstore.DirDelim
+	}
+
+	// If recursive iteration is enabled we should pass an empty delimiter.
+	delimiter := objstore.DirDelim
+	if objstore.ApplyIterOptions(options...).Recursive {
+		delimiter = ""
+	}
+
+	// The iteration is only retried until the first entry is passed to f, to not pass any entry twice.
+	iterated := false
+	return b.withRetries(ctx, "iter", func(ctx context.Context) (bool, error) {
+		it := b.bkt.Objects(ctx, &storage.Query{
+			Prefix:    dir,
+			Delimiter: delimiter,
+		})
+		for {
+			select {
+			case <-ctx.Done():
+				return !iterated, ctx.Err()
+			default:
+			}
+			attrs, err := it.Next()
+			if err == iterator.Done {
+				return false, nil
+			}
+			if err != nil {
+				return !iterated, err
+			}
+			iterated = true
+			if err := f(attrs.Prefix + attrs.Name); err != nil {
+				return false, err
+			}
+		}
+	})
+}
+
+// Get returns a reader for the given object name.
+func (b *Bucket) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	return b.getRange(ctx, "get", name, 0, -1)
+}
+
+// GetRange returns a new range reader for the given object name and range.
+func (b *Bucket) GetRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	return b.getRange(ctx, "get_range", name, off, length)
+}
+
+func (b *Bucket) getRange(ctx context.Context, op, name string, off, length int64) (io.ReadCloser, error) {
+	var reader io.ReadCloser
+
+	err := b.withRetriesCancel(ctx, op, func(ctx context.Context, cancel context.CancelFunc) (bool, error) {
+		r, err := b.bkt.Object(name).NewRangeReader(ctx, off, length)
+		if err != nil {
+			return true, err
+		}
+
+		// The operation timeout covers reading the object, so the context is only canceled once the reader is closed.
+		reader = &cancelOnCloseReader{ReadCloser: r, cancel: cancel}
+		return false, nil
+	})
+
+	return reader, err
+}
+
+// Attributes returns information about the specified object.
+func (b *Bucket) Attributes(ctx context.Context, name string) (objstore.ObjectAttributes, error) {
+	var attrs *storage.ObjectAttrs
+
+	err := b.withRetries(ctx, "attributes", func(ctx context.Context) (retry bool, err error) {
+		attrs, err = b.bkt.Object(name).Attrs(ctx)
+		return true, err
+	})
+	if err != nil {
+		return objstore.ObjectAttributes{}, err
+	}
+
+	return objstore.ObjectAttributes{
+		Size:         attrs.Size,
+		LastModified: attrs.Updated,
+	}, nil
+}
+
+// Exists checks if the given object exists.
+func (b *Bucket) Exists(ctx context.Context, name string) (bool, error) {
+	if _, err := b.Attributes(ctx, name); err == nil {
+		return true, nil
+	} else if !b.IsObjNotFoundErr(err) {
+		return false, err
+	}
+	return false, nil
+}
+
+// Upload writes the file specified in src to remote GCS location specified as target.
+func (b *Bucket) Upload(ctx context.Context, name string, r io.Reader) error {
+	// The upload can only be retried if the content can be read again from the start.
+	seeker, seekable := r.(io.Seeker)
+	start := int64(0)
+	if seekable {
+		var err error
+		if start, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			seekable = false
+		}
+	}
+
+	attempted := false
+	return b.withRetries(ctx, "upload", func(ctx context.Context) (bool, error) {
+		if attempted {
+			if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+				return false, err
+			}
+		}
+		attempted = true
+
+		// The writer is aborted once the context of the attempt is canceled, if not closed yet.
+		w := b.bkt.Object(name).NewWriter(ctx)
+		if _, err := io.Copy(w, r); err != nil {
+			return seekable, err
+		}
+		return seekable, w.Close()
+	})
+}
+
+// Delete removes the object with the given name.
+func (b *Bucket) Delete(ctx context.Context, name string) error {
+	return b.withRetries(ctx, "delete", func(ctx context.Context) (bool, error) {
+		return true, b.bkt.Object(name).Delete(ctx)
+	})
+}
+
+// IsObjNotFoundErr returns true if error means that object is not found. Relevant to Get operations.
+func (b *Bucket) IsObjNotFoundErr(err error) bool {
+	return errors.Is(err, storage.ErrObjectNotExist)
+}
+
+func (b *Bucket) Close() error {
+	return b.closer.Close()
+}
+
+// withRetries runs the operation, bounding each attempt by the operation timeout, and retries it while
+// it fails with a retriable error. The operation returns whether it can be retried if it fails.
+func (b *Bucket) withRetries(ctx context.Context, op string, f func(ctx context.Context) (bool, error)) error {
+	return b.withRetriesCancel(ctx, op, func(ctx context.Context, cancel context.CancelFunc) (bool, error) {
+		defer cancel()
+		return f(ctx)
+	})
+}
+
+// withRetriesCancel is like withRetries, but the operation is responsible for canceling the context of
+// the attempt if it succeeds.
+func (b *Bucket) withRetriesCancel(ctx context.Context, op string, f func(ctx context.Context, cancel context.CancelFunc) (bool, error)) error {
+	retries := backoff.New(ctx, b.retries)
+
+	var err error
+	for retries.Ongoing() {
+		var (
+			attemptCtx context.Context
+			cancel     context.CancelFunc
+		)
+		if b.operationTimeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, b.operationTimeout)
+		} else {
+			attemptCtx, cancel = context.WithCancel(ctx)
+		}
+
+		var retriable bool
+		retriable, err = f(attemptCtx, cancel)
+		if err == nil {
+			return nil
+		}
+
+		// The attempt timed out if its context expired while the parent context is still valid.
+		timedOut := errors.Is(attemptCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil
+		cancel()
+
+		if !retriable || !(timedOut || isRetriableError(err)) {
+			return err
+		}
+		if timedOut {
+			err = errors.Wrapf(err, "GCS %s operation timed out after %s", op, b.operationTimeout)
+		}
+
+		retries.Wait()
+		if retries.Ongoing() {
+			level.Warn(b.logger).Log("msg", "retrying failed GCS operation", "operation", op, "bucket", b.name, "err", err)
+		}
+	}
+
+	if err == nil {
+		// The context was canceled before the first attempt.
+		return ctx.Err()
+	}
+	return err
+}
+
+// isRetriableError returns whether the error is transient, and the operation can be retried.
+func isRetriableError(err error) bool {
+	if errors.Is(err, storage.ErrObjectNotExist) || errors.Is(err, storage.ErrBucketNotExist) || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Code == 429 || apiErr.Code >= 500
+	}
+
+	// Other errors are connection errors, or the response body being interrupted.
+	return true
+}
+
+// cancelOnCloseReader cancels the context of the request once closed.
+type cancelOnCloseReader struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (r *cancelOnCloseReader) Close() error {
+	defer r.cancel()
+	return r.ReadCloser.Close()
+}
@@ -7,26 +7,87 @@ package gcs
 
 import (
 	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"runtime"
 
+	"cloud.google.com/go/storage"
 	"github.com/go-kit/log"
+	"github.com/grafana/dskit/backoff"
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/version"
 	"github.com/thanos-io/thanos/pkg/objstore"
-	"github.com/thanos-io/thanos/pkg/objstore/gcs"
-	yaml "gopkg.in/yaml.v2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/option"
+	htransport "google.golang.org/api/transport/http"
 )
 
 // NewBucketClient creates a new GCS bucket client
 func NewBucketClient(ctx context.Context, cfg Config, name string, logger log.Logger) (objstore.Bucket, error) {
-	bucketConfig := gcs.Config{
-		Bucket:         cfg.BucketName,
-		ServiceAccount: cfg.ServiceAccount.Value,
+	if cfg.BucketName == "" {
+		return nil, errors.New("missing Google Cloud Storage bucket name for stored blocks")
 	}
 
-	// Thanos currently doesn't support passing the config as is, but expects a YAML,
-	// so we're going to serialize it.
-	serialized, err := yaml.Marshal(bucketConfig)
+	opts, err := clientOptions(ctx, cfg, name)
 	if err != nil {
 		return nil, err
 	}
 
-	return gcs.NewBucket(ctx, logger, serialized, name)
+	gcsClient, err := storage.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Bucket{
+		logger: logger,
+		bkt:    gcsClient.Bucket(cfg.BucketName),
+		name:   cfg.BucketName,
+		closer: gcsClient,
+
+		operationTimeout: cfg.OperationTimeout,
+		retries: backoff.Config{
+			MinBackoff: cfg.MinRetryBackoff,
+			MaxBackoff: cfg.MaxRetryBackoff,
+			MaxRetries: cfg.MaxRetries + 1, // The first attempt is counted too.
+		},
+	}, nil
+}
+
+func clientOptions(ctx context.Context, cfg Config, name string) ([]option.ClientOption, error) {
+	var opts []option.ClientOption
+
+	if cfg.WithoutAuthentication {
+		opts = append(opts, option.WithoutAuthentication())
+	} else if cfg.ServiceAccount.Value != "" {
+		// If ServiceAccount is provided, use them in GCS client, otherwise fallback to Google default logic.
+		credentials, err := google.CredentialsFromJSON(ctx, []byte(cfg.ServiceAccount.Value), storage.ScopeFullControl)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create credentials from JSON")
+		}
+		opts = append(opts, option.WithCredentials(credentials))
+	}
+
+	if cfg.Endpoint != "" {
+		opts = append(opts, option.WithEndpoint(cfg.Endpoint))
+	}
+
+	opts = append(opts,
+		option.WithUserAgent(fmt.Sprintf("mimir-%s/%s (%s)", name, version.Version, runtime.Version())),
+	)
+
+	if cfg.InsecureSkipVerify {
+		// The HTTP client passed to the GCS client is used as is, so the transport authenticating the
+		// requests is built here, on top of the base transport skipping the TLS verification.
+		base := http.DefaultTransport.(*http.Transport).Clone()
+		base.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec
+
+		transport, err := htransport.NewTransport(ctx, base, append(opts, option.WithScopes(storage.ScopeFullControl))...)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, option.WithHTTPClient(&http.Client{Transport: transport}))
+	}
+
+	return opts, nil
 }
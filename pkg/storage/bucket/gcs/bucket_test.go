@@ -0,0 +1,175 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package gcs
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+// newFakeGCSServer returns a server emulating the GCS API for a bucket containing the input objects. The first
+// requests hang until the client gives up.
+func newFakeGCSServer(t *testing.T, bucket string, objects map[string]string, hangingRequests int) (*httptest.Server, *atomic.Int64) {
+	requests := atomic.NewInt64(0)
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Inc() <= int64(hangingRequests) {
+			<-r.Context().Done()
+			return
+		}
+
+		switch {
+		// Objects metadata, through the JSON API.
+		case strings.HasPrefix(r.URL.Path, "/storage/v1/b/"+bucket+"/o/"):
+			name := strings.TrimPrefix(r.URL.Path, "/storage/v1/b/"+bucket+"/o/")
+			content, ok := objects[name]
+			if !ok {
+				http.Error(w, `{"error":{"code":404,"message":"Not Found"}}`, http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			require.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{
+				"bucket":  bucket,
+				"name":    name,
+				"size":    strconv.Itoa(len(content)),
+				"updated": "2022-01-01T00:00:00Z",
+			}))
+
+		// Objects content, through the XML API.
+		case strings.HasPrefix(r.URL.Path, "/"+bucket+"/"):
+			content, ok := objects[strings.TrimPrefix(r.URL.Path, "/"+bucket+"/")]
+			if !ok {
+				http.Error(w, "Not Found", http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(content))
+
+		default:
+			http.Error(w, "Not Found", http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, requests
+}
+
+func newTestBucketClient(t *testing.T, endpoint string, setup func(cfg *Config)) *Bucket {
+	cfg := Config{}
+	flagext.DefaultValues(&cfg)
+	cfg.BucketName = "test-bucket"
+	cfg.Endpoint = endpoint + "/storage/v1/"
+	cfg.InsecureSkipVerify = true
+	cfg.WithoutAuthentication = true
+	setup(&cfg)
+	require.NoError(t, cfg.Validate())
+
+	client, err := NewBucketClient(context.Background(), cfg, "test", log.NewNopLogger())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	return client.(*Bucket)
+}
+
+func TestBucket_CustomEndpoint(t *testing.T) {
+	srv, _ := newFakeGCSServer(t, "test-bucket", map[string]string{"dir/object": "content"}, 0)
+	bkt := newTestBucketClient(t, srv.URL, func(cfg *Config) {})
+	ctx := context.Background()
+
+	reader, err := bkt.Get(ctx, "dir/object")
+	require.NoError(t, err)
+	content, err := ioutil.ReadAll(reader)
+	require.NoError(t, err)
+	require.NoError(t, reader.Close())
+	assert.Equal(t, "content", string(content))
+
+	attrs, err := bkt.Attributes(ctx, "dir/object")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("content")), attrs.Size)
+	assert.Equal(t, time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), attrs.LastModified)
+
+	exists, err := bkt.Exists(ctx, "dir/missing")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	_, err = bkt.Get(ctx, "dir/missing")
+	assert.True(t, bkt.IsObjNotFoundErr(err))
+}
+
+func TestBucket_OperationTimeoutAndRetries(t *testing.T) {
+	tests := map[string]struct {
+		maxRetries       int
+		hangingRequests  int
+		expectedErr      bool
+		expectedRequests int64
+	}{
+		"should succeed without retries if the first attempt succeeds": {
+			maxRetries:       2,
+			expectedRequests: 1,
+		},
+		"should retry the attempts timing out": {
+			maxRetries:       2,
+			hangingRequests:  2,
+			expectedRequests: 3,
+		},
+		"should fail once the retries are exhausted": {
+			maxRetries:       2,
+			hangingRequests:  3,
+			expectedErr:      true,
+			expectedRequests: 3,
+		},
+		"should fail on timeout without retries": {
+			maxRetries:       0,
+			hangingRequests:  1,
+			expectedErr:      true,
+			expectedRequests: 1,
+		},
+	}
+
+	for testName, testData := range tests {
+		testData := testData
+
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			srv, requests := newFakeGCSServer(t, "test-bucket", map[string]string{"object": "content"}, testData.hangingRequests)
+			bkt := newTestBucketClient(t, srv.URL, func(cfg *Config) {
+				cfg.OperationTimeout = 200 * time.Millisecond
+				cfg.MaxRetries = testData.maxRetries
+				cfg.MinRetryBackoff = 10 * time.Millisecond
+				cfg.MaxRetryBackoff = 10 * time.Millisecond
+			})
+
+			_, err := bkt.Attributes(context.Background(), "object")
+			if testData.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, testData.expectedRequests, requests.Load())
+		})
+	}
+}
+
+func TestBucket_ShouldNotRetryNotFoundErrors(t *testing.T) {
+	srv, requests := newFakeGCSServer(t, "test-bucket", nil, 0)
+	bkt := newTestBucketClient(t, srv.URL, func(cfg *Config) {
+		cfg.MaxRetries = 2
+	})
+
+	_, err := bkt.Attributes(context.Background(), "missing")
+	assert.True(t, bkt.IsObjNotFoundErr(err))
+	assert.Equal(t, int64(1), requests.Load())
+}
@@ -6,15 +6,34 @@
 package gcs
 
 import (
+	"errors"
 	"flag"
+	"net/url"
+	"time"
 
 	"github.com/grafana/dskit/flagext"
 )
 
+var (
+	errInvalidEndpoint         = errors.New("invalid GCS endpoint, it must be an https:// URL")
+	errInvalidMaxRetries       = errors.New("invalid GCS max retries, it must be greater than or equal to 0")
+	errInvalidRetryBackoff     = errors.New("invalid GCS retry backoff, the min backoff must be greater than 0 and not greater than the max backoff")
+	errInvalidOperationTimeout = errors.New("invalid GCS operation timeout, it must be greater than or equal to 0")
+)
+
 // Config holds the config options for GCS backend
 type Config struct {
 	BucketName     string         `yaml:"bucket_name"`
 	ServiceAccount flagext.Secret `yaml:"service_account"`
+
+	Endpoint              string `yaml:"endpoint" category:"experimental"`
+	InsecureSkipVerify    bool   `yaml:"insecure_skip_verify" category:"experimental"`
+	WithoutAuthentication bool   `yaml:"without_authentication" category:"experimental"`
+
+	OperationTimeout time.Duration `yaml:"operation_timeout" category:"experimental"`
+	MaxRetries       int           `yaml:"max_retries" category:"experimental"`
+	MinRetryBackoff  time.Duration `yaml:"min_retry_backoff" category:"experimental"`
+	MaxRetryBackoff  time.Duration `yaml:"max_retry_backoff" category:"experimental"`
 }
 
 // RegisterFlags registers the flags for GCS storage
@@ -26,4 +45,30 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 func (cfg *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
 	f.StringVar(&cfg.BucketName, prefix+"gcs.bucket-name", "", "GCS bucket name")
 	f.Var(&cfg.ServiceAccount, prefix+"gcs.service-account", "JSON representing either a Google Developers Console client_credentials.json file or a Google Developers service account key file. If empty, fallback to Google default logic.")
+	f.StringVar(&cfg.Endpoint, prefix+"gcs.endpoint", "", "Override the GCS JSON API endpoint, for example to use a private Google endpoint or a GCS emulator like fake-gcs-server (https://localhost:4443/storage/v1/). It must be an https:// URL. If empty, the default endpoint is used.")
+	f.BoolVar(&cfg.InsecureSkipVerify, prefix+"gcs.insecure-skip-verify", false, "If enabled, the client accepts any certificate and hostname of the GCS endpoint. This could be useful in local dev/test environments while using a GCS emulator.")
+	f.BoolVar(&cfg.WithoutAuthentication, prefix+"gcs.without-authentication", false, "If enabled, the requests to GCS are not authenticated. This could be useful in local dev/test environments while using a GCS emulator.")
+	f.DurationVar(&cfg.OperationTimeout, prefix+"gcs.operation-timeout", 0, "Timeout of each attempt of a GCS operation, including the retries done by the GCS client on transient errors. Reading an object is included in the timeout of the operation opening it. 0 to disable.")
+	f.IntVar(&cfg.MaxRetries, prefix+"gcs.max-retries", 0, "Maximum number of times a GCS operation is retried when an attempt times out or fails with a transient error. Uploads are only retried if their content can be read again. 0 to disable.")
+	f.DurationVar(&cfg.MinRetryBackoff, prefix+"gcs.min-retry-backoff", 100*time.Millisecond, "Minimum backoff before retrying a GCS operation.")
+	f.DurationVar(&cfg.MaxRetryBackoff, prefix+"gcs.max-retry-backoff", 10*time.Second, "Maximum backoff before retrying a GCS operation.")
+}
+
+// Validate config and returns error on failure
+func (cfg *Config) Validate() error {
+	if cfg.Endpoint != "" {
+		if u, err := url.Parse(cfg.Endpoint); err != nil || u.Scheme != "https" || u.Host == "" {
+			return errInvalidEndpoint
+		}
+	}
+	if cfg.OperationTimeout < 0 {
+		return errInvalidOperationTimeout
+	}
+	if cfg.MaxRetries < 0 {
+		return errInvalidMaxRetries
+	}
+	if cfg.MaxRetries > 0 && (cfg.MinRetryBackoff <= 0 || cfg.MinRetryBackoff > cfg.MaxRetryBackoff) {
+		return errInvalidRetryBackoff
+	}
+	return nil
 }
@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package gcs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/flagext"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	tests := map[string]struct {
+		setup    func(cfg *Config)
+		expected error
+	}{
+		"should pass with default config": {
+			setup: func(cfg *Config) {},
+		},
+		"should pass with an https endpoint": {
+			setup: func(cfg *Config) {
+				cfg.Endpoint = "https://localhost:4443/storage/v1/"
+			},
+		},
+		"should fail with an http endpoint": {
+			setup: func(cfg *Config) {
+				cfg.Endpoint = "http://localhost:4443/storage/v1/"
+			},
+			expected: errInvalidEndpoint,
+		},
+		"should fail with an endpoint without scheme": {
+			setup: func(cfg *Config) {
+				cfg.Endpoint = "localhost:4443"
+			},
+			expected: errInvalidEndpoint,
+		},
+		"should fail with a negative operation timeout": {
+			setup: func(cfg *Config) {
+				cfg.OperationTimeout = -time.Second
+			},
+			expected: errInvalidOperationTimeout,
+		},
+		"should fail with negative max retries": {
+			setup: func(cfg *Config) {
+				cfg.MaxRetries = -1
+			},
+			expected: errInvalidMaxRetries,
+		},
+		"should fail with retries and min backoff greater than max backoff": {
+			setup: func(cfg *Config) {
+				cfg.MaxRetries = 3
+				cfg.MinRetryBackoff = time.Minute
+				cfg.MaxRetryBackoff = time.Second
+			},
+			expected: errInvalidRetryBackoff,
+		},
+		"should pass with retries": {
+			setup: func(cfg *Config) {
+				cfg.MaxRetries = 3
+			},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			cfg := Config{}
+			flagext.DefaultValues(&cfg)
+			testData.setup(&cfg)
+
+			assert.Equal(t, testData.expected, cfg.Validate())
+		})
+	}
+}
@@ -0,0 +1,192 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+// Provenance-includes-location: https://github.com/thanos-io/thanos/blob/main/pkg/objstore/swift/swift.go
+// Provenance-includes-license: Apache-2.0
+// Provenance-includes-copyright: The Thanos Authors.
+
+package swift
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/ncw/swift"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/runutil"
+)
+
+const (
+	// dirDelim is the delimiter used to model a directory structure in an object store bucket.
+	dirDelim = '/'
+
+	// segmentsDir is the name of the directory in the container, where the parts of the large objects are stored.
+	segmentsDir = "segments/"
+)
+
+// Container implements the objstore.Bucket interface against OpenStack Swift. Unlike the Thanos Swift client,
+// the number of objects listed by each request can be configured.
+type Container struct {
+	logger                 log.Logger
+	name                   string
+	connection             *swift.Connection
+	chunkSize              int64
+	useDynamicLargeObjects bool
+	segmentsContainer      string
+	listPageSize           int
+}
+
+// Name returns the container name for swift.
+func (c *Container) Name() string {
+	return c.name
+}
+
+// Iter calls f for each entry in the given directory. The argument to f is the full
+// object name including the prefix of the inspected directory.
+func (c *Container) Iter(_ context.Context, dir string, f func(string) error, options ...objstore.IterOption) error {
+	if dir != "" {
+		dir = strings.TrimSuffix(dir, string(dirDelim)) + string(dirDelim)
+	}
+
+	listOptions := &swift.ObjectsOpts{
+		Prefix:    dir,
+		Delimiter: dirDelim,
+		Limit:     c.listPageSize,
+	}
+	if objstore.ApplyIterOptions(options...).Recursive {
+		listOptions.Delimiter = rune(0)
+	}
+
+	return c.connection.ObjectsWalk(c.name, listOptions, func(opts *swift.ObjectsOpts) (interface{}, error) {
+		objects, err := c.connection.ObjectNames(c.name, opts)
+		if err != nil {
+			return objects, errors.Wrap(err, "list object names")
+		}
+		for _, object := range objects {
+			if object == segmentsDir {
+				continue
+			}
+			if err := f(object); err != nil {
+				return objects, errors.Wrap(err, "iteration over objects")
+			}
+		}
+		return objects, nil
+	})
+}
+
+func (c *Container) get(name string, headers swift.Headers, checkHash bool) (io.ReadCloser, error) {
+	if name == "" {
+		return nil, errors.New("object name cannot be empty")
+	}
+	file, _, err := c.connection.ObjectOpen(c.name, name, checkHash, headers)
+	if err != nil {
+		return nil, errors.Wrap(err, "open object")
+	}
+	return file, err
+}
+
+// Get returns a reader for the given object name.
+func (c *Container) Get(_ context.Context, name string) (io.ReadCloser, error) {
+	return c.get(name, swift.Headers{}, true)
+}
+
+func (c *Container) GetRange(_ context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	// Set Range HTTP header, see the docs https://docs.openstack.org/api-ref/object-store/?expanded=show-container-details-and-list-objects-detail,get-object-content-and-metadata-detail#id76.
+	bytesRange := fmt.Sprintf("bytes=%d-", off)
+	if length != -1 {
+		bytesRange = fmt.Sprintf("%s%d", bytesRange, off+length-1)
+	}
+	return c.get(name, swift.Headers{"Range": bytesRange}, false)
+}
+
+// Attributes returns information about the specified object.
+func (c *Container) Attributes(_ context.Context, name string) (objstore.ObjectAttributes, error) {
+	if name == "" {
+		return objstore.ObjectAttributes{}, errors.New("object name cannot be empty")
+	}
+	info, _, err := c.connection.Object(c.name, name)
+	if err != nil {
+		return objstore.ObjectAttributes{}, errors.Wrap(err, "get object attributes")
+	}
+	return objstore.ObjectAttributes{
+		Size:         info.Bytes,
+		LastModified: info.LastModified,
+	}, nil
+}
+
+// Exists checks if the given object exists.
+func (c *Container) Exists(_ context.Context, name string) (bool, error) {
+	found := true
+	_, _, err := c.connection.Object(c.name, name)
+	if c.IsObjNotFoundErr(err) {
+		err = nil
+		found = false
+	}
+	return found, err
+}
+
+// IsObjNotFoundErr returns true if error means that object is not found. Relevant to Get operations.
+func (c *Container) IsObjNotFoundErr(err error) bool {
+	return errors.Is(err, swift.ObjectNotFound)
+}
+
+// Upload writes the contents of the reader as an object into the container.
+func (c *Container) Upload(_ context.Context, name string, r io.Reader) (err error) {
+	size, err := objstore.TryToGetSize(r)
+	if err != nil {
+		level.Warn(c.logger).Log("msg", "could not guess file size, using large object to avoid issues if the file is larger than limit", "name", name, "err", err)
+		// Anything higher or equal to chunk size so the SLO is used.
+		size = c.chunkSize
+	}
+	var file io.WriteCloser
+	if size >= c.chunkSize {
+		opts := swift.LargeObjectOpts{
+			Container:        c.name,
+			ObjectName:       name,
+			ChunkSize:        c.chunkSize,
+			SegmentContainer: c.segmentsContainer,
+			CheckHash:        true,
+		}
+		if c.useDynamicLargeObjects {
+			if file, err = c.connection.DynamicLargeObjectCreateFile(&opts); err != nil {
+				return errors.Wrap(err, "create DLO file")
+			}
+		} else {
+			if file, err = c.connection.StaticLargeObjectCreateFile(&opts); err != nil {
+				return errors.Wrap(err, "create SLO file")
+			}
+		}
+	} else {
+		if file, err = c.connection.ObjectCreate(c.name, name, true, "", "", swift.Headers{}); err != nil {
+			return errors.Wrap(err, "create file")
+		}
+	}
+	defer runutil.CloseWithErrCapture(&err, file, "upload object close")
+	if _, err := io.Copy(file, r); err != nil {
+		return errors.Wrap(err, "uploading object")
+	}
+	return nil
+}
+
+// Delete removes the object with the given name.
+func (c *Container) Delete(_ context.Context, name string) error {
+	return errors.Wrap(c.connection.LargeObjectDelete(c.name, name), "delete object")
+}
+
+func (*Container) Close() error {
+	// Nothing to close.
+	return nil
+}
+
+func ensureContainer(connection *swift.Connection, name string) error {
+	if _, _, err := connection.Container(name); err != nil {
+		if err != swift.ContainerNotFound {
+			return errors.Wrapf(err, "verify container %s", name)
+		}
+		return fmt.Errorf("unable to find the expected container %s", name)
+	}
+	return nil
+}
@@ -7,45 +7,51 @@ package swift
 
 import (
 	"github.com/go-kit/log"
-	"github.com/prometheus/common/model"
+	"github.com/ncw/swift"
+	"github.com/pkg/errors"
 	"github.com/thanos-io/thanos/pkg/objstore"
-	"github.com/thanos-io/thanos/pkg/objstore/swift"
-	yaml "gopkg.in/yaml.v2"
 )
 
 // NewBucketClient creates a new Swift bucket client
-func NewBucketClient(cfg Config, name string, logger log.Logger) (objstore.Bucket, error) {
-	bucketConfig := swift.Config{
-		AuthVersion:       cfg.AuthVersion,
-		AuthUrl:           cfg.AuthURL,
-		Username:          cfg.Username,
-		UserDomainName:    cfg.UserDomainName,
-		UserDomainID:      cfg.UserDomainID,
-		UserId:            cfg.UserID,
-		Password:          cfg.Password.String(),
-		DomainId:          cfg.DomainID,
-		DomainName:        cfg.DomainName,
-		ProjectID:         cfg.ProjectID,
-		ProjectName:       cfg.ProjectName,
-		ProjectDomainID:   cfg.ProjectDomainID,
-		ProjectDomainName: cfg.ProjectDomainName,
-		RegionName:        cfg.RegionName,
-		ContainerName:     cfg.ContainerName,
-		Retries:           cfg.MaxRetries,
-		ConnectTimeout:    model.Duration(cfg.ConnectTimeout),
-		Timeout:           model.Duration(cfg.RequestTimeout),
-
-		// Hard-coded defaults.
-		ChunkSize:              swift.DefaultConfig.ChunkSize,
-		UseDynamicLargeObjects: false,
+func NewBucketClient(cfg Config, _ string, logger log.Logger) (objstore.Bucket, error) {
+	connection := &swift.Connection{
+		AuthVersion:    cfg.AuthVersion,
+		AuthUrl:        cfg.AuthURL,
+		UserName:       cfg.Username,
+		Domain:         cfg.DomainName,
+		DomainId:       cfg.DomainID,
+		UserId:         cfg.UserID,
+		ApiKey:         cfg.Password.String(),
+		Tenant:         cfg.ProjectName,
+		TenantId:       cfg.ProjectID,
+		TenantDomain:   cfg.ProjectDomainName,
+		TenantDomainId: cfg.ProjectDomainID,
+		Region:         cfg.RegionName,
+		Retries:        cfg.MaxRetries,
+		ConnectTimeout: cfg.ConnectTimeout,
+		Timeout:        cfg.RequestTimeout,
+	}
+	if err := connection.Authenticate(); err != nil {
+		return nil, errors.Wrap(err, "authentication")
 	}
 
-	// Thanos currently doesn't support passing the config as is, but expects a YAML,
-	// so we're going to serialize it.
-	serialized, err := yaml.Marshal(bucketConfig)
-	if err != nil {
+	if err := ensureContainer(connection, cfg.ContainerName); err != nil {
+		return nil, err
+	}
+	segmentsContainer := cfg.LargeObjectSegmentContainerName
+	if segmentsContainer == "" {
+		segmentsContainer = cfg.ContainerName
+	} else if err := ensureContainer(connection, segmentsContainer); err != nil {
 		return nil, err
 	}
 
-	return swift.NewContainer(logger, serialized)
+	return &Container{
+		logger:                 logger,
+		name:                   cfg.ContainerName,
+		connection:             connection,
+		chunkSize:              cfg.LargeObjectChunkSize,
+		useDynamicLargeObjects: cfg.UseDynamicLargeObjects,
+		segmentsContainer:      segmentsContainer,
+		listPageSize:           cfg.ListPageSize,
+	}, nil
 }
@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package swift
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"go.uber.org/atomic"
+)
+
+// newFakeSwiftServer returns a server emulating the Swift API, with v1 authentication, for a container
+// containing the input objects.
+func newFakeSwiftServer(t *testing.T, container string, objects []string) (*httptest.Server, *atomic.Int64) {
+	listRequests := atomic.NewInt64(0)
+	sort.Strings(objects)
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/auth/v1.0":
+			w.Header().Set("X-Storage-Url", srv.URL+"/v1/AUTH_test")
+			w.Header().Set("X-Auth-Token", "token")
+			w.WriteHeader(http.StatusOK)
+
+		case r.URL.Path == "/v1/AUTH_test/"+container && r.Method == http.MethodHead:
+			w.Header().Set("X-Container-Object-Count", strconv.Itoa(len(objects)))
+			w.Header().Set("X-Container-Bytes-Used", "0")
+			w.WriteHeader(http.StatusNoContent)
+
+		case r.URL.Path == "/v1/AUTH_test/"+container && r.Method == http.MethodGet:
+			listRequests.Inc()
+
+			query := r.URL.Query()
+			limit, err := strconv.Atoi(query.Get("limit"))
+			require.NoError(t, err)
+
+			var page []string
+			for _, name := range objects {
+				if len(page) == limit {
+					break
+				}
+				if name > query.Get("marker") && strings.HasPrefix(name, query.Get("prefix")) {
+					page = append(page, name)
+				}
+			}
+
+			if len(page) == 0 {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = w.Write([]byte(strings.Join(page, "\n") + "\n"))
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, listRequests
+}
+
+func TestContainer_IterWithListPageSize(t *testing.T) {
+	objects := []string{"a/1", "a/2", "a/3", "a/4", "a/5", "b/1"}
+
+	tests := map[string]struct {
+		listPageSize         int
+		expectedListRequests int64
+	}{
+		"page size greater than the number of objects": {
+			listPageSize:         1000,
+			expectedListRequests: 1,
+		},
+		"page size not dividing the number of objects": {
+			listPageSize:         2,
+			expectedListRequests: 3,
+		},
+		"page size dividing the number of objects": {
+			listPageSize:         5,
+			expectedListRequests: 2,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			srv, listRequests := newFakeSwiftServer(t, "test-container", objects)
+
+			cfg := Config{}
+			flagext.DefaultValues(&cfg)
+			cfg.AuthVersion = 1
+			cfg.AuthURL = srv.URL + "/auth/v1.0"
+			cfg.Username = "test"
+			cfg.Password = flagext.Secret{Value: "test"}
+			cfg.ContainerName = "test-container"
+			cfg.ListPageSize = testData.listPageSize
+			require.NoError(t, cfg.Validate())
+
+			bkt, err := NewBucketClient(cfg, "test", log.NewNopLogger())
+			require.NoError(t, err)
+
+			var actual []string
+			require.NoError(t, bkt.Iter(context.Background(), "a", func(name string) error {
+				actual = append(actual, name)
+				return nil
+			}, objstore.WithRecursiveIter))
+
+			assert.Equal(t, []string{"a/1", "a/2", "a/3", "a/4", "a/5"}, actual)
+			assert.Equal(t, testData.expectedListRequests, listRequests.Load())
+		})
+	}
+}
@@ -6,12 +6,24 @@
 package swift
 
 import (
+	"errors"
 	"flag"
+	"fmt"
 	"time"
 
 	"github.com/grafana/dskit/flagext"
 )
 
+const (
+	// maxListPageSize is the default maximum number of objects returned by a listing request, in Swift.
+	maxListPageSize = 10000
+)
+
+var (
+	errInvalidListPageSize = errors.New("invalid Swift list page size, it must be between 1 and 10000")
+	errInvalidChunkSize    = errors.New("invalid Swift large object chunk size, it must be greater than 0")
+)
+
 // Config holds the config options for Swift backend
 type Config struct {
 	AuthVersion       int            `yaml:"auth_version"`
@@ -32,6 +44,11 @@ type Config struct {
 	MaxRetries        int            `yaml:"max_retries" category:"advanced"`
 	ConnectTimeout    time.Duration  `yaml:"connect_timeout" category:"advanced"`
 	RequestTimeout    time.Duration  `yaml:"request_timeout" category:"advanced"`
+
+	ListPageSize                    int    `yaml:"list_page_size" category:"experimental"`
+	LargeObjectChunkSize            int64  `yaml:"large_object_chunk_size" category:"experimental"`
+	LargeObjectSegmentContainerName string `yaml:"large_object_segments_container_name" category:"experimental"`
+	UseDynamicLargeObjects          bool   `yaml:"use_dynamic_large_objects" category:"experimental"`
 }
 
 // RegisterFlags registers the flags for Swift storage
@@ -59,4 +76,19 @@ func (cfg *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
 	f.IntVar(&cfg.MaxRetries, prefix+"swift.max-retries", 3, "Max retries on requests error.")
 	f.DurationVar(&cfg.ConnectTimeout, prefix+"swift.connect-timeout", 10*time.Second, "Time after which a connection attempt is aborted.")
 	f.DurationVar(&cfg.RequestTimeout, prefix+"swift.request-timeout", 5*time.Second, "Time after which an idle request is aborted. The timeout watchdog is reset each time some data is received, so the timeout triggers after X time no data is received on a request.")
+	f.IntVar(&cfg.ListPageSize, prefix+"swift.list-page-size", 1000, fmt.Sprintf("Maximum number of objects returned by each request listing the objects of the container. Must not be greater than the listing limit of the Swift cluster, which is %d by default.", maxListPageSize))
+	f.Int64Var(&cfg.LargeObjectChunkSize, prefix+"swift.large-object-chunk-size", 1024*1024*1024, "Objects greater than or equal to this size, in bytes, are uploaded as large objects, in segments of this size.")
+	f.StringVar(&cfg.LargeObjectSegmentContainerName, prefix+"swift.large-object-segments-container-name", "", "Name of the OpenStack Swift container to store the segments of the large objects in. If empty, the segments are stored in the container of the objects.")
+	f.BoolVar(&cfg.UseDynamicLargeObjects, prefix+"swift.use-dynamic-large-objects", false, "If enabled, large objects are uploaded as dynamic large objects, instead of static large objects.")
+}
+
+// Validate config and returns error on failure
+func (cfg *Config) Validate() error {
+	if cfg.ListPageSize < 1 || cfg.ListPageSize > maxListPageSize {
+		return errInvalidListPageSize
+	}
+	if cfg.LargeObjectChunkSize <= 0 {
+		return errInvalidChunkSize
+	}
+	return nil
 }
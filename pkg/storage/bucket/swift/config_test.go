@@ -0,0 +1,49 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package swift
+
+import (
+	"testing"
+
+	"github.com/grafana/dskit/flagext"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	tests := map[string]struct {
+		setup    func(cfg *Config)
+		expected error
+	}{
+		"should pass with default config": {
+			setup: func(cfg *Config) {},
+		},
+		"should fail with a list page size of 0": {
+			setup: func(cfg *Config) {
+				cfg.ListPageSize = 0
+			},
+			expected: errInvalidListPageSize,
+		},
+		"should fail with a list page size greater than the Swift listing limit": {
+			setup: func(cfg *Config) {
+				cfg.ListPageSize = maxListPageSize + 1
+			},
+			expected: errInvalidListPageSize,
+		},
+		"should fail with a large object chunk size of 0": {
+			setup: func(cfg *Config) {
+				cfg.LargeObjectChunkSize = 0
+			},
+			expected: errInvalidChunkSize,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			cfg := Config{}
+			flagext.DefaultValues(&cfg)
+			testData.setup(&cfg)
+
+			assert.Equal(t, testData.expected, cfg.Validate())
+		})
+	}
+}
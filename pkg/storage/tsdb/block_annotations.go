@@ -0,0 +1,224 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package tsdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/objstore"
+)
+
+// BlockAnnotationsField is the name of the meta.json top-level field storing the block annotations.
+// Annotations are arbitrary key/value pairs used to track the provenance of a block (eg. source
+// cluster or backfill job ID). Unlike external labels, they don't affect how blocks are grouped
+// for compaction.
+const BlockAnnotationsField = "annotations"
+
+// BlockAnnotations holds the annotations of a block. A block compacted from blocks having different
+// values for the same annotation key has all of them.
+type BlockAnnotations map[string]BlockAnnotationValues
+
+// Matches returns whether, for each key of the filter, the annotations have the filter's value among theirs.
+func (a BlockAnnotations) Matches(filter map[string]string) bool {
+	for key, value := range filter {
+		if !a[key].Contains(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the annotations sorted by key, in a human readable format.
+func (a BlockAnnotations) String() string {
+	keys := make([]string, 0, len(a))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	b := strings.Builder{}
+	b.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(key)
+		b.WriteByte('=')
+		b.WriteString(a[key].String())
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// BlockAnnotationValues holds the distinct values of a block annotation, sorted. It's encoded in the
+// meta.json as a string when there's a single value, and as a list of strings otherwise.
+type BlockAnnotationValues []string
+
+// Contains returns whether the input value is one of the annotation values.
+func (v BlockAnnotationValues) Contains(value string) bool {
+	i := sort.SearchStrings(v, value)
+	return i < len(v) && v[i] == value
+}
+
+func (v BlockAnnotationValues) String() string {
+	if len(v) == 1 {
+		return strconv.Quote(v[0])
+	}
+
+	quoted := make([]string, 0, len(v))
+	for _, value := range v {
+		quoted = append(quoted, strconv.Quote(value))
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// MarshalJSON implements json.Marshaler.
+func (v BlockAnnotationValues) MarshalJSON() ([]byte, error) {
+	if len(v) == 1 {
+		return json.Marshal(v[0])
+	}
+	return json.Marshal([]string(v))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (v *BlockAnnotationValues) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err == nil {
+		*v = BlockAnnotationValues{value}
+		return nil
+	}
+
+	var values []string
+	if err := json.Unmarshal(data, &values); err != nil {
+		return errors.New("block annotation value must be a string or a list of strings")
+	}
+	*v = newBlockAnnotationValues(values...)
+	return nil
+}
+
+// newBlockAnnotationValues returns the sorted distinct input values.
+func newBlockAnnotationValues(values ...string) BlockAnnotationValues {
+	out := make(BlockAnnotationValues, 0, len(values))
+	for _, value := range values {
+		if !out.Contains(value) {
+			i := sort.SearchStrings(out, value)
+			out = append(out, "")
+			copy(out[i+1:], out[i:])
+			out[i] = value
+		}
+	}
+	return out
+}
+
+// ParseBlockAnnotations returns the annotations stored in the input meta.json content.
+// Returns nil if the block has no annotations.
+func ParseBlockAnnotations(metaJSON []byte) (BlockAnnotations, error) {
+	content := struct {
+		Annotations BlockAnnotations `json:"annotations"`
+	}{}
+
+	if err := json.Unmarshal(metaJSON, &content); err != nil {
+		return nil, errors.Wrap(err, "decode block annotations")
+	}
+	return content.Annotations, nil
+}
+
+// ReadBlockAnnotationsFromDir returns the annotations of the block stored in the local directory.
+func ReadBlockAnnotationsFromDir(dir string) (BlockAnnotations, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, block.MetaFilename))
+	if err != nil {
+		return nil, errors.Wrap(err, "read meta file")
+	}
+	return ParseBlockAnnotations(data)
+}
+
+// SetBlockAnnotations returns the input meta.json content with the annotations replaced by the input ones.
+// All other fields are preserved as is.
+func SetBlockAnnotations(metaJSON []byte, annotations BlockAnnotations) ([]byte, error) {
+	content := map[string]json.RawMessage{}
+	if err := json.Unmarshal(metaJSON, &content); err != nil {
+		return nil, errors.Wrap(err, "decode meta file")
+	}
+
+	if len(annotations) == 0 {
+		delete(content, BlockAnnotationsField)
+	} else {
+		encoded, err := json.Marshal(annotations)
+		if err != nil {
+			return nil, errors.Wrap(err, "encode block annotations")
+		}
+		content[BlockAnnotationsField] = encoded
+	}
+
+	return json.MarshalIndent(content, "", "\t")
+}
+
+// BucketWithBlockAnnotations returns a bucket which sets the input annotations in the meta.json of the block
+// uploaded through it. block.Upload() re-encodes the meta.json without the fields unknown to Thanos, so the
+// annotations have to be set while it's uploaded: the meta.json is uploaded last, so the block never appears
+// in the bucket without them.
+func BucketWithBlockAnnotations(bkt objstore.Bucket, blockID ulid.ULID, annotations BlockAnnotations) objstore.Bucket {
+	return &blockAnnotationsBucket{
+		Bucket:      bkt,
+		metaPath:    path.Join(blockID.String(), block.MetaFilename),
+		annotations: annotations,
+	}
+}
+
+type blockAnnotationsBucket struct {
+	objstore.Bucket
+
+	metaPath    string
+	annotations BlockAnnotations
+}
+
+func (b *blockAnnotationsBucket) Upload(ctx context.Context, name string, r io.Reader) error {
+	if name != b.metaPath {
+		return b.Bucket.Upload(ctx, name, r)
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return errors.Wrap(err, "read meta file")
+	}
+
+	data, err = SetBlockAnnotations(data, b.annotations)
+	if err != nil {
+		return err
+	}
+
+	return b.Bucket.Upload(ctx, name, bytes.NewReader(data))
+}
+
+// MergeBlockAnnotations merges the annotations of multiple blocks, as done when the blocks are compacted
+// together. When a key has different values across blocks, the merged annotation has all of them.
+func MergeBlockAnnotations(all ...BlockAnnotations) BlockAnnotations {
+	values := map[string][]string{}
+	for _, annotations := range all {
+		for key, vs := range annotations {
+			values[key] = append(values[key], vs...)
+		}
+	}
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	merged := make(BlockAnnotations, len(values))
+	for key, vs := range values {
+		merged[key] = newBlockAnnotationValues(vs...)
+	}
+	return merged
+}
@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package tsdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/oklog/ulid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/objstore"
+)
+
+func TestSetAndParseBlockAnnotations(t *testing.T) {
+	meta := `{"ulid":"01EQK4QKFHVSZYVJ908Y7HH9E0","minTime":10,"maxTime":20,"version":1,"thanos":{"labels":{"__org_id__":"user-1"},"source":"compactor"}}`
+
+	annotations, err := ParseBlockAnnotations([]byte(meta))
+	require.NoError(t, err)
+	assert.Nil(t, annotations)
+
+	updated, err := SetBlockAnnotations([]byte(meta), BlockAnnotations{
+		"source_cluster": {"eu-west"},
+		"backfill_job":   {"job,1", "job,2"},
+	})
+	require.NoError(t, err)
+
+	// A single value is encoded as a string, multiple values as a list.
+	raw := struct {
+		Annotations map[string]interface{} `json:"annotations"`
+	}{}
+	require.NoError(t, json.Unmarshal(updated, &raw))
+	assert.Equal(t, map[string]interface{}{"source_cluster": "eu-west", "backfill_job": []interface{}{"job,1", "job,2"}}, raw.Annotations)
+
+	annotations, err = ParseBlockAnnotations(updated)
+	require.NoError(t, err)
+	assert.Equal(t, BlockAnnotations{"source_cluster": {"eu-west"}, "backfill_job": {"job,1", "job,2"}}, annotations)
+
+	// All other fields are preserved.
+	original, err := metadata.Read(ioutil.NopCloser(strings.NewReader(meta)))
+	require.NoError(t, err)
+	decoded, err := metadata.Read(ioutil.NopCloser(bytes.NewReader(updated)))
+	require.NoError(t, err)
+	assert.Equal(t, original, decoded)
+
+	// Setting empty annotations removes them.
+	updated, err = SetBlockAnnotations(updated, nil)
+	require.NoError(t, err)
+	assert.NotContains(t, string(updated), BlockAnnotationsField)
+}
+
+func TestParseBlockAnnotations_ShouldSortAndDeduplicateValues(t *testing.T) {
+	annotations, err := ParseBlockAnnotations([]byte(`{"annotations":{"source_cluster":["us-east","eu-west","us-east"]}}`))
+	require.NoError(t, err)
+	assert.Equal(t, BlockAnnotations{"source_cluster": {"eu-west", "us-east"}}, annotations)
+
+	_, err = ParseBlockAnnotations([]byte(`{"annotations":{"source_cluster":1}}`))
+	assert.Error(t, err)
+}
+
+func TestBucketWithBlockAnnotations(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+	blockID := ulid.MustNew(1, nil)
+	annotations := BlockAnnotations{"backfill_job": {"job-1"}}
+
+	annotated := BucketWithBlockAnnotations(bkt, blockID, annotations)
+	require.NoError(t, annotated.Upload(ctx, path.Join(blockID.String(), "index"), strings.NewReader("index")))
+	require.NoError(t, annotated.Upload(ctx, path.Join(blockID.String(), metadata.MetaFilename), strings.NewReader(`{"ulid":"`+blockID.String()+`","version":1}`)))
+
+	// Only the meta.json is modified.
+	assert.Equal(t, []byte("index"), bkt.Objects()[path.Join(blockID.String(), "index")])
+
+	actual, err := ParseBlockAnnotations(bkt.Objects()[path.Join(blockID.String(), metadata.MetaFilename)])
+	require.NoError(t, err)
+	assert.Equal(t, annotations, actual)
+}
+
+func TestMergeBlockAnnotations(t *testing.T) {
+	assert.Nil(t, MergeBlockAnnotations(nil, BlockAnnotations{}))
+
+	merged := MergeBlockAnnotations(
+		BlockAnnotations{"source_cluster": {"eu-west"}, "backfill_job": {"job,1"}},
+		nil,
+		BlockAnnotations{"source_cluster": {"us-east"}},
+		BlockAnnotations{"source_cluster": {"ap-south", "eu-west"}},
+	)
+	assert.Equal(t, BlockAnnotations{
+		"source_cluster": {"ap-south", "eu-west", "us-east"},
+		"backfill_job":   {"job,1"},
+	}, merged)
+}
+
+func TestBlockAnnotations_Matches(t *testing.T) {
+	annotations := BlockAnnotations{
+		"source_cluster": {"eu-west", "us-east"},
+		"backfill_job":   {"job,1"},
+	}
+
+	assert.True(t, annotations.Matches(nil))
+	assert.True(t, annotations.Matches(map[string]string{"source_cluster": "us-east"}))
+	assert.True(t, annotations.Matches(map[string]string{"source_cluster": "eu-west", "backfill_job": "job,1"}))
+	assert.False(t, annotations.Matches(map[string]string{"source_cluster": "ap-south"}))
+	assert.False(t, annotations.Matches(map[string]string{"source_cluster": "eu-west,us-east"}))
+	assert.False(t, annotations.Matches(map[string]string{"other": "eu-west"}))
+
+	assert.Equal(t, `{backfill_job="job,1", source_cluster=["eu-west", "us-east"]}`, annotations.String())
+}
@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package bloom
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/runutil"
+)
+
+const (
+	// Filename is the name of the series bloom filter file stored in the block directory.
+	Filename = "series-bloom.bin"
+
+	// DefaultFalsePositiveRate is the false positive rate used when building a block's filter.
+	DefaultFalsePositiveRate = 0.01
+)
+
+// WriteForBlock builds the bloom filter over all label pairs stored in the index of the block
+// located in blockDir, and writes it to the block directory.
+func WriteForBlock(blockDir string, falsePositiveRate float64) (_ *Filter, err error) {
+	indexr, err := index.NewFileReader(filepath.Join(blockDir, block.IndexFilename))
+	if err != nil {
+		return nil, errors.Wrap(err, "open index")
+	}
+	defer runutil.CloseWithErrCapture(&err, indexr, "close index")
+
+	names, err := indexr.LabelNames()
+	if err != nil {
+		return nil, errors.Wrap(err, "read label names")
+	}
+
+	pairs := make(map[string][]string, len(names))
+	numPairs := 0
+	for _, name := range names {
+		values, err := indexr.SortedLabelValues(name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "read label values for %s", name)
+		}
+		pairs[name] = values
+		numPairs += len(values)
+	}
+
+	f := New(numPairs, falsePositiveRate)
+	for name, values := range pairs {
+		for _, value := range values {
+			f.AddLabel(name, value)
+		}
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(blockDir, Filename), f.Marshal(), os.FileMode(0600)); err != nil {
+		return nil, errors.Wrap(err, "write bloom filter")
+	}
+	return f, nil
+}
+
+// Upload uploads the bloom filter file from blockDir to the bucket.
+func Upload(ctx context.Context, logger log.Logger, bkt objstore.Bucket, blockDir string, blockID ulid.ULID) error {
+	return objstore.UploadFile(ctx, logger, bkt, filepath.Join(blockDir, Filename), path.Join(blockID.String(), Filename))
+}
+
+// ReadFromBucket reads the bloom filter of the given block from the bucket. Returns nil
+// and no error if the block has no bloom filter.
+func ReadFromBucket(ctx context.Context, bkt objstore.BucketReader, blockID ulid.ULID) (_ *Filter, err error) {
+	r, err := bkt.Get(ctx, path.Join(blockID.String(), Filename))
+	if bkt.IsObjNotFoundErr(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "get bloom filter")
+	}
+	defer runutil.CloseWithErrCapture(&err, r, "close bloom filter reader")
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "read bloom filter")
+	}
+
+	return Unmarshal(data)
+}
@@ -0,0 +1,169 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package bloom
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+const (
+	// magic is the magic number at the beginning of an encoded filter.
+	magic = uint32(0xB10F117E)
+
+	// formatVersion1 is the only encoding version currently supported.
+	formatVersion1 = byte(1)
+
+	// headerSize is the size of the encoded filter header: magic, version and number of hash functions.
+	headerSize = 4 + 1 + 1
+
+	// labelSep is used to separate the label name from the label value when hashing a label pair.
+	// It's a byte which can't occur in a valid UTF-8 string.
+	labelSep = byte(0xff)
+)
+
+var (
+	errInvalidMagic   = errors.New("invalid bloom filter magic number")
+	errInvalidVersion = errors.New("unsupported bloom filter version")
+	errInvalidSize    = errors.New("invalid bloom filter size")
+)
+
+// Filter is a bloom filter over the label pairs (name and value) of the series in a block.
+// It can be used to find out whether a block definitely doesn't contain any series with
+// a given label pair. Filter is not safe for concurrent writes, but it's safe for concurrent
+// reads once built.
+type Filter struct {
+	bits   []uint64
+	hashes uint8
+}
+
+// New returns a Filter sized to hold expectedItems with the given false positive rate.
+func New(expectedItems int, falsePositiveRate float64) *Filter {
+	if expectedItems < 1 {
+		expectedItems = 1
+	}
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		falsePositiveRate = 0.01
+	}
+
+	// Optimal number of bits and hash functions. See https://en.wikipedia.org/wiki/Bloom_filter#Optimal_number_of_hash_functions
+	numBits := math.Ceil(-float64(expectedItems) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2))
+	numHashes := math.Round(numBits / float64(expectedItems) * math.Ln2)
+
+	words := int(math.Ceil(numBits / 64))
+	if words < 1 {
+		words = 1
+	}
+	if numHashes < 1 {
+		numHashes = 1
+	} else if numHashes > math.MaxUint8 {
+		numHashes = math.MaxUint8
+	}
+
+	return &Filter{
+		bits:   make([]uint64, words),
+		hashes: uint8(numHashes),
+	}
+}
+
+// AddLabel adds the label pair to the filter.
+func (f *Filter) AddLabel(name, value string) {
+	h1, h2 := hashLabel(name, value)
+	numBits := uint32(len(f.bits) * 64)
+
+	for i := uint32(0); i < uint32(f.hashes); i++ {
+		bit := (h1 + i*h2) % numBits
+		f.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+// MayContainLabel returns false if the filter definitely doesn't contain the label pair,
+// true if it may contain it.
+func (f *Filter) MayContainLabel(name, value string) bool {
+	h1, h2 := hashLabel(name, value)
+	numBits := uint32(len(f.bits) * 64)
+
+	for i := uint32(0); i < uint32(f.hashes); i++ {
+		bit := (h1 + i*h2) % numBits
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// MayMatch returns false if the filter proves that no series can match all the input matchers.
+// Only equality matchers with a non-empty value are taken in account, because they're the only
+// ones which require the label pair to exist in the block.
+func (f *Filter) MayMatch(matchers []*labels.Matcher) bool {
+	for _, m := range matchers {
+		if m.Type != labels.MatchEqual || m.Value == "" {
+			continue
+		}
+		if !f.MayContainLabel(m.Name, m.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// Size returns the size of the filter, in bytes, once encoded.
+func (f *Filter) Size() int {
+	return headerSize + len(f.bits)*8
+}
+
+// Marshal encodes the filter.
+func (f *Filter) Marshal() []byte {
+	out := make([]byte, f.Size())
+	binary.BigEndian.PutUint32(out[0:4], magic)
+	out[4] = formatVersion1
+	out[5] = f.hashes
+
+	for i, word := range f.bits {
+		binary.BigEndian.PutUint64(out[headerSize+i*8:], word)
+	}
+	return out
+}
+
+// Unmarshal decodes a filter previously encoded with Marshal.
+func Unmarshal(data []byte) (*Filter, error) {
+	if len(data) < headerSize {
+		return nil, errInvalidSize
+	}
+	if binary.BigEndian.Uint32(data[0:4]) != magic {
+		return nil, errInvalidMagic
+	}
+	if data[4] != formatVersion1 {
+		return nil, errInvalidVersion
+	}
+
+	payload := data[headerSize:]
+	if len(payload) == 0 || len(payload)%8 != 0 || data[5] == 0 {
+		return nil, errInvalidSize
+	}
+
+	f := &Filter{
+		bits:   make([]uint64, len(payload)/8),
+		hashes: data[5],
+	}
+	for i := range f.bits {
+		f.bits[i] = binary.BigEndian.Uint64(payload[i*8:])
+	}
+	return f, nil
+}
+
+// hashLabel returns the two hashes used to compute the filter positions of a label pair,
+// using the double hashing technique.
+func hashLabel(name, value string) (uint32, uint32) {
+	b := make([]byte, 0, len(name)+len(value)+1)
+	b = append(b, name...)
+	b = append(b, labelSep)
+	b = append(b, value...)
+
+	h := xxhash.Sum64(b)
+	return uint32(h), uint32(h>>32) | 1
+}
@@ -0,0 +1,155 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package bloom
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/testutil"
+)
+
+func TestFilter_AddAndMayContainLabel(t *testing.T) {
+	f := New(1000, 0.01)
+
+	for i := 0; i < 1000; i++ {
+		f.AddLabel("pod", fmt.Sprintf("pod-%d", i))
+	}
+
+	// No false negatives.
+	for i := 0; i < 1000; i++ {
+		assert.True(t, f.MayContainLabel("pod", fmt.Sprintf("pod-%d", i)))
+	}
+
+	// The false positive rate should be roughly the configured one.
+	falsePositives := 0
+	for i := 0; i < 10000; i++ {
+		if f.MayContainLabel("pod", fmt.Sprintf("other-%d", i)) {
+			falsePositives++
+		}
+	}
+	assert.Less(t, falsePositives, 300)
+
+	// The label name is part of the hashed key.
+	assert.False(t, f.MayContainLabel("pod-0", "pod"))
+}
+
+func TestFilter_MayMatch(t *testing.T) {
+	f := New(10, 0.01)
+	f.AddLabel(labels.MetricName, "up")
+	f.AddLabel("job", "mimir")
+
+	tests := map[string]struct {
+		matchers []*labels.Matcher
+		expected bool
+	}{
+		"no matchers": {
+			expected: true,
+		},
+		"equal matchers on existing label pairs": {
+			matchers: []*labels.Matcher{
+				labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "up"),
+				labels.MustNewMatcher(labels.MatchEqual, "job", "mimir"),
+			},
+			expected: true,
+		},
+		"equal matcher on non existing label pair": {
+			matchers: []*labels.Matcher{
+				labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "up"),
+				labels.MustNewMatcher(labels.MatchEqual, "job", "prometheus"),
+			},
+			expected: false,
+		},
+		"equal matcher with empty value": {
+			matchers: []*labels.Matcher{
+				labels.MustNewMatcher(labels.MatchEqual, "pod", ""),
+			},
+			expected: true,
+		},
+		"non equal matchers are ignored": {
+			matchers: []*labels.Matcher{
+				labels.MustNewMatcher(labels.MatchNotEqual, "job", "prometheus"),
+				labels.MustNewMatcher(labels.MatchRegexp, "job", "prom.*"),
+			},
+			expected: true,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			assert.Equal(t, testData.expected, f.MayMatch(testData.matchers))
+		})
+	}
+}
+
+func TestFilter_MarshalUnmarshal(t *testing.T) {
+	f := New(100, 0.05)
+	f.AddLabel("job", "mimir")
+
+	decoded, err := Unmarshal(f.Marshal())
+	require.NoError(t, err)
+	assert.Equal(t, f, decoded)
+	assert.True(t, decoded.MayContainLabel("job", "mimir"))
+
+	_, err = Unmarshal(nil)
+	assert.Equal(t, errInvalidSize, err)
+
+	data := f.Marshal()
+	data[0] = 0
+	_, err = Unmarshal(data)
+	assert.Equal(t, errInvalidMagic, err)
+
+	data = f.Marshal()
+	data[4] = 2
+	_, err = Unmarshal(data)
+	assert.Equal(t, errInvalidVersion, err)
+
+	_, err = Unmarshal(f.Marshal()[:headerSize+3])
+	assert.Equal(t, errInvalidSize, err)
+}
+
+func TestWriteForBlock(t *testing.T) {
+	ctx := context.Background()
+	bkt, _ := testutil.PrepareFilesystemBucket(t)
+	blocksDir := t.TempDir()
+
+	chk := chunkenc.NewXORChunk()
+	app, err := chk.Appender()
+	require.NoError(t, err)
+	app.Append(10, 1)
+
+	meta, err := testutil.GenerateBlockFromSpec("user-1", blocksDir, testutil.BlockSeriesSpecs{
+		{Labels: labels.FromStrings(labels.MetricName, "up", "job", "mimir"), Chunks: []chunks.Meta{{MinTime: 10, MaxTime: 10, Chunk: chk}}},
+		{Labels: labels.FromStrings(labels.MetricName, "up", "job", "loki"), Chunks: []chunks.Meta{{MinTime: 10, MaxTime: 10, Chunk: chk}}},
+	})
+	require.NoError(t, err)
+
+	blockDir := filepath.Join(blocksDir, meta.ULID.String())
+	written, err := WriteForBlock(blockDir, DefaultFalsePositiveRate)
+	require.NoError(t, err)
+	require.NoError(t, Upload(ctx, log.NewNopLogger(), bkt, blockDir, meta.ULID))
+
+	read, err := ReadFromBucket(ctx, bkt, meta.ULID)
+	require.NoError(t, err)
+	assert.Equal(t, written, read)
+
+	assert.True(t, read.MayContainLabel(labels.MetricName, "up"))
+	assert.True(t, read.MayContainLabel("job", "mimir"))
+	assert.True(t, read.MayContainLabel("job", "loki"))
+	assert.False(t, read.MayContainLabel("job", "tempo"))
+
+	// A block without bloom filter.
+	read, err = ReadFromBucket(ctx, bkt, ulid.MustNew(1, nil))
+	require.NoError(t, err)
+	assert.Nil(t, read)
+}
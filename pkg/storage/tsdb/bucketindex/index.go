@@ -91,6 +91,9 @@ type Block struct {
 
 	// Block's compactor shard ID, copied from tsdb.CompactorShardIDExternalLabel label.
 	CompactorShardID string `json:"compactor_shard_id,omitempty"`
+
+	// SizeBytes is the total size of the block files, if listed in the block's meta.json. Zero if unknown.
+	SizeBytes int64 `json:"size_bytes,omitempty"`
 }
 
 // Within returns whether the block contains samples within the provided range.
@@ -157,7 +160,17 @@ func BlockFromThanosMeta(meta metadata.Meta) *Block {
 		SegmentsFormat:   segmentsFormat,
 		SegmentsNum:      segmentsNum,
 		CompactorShardID: meta.Thanos.Labels[mimir_tsdb.CompactorShardIDExternalLabel],
+		SizeBytes:        blockSizeBytes(meta),
+	}
+}
+
+// blockSizeBytes returns the total size of the block files listed in the meta.json.
+func blockSizeBytes(meta metadata.Meta) int64 {
+	size := int64(0)
+	for _, f := range meta.Thanos.Files {
+		size += f.SizeBytes
 	}
+	return size
 }
 
 func detectBlockSegmentsFormat(meta metadata.Meta) (string, int) {
@@ -26,6 +26,13 @@ import (
 	"github.com/grafana/mimir/pkg/storage/tsdb/bucketcache"
 )
 
+const (
+	// The versions of the format of the entries stored in the chunks and metadata caches.
+	// Bump them whenever the format changes in an incompatible way.
+	chunksCacheSchemaVersion   = 1
+	metadataCacheSchemaVersion = 1
+)
+
 type ChunksCacheConfig struct {
 	cache.BackendConfig `yaml:",inline"`
 
@@ -94,12 +101,12 @@ func CreateCachingBucket(chunksConfig ChunksCacheConfig, metadataConfig Metadata
 	cfg := bucketcache.NewCachingBucketConfig()
 	cachingConfigured := false
 
-	chunksCache, err := cache.CreateClient("chunks-cache", chunksConfig.BackendConfig, logger, reg)
+	chunksCache, err := cache.CreateClient("chunks-cache", chunksCacheSchemaVersion, chunksConfig.BackendConfig, logger, reg)
 	if err != nil {
 		return nil, errors.Wrapf(err, "chunks-cache")
 	}
 
-	metadataCache, err := cache.CreateClient("metadata-cache", metadataConfig.BackendConfig, logger, reg)
+	metadataCache, err := cache.CreateClient("metadata-cache", metadataCacheSchemaVersion, metadataConfig.BackendConfig, logger, reg)
 	if err != nil {
 		return nil, errors.Wrapf(err, "metadata-cache")
 	}
@@ -70,9 +70,13 @@ var (
 	errInvalidWALSegmentSizeBytes   = errors.New("invalid TSDB WAL segment size bytes")
 	errInvalidStripeSize            = errors.New("invalid TSDB stripe size")
 	errEmptyBlockranges             = errors.New("empty block ranges for TSDB")
+
+	errInvalidIndexHeaderFormatVersion    = errors.New("invalid index-header format version, supported values are 1 and 2")
+	errIndexHeaderLazyLoadingWithFormatV2 = errors.New("the index-header lazy loading is not supported by the index-header format version 2")
 )
 
 // BlocksStorageConfig holds the config information for the blocks storage.
+//
 //nolint:golint
 type BlocksStorageConfig struct {
 	Bucket      bucket.Config     `yaml:",inline"`
@@ -138,6 +142,7 @@ func (cfg *BlocksStorageConfig) Validate() error {
 }
 
 // TSDBConfig holds the config for TSDB opened in the ingesters.
+//
 //nolint:golint
 type TSDBConfig struct {
 	Dir                       string        `yaml:"dir"`
@@ -145,6 +150,7 @@ type TSDBConfig struct {
 	Retention                 time.Duration `yaml:"retention_period"`
 	ShipInterval              time.Duration `yaml:"ship_interval" category:"advanced"`
 	ShipConcurrency           int           `yaml:"ship_concurrency" category:"advanced"`
+	ShipMaxBytesPerSecond     int           `yaml:"ship_max_bytes_per_second" category:"experimental"`
 	HeadCompactionInterval    time.Duration `yaml:"head_compaction_interval" category:"advanced"`
 	HeadCompactionConcurrency int           `yaml:"head_compaction_concurrency" category:"advanced"`
 	HeadCompactionIdleTimeout time.Duration `yaml:"head_compaction_idle_timeout" category:"advanced"`
@@ -184,6 +190,7 @@ func (cfg *TSDBConfig) RegisterFlags(f *flag.FlagSet) {
 	f.DurationVar(&cfg.Retention, "blocks-storage.tsdb.retention-period", 24*time.Hour, "TSDB blocks retention in the ingester before a block is removed. This should be larger than the -blocks-storage.tsdb.block-ranges-period, -querier.query-store-after and large enough to give store-gateways and queriers enough time to discover newly uploaded blocks.")
 	f.DurationVar(&cfg.ShipInterval, "blocks-storage.tsdb.ship-interval", 1*time.Minute, "How frequently the TSDB blocks are scanned and new ones are shipped to the storage. 0 means shipping is disabled.")
 	f.IntVar(&cfg.ShipConcurrency, "blocks-storage.tsdb.ship-concurrency", 10, "Maximum number of tenants concurrently shipping blocks to the storage.")
+	f.IntVar(&cfg.ShipMaxBytesPerSecond, "blocks-storage.tsdb.ship-max-bytes-per-second", 0, "Maximum upload bandwidth, in bytes per second, used by the ingester to ship blocks to the storage, across all tenants. 0 to disable.")
 	f.Uint64Var(&cfg.SeriesHashCacheMaxBytes, "blocks-storage.tsdb.series-hash-cache-max-size-bytes", uint64(1*units.Gibibyte), "Max size - in bytes - of the in-memory series hash cache. The cache is shared across all tenants and it's used only when query sharding is enabled.")
 	f.IntVar(&cfg.MaxTSDBOpeningConcurrencyOnStartup, "blocks-storage.tsdb.max-tsdb-opening-concurrency-on-startup", 10, "limit the number of concurrently opening TSDB's on startup")
 	f.DurationVar(&cfg.HeadCompactionInterval, "blocks-storage.tsdb.head-compaction-interval", 1*time.Minute, "How frequently ingesters try to compact TSDB head. Block is only created if data covers smallest block range. Must be greater than 0 and max 5 minutes.")
@@ -286,6 +293,12 @@ type BucketStoreConfig struct {
 	// On the contrary, smaller value will increase baseline memory usage, but improve latency slightly.
 	// 1 will keep all in memory. Default value is the same as in Prometheus which gives a good balance.
 	PostingOffsetsInMemSampling int `yaml:"postings_offsets_in_mem_sampling" category:"advanced"`
+
+	// Controls whether blocks series bloom filters (written by the compactor) are used to skip blocks.
+	SeriesBloomFilterEnabled bool `yaml:"series_bloom_filter_enabled" category:"experimental"`
+
+	// Controls the format version of the index-headers written and loaded by the store-gateway.
+	IndexHeaderFormatVersion int `yaml:"index_header_format_version" category:"experimental"`
 }
 
 // RegisterFlags registers the BucketStore flags
@@ -310,6 +323,8 @@ func (cfg *BucketStoreConfig) RegisterFlags(f *flag.FlagSet) {
 		"The idea of ignore-deletion-marks-delay is to ignore blocks that are marked for deletion with some delay. This ensures store can still serve blocks that are meant to be deleted but do not have a replacement yet.")
 	f.DurationVar(&cfg.IgnoreBlocksWithin, "blocks-storage.bucket-store.ignore-blocks-within", 0, "Blocks with minimum time within this duration are ignored, and not loaded by store-gateway. Useful when used together with -querier.query-store-after to prevent loading young blocks, because there are usually many of them (depending on number of ingesters) and they are not yet compacted. Negative values or 0 disable the filter.")
 	f.IntVar(&cfg.PostingOffsetsInMemSampling, "blocks-storage.bucket-store.posting-offsets-in-mem-sampling", DefaultPostingOffsetInMemorySampling, "Controls what is the ratio of postings offsets that the store will hold in memory.")
+	f.BoolVar(&cfg.SeriesBloomFilterEnabled, "blocks-storage.bucket-store.series-bloom-filter-enabled", false, "If enabled, store-gateway will load the series bloom filter of each block (if written by the compactor) and use it to skip blocks which don't contain the label pairs requested by equality matchers.")
+	f.IntVar(&cfg.IndexHeaderFormatVersion, "blocks-storage.bucket-store.index-header-format-version", 1, "Format version of the index-headers written and loaded by the store-gateway. Supported values: 1, 2. The version 2 is memory-mapped and loaded without reading it through, reducing the startup time and the memory used for the blocks with many symbols or label values. The existing version 1 index-headers are converted to the version 2 when loaded. The version 2 requires the index-header lazy loading to be disabled.")
 	f.BoolVar(&cfg.IndexHeaderLazyLoadingEnabled, "blocks-storage.bucket-store.index-header-lazy-loading-enabled", true, "If enabled, store-gateway will lazy load an index-header only once required by a query.")
 	f.DurationVar(&cfg.IndexHeaderLazyLoadingIdleTimeout, "blocks-storage.bucket-store.index-header-lazy-loading-idle-timeout", 60*time.Minute, "If index-header lazy loading is enabled and this setting is > 0, the store-gateway will offload unused index-headers after 'idle timeout' inactivity.")
 	f.Uint64Var(&cfg.PartitionerMaxGapBytes, "blocks-storage.bucket-store.partitioner-max-gap-bytes", DefaultPartitionerMaxGapSize, "Max size - in bytes - of a gap for which the partitioner aggregates together two bucket GET object requests.")
@@ -329,6 +344,12 @@ func (cfg *BucketStoreConfig) Validate() error {
 	if err != nil {
 		return errors.Wrap(err, "metadata-cache configuration")
 	}
+	if cfg.IndexHeaderFormatVersion != 1 && cfg.IndexHeaderFormatVersion != 2 {
+		return errInvalidIndexHeaderFormatVersion
+	}
+	if cfg.IndexHeaderFormatVersion == 2 && cfg.IndexHeaderLazyLoadingEnabled {
+		return errIndexHeaderLazyLoadingWithFormatV2
+	}
 	return nil
 }
 
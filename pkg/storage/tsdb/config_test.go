@@ -120,6 +120,26 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			expectedErr: errInvalidWALSegmentSizeBytes,
 		},
+		"should pass on index-header format version 2": {
+			setup: func(cfg *BlocksStorageConfig) {
+				cfg.BucketStore.IndexHeaderFormatVersion = 2
+				cfg.BucketStore.IndexHeaderLazyLoadingEnabled = false
+			},
+			expectedErr: nil,
+		},
+		"should fail on index-header format version 2 with lazy loading enabled": {
+			setup: func(cfg *BlocksStorageConfig) {
+				cfg.BucketStore.IndexHeaderFormatVersion = 2
+				cfg.BucketStore.IndexHeaderLazyLoadingEnabled = true
+			},
+			expectedErr: errIndexHeaderLazyLoadingWithFormatV2,
+		},
+		"should fail on invalid index-header format version": {
+			setup: func(cfg *BlocksStorageConfig) {
+				cfg.BucketStore.IndexHeaderFormatVersion = 3
+			},
+			expectedErr: errInvalidIndexHeaderFormatVersion,
+		},
 	}
 
 	for testName, testData := range tests {
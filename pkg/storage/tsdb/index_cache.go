@@ -14,7 +14,6 @@ import (
 	"github.com/go-kit/log"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/thanos-io/thanos/pkg/cacheutil"
 	"github.com/thanos-io/thanos/pkg/model"
 
 	"github.com/grafana/mimir/pkg/cache"
@@ -33,6 +32,10 @@ const (
 	IndexCacheBackendDefault = IndexCacheBackendInMemory
 
 	defaultMaxItemSize = model.Bytes(128 * units.MiB)
+
+	// The version of the format of the entries stored in the memcached index cache.
+	// Bump it whenever the format changes in an incompatible way.
+	indexCacheSchemaVersion = 1
 )
 
 var (
@@ -108,7 +111,7 @@ func newInMemoryIndexCache(cfg InMemoryIndexCacheConfig, logger log.Logger, regi
 }
 
 func newMemcachedIndexCache(cfg cache.MemcachedConfig, logger log.Logger, registerer prometheus.Registerer) (indexcache.IndexCache, error) {
-	client, err := cacheutil.NewMemcachedClientWithConfig(logger, "index-cache", cfg.ToMemcachedClientConfig(), registerer)
+	client, err := cache.NewMemcachedClient("index-cache", indexCacheSchemaVersion, cfg, logger, registerer)
 	if err != nil {
 		return nil, errors.Wrap(err, "create index cache memcached client")
 	}
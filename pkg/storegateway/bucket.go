@@ -18,7 +18,9 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"regexp/syntax"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -54,12 +56,16 @@ import (
 	"github.com/thanos-io/thanos/pkg/strutil"
 	"github.com/thanos-io/thanos/pkg/tracing"
 	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	grpc_metadata "google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
 	"github.com/grafana/mimir/pkg/storage/sharding"
 	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/bloom"
 	"github.com/grafana/mimir/pkg/storegateway/indexcache"
+	mimir_indexheader "github.com/grafana/mimir/pkg/storegateway/indexheader"
 	util_math "github.com/grafana/mimir/pkg/util/math"
 	"github.com/grafana/mimir/pkg/util/spanlogger"
 )
@@ -151,6 +157,12 @@ type BucketStore struct {
 
 	// Enables hints in the Series() response.
 	enableSeriesResponseHints bool
+
+	// Enables loading blocks series bloom filter (if any) to skip blocks not matching the request.
+	seriesBloomFilterEnabled bool
+
+	// Enables the usage of the index-header format version 2.
+	indexHeaderFormatV2 bool
 }
 
 type noopCache struct{}
@@ -228,6 +240,20 @@ func WithFilterConfig(filter *FilterConfig) BucketStoreOption {
 	}
 }
 
+// WithSeriesBloomFilter enables the usage of blocks series bloom filter, if available in the bucket.
+func WithSeriesBloomFilter() BucketStoreOption {
+	return func(s *BucketStore) {
+		s.seriesBloomFilterEnabled = true
+	}
+}
+
+// WithIndexHeaderFormatV2 enables the usage of the index-header format version 2.
+func WithIndexHeaderFormatV2() BucketStoreOption {
+	return func(s *BucketStore) {
+		s.indexHeaderFormatV2 = true
+	}
+}
+
 // WithDebugLogging enables debug logging.
 func WithDebugLogging() BucketStoreOption {
 	return func(s *BucketStore) {
@@ -423,6 +449,20 @@ func (s *BucketStore) getBlock(id ulid.ULID) *bucketBlock {
 	return s.blocks[id]
 }
 
+// newIndexHeaderReader returns the reader of the index-header of the input block, in the configured format
+// version. The version 1 is used for the blocks whose index is not supported by the version 2.
+func (s *BucketStore) newIndexHeaderReader(ctx context.Context, id ulid.ULID) (indexheader.Reader, error) {
+	if s.indexHeaderFormatV2 {
+		r, err := mimir_indexheader.NewBinaryReader(ctx, s.logger, s.bkt, s.dir, id)
+		if !errors.Is(err, mimir_indexheader.ErrUnsupportedIndexVersion) {
+			return r, err
+		}
+		level.Info(s.logger).Log("msg", "falling back to the index-header version 1", "id", id, "err", err)
+	}
+
+	return s.indexReaderPool.NewBinaryReader(ctx, s.logger, s.bkt, s.dir, id, s.postingOffsetsInMemSampling)
+}
+
 func (s *BucketStore) addBlock(ctx context.Context, meta *metadata.Meta) (err error) {
 	dir := filepath.Join(s.dir, meta.ULID.String())
 	start := time.Now()
@@ -444,14 +484,7 @@ func (s *BucketStore) addBlock(ctx context.Context, meta *metadata.Meta) (err er
 	lset := labels.FromMap(meta.Thanos.Labels)
 	h := lset.Hash()
 
-	indexHeaderReader, err := s.indexReaderPool.NewBinaryReader(
-		ctx,
-		s.logger,
-		s.bkt,
-		s.dir,
-		meta.ULID,
-		s.postingOffsetsInMemSampling,
-	)
+	indexHeaderReader, err := s.newIndexHeaderReader(ctx, meta.ULID)
 	if err != nil {
 		return errors.Wrap(err, "create index header reader")
 	}
@@ -483,6 +516,14 @@ func (s *BucketStore) addBlock(ctx context.Context, meta *metadata.Meta) (err er
 		}
 	}()
 
+	if s.seriesBloomFilterEnabled {
+		// The bloom filter is just an optimization, so we don't fail the block loading if we can't read it.
+		if b.seriesBloom, err = bloom.ReadFromBucket(ctx, s.bkt, meta.ULID); err != nil {
+			level.Warn(s.logger).Log("msg", "failed to load series bloom filter", "id", meta.ULID, "err", err)
+			b.seriesBloom, err = nil, nil
+		}
+	}
+
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
@@ -972,6 +1013,11 @@ func debugFoundBlockSetOverview(logger log.Logger, mint, maxt, maxResolutionMill
 	level.Debug(logger).Log("msg", "Blocks source resolutions", "blocks", len(bs), "Maximum Resolution", maxResolutionMillis, "mint", mint, "maxt", maxt, "lset", lset.String(), "spans", strings.Join(parts, "\n"))
 }
 
+// SeriesPostingsBytesTrailer is the gRPC trailer of the Series() response holding the size in bytes of
+// the postings read from the blocks index to look up the series, so that the querier can enforce the limit
+// to the size of the data fetched by a query.
+const SeriesPostingsBytesTrailer = "mimir-series-postings-bytes"
+
 // Series implements the storepb.StoreServer interface.
 func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_SeriesServer) (err error) {
 	if s.queryGate != nil {
@@ -1046,6 +1092,13 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 				resHints.AddQueriedBlock(b.meta.ULID)
 			}
 
+			// The block is reported as queried even if skipped, because it has been
+			// checked and it's guaranteed to not contain any matching series.
+			if !b.mayContainSeriesMatching(blockMatchers) {
+				s.metrics.seriesBloomFilterSkippedBlocks.Inc()
+				continue
+			}
+
 			var chunkr *bucketChunkReader
 			// We must keep the readers open until all their data has been sent.
 			indexr := b.indexReader()
@@ -1138,6 +1191,9 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 		}
 		stats.blocksQueried = len(res)
 		stats.getAllDuration = time.Since(begin)
+
+		// The trailer can't be set if the store isn't called through gRPC, like in tests.
+		_ = grpc.SetTrailer(ctx, grpc_metadata.Pairs(SeriesPostingsBytesTrailer, strconv.Itoa(stats.postingsTouchedSizeSum)))
 		s.metrics.seriesGetAllDuration.Observe(stats.getAllDuration.Seconds())
 		s.metrics.seriesBlocksQueried.Observe(float64(stats.blocksQueried))
 	}
@@ -1244,6 +1300,11 @@ func (s *BucketStore) LabelNames(ctx context.Context, req *storepb.LabelNamesReq
 
 		resHints.AddQueriedBlock(b.meta.ULID)
 
+		if !b.mayContainSeriesMatching(reqSeriesMatchers) {
+			s.metrics.seriesBloomFilterSkippedBlocks.Inc()
+			continue
+		}
+
 		indexr := b.indexReader()
 
 		g.Go(func() error {
@@ -1405,6 +1466,11 @@ func (s *BucketStore) LabelValues(ctx context.Context, req *storepb.LabelValuesR
 
 		resHints.AddQueriedBlock(b.meta.ULID)
 
+		if !b.mayContainSeriesMatching(reqSeriesMatchers) {
+			s.metrics.seriesBloomFilterSkippedBlocks.Inc()
+			continue
+		}
+
 		indexr := b.indexReader()
 
 		g.Go(func() error {
@@ -1701,6 +1767,9 @@ type bucketBlock struct {
 	// request hints' BlockMatchers.
 	relabelLabels labels.Labels
 
+	// Bloom filter over the label pairs of the block's series. Nil if not available.
+	seriesBloom *bloom.Filter
+
 	expandedPostingsPromises sync.Map
 }
 
@@ -1833,6 +1902,25 @@ func (b *bucketBlock) matchRelabelLabels(matchers []*labels.Matcher) bool {
 	return true
 }
 
+// mayContainSeriesMatching returns false if the block's series bloom filter proves that the block
+// doesn't contain any series matching the input matchers. Matchers on the block's external labels
+// are ignored, because external labels are not stored in the block's index.
+func (b *bucketBlock) mayContainSeriesMatching(matchers []*labels.Matcher) bool {
+	if b.seriesBloom == nil {
+		return true
+	}
+
+	for _, m := range matchers {
+		if _, ok := b.meta.Thanos.Labels[m.Name]; ok {
+			continue
+		}
+		if !b.seriesBloom.MayMatch([]*labels.Matcher{m}) {
+			return false
+		}
+	}
+	return true
+}
+
 // overlapsClosedInterval returns true if the block overlaps [mint, maxt).
 func (b *bucketBlock) overlapsClosedInterval(mint, maxt int64) bool {
 	// The block itself is a half-open interval
@@ -1997,10 +2085,13 @@ func (r *bucketIndexReader) expandedPostings(ctx context.Context, ms []*labels.M
 	// NOTE: Derived from tsdb.PostingsForMatchers.
 	for _, m := range ms {
 		// Each group is separate to tell later what postings are intersecting with what.
-		pg, err := toPostingGroup(r.block.indexHeaderReader.LabelValues, m)
+		pg, optimization, err := toPostingGroup(r.block.indexHeaderReader.LabelValues, m)
 		if err != nil {
 			return nil, errors.Wrap(err, "toPostingGroup")
 		}
+		if m.Type == labels.MatchRegexp || m.Type == labels.MatchNotRegexp {
+			r.block.metrics.regexpMatchers.WithLabelValues(optimization).Inc()
+		}
 
 		// If this groups adds nothing, it's an empty group. We can shortcut this, since intersection with empty
 		// postings would return no postings anyway.
@@ -2112,24 +2203,38 @@ func checkNilPosting(l labels.Label, p index.Postings) index.Postings {
 	return p
 }
 
+const (
+	// regexpOptimizationSetMatches is used for the regexp matchers matching a set of values, like `foo|bar`,
+	// whose postings are looked up directly.
+	regexpOptimizationSetMatches = "set_matches"
+
+	// regexpOptimizationPrefix is used for the regexp matchers starting with a literal prefix, like `foo.*`,
+	// which are only run on the label values with the prefix.
+	regexpOptimizationPrefix = "prefix"
+
+	// regexpOptimizationNone is used for the regexp matchers run on all the label values.
+	regexpOptimizationNone = "none"
+)
+
 // NOTE: Derived from tsdb.postingsForMatcher. index.Merge is equivalent to map duplication.
-func toPostingGroup(lvalsFn func(name string) ([]string, error), m *labels.Matcher) (*postingGroup, error) {
+// The optimization used to find the values matched by a regexp matcher is returned too.
+func toPostingGroup(lvalsFn func(name string) ([]string, error), m *labels.Matcher) (*postingGroup, string, error) {
 	if setMatches := m.SetMatches(); len(setMatches) > 0 && (m.Type == labels.MatchRegexp || m.Type == labels.MatchNotRegexp) {
 		keys := make([]labels.Label, 0, len(setMatches))
 		for _, val := range setMatches {
 			keys = append(keys, labels.Label{Name: m.Name, Value: val})
 		}
 		if m.Type == labels.MatchNotRegexp {
-			return newPostingGroup(true, nil, keys), nil
+			return newPostingGroup(true, nil, keys), regexpOptimizationSetMatches, nil
 		}
-		return newPostingGroup(false, keys, nil), nil
+		return newPostingGroup(false, keys, nil), regexpOptimizationSetMatches, nil
 	}
 
 	if m.Value != "" {
 		// Fast-path for equal matching.
 		// Works for every case except for `foo=""`, which is a special case, see below.
 		if m.Type == labels.MatchEqual {
-			return newPostingGroup(false, []labels.Label{{Name: m.Name, Value: m.Value}}, nil), nil
+			return newPostingGroup(false, []labels.Label{{Name: m.Name, Value: m.Value}}, nil), regexpOptimizationNone, nil
 		}
 
 		// If matcher is `label!="foo"`, we select an empty label value too,
@@ -2137,13 +2242,23 @@ func toPostingGroup(lvalsFn func(name string) ([]string, error), m *labels.Match
 		// So this matcher selects all series in the storage,
 		// except for the ones that do have `label="foo"`
 		if m.Type == labels.MatchNotEqual {
-			return newPostingGroup(true, nil, []labels.Label{{Name: m.Name, Value: m.Value}}), nil
+			return newPostingGroup(true, nil, []labels.Label{{Name: m.Name, Value: m.Value}}), regexpOptimizationNone, nil
 		}
 	}
 
 	vals, err := lvalsFn(m.Name)
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+
+	// The values matching a regexp with a literal prefix, like `foo.*`, all have the prefix, so the regexp
+	// only needs to be run on them. The label values are sorted, so they're found with a binary search.
+	optimization := regexpOptimizationNone
+	if m.Type == labels.MatchRegexp || m.Type == labels.MatchNotRegexp {
+		if prefix := regexpLiteralPrefix(m.Value); prefix != "" {
+			vals = labelValuesWithPrefix(vals, prefix)
+			optimization = regexpOptimizationPrefix
+		}
 	}
 
 	// This is a more generic approach for the previous case.
@@ -2160,7 +2275,7 @@ func toPostingGroup(lvalsFn func(name string) ([]string, error), m *labels.Match
 			}
 		}
 
-		return newPostingGroup(true, nil, toRemove), nil
+		return newPostingGroup(true, nil, toRemove), optimization, nil
 	}
 
 	// Our matcher does not match the empty value, so we just need the postings that correspond
@@ -2172,7 +2287,44 @@ func toPostingGroup(lvalsFn func(name string) ([]string, error), m *labels.Match
 		}
 	}
 
-	return newPostingGroup(false, toAdd, nil), nil
+	return newPostingGroup(false, toAdd, nil), optimization, nil
+}
+
+// regexpLiteralPrefix returns the literal prefix of all the strings matched by the regexp, or an
+// empty string if the regexp has no literal prefix or can't be parsed.
+func regexpLiteralPrefix(expr string) string {
+	// The matchers' regexps are parsed with the Perl syntax, like regexp.Compile() does.
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return ""
+	}
+	re = re.Simplify()
+
+	for re.Op == syntax.OpCapture {
+		re = re.Sub[0]
+	}
+	if re.Op == syntax.OpConcat {
+		// The matchers' regexps are anchored anyway, so a leading `^` doesn't change the prefix.
+		sub := re.Sub
+		for len(sub) > 1 && sub[0].Op == syntax.OpBeginText {
+			sub = sub[1:]
+		}
+		re = sub[0]
+	}
+	if re.Op != syntax.OpLiteral || re.Flags&syntax.FoldCase != 0 {
+		return ""
+	}
+	return string(re.Rune)
+}
+
+// labelValuesWithPrefix returns the values with the prefix. The input values must be sorted.
+func labelValuesWithPrefix(vals []string, prefix string) []string {
+	start := sort.SearchStrings(vals, prefix)
+	end := start
+	for end < len(vals) && strings.HasPrefix(vals[end], prefix) {
+		end++
+	}
+	return vals[start:end]
 }
 
 type postingPtr struct {
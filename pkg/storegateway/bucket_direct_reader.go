@@ -0,0 +1,193 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/tsdb/hashcache"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"golang.org/x/time/rate"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	"github.com/grafana/mimir/pkg/storage/tsdb"
+	util_log "github.com/grafana/mimir/pkg/util/log"
+	util_math "github.com/grafana/mimir/pkg/util/math"
+)
+
+// DirectBucketReaderConfig holds the config of a DirectBucketReader.
+type DirectBucketReaderConfig struct {
+	// Max number of stores opened per second. 0 to disable the limit.
+	RateLimit float64
+
+	// Max number of index-headers kept on disk between stores. 0 to disable the cache.
+	IndexHeaderCacheSize int
+}
+
+// DirectBucketReader reads blocks directly from the bucket, without going through the store-gateways.
+// It's meant to be used by queriers as a slow path, when the store-gateways can't serve some blocks:
+// each store opened by the reader loads the requested blocks, building their index-header unless
+// it's found in the reader's index-header cache.
+type DirectBucketReader struct {
+	cfg    tsdb.BlocksStorageConfig
+	bkt    objstore.Bucket
+	dir    string
+	logger log.Logger
+
+	limiter          *rate.Limiter
+	indexHeaderCache *indexHeaderCache
+
+	partitioner     Partitioner
+	seriesHashCache *hashcache.SeriesHashCache
+	metrics         *BucketStoreMetrics
+}
+
+// NewDirectBucketReader returns a DirectBucketReader storing the index-headers of the opened blocks
+// in dir. Any content of dir is removed, because it's left over by a previous process.
+func NewDirectBucketReader(readerCfg DirectBucketReaderConfig, cfg tsdb.BlocksStorageConfig, bkt objstore.Bucket, dir string, logger log.Logger) *DirectBucketReader {
+	if err := os.RemoveAll(dir); err != nil {
+		level.Warn(logger).Log("msg", "failed to clean up bucket reader dir", "dir", dir, "err", err)
+	}
+
+	limit := rate.Inf
+	if readerCfg.RateLimit > 0 {
+		limit = rate.Limit(readerCfg.RateLimit)
+	}
+
+	return &DirectBucketReader{
+		cfg:    cfg,
+		bkt:    bkt,
+		dir:    dir,
+		logger: logger,
+
+		limiter:          rate.NewLimiter(limit, util_math.Max(1, int(readerCfg.RateLimit))),
+		indexHeaderCache: newIndexHeaderCache(filepath.Join(dir, "index-headers"), readerCfg.IndexHeaderCacheSize, logger),
+
+		// Metrics are not registered, because they would clash with the store-gateway ones
+		// when running in monolithic mode.
+		partitioner:     newGapBasedPartitioner(cfg.BucketStore.PartitionerMaxGapBytes, nil),
+		seriesHashCache: hashcache.NewSeriesHashCache(cfg.BucketStore.SeriesHashCacheMaxBytes),
+		metrics:         NewBucketStoreMetrics(nil),
+	}
+}
+
+// DirectBucketStore is a BucketStore loading only a given set of blocks. It must be closed once done.
+type DirectBucketStore struct {
+	*BucketStore
+
+	dir string
+}
+
+// ErrDirectBucketReaderRateLimited is returned when a store can't be opened in time because of the rate limit.
+var ErrDirectBucketReaderRateLimited = errors.New("rate limited reading blocks from the bucket")
+
+// Close the store and remove its local files.
+func (s *DirectBucketStore) Close() error {
+	err := s.BucketStore.Close()
+	if rmErr := os.RemoveAll(s.dir); err == nil {
+		err = rmErr
+	}
+	return err
+}
+
+// Open returns a store to query the given blocks of a tenant.
+func (r *DirectBucketReader) Open(ctx context.Context, userID string, blockIDs []ulid.ULID) (*DirectBucketStore, error) {
+	if err := r.limiter.Wait(ctx); err != nil {
+		return nil, errors.Wrap(ErrDirectBucketReaderRateLimited, err.Error())
+	}
+
+	storesDir := filepath.Join(r.dir, "stores")
+	if err := os.MkdirAll(storesDir, 0750); err != nil {
+		return nil, errors.Wrap(err, "create bucket reader dir")
+	}
+
+	dir, err := os.MkdirTemp(storesDir, userID+"-")
+	if err != nil {
+		return nil, errors.Wrap(err, "create bucket reader dir")
+	}
+
+	// Reuse the index-headers built by previous stores, if any.
+	for _, blockID := range blockIDs {
+		r.indexHeaderCache.restore(userID, blockID, dir)
+	}
+
+	// The reader never uploads anything, so there's no need for a tenant config provider.
+	userBkt := bucket.NewUserBucketClient(userID, r.bkt, nil)
+
+	bs, err := NewBucketStore(
+		userID,
+		userBkt,
+		&staticMetadataFetcher{bkt: userBkt, blockIDs: blockIDs},
+		dir,
+		NewChunksLimiterFactory(0), // Limits are enforced by the querier.
+		NewSeriesLimiterFactory(0),
+		r.partitioner,
+		r.cfg.BucketStore.BlockSyncConcurrency,
+		false,
+		r.cfg.BucketStore.PostingOffsetsInMemSampling,
+		true,  // Enable series hints, used by the querier consistency check.
+		false, // The store is short-lived, so there's no need to lazy load the index-headers.
+		0,
+		r.seriesHashCache,
+		r.metrics,
+		WithLogger(util_log.WithUserID(userID, r.logger)),
+	)
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+
+	store := &DirectBucketStore{BucketStore: bs, dir: dir}
+	if err := store.SyncBlocks(ctx); err != nil {
+		_ = store.Close()
+		return nil, errors.Wrap(err, "load blocks")
+	}
+
+	for _, blockID := range blockIDs {
+		r.indexHeaderCache.store(userID, blockID, dir)
+	}
+
+	return store, nil
+}
+
+// staticMetadataFetcher is a block.MetadataFetcher fetching the metadata of a fixed set of blocks.
+type staticMetadataFetcher struct {
+	bkt      objstore.BucketReader
+	blockIDs []ulid.ULID
+}
+
+func (f *staticMetadataFetcher) Fetch(ctx context.Context) (map[ulid.ULID]*metadata.Meta, map[ulid.ULID]error, error) {
+	metas := make(map[ulid.ULID]*metadata.Meta, len(f.blockIDs))
+	partial := map[ulid.ULID]error{}
+
+	for _, blockID := range f.blockIDs {
+		r, err := f.bkt.Get(ctx, path.Join(blockID.String(), block.MetaFilename))
+		if f.bkt.IsObjNotFoundErr(err) {
+			partial[blockID] = err
+			continue
+		}
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "get meta file for block %s", blockID)
+		}
+
+		meta, err := metadata.Read(r)
+		if err != nil {
+			partial[blockID] = err
+			continue
+		}
+		metas[blockID] = meta
+	}
+
+	return metas, partial, nil
+}
+
+func (f *staticMetadataFetcher) UpdateOnChange(func([]metadata.Meta, error)) {}
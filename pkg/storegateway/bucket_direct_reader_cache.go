@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	lru "github.com/hashicorp/golang-lru/simplelru"
+	"github.com/oklog/ulid"
+	"github.com/thanos-io/thanos/pkg/block"
+)
+
+// indexHeaderCache keeps on local disk the index-headers built by the stores opened by a DirectBucketReader,
+// so that they don't have to be built again from the bucket when the same blocks are queried again.
+// Index-headers are hard linked in and out of the cache, so that evicting an index-header
+// doesn't affect the stores currently using it.
+type indexHeaderCache struct {
+	dir    string
+	logger log.Logger
+
+	mtx sync.Mutex
+	lru *lru.LRU // Nil if the cache is disabled.
+}
+
+func newIndexHeaderCache(dir string, size int, logger log.Logger) *indexHeaderCache {
+	c := &indexHeaderCache{
+		dir:    dir,
+		logger: logger,
+	}
+
+	if size > 0 {
+		// The only error returned by NewLRU is for a non-positive size.
+		c.lru, _ = lru.NewLRU(size, c.onEvict)
+	}
+
+	return c
+}
+
+// restore links the cached index-header of the block, if any, into the given store dir.
+func (c *indexHeaderCache) restore(userID string, blockID ulid.ULID, storeDir string) {
+	if c.lru == nil {
+		return
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	key := c.path(userID, blockID)
+	if _, ok := c.lru.Get(key); !ok {
+		return
+	}
+
+	dst := filepath.Join(storeDir, blockID.String(), block.IndexHeaderFilename)
+	if err := os.MkdirAll(filepath.Dir(dst), 0750); err != nil {
+		level.Warn(c.logger).Log("msg", "failed to restore cached index-header", "block", blockID, "err", err)
+		return
+	}
+
+	if err := os.Link(key, dst); err != nil {
+		// The index-header will be built again by the store.
+		level.Warn(c.logger).Log("msg", "failed to restore cached index-header", "block", blockID, "err", err)
+		c.lru.Remove(key)
+	}
+}
+
+// store adds the index-header of the block found in the given store dir to the cache.
+func (c *indexHeaderCache) store(userID string, blockID ulid.ULID, storeDir string) {
+	if c.lru == nil {
+		return
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	key := c.path(userID, blockID)
+	if c.lru.Contains(key) {
+		return
+	}
+
+	src := filepath.Join(storeDir, blockID.String(), block.IndexHeaderFilename)
+	if _, err := os.Stat(src); err != nil {
+		// The block hasn't been loaded by the store.
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(key), 0750); err != nil {
+		level.Warn(c.logger).Log("msg", "failed to cache index-header", "block", blockID, "err", err)
+		return
+	}
+
+	if err := os.Link(src, key); err != nil {
+		level.Warn(c.logger).Log("msg", "failed to cache index-header", "block", blockID, "err", err)
+		return
+	}
+
+	c.lru.Add(key, struct{}{})
+}
+
+func (c *indexHeaderCache) path(userID string, blockID ulid.ULID) string {
+	return filepath.Join(c.dir, userID, blockID.String(), block.IndexHeaderFilename)
+}
+
+func (c *indexHeaderCache) onEvict(key, _ interface{}) {
+	if err := os.RemoveAll(filepath.Dir(key.(string))); err != nil {
+		level.Warn(c.logger).Log("msg", "failed to remove evicted index-header", "path", key, "err", err)
+	}
+}
@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+)
+
+func TestDirectBucketReader(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+	userBkt := bucket.NewUserBucketClient("user-1", bkt, nil)
+
+	block1 := uploadTestBlock(t, t.TempDir(), userBkt, 50)
+	block2 := uploadTestBlock(t, t.TempDir(), userBkt, 50)
+
+	dir := t.TempDir()
+	r := NewDirectBucketReader(DirectBucketReaderConfig{IndexHeaderCacheSize: 1}, mockStorageConfig(t), bkt, dir, log.NewNopLogger())
+
+	cachedIndexHeader := func(blockID ulid.ULID) string {
+		return filepath.Join(dir, "index-headers", "user-1", blockID.String(), block.IndexHeaderFilename)
+	}
+
+	queryLabelNames := func(blockID ulid.ULID) {
+		store, err := r.Open(ctx, "user-1", []ulid.ULID{blockID})
+		require.NoError(t, err)
+
+		res, err := store.LabelNames(ctx, &storepb.LabelNamesRequest{Start: 0, End: 1})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"i", "j", "n", "p", "q", "r", "s", "t"}, res.Names)
+
+		require.NoError(t, store.Close())
+	}
+
+	// The index-header built by the first store is cached.
+	queryLabelNames(block1)
+	assert.FileExists(t, cachedIndexHeader(block1))
+
+	// The cached index-header is used by the next store.
+	cachedStat, err := os.Stat(cachedIndexHeader(block1))
+	require.NoError(t, err)
+	queryLabelNames(block1)
+	newStat, err := os.Stat(cachedIndexHeader(block1))
+	require.NoError(t, err)
+	assert.True(t, os.SameFile(cachedStat, newStat))
+
+	// The least recently used index-header is evicted once the cache is full.
+	queryLabelNames(block2)
+	assert.FileExists(t, cachedIndexHeader(block2))
+	assert.NoFileExists(t, cachedIndexHeader(block1))
+
+	// All stores have been removed from disk.
+	entries, err := os.ReadDir(filepath.Join(dir, "stores"))
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestDirectBucketReader_RateLimit(t *testing.T) {
+	bkt := objstore.NewInMemBucket()
+	r := NewDirectBucketReader(DirectBucketReaderConfig{RateLimit: 1}, mockStorageConfig(t), bkt, t.TempDir(), log.NewNopLogger())
+
+	// The first store is allowed by the burst.
+	store, err := r.Open(context.Background(), "user-1", nil)
+	require.NoError(t, err)
+	require.NoError(t, store.Close())
+
+	// The next store can't be opened before the context deadline.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err = r.Open(ctx, "user-1", nil)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrDirectBucketReaderRateLimited)
+}
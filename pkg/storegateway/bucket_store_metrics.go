@@ -36,6 +36,8 @@ type BucketStoreMetrics struct {
 	queriesDropped        *prometheus.CounterVec
 	seriesRefetches       prometheus.Counter
 
+	seriesBloomFilterSkippedBlocks prometheus.Counter
+
 	cachedPostingsCompressions           *prometheus.CounterVec
 	cachedPostingsCompressionErrors      *prometheus.CounterVec
 	cachedPostingsCompressionTimeSeconds *prometheus.CounterVec
@@ -48,6 +50,8 @@ type BucketStoreMetrics struct {
 	seriesFetchDuration   prometheus.Histogram
 	postingsFetchDuration prometheus.Histogram
 
+	regexpMatchers *prometheus.CounterVec
+
 	indexHeaderReaderMetrics *indexheader.ReaderPoolMetrics
 }
 
@@ -107,6 +111,10 @@ func NewBucketStoreMetrics(reg prometheus.Registerer) *BucketStoreMetrics {
 		Name: "cortex_bucket_store_series_refetches_total",
 		Help: "Total number of cases where the built-in max series size was not enough to fetch series from index, resulting in refetch.",
 	})
+	m.seriesBloomFilterSkippedBlocks = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "cortex_bucket_store_series_bloom_filter_skipped_blocks_total",
+		Help: "Total number of blocks skipped by requests because the block's series bloom filter proved the block doesn't contain any matching series.",
+	})
 	m.resultSeriesCount = promauto.With(reg).NewSummary(prometheus.SummaryOpts{
 		Name: "cortex_bucket_store_series_result_series",
 		Help: "Number of series observed in the final result of a query.",
@@ -166,6 +174,11 @@ func NewBucketStoreMetrics(reg prometheus.Registerer) *BucketStoreMetrics {
 		Help: "Total number of fetch hits to the in-memory series hash cache.",
 	})
 
+	m.regexpMatchers = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+		Name: "cortex_bucket_store_regexp_matchers_total",
+		Help: "Total number of regexp label matchers looked up in the blocks index, by optimization used to find the matching label values.",
+	}, []string{"optimization"})
+
 	m.chunkSizeBytes = promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
 		Name: "cortex_bucket_store_sent_chunk_size_bytes",
 		Help: "Size in bytes of the chunks for the single series, which is adequate to the gRPC message size sent to querier.",
@@ -38,6 +38,7 @@ import (
 	"github.com/grafana/mimir/pkg/storage/bucket"
 	"github.com/grafana/mimir/pkg/storage/tsdb"
 	"github.com/grafana/mimir/pkg/storegateway/indexcache"
+	mimir_indexheader "github.com/grafana/mimir/pkg/storegateway/indexheader"
 	util_log "github.com/grafana/mimir/pkg/util/log"
 	"github.com/grafana/mimir/pkg/util/spanlogger"
 	"github.com/grafana/mimir/pkg/util/validation"
@@ -67,7 +68,7 @@ type BucketStores struct {
 	partitioner Partitioner
 
 	// Gate used to limit query concurrency across all tenants.
-	queryGate gate.Gate
+	queryGate *trackingGate
 
 	// Keeps a bucket store for each tenant.
 	storesMu sync.RWMutex
@@ -90,7 +91,7 @@ func NewBucketStores(cfg tsdb.BlocksStorageConfig, shardingStrategy ShardingStra
 
 	// The number of concurrent queries against the tenants BucketStores are limited.
 	queryGateReg := extprom.WrapRegistererWithPrefix("cortex_bucket_stores_", reg)
-	queryGate := gate.New(queryGateReg, cfg.BucketStore.MaxConcurrent)
+	queryGate := newTrackingGate(gate.New(queryGateReg, cfg.BucketStore.MaxConcurrent), cfg.BucketStore.MaxConcurrent)
 	promauto.With(reg).NewGauge(prometheus.GaugeOpts{
 		Name: "cortex_bucket_stores_gate_queries_concurrent_max",
 		Help: "Number of maximum concurrent queries allowed.",
@@ -495,6 +496,12 @@ func (u *BucketStores) getOrCreateStore(userID string) (*BucketStore, error) {
 	if u.logLevel.String() == "debug" {
 		bucketStoreOpts = append(bucketStoreOpts, WithDebugLogging())
 	}
+	if u.cfg.BucketStore.SeriesBloomFilterEnabled {
+		bucketStoreOpts = append(bucketStoreOpts, WithSeriesBloomFilter())
+	}
+	if u.cfg.BucketStore.IndexHeaderFormatVersion == mimir_indexheader.BinaryFormatV2 {
+		bucketStoreOpts = append(bucketStoreOpts, WithIndexHeaderFormatV2())
+	}
 
 	bs, err := NewBucketStore(
 		userID,
@@ -64,7 +64,9 @@ import (
 	"github.com/grafana/mimir/pkg/compactor"
 	"github.com/grafana/mimir/pkg/storage/sharding"
 	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/bloom"
 	"github.com/grafana/mimir/pkg/storegateway/indexcache"
+	mimir_indexheader "github.com/grafana/mimir/pkg/storegateway/indexheader"
 	"github.com/grafana/mimir/pkg/util/test"
 )
 
@@ -1549,6 +1551,88 @@ func createBlockFromHead(t testing.TB, dir string, head *tsdb.Head) ulid.ULID {
 
 // Very similar benchmark to ths: https://github.com/prometheus/prometheus/blob/1d1732bc25cc4b47f513cb98009a4eb91879f175/tsdb/querier_bench_test.go#L82,
 // but with postings results check when run as test.
+func TestToPostingGroup_RegexpOptimizations(t *testing.T) {
+	vals := []string{"bar", "foo", "foo1", "foo2", "foobar", "fop"}
+	lvalsFn := func(string) ([]string, error) { return vals, nil }
+
+	valueLabels := func(values ...string) []labels.Label {
+		var out []labels.Label
+		for _, v := range values {
+			out = append(out, labels.Label{Name: "l", Value: v})
+		}
+		return out
+	}
+
+	tests := map[string]struct {
+		matcher              *labels.Matcher
+		expectedGroup        *postingGroup
+		expectedOptimization string
+	}{
+		"set matches": {
+			matcher:              labels.MustNewMatcher(labels.MatchRegexp, "l", "foo|bar"),
+			expectedGroup:        newPostingGroup(false, valueLabels("foo", "bar"), nil),
+			expectedOptimization: regexpOptimizationSetMatches,
+		},
+		"prefix": {
+			matcher:              labels.MustNewMatcher(labels.MatchRegexp, "l", "foo.+"),
+			expectedGroup:        newPostingGroup(false, valueLabels("foo1", "foo2", "foobar"), nil),
+			expectedOptimization: regexpOptimizationPrefix,
+		},
+		"prefix with anchor": {
+			matcher:              labels.MustNewMatcher(labels.MatchRegexp, "l", "^foo.$"),
+			expectedGroup:        newPostingGroup(false, valueLabels("foo1", "foo2"), nil),
+			expectedOptimization: regexpOptimizationPrefix,
+		},
+		"negated prefix": {
+			matcher:              labels.MustNewMatcher(labels.MatchNotRegexp, "l", "foo.*"),
+			expectedGroup:        newPostingGroup(true, nil, valueLabels("foo", "foo1", "foo2", "foobar")),
+			expectedOptimization: regexpOptimizationPrefix,
+		},
+		"prefix matching no values": {
+			matcher:              labels.MustNewMatcher(labels.MatchRegexp, "l", "baz.*"),
+			expectedGroup:        newPostingGroup(false, nil, nil),
+			expectedOptimization: regexpOptimizationPrefix,
+		},
+		"case insensitive": {
+			matcher:              labels.MustNewMatcher(labels.MatchRegexp, "l", "(?i)FOO.+"),
+			expectedGroup:        newPostingGroup(false, valueLabels("foo1", "foo2", "foobar"), nil),
+			expectedOptimization: regexpOptimizationNone,
+		},
+		"no prefix": {
+			matcher:              labels.MustNewMatcher(labels.MatchRegexp, "l", ".*o.*"),
+			expectedGroup:        newPostingGroup(false, valueLabels("foo", "foo1", "foo2", "foobar", "fop"), nil),
+			expectedOptimization: regexpOptimizationNone,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			pg, optimization, err := toPostingGroup(lvalsFn, tc.matcher)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedGroup, pg)
+			assert.Equal(t, tc.expectedOptimization, optimization)
+		})
+	}
+}
+
+func TestRegexpLiteralPrefix(t *testing.T) {
+	for expr, expected := range map[string]string{
+		"foo.*":      "foo",
+		"^foo.*$":    "foo",
+		"foo.*bar":   "foo",
+		"foo(a|b)":   "foo",
+		"fo[o]bar.*": "foobar",
+		"foo|foo1.*": "foo",
+		"foo|bar.*":  "",
+		".*foo":      "",
+		"(?i)foo.*":  "",
+		"":           "",
+		"(":          "",
+	} {
+		assert.Equal(t, expected, regexpLiteralPrefix(expr), expr)
+	}
+}
+
 func benchmarkExpandedPostings(
 	t test.TB,
 	newTestBucketBlock func() *bucketBlock,
@@ -2131,6 +2215,201 @@ func TestSeries_ErrorUnmarshallingRequestHints(t *testing.T) {
 	assert.Equal(t, true, regexp.MustCompile(".*unmarshal series request hints.*").MatchString(err.Error()))
 }
 
+func TestSeries_SeriesBloomFilter(t *testing.T) {
+	for _, bloomEnabled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("bloom filter enabled: %t", bloomEnabled), func(t *testing.T) {
+			tmpDir := t.TempDir()
+			bktDir := filepath.Join(tmpDir, "bkt")
+			bkt, err := filesystem.NewBucket(bktDir)
+			require.NoError(t, err)
+			t.Cleanup(func() { assert.NoError(t, bkt.Close()) })
+
+			var (
+				logger   = log.NewNopLogger()
+				instrBkt = objstore.WithNoopInstr(bkt)
+				random   = rand.New(rand.NewSource(120))
+			)
+
+			// Create TSDB blocks, each one with a series bloom filter.
+			var blockIDs []ulid.ULID
+			for i := 0; i < 2; i++ {
+				head, _ := createHeadWithSeries(t, i, headGenOptions{
+					TSDBDir:          filepath.Join(tmpDir, strconv.Itoa(i)),
+					SamplesPerSeries: 1,
+					Series:           2,
+					Random:           random,
+				})
+				blockID := createBlockFromHead(t, bktDir, head)
+				require.NoError(t, head.Close())
+
+				blockDir := filepath.Join(bktDir, blockID.String())
+				_, err := metadata.InjectThanos(logger, blockDir, metadata.Thanos{
+					Labels:     labels.Labels{{Name: "ext1", Value: "1"}}.Map(),
+					Downsample: metadata.ThanosDownsample{Resolution: 0},
+					Source:     metadata.TestSource,
+				}, nil)
+				require.NoError(t, err)
+
+				_, err = bloom.WriteForBlock(blockDir, bloom.DefaultFalsePositiveRate)
+				require.NoError(t, err)
+
+				blockIDs = append(blockIDs, blockID)
+			}
+
+			fetcher, err := block.NewMetaFetcher(logger, 10, instrBkt, tmpDir, nil, nil)
+			require.NoError(t, err)
+
+			var opts []BucketStoreOption
+			if bloomEnabled {
+				opts = append(opts, WithSeriesBloomFilter())
+			}
+
+			metrics := NewBucketStoreMetrics(nil)
+			store, err := NewBucketStore(
+				"tenant",
+				instrBkt,
+				fetcher,
+				tmpDir,
+				NewChunksLimiterFactory(10000/MaxSamplesPerChunk),
+				NewSeriesLimiterFactory(0),
+				newGapBasedPartitioner(mimir_tsdb.DefaultPartitionerMaxGapSize, nil),
+				10,
+				false,
+				mimir_tsdb.DefaultPostingOffsetInMemorySampling,
+				true,
+				false,
+				0,
+				hashcache.NewSeriesHashCache(1024*1024),
+				metrics,
+				opts...,
+			)
+			require.NoError(t, err)
+			require.NoError(t, store.SyncBlocks(context.Background()))
+			t.Cleanup(func() { assert.NoError(t, store.Close()) })
+
+			expectedHints := hintspb.SeriesResponseHints{}
+			for _, id := range blockIDs {
+				expectedHints.AddQueriedBlock(id)
+			}
+
+			// Matching series. External labels matchers must not be checked against the bloom filter.
+			srv := newBucketStoreSeriesServer(context.Background())
+			require.NoError(t, store.Series(&storepb.SeriesRequest{
+				MinTime: 0,
+				MaxTime: 3,
+				Matchers: []storepb.LabelMatcher{
+					{Type: storepb.LabelMatcher_EQ, Name: "foo", Value: "bar"},
+					{Type: storepb.LabelMatcher_EQ, Name: "ext1", Value: "1"},
+				},
+			}, srv))
+			assert.Len(t, srv.SeriesSet, 4)
+			assert.Equal(t, 0.0, promtest.ToFloat64(metrics.seriesBloomFilterSkippedBlocks))
+
+			// Not matching series.
+			srv = newBucketStoreSeriesServer(context.Background())
+			require.NoError(t, store.Series(&storepb.SeriesRequest{
+				MinTime: 0,
+				MaxTime: 3,
+				Matchers: []storepb.LabelMatcher{
+					{Type: storepb.LabelMatcher_EQ, Name: "foo", Value: "baz"},
+				},
+			}, srv))
+			assert.Len(t, srv.SeriesSet, 0)
+
+			// Skipped blocks must be reported as queried.
+			assert.ElementsMatch(t, expectedHints.QueriedBlocks, srv.Hints.QueriedBlocks)
+
+			if bloomEnabled {
+				assert.Equal(t, 2.0, promtest.ToFloat64(metrics.seriesBloomFilterSkippedBlocks))
+			} else {
+				assert.Equal(t, 0.0, promtest.ToFloat64(metrics.seriesBloomFilterSkippedBlocks))
+			}
+		})
+	}
+}
+
+func TestSeries_IndexHeaderFormatV2(t *testing.T) {
+	tmpDir := t.TempDir()
+	bktDir := filepath.Join(tmpDir, "bkt")
+	bkt, err := filesystem.NewBucket(bktDir)
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, bkt.Close()) })
+
+	var (
+		logger   = log.NewNopLogger()
+		instrBkt = objstore.WithNoopInstr(bkt)
+		random   = rand.New(rand.NewSource(120))
+	)
+
+	head, _ := createHeadWithSeries(t, 0, headGenOptions{
+		TSDBDir:          filepath.Join(tmpDir, "0"),
+		SamplesPerSeries: 1,
+		Series:           4,
+		Random:           random,
+	})
+	blockID := createBlockFromHead(t, bktDir, head)
+	require.NoError(t, head.Close())
+
+	_, err = metadata.InjectThanos(logger, filepath.Join(bktDir, blockID.String()), metadata.Thanos{
+		Labels:     labels.Labels{{Name: "ext1", Value: "1"}}.Map(),
+		Downsample: metadata.ThanosDownsample{Resolution: 0},
+		Source:     metadata.TestSource,
+	}, nil)
+	require.NoError(t, err)
+
+	// Run the store with the index-header version 1 first, and then with the version 2, which converts the
+	// index-header version 1 left on disk.
+	var expected []*storepb.Series
+	for _, v2Enabled := range []bool{false, true} {
+		fetcher, err := block.NewMetaFetcher(logger, 10, instrBkt, tmpDir, nil, nil)
+		require.NoError(t, err)
+
+		var opts []BucketStoreOption
+		if v2Enabled {
+			opts = append(opts, WithIndexHeaderFormatV2())
+		}
+
+		store, err := NewBucketStore(
+			"tenant",
+			instrBkt,
+			fetcher,
+			tmpDir,
+			NewChunksLimiterFactory(10000/MaxSamplesPerChunk),
+			NewSeriesLimiterFactory(0),
+			newGapBasedPartitioner(mimir_tsdb.DefaultPartitionerMaxGapSize, nil),
+			10,
+			false,
+			mimir_tsdb.DefaultPostingOffsetInMemorySampling,
+			true,
+			false,
+			0,
+			hashcache.NewSeriesHashCache(1024*1024),
+			NewBucketStoreMetrics(nil),
+			opts...,
+		)
+		require.NoError(t, err)
+		require.NoError(t, store.SyncBlocks(context.Background()))
+
+		srv := newBucketStoreSeriesServer(context.Background())
+		require.NoError(t, store.Series(&storepb.SeriesRequest{
+			MinTime:  0,
+			MaxTime:  3,
+			Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: "foo", Value: "bar|baz"}},
+		}, srv))
+		require.NoError(t, store.Close())
+
+		if !v2Enabled {
+			require.NotEmpty(t, srv.SeriesSet)
+			expected = srv.SeriesSet
+			continue
+		}
+
+		assert.Equal(t, expected, srv.SeriesSet)
+		_, err = os.Stat(filepath.Join(tmpDir, blockID.String(), mimir_indexheader.IndexHeaderV2Filename))
+		assert.NoError(t, err)
+	}
+}
+
 func TestSeries_BlockWithMultipleChunks(t *testing.T) {
 	tmpDir, err := ioutil.TempDir("", "test-block-with-multiple-chunks")
 	assert.NoError(t, err)
@@ -9,10 +9,12 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/flagext"
 	"github.com/grafana/dskit/kv"
 	"github.com/grafana/dskit/ring"
 	"github.com/grafana/dskit/services"
@@ -46,16 +48,27 @@ const (
 var (
 	// Validation errors.
 	errInvalidTenantShardSize = errors.New("invalid tenant shard size, the value must be greater or equal to 0")
+	errInvalidBlockAgeRange   = errors.New("invalid blocks age range, the max block age must be 0 or greater than the min block age")
 )
 
 // Config holds the store gateway config.
 type Config struct {
 	ShardingRing RingConfig `yaml:"sharding_ring" doc:"description=The hash ring configuration."`
+
+	EnabledTenants  flagext.StringSliceCSV `yaml:"enabled_tenants" category:"experimental"`
+	DisabledTenants flagext.StringSliceCSV `yaml:"disabled_tenants" category:"experimental"`
+	MinBlockAge     time.Duration          `yaml:"min_block_age" category:"experimental"`
+	MaxBlockAge     time.Duration          `yaml:"max_block_age" category:"experimental"`
 }
 
 // RegisterFlags registers the Config flags.
 func (cfg *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	cfg.ShardingRing.RegisterFlags(f, logger)
+
+	f.Var(&cfg.EnabledTenants, "store-gateway.enabled-tenants", "Comma separated list of tenants whose blocks can be loaded by this store-gateway. If specified, only these tenants will be served by the store-gateway, otherwise all tenants can be served. Subject to sharding.")
+	f.Var(&cfg.DisabledTenants, "store-gateway.disabled-tenants", "Comma separated list of tenants whose blocks cannot be loaded by this store-gateway. If specified, and the store-gateway would normally serve a given tenant (via -store-gateway.enabled-tenants or sharding), it will be ignored instead.")
+	f.DurationVar(&cfg.MinBlockAge, "store-gateway.min-block-age", 0, "If greater than 0, this store-gateway only loads blocks whose max time is older than the configured age. Allows to run a dedicated pool of store-gateways for long-range queries. 0 = no limit.")
+	f.DurationVar(&cfg.MaxBlockAge, "store-gateway.max-block-age", 0, "If greater than 0, this store-gateway only loads blocks whose max time is within the configured age. 0 = no limit.")
 }
 
 // Validate the Config.
@@ -63,10 +76,17 @@ func (cfg *Config) Validate(limits validation.Limits) error {
 	if limits.StoreGatewayTenantShardSize < 0 {
 		return errInvalidTenantShardSize
 	}
+	if cfg.MinBlockAge < 0 || cfg.MaxBlockAge < 0 || (cfg.MaxBlockAge > 0 && cfg.MaxBlockAge <= cfg.MinBlockAge) {
+		return errInvalidBlockAgeRange
+	}
 
 	return nil
 }
 
+func (cfg *Config) isPoolFilteringEnabled() bool {
+	return len(cfg.EnabledTenants) > 0 || len(cfg.DisabledTenants) > 0 || cfg.MinBlockAge > 0 || cfg.MaxBlockAge > 0
+}
+
 // StoreGateway is the Mimir service responsible to expose an API over the bucket
 // where blocks are stored, supporting blocks sharding and replication across a pool
 // of store gateway instances (optional).
@@ -157,6 +177,10 @@ func newStoreGateway(gatewayCfg Config, storageCfg mimir_tsdb.BlocksStorageConfi
 	}
 
 	shardingStrategy = NewShuffleShardingStrategy(g.ring, lifecyclerCfg.ID, lifecyclerCfg.Addr, limits, logger)
+	if gatewayCfg.isPoolFilteringEnabled() {
+		level.Info(logger).Log("msg", "store-gateway serving a subset of tenants and blocks", "enabled_tenants", strings.Join(gatewayCfg.EnabledTenants, ", "), "disabled_tenants", strings.Join(gatewayCfg.DisabledTenants, ", "), "min_block_age", gatewayCfg.MinBlockAge, "max_block_age", gatewayCfg.MaxBlockAge)
+		shardingStrategy = NewPoolFilteringStrategy(shardingStrategy, gatewayCfg.EnabledTenants, gatewayCfg.DisabledTenants, gatewayCfg.MinBlockAge, gatewayCfg.MaxBlockAge)
+	}
 
 	g.stores, err = NewBucketStores(storageCfg, shardingStrategy, bucketClient, limits, logLevel, logger, extprom.WrapRegistererWith(prometheus.Labels{"component": "store-gateway"}, reg))
 	if err != nil {
@@ -0,0 +1,117 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/oklog/ulid"
+	"github.com/thanos-io/thanos/pkg/block"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/tenant"
+	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/listblocks"
+)
+
+// BlockInfo holds the details of a block returned by the blocks listing API.
+type BlockInfo struct {
+	ID              string                `json:"id"`
+	MinTime         int64                 `json:"min_time"`
+	MaxTime         int64                 `json:"max_time"`
+	SizeBytes       uint64                `json:"size_bytes,omitempty"`
+	CompactionLevel int                   `json:"compaction_level"`
+	Source          string                `json:"source"`
+	Labels          map[string]string     `json:"labels,omitempty"`
+	Annotations     tsdb.BlockAnnotations `json:"annotations,omitempty"`
+
+	// Unix timestamp (seconds) of the deletion mark, set only for blocks marked for deletion.
+	DeletionTime int64 `json:"deletion_time,omitempty"`
+}
+
+// BlocksListResponse is the response of the blocks listing API.
+type BlocksListResponse struct {
+	Blocks []BlockInfo `json:"blocks"`
+}
+
+// blocksAPITenantID returns the tenant the blocks API request is for. The tenant is read from the
+// URL path when the API is called in operator mode, or from the request otherwise.
+func blocksAPITenantID(req *http.Request) (string, error) {
+	if tenantID := mux.Vars(req)["tenant"]; tenantID != "" {
+		return tenantID, nil
+	}
+	return tenant.TenantID(req.Context())
+}
+
+// BlocksListHandler lists the blocks of a tenant. Blocks marked for deletion are included
+// only if the show_deleted=true query parameter is set.
+func (s *StoreGateway) BlocksListHandler(w http.ResponseWriter, req *http.Request) {
+	tenantID, err := blocksAPITenantID(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	showDeleted := req.URL.Query().Get("show_deleted") == "true"
+
+	metasMap, deletedTimes, annotations, err := listblocks.LoadMetaFilesAndDeletionMarkers(req.Context(), s.stores.bucket, tenantID, showDeleted, time.Time{})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read block metadata: %s", err), http.StatusInternalServerError)
+		return
+	}
+
+	resp := BlocksListResponse{Blocks: make([]BlockInfo, 0, len(metasMap))}
+	for _, m := range listblocks.SortBlocks(metasMap) {
+		info := BlockInfo{
+			ID:              m.ULID.String(),
+			MinTime:         m.MinTime,
+			MaxTime:         m.MaxTime,
+			SizeBytes:       listblocks.GetBlockSizeBytes(m),
+			CompactionLevel: m.Compaction.Level,
+			Source:          string(m.Thanos.Source),
+			Labels:          m.Thanos.Labels,
+			Annotations:     annotations[m.ULID],
+		}
+		if deletionTime, ok := deletedTimes[m.ULID]; ok {
+			info.DeletionTime = deletionTime.Unix()
+		}
+		resp.Blocks = append(resp.Blocks, info)
+	}
+
+	util.WriteJSONResponse(w, resp)
+}
+
+// BlockMetaHandler returns the meta.json of a tenant's block, as stored in the bucket.
+func (s *StoreGateway) BlockMetaHandler(w http.ResponseWriter, req *http.Request) {
+	tenantID, err := blocksAPITenantID(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	blockID, err := ulid.Parse(mux.Vars(req)["block"])
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid block ID: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	r, err := s.stores.bucket.Get(req.Context(), path.Join(tenantID, blockID.String(), block.MetaFilename))
+	if s.stores.bucket.IsObjNotFoundErr(err) {
+		http.Error(w, "block not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read block metadata: %s", err), http.StatusInternalServerError)
+		return
+	}
+	defer r.Close()
+
+	w.Header().Set("Content-Type", "application/json")
+	// We ignore errors here, because we cannot do anything about them once the response has started.
+	_, _ = io.Copy(w, r)
+}
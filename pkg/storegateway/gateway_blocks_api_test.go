@@ -0,0 +1,134 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/gorilla/mux"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+)
+
+func TestStoreGateway_BlocksAPI(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+
+	block1 := ulid.MustNew(1, nil)
+	block2 := ulid.MustNew(2, nil)
+	otherTenantBlock := ulid.MustNew(3, nil)
+
+	uploadMeta := func(tenantID string, blockID ulid.ULID, minT, maxT int64) {
+		meta := metadata.Meta{Thanos: metadata.Thanos{Version: metadata.ThanosVersion1, Source: metadata.CompactorSource, Labels: map[string]string{"__org_id__": tenantID}}}
+		meta.ULID, meta.MinTime, meta.MaxTime, meta.Version = blockID, minT, maxT, metadata.TSDBVersion1
+		meta.Compaction.Level = 2
+
+		buf := bytes.Buffer{}
+		require.NoError(t, meta.Write(&buf))
+		require.NoError(t, bkt.Upload(ctx, path.Join(tenantID, blockID.String(), metadata.MetaFilename), &buf))
+	}
+
+	uploadMeta("user-1", block1, 10, 20)
+	uploadMeta("user-1", block2, 20, 30)
+	uploadMeta("user-2", otherTenantBlock, 10, 20)
+
+	userBkt := bucket.NewUserBucketClient("user-1", bkt, nil)
+	require.NoError(t, block.MarkForDeletion(ctx, log.NewNopLogger(), bucketindex.BucketWithGlobalMarkers(userBkt), block2, "test", promauto.With(nil).NewCounter(prometheus.CounterOpts{})))
+
+	g := &StoreGateway{stores: &BucketStores{bucket: bkt}}
+
+	router := mux.NewRouter()
+	router.Path("/api/v1/blocks").HandlerFunc(g.BlocksListHandler)
+	router.Path("/api/v1/blocks/{block}/meta.json").HandlerFunc(g.BlockMetaHandler)
+	router.Path("/store-gateway/tenant/{tenant}/api/v1/blocks").HandlerFunc(g.BlocksListHandler)
+
+	listBlocks := func(t *testing.T, req *http.Request) BlocksListResponse {
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+		out := BlocksListResponse{}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &out))
+		return out
+	}
+
+	t.Run("list blocks of the request tenant", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/blocks", nil)
+		req = req.WithContext(user.InjectOrgID(req.Context(), "user-1"))
+
+		assert.Equal(t, BlocksListResponse{Blocks: []BlockInfo{
+			{ID: block1.String(), MinTime: 10, MaxTime: 20, CompactionLevel: 2, Source: string(metadata.CompactorSource), Labels: map[string]string{"__org_id__": "user-1"}},
+		}}, listBlocks(t, req))
+	})
+
+	t.Run("list blocks including the ones marked for deletion", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/blocks?show_deleted=true", nil)
+		req = req.WithContext(user.InjectOrgID(req.Context(), "user-1"))
+
+		blocks := listBlocks(t, req).Blocks
+		require.Len(t, blocks, 2)
+		assert.Equal(t, block2.String(), blocks[1].ID)
+		assert.InDelta(t, time.Now().Unix(), blocks[1].DeletionTime, 60)
+	})
+
+	t.Run("list blocks of any tenant in operator mode", func(t *testing.T) {
+		blocks := listBlocks(t, httptest.NewRequest("GET", "/store-gateway/tenant/user-2/api/v1/blocks", nil)).Blocks
+		require.Len(t, blocks, 1)
+		assert.Equal(t, otherTenantBlock.String(), blocks[0].ID)
+	})
+
+	t.Run("missing tenant", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, httptest.NewRequest("GET", "/api/v1/blocks", nil))
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	})
+
+	t.Run("get block meta", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/blocks/"+block1.String()+"/meta.json", nil)
+		req = req.WithContext(user.InjectOrgID(req.Context(), "user-1"))
+
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		meta := metadata.Meta{}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &meta))
+		assert.Equal(t, block1, meta.ULID)
+	})
+
+	t.Run("get block meta of a block belonging to another tenant", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/blocks/"+otherTenantBlock.String()+"/meta.json", nil)
+		req = req.WithContext(user.InjectOrgID(req.Context(), "user-1"))
+
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("get block meta with invalid block ID", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/blocks/invalid/meta.json", nil)
+		req = req.WithContext(user.InjectOrgID(req.Context(), "user-1"))
+
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
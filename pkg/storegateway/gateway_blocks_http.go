@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -41,9 +42,13 @@ const blocksPageTemplate = `
 			{{ if not .ShowParents }}
 				<a href="{{ .ShowParentsQuery }}">Show Parents</a>
 			{{ end }}
+			{{ if not .ShowAnnotations }}
+				<a href="{{ .ShowAnnotationsQuery }}">Show Annotations</a>
+			{{ end }}
 		</p>
 		<p>
 			Use ?split_count= query param to show split compactor count preview.
+			Use ?annotation=key=value query param (can be repeated) to only show blocks with the given annotations.
 		</p>
 		<table border="1" cellpadding="5" style="border-collapse: collapse">
 			<thead>
@@ -60,6 +65,7 @@ const blocksPageTemplate = `
 					<th>Labels (excl. {{ .TSDBTenantIDExternalLabel }})</th>
 					{{ if .ShowSources }}<th>Sources</th>{{ end }}
 					{{ if .ShowParents }}<th>Parents</th>{{ end }}
+					{{ if .ShowAnnotations }}<th>Annotations</th>{{ end }}
 				</tr>
 			</thead>
 			<tbody style="font-family: monospace;">
@@ -92,6 +98,7 @@ const blocksPageTemplate = `
 							{{ end }}
 						</td>
 					{{ end }}
+					{{ if $page.ShowAnnotations }}<td>{{ .Annotations }}</td>{{ end }}
 				</tr>
 				{{ end }}
 			</tbody>
@@ -117,6 +124,7 @@ func (s *StoreGateway) BlocksHandler(w http.ResponseWriter, req *http.Request) {
 	showDeleted := req.Form.Get("show_deleted") == "true"
 	showSources := req.Form.Get("show_sources") == "true"
 	showParents := req.Form.Get("show_parents") == "true"
+	showAnnotations := req.Form.Get("show_annotations") == "true"
 	var splitCount int
 	if sc := req.Form.Get("split_count"); sc != "" {
 		var err error
@@ -127,7 +135,17 @@ func (s *StoreGateway) BlocksHandler(w http.ResponseWriter, req *http.Request) {
 		}
 	}
 
-	metasMap, deletedTimes, err := listblocks.LoadMetaFilesAndDeletionMarkers(req.Context(), s.stores.bucket, tenantID, showDeleted, time.Time{})
+	annotationsFilter := map[string]string{}
+	for _, a := range req.Form["annotation"] {
+		parts := strings.SplitN(a, "=", 2)
+		if len(parts) != 2 {
+			util.WriteTextResponse(w, fmt.Sprintf("Bad annotation param, expected key=value: %s", a))
+			return
+		}
+		annotationsFilter[parts[0]] = parts[1]
+	}
+
+	metasMap, deletedTimes, annotations, err := listblocks.LoadMetaFilesAndDeletionMarkers(req.Context(), s.stores.bucket, tenantID, showDeleted, time.Time{})
 	if err != nil {
 		util.WriteTextResponse(w, fmt.Sprintf("Failed to read block metadata: %s", err))
 		return
@@ -147,12 +165,14 @@ func (s *StoreGateway) BlocksHandler(w http.ResponseWriter, req *http.Request) {
 		Labels          string
 		Sources         []string
 		Parents         []string
+		Annotations     string
 	}
 
 	type richMeta struct {
 		*metadata.Meta
-		DeletedTime *int64  `json:"deletedTime,omitempty"`
-		SplitID     *uint32 `json:"splitId,omitempty"`
+		DeletedTime *int64            `json:"deletedTime,omitempty"`
+		SplitID     *uint32           `json:"splitId,omitempty"`
+		Annotations tsdb.BlockAnnotations `json:"annotations,omitempty"`
 	}
 
 	formattedBlocks := make([]formattedBlockData, 0, len(metas))
@@ -162,6 +182,9 @@ func (s *StoreGateway) BlocksHandler(w http.ResponseWriter, req *http.Request) {
 		if !showDeleted && !deletedTimes[m.ULID].IsZero() {
 			continue
 		}
+		if !annotations[m.ULID].Matches(annotationsFilter) {
+			continue
+		}
 		var parents []string
 		for _, pb := range m.Compaction.Parents {
 			parents = append(parents, pb.ULID.String())
@@ -189,6 +212,7 @@ func (s *StoreGateway) BlocksHandler(w http.ResponseWriter, req *http.Request) {
 			Labels:          lbls.WithoutLabels(tsdb.TenantIDExternalLabel).String(),
 			Sources:         sources,
 			Parents:         parents,
+			Annotations:     annotations[m.ULID].String(),
 		})
 		var deletedAt *int64
 		if dt, ok := deletedTimes[m.ULID]; ok {
@@ -199,6 +223,7 @@ func (s *StoreGateway) BlocksHandler(w http.ResponseWriter, req *http.Request) {
 			Meta:        m,
 			DeletedTime: deletedAt,
 			SplitID:     blockSplitID,
+			Annotations: annotations[m.ULID],
 		})
 	}
 
@@ -211,10 +236,12 @@ func (s *StoreGateway) BlocksHandler(w http.ResponseWriter, req *http.Request) {
 		ShowSplitCount  bool                 `json:"-"`
 		ShowSources     bool                 `json:"-"`
 		ShowParents     bool                 `json:"-"`
+		ShowAnnotations bool                 `json:"-"`
 
-		ShowDeletedQuery string `json:"-"`
-		ShowSourcesQuery string `json:"-"`
-		ShowParentsQuery string `json:"-"`
+		ShowDeletedQuery     string `json:"-"`
+		ShowSourcesQuery     string `json:"-"`
+		ShowParentsQuery     string `json:"-"`
+		ShowAnnotationsQuery string `json:"-"`
 
 		TSDBTenantIDExternalLabel string `json:"-"`
 	}{
@@ -223,14 +250,16 @@ func (s *StoreGateway) BlocksHandler(w http.ResponseWriter, req *http.Request) {
 		RichMetas:       richMetas,
 		FormattedBlocks: formattedBlocks,
 
-		ShowSplitCount: splitCount > 0,
-		ShowDeleted:    showDeleted,
-		ShowSources:    showSources,
-		ShowParents:    showParents,
+		ShowSplitCount:  splitCount > 0,
+		ShowDeleted:     showDeleted,
+		ShowSources:     showSources,
+		ShowParents:     showParents,
+		ShowAnnotations: showAnnotations,
 
-		ShowDeletedQuery: queryWithTrueBoolParam(*req.URL, req.Form, "show_deleted"),
-		ShowSourcesQuery: queryWithTrueBoolParam(*req.URL, req.Form, "show_sources"),
-		ShowParentsQuery: queryWithTrueBoolParam(*req.URL, req.Form, "show_parents"),
+		ShowDeletedQuery:     queryWithTrueBoolParam(*req.URL, req.Form, "show_deleted"),
+		ShowSourcesQuery:     queryWithTrueBoolParam(*req.URL, req.Form, "show_sources"),
+		ShowParentsQuery:     queryWithTrueBoolParam(*req.URL, req.Form, "show_parents"),
+		ShowAnnotationsQuery: queryWithTrueBoolParam(*req.URL, req.Form, "show_annotations"),
 
 		TSDBTenantIDExternalLabel: tsdb.TenantIDExternalLabel,
 	}, blocksTemplate, req)
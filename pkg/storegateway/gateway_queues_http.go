@@ -0,0 +1,23 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/grafana/mimir/pkg/util"
+)
+
+// QueuesDebugStatus is the state of the store-gateway queues returned by QueuesDebugHandler.
+type QueuesDebugStatus struct {
+	QueryGate QueryGateStatus `json:"query_gate"`
+}
+
+// QueuesDebugHandler writes the state of the gate limiting the concurrent queries as JSON, to find out
+// whether the queries are queued in the store-gateway.
+func (s *StoreGateway) QueuesDebugHandler(w http.ResponseWriter, _ *http.Request) {
+	util.WriteJSONResponse(w, QueuesDebugStatus{
+		QueryGate: s.stores.queryGate.status(time.Now()),
+	})
+}
@@ -77,6 +77,26 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			expected: nil,
 		},
+		"should pass if the blocks age range is valid": {
+			setup: func(cfg *Config, limits *validation.Limits) {
+				cfg.MinBlockAge = 24 * time.Hour
+				cfg.MaxBlockAge = 48 * time.Hour
+			},
+			expected: nil,
+		},
+		"should fail if the max block age is not greater than the min block age": {
+			setup: func(cfg *Config, limits *validation.Limits) {
+				cfg.MinBlockAge = 24 * time.Hour
+				cfg.MaxBlockAge = 24 * time.Hour
+			},
+			expected: errInvalidBlockAgeRange,
+		},
+		"should fail if the min block age is negative": {
+			setup: func(cfg *Config, limits *validation.Limits) {
+				cfg.MinBlockAge = -time.Hour
+			},
+			expected: errInvalidBlockAgeRange,
+		},
 	}
 
 	for testName, testData := range tests {
@@ -7,6 +7,7 @@ package indexcache
 
 import (
 	"context"
+	"regexp"
 	"sort"
 	"strings"
 
@@ -79,13 +80,16 @@ type IndexCache interface {
 // LabelMatchersKey represents a canonical key for a []*matchers.Matchers slice
 type LabelMatchersKey string
 
-// CanonicalLabelMatchersKey creates a canonical version of LabelMatchersKey
+// CanonicalLabelMatchersKey creates a canonical version of LabelMatchersKey, so that the sets of matchers
+// selecting the same series share the same key: the matchers are sorted and deduplicated, and the regexp
+// matchers only matching a set of literal values are normalized (see canonicalLabelMatcher).
 func CanonicalLabelMatchersKey(ms []*labels.Matcher) LabelMatchersKey {
 	sorted := make([]labels.Matcher, len(ms))
 	for i := range ms {
-		sorted[i] = labels.Matcher{Type: ms[i].Type, Name: ms[i].Name, Value: ms[i].Value}
+		sorted[i] = canonicalLabelMatcher(ms[i])
 	}
 	sort.Sort(sortedLabelMatchers(sorted))
+	sorted = uniqueLabelMatchers(sorted)
 
 	const (
 		typeLen = 2
@@ -106,6 +110,69 @@ func CanonicalLabelMatchersKey(ms []*labels.Matcher) LabelMatchersKey {
 	return LabelMatchersKey(sb.String())
 }
 
+// canonicalLabelMatcher returns a copy of the matcher without the compiled regexp. The regexp matchers
+// only matching a set of literal values, like `=~"b|a"` or `=~"(a|b)"`, are normalized to the sorted
+// alternation of the values, like `=~"a|b"`, or to an equality matcher if they only match one value,
+// like `=~"a"` to `="a"`.
+func canonicalLabelMatcher(m *labels.Matcher) labels.Matcher {
+	c := labels.Matcher{Type: m.Type, Name: m.Name, Value: m.Value}
+	if m.Type != labels.MatchRegexp && m.Type != labels.MatchNotRegexp {
+		return c
+	}
+
+	set := m.SetMatches()
+	if len(set) == 0 {
+		return c
+	}
+	// The set is owned by the matcher, so it's copied before being sorted.
+	values := uniqueStrings(append([]string(nil), set...))
+
+	if len(values) == 1 {
+		if m.Type == labels.MatchRegexp {
+			c.Type = labels.MatchEqual
+		} else {
+			c.Type = labels.MatchNotEqual
+		}
+		c.Value = values[0]
+		return c
+	}
+
+	for i, v := range values {
+		values[i] = regexp.QuoteMeta(v)
+	}
+	c.Value = strings.Join(values, "|")
+	return c
+}
+
+// uniqueStrings sorts the input slice and removes the duplicates, in place.
+func uniqueStrings(values []string) []string {
+	sort.Strings(values)
+	if len(values) < 2 {
+		return values
+	}
+	unique := values[:1]
+	for _, v := range values[1:] {
+		if v != unique[len(unique)-1] {
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
+
+// uniqueLabelMatchers removes the duplicates from the input sorted slice, in place.
+func uniqueLabelMatchers(ms []labels.Matcher) []labels.Matcher {
+	if len(ms) < 2 {
+		return ms
+	}
+	unique := ms[:1]
+	for _, m := range ms[1:] {
+		if last := unique[len(unique)-1]; m.Type != last.Type || m.Name != last.Name || m.Value != last.Value {
+			unique = append(unique, m)
+		}
+	}
+	return unique
+}
+
 type sortedLabelMatchers []labels.Matcher
 
 func (c sortedLabelMatchers) Less(i, j int) bool {
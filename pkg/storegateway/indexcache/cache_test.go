@@ -26,6 +26,73 @@ func TestCanonicalLabelMatchersKey(t *testing.T) {
 	assert.Equal(t, CanonicalLabelMatchersKey([]*labels.Matcher{foo, bar}), CanonicalLabelMatchersKey([]*labels.Matcher{bar, foo}))
 }
 
+func TestCanonicalLabelMatchersKey_ShouldNormalizeTheMatchers(t *testing.T) {
+	tests := map[string]struct {
+		first, second []*labels.Matcher
+		same          bool
+	}{
+		"duplicated matchers": {
+			first:  []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "foo", "bar"), labels.MustNewMatcher(labels.MatchEqual, "foo", "bar")},
+			second: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "foo", "bar")},
+			same:   true,
+		},
+		"regexp matching a single literal value": {
+			first:  []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, "foo", "bar")},
+			second: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "foo", "bar")},
+			same:   true,
+		},
+		"negated regexp matching a single literal value with metacharacters": {
+			first:  []*labels.Matcher{labels.MustNewMatcher(labels.MatchNotRegexp, "foo", `^a\.b$`)},
+			second: []*labels.Matcher{labels.MustNewMatcher(labels.MatchNotEqual, "foo", "a.b")},
+			same:   true,
+		},
+		"regexp matching a set of literal values in a different order": {
+			first:  []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, "foo", "b|a|c")},
+			second: []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, "foo", "(a|c|b|a)")},
+			same:   true,
+		},
+		"regexp matching a set of literal values with metacharacters": {
+			first:  []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, "foo", `a\.b|c`)},
+			second: []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, "foo", `c|a\.b`)},
+			same:   true,
+		},
+		"regexp matching different sets of literal values": {
+			first:  []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, "foo", `a.b|c`)},
+			second: []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, "foo", `a\.b|c`)},
+			same:   false,
+		},
+		"regexp matching literal values and its negation": {
+			first:  []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, "foo", "a|b")},
+			second: []*labels.Matcher{labels.MustNewMatcher(labels.MatchNotRegexp, "foo", "b|a")},
+			same:   false,
+		},
+		"case insensitive regexp": {
+			first:  []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, "foo", "(?i)bar")},
+			second: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "foo", "bar")},
+			same:   false,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if tc.same {
+				assert.Equal(t, CanonicalLabelMatchersKey(tc.first), CanonicalLabelMatchersKey(tc.second))
+			} else {
+				assert.NotEqual(t, CanonicalLabelMatchersKey(tc.first), CanonicalLabelMatchersKey(tc.second))
+			}
+		})
+	}
+}
+
+func TestCanonicalLabelMatchersKey_ShouldNotModifyTheMatchers(t *testing.T) {
+	m := labels.MustNewMatcher(labels.MatchRegexp, "foo", "c|b|a")
+	set := append([]string(nil), m.SetMatches()...)
+
+	CanonicalLabelMatchersKey([]*labels.Matcher{m})
+	assert.Equal(t, set, m.SetMatches())
+	assert.Equal(t, "c|b|a", m.Value)
+}
+
 func BenchmarkCanonicalLabelMatchersKey(b *testing.B) {
 	ms := make([]*labels.Matcher, 20)
 	for i := range ms {
@@ -0,0 +1,403 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package indexheader
+
+import (
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/tsdb/encoding"
+	"github.com/prometheus/prometheus/tsdb/fileutil"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/thanos-io/thanos/pkg/block"
+	thanos_indexheader "github.com/thanos-io/thanos/pkg/block/indexheader"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/runutil"
+)
+
+const valueSymbolsCacheSize = 1024
+
+// BinaryReader reads an index-header version 2. It implements the Thanos indexheader.Reader interface.
+type BinaryReader struct {
+	b index.ByteSlice
+
+	// Close that releases the underlying resources of the byte slice.
+	c io.Closer
+
+	indexVersion        int
+	indexLastPostingEnd int64
+
+	// Start of the content of each section.
+	symbolsStart       int
+	symbolOffsetsStart int
+	postingsStart      int
+	postingsEnd        int
+	entryOffsetsStart  int
+
+	numSymbols int
+	numEntries int
+
+	// The range of the postings offset table entries of each label name is the only information about the
+	// postings kept in memory, the label values are looked up through the memory-mapped file.
+	postings   map[string]labelNameEntries
+	labelNames []string
+
+	// Cache of the label name symbol lookups, as there are not many and they are half of all lookups.
+	nameSymbols map[uint32]string
+	// Direct cache of values, to not allocate a new string for the values looked up frequently.
+	valueSymbolsMx sync.Mutex
+	valueSymbols   [valueSymbolsCacheSize]struct {
+		index  uint32
+		symbol string
+	}
+}
+
+// NewBinaryReader loads the index-header version 2 of the block from disk. If it's not present, it's converted from
+// the index-header version 1 if present on disk, which is then removed, or built from the block index in the bucket.
+func NewBinaryReader(ctx context.Context, logger log.Logger, bkt objstore.BucketReader, dir string, id ulid.ULID) (*BinaryReader, error) {
+	path := filepath.Join(dir, id.String(), IndexHeaderV2Filename)
+	br, err := newFileBinaryReader(path)
+	if err == nil {
+		return br, nil
+	}
+
+	level.Debug(logger).Log("msg", "failed to read index-header v2 from disk; recreating", "path", path, "err", err)
+
+	start := time.Now()
+	v1Path := filepath.Join(dir, id.String(), block.IndexHeaderFilename)
+	if _, err := os.Stat(v1Path); err == nil {
+		err = ConvertBinaryV1(v1Path, path)
+		if err == nil {
+			level.Debug(logger).Log("msg", "converted index-header v1 to v2", "path", path, "elapsed", time.Since(start))
+			if err := os.Remove(v1Path); err != nil {
+				level.Warn(logger).Log("msg", "failed to remove index-header v1 after conversion", "path", v1Path, "err", err)
+			}
+			return newFileBinaryReader(path)
+		}
+		if errors.Is(err, ErrUnsupportedIndexVersion) {
+			return nil, err
+		}
+
+		level.Warn(logger).Log("msg", "failed to convert index-header v1 to v2; rebuilding it from the bucket", "path", v1Path, "err", err)
+	}
+
+	if err := WriteBinary(ctx, bkt, id, path); err != nil {
+		return nil, err
+	}
+
+	level.Debug(logger).Log("msg", "built index-header v2 file", "path", path, "elapsed", time.Since(start))
+	return newFileBinaryReader(path)
+}
+
+// WriteBinary builds the index-header version 2 at path from the block index in the bucket.
+func WriteBinary(ctx context.Context, bkt objstore.BucketReader, id ulid.ULID, path string) error {
+	v1Path := path + ".v1.tmp"
+	defer os.Remove(v1Path) //nolint:errcheck
+
+	if err := thanos_indexheader.WriteBinary(ctx, bkt, id, v1Path); err != nil {
+		return errors.Wrap(err, "write index header")
+	}
+	return errors.Wrap(ConvertBinaryV1(v1Path, path), "convert index header")
+}
+
+func newFileBinaryReader(path string) (br *BinaryReader, err error) {
+	f, err := fileutil.OpenMmapFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			runutil.CloseWithErrCapture(&err, f, "index header close")
+		}
+	}()
+
+	r := &BinaryReader{
+		b: realByteSlice(f.Bytes()),
+		c: f,
+	}
+
+	// Verify header.
+	if r.b.Len() < headerLen {
+		return nil, errors.Wrap(encoding.ErrInvalidSize, "index header's header")
+	}
+	if m := binary.BigEndian.Uint32(r.b.Range(0, 4)); m != thanos_indexheader.MagicIndex {
+		return nil, errors.Errorf("invalid magic number %x", m)
+	}
+	if v := int(r.b.Range(4, 5)[0]); v != BinaryFormatV2 {
+		return nil, errors.Errorf("unknown index header file version %d", v)
+	}
+	r.indexVersion = int(r.b.Range(5, 6)[0])
+	r.indexLastPostingEnd = int64(binary.BigEndian.Uint64(r.b.Range(6, headerLen)))
+
+	toc, err := readBinaryTOC(r.b)
+	if err != nil {
+		return nil, errors.Wrap(err, "read index header TOC")
+	}
+
+	// The sections are memory-mapped, so their checksums are verified on load to not serve a corrupted file.
+	for _, off := range []uint64{toc.Symbols, toc.PostingsOffsetTable, toc.SymbolOffsets, toc.PostingsEntryOffsets} {
+		if d := encoding.NewDecbufAt(r.b, int(off), castagnoliTable); d.Err() != nil {
+			return nil, errors.Wrapf(d.Err(), "verify index header section at offset %d", off)
+		}
+	}
+
+	if r.symbolsStart, _, err = sectionBounds(r.b, toc.Symbols); err != nil {
+		return nil, errors.Wrap(err, "read symbols")
+	}
+	if r.postingsStart, r.postingsEnd, err = sectionBounds(r.b, toc.PostingsOffsetTable); err != nil {
+		return nil, errors.Wrap(err, "read postings offset table")
+	}
+	if r.symbolOffsetsStart, r.numSymbols, err = fixedWidthSection(r.b, toc.SymbolOffsets); err != nil {
+		return nil, errors.Wrap(err, "read symbol offsets")
+	}
+	if r.entryOffsetsStart, r.numEntries, err = fixedWidthSection(r.b, toc.PostingsEntryOffsets); err != nil {
+		return nil, errors.Wrap(err, "read postings entry offsets")
+	}
+
+	// The checksum of the label names is verified while decoding them.
+	d := encoding.NewDecbufAt(r.b, int(toc.LabelNames), castagnoliTable)
+	cnt := d.Be32int()
+	r.postings = make(map[string]labelNameEntries, cnt)
+	r.labelNames = make([]string, 0, cnt)
+	allPostingsKeyName, _ := index.AllPostingsKey()
+	for i := 0; d.Err() == nil && i < cnt; i++ {
+		e := labelNameEntries{name: d.UvarintStr(), first: int(d.Uvarint()), count: int(d.Uvarint())}
+		if e.first+e.count > r.numEntries {
+			return nil, errors.Wrapf(encoding.ErrInvalidSize, "entries of label name %q out of bounds", e.name)
+		}
+		r.postings[e.name] = e
+		if e.name != allPostingsKeyName {
+			r.labelNames = append(r.labelNames, e.name)
+		}
+	}
+	if d.Err() != nil {
+		return nil, errors.Wrap(d.Err(), "read label names")
+	}
+
+	r.nameSymbols = make(map[uint32]string, len(r.labelNames))
+	for _, name := range r.labelNames {
+		ref, err := r.reverseLookupSymbol(name)
+		if err != nil {
+			return nil, errors.Wrap(err, "reverse symbol lookup")
+		}
+		r.nameSymbols[ref] = name
+	}
+
+	return r, nil
+}
+
+// fixedWidthSection returns the start of the entries of the fixed-width section at the input offset, and their number.
+func fixedWidthSection(bs index.ByteSlice, off uint64) (int, int, error) {
+	start, end, err := sectionBounds(bs, off)
+	if err != nil {
+		return 0, 0, err
+	}
+	if end-start < 4 {
+		return 0, 0, encoding.ErrInvalidSize
+	}
+	cnt := int(binary.BigEndian.Uint32(bs.Range(start, start+4)))
+	if end-start != 4+8*cnt {
+		return 0, 0, encoding.ErrInvalidSize
+	}
+	return start + 4, cnt, nil
+}
+
+// IndexVersion implements indexheader.Reader.
+func (r *BinaryReader) IndexVersion() (int, error) {
+	return r.indexVersion, nil
+}
+
+// PostingsOffset implements indexheader.Reader.
+func (r *BinaryReader) PostingsOffset(name, value string) (index.Range, error) {
+	e, ok := r.postings[name]
+	if !ok {
+		return index.Range{}, thanos_indexheader.NotFoundRangeErr
+	}
+
+	var err error
+	i := sort.Search(e.count, func(i int) bool {
+		v, _, entryErr := r.postingsEntry(e.first + i)
+		if entryErr != nil {
+			err = entryErr
+			return true
+		}
+		return yoloString(v) >= value
+	})
+	if err != nil {
+		return index.Range{}, errors.Wrap(err, "get postings offset entry")
+	}
+	if i == e.count {
+		return index.Range{}, thanos_indexheader.NotFoundRangeErr
+	}
+
+	v, off, err := r.postingsEntry(e.first + i)
+	if err != nil {
+		return index.Range{}, errors.Wrap(err, "get postings offset entry")
+	}
+	if string(v) != value {
+		return index.Range{}, thanos_indexheader.NotFoundRangeErr
+	}
+
+	// The postings end where the next ones start. The last postings end where the postings offset table starts
+	// in the block index. In both cases, the checksum of the postings is excluded.
+	end := r.indexLastPostingEnd
+	if next := e.first + i + 1; next < r.numEntries {
+		if _, end, err = r.postingsEntry(next); err != nil {
+			return index.Range{}, errors.Wrap(err, "get postings offset entry")
+		}
+	}
+
+	return index.Range{Start: off + postingLengthFieldSize, End: end - crc32.Size}, nil
+}
+
+// postingsEntry returns the label value and the postings offset of the i-th entry of the postings offset table.
+// The returned value is only valid until the reader is closed.
+func (r *BinaryReader) postingsEntry(i int) ([]byte, int64, error) {
+	off := binary.BigEndian.Uint64(r.b.Range(r.entryOffsetsStart+8*i, r.entryOffsetsStart+8*i+8))
+	if uint64(r.postingsEnd-r.postingsStart) <= off {
+		return nil, 0, encoding.ErrInvalidSize
+	}
+
+	// Posting offset table entry format is as follows:
+	// │ ┌────────────────────────────────────────┐ │
+	// │ │  n = 2 <1b>                            │ │
+	// │ ├──────────────────────┬─────────────────┤ │
+	// │ │ len(name) <uvarint>  │ name <bytes>    │ │
+	// │ ├──────────────────────┼─────────────────┤ │
+	// │ │ len(value) <uvarint> │ value <bytes>   │ │
+	// │ ├──────────────────────┴─────────────────┤ │
+	// │ │  offset <uvarint64>                    │ │
+	// │ └────────────────────────────────────────┘ │
+	d := encoding.Decbuf{B: r.b.Range(r.postingsStart+int(off), r.postingsEnd)}
+	d.Uvarint()      // Keycount.
+	d.UvarintBytes() // Label name.
+	value := d.UvarintBytes()
+	postingOffset := int64(d.Uvarint64())
+	return value, postingOffset, d.Err()
+}
+
+// LookupSymbol implements indexheader.Reader.
+func (r *BinaryReader) LookupSymbol(o uint32) (string, error) {
+	cacheIndex := o % valueSymbolsCacheSize
+	r.valueSymbolsMx.Lock()
+	if cached := r.valueSymbols[cacheIndex]; cached.index == o && cached.symbol != "" {
+		v := cached.symbol
+		r.valueSymbolsMx.Unlock()
+		return v, nil
+	}
+	r.valueSymbolsMx.Unlock()
+
+	if s, ok := r.nameSymbols[o]; ok {
+		return s, nil
+	}
+
+	b, err := r.symbol(int(o))
+	if err != nil {
+		return "", err
+	}
+	s := string(b)
+
+	r.valueSymbolsMx.Lock()
+	r.valueSymbols[cacheIndex].index = o
+	r.valueSymbols[cacheIndex].symbol = s
+	r.valueSymbolsMx.Unlock()
+
+	return s, nil
+}
+
+// symbol returns the i-th symbol. The returned value is only valid until the reader is closed.
+func (r *BinaryReader) symbol(i int) ([]byte, error) {
+	if i >= r.numSymbols {
+		return nil, errors.Errorf("unknown symbol offset %d", i)
+	}
+
+	off := binary.BigEndian.Uint64(r.b.Range(r.symbolOffsetsStart+8*i, r.symbolOffsetsStart+8*i+8))
+	if uint64(r.b.Len()-r.symbolsStart) <= off {
+		return nil, encoding.ErrInvalidSize
+	}
+
+	d := encoding.Decbuf{B: r.b.Range(r.symbolsStart+int(off), r.b.Len())}
+	b := d.UvarintBytes()
+	return b, d.Err()
+}
+
+// reverseLookupSymbol returns the reference of the input symbol. The symbols are sorted in the index version 2.
+func (r *BinaryReader) reverseLookupSymbol(sym string) (uint32, error) {
+	var err error
+	i := sort.Search(r.numSymbols, func(i int) bool {
+		b, symErr := r.symbol(i)
+		if symErr != nil {
+			err = symErr
+			return true
+		}
+		return yoloString(b) >= sym
+	})
+	if err != nil {
+		return 0, err
+	}
+	if i == r.numSymbols {
+		return 0, errors.Errorf("unknown symbol %q", sym)
+	}
+	if b, err := r.symbol(i); err != nil {
+		return 0, err
+	} else if string(b) != sym {
+		return 0, errors.Errorf("unknown symbol %q", sym)
+	}
+	return uint32(i), nil
+}
+
+// LabelValues implements indexheader.Reader. The returned values are only valid until the reader is closed.
+func (r *BinaryReader) LabelValues(name string) ([]string, error) {
+	e, ok := r.postings[name]
+	if !ok || e.count == 0 {
+		return nil, nil
+	}
+
+	values := make([]string, 0, e.count)
+	for i := e.first; i < e.first+e.count; i++ {
+		v, _, err := r.postingsEntry(i)
+		if err != nil {
+			return nil, errors.Wrap(err, "get postings offset entry")
+		}
+		values = append(values, yoloString(v))
+	}
+	return values, nil
+}
+
+// LabelNames implements indexheader.Reader.
+func (r *BinaryReader) LabelNames() ([]string, error) {
+	return append([]string(nil), r.labelNames...), nil
+}
+
+// Close implements indexheader.Reader.
+func (r *BinaryReader) Close() error { return r.c.Close() }
+
+func yoloString(b []byte) string {
+	return *((*string)(unsafe.Pointer(&b)))
+}
+
+type realByteSlice []byte
+
+func (b realByteSlice) Len() int {
+	return len(b)
+}
+
+func (b realByteSlice) Range(start, end int) []byte {
+	return b[start:end]
+}
+
+func (b realByteSlice) Sub(start, end int) index.ByteSlice {
+	return b[start:end]
+}
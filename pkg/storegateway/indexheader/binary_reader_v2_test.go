@@ -0,0 +1,274 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package indexheader
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/encoding"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/block"
+	thanos_indexheader "github.com/thanos-io/thanos/pkg/block/indexheader"
+	"github.com/thanos-io/thanos/pkg/objstore/filesystem"
+)
+
+func TestBinaryReader_ShouldReadTheSameAsTheV1Reader(t *testing.T) {
+	ctx := context.Background()
+	bkt, id, symbols := prepareBlockIndex(t, 1000)
+
+	v1, err := thanos_indexheader.NewBinaryReader(ctx, log.NewNopLogger(), bkt, t.TempDir(), id, 32)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, v1.Close()) })
+
+	v2, err := NewBinaryReader(ctx, log.NewNopLogger(), bkt, t.TempDir(), id)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, v2.Close()) })
+
+	compareReaders(t, v1, v2, symbols)
+}
+
+func TestBinaryReader_ShouldConvertTheV1IndexHeaderOnDisk(t *testing.T) {
+	ctx := context.Background()
+	bkt, id, symbols := prepareBlockIndex(t, 100)
+	dir := t.TempDir()
+
+	v1Path := filepath.Join(dir, id.String(), block.IndexHeaderFilename)
+	require.NoError(t, os.MkdirAll(filepath.Dir(v1Path), 0750))
+	require.NoError(t, thanos_indexheader.WriteBinary(ctx, bkt, id, v1Path))
+
+	v1, err := thanos_indexheader.NewBinaryReader(ctx, log.NewNopLogger(), bkt, t.TempDir(), id, 32)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, v1.Close()) })
+
+	// The bucket is empty, so the index-header can only be converted from the one on disk.
+	emptyBkt, err := filesystem.NewBucket(t.TempDir())
+	require.NoError(t, err)
+
+	v2, err := NewBinaryReader(ctx, log.NewNopLogger(), emptyBkt, dir, id)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, v2.Close()) })
+
+	compareReaders(t, v1, v2, symbols)
+
+	// The index-header v1 has been removed once converted.
+	_, err = os.Stat(v1Path)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(dir, id.String(), IndexHeaderV2Filename))
+	assert.NoError(t, err)
+}
+
+func TestBinaryReader_ShouldRebuildACorruptedIndexHeader(t *testing.T) {
+	ctx := context.Background()
+	bkt, id, symbols := prepareBlockIndex(t, 100)
+	dir := t.TempDir()
+
+	v2, err := NewBinaryReader(ctx, log.NewNopLogger(), bkt, dir, id)
+	require.NoError(t, err)
+	require.NoError(t, v2.Close())
+
+	// Corrupt the TOC.
+	path := filepath.Join(dir, id.String(), IndexHeaderV2Filename)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	content[len(content)-10]++
+	require.NoError(t, os.WriteFile(path, content, 0666))
+
+	_, err = newFileBinaryReader(path)
+	require.Error(t, err)
+
+	v2, err = NewBinaryReader(ctx, log.NewNopLogger(), bkt, dir, id)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, v2.Close()) })
+
+	v1, err := thanos_indexheader.NewBinaryReader(ctx, log.NewNopLogger(), bkt, t.TempDir(), id, 32)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, v1.Close()) })
+
+	compareReaders(t, v1, v2, symbols)
+}
+
+func TestBinaryReader_ShouldFailOnCorruptedSections(t *testing.T) {
+	ctx := context.Background()
+	bkt, id, _ := prepareBlockIndex(t, 100)
+	dir := t.TempDir()
+
+	v2, err := NewBinaryReader(ctx, log.NewNopLogger(), bkt, dir, id)
+	require.NoError(t, err)
+	require.NoError(t, v2.Close())
+
+	// Corrupt a symbol, right after the header and the length and count of the symbols table.
+	path := filepath.Join(dir, id.String(), IndexHeaderV2Filename)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	content[headerLen+8+1]++
+	require.NoError(t, os.WriteFile(path, content, 0666))
+
+	_, err = newFileBinaryReader(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), encoding.ErrInvalidChecksum.Error())
+}
+
+func TestConvertBinaryV1_ShouldFailOnCorruptedSections(t *testing.T) {
+	ctx := context.Background()
+	bkt, id, _ := prepareBlockIndex(t, 100)
+	dir := t.TempDir()
+
+	v1Path := filepath.Join(dir, block.IndexHeaderFilename)
+	require.NoError(t, thanos_indexheader.WriteBinary(ctx, bkt, id, v1Path))
+
+	// Corrupt a symbol, right after the header and the length and count of the symbols table.
+	content, err := os.ReadFile(v1Path)
+	require.NoError(t, err)
+	content[headerLen+8+1]++
+	require.NoError(t, os.WriteFile(v1Path, content, 0666))
+
+	require.Error(t, ConvertBinaryV1(v1Path, filepath.Join(dir, IndexHeaderV2Filename)))
+	_, err = os.Stat(filepath.Join(dir, IndexHeaderV2Filename))
+	assert.True(t, os.IsNotExist(err))
+}
+
+// BenchmarkBinaryReader_Open compares the time and the memory taken to open an existing index-header
+// version 1 and version 2.
+func BenchmarkBinaryReader_Open(b *testing.B) {
+	ctx := context.Background()
+	bkt, id, _ := prepareBlockIndex(b, 100000)
+	dir := b.TempDir()
+
+	v1Path := filepath.Join(dir, id.String(), block.IndexHeaderFilename)
+	require.NoError(b, os.MkdirAll(filepath.Dir(v1Path), 0750))
+	require.NoError(b, thanos_indexheader.WriteBinary(ctx, bkt, id, v1Path))
+	require.NoError(b, WriteBinary(ctx, bkt, id, filepath.Join(dir, id.String(), IndexHeaderV2Filename)))
+
+	b.Run("v1", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			r, err := thanos_indexheader.NewBinaryReader(ctx, log.NewNopLogger(), bkt, dir, id, 32)
+			require.NoError(b, err)
+			require.NoError(b, r.Close())
+		}
+	})
+
+	b.Run("v2", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			r, err := NewBinaryReader(ctx, log.NewNopLogger(), bkt, dir, id)
+			require.NoError(b, err)
+			require.NoError(b, r.Close())
+		}
+	})
+}
+
+func compareReaders(t *testing.T, expected thanos_indexheader.Reader, actual thanos_indexheader.Reader, symbols []string) {
+	expectedVersion, err := expected.IndexVersion()
+	require.NoError(t, err)
+	actualVersion, err := actual.IndexVersion()
+	require.NoError(t, err)
+	assert.Equal(t, expectedVersion, actualVersion)
+
+	expectedNames, err := expected.LabelNames()
+	require.NoError(t, err)
+	actualNames, err := actual.LabelNames()
+	require.NoError(t, err)
+	require.Equal(t, expectedNames, actualNames)
+
+	for _, name := range expectedNames {
+		expectedValues, err := expected.LabelValues(name)
+		require.NoError(t, err)
+		actualValues, err := actual.LabelValues(name)
+		require.NoError(t, err)
+		require.Equal(t, expectedValues, actualValues, name)
+
+		// Look up the existing values, as well as values before, between and after them.
+		lookups := append([]string{"", "~"}, expectedValues...)
+		for _, v := range expectedValues {
+			lookups = append(lookups, v+"0")
+		}
+
+		for _, v := range lookups {
+			expectedRange, expectedErr := expected.PostingsOffset(name, v)
+			actualRange, actualErr := actual.PostingsOffset(name, v)
+			assert.Equal(t, expectedErr, actualErr, "%s=%s", name, v)
+			assert.Equal(t, expectedRange, actualRange, "%s=%s", name, v)
+		}
+	}
+
+	// The postings of all the series are looked up with the empty label name and value.
+	expectedRange, err := expected.PostingsOffset(index.AllPostingsKey())
+	require.NoError(t, err)
+	actualRange, err := actual.PostingsOffset(index.AllPostingsKey())
+	require.NoError(t, err)
+	assert.Equal(t, expectedRange, actualRange)
+
+	_, err = actual.PostingsOffset("missing", "value")
+	assert.Equal(t, thanos_indexheader.NotFoundRangeErr, err)
+	values, err := actual.LabelValues("missing")
+	assert.NoError(t, err)
+	assert.Empty(t, values)
+
+	for i, sym := range symbols {
+		expectedSym, err := expected.LookupSymbol(uint32(i))
+		require.NoError(t, err)
+		actualSym, err := actual.LookupSymbol(uint32(i))
+		require.NoError(t, err)
+		require.Equal(t, sym, expectedSym)
+		require.Equal(t, expectedSym, actualSym)
+	}
+
+	_, err = actual.LookupSymbol(uint32(len(symbols)))
+	assert.Error(t, err)
+}
+
+// prepareBlockIndex writes the index of a block with the input number of series in a filesystem bucket,
+// and returns the bucket, the block ID and the symbols of the index.
+func prepareBlockIndex(t testing.TB, numSeries int) (*filesystem.Bucket, ulid.ULID, []string) {
+	bktDir := t.TempDir()
+	id := ulid.MustNew(1, nil)
+	require.NoError(t, os.MkdirAll(filepath.Join(bktDir, id.String()), 0750))
+
+	series := make([]labels.Labels, 0, numSeries)
+	symbolsMap := map[string]struct{}{}
+	for i := 0; i < numSeries; i++ {
+		lbls := labels.FromStrings(
+			labels.MetricName, fmt.Sprintf("metric_%d", i%10),
+			"series", fmt.Sprintf("%06d", i),
+			"zone", fmt.Sprintf("zone-%d", i%3),
+		)
+		series = append(series, lbls)
+		for _, l := range lbls {
+			symbolsMap[l.Name] = struct{}{}
+			symbolsMap[l.Value] = struct{}{}
+		}
+	}
+	sort.Slice(series, func(i, j int) bool { return labels.Compare(series[i], series[j]) < 0 })
+
+	symbols := make([]string, 0, len(symbolsMap))
+	for s := range symbolsMap {
+		symbols = append(symbols, s)
+	}
+	sort.Strings(symbols)
+
+	w, err := index.NewWriter(context.Background(), filepath.Join(bktDir, id.String(), block.IndexFilename))
+	require.NoError(t, err)
+	for _, s := range symbols {
+		require.NoError(t, w.AddSymbol(s))
+	}
+	for i, s := range series {
+		require.NoError(t, w.AddSeries(storage.SeriesRef(i+1), s))
+	}
+	require.NoError(t, w.Close())
+
+	bkt, err := filesystem.NewBucket(bktDir)
+	require.NoError(t, err)
+	return bkt, id, symbols
+}
@@ -0,0 +1,239 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package indexheader
+
+import (
+	"bufio"
+	"encoding/binary"
+	"hash/crc32"
+	"math"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/tsdb/encoding"
+	"github.com/prometheus/prometheus/tsdb/fileutil"
+	"github.com/prometheus/prometheus/tsdb/index"
+	thanos_indexheader "github.com/thanos-io/thanos/pkg/block/indexheader"
+	"github.com/thanos-io/thanos/pkg/runutil"
+)
+
+// labelNameEntries is the range of the postings offset table entries of a label name.
+type labelNameEntries struct {
+	name  string
+	first int
+	count int
+}
+
+// ConvertBinaryV1 writes the index-header version 2 at path from the index-header version 1 at v1Path.
+// The file is written atomically, and its checksums are verified once written.
+func ConvertBinaryV1(v1Path, path string) (err error) {
+	f, err := fileutil.OpenMmapFile(v1Path)
+	if err != nil {
+		return err
+	}
+	defer runutil.CloseWithErrCapture(&err, f, "close index-header v1")
+
+	bs := realByteSlice(f.Bytes())
+	if bs.Len() < headerLen+binaryTOCV1Len {
+		return errors.Wrap(encoding.ErrInvalidSize, "index-header v1")
+	}
+	if m := binary.BigEndian.Uint32(bs.Range(0, 4)); m != thanos_indexheader.MagicIndex {
+		return errors.Errorf("invalid magic number %x", m)
+	}
+	if v := int(bs.Range(4, 5)[0]); v != thanos_indexheader.BinaryFormatV1 {
+		return errors.Errorf("unexpected index-header version %d", v)
+	}
+	if v := int(bs.Range(5, 6)[0]); v == index.FormatV1 {
+		return ErrUnsupportedIndexVersion
+	}
+
+	tocV1 := bs.Range(bs.Len()-binaryTOCV1Len, bs.Len())
+	d := encoding.Decbuf{B: tocV1[:len(tocV1)-crc32.Size]}
+	if d.Crc32(castagnoliTable) != binary.BigEndian.Uint32(tocV1[len(tocV1)-crc32.Size:]) {
+		return errors.Wrap(encoding.ErrInvalidChecksum, "read index-header v1 TOC")
+	}
+	symbolsV1, postingsV1 := d.Be64(), d.Be64()
+
+	// Verify the checksums of the tables copied from the version 1, and collect the offsets of their entries.
+	symbolOffsets, err := readSymbolOffsets(bs, symbolsV1)
+	if err != nil {
+		return errors.Wrap(err, "read symbols")
+	}
+	entryOffsets, names, err := readPostingsEntries(bs, postingsV1)
+	if err != nil {
+		return errors.Wrap(err, "read postings offset table")
+	}
+
+	tmpPath := path + ".tmp"
+	if err := writeBinary(tmpPath, bs, symbolsV1, postingsV1, symbolOffsets, entryOffsets, names); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+
+	if err := verifyBinary(tmpPath); err != nil {
+		_ = os.Remove(tmpPath)
+		return errors.Wrap(err, "verify index-header")
+	}
+
+	// Create the index-header in atomic way, to avoid partial writes (e.g. during restart or crash of the store-gateway).
+	return os.Rename(tmpPath, path)
+}
+
+func readSymbolOffsets(bs index.ByteSlice, off uint64) ([]uint64, error) {
+	d := encoding.NewDecbufAt(bs, int(off), castagnoliTable)
+	origLen := d.Len()
+	cnt := d.Be32int()
+
+	offsets := make([]uint64, 0, cnt)
+	for d.Err() == nil && len(offsets) < cnt {
+		offsets = append(offsets, uint64(origLen-d.Len()))
+		d.UvarintBytes() // The symbol.
+	}
+	return offsets, d.Err()
+}
+
+func readPostingsEntries(bs index.ByteSlice, off uint64) ([]uint64, []labelNameEntries, error) {
+	var (
+		offsets []uint64
+		names   []labelNameEntries
+	)
+
+	err := index.ReadOffsetTable(bs, off, func(key []string, _ uint64, tableOff int) error {
+		if len(key) != 2 {
+			return errors.Errorf("unexpected key length for posting table %d", len(key))
+		}
+
+		if len(names) == 0 || names[len(names)-1].name != key[0] {
+			if len(names) > 0 && names[len(names)-1].name > key[0] {
+				return errors.Errorf("postings offset table not sorted by label name")
+			}
+			names = append(names, labelNameEntries{name: key[0], first: len(offsets)})
+		}
+		names[len(names)-1].count++
+		offsets = append(offsets, uint64(tableOff))
+		return nil
+	})
+	return offsets, names, err
+}
+
+func writeBinary(path string, bs index.ByteSlice, symbolsV1, postingsV1 uint64, symbolOffsets, entryOffsets []uint64, names []labelNameEntries) (err error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	defer runutil.CloseWithErrCapture(&err, f, "close index-header")
+
+	w := &positionWriter{w: bufio.NewWriterSize(f, 32*1024)}
+	buf := encoding.Encbuf{}
+	toc := binaryTOC{}
+
+	// The header is the same as the version 1 one, but the version.
+	header := append([]byte{}, bs.Range(0, headerLen)...)
+	header[4] = BinaryFormatV2
+	w.write(header)
+
+	toc.Symbols = w.pos
+	w.write(bs.Range(int(symbolsV1), sectionEnd(bs, symbolsV1)))
+
+	toc.PostingsOffsetTable = w.pos
+	w.write(bs.Range(int(postingsV1), sectionEnd(bs, postingsV1)))
+
+	toc.SymbolOffsets = w.pos
+	w.writeSection(&buf, func(buf *encoding.Encbuf) {
+		buf.PutBE32int(len(symbolOffsets))
+		for _, o := range symbolOffsets {
+			buf.PutBE64(o)
+		}
+	})
+
+	toc.PostingsEntryOffsets = w.pos
+	w.writeSection(&buf, func(buf *encoding.Encbuf) {
+		buf.PutBE32int(len(entryOffsets))
+		for _, o := range entryOffsets {
+			buf.PutBE64(o)
+		}
+	})
+
+	toc.LabelNames = w.pos
+	w.writeSection(&buf, func(buf *encoding.Encbuf) {
+		buf.PutBE32int(len(names))
+		for _, n := range names {
+			buf.PutUvarintStr(n.name)
+			buf.PutUvarint(n.first)
+			buf.PutUvarint(n.count)
+		}
+	})
+
+	buf.Reset()
+	toc.encode(&buf)
+	w.write(buf.Get())
+
+	if w.err != nil {
+		return w.err
+	}
+	if err := w.w.Flush(); err != nil {
+		return errors.Wrap(err, "flush")
+	}
+	return errors.Wrap(f.Sync(), "sync")
+}
+
+// sectionEnd returns the end of the section at the input offset, including its checksum.
+func sectionEnd(bs index.ByteSlice, off uint64) int {
+	return int(off) + 4 + int(binary.BigEndian.Uint32(bs.Range(int(off), int(off)+4))) + crc32.Size
+}
+
+// verifyBinary verifies the checksums of all the sections of the index-header version 2 at path.
+func verifyBinary(path string) (err error) {
+	f, err := fileutil.OpenMmapFile(path)
+	if err != nil {
+		return err
+	}
+	defer runutil.CloseWithErrCapture(&err, f, "close index-header")
+
+	bs := realByteSlice(f.Bytes())
+	toc, err := readBinaryTOC(bs)
+	if err != nil {
+		return err
+	}
+	for _, off := range []uint64{toc.Symbols, toc.PostingsOffsetTable, toc.SymbolOffsets, toc.PostingsEntryOffsets, toc.LabelNames} {
+		if d := encoding.NewDecbufAt(bs, int(off), castagnoliTable); d.Err() != nil {
+			return errors.Wrapf(d.Err(), "section at offset %d", off)
+		}
+	}
+	return nil
+}
+
+// positionWriter keeps track of the position in the file and of the first write error.
+type positionWriter struct {
+	w   *bufio.Writer
+	pos uint64
+	err error
+}
+
+func (w *positionWriter) write(b []byte) {
+	if w.err != nil {
+		return
+	}
+	n, err := w.w.Write(b)
+	w.pos += uint64(n)
+	w.err = err
+}
+
+// writeSection writes a section with the content encoded by the input function, preceded by its length
+// and followed by its checksum.
+func (w *positionWriter) writeSection(buf *encoding.Encbuf, encode func(buf *encoding.Encbuf)) {
+	buf.Reset()
+	buf.PutBE32(0) // Placeholder for the length.
+	encode(buf)
+
+	b := buf.Get()
+	if len(b)-4 > math.MaxUint32 {
+		if w.err == nil {
+			w.err = errors.Errorf("index-header section too large: %d bytes", len(b)-4)
+		}
+		return
+	}
+	binary.BigEndian.PutUint32(b, uint32(len(b)-4))
+	buf.PutBE32(crc32.Checksum(b[4:], castagnoliTable))
+	w.write(buf.Get())
+}
@@ -0,0 +1,144 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package indexheader implements the version 2 of the index-header format, designed to be memory-mapped
+// and opened without reading it through.
+//
+// The version 1 of the format (see github.com/thanos-io/thanos/pkg/block/indexheader) contains a copy of the
+// symbols table and of the postings offset table of the block index. Both tables are made of variable length
+// entries, so opening a version 1 index-header requires to read through both of them to keep in memory a sample
+// of the symbols offsets and of the postings offsets, which takes time and memory for the blocks with many
+// symbols or label values.
+//
+// The version 2 of the format contains the same tables, followed by fixed-width tables with the offset of each
+// symbol and of each postings offset table entry, and a table with the range of entries of each label name, so
+// that symbols and postings offsets are looked up through the memory-mapped file with a binary search. Each
+// section is checksummed.
+//
+//	┌──────────────────────────────────────────────────────────────────┐
+//	│ magic <4b> │ version <1b> = 2 │ index version <1b> │             │
+//	│ index postings offset table offset <8b>                          │
+//	├──────────────────────────────────────────────────────────────────┤
+//	│ symbols table (copied from the block index)                      │
+//	├──────────────────────────────────────────────────────────────────┤
+//	│ postings offset table (copied from the block index)              │
+//	├──────────────────────────────────────────────────────────────────┤
+//	│ len <4b> │ count <4b> │ symbol offset <8b> ... │ CRC32 <4b>      │
+//	├──────────────────────────────────────────────────────────────────┤
+//	│ len <4b> │ count <4b> │ postings entry offset <8b> ... │ CRC32   │
+//	├──────────────────────────────────────────────────────────────────┤
+//	│ len <4b> │ count <4b> │                                          │
+//	│ len(name) <uvarint> │ name │ first entry <uvarint> │             │
+//	│ entries <uvarint> ... │ CRC32 <4b>                               │
+//	├──────────────────────────────────────────────────────────────────┤
+//	│ TOC: offset of each of the 5 sections <8b each> │ CRC32 <4b>     │
+//	└──────────────────────────────────────────────────────────────────┘
+//
+// The symbol offsets are relative to the beginning of the symbols table content, and the postings entry offsets
+// are relative to the beginning of the postings offset table content, as done by the block index.
+package indexheader
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/tsdb/encoding"
+	"github.com/prometheus/prometheus/tsdb/index"
+)
+
+const (
+	// BinaryFormatV2 is the version 2 of the index-header format.
+	BinaryFormatV2 = 2
+
+	// IndexHeaderV2Filename is the name of the index-header version 2 file in the block directory. It's different
+	// from the version 1 one, so that rolling back to the version 1 doesn't require to clean up the disk.
+	IndexHeaderV2Filename = "index-header-v2"
+
+	// headerLen is the length of the header, the same as in the version 1.
+	headerLen = 4 + 1 + 1 + 8
+
+	// binaryTOCLen is the length of the table of content at the end of the file.
+	binaryTOCLen = 5*8 + crc32.Size
+
+	// binaryTOCV1Len is the length of the table of content at the end of a version 1 file.
+	binaryTOCV1Len = 2*8 + crc32.Size
+
+	postingLengthFieldSize = 4
+)
+
+var (
+	castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+	// ErrUnsupportedIndexVersion is returned when the block index version is not supported by the version 2
+	// of the index-header, which only supports the index version 2 (and later).
+	ErrUnsupportedIndexVersion = errors.New("the index-header version 2 doesn't support the index version 1")
+)
+
+// binaryTOC is the table of content of the index-header version 2.
+type binaryTOC struct {
+	// Symbols is the offset of the symbols table, copied from the block index.
+	Symbols uint64
+	// PostingsOffsetTable is the offset of the postings offset table, copied from the block index.
+	PostingsOffsetTable uint64
+	// SymbolOffsets is the offset of the table of the offsets of each symbol.
+	SymbolOffsets uint64
+	// PostingsEntryOffsets is the offset of the table of the offsets of each postings offset table entry.
+	PostingsEntryOffsets uint64
+	// LabelNames is the offset of the table of the range of postings offset table entries of each label name.
+	LabelNames uint64
+}
+
+func (t binaryTOC) encode(buf *encoding.Encbuf) {
+	buf.PutBE64(t.Symbols)
+	buf.PutBE64(t.PostingsOffsetTable)
+	buf.PutBE64(t.SymbolOffsets)
+	buf.PutBE64(t.PostingsEntryOffsets)
+	buf.PutBE64(t.LabelNames)
+	buf.PutBE32(crc32.Checksum(buf.Get(), castagnoliTable))
+}
+
+// readBinaryTOC reads the table of content at the end of the index-header, verifying its checksum.
+func readBinaryTOC(bs index.ByteSlice) (*binaryTOC, error) {
+	if bs.Len() < headerLen+binaryTOCLen {
+		return nil, encoding.ErrInvalidSize
+	}
+	b := bs.Range(bs.Len()-binaryTOCLen, bs.Len())
+
+	expCRC := binary.BigEndian.Uint32(b[len(b)-crc32.Size:])
+	d := encoding.Decbuf{B: b[:len(b)-crc32.Size]}
+	if d.Crc32(castagnoliTable) != expCRC {
+		return nil, errors.Wrap(encoding.ErrInvalidChecksum, "read index-header TOC")
+	}
+
+	toc := &binaryTOC{
+		Symbols:              d.Be64(),
+		PostingsOffsetTable:  d.Be64(),
+		SymbolOffsets:        d.Be64(),
+		PostingsEntryOffsets: d.Be64(),
+		LabelNames:           d.Be64(),
+	}
+	if err := d.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, off := range []uint64{toc.Symbols, toc.PostingsOffsetTable, toc.SymbolOffsets, toc.PostingsEntryOffsets, toc.LabelNames} {
+		if off < headerLen || off >= uint64(bs.Len()-binaryTOCLen) {
+			return nil, errors.Wrapf(encoding.ErrInvalidSize, "index-header section offset %d out of bounds", off)
+		}
+	}
+	return toc, nil
+}
+
+// sectionBounds returns the bounds of the content of the section at the input offset, which starts with the
+// length of its content and ends with its checksum, without verifying the checksum.
+func sectionBounds(bs index.ByteSlice, off uint64) (int, int, error) {
+	if off+4 > uint64(bs.Len()) {
+		return 0, 0, encoding.ErrInvalidSize
+	}
+	start := int(off) + 4
+	end := start + int(binary.BigEndian.Uint32(bs.Range(int(off), start)))
+	if end+crc32.Size > bs.Len() {
+		return 0, 0, encoding.ErrInvalidSize
+	}
+	return start, end, nil
+}
@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/thanos-io/thanos/pkg/gate"
+)
+
+// QueryGateStatus is the state of the gate limiting the concurrent queries.
+type QueryGateStatus struct {
+	MaxConcurrent int `json:"max_concurrent"`
+	Inflight      int `json:"inflight"`
+
+	// Number of queries waiting for their turn at the gate, and the time the oldest one has been waiting for.
+	Waiting              int     `json:"waiting"`
+	OldestWaitingSeconds float64 `json:"oldest_waiting_seconds"`
+}
+
+// trackingGate is a gate.Gate keeping track of the requests waiting at the gate and the ones that went through it.
+type trackingGate struct {
+	gate          gate.Gate
+	maxConcurrent int
+
+	mtx          sync.Mutex
+	inflight     int
+	nextWaiterID uint64
+	waitingSince map[uint64]time.Time
+}
+
+func newTrackingGate(g gate.Gate, maxConcurrent int) *trackingGate {
+	return &trackingGate{
+		gate:          g,
+		maxConcurrent: maxConcurrent,
+		waitingSince:  map[uint64]time.Time{},
+	}
+}
+
+func (g *trackingGate) Start(ctx context.Context) error {
+	g.mtx.Lock()
+	id := g.nextWaiterID
+	g.nextWaiterID++
+	g.waitingSince[id] = time.Now()
+	g.mtx.Unlock()
+
+	err := g.gate.Start(ctx)
+
+	g.mtx.Lock()
+	delete(g.waitingSince, id)
+	if err == nil {
+		g.inflight++
+	}
+	g.mtx.Unlock()
+
+	return err
+}
+
+func (g *trackingGate) Done() {
+	g.mtx.Lock()
+	g.inflight--
+	g.mtx.Unlock()
+
+	g.gate.Done()
+}
+
+func (g *trackingGate) status(now time.Time) QueryGateStatus {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	status := QueryGateStatus{
+		MaxConcurrent: g.maxConcurrent,
+		Inflight:      g.inflight,
+		Waiting:       len(g.waitingSince),
+	}
+	for _, since := range g.waitingSince {
+		if wait := now.Sub(since).Seconds(); wait > status.OldestWaitingSeconds {
+			status.OldestWaitingSeconds = wait
+		}
+	}
+	return status
+}
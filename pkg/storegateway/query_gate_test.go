@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/gate"
+)
+
+func TestTrackingGate(t *testing.T) {
+	g := newTrackingGate(gate.New(nil, 1), 1)
+	assert.Equal(t, QueryGateStatus{MaxConcurrent: 1}, g.status(time.Now()))
+
+	require.NoError(t, g.Start(context.Background()))
+	assert.Equal(t, QueryGateStatus{MaxConcurrent: 1, Inflight: 1}, g.status(time.Now()))
+
+	// The second query waits until the first one is done.
+	started := make(chan error)
+	go func() {
+		started <- g.Start(context.Background())
+	}()
+
+	require.Eventually(t, func() bool {
+		return g.status(time.Now()).Waiting == 1
+	}, time.Second, time.Millisecond)
+
+	status := g.status(time.Now().Add(time.Minute))
+	assert.Equal(t, 1, status.Inflight)
+	assert.GreaterOrEqual(t, status.OldestWaitingSeconds, time.Minute.Seconds())
+
+	// A query giving up while waiting is not tracked anymore.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.Error(t, g.Start(ctx))
+
+	g.Done()
+	require.NoError(t, <-started)
+	assert.Equal(t, QueryGateStatus{MaxConcurrent: 1, Inflight: 1}, g.status(time.Now()))
+
+	g.Done()
+	assert.Equal(t, QueryGateStatus{MaxConcurrent: 1}, g.status(time.Now()))
+}
@@ -7,6 +7,7 @@ package storegateway
 
 import (
 	"context"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
@@ -18,10 +19,12 @@ import (
 	"github.com/thanos-io/thanos/pkg/objstore"
 
 	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/util"
 )
 
 const (
-	shardExcludedMeta = "shard-excluded"
+	shardExcludedMeta    = "shard-excluded"
+	blockAgeExcludedMeta = "block-age-excluded"
 )
 
 type ShardingStrategy interface {
@@ -133,6 +136,57 @@ func filterBlocksByRingSharding(r ring.ReadRing, instanceAddr string, metas map[
 	}
 }
 
+// PoolFilteringStrategy wraps a ShardingStrategy to restrict the tenants and the blocks served
+// by a store-gateway, allowing a dedicated pool of store-gateways to only serve a subset of them
+// (e.g. only the blocks older than a given age).
+type PoolFilteringStrategy struct {
+	next           ShardingStrategy
+	allowedTenants *util.AllowedTenants
+	minBlockAge    time.Duration
+	maxBlockAge    time.Duration
+}
+
+// NewPoolFilteringStrategy makes a new PoolFilteringStrategy. A min or max block age of 0 means no limit.
+func NewPoolFilteringStrategy(next ShardingStrategy, enabledTenants, disabledTenants []string, minBlockAge, maxBlockAge time.Duration) *PoolFilteringStrategy {
+	return &PoolFilteringStrategy{
+		next:           next,
+		allowedTenants: util.NewAllowedTenants(enabledTenants, disabledTenants),
+		minBlockAge:    minBlockAge,
+		maxBlockAge:    maxBlockAge,
+	}
+}
+
+// FilterUsers implements ShardingStrategy.
+func (s *PoolFilteringStrategy) FilterUsers(ctx context.Context, userIDs []string) []string {
+	var allowedIDs []string
+
+	for _, userID := range userIDs {
+		if s.allowedTenants.IsAllowed(userID) {
+			allowedIDs = append(allowedIDs, userID)
+		}
+	}
+
+	return s.next.FilterUsers(ctx, allowedIDs)
+}
+
+// FilterBlocks implements ShardingStrategy.
+func (s *PoolFilteringStrategy) FilterBlocks(ctx context.Context, userID string, metas map[ulid.ULID]*metadata.Meta, loaded map[ulid.ULID]struct{}, synced *extprom.TxGaugeVec) error {
+	now := time.Now()
+
+	for blockID, meta := range metas {
+		age := now.Sub(util.TimeFromMillis(meta.MaxTime))
+
+		// The age is computed on the block max time, so that the blocks are partitioned without overlaps
+		// between pools configured with contiguous ranges.
+		if (s.minBlockAge > 0 && age < s.minBlockAge) || (s.maxBlockAge > 0 && age >= s.maxBlockAge) {
+			synced.WithLabelValues(blockAgeExcludedMeta).Inc()
+			delete(metas, blockID)
+		}
+	}
+
+	return s.next.FilterBlocks(ctx, userID, metas, loaded, synced)
+}
+
 // GetShuffleShardingSubring returns the subring to be used for a given user. This function
 // should be used both by store-gateway and querier in order to guarantee the same logic is used.
 func GetShuffleShardingSubring(ring *ring.Ring, userID string, limits ShardingLimits) ring.ReadRing {
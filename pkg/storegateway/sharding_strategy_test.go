@@ -17,6 +17,7 @@ import (
 	"github.com/oklog/ulid"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/tsdb"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/thanos-io/thanos/pkg/block/metadata"
@@ -390,6 +391,84 @@ func TestShuffleShardingStrategy(t *testing.T) {
 	}
 }
 
+func TestPoolFilteringStrategy(t *testing.T) {
+	now := time.Now()
+	block1 := ulid.MustNew(1, nil)
+	block2 := ulid.MustNew(2, nil)
+	block3 := ulid.MustNew(3, nil)
+
+	metas := func() map[ulid.ULID]*metadata.Meta {
+		return map[ulid.ULID]*metadata.Meta{
+			block1: {BlockMeta: tsdb.BlockMeta{MinTime: now.Add(-2 * time.Hour).UnixMilli(), MaxTime: now.UnixMilli()}},
+			block2: {BlockMeta: tsdb.BlockMeta{MinTime: now.Add(-50 * time.Hour).UnixMilli(), MaxTime: now.Add(-48 * time.Hour).UnixMilli()}},
+			block3: {BlockMeta: tsdb.BlockMeta{MinTime: now.Add(-100 * 24 * time.Hour).UnixMilli(), MaxTime: now.Add(-99 * 24 * time.Hour).UnixMilli()}},
+		}
+	}
+
+	tests := map[string]struct {
+		enabledTenants  []string
+		disabledTenants []string
+		minBlockAge     time.Duration
+		maxBlockAge     time.Duration
+		expectedUsers   []string
+		expectedBlocks  []ulid.ULID
+	}{
+		"no filtering": {
+			expectedUsers:  []string{"user-1", "user-2", "user-3"},
+			expectedBlocks: []ulid.ULID{block1, block2, block3},
+		},
+		"enabled tenants": {
+			enabledTenants: []string{"user-1", "user-3"},
+			expectedUsers:  []string{"user-1", "user-3"},
+			expectedBlocks: []ulid.ULID{block1, block2, block3},
+		},
+		"enabled and disabled tenants": {
+			enabledTenants:  []string{"user-1", "user-3"},
+			disabledTenants: []string{"user-3"},
+			expectedUsers:   []string{"user-1"},
+			expectedBlocks:  []ulid.ULID{block1, block2, block3},
+		},
+		"min block age": {
+			minBlockAge:    30 * 24 * time.Hour,
+			expectedUsers:  []string{"user-1", "user-2", "user-3"},
+			expectedBlocks: []ulid.ULID{block3},
+		},
+		"max block age": {
+			maxBlockAge:    30 * 24 * time.Hour,
+			expectedUsers:  []string{"user-1", "user-2", "user-3"},
+			expectedBlocks: []ulid.ULID{block1, block2},
+		},
+		"min and max block age": {
+			minBlockAge:    24 * time.Hour,
+			maxBlockAge:    30 * 24 * time.Hour,
+			expectedUsers:  []string{"user-1", "user-2", "user-3"},
+			expectedBlocks: []ulid.ULID{block2},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			filter := NewPoolFilteringStrategy(newNoShardingStrategy(), testData.enabledTenants, testData.disabledTenants, testData.minBlockAge, testData.maxBlockAge)
+			assert.Equal(t, testData.expectedUsers, filter.FilterUsers(context.Background(), []string{"user-1", "user-2", "user-3"}))
+
+			synced := extprom.NewTxGaugeVec(nil, prometheus.GaugeOpts{}, []string{"state"})
+			synced.WithLabelValues(blockAgeExcludedMeta).Set(0)
+
+			actualMetas := metas()
+			require.NoError(t, filter.FilterBlocks(context.Background(), "user-1", actualMetas, map[ulid.ULID]struct{}{}, synced))
+
+			var actualBlocks []ulid.ULID
+			for id := range actualMetas {
+				actualBlocks = append(actualBlocks, id)
+			}
+			assert.ElementsMatch(t, testData.expectedBlocks, actualBlocks)
+
+			synced.Submit()
+			assert.Equal(t, float64(3-len(testData.expectedBlocks)), testutil.ToFloat64(synced))
+		})
+	}
+}
+
 type shardingLimitsMock struct {
 	storeGatewayTenantShardSize int
 }
@@ -0,0 +1,215 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package streamingpromql provides a PromQL engine which evaluates a subset of PromQL
+// expressions one series at a time, instead of loading all the selected series in memory
+// before evaluating the expression like the Prometheus engine does.
+package streamingpromql
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/timestamp"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/util/stats"
+)
+
+const defaultLookbackDelta = 5 * time.Minute
+
+// ErrNotSupported is returned when creating a query whose expression can't be evaluated by the streaming engine.
+var ErrNotSupported = errors.New("expression not supported by the streaming engine")
+
+// Engine evaluates the PromQL expressions made of a vector selector, optionally passed through
+// a range vector function and optionally aggregated, like sum by (job) (rate(metric[5m])).
+// Creating a query for any other expression fails with ErrNotSupported.
+type Engine struct {
+	timeout       time.Duration
+	maxSamples    int
+	lookbackDelta time.Duration
+	tracker       promql.QueryTracker
+}
+
+// NewEngine returns a new streaming engine. The options are the ones of the Prometheus
+// engine, so that both engines can be configured and share the same active query tracker.
+func NewEngine(opts promql.EngineOpts) *Engine {
+	lookbackDelta := opts.LookbackDelta
+	if lookbackDelta == 0 {
+		lookbackDelta = defaultLookbackDelta
+	}
+
+	return &Engine{
+		timeout:       opts.Timeout,
+		maxSamples:    opts.MaxSamples,
+		lookbackDelta: lookbackDelta,
+		tracker:       opts.ActiveQueryTracker,
+	}
+}
+
+// NewInstantQuery returns an evaluation query for the given expression at the given time.
+func (e *Engine) NewInstantQuery(q storage.Queryable, qs string, ts time.Time) (promql.Query, error) {
+	return e.newQuery(q, qs, ts, ts, 0)
+}
+
+// NewRangeQuery returns an evaluation query for the given time range and with the resolution set by the interval.
+func (e *Engine) NewRangeQuery(q storage.Queryable, qs string, start, end time.Time, interval time.Duration) (promql.Query, error) {
+	return e.newQuery(q, qs, start, end, interval)
+}
+
+func (e *Engine) newQuery(q storage.Queryable, qs string, start, end time.Time, interval time.Duration) (*query, error) {
+	expr, err := parser.ParseExpr(qs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Matrix results are only returned by instant queries of range vector selectors,
+	// which are not worth streaming.
+	if expr.Type() != parser.ValueTypeVector {
+		return nil, errors.Wrapf(ErrNotSupported, "expression of type %s", parser.DocumentedType(expr.Type()))
+	}
+
+	p, err := newPlan(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &query{
+		engine:    e,
+		queryable: q,
+		qs:        qs,
+		plan:      p,
+		stmt: &parser.EvalStmt{
+			Expr:     expr,
+			Start:    start,
+			End:      end,
+			Interval: interval,
+		},
+		stats: stats.NewQueryTimers(),
+	}, nil
+}
+
+// query implements promql.Query.
+type query struct {
+	engine    *Engine
+	queryable storage.Queryable
+	qs        string
+	plan      *plan
+	stmt      *parser.EvalStmt
+	stats     *stats.QueryTimers
+	cancel    func()
+
+	peakSamples int
+}
+
+// Exec implements promql.Query.
+func (q *query) Exec(ctx context.Context) *promql.Result {
+	res, warnings, err := q.exec(ctx)
+	return &promql.Result{Value: res, Warnings: warnings, Err: err}
+}
+
+func (q *query) exec(ctx context.Context) (parser.Value, storage.Warnings, error) {
+	ctx, cancel := context.WithTimeout(ctx, q.engine.timeout)
+	q.cancel = cancel
+	defer cancel()
+
+	execSpanTimer, ctx := q.stats.GetSpanTimer(ctx, stats.ExecTotalTime)
+	defer execSpanTimer.Finish()
+
+	// The active query tracker guarantees that we don't run over the max concurrent queries.
+	if q.engine.tracker != nil {
+		queueSpanTimer, _ := q.stats.GetSpanTimer(ctx, stats.ExecQueueTime)
+		queryIndex, err := q.engine.tracker.Insert(ctx, q.qs)
+		queueSpanTimer.Finish()
+		if err != nil {
+			return nil, nil, contextErr(err)
+		}
+		defer q.engine.tracker.Delete(queryIndex)
+	}
+
+	evalSpanTimer, ctx := q.stats.GetSpanTimer(ctx, stats.EvalTotalTime)
+	defer evalSpanTimer.Finish()
+
+	if err := contextDone(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	start, end := timestamp.FromTime(q.stmt.Start), timestamp.FromTime(q.stmt.End)
+	interval := durationMilliseconds(q.stmt.Interval)
+	lookbackDelta := durationMilliseconds(q.engine.lookbackDelta)
+	hints := q.plan.selectHints(start, end, interval, lookbackDelta)
+
+	querier, err := q.queryable.Querier(ctx, hints.Start, hints.End)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer querier.Close()
+
+	ev := &evaluator{
+		ctx:           ctx,
+		plan:          q.plan,
+		start:         start,
+		end:           end,
+		interval:      interval,
+		lookbackDelta: lookbackDelta,
+		maxSamples:    q.engine.maxSamples,
+	}
+	if ev.interval == 0 {
+		// Instant queries are evaluated as range queries with a single step.
+		ev.interval = 1
+	}
+
+	set := querier.Select(false, hints, q.plan.selector.LabelMatchers...)
+	mat, err := ev.eval(set)
+	q.peakSamples = ev.peakSamples
+	warnings := set.Warnings()
+	if err != nil {
+		return nil, warnings, err
+	}
+
+	if q.stmt.Start == q.stmt.End && q.stmt.Interval == 0 {
+		vector := make(promql.Vector, 0, len(mat))
+		for _, s := range mat {
+			vector = append(vector, promql.Sample{Metric: s.Metric, Point: promql.Point{V: s.Points[0].V, T: start}})
+		}
+		return vector, warnings, nil
+	}
+
+	sortSpanTimer, _ := q.stats.GetSpanTimer(ctx, stats.ResultSortTime)
+	sort.Sort(mat)
+	sortSpanTimer.Finish()
+
+	return mat, warnings, nil
+}
+
+// Close implements promql.Query.
+func (q *query) Close() {}
+
+// Statement implements promql.Query.
+func (q *query) Statement() parser.Statement {
+	return q.stmt
+}
+
+// Stats implements promql.Query.
+func (q *query) Stats() *stats.QueryTimers {
+	return q.stats
+}
+
+// PeakSamples returns the highest number of samples held in memory while executing the query.
+func (q *query) PeakSamples() int {
+	return q.peakSamples
+}
+
+// Cancel implements promql.Query.
+func (q *query) Cancel() {
+	if q.cancel != nil {
+		q.cancel()
+	}
+}
+
+// String implements promql.Query.
+func (q *query) String() string {
+	return q.qs
+}
@@ -0,0 +1,234 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package streamingpromql
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/promql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_ShouldReturnTheSameResultsAsThePrometheusEngine(t *testing.T) {
+	test, err := promql.NewTest(t, `
+		load 1m
+			http_requests_total{job="api", instance="1", status="200"} 0+10x60 0+10x60
+			http_requests_total{job="api", instance="2", status="200"} 0+7x30 _x10 300+7x80
+			http_requests_total{job="api", instance="1", status="500"} 0+1x20 stale 20+1x40
+			http_requests_total{job="db", instance="3", status="200"} 5+3x120
+			http_requests_total{job="db", instance="4", status="500"} 1 2 3 4 5 _x50 10 20 30
+			temperature{room="a"} 20 21 19 Inf 22 -Inf 20x100
+			temperature{room="b"} -5+0.5x120
+	`)
+	require.NoError(t, err)
+	defer test.Close()
+	require.NoError(t, test.Run())
+
+	opts := promql.EngineOpts{
+		MaxSamples:    1e6,
+		Timeout:       time.Minute,
+		LookbackDelta: 5 * time.Minute,
+	}
+	standard := promql.NewEngine(opts)
+	streaming := NewEngine(opts)
+
+	queries := []string{
+		`http_requests_total`,
+		`http_requests_total{status="200"}`,
+		`http_requests_total offset 10m`,
+		`(http_requests_total)`,
+		`temperature`,
+		`rate(http_requests_total[5m])`,
+		`increase(http_requests_total[2m])`,
+		`irate(http_requests_total[5m] offset 3m)`,
+		`delta(temperature[10m])`,
+		`deriv(temperature[10m])`,
+		`idelta(temperature[3m])`,
+		`changes(http_requests_total[15m])`,
+		`resets(http_requests_total[1h])`,
+		`avg_over_time(temperature[5m])`,
+		`sum_over_time(http_requests_total[3m])`,
+		`count_over_time(http_requests_total[10m])`,
+		`min_over_time(temperature[5m])`,
+		`max_over_time(temperature[5m])`,
+		`last_over_time(http_requests_total[5m])`,
+		`present_over_time(http_requests_total[1m])`,
+		`stddev_over_time(temperature[10m])`,
+		`stdvar_over_time(temperature[10m])`,
+		`sum(http_requests_total)`,
+		`sum by (job) (http_requests_total)`,
+		`sum without (instance) (http_requests_total)`,
+		`avg by (status) (http_requests_total)`,
+		`avg(temperature)`,
+		`count by (job, status) (http_requests_total)`,
+		`min(temperature)`,
+		`max by (room) (temperature)`,
+		`group by (job) (http_requests_total)`,
+		`stddev(http_requests_total)`,
+		`stdvar by (job) (http_requests_total)`,
+		`sum by (job) (rate(http_requests_total[5m]))`,
+		`max without (status) (increase(http_requests_total[10m] offset 5m))`,
+		`sum((rate(http_requests_total[5m])))`,
+	}
+
+	ranges := map[string]struct {
+		start, end time.Time
+		step       time.Duration
+	}{
+		"step aligned with samples":     {start: time.Unix(0, 0), end: time.Unix(0, 0).Add(2 * time.Hour), step: time.Minute},
+		"step not aligned with samples": {start: time.Unix(17, 0), end: time.Unix(0, 0).Add(3 * time.Hour), step: 37 * time.Second},
+		"step larger than ranges":       {start: time.Unix(0, 0), end: time.Unix(0, 0).Add(2 * time.Hour), step: 20 * time.Minute},
+	}
+
+	for _, qs := range queries {
+		t.Run(qs, func(t *testing.T) {
+			for name, r := range ranges {
+				t.Run(name, func(t *testing.T) {
+					expectedQuery, err := standard.NewRangeQuery(test.Queryable(), qs, r.start, r.end, r.step)
+					require.NoError(t, err)
+					expected := expectedQuery.Exec(context.Background())
+					require.NoError(t, expected.Err)
+
+					actualQuery, err := streaming.NewRangeQuery(test.Queryable(), qs, r.start, r.end, r.step)
+					require.NoError(t, err)
+					actual := actualQuery.Exec(context.Background())
+					require.NoError(t, actual.Err)
+
+					// Results are compared as strings, because NaN values are never equal.
+					assert.Equal(t, expected.Value.String(), actual.Value.String())
+				})
+			}
+
+			for _, ts := range []time.Time{time.Unix(0, 0), time.Unix(0, 0).Add(25 * time.Minute), time.Unix(0, 0).Add(61*time.Minute + 30*time.Second), time.Unix(0, 0).Add(10 * time.Hour)} {
+				expectedQuery, err := standard.NewInstantQuery(test.Queryable(), qs, ts)
+				require.NoError(t, err)
+				expected := expectedQuery.Exec(context.Background())
+				require.NoError(t, expected.Err)
+
+				actualQuery, err := streaming.NewInstantQuery(test.Queryable(), qs, ts)
+				require.NoError(t, err)
+				actual := actualQuery.Exec(context.Background())
+				require.NoError(t, actual.Err)
+
+				// The order of the samples of instant queries is not guaranteed.
+				expectedVector, actualVector := expected.Value.(promql.Vector), actual.Value.(promql.Vector)
+				sortVector(expectedVector)
+				sortVector(actualVector)
+				assert.Equal(t, expectedVector.String(), actualVector.String(), ts)
+			}
+		})
+	}
+}
+
+func TestEngine_UnsupportedExpressions(t *testing.T) {
+	engine := NewEngine(promql.EngineOpts{})
+
+	for _, qs := range []string{
+		`metric[5m]`,
+		`1`,
+		`-metric`,
+		`metric + 1`,
+		`metric / other_metric`,
+		`abs(metric)`,
+		`absent_over_time(metric[5m])`,
+		`quantile_over_time(0.5, metric[5m])`,
+		`rate(metric[5m:1m])`,
+		`topk(5, metric)`,
+		`sum(max by (job) (metric))`,
+		`metric @ 100`,
+		`rate(metric[5m] @ end())`,
+	} {
+		t.Run(qs, func(t *testing.T) {
+			_, err := engine.NewInstantQuery(nil, qs, time.Now())
+			assert.ErrorIs(t, err, ErrNotSupported)
+		})
+	}
+}
+
+func TestEngine_MaxSamples(t *testing.T) {
+	test, err := promql.NewTest(t, `
+		load 1m
+			metric{instance="1"} 0+1x120
+			metric{instance="2"} 0+1x120
+	`)
+	require.NoError(t, err)
+	defer test.Close()
+	require.NoError(t, test.Run())
+
+	start, end := time.Unix(0, 0), time.Unix(0, 0).Add(time.Hour)
+
+	tests := map[string]struct {
+		query       string
+		maxSamples  int
+		expectedErr error
+	}{
+		"should fail if the output series don't fit in the limit": {
+			query:       `metric`,
+			maxSamples:  100,
+			expectedErr: promql.ErrTooManySamples(env),
+		},
+		"should succeed if the aggregated output fits in the limit": {
+			query:      `sum(metric)`,
+			maxSamples: 100,
+		},
+		"should fail if the samples of a range don't fit in the limit": {
+			query:       `sum(rate(metric[2h]))`,
+			maxSamples:  100,
+			expectedErr: promql.ErrTooManySamples(env),
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			engine := NewEngine(promql.EngineOpts{MaxSamples: testData.maxSamples, Timeout: time.Minute})
+
+			q, err := engine.NewRangeQuery(test.Queryable(), testData.query, start, end, time.Minute)
+			require.NoError(t, err)
+
+			res := q.Exec(context.Background())
+			assert.Equal(t, testData.expectedErr, res.Err)
+		})
+	}
+}
+
+func TestEngine_PeakSamples(t *testing.T) {
+	test, err := promql.NewTest(t, `
+		load 1m
+			metric{instance="1"} 0+1x120
+			metric{instance="2"} 0+1x120
+	`)
+	require.NoError(t, err)
+	defer test.Close()
+	require.NoError(t, test.Run())
+
+	start, end := time.Unix(0, 0), time.Unix(0, 0).Add(time.Hour)
+	engine := NewEngine(promql.EngineOpts{MaxSamples: 1e6, Timeout: time.Minute})
+
+	for qs, expectedPeakSamples := range map[string]int{
+		// All the output points are held in memory.
+		`metric`: 122,
+		// Only the aggregated points are held in memory.
+		`sum(metric)`: 61,
+		// The aggregated points and the window of the series being evaluated are held in memory.
+		`sum(rate(metric[5m]))`: 66,
+	} {
+		t.Run(qs, func(t *testing.T) {
+			q, err := engine.NewRangeQuery(test.Queryable(), qs, start, end, time.Minute)
+			require.NoError(t, err)
+
+			res := q.Exec(context.Background())
+			require.NoError(t, res.Err)
+			assert.Equal(t, expectedPeakSamples, q.(*query).PeakSamples())
+		})
+	}
+}
+
+func sortVector(v promql.Vector) {
+	sort.Slice(v, func(i, j int) bool {
+		return v[i].Metric.String() < v[j].Metric.String()
+	})
+}
@@ -0,0 +1,449 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package streamingpromql
+
+import (
+	"context"
+	"math"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/storage"
+)
+
+const env = "query execution"
+
+// evaluator evaluates a plan over the selected series, one series at a time. Only the output
+// series, or the aggregated values of each group at each step, are kept in memory.
+type evaluator struct {
+	ctx           context.Context
+	plan          *plan
+	start, end    int64
+	interval      int64
+	lookbackDelta int64
+	maxSamples    int
+
+	// Number of samples currently held in memory, and the highest number held at any time.
+	currentSamples int
+	peakSamples    int
+}
+
+func (ev *evaluator) numSteps() int {
+	return int((ev.end-ev.start)/ev.interval) + 1
+}
+
+// eval consumes the series set and returns the resulting matrix. Each output series contains
+// one point per step at which it has a value.
+func (ev *evaluator) eval(set storage.SeriesSet) (promql.Matrix, error) {
+	var (
+		output promql.Matrix
+		groups *aggregationGroups
+
+		// Hashes of the series returned by the function, used to detect duplicated label sets.
+		seenSeries map[uint64]struct{}
+	)
+
+	if ev.plan.aggregation != nil {
+		groups = newAggregationGroups(ev.plan.aggregation, ev.plan.grouping, ev.numSteps())
+	}
+	if ev.plan.function != nil {
+		seenSeries = map[uint64]struct{}{}
+	}
+
+	var (
+		points   []promql.Point
+		memoIt   = storage.NewMemoizedEmptyIterator(ev.lookbackDelta)
+		bufferIt = storage.NewBuffer(durationMilliseconds(ev.plan.selectRange))
+		window   []promql.Point
+		err      error
+	)
+
+	for set.Next() {
+		if err := contextDone(ev.ctx); err != nil {
+			return nil, err
+		}
+
+		series := set.At()
+		metric := series.Labels()
+
+		if ev.plan.function == nil {
+			memoIt.Reset(series.Iterator())
+			points, err = ev.selectorPoints(memoIt, points[:0])
+		} else {
+			bufferIt.Reset(series.Iterator())
+			points, window, err = ev.functionPoints(bufferIt, metric, points[:0], window[:0])
+
+			// The last_over_time function acts like offset; thus, it should keep the metric name.
+			if ev.plan.functionName != "last_over_time" {
+				metric = dropMetricName(metric)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if len(points) == 0 {
+			continue
+		}
+
+		if seenSeries != nil {
+			h := metric.Hash()
+			if _, ok := seenSeries[h]; ok {
+				return nil, errors.New("vector cannot contain metrics with the same labelset")
+			}
+			seenSeries[h] = struct{}{}
+		}
+
+		if groups != nil {
+			ev.currentSamples += groups.add(metric, points, ev.start, ev.interval)
+		} else {
+			output = append(output, promql.Series{Metric: metric, Points: append([]promql.Point(nil), points...)})
+			ev.currentSamples += len(points)
+		}
+
+		if err := ev.checkSamples(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := set.Err(); err != nil {
+		return nil, errors.Wrap(err, "expanding series")
+	}
+
+	if groups != nil {
+		return groups.matrix(ev.start, ev.interval), nil
+	}
+	return output, nil
+}
+
+// checkSamples updates the peak number of samples held in memory, and checks it against the limit.
+func (ev *evaluator) checkSamples() error {
+	if ev.currentSamples > ev.peakSamples {
+		ev.peakSamples = ev.currentSamples
+	}
+	if ev.currentSamples > ev.maxSamples {
+		return promql.ErrTooManySamples(env)
+	}
+	return nil
+}
+
+// selectorPoints evaluates the vector selector at each step for the series of the given iterator.
+func (ev *evaluator) selectorPoints(it *storage.MemoizedSeriesIterator, out []promql.Point) ([]promql.Point, error) {
+	offset := durationMilliseconds(ev.plan.selector.Offset)
+
+	for ts := ev.start; ts <= ev.end; ts += ev.interval {
+		refTime := ts - offset
+
+		var (
+			t  int64
+			v  float64
+			ok = it.Seek(refTime)
+		)
+		if !ok && it.Err() != nil {
+			return nil, it.Err()
+		}
+		if ok {
+			t, v = it.At()
+		}
+
+		if !ok || t > refTime {
+			t, v, ok = it.PeekPrev()
+			if !ok || t < refTime-ev.lookbackDelta {
+				continue
+			}
+		}
+		if value.IsStaleNaN(v) {
+			continue
+		}
+
+		out = append(out, promql.Point{T: ts, V: v})
+	}
+
+	return out, nil
+}
+
+// functionPoints evaluates the range vector function at each step for the series of the given iterator.
+// The window is the buffer of points the function is called with, which is reused across series.
+func (ev *evaluator) functionPoints(it *storage.BufferedSeriesIterator, metric labels.Labels, out, window []promql.Point) ([]promql.Point, []promql.Point, error) {
+	var (
+		offset     = durationMilliseconds(ev.plan.selector.Offset)
+		selRange   = durationMilliseconds(ev.plan.selectRange)
+		stepRange  = selRange
+		inMatrix   = promql.Matrix{{Metric: metric}}
+		inArgs     = []parser.Value{inMatrix}
+		enh        = &promql.EvalNodeHelper{Out: make(promql.Vector, 0, 1)}
+		windowSize = 0
+		err        error
+	)
+	if stepRange > ev.interval {
+		stepRange = ev.interval
+	}
+
+	// The points in the window are accounted as in memory while the series is evaluated.
+	defer func() {
+		ev.currentSamples -= windowSize
+	}()
+
+	for ts := ev.start; ts <= ev.end; ts += ev.interval {
+		maxt := ts - offset
+		mint := maxt - selRange
+
+		window, err = ev.rangePoints(it, mint, maxt, window)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		ev.currentSamples += len(window) - windowSize
+		windowSize = len(window)
+		if err := ev.checkSamples(); err != nil {
+			return nil, nil, err
+		}
+
+		if len(window) == 0 {
+			continue
+		}
+
+		inMatrix[0].Points = window
+		enh.Ts = ts
+		outVec := ev.plan.function(inArgs, ev.plan.functionArgs, enh)
+		enh.Out = outVec[:0]
+		if len(outVec) > 0 {
+			out = append(out, promql.Point{T: ts, V: outVec[0].V})
+		}
+
+		// Only buffer stepRange milliseconds from the second step on.
+		it.ReduceDelta(stepRange)
+	}
+
+	return out, window, nil
+}
+
+// rangePoints returns the points of the series in the [mint, maxt] range. The points of the
+// previous step which are still in the range are retained, and only newer points are read from the iterator.
+func (ev *evaluator) rangePoints(it *storage.BufferedSeriesIterator, mint, maxt int64, out []promql.Point) ([]promql.Point, error) {
+	if len(out) > 0 && out[len(out)-1].T >= mint {
+		var drop int
+		for drop = 0; out[drop].T < mint; drop++ {
+		}
+		copy(out, out[drop:])
+		out = out[:len(out)-drop]
+		// Only append points with timestamps after the last timestamp we have.
+		mint = out[len(out)-1].T + 1
+	} else {
+		out = out[:0]
+	}
+
+	ok := it.Seek(maxt)
+	if !ok && it.Err() != nil {
+		return nil, it.Err()
+	}
+
+	buf := it.Buffer()
+	for buf.Next() {
+		t, v := buf.At()
+		if value.IsStaleNaN(v) {
+			continue
+		}
+		// Values in the buffer are guaranteed to be smaller than maxt.
+		if t >= mint {
+			out = append(out, promql.Point{T: t, V: v})
+		}
+	}
+
+	// The seeked sample might also be in the range.
+	if ok {
+		t, v := it.At()
+		if t == maxt && !value.IsStaleNaN(v) {
+			out = append(out, promql.Point{T: t, V: v})
+		}
+	}
+
+	return out, nil
+}
+
+// aggregationGroups holds the state of the aggregation of each group at each step.
+type aggregationGroups struct {
+	op       parser.ItemType
+	grouping []string
+	without  bool
+	numSteps int
+
+	groups  map[uint64]*aggregationGroup
+	ordered []*aggregationGroup // Groups in the order they have been created.
+
+	lb  *labels.Builder
+	buf []byte
+}
+
+type aggregationGroup struct {
+	labels labels.Labels
+	steps  []aggregationStep
+}
+
+type aggregationStep struct {
+	present bool
+	value   float64
+	mean    float64
+	count   int
+}
+
+func newAggregationGroups(agg *parser.AggregateExpr, grouping []string, numSteps int) *aggregationGroups {
+	return &aggregationGroups{
+		op:       agg.Op,
+		grouping: grouping,
+		without:  agg.Without,
+		numSteps: numSteps,
+		groups:   map[uint64]*aggregationGroup{},
+		lb:       labels.NewBuilder(nil),
+		buf:      make([]byte, 0, 1024),
+	}
+}
+
+// add aggregates the points of a series into its group, and returns the number of steps
+// for which the group got a value for the first time.
+func (g *aggregationGroups) add(metric labels.Labels, points []promql.Point, start, interval int64) int {
+	var key uint64
+	if g.without {
+		key, g.buf = metric.HashWithoutLabels(g.buf, g.grouping...)
+	} else if len(g.grouping) > 0 {
+		key, g.buf = metric.HashForLabels(g.buf, g.grouping...)
+	}
+
+	group, ok := g.groups[key]
+	if !ok {
+		var m labels.Labels
+		if g.without {
+			g.lb.Reset(metric)
+			g.lb.Del(g.grouping...)
+			g.lb.Del(labels.MetricName)
+			m = g.lb.Labels()
+		} else {
+			m = metric.WithLabels(g.grouping...)
+		}
+
+		group = &aggregationGroup{labels: m, steps: make([]aggregationStep, g.numSteps)}
+		g.groups[key] = group
+		g.ordered = append(g.ordered, group)
+	}
+
+	added := 0
+	for _, p := range points {
+		step := &group.steps[(p.T-start)/interval]
+		if !step.present {
+			step.present = true
+			step.value = p.V
+			step.mean = p.V
+			step.count = 1
+
+			switch g.op {
+			case parser.STDVAR, parser.STDDEV:
+				step.value = 0
+			case parser.GROUP:
+				step.value = 1
+			}
+			added++
+			continue
+		}
+
+		switch g.op {
+		case parser.SUM:
+			step.value += p.V
+
+		case parser.AVG:
+			step.count++
+			if math.IsInf(step.mean, 0) {
+				if math.IsInf(p.V, 0) && (step.mean > 0) == (p.V > 0) {
+					// The mean and the value are Inf of the same sign. They can't be
+					// subtracted, but the mean is correct already.
+					break
+				}
+				if !math.IsInf(p.V, 0) && !math.IsNaN(p.V) {
+					// The mean is infinite, and adding a finite value doesn't change it.
+					break
+				}
+			}
+			// Divide each side of the `-` by the count to avoid float64 overflows.
+			step.mean += p.V/float64(step.count) - step.mean/float64(step.count)
+
+		case parser.MAX:
+			if step.value < p.V || math.IsNaN(step.value) {
+				step.value = p.V
+			}
+
+		case parser.MIN:
+			if step.value > p.V || math.IsNaN(step.value) {
+				step.value = p.V
+			}
+
+		case parser.COUNT:
+			step.count++
+
+		case parser.STDVAR, parser.STDDEV:
+			step.count++
+			delta := p.V - step.mean
+			step.mean += delta / float64(step.count)
+			step.value += delta * (p.V - step.mean)
+		}
+	}
+
+	return added
+}
+
+// matrix returns the aggregated value of each group at each step.
+func (g *aggregationGroups) matrix(start, interval int64) promql.Matrix {
+	mat := make(promql.Matrix, 0, len(g.ordered))
+
+	for _, group := range g.ordered {
+		s := promql.Series{Metric: group.labels}
+
+		for i, step := range group.steps {
+			if !step.present {
+				continue
+			}
+
+			v := step.value
+			switch g.op {
+			case parser.AVG:
+				v = step.mean
+			case parser.COUNT:
+				v = float64(step.count)
+			case parser.STDVAR:
+				v = step.value / float64(step.count)
+			case parser.STDDEV:
+				v = math.Sqrt(step.value / float64(step.count))
+			}
+
+			s.Points = append(s.Points, promql.Point{T: start + int64(i)*interval, V: v})
+		}
+
+		mat = append(mat, s)
+	}
+
+	return mat
+}
+
+func dropMetricName(l labels.Labels) labels.Labels {
+	return labels.NewBuilder(l).Del(labels.MetricName).Labels()
+}
+
+// contextDone returns the error to return if the query context is done.
+func contextDone(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return contextErr(err)
+	}
+	return nil
+}
+
+func contextErr(err error) error {
+	switch err {
+	case context.Canceled:
+		return promql.ErrQueryCanceled(env)
+	case context.DeadlineExceeded:
+		return promql.ErrQueryTimeout(env)
+	default:
+		return err
+	}
+}
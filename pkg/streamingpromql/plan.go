@@ -0,0 +1,156 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package streamingpromql
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/storage"
+)
+
+// supportedAggregations are the aggregations which can be computed incrementally, without
+// keeping more than one value per group and step in memory.
+var supportedAggregations = map[parser.ItemType]bool{
+	parser.SUM:    true,
+	parser.AVG:    true,
+	parser.COUNT:  true,
+	parser.MIN:    true,
+	parser.MAX:    true,
+	parser.GROUP:  true,
+	parser.STDDEV: true,
+	parser.STDVAR: true,
+}
+
+// supportedFunctions are the functions taking a range vector as their only argument,
+// which can be evaluated for one series at a time.
+var supportedFunctions = map[string]bool{
+	"avg_over_time":     true,
+	"changes":           true,
+	"count_over_time":   true,
+	"delta":             true,
+	"deriv":             true,
+	"idelta":            true,
+	"increase":          true,
+	"irate":             true,
+	"last_over_time":    true,
+	"max_over_time":     true,
+	"min_over_time":     true,
+	"present_over_time": true,
+	"rate":              true,
+	"resets":            true,
+	"stddev_over_time":  true,
+	"stdvar_over_time":  true,
+	"sum_over_time":     true,
+}
+
+// plan describes how a supported expression is evaluated: the series returned by the selector
+// are optionally passed through a range vector function and then optionally aggregated.
+type plan struct {
+	selector *parser.VectorSelector
+
+	// Range vector function applied to the selected series. Nil if the expression is a plain vector selector.
+	function     promql.FunctionCall
+	functionArgs parser.Expressions
+	functionName string
+	selectRange  time.Duration
+
+	// Aggregation applied to the series. Nil if the expression is not aggregated.
+	aggregation *parser.AggregateExpr
+	grouping    []string
+}
+
+func newPlan(expr parser.Expr) (*plan, error) {
+	p := &plan{}
+
+	expr = unwrapParenExpr(expr)
+	if agg, ok := expr.(*parser.AggregateExpr); ok {
+		if !supportedAggregations[agg.Op] {
+			return nil, errors.Wrapf(ErrNotSupported, "aggregation %s", agg.Op)
+		}
+
+		p.aggregation = agg
+		// Grouping labels must be sorted, as expected by the grouping key functions.
+		p.grouping = append([]string(nil), agg.Grouping...)
+		sort.Strings(p.grouping)
+		expr = unwrapParenExpr(agg.Expr)
+	}
+
+	switch e := expr.(type) {
+	case *parser.VectorSelector:
+		p.selector = e
+
+	case *parser.Call:
+		if !supportedFunctions[e.Func.Name] || len(e.Args) != 1 {
+			return nil, errors.Wrapf(ErrNotSupported, "function %s", e.Func.Name)
+		}
+
+		ms, ok := unwrapParenExpr(e.Args[0]).(*parser.MatrixSelector)
+		if !ok {
+			return nil, errors.Wrapf(ErrNotSupported, "argument of function %s", e.Func.Name)
+		}
+
+		p.selector = ms.VectorSelector.(*parser.VectorSelector)
+		p.selectRange = ms.Range
+		p.function = promql.FunctionCalls[e.Func.Name]
+		p.functionArgs = parser.Expressions{ms}
+		p.functionName = e.Func.Name
+
+	default:
+		return nil, errors.Wrapf(ErrNotSupported, "expression %s", parser.DocumentedType(expr.Type()))
+	}
+
+	if p.selector.Timestamp != nil || p.selector.StartOrEnd != 0 {
+		return nil, errors.Wrap(ErrNotSupported, "@ modifier")
+	}
+	if p.selector.OriginalOffset < 0 {
+		return nil, errors.Wrap(ErrNotSupported, "negative offset")
+	}
+
+	// The offset is what the functions look at to compute the boundaries of the range, and it's
+	// set by the standard engine only once the query is executed.
+	p.selector.Offset = p.selector.OriginalOffset
+
+	return p, nil
+}
+
+// selectHints returns the hints to select the series for the given evaluation time range,
+// which are the same as the ones passed to the querier by the standard engine.
+func (p *plan) selectHints(start, end, interval, lookbackDelta int64) *storage.SelectHints {
+	hints := &storage.SelectHints{
+		Start: start - durationMilliseconds(p.selector.Offset),
+		End:   end - durationMilliseconds(p.selector.Offset),
+		Step:  interval,
+		Range: durationMilliseconds(p.selectRange),
+	}
+
+	if p.function != nil {
+		hints.Start -= durationMilliseconds(p.selectRange)
+		hints.Func = p.functionName
+	} else {
+		hints.Start -= lookbackDelta
+		if p.aggregation != nil {
+			hints.Func = p.aggregation.Op.String()
+			hints.By, hints.Grouping = !p.aggregation.Without, p.aggregation.Grouping
+		}
+	}
+
+	return hints
+}
+
+func unwrapParenExpr(e parser.Expr) parser.Expr {
+	for {
+		p, ok := e.(*parser.ParenExpr)
+		if !ok {
+			return e
+		}
+		e = p.Expr
+	}
+}
+
+func durationMilliseconds(d time.Duration) int64 {
+	return int64(d / time.Millisecond)
+}
@@ -74,12 +74,15 @@ func (w *dnsWatcher) watchDNSLoop(servCtx context.Context) error {
 		}
 
 		for _, update := range updates {
+			// The resolver joins IPv6 addresses and ports without square brackets.
+			addr := normalizeHostPort(update.Addr)
+
 			switch update.Op {
 			case grpcutil.Add:
-				w.notifications.AddressAdded(update.Addr)
+				w.notifications.AddressAdded(addr)
 
 			case grpcutil.Delete:
-				w.notifications.AddressRemoved(update.Addr)
+				w.notifications.AddressRemoved(addr)
 
 			default:
 				return fmt.Errorf("unknown op: %v", update.Op)
@@ -0,0 +1,7 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+//go:build !fips
+
+package fips
+
+// EnforcedByBuild is true in the binaries built with the fips build tag, where the FIPS mode can't be disabled.
+const EnforcedByBuild = false
@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package fips implements the FIPS mode, which restricts the TLS versions, cipher suites and
+// elliptic curves used by the Mimir servers and clients to the FIPS-approved ones.
+package fips
+
+import (
+	"crypto/tls"
+	"net/http"
+
+	"go.uber.org/atomic"
+
+	"github.com/grafana/mimir/pkg/util"
+)
+
+// enabled is whether the FIPS mode has been enabled by the configuration.
+var enabled = atomic.NewBool(false)
+
+// CipherSuites are the FIPS-approved TLS 1.2 cipher suites. They only use AES-GCM encryption and
+// SHA-256 or SHA-384 hashes.
+var CipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
+}
+
+// CurvePreferences are the FIPS-approved elliptic curves.
+var CurvePreferences = []tls.CurveID{
+	tls.CurveP256,
+	tls.CurveP384,
+	tls.CurveP521,
+}
+
+// SetEnabled enables or disables the FIPS mode. The FIPS mode can't be disabled in the binaries built with
+// the fips build tag.
+func SetEnabled(v bool) {
+	enabled.Store(v)
+}
+
+// Enabled returns whether the FIPS mode is enabled, either by the configuration or by the build.
+func Enabled() bool {
+	return EnforcedByBuild || enabled.Load()
+}
+
+// ApplyTLSPolicy restricts the TLS config to the FIPS-approved versions, cipher suites and curves, if the
+// FIPS mode is enabled. TLS 1.3 is disabled, because its cipher suites can't be restricted.
+func ApplyTLSPolicy(cfg *tls.Config) {
+	if !Enabled() {
+		return
+	}
+
+	cfg.MinVersion = tls.VersionTLS12
+	cfg.MaxVersion = tls.VersionTLS12
+	cfg.CipherSuites = CipherSuites
+	cfg.CurvePreferences = CurvePreferences
+}
+
+// Status is the FIPS compliance status of a Mimir process.
+type Status struct {
+	Enabled         bool `json:"enabled"`
+	EnforcedByBuild bool `json:"enforced_by_build"`
+
+	// Compliant is true if the FIPS mode is enabled and both the HTTP and gRPC servers serve TLS.
+	Compliant      bool `json:"compliant"`
+	HTTPTLSEnabled bool `json:"http_tls_enabled"`
+	GRPCTLSEnabled bool `json:"grpc_tls_enabled"`
+
+	TLSVersions  []string `json:"tls_versions"`
+	CipherSuites []string `json:"cipher_suites"`
+	Curves       []string `json:"curves"`
+}
+
+// GetStatus returns the FIPS compliance status, given whether the HTTP and gRPC servers serve TLS.
+func GetStatus(httpTLSEnabled, grpcTLSEnabled bool) Status {
+	s := Status{
+		Enabled:         Enabled(),
+		EnforcedByBuild: EnforcedByBuild,
+		HTTPTLSEnabled:  httpTLSEnabled,
+		GRPCTLSEnabled:  grpcTLSEnabled,
+	}
+	s.Compliant = s.Enabled && httpTLSEnabled && grpcTLSEnabled
+
+	if !s.Enabled {
+		return s
+	}
+
+	s.TLSVersions = []string{"TLS 1.2"}
+	for _, id := range CipherSuites {
+		s.CipherSuites = append(s.CipherSuites, tls.CipherSuiteName(id))
+	}
+	for _, c := range CurvePreferences {
+		s.Curves = append(s.Curves, c.String())
+	}
+	return s
+}
+
+// StatusHandler returns the handler of the FIPS compliance status endpoint.
+func StatusHandler(httpTLSEnabled, grpcTLSEnabled bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		util.WriteJSONResponse(w, GetStatus(httpTLSEnabled, grpcTLSEnabled))
+	})
+}
@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package fips
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyTLSPolicy(t *testing.T) {
+	t.Run("should not change the TLS config if the FIPS mode is disabled", func(t *testing.T) {
+		if EnforcedByBuild {
+			t.Skip("the FIPS mode is enforced by the build")
+		}
+
+		cfg := &tls.Config{}
+		ApplyTLSPolicy(cfg)
+		assert.Equal(t, &tls.Config{}, cfg)
+	})
+
+	t.Run("should restrict the TLS config if the FIPS mode is enabled", func(t *testing.T) {
+		SetEnabled(true)
+		t.Cleanup(func() { SetEnabled(false) })
+
+		cfg := &tls.Config{MinVersion: tls.VersionTLS10}
+		ApplyTLSPolicy(cfg)
+		assert.Equal(t, uint16(tls.VersionTLS12), cfg.MinVersion)
+		assert.Equal(t, uint16(tls.VersionTLS12), cfg.MaxVersion)
+		assert.Equal(t, CipherSuites, cfg.CipherSuites)
+		assert.Equal(t, CurvePreferences, cfg.CurvePreferences)
+	})
+}
+
+func TestStatusHandler(t *testing.T) {
+	SetEnabled(true)
+	t.Cleanup(func() { SetEnabled(false) })
+
+	tests := map[string]struct {
+		httpTLSEnabled, grpcTLSEnabled bool
+		expectedCompliant              bool
+	}{
+		"should be compliant if both servers serve TLS": {
+			httpTLSEnabled:    true,
+			grpcTLSEnabled:    true,
+			expectedCompliant: true,
+		},
+		"should not be compliant if the gRPC server doesn't serve TLS": {
+			httpTLSEnabled:    true,
+			expectedCompliant: false,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			StatusHandler(testData.httpTLSEnabled, testData.grpcTLSEnabled).ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/status/fips", nil))
+
+			var status Status
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+			assert.True(t, status.Enabled)
+			assert.Equal(t, testData.expectedCompliant, status.Compliant)
+			assert.Equal(t, []string{"TLS 1.2"}, status.TLSVersions)
+			assert.Contains(t, status.CipherSuites, "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256")
+			assert.Equal(t, []string{"CurveP256", "CurveP384", "CurveP521"}, status.Curves)
+		})
+	}
+}
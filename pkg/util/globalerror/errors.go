@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package globalerror is the registry of the stable IDs of the errors returned by Mimir to clients,
+// such as rejected samples, exceeded limits and invalid requests. The IDs are appended to the error
+// messages as error codes (for example "err-mimir-max-series-per-user"), so that automation can react
+// to specific failures without parsing the error messages, which may change over time.
+package globalerror
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+)
+
+// ID is the stable ID of an error. IDs must never be changed or reused for a different error.
+type ID string
+
+// Distributor and ingestion validation errors.
+const (
+	MissingMetricName            ID = "missing-metric-name"
+	InvalidMetricName            ID = "metric-name-invalid"
+	MaxLabelNamesPerSeries       ID = "max-label-names-per-series"
+	MaxLabelsSizeBytes           ID = "max-labels-size-bytes"
+	InvalidLabel                 ID = "label-invalid"
+	LabelNameTooLong             ID = "label-name-too-long"
+	LabelValueTooLong            ID = "label-value-too-long"
+	DuplicateLabelNames          ID = "duplicate-label-names"
+	LabelsNotSorted              ID = "labels-not-sorted"
+	SampleTooFarInFuture         ID = "too-far-in-future"
+	CreatedTimestampInvalid      ID = "created-timestamp-invalid"
+	SampleTooFrequent            ID = "sample-too-frequent"
+	ExemplarLabelsMissing        ID = "exemplar-labels-missing"
+	ExemplarLabelsTooLong        ID = "exemplar-labels-too-long"
+	ExemplarTimestampInvalid     ID = "exemplar-timestamp-invalid"
+	MetadataMissingMetricName    ID = "metadata-missing-metric-name"
+	MetadataTooLong              ID = "metadata-too-long"
+	IngestionRateLimited         ID = "ingestion-rate-limited"
+	TooManyHAClusters            ID = "too-many-ha-clusters"
+	DistributorMaxIngestionRate  ID = "distributor-max-ingestion-rate"
+	DistributorMaxInflightPushes ID = "distributor-max-inflight-push-requests"
+)
+
+// Ingester errors.
+const (
+	MaxSeriesPerUser            ID = "max-series-per-user"
+	MaxSeriesPerMetric          ID = "max-series-per-metric"
+	MaxSeriesCreatedPerHour     ID = "max-series-created-per-hour"
+	MaxMetadataPerUser          ID = "max-metadata-per-user"
+	MaxMetadataPerMetric        ID = "max-metadata-per-metric"
+	SampleOutOfBounds           ID = "sample-out-of-bounds"
+	SampleOutOfOrder            ID = "sample-out-of-order"
+	SampleDuplicateTimestamp    ID = "sample-duplicate-timestamp"
+	IngesterMaxIngestionRate    ID = "ingester-max-ingestion-rate"
+	IngesterMaxTenants          ID = "ingester-max-tenants"
+	IngesterMaxInMemorySeries   ID = "ingester-max-series"
+	IngesterMaxInflightPushes   ID = "ingester-max-inflight-push-requests"
+	IngesterDiskSpaceLow        ID = "ingester-disk-space-low"
+	IngesterDiskSpaceLowNoWrite ID = "ingester-disk-space-low-read-only"
+)
+
+// Querier and query-frontend errors.
+const (
+	MaxSeriesPerQuery               ID = "max-series-per-query"
+	MaxChunksPerQuery               ID = "max-chunks-per-query"
+	MaxChunkBytesPerQuery           ID = "max-chunks-bytes-per-query"
+	MaxSeriesPerQueryFromSource     ID = "max-series-per-query-from-source"
+	MaxChunksPerQueryFromSource     ID = "max-chunks-per-query-from-source"
+	MaxChunkBytesPerQueryFromSource ID = "max-chunks-bytes-per-query-from-source"
+	MaxDataBytesPerQuery            ID = "max-data-bytes-per-query"
+	MaxQueryLength                  ID = "max-query-length"
+	DeadlineBudgetExhausted         ID = "deadline-budget-exhausted"
+	MaxConcurrentQueriesPerTenant   ID = "max-concurrent-queries-per-tenant"
+)
+
+// API errors.
+const (
+	RequestRateLimited ID = "request-rate-limited"
+)
+
+const (
+	codePrefix = "err-mimir-"
+
+	// HeaderName is the HTTP response header carrying the code of the error returned to the client.
+	HeaderName = "X-Mimir-Error-Code"
+)
+
+var codeRegexp = regexp.MustCompile(`\((` + codePrefix + `[a-z0-9-]+)\)`)
+
+// Code returns the error code of the ID, as exposed to clients.
+func (id ID) Code() string {
+	return codePrefix + string(id)
+}
+
+// Message returns the error message with the error code appended.
+func (id ID) Message(msg string) string {
+	return fmt.Sprintf("%s (%s)", msg, id.Code())
+}
+
+// CodeFromMessage returns the error code found in the error message, if any.
+// If the message contains multiple codes, the last one is returned.
+func CodeFromMessage(msg string) (string, bool) {
+	matches := codeRegexp.FindAllStringSubmatch(msg, -1)
+	if len(matches) == 0 {
+		return "", false
+	}
+	return matches[len(matches)-1][1], true
+}
+
+// SetHeader sets the error code found in the error message, if any, as HTTP response header.
+func SetHeader(header http.Header, msg string) {
+	if code, ok := CodeFromMessage(msg); ok {
+		header.Set(HeaderName, code)
+	}
+}
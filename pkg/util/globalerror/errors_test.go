@@ -0,0 +1,58 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package globalerror
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestID_Message(t *testing.T) {
+	assert.Equal(t, "per-user series limit of 10 exceeded (err-mimir-max-series-per-user)", MaxSeriesPerUser.Message("per-user series limit of 10 exceeded"))
+}
+
+func TestCodeFromMessage(t *testing.T) {
+	tests := map[string]struct {
+		msg          string
+		expectedCode string
+		expectedOK   bool
+	}{
+		"no code": {
+			msg: "something went wrong",
+		},
+		"code at the end of the message": {
+			msg:          MaxSeriesPerUser.Message("per-user series limit of 10 exceeded"),
+			expectedCode: "err-mimir-max-series-per-user",
+			expectedOK:   true,
+		},
+		"message wrapped in JSON": {
+			msg:          `{"status":"error","errorType":"bad_data","error":"` + MaxQueryLength.Message("the query time range exceeds the limit") + `"}`,
+			expectedCode: "err-mimir-max-query-length",
+			expectedOK:   true,
+		},
+		"message wrapping another message": {
+			msg:          "user=user-1: " + SampleOutOfOrder.Message("err: out of order sample. "+MissingMetricName.Message("nested")),
+			expectedCode: "err-mimir-sample-out-of-order",
+			expectedOK:   true,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			code, ok := CodeFromMessage(testData.msg)
+			assert.Equal(t, testData.expectedOK, ok)
+			assert.Equal(t, testData.expectedCode, code)
+		})
+	}
+}
+
+func TestSetHeader(t *testing.T) {
+	header := http.Header{}
+	SetHeader(header, "something went wrong")
+	assert.Empty(t, header.Get(HeaderName))
+
+	SetHeader(header, IngestionRateLimited.Message("ingestion rate limit exceeded"))
+	assert.Equal(t, "err-mimir-ingestion-rate-limited", header.Get(HeaderName))
+}
@@ -0,0 +1,176 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package grpcencoding
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+	"go.uber.org/atomic"
+)
+
+// defaultMaxDecompressedSize is the default max size of a decompressed message, matching the default
+// max receive message size of the gRPC clients.
+const defaultMaxDecompressedSize = 100 << 20
+
+// maxDecompressedSize is the max size of a decompressed message, regardless of the max receive message
+// size of the gRPC client or server receiving it.
+var maxDecompressedSize = atomic.NewInt64(defaultMaxDecompressedSize)
+
+// SetMaxDecompressedSize sets the max size of a message decompressed by the compressors of this package,
+// which should be the largest max receive message size of the gRPC clients and servers of the process.
+// It must be called before any message is decompressed.
+func SetMaxDecompressedSize(size int) {
+	maxDecompressedSize.Store(int64(size))
+}
+
+// errDecompressedSizeExceeded is returned when decompressing a message larger than the max decompressed size.
+func errDecompressedSizeExceeded(size int) error {
+	return errors.Errorf("decompressed message size %d bytes exceeds the limit of %d bytes", size, maxDecompressedSize.Load())
+}
+
+// blockCompressor is a compressor encoding each message as a whole, instead of streaming it.
+type blockCompressor struct {
+	name   string
+	encode func(dst, src []byte) []byte
+	decode func(dst, src []byte) ([]byte, error)
+
+	// decodedLen returns the size of the decompressed message, or -1 if unknown.
+	decodedLen func(src []byte) int
+}
+
+func (c *blockCompressor) Name() string {
+	return c.name
+}
+
+func (c *blockCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	return &blockWriter{w: w, encode: c.encode}, nil
+}
+
+// Decompress returns a reader decoding the message on first read, so that gRPC can reject the messages
+// larger than the max receive message size through DecompressedSize before they're decoded.
+func (c *blockCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	return &blockReader{r: r, decode: c.decode}, nil
+}
+
+// DecompressedSize returns the size of the decompressed message, or -1 if unknown. gRPC calls it before
+// reading the message, to reject the messages larger than the max receive message size.
+func (c *blockCompressor) DecompressedSize(src []byte) int {
+	return c.decodedLen(src)
+}
+
+// blockReader decodes the whole message on first read.
+type blockReader struct {
+	r       io.Reader
+	decode  func(dst, src []byte) ([]byte, error)
+	decoded *bytes.Reader
+}
+
+func (r *blockReader) Read(p []byte) (int, error) {
+	if r.decoded == nil {
+		src, err := io.ReadAll(r.r)
+		if err != nil {
+			return 0, err
+		}
+		dst, err := r.decode(nil, src)
+		if err != nil {
+			return 0, err
+		}
+		r.decoded = bytes.NewReader(dst)
+	}
+	return r.decoded.Read(p)
+}
+
+// blockWriter buffers the message and encodes it once closed.
+type blockWriter struct {
+	w      io.Writer
+	buf    bytes.Buffer
+	encode func(dst, src []byte) []byte
+}
+
+func (w *blockWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *blockWriter) Close() error {
+	_, err := w.w.Write(w.encode(nil, w.buf.Bytes()))
+	return err
+}
+
+func newSnappyBlockCompressor() *blockCompressor {
+	decodedLen := func(src []byte) int {
+		n, err := snappy.DecodedLen(src)
+		if err != nil {
+			return -1
+		}
+		return n
+	}
+
+	return &blockCompressor{
+		name:   SnappyBlockName,
+		encode: snappy.Encode,
+		decode: func(dst, src []byte) ([]byte, error) {
+			// The decoded length is read from the header, and allocated before decoding the message.
+			if n := decodedLen(src); int64(n) > maxDecompressedSize.Load() {
+				return nil, errDecompressedSizeExceeded(n)
+			}
+			return snappy.Decode(dst, src)
+		},
+		decodedLen: decodedLen,
+	}
+}
+
+func newZstdCompressor() *blockCompressor {
+	// The encoder and decoder are safe for concurrent use when encoding and decoding whole messages.
+	// They're created on first use, because they start goroutines which would run in every process
+	// importing this package, even if the compression is not used.
+	var (
+		encoderOnce sync.Once
+		encoder     *zstd.Encoder
+		decoderOnce sync.Once
+		decoder     *zstd.Decoder
+	)
+
+	// Errors can only be returned because of invalid options.
+	getEncoder := func() *zstd.Encoder {
+		encoderOnce.Do(func() {
+			var err error
+			if encoder, err = zstd.NewWriter(nil); err != nil {
+				panic(err)
+			}
+		})
+		return encoder
+	}
+	getDecoder := func() *zstd.Decoder {
+		decoderOnce.Do(func() {
+			var err error
+			if decoder, err = zstd.NewReader(nil, zstd.WithDecoderMaxMemory(uint64(maxDecompressedSize.Load()))); err != nil {
+				panic(err)
+			}
+		})
+		return decoder
+	}
+
+	return &blockCompressor{
+		name:   ZstdName,
+		encode: func(dst, src []byte) []byte { return getEncoder().EncodeAll(src, dst) },
+		decode: func(dst, src []byte) ([]byte, error) { return getDecoder().DecodeAll(src, dst) },
+		decodedLen: func(src []byte) int {
+			// The content size is written in the frame header by EncodeAll. A message may be made of
+			// multiple frames, in which case the decoded size is bounded by the decoder max memory only.
+			h := zstd.Header{}
+			if err := h.Decode(src); err != nil || !h.HasFCS {
+				return -1
+			}
+			if h.FrameContentSize > math.MaxInt32 {
+				return math.MaxInt32
+			}
+			return int(h.FrameContentSize)
+		},
+	}
+}
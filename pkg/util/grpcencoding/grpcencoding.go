@@ -0,0 +1,96 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package grpcencoding registers the gRPC compressors supported by Mimir, in addition to the
+// ones registered by gRPC and dskit, and instruments all of them. The compressors are registered
+// when the package is imported, and must be registered in both the clients and the servers.
+package grpcencoding
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/grpcclient"
+	"github.com/grafana/dskit/grpcencoding/snappy"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/encoding/gzip"
+)
+
+const (
+	// SnappyBlockName is the name of the snappy compressor using the block format instead of the
+	// stream format, which is faster for the small messages exchanged between Mimir components.
+	SnappyBlockName = "snappy-block"
+
+	// ZstdName is the name of the zstd compressor.
+	ZstdName = "zstd"
+)
+
+// supported is the list of the supported compressions.
+var supported = []string{gzip.Name, snappy.Name, SnappyBlockName, ZstdName}
+
+// SupportedUsage lists the supported compressions, to be used in the flags usage.
+var SupportedUsage = fmt.Sprintf("Supported values are: '%s' and '%s'.", strings.Join(supported[:len(supported)-1], "', '"), supported[len(supported)-1])
+
+func init() {
+	encoding.RegisterCompressor(newSnappyBlockCompressor())
+	encoding.RegisterCompressor(newZstdCompressor())
+
+	for _, name := range supported {
+		encoding.RegisterCompressor(newInstrumentedCompressor(encoding.GetCompressor(name)))
+	}
+}
+
+// Validate returns an error if the input compression isn't supported. An empty compression is valid.
+func Validate(compression string) error {
+	if compression == "" {
+		return nil
+	}
+	for _, name := range supported {
+		if compression == name {
+			return nil
+		}
+	}
+	return errors.Errorf("unsupported compression type: %s", compression)
+}
+
+// ValidateClientConfig validates the gRPC client config, accepting all the compressions
+// supported by Mimir and not only the ones supported by dskit.
+func ValidateClientConfig(cfg grpcclient.Config, logger log.Logger) error {
+	if err := Validate(cfg.GRPCCompression); err != nil {
+		return err
+	}
+
+	cfg.GRPCCompression = ""
+	return cfg.Validate(logger)
+}
+
+type compressionCtxKey struct{}
+
+// ContextWithCompression returns a context overriding the compression of the gRPC requests issued with it.
+// The override only applies to the clients using the interceptors of this package.
+func ContextWithCompression(ctx context.Context, compression string) context.Context {
+	if compression == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, compressionCtxKey{}, compression)
+}
+
+func withCompressionFromContext(ctx context.Context, opts []grpc.CallOption) []grpc.CallOption {
+	if compression, ok := ctx.Value(compressionCtxKey{}).(string); ok {
+		return append(opts, grpc.UseCompressor(compression))
+	}
+	return opts
+}
+
+// UnaryClientInterceptor applies the compression set in the context through ContextWithCompression.
+func UnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(ctx, method, req, reply, cc, withCompressionFromContext(ctx, opts)...)
+}
+
+// StreamClientInterceptor applies the compression set in the context through ContextWithCompression.
+func StreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(ctx, desc, cc, method, withCompressionFromContext(ctx, opts)...)
+}
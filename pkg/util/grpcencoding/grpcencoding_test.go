@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package grpcencoding
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/grpcclient"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+)
+
+func TestCompressors(t *testing.T) {
+	message := []byte(strings.Repeat("series{label=\"value\"} ", 1000))
+
+	for _, name := range supported {
+		t.Run(name, func(t *testing.T) {
+			c := encoding.GetCompressor(name)
+			require.NotNil(t, c)
+			require.IsType(t, &instrumentedCompressor{}, c)
+
+			compressed := bytes.Buffer{}
+			w, err := c.Compress(&compressed)
+			require.NoError(t, err)
+			_, err = w.Write(message)
+			require.NoError(t, err)
+			require.NoError(t, w.Close())
+			assert.Less(t, compressed.Len(), len(message))
+
+			compressedLen := compressed.Len()
+			r, err := c.Decompress(&compressed)
+			require.NoError(t, err)
+			decompressed, err := io.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, message, decompressed)
+
+			for _, op := range []string{operationCompress, operationDecompress} {
+				assert.GreaterOrEqual(t, testutil.ToFloat64(uncompressedBytes.WithLabelValues(name, op)), float64(len(message)))
+				assert.GreaterOrEqual(t, testutil.ToFloat64(compressedBytes.WithLabelValues(name, op)), float64(compressedLen))
+			}
+		})
+	}
+}
+
+func TestBlockCompressors_DecompressedSize(t *testing.T) {
+	message := []byte(strings.Repeat("series{label=\"value\"} ", 1000))
+
+	for _, name := range []string{SnappyBlockName, ZstdName} {
+		t.Run(name, func(t *testing.T) {
+			c := encoding.GetCompressor(name).(*instrumentedCompressor)
+
+			compressed := bytes.Buffer{}
+			w, err := c.Compress(&compressed)
+			require.NoError(t, err)
+			_, err = w.Write(message)
+			require.NoError(t, err)
+			require.NoError(t, w.Close())
+
+			assert.Equal(t, len(message), c.DecompressedSize(compressed.Bytes()))
+			assert.Equal(t, -1, c.DecompressedSize([]byte{0xff}))
+		})
+	}
+
+	t.Run("should reject a snappy message larger than the max decompressed size before decoding it", func(t *testing.T) {
+		SetMaxDecompressedSize(1024)
+		t.Cleanup(func() {
+			SetMaxDecompressedSize(defaultMaxDecompressedSize)
+		})
+
+		// The header claims a 1GiB message, which would be allocated before being decoded.
+		src := []byte{0x80, 0x80, 0x80, 0x80, 0x04, 0x00}
+		c := encoding.GetCompressor(SnappyBlockName).(*instrumentedCompressor)
+		assert.Equal(t, 1<<30, c.DecompressedSize(src))
+
+		r, err := c.Decompress(bytes.NewReader(src))
+		require.NoError(t, err)
+		_, err = io.ReadAll(r)
+		assert.EqualError(t, err, "decompressed message size 1073741824 bytes exceeds the limit of 1024 bytes")
+	})
+}
+
+func TestValidateClientConfig(t *testing.T) {
+	for _, compression := range append([]string{""}, supported...) {
+		assert.NoError(t, ValidateClientConfig(grpcclient.Config{GRPCCompression: compression}, log.NewNopLogger()), compression)
+	}
+
+	assert.EqualError(t, ValidateClientConfig(grpcclient.Config{GRPCCompression: "lz4"}, log.NewNopLogger()), "unsupported compression type: lz4")
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	var callOpts []grpc.CallOption
+	invoker := func(_ context.Context, _ string, _, _ interface{}, _ *grpc.ClientConn, opts ...grpc.CallOption) error {
+		callOpts = opts
+		return nil
+	}
+
+	require.NoError(t, UnaryClientInterceptor(context.Background(), "method", nil, nil, nil, invoker))
+	assert.Empty(t, callOpts)
+
+	require.NoError(t, UnaryClientInterceptor(ContextWithCompression(context.Background(), ""), "method", nil, nil, nil, invoker))
+	assert.Empty(t, callOpts)
+
+	require.NoError(t, UnaryClientInterceptor(ContextWithCompression(context.Background(), ZstdName), "method", nil, nil, nil, invoker))
+	require.Len(t, callOpts, 1)
+	assert.Equal(t, grpc.CompressorCallOption{CompressorType: ZstdName}, callOpts[0])
+}
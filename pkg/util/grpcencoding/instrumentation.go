@@ -0,0 +1,172 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package grpcencoding
+
+import (
+	"io"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc/encoding"
+)
+
+const (
+	operationCompress   = "compress"
+	operationDecompress = "decompress"
+)
+
+var (
+	uncompressedBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "cortex",
+		Name:      "grpc_compression_uncompressed_bytes_total",
+		Help:      "Total size of the gRPC messages before compression or after decompression.",
+	}, []string{"compression", "operation"})
+	compressedBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "cortex",
+		Name:      "grpc_compression_compressed_bytes_total",
+		Help:      "Total size of the gRPC messages after compression or before decompression.",
+	}, []string{"compression", "operation"})
+	compressionDuration = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "cortex",
+		Name:      "grpc_compression_duration_seconds_total",
+		Help:      "Total time spent compressing or decompressing gRPC messages.",
+	}, []string{"compression", "operation"})
+)
+
+// instrumentedCompressor tracks the bytes processed by the wrapped compressor and the time it spends.
+type instrumentedCompressor struct {
+	encoding.Compressor
+
+	compressUncompressedBytes   prometheus.Counter
+	compressCompressedBytes     prometheus.Counter
+	compressDuration            prometheus.Counter
+	decompressUncompressedBytes prometheus.Counter
+	decompressCompressedBytes   prometheus.Counter
+	decompressDuration          prometheus.Counter
+}
+
+func newInstrumentedCompressor(c encoding.Compressor) *instrumentedCompressor {
+	name := c.Name()
+
+	return &instrumentedCompressor{
+		Compressor:                  c,
+		compressUncompressedBytes:   uncompressedBytes.WithLabelValues(name, operationCompress),
+		compressCompressedBytes:     compressedBytes.WithLabelValues(name, operationCompress),
+		compressDuration:            compressionDuration.WithLabelValues(name, operationCompress),
+		decompressUncompressedBytes: uncompressedBytes.WithLabelValues(name, operationDecompress),
+		decompressCompressedBytes:   compressedBytes.WithLabelValues(name, operationDecompress),
+		decompressDuration:          compressionDuration.WithLabelValues(name, operationDecompress),
+	}
+}
+
+func (c *instrumentedCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	cw := &countingWriter{w: w}
+
+	start := time.Now()
+	wc, err := c.Compressor.Compress(cw)
+	if err != nil {
+		return nil, err
+	}
+
+	return &instrumentedWriter{wc: wc, compressed: cw, compressor: c, elapsed: time.Since(start)}, nil
+}
+
+func (c *instrumentedCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	cr := &countingReader{r: r}
+
+	start := time.Now()
+	dr, err := c.Compressor.Decompress(cr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &instrumentedReader{r: dr, compressed: cr, compressor: c, elapsed: time.Since(start)}, nil
+}
+
+// DecompressedSize returns the size of the decompressed message if the wrapped compressor can tell it
+// without decompressing the message, or -1 otherwise.
+func (c *instrumentedCompressor) DecompressedSize(buf []byte) int {
+	if sizer, ok := c.Compressor.(interface {
+		DecompressedSize(compressedBytes []byte) int
+	}); ok {
+		return sizer.DecompressedSize(buf)
+	}
+	return -1
+}
+
+type instrumentedWriter struct {
+	wc         io.WriteCloser
+	compressed *countingWriter
+	compressor *instrumentedCompressor
+
+	uncompressed int
+	elapsed      time.Duration
+}
+
+func (w *instrumentedWriter) Write(p []byte) (int, error) {
+	start := time.Now()
+	n, err := w.wc.Write(p)
+	w.elapsed += time.Since(start)
+	w.uncompressed += n
+	return n, err
+}
+
+func (w *instrumentedWriter) Close() error {
+	start := time.Now()
+	err := w.wc.Close()
+	w.elapsed += time.Since(start)
+
+	w.compressor.compressUncompressedBytes.Add(float64(w.uncompressed))
+	w.compressor.compressCompressedBytes.Add(float64(w.compressed.n))
+	w.compressor.compressDuration.Add(w.elapsed.Seconds())
+	return err
+}
+
+type instrumentedReader struct {
+	r          io.Reader
+	compressed *countingReader
+	compressor *instrumentedCompressor
+
+	uncompressed int
+	elapsed      time.Duration
+	done         bool
+}
+
+func (r *instrumentedReader) Read(p []byte) (int, error) {
+	start := time.Now()
+	n, err := r.r.Read(p)
+	r.elapsed += time.Since(start)
+	r.uncompressed += n
+
+	// The metrics are tracked once the whole message has been read.
+	if err == io.EOF && !r.done {
+		r.done = true
+		r.compressor.decompressUncompressedBytes.Add(float64(r.uncompressed))
+		r.compressor.decompressCompressedBytes.Add(float64(r.compressed.n))
+		r.compressor.decompressDuration.Add(r.elapsed.Seconds())
+	}
+	return n, err
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += n
+	return n, err
+}
+
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += n
+	return n, err
+}
@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package httpgrpcutil
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/weaveworks/common/httpgrpc"
+)
+
+// DeadlineBudgetHeader is the header carrying the remaining time, in milliseconds, a request
+// can be processed for before its deadline is exhausted. Each hop handing the request over to the
+// next component updates the header with the budget left at that time.
+const DeadlineBudgetHeader = "X-Deadline-Budget-Ms"
+
+// ParseDeadlineBudget parses the value of the DeadlineBudgetHeader.
+func ParseDeadlineBudget(value string) (time.Duration, error) {
+	ms, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid %s header", DeadlineBudgetHeader)
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// GetDeadlineBudget returns the remaining deadline budget of the request, and whether the request has one.
+func GetDeadlineBudget(req *httpgrpc.HTTPRequest) (time.Duration, bool, error) {
+	for _, h := range req.Headers {
+		if http.CanonicalHeaderKey(h.Key) != DeadlineBudgetHeader || len(h.Values) == 0 {
+			continue
+		}
+
+		budget, err := ParseDeadlineBudget(h.Values[0])
+		return budget, err == nil, err
+	}
+	return 0, false, nil
+}
+
+// SetDeadlineBudget sets the remaining deadline budget of the request, replacing any previous value.
+func SetDeadlineBudget(req *httpgrpc.HTTPRequest, budget time.Duration) {
+	value := []string{strconv.FormatInt(budget.Milliseconds(), 10)}
+
+	for _, h := range req.Headers {
+		if http.CanonicalHeaderKey(h.Key) == DeadlineBudgetHeader {
+			h.Values = value
+			return
+		}
+	}
+	req.Headers = append(req.Headers, &httpgrpc.Header{Key: DeadlineBudgetHeader, Values: value})
+}
+
+// SetDeadlineBudgetFromContext sets the remaining deadline budget of the request to the time left until
+// the context deadline. The request is left unchanged if the context has no deadline.
+func SetDeadlineBudgetFromContext(ctx context.Context, req *httpgrpc.HTTPRequest) {
+	if deadline, ok := ctx.Deadline(); ok {
+		SetDeadlineBudget(req, time.Until(deadline))
+	}
+}
@@ -0,0 +1,63 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package httpgrpcutil
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+)
+
+func TestDeadlineBudget(t *testing.T) {
+	req := &httpgrpc.HTTPRequest{Headers: []*httpgrpc.Header{{Key: "X-Scope-OrgID", Values: []string{"user-1"}}}}
+
+	_, ok, err := GetDeadlineBudget(req)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	SetDeadlineBudget(req, 10*time.Second)
+	budget, ok, err := GetDeadlineBudget(req)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 10*time.Second, budget)
+
+	// Setting the budget again replaces the previous value.
+	SetDeadlineBudget(req, 5*time.Second)
+	budget, _, err = GetDeadlineBudget(req)
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Second, budget)
+	assert.Len(t, req.Headers, 2)
+
+	// The header key is case-insensitive.
+	req.Headers[1].Key = "x-deadline-budget-ms"
+	budget, _, err = GetDeadlineBudget(req)
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Second, budget)
+
+	req.Headers[1].Values = []string{"invalid"}
+	_, ok, err = GetDeadlineBudget(req)
+	assert.Error(t, err)
+	assert.False(t, ok)
+}
+
+func TestSetDeadlineBudgetFromContext(t *testing.T) {
+	req := &httpgrpc.HTTPRequest{}
+
+	// A context without deadline doesn't set any budget.
+	SetDeadlineBudgetFromContext(context.Background(), req)
+	assert.Empty(t, req.Headers)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	SetDeadlineBudgetFromContext(ctx, req)
+	budget, ok, err := GetDeadlineBudget(req)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.LessOrEqual(t, budget, time.Minute)
+	assert.Greater(t, budget, 59*time.Second)
+}
@@ -8,6 +8,9 @@ import (
 	"github.com/weaveworks/common/httpgrpc"
 )
 
+// StatusClientClosedRequest is the status code of the requests whose client went away before getting the response.
+const StatusClientClosedRequest = 499
+
 // PrioritizeRecoverableErr checks whether in the given slice of errors there is a recoverable error, if yes then it will
 // return the first recoverable error, if not then it will return the first non-recoverable error, if there is no
 // error at all then it will return nil.
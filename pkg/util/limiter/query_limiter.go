@@ -15,42 +15,126 @@ import (
 
 	"github.com/grafana/mimir/pkg/ingester/client"
 	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/globalerror"
 )
 
 type queryLimiterCtxKey struct{}
 
 var (
 	ctxKey                    = &queryLimiterCtxKey{}
-	ErrMaxSeriesHit           = "the query hit the max number of series limit (limit: %d series)"
-	ErrMaxChunkBytesHit       = "the query hit the aggregated chunks size limit (limit: %d bytes)"
-	ErrMaxChunksPerQueryLimit = "the query hit the max number of chunks limit (limit: %d chunks)"
+	ErrMaxSeriesHit           = globalerror.MaxSeriesPerQuery.Message("the query hit the max number of series limit (limit: %d series)")
+	ErrMaxChunkBytesHit       = globalerror.MaxChunkBytesPerQuery.Message("the query hit the aggregated chunks size limit (limit: %d bytes)")
+	ErrMaxChunksPerQueryLimit = globalerror.MaxChunksPerQuery.Message("the query hit the max number of chunks limit (limit: %d chunks)")
+	ErrMaxDataBytesHit        = globalerror.MaxDataBytesPerQuery.Message("the query hit the aggregated size of fetched data limit (limit: %d bytes)")
+
+	ErrMaxSeriesFromSourceHit           = globalerror.MaxSeriesPerQueryFromSource.Message("the query hit the max number of series fetched from %s limit (limit: %d series)")
+	ErrMaxChunkBytesFromSourceHit       = globalerror.MaxChunkBytesPerQueryFromSource.Message("the query hit the aggregated size of chunks fetched from %s limit (limit: %d bytes)")
+	ErrMaxChunksPerQueryFromSourceLimit = globalerror.MaxChunksPerQueryFromSource.Message("the query hit the max number of chunks fetched from %s limit (limit: %d chunks)")
 )
 
-type QueryLimiter struct {
+// Source is a source of the data fetched by a query.
+type Source string
+
+const (
+	SourceIngesters     Source = "ingesters"
+	SourceStoreGateways Source = "store-gateways"
+)
+
+// SourceLimits are the limits to the data a query can fetch from a single source. 0 to disable a limit.
+type SourceLimits struct {
+	MaxSeries     int
+	MaxChunkBytes int
+	MaxChunks     int
+}
+
+// fetchedData tracks the data fetched by a query, and checks it against the configured limits.
+type fetchedData struct {
 	uniqueSeriesMx sync.Mutex
 	uniqueSeries   map[model.Fingerprint]struct{}
 
 	chunkBytesCount atomic.Int64
 	chunkCount      atomic.Int64
 
-	maxSeriesPerQuery     int
-	maxChunkBytesPerQuery int
-	maxChunksPerQuery     int
+	maxSeries     int
+	maxChunkBytes int
+	maxChunks     int
+}
+
+func newFetchedData(maxSeries, maxChunkBytes, maxChunks int) *fetchedData {
+	return &fetchedData{
+		uniqueSeries:  map[model.Fingerprint]struct{}{},
+		maxSeries:     maxSeries,
+		maxChunkBytes: maxChunkBytes,
+		maxChunks:     maxChunks,
+	}
+}
+
+// addSeries adds the series and returns whether the limit is exceeded.
+func (d *fetchedData) addSeries(fingerprint model.Fingerprint) bool {
+	if d.maxSeries == 0 {
+		return false
+	}
+
+	d.uniqueSeriesMx.Lock()
+	defer d.uniqueSeriesMx.Unlock()
+
+	d.uniqueSeries[fingerprint] = struct{}{}
+	return len(d.uniqueSeries) > d.maxSeries
+}
+
+// addChunkBytes adds the chunks size and returns whether the limit is exceeded.
+func (d *fetchedData) addChunkBytes(chunkSizeInBytes int) bool {
+	if d.maxChunkBytes == 0 {
+		return false
+	}
+	return d.chunkBytesCount.Add(int64(chunkSizeInBytes)) > int64(d.maxChunkBytes)
+}
+
+// addChunks adds the number of chunks and returns whether the limit is exceeded.
+func (d *fetchedData) addChunks(count int) bool {
+	if d.maxChunks == 0 {
+		return false
+	}
+	return d.chunkCount.Add(int64(count)) > int64(d.maxChunks)
+}
+
+type QueryLimiter struct {
+	// total tracks the data fetched from all sources.
+	total *fetchedData
+
+	// sources tracks the data fetched from each source having limits. The map
+	// is only written while building the limiter, before the query runs.
+	sources map[Source]*fetchedData
+
+	// dataBytesCount tracks the size of all the data fetched from all sources, including
+	// the series labels and the index postings, which aren't tracked by the chunks size.
+	dataBytesCount atomic.Int64
+	maxDataBytes   int
 }
 
 // NewQueryLimiter makes a new per-query limiter. Each query limiter
 // is configured using the `maxSeriesPerQuery` limit.
 func NewQueryLimiter(maxSeriesPerQuery, maxChunkBytesPerQuery int, maxChunksPerQuery int) *QueryLimiter {
 	return &QueryLimiter{
-		uniqueSeriesMx: sync.Mutex{},
-		uniqueSeries:   map[model.Fingerprint]struct{}{},
-
-		maxSeriesPerQuery:     maxSeriesPerQuery,
-		maxChunkBytesPerQuery: maxChunkBytesPerQuery,
-		maxChunksPerQuery:     maxChunksPerQuery,
+		total:   newFetchedData(maxSeriesPerQuery, maxChunkBytesPerQuery, maxChunksPerQuery),
+		sources: map[Source]*fetchedData{},
 	}
 }
 
+// WithSourceLimits configures the limits to the data the query can fetch from the source, in addition
+// to the limits to the data fetched from all sources. It must be called before the limiter is used.
+func (ql *QueryLimiter) WithSourceLimits(source Source, limits SourceLimits) *QueryLimiter {
+	ql.sources[source] = newFetchedData(limits.MaxSeries, limits.MaxChunkBytes, limits.MaxChunks)
+	return ql
+}
+
+// WithMaxDataBytes configures the limit to the size of all the data the query can fetch from all sources.
+// It must be called before the limiter is used.
+func (ql *QueryLimiter) WithMaxDataBytes(maxDataBytes int) *QueryLimiter {
+	ql.maxDataBytes = maxDataBytes
+	return ql
+}
+
 func AddQueryLimiterToContext(ctx context.Context, limiter *QueryLimiter) context.Context {
 	return context.WithValue(ctx, ctxKey, limiter)
 }
@@ -66,50 +150,62 @@ func QueryLimiterFromContextWithFallback(ctx context.Context) *QueryLimiter {
 	return ql
 }
 
-// AddSeries adds the input series and returns an error if the limit is reached.
-func (ql *QueryLimiter) AddSeries(seriesLabels []mimirpb.LabelAdapter) error {
-	// If the max series is unlimited just return without managing map
-	if ql.maxSeriesPerQuery == 0 {
+// AddSeries adds the input series fetched from the source and returns an error if the limit is reached.
+func (ql *QueryLimiter) AddSeries(source Source, seriesLabels []mimirpb.LabelAdapter) error {
+	sourceData := ql.sources[source]
+
+	// If the max series is unlimited just return without computing the fingerprint.
+	if ql.total.maxSeries == 0 && (sourceData == nil || sourceData.maxSeries == 0) {
 		return nil
 	}
 	fingerprint := client.FastFingerprint(seriesLabels)
 
-	ql.uniqueSeriesMx.Lock()
-	defer ql.uniqueSeriesMx.Unlock()
-
-	ql.uniqueSeries[fingerprint] = struct{}{}
-	if len(ql.uniqueSeries) > ql.maxSeriesPerQuery {
+	if ql.total.addSeries(fingerprint) {
 		// Format error with max limit
-		return fmt.Errorf(ErrMaxSeriesHit, ql.maxSeriesPerQuery)
+		return fmt.Errorf(ErrMaxSeriesHit, ql.total.maxSeries)
+	}
+	if sourceData != nil && sourceData.addSeries(fingerprint) {
+		return fmt.Errorf(ErrMaxSeriesFromSourceHit, source, sourceData.maxSeries)
 	}
 	return nil
 }
 
 // uniqueSeriesCount returns the count of unique series seen by this query limiter.
 func (ql *QueryLimiter) uniqueSeriesCount() int {
-	ql.uniqueSeriesMx.Lock()
-	defer ql.uniqueSeriesMx.Unlock()
-	return len(ql.uniqueSeries)
+	ql.total.uniqueSeriesMx.Lock()
+	defer ql.total.uniqueSeriesMx.Unlock()
+	return len(ql.total.uniqueSeries)
 }
 
-// AddChunkBytes adds the input chunk size in bytes and returns an error if the limit is reached.
-func (ql *QueryLimiter) AddChunkBytes(chunkSizeInBytes int) error {
-	if ql.maxChunkBytesPerQuery == 0 {
-		return nil
+// AddChunkBytes adds the input chunk size in bytes fetched from the source and returns an error if the limit is reached.
+func (ql *QueryLimiter) AddChunkBytes(source Source, chunkSizeInBytes int) error {
+	if ql.total.addChunkBytes(chunkSizeInBytes) {
+		return fmt.Errorf(ErrMaxChunkBytesHit, ql.total.maxChunkBytes)
 	}
-	if ql.chunkBytesCount.Add(int64(chunkSizeInBytes)) > int64(ql.maxChunkBytesPerQuery) {
-		return fmt.Errorf(ErrMaxChunkBytesHit, ql.maxChunkBytesPerQuery)
+	if sourceData := ql.sources[source]; sourceData != nil && sourceData.addChunkBytes(chunkSizeInBytes) {
+		return fmt.Errorf(ErrMaxChunkBytesFromSourceHit, source, sourceData.maxChunkBytes)
 	}
 	return nil
 }
 
-func (ql *QueryLimiter) AddChunks(count int) error {
-	if ql.maxChunksPerQuery == 0 {
-		return nil
+// AddChunks adds the number of chunks fetched from the source and returns an error if the limit is reached.
+func (ql *QueryLimiter) AddChunks(source Source, count int) error {
+	if ql.total.addChunks(count) {
+		return fmt.Errorf(ErrMaxChunksPerQueryLimit, ql.total.maxChunks)
 	}
+	if sourceData := ql.sources[source]; sourceData != nil && sourceData.addChunks(count) {
+		return fmt.Errorf(ErrMaxChunksPerQueryFromSourceLimit, source, sourceData.maxChunks)
+	}
+	return nil
+}
 
-	if ql.chunkCount.Add(int64(count)) > int64(ql.maxChunksPerQuery) {
-		return fmt.Errorf(fmt.Sprintf(ErrMaxChunksPerQueryLimit, ql.maxChunksPerQuery))
+// AddDataBytes adds the size in bytes of data fetched from any source and returns an error if the limit is reached.
+func (ql *QueryLimiter) AddDataBytes(dataSizeInBytes int) error {
+	if ql.maxDataBytes == 0 {
+		return nil
+	}
+	if ql.dataBytesCount.Add(int64(dataSizeInBytes)) > int64(ql.maxDataBytes) {
+		return fmt.Errorf(ErrMaxDataBytesHit, ql.maxDataBytes)
 	}
 	return nil
 }
@@ -32,14 +32,14 @@ func TestQueryLimiter_AddSeries_ShouldReturnNoErrorOnLimitNotExceeded(t *testing
 		})
 		limiter = NewQueryLimiter(100, 0, 0)
 	)
-	err := limiter.AddSeries(mimirpb.FromLabelsToLabelAdapters(series1))
+	err := limiter.AddSeries(SourceIngesters, mimirpb.FromLabelsToLabelAdapters(series1))
 	assert.NoError(t, err)
-	err = limiter.AddSeries(mimirpb.FromLabelsToLabelAdapters(series2))
+	err = limiter.AddSeries(SourceIngesters, mimirpb.FromLabelsToLabelAdapters(series2))
 	assert.NoError(t, err)
 	assert.Equal(t, 2, limiter.uniqueSeriesCount())
 
 	// Re-add previous series to make sure it's not double counted
-	err = limiter.AddSeries(mimirpb.FromLabelsToLabelAdapters(series1))
+	err = limiter.AddSeries(SourceIngesters, mimirpb.FromLabelsToLabelAdapters(series1))
 	assert.NoError(t, err)
 	assert.Equal(t, 2, limiter.uniqueSeriesCount())
 }
@@ -60,21 +60,87 @@ func TestQueryLimiter_AddSeriers_ShouldReturnErrorOnLimitExceeded(t *testing.T)
 		})
 		limiter = NewQueryLimiter(1, 0, 0)
 	)
-	err := limiter.AddSeries(mimirpb.FromLabelsToLabelAdapters(series1))
+	err := limiter.AddSeries(SourceIngesters, mimirpb.FromLabelsToLabelAdapters(series1))
 	require.NoError(t, err)
-	err = limiter.AddSeries(mimirpb.FromLabelsToLabelAdapters(series2))
+	err = limiter.AddSeries(SourceIngesters, mimirpb.FromLabelsToLabelAdapters(series2))
 	require.Error(t, err)
 }
 
 func TestQueryLimiter_AddChunkBytes(t *testing.T) {
 	var limiter = NewQueryLimiter(0, 100, 0)
 
-	err := limiter.AddChunkBytes(100)
+	err := limiter.AddChunkBytes(SourceIngesters, 100)
 	require.NoError(t, err)
-	err = limiter.AddChunkBytes(1)
+	err = limiter.AddChunkBytes(SourceIngesters, 1)
 	require.Error(t, err)
 }
 
+func TestQueryLimiter_AddChunks(t *testing.T) {
+	var limiter = NewQueryLimiter(0, 0, 100)
+
+	err := limiter.AddChunks(SourceIngesters, 60)
+	require.NoError(t, err)
+	err = limiter.AddChunks(SourceStoreGateways, 40)
+	require.NoError(t, err)
+	err = limiter.AddChunks(SourceStoreGateways, 1)
+	require.EqualError(t, err, fmt.Sprintf(ErrMaxChunksPerQueryLimit, 100))
+}
+
+func TestQueryLimiter_AddDataBytes(t *testing.T) {
+	var limiter = NewQueryLimiter(0, 0, 0).WithMaxDataBytes(100)
+
+	err := limiter.AddDataBytes(60)
+	require.NoError(t, err)
+	err = limiter.AddDataBytes(40)
+	require.NoError(t, err)
+	err = limiter.AddDataBytes(1)
+	require.EqualError(t, err, fmt.Sprintf(ErrMaxDataBytesHit, 100))
+}
+
+func TestQueryLimiter_SourceLimits(t *testing.T) {
+	series := func(i int) []mimirpb.LabelAdapter {
+		return mimirpb.FromLabelsToLabelAdapters(labels.FromStrings(labels.MetricName, "test_metric", "series", fmt.Sprint(i)))
+	}
+
+	t.Run("series", func(t *testing.T) {
+		limiter := NewQueryLimiter(3, 0, 0).WithSourceLimits(SourceIngesters, SourceLimits{MaxSeries: 1})
+
+		require.NoError(t, limiter.AddSeries(SourceIngesters, series(1)))
+		// The same series fetched again from the same source isn't counted twice.
+		require.NoError(t, limiter.AddSeries(SourceIngesters, series(1)))
+		// The limit applies to the source only.
+		require.NoError(t, limiter.AddSeries(SourceStoreGateways, series(2)))
+		require.EqualError(t, limiter.AddSeries(SourceIngesters, series(3)), fmt.Sprintf(ErrMaxSeriesFromSourceHit, SourceIngesters, 1))
+		// The limit to the series fetched from all sources still applies.
+		require.EqualError(t, limiter.AddSeries(SourceStoreGateways, series(4)), fmt.Sprintf(ErrMaxSeriesHit, 3))
+	})
+
+	t.Run("series without total limit", func(t *testing.T) {
+		limiter := NewQueryLimiter(0, 0, 0).WithSourceLimits(SourceStoreGateways, SourceLimits{MaxSeries: 1})
+
+		require.NoError(t, limiter.AddSeries(SourceIngesters, series(1)))
+		require.NoError(t, limiter.AddSeries(SourceIngesters, series(2)))
+		require.NoError(t, limiter.AddSeries(SourceStoreGateways, series(1)))
+		require.EqualError(t, limiter.AddSeries(SourceStoreGateways, series(2)), fmt.Sprintf(ErrMaxSeriesFromSourceHit, SourceStoreGateways, 1))
+	})
+
+	t.Run("chunk bytes", func(t *testing.T) {
+		limiter := NewQueryLimiter(0, 0, 0).WithSourceLimits(SourceStoreGateways, SourceLimits{MaxChunkBytes: 100})
+
+		require.NoError(t, limiter.AddChunkBytes(SourceIngesters, 1000))
+		require.NoError(t, limiter.AddChunkBytes(SourceStoreGateways, 100))
+		require.EqualError(t, limiter.AddChunkBytes(SourceStoreGateways, 1), fmt.Sprintf(ErrMaxChunkBytesFromSourceHit, SourceStoreGateways, 100))
+	})
+
+	t.Run("chunks", func(t *testing.T) {
+		limiter := NewQueryLimiter(0, 0, 0).WithSourceLimits(SourceIngesters, SourceLimits{MaxChunks: 10})
+
+		require.NoError(t, limiter.AddChunks(SourceStoreGateways, 100))
+		require.NoError(t, limiter.AddChunks(SourceIngesters, 10))
+		require.EqualError(t, limiter.AddChunks(SourceIngesters, 1), fmt.Sprintf(ErrMaxChunksPerQueryFromSourceLimit, SourceIngesters, 10))
+	})
+}
+
 func BenchmarkQueryLimiter_AddSeries(b *testing.B) {
 	const (
 		metricName = "test_metric"
@@ -91,7 +157,7 @@ func BenchmarkQueryLimiter_AddSeries(b *testing.B) {
 
 	limiter := NewQueryLimiter(b.N+1, 0, 0)
 	for _, s := range series {
-		err := limiter.AddSeries(mimirpb.FromLabelsToLabelAdapters(s))
+		err := limiter.AddSeries(SourceIngesters, mimirpb.FromLabelsToLabelAdapters(s))
 		assert.NoError(b, err)
 	}
 
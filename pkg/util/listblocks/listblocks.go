@@ -3,8 +3,10 @@
 package listblocks
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"io/ioutil"
 	"path"
 	"sort"
 	"sync"
@@ -27,7 +29,8 @@ import (
 // If ulidMinTime is non-zero, then only blocks with ULID time higher than that are read,
 // this is useful to filter the results for users with high amount of blocks without reading the metas
 // (but it can be inexact since ULID time can differ from block min/max times range).
-func LoadMetaFilesAndDeletionMarkers(ctx context.Context, bkt objstore.BucketReader, user string, showDeleted bool, ulidMinTime time.Time) (metas map[ulid.ULID]*metadata.Meta, deletionTimes map[ulid.ULID]time.Time, _ error) {
+// The annotations stored in the meta files are returned too, for the blocks which have any.
+func LoadMetaFilesAndDeletionMarkers(ctx context.Context, bkt objstore.BucketReader, user string, showDeleted bool, ulidMinTime time.Time) (metas map[ulid.ULID]*metadata.Meta, deletionTimes map[ulid.ULID]time.Time, annotations map[ulid.ULID]tsdb.BlockAnnotations, _ error) {
 	deletedBlocks := map[ulid.ULID]bool{}
 	deletionMarkerFiles := []string(nil)
 
@@ -40,7 +43,7 @@ func LoadMetaFilesAndDeletionMarkers(ctx context.Context, bkt objstore.BucketRea
 		return nil
 	})
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	metaPaths := []string(nil)
@@ -62,18 +65,18 @@ func LoadMetaFilesAndDeletionMarkers(ctx context.Context, bkt objstore.BucketRea
 	})
 
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	if showDeleted {
 		deletionTimes, err = fetchDeletionTimes(ctx, bkt, deletionMarkerFiles)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
 	}
 
-	metas, err = fetchMetas(ctx, bkt, metaPaths)
-	return metas, deletionTimes, err
+	metas, annotations, err = fetchMetas(ctx, bkt, metaPaths)
+	return metas, deletionTimes, annotations, err
 }
 
 const concurrencyLimit = 32
@@ -108,11 +111,12 @@ func fetchDeletionTimes(ctx context.Context, bkt objstore.BucketReader, deletion
 	})
 }
 
-func fetchMetas(ctx context.Context, bkt objstore.BucketReader, metaFiles []string) (map[ulid.ULID]*metadata.Meta, error) {
+func fetchMetas(ctx context.Context, bkt objstore.BucketReader, metaFiles []string) (map[ulid.ULID]*metadata.Meta, map[ulid.ULID]tsdb.BlockAnnotations, error) {
 	mu := sync.Mutex{}
 	metas := map[ulid.ULID]*metadata.Meta{}
+	annotations := map[ulid.ULID]tsdb.BlockAnnotations{}
 
-	return metas, concurrency.ForEachJob(ctx, len(metaFiles), concurrencyLimit, func(ctx context.Context, idx int) error {
+	return metas, annotations, concurrency.ForEachJob(ctx, len(metaFiles), concurrencyLimit, func(ctx context.Context, idx int) error {
 		r, err := bkt.Get(ctx, metaFiles[idx])
 		if err != nil {
 			if bkt.IsObjNotFoundErr(err) {
@@ -123,13 +127,26 @@ func fetchMetas(ctx context.Context, bkt objstore.BucketReader, metaFiles []stri
 		}
 		defer r.Close()
 
-		m, err := metadata.Read(r)
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			return err
+		}
+
+		m, err := metadata.Read(ioutil.NopCloser(bytes.NewReader(data)))
+		if err != nil {
+			return err
+		}
+
+		a, err := tsdb.ParseBlockAnnotations(data)
 		if err != nil {
 			return err
 		}
 
 		mu.Lock()
 		metas[m.ULID] = m
+		if len(a) > 0 {
+			annotations[m.ULID] = a
+		}
 		mu.Unlock()
 
 		return nil
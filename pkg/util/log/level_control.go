@@ -0,0 +1,156 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+)
+
+// componentKey is the key identifying the component of the log lines, as added by log.With(logger, "component", ...).
+const componentKey = "component"
+
+// LogLevels holds the runtime log level changes applied by the loggers created with NewDefaultLogger.
+var LogLevels = NewLevelControl(time.Now)
+
+// LevelChange replaces, until it expires, the configured log level of all the log lines,
+// or of the log lines of a single component when Component is set.
+type LevelChange struct {
+	Component string    `json:"component,omitempty"`
+	Level     string    `json:"level"`
+	Until     time.Time `json:"until"`
+}
+
+// LevelControl holds the runtime log level changes, and reverts them once they expire.
+type LevelControl struct {
+	now func() time.Time
+
+	// hasChanges allows to skip the lock when there's no change.
+	hasChanges *atomic.Bool
+
+	mtx     sync.Mutex
+	changes map[string]LevelChange
+}
+
+// NewLevelControl makes a new LevelControl without changes.
+func NewLevelControl(now func() time.Time) *LevelControl {
+	return &LevelControl{
+		now:        now,
+		hasChanges: atomic.NewBool(false),
+		changes:    map[string]LevelChange{},
+	}
+}
+
+// SetLevel adds or replaces the level change of a component, or the global one if the component is empty.
+func (c *LevelControl) SetLevel(change LevelChange) error {
+	if _, ok := levelRanks[change.Level]; !ok {
+		return fmt.Errorf("invalid log level %q", change.Level)
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.changes[change.Component] = change
+	c.hasChanges.Store(true)
+	return nil
+}
+
+// ResetLevel reverts the level change of a component, or the global one if the component is empty.
+func (c *LevelControl) ResetLevel(component string) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	delete(c.changes, component)
+	c.hasChanges.Store(len(c.changes) > 0)
+}
+
+// Levels returns the level changes that haven't expired yet.
+func (c *LevelControl) Levels() []LevelChange {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.purgeExpired()
+
+	res := make([]LevelChange, 0, len(c.changes))
+	for _, change := range c.changes {
+		res = append(res, change)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Component < res[j].Component
+	})
+	return res
+}
+
+// levelRank returns the rank of the level of the component, if changed. The change of the
+// component takes precedence over the global one.
+func (c *LevelControl) levelRank(component string) (int, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.purgeExpired()
+
+	if change, ok := c.changes[component]; ok {
+		return levelRanks[change.Level], true
+	}
+	if change, ok := c.changes[""]; ok {
+		return levelRanks[change.Level], true
+	}
+	return 0, false
+}
+
+// purgeExpired must be called with the lock held.
+func (c *LevelControl) purgeExpired() {
+	now := c.now()
+	for component, change := range c.changes {
+		if !now.Before(change.Until) {
+			delete(c.changes, component)
+		}
+	}
+	c.hasChanges.Store(len(c.changes) > 0)
+}
+
+// ServeHTTP lists (GET), changes (POST) or reverts (DELETE) the log levels. The optional "component"
+// parameter selects the log lines of a single component, otherwise the global level is changed.
+// When changing a level, the "level" parameter is required, and the "duration" parameter sets
+// after how long the change is reverted (default 10m, max 1h).
+func (c *LevelControl) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	component := r.FormValue("component")
+
+	switch r.Method {
+	case http.MethodGet:
+		// Nothing to do, the levels are listed below.
+
+	case http.MethodPost:
+		change := LevelChange{Component: component, Level: r.FormValue("level")}
+
+		duration, err := parseOverrideDuration(r.FormValue("duration"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		change.Until = c.now().Add(duration)
+
+		if err := c.SetLevel(change); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+	case http.MethodDelete:
+		c.ResetLevel(component)
+
+	default:
+		http.Error(w, "unsupported method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(c.Levels()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
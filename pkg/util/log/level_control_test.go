@@ -0,0 +1,117 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/logging"
+)
+
+func TestLevelControl_Filter(t *testing.T) {
+	now := time.Now()
+	levels := NewLevelControl(func() time.Time { return now })
+
+	var lvl logging.Level
+	require.NoError(t, lvl.Set("info"))
+	buf := &bytes.Buffer{}
+	logger := newTenantFilter(log.NewLogfmtLogger(buf), lvl, levels, NewTenantLogsControl(time.Now))
+
+	logAll := func() []string {
+		buf.Reset()
+		level.Debug(logger).Log("msg", "debug")
+		level.Info(logger).Log("msg", "info")
+		level.Debug(log.With(logger, componentKey, "compactor")).Log("msg", "compactor-debug")
+		level.Info(log.With(logger, componentKey, "compactor")).Log("msg", "compactor-info")
+
+		var msgs []string
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			if line != "" {
+				msgs = append(msgs, line[strings.Index(line, "msg=")+4:])
+			}
+		}
+		return msgs
+	}
+
+	assert.Equal(t, []string{"info", "compactor-info"}, logAll())
+
+	require.NoError(t, levels.SetLevel(LevelChange{Level: "debug", Until: now.Add(time.Minute)}))
+	assert.Equal(t, []string{"debug", "info", "compactor-debug", "compactor-info"}, logAll())
+
+	// The level of the component takes precedence over the global one.
+	require.NoError(t, levels.SetLevel(LevelChange{Component: "compactor", Level: "warn", Until: now.Add(2 * time.Minute)}))
+	assert.Equal(t, []string{"debug", "info"}, logAll())
+
+	// The global level is reverted first.
+	now = now.Add(time.Minute)
+	assert.Equal(t, []string{"info"}, logAll())
+	assert.Len(t, levels.Levels(), 1)
+
+	levels.ResetLevel("compactor")
+	assert.Equal(t, []string{"info", "compactor-info"}, logAll())
+	assert.Empty(t, levels.Levels())
+
+	assert.Error(t, levels.SetLevel(LevelChange{Level: "verbose"}))
+}
+
+func TestLevelControl_ServeHTTP(t *testing.T) {
+	now := time.Now()
+	levels := NewLevelControl(func() time.Time { return now })
+
+	request := func(method string, params url.Values) (int, []LevelChange) {
+		req := httptest.NewRequest(method, "/log_level?"+params.Encode(), nil)
+		rec := httptest.NewRecorder()
+		levels.ServeHTTP(rec, req)
+
+		var changes []LevelChange
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &changes))
+		}
+		return rec.Code, changes
+	}
+
+	code, changes := request(http.MethodPost, url.Values{"level": {"debug"}})
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, changes, 1)
+	assert.Equal(t, "", changes[0].Component)
+	assert.Equal(t, "debug", changes[0].Level)
+	assert.True(t, now.Add(defaultOverrideDuration).Equal(changes[0].Until))
+
+	code, changes = request(http.MethodPost, url.Values{"component": {"compactor"}, "level": {"error"}, "duration": {"30m"}})
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, changes, 2)
+	assert.Equal(t, "compactor", changes[1].Component)
+	assert.True(t, now.Add(30*time.Minute).Equal(changes[1].Until))
+
+	code, changes = request(http.MethodGet, nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Len(t, changes, 2)
+
+	code, changes = request(http.MethodDelete, nil)
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, changes, 1)
+	assert.Equal(t, "compactor", changes[0].Component)
+
+	for name, params := range map[string]url.Values{
+		"missing level":     {"component": {"compactor"}},
+		"invalid level":     {"level": {"verbose"}},
+		"invalid duration":  {"level": {"debug"}, "duration": {"forever"}},
+		"too long duration": {"level": {"debug"}, "duration": {"2h"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			code, _ := request(http.MethodPost, params)
+			assert.Equal(t, http.StatusBadRequest, code)
+		})
+	}
+}
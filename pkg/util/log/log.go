@@ -36,7 +36,9 @@ func InitLogger(cfg *server.Config) {
 	cfg.Log = logging.GoKit(log.With(l, "caller", log.Caller(4)))
 }
 
-// NewDefaultLogger creates a new gokit logger with the configured level and format
+// NewDefaultLogger creates a new gokit logger with the configured level and format,
+// honoring the runtime log level changes of LogLevels and the per-tenant log level overrides
+// and rate limits of TenantLogs.
 func NewDefaultLogger(l logging.Level, format logging.Format) log.Logger {
 	var logger log.Logger
 	if format.String() == "json" {
@@ -46,7 +48,7 @@ func NewDefaultLogger(l logging.Level, format logging.Format) log.Logger {
 	}
 
 	// return a Logger without caller information, shouldn't use directly
-	return log.With(level.NewFilter(logger, l.Gokit), "ts", log.DefaultTimestampUTC)
+	return log.With(newTenantFilter(logger, l, LogLevels, TenantLogs), "ts", log.DefaultTimestampUTC)
 }
 
 // CheckFatal prints an error and exits with error code 1 if err is non-nil
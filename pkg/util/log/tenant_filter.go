@@ -0,0 +1,340 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/weaveworks/common/logging"
+	"go.uber.org/atomic"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// tenantKey and traceIDKey are the keys added to the log lines by WithUserID and WithTraceID.
+	tenantKey  = "org_id"
+	traceIDKey = "traceID"
+
+	defaultOverrideDuration = 10 * time.Minute
+	maxOverrideDuration     = time.Hour
+
+	// idleLimiterTimeout is the period after which the rate limiter of a tenant that hasn't logged is removed.
+	idleLimiterTimeout = time.Minute
+)
+
+var (
+	// TenantLogs holds the per-tenant log level overrides and rate limits applied by the loggers
+	// created with NewDefaultLogger.
+	TenantLogs = NewTenantLogsControl(time.Now)
+
+	discardedLogLines = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "cortex_log_lines_discarded_total",
+		Help: "Total number of log lines discarded because a tenant exceeded the per-tenant log rate limit.",
+	})
+)
+
+// levelRanks orders the log levels from the most to the least verbose.
+var levelRanks = map[string]int{
+	"debug": 0,
+	"info":  1,
+	"warn":  2,
+	"error": 3,
+}
+
+// LogLevelOverride lowers the minimum level of the log lines of a single tenant or trace until it expires.
+type LogLevelOverride struct {
+	Tenant  string    `json:"tenant,omitempty"`
+	TraceID string    `json:"trace_id,omitempty"`
+	Level   string    `json:"level"`
+	Until   time.Time `json:"until"`
+}
+
+type overrideKey struct {
+	tenant  string
+	traceID string
+}
+
+type tenantLimiter struct {
+	limiter  *rate.Limiter
+	lastUsed time.Time
+}
+
+// TenantLogsControl holds the log level overrides of tenants and traces, and the per-tenant log rate limits.
+type TenantLogsControl struct {
+	now func() time.Time
+
+	// hasOverrides and rateLimited allow to skip the lock when there's nothing to do.
+	hasOverrides *atomic.Bool
+	rateLimited  *atomic.Bool
+
+	mtx       sync.Mutex
+	overrides map[overrideKey]LogLevelOverride
+	limit     rate.Limit
+	burst     int
+	limiters  map[string]*tenantLimiter
+	lastPurge time.Time
+}
+
+// NewTenantLogsControl makes a new TenantLogsControl without overrides nor rate limits.
+func NewTenantLogsControl(now func() time.Time) *TenantLogsControl {
+	return &TenantLogsControl{
+		now:          now,
+		hasOverrides: atomic.NewBool(false),
+		rateLimited:  atomic.NewBool(false),
+		overrides:    map[overrideKey]LogLevelOverride{},
+		limiters:     map[string]*tenantLimiter{},
+		lastPurge:    now(),
+	}
+}
+
+// SetRateLimit sets the maximum number of log lines per second, and the burst, allowed for each tenant.
+// A limit of 0 disables the rate limiting.
+func (c *TenantLogsControl) SetRateLimit(limit float64, burst int) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.limit = rate.Limit(limit)
+	c.burst = burst
+	c.limiters = map[string]*tenantLimiter{}
+	c.rateLimited.Store(limit > 0)
+}
+
+// SetOverride adds or replaces the log level override of a tenant or trace.
+func (c *TenantLogsControl) SetOverride(o LogLevelOverride) error {
+	if (o.Tenant == "") == (o.TraceID == "") {
+		return fmt.Errorf("exactly one of tenant and trace ID must be set")
+	}
+	if _, ok := levelRanks[o.Level]; !ok {
+		return fmt.Errorf("invalid log level %q", o.Level)
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.overrides[overrideKey{tenant: o.Tenant, traceID: o.TraceID}] = o
+	c.hasOverrides.Store(true)
+	return nil
+}
+
+// DeleteOverride removes the log level override of a tenant or trace, if any.
+func (c *TenantLogsControl) DeleteOverride(tenant, traceID string) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	delete(c.overrides, overrideKey{tenant: tenant, traceID: traceID})
+	c.hasOverrides.Store(len(c.overrides) > 0)
+}
+
+// Overrides returns the log level overrides that haven't expired yet.
+func (c *TenantLogsControl) Overrides() []LogLevelOverride {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.purgeExpiredOverrides()
+
+	res := make([]LogLevelOverride, 0, len(c.overrides))
+	for _, o := range c.overrides {
+		res = append(res, o)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Tenant != res[j].Tenant {
+			return res[i].Tenant < res[j].Tenant
+		}
+		return res[i].TraceID < res[j].TraceID
+	})
+	return res
+}
+
+// overrideRank returns the rank of the minimum log level of the most verbose override
+// matching the tenant or the trace, if any.
+func (c *TenantLogsControl) overrideRank(tenant, traceID string) (int, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.purgeExpiredOverrides()
+
+	rank, found := 0, false
+	for _, key := range []overrideKey{{tenant: tenant}, {traceID: traceID}} {
+		if key.tenant == "" && key.traceID == "" {
+			continue
+		}
+		if o, ok := c.overrides[key]; ok {
+			if r := levelRanks[o.Level]; !found || r < rank {
+				rank, found = r, true
+			}
+		}
+	}
+	return rank, found
+}
+
+// purgeExpiredOverrides must be called with the lock held.
+func (c *TenantLogsControl) purgeExpiredOverrides() {
+	now := c.now()
+	for key, o := range c.overrides {
+		if !now.Before(o.Until) {
+			delete(c.overrides, key)
+		}
+	}
+	c.hasOverrides.Store(len(c.overrides) > 0)
+}
+
+// allowTenant returns whether the tenant hasn't exceeded its log rate limit.
+func (c *TenantLogsControl) allowTenant(tenant string) bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.limit <= 0 {
+		return true
+	}
+
+	now := c.now()
+	if now.Sub(c.lastPurge) >= idleLimiterTimeout {
+		for t, l := range c.limiters {
+			if now.Sub(l.lastUsed) >= idleLimiterTimeout {
+				delete(c.limiters, t)
+			}
+		}
+		c.lastPurge = now
+	}
+
+	l, ok := c.limiters[tenant]
+	if !ok {
+		l = &tenantLimiter{limiter: rate.NewLimiter(c.limit, c.burst)}
+		c.limiters[tenant] = l
+	}
+	l.lastUsed = now
+	return l.limiter.AllowN(now, 1)
+}
+
+// ServeHTTP lists (GET), adds (POST) or removes (DELETE) the log level overrides.
+// The override is selected with the "tenant" or "trace_id" parameter. When adding an override,
+// the "level" parameter sets the minimum log level (default debug) and the "duration" parameter
+// sets for how long the override applies (default 10m, max 1h).
+func (c *TenantLogsControl) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	tenant, traceID := r.FormValue("tenant"), r.FormValue("trace_id")
+
+	switch r.Method {
+	case http.MethodGet:
+		// Nothing to do, the overrides are listed below.
+
+	case http.MethodPost:
+		o := LogLevelOverride{Tenant: tenant, TraceID: traceID, Level: r.FormValue("level")}
+		if o.Level == "" {
+			o.Level = "debug"
+		}
+
+		duration, err := parseOverrideDuration(r.FormValue("duration"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		o.Until = c.now().Add(duration)
+
+		if err := c.SetOverride(o); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+	case http.MethodDelete:
+		if (tenant == "") == (traceID == "") {
+			http.Error(w, "exactly one of tenant and trace ID must be set", http.StatusBadRequest)
+			return
+		}
+		c.DeleteOverride(tenant, traceID)
+
+	default:
+		http.Error(w, "unsupported method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(c.Overrides()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// parseOverrideDuration parses the duration of an override set through the HTTP endpoints,
+// returning the default one if empty.
+func parseOverrideDuration(d string) (time.Duration, error) {
+	if d == "" {
+		return defaultOverrideDuration, nil
+	}
+	duration, err := time.ParseDuration(d)
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("invalid duration %q", d)
+	}
+	if duration > maxOverrideDuration {
+		return 0, fmt.Errorf("duration can't be longer than %s", maxOverrideDuration)
+	}
+	return duration, nil
+}
+
+// tenantFilter is a log.Logger only passing the log lines at or above the minimum log level,
+// as changed at runtime for the component, or the level of a matching override, and dropping
+// the log lines of the tenants exceeding their rate limit.
+type tenantFilter struct {
+	next     log.Logger
+	minRank  int
+	levels   *LevelControl
+	controls *TenantLogsControl
+}
+
+func newTenantFilter(next log.Logger, minLevel logging.Level, levels *LevelControl, controls *TenantLogsControl) log.Logger {
+	minRank, ok := levelRanks[minLevel.String()]
+	if !ok {
+		minRank = levelRanks["info"]
+	}
+	return &tenantFilter{next: next, minRank: minRank, levels: levels, controls: controls}
+}
+
+func (f *tenantFilter) Log(keyvals ...interface{}) error {
+	rank, hasLevel := 0, false
+	tenant, traceID, component := "", "", ""
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		switch keyvals[i] {
+		case level.Key():
+			if v, ok := keyvals[i+1].(level.Value); ok {
+				rank, hasLevel = levelRanks[v.String()]
+			}
+		case tenantKey:
+			tenant, _ = keyvals[i+1].(string)
+		case traceIDKey:
+			traceID, _ = keyvals[i+1].(string)
+		case componentKey:
+			component, _ = keyvals[i+1].(string)
+		}
+	}
+
+	minRank := f.minRank
+	if f.levels.hasChanges.Load() {
+		if r, ok := f.levels.levelRank(component); ok {
+			minRank = r
+		}
+	}
+
+	// Like level.NewFilter, the log lines without a level are always passed.
+	if hasLevel && rank < minRank {
+		if !f.controls.hasOverrides.Load() {
+			return nil
+		}
+		if overrideRank, ok := f.controls.overrideRank(tenant, traceID); !ok || rank < overrideRank {
+			return nil
+		}
+	}
+
+	if tenant != "" && f.controls.rateLimited.Load() && !f.controls.allowTenant(tenant) {
+		discardedLogLines.Inc()
+		return nil
+	}
+
+	return f.next.Log(keyvals...)
+}
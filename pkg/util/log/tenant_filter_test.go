@@ -0,0 +1,158 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/logging"
+)
+
+func newTestTenantFilter(t *testing.T, minLevel string, now func() time.Time) (log.Logger, *TenantLogsControl, *bytes.Buffer) {
+	var lvl logging.Level
+	require.NoError(t, lvl.Set(minLevel))
+
+	buf := &bytes.Buffer{}
+	controls := NewTenantLogsControl(now)
+	return newTenantFilter(log.NewLogfmtLogger(buf), lvl, NewLevelControl(now), controls), controls, buf
+}
+
+func TestTenantFilter_Overrides(t *testing.T) {
+	now := time.Now()
+	logger, controls, buf := newTestTenantFilter(t, "info", func() time.Time { return now })
+
+	logAll := func() []string {
+		buf.Reset()
+		level.Debug(log.With(logger, tenantKey, "user-1")).Log("msg", "user-1")
+		level.Debug(log.With(logger, tenantKey, "user-2")).Log("msg", "user-2")
+		level.Debug(log.With(logger, traceIDKey, "trace-1")).Log("msg", "trace-1")
+		level.Info(log.With(logger, tenantKey, "user-2")).Log("msg", "info")
+		logger.Log("msg", "no level")
+
+		var msgs []string
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			msgs = append(msgs, line[strings.Index(line, "msg=")+4:])
+		}
+		return msgs
+	}
+
+	assert.Equal(t, []string{"info", `"no level"`}, logAll())
+
+	require.NoError(t, controls.SetOverride(LogLevelOverride{Tenant: "user-1", Level: "debug", Until: now.Add(time.Minute)}))
+	require.NoError(t, controls.SetOverride(LogLevelOverride{TraceID: "trace-1", Level: "debug", Until: now.Add(2 * time.Minute)}))
+	assert.Equal(t, []string{"user-1", "trace-1", "info", `"no level"`}, logAll())
+
+	// The override of user-1 expires first.
+	now = now.Add(time.Minute)
+	assert.Equal(t, []string{"trace-1", "info", `"no level"`}, logAll())
+	assert.Len(t, controls.Overrides(), 1)
+
+	controls.DeleteOverride("", "trace-1")
+	assert.Equal(t, []string{"info", `"no level"`}, logAll())
+	assert.Empty(t, controls.Overrides())
+
+	assert.Error(t, controls.SetOverride(LogLevelOverride{Level: "debug"}))
+	assert.Error(t, controls.SetOverride(LogLevelOverride{Tenant: "user-1", TraceID: "trace-1", Level: "debug"}))
+	assert.Error(t, controls.SetOverride(LogLevelOverride{Tenant: "user-1", Level: "verbose"}))
+}
+
+func TestTenantFilter_RateLimit(t *testing.T) {
+	now := time.Now()
+	logger, controls, buf := newTestTenantFilter(t, "info", func() time.Time { return now })
+	controls.SetRateLimit(1, 2)
+
+	countLines := func(tenant string, n int) int {
+		buf.Reset()
+		for i := 0; i < n; i++ {
+			level.Info(log.With(logger, tenantKey, tenant)).Log("msg", "hello")
+		}
+		return strings.Count(buf.String(), "\n")
+	}
+
+	assert.Equal(t, 2, countLines("user-1", 10))
+	assert.Equal(t, 2, countLines("user-2", 10))
+	assert.Equal(t, 0, countLines("user-1", 10))
+
+	// The log lines without a tenant are never rate limited.
+	buf.Reset()
+	for i := 0; i < 10; i++ {
+		level.Info(logger).Log("msg", "hello")
+	}
+	assert.Equal(t, 10, strings.Count(buf.String(), "\n"))
+
+	now = now.Add(time.Second)
+	assert.Equal(t, 1, countLines("user-1", 10))
+
+	// The idle limiters are removed.
+	now = now.Add(idleLimiterTimeout)
+	assert.Equal(t, 2, countLines("user-1", 10))
+	controls.mtx.Lock()
+	assert.Len(t, controls.limiters, 1)
+	controls.mtx.Unlock()
+
+	controls.SetRateLimit(0, 0)
+	assert.Equal(t, 10, countLines("user-1", 10))
+}
+
+func TestTenantLogsControl_ServeHTTP(t *testing.T) {
+	now := time.Now()
+	controls := NewTenantLogsControl(func() time.Time { return now })
+
+	request := func(method string, params url.Values) (int, []LogLevelOverride) {
+		req := httptest.NewRequest(method, "/log/overrides?"+params.Encode(), nil)
+		rec := httptest.NewRecorder()
+		controls.ServeHTTP(rec, req)
+
+		var overrides []LogLevelOverride
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &overrides))
+		}
+		return rec.Code, overrides
+	}
+
+	code, overrides := request(http.MethodPost, url.Values{"tenant": {"user-1"}})
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, overrides, 1)
+	assert.Equal(t, "user-1", overrides[0].Tenant)
+	assert.Equal(t, "debug", overrides[0].Level)
+	assert.True(t, now.Add(defaultOverrideDuration).Equal(overrides[0].Until))
+
+	code, overrides = request(http.MethodPost, url.Values{"trace_id": {"trace-1"}, "level": {"warn"}, "duration": {"1m"}})
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, overrides, 2)
+	assert.Equal(t, LogLevelOverride{TraceID: "trace-1", Level: "warn", Until: overrides[0].Until}, overrides[0])
+	assert.True(t, now.Add(time.Minute).Equal(overrides[0].Until))
+
+	code, overrides = request(http.MethodGet, nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Len(t, overrides, 2)
+
+	code, overrides = request(http.MethodDelete, url.Values{"tenant": {"user-1"}})
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, overrides, 1)
+	assert.Equal(t, "trace-1", overrides[0].TraceID)
+
+	for name, params := range map[string]url.Values{
+		"missing tenant and trace ID": {"level": {"debug"}},
+		"invalid level":               {"tenant": {"user-1"}, "level": {"verbose"}},
+		"invalid duration":            {"tenant": {"user-1"}, "duration": {"forever"}},
+		"negative duration":           {"tenant": {"user-1"}, "duration": {"-1m"}},
+		"too long duration":           {"tenant": {"user-1"}, "duration": {"2h"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			code, _ := request(http.MethodPost, params)
+			assert.Equal(t, http.StatusBadRequest, code)
+		})
+	}
+}
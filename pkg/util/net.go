@@ -8,6 +8,7 @@ package util
 import (
 	"fmt"
 	"net"
+	"strconv"
 	"strings"
 
 	"github.com/go-kit/log/level"
@@ -15,9 +16,44 @@ import (
 	util_log "github.com/grafana/mimir/pkg/util/log"
 )
 
-// GetFirstAddressOf returns the first IPv4 address of the supplied interface names, omitting any 169.254.x.x automatic private IPs if possible.
-func GetFirstAddressOf(names []string) (string, error) {
-	var ipAddr string
+const (
+	// AddrFamilyIPv4 and AddrFamilyIPv6 are the IP address families which can be preferred
+	// when auto-detecting the address of an instance.
+	AddrFamilyIPv4 = "ipv4"
+	AddrFamilyIPv6 = "ipv6"
+)
+
+// AddrFamilyUsage describes the supported IP address families, to be used in flags descriptions.
+const AddrFamilyUsage = "Supported values are: " + AddrFamilyIPv4 + ", " + AddrFamilyIPv6 + ". If the network interfaces have no address of the preferred family, an address of the other family is used."
+
+// ValidateAddrFamily returns an error if the input IP address family is not supported.
+func ValidateAddrFamily(family string) error {
+	if family != AddrFamilyIPv4 && family != AddrFamilyIPv6 {
+		return fmt.Errorf("unsupported IP address family: %q", family)
+	}
+	return nil
+}
+
+// GetInstanceAddr returns the configured address if not empty, otherwise the first address
+// of the supplied interface names, preferring the input IP address family.
+func GetInstanceAddr(configAddr string, names []string, family string) (string, error) {
+	if configAddr != "" {
+		return configAddr, nil
+	}
+	return GetFirstAddressOf(names, family)
+}
+
+// GetFirstAddressOf returns the first address of the supplied interface names of the preferred IP address family,
+// falling back to the other family if no address of the preferred one is found. An empty family prefers IPv4.
+// Link-local addresses (169.254.x.x automatic private IPs and fe80::/10) are omitted if possible.
+func GetFirstAddressOf(names []string, family string) (string, error) {
+	if family != "" {
+		if err := ValidateAddrFamily(family); err != nil {
+			return "", err
+		}
+	}
+
+	var infAddrs [][]net.Addr
 	for _, name := range names {
 		inf, err := net.InterfaceByName(name)
 		if err != nil {
@@ -33,35 +69,81 @@ func GetFirstAddressOf(names []string) (string, error) {
 			level.Warn(util_log.Logger).Log("msg", "no addresses found for interface", "inf", name, "err", err)
 			continue
 		}
-		if ip := filterIPs(addrs); ip != "" {
-			ipAddr = ip
-		}
-		if strings.HasPrefix(ipAddr, `169.254.`) || ipAddr == "" {
-			continue
-		}
-		return ipAddr, nil
+		infAddrs = append(infAddrs, addrs)
+	}
+
+	preferIPv6 := family == AddrFamilyIPv6
+	ipAddr := firstAddressOf(infAddrs, preferIPv6)
+	if ipAddr == "" {
+		ipAddr = firstAddressOf(infAddrs, !preferIPv6)
 	}
 	if ipAddr == "" {
 		return "", fmt.Errorf("No address found for %s", names)
 	}
-	if strings.HasPrefix(ipAddr, `169.254.`) {
-		level.Warn(util_log.Logger).Log("msg", "using automatic private ip", "address", ipAddr)
+	if isLinkLocal(ipAddr) {
+		level.Warn(util_log.Logger).Log("msg", "using link-local ip", "address", ipAddr)
 	}
 	return ipAddr, nil
 }
 
-// filterIPs attempts to return the first non automatic private IP (APIPA / 169.254.x.x) if possible, only returning APIPA if available and no other valid IP is found.
-func filterIPs(addrs []net.Addr) string {
+// firstAddressOf returns the first non link-local address of the input family, falling back
+// to the link-local address of the last interface having one.
+func firstAddressOf(infAddrs [][]net.Addr, ipv6 bool) string {
+	var ipAddr string
+	for _, addrs := range infAddrs {
+		if ip := filterIPs(addrs, ipv6); ip != "" {
+			ipAddr = ip
+		}
+		if ipAddr != "" && !isLinkLocal(ipAddr) {
+			return ipAddr
+		}
+	}
+	return ipAddr
+}
+
+// filterIPs attempts to return the first non link-local IP of the input family if possible, only returning a link-local IP if available and no other valid IP is found.
+func filterIPs(addrs []net.Addr, ipv6 bool) string {
 	var ipAddr string
 	for _, addr := range addrs {
 		if v, ok := addr.(*net.IPNet); ok {
-			if ip := v.IP.To4(); ip != nil {
-				ipAddr = v.IP.String()
-				if !strings.HasPrefix(ipAddr, `169.254.`) {
-					return ipAddr
-				}
+			if (v.IP.To4() == nil) != ipv6 {
+				continue
+			}
+			ipAddr = v.IP.String()
+			if !v.IP.IsLinkLocalUnicast() {
+				return ipAddr
 			}
 		}
 	}
 	return ipAddr
 }
+
+func isLinkLocal(addr string) bool {
+	ip := net.ParseIP(addr)
+	return ip != nil && ip.IsLinkLocalUnicast()
+}
+
+// JoinHostPort combines the host and port into an address of the form "host:port",
+// enclosing the host in square brackets if it's an IPv6 address.
+func JoinHostPort(host string, port int) string {
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+// LifecyclerAddr returns the address to configure in the dskit ring.Lifecycler, which joins
+// the address and the port without enclosing IPv6 addresses in square brackets.
+func LifecyclerAddr(addr string) string {
+	if ip := net.ParseIP(addr); ip != nil && ip.To4() == nil {
+		return "[" + addr + "]"
+	}
+	return addr
+}
+
+// normalizeHostPort encloses the host of a "host:port" address in square brackets if it's
+// an IPv6 address joined to the port without them.
+func normalizeHostPort(addr string) string {
+	if strings.HasPrefix(addr, "[") || strings.Count(addr, ":") < 2 {
+		return addr
+	}
+	i := strings.LastIndex(addr, ":")
+	return net.JoinHostPort(addr[:i], addr[i+1:])
+}
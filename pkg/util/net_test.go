@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package util
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFirstAddressOf(t *testing.T) {
+	mustParseCIDRs := func(cidrs ...string) []net.Addr {
+		addrs := make([]net.Addr, 0, len(cidrs))
+		for _, cidr := range cidrs {
+			ip, ipNet, err := net.ParseCIDR(cidr)
+			require.NoError(t, err)
+			ipNet.IP = ip
+			addrs = append(addrs, ipNet)
+		}
+		return addrs
+	}
+
+	tests := map[string]struct {
+		infAddrs     [][]net.Addr
+		expectedIPv4 string
+		expectedIPv6 string
+	}{
+		"no addresses": {
+			infAddrs: nil,
+		},
+		"dual-stack interface": {
+			infAddrs:     [][]net.Addr{mustParseCIDRs("fe80::1/64", "10.0.0.1/8", "fd00::1/64")},
+			expectedIPv4: "10.0.0.1",
+			expectedIPv6: "fd00::1",
+		},
+		"IPv6-only interface": {
+			infAddrs:     [][]net.Addr{mustParseCIDRs("fe80::1/64", "2001:db8::1/64")},
+			expectedIPv6: "2001:db8::1",
+		},
+		"link-local addresses are used only if there's no other address": {
+			infAddrs:     [][]net.Addr{mustParseCIDRs("169.254.0.1/16", "fe80::1/64"), mustParseCIDRs("10.0.0.1/8")},
+			expectedIPv4: "10.0.0.1",
+			expectedIPv6: "fe80::1",
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			assert.Equal(t, testData.expectedIPv4, firstAddressOf(testData.infAddrs, false))
+			assert.Equal(t, testData.expectedIPv6, firstAddressOf(testData.infAddrs, true))
+		})
+	}
+}
+
+func TestValidateAddrFamily(t *testing.T) {
+	assert.NoError(t, ValidateAddrFamily(AddrFamilyIPv4))
+	assert.NoError(t, ValidateAddrFamily(AddrFamilyIPv6))
+	assert.Error(t, ValidateAddrFamily(""))
+	assert.Error(t, ValidateAddrFamily("ipv5"))
+}
+
+func TestJoinHostPort(t *testing.T) {
+	assert.Equal(t, "10.0.0.1:9095", JoinHostPort("10.0.0.1", 9095))
+	assert.Equal(t, "[2001:db8::1]:9095", JoinHostPort("2001:db8::1", 9095))
+	assert.Equal(t, "localhost:9095", JoinHostPort("localhost", 9095))
+}
+
+func TestLifecyclerAddr(t *testing.T) {
+	assert.Equal(t, "10.0.0.1", LifecyclerAddr("10.0.0.1"))
+	assert.Equal(t, "[2001:db8::1]", LifecyclerAddr("2001:db8::1"))
+	assert.Equal(t, "localhost", LifecyclerAddr("localhost"))
+}
+
+func TestNormalizeHostPort(t *testing.T) {
+	assert.Equal(t, "10.0.0.1:9095", normalizeHostPort("10.0.0.1:9095"))
+	assert.Equal(t, "localhost:9095", normalizeHostPort("localhost:9095"))
+	assert.Equal(t, "[2001:db8::1]:9095", normalizeHostPort("2001:db8::1:9095"))
+	assert.Equal(t, "[2001:db8::1]:9095", normalizeHostPort("[2001:db8::1]:9095"))
+}
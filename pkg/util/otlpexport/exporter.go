@@ -0,0 +1,184 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package otlpexport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/services"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/mimir/pkg/util/version"
+)
+
+const (
+	serviceName = "mimir"
+	scopeName   = "github.com/grafana/mimir"
+
+	// Max size of the response body read to report a failed export.
+	maxErrorResponseBytes = 1024
+)
+
+var (
+	errInvalidEndpoint = errors.New("the OTLP metrics export endpoint must be an http or https URL")
+	errInvalidInterval = errors.New("the OTLP metrics export interval must be greater than 0")
+)
+
+// Config configures the export of the internal metrics to an OTLP endpoint.
+type Config struct {
+	Endpoint string        `yaml:"endpoint" category:"experimental"`
+	Interval time.Duration `yaml:"interval" category:"experimental"`
+	Timeout  time.Duration `yaml:"timeout" category:"experimental"`
+}
+
+// RegisterFlags registers the OTLP metrics export flags.
+func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
+	f.StringVar(&cfg.Endpoint, "otlp-metrics-export.endpoint", "", "URL of the OTLP/HTTP endpoint the internal metrics are pushed to, in addition to being exposed on /metrics. The metrics are encoded in JSON, for example to http://otel-collector:4318/v1/metrics. If empty, the metrics are not pushed.")
+	f.DurationVar(&cfg.Interval, "otlp-metrics-export.interval", 15*time.Second, "How often the internal metrics are pushed to the OTLP endpoint.")
+	f.DurationVar(&cfg.Timeout, "otlp-metrics-export.timeout", 10*time.Second, "Timeout of the requests pushing the internal metrics to the OTLP endpoint.")
+}
+
+// Validate the config.
+func (cfg *Config) Validate() error {
+	if cfg.Endpoint == "" {
+		return nil
+	}
+	if u, err := url.Parse(cfg.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errInvalidEndpoint
+	}
+	if cfg.Interval <= 0 {
+		return errInvalidInterval
+	}
+	return nil
+}
+
+// Exporter periodically pushes the metrics of a prometheus.Gatherer to an OTLP/HTTP endpoint, for the
+// meta-monitoring pipelines based on the OpenTelemetry collector. The counters are pushed as cumulative
+// sums since the exporter has been created.
+type Exporter struct {
+	services.Service
+
+	cfg       Config
+	gatherer  prometheus.Gatherer
+	client    *http.Client
+	resource  resource
+	startTime time.Time
+	logger    log.Logger
+
+	exports       prometheus.Counter
+	exportsFailed prometheus.Counter
+}
+
+// NewExporter makes a new Exporter. The targets are the components run by the process, added to the
+// attributes of the exported resource.
+func NewExporter(cfg Config, targets []string, gatherer prometheus.Gatherer, logger log.Logger, reg prometheus.Registerer) *Exporter {
+	hostname, err := os.Hostname()
+	if err != nil {
+		level.Warn(logger).Log("msg", "failed to get hostname", "err", err)
+	}
+
+	e := &Exporter{
+		cfg:      cfg,
+		gatherer: gatherer,
+		client:   &http.Client{Timeout: cfg.Timeout},
+		resource: resource{Attributes: []keyValue{
+			{Key: "service.name", Value: anyValue{StringValue: serviceName}},
+			{Key: "service.instance.id", Value: anyValue{StringValue: hostname}},
+			{Key: "service.version", Value: anyValue{StringValue: version.Version}},
+			{Key: "mimir.target", Value: anyValue{StringValue: strings.Join(targets, ",")}},
+		}},
+		startTime: time.Now(),
+		logger:    logger,
+
+		exports: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_otlp_metrics_exports_total",
+			Help: "Total number of pushes of the internal metrics to the OTLP endpoint.",
+		}),
+		exportsFailed: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_otlp_metrics_exports_failed_total",
+			Help: "Total number of failed pushes of the internal metrics to the OTLP endpoint.",
+		}),
+	}
+
+	e.Service = services.NewTimerService(cfg.Interval, nil, e.iteration, e.stopping).WithName("otlp metrics export")
+	return e
+}
+
+func (e *Exporter) iteration(ctx context.Context) error {
+	e.export(ctx)
+
+	// Failed exports are retried at the next interval, so they don't stop the service.
+	return nil
+}
+
+// stopping pushes the metrics a last time, so that the latest values aren't lost on shutdown.
+func (e *Exporter) stopping(_ error) error {
+	e.export(context.Background())
+	return nil
+}
+
+func (e *Exporter) export(ctx context.Context) {
+	e.exports.Inc()
+	if err := e.push(ctx, time.Now()); err != nil {
+		e.exportsFailed.Inc()
+		level.Warn(e.logger).Log("msg", "failed to push the internal metrics to the OTLP endpoint", "endpoint", e.cfg.Endpoint, "err", err)
+	}
+}
+
+func (e *Exporter) push(ctx context.Context, now time.Time) error {
+	families, err := e.gatherer.Gather()
+	if err != nil {
+		// Gather returns the metrics it has been able to gather along with the error, so they're still pushed.
+		level.Warn(e.logger).Log("msg", "failed to gather some of the internal metrics", "err", err)
+	}
+
+	body, err := json.Marshal(exportMetricsServiceRequest{
+		ResourceMetrics: []resourceMetrics{{
+			Resource: e.resource,
+			ScopeMetrics: []scopeMetrics{{
+				Scope:   instrumentationScope{Name: scopeName, Version: version.Version},
+				Metrics: convertMetricFamilies(families, e.startTime, now),
+			}},
+		}},
+	})
+	if err != nil {
+		return errors.Wrap(err, "encode metrics")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.cfg.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "mimir/"+version.Version)
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorResponseBytes))
+		return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+
+	// Drain the body so that the connection can be reused.
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	return nil
+}
@@ -0,0 +1,154 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package otlpexport
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	tests := map[string]struct {
+		cfg      Config
+		expected error
+	}{
+		"should pass if the export is disabled": {
+			cfg: Config{},
+		},
+		"should pass with an http endpoint": {
+			cfg: Config{Endpoint: "http://otel-collector:4318/v1/metrics", Interval: time.Second},
+		},
+		"should fail with an endpoint which is not an URL": {
+			cfg:      Config{Endpoint: "otel-collector:4318", Interval: time.Second},
+			expected: errInvalidEndpoint,
+		},
+		"should fail with a zero interval": {
+			cfg:      Config{Endpoint: "https://otel-collector:4318/v1/metrics"},
+			expected: errInvalidInterval,
+		},
+	}
+
+	for name, testData := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, testData.expected, testData.cfg.Validate())
+		})
+	}
+}
+
+func TestExporter_Push(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+		Name: "test_requests_total",
+		Help: "Total requests.",
+	}, []string{"status"}).WithLabelValues("200").Add(3)
+	promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+		Name: "test_inflight",
+		Help: "In-flight requests.",
+	}).Set(math.NaN())
+	hist := promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
+		Name:    "test_duration_seconds",
+		Help:    "Duration.",
+		Buckets: []float64{1, 2},
+	})
+	hist.Observe(0.5)
+	hist.Observe(1.5)
+	hist.Observe(5)
+	promauto.With(reg).NewSummary(prometheus.SummaryOpts{
+		Name:       "test_size_bytes",
+		Help:       "Size.",
+		Objectives: map[float64]float64{0.5: 0.05},
+	}).Observe(10)
+
+	var received []byte
+	var contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		received, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	startTime := time.Unix(100, 0)
+	now := time.Unix(200, 0)
+
+	e := NewExporter(Config{Endpoint: server.URL + "/v1/metrics", Interval: time.Hour, Timeout: time.Second}, []string{"ingester"}, reg, log.NewNopLogger(), nil)
+	e.startTime = startTime
+	require.NoError(t, e.push(context.Background(), now))
+	assert.Equal(t, "application/json", contentType)
+
+	var req struct {
+		ResourceMetrics []struct {
+			Resource struct {
+				Attributes []struct {
+					Key   string
+					Value struct{ StringValue string }
+				}
+			}
+			ScopeMetrics []struct {
+				Metrics []json.RawMessage
+			}
+		}
+	}
+	require.NoError(t, json.Unmarshal(received, &req))
+	require.Len(t, req.ResourceMetrics, 1)
+
+	attrs := map[string]string{}
+	for _, attr := range req.ResourceMetrics[0].Resource.Attributes {
+		attrs[attr.Key] = attr.Value.StringValue
+	}
+	assert.Equal(t, "mimir", attrs["service.name"])
+	assert.Equal(t, "ingester", attrs["mimir.target"])
+
+	require.Len(t, req.ResourceMetrics[0].ScopeMetrics, 1)
+	metrics := req.ResourceMetrics[0].ScopeMetrics[0].Metrics
+	require.Len(t, metrics, 4)
+
+	// The metric families are gathered sorted by name.
+	assert.JSONEq(t, `{"name":"test_duration_seconds","description":"Duration.","histogram":{"aggregationTemporality":2,"dataPoints":[
+		{"startTimeUnixNano":"100000000000","timeUnixNano":"200000000000","count":"3","sum":7,"bucketCounts":["1","1","1"],"explicitBounds":[1,2]}
+	]}}`, string(metrics[0]))
+	assert.JSONEq(t, `{"name":"test_inflight","description":"In-flight requests.","gauge":{"dataPoints":[
+		{"timeUnixNano":"200000000000","asDouble":"NaN"}
+	]}}`, string(metrics[1]))
+	assert.JSONEq(t, `{"name":"test_requests_total","description":"Total requests.","sum":{"aggregationTemporality":2,"isMonotonic":true,"dataPoints":[
+		{"attributes":[{"key":"status","value":{"stringValue":"200"}}],"startTimeUnixNano":"100000000000","timeUnixNano":"200000000000","asDouble":3}
+	]}}`, string(metrics[2]))
+	assert.JSONEq(t, `{"name":"test_size_bytes","description":"Size.","summary":{"dataPoints":[
+		{"startTimeUnixNano":"100000000000","timeUnixNano":"200000000000","count":"1","sum":10,"quantileValues":[{"quantile":0.5,"value":10}]}
+	]}}`, string(metrics[3]))
+}
+
+func TestExporter_ShouldTrackFailedPushes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	reg := prometheus.NewPedanticRegistry()
+	e := NewExporter(Config{Endpoint: server.URL, Interval: time.Hour, Timeout: time.Second}, []string{"all"}, reg, log.NewNopLogger(), reg)
+
+	require.EqualError(t, e.push(context.Background(), time.Now()), "unexpected status code 503: unavailable")
+
+	e.export(context.Background())
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_otlp_metrics_exports_failed_total Total number of failed pushes of the internal metrics to the OTLP endpoint.
+		# TYPE cortex_otlp_metrics_exports_failed_total counter
+		cortex_otlp_metrics_exports_failed_total 1
+		# HELP cortex_otlp_metrics_exports_total Total number of pushes of the internal metrics to the OTLP endpoint.
+		# TYPE cortex_otlp_metrics_exports_total counter
+		cortex_otlp_metrics_exports_total 1
+	`), "cortex_otlp_metrics_exports_total", "cortex_otlp_metrics_exports_failed_total"))
+}
@@ -0,0 +1,237 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package otlpexport
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// The types below are the subset of the OTLP metrics data model needed to export the Prometheus metrics,
+// encoded according to the OTLP/HTTP JSON encoding: the field names are in lowerCamelCase, the 64 bits
+// integers are encoded as strings, and the enums as integers.
+
+const aggregationTemporalityCumulative = 2
+
+type exportMetricsServiceRequest struct {
+	ResourceMetrics []resourceMetrics `json:"resourceMetrics"`
+}
+
+type resourceMetrics struct {
+	Resource     resource       `json:"resource"`
+	ScopeMetrics []scopeMetrics `json:"scopeMetrics"`
+}
+
+type resource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+type scopeMetrics struct {
+	Scope   instrumentationScope `json:"scope"`
+	Metrics []metric             `json:"metrics"`
+}
+
+type instrumentationScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type metric struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Gauge       *gauge     `json:"gauge,omitempty"`
+	Sum         *sum       `json:"sum,omitempty"`
+	Histogram   *histogram `json:"histogram,omitempty"`
+	Summary     *summary   `json:"summary,omitempty"`
+}
+
+type gauge struct {
+	DataPoints []numberDataPoint `json:"dataPoints"`
+}
+
+type sum struct {
+	DataPoints             []numberDataPoint `json:"dataPoints"`
+	AggregationTemporality int               `json:"aggregationTemporality"`
+	IsMonotonic            bool              `json:"isMonotonic"`
+}
+
+type histogram struct {
+	DataPoints             []histogramDataPoint `json:"dataPoints"`
+	AggregationTemporality int                  `json:"aggregationTemporality"`
+}
+
+type summary struct {
+	DataPoints []summaryDataPoint `json:"dataPoints"`
+}
+
+type numberDataPoint struct {
+	Attributes        []keyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano uint64Str  `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      uint64Str  `json:"timeUnixNano"`
+	AsDouble          double     `json:"asDouble"`
+}
+
+type histogramDataPoint struct {
+	Attributes        []keyValue  `json:"attributes,omitempty"`
+	StartTimeUnixNano uint64Str   `json:"startTimeUnixNano"`
+	TimeUnixNano      uint64Str   `json:"timeUnixNano"`
+	Count             uint64Str   `json:"count"`
+	Sum               double      `json:"sum"`
+	BucketCounts      []uint64Str `json:"bucketCounts"`
+	ExplicitBounds    []double    `json:"explicitBounds"`
+}
+
+type summaryDataPoint struct {
+	Attributes        []keyValue      `json:"attributes,omitempty"`
+	StartTimeUnixNano uint64Str       `json:"startTimeUnixNano"`
+	TimeUnixNano      uint64Str       `json:"timeUnixNano"`
+	Count             uint64Str       `json:"count"`
+	Sum               double          `json:"sum"`
+	QuantileValues    []quantileValue `json:"quantileValues"`
+}
+
+type quantileValue struct {
+	Quantile double `json:"quantile"`
+	Value    double `json:"value"`
+}
+
+// uint64Str is a 64 bits integer, encoded as a string.
+type uint64Str uint64
+
+func (v uint64Str) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strconv.FormatUint(uint64(v), 10))
+}
+
+// double is a float64 encoding the special values as strings, which encoding/json doesn't support.
+type double float64
+
+func (v double) MarshalJSON() ([]byte, error) {
+	f := float64(v)
+	switch {
+	case math.IsNaN(f):
+		return []byte(`"NaN"`), nil
+	case math.IsInf(f, 1):
+		return []byte(`"Infinity"`), nil
+	case math.IsInf(f, -1):
+		return []byte(`"-Infinity"`), nil
+	}
+	return json.Marshal(f)
+}
+
+// convertMetricFamilies converts the gathered Prometheus metric families to OTLP metrics. The counters are
+// converted to cumulative monotonic sums starting at startTime, and the untyped metrics to gauges.
+func convertMetricFamilies(families []*dto.MetricFamily, startTime, now time.Time) []metric {
+	start, ts := uint64Str(startTime.UnixNano()), uint64Str(now.UnixNano())
+
+	res := make([]metric, 0, len(families))
+	for _, mf := range families {
+		m := metric{Name: mf.GetName(), Description: mf.GetHelp()}
+
+		switch mf.GetType() {
+		case dto.MetricType_COUNTER:
+			m.Sum = &sum{AggregationTemporality: aggregationTemporalityCumulative, IsMonotonic: true}
+			for _, pm := range mf.GetMetric() {
+				m.Sum.DataPoints = append(m.Sum.DataPoints, numberDataPoint{
+					Attributes:        convertLabels(pm.GetLabel()),
+					StartTimeUnixNano: start,
+					TimeUnixNano:      ts,
+					AsDouble:          double(pm.GetCounter().GetValue()),
+				})
+			}
+
+		case dto.MetricType_GAUGE, dto.MetricType_UNTYPED:
+			m.Gauge = &gauge{}
+			for _, pm := range mf.GetMetric() {
+				value := pm.GetGauge().GetValue()
+				if mf.GetType() == dto.MetricType_UNTYPED {
+					value = pm.GetUntyped().GetValue()
+				}
+				m.Gauge.DataPoints = append(m.Gauge.DataPoints, numberDataPoint{
+					Attributes:   convertLabels(pm.GetLabel()),
+					TimeUnixNano: ts,
+					AsDouble:     double(value),
+				})
+			}
+
+		case dto.MetricType_HISTOGRAM:
+			m.Histogram = &histogram{AggregationTemporality: aggregationTemporalityCumulative}
+			for _, pm := range mf.GetMetric() {
+				m.Histogram.DataPoints = append(m.Histogram.DataPoints, convertHistogram(pm, start, ts))
+			}
+
+		case dto.MetricType_SUMMARY:
+			m.Summary = &summary{}
+			for _, pm := range mf.GetMetric() {
+				dp := summaryDataPoint{
+					Attributes:        convertLabels(pm.GetLabel()),
+					StartTimeUnixNano: start,
+					TimeUnixNano:      ts,
+					Count:             uint64Str(pm.GetSummary().GetSampleCount()),
+					Sum:               double(pm.GetSummary().GetSampleSum()),
+					QuantileValues:    []quantileValue{},
+				}
+				for _, q := range pm.GetSummary().GetQuantile() {
+					dp.QuantileValues = append(dp.QuantileValues, quantileValue{Quantile: double(q.GetQuantile()), Value: double(q.GetValue())})
+				}
+				m.Summary.DataPoints = append(m.Summary.DataPoints, dp)
+			}
+
+		default:
+			continue
+		}
+
+		res = append(res, m)
+	}
+	return res
+}
+
+// convertHistogram converts the cumulative buckets of a Prometheus histogram to the OTLP buckets, which
+// count the observations between two consecutive bounds, plus one for the observations above the last bound.
+func convertHistogram(pm *dto.Metric, start, ts uint64Str) histogramDataPoint {
+	h := pm.GetHistogram()
+	dp := histogramDataPoint{
+		Attributes:        convertLabels(pm.GetLabel()),
+		StartTimeUnixNano: start,
+		TimeUnixNano:      ts,
+		Count:             uint64Str(h.GetSampleCount()),
+		Sum:               double(h.GetSampleSum()),
+		BucketCounts:      []uint64Str{},
+		ExplicitBounds:    []double{},
+	}
+
+	prev := uint64(0)
+	for _, b := range h.GetBucket() {
+		if math.IsInf(b.GetUpperBound(), 1) {
+			break
+		}
+		dp.ExplicitBounds = append(dp.ExplicitBounds, double(b.GetUpperBound()))
+		dp.BucketCounts = append(dp.BucketCounts, uint64Str(b.GetCumulativeCount()-prev))
+		prev = b.GetCumulativeCount()
+	}
+	dp.BucketCounts = append(dp.BucketCounts, uint64Str(h.GetSampleCount()-prev))
+	return dp
+}
+
+func convertLabels(lbls []*dto.LabelPair) []keyValue {
+	if len(lbls) == 0 {
+		return nil
+	}
+	res := make([]keyValue, 0, len(lbls))
+	for _, l := range lbls {
+		res = append(res, keyValue{Key: l.GetName(), Value: anyValue{StringValue: l.GetValue()}})
+	}
+	return res
+}
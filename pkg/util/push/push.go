@@ -16,6 +16,8 @@ import (
 
 	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/globalerror"
+	"github.com/grafana/mimir/pkg/util/httpgrpcutil"
 	"github.com/grafana/mimir/pkg/util/log"
 )
 
@@ -43,6 +45,21 @@ func Handler(
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		logger := log.WithContext(ctx, log.Logger)
+
+		// Stop processing the request once the deadline budget set by the client is exhausted,
+		// since the client gives up on the request anyway.
+		if value := r.Header.Get(httpgrpcutil.DeadlineBudgetHeader); value != "" {
+			budget, err := httpgrpcutil.ParseDeadlineBudget(value)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, budget)
+			defer cancel()
+		}
+
 		if sourceIPs != nil {
 			source := sourceIPs.Get(r)
 			if source != "" {
@@ -82,12 +99,19 @@ func Handler(
 		if _, err := push(ctx, &req.WriteRequest, cleanup); err != nil {
 			resp, ok := httpgrpc.HTTPResponseFromError(err)
 			if !ok {
+				globalerror.SetHeader(w.Header(), err.Error())
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
 			}
-			if resp.GetCode() != 202 {
+			switch resp.GetCode() {
+			case http.StatusAccepted:
+			case httpgrpcutil.StatusClientClosedRequest:
+				// The client went away, so there's nobody to report the error to.
+				level.Debug(logger).Log("msg", "push canceled by the client", "err", err)
+			default:
 				level.Error(logger).Log("msg", "push error", "err", err)
 			}
+			globalerror.SetHeader(w.Header(), string(resp.Body))
 			http.Error(w, string(resp.Body), int(resp.Code))
 		}
 	})
@@ -17,9 +17,12 @@ import (
 	"github.com/prometheus/prometheus/prompb"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
 	"github.com/weaveworks/common/middleware"
 
 	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/globalerror"
+	"github.com/grafana/mimir/pkg/util/httpgrpcutil"
 )
 
 func TestHandler_remoteWrite(t *testing.T) {
@@ -39,6 +42,58 @@ func TestHandler_cortexWriteRequest(t *testing.T) {
 	assert.Equal(t, 200, resp.Code)
 }
 
+func TestHandler_ErrorCode(t *testing.T) {
+	req := createRequest(t, createPrometheusRemoteWriteProtobuf(t))
+	resp := httptest.NewRecorder()
+	handler := Handler(100000, nil, false, func(ctx context.Context, request *mimirpb.WriteRequest, cleanup func()) (*mimirpb.WriteResponse, error) {
+		cleanup()
+		return nil, httpgrpc.Errorf(http.StatusTooManyRequests, globalerror.IngestionRateLimited.Message("ingestion rate limit exceeded"))
+	})
+	handler.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+	assert.Equal(t, "err-mimir-ingestion-rate-limited", resp.Header().Get(globalerror.HeaderName))
+}
+
+func TestHandler_DeadlineBudget(t *testing.T) {
+	t.Run("the deadline budget is applied to the push context", func(t *testing.T) {
+		req := createRequest(t, createPrometheusRemoteWriteProtobuf(t))
+		req.Header.Set(httpgrpcutil.DeadlineBudgetHeader, "5000")
+		resp := httptest.NewRecorder()
+		handler := Handler(100000, nil, false, func(ctx context.Context, request *mimirpb.WriteRequest, cleanup func()) (*mimirpb.WriteResponse, error) {
+			defer cleanup()
+			deadline, ok := ctx.Deadline()
+			require.True(t, ok)
+			assert.WithinDuration(t, time.Now().Add(5*time.Second), deadline, time.Second)
+			return &mimirpb.WriteResponse{}, nil
+		})
+		handler.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("an invalid deadline budget is rejected", func(t *testing.T) {
+		req := createRequest(t, createPrometheusRemoteWriteProtobuf(t))
+		req.Header.Set(httpgrpcutil.DeadlineBudgetHeader, "invalid")
+		resp := httptest.NewRecorder()
+		handler := Handler(100000, nil, false, func(ctx context.Context, request *mimirpb.WriteRequest, cleanup func()) (*mimirpb.WriteResponse, error) {
+			t.Fatal("the push function shouldn't be called")
+			return nil, nil
+		})
+		handler.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("the requests whose client went away get the client closed request status code", func(t *testing.T) {
+		req := createRequest(t, createPrometheusRemoteWriteProtobuf(t))
+		resp := httptest.NewRecorder()
+		handler := Handler(100000, nil, false, func(ctx context.Context, request *mimirpb.WriteRequest, cleanup func()) (*mimirpb.WriteResponse, error) {
+			cleanup()
+			return nil, httpgrpc.Errorf(httpgrpcutil.StatusClientClosedRequest, context.Canceled.Error())
+		})
+		handler.ServeHTTP(resp, req)
+		assert.Equal(t, httpgrpcutil.StatusClientClosedRequest, resp.Code)
+	})
+}
+
 func TestHandler_EnsureSkipLabelNameValidationBehaviour(t *testing.T) {
 	tests := []struct {
 		name                                      string
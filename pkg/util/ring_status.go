@@ -0,0 +1,34 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package util
+
+import (
+	"html/template"
+	"net/http"
+)
+
+var ringStatusMessageTemplate = template.Must(template.New("ringStatusMessage").Parse(`
+	<!DOCTYPE html>
+	<html>
+		<head>
+			<meta charset="UTF-8">
+			<title>{{ .Title }}</title>
+		</head>
+		<body>
+			<h1>{{ .Title }}</h1>
+			<p>{{ .Message }}</p>
+		</body>
+	</html>`))
+
+// WriteRingStatusMessage responds with a message in place of the ring status page, for example
+// because the ring can't be read yet. Like the ring status page, the message is rendered as JSON
+// if requested by the Accept header, or as a HTML page with the given title otherwise.
+func WriteRingStatusMessage(w http.ResponseWriter, r *http.Request, title, message string) {
+	RenderHTTPResponse(w, struct {
+		Title   string `json:"-"`
+		Message string `json:"message"`
+	}{
+		Title:   title,
+		Message: message,
+	}, ringStatusMessageTemplate, r)
+}
@@ -0,0 +1,35 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package util
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteRingStatusMessage(t *testing.T) {
+	t.Run("should render a HTML page by default", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		WriteRingStatusMessage(rec, httptest.NewRequest(http.MethodGet, "/ring", nil), "Test Ring", "Test is not running yet.")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+		assert.Contains(t, rec.Body.String(), "<h1>Test Ring</h1>")
+		assert.Contains(t, rec.Body.String(), "<p>Test is not running yet.</p>")
+	})
+
+	t.Run("should render JSON if requested", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/ring", nil)
+		req.Header.Set("Accept", "application/json")
+
+		rec := httptest.NewRecorder()
+		WriteRingStatusMessage(rec, req, "Test Ring", "Test is not running yet.")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"message": "Test is not running yet."}`, rec.Body.String())
+	})
+}
@@ -0,0 +1,328 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package tlsreload
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	dstls "github.com/grafana/dskit/crypto/tls"
+	"github.com/grafana/dskit/grpcclient"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	node_https "github.com/prometheus/node_exporter/https"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/grafana/mimir/pkg/util/fips"
+	util_log "github.com/grafana/mimir/pkg/util/log"
+)
+
+// CheckInterval is how frequently the certificate, key and CA files are checked for changes.
+// The files are checked when a TLS connection is established, so no check is done while idle.
+const CheckInterval = 10 * time.Second
+
+var (
+	errInsecureSkipVerifyFIPS = errors.New("skipping the verification of the server certificate is not allowed in FIPS mode")
+
+	reloadsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cortex_tls_reloads_total",
+		Help: "Total number of reloads of the TLS certificates and CAs after their files changed.",
+	}, []string{"result"})
+
+	certificateExpiry = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "cortex_tls_certificate_expiry_timestamp_seconds",
+		Help: "Expiry timestamp of the TLS certificate currently loaded from the file.",
+	}, []string{"cert_file"})
+)
+
+// ServerConfig returns the TLS config of a server using the certificate and the client CAs
+// configured in cfg. The files are reloaded once changed, without restarting the server.
+func ServerConfig(cfg node_https.TLSStruct) (*tls.Config, error) {
+	// Parse and validate the config the same way the server does.
+	tlsCfg, err := node_https.ConfigToTLSConfig(&cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := newReloader(cfg.TLSCertPath, cfg.TLSKeyPath, cfg.ClientCAs, CheckInterval, util_log.Logger)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsCfg.GetCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		return r.certificate(), nil
+	}
+
+	// The client certificates are verified against the client CAs currently loaded, instead of
+	// the ones loaded when the server started.
+	tlsCfg.ClientCAs = nil
+	switch tlsCfg.ClientAuth {
+	case tls.VerifyClientCertIfGiven:
+		tlsCfg.ClientAuth = tls.RequestClientCert
+		tlsCfg.VerifyConnection = r.verifyClientCertificate
+	case tls.RequireAndVerifyClientCert:
+		tlsCfg.ClientAuth = tls.RequireAnyClientCert
+		tlsCfg.VerifyConnection = r.verifyClientCertificate
+	}
+
+	fips.ApplyTLSPolicy(tlsCfg)
+	return tlsCfg, nil
+}
+
+// ClientConfig returns the TLS config of a client using the certificate and the CAs configured
+// in cfg. The files are reloaded once changed, without recreating the client.
+func ClientConfig(cfg dstls.ClientConfig) (*tls.Config, error) {
+	if cfg.InsecureSkipVerify && fips.Enabled() {
+		return nil, errInsecureSkipVerifyFIPS
+	}
+
+	// Parse and validate the config the same way the client does.
+	tlsCfg, err := cfg.GetTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := newReloader(cfg.CertPath, cfg.KeyPath, cfg.CAPath, CheckInterval, util_log.Logger)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.CertPath != "" {
+		tlsCfg.Certificates = nil
+		tlsCfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return r.certificate(), nil
+		}
+	}
+
+	// The server certificate is verified against the CAs currently loaded, instead of the ones loaded
+	// when the client was created. The verification done by the TLS library is skipped, because it
+	// can only use the CAs loaded once.
+	if cfg.CAPath != "" && !cfg.InsecureSkipVerify {
+		tlsCfg.RootCAs = nil
+		tlsCfg.InsecureSkipVerify = true
+		tlsCfg.VerifyConnection = r.verifyServerCertificate
+	}
+
+	fips.ApplyTLSPolicy(tlsCfg)
+	return tlsCfg, nil
+}
+
+// GRPCDialOptions returns the gRPC dial options of the client config, like grpcclient.Config.DialOption
+// does, but using TLS credentials which reload the certificate and the CAs once their files changed.
+func GRPCDialOptions(cfg grpcclient.Config, unaryClientInterceptors []grpc.UnaryClientInterceptor, streamClientInterceptors []grpc.StreamClientInterceptor) ([]grpc.DialOption, error) {
+	opts, err := cfg.DialOption(unaryClientInterceptors, streamClientInterceptors)
+	if err != nil || !cfg.TLSEnabled {
+		return opts, err
+	}
+
+	tlsCfg, err := ClientConfig(cfg.TLS)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating grpc dial options")
+	}
+
+	// The last transport credentials override the ones set by grpcclient.Config.DialOption.
+	return append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsCfg))), nil
+}
+
+// fileState is the state of a file used to detect changes.
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// reloader holds the certificate and the CAs loaded from files, and reloads them once the files changed.
+// The files are checked at most once every check interval, when the certificate or the CAs are used.
+type reloader struct {
+	certPath, keyPath, caPath string
+	checkInterval             time.Duration
+	logger                    log.Logger
+
+	mtx       sync.Mutex
+	lastCheck time.Time
+	states    []fileState
+	cert      *tls.Certificate
+	caPool    *x509.CertPool
+}
+
+func newReloader(certPath, keyPath, caPath string, checkInterval time.Duration, logger log.Logger) (*reloader, error) {
+	r := &reloader{
+		certPath:      certPath,
+		keyPath:       keyPath,
+		caPath:        caPath,
+		checkInterval: checkInterval,
+		logger:        logger,
+	}
+
+	states, err := r.fileStates()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	r.states = states
+	r.lastCheck = time.Now()
+	return r, nil
+}
+
+func (r *reloader) paths() []string {
+	var paths []string
+	for _, p := range []string{r.certPath, r.keyPath, r.caPath} {
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+func (r *reloader) fileStates() ([]fileState, error) {
+	paths := r.paths()
+	states := make([]fileState, 0, len(paths))
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, fileState{modTime: info.ModTime(), size: info.Size()})
+	}
+	return states, nil
+}
+
+// load loads the certificate and the CAs from the files. Must be called with the lock held, or before
+// the reloader is used.
+func (r *reloader) load() error {
+	var cert *tls.Certificate
+	if r.certPath != "" {
+		c, err := tls.LoadX509KeyPair(r.certPath, r.keyPath)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load TLS certificate %s,%s", r.certPath, r.keyPath)
+		}
+		if c.Leaf, err = x509.ParseCertificate(c.Certificate[0]); err != nil {
+			return errors.Wrapf(err, "failed to parse TLS certificate %s", r.certPath)
+		}
+		cert = &c
+	}
+
+	var caPool *x509.CertPool
+	if r.caPath != "" {
+		ca, err := os.ReadFile(r.caPath)
+		if err != nil {
+			return errors.Wrapf(err, "error loading ca cert: %s", r.caPath)
+		}
+		caPool = x509.NewCertPool()
+		if !caPool.AppendCertsFromPEM(ca) {
+			return errors.Errorf("no valid CA certificate found in %s", r.caPath)
+		}
+	}
+
+	r.cert, r.caPool = cert, caPool
+	if cert != nil {
+		certificateExpiry.WithLabelValues(r.certPath).Set(float64(cert.Leaf.NotAfter.Unix()))
+	}
+	return nil
+}
+
+// maybeReload reloads the files if they changed since they were loaded. If the reload fails, the
+// previous certificate and CAs are kept, and the reload is retried at the next check.
+func (r *reloader) maybeReload() {
+	now := time.Now()
+	if now.Sub(r.lastCheck) < r.checkInterval {
+		return
+	}
+	r.lastCheck = now
+
+	states, err := r.fileStates()
+	if err != nil {
+		reloadsTotal.WithLabelValues("failure").Inc()
+		level.Warn(r.logger).Log("msg", "failed to check the TLS files for changes", "files", strings.Join(r.paths(), ","), "err", err)
+		return
+	}
+	if statesEqual(states, r.states) {
+		return
+	}
+
+	if err := r.load(); err != nil {
+		reloadsTotal.WithLabelValues("failure").Inc()
+		level.Warn(r.logger).Log("msg", "failed to reload the TLS files, keeping the previous ones", "files", strings.Join(r.paths(), ","), "err", err)
+		return
+	}
+
+	r.states = states
+	reloadsTotal.WithLabelValues("success").Inc()
+	level.Info(r.logger).Log("msg", "reloaded the TLS files", "files", strings.Join(r.paths(), ","))
+}
+
+func (r *reloader) certificate() *tls.Certificate {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.maybeReload()
+	if r.cert == nil {
+		// No certificate is sent.
+		return &tls.Certificate{}
+	}
+	return r.cert
+}
+
+func (r *reloader) certPool() *x509.CertPool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.maybeReload()
+	return r.caPool
+}
+
+func (r *reloader) verifyClientCertificate(cs tls.ConnectionState) error {
+	if len(cs.PeerCertificates) == 0 {
+		// The TLS library already checked whether the client certificate is required.
+		return nil
+	}
+
+	_, err := cs.PeerCertificates[0].Verify(x509.VerifyOptions{
+		Roots:         r.certPool(),
+		Intermediates: intermediates(cs.PeerCertificates),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	return err
+}
+
+func (r *reloader) verifyServerCertificate(cs tls.ConnectionState) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("the server didn't send any certificate")
+	}
+
+	_, err := cs.PeerCertificates[0].Verify(x509.VerifyOptions{
+		DNSName:       cs.ServerName,
+		Roots:         r.certPool(),
+		Intermediates: intermediates(cs.PeerCertificates),
+	})
+	return err
+}
+
+func intermediates(certs []*x509.Certificate) *x509.CertPool {
+	pool := x509.NewCertPool()
+	for _, c := range certs[1:] {
+		pool.AddCert(c)
+	}
+	return pool
+}
+
+func statesEqual(a, b []fileState) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].modTime.Equal(b[i].modTime) || a[i].size != b[i].size {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,236 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package tlsreload
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	dstls "github.com/grafana/dskit/crypto/tls"
+	node_https "github.com/prometheus/node_exporter/https"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/integration/ca"
+	"github.com/grafana/mimir/pkg/util/fips"
+)
+
+// testCertsGeneration is incremented each time the test certificates are written.
+var testCertsGeneration int
+
+type testCerts struct {
+	dir                   string
+	caCert                string
+	serverCert, serverKey string
+	clientCert, clientKey string
+}
+
+// writeTestCerts writes a CA, and a server and client certificates signed by it, to dir.
+// Existing files are replaced.
+func writeTestCerts(t *testing.T, dir, name string) testCerts {
+	c := testCerts{
+		dir:        dir,
+		caCert:     filepath.Join(dir, "ca.crt"),
+		serverCert: filepath.Join(dir, "server.crt"),
+		serverKey:  filepath.Join(dir, "server.key"),
+		clientCert: filepath.Join(dir, "client.crt"),
+		clientKey:  filepath.Join(dir, "client.key"),
+	}
+	for _, f := range []string{c.caCert, c.serverCert, c.serverKey, c.clientCert, c.clientKey} {
+		require.NoError(t, os.RemoveAll(f))
+	}
+
+	testCA := ca.New(name)
+	require.NoError(t, testCA.WriteCACertificate(c.caCert))
+	require.NoError(t, testCA.WriteCertificate(&x509.Certificate{
+		Subject:     pkix.Name{CommonName: name + " server"},
+		DNSNames:    []string{"localhost"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, c.serverCert, c.serverKey))
+	require.NoError(t, testCA.WriteCertificate(&x509.Certificate{
+		Subject:     pkix.Name{CommonName: name + " client"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, c.clientCert, c.clientKey))
+
+	// Ensure the modification time changes, even on file systems with a coarse resolution.
+	testCertsGeneration++
+	future := time.Now().Add(time.Duration(testCertsGeneration) * time.Second)
+	for _, f := range []string{c.caCert, c.serverCert, c.serverKey, c.clientCert, c.clientKey} {
+		require.NoError(t, os.Chtimes(f, future, future))
+	}
+	return c
+}
+
+func TestReloader(t *testing.T) {
+	dir := t.TempDir()
+	certs := writeTestCerts(t, dir, "first")
+
+	r, err := newReloader(certs.serverCert, certs.serverKey, certs.caCert, 0, log.NewNopLogger())
+	require.NoError(t, err)
+	assert.Equal(t, "first server", r.certificate().Leaf.Subject.CommonName)
+	firstPool := r.certPool()
+
+	// The files are reloaded once changed.
+	certs = writeTestCerts(t, dir, "second")
+	assert.Equal(t, "second server", r.certificate().Leaf.Subject.CommonName)
+	assert.NotSame(t, firstPool, r.certPool())
+
+	// If the files are invalid, the previous certificate is kept.
+	require.NoError(t, os.WriteFile(certs.serverKey, []byte("invalid"), 0600))
+	assert.Equal(t, "second server", r.certificate().Leaf.Subject.CommonName)
+
+	// The files aren't checked again before the check interval elapsed.
+	r.checkInterval = time.Hour
+	writeTestCerts(t, dir, "third")
+	assert.Equal(t, "second server", r.certificate().Leaf.Subject.CommonName)
+}
+
+func TestNewReloader_ShouldFailOnInvalidFiles(t *testing.T) {
+	dir := t.TempDir()
+	certs := writeTestCerts(t, dir, "first")
+
+	_, err := newReloader(filepath.Join(dir, "missing.crt"), certs.serverKey, "", 0, log.NewNopLogger())
+	assert.Error(t, err)
+
+	_, err = newReloader(certs.serverCert, certs.clientKey, "", 0, log.NewNopLogger())
+	assert.Error(t, err)
+
+	require.NoError(t, os.WriteFile(certs.caCert, []byte("invalid"), 0600))
+	_, err = newReloader("", "", certs.caCert, 0, log.NewNopLogger())
+	assert.Error(t, err)
+}
+
+func TestServerAndClientConfig(t *testing.T) {
+	dir := t.TempDir()
+	certs := writeTestCerts(t, dir, "first")
+	otherCerts := writeTestCerts(t, t.TempDir(), "other")
+
+	serverCfg, err := ServerConfig(node_https.TLSStruct{
+		TLSCertPath: certs.serverCert,
+		TLSKeyPath:  certs.serverKey,
+		ClientAuth:  "RequireAndVerifyClientCert",
+		ClientCAs:   certs.caCert,
+	})
+	require.NoError(t, err)
+
+	lis, err := tls.Listen("tcp", "localhost:0", serverCfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = lis.Close() })
+
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			// Complete the handshake, so that the client gets the outcome of the verification.
+			_ = conn.(*tls.Conn).Handshake()
+			_ = conn.Close()
+		}
+	}()
+
+	handshake := func(cfg dstls.ClientConfig) error {
+		clientCfg, err := ClientConfig(cfg)
+		require.NoError(t, err)
+
+		conn, err := tls.Dial("tcp", lis.Addr().String(), clientCfg)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		// The server verifies the client certificate after the client completed the handshake,
+		// so the verification failure is only reported when reading.
+		// The server closes the connection once the handshake succeeded.
+		_, err = conn.Read(make([]byte, 1))
+		if err == io.EOF {
+			return nil
+		}
+		return err
+	}
+
+	t.Run("should succeed with a client certificate signed by the CA", func(t *testing.T) {
+		assert.NoError(t, handshake(dstls.ClientConfig{CertPath: certs.clientCert, KeyPath: certs.clientKey, CAPath: certs.caCert, ServerName: "localhost"}))
+	})
+
+	t.Run("should fail without a client certificate", func(t *testing.T) {
+		assert.Error(t, handshake(dstls.ClientConfig{CAPath: certs.caCert, ServerName: "localhost"}))
+	})
+
+	t.Run("should fail with a client certificate not signed by the CA", func(t *testing.T) {
+		assert.Error(t, handshake(dstls.ClientConfig{CertPath: otherCerts.clientCert, KeyPath: otherCerts.clientKey, CAPath: certs.caCert, ServerName: "localhost"}))
+	})
+
+	t.Run("should fail if the server certificate is not signed by the CA", func(t *testing.T) {
+		assert.Error(t, handshake(dstls.ClientConfig{CertPath: certs.clientCert, KeyPath: certs.clientKey, CAPath: otherCerts.caCert, ServerName: "localhost"}))
+	})
+
+	t.Run("should fail if the server name doesn't match the server certificate", func(t *testing.T) {
+		assert.Error(t, handshake(dstls.ClientConfig{CertPath: certs.clientCert, KeyPath: certs.clientKey, CAPath: certs.caCert, ServerName: "other"}))
+	})
+
+	t.Run("should succeed if the server certificate verification is skipped", func(t *testing.T) {
+		assert.NoError(t, handshake(dstls.ClientConfig{CertPath: certs.clientCert, KeyPath: certs.clientKey, CAPath: otherCerts.caCert, InsecureSkipVerify: true}))
+	})
+}
+
+func TestServerAndClientConfig_FIPSMode(t *testing.T) {
+	fips.SetEnabled(true)
+	t.Cleanup(func() { fips.SetEnabled(false) })
+
+	certs := writeTestCerts(t, t.TempDir(), "first")
+
+	serverCfg, err := ServerConfig(node_https.TLSStruct{TLSCertPath: certs.serverCert, TLSKeyPath: certs.serverKey})
+	require.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS12), serverCfg.MaxVersion)
+	assert.Equal(t, fips.CipherSuites, serverCfg.CipherSuites)
+
+	lis, err := tls.Listen("tcp", "localhost:0", serverCfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = lis.Close() })
+
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.(*tls.Conn).Handshake()
+			_ = conn.Close()
+		}
+	}()
+
+	t.Run("should negotiate a FIPS-approved cipher suite", func(t *testing.T) {
+		clientCfg, err := ClientConfig(dstls.ClientConfig{CAPath: certs.caCert, ServerName: "localhost"})
+		require.NoError(t, err)
+
+		conn, err := tls.Dial("tcp", lis.Addr().String(), clientCfg)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		state := conn.ConnectionState()
+		assert.Equal(t, uint16(tls.VersionTLS12), state.Version)
+		assert.Contains(t, fips.CipherSuites, state.CipherSuite)
+	})
+
+	t.Run("should reject a client which only supports cipher suites not approved", func(t *testing.T) {
+		_, err := tls.Dial("tcp", lis.Addr().String(), &tls.Config{
+			InsecureSkipVerify: true,
+			MaxVersion:         tls.VersionTLS12,
+			CipherSuites:       []uint16{tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("should not allow to skip the verification of the server certificate", func(t *testing.T) {
+		_, err := ClientConfig(dstls.ClientConfig{InsecureSkipVerify: true})
+		assert.Equal(t, errInsecureSkipVerifyFIPS, err)
+	})
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/prometheus/common/model"
 
 	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/globalerror"
 )
 
 // ValidationError is an error returned by series validation.
@@ -34,7 +35,7 @@ func (e *genericValidationError) Error() string {
 
 func newLabelNameTooLongError(series []mimirpb.LabelAdapter, labelName string) ValidationError {
 	return &genericValidationError{
-		message: "label name too long: %.200q metric %.200q",
+		message: globalerror.LabelNameTooLong.Message("label name too long: %.200q metric %.200q"),
 		cause:   labelName,
 		series:  series,
 	}
@@ -48,7 +49,7 @@ type labelValueTooLongError struct {
 }
 
 func (e *labelValueTooLongError) Error() string {
-	return fmt.Sprintf("label value too long for metric: %.200q label value: %.200q", formatLabelSet(e.series), e.labelValue)
+	return globalerror.LabelValueTooLong.Message(fmt.Sprintf("label value too long for metric: %.200q label value: %.200q", formatLabelSet(e.series), e.labelValue))
 }
 
 func newLabelValueTooLongError(series []mimirpb.LabelAdapter, labelValue string) ValidationError {
@@ -60,7 +61,7 @@ func newLabelValueTooLongError(series []mimirpb.LabelAdapter, labelValue string)
 
 func newInvalidLabelError(series []mimirpb.LabelAdapter, labelName string) ValidationError {
 	return &genericValidationError{
-		message: "sample invalid label: %.200q metric %.200q",
+		message: globalerror.InvalidLabel.Message("sample invalid label: %.200q metric %.200q"),
 		cause:   labelName,
 		series:  series,
 	}
@@ -68,7 +69,7 @@ func newInvalidLabelError(series []mimirpb.LabelAdapter, labelName string) Valid
 
 func newDuplicatedLabelError(series []mimirpb.LabelAdapter, labelName string) ValidationError {
 	return &genericValidationError{
-		message: "duplicate label name: %.200q metric %.200q",
+		message: globalerror.DuplicateLabelNames.Message("duplicate label name: %.200q metric %.200q"),
 		cause:   labelName,
 		series:  series,
 	}
@@ -76,7 +77,7 @@ func newDuplicatedLabelError(series []mimirpb.LabelAdapter, labelName string) Va
 
 func newLabelsNotSortedError(series []mimirpb.LabelAdapter, labelName string) ValidationError {
 	return &genericValidationError{
-		message: "labels not sorted: %.200q metric %.200q",
+		message: globalerror.LabelsNotSorted.Message("labels not sorted: %.200q metric %.200q"),
 		cause:   labelName,
 		series:  series,
 	}
@@ -95,9 +96,29 @@ func newTooManyLabelsError(series []mimirpb.LabelAdapter, limit int) ValidationE
 }
 
 func (e *tooManyLabelsError) Error() string {
-	return fmt.Sprintf(
+	return globalerror.MaxLabelNamesPerSeries.Message(fmt.Sprintf(
 		"series has too many labels (actual: %d, limit: %d) series: '%s'",
-		len(e.series), e.limit, mimirpb.FromLabelAdaptersToMetric(e.series).String())
+		len(e.series), e.limit, mimirpb.FromLabelAdaptersToMetric(e.series).String()))
+}
+
+type labelsSizeTooLargeError struct {
+	series []mimirpb.LabelAdapter
+	size   int
+	limit  int
+}
+
+func newLabelsSizeTooLargeError(series []mimirpb.LabelAdapter, size, limit int) ValidationError {
+	return &labelsSizeTooLargeError{
+		series: series,
+		size:   size,
+		limit:  limit,
+	}
+}
+
+func (e *labelsSizeTooLargeError) Error() string {
+	return globalerror.MaxLabelsSizeBytes.Message(fmt.Sprintf(
+		"series labels size exceeds the limit (actual: %d bytes, limit: %d bytes) series: '%.200s'",
+		e.size, e.limit, mimirpb.FromLabelAdaptersToMetric(e.series).String()))
 }
 
 type noMetricNameError struct{}
@@ -107,7 +128,7 @@ func newNoMetricNameError() ValidationError {
 }
 
 func (e *noMetricNameError) Error() string {
-	return "sample missing metric name"
+	return globalerror.MissingMetricName.Message("sample missing metric name")
 }
 
 type invalidMetricNameError struct {
@@ -121,7 +142,7 @@ func newInvalidMetricNameError(metricName string) ValidationError {
 }
 
 func (e *invalidMetricNameError) Error() string {
-	return fmt.Sprintf("sample invalid metric name: %.200q", e.metricName)
+	return globalerror.InvalidMetricName.Message(fmt.Sprintf("sample invalid metric name: %.200q", e.metricName))
 }
 
 // sampleValidationError is a ValidationError implementation suitable for sample validation errors.
@@ -137,7 +158,25 @@ func (e *sampleValidationError) Error() string {
 
 func newSampleTimestampTooNewError(metricName string, timestamp int64) ValidationError {
 	return &sampleValidationError{
-		message:    "timestamp too new: %d metric: %.200q",
+		message:    globalerror.SampleTooFarInFuture.Message("timestamp too new: %d metric: %.200q"),
+		metricName: metricName,
+		timestamp:  timestamp,
+	}
+}
+
+func newCreatedTimestampInvalidError(metricName string, timestamp int64) ValidationError {
+	return &sampleValidationError{
+		message:    globalerror.CreatedTimestampInvalid.Message("created timestamp newer than the first sample of the series: %d metric: %.200q"),
+		metricName: metricName,
+		timestamp:  timestamp,
+	}
+}
+
+// NewSampleTooFrequentError returns the error for a sample arriving closer than the minimum sample interval
+// to the previous sample of the series.
+func NewSampleTooFrequentError(metricName string, timestamp int64) ValidationError {
+	return &sampleValidationError{
+		message:    globalerror.SampleTooFrequent.Message("sample closer than the minimum sample interval to the previous sample of the series: %d metric: %.200q"),
 		metricName: metricName,
 		timestamp:  timestamp,
 	}
@@ -157,7 +196,7 @@ func (e *exemplarValidationError) Error() string {
 
 func newExemplarEmtpyLabelsError(seriesLabels []mimirpb.LabelAdapter, exemplarLabels []mimirpb.LabelAdapter, timestamp int64) ValidationError {
 	return &exemplarValidationError{
-		message:        "exemplar missing labels, timestamp: %d series: %s labels: %s",
+		message:        globalerror.ExemplarLabelsMissing.Message("exemplar missing labels, timestamp: %d series: %s labels: %s"),
 		seriesLabels:   seriesLabels,
 		exemplarLabels: exemplarLabels,
 		timestamp:      timestamp,
@@ -166,7 +205,7 @@ func newExemplarEmtpyLabelsError(seriesLabels []mimirpb.LabelAdapter, exemplarLa
 
 func newExemplarMissingTimestampError(seriesLabels []mimirpb.LabelAdapter, exemplarLabels []mimirpb.LabelAdapter, timestamp int64) ValidationError {
 	return &exemplarValidationError{
-		message:        "exemplar missing timestamp, timestamp: %d series: %s labels: %s",
+		message:        globalerror.ExemplarTimestampInvalid.Message("exemplar missing timestamp, timestamp: %d series: %s labels: %s"),
 		seriesLabels:   seriesLabels,
 		exemplarLabels: exemplarLabels,
 		timestamp:      timestamp,
@@ -177,7 +216,7 @@ var labelLenMsg = "exemplar combined labelset exceeds " + strconv.Itoa(ExemplarM
 
 func newExemplarLabelLengthError(seriesLabels []mimirpb.LabelAdapter, exemplarLabels []mimirpb.LabelAdapter, timestamp int64) ValidationError {
 	return &exemplarValidationError{
-		message:        labelLenMsg,
+		message:        globalerror.ExemplarLabelsTooLong.Message(labelLenMsg),
 		seriesLabels:   seriesLabels,
 		exemplarLabels: exemplarLabels,
 		timestamp:      timestamp,
@@ -9,6 +9,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"flag"
+	"fmt"
 	"math"
 	"strings"
 	"time"
@@ -19,6 +20,46 @@ import (
 	"golang.org/x/time/rate"
 )
 
+const (
+	// MinSampleIntervalPolicyReject rejects, with an error, the samples arriving closer than
+	// the minimum sample interval to the previous sample of the series.
+	MinSampleIntervalPolicyReject = "reject"
+
+	// MinSampleIntervalPolicyDownsample keeps the first sample of each interval, aligned to the
+	// interval boundaries, and drops the other samples of the series without error.
+	MinSampleIntervalPolicyDownsample = "downsample"
+
+	// VerticalMergeStrategyChain keeps one of the samples with the same timestamp, without
+	// guarantees about which one is kept. This is the TSDB default behaviour.
+	VerticalMergeStrategyChain = "chain"
+
+	// VerticalMergeStrategyMaxValue keeps the sample with the highest value among the samples
+	// with the same timestamp.
+	VerticalMergeStrategyMaxValue = "max-value"
+
+	// VerticalMergeStrategyError fails the compaction if samples with the same timestamp have different values.
+	VerticalMergeStrategyError = "error"
+
+	// DuplicateTimestampPolicyReject rejects a sample with the same timestamp as an existing sample
+	// and a different value. This is the TSDB default behaviour.
+	DuplicateTimestampPolicyReject = "reject"
+
+	// DuplicateTimestampPolicyKeepFirst drops, without error, a sample with the same timestamp as
+	// an existing sample and a different value.
+	DuplicateTimestampPolicyKeepFirst = "keep-first"
+
+	// DuplicateTimestampPolicyKeepLast keeps the last of the samples with the same timestamp in a
+	// write request. The samples already stored in the head can't be rewritten, so a sample with
+	// the same timestamp as a stored one is dropped without error, like with keep-first.
+	DuplicateTimestampPolicyKeepLast = "keep-last"
+
+	// QueryEngineStandard is the Prometheus PromQL engine.
+	QueryEngineStandard = "standard"
+
+	// QueryEngineStreaming is the PromQL engine evaluating a subset of PromQL expressions one series at a time.
+	QueryEngineStreaming = "streaming"
+)
+
 // LimitError are errors that do not comply with the limits specified.
 type LimitError string
 
@@ -51,53 +92,85 @@ type Limits struct {
 	MaxLabelNameLength        int                 `yaml:"max_label_name_length" json:"max_label_name_length"`
 	MaxLabelValueLength       int                 `yaml:"max_label_value_length" json:"max_label_value_length"`
 	MaxLabelNamesPerSeries    int                 `yaml:"max_label_names_per_series" json:"max_label_names_per_series"`
+	MaxLabelsSizeBytes        int                 `yaml:"max_labels_size_bytes" json:"max_labels_size_bytes" category:"experimental"`
 	MaxMetadataLength         int                 `yaml:"max_metadata_length" json:"max_metadata_length"`
 	CreationGracePeriod       model.Duration      `yaml:"creation_grace_period" json:"creation_grace_period" category:"advanced"`
 	EnforceMetadataMetricName bool                `yaml:"enforce_metadata_metric_name" json:"enforce_metadata_metric_name" category:"advanced"`
 	IngestionTenantShardSize  int                 `yaml:"ingestion_tenant_shard_size" json:"ingestion_tenant_shard_size"`
+	MinSampleInterval         model.Duration      `yaml:"min_sample_interval" json:"min_sample_interval" category:"experimental"`
+	MinSampleIntervalPolicy   string              `yaml:"min_sample_interval_policy" json:"min_sample_interval_policy" category:"experimental"`
 	MetricRelabelConfigs      []*relabel.Config   `yaml:"metric_relabel_configs,omitempty" json:"metric_relabel_configs,omitempty" doc:"nocli|description=List of metric relabel configurations. Note that in most situations, it is more effective to use metrics relabeling directly in the Prometheus server, e.g. remote_write.write_relabel_configs." category:"experimental"`
 
 	// Ingester enforced limits.
 	// Series
 	MaxGlobalSeriesPerUser   int `yaml:"max_global_series_per_user" json:"max_global_series_per_user"`
 	MaxGlobalSeriesPerMetric int `yaml:"max_global_series_per_metric" json:"max_global_series_per_metric"`
+	MaxSeriesCreatedPerHour  int `yaml:"max_series_created_per_hour" json:"max_series_created_per_hour" category:"experimental"`
 	// Metadata
 	MaxGlobalMetricsWithMetadataPerUser int `yaml:"max_global_metadata_per_user" json:"max_global_metadata_per_user"`
 	MaxGlobalMetadataPerMetric          int `yaml:"max_global_metadata_per_metric" json:"max_global_metadata_per_metric"`
 	// Exemplars
 	MaxGlobalExemplarsPerUser int `yaml:"max_global_exemplars_per_user" json:"max_global_exemplars_per_user" category:"experimental"`
+	// Blocks shipping
+	IngesterShipMaxBytesPerSecond int `yaml:"ingester_ship_max_bytes_per_second" json:"ingester_ship_max_bytes_per_second" category:"experimental"`
+	// Samples
+	DuplicateTimestampPolicy             string `yaml:"duplicate_timestamp_policy" json:"duplicate_timestamp_policy" category:"experimental"`
+	CreatedTimestampZeroIngestionEnabled bool   `yaml:"created_timestamp_zero_ingestion_enabled" json:"created_timestamp_zero_ingestion_enabled" category:"experimental"`
 
 	// Querier enforced limits.
-	MaxChunksPerQuery              int            `yaml:"max_fetched_chunks_per_query" json:"max_fetched_chunks_per_query"`
-	MaxFetchedSeriesPerQuery       int            `yaml:"max_fetched_series_per_query" json:"max_fetched_series_per_query"`
-	MaxFetchedChunkBytesPerQuery   int            `yaml:"max_fetched_chunk_bytes_per_query" json:"max_fetched_chunk_bytes_per_query"`
-	MaxQueryLookback               model.Duration `yaml:"max_query_lookback" json:"max_query_lookback"`
-	MaxQueryLength                 model.Duration `yaml:"max_query_length" json:"max_query_length"`
-	MaxQueryParallelism            int            `yaml:"max_query_parallelism" json:"max_query_parallelism"`
-	MaxLabelsQueryLength           model.Duration `yaml:"max_labels_query_length" json:"max_labels_query_length"`
-	MaxCacheFreshness              model.Duration `yaml:"max_cache_freshness" json:"max_cache_freshness" category:"advanced"`
-	MaxQueriersPerTenant           int            `yaml:"max_queriers_per_tenant" json:"max_queriers_per_tenant"`
-	QueryShardingTotalShards       int            `yaml:"query_sharding_total_shards" json:"query_sharding_total_shards"`
-	QueryShardingMaxShardedQueries int            `yaml:"query_sharding_max_sharded_queries" json:"query_sharding_max_sharded_queries"`
+	MaxChunksPerQuery                 int            `yaml:"max_fetched_chunks_per_query" json:"max_fetched_chunks_per_query"`
+	MaxFetchedSeriesPerQuery          int            `yaml:"max_fetched_series_per_query" json:"max_fetched_series_per_query"`
+	MaxFetchedChunkBytesPerQuery      int            `yaml:"max_fetched_chunk_bytes_per_query" json:"max_fetched_chunk_bytes_per_query"`
+	MaxQueryLookback                  model.Duration `yaml:"max_query_lookback" json:"max_query_lookback"`
+	MaxQueryLength                    model.Duration `yaml:"max_query_length" json:"max_query_length"`
+	MaxQueryParallelism               int            `yaml:"max_query_parallelism" json:"max_query_parallelism"`
+	MaxLabelsQueryLength              model.Duration `yaml:"max_labels_query_length" json:"max_labels_query_length"`
+	QueryEngine                       string         `yaml:"query_engine" json:"query_engine" category:"experimental"`
+	MaxCacheFreshness                 model.Duration `yaml:"max_cache_freshness" json:"max_cache_freshness" category:"advanced"`
+	ResultsCacheTTL                   model.Duration `yaml:"results_cache_ttl" json:"results_cache_ttl" category:"experimental"`
+	ResultsCacheMaxQueryLength        model.Duration `yaml:"results_cache_max_query_length" json:"results_cache_max_query_length" category:"experimental"`
+	ResultsCacheUnalignedRequests     bool           `yaml:"results_cache_unaligned_requests" json:"results_cache_unaligned_requests" category:"experimental"`
+	MaxQueriersPerTenant              int            `yaml:"max_queriers_per_tenant" json:"max_queriers_per_tenant"`
+	QueryShardingTotalShards          int            `yaml:"query_sharding_total_shards" json:"query_sharding_total_shards"`
+	QueryShardingMaxShardedQueries    int            `yaml:"query_sharding_max_sharded_queries" json:"query_sharding_max_sharded_queries"`
+	QueryShardingTargetSeriesPerShard int            `yaml:"query_sharding_target_series_per_shard" json:"query_sharding_target_series_per_shard" category:"experimental"`
+	QuerySLOLatencyThreshold          model.Duration `yaml:"query_slo_latency_threshold" json:"query_slo_latency_threshold" category:"experimental"`
+	SlowQueryLogThreshold             model.Duration `yaml:"slow_query_log_threshold" json:"slow_query_log_threshold" category:"experimental"`
+	MaxConcurrentQueriesPerTenant     int            `yaml:"max_concurrent_queries_per_tenant" json:"max_concurrent_queries_per_tenant" category:"experimental"`
+	MaxQueuedQueriesPerTenant         int            `yaml:"max_queued_queries_per_tenant" json:"max_queued_queries_per_tenant" category:"experimental"`
+	// Querier enforced limits to the data fetched from each source.
+	MaxChunksPerQueryFromIngesters                int `yaml:"max_fetched_chunks_per_query_from_ingesters" json:"max_fetched_chunks_per_query_from_ingesters" category:"experimental"`
+	MaxChunksPerQueryFromStoreGateways            int `yaml:"max_fetched_chunks_per_query_from_store_gateways" json:"max_fetched_chunks_per_query_from_store_gateways" category:"experimental"`
+	MaxFetchedSeriesPerQueryFromIngesters         int `yaml:"max_fetched_series_per_query_from_ingesters" json:"max_fetched_series_per_query_from_ingesters" category:"experimental"`
+	MaxFetchedSeriesPerQueryFromStoreGateways     int `yaml:"max_fetched_series_per_query_from_store_gateways" json:"max_fetched_series_per_query_from_store_gateways" category:"experimental"`
+	MaxFetchedChunkBytesPerQueryFromIngesters     int `yaml:"max_fetched_chunk_bytes_per_query_from_ingesters" json:"max_fetched_chunk_bytes_per_query_from_ingesters" category:"experimental"`
+	MaxFetchedChunkBytesPerQueryFromStoreGateways int `yaml:"max_fetched_chunk_bytes_per_query_from_store_gateways" json:"max_fetched_chunk_bytes_per_query_from_store_gateways" category:"experimental"`
+	MaxFetchedDataBytesPerQuery                   int `yaml:"max_fetched_data_bytes_per_query" json:"max_fetched_data_bytes_per_query" category:"experimental"`
 	// Cardinality
 	CardinalityAnalysisEnabled                    bool `yaml:"cardinality_analysis_enabled" json:"cardinality_analysis_enabled"`
 	LabelNamesAndValuesResultsMaxSizeBytes        int  `yaml:"label_names_and_values_results_max_size_bytes" json:"label_names_and_values_results_max_size_bytes"`
 	LabelValuesMaxCardinalityLabelNamesPerRequest int  `yaml:"label_values_max_cardinality_label_names_per_request" json:"label_values_max_cardinality_label_names_per_request"`
+	ActiveSeriesResultsMaxSizeBytes               int  `yaml:"active_series_results_max_size_bytes" json:"active_series_results_max_size_bytes" category:"experimental"`
 
 	// Ruler defaults and limits.
 	RulerEvaluationDelay        model.Duration `yaml:"ruler_evaluation_delay_duration" json:"ruler_evaluation_delay_duration"`
 	RulerTenantShardSize        int            `yaml:"ruler_tenant_shard_size" json:"ruler_tenant_shard_size"`
 	RulerMaxRulesPerRuleGroup   int            `yaml:"ruler_max_rules_per_rule_group" json:"ruler_max_rules_per_rule_group"`
 	RulerMaxRuleGroupsPerTenant int            `yaml:"ruler_max_rule_groups_per_tenant" json:"ruler_max_rule_groups_per_tenant"`
+	RulerAlertmanagerURL        string         `yaml:"ruler_alertmanager_url" json:"ruler_alertmanager_url" category:"experimental"`
 
 	// Store-gateway.
 	StoreGatewayTenantShardSize int `yaml:"store_gateway_tenant_shard_size" json:"store_gateway_tenant_shard_size"`
 
 	// Compactor.
-	CompactorBlocksRetentionPeriod model.Duration `yaml:"compactor_blocks_retention_period" json:"compactor_blocks_retention_period"`
-	CompactorSplitAndMergeShards   int            `yaml:"compactor_split_and_merge_shards" json:"compactor_split_and_merge_shards"`
-	CompactorSplitGroups           int            `yaml:"compactor_split_groups" json:"compactor_split_groups"`
-	CompactorTenantShardSize       int            `yaml:"compactor_tenant_shard_size" json:"compactor_tenant_shard_size"`
+	CompactorBlocksRetentionPeriod           model.Duration `yaml:"compactor_blocks_retention_period" json:"compactor_blocks_retention_period"`
+	CompactorSplitAndMergeShards             int            `yaml:"compactor_split_and_merge_shards" json:"compactor_split_and_merge_shards"`
+	CompactorSplitGroups                     int            `yaml:"compactor_split_groups" json:"compactor_split_groups"`
+	CompactorTenantShardSize                 int            `yaml:"compactor_tenant_shard_size" json:"compactor_tenant_shard_size"`
+	CompactorTenantMaxShardSize              int            `yaml:"compactor_tenant_max_shard_size" json:"compactor_tenant_max_shard_size" category:"experimental"`
+	CompactorTenantShardSizeJobsPerCompactor int            `yaml:"compactor_tenant_shard_size_jobs_per_compactor" json:"compactor_tenant_shard_size_jobs_per_compactor" category:"experimental"`
+	CompactorVerticalMergeStrategy           string         `yaml:"compactor_vertical_merge_strategy" json:"compactor_vertical_merge_strategy" category:"experimental"`
+	CompactorBlocksObjectLockPeriod          model.Duration `yaml:"compactor_blocks_object_lock_period" json:"compactor_blocks_object_lock_period" category:"experimental"`
 
 	// This config doesn't have a CLI flag registered here because they're registered in
 	// their own original config struct.
@@ -112,12 +185,26 @@ type Limits struct {
 	NotificationRateLimit               float64                  `yaml:"alertmanager_notification_rate_limit" json:"alertmanager_notification_rate_limit"`
 	NotificationRateLimitPerIntegration NotificationRateLimitMap `yaml:"alertmanager_notification_rate_limit_per_integration" json:"alertmanager_notification_rate_limit_per_integration"`
 
-	AlertmanagerMaxConfigSizeBytes             int `yaml:"alertmanager_max_config_size_bytes" json:"alertmanager_max_config_size_bytes"`
-	AlertmanagerMaxTemplatesCount              int `yaml:"alertmanager_max_templates_count" json:"alertmanager_max_templates_count"`
-	AlertmanagerMaxTemplateSizeBytes           int `yaml:"alertmanager_max_template_size_bytes" json:"alertmanager_max_template_size_bytes"`
-	AlertmanagerMaxDispatcherAggregationGroups int `yaml:"alertmanager_max_dispatcher_aggregation_groups" json:"alertmanager_max_dispatcher_aggregation_groups"`
-	AlertmanagerMaxAlertsCount                 int `yaml:"alertmanager_max_alerts_count" json:"alertmanager_max_alerts_count"`
-	AlertmanagerMaxAlertsSizeBytes             int `yaml:"alertmanager_max_alerts_size_bytes" json:"alertmanager_max_alerts_size_bytes"`
+	AlertmanagerMaxConfigSizeBytes             int                    `yaml:"alertmanager_max_config_size_bytes" json:"alertmanager_max_config_size_bytes"`
+	AlertmanagerMaxTemplatesCount              int                    `yaml:"alertmanager_max_templates_count" json:"alertmanager_max_templates_count"`
+	AlertmanagerMaxTemplateSizeBytes           int                    `yaml:"alertmanager_max_template_size_bytes" json:"alertmanager_max_template_size_bytes"`
+	AlertmanagerMaxDispatcherAggregationGroups int                    `yaml:"alertmanager_max_dispatcher_aggregation_groups" json:"alertmanager_max_dispatcher_aggregation_groups"`
+	AlertmanagerMaxAlertsCount                 int                    `yaml:"alertmanager_max_alerts_count" json:"alertmanager_max_alerts_count"`
+	AlertmanagerMaxAlertsSizeBytes             int                    `yaml:"alertmanager_max_alerts_size_bytes" json:"alertmanager_max_alerts_size_bytes"`
+	AlertmanagerReplicationFactor              int                    `yaml:"alertmanager_replication_factor" json:"alertmanager_replication_factor" category:"experimental"`
+	AlertmanagerMaxConfigVersions              int                    `yaml:"alertmanager_max_config_versions" json:"alertmanager_max_config_versions" category:"experimental"`
+	AlertmanagerNotificationAuditLogSize       int                    `yaml:"alertmanager_notification_audit_log_size" json:"alertmanager_notification_audit_log_size" category:"experimental"`
+	AlertmanagerMaxTemplateOutputSizeBytes     int                    `yaml:"alertmanager_max_template_output_size_bytes" json:"alertmanager_max_template_output_size_bytes" category:"experimental"`
+	AlertmanagerMaxTemplateExecutionTime       model.Duration         `yaml:"alertmanager_max_template_execution_time" json:"alertmanager_max_template_execution_time" category:"experimental"`
+	AlertmanagerTemplateAllowedFunctions       flagext.StringSliceCSV `yaml:"alertmanager_template_allowed_functions" json:"alertmanager_template_allowed_functions" category:"experimental"`
+
+	// API.
+	PushRequestRateLimit  float64 `yaml:"push_request_rate_limit" json:"push_request_rate_limit" category:"experimental"`
+	PushRequestBurstSize  int     `yaml:"push_request_burst_size" json:"push_request_burst_size" category:"experimental"`
+	QueryRequestRateLimit float64 `yaml:"query_request_rate_limit" json:"query_request_rate_limit" category:"experimental"`
+	QueryRequestBurstSize int     `yaml:"query_request_burst_size" json:"query_request_burst_size" category:"experimental"`
+	AdminRequestRateLimit float64 `yaml:"admin_request_rate_limit" json:"admin_request_rate_limit" category:"experimental"`
+	AdminRequestBurstSize int     `yaml:"admin_request_burst_size" json:"admin_request_burst_size" category:"experimental"`
 
 	ForwardingRules ForwardingRules `yaml:"forwarding_rules" json:"forwarding_rules" doc:"nocli|description=Rules based on which the Distributor decides whether a metric should be forwarded to an alternative remote_write API endpoint."`
 }
@@ -135,43 +222,73 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&l.MaxLabelNameLength, "validation.max-length-label-name", 1024, "Maximum length accepted for label names")
 	f.IntVar(&l.MaxLabelValueLength, "validation.max-length-label-value", 2048, "Maximum length accepted for label value. This setting also applies to the metric name")
 	f.IntVar(&l.MaxLabelNamesPerSeries, "validation.max-label-names-per-series", 30, "Maximum number of label names per series.")
+	f.IntVar(&l.MaxLabelsSizeBytes, "validation.max-labels-size-bytes", 0, "Maximum combined size, in bytes, of the names and values of all labels of a series, including the metric name. 0 to disable.")
 	f.IntVar(&l.MaxMetadataLength, "validation.max-metadata-length", 1024, "Maximum length accepted for metric metadata. Metadata refers to Metric Name, HELP and UNIT.")
 	_ = l.CreationGracePeriod.Set("10m")
 	f.Var(&l.CreationGracePeriod, "validation.create-grace-period", "Controls how far into the future incoming samples are accepted compared to the wall clock. Any sample with timestamp `t` will be rejected if `t > (now + validation.create-grace-period)`.")
 	f.BoolVar(&l.EnforceMetadataMetricName, "validation.enforce-metadata-metric-name", true, "Enforce every metadata has a metric name.")
+	f.Var(&l.MinSampleInterval, "distributor.min-sample-interval", "Minimum interval between two samples of the same series. The samples arriving closer together are handled according to -distributor.min-sample-interval-policy. The interval is enforced by each distributor on the samples it receives, so it may not be enforced when the samples of a series are spread across distributors. 0 to disable.")
+	f.StringVar(&l.MinSampleIntervalPolicy, "distributor.min-sample-interval-policy", MinSampleIntervalPolicyReject, "How the distributors handle the samples arriving closer together than -distributor.min-sample-interval. Supported values are: reject (reject the samples with an error), downsample (keep the first sample of each interval, aligned to the interval boundaries, and drop the other samples without error).")
 
 	f.IntVar(&l.MaxGlobalSeriesPerUser, "ingester.max-global-series-per-user", 150000, "The maximum number of active series per tenant, across the cluster before replication. 0 to disable.")
 	f.IntVar(&l.MaxGlobalSeriesPerMetric, "ingester.max-global-series-per-metric", 20000, "The maximum number of active series per metric name, across the cluster before replication. 0 to disable.")
+	f.IntVar(&l.MaxSeriesCreatedPerHour, "ingester.max-series-created-per-hour", 0, "The maximum number of series that can be created in the last hour, across the cluster before replication. New series exceeding the limit are rejected, while the existing series keep being ingested. 0 to disable.")
 
 	f.IntVar(&l.MaxGlobalMetricsWithMetadataPerUser, "ingester.max-global-metadata-per-user", 0, "The maximum number of active metrics with metadata per tenant, across the cluster. 0 to disable.")
 	f.IntVar(&l.MaxGlobalMetadataPerMetric, "ingester.max-global-metadata-per-metric", 0, "The maximum number of metadata per metric, across the cluster. 0 to disable.")
 	f.IntVar(&l.MaxGlobalExemplarsPerUser, "ingester.max-global-exemplars-per-user", 0, "The maximum number of exemplars in memory, across the cluster. 0 to disable exemplars ingestion.")
+	f.IntVar(&l.IngesterShipMaxBytesPerSecond, "ingester.ship-max-bytes-per-second", 0, "Maximum upload bandwidth, in bytes per second, used by each ingester to ship the tenant's blocks to the storage. 0 to disable.")
+	f.StringVar(&l.DuplicateTimestampPolicy, "ingester.duplicate-timestamp-policy", "reject", "How the ingesters handle a sample with the same timestamp as an existing sample of the series but a different value. Supported values are: reject (reject the sample with an error), keep-first (drop the new sample without error), keep-last (keep the most recent of the samples in the same request, and drop the new sample without error if a sample with the same timestamp is already stored).")
+	f.BoolVar(&l.CreatedTimestampZeroIngestionEnabled, "ingester.created-timestamp-zero-ingestion-enabled", false, "True to ingest a zero sample at the created timestamp of the series that carry one, such as counters, histograms and summaries, before their first sample. This improves the correctness of functions like rate() and increase() across counter restarts. The created timestamp must not be newer than the first sample of the series in the same request, otherwise the series is rejected.")
 
 	f.IntVar(&l.MaxChunksPerQuery, "querier.max-fetched-chunks-per-query", 2e6, "Maximum number of chunks that can be fetched in a single query from ingesters and long-term storage. This limit is enforced in the querier, ruler and store-gateway. 0 to disable.")
 	f.IntVar(&l.MaxFetchedSeriesPerQuery, "querier.max-fetched-series-per-query", 0, "The maximum number of unique series for which a query can fetch samples from each ingesters and storage. This limit is enforced in the querier and ruler. 0 to disable")
 	f.IntVar(&l.MaxFetchedChunkBytesPerQuery, "querier.max-fetched-chunk-bytes-per-query", 0, "The maximum size of all chunks in bytes that a query can fetch from each ingester and storage. This limit is enforced in the querier and ruler. 0 to disable.")
+	f.IntVar(&l.MaxChunksPerQueryFromIngesters, "querier.max-fetched-chunks-per-query-from-ingesters", 0, "Maximum number of chunks that can be fetched in a single query from ingesters. This limit is enforced in the querier and ruler, in addition to -querier.max-fetched-chunks-per-query. 0 to disable.")
+	f.IntVar(&l.MaxChunksPerQueryFromStoreGateways, "querier.max-fetched-chunks-per-query-from-store-gateways", 0, "Maximum number of chunks that can be fetched in a single query from store-gateways. This limit is enforced in the querier and ruler, in addition to -querier.max-fetched-chunks-per-query. 0 to disable.")
+	f.IntVar(&l.MaxFetchedSeriesPerQueryFromIngesters, "querier.max-fetched-series-per-query-from-ingesters", 0, "The maximum number of unique series for which a query can fetch samples from ingesters. This limit is enforced in the querier and ruler, in addition to -querier.max-fetched-series-per-query. 0 to disable.")
+	f.IntVar(&l.MaxFetchedSeriesPerQueryFromStoreGateways, "querier.max-fetched-series-per-query-from-store-gateways", 0, "The maximum number of unique series for which a query can fetch samples from store-gateways. This limit is enforced in the querier and ruler, in addition to -querier.max-fetched-series-per-query. 0 to disable.")
+	f.IntVar(&l.MaxFetchedChunkBytesPerQueryFromIngesters, "querier.max-fetched-chunk-bytes-per-query-from-ingesters", 0, "The maximum size of all chunks in bytes that a query can fetch from ingesters. This limit is enforced in the querier and ruler, in addition to -querier.max-fetched-chunk-bytes-per-query. 0 to disable.")
+	f.IntVar(&l.MaxFetchedChunkBytesPerQueryFromStoreGateways, "querier.max-fetched-chunk-bytes-per-query-from-store-gateways", 0, "The maximum size of all chunks in bytes that a query can fetch from store-gateways. This limit is enforced in the querier and ruler, in addition to -querier.max-fetched-chunk-bytes-per-query. 0 to disable.")
+	f.IntVar(&l.MaxFetchedDataBytesPerQuery, "querier.max-fetched-data-bytes-per-query", 0, "The maximum size in bytes of all the data that a query can fetch from ingesters and store-gateways, including the series labels and chunks received by the querier, and the index postings read by the store-gateways. Unlike -querier.max-fetched-chunk-bytes-per-query, it accounts for the actual size of the fetched data. This limit is enforced in the querier and ruler. 0 to disable.")
 	f.Var(&l.MaxQueryLength, "store.max-query-length", "Limit the query time range (end - start time). This limit is enforced in the query-frontend (on the received query), in the querier (on the query possibly split by the query-frontend) and ruler. 0 to disable.")
 	f.Var(&l.MaxQueryLookback, "querier.max-query-lookback", "Limit how long back data (series and metadata) can be queried, up until <lookback> duration ago. This limit is enforced in the query-frontend, querier and ruler. If the requested time range is outside the allowed range, the request will not fail but will be manipulated to only query data within the allowed time range. 0 to disable.")
 	f.IntVar(&l.MaxQueryParallelism, "querier.max-query-parallelism", 14, "Maximum number of split (by time) or partial (by shard) queries that will be scheduled in parallel by the query-frontend for a single input query. This limit is introduced to have a fairer query scheduling and avoid a single query over a large time range saturating all available queriers.")
 	f.Var(&l.MaxLabelsQueryLength, "store.max-labels-query-length", "Limit the time range (end - start time) of series, label names and values queries. This limit is enforced in the querier. If the requested time range is outside the allowed range, the request will not fail but will be manipulated to only query data within the allowed time range. 0 to disable.")
+	f.StringVar(&l.QueryEngine, "querier.query-engine", QueryEngineStandard, "PromQL engine used by the querier to evaluate queries. Supported values are: standard, streaming. The streaming engine evaluates the supported expressions one series at a time, and falls back to the standard engine for any other expression. The engine can be selected for a single query with the Query-Engine HTTP header.")
 	f.IntVar(&l.LabelNamesAndValuesResultsMaxSizeBytes, "querier.label-names-and-values-results-max-size-bytes", 400*1024*1024, "Maximum size in bytes of distinct label names and values. When querier receives response from ingester, it merges the response with responses from other ingesters. This maximum size limit is applied to the merged(distinct) results. If the limit is reached, an error is returned.")
 	f.BoolVar(&l.CardinalityAnalysisEnabled, "querier.cardinality-analysis-enabled", false, "Enables endpoints used for cardinality analysis.")
 	f.IntVar(&l.LabelValuesMaxCardinalityLabelNamesPerRequest, "querier.label-values-max-cardinality-label-names-per-request", 100, "Maximum number of label names allowed to be queried in a single /api/v1/cardinality/label_values API call.")
+	f.IntVar(&l.ActiveSeriesResultsMaxSizeBytes, "querier.active-series-results-max-size-bytes", 400*1024*1024, "Maximum size in bytes of the distinct active series labels returned by a single /api/v1/cardinality/active_series API call. The limit is applied to the results merged from all the ingesters. If the limit is reached, an error is returned. 0 to disable.")
 	_ = l.MaxCacheFreshness.Set("1m")
 	f.Var(&l.MaxCacheFreshness, "query-frontend.max-cache-freshness", "Most recent allowed cacheable result per-tenant, to prevent caching very recent results that might still be in flux.")
+	_ = l.ResultsCacheTTL.Set("7d")
+	f.Var(&l.ResultsCacheTTL, "query-frontend.results-cache-ttl", "Time to live of the query results stored in the results cache for the tenant.")
+	f.Var(&l.ResultsCacheMaxQueryLength, "query-frontend.results-cache-max-query-length", "Queries whose time range is longer than this limit are not cached for the tenant. 0 to disable limit.")
+	f.BoolVar(&l.ResultsCacheUnalignedRequests, "query-frontend.results-cache-unaligned-requests", false, "Cache requests that are not step-aligned for the tenant. Requests that are not step-aligned are cached for all tenants when -query-frontend.cache-unaligned-requests is enabled.")
 	f.IntVar(&l.MaxQueriersPerTenant, "query-frontend.max-queriers-per-tenant", 0, "Maximum number of queriers that can handle requests for a single tenant. If set to 0 or value higher than number of available queriers, *all* queriers will handle requests for the tenant. Each frontend (or query-scheduler, if used) will select the same set of queriers for the same tenant (given that all queriers are connected to all frontends / query-schedulers). This option only works with queriers connecting to the query-frontend / query-scheduler, not when using downstream URL.")
 	f.IntVar(&l.QueryShardingTotalShards, "query-frontend.query-sharding-total-shards", 16, "The amount of shards to use when doing parallelisation via query sharding by tenant. 0 to disable query sharding for tenant. Query sharding implementation will adjust the number of query shards based on compactor shards. This allows querier to not search the blocks which cannot possibly have the series for given query shard.")
 	f.IntVar(&l.QueryShardingMaxShardedQueries, "query-frontend.query-sharding-max-sharded-queries", 128, "The max number of sharded queries that can be run for a given received query. 0 to disable limit.")
+	f.IntVar(&l.QueryShardingTargetSeriesPerShard, "query-frontend.query-sharding-target-series-per-shard", 0, "The target number of series fetched by each query shard. When set, the number of shards of a query is chosen based on the number of series fetched by previous executions of the same query, up to the configured total shards, so that queries fetching few series are not sharded. Requires the results cache to be enabled. 0 to disable.")
+	f.Var(&l.QuerySLOLatencyThreshold, "query-frontend.query-slo-latency-threshold", "Max response time of the tenant's queries meeting the latency objective of the query SLO. When set, the query-frontend tracks the tenant's queries, the successful ones and the ones within the latency objective in the cortex_query_frontend_slo_* metrics. 0 to disable.")
+	f.Var(&l.SlowQueryLogThreshold, "query-frontend.slow-query-log-threshold", "Response time above which the tenant's queries are logged to the slow query log configured with -query-frontend.slow-query-log-file. 0 to disable.")
+	f.IntVar(&l.MaxConcurrentQueriesPerTenant, "query-frontend.max-concurrent-queries-per-tenant", 0, "Maximum number of range and instant queries of the tenant run concurrently by each query-frontend. The queries above the limit wait in a per-tenant queue, up to -query-frontend.max-queued-queries-per-tenant, and are rejected with the 429 status code once the queue is full. 0 to disable.")
+	f.IntVar(&l.MaxQueuedQueriesPerTenant, "query-frontend.max-queued-queries-per-tenant", 10, "Maximum number of queries of the tenant waiting in each query-frontend for one of the concurrent queries allowed by -query-frontend.max-concurrent-queries-per-tenant to complete. 0 to reject the queries above the limit immediately.")
 
 	f.Var(&l.RulerEvaluationDelay, "ruler.evaluation-delay-duration", "Duration to delay the evaluation of rules to ensure the underlying metrics have been pushed.")
 	f.IntVar(&l.RulerTenantShardSize, "ruler.tenant-shard-size", 0, "The tenant's shard size when sharding is used by ruler. Value of 0 disables shuffle sharding for the tenant, and tenant rules will be sharded across all ruler replicas.")
 	f.IntVar(&l.RulerMaxRulesPerRuleGroup, "ruler.max-rules-per-rule-group", 20, "Maximum number of rules per rule group per-tenant. 0 to disable.")
 	f.IntVar(&l.RulerMaxRuleGroupsPerTenant, "ruler.max-rule-groups-per-tenant", 70, "Maximum number of rule groups per-tenant. 0 to disable.")
+	f.StringVar(&l.RulerAlertmanagerURL, "ruler.tenant-alertmanager-url", "", "Comma-separated list of URL(s) of the Alertmanager(s) to send the tenant's notifications to, overriding -ruler.alertmanager-url. Supports the same formats of -ruler.alertmanager-url. If empty, -ruler.alertmanager-url is used.")
 
 	f.Var(&l.CompactorBlocksRetentionPeriod, "compactor.blocks-retention-period", "Delete blocks containing samples older than the specified retention period. 0 to disable.")
 	f.IntVar(&l.CompactorSplitAndMergeShards, "compactor.split-and-merge-shards", 0, "The number of shards to use when splitting blocks. 0 to disable splitting.")
 	f.IntVar(&l.CompactorSplitGroups, "compactor.split-groups", 1, "Number of groups that blocks for splitting should be grouped into. Each group of blocks is then split separately. Number of output split shards is controlled by -compactor.split-and-merge-shards.")
 	f.IntVar(&l.CompactorTenantShardSize, "compactor.compactor-tenant-shard-size", 0, "Max number of compactors that can compact blocks for single tenant. 0 to disable the limit and use all compactors.")
+	f.IntVar(&l.CompactorTenantMaxShardSize, "compactor.compactor-tenant-max-shard-size", 0, "Max number of compactors the tenant's shard can be automatically grown to, when the tenant has a backlog of compaction jobs. The shard is shrunk back once the backlog has been compacted, down to -compactor.compactor-tenant-shard-size. Ignored if -compactor.compactor-tenant-shard-size is 0. 0 to disable.")
+	f.IntVar(&l.CompactorTenantShardSizeJobsPerCompactor, "compactor.compactor-tenant-shard-size-jobs-per-compactor", 10, "Number of pending compaction jobs per compactor in the tenant's shard above which the shard is grown, when -compactor.compactor-tenant-max-shard-size is set.")
+	f.Var(&l.CompactorBlocksObjectLockPeriod, "compactor.blocks-object-lock-period", "Minimum time the objects of the tenant's blocks are locked in the storage after being written, when the bucket enforces object lock or immutability. The compactor doesn't delete blocks until all their objects are unlocked, and keeps them marked for deletion in the meanwhile. Must not be greater than the blocks retention period. 0 to disable.")
+	f.StringVar(&l.CompactorVerticalMergeStrategy, "compactor.vertical-merge-strategy", VerticalMergeStrategyChain, "How samples with the same timestamp and different values are resolved when compacting overlapping blocks. Supported values are: chain (keep any of them), max-value (keep the highest value), error (fail the compaction).")
 
 	// Store-gateway.
 	f.IntVar(&l.StoreGatewayTenantShardSize, "store-gateway.tenant-shard-size", 0, "The tenant's shard size, used when store-gateway sharding is enabled. Value of 0 disables shuffle sharding for the tenant, that is all tenant blocks are sharded across all store-gateway replicas.")
@@ -192,6 +309,19 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&l.AlertmanagerMaxDispatcherAggregationGroups, "alertmanager.max-dispatcher-aggregation-groups", 0, "Maximum number of aggregation groups in Alertmanager's dispatcher that a tenant can have. Each active aggregation group uses single goroutine. When the limit is reached, dispatcher will not dispatch alerts that belong to additional aggregation groups, but existing groups will keep working properly. 0 = no limit.")
 	f.IntVar(&l.AlertmanagerMaxAlertsCount, "alertmanager.max-alerts-count", 0, "Maximum number of alerts that a single tenant can have. Inserting more alerts will fail with a log message and metric increment. 0 = no limit.")
 	f.IntVar(&l.AlertmanagerMaxAlertsSizeBytes, "alertmanager.max-alerts-size-bytes", 0, "Maximum total size of alerts that a single tenant can have, alert size is the sum of the bytes of its labels, annotations and generatorURL. Inserting more alerts will fail with a log message and metric increment. 0 = no limit.")
+	f.IntVar(&l.AlertmanagerReplicationFactor, "alertmanager.replication-factor", 0, "Number of Alertmanager replicas the tenant's alerts, silences and notification state are sharded and replicated to. Values higher than the Alertmanager sharding ring replication factor are capped to it. 0 = use the sharding ring replication factor.")
+	f.IntVar(&l.AlertmanagerMaxConfigVersions, "alertmanager.max-config-versions", 0, "Maximum number of versions of the tenant's Alertmanager configuration, including templates and assets, to keep in the storage. A new version is stored every time the configuration is changed through the API, and old versions can be restored. 0 = versioning disabled.")
+	f.IntVar(&l.AlertmanagerNotificationAuditLogSize, "alertmanager.notification-audit-log-size", 0, "Maximum number of notification attempts of the tenant, including their receiver, integration, status and latency, to keep in memory in each Alertmanager replica and to expose via the notification audit log API. 0 = audit log disabled.")
+	f.IntVar(&l.AlertmanagerMaxTemplateOutputSizeBytes, "alertmanager.max-template-output-size-bytes", 0, "Maximum size of the output of a template of the tenant, when rendered against sample notification data while validating the Alertmanager configuration uploaded through the API, and when rendering a notification. Notifications exceeding the limit are not sent. 0 = no limit.")
+	f.Var(&l.AlertmanagerMaxTemplateExecutionTime, "alertmanager.max-template-execution-time", "Maximum time to render a template of the tenant, when rendered against sample notification data while validating the Alertmanager configuration uploaded through the API, and when rendering a notification. Notifications exceeding the limit are not sent. 0 = no limit.")
+	f.Var(&l.AlertmanagerTemplateAllowedFunctions, "alertmanager.template-allowed-functions", "Comma-separated list of Alertmanager template functions (eg. toUpper, reReplaceAll) the tenant's templates are allowed to use. The functions built into the Go template engine are always allowed. Empty = all functions are allowed.")
+
+	f.Float64Var(&l.PushRequestRateLimit, "api.push-endpoints.request-rate-limit", 0, "Per-tenant rate limit, in requests per second, of the authenticated HTTP requests to the push endpoints, enforced by each Mimir instance serving them. The requests above the limit are rejected with the 429 status code. 0 to disable.")
+	f.IntVar(&l.PushRequestBurstSize, "api.push-endpoints.request-burst-size", 0, "Per-tenant allowed burst size, in number of requests, of the authenticated HTTP requests to the push endpoints. 0 to use the request rate limit, rounded up.")
+	f.Float64Var(&l.QueryRequestRateLimit, "api.query-endpoints.request-rate-limit", 0, "Per-tenant rate limit, in requests per second, of the authenticated HTTP requests to the query endpoints, enforced by each Mimir instance serving them. The requests above the limit are rejected with the 429 status code. 0 to disable.")
+	f.IntVar(&l.QueryRequestBurstSize, "api.query-endpoints.request-burst-size", 0, "Per-tenant allowed burst size, in number of requests, of the authenticated HTTP requests to the query endpoints. 0 to use the request rate limit, rounded up.")
+	f.Float64Var(&l.AdminRequestRateLimit, "api.admin-endpoints.request-rate-limit", 0, "Per-tenant rate limit, in requests per second, of the authenticated HTTP requests to the admin endpoints, enforced by each Mimir instance serving them. The requests above the limit are rejected with the 429 status code. 0 to disable.")
+	f.IntVar(&l.AdminRequestBurstSize, "api.admin-endpoints.request-burst-size", 0, "Per-tenant allowed burst size, in number of requests, of the authenticated HTTP requests to the admin endpoints. 0 to use the request rate limit, rounded up.")
 }
 
 // UnmarshalYAML implements the yaml.Unmarshaler interface.
@@ -207,7 +337,11 @@ func (l *Limits) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		l.copyNotificationIntegrationLimits(defaultLimits.NotificationRateLimitPerIntegration)
 	}
 	type plain Limits
-	return unmarshal((*plain)(l))
+	if err := unmarshal((*plain)(l)); err != nil {
+		return err
+	}
+
+	return l.Validate()
 }
 
 // UnmarshalJSON implements the json.Unmarshaler interface.
@@ -225,7 +359,41 @@ func (l *Limits) UnmarshalJSON(data []byte) error {
 	dec := json.NewDecoder(bytes.NewReader(data))
 	dec.DisallowUnknownFields()
 
-	return dec.Decode((*plain)(l))
+	if err := dec.Decode((*plain)(l)); err != nil {
+		return err
+	}
+
+	return l.Validate()
+}
+
+// Validate returns an error if any of the limits has an unsupported value. It's called when the limits
+// are unmarshalled, so that invalid per-tenant overrides are rejected when the runtime config is loaded.
+func (l *Limits) Validate() error {
+	switch l.MinSampleIntervalPolicy {
+	case MinSampleIntervalPolicyReject, MinSampleIntervalPolicyDownsample:
+	default:
+		return fmt.Errorf("unsupported min sample interval policy %q, supported values are: %s, %s", l.MinSampleIntervalPolicy, MinSampleIntervalPolicyReject, MinSampleIntervalPolicyDownsample)
+	}
+
+	switch l.CompactorVerticalMergeStrategy {
+	case VerticalMergeStrategyChain, VerticalMergeStrategyMaxValue, VerticalMergeStrategyError:
+	default:
+		return fmt.Errorf("unsupported compactor vertical merge strategy %q, supported values are: %s, %s, %s", l.CompactorVerticalMergeStrategy, VerticalMergeStrategyChain, VerticalMergeStrategyMaxValue, VerticalMergeStrategyError)
+	}
+
+	switch l.DuplicateTimestampPolicy {
+	case DuplicateTimestampPolicyReject, DuplicateTimestampPolicyKeepFirst, DuplicateTimestampPolicyKeepLast:
+	default:
+		return fmt.Errorf("unsupported duplicate timestamp policy %q, supported values are: %s, %s, %s", l.DuplicateTimestampPolicy, DuplicateTimestampPolicyReject, DuplicateTimestampPolicyKeepFirst, DuplicateTimestampPolicyKeepLast)
+	}
+
+	switch l.QueryEngine {
+	case QueryEngineStandard, QueryEngineStreaming:
+	default:
+		return fmt.Errorf("unsupported query engine %q, supported values are: %s, %s", l.QueryEngine, QueryEngineStandard, QueryEngineStreaming)
+	}
+
+	return nil
 }
 
 func (l *Limits) copyNotificationIntegrationLimits(defaults NotificationRateLimitMap) {
@@ -291,6 +459,11 @@ func (o *Overrides) LabelValuesMaxCardinalityLabelNamesPerRequest(userID string)
 	return o.getOverridesForUser(userID).LabelValuesMaxCardinalityLabelNamesPerRequest
 }
 
+// ActiveSeriesResultsMaxSizeBytes returns the maximum size in bytes of the distinct active series labels.
+func (o *Overrides) ActiveSeriesResultsMaxSizeBytes(userID string) int {
+	return o.getOverridesForUser(userID).ActiveSeriesResultsMaxSizeBytes
+}
+
 // IngestionBurstSize returns the burst size for ingestion rate.
 func (o *Overrides) IngestionBurstSize(userID string) int {
 	return o.getOverridesForUser(userID).IngestionBurstSize
@@ -332,12 +505,27 @@ func (o *Overrides) MaxLabelNamesPerSeries(userID string) int {
 	return o.getOverridesForUser(userID).MaxLabelNamesPerSeries
 }
 
+// MaxLabelsSizeBytes returns the maximum combined size, in bytes, of the label names and values of a series.
+func (o *Overrides) MaxLabelsSizeBytes(userID string) int {
+	return o.getOverridesForUser(userID).MaxLabelsSizeBytes
+}
+
 // MaxMetadataLength returns maximum length metadata can be. Metadata refers
 // to the Metric Name, HELP and UNIT.
 func (o *Overrides) MaxMetadataLength(userID string) int {
 	return o.getOverridesForUser(userID).MaxMetadataLength
 }
 
+// MinSampleInterval returns the minimum interval between two samples of the same series, or 0 if disabled.
+func (o *Overrides) MinSampleInterval(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).MinSampleInterval)
+}
+
+// MinSampleIntervalPolicy returns how the samples arriving closer together than the minimum sample interval are handled.
+func (o *Overrides) MinSampleIntervalPolicy(userID string) string {
+	return o.getOverridesForUser(userID).MinSampleIntervalPolicy
+}
+
 // CreationGracePeriod is misnamed, and actually returns how far into the future
 // we should accept samples.
 func (o *Overrides) CreationGracePeriod(userID string) time.Duration {
@@ -349,6 +537,11 @@ func (o *Overrides) MaxGlobalSeriesPerUser(userID string) int {
 	return o.getOverridesForUser(userID).MaxGlobalSeriesPerUser
 }
 
+// MaxSeriesCreatedPerHour returns the maximum number of series which can be created in the last hour across the cluster.
+func (o *Overrides) MaxSeriesCreatedPerHour(userID string) int {
+	return o.getOverridesForUser(userID).MaxSeriesCreatedPerHour
+}
+
 // MaxGlobalSeriesPerMetric returns the maximum number of series allowed per metric across the cluster.
 func (o *Overrides) MaxGlobalSeriesPerMetric(userID string) int {
 	return o.getOverridesForUser(userID).MaxGlobalSeriesPerMetric
@@ -370,6 +563,48 @@ func (o *Overrides) MaxFetchedChunkBytesPerQuery(userID string) int {
 	return o.getOverridesForUser(userID).MaxFetchedChunkBytesPerQuery
 }
 
+// MaxChunksPerQueryFromIngesters returns the maximum number of chunks allowed per query when fetching
+// chunks from ingesters.
+func (o *Overrides) MaxChunksPerQueryFromIngesters(userID string) int {
+	return o.getOverridesForUser(userID).MaxChunksPerQueryFromIngesters
+}
+
+// MaxChunksPerQueryFromStoreGateways returns the maximum number of chunks allowed per query when fetching
+// chunks from store-gateways.
+func (o *Overrides) MaxChunksPerQueryFromStoreGateways(userID string) int {
+	return o.getOverridesForUser(userID).MaxChunksPerQueryFromStoreGateways
+}
+
+// MaxFetchedSeriesPerQueryFromIngesters returns the maximum number of series allowed per query when fetching
+// chunks from ingesters.
+func (o *Overrides) MaxFetchedSeriesPerQueryFromIngesters(userID string) int {
+	return o.getOverridesForUser(userID).MaxFetchedSeriesPerQueryFromIngesters
+}
+
+// MaxFetchedSeriesPerQueryFromStoreGateways returns the maximum number of series allowed per query when fetching
+// chunks from store-gateways.
+func (o *Overrides) MaxFetchedSeriesPerQueryFromStoreGateways(userID string) int {
+	return o.getOverridesForUser(userID).MaxFetchedSeriesPerQueryFromStoreGateways
+}
+
+// MaxFetchedChunkBytesPerQueryFromIngesters returns the maximum number of bytes for chunks allowed per query when
+// fetching chunks from ingesters.
+func (o *Overrides) MaxFetchedChunkBytesPerQueryFromIngesters(userID string) int {
+	return o.getOverridesForUser(userID).MaxFetchedChunkBytesPerQueryFromIngesters
+}
+
+// MaxFetchedChunkBytesPerQueryFromStoreGateways returns the maximum number of bytes for chunks allowed per query
+// when fetching chunks from store-gateways.
+func (o *Overrides) MaxFetchedChunkBytesPerQueryFromStoreGateways(userID string) int {
+	return o.getOverridesForUser(userID).MaxFetchedChunkBytesPerQueryFromStoreGateways
+}
+
+// MaxFetchedDataBytesPerQuery returns the maximum size in bytes of all the data allowed per query when fetching
+// data from ingesters and store-gateways.
+func (o *Overrides) MaxFetchedDataBytesPerQuery(userID string) int {
+	return o.getOverridesForUser(userID).MaxFetchedDataBytesPerQuery
+}
+
 // MaxQueryLookback returns the max lookback period of queries.
 func (o *Overrides) MaxQueryLookback(userID string) time.Duration {
 	return time.Duration(o.getOverridesForUser(userID).MaxQueryLookback)
@@ -391,6 +626,41 @@ func (o *Overrides) MaxCacheFreshness(userID string) time.Duration {
 	return time.Duration(o.getOverridesForUser(userID).MaxCacheFreshness)
 }
 
+// ResultsCacheTTL returns the time to live of the query results stored in the results cache.
+func (o *Overrides) ResultsCacheTTL(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).ResultsCacheTTL)
+}
+
+// ResultsCacheMaxQueryLength returns the max length (in time) of a query whose results can be cached.
+func (o *Overrides) ResultsCacheMaxQueryLength(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).ResultsCacheMaxQueryLength)
+}
+
+// ResultsCacheUnalignedRequests returns whether requests that are not step-aligned can be cached.
+func (o *Overrides) ResultsCacheUnalignedRequests(userID string) bool {
+	return o.getOverridesForUser(userID).ResultsCacheUnalignedRequests
+}
+
+// QuerySLOLatencyThreshold returns the max response time of the queries meeting the tenant's latency objective.
+func (o *Overrides) QuerySLOLatencyThreshold(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).QuerySLOLatencyThreshold)
+}
+
+// SlowQueryLogThreshold returns the response time above which the tenant's queries are logged to the slow query log.
+func (o *Overrides) SlowQueryLogThreshold(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).SlowQueryLogThreshold)
+}
+
+// MaxConcurrentQueriesPerTenant returns the max number of queries of the tenant run concurrently by each query-frontend.
+func (o *Overrides) MaxConcurrentQueriesPerTenant(userID string) int {
+	return o.getOverridesForUser(userID).MaxConcurrentQueriesPerTenant
+}
+
+// MaxQueuedQueriesPerTenant returns the max number of queries of the tenant waiting to run in each query-frontend.
+func (o *Overrides) MaxQueuedQueriesPerTenant(userID string) int {
+	return o.getOverridesForUser(userID).MaxQueuedQueriesPerTenant
+}
+
 // MaxQueriersPerUser returns the maximum number of queriers that can handle requests for this user.
 func (o *Overrides) MaxQueriersPerUser(userID string) int {
 	return o.getOverridesForUser(userID).MaxQueriersPerTenant
@@ -414,6 +684,17 @@ func (o *Overrides) QueryShardingMaxShardedQueries(userID string) int {
 	return o.getOverridesForUser(userID).QueryShardingMaxShardedQueries
 }
 
+// QueryShardingTargetSeriesPerShard returns the target number of series fetched by each query shard.
+// 0 to disable cardinality-based shard count selection.
+func (o *Overrides) QueryShardingTargetSeriesPerShard(userID string) int {
+	return o.getOverridesForUser(userID).QueryShardingTargetSeriesPerShard
+}
+
+// QueryEngine returns the PromQL engine used to evaluate the queries of the tenant.
+func (o *Overrides) QueryEngine(userID string) string {
+	return o.getOverridesForUser(userID).QueryEngine
+}
+
 // EnforceMetadataMetricName whether to enforce the presence of a metric name on metadata.
 func (o *Overrides) EnforceMetadataMetricName(userID string) bool {
 	return o.getOverridesForUser(userID).EnforceMetadataMetricName
@@ -434,6 +715,21 @@ func (o *Overrides) MaxGlobalExemplarsPerUser(userID string) int {
 	return o.getOverridesForUser(userID).MaxGlobalExemplarsPerUser
 }
 
+// DuplicateTimestampPolicy returns how samples with the same timestamp as an existing sample and a different value are handled.
+func (o *Overrides) DuplicateTimestampPolicy(userID string) string {
+	return o.getOverridesForUser(userID).DuplicateTimestampPolicy
+}
+
+// CreatedTimestampZeroIngestionEnabled returns whether a zero sample is ingested at the created timestamp of the series.
+func (o *Overrides) CreatedTimestampZeroIngestionEnabled(userID string) bool {
+	return o.getOverridesForUser(userID).CreatedTimestampZeroIngestionEnabled
+}
+
+// IngesterShipMaxBytesPerSecond returns the maximum bandwidth each ingester can use to ship the blocks of a given user.
+func (o *Overrides) IngesterShipMaxBytesPerSecond(userID string) int {
+	return o.getOverridesForUser(userID).IngesterShipMaxBytesPerSecond
+}
+
 // IngestionTenantShardSize returns the ingesters shard size for a given user.
 func (o *Overrides) IngestionTenantShardSize(userID string) int {
 	return o.getOverridesForUser(userID).IngestionTenantShardSize
@@ -444,16 +740,37 @@ func (o *Overrides) CompactorTenantShardSize(userID string) int {
 	return o.getOverridesForUser(userID).CompactorTenantShardSize
 }
 
+// CompactorTenantMaxShardSize returns the max number of compactors the user's shard can be automatically grown to. 0 = disabled.
+func (o *Overrides) CompactorTenantMaxShardSize(userID string) int {
+	return o.getOverridesForUser(userID).CompactorTenantMaxShardSize
+}
+
+// CompactorTenantShardSizeJobsPerCompactor returns the number of pending compaction jobs per compactor above which
+// the user's shard is grown.
+func (o *Overrides) CompactorTenantShardSizeJobsPerCompactor(userID string) int {
+	return o.getOverridesForUser(userID).CompactorTenantShardSizeJobsPerCompactor
+}
+
 // EvaluationDelay returns the rules evaluation delay for a given user.
 func (o *Overrides) EvaluationDelay(userID string) time.Duration {
 	return time.Duration(o.getOverridesForUser(userID).RulerEvaluationDelay)
 }
 
+// CompactorVerticalMergeStrategy returns the strategy used to resolve conflicting samples when compacting overlapping blocks.
+func (o *Overrides) CompactorVerticalMergeStrategy(userID string) string {
+	return o.getOverridesForUser(userID).CompactorVerticalMergeStrategy
+}
+
 // CompactorBlocksRetentionPeriod returns the retention period for a given user.
 func (o *Overrides) CompactorBlocksRetentionPeriod(userID string) time.Duration {
 	return time.Duration(o.getOverridesForUser(userID).CompactorBlocksRetentionPeriod)
 }
 
+// CompactorBlocksObjectLockPeriod returns the minimum time the objects of the blocks are locked in the storage for a given user.
+func (o *Overrides) CompactorBlocksObjectLockPeriod(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).CompactorBlocksObjectLockPeriod)
+}
+
 // CompactorSplitAndMergeShards returns the number of shards to use when splitting blocks.
 func (o *Overrides) CompactorSplitAndMergeShards(userID string) int {
 	return o.getOverridesForUser(userID).CompactorSplitAndMergeShards
@@ -484,6 +801,11 @@ func (o *Overrides) RulerMaxRuleGroupsPerTenant(userID string) int {
 	return o.getOverridesForUser(userID).RulerMaxRuleGroupsPerTenant
 }
 
+// RulerAlertmanagerURL returns the Alertmanager URL(s) overriding the ruler's ones for a given user.
+func (o *Overrides) RulerAlertmanagerURL(userID string) string {
+	return o.getOverridesForUser(userID).RulerAlertmanagerURL
+}
+
 // StoreGatewayTenantShardSize returns the store-gateway shard size for a given user.
 func (o *Overrides) StoreGatewayTenantShardSize(userID string) int {
 	return o.getOverridesForUser(userID).StoreGatewayTenantShardSize
@@ -593,6 +915,60 @@ func (o *Overrides) AlertmanagerMaxAlertsSizeBytes(userID string) int {
 	return o.getOverridesForUser(userID).AlertmanagerMaxAlertsSizeBytes
 }
 
+func (o *Overrides) AlertmanagerReplicationFactor(userID string) int {
+	return o.getOverridesForUser(userID).AlertmanagerReplicationFactor
+}
+
+func (o *Overrides) AlertmanagerMaxConfigVersions(userID string) int {
+	return o.getOverridesForUser(userID).AlertmanagerMaxConfigVersions
+}
+
+func (o *Overrides) AlertmanagerNotificationAuditLogSize(userID string) int {
+	return o.getOverridesForUser(userID).AlertmanagerNotificationAuditLogSize
+}
+
+func (o *Overrides) AlertmanagerMaxTemplateOutputSizeBytes(userID string) int {
+	return o.getOverridesForUser(userID).AlertmanagerMaxTemplateOutputSizeBytes
+}
+
+func (o *Overrides) AlertmanagerMaxTemplateExecutionTime(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).AlertmanagerMaxTemplateExecutionTime)
+}
+
+func (o *Overrides) AlertmanagerTemplateAllowedFunctions(userID string) []string {
+	return o.getOverridesForUser(userID).AlertmanagerTemplateAllowedFunctions
+}
+
+// PushRequestRateLimit returns the limit of the rate of the HTTP requests of the tenant to the push endpoints.
+func (o *Overrides) PushRequestRateLimit(userID string) float64 {
+	return o.getOverridesForUser(userID).PushRequestRateLimit
+}
+
+// PushRequestBurstSize returns the burst size of the HTTP requests of the tenant to the push endpoints.
+func (o *Overrides) PushRequestBurstSize(userID string) int {
+	return o.getOverridesForUser(userID).PushRequestBurstSize
+}
+
+// QueryRequestRateLimit returns the limit of the rate of the HTTP requests of the tenant to the query endpoints.
+func (o *Overrides) QueryRequestRateLimit(userID string) float64 {
+	return o.getOverridesForUser(userID).QueryRequestRateLimit
+}
+
+// QueryRequestBurstSize returns the burst size of the HTTP requests of the tenant to the query endpoints.
+func (o *Overrides) QueryRequestBurstSize(userID string) int {
+	return o.getOverridesForUser(userID).QueryRequestBurstSize
+}
+
+// AdminRequestRateLimit returns the limit of the rate of the HTTP requests of the tenant to the admin endpoints.
+func (o *Overrides) AdminRequestRateLimit(userID string) float64 {
+	return o.getOverridesForUser(userID).AdminRequestRateLimit
+}
+
+// AdminRequestBurstSize returns the burst size of the HTTP requests of the tenant to the admin endpoints.
+func (o *Overrides) AdminRequestBurstSize(userID string) int {
+	return o.getOverridesForUser(userID).AdminRequestBurstSize
+}
+
 func (o *Overrides) ForwardingRules(user string) ForwardingRules {
 	return o.getOverridesForUser(user).ForwardingRules
 }
@@ -12,6 +12,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/grafana/dskit/flagext"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/relabel"
 	"github.com/stretchr/testify/assert"
@@ -71,9 +72,9 @@ func TestOverridesManager_GetOverrides(t *testing.T) {
 }
 
 func TestLimitsLoadingFromYaml(t *testing.T) {
-	SetDefaultLimitsForYAMLUnmarshalling(Limits{
-		MaxLabelNameLength: 100,
-	})
+	defaults := limitsWithFlagDefaults()
+	defaults.MaxLabelNameLength = 100
+	SetDefaultLimitsForYAMLUnmarshalling(defaults)
 
 	inp := `ingestion_rate: 0.5`
 
@@ -86,9 +87,9 @@ func TestLimitsLoadingFromYaml(t *testing.T) {
 }
 
 func TestLimitsLoadingFromJson(t *testing.T) {
-	SetDefaultLimitsForYAMLUnmarshalling(Limits{
-		MaxLabelNameLength: 100,
-	})
+	defaults := limitsWithFlagDefaults()
+	defaults.MaxLabelNameLength = 100
+	SetDefaultLimitsForYAMLUnmarshalling(defaults)
 
 	inp := `{"ingestion_rate": 0.5}`
 
@@ -109,6 +110,45 @@ func TestLimitsLoadingFromJson(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestLimitsLoadingShouldFailOnUnsupportedValues(t *testing.T) {
+	SetDefaultLimitsForYAMLUnmarshalling(limitsWithFlagDefaults())
+
+	for name, tc := range map[string]struct {
+		field         string
+		expectedError string
+	}{
+		"min sample interval policy": {
+			field:         "min_sample_interval_policy",
+			expectedError: `unsupported min sample interval policy "drop", supported values are: reject, downsample`,
+		},
+		"compactor vertical merge strategy": {
+			field:         "compactor_vertical_merge_strategy",
+			expectedError: `unsupported compactor vertical merge strategy "drop", supported values are: chain, max-value, error`,
+		},
+		"duplicate timestamp policy": {
+			field:         "duplicate_timestamp_policy",
+			expectedError: `unsupported duplicate timestamp policy "drop", supported values are: reject, keep-first, keep-last`,
+		},
+		"query engine": {
+			field:         "query_engine",
+			expectedError: `unsupported query engine "drop", supported values are: standard, streaming`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			l := Limits{}
+			err := yaml.UnmarshalStrict([]byte(tc.field+`: drop`), &l)
+			assert.EqualError(t, err, tc.expectedError)
+
+			l = Limits{}
+			err = json.Unmarshal([]byte(`{"`+tc.field+`": "drop"}`), &l)
+			assert.EqualError(t, err, tc.expectedError)
+		})
+	}
+
+	l := Limits{}
+	require.NoError(t, yaml.UnmarshalStrict([]byte(`min_sample_interval_policy: downsample`), &l))
+}
+
 func TestLimitsTagsYamlMatchJson(t *testing.T) {
 	limits := reflect.TypeOf(Limits{})
 	n := limits.NumField()
@@ -137,6 +177,8 @@ max_query_length: 1s
 `
 	inputJSON := `{"max_query_lookback": "1s", "max_query_length": "1s"}`
 
+	SetDefaultLimitsForYAMLUnmarshalling(limitsWithFlagDefaults())
+
 	limitsYAML := Limits{}
 	err := yaml.Unmarshal([]byte(inputYAML), &limitsYAML)
 	require.NoError(t, err, "expected to be able to unmarshal from YAML")
@@ -165,7 +207,7 @@ func TestLimitsAlwaysUsesPromDuration(t *testing.T) {
 }
 
 func TestMetricRelabelConfigLimitsLoadingFromYaml(t *testing.T) {
-	SetDefaultLimitsForYAMLUnmarshalling(Limits{})
+	SetDefaultLimitsForYAMLUnmarshalling(limitsWithFlagDefaults())
 
 	inp := `
 metric_relabel_configs:
@@ -335,6 +377,8 @@ alertmanager_notification_rate_limit_per_integration:
 		},
 	} {
 		t.Run(name, func(t *testing.T) {
+			SetDefaultLimitsForYAMLUnmarshalling(limitsWithFlagDefaults())
+
 			limitsYAML := Limits{}
 			err := yaml.Unmarshal([]byte(tc.inputYAML), &limitsYAML)
 			require.NoError(t, err, "expected to be able to unmarshal from YAML")
@@ -467,7 +511,7 @@ testuser:
 		},
 	} {
 		t.Run(name, func(t *testing.T) {
-			SetDefaultLimitsForYAMLUnmarshalling(Limits{})
+			SetDefaultLimitsForYAMLUnmarshalling(limitsWithFlagDefaults())
 
 			limitsYAML := Limits{}
 			err := yaml.Unmarshal([]byte(baseYaml), &limitsYAML)
@@ -489,3 +533,10 @@ testuser:
 		})
 	}
 }
+
+// limitsWithFlagDefaults returns the limits set to the default values of their flags.
+func limitsWithFlagDefaults() Limits {
+	limits := Limits{}
+	flagext.DefaultValues(&limits)
+	return limits
+}
@@ -19,14 +19,12 @@ import (
 	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/util"
 	"github.com/grafana/mimir/pkg/util/extract"
+	"github.com/grafana/mimir/pkg/util/globalerror"
 )
 
 const (
 	discardReasonLabel = "reason"
 
-	errMetadataMissingMetricName = "metadata missing metric name"
-	errMetadataTooLong           = "metadata '%s' value too long: %.200q metric %.200q"
-
 	typeMetricName = "METRIC_NAME"
 	typeHelp       = "HELP"
 	typeUnit       = "UNIT"
@@ -35,12 +33,10 @@ const (
 	helpTooLong       = "help_too_long"
 	unitTooLong       = "unit_too_long"
 
-	// ErrQueryTooLong is used in chunk store, querier and query frontend.
-	ErrQueryTooLong = "the query time range exceeds the limit (query length: %s, limit: %s)"
-
 	missingMetricName      = "missing_metric_name"
 	invalidMetricName      = "metric_name_invalid"
 	maxLabelNamesPerSeries = "max_label_names_per_series"
+	maxLabelsSizeBytes     = "max_labels_size_bytes"
 	tooFarInFuture         = "too_far_in_future"
 	invalidLabel           = "label_invalid"
 	labelNameTooLong       = "label_name_too_long"
@@ -48,6 +44,9 @@ const (
 	labelsNotSorted        = "labels_not_sorted"
 	labelValueTooLong      = "label_value_too_long"
 
+	// Created timestamp validation reasons
+	createdTimestampInvalid = "created_timestamp_invalid"
+
 	// Exemplar-specific validation reasons
 	exemplarLabelsMissing    = "exemplar_labels_missing"
 	exemplarLabelsBlank      = "exemplar_labels_blank"
@@ -62,11 +61,23 @@ const (
 	// Too many HA clusters is one of the reasons for discarding samples.
 	TooManyHAClusters = "too_many_ha_clusters"
 
+	// SampleTooFrequent is the reason for discarding the samples arriving closer together than the
+	// minimum sample interval of the tenant. Declared here because enforced by the distributor.
+	SampleTooFrequent = "sample_too_frequent"
+
 	// The combined length of the label names and values of an Exemplar's LabelSet MUST NOT exceed 128 UTF-8 characters
 	// https://github.com/OpenObservability/OpenMetrics/blob/main/specification/OpenMetrics.md#exemplars
 	ExemplarMaxLabelSetLength = 128
 )
 
+var (
+	errMetadataMissingMetricName = globalerror.MetadataMissingMetricName.Message("metadata missing metric name")
+	errMetadataTooLong           = globalerror.MetadataTooLong.Message("metadata '%s' value too long: %.200q metric %.200q")
+
+	// ErrQueryTooLong is used in chunk store, querier and query frontend.
+	ErrQueryTooLong = globalerror.MaxQueryLength.Message("the query time range exceeds the limit (query length: %s, limit: %s)")
+)
+
 // DiscardedSamples is a metric of the number of discarded samples, by reason.
 var DiscardedSamples = prometheus.NewCounterVec(
 	prometheus.CounterOpts{
@@ -119,6 +130,18 @@ func ValidateSample(now model.Time, cfg SampleValidationConfig, userID string, l
 	return nil
 }
 
+// ValidateCreatedTimestamp returns an error if the created timestamp of the series is newer than its first sample.
+// The returned error may retain the provided series labels.
+func ValidateCreatedTimestamp(userID string, ls []mimirpb.LabelAdapter, createdTimestamp int64, firstSample mimirpb.Sample) ValidationError {
+	if createdTimestamp < 0 || createdTimestamp > firstSample.TimestampMs {
+		unsafeMetricName, _ := extract.UnsafeMetricNameFromLabelAdapters(ls)
+		DiscardedSamples.WithLabelValues(createdTimestampInvalid, userID).Inc()
+		return newCreatedTimestampInvalidError(unsafeMetricName, createdTimestamp)
+	}
+
+	return nil
+}
+
 // ValidateExemplar returns an error if the exemplar is invalid.
 // The returned error may retain the provided series labels.
 func ValidateExemplar(userID string, ls []mimirpb.LabelAdapter, e mimirpb.Exemplar) ValidationError {
@@ -183,6 +206,7 @@ func ExemplarTimestampOK(userID string, minTS int64, e mimirpb.Exemplar) bool {
 // LabelValidationConfig helps with getting required config to validate labels.
 type LabelValidationConfig interface {
 	MaxLabelNamesPerSeries(userID string) int
+	MaxLabelsSizeBytes(userID string) int
 	MaxLabelNameLength(userID string) int
 	MaxLabelValueLength(userID string) int
 }
@@ -210,6 +234,7 @@ func ValidateLabels(cfg LabelValidationConfig, userID string, ls []mimirpb.Label
 	maxLabelNameLength := cfg.MaxLabelNameLength(userID)
 	maxLabelValueLength := cfg.MaxLabelValueLength(userID)
 	lastLabelName := ""
+	labelsSizeBytes := 0
 	for _, l := range ls {
 		if !skipLabelNameValidation && !model.LabelName(l.Name).IsValid() {
 			DiscardedSamples.WithLabelValues(invalidLabel, userID).Inc()
@@ -229,6 +254,12 @@ func ValidateLabels(cfg LabelValidationConfig, userID string, ls []mimirpb.Label
 		}
 
 		lastLabelName = l.Name
+		labelsSizeBytes += len(l.Name) + len(l.Value)
+	}
+
+	if limit := cfg.MaxLabelsSizeBytes(userID); limit > 0 && labelsSizeBytes > limit {
+		DiscardedSamples.WithLabelValues(maxLabelsSizeBytes, userID).Inc()
+		return newLabelsSizeTooLargeError(ls, labelsSizeBytes, limit)
 	}
 	return nil
 }
@@ -23,6 +23,7 @@ import (
 
 type validateLabelsCfg struct {
 	maxLabelNamesPerSeries int
+	maxLabelsSizeBytes     int
 	maxLabelNameLength     int
 	maxLabelValueLength    int
 }
@@ -31,6 +32,10 @@ func (v validateLabelsCfg) MaxLabelNamesPerSeries(userID string) int {
 	return v.maxLabelNamesPerSeries
 }
 
+func (v validateLabelsCfg) MaxLabelsSizeBytes(userID string) int {
+	return v.maxLabelsSizeBytes
+}
+
 func (v validateLabelsCfg) MaxLabelNameLength(userID string) int {
 	return v.maxLabelNameLength
 }
@@ -59,6 +64,7 @@ func TestValidateLabels(t *testing.T) {
 	cfg.maxLabelValueLength = 25
 	cfg.maxLabelNameLength = 25
 	cfg.maxLabelNamesPerSeries = 2
+	cfg.maxLabelsSizeBytes = 40
 
 	for _, c := range []struct {
 		metric                  model.Metric
@@ -118,6 +124,14 @@ func TestValidateLabels(t *testing.T) {
 			true,
 			nil,
 		},
+		{
+			map[model.LabelName]model.LabelValue{model.MetricNameLabel: "exceeds_labels_size", "label_name": "label_value"},
+			false,
+			newLabelsSizeTooLargeError([]mimirpb.LabelAdapter{
+				{Name: model.MetricNameLabel, Value: "exceeds_labels_size"},
+				{Name: "label_name", Value: "label_value"},
+			}, 48, 40),
+		},
 	} {
 		err := ValidateLabels(cfg, userID, mimirpb.FromMetricsToLabelAdapters(c.metric), c.skipLabelNameValidation)
 		assert.Equal(t, c.err, err, "wrong error")
@@ -132,6 +146,7 @@ func TestValidateLabels(t *testing.T) {
 			cortex_discarded_samples_total{reason="label_name_too_long",user="testUser"} 1
 			cortex_discarded_samples_total{reason="label_value_too_long",user="testUser"} 1
 			cortex_discarded_samples_total{reason="max_label_names_per_series",user="testUser"} 1
+			cortex_discarded_samples_total{reason="max_labels_size_bytes",user="testUser"} 1
 			cortex_discarded_samples_total{reason="metric_name_invalid",user="testUser"} 1
 			cortex_discarded_samples_total{reason="missing_metric_name",user="testUser"} 1
 
@@ -238,22 +253,22 @@ func TestValidateMetadata(t *testing.T) {
 		{
 			"with no metric name",
 			&mimirpb.MetricMetadata{MetricFamilyName: "", Type: mimirpb.COUNTER, Help: "Number of goroutines.", Unit: ""},
-			httpgrpc.Errorf(http.StatusBadRequest, "metadata missing metric name"),
+			httpgrpc.Errorf(http.StatusBadRequest, "metadata missing metric name (err-mimir-metadata-missing-metric-name)"),
 		},
 		{
 			"with a long metric name",
 			&mimirpb.MetricMetadata{MetricFamilyName: "go_goroutines_and_routines_and_routines", Type: mimirpb.COUNTER, Help: "Number of goroutines.", Unit: ""},
-			httpgrpc.Errorf(http.StatusBadRequest, "metadata 'METRIC_NAME' value too long: \"go_goroutines_and_routines_and_routines\" metric \"go_goroutines_and_routines_and_routines\""),
+			httpgrpc.Errorf(http.StatusBadRequest, "metadata 'METRIC_NAME' value too long: \"go_goroutines_and_routines_and_routines\" metric \"go_goroutines_and_routines_and_routines\" (err-mimir-metadata-too-long)"),
 		},
 		{
 			"with a long help",
 			&mimirpb.MetricMetadata{MetricFamilyName: "go_goroutines", Type: mimirpb.COUNTER, Help: "Number of goroutines that currently exist.", Unit: ""},
-			httpgrpc.Errorf(http.StatusBadRequest, "metadata 'HELP' value too long: \"Number of goroutines that currently exist.\" metric \"go_goroutines\""),
+			httpgrpc.Errorf(http.StatusBadRequest, "metadata 'HELP' value too long: \"Number of goroutines that currently exist.\" metric \"go_goroutines\" (err-mimir-metadata-too-long)"),
 		},
 		{
 			"with a long unit",
 			&mimirpb.MetricMetadata{MetricFamilyName: "go_goroutines", Type: mimirpb.COUNTER, Help: "Number of goroutines.", Unit: "a_made_up_unit_that_is_really_long"},
-			httpgrpc.Errorf(http.StatusBadRequest, "metadata 'UNIT' value too long: \"a_made_up_unit_that_is_really_long\" metric \"go_goroutines\""),
+			httpgrpc.Errorf(http.StatusBadRequest, "metadata 'UNIT' value too long: \"a_made_up_unit_that_is_really_long\" metric \"go_goroutines\" (err-mimir-metadata-too-long)"),
 		},
 	} {
 		t.Run(c.desc, func(t *testing.T) {
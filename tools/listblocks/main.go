@@ -35,6 +35,7 @@ type config struct {
 	showParents         bool
 	showCompactionLevel bool
 	showBlockSize       bool
+	showAnnotations     bool
 	splitCount          int
 	minTime             flagext.Time
 	maxTime             flagext.Time
@@ -53,6 +54,7 @@ func main() {
 	flag.BoolVar(&cfg.showParents, "show-parents", false, "Show parent blocks")
 	flag.BoolVar(&cfg.showCompactionLevel, "show-compaction-level", false, "Show compaction level")
 	flag.BoolVar(&cfg.showBlockSize, "show-block-size", false, "Show size of block based on details in meta.json, if available")
+	flag.BoolVar(&cfg.showAnnotations, "show-annotations", false, "Show block annotations")
 	flag.IntVar(&cfg.splitCount, "split-count", 0, "It not 0, shows split number that would be used for grouping blocks during split compaction")
 	flag.Var(&cfg.minTime, "min-time", "If set, only blocks with MinTime >= this value are printed")
 	flag.Var(&cfg.maxTime, "max-time", "If set, only blocks with MaxTime <= this value are printed")
@@ -77,17 +79,17 @@ func main() {
 		loadMetasMinTime = time.Time(cfg.minTime)
 	}
 
-	metas, deletedTimes, err := listblocks.LoadMetaFilesAndDeletionMarkers(ctx, bkt, cfg.userID, cfg.showDeleted, loadMetasMinTime)
+	metas, deletedTimes, annotations, err := listblocks.LoadMetaFilesAndDeletionMarkers(ctx, bkt, cfg.userID, cfg.showDeleted, loadMetasMinTime)
 	if err != nil {
 		log.Fatalln("failed to read block metadata:", err)
 	}
 
-	printMetas(metas, deletedTimes, cfg)
+	printMetas(metas, deletedTimes, annotations, cfg)
 }
 
 // nolint:errcheck
 //goland:noinspection GoUnhandledErrorResult
-func printMetas(metas map[ulid.ULID]*metadata.Meta, deletedTimes map[ulid.ULID]time.Time, cfg config) {
+func printMetas(metas map[ulid.ULID]*metadata.Meta, deletedTimes map[ulid.ULID]time.Time, annotations map[ulid.ULID]tsdb.BlockAnnotations, cfg config) {
 	blocks := listblocks.SortBlocks(metas)
 
 	tabber := tabwriter.NewWriter(os.Stdout, 1, 4, 3, ' ', 0)
@@ -116,6 +118,9 @@ func printMetas(metas map[ulid.ULID]*metadata.Meta, deletedTimes map[ulid.ULID]t
 	if cfg.showLabels {
 		fmt.Fprintf(tabber, "Labels (excl. "+tsdb.TenantIDExternalLabel+")\t")
 	}
+	if cfg.showAnnotations {
+		fmt.Fprintf(tabber, "Annotations\t")
+	}
 	if cfg.showSources {
 		fmt.Fprintf(tabber, "Sources\t")
 	}
@@ -171,6 +176,10 @@ func printMetas(metas map[ulid.ULID]*metadata.Meta, deletedTimes map[ulid.ULID]t
 			}
 		}
 
+		if cfg.showAnnotations {
+			fmt.Fprintf(tabber, "%s\t", annotations[b.ULID])
+		}
+
 		if cfg.showSources {
 			// No tab at the end.
 			fmt.Fprintf(tabber, "%v", b.Compaction.Sources)
@@ -0,0 +1,248 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+)
+
+const (
+	storageBlocks       = "blocks"
+	storageRuler        = "ruler"
+	storageAlertmanager = "alertmanager"
+)
+
+type config struct {
+	bucket            bucket.Config
+	tenantID          string
+	storage           string
+	sourcePrefix      string
+	destinationPrefix string
+	deleteSource      bool
+	dryRun            bool
+
+	helpAll bool
+}
+
+func main() {
+	ctx := context.Background()
+	logger := log.WithPrefix(log.NewLogfmtLogger(os.Stderr), "time", log.DefaultTimestampUTC)
+
+	cfg := parseFlags()
+	validateConfig(logger, cfg)
+
+	bkt, err := bucket.NewClient(ctx, cfg.bucket, "bucket", logger, nil)
+	if err != nil {
+		level.Error(logger).Log("msg", "Can't instantiate bucket.", "err", err)
+		os.Exit(1)
+	}
+
+	src, dst := prefixedBucket(bkt, cfg.sourcePrefix), prefixedBucket(bkt, cfg.destinationPrefix)
+
+	objects, err := listTenantObjects(ctx, src, tenantPaths(cfg.storage, cfg.tenantID))
+	if err != nil {
+		level.Error(logger).Log("msg", "Can't list the tenant objects.", "err", err)
+		os.Exit(1)
+	}
+	if len(objects) == 0 {
+		level.Warn(logger).Log("msg", "No objects found for the tenant. Nothing was done.", "tenant", cfg.tenantID, "prefix", cfg.sourcePrefix)
+		os.Exit(0)
+	}
+
+	if cfg.dryRun {
+		for _, name := range objects {
+			logger.Log("msg", "Dry-run, not copying object.", "object", name)
+		}
+		os.Exit(0)
+	}
+
+	for _, name := range objects {
+		if err := copyObject(ctx, src, dst, name); err != nil {
+			level.Error(logger).Log("msg", "Can't copy object.", "object", name, "err", err)
+			os.Exit(1)
+		}
+	}
+	level.Info(logger).Log("msg", "Successfully copied objects.", "objects", len(objects))
+
+	if !cfg.deleteSource {
+		return
+	}
+
+	// Delete the objects in the reverse order they've been copied, so that the blocks are not seen
+	// as complete while being partially deleted.
+	for i := len(objects) - 1; i >= 0; i-- {
+		if err := src.Delete(ctx, objects[i]); err != nil {
+			level.Error(logger).Log("msg", "Can't delete source object.", "object", objects[i], "err", err)
+			os.Exit(1)
+		}
+	}
+	level.Info(logger).Log("msg", "Successfully deleted source objects.", "objects", len(objects))
+}
+
+func parseFlags() config {
+	var cfg config
+
+	// We define two flag sets, one on basic straightforward flags of this cli, and the other one with all flags,
+	// which includes the bucket configuration flags, as there quite a lot of them and the help output with them
+	// might look a little bit overwhelming at first contact.
+	fullFlagSet := flag.NewFlagSet("migrate-storage-prefix", flag.ExitOnError)
+	fullFlagSet.SetOutput(os.Stdout)
+	basicFlagSet := flag.NewFlagSet("migrate-storage-prefix", flag.ExitOnError)
+	basicFlagSet.SetOutput(os.Stdout)
+
+	// We register our basic flags on both basic and full flag set.
+	for _, f := range []*flag.FlagSet{basicFlagSet, fullFlagSet} {
+		f.StringVar(&cfg.tenantID, "tenant", "", "Tenant ID whose objects are migrated. Required.")
+		f.StringVar(&cfg.storage, "storage", storageBlocks, fmt.Sprintf("Storage the bucket is used for, which determines the tenant objects. Valid options: %s.", strings.Join([]string{storageBlocks, storageRuler, storageAlertmanager}, ", ")))
+		f.StringVar(&cfg.sourcePrefix, "source-prefix", "", "Storage prefix the tenant objects are currently stored under. Empty if the objects are stored at the root of the bucket.")
+		f.StringVar(&cfg.destinationPrefix, "destination-prefix", "", "Storage prefix to move the tenant objects under. Empty to move the objects at the root of the bucket.")
+		f.BoolVar(&cfg.deleteSource, "delete-source", false, "Delete the source objects once all of them have been copied.")
+		f.BoolVar(&cfg.dryRun, "dry-run", false, "Don't copy nor delete the objects, just print the objects which would be migrated.")
+		f.BoolVar(&cfg.helpAll, "help-all", false, "Show help for all flags, including the bucket backend configuration.")
+	}
+
+	commonUsageHeader := func() {
+		fmt.Println("This tool migrates the objects of a tenant from a storage prefix to another one of the same bucket, like configured by -<storage>.storage-prefix in Mimir.")
+		fmt.Println("")
+		fmt.Println("Usage:")
+		fmt.Println("        migrate-storage-prefix -tenant <tenant id> [-storage <blocks|ruler|alertmanager>] [-source-prefix <prefix>] [-destination-prefix <prefix>] [-delete-source] [-dry-run]")
+		fmt.Println("")
+	}
+
+	// We set the usage to fullFlagSet as that's the flag set we'll be always parsing,
+	// but by default we print only the basic flag set defaults.
+	fullFlagSet.Usage = func() {
+		commonUsageHeader()
+		if cfg.helpAll {
+			fullFlagSet.PrintDefaults()
+		} else {
+			basicFlagSet.PrintDefaults()
+		}
+	}
+
+	// We set only the `-backend` flag on the basicFlagSet, to make sure that user sees that there are more backends supported.
+	// Then we register all bucket flags on the full flag set, which is the flag set we're parsing.
+	basicFlagSet.StringVar(&cfg.bucket.Backend, "backend", bucket.Filesystem, fmt.Sprintf("Backend storage to use. Supported backends are: %s. Use -help-all to see help on backends configuration.", strings.Join(bucket.SupportedBackends, ", ")))
+	cfg.bucket.RegisterFlags(fullFlagSet)
+
+	if err := fullFlagSet.Parse(os.Args[1:]); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	// See if user did `migrate-storage-prefix -help-all`.
+	if cfg.helpAll {
+		commonUsageHeader()
+		fullFlagSet.PrintDefaults()
+		os.Exit(0)
+	}
+
+	return cfg
+}
+
+func validateConfig(logger log.Logger, cfg config) {
+	if cfg.tenantID == "" {
+		level.Error(logger).Log("msg", "Flag -tenant is required.")
+		os.Exit(1)
+	}
+	if cfg.storage != storageBlocks && cfg.storage != storageRuler && cfg.storage != storageAlertmanager {
+		level.Error(logger).Log("msg", "Invalid -storage flag value.", "value", cfg.storage)
+		os.Exit(1)
+	}
+	if cfg.bucket.StoragePrefix != "" {
+		level.Error(logger).Log("msg", "Flag -storage-prefix is not supported, use -source-prefix and -destination-prefix instead.")
+		os.Exit(1)
+	}
+	if cfg.sourcePrefix == cfg.destinationPrefix {
+		level.Error(logger).Log("msg", "Flags -source-prefix and -destination-prefix must be different.")
+		os.Exit(1)
+	}
+	for _, prefix := range []string{cfg.sourcePrefix, cfg.destinationPrefix} {
+		// The prefixes must be valid storage prefixes, as configured in Mimir.
+		prefixCfg := cfg.bucket
+		prefixCfg.StoragePrefix = prefix
+		if err := prefixCfg.Validate(); err != nil {
+			level.Error(logger).Log("msg", "Invalid bucket configuration.", "prefix", prefix, "err", err)
+			os.Exit(1)
+		}
+	}
+}
+
+func prefixedBucket(bkt objstore.Bucket, prefix string) objstore.Bucket {
+	if prefix == "" {
+		return bkt
+	}
+	return bucket.NewPrefixedBucketClient(bkt, prefix)
+}
+
+// tenantPaths returns the paths of the tenant objects in the storage. Paths ending with the delimiter
+// are directories, including all the objects under them.
+func tenantPaths(storage, tenantID string) []string {
+	switch storage {
+	case storageRuler:
+		return []string{path.Join("rules", tenantID) + objstore.DirDelim}
+	case storageAlertmanager:
+		return []string{
+			path.Join("alerts", tenantID),
+			path.Join("alerts-versions", tenantID) + objstore.DirDelim,
+			path.Join("alertmanager", tenantID) + objstore.DirDelim,
+			path.Join("alertmanager-audit", tenantID) + objstore.DirDelim,
+		}
+	default:
+		return []string{tenantID + objstore.DirDelim}
+	}
+}
+
+// listTenantObjects returns the tenant objects, in the order they should be copied: the meta.json of
+// the blocks are copied last, so that the blocks are only seen once complete.
+func listTenantObjects(ctx context.Context, bkt objstore.Bucket, paths []string) ([]string, error) {
+	var objects []string
+	for _, p := range paths {
+		if !strings.HasSuffix(p, objstore.DirDelim) {
+			exists, err := bkt.Exists(ctx, p)
+			if err != nil {
+				return nil, err
+			}
+			if exists {
+				objects = append(objects, p)
+			}
+			continue
+		}
+
+		err := bkt.Iter(ctx, p, func(name string) error {
+			objects = append(objects, name)
+			return nil
+		}, objstore.WithRecursiveIter)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(objects, func(i, j int) bool {
+		return path.Base(objects[i]) != block.MetaFilename && path.Base(objects[j]) == block.MetaFilename
+	})
+	return objects, nil
+}
+
+func copyObject(ctx context.Context, src, dst objstore.Bucket, name string) error {
+	r, err := src.Get(ctx, name)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	return dst.Upload(ctx, name, r)
+}
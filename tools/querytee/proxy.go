@@ -21,6 +21,7 @@ import (
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/gorilla/mux"
+	"github.com/grafana/dskit/flagext"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -37,6 +38,9 @@ type ProxyConfig struct {
 	UseRelativeError               bool
 	PassThroughNonRegisteredRoutes bool
 	SkipRecentSamples              time.Duration
+	MirroredRequestsPercentage     float64
+	MirroredTenants                flagext.StringSliceCSV
+	RequestLogFile                 string
 }
 
 func (cfg *ProxyConfig) RegisterFlags(f *flag.FlagSet) {
@@ -49,6 +53,9 @@ func (cfg *ProxyConfig) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&cfg.UseRelativeError, "proxy.compare-use-relative-error", false, "Use relative error tolerance when comparing floating point values.")
 	f.DurationVar(&cfg.SkipRecentSamples, "proxy.compare-skip-recent-samples", 60*time.Second, "The window from now to skip comparing samples. 0 to disable.")
 	f.BoolVar(&cfg.PassThroughNonRegisteredRoutes, "proxy.passthrough-non-registered-routes", false, "Passthrough requests for non-registered routes to preferred backend.")
+	f.Float64Var(&cfg.MirroredRequestsPercentage, "proxy.mirrored-requests-percentage", 100, "The percentage of requests, randomly sampled, sent to all backends. The other requests are only sent to the preferred backend, or to the first backend if no preferred backend is configured, and their responses are not compared.")
+	f.Var(&cfg.MirroredTenants, "proxy.mirrored-tenants", "Comma separated list of tenants whose requests are sent to all backends. The requests of the other tenants are only sent to the preferred backend, or to the first backend if no preferred backend is configured. If empty, the requests of all tenants are mirrored.")
+	f.StringVar(&cfg.RequestLogFile, "proxy.request-log-file", "", "Path to a file where the received requests are appended, one JSON object per line, so that they can be replayed later. The requests authentication is not captured. If empty, the requests are not captured.")
 }
 
 type Route struct {
@@ -59,11 +66,13 @@ type Route struct {
 }
 
 type Proxy struct {
-	cfg      ProxyConfig
-	backends []*ProxyBackend
-	logger   log.Logger
-	metrics  *ProxyMetrics
-	routes   []Route
+	cfg        ProxyConfig
+	backends   []*ProxyBackend
+	logger     log.Logger
+	metrics    *ProxyMetrics
+	routes     []Route
+	mirroring  *RequestMirroring
+	requestLog *RequestLog
 
 	// The HTTP server used to run the proxy service.
 	srv         *http.Server
@@ -82,6 +91,10 @@ func NewProxy(cfg ProxyConfig, logger log.Logger, routes []Route, registerer pro
 		return nil, fmt.Errorf("when enabling passthrough for non-registered routes -backend.preferred flag must be set to hostname of backend where those requests needs to be passed")
 	}
 
+	if cfg.MirroredRequestsPercentage < 0 || cfg.MirroredRequestsPercentage > 100 {
+		return nil, fmt.Errorf("the -proxy.mirrored-requests-percentage flag must be between 0 and 100")
+	}
+
 	p := &Proxy{
 		cfg:     cfg,
 		logger:  logger,
@@ -147,6 +160,19 @@ func NewProxy(cfg ProxyConfig, logger log.Logger, routes []Route, registerer pro
 		level.Warn(p.logger).Log("msg", "The proxy is running with only 1 backend. At least 2 backends are required to fulfil the purpose of the proxy and compare results.")
 	}
 
+	if cfg.MirroredRequestsPercentage < 100 || len(cfg.MirroredTenants) > 0 {
+		p.mirroring = NewRequestMirroring(cfg.MirroredRequestsPercentage, cfg.MirroredTenants)
+	}
+
+	// Open the request log last, so that it doesn't need to be closed on errors.
+	if cfg.RequestLogFile != "" {
+		requestLog, err := NewRequestLog(cfg.RequestLogFile)
+		if err != nil {
+			return nil, err
+		}
+		p.requestLog = requestLog
+	}
+
 	return p, nil
 }
 
@@ -170,7 +196,7 @@ func (p *Proxy) Start() error {
 		if p.cfg.CompareResponses {
 			comparator = route.ResponseComparator
 		}
-		router.Path(route.Path).Methods(route.Methods...).Handler(NewProxyEndpoint(p.backends, route.RouteName, p.metrics, p.logger, comparator))
+		router.Path(route.Path).Methods(route.Methods...).Handler(NewProxyEndpoint(p.backends, route.RouteName, p.metrics, p.logger, comparator, p.mirroring, p.requestLog))
 	}
 
 	if p.cfg.PassThroughNonRegisteredRoutes {
@@ -208,7 +234,14 @@ func (p *Proxy) Stop() error {
 		return nil
 	}
 
-	return p.srv.Shutdown(context.Background())
+	if err := p.srv.Shutdown(context.Background()); err != nil {
+		return err
+	}
+
+	if p.requestLog != nil {
+		return p.requestLog.Close()
+	}
+	return nil
 }
 
 func (p *Proxy) Await() {
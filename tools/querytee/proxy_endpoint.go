@@ -30,6 +30,8 @@ type ProxyEndpoint struct {
 	metrics    *ProxyMetrics
 	logger     log.Logger
 	comparator ResponsesComparator
+	mirroring  *RequestMirroring
+	requestLog *RequestLog
 
 	// Whether for this endpoint there's a preferred backend configured.
 	hasPreferredBackend bool
@@ -38,7 +40,9 @@ type ProxyEndpoint struct {
 	routeName string
 }
 
-func NewProxyEndpoint(backends []*ProxyBackend, routeName string, metrics *ProxyMetrics, logger log.Logger, comparator ResponsesComparator) *ProxyEndpoint {
+// NewProxyEndpoint makes a new ProxyEndpoint. If mirroring is nil, all requests are sent to all backends.
+// If requestLog is nil, the received requests are not captured.
+func NewProxyEndpoint(backends []*ProxyBackend, routeName string, metrics *ProxyMetrics, logger log.Logger, comparator ResponsesComparator, mirroring *RequestMirroring, requestLog *RequestLog) *ProxyEndpoint {
 	hasPreferredBackend := false
 	for _, backend := range backends {
 		if backend.preferred {
@@ -53,14 +57,24 @@ func NewProxyEndpoint(backends []*ProxyBackend, routeName string, metrics *Proxy
 		metrics:             metrics,
 		logger:              logger,
 		comparator:          comparator,
+		mirroring:           mirroring,
+		requestLog:          requestLog,
 		hasPreferredBackend: hasPreferredBackend,
 	}
 }
 
 func (p *ProxyEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	// Send the same request to all backends.
-	resCh := make(chan *backendResponse, len(p.backends))
-	go p.executeBackendRequests(r, resCh)
+	// Send the same request to all backends, unless it's not selected to be mirrored.
+	backends := p.backends
+	if p.mirroring != nil && len(backends) > 1 {
+		if mirror, reason := p.mirroring.shouldMirror(r); !mirror {
+			backends = []*ProxyBackend{p.primaryBackend()}
+			p.metrics.requestsNotMirroredTotal.WithLabelValues(p.routeName, reason).Inc()
+		}
+	}
+
+	resCh := make(chan *backendResponse, len(backends))
+	go p.executeBackendRequests(r, backends, resCh)
 
 	// Wait for the first response that's feasible to be sent back to the client.
 	downstreamRes := p.waitBackendResponseForDownstream(resCh)
@@ -77,12 +91,22 @@ func (p *ProxyEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	p.metrics.responsesTotal.WithLabelValues(downstreamRes.backend.name, r.Method, p.routeName).Inc()
 }
 
-func (p *ProxyEndpoint) executeBackendRequests(r *http.Request, resCh chan *backendResponse) {
+// primaryBackend returns the preferred backend, or the first one if no backend is preferred.
+func (p *ProxyEndpoint) primaryBackend() *ProxyBackend {
+	for _, b := range p.backends {
+		if b.preferred {
+			return b
+		}
+	}
+	return p.backends[0]
+}
+
+func (p *ProxyEndpoint) executeBackendRequests(r *http.Request, backends []*ProxyBackend, resCh chan *backendResponse) {
 	var (
 		wg           = sync.WaitGroup{}
 		err          error
 		body         []byte
-		responses    = make([]*backendResponse, 0, len(p.backends))
+		responses    = make([]*backendResponse, 0, len(backends))
 		responsesMtx = sync.Mutex{}
 		query        = r.URL.RawQuery
 	)
@@ -106,8 +130,23 @@ func (p *ProxyEndpoint) executeBackendRequests(r *http.Request, resCh chan *back
 
 	level.Debug(p.logger).Log("msg", "Received request", "path", r.URL.Path, "query", query)
 
-	wg.Add(len(p.backends))
-	for _, b := range p.backends {
+	if p.requestLog != nil {
+		err := p.requestLog.Log(RequestLogEntry{
+			Time:        time.Now(),
+			Method:      r.Method,
+			Path:        r.URL.Path,
+			Query:       r.URL.RawQuery,
+			Body:        string(body),
+			ContentType: r.Header.Get("Content-Type"),
+			TenantID:    requestTenantID(r),
+		})
+		if err != nil {
+			level.Warn(p.logger).Log("msg", "Unable to write request to the request log", "err", err)
+		}
+	}
+
+	wg.Add(len(backends))
+	for _, b := range backends {
 		b := b
 
 		go func() {
@@ -154,8 +193,8 @@ func (p *ProxyEndpoint) executeBackendRequests(r *http.Request, resCh chan *back
 	wg.Wait()
 	close(resCh)
 
-	// Compare responses.
-	if p.comparator != nil {
+	// Compare responses, unless the request has not been mirrored.
+	if p.comparator != nil && len(responses) > 1 {
 		expectedResponse := responses[0]
 		actualResponse := responses[1]
 		if responses[1].backend.preferred {
@@ -16,9 +16,10 @@ const (
 )
 
 type ProxyMetrics struct {
-	requestDuration        *prometheus.HistogramVec
-	responsesTotal         *prometheus.CounterVec
-	responsesComparedTotal *prometheus.CounterVec
+	requestDuration          *prometheus.HistogramVec
+	responsesTotal           *prometheus.CounterVec
+	responsesComparedTotal   *prometheus.CounterVec
+	requestsNotMirroredTotal *prometheus.CounterVec
 }
 
 func NewProxyMetrics(registerer prometheus.Registerer) *ProxyMetrics {
@@ -39,6 +40,11 @@ func NewProxyMetrics(registerer prometheus.Registerer) *ProxyMetrics {
 			Name:      "responses_compared_total",
 			Help:      "Total number of responses compared per route name by result.",
 		}, []string{"route", "result"}),
+		requestsNotMirroredTotal: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "cortex_querytee",
+			Name:      "requests_not_mirrored_total",
+			Help:      "Total number of requests only sent to the preferred backend per route name by reason.",
+		}, []string{"route", "reason"}),
 	}
 
 	return m
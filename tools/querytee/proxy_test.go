@@ -163,6 +163,8 @@ func Test_Proxy_RequestsForwarding(t *testing.T) {
 				PreferredBackend:   strconv.Itoa(testData.preferredBackendIdx),
 				ServerServicePort:  0,
 				BackendReadTimeout: time.Second,
+
+				MirroredRequestsPercentage: 100,
 			}
 
 			if len(backendURLs) == 2 {
@@ -316,6 +318,7 @@ func TestProxy_Passthrough(t *testing.T) {
 				ServerServicePort:              0,
 				BackendReadTimeout:             time.Second,
 				PassThroughNonRegisteredRoutes: true,
+				MirroredRequestsPercentage:     100,
 			}
 
 			p, err := NewProxy(cfg, log.NewNopLogger(), testRoutes, nil)
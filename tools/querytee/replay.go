@@ -0,0 +1,215 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querytee
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+type ReplayConfig struct {
+	RequestLogFile string
+	Endpoint       string
+	Speed          float64
+	MaxConcurrency int
+	ReadTimeout    time.Duration
+}
+
+func (cfg *ReplayConfig) RegisterFlags(f *flag.FlagSet) {
+	f.StringVar(&cfg.RequestLogFile, "replay.request-log-file", "", "Path to a request log file, captured via -proxy.request-log-file, to replay against the replay endpoint. When set, the query-tee replays the requests and exits, instead of running the proxy.")
+	f.StringVar(&cfg.Endpoint, "replay.endpoint", "", "The backend endpoint to replay the requests against.")
+	f.Float64Var(&cfg.Speed, "replay.speed", 1, "The speed at which the requests are replayed, relative to the time they've been received at. For example, 2 replays the requests twice as fast as they've been received. 0 to replay the requests as fast as possible.")
+	f.IntVar(&cfg.MaxConcurrency, "replay.max-concurrency", 10, "The max number of requests replayed concurrently. When reached, the replay slows down.")
+	f.DurationVar(&cfg.ReadTimeout, "replay.read-timeout", 90*time.Second, "The timeout when reading the response from the replay endpoint.")
+}
+
+func (cfg *ReplayConfig) Validate() error {
+	if cfg.Endpoint == "" {
+		return errors.New("the -replay.endpoint flag must be set to replay requests")
+	}
+	if cfg.Speed < 0 {
+		return errors.New("the -replay.speed flag must be greater than or equal to 0")
+	}
+	if cfg.MaxConcurrency <= 0 {
+		return errors.New("the -replay.max-concurrency flag must be greater than 0")
+	}
+	return nil
+}
+
+// Replayer replays the requests of a request log against a backend, preserving the
+// time between the requests, scaled by the configured speed.
+type Replayer struct {
+	cfg     ReplayConfig
+	backend *ProxyBackend
+	logger  log.Logger
+
+	requestDuration *prometheus.HistogramVec
+	requestsLag     prometheus.Histogram
+}
+
+func NewReplayer(cfg ReplayConfig, logger log.Logger, registerer prometheus.Registerer) (*Replayer, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid replay endpoint %s", cfg.Endpoint)
+	}
+
+	return &Replayer{
+		cfg:     cfg,
+		backend: NewProxyBackend(u.Hostname(), u, cfg.ReadTimeout, true),
+		logger:  logger,
+		requestDuration: promauto.With(registerer).NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "cortex_querytee",
+			Name:      "replay_request_duration_seconds",
+			Help:      "Time (in seconds) spent serving the replayed requests.",
+			Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 0.75, 1, 1.5, 2, 3, 4, 5, 10, 25, 50, 100},
+		}, []string{"method", "status_code"}),
+		requestsLag: promauto.With(registerer).NewHistogram(prometheus.HistogramOpts{
+			Namespace: "cortex_querytee",
+			Name:      "replay_request_lag_seconds",
+			Help:      "Time (in seconds) the replayed requests have been sent later than scheduled, because of the max concurrency.",
+			Buckets:   []float64{.001, .01, .1, 1, 10, 100},
+		}),
+	}, nil
+}
+
+// Run replays all the requests of the request log, and returns once all of them have completed.
+func (r *Replayer) Run(ctx context.Context) error {
+	file, err := os.Open(r.cfg.RequestLogFile)
+	if err != nil {
+		return errors.Wrap(err, "open request log file")
+	}
+	defer file.Close()
+
+	var (
+		wg          sync.WaitGroup
+		concurrency = make(chan struct{}, r.cfg.MaxConcurrency)
+		dec         = json.NewDecoder(file)
+		start       = time.Now()
+		first       time.Time
+		replayed    int
+	)
+	defer wg.Wait()
+
+	for {
+		var entry RequestLogEntry
+		if err := dec.Decode(&entry); err == io.EOF {
+			break
+		} else if err != nil {
+			return errors.Wrapf(err, "read request log entry %d", replayed+1)
+		}
+
+		// Wait until the time the request should be replayed at.
+		if first.IsZero() {
+			first = entry.Time
+		}
+		scheduled := start
+		if r.cfg.Speed > 0 {
+			scheduled = start.Add(time.Duration(float64(entry.Time.Sub(first)) / r.cfg.Speed))
+		}
+		if err := sleepUntil(ctx, scheduled); err != nil {
+			return err
+		}
+
+		select {
+		case concurrency <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if r.cfg.Speed > 0 {
+			r.requestsLag.Observe(time.Since(scheduled).Seconds())
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-concurrency }()
+
+			r.replayRequest(entry)
+		}()
+		replayed++
+	}
+
+	level.Info(r.logger).Log("msg", "Replayed requests", "requests", replayed, "elapsed", time.Since(start))
+	return nil
+}
+
+func (r *Replayer) replayRequest(entry RequestLogEntry) {
+	req, body, err := entry.request()
+	if err != nil {
+		level.Warn(r.logger).Log("msg", "Unable to create request to replay", "path", entry.Path, "err", err)
+		return
+	}
+
+	start := time.Now()
+	status, _, err := r.backend.ForwardRequest(req, body)
+	elapsed := time.Since(start)
+
+	res := &backendResponse{backend: r.backend, status: status, err: err}
+	lvl := level.Debug
+	if !res.succeeded() {
+		lvl = level.Warn
+	}
+
+	lvl(r.logger).Log("msg", "Replayed request", "path", entry.Path, "query", entry.Query, "tenant", entry.TenantID, "status", status, "elapsed", elapsed, "err", err)
+	r.requestDuration.WithLabelValues(entry.Method, strconv.Itoa(res.statusCode())).Observe(elapsed.Seconds())
+}
+
+// request returns the HTTP request captured by the entry, and its body.
+func (e RequestLogEntry) request() (*http.Request, io.ReadCloser, error) {
+	u := &url.URL{Path: e.Path, RawQuery: e.Query}
+	req, err := http.NewRequest(e.Method, u.String(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if e.ContentType != "" {
+		req.Header.Set("Content-Type", e.ContentType)
+	}
+	if e.TenantID != "" {
+		req.Header.Set("X-Scope-OrgID", e.TenantID)
+	}
+
+	var body io.ReadCloser
+	if e.Body != "" {
+		body = ioutil.NopCloser(bytes.NewReader([]byte(e.Body)))
+	}
+
+	return req, body, nil
+}
+
+func sleepUntil(ctx context.Context, t time.Time) error {
+	d := time.Until(t)
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
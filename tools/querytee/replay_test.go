@@ -0,0 +1,158 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querytee
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxy_ShouldCaptureRequestsToReplay(t *testing.T) {
+	backend := httptest.NewServer(mockQueryResponse("/api/v1/query", 200, "ok"))
+	t.Cleanup(backend.Close)
+
+	requestLogFile := filepath.Join(t.TempDir(), "requests.log")
+
+	cfg := ProxyConfig{
+		BackendEndpoints:           backend.URL,
+		BackendReadTimeout:         time.Second,
+		MirroredRequestsPercentage: 100,
+		RequestLogFile:             requestLogFile,
+	}
+
+	p, err := NewProxy(cfg, log.NewNopLogger(), testRoutes, nil)
+	require.NoError(t, err)
+	require.NoError(t, p.Start())
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("http://%s/api/v1/query?query=up", p.Endpoint()), nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Scope-OrgID", "user-1")
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	require.Equal(t, 200, res.StatusCode)
+
+	require.NoError(t, p.Stop())
+
+	// Replay the captured requests.
+	var (
+		replayedMtx sync.Mutex
+		replayed    []*http.Request
+	)
+	replayBackend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		replayedMtx.Lock()
+		replayed = append(replayed, r)
+		replayedMtx.Unlock()
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(replayBackend.Close)
+
+	replayer, err := NewReplayer(ReplayConfig{
+		RequestLogFile: requestLogFile,
+		Endpoint:       replayBackend.URL + "/prometheus",
+		Speed:          0,
+		MaxConcurrency: 1,
+		ReadTimeout:    time.Second,
+	}, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	require.NoError(t, replayer.Run(context.Background()))
+
+	require.Len(t, replayed, 1)
+	assert.Equal(t, "GET", replayed[0].Method)
+	assert.Equal(t, "/prometheus/api/v1/query", replayed[0].URL.Path)
+	assert.Equal(t, "query=up", replayed[0].URL.RawQuery)
+	assert.Equal(t, "user-1", replayed[0].Header.Get("X-Scope-OrgID"))
+}
+
+func TestReplayer_Run(t *testing.T) {
+	var (
+		receivedMtx sync.Mutex
+		received    []time.Time
+		bodies      []string
+	)
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+
+		receivedMtx.Lock()
+		received = append(received, time.Now())
+		bodies = append(bodies, string(body))
+		receivedMtx.Unlock()
+
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(backend.Close)
+
+	// Write a request log with requests received 2s apart.
+	requestLogFile := filepath.Join(t.TempDir(), "requests.log")
+	requestLog, err := NewRequestLog(requestLogFile)
+	require.NoError(t, err)
+
+	now := time.Now()
+	require.NoError(t, requestLog.Log(RequestLogEntry{Time: now, Method: "GET", Path: "/api/v1/query", Query: "query=up"}))
+	require.NoError(t, requestLog.Log(RequestLogEntry{Time: now.Add(2 * time.Second), Method: "POST", Path: "/api/v1/query", Body: "query=up", ContentType: "application/x-www-form-urlencoded"}))
+	require.NoError(t, requestLog.Close())
+
+	// Replay the requests 10x faster.
+	replayer, err := NewReplayer(ReplayConfig{
+		RequestLogFile: requestLogFile,
+		Endpoint:       backend.URL,
+		Speed:          10,
+		MaxConcurrency: 10,
+		ReadTimeout:    time.Second,
+	}, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	require.NoError(t, replayer.Run(context.Background()))
+
+	require.Len(t, received, 2)
+	assert.GreaterOrEqual(t, received[1].Sub(received[0]), 150*time.Millisecond)
+	assert.Less(t, received[1].Sub(received[0]), 2*time.Second)
+	assert.Equal(t, []string{"", "query=up"}, bodies)
+}
+
+func TestReplayConfig_Validate(t *testing.T) {
+	tests := map[string]struct {
+		cfg         ReplayConfig
+		expectedErr string
+	}{
+		"valid": {
+			cfg: ReplayConfig{Endpoint: "http://localhost", Speed: 1, MaxConcurrency: 1},
+		},
+		"missing endpoint": {
+			cfg:         ReplayConfig{Speed: 1, MaxConcurrency: 1},
+			expectedErr: "-replay.endpoint",
+		},
+		"negative speed": {
+			cfg:         ReplayConfig{Endpoint: "http://localhost", Speed: -1, MaxConcurrency: 1},
+			expectedErr: "-replay.speed",
+		},
+		"zero max concurrency": {
+			cfg:         ReplayConfig{Endpoint: "http://localhost", Speed: 1},
+			expectedErr: "-replay.max-concurrency",
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			err := testData.cfg.Validate()
+			if testData.expectedErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.True(t, strings.Contains(err.Error(), testData.expectedErr))
+			}
+		})
+	}
+}
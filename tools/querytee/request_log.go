@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querytee
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RequestLogEntry is a request received by the proxy, as captured in the request log.
+// The request authentication is not captured.
+type RequestLogEntry struct {
+	Time        time.Time `json:"time"`
+	Method      string    `json:"method"`
+	Path        string    `json:"path"`
+	Query       string    `json:"query,omitempty"`
+	Body        string    `json:"body,omitempty"`
+	ContentType string    `json:"content_type,omitempty"`
+	TenantID    string    `json:"tenant_id,omitempty"`
+}
+
+// RequestLog appends the requests received by the proxy to a file, one JSON entry per line.
+type RequestLog struct {
+	mtx  sync.Mutex
+	file io.WriteCloser
+	enc  *json.Encoder
+}
+
+// NewRequestLog opens the file at the given path, to append the requests to.
+func NewRequestLog(path string) (*RequestLog, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, errors.Wrap(err, "open request log file")
+	}
+
+	return &RequestLog{
+		file: file,
+		enc:  json.NewEncoder(file),
+	}, nil
+}
+
+// Log appends the entry to the request log.
+func (l *RequestLog) Log(entry RequestLogEntry) error {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	return l.enc.Encode(entry)
+}
+
+func (l *RequestLog) Close() error {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	return l.file.Close()
+}
@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querytee
+
+import (
+	"math/rand"
+	"net/http"
+)
+
+const (
+	notMirroredReasonTenant   = "tenant"
+	notMirroredReasonSampling = "sampling"
+)
+
+// RequestMirroring selects the requests sent to all backends. The other requests are only sent
+// to the preferred backend, and their responses are not compared.
+type RequestMirroring struct {
+	percentage float64
+	tenants    map[string]struct{}
+
+	// random returns a number in [0.0,100.0), used to sample the requests.
+	random func() float64
+}
+
+// NewRequestMirroring makes a new RequestMirroring, mirroring the given percentage of requests
+// of the given tenants, or of all tenants if empty.
+func NewRequestMirroring(percentage float64, tenants []string) *RequestMirroring {
+	m := &RequestMirroring{
+		percentage: percentage,
+		random: func() float64 {
+			return rand.Float64() * 100
+		},
+	}
+
+	if len(tenants) > 0 {
+		m.tenants = make(map[string]struct{}, len(tenants))
+		for _, tenant := range tenants {
+			m.tenants[tenant] = struct{}{}
+		}
+	}
+
+	return m
+}
+
+// shouldMirror returns whether the request should be sent to all backends, and otherwise the reason why not.
+func (m *RequestMirroring) shouldMirror(r *http.Request) (bool, string) {
+	if m.tenants != nil {
+		if _, ok := m.tenants[requestTenantID(r)]; !ok {
+			return false, notMirroredReasonTenant
+		}
+	}
+
+	if m.percentage < 100 && m.random() >= m.percentage {
+		return false, notMirroredReasonSampling
+	}
+
+	return true, ""
+}
+
+// requestTenantID returns the tenant ID of the request, from the tenant header or, if missing,
+// the HTTP basic authentication username as used by authenticating gateways.
+func requestTenantID(r *http.Request) string {
+	if tenantID := r.Header.Get("X-Scope-OrgID"); tenantID != "" {
+		return tenantID
+	}
+
+	user, _, _ := r.BasicAuth()
+	return user
+}
@@ -0,0 +1,149 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querytee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+func TestRequestMirroring_shouldMirror(t *testing.T) {
+	requestWithTenant := func(tenantID string) *http.Request {
+		r := httptest.NewRequest("GET", "/api/v1/query", nil)
+		r.Header.Set("X-Scope-OrgID", tenantID)
+		return r
+	}
+	requestWithBasicAuth := func(user string) *http.Request {
+		r := httptest.NewRequest("GET", "/api/v1/query", nil)
+		r.SetBasicAuth(user, "password")
+		return r
+	}
+
+	tests := map[string]struct {
+		percentage     float64
+		tenants        []string
+		random         float64
+		request        *http.Request
+		expectedMirror bool
+		expectedReason string
+	}{
+		"all requests mirrored": {
+			percentage:     100,
+			random:         99.9,
+			request:        requestWithTenant("user-1"),
+			expectedMirror: true,
+		},
+		"request sampled": {
+			percentage:     10,
+			random:         9.9,
+			request:        requestWithTenant("user-1"),
+			expectedMirror: true,
+		},
+		"request not sampled": {
+			percentage:     10,
+			random:         10,
+			request:        requestWithTenant("user-1"),
+			expectedMirror: false,
+			expectedReason: notMirroredReasonSampling,
+		},
+		"no requests mirrored": {
+			percentage:     0,
+			random:         0,
+			request:        requestWithTenant("user-1"),
+			expectedMirror: false,
+			expectedReason: notMirroredReasonSampling,
+		},
+		"tenant mirrored": {
+			percentage:     100,
+			tenants:        []string{"user-1", "user-2"},
+			request:        requestWithTenant("user-2"),
+			expectedMirror: true,
+		},
+		"tenant not mirrored": {
+			percentage:     100,
+			tenants:        []string{"user-1", "user-2"},
+			request:        requestWithTenant("user-3"),
+			expectedMirror: false,
+			expectedReason: notMirroredReasonTenant,
+		},
+		"tenant from basic auth mirrored": {
+			percentage:     100,
+			tenants:        []string{"user-1"},
+			request:        requestWithBasicAuth("user-1"),
+			expectedMirror: true,
+		},
+		"tenant mirrored but request not sampled": {
+			percentage:     50,
+			tenants:        []string{"user-1"},
+			random:         50,
+			request:        requestWithTenant("user-1"),
+			expectedMirror: false,
+			expectedReason: notMirroredReasonSampling,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			m := NewRequestMirroring(testData.percentage, testData.tenants)
+			m.random = func() float64 { return testData.random }
+
+			mirror, reason := m.shouldMirror(testData.request)
+			assert.Equal(t, testData.expectedMirror, mirror)
+			assert.Equal(t, testData.expectedReason, reason)
+		})
+	}
+}
+
+func TestProxyEndpoint_ShouldOnlySendNotMirroredRequestsToPreferredBackend(t *testing.T) {
+	var requests1, requests2 atomic.Int64
+
+	backend1 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests1.Inc()
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(backend1.Close)
+	backendURL1, err := url.Parse(backend1.URL)
+	require.NoError(t, err)
+
+	backend2 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests2.Inc()
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(backend2.Close)
+	backendURL2, err := url.Parse(backend2.URL)
+	require.NoError(t, err)
+
+	backends := []*ProxyBackend{
+		NewProxyBackend("backend-1", backendURL1, time.Second, false),
+		NewProxyBackend("backend-2", backendURL2, time.Second, true),
+	}
+
+	reg := prometheus.NewPedanticRegistry()
+	endpoint := NewProxyEndpoint(backends, "test", NewProxyMetrics(reg), log.NewNopLogger(), nil, NewRequestMirroring(100, []string{"user-1"}), nil)
+
+	for _, tenantID := range []string{"user-1", "user-2", "user-2"} {
+		r := httptest.NewRequest("GET", "/api/v1/test", nil)
+		r.Header.Set("X-Scope-OrgID", tenantID)
+
+		w := httptest.NewRecorder()
+		endpoint.ServeHTTP(w, r)
+		require.Equal(t, 200, w.Code)
+		require.Equal(t, "ok", w.Body.String())
+	}
+
+	// The mirrored request may still be in progress against the non preferred backend.
+	require.Eventually(t, func() bool { return requests1.Load() == 1 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int64(3), requests2.Load())
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(endpoint.metrics.requestsNotMirroredTotal.WithLabelValues("test", notMirroredReasonTenant)))
+}